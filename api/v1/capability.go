@@ -0,0 +1,63 @@
+package v1
+
+// 当前用户操作权限相关 API 定义
+// 综合管理员名单（security.admin_users）、虚拟机归属、删除保护、维护锁和集群能力矩阵，
+// 返回当前用户可以执行哪些操作，前端据此隐藏或禁用按钮，避免用户点击后才收到 403
+
+// 操作不可用的原因
+const (
+	CapabilityReasonAdminRequired     = "admin_required"             // 仅管理员可操作
+	CapabilityReasonClusterPermission = "cluster_permission_missing" // 集群 API Token 缺少该功能需要的权限
+	CapabilityReasonNotVMOwner        = "not_vm_owner"               // 仅虚拟机负责人、创建人和管理员可操作
+	CapabilityReasonVMProtected       = "vm_protected"               // 虚拟机开启了删除保护
+	CapabilityReasonVMLocked          = "vm_locked"                  // 虚拟机被他人锁定维护
+)
+
+// GetMyCapabilitiesRequest 查询当前用户的操作权限
+type GetMyCapabilitiesRequest struct {
+	ClusterID int64   `form:"cluster_id" example:"1"`                           // 只返回该集群，为空返回全部集群
+	VMIds     []int64 `form:"vm_ids" binding:"omitempty,max=100" example:"1,2"` // 同时返回这些虚拟机（pve_vm 表 ID）上的操作权限
+}
+
+// CapabilityItem 单个操作的权限
+type CapabilityItem struct {
+	Operation string   `json:"operation"` // 操作标识，如 vm.delete
+	Name      string   `json:"name"`
+	Allowed   bool     `json:"allowed"`
+	Reason    string   `json:"reason,omitempty"`  // 不可用的原因，见 CapabilityReason*
+	Detail    string   `json:"detail,omitempty"`  // 原因说明，如锁定人、保护原因
+	Feature   string   `json:"feature,omitempty"` // 依赖的集群能力（能力矩阵中的功能标识）
+	Missing   []string `json:"missing,omitempty"` // 集群 API Token 缺少的权限
+}
+
+// ClusterCapabilities 集群范围的操作权限
+type ClusterCapabilities struct {
+	ClusterID   int64  `json:"cluster_id"`
+	ClusterName string `json:"cluster_name"`
+	// Probed 为 false 表示集群能力未能探测（无法连接或认证失败），此时只按 PveSphere 自身的规则判断
+	Probed     bool             `json:"probed"`
+	Operations []CapabilityItem `json:"operations"`
+}
+
+// VMCapabilities 虚拟机范围的操作权限
+type VMCapabilities struct {
+	VmId       int64            `json:"vm_id"`
+	VMID       uint32           `json:"vmid"`
+	VmName     string           `json:"vm_name"`
+	ClusterID  int64            `json:"cluster_id"`
+	Operations []CapabilityItem `json:"operations"`
+}
+
+// MyCapabilitiesData 当前用户的操作权限
+type MyCapabilitiesData struct {
+	Username string                `json:"username"`
+	IsAdmin  bool                  `json:"is_admin"`
+	Global   []CapabilityItem      `json:"global"` // 与集群无关的操作
+	Clusters []ClusterCapabilities `json:"clusters"`
+	VMs      []VMCapabilities      `json:"vms"`
+}
+
+type MyCapabilitiesResponse struct {
+	Response
+	Data MyCapabilitiesData
+}
@@ -0,0 +1,134 @@
+package v1
+
+import "time"
+
+// 变更窗口（维护窗口 / 封网期）相关 API 定义
+// 存在作用于某集群/应用的维护窗口时，该范围内的变更操作只能在维护窗口内执行；封网期内一律禁止变更。
+// 管理员可在请求头 X-Change-Override 中填写原因紧急放行，放行记录写入审计日志。
+
+// ChangeOverrideHeader 紧急放行（break-glass）原因请求头
+const ChangeOverrideHeader = "X-Change-Override"
+
+// ChangeOverrideCtxKey 紧急放行原因在请求上下文中的 key
+const ChangeOverrideCtxKey = "change_override"
+
+// CreateChangeWindowRequest 创建变更窗口请求
+type CreateChangeWindowRequest struct {
+	Name        string     `json:"name" binding:"required" example:"周末维护窗口"`
+	Kind        string     `json:"kind" binding:"required,oneof=maintenance freeze" example:"maintenance"` // maintenance 允许变更 / freeze 禁止变更
+	ClusterID   int64      `json:"cluster_id" example:"1"`                                                 // 集群ID，0 表示全部集群
+	AppId       string     `json:"app_id" example:"app-001"`                                               // 应用ID，空表示全部应用
+	Description string     `json:"description" example:"每周六凌晨维护"`
+	Recurrence  string     `json:"recurrence" binding:"required,oneof=once weekly" example:"weekly"` // once 一次性 / weekly 每周重复
+	StartTime   *time.Time `json:"start_time,omitempty" example:"2026-01-20T00:00:00+08:00"`         // once：开始时间
+	EndTime     *time.Time `json:"end_time,omitempty" example:"2026-02-05T00:00:00+08:00"`           // once：结束时间
+	Weekdays    []int      `json:"weekdays,omitempty" example:"6,0"`                                 // weekly：星期（0=周日 ... 6=周六）
+	DailyStart  string     `json:"daily_start,omitempty" example:"22:00"`                            // weekly：每日开始时间 HH:MM
+	DailyEnd    string     `json:"daily_end,omitempty" example:"06:00"`                              // weekly：每日结束时间 HH:MM，早于开始时间表示跨零点
+	Timezone    string     `json:"timezone,omitempty" example:"Asia/Shanghai"`                       // IANA 时区，默认服务器本地时区
+	IsEnabled   *int8      `json:"is_enabled,omitempty" example:"1"`                                 // 默认启用
+}
+
+// UpdateChangeWindowRequest 更新变更窗口请求（整体替换时间规则）
+type UpdateChangeWindowRequest = CreateChangeWindowRequest
+
+// ListChangeWindowsRequest 变更窗口列表请求
+type ListChangeWindowsRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	ClusterID int64  `form:"cluster_id" example:"1"` // 包含全局窗口
+	Kind      string `form:"kind" binding:"omitempty,oneof=maintenance freeze" example:"freeze"`
+}
+
+// ChangeWindowItem 变更窗口信息
+type ChangeWindowItem struct {
+	Id          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Kind        string     `json:"kind"`
+	ClusterID   int64      `json:"cluster_id"`
+	AppId       string     `json:"app_id"`
+	Description string     `json:"description"`
+	Recurrence  string     `json:"recurrence"`
+	StartTime   *time.Time `json:"start_time"`
+	EndTime     *time.Time `json:"end_time"`
+	Weekdays    []int      `json:"weekdays"`
+	DailyStart  string     `json:"daily_start"`
+	DailyEnd    string     `json:"daily_end"`
+	Timezone    string     `json:"timezone"`
+	IsEnabled   int8       `json:"is_enabled"`
+	Active      bool       `json:"active"` // 当前是否处于该窗口内
+	Creator     string     `json:"creator"`
+	Modifier    string     `json:"modifier"`
+	CreateTime  time.Time  `json:"create_time"`
+	UpdateTime  time.Time  `json:"update_time"`
+}
+
+// GetChangeWindowResponse 变更窗口详情响应
+type GetChangeWindowResponse struct {
+	Response
+	Data ChangeWindowItem
+}
+
+// ListChangeWindowsResponse 变更窗口列表响应
+type ListChangeWindowsResponse struct {
+	Response
+	Data ListChangeWindowsResponseData
+}
+
+type ListChangeWindowsResponseData struct {
+	Total int64              `json:"total"`
+	List  []ChangeWindowItem `json:"list"`
+}
+
+// GetChangeStatusRequest 查询当前是否允许变更
+type GetChangeStatusRequest struct {
+	ClusterID int64  `form:"cluster_id" binding:"required" example:"1"`
+	AppId     string `form:"app_id" example:"app-001"`
+}
+
+// ChangeStatusData 当前变更状态
+type ChangeStatusData struct {
+	Allowed bool   `json:"allowed"`
+	Reason  string `json:"reason,omitempty"` // 不允许变更的原因
+	// BlockedBy 拦截变更的窗口（封网期），不在任何维护窗口内时为空
+	BlockedBy *ChangeWindowItem `json:"blocked_by,omitempty"`
+	// ActiveWindows 当前生效的维护窗口
+	ActiveWindows []ChangeWindowItem `json:"active_windows"`
+}
+
+// GetChangeStatusResponse 当前变更状态响应
+type GetChangeStatusResponse struct {
+	Response
+	Data ChangeStatusData
+}
+
+// ListChangeOverridesRequest 紧急放行审计查询
+type ListChangeOverridesRequest struct {
+	Page      int   `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize  int   `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	ClusterID int64 `form:"cluster_id" example:"1"`
+}
+
+// ChangeOverrideItem 紧急放行审计记录
+type ChangeOverrideItem struct {
+	Id         int64     `json:"id"`
+	Operator   string    `json:"operator"`
+	Action     string    `json:"action"` // 被放行的操作，如 vm.stop
+	Target     string    `json:"target"`
+	ClusterID  int64     `json:"cluster_id"`
+	AppId      string    `json:"app_id"`
+	WindowID   int64     `json:"window_id"`
+	WindowName string    `json:"window_name"`
+	Reason     string    `json:"reason"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+type ListChangeOverridesResponseData struct {
+	Total int64                `json:"total"`
+	List  []ChangeOverrideItem `json:"list"`
+}
+
+type ListChangeOverridesResponse struct {
+	Response
+	Data ListChangeOverridesResponseData
+}
@@ -0,0 +1,55 @@
+package v1
+
+import "time"
+
+// 集群能力探测相关 API 定义
+// 注册集群时检查 API Token 在 / 上的有效权限，逐项对照 PveSphere 各功能需要的权限，
+// 报告缺少的权限并保存能力矩阵，前端据此提前禁用当前 Token 无权使用的功能
+
+// ProbeClusterRequest 接入向导：保存集群前使用凭据探测能力
+type ProbeClusterRequest struct {
+	ApiUrl    string `json:"api_url" binding:"required,url" example:"https://10.0.0.1:8006"`
+	UserId    string `json:"user_id" binding:"required" example:"pvesphere@pve!pvesphere"`
+	UserToken string `json:"user_token" binding:"required"`
+}
+
+// ClusterFeatureCapability 单个功能的能力
+type ClusterFeatureCapability struct {
+	Feature   string   `json:"feature"` // 功能标识，如 vm.create
+	Name      string   `json:"name"`
+	Supported bool     `json:"supported"`
+	Optional  bool     `json:"optional"` // 可选功能，缺少权限不影响接入
+	Required  []string `json:"required"` // 当前 PVE 版本下需要的权限
+	Missing   []string `json:"missing"`
+}
+
+// ClusterCapabilityData 集群能力矩阵
+type ClusterCapabilityData struct {
+	ClusterID     int64                      `json:"cluster_id,omitempty"`
+	UserID        string                     `json:"user_id"`
+	Connected     bool                       `json:"connected"`
+	Authenticated bool                       `json:"authenticated"`
+	PveVersion    string                     `json:"pve_version,omitempty"`
+	Privileges    []string                   `json:"privileges"` // Token 在 / 上的有效权限
+	Missing       []string                   `json:"missing"`    // 必需功能缺少的权限
+	Features      []ClusterFeatureCapability `json:"features"`
+	Hints         []string                   `json:"hints"`
+	Message       string                     `json:"message,omitempty"`
+	ProbeTime     *time.Time                 `json:"probe_time,omitempty"`
+}
+
+type ClusterCapabilityResponse struct {
+	Response
+	Data ClusterCapabilityData
+}
+
+// CreateClusterData 创建集群结果，包含注册时的能力探测结果
+type CreateClusterData struct {
+	ID           int64                  `json:"id"`
+	Capabilities *ClusterCapabilityData `json:"capabilities,omitempty"`
+}
+
+type CreateClusterResponse struct {
+	Response
+	Data CreateClusterData
+}
@@ -0,0 +1,72 @@
+package v1
+
+import "time"
+
+// 集群接入地址 / 证书更换相关 API 定义
+// 集群迁移到负载均衡之后、更换 API Token 或节点证书续期后，通过 verify 接口预检新的接入信息，确认无误后再 apply：
+// - connect：新地址能否连通并通过认证
+// - identity：新地址是否仍指向同一个集群（已登记的节点是否都在）
+// - certificates：刷新各节点 pve-ssl.pem 的指纹，与保存的指纹对比（跨集群迁移使用这些指纹）
+// - capabilities / storage / tasks：重新检查依赖的功能（Token 权限、存储列表、任务列表）
+// - remote_migration：是否所有节点都有可用的证书指纹
+// connect 或 identity 失败时 apply 不会保存任何修改；其余检查失败只作为警告返回
+
+// 检查项状态
+const (
+	EndpointCheckOK      = "ok"
+	EndpointCheckWarning = "warning"
+	EndpointCheckFailed  = "failed"
+)
+
+// 节点证书指纹对比结果
+const (
+	NodeCertUnchanged   = "unchanged"
+	NodeCertChanged     = "changed"
+	NodeCertNew         = "new"         // 之前没有保存过指纹
+	NodeCertUnavailable = "unavailable" // 无法读取证书
+)
+
+// UpdateClusterEndpointRequest 集群接入信息，未填写的字段沿用当前配置；全部不填时仅重新验证并刷新证书指纹
+type UpdateClusterEndpointRequest struct {
+	ApiUrl    *string `json:"api_url,omitempty" binding:"omitempty,url" example:"https://pve-lb.example.com:8006"`
+	UserId    *string `json:"user_id,omitempty" example:"api-user@pve!pvesphere"`
+	UserToken *string `json:"user_token,omitempty" example:"your-token"`
+}
+
+// ClusterEndpointCheck 单项检查结果
+type ClusterEndpointCheck struct {
+	Check   string `json:"check"`  // connect / identity / certificates / capabilities / storage / tasks / remote_migration
+	Status  string `json:"status"` // ok / warning / failed
+	Message string `json:"message"`
+}
+
+// ClusterNodeCertificate 节点证书指纹
+type ClusterNodeCertificate struct {
+	NodeName            string     `json:"node_name"`
+	Fingerprint         string     `json:"fingerprint"`
+	PreviousFingerprint string     `json:"previous_fingerprint,omitempty"` // 指纹发生变化时为之前保存的指纹
+	Status              string     `json:"status,omitempty"`               // unchanged / changed / new / unavailable
+	NotAfter            *time.Time `json:"not_after,omitempty"`
+	VerifyTime          *time.Time `json:"verify_time,omitempty"`
+	Message             string     `json:"message,omitempty"`
+}
+
+type ClusterEndpointData struct {
+	ClusterID    int64                    `json:"cluster_id"`
+	ApiUrl       string                   `json:"api_url"`
+	PveVersion   string                   `json:"pve_version,omitempty"`
+	Passed       bool                     `json:"passed"`  // 没有 failed 的检查项
+	Applied      bool                     `json:"applied"` // 是否已保存新的接入信息和证书指纹
+	Checks       []ClusterEndpointCheck   `json:"checks"`
+	Certificates []ClusterNodeCertificate `json:"certificates"`
+}
+
+type ClusterEndpointResponse struct {
+	Response
+	Data ClusterEndpointData
+}
+
+type ListClusterCertificatesResponse struct {
+	Response
+	Data []ClusterNodeCertificate
+}
@@ -0,0 +1,46 @@
+package v1
+
+import "time"
+
+// 集群外部变更相关 API 定义
+// 后台定期轮询各集群的 Proxmox 任务列表和节点 journal，把不是由 PveSphere（集群配置的 API 用户）发起的变更
+// 记录为集群事件：创建、克隆、恢复、删除、迁移、启停虚拟机（来自任务），以及修改虚拟机配置（来自 journal 中的 "update VM"）。
+// 新事件会通知管理员和虚拟机负责人，创建、删除、迁移后立即重新扫描待认领虚拟机，无需等待下一次全量同步
+
+// ListClusterEventsRequest 集群外部变更查询
+type ListClusterEventsRequest struct {
+	Page      int        `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize  int        `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	ClusterID int64      `form:"cluster_id" example:"1"`
+	VMID      uint32     `form:"vmid" example:"100"`
+	Type      string     `form:"type" example:"vm_deleted"` // vm_created / vm_cloned / vm_restored / vm_deleted / vm_migrated / vm_started / vm_stopped / vm_config_changed
+	Since     *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-01-01T08:00:00+08:00"`
+}
+
+// ClusterEventItem 集群外部变更
+type ClusterEventItem struct {
+	Id          int64     `json:"id"`
+	ClusterID   int64     `json:"cluster_id"`
+	ClusterName string    `json:"cluster_name"`
+	Source      string    `json:"source"` // task / journal
+	Type        string    `json:"type"`
+	NodeName    string    `json:"node_name"`
+	VMID        uint32    `json:"vmid"`
+	VmName      string    `json:"vm_name"`
+	User        string    `json:"user"`   // 执行变更的 Proxmox 用户
+	UPID        string    `json:"upid"`   // 来源任务，journal 事件为空
+	Status      string    `json:"status"` // 任务结果（OK 或错误信息）
+	Detail      string    `json:"detail"` // 任务类型或修改的配置项，如 "-memory 4096 -cores 2"
+	EventTime   time.Time `json:"event_time"`
+	CreateTime  time.Time `json:"create_time"`
+}
+
+type ListClusterEventsResponseData struct {
+	Total int64              `json:"total"`
+	List  []ClusterEventItem `json:"list"`
+}
+
+type ListClusterEventsResponse struct {
+	Response
+	Data ListClusterEventsResponseData `json:"data"`
+}
@@ -0,0 +1,93 @@
+package v1
+
+import "time"
+
+// 控制台会话审计相关 API 定义
+// 每次通过 PveSphere 建立的虚拟机 / 节点控制台 websocket 连接都会记录操作人、目标、来源 IP、起止时间和流量；
+// 集群开启 console_recording（或虚拟机所属应用在 console_audit.recording.app_ids 中）时，
+// 额外录制 Proxmox -> 浏览器方向的画面帧（不含键盘输入），会话结束后上传到对象存储，超过保留期后自动删除。
+
+// ListConsoleSessionsRequest 控制台会话列表请求
+type ListConsoleSessionsRequest struct {
+	Page            int        `form:"page" example:"1"`
+	PageSize        int        `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	UserID          string     `form:"user_id" example:"u_123"`
+	TargetType      string     `form:"target_type" binding:"omitempty,oneof=vm node" example:"vm"`
+	TargetID        int64      `form:"target_id" example:"1"` // pve_vm / pve_node 表 ID
+	ClusterID       int64      `form:"cluster_id" example:"1"`
+	RecordingStatus string     `form:"recording_status" binding:"omitempty,oneof=none recording uploaded failed expired" example:"uploaded"`
+	StartFrom       *time.Time `form:"start_from" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-01-01T00:00:00Z"`
+	StartTo         *time.Time `form:"start_to" time_format:"2006-01-02T15:04:05Z07:00" example:"2025-02-01T00:00:00Z"`
+}
+
+// ConsoleSessionItem 控制台会话审计信息
+type ConsoleSessionItem struct {
+	Id                 int64      `json:"id"`
+	SessionID          string     `json:"session_id"`
+	UserID             string     `json:"user_id"`
+	Username           string     `json:"username"`
+	TargetType         string     `json:"target_type"` // vm, node
+	TargetID           int64      `json:"target_id"`
+	TargetName         string     `json:"target_name"`
+	VMID               uint32     `json:"vmid,omitempty"`
+	ClusterID          int64      `json:"cluster_id"`
+	NodeName           string     `json:"node_name"`
+	AppId              string     `json:"app_id,omitempty"`
+	ConsoleType        string     `json:"console_type"` // vnc, vncshell, termproxy
+	ClientIP           string     `json:"client_ip"`
+	UserAgent          string     `json:"user_agent"`
+	StartTime          time.Time  `json:"start_time"`
+	EndTime            *time.Time `json:"end_time"`
+	DurationSeconds    int64      `json:"duration_seconds"`
+	BytesIn            int64      `json:"bytes_in"`  // 浏览器 -> Proxmox
+	BytesOut           int64      `json:"bytes_out"` // Proxmox -> 浏览器
+	CloseReason        string     `json:"close_reason"`
+	RecordingStatus    string     `json:"recording_status"` // none, recording, uploaded, failed, expired
+	RecordingSize      int64      `json:"recording_size"`
+	RecordingFrames    int64      `json:"recording_frames"`
+	RecordingTruncated bool       `json:"recording_truncated"` // 超过 console_audit.recording.max_bytes 后停止录制
+	RecordingExpireAt  *time.Time `json:"recording_expire_at"`
+	ErrorMessage       string     `json:"error_message,omitempty"`
+}
+
+// GetConsoleSessionResponse 控制台会话详情响应
+type GetConsoleSessionResponse struct {
+	Response
+	Data ConsoleSessionItem
+}
+
+// ListConsoleSessionsResponse 控制台会话列表响应
+type ListConsoleSessionsResponse struct {
+	Response
+	Data ListConsoleSessionsResponseData
+}
+
+type ListConsoleSessionsResponseData struct {
+	Total int64                `json:"total"`
+	List  []ConsoleSessionItem `json:"list"`
+}
+
+// ConsoleProxyMetrics 控制台 websocket 代理的运行指标（当前实例，重启后清零）
+type ConsoleProxyMetrics struct {
+	ActiveSessions   int64 `json:"active_sessions"`   // 当前连接数
+	PeakSessions     int64 `json:"peak_sessions"`     // 启动以来的最大并发连接数
+	ActiveClientIPs  int   `json:"active_client_ips"` // 当前有连接的来源 IP 数
+	TotalSessions    int64 `json:"total_sessions"`    // 启动以来建立的连接数
+	RejectedSessions int64 `json:"rejected_sessions"` // 超过并发上限被拒绝的连接数
+	IdleTimeouts     int64 `json:"idle_timeouts"`     // 因空闲超时关闭的连接数
+	BytesIn          int64 `json:"bytes_in"`          // 浏览器 -> Proxmox
+	BytesOut         int64 `json:"bytes_out"`         // Proxmox -> 浏览器
+	MessagesIn       int64 `json:"messages_in"`
+	MessagesOut      int64 `json:"messages_out"`
+	// 当前配置（console.proxy.*）
+	MaxSessions        int   `json:"max_sessions"` // 0 表示不限制
+	MaxSessionsPerIP   int   `json:"max_per_ip"`   // 0 表示不限制
+	ReadLimit          int64 `json:"read_limit"`   // 单条消息的大小上限（字节）
+	BufferSize         int   `json:"buffer_size"`  // websocket 读写缓冲区大小（字节）
+	IdleTimeoutSeconds int64 `json:"idle_timeout"` // 0 表示不限制
+}
+
+type GetConsoleProxyMetricsResponse struct {
+	Response
+	Data ConsoleProxyMetrics
+}
@@ -0,0 +1,161 @@
+package v1
+
+import "time"
+
+// 存储内容复制策略相关 API 定义
+// 策略规定源存储上的 ISO / 容器模板（可按文件名通配符过滤）必须同样存在于一个或多个目标存储，
+// 如“存储 X 上的 ISO 必须存在于 Y、Z”或“容器模板必须存在于每个节点的 local 存储”。
+// 目标存储为共享存储时只检查一份，为节点本地存储时检查每个拥有该存储的节点。
+// 对账时列出各目标缺少的文件；复制时从本服务主机上挂载的源存储目录（content_replication.source_dirs）
+// 读取文件并上传到目标存储。只补齐缺少的文件，不删除目标上多出的文件，也不覆盖同名文件
+
+// 可复制的内容类型
+const (
+	ContentReplicationContentISO    = "iso"
+	ContentReplicationContentVZTmpl = "vztmpl"
+)
+
+// CreateContentReplicationPolicyRequest 创建复制策略
+type CreateContentReplicationPolicyRequest struct {
+	Name           string   `json:"name" binding:"required,max=100" example:"iso-to-local"`
+	ClusterID      int64    `json:"cluster_id" binding:"required" example:"1"`
+	Content        string   `json:"content" binding:"required,oneof=iso vztmpl" example:"iso"`
+	SourceStorage  string   `json:"source_storage" binding:"required,max=100" example:"iso-nfs"`
+	SourceNode     string   `json:"source_node" binding:"max=100" example:"pve1"`                    // 为空时使用任一在线且能访问源存储的节点
+	Pattern        string   `json:"pattern" binding:"max=255" example:"debian-*.iso"`                // 文件名通配符，为空表示全部
+	TargetStorages []string `json:"target_storages" binding:"required,min=1,max=20" example:"local"` // 目标存储名称
+	AutoCopy       *bool    `json:"auto_copy" example:"true"`                                        // 定期对账时自动复制缺失的文件，默认 true
+	Enabled        *bool    `json:"enabled" example:"true"`                                          // 默认 true
+	Description    string   `json:"description" binding:"max=500"`
+}
+
+// UpdateContentReplicationPolicyRequest 修改复制策略，未传的字段保持不变
+type UpdateContentReplicationPolicyRequest struct {
+	Name           *string  `json:"name" binding:"omitempty,max=100"`
+	SourceStorage  *string  `json:"source_storage" binding:"omitempty,max=100"`
+	SourceNode     *string  `json:"source_node" binding:"omitempty,max=100"`
+	Pattern        *string  `json:"pattern" binding:"omitempty,max=255"`
+	TargetStorages []string `json:"target_storages" binding:"omitempty,max=20"`
+	AutoCopy       *bool    `json:"auto_copy"`
+	Enabled        *bool    `json:"enabled"`
+	Description    *string  `json:"description" binding:"omitempty,max=500"`
+}
+
+// ListContentReplicationPoliciesRequest 查询复制策略
+type ListContentReplicationPoliciesRequest struct {
+	ClusterID int64 `form:"cluster_id" example:"1"`
+}
+
+// ContentReplicationPolicyItem 复制策略
+type ContentReplicationPolicyItem struct {
+	Id             int64    `json:"id"`
+	Name           string   `json:"name"`
+	ClusterID      int64    `json:"cluster_id"`
+	ClusterName    string   `json:"cluster_name"`
+	Content        string   `json:"content"`
+	SourceStorage  string   `json:"source_storage"`
+	SourceNode     string   `json:"source_node"`
+	Pattern        string   `json:"pattern"`
+	TargetStorages []string `json:"target_storages"`
+	AutoCopy       bool     `json:"auto_copy"`
+	Enabled        bool     `json:"enabled"`
+	Description    string   `json:"description"`
+	// 最近一次对账结果：unknown / in_sync / diverged / error
+	Status        string     `json:"status"`
+	MissingCount  int        `json:"missing_count"`
+	LastCheckTime *time.Time `json:"last_check_time"`
+	LastError     string     `json:"last_error"`
+	Creator       string     `json:"creator"`
+	Modifier      string     `json:"modifier"`
+	CreateTime    time.Time  `json:"create_time"`
+	UpdateTime    time.Time  `json:"update_time"`
+}
+
+type ContentReplicationPolicyResponse struct {
+	Response
+	Data ContentReplicationPolicyItem
+}
+
+type ListContentReplicationPoliciesResponseData struct {
+	List []ContentReplicationPolicyItem `json:"list"`
+}
+
+type ListContentReplicationPoliciesResponse struct {
+	Response
+	Data ListContentReplicationPoliciesResponseData
+}
+
+// ContentReplicationFile 源存储上的文件
+type ContentReplicationFile struct {
+	FileName string `json:"file_name"`
+	Size     int64  `json:"size"`
+}
+
+// ContentReplicationTarget 一个节点上的目标存储的对账结果
+type ContentReplicationTarget struct {
+	NodeName    string                   `json:"node_name"`
+	StorageName string                   `json:"storage_name"`
+	Shared      bool                     `json:"shared"`
+	Missing     []ContentReplicationFile `json:"missing"`
+	Copying     []string                 `json:"copying"`         // 缺少但已有复制任务在进行的文件
+	Error       string                   `json:"error,omitempty"` // 无法读取该存储时的原因
+}
+
+// ContentReplicationReport 对账结果
+type ContentReplicationReport struct {
+	PolicyID      int64                      `json:"policy_id"`
+	Status        string                     `json:"status"`
+	SourceNode    string                     `json:"source_node"`
+	SourceStorage string                     `json:"source_storage"`
+	SourceFiles   []ContentReplicationFile   `json:"source_files"`
+	Targets       []ContentReplicationTarget `json:"targets"`
+	MissingCount  int                        `json:"missing_count"` // 各目标缺少的文件数之和
+	QueuedCopies  int                        `json:"queued_copies"` // 本次新建的复制任务数
+	CheckTime     time.Time                  `json:"check_time"`
+	Error         string                     `json:"error,omitempty"` // 源存储无法读取时的原因
+}
+
+type ContentReplicationReportResponse struct {
+	Response
+	Data ContentReplicationReport
+}
+
+// ListContentReplicationCopiesRequest 查询复制任务
+type ListContentReplicationCopiesRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	PolicyID int64  `form:"policy_id" example:"1"`
+	Status   string `form:"status" binding:"omitempty,oneof=pending copying completed failed" example:"failed"`
+}
+
+// ContentReplicationCopyItem 复制任务
+type ContentReplicationCopyItem struct {
+	Id               int64      `json:"id"`
+	PolicyID         int64      `json:"policy_id"`
+	ClusterID        int64      `json:"cluster_id"`
+	Content          string     `json:"content"`
+	FileName         string     `json:"file_name"`
+	SourceNode       string     `json:"source_node"`
+	SourceStorage    string     `json:"source_storage"`
+	TargetNode       string     `json:"target_node"`
+	TargetStorage    string     `json:"target_storage"`
+	Status           string     `json:"status"`
+	TotalBytes       int64      `json:"total_bytes"`
+	TransferredBytes int64      `json:"transferred_bytes"`
+	Progress         int        `json:"progress"`
+	StartTime        *time.Time `json:"start_time"`
+	EndTime          *time.Time `json:"end_time"`
+	ErrorMessage     string     `json:"error_message"`
+	Creator          string     `json:"creator"`
+	CreateTime       time.Time  `json:"create_time"`
+}
+
+type ListContentReplicationCopiesResponseData struct {
+	Total int64                        `json:"total"`
+	List  []ContentReplicationCopyItem `json:"list"`
+}
+
+type ListContentReplicationCopiesResponse struct {
+	Response
+	Data ListContentReplicationCopiesResponseData
+}
@@ -0,0 +1,165 @@
+package v1
+
+import "time"
+
+// 成本核算（showback / chargeback）相关 API 定义
+// 用量按 (日期, 集群, 应用, 成本中心) 定期采集：vCPU、内存仅在虚拟机运行时计量，存储按已分配磁盘容量持续计量；
+// 费用 = 用量 × 集群价格模型（集群未配置时使用 cluster_id=0 的默认价格）。
+
+// 报表分组方式
+const (
+	CostGroupByApp        = "app"
+	CostGroupByCluster    = "cluster"
+	CostGroupByCostCenter = "cost_center"
+)
+
+// SetCostPriceRequest 设置集群价格模型（按 cluster_id 新增或覆盖）
+type SetCostPriceRequest struct {
+	ClusterID           int64   `json:"cluster_id" example:"1"` // 0 表示默认价格
+	VcpuHourPrice       float64 `json:"vcpu_hour_price" binding:"min=0" example:"0.05"`
+	RamGbHourPrice      float64 `json:"ram_gb_hour_price" binding:"min=0" example:"0.01"`
+	StorageGbMonthPrice float64 `json:"storage_gb_month_price" binding:"min=0" example:"0.3"`
+	Describes           string  `json:"describes" binding:"max=500" example:"2026 年度价格"`
+}
+
+// CostPriceItem 价格模型
+type CostPriceItem struct {
+	Id                  int64     `json:"id"`
+	ClusterID           int64     `json:"cluster_id"`
+	ClusterName         string    `json:"cluster_name"` // 默认价格为空
+	VcpuHourPrice       float64   `json:"vcpu_hour_price"`
+	RamGbHourPrice      float64   `json:"ram_gb_hour_price"`
+	StorageGbMonthPrice float64   `json:"storage_gb_month_price"`
+	Describes           string    `json:"describes"`
+	Creator             string    `json:"creator"`
+	Modifier            string    `json:"modifier"`
+	CreateTime          time.Time `json:"create_time"`
+	UpdateTime          time.Time `json:"update_time"`
+}
+
+type ListCostPricesResponseData struct {
+	Currency string          `json:"currency"`
+	List     []CostPriceItem `json:"list"`
+}
+
+// ListCostPricesResponse 价格模型列表响应
+type ListCostPricesResponse struct {
+	Response
+	Data ListCostPricesResponseData
+}
+
+// GetCostReportRequest 月度成本报表请求
+type GetCostReportRequest struct {
+	Month      string `form:"month" binding:"required" example:"2026-01"` // YYYY-MM
+	ClusterID  int64  `form:"cluster_id" example:"1"`
+	AppId      string `form:"app_id" example:"app-001"`
+	CostCenter string `form:"cost_center" example:"CC-1001"`
+	GroupBy    string `form:"group_by" binding:"omitempty,oneof=app cluster cost_center" example:"app"` // 默认 app
+}
+
+// CostReportItem 成本报表行
+type CostReportItem struct {
+	AppId           string  `json:"app_id"`                 // group_by=app，空表示未归属应用的虚拟机
+	ClusterID       int64   `json:"cluster_id"`             // group_by=cluster
+	ClusterName     string  `json:"cluster_name,omitempty"` // group_by=cluster
+	CostCenter      string  `json:"cost_center,omitempty"`  // group_by=cost_center，空表示未设置成本中心的虚拟机
+	VcpuHours       float64 `json:"vcpu_hours"`
+	RamGbHours      float64 `json:"ram_gb_hours"`
+	StorageGbMonths float64 `json:"storage_gb_months"`
+	CPUCost         float64 `json:"cpu_cost"`
+	RAMCost         float64 `json:"ram_cost"`
+	StorageCost     float64 `json:"storage_cost"`
+	TotalCost       float64 `json:"total_cost"`
+	// Budget 应用月度预算（仅 group_by=app 且已配置预算时返回）
+	Budget *float64 `json:"budget,omitempty"`
+	// BudgetUsedPercent 预算使用百分比
+	BudgetUsedPercent *float64 `json:"budget_used_percent,omitempty"`
+}
+
+// CostReportData 月度成本报表
+type CostReportData struct {
+	Month     string           `json:"month"`
+	Currency  string           `json:"currency"`
+	GroupBy   string           `json:"group_by"`
+	TotalCost float64          `json:"total_cost"`
+	Items     []CostReportItem `json:"items"`
+}
+
+// GetCostReportResponse 月度成本报表响应
+type GetCostReportResponse struct {
+	Response
+	Data CostReportData
+}
+
+// CreateCostBudgetRequest 创建应用月度预算
+type CreateCostBudgetRequest struct {
+	AppId          string  `json:"app_id" binding:"required,max=100" example:"app-001"`
+	MonthlyBudget  float64 `json:"monthly_budget" binding:"required,gt=0" example:"5000"`
+	AlertThreshold int     `json:"alert_threshold" binding:"omitempty,min=1,max=100" example:"80"` // 告警阈值（预算百分比），默认 80
+	Describes      string  `json:"describes" binding:"max=500" example:"研发部门"`
+}
+
+// UpdateCostBudgetRequest 更新应用月度预算
+type UpdateCostBudgetRequest struct {
+	MonthlyBudget  *float64 `json:"monthly_budget,omitempty" binding:"omitempty,gt=0" example:"8000"`
+	AlertThreshold *int     `json:"alert_threshold,omitempty" binding:"omitempty,min=1,max=100" example:"90"`
+	Describes      *string  `json:"describes,omitempty" binding:"omitempty,max=500"`
+}
+
+// CostBudgetItem 应用月度预算
+type CostBudgetItem struct {
+	Id             int64     `json:"id"`
+	AppId          string    `json:"app_id"`
+	MonthlyBudget  float64   `json:"monthly_budget"`
+	AlertThreshold int       `json:"alert_threshold"`
+	Describes      string    `json:"describes"`
+	CurrentCost    float64   `json:"current_cost"` // 本月截至目前的费用
+	UsedPercent    float64   `json:"used_percent"` // 本月预算使用百分比
+	Creator        string    `json:"creator"`
+	Modifier       string    `json:"modifier"`
+	CreateTime     time.Time `json:"create_time"`
+	UpdateTime     time.Time `json:"update_time"`
+}
+
+type ListCostBudgetsResponseData struct {
+	Month    string           `json:"month"`
+	Currency string           `json:"currency"`
+	List     []CostBudgetItem `json:"list"`
+}
+
+// ListCostBudgetsResponse 预算列表响应
+type ListCostBudgetsResponse struct {
+	Response
+	Data ListCostBudgetsResponseData
+}
+
+// ListCostAlertsRequest 预算告警查询
+type ListCostAlertsRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	Month    string `form:"month" example:"2026-01"`
+	AppId    string `form:"app_id" example:"app-001"`
+}
+
+// CostAlertItem 预算告警记录
+type CostAlertItem struct {
+	Id         int64     `json:"id"`
+	BudgetID   int64     `json:"budget_id"`
+	AppId      string    `json:"app_id"`
+	Month      string    `json:"month"`
+	Level      string    `json:"level"` // threshold 达到告警阈值 / exceeded 超出预算
+	Cost       float64   `json:"cost"`
+	Budget     float64   `json:"budget"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+type ListCostAlertsResponseData struct {
+	Total int64           `json:"total"`
+	List  []CostAlertItem `json:"list"`
+}
+
+// ListCostAlertsResponse 预算告警列表响应
+type ListCostAlertsResponse struct {
+	Response
+	Data ListCostAlertsResponseData
+}
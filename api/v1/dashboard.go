@@ -1,5 +1,7 @@
 package v1
 
+import "time"
+
 // Dashboard 相关 API 定义
 
 // ==================== Scopes ====================
@@ -11,21 +13,31 @@ type DashboardScopesResponse struct {
 }
 
 type DashboardScopesData struct {
-	Items []ScopeItem `json:"items"`
+	Items []ScopeItem     `json:"items"`
+	Sites []SiteScopeItem `json:"sites"`
 }
 
 type ScopeItem struct {
 	ClusterID        int64  `json:"cluster_id" example:"1"`
 	ClusterName      string `json:"cluster_name" example:"pve-prod-01"`
 	ClusterNameAlias string `json:"cluster_name_alias" example:"生产集群一"`
+	SiteID           int64  `json:"site_id" example:"1"` // 所属站点ID，0 表示未分配
+}
+
+type SiteScopeItem struct {
+	SiteID        int64  `json:"site_id" example:"1"`
+	SiteName      string `json:"site_name" example:"dc-beijing"`
+	SiteNameAlias string `json:"site_name_alias" example:"北京机房"`
+	Region        string `json:"region" example:"cn-north"`
 }
 
 // ==================== Overview ====================
 
 // DashboardOverviewRequest 全局概览请求
 type DashboardOverviewRequest struct {
-	Scope     string `form:"scope" example:"all"`    // all 或 cluster
+	Scope     string `form:"scope" example:"all"`    // all、site 或 cluster
 	ClusterID *int64 `form:"cluster_id" example:"1"` // 当 scope 为 cluster 时使用
+	SiteID    *int64 `form:"site_id" example:"1"`    // 当 scope 为 site 时使用
 }
 
 // DashboardOverviewResponse 全局概览响应
@@ -35,8 +47,9 @@ type DashboardOverviewResponse struct {
 }
 
 type DashboardOverviewData struct {
-	Scope     string                   `json:"scope" example:"all"`  // all 或 cluster
+	Scope     string                   `json:"scope" example:"all"`  // all、site 或 cluster
 	ClusterID *int64                   `json:"cluster_id,omitempty"` // 集群ID（当 scope 为 cluster 时）
+	SiteID    *int64                   `json:"site_id,omitempty"`    // 站点ID（当 scope 为 site 时）
 	Summary   DashboardOverviewSummary `json:"summary"`              // 概览统计
 	Health    DashboardOverviewHealth  `json:"health"`               // 健康状态
 }
@@ -54,12 +67,46 @@ type DashboardOverviewHealth struct {
 	Critical int64 `json:"critical" example:"0"` // 严重数量
 }
 
+// ==================== Sites ====================
+
+// DashboardSitesResponse 按站点分组的概览响应
+type DashboardSitesResponse struct {
+	Response
+	Data DashboardSitesData `json:"data"`
+}
+
+type DashboardSitesData struct {
+	Items []SiteOverviewItem `json:"items"`
+}
+
+// SiteOverviewItem 单个站点的概览，未分配站点的集群归入 site_id 为 0 的分组
+type SiteOverviewItem struct {
+	SiteID        int64                    `json:"site_id" example:"1"`
+	SiteName      string                   `json:"site_name" example:"dc-beijing"`
+	SiteNameAlias string                   `json:"site_name_alias" example:"北京机房"`
+	Region        string                   `json:"region" example:"cn-north"`
+	Summary       DashboardOverviewSummary `json:"summary"` // 概览统计
+	Health        DashboardOverviewHealth  `json:"health"`  // 健康状态
+}
+
 // ==================== Resources ====================
 
 // DashboardResourcesRequest 资源使用率请求
 type DashboardResourcesRequest struct {
-	Scope     string `form:"scope" example:"all"`    // all 或 cluster
+	Scope     string `form:"scope" example:"all"`    // all、site 或 cluster
 	ClusterID *int64 `form:"cluster_id" example:"1"` // 当 scope 为 cluster 时使用
+	SiteID    *int64 `form:"site_id" example:"1"`    // 当 scope 为 site 时使用
+	DashboardVMFilter
+}
+
+// DashboardVMFilter 按项目、团队、标签、环境或节点过滤 Dashboard 统计的资源。
+// 指定 app_id、team、tag、environment 任一项时只统计匹配的虚拟机，node_id 只统计该节点
+type DashboardVMFilter struct {
+	AppId       string `form:"app_id" json:"app_id,omitempty" example:"app-001"`                                               // 项目（应用ID）
+	Team        string `form:"team" json:"team,omitempty" example:"payments"`                                                  // 所属团队
+	Tag         string `form:"tag" json:"tag,omitempty" example:"web"`                                                         // Proxmox 标签
+	Environment string `form:"environment" json:"environment,omitempty" binding:"omitempty,oneof=prod stage dev" example:"prod"` // 环境
+	NodeID      *int64 `form:"node_id" json:"node_id,omitempty" example:"1"`                                                   // 节点ID
 }
 
 // DashboardResourcesResponse 资源使用率响应
@@ -69,11 +116,15 @@ type DashboardResourcesResponse struct {
 }
 
 type DashboardResourcesData struct {
-	Scope     string        `json:"scope" example:"all"`  // all 或 cluster
+	Scope     string        `json:"scope" example:"all"`  // all、site 或 cluster
 	ClusterID *int64        `json:"cluster_id,omitempty"` // 集群ID（当 scope 为 cluster 时）
+	SiteID    *int64        `json:"site_id,omitempty"`    // 站点ID（当 scope 为 site 时）
 	CPU       ResourceUsage `json:"cpu"`                  // CPU 使用率
 	Memory    ResourceUsage `json:"memory"`               // 内存使用率
 	Storage   ResourceUsage `json:"storage"`              // 存储使用率
+	// 过滤条件；按虚拟机过滤时使用率基于匹配虚拟机分配的 vCPU、内存和磁盘
+	Filter     *DashboardVMFilter `json:"filter,omitempty"`
+	MatchedVMs *int64             `json:"matched_vms,omitempty"` // 按虚拟机过滤时匹配的虚拟机数量
 }
 
 type ResourceUsage struct {
@@ -82,15 +133,22 @@ type ResourceUsage struct {
 	UsedBytes    *int64   `json:"used_bytes,omitempty" example:"180000000000"`  // 已使用字节数（内存/存储）
 	TotalBytes   *int64   `json:"total_bytes,omitempty" example:"260000000000"` // 总字节数（内存/存储）
 	UsagePercent float64  `json:"usage_percent" example:"72.0"`                 // 使用率百分比
+	// 集群为宿主机、Ceph 和系统进程预留的部分（仅 CPU 和内存，按虚拟机过滤时不返回）
+	ReservedCores  *float64 `json:"reserved_cores,omitempty" example:"25"`           // 预留的 CPU 核心数
+	AvailableCores *float64 `json:"available_cores,omitempty" example:"45"`          // 可用 CPU 核心数：总核心数 - 预留 - 已使用
+	ReservedBytes  *int64   `json:"reserved_bytes,omitempty" example:"26000000000"`  // 预留的内存字节数
+	AvailableBytes *int64   `json:"available_bytes,omitempty" example:"54000000000"` // 可用内存字节数：总字节数 - 预留 - 已使用
 }
 
 // ==================== Hotspots ====================
 
 // DashboardHotspotsRequest 压力和风险焦点请求
 type DashboardHotspotsRequest struct {
-	Scope     string `form:"scope" example:"all"`    // all 或 cluster
+	Scope     string `form:"scope" example:"all"`    // all、site 或 cluster
 	ClusterID *int64 `form:"cluster_id" example:"1"` // 当 scope 为 cluster 时使用
+	SiteID    *int64 `form:"site_id" example:"1"`    // 当 scope 为 site 时使用
 	Limit     int    `form:"limit" example:"5"`      // Top N 数量，默认 5
+	DashboardVMFilter
 }
 
 // DashboardHotspotsResponse 压力和风险焦点响应
@@ -100,12 +158,14 @@ type DashboardHotspotsResponse struct {
 }
 
 type DashboardHotspotsData struct {
-	Scope      string              `json:"scope" example:"all"`    // all 或 cluster
+	Scope      string              `json:"scope" example:"all"`    // all、site 或 cluster
 	ClusterID  *int64              `json:"cluster_id,omitempty"`  // 集群ID（当 scope 为 cluster 时）
+	SiteID     *int64              `json:"site_id,omitempty"`     // 站点ID（当 scope 为 site 时）
 	VMHotspots VMHotspots          `json:"vm_hotspots"`           // 虚拟机热点
 	NodeHotspots NodeHotspots      `json:"node_hotspots"`         // 节点热点
 	StorageHotspots []StorageHotspot `json:"storage_hotspots"`   // 存储热点
 	RecentRisks []RecentRisk       `json:"recent_risks"`         // 最近风险（24h）
+	Filter *DashboardVMFilter `json:"filter,omitempty"` // 过滤条件
 }
 
 // VMHotspots 虚拟机热点（按指标类型分组）
@@ -156,8 +216,9 @@ type RecentRisk struct {
 
 // DashboardOperationsRequest 运行中操作请求
 type DashboardOperationsRequest struct {
-	Scope     string `form:"scope" example:"all"`    // all 或 cluster
+	Scope     string `form:"scope" example:"all"`    // all、site 或 cluster
 	ClusterID *int64 `form:"cluster_id" example:"1"` // 当 scope 为 cluster 时使用
+	SiteID    *int64 `form:"site_id" example:"1"`    // 当 scope 为 site 时使用
 }
 
 // DashboardOperationsResponse 运行中操作响应
@@ -167,8 +228,9 @@ type DashboardOperationsResponse struct {
 }
 
 type DashboardOperationsData struct {
-	Scope     string             `json:"scope" example:"all"`  // all 或 cluster
+	Scope     string             `json:"scope" example:"all"`  // all、site 或 cluster
 	ClusterID *int64             `json:"cluster_id,omitempty"` // 集群ID（当 scope 为 cluster 时）
+	SiteID    *int64             `json:"site_id,omitempty"`    // 站点ID（当 scope 为 site 时）
 	Summary   []OperationSummary `json:"summary"`              // 操作摘要（按类型聚合）
 	Items     []OperationItem    `json:"items,omitempty"`      // 操作明细（可选）
 }
@@ -187,3 +249,167 @@ type OperationItem struct {
 	Status        string `json:"status" example:"running"`                  // running / pending / failed / completed
 	StartedAt     string `json:"started_at" example:"2025-12-23T07:50:00Z"` // 开始时间
 }
+
+// ==================== Energy ====================
+
+// DashboardEnergyRequest 能耗概览请求
+type DashboardEnergyRequest struct {
+	Scope     string `form:"scope" example:"all"`    // all、site 或 cluster
+	ClusterID *int64 `form:"cluster_id" example:"1"` // 当 scope 为 cluster 时使用
+	SiteID    *int64 `form:"site_id" example:"1"`    // 当 scope 为 site 时使用
+}
+
+// DashboardEnergyResponse 能耗概览响应
+type DashboardEnergyResponse struct {
+	Response
+	Data DashboardEnergyData `json:"data"`
+}
+
+type DashboardEnergyData struct {
+	Scope          string            `json:"scope" example:"all"`          // all、site 或 cluster
+	ClusterID      *int64            `json:"cluster_id,omitempty"`         // 集群ID（当 scope 为 cluster 时）
+	SiteID         *int64            `json:"site_id,omitempty"`            // 站点ID（当 scope 为 site 时）
+	TotalNodes     int64             `json:"total_nodes" example:"10"`     // 节点总数
+	MonitoredNodes int64             `json:"monitored_nodes" example:"8"`  // 已配置 BMC 功率采集的节点数
+	CurrentWatts   float64           `json:"current_watts" example:"3200"` // 最近一次读数之和
+	TodayKwh       float64           `json:"today_kwh" example:"41.5"`     // 今日能耗
+	MonthKwh       float64           `json:"month_kwh" example:"980.2"`    // 本月能耗
+	MonthCarbonKg  float64           `json:"month_carbon_kg" example:"5"`  // 本月碳排放（kg CO2e）
+	Nodes          []NodeEnergyUsage `json:"nodes"`                        // 按当前功率从高到低排序
+}
+
+type NodeEnergyUsage struct {
+	NodeID          int64   `json:"node_id" example:"1"`
+	NodeName        string  `json:"node_name" example:"pve-node-1"`
+	ClusterID       int64   `json:"cluster_id" example:"1"`
+	ClusterName     string  `json:"cluster_name" example:"cluster-1"`
+	CurrentWatts    float64 `json:"current_watts" example:"320"`
+	LastReadingTime string  `json:"last_reading_time" example:"2025-12-23T07:50:00Z"` // 最近一次读数时间，空表示尚未读取成功
+	LastError       string  `json:"last_error,omitempty"`
+	TodayKwh        float64 `json:"today_kwh" example:"4.1"`
+}
+
+// ==================== Risks ====================
+
+// DashboardRisksRequest 风险面板请求
+type DashboardRisksRequest struct {
+	Scope     string `form:"scope" example:"all"`    // all、site 或 cluster
+	ClusterID *int64 `form:"cluster_id" example:"1"` // 当 scope 为 cluster 时使用
+	SiteID    *int64 `form:"site_id" example:"1"`    // 当 scope 为 site 时使用
+}
+
+// DashboardRisksResponse 风险面板响应
+type DashboardRisksResponse struct {
+	Response
+	Data DashboardRisksData `json:"data"`
+}
+
+type DashboardRisksData struct {
+	Scope     string       `json:"scope" example:"all"`  // all、site 或 cluster
+	ClusterID *int64       `json:"cluster_id,omitempty"` // 集群ID（当 scope 为 cluster 时）
+	SiteID    *int64       `json:"site_id,omitempty"`    // 站点ID（当 scope 为 site 时）
+	Risks     []RecentRisk `json:"risks"`
+}
+
+// ==================== Alerts ====================
+
+// DashboardAlertsRequest 告警面板请求
+type DashboardAlertsRequest struct {
+	Scope     string `form:"scope" example:"all"`    // all、site 或 cluster
+	ClusterID *int64 `form:"cluster_id" example:"1"` // 当 scope 为 cluster 时使用
+	SiteID    *int64 `form:"site_id" example:"1"`    // 当 scope 为 site 时使用
+	Limit     int    `form:"limit" example:"20"`     // 返回的告警条数，默认 20（统计数不受影响）
+}
+
+// DashboardAlertsResponse 告警面板响应
+type DashboardAlertsResponse struct {
+	Response
+	Data DashboardAlertsData `json:"data"`
+}
+
+type DashboardAlertsData struct {
+	Scope     string               `json:"scope" example:"all"`  // all、site 或 cluster
+	ClusterID *int64               `json:"cluster_id,omitempty"` // 集群ID（当 scope 为 cluster 时）
+	SiteID    *int64               `json:"site_id,omitempty"`    // 站点ID（当 scope 为 site 时）
+	Total     int64                `json:"total" example:"3"`    // 未恢复告警总数
+	BySource  map[string]int64     `json:"by_source"`            // 按来源统计
+	Alerts    []DashboardAlertItem `json:"alerts"`               // 按发生时间从新到旧
+}
+
+// DashboardAlertItem 各模块未恢复的告警
+type DashboardAlertItem struct {
+	Source       string `json:"source" example:"node_disk"` // license / zfs_pool / node_disk
+	ID           int64  `json:"id" example:"1"`             // 来源模块中的告警 ID
+	Level        string `json:"level" example:"warning"`    // warning / critical
+	Message      string `json:"message" example:"Disk /dev/sdb on pve-07: reallocated"`
+	ClusterID    int64  `json:"cluster_id,omitempty" example:"1"`
+	TargetType   string `json:"target_type" example:"node"` // node / license
+	TargetID     string `json:"target_id" example:"node-7"`
+	TargetName   string `json:"target_name" example:"pve-07"`
+	OccurredAt   string `json:"occurred_at" example:"2025-12-23T08:01:00Z"` // 首次发生时间
+	RelativeTime string `json:"relative_time" example:"5 min ago"`
+}
+
+// ==================== Widgets / Layout ====================
+
+// DashboardWidget 可用的 Dashboard 面板
+type DashboardWidget struct {
+	Key           string `json:"key" example:"hotspots"`
+	Name          string `json:"name" example:"压力和风险焦点"`
+	Endpoint      string `json:"endpoint" example:"/api/v1/dashboard/hotspots"` // 面板数据接口，均支持 scope / cluster_id / site_id
+	DefaultWidth  int    `json:"default_width" example:"6"`                     // 默认宽度（12 栅格）
+	DefaultHeight int    `json:"default_height" example:"4"`
+}
+
+// DashboardLayoutItem 布局中的一个面板
+type DashboardLayoutItem struct {
+	Widget  string                 `json:"widget" binding:"required" example:"hotspots"` // 面板 key
+	X       int                    `json:"x" binding:"min=0,max=11" example:"0"`
+	Y       int                    `json:"y" binding:"min=0" example:"0"`
+	W       int                    `json:"w" binding:"min=1,max=12" example:"6"`
+	H       int                    `json:"h" binding:"min=1,max=24" example:"4"`
+	Options map[string]interface{} `json:"options,omitempty"` // 面板参数，如 {"limit": 10}
+}
+
+// DashboardLayoutData Dashboard 布局
+type DashboardLayoutData struct {
+	Preset     string                `json:"preset" example:"ops"` // ops / management / custom
+	Scope      string                `json:"scope" example:"all"`
+	ClusterID  *int64                `json:"cluster_id,omitempty"`
+	SiteID     *int64                `json:"site_id,omitempty"`
+	Widgets    []DashboardLayoutItem `json:"widgets"`
+	Customized bool                  `json:"customized"`            // 是否为用户保存的布局（否则为预设布局）
+	UpdateTime *time.Time            `json:"update_time,omitempty"` // 用户布局的保存时间
+}
+
+// DashboardWidgetsData 可用面板及预设布局
+type DashboardWidgetsData struct {
+	Widgets []DashboardWidget     `json:"widgets"`
+	Presets []DashboardLayoutData `json:"presets"`
+}
+
+// DashboardWidgetsResponse 可用面板响应
+type DashboardWidgetsResponse struct {
+	Response
+	Data DashboardWidgetsData `json:"data"`
+}
+
+// GetDashboardLayoutRequest 获取布局请求
+type GetDashboardLayoutRequest struct {
+	Preset string `form:"preset" binding:"omitempty,oneof=ops management" example:"ops"` // 指定时返回预设布局，否则返回用户布局（未保存时为 ops 预设）
+}
+
+// SaveDashboardLayoutRequest 保存用户布局
+type SaveDashboardLayoutRequest struct {
+	Preset    string                `json:"preset" binding:"omitempty,oneof=ops management custom" example:"ops"`
+	Scope     string                `json:"scope" binding:"omitempty,oneof=all site cluster" example:"all"`
+	ClusterID *int64                `json:"cluster_id,omitempty" example:"1"`
+	SiteID    *int64                `json:"site_id,omitempty" example:"1"`
+	Widgets   []DashboardLayoutItem `json:"widgets" binding:"required,max=30,dive"`
+}
+
+// DashboardLayoutResponse 布局响应
+type DashboardLayoutResponse struct {
+	Response
+	Data DashboardLayoutData `json:"data"`
+}
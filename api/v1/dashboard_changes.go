@@ -0,0 +1,103 @@
+package v1
+
+import "time"
+
+// 变化概览相关 API 定义
+// GET /api/v1/dashboard/changes 按集群汇总一段时间（默认最近 24 小时）内的变化，适合晨会回顾：
+// - 新建、删除、迁移的虚拟机：来自控制器同步时记录的虚拟机出现（added）/消失（removed）事件，
+//   同一虚拟机在一个节点消失、在另一个节点出现（名称不变）时计为迁移
+// - 配置修改：来自操作审计中成功的 Proxmox 配置调用（.../qemu/{vmid}/config），不含直接在 Proxmox 中的修改
+// - 容量变化：与时间段开始前最近一次容量快照（dashboard.capacity_snapshot）对比，没有快照时不计算差值
+
+// 可选的统计时间段
+const (
+	DashboardChangePeriod24h = "24h"
+	DashboardChangePeriod7d  = "7d"
+	DashboardChangePeriod30d = "30d"
+)
+
+// DashboardChangesRequest 变化概览请求
+type DashboardChangesRequest struct {
+	Period    string     `form:"period" binding:"omitempty,oneof=24h 7d 30d" example:"24h"`                         // 统计时间段，默认 24h
+	Since     *time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00" example:"2026-01-01T08:00:00+08:00"` // 指定时覆盖 period，统计 since 至今的变化
+	ClusterID *int64     `form:"cluster_id" example:"1"`                                                            // 只统计该集群
+}
+
+// DashboardVMChange 新建或删除的虚拟机
+type DashboardVMChange struct {
+	VMID     uint32    `json:"vmid"`
+	VmName   string    `json:"vm_name"`
+	NodeName string    `json:"node_name"`
+	Time     time.Time `json:"time"`
+}
+
+// DashboardVMMigration 迁移到其他节点的虚拟机
+type DashboardVMMigration struct {
+	VMID     uint32    `json:"vmid"`
+	VmName   string    `json:"vm_name"`
+	FromNode string    `json:"from_node"`
+	ToNode   string    `json:"to_node"`
+	Time     time.Time `json:"time"`
+}
+
+// DashboardVMConfigChange 时间段内修改过配置的虚拟机（同一虚拟机的多次修改合并）
+type DashboardVMConfigChange struct {
+	VMID       uint32    `json:"vmid"`
+	VmName     string    `json:"vm_name"`
+	NodeName   string    `json:"node_name"`
+	Count      int       `json:"count"`       // 修改次数
+	Users      []string  `json:"users"`       // 修改人
+	LastChange time.Time `json:"last_change"` // 最近一次修改时间
+}
+
+// DashboardCapacityValue 容量指标的起止值
+type DashboardCapacityValue struct {
+	Before  int64 `json:"before"`
+	Current int64 `json:"current"`
+	Delta   int64 `json:"delta"`
+}
+
+// DashboardCapacityDelta 集群容量变化（不含模板虚拟机）
+type DashboardCapacityDelta struct {
+	BaselineTime *time.Time             `json:"baseline_time"` // 对比的快照时间，为空表示没有可对比的快照（before、delta 为 0）
+	VMs          DashboardCapacityValue `json:"vms"`
+	RunningVMs   DashboardCapacityValue `json:"running_vms"`
+	VCPUs        DashboardCapacityValue `json:"vcpus"`
+	MemoryMB     DashboardCapacityValue `json:"memory_mb"`     // 虚拟机分配的内存
+	DiskMB       DashboardCapacityValue `json:"disk_mb"`       // 虚拟机分配的磁盘
+	StorageUsed  DashboardCapacityValue `json:"storage_used"`  // 存储已用（字节）
+	StorageTotal DashboardCapacityValue `json:"storage_total"` // 存储总量（字节）
+}
+
+// DashboardClusterChanges 单个集群的变化
+type DashboardClusterChanges struct {
+	ClusterID     int64                     `json:"cluster_id"`
+	ClusterName   string                    `json:"cluster_name"`
+	Created       []DashboardVMChange       `json:"created"`
+	Deleted       []DashboardVMChange       `json:"deleted"`
+	Migrations    []DashboardVMMigration    `json:"migrations"`
+	ConfigChanges []DashboardVMConfigChange `json:"config_changes"`
+	Capacity      DashboardCapacityDelta    `json:"capacity"`
+}
+
+// DashboardChangeSummary 所有集群合计
+type DashboardChangeSummary struct {
+	Created       int `json:"created"`
+	Deleted       int `json:"deleted"`
+	Migrated      int `json:"migrated"`
+	ConfigChanged int `json:"config_changed"` // 修改过配置的虚拟机数
+}
+
+// DashboardChangesData 变化概览
+type DashboardChangesData struct {
+	From      time.Time                 `json:"from"`
+	To        time.Time                 `json:"to"`
+	Summary   DashboardChangeSummary    `json:"summary"`
+	Clusters  []DashboardClusterChanges `json:"clusters"`
+	Truncated bool                      `json:"truncated"` // 操作审计记录过多，配置修改只统计了最早的一部分
+}
+
+type DashboardChangesResponse struct {
+	Response
+	Data DashboardChangesData `json:"data"`
+}
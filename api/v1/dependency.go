@@ -0,0 +1,54 @@
+package v1
+
+// 依赖查询对象类型
+const (
+	DependencyTargetStorage  = "storage"
+	DependencyTargetNode     = "node"
+	DependencyTargetTemplate = "template"
+	DependencyTargetSubnet   = "subnet"
+)
+
+// 依赖项类型
+const (
+	DependencyKindVM               = "vm"
+	DependencyKindTemplateInstance = "template_instance"
+	DependencyKindIPAddress        = "ip_address"
+	DependencyKindVMProfile        = "vm_profile"
+	DependencyKindStorage          = "storage"
+)
+
+// DependencyLookupRequest 查询依赖某个存储、节点、模板或网段的资源
+type DependencyLookupRequest struct {
+	Type      string `form:"type" binding:"required,oneof=storage node template subnet" example:"storage"`
+	ID        int64  `form:"id" binding:"required_unless=Type subnet" example:"1"`                        // 存储 / 节点 / 模板 ID，type=subnet 时不需要
+	CIDR      string `form:"cidr" binding:"required_if=Type subnet,omitempty,cidr" example:"10.0.0.0/24"` // type=subnet 时必填
+	ClusterID int64  `form:"cluster_id" example:"1"`                                                      // type=subnet 时只查询该集群，默认全部集群
+}
+
+// DependencyItem 依赖项
+type DependencyItem struct {
+	Kind      string `json:"kind"` // vm / template_instance / ip_address / vm_profile / storage
+	Id        int64  `json:"id"`
+	Name      string `json:"name"`
+	VMID      uint32 `json:"vmid,omitempty"`
+	ClusterID int64  `json:"cluster_id,omitempty"`
+	NodeName  string `json:"node_name,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Owner     string `json:"owner,omitempty"`
+	Detail    string `json:"detail,omitempty"` // 依赖关系说明，如 scsi0 -> local-lvm:vm-101-disk-0
+}
+
+type DependencyLookupData struct {
+	Type     string            `json:"type"`
+	Target   string            `json:"target"` // 查询对象的描述，如 local-lvm@pve-node-1
+	Total    int               `json:"total"`
+	Counts   map[string]int    `json:"counts"` // 按 kind 统计
+	Items    []*DependencyItem `json:"items"`
+	Source   string            `json:"source"` // proxmox：磁盘引用实时读取自 Proxmox；database：只基于平台数据库
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+type DependencyLookupResponse struct {
+	Response
+	Data DependencyLookupData
+}
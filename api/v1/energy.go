@@ -0,0 +1,55 @@
+package v1
+
+// 能耗统计相关 API 定义
+// 节点能耗 = BMC 功率读数 × 采集间隔；虚拟机能耗为估算值：每次采集时节点能耗按运行中虚拟机的 vCPU 数比例分摊，
+// 节点上没有运行中虚拟机时的能耗计为节点空闲能耗（idle_kwh），不分摊到虚拟机。
+
+// 报表分组方式
+const (
+	EnergyGroupByNode       = "node"
+	EnergyGroupByCluster    = "cluster"
+	EnergyGroupByApp        = "app"
+	EnergyGroupByVM         = "vm"
+	EnergyGroupByCostCenter = "cost_center"
+)
+
+// GetEnergyReportRequest 月度能耗报表请求
+type GetEnergyReportRequest struct {
+	Month     string `form:"month" binding:"required" example:"2026-01"` // YYYY-MM
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	NodeID    int64  `form:"node_id" example:"1"`
+	AppId     string `form:"app_id" example:"app-001"`                                                          // 仅 group_by=app / vm / cost_center 时生效
+	GroupBy   string `form:"group_by" binding:"omitempty,oneof=node cluster app vm cost_center" example:"node"` // 默认 node
+}
+
+// EnergyReportItem 能耗报表行，分组字段按 group_by 填充
+type EnergyReportItem struct {
+	ClusterID   int64   `json:"cluster_id,omitempty"`
+	ClusterName string  `json:"cluster_name,omitempty"`
+	NodeID      int64   `json:"node_id,omitempty"`
+	NodeName    string  `json:"node_name,omitempty"`
+	AppId       string  `json:"app_id,omitempty"`
+	CostCenter  string  `json:"cost_center,omitempty"`
+	VmId        int64   `json:"vm_id,omitempty"`
+	VmName      string  `json:"vm_name,omitempty"`
+	EnergyKwh   float64 `json:"energy_kwh"`
+	IdleKwh     float64 `json:"idle_kwh,omitempty"`  // group_by=node / cluster：未分摊到虚拟机的能耗
+	MaxWatts    float64 `json:"max_watts,omitempty"` // group_by=node：峰值功率
+	CarbonKg    float64 `json:"carbon_kg"`           // 按 energy.carbon_intensity 折算的碳排放（kg CO2e），未配置时为 0
+}
+
+// EnergyReportData 月度能耗报表
+type EnergyReportData struct {
+	Month           string             `json:"month"`
+	GroupBy         string             `json:"group_by"`
+	CarbonIntensity float64            `json:"carbon_intensity"` // g CO2e / kWh
+	TotalKwh        float64            `json:"total_kwh"`
+	TotalCarbonKg   float64            `json:"total_carbon_kg"`
+	Items           []EnergyReportItem `json:"items"`
+}
+
+// GetEnergyReportResponse 月度能耗报表响应
+type GetEnergyReportResponse struct {
+	Response
+	Data EnergyReportData
+}
@@ -9,17 +9,306 @@ var (
 	ErrInternalServerError = newError(500, "internal server error")
 
 	// more biz errors
-	ErrEmailAlreadyUse   = newError(1001, "The email is already in use.")
+	ErrEmailAlreadyUse    = newError(1001, "The email is already in use.")
 	ErrUsernameAlreadyUse = newError(1002, "The username is already in use.")
 
 	// vm create mode errors
-	ErrInvalidCreateMode = newError(400, "invalid create mode")
-	
+	ErrInvalidCreateMode = newError(1101, "invalid create mode")
+
 	// template management errors
-	ErrStorageNotFound        = newError(2001, "storage not found")
-	ErrNodeNotFound           = newError(2002, "node not found")
-	ErrFileUploadFailed       = newError(2003, "file upload failed")
-	ErrTemplateImportFailed   = newError(2004, "template import failed")
-	ErrSharedStorageNoSync    = newError(2005, "shared storage does not need sync")
-	ErrInvalidOperation       = newError(2006, "invalid operation")
+	ErrStorageNotFound      = newError(2001, "storage not found")
+	ErrNodeNotFound         = newError(2002, "node not found")
+	ErrFileUploadFailed     = newError(2003, "file upload failed")
+	ErrTemplateImportFailed = newError(2004, "template import failed")
+	ErrSharedStorageNoSync  = newError(2005, "shared storage does not need sync")
+	ErrInvalidOperation     = newError(2006, "invalid operation")
+
+	// vm qos errors
+	ErrInvalidQosLimit      = newError(2101, "invalid qos limit")
+	ErrQosProfileNameExists = newError(2102, "qos profile name already exists")
+
+	// storage gc errors
+	ErrStorageGCScanRunning = newError(2201, "storage gc scan is already running for this cluster")
+
+	// vm list view errors
+	ErrVMListViewNameExists = newError(2301, "vm list view name already exists")
+	ErrVMListViewForbidden  = newError(2302, "only the owner can modify this vm list view")
+
+	// system config errors
+	ErrAdminRequired      = newError(2401, "admin privilege required")
+	ErrInvalidConfigValue = newError(2402, "invalid config value")
+	ErrConfigReloadFailed = newError(2403, "failed to reload config file")
+
+	// resource / vm operation errors（可通过 WithDetail 附加资源 ID 等细节）
+	ErrClusterNotFound           = newError(2501, "cluster not found")
+	ErrTemplateNotFound          = newError(2502, "template not found")
+	ErrTemplateNoInstance        = newError(2503, "template has no available instance")
+	ErrClusterRequired           = newError(2504, "cluster_id or cluster_name is required")
+	ErrNodeRequired              = newError(2505, "node_id, node_name or node_pool_id is required")
+	ErrVMAlreadyExists           = newError(2506, "vm already exists on the node")
+	ErrClusterNotSchedulable     = newError(2507, "cluster is not schedulable")
+	ErrInvalidClusterID          = newError(2508, "invalid cluster id")
+	ErrInvalidNodeID             = newError(2509, "invalid node id")
+	ErrVMAlreadyDestroyed        = newError(2510, "vm has already been destroyed")
+	ErrVMNotStopped              = newError(2511, "vm is not stopped, please stop it first")
+	ErrVMAlreadyRunning          = newError(2512, "vm is already running")
+	ErrVMAlreadyStopped          = newError(2513, "vm is already stopped")
+	ErrProxmoxRequestFailed      = newError(2514, "proxmox request failed")
+	ErrMissingParameter          = newError(2515, "missing required parameter")
+	ErrVMNotFound                = newError(2516, "vm not found")
+	ErrTargetNodeNotInCluster    = newError(2517, "target node is not in the target cluster")
+	ErrMigrateCrossCluster       = newError(2518, "target node is in another cluster, please use remote migration")
+	ErrInvalidClusterAPIURL      = newError(2519, "invalid cluster api url")
+	ErrStorageContentUnsupported = newError(2520, "storage does not support vm disk images")
+	ErrInvalidParameter          = newError(2521, "invalid parameter")
+	ErrCreateVMValidationFailed  = newError(2522, "vm create parameters failed validation against the cluster")
+	ErrBalloonUnsupported        = newError(2523, "guest os type does not support memory ballooning")
+
+	// grafana datasource errors
+	ErrInvalidMetricTarget = newError(2601, "invalid metric target, expected <vm|node>:<id>:<metric>[:AVERAGE|MAX]")
+	ErrUnknownMetric       = newError(2602, "unknown metric")
+
+	// vm stack errors
+	ErrVMStackInvalidSpec       = newError(2701, "invalid vm stack spec")
+	ErrVMStackNameExists        = newError(2702, "vm stack name already exists")
+	ErrVMStackProvisioning      = newError(2703, "vm stack is being provisioned")
+	ErrVMStackNoSchedulableNode = newError(2704, "no schedulable node available for vm stack")
+
+	// site errors
+	ErrSiteNotFound    = newError(2801, "site not found")
+	ErrSiteNameExists  = newError(2802, "site name already exists")
+	ErrSiteHasClusters = newError(2803, "site still has clusters, please move them to another site first")
+
+	// change control errors
+	ErrChangeFrozen             = newError(2901, "change freeze in effect, operation is blocked")
+	ErrOutsideMaintenanceWindow = newError(2902, "operation is outside the allowed maintenance windows")
+	ErrChangeOverrideForbidden  = newError(2903, "only admin users can override change control")
+	ErrInvalidChangeWindow      = newError(2904, "invalid change window")
+
+	// cost accounting errors
+	ErrInvalidReportMonth = newError(3001, "invalid month, expected YYYY-MM")
+	ErrCostBudgetExists   = newError(3002, "budget for this app already exists")
+
+	// node bmc / energy errors
+	ErrNodeBMCNotConfigured = newError(3101, "bmc is not configured for this node")
+	ErrBMCRequestFailed     = newError(3102, "bmc request failed")
+	ErrNodeNotInMaintenance = newError(3103, "node must be unschedulable before power off or cycle")
+	ErrNodeHasRunningVMs    = newError(3104, "node still has running vms, migrate or stop them first")
+
+	// wake-on-lan errors
+	ErrNodeWolNotConfigured = newError(3201, "wake-on-lan mac is not configured for this node")
+	ErrInvalidMACAddress    = newError(3202, "invalid mac address")
+	ErrInvalidWolRelayNode  = newError(3203, "relay node must be another online node in the same cluster")
+	ErrWolSendFailed        = newError(3204, "failed to send wake-on-lan packet")
+
+	// vm import errors
+	ErrVMImportSourceNotFound = newError(3301, "vm import source not found")
+	ErrVMImportSourceExists   = newError(3302, "vm import source already exists")
+	ErrVMImportInvalidVolume  = newError(3303, "volume is not an importable guest")
+	ErrVMImportInvalidSpec    = newError(3304, "invalid vm import spec")
+	ErrVMImportTaskNotFound   = newError(3305, "vm import task not found")
+
+	// image transfer errors
+	ErrObjectStoreNotFound          = newError(3401, "object store not found")
+	ErrObjectStoreExists            = newError(3402, "object store already exists")
+	ErrObjectStoreUnreachable       = newError(3403, "object store request failed")
+	ErrObjectStoreInUse             = newError(3404, "object store has running transfer tasks")
+	ErrTransferStagingNotConfigured = newError(3405, "staging storage has no local directory configured")
+	ErrTransferInvalidObject        = newError(3406, "object is not an exported vm backup")
+	ErrTransferTaskNotFound         = newError(3407, "image transfer task not found")
+
+	// template catalog errors
+	ErrCatalogNotFound        = newError(3501, "template catalog not found")
+	ErrCatalogExists          = newError(3502, "template catalog already exists")
+	ErrCatalogFetchFailed     = newError(3503, "failed to fetch template catalog")
+	ErrCatalogInUse           = newError(3504, "template catalog has running install tasks")
+	ErrCatalogImageNotFound   = newError(3505, "catalog image not found")
+	ErrCatalogInstallNotFound = newError(3506, "catalog install task not found")
+
+	// node version errors
+	ErrMigrationIncompatible = newError(3601, "target node is incompatible with vm for migration")
+
+	// license errors
+	ErrLicenseNotFound           = newError(3701, "license not found")
+	ErrLicenseExists             = newError(3702, "license already exists")
+	ErrLicenseAssignmentExists   = newError(3703, "license is already assigned to this target")
+	ErrLicenseAssignmentNotFound = newError(3704, "license assignment not found")
+	ErrLicenseTargetNotFound     = newError(3705, "license target not found")
+
+	// vm claim errors
+	ErrUnclaimedVMNotFound = newError(3801, "unclaimed vm not found")
+	ErrUnclaimedVMGone     = newError(3802, "vm no longer exists in proxmox")
+	ErrUnclaimedVMNodeSync = newError(3803, "vm node is not synced yet")
+
+	// vm profile errors
+	ErrVMProfileNotFound        = newError(3901, "vm profile not found")
+	ErrVMProfileExists          = newError(3902, "vm profile already exists")
+	ErrVMProfileClusterMismatch = newError(3903, "vm profile does not belong to the cluster")
+
+	// vm storage move errors
+	ErrStorageMoveNotFound   = newError(4001, "storage move task not found")
+	ErrStorageMoveInProgress = newError(4002, "vm already has a storage move task in progress")
+	ErrStorageMoveNoDisks    = newError(4003, "no vm disks need to be moved to the target storage")
+
+	// rebalance errors
+	ErrRebalancePlanNotFound   = newError(4101, "rebalance plan not found")
+	ErrRebalanceNotNeeded      = newError(4102, "cluster load is balanced, no migrations suggested")
+	ErrRebalancePlanNotPending = newError(4103, "rebalance plan is not pending")
+	ErrRebalancePlanExpired    = newError(4104, "rebalance plan is outdated, please recompute")
+	ErrRebalanceInProgress     = newError(4105, "cluster already has a rebalance plan being applied")
+
+	// template deletion errors
+	ErrTemplateSyncInProgress  = newError(4201, "template has sync tasks in progress, cancel them first")
+	ErrTemplateHasLinkedClones = newError(4202, "template still has linked clones")
+
+	// console session errors
+	ErrConsoleSessionNotFound      = newError(4301, "console session not found or expired")
+	ErrConsoleAuditUnavailable     = newError(4302, "console audit record could not be created")
+	ErrConsoleRecordingUnavailable = newError(4303, "console recording is not available")
+	ErrConsoleConnectionLimit      = newError(4304, "too many concurrent console connections")
+
+	// storage browser errors
+	ErrStorageVolumeNotFound     = newError(4401, "volume not found on storage")
+	ErrStorageVolumeInUse        = newError(4402, "volume is referenced by a vm config")
+	ErrStorageDeleteTokenInvalid = newError(4403, "delete confirmation token is invalid or expired")
+
+	// node disk provisioning errors
+	ErrZFSPoolNotFound    = newError(4501, "zfs pool not found on node")
+	ErrInvalidZFSPoolSpec = newError(4502, "invalid zfs pool spec")
+	ErrDiskNotFound       = newError(4503, "disk not found on node")
+	ErrDiskInUse          = newError(4504, "disk is in use, wipe it first")
+	ErrInvalidLVMSpec     = newError(4505, "invalid lvm spec")
+
+	// notification errors
+	ErrNotificationNotFound = newError(4601, "notification not found")
+
+	// vm network diagnostics errors
+	ErrInvalidDiagTarget = newError(4701, "invalid diagnostic target")
+
+	// mac address registry errors
+	ErrMACAddressInUse    = newError(4801, "mac address is already registered")
+	ErrMACAddressNotFound = newError(4802, "mac address not found")
+	ErrMACPoolExhausted   = newError(4803, "failed to generate a unique mac address")
+
+	// proxmox access management errors
+	ErrInvalidPveUserID = newError(4901, "invalid proxmox user id")
+	ErrPveTokenExists   = newError(4902, "proxmox api token already exists")
+	ErrPveLoginFailed   = newError(4903, "proxmox login failed")
+
+	// vm maintenance lock errors
+	ErrVMLocked      = newError(5001, "vm is locked for maintenance")
+	ErrVMLockNotHeld = newError(5002, "vm lock is held by another user")
+
+	// proxmox vm lock errors
+	ErrVMProxmoxLocked = newError(5101, "vm is locked by a running proxmox task")
+
+	// pending operation errors
+	ErrOperationQueued          = newError(5201, "proxmox cluster is unreachable, operation queued for retry")
+	ErrPendingOperationNotFound = newError(5202, "pending operation not found")
+	ErrPendingOperationFinished = newError(5203, "pending operation is no longer pending")
+
+	// vm credential errors
+	ErrSecretBackendUnavailable = newError(5301, "secret backend unavailable")
+	ErrVMCredentialNotFound     = newError(5302, "vm has no stored credentials")
+	ErrVMCredentialLeaseInvalid = newError(5303, "credential lease is invalid or expired")
+	ErrVMCredentialForbidden    = newError(5304, "only the vm owner, creator or admins can read credentials")
+
+	// boot order policy errors
+	ErrBootOrderPolicyNotFound = newError(5401, "boot order policy not found")
+	ErrBootOrderPolicyExists   = newError(5402, "boot order policy for this tag already exists")
+
+	// vm create job errors
+	ErrVMCreateJobNotFound = newError(5501, "vm create job not found")
+
+	// operation audit errors
+	ErrOperationAuditNotFound = newError(5601, "operation not found")
+
+	// node pool errors
+	ErrNodePoolNotFound        = newError(5701, "node pool not found")
+	ErrNodePoolExists          = newError(5702, "node pool already exists")
+	ErrNodePoolClusterMismatch = newError(5703, "node pool does not belong to the cluster")
+	ErrNodePoolNoCapacity      = newError(5704, "no node in the node pool can host the vm")
+	ErrNodePoolQuotaExceeded   = newError(5705, "node pool quota exceeded")
+
+	// template promotion errors
+	ErrTemplatePromotionNotFound    = newError(5801, "template promotion not found")
+	ErrTemplatePromotionStage       = newError(5802, "target cluster is not the next stage of the template's cluster")
+	ErrTemplatePromotionProvenance  = newError(5803, "template was not promoted from the previous stage")
+	ErrTemplatePromotionInProgress  = newError(5804, "template already has a promotion to this cluster in progress")
+	ErrTemplatePromotionNotPending  = newError(5805, "template promotion is not pending approval")
+	ErrTemplatePromotionSelfApprove = newError(5806, "requester cannot approve their own promotion")
+
+	// metadata backup errors
+	ErrMetadataBundleInvalid  = newError(5901, "invalid metadata bundle")
+	ErrMetadataBundleVersion  = newError(5902, "unsupported metadata bundle version")
+	ErrMetadataPassphrase     = newError(5903, "wrong passphrase for metadata bundle")
+	ErrMetadataTargetNotEmpty = newError(5904, "management database is not empty, import requires a fresh instance")
+
+	// vmid range errors
+	ErrVMIDRangeNotFound  = newError(6001, "vmid range not found")
+	ErrVMIDRangeInvalid   = newError(6002, "invalid vmid range")
+	ErrVMIDRangeOverlap   = newError(6003, "vmid range overlaps an existing range")
+	ErrVMIDRangeExhausted = newError(6004, "no free vmid in the range")
+	ErrVMIDReservedByTeam = newError(6005, "vmid belongs to another team's range")
+	ErrVMIDQuarantined    = newError(6006, "vmid was released recently and is quarantined")
+
+	// guest agent install errors
+	ErrAgentInstallNotFound      = newError(6101, "guest agent installation not found")
+	ErrAgentInstallInProgress    = newError(6102, "guest agent installation already in progress for this vm")
+	ErrAgentInstallFinished      = newError(6103, "guest agent installation already finished")
+	ErrGuestAgentRunning         = newError(6104, "guest agent is already running")
+	ErrAgentInstallSourceMissing = newError(6105, "no installer volume configured for this method")
+	ErrCloudInitDriveMissing     = newError(6106, "vm has no cloud-init drive")
+	ErrNoFreeCDROMSlot           = newError(6107, "no free ide/sata slot to attach the installer iso")
+
+	// cluster endpoint errors
+	ErrClusterEndpointCheckFailed = newError(6201, "cluster endpoint verification failed")
+	ErrNodeCertificateChanged     = newError(6202, "target node certificate changed, re-verify the cluster endpoint")
+	ErrNodeCertificateUnavailable = newError(6203, "unable to get target node certificate fingerprint")
+
+	// remote migration errors
+	ErrRemoteMigratePreflightFailed       = newError(6211, "remote migration pre-flight check failed")
+	ErrMigrationWindowNotFound            = newError(6212, "migration window not found")
+	ErrRemoteMigrationJobNotFound         = newError(6213, "remote migration job not found")
+	ErrRemoteMigrationJobFinished         = newError(6214, "remote migration job is no longer scheduled")
+	ErrRemoteMigrationCutoverNotFound     = newError(6215, "remote migration cutover not found")
+	ErrRemoteMigrationCutoverNotRetryable = newError(6216, "only cutovers that failed after a successful migration can be retried")
+
+	// storage forecast errors
+	ErrStorageSpaceInsufficient = newError(6221, "target storage does not have enough space for the operation")
+
+	// vm bulk delete errors
+	ErrVMBulkDeleteNoTargets    = newError(6231, "no vm in the selection can be deleted")
+	ErrVMBulkDeleteTokenInvalid = newError(6232, "bulk delete confirmation token is invalid or expired")
+	ErrVMBulkDeleteConfirmText  = newError(6233, "confirmation text does not match")
+	ErrVMBulkDeleteJobNotFound  = newError(6234, "vm bulk delete job not found")
+
+	// vm protection errors
+	ErrVMProtected = newError(6241, "vm is protected against delete, stop and migrate")
+
+	// vm notes errors
+	ErrVMNotesInvalid  = newError(6251, "vm notes are not valid")
+	ErrVMNotesConflict = newError(6252, "vm notes were changed in proxmox, reload and try again")
+
+	// report errors
+	ErrReportScheduleNotFound = newError(6261, "report schedule not found")
+	ErrReportScheduleInvalid  = newError(6262, "report schedule is not valid")
+	ErrReportScheduleExists   = newError(6263, "report schedule name already exists")
+	ErrReportRunNotFound      = newError(6264, "report run not found")
+
+	// vm hibernation errors
+	ErrVMNotHibernated       = newError(6271, "vm is not hibernated")
+	ErrVMStateStorageInvalid = newError(6272, "vm state storage is not valid")
+
+	// first boot hook errors
+	ErrFirstBootHookNotFound = newError(6281, "first boot hook not found")
+	ErrFirstBootHookInvalid  = newError(6282, "first boot hook is not valid")
+	ErrFirstBootRunNotFound  = newError(6283, "first boot run not found")
+	ErrFirstBootRunActive    = newError(6284, "first boot run is still in progress")
+
+	// content replication errors
+	ErrContentReplicationPolicyNotFound = newError(6291, "content replication policy not found")
+	ErrContentReplicationPolicyInvalid  = newError(6292, "content replication policy is not valid")
+	ErrContentReplicationPolicyExists   = newError(6293, "content replication policy name already exists")
+	ErrContentReplicationSourceFailed   = newError(6294, "failed to read content replication source storage")
 )
@@ -0,0 +1,144 @@
+package v1
+
+import "time"
+
+// 模板首次启动钩子相关 API 定义
+// 模板可以配置一个 webhook 或服务端脚本（first_boot.scripts 中登记的脚本名），从该模板创建的虚拟机
+// 首次 guest agent 上线后，PveSphere 调用一次并传入虚拟机元数据和 IP，用于自动登记到 CMDB、监控等系统。
+// - webhook：以 JSON POST 推送，配置了 secret 时在 X-PveSphere-Signature 头中携带 sha256=<HMAC-SHA256 十六进制>
+// - script：以 JSON 作为标准输入执行脚本，退出码为 0 视为成功
+// 调用失败按 first_boot.retry_interval 重试，超过 first_boot.max_attempts 次或等待 agent 超过 first_boot.agent_timeout 后标记为 failed
+
+// 首次启动钩子类型
+const (
+	FirstBootHookTypeWebhook = "webhook"
+	FirstBootHookTypeScript  = "script"
+)
+
+// FirstBootEvent 首次启动钩子推送的事件名
+const FirstBootEvent = "vm_first_boot"
+
+// SaveFirstBootHookRequest 创建或替换模板的首次启动钩子
+type SaveFirstBootHookRequest struct {
+	Type        string `json:"type" binding:"required,oneof=webhook script" example:"webhook"`
+	URL         string `json:"url" binding:"omitempty,max=500" example:"https://cmdb.example.com/hooks/pvesphere"` // type=webhook 时必填，http 或 https
+	Secret      string `json:"secret" binding:"max=255"`                                                           // webhook 签名密钥，为空时保留原密钥
+	ClearSecret bool   `json:"clear_secret"`                                                                       // 清除原签名密钥，不再签名
+	Script      string `json:"script" binding:"omitempty,max=100" example:"register-cmdb"`                         // type=script 时必填
+	Enabled     *bool  `json:"enabled" example:"true"`                                                             // 默认 true
+	Description string `json:"description" binding:"max=500"`
+}
+
+// FirstBootHookItem 首次启动钩子
+type FirstBootHookItem struct {
+	Id          int64     `json:"id"`
+	TemplateID  int64     `json:"template_id"`
+	Type        string    `json:"type"`
+	URL         string    `json:"url,omitempty"`
+	HasSecret   bool      `json:"has_secret"` // 是否配置了签名密钥，密钥本身不返回
+	Script      string    `json:"script,omitempty"`
+	Enabled     bool      `json:"enabled"`
+	Description string    `json:"description"`
+	Creator     string    `json:"creator"`
+	Modifier    string    `json:"modifier"`
+	CreateTime  time.Time `json:"create_time"`
+	UpdateTime  time.Time `json:"update_time"`
+}
+
+type FirstBootHookResponse struct {
+	Response
+	Data FirstBootHookItem
+}
+
+// FirstBootPayload 调用钩子时传入的数据
+type FirstBootPayload struct {
+	Event           string                   `json:"event"` // vm_first_boot
+	RunID           int64                    `json:"run_id"`
+	VM              FirstBootVMInfo          `json:"vm"`
+	Template        FirstBootTemplateInfo    `json:"template"`
+	IPAddresses     []string                 `json:"ip_addresses"` // agent 上报的 IP，不含回环和链路本地地址
+	Interfaces      []FirstBootInterfaceInfo `json:"interfaces"`
+	OS              string                   `json:"os,omitempty"` // agent 上报的 pretty-name
+	AgentOnlineTime time.Time                `json:"agent_online_time"`
+}
+
+// FirstBootVMInfo 虚拟机元数据
+type FirstBootVMInfo struct {
+	Id              int64  `json:"id"`
+	VMID            uint32 `json:"vmid"`
+	Name            string `json:"name"`
+	ClusterID       int64  `json:"cluster_id"`
+	ClusterName     string `json:"cluster_name"`
+	NodeName        string `json:"node_name"`
+	CPUNum          int    `json:"cpu_num"`
+	MemoryMB        int    `json:"memory_mb"`
+	AppId           string `json:"app_id,omitempty"`
+	Owner           string `json:"owner,omitempty"`
+	Team            string `json:"team,omitempty"`
+	Contact         string `json:"contact,omitempty"`
+	BusinessService string `json:"business_service,omitempty"`
+	Environment     string `json:"environment,omitempty"`
+	CostCenter      string `json:"cost_center,omitempty"`
+	Creator         string `json:"creator,omitempty"`
+}
+
+// FirstBootTemplateInfo 虚拟机所用模板
+type FirstBootTemplateInfo struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// FirstBootInterfaceInfo agent 上报的网卡
+type FirstBootInterfaceInfo struct {
+	Name        string   `json:"name"`
+	MAC         string   `json:"mac"`
+	IPAddresses []string `json:"ip_addresses"`
+}
+
+// FirstBootRunItem 首次启动钩子执行记录
+type FirstBootRunItem struct {
+	Id              int64      `json:"id"`
+	HookID          int64      `json:"hook_id"`
+	TemplateID      int64      `json:"template_id"`
+	ClusterID       int64      `json:"cluster_id"`
+	VmId            int64      `json:"vm_id"`
+	VMID            uint32     `json:"vmid"`
+	VmName          string     `json:"vm_name"`
+	Type            string     `json:"type"`
+	Target          string     `json:"target"`  // webhook 地址或脚本名
+	Status          string     `json:"status"`  // waiting / retrying / succeeded / failed
+	Message         string     `json:"message"` // 最近一次调用结果或失败原因
+	IPAddresses     []string   `json:"ip_addresses"`
+	Checks          int        `json:"checks"`   // 已 ping agent 次数
+	Attempts        int        `json:"attempts"` // 已调用钩子次数
+	AgentOnlineTime *time.Time `json:"agent_online_time"`
+	NextAttemptTime *time.Time `json:"next_attempt_time"`
+	FinishTime      *time.Time `json:"finish_time"`
+	Creator         string     `json:"creator"`
+	CreateTime      time.Time  `json:"create_time"`
+	UpdateTime      time.Time  `json:"update_time"`
+}
+
+type FirstBootRunResponse struct {
+	Response
+	Data FirstBootRunItem
+}
+
+// ListFirstBootRunsRequest 查询首次启动钩子执行记录
+type ListFirstBootRunsRequest struct {
+	Page       int    `form:"page" example:"1"`
+	PageSize   int    `form:"page_size" binding:"omitempty,max=100" example:"20"`
+	ClusterID  int64  `form:"cluster_id" example:"1"`
+	TemplateID int64  `form:"template_id" example:"1"`
+	Status     string `form:"status" binding:"omitempty,oneof=waiting retrying succeeded failed" example:"failed"`
+}
+
+type ListFirstBootRunsResponseData struct {
+	Total int64              `json:"total"`
+	List  []FirstBootRunItem `json:"list"`
+}
+
+type ListFirstBootRunsResponse struct {
+	Response
+	Data ListFirstBootRunsResponseData
+}
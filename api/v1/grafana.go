@@ -0,0 +1,52 @@
+package v1
+
+import "time"
+
+// Grafana JSON 数据源相关 API 定义
+// 接口遵循 Grafana SimpleJSON / JSON 数据源协议（/search、/query、/annotations），
+// 请求与响应不经过统一的 Response 包装，Grafana 可直接解析。
+//
+// 指标 target 格式：<kind>:<id>:<metric>[:<cf>]
+//   - kind：vm 或 node
+//   - id：虚拟机 / 节点的数据库 ID
+//   - metric：Proxmox RRD 字段，如 cpu、mem、netin
+//   - cf：聚合函数 AVERAGE（默认）或 MAX
+// 例如 vm:12:cpu、node:3:loadavg:MAX
+
+// GrafanaSearchRequest 指标搜索请求（Grafana 查询编辑器的指标下拉框）
+type GrafanaSearchRequest struct {
+	Target string `json:"target" example:"web"` // 关键字，匹配虚拟机/节点名称或指标名
+}
+
+// GrafanaSearchItem 指标搜索结果项，Text 用于显示，Value 为 target
+type GrafanaSearchItem struct {
+	Text  string `json:"text" example:"vm web-01 cpu"`
+	Value string `json:"value" example:"vm:12:cpu"`
+}
+
+// GrafanaQueryRequest 时序数据查询请求
+type GrafanaQueryRequest struct {
+	Range         GrafanaTimeRange     `json:"range"`
+	IntervalMs    int64                `json:"intervalMs" example:"60000"`
+	MaxDataPoints int                  `json:"maxDataPoints" example:"500"`
+	Targets       []GrafanaQueryTarget `json:"targets"`
+}
+
+type GrafanaTimeRange struct {
+	From time.Time `json:"from" example:"2025-01-01T00:00:00Z"`
+	To   time.Time `json:"to" example:"2025-01-01T06:00:00Z"`
+}
+
+type GrafanaQueryTarget struct {
+	RefID  string `json:"refId" example:"A"`
+	Target string `json:"target" example:"vm:12:cpu"`
+	Type   string `json:"type,omitempty" example:"timeserie"`
+	Hide   bool   `json:"hide,omitempty"`
+}
+
+// GrafanaTimeSeries 时序数据，Datapoints 每项为 [value, 毫秒时间戳]
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target" example:"vm web-01 cpu"`
+	RefID      string       `json:"refId,omitempty" example:"A"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// 错误信息国际化：错误码保持不变，message 根据 Accept-Language 返回对应语言
+// en-US 文案即 newError 注册时的 msg，其他语言在 messageCatalog 中按错误码维护
+
+const (
+	LangEnUS = "en-US"
+	LangZhCN = "zh-CN"
+)
+
+// DefaultLanguage 请求未携带 Accept-Language（或无法匹配）时使用的语言
+var DefaultLanguage = LangEnUS
+
+// supportedLanguages 顺序与 languageMatcher 的候选顺序一致
+var supportedLanguages = []string{LangEnUS, LangZhCN}
+
+var languageMatcher = language.NewMatcher([]language.Tag{
+	language.AmericanEnglish,
+	language.SimplifiedChinese,
+})
+
+// unknownErrorMessages 未注册错误的提示
+var unknownErrorMessages = map[string]string{
+	LangEnUS: "unknown error",
+	LangZhCN: "未知错误",
+}
+
+// SupportedLanguage 判断是否为支持的语言
+func SupportedLanguage(lang string) bool {
+	for _, l := range supportedLanguages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// NegotiateLanguage 根据 Accept-Language 头选择语言（如 "zh-CN,zh;q=0.9,en;q=0.8" -> zh-CN）
+func NegotiateLanguage(acceptLanguage string) string {
+	if acceptLanguage == "" {
+		return DefaultLanguage
+	}
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+	_, index, confidence := languageMatcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+	return supportedLanguages[index]
+}
+
+// RequestLanguage 返回本次请求使用的语言，?lang= 参数优先于 Accept-Language
+func RequestLanguage(ctx *gin.Context) string {
+	if lang := ctx.Query("lang"); SupportedLanguage(lang) {
+		return lang
+	}
+	return NegotiateLanguage(ctx.GetHeader("Accept-Language"))
+}
+
+// DetailError 在已注册错误的基础上附加细节（如资源 ID、Proxmox 返回的错误），错误码与基础错误一致
+type DetailError struct {
+	Err    error
+	Detail string
+}
+
+func (e *DetailError) Error() string {
+	return e.Err.Error() + ": " + e.Detail
+}
+
+func (e *DetailError) Unwrap() error {
+	return e.Err
+}
+
+// WithDetail 为错误附加细节
+func WithDetail(err error, detail string) error {
+	return &DetailError{Err: err, Detail: detail}
+}
+
+// WithDetailf 为错误附加格式化的细节
+func WithDetailf(err error, format string, args ...interface{}) error {
+	return &DetailError{Err: err, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Localize 返回错误对应的错误码和指定语言的提示信息，未注册的错误返回 ok=false
+func Localize(err error, lang string) (code int, message string, ok bool) {
+	var detail string
+	var de *DetailError
+	if errors.As(err, &de) {
+		detail = de.Detail
+		err = de.Err
+	}
+
+	code, ok = errorCodeMap[err]
+	if !ok {
+		msg, found := unknownErrorMessages[lang]
+		if !found {
+			msg = unknownErrorMessages[LangEnUS]
+		}
+		return 500, msg, false
+	}
+
+	message = err.Error()
+	if msgs, found := messageCatalog[lang]; found {
+		if msg, found := msgs[code]; found {
+			message = msg
+		}
+	}
+	if detail != "" {
+		message += ": " + detail
+	}
+	return code, message, true
+}
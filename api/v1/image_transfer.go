@@ -0,0 +1,137 @@
+package v1
+
+import "time"
+
+// 镜像传输相关 API 定义
+// 以 vzdump 备份为传输单位：导出时备份到中转存储后分段上传到 S3 兼容对象存储，并写入带 SHA256 的清单；
+// 导入时分段下载到中转存储、按清单校验后恢复为虚拟机。可用于无网络互通的站点间迁移虚拟机。
+// 中转存储需为节点与本服务共享的目录类存储（如 NFS），通过配置 image_transfer.staging_dirs 指定本地挂载目录。
+
+// CreateObjectStoreRequest 添加对象存储请求
+type CreateObjectStoreRequest struct {
+	Name           string `json:"name" binding:"required" example:"site-a-minio"`
+	Endpoint       string `json:"endpoint" binding:"required" example:"https://minio.example.com:9000"`
+	Region         string `json:"region,omitempty" example:"us-east-1"`
+	Bucket         string `json:"bucket" binding:"required" example:"pve-transfer"`
+	Prefix         string `json:"prefix,omitempty" example:"exports"` // 对象键前缀
+	AccessKey      string `json:"access_key" binding:"required" example:"minioadmin"`
+	SecretKey      string `json:"secret_key" binding:"required" example:"minioadmin"`
+	PathStyle      *int8  `json:"path_style,omitempty" example:"1"` // 是否使用 path-style 访问，默认 1（MinIO、Ceph RGW 需开启）
+	SkipCertVerify int8   `json:"skip_cert_verify,omitempty" example:"0"`
+}
+
+// ObjectStoreItem 对象存储信息（不返回 secret_key）
+type ObjectStoreItem struct {
+	Id             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Endpoint       string    `json:"endpoint"`
+	Region         string    `json:"region"`
+	Bucket         string    `json:"bucket"`
+	Prefix         string    `json:"prefix"`
+	AccessKey      string    `json:"access_key"`
+	PathStyle      int8      `json:"path_style"`
+	SkipCertVerify int8      `json:"skip_cert_verify"`
+	Creator        string    `json:"creator"`
+	CreateTime     time.Time `json:"create_time"`
+}
+
+// ListObjectStoresResponse 对象存储列表响应
+type ListObjectStoresResponse struct {
+	Response
+	Data []ObjectStoreItem
+}
+
+// ListStoreObjectsRequest 列出对象存储中的备份请求
+type ListStoreObjectsRequest struct {
+	Prefix string `form:"prefix" example:"exports/cluster-a/"` // 在对象存储前缀之下进一步过滤
+}
+
+// StoreObjectItem 对象存储中的虚拟机备份
+type StoreObjectItem struct {
+	Key          string    `json:"key"`
+	Size         int64     `json:"size"`
+	LastModified time.Time `json:"last_modified"`
+	HasManifest  bool      `json:"has_manifest"` // 是否有导出清单（无清单时导入不做 SHA256 校验）
+}
+
+// ListStoreObjectsResponse 备份列表响应
+type ListStoreObjectsResponse struct {
+	Response
+	Data []StoreObjectItem
+}
+
+// CreateImageExportRequest 导出虚拟机到对象存储请求
+type CreateImageExportRequest struct {
+	VmId           int64  `json:"vm_id" binding:"required" example:"1"`                    // 虚拟机数据库ID
+	StoreID        int64  `json:"store_id" binding:"required" example:"1"`                 // 目标对象存储
+	StagingStorage string `json:"staging_storage" binding:"required" example:"backup-nfs"` // 中转存储（需支持 backup 内容）
+	Mode           string `json:"mode,omitempty" binding:"omitempty,oneof=snapshot suspend stop" example:"snapshot"`
+	KeepStaging    bool   `json:"keep_staging,omitempty" example:"false"` // 上传完成后保留中转存储上的备份文件
+}
+
+// CreateImageImportRequest 从对象存储导入虚拟机请求
+type CreateImageImportRequest struct {
+	StoreID        int64  `json:"store_id" binding:"required" example:"1"`
+	ObjectKey      string `json:"object_key" binding:"required" example:"exports/cluster-a/vzdump-qemu-100-2026_01_01-00_00_00.vma.zst"`
+	NodeID         int64  `json:"node_id" binding:"required" example:"1"`                  // 恢复到的节点
+	StagingStorage string `json:"staging_storage" binding:"required" example:"backup-nfs"` // 中转存储（需支持 backup 内容）
+	TargetStorage  string `json:"target_storage" binding:"required" example:"local-lvm"`   // 虚拟机磁盘目标存储
+	VMID           uint32 `json:"vmid,omitempty" example:"10000001"`                       // 不传则自动生成
+	VmName         string `json:"vm_name,omitempty" example:"web"`                         // 不传则保留备份中的名称
+	AppId          string `json:"app_id,omitempty" example:"web"`
+	KeepStaging    bool   `json:"keep_staging,omitempty" example:"false"` // 恢复完成后保留中转存储上的备份文件
+}
+
+// ListImageTransfersRequest 传输任务列表请求
+type ListImageTransfersRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	Direction string `form:"direction" binding:"omitempty,oneof=export import" example:"export"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	Status    string `form:"status" example:"running"`
+}
+
+// ImageTransferItem 传输任务信息
+type ImageTransferItem struct {
+	Id               int64      `json:"id"`
+	Direction        string     `json:"direction"` // export / import
+	StoreID          int64      `json:"store_id"`
+	ObjectKey        string     `json:"object_key"`
+	ClusterID        int64      `json:"cluster_id"`
+	NodeID           int64      `json:"node_id"`
+	NodeName         string     `json:"node_name"`
+	VMID             uint32     `json:"vmid"`
+	VmName           string     `json:"vm_name"`
+	VmId             int64      `json:"vm_id"`
+	StagingStorage   string     `json:"staging_storage"`
+	TargetStorage    string     `json:"target_storage"`
+	Archive          string     `json:"archive"`
+	Status           string     `json:"status"` // pending, running, completed, failed
+	Phase            string     `json:"phase"`  // backup, upload, download, verify, restore
+	TotalBytes       int64      `json:"total_bytes"`
+	TransferredBytes int64      `json:"transferred_bytes"`
+	Progress         int        `json:"progress"` // 0-100
+	Checksum         string     `json:"checksum"` // SHA256
+	ErrorMessage     string     `json:"error_message"`
+	StartTime        *time.Time `json:"start_time"`
+	EndTime          *time.Time `json:"end_time"`
+	Creator          string     `json:"creator"`
+	CreateTime       time.Time  `json:"create_time"`
+}
+
+// GetImageTransferResponse 传输任务详情响应
+type GetImageTransferResponse struct {
+	Response
+	Data ImageTransferItem
+}
+
+// ListImageTransfersResponse 传输任务列表响应
+type ListImageTransfersResponse struct {
+	Response
+	Data ListImageTransfersResponseData
+}
+
+type ListImageTransfersResponseData struct {
+	Total int64               `json:"total"`
+	List  []ImageTransferItem `json:"list"`
+}
@@ -0,0 +1,160 @@
+package v1
+
+import "time"
+
+// 许可证台账相关 API 定义
+// 许可证（密钥或订阅编号）可分配给虚拟机或模板，分配给模板时从该模板创建的虚拟机都计入占用；
+// 虚拟机的操作系统类型由 guest agent 的 get-osinfo 定期采集，用于按操作系统统计实际运行数量，
+// 分配数或实际运行数超过授权数量时记录超用告警。
+
+// CreateLicenseRequest 新增许可证
+type CreateLicenseRequest struct {
+	Name           string     `json:"name" binding:"required,max=100" example:"win2022-dc-2026"`
+	OSType         string     `json:"os_type" binding:"required,max=50" example:"windows"` // 操作系统类型：windows，或 Linux 发行版 id（rhel、sles、ubuntu 等）
+	Product        string     `json:"product" binding:"max=200" example:"Windows Server 2022 Datacenter"`
+	LicenseKey     string     `json:"license_key" binding:"max=500" example:"XXXXX-XXXXX-XXXXX-XXXXX-XXXXX"`
+	SubscriptionID string     `json:"subscription_id" binding:"max=200" example:"RH00003"`
+	Seats          int        `json:"seats" binding:"min=0" example:"10"` // 授权数量，0 表示不限
+	ExpireAt       *time.Time `json:"expire_at,omitempty" example:"2026-12-31T00:00:00Z"`
+	Describes      string     `json:"describes" binding:"max=500" example:"2026 年度 SPLA"`
+}
+
+// UpdateLicenseRequest 更新许可证
+type UpdateLicenseRequest struct {
+	Name           *string    `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	OSType         *string    `json:"os_type,omitempty" binding:"omitempty,min=1,max=50"`
+	Product        *string    `json:"product,omitempty" binding:"omitempty,max=200"`
+	LicenseKey     *string    `json:"license_key,omitempty" binding:"omitempty,max=500"`
+	SubscriptionID *string    `json:"subscription_id,omitempty" binding:"omitempty,max=200"`
+	Seats          *int       `json:"seats,omitempty" binding:"omitempty,min=0"`
+	ExpireAt       *time.Time `json:"expire_at,omitempty"`
+	ClearExpireAt  bool       `json:"clear_expire_at,omitempty"` // 清除到期时间
+	Describes      *string    `json:"describes,omitempty" binding:"omitempty,max=500"`
+}
+
+// ListLicensesRequest 许可证列表查询
+type ListLicensesRequest struct {
+	OSType string `form:"os_type" example:"windows"`
+}
+
+// LicenseItem 许可证
+type LicenseItem struct {
+	Id             int64      `json:"id"`
+	Name           string     `json:"name"`
+	OSType         string     `json:"os_type"`
+	Product        string     `json:"product"`
+	LicenseKey     string     `json:"license_key"` // 脱敏后的密钥，仅保留末 5 位
+	SubscriptionID string     `json:"subscription_id"`
+	Seats          int        `json:"seats"`
+	Consumed       int        `json:"consumed"` // 占用数：直接分配的虚拟机 + 从已分配模板创建的虚拟机
+	OverConsumed   bool       `json:"over_consumed"`
+	Expired        bool       `json:"expired"`
+	ExpireAt       *time.Time `json:"expire_at"`
+	Describes      string     `json:"describes"`
+	Creator        string     `json:"creator"`
+	Modifier       string     `json:"modifier"`
+	CreateTime     time.Time  `json:"create_time"`
+	UpdateTime     time.Time  `json:"update_time"`
+}
+
+type ListLicensesResponseData struct {
+	List []LicenseItem `json:"list"`
+}
+
+// ListLicensesResponse 许可证列表响应
+type ListLicensesResponse struct {
+	Response
+	Data ListLicensesResponseData
+}
+
+// LicenseAssignmentItem 许可证分配记录
+type LicenseAssignmentItem struct {
+	Id         int64     `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetID   int64     `json:"target_id"`
+	TargetName string    `json:"target_name"` // 虚拟机名称或模板名称，对象已删除时为空
+	Consumed   int       `json:"consumed"`    // 该分配占用的数量（模板为从其创建的虚拟机数）
+	Creator    string    `json:"creator"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+// LicenseDetail 许可证详情
+type LicenseDetail struct {
+	LicenseItem
+	Assignments []LicenseAssignmentItem `json:"assignments"`
+}
+
+// GetLicenseResponse 许可证详情响应
+type GetLicenseResponse struct {
+	Response
+	Data LicenseDetail
+}
+
+// LicenseKeyData 许可证完整密钥
+type LicenseKeyData struct {
+	LicenseKey string `json:"license_key"`
+}
+
+// GetLicenseKeyResponse 许可证完整密钥响应
+type GetLicenseKeyResponse struct {
+	Response
+	Data LicenseKeyData
+}
+
+// AssignLicenseRequest 分配许可证
+type AssignLicenseRequest struct {
+	TargetType string `json:"target_type" binding:"required,oneof=vm template" example:"vm"`
+	TargetID   int64  `json:"target_id" binding:"required" example:"1"` // 虚拟机ID 或模板ID
+}
+
+// LicenseUsageItem 按操作系统类型汇总的许可证用量
+type LicenseUsageItem struct {
+	OSType     string `json:"os_type"`
+	Seats      int    `json:"seats"`      // 未过期许可证的授权数量合计
+	Unlimited  bool   `json:"unlimited"`  // 存在不限数量的许可证
+	Assigned   int    `json:"assigned"`   // 分配占用数
+	Detected   int    `json:"detected"`   // guest agent 识别为该操作系统的虚拟机数
+	Unlicensed int    `json:"unlicensed"` // 识别为该操作系统但未分配任何许可证的虚拟机数
+	Over       bool   `json:"over"`       // 分配数或识别数超过授权数量
+}
+
+type LicenseUsageData struct {
+	List []LicenseUsageItem `json:"list"`
+}
+
+// GetLicenseUsageResponse 许可证用量响应
+type GetLicenseUsageResponse struct {
+	Response
+	Data LicenseUsageData
+}
+
+// ListLicenseAlertsRequest 许可证告警查询
+type ListLicenseAlertsRequest struct {
+	Page      int   `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize  int   `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	LicenseID int64 `form:"license_id" example:"1"`
+	Open      bool  `form:"open" example:"true"` // 只返回未恢复的告警
+}
+
+// LicenseAlertItem 许可证超用告警
+type LicenseAlertItem struct {
+	Id          int64      `json:"id"`
+	LicenseID   int64      `json:"license_id"` // 0 表示按操作系统类型汇总的超用
+	LicenseName string     `json:"license_name"`
+	OSType      string     `json:"os_type"`
+	Consumed    int        `json:"consumed"`
+	Seats       int        `json:"seats"`
+	ResolvedAt  *time.Time `json:"resolved_at"`
+	CreateTime  time.Time  `json:"create_time"`
+}
+
+type ListLicenseAlertsResponseData struct {
+	Total int64              `json:"total"`
+	List  []LicenseAlertItem `json:"list"`
+}
+
+// ListLicenseAlertsResponse 许可证告警列表响应
+type ListLicenseAlertsResponse struct {
+	Response
+	Data ListLicenseAlertsResponseData
+}
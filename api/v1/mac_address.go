@@ -0,0 +1,110 @@
+package v1
+
+import "time"
+
+// MAC 地址登记相关 API 定义
+// 平台为虚拟机网卡生成或登记用户指定的 MAC，跨集群保证不重复，并可导出为外部 DHCP 服务器（ISC dhcpd / Kea）的保留配置
+
+// ListMACAddressesRequest 列表查询请求
+type ListMACAddressesRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"20"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	VMId      int64  `form:"vm_id" example:"1"`                                                                       // 平台虚拟机ID
+	Source    string `form:"source" binding:"omitempty,oneof=generated user reserved discovered" example:"generated"` // 来源
+	MAC       string `form:"mac" example:"BC:24:11"`                                                                  // MAC（模糊匹配）
+}
+
+// MACAddressItem MAC 地址登记信息
+type MACAddressItem struct {
+	Id          int64     `json:"id"`
+	MAC         string    `json:"mac"`
+	ClusterID   int64     `json:"cluster_id"`
+	VMId        int64     `json:"vm_id"`
+	VMID        uint32    `json:"vmid"`
+	NicName     string    `json:"nic_name"`
+	Source      string    `json:"source"` // generated / user / reserved / discovered
+	Hostname    string    `json:"hostname"`
+	Description string    `json:"description"`
+	Creator     string    `json:"creator"`
+	CreateTime  time.Time `json:"create_time"`
+	UpdateTime  time.Time `json:"update_time"`
+}
+
+type ListMACAddressesResponseData struct {
+	Total int64            `json:"total"`
+	List  []MACAddressItem `json:"list"`
+}
+
+type ListMACAddressesResponse struct {
+	Response
+	Data ListMACAddressesResponseData
+}
+
+// ReserveMACAddressRequest 预留 MAC 地址（不传 mac 时由平台生成），创建虚拟机时可通过 mac_address 使用预留的地址
+type ReserveMACAddressRequest struct {
+	MAC         string `json:"mac,omitempty" example:"BC:24:11:00:00:01"`
+	ClusterID   int64  `json:"cluster_id,omitempty" example:"1"`
+	Hostname    string `json:"hostname,omitempty" binding:"max=255" example:"vm-001"`
+	Description string `json:"description,omitempty" binding:"max=500" example:"预留给数据库主机"`
+}
+
+type MACAddressResponse struct {
+	Response
+	Data MACAddressItem
+}
+
+// SyncMACAddressesRequest 从 Proxmox 同步虚拟机网卡 MAC
+type SyncMACAddressesRequest struct {
+	ClusterID int64 `json:"cluster_id,omitempty" example:"1"` // 不传则同步全部集群
+}
+
+// MACLocation MAC 在 Proxmox 中的位置
+type MACLocation struct {
+	ClusterID int64  `json:"cluster_id"`
+	Node      string `json:"node,omitempty"`
+	VMID      uint32 `json:"vmid"`
+	VMName    string `json:"vm_name,omitempty"`
+	NicName   string `json:"nic_name,omitempty"`
+}
+
+// MACConflict 重复的 MAC：多台虚拟机使用同一 MAC，或 MAC 已登记在未同步的集群中的其他虚拟机
+type MACConflict struct {
+	MAC       string        `json:"mac"`
+	Locations []MACLocation `json:"locations"`
+}
+
+// SyncMACAddressesData 同步结果
+type SyncMACAddressesData struct {
+	Clusters   int           `json:"clusters"`
+	ScannedVMs int           `json:"scanned_vms"`
+	Discovered int           `json:"discovered"` // 新登记
+	Updated    int           `json:"updated"`    // 位置或关联虚拟机发生变化
+	Removed    int           `json:"removed"`    // 已不存在的同步记录
+	Conflicts  []MACConflict `json:"conflicts"`
+	Errors     []string      `json:"errors"` // 无法访问的集群
+}
+
+type SyncMACAddressesResponse struct {
+	Response
+	Data SyncMACAddressesData
+}
+
+// ExportDHCPReservationsRequest 导出 DHCP 保留配置
+type ExportDHCPReservationsRequest struct {
+	Format     string `form:"format" binding:"omitempty,oneof=isc kea" example:"isc"` // isc（dhcpd.conf host 声明，默认）或 kea（Dhcp4 reservations JSON）
+	ClusterID  int64  `form:"cluster_id" example:"1"`
+	OnlyWithIP bool   `form:"only_with_ip" example:"false"` // 只导出已在 IP 地址表中分配了 IP 的网卡
+}
+
+// KeaReservation Kea Dhcp4 reservations 数组元素
+type KeaReservation struct {
+	HWAddress string `json:"hw-address"`
+	IPAddress string `json:"ip-address,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+}
+
+// KeaReservationsExport Kea 导出格式，可直接合并到 Dhcp4 或 subnet4 配置中
+type KeaReservationsExport struct {
+	Reservations []KeaReservation `json:"reservations"`
+}
@@ -0,0 +1,254 @@
+package v1
+
+// messageCatalog 各语言的错误提示（按错误码索引），缺失时回退到 en-US（newError 注册的文案）
+// 新增错误码时请同步补充对应翻译
+var messageCatalog = map[string]map[int]string{
+	LangZhCN: {
+		0:    "成功",
+		400:  "请求参数错误",
+		401:  "未登录或登录已过期",
+		404:  "资源不存在",
+		500:  "服务器内部错误",
+		1001: "邮箱已被使用",
+		1002: "用户名已被使用",
+		1101: "无效的创建方式",
+		2001: "存储不存在",
+		2002: "节点不存在",
+		2003: "文件上传失败",
+		2004: "模板导入失败",
+		2005: "共享存储无需同步",
+		2006: "无效的操作",
+		2101: "无效的限速配置",
+		2102: "QoS 模板名称已存在",
+		2201: "该集群已有正在进行的存储清理扫描",
+		2301: "视图名称已存在",
+		2302: "只有视图所有者可以修改该视图",
+		2401: "需要管理员权限",
+		2402: "无效的配置值",
+		2403: "重新加载配置文件失败",
+		2501: "集群不存在",
+		2502: "模板不存在",
+		2503: "模板没有可用的模板实例",
+		2504: "必须提供 cluster_id 或 cluster_name",
+		2505: "必须提供 node_id、node_name 或 node_pool_id",
+		2506: "虚拟机在节点上已存在",
+		2507: "集群不可调度",
+		2508: "集群 ID 无效",
+		2509: "节点 ID 无效",
+		2510: "虚拟机已经销毁，请勿重复销毁",
+		2511: "虚拟机未停止，请先停止虚拟机",
+		2512: "虚拟机已在运行中，无需启动",
+		2513: "虚拟机已停止，无需关机",
+		2514: "请求 Proxmox 失败",
+		2515: "缺少必填参数",
+		2516: "虚拟机不存在",
+		2517: "目标节点不在指定的目标集群内",
+		2518: "目标节点不在同一集群内，请使用远程迁移接口",
+		2519: "集群 API URL 格式错误",
+		2520: "存储不支持 VM 磁盘镜像（images），请选择支持 images 的存储（如 local-lvm）",
+		2521: "参数不合法",
+		2522: "虚拟机创建参数未通过集群校验",
+		2523: "该操作系统类型不支持内存 ballooning",
+
+		2601: "指标 target 格式错误，应为 <vm|node>:<id>:<metric>[:AVERAGE|MAX]",
+		2602: "不支持的指标",
+
+		2701: "虚拟机编排配置错误",
+		2702: "虚拟机编排名称已存在",
+		2703: "虚拟机编排正在创建中",
+		2704: "没有可用于虚拟机编排的可调度节点",
+
+		2801: "站点不存在",
+		2802: "站点名称已存在",
+		2803: "站点下仍有集群，请先将集群移出该站点",
+
+		2901: "当前处于封网期，禁止变更",
+		2902: "当前不在允许变更的维护窗口内",
+		2903: "仅管理员可紧急放行变更管控",
+		2904: "变更窗口配置错误",
+
+		3001: "月份格式错误，应为 YYYY-MM",
+		3002: "该应用已配置预算",
+
+		3101: "该节点未配置 BMC",
+		3102: "BMC 请求失败",
+		3103: "断电或重启前需先将节点设置为不可调度",
+		3104: "节点上仍有运行中的虚拟机，请先迁移或关闭",
+
+		3201: "该节点未配置 Wake-on-LAN MAC 地址",
+		3202: "MAC 地址格式错误",
+		3203: "中继节点必须是同一集群内另一个在线节点",
+		3204: "发送唤醒包失败",
+
+		3301: "导入源不存在",
+		3302: "导入源已存在",
+		3303: "该卷不是可导入的虚拟机",
+		3304: "导入参数错误",
+		3305: "导入任务不存在",
+
+		3401: "对象存储不存在",
+		3402: "对象存储已存在",
+		3403: "对象存储请求失败",
+		3404: "对象存储上有进行中的传输任务",
+		3405: "中转存储未配置本地目录",
+		3406: "该对象不是导出的虚拟机备份",
+		3407: "镜像传输任务不存在",
+
+		3501: "模板目录不存在",
+		3502: "模板目录已存在",
+		3503: "获取模板目录失败",
+		3504: "模板目录有进行中的安装任务",
+		3505: "目录镜像不存在",
+		3506: "安装任务不存在",
+
+		3601: "目标节点与虚拟机不兼容，无法迁移",
+
+		3701: "许可证不存在",
+		3702: "许可证已存在",
+		3703: "许可证已分配给该对象",
+		3704: "许可证分配记录不存在",
+		3705: "分配对象不存在",
+
+		3801: "待认领虚拟机不存在",
+		3802: "虚拟机已不在 Proxmox 中",
+		3803: "虚拟机所在节点尚未同步",
+
+		3901: "虚拟机创建规格不存在",
+		3902: "虚拟机创建规格已存在",
+		3903: "虚拟机创建规格不属于该集群",
+
+		4001: "存储迁移任务不存在",
+		4002: "虚拟机已有进行中的存储迁移任务",
+		4003: "虚拟机没有需要移动到目标存储的磁盘",
+
+		4101: "再平衡方案不存在",
+		4102: "集群负载均衡，无需迁移",
+		4103: "再平衡方案不是待执行状态",
+		4104: "再平衡方案已过期，请重新计算",
+		4105: "集群已有正在执行的再平衡方案",
+
+		4201: "模板有进行中的同步任务，请先取消",
+		4202: "模板仍有链接克隆的虚拟机",
+
+		4301: "控制台会话不存在或已过期",
+		4302: "无法记录控制台会话审计信息",
+		4303: "控制台录像不存在或已过期",
+		4304: "控制台并发连接数已达上限",
+
+		4401: "存储上不存在该卷",
+		4402: "该卷仍被虚拟机配置引用",
+		4403: "删除确认令牌无效或已过期",
+
+		4501: "节点上不存在该 ZFS 存储池",
+		4502: "ZFS 存储池参数错误",
+		4503: "节点上不存在该磁盘",
+		4504: "磁盘已被使用，请先擦除磁盘",
+		4505: "LVM 参数错误",
+
+		4601: "通知不存在",
+
+		4701: "诊断目标地址无效",
+
+		4801: "MAC 地址已被登记使用",
+		4802: "MAC 地址不存在",
+		4803: "无法生成不重复的 MAC 地址",
+
+		4901: "Proxmox 用户ID格式错误",
+		4902: "Proxmox API Token 已存在",
+		4903: "Proxmox 登录失败",
+
+		5001: "虚拟机已被锁定维护",
+		5002: "虚拟机维护锁由其他用户持有",
+
+		5101: "虚拟机正在执行 Proxmox 任务（已锁定），请稍后重试",
+
+		5201: "Proxmox 集群暂时不可达，操作已加入重试队列",
+		5202: "待重试操作不存在",
+		5203: "该操作已不在待重试状态",
+
+		5301: "密钥后端不可用",
+		5302: "虚拟机未保存登录凭据",
+		5303: "凭据租约无效或已过期",
+		5304: "只有虚拟机负责人、创建人或管理员可以读取凭据",
+
+		5401: "启动顺序策略不存在",
+		5402: "该标签的启动顺序策略已存在",
+
+		5501: "虚拟机创建任务不存在",
+
+		5601: "操作记录不存在",
+
+		5701: "节点池不存在",
+		5702: "节点池已存在",
+		5703: "节点池不属于该集群",
+		5704: "节点池中没有可容纳该虚拟机的节点",
+		5705: "超出节点池配额",
+
+		5801: "模板晋级记录不存在",
+		5802: "目标集群不是模板所在集群的下一环境",
+		5803: "模板不是从上一环境晋级而来",
+		5804: "该模板已有晋级到此集群的任务在进行中",
+		5805: "模板晋级不在待审批状态",
+		5806: "不能审批自己发起的晋级",
+
+		5901: "元数据包无效",
+		5902: "不支持的元数据包版本",
+		5903: "元数据包口令错误",
+		5904: "管理数据库不为空，只能导入到新实例",
+
+		6001: "VMID 段不存在",
+		6002: "VMID 段无效",
+		6003: "VMID 段与已有的段重叠",
+		6004: "VMID 段中没有可用的 VMID",
+		6005: "VMID 属于其他团队的 VMID 段",
+		6006: "VMID 最近被释放，仍在隔离期内",
+
+		6101: "guest agent 安装记录不存在",
+		6102: "该虚拟机已有进行中的 guest agent 安装",
+		6103: "guest agent 安装已结束",
+		6104: "guest agent 已在运行",
+		6105: "未配置该安装方式的安装介质",
+		6106: "虚拟机没有 cloud-init 驱动器",
+		6107: "没有空闲的 IDE/SATA 插槽用于挂载安装 ISO",
+
+		6201: "集群接入地址验证未通过",
+		6202: "目标节点证书已变更，请重新验证集群接入地址",
+		6203: "无法获取目标节点证书指纹",
+
+		6211: "跨集群迁移预检未通过",
+		6212: "迁移窗口不存在",
+		6213: "计划迁移任务不存在",
+		6214: "计划迁移任务已发起或已取消",
+		6215: "迁移切换记录不存在",
+		6216: "只有迁移成功但切换失败的记录可以重试",
+
+		6221: "目标存储空间不足",
+
+		6231: "所选虚拟机中没有可删除的虚拟机",
+		6232: "批量删除确认令牌无效或已过期",
+		6233: "确认文本不匹配",
+		6234: "批量删除任务不存在",
+
+		6241: "虚拟机已开启删除保护，不能删除、停止或迁移",
+
+		6251: "虚拟机备注格式不正确",
+		6252: "虚拟机备注已在 Proxmox 中修改，请重新读取后再提交",
+
+		6261: "报告计划不存在",
+		6262: "报告计划配置不正确",
+		6263: "报告计划名称已存在",
+		6264: "报告发送记录不存在",
+
+		6271: "虚拟机未处于休眠状态",
+		6272: "虚拟机状态存储不可用",
+
+		6281: "首次启动钩子不存在",
+		6282: "首次启动钩子配置不正确",
+		6283: "首次启动钩子执行记录不存在",
+		6284: "首次启动钩子仍在执行中",
+		6291: "存储内容复制策略不存在",
+		6292: "存储内容复制策略配置不正确",
+		6293: "存储内容复制策略名称已存在",
+		6294: "无法读取存储内容复制策略的源存储",
+	},
+}
@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 元数据备份相关 API 定义
+// 将 PveSphere 自身的元数据（站点、集群、节点、存储、虚拟机、模板、IPAM、策略等）导出为带版本号的 JSON / YAML 包，
+// 用于管理平台自身的灾备：在新实例上导入即可恢复。集群 API Token、虚拟机密码、BMC 密码、对象存储密钥等敏感信息
+// 不以明文出现在包中，而是用导出时提供的口令加密（AES-256-GCM）；导入时用同一口令解密，
+// 再按新实例的配置重新保存（虚拟机密码写入当前 secret.backend，使用新实例的密钥加密）。
+// 用户账号不在导出范围内。
+
+// MetadataBundleVersion 当前元数据包格式版本，导入时拒绝更高版本的包
+const MetadataBundleVersion = 1
+
+// ExportMetadataRequest 导出元数据请求
+type ExportMetadataRequest struct {
+	Passphrase string `json:"passphrase" binding:"required,min=12" example:"correct-horse-battery"` // 加密包内敏感信息的口令，导入时需提供
+	Format     string `json:"format" binding:"omitempty,oneof=json yaml" example:"json"`            // 默认 json
+}
+
+// ImportMetadataRequest 导入元数据请求（multipart/form-data，文件字段为 file）
+type ImportMetadataRequest struct {
+	Passphrase string `form:"passphrase" binding:"required" example:"correct-horse-battery"`
+	DryRun     bool   `form:"dry_run" example:"false"` // 只校验包和口令并返回统计，不写入
+}
+
+// MetadataBundle 元数据包
+type MetadataBundle struct {
+	FormatVersion int                        `json:"format_version"`
+	ExportedAt    time.Time                  `json:"exported_at"`
+	ExportedBy    string                     `json:"exported_by"`
+	Encryption    string                     `json:"encryption"` // 敏感信息的加密方式
+	Counts        map[string]int             `json:"counts"`     // 各部分的记录数
+	Sections      map[string]json.RawMessage `json:"sections"`   // 部分名 -> 记录数组
+	Secrets       []MetadataSecret           `json:"secrets"`
+}
+
+// MetadataSecret 包中加密保存的敏感字段
+type MetadataSecret struct {
+	Section string `json:"section"` // 所属部分，如 clusters
+	ID      int64  `json:"id"`      // 记录 ID
+	Field   string `json:"field"`   // 字段名，如 user_token
+	Value   string `json:"value"`   // 密文
+}
+
+// ImportMetadataResponseData 导入结果
+type ImportMetadataResponseData struct {
+	FormatVersion int            `json:"format_version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	ExportedBy    string         `json:"exported_by"`
+	DryRun        bool           `json:"dry_run"`
+	Counts        map[string]int `json:"counts"`   // 各部分导入（或将导入）的记录数
+	Secrets       int            `json:"secrets"`  // 解密成功的敏感字段数
+	Warnings      []string       `json:"warnings"` // 未能重新保存的虚拟机密码等
+}
+
+// ImportMetadataResponse 导入元数据响应
+type ImportMetadataResponse struct {
+	Response
+	Data ImportMetadataResponseData
+}
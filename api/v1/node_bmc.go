@@ -0,0 +1,119 @@
+package v1
+
+import "time"
+
+// SetNodeBMCRequest 设置节点 BMC 配置（不存在时新增）
+type SetNodeBMCRequest struct {
+	Protocol           string `json:"protocol" binding:"required,oneof=redfish ipmi" example:"redfish"`
+	Address            string `json:"address" binding:"required,max=255" example:"https://10.0.0.101"` // Redfish 为 https://host[:port]，IPMI 为 host[:port]
+	Username           string `json:"username" binding:"max=100" example:"admin"`
+	Password           string `json:"password" binding:"max=255" example:"secret"` // 更新时留空表示不修改
+	InsecureSkipVerify *int8  `json:"insecure_skip_verify,omitempty" example:"1"`  // 默认 1（BMC 普遍使用自签名证书）
+	PowerMonitor       *int8  `json:"power_monitor,omitempty" example:"1"`         // 是否采集功率用于能耗统计，默认 1
+}
+
+// NodeBMCDetail 节点 BMC 配置，不返回密码
+type NodeBMCDetail struct {
+	Id                 int64      `json:"id"`
+	NodeID             int64      `json:"node_id"`
+	ClusterID          int64      `json:"cluster_id"`
+	Protocol           string     `json:"protocol"`
+	Address            string     `json:"address"`
+	Username           string     `json:"username"`
+	HasPassword        bool       `json:"has_password"`
+	InsecureSkipVerify int8       `json:"insecure_skip_verify"`
+	PowerMonitor       int8       `json:"power_monitor"`
+	LastPowerWatts     float64    `json:"last_power_watts"`
+	LastReadingTime    *time.Time `json:"last_reading_time"`
+	LastError          string     `json:"last_error"`
+	Creator            string     `json:"creator"`
+	Modifier           string     `json:"modifier"`
+	CreateTime         time.Time  `json:"create_time"`
+	UpdateTime         time.Time  `json:"update_time"`
+}
+
+// GetNodeBMCResponse 节点 BMC 配置响应
+type GetNodeBMCResponse struct {
+	Response
+	Data NodeBMCDetail
+}
+
+// NodePowerReading 节点实时功率读数
+type NodePowerReading struct {
+	NodeID    int64     `json:"node_id"`
+	Watts     float64   `json:"watts"`
+	ReadingAt time.Time `json:"reading_at"`
+}
+
+// GetNodePowerResponse 节点实时功率响应
+type GetNodePowerResponse struct {
+	Response
+	Data NodePowerReading
+}
+
+// NodePowerActionRequest 节点带外电源操作请求
+// off / cycle 要求节点已设置为不可调度（is_schedulable=0）且没有运行中的虚拟机，force=true 时跳过检查（用于挂死节点）
+type NodePowerActionRequest struct {
+	Action string `json:"action" binding:"required,oneof=on off cycle" example:"cycle"`
+	Force  bool   `json:"force" example:"false"`
+}
+
+// NodePowerState 节点电源状态
+type NodePowerState struct {
+	NodeID     int64  `json:"node_id"`
+	PowerState string `json:"power_state"` // on / off / unknown
+}
+
+// GetNodePowerStateResponse 节点电源状态响应
+type GetNodePowerStateResponse struct {
+	Response
+	Data NodePowerState
+}
+
+// NodeSensor 节点传感器读数
+type NodeSensor struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`    // temperature / fan / voltage / other
+	Reading *float64 `json:"reading"` // 无读数时为空
+	Unit    string   `json:"unit"`
+	Status  string   `json:"status"`
+}
+
+// GetNodeSensorsResponse 节点传感器响应
+type GetNodeSensorsResponse struct {
+	Response
+	Data []NodeSensor
+}
+
+// SetNodeWolRequest 设置节点 Wake-on-LAN MAC 地址请求
+type SetNodeWolRequest struct {
+	MAC string `json:"mac" binding:"required" example:"aa:bb:cc:dd:ee:ff"`
+}
+
+// WakeNodeRequest 唤醒节点请求
+// relay_node_id 为空时由平台直接广播魔术包（要求平台与节点处于同一二层网络），
+// 否则通过同一集群内的另一在线节点（Proxmox wakeonlan 接口）发送
+type WakeNodeRequest struct {
+	RelayNodeID int64 `json:"relay_node_id" example:"2"`
+}
+
+// 唤醒方式
+const (
+	WakeModeDirect = "direct"
+	WakeModeRelay  = "relay"
+)
+
+// NodeWakeResult 唤醒结果
+type NodeWakeResult struct {
+	NodeID    int64     `json:"node_id"`
+	MAC       string    `json:"mac"`
+	Mode      string    `json:"mode"`       // direct / relay
+	RelayNode string    `json:"relay_node"` // 中继节点名称，direct 模式为空
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// WakeNodeResponse 唤醒节点响应
+type WakeNodeResponse struct {
+	Response
+	Data NodeWakeResult
+}
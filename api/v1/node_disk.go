@@ -0,0 +1,90 @@
+package v1
+
+import "time"
+
+// CreateNodeLVMRequest 在未使用的磁盘上创建 LVM 卷组或 LVM-thin 存储池
+type CreateNodeLVMRequest struct {
+	Device     string `json:"device" binding:"required" example:"/dev/sdb"`  // 整盘设备，需未被使用（可先调用 wipedisk 擦除）
+	Name       string `json:"name" binding:"required,max=64" example:"data"` // 卷组名；LVM-thin 时同时作为 thin pool 名
+	AddStorage bool   `json:"add_storage" example:"true"`                    // 同时在集群中添加同名的 lvm / lvmthin 存储
+}
+
+// GetNodeDiskSMARTRequest 实时读取磁盘 SMART 信息
+type GetNodeDiskSMARTRequest struct {
+	Disk string `form:"disk" binding:"required" example:"/dev/sda"` // 磁盘设备名
+}
+
+// SMARTAttribute ATA 磁盘的 SMART 属性
+type SMARTAttribute struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Value     string `json:"value"`
+	Worst     string `json:"worst"`
+	Threshold string `json:"threshold"`
+	Raw       string `json:"raw"`
+	Flags     string `json:"flags"`
+	Fail      string `json:"fail"` // 非空表示该属性已低于阈值
+}
+
+// NodeDiskSMART 磁盘 SMART 信息及提取出的关键指标
+type NodeDiskSMART struct {
+	NodeID             int64            `json:"node_id"`
+	NodeName           string           `json:"node_name"`
+	Disk               string           `json:"disk"`
+	Health             string           `json:"health"` // PASSED / FAILED / UNKNOWN
+	Type               string           `json:"type"`   // ata 返回属性表，text（NVMe / SAS）返回 smartctl 原始输出
+	Attributes         []SMARTAttribute `json:"attributes,omitempty"`
+	Text               string           `json:"text,omitempty"`
+	Wearout            *int             `json:"wearout"` // SSD 剩余寿命百分比
+	ReallocatedSectors int64            `json:"reallocated_sectors"`
+	PendingSectors     int64            `json:"pending_sectors"`
+	Uncorrectable      int64            `json:"uncorrectable"`
+	PowerOnHours       int64            `json:"power_on_hours"`
+	Temperature        int64            `json:"temperature"`
+}
+
+// GetNodeDiskSMARTResponse 磁盘 SMART 信息响应
+type GetNodeDiskSMARTResponse struct {
+	Response
+	Data NodeDiskSMART
+}
+
+// ListNodeDiskAlertsRequest 磁盘 SMART 告警查询
+type ListNodeDiskAlertsRequest struct {
+	Page      int   `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize  int   `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	ClusterID int64 `form:"cluster_id" example:"1"`
+	NodeID    int64 `form:"node_id" example:"1"`
+	Open      bool  `form:"open" example:"true"` // 只返回未恢复的告警
+}
+
+// NodeDiskAlertItem 磁盘 SMART 告警
+type NodeDiskAlertItem struct {
+	Id                 int64      `json:"id"`
+	ClusterID          int64      `json:"cluster_id"`
+	NodeID             int64      `json:"node_id"`
+	NodeName           string     `json:"node_name"`
+	DevPath            string     `json:"devpath"`
+	Serial             string     `json:"serial"`
+	Model              string     `json:"model"`
+	Reasons            string     `json:"reasons"` // 逗号分隔：smart_failed / reallocated / pending / uncorrectable / wearout
+	Health             string     `json:"health"`
+	Wearout            *int       `json:"wearout"`
+	ReallocatedSectors int64      `json:"reallocated_sectors"`
+	PendingSectors     int64      `json:"pending_sectors"`
+	Uncorrectable      int64      `json:"uncorrectable"`
+	ResolvedAt         *time.Time `json:"resolved_at"`
+	CreateTime         time.Time  `json:"create_time"`
+	UpdateTime         time.Time  `json:"update_time"`
+}
+
+type ListNodeDiskAlertsResponseData struct {
+	Total int64               `json:"total"`
+	List  []NodeDiskAlertItem `json:"list"`
+}
+
+// ListNodeDiskAlertsResponse 磁盘 SMART 告警列表响应
+type ListNodeDiskAlertsResponse struct {
+	Response
+	Data ListNodeDiskAlertsResponseData
+}
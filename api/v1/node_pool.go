@@ -0,0 +1,87 @@
+package v1
+
+import "time"
+
+// 节点池相关 API 定义
+// 节点池将集群内的节点分组（如 gpu-nodes、ssd-nodes、dmz），一个节点可以属于多个节点池。
+// 创建虚拟机时可传 node_pool_id 代替 node_id，由调度器在池内选择节点；
+// 节点池可设置配额（虚拟机数、vCPU、内存），池内节点上的虚拟机合计不能超过配额，0 表示不限制。
+
+// NodePoolQuota 节点池配额，0 表示不限制
+type NodePoolQuota struct {
+	MaxVMs      int   `json:"max_vms" binding:"min=0" example:"50"`
+	MaxCPU      int   `json:"max_cpu" binding:"min=0" example:"200"`          // 池内虚拟机 vCPU 合计
+	MaxMemoryMB int64 `json:"max_memory_mb" binding:"min=0" example:"409600"` // 池内虚拟机内存合计（MB）
+}
+
+// CreateNodePoolRequest 新增节点池
+type CreateNodePoolRequest struct {
+	ClusterID int64   `json:"cluster_id" binding:"required,min=1" example:"1"`
+	Name      string  `json:"name" binding:"required,max=100" example:"gpu-nodes"`
+	Describes string  `json:"describes" binding:"max=500" example:"带 GPU 的计算节点"`
+	NodeIDs   []int64 `json:"node_ids" example:"1,2"` // 成员节点，必须属于该集群
+	NodePoolQuota
+}
+
+// UpdateNodePoolRequest 更新节点池，不传的字段保持不变
+type UpdateNodePoolRequest struct {
+	Name        *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Describes   *string `json:"describes,omitempty" binding:"omitempty,max=500"`
+	NodeIDs     []int64 `json:"node_ids,omitempty"` // 传入时替换全部成员节点，传空数组清空
+	MaxVMs      *int    `json:"max_vms,omitempty" binding:"omitempty,min=0"`
+	MaxCPU      *int    `json:"max_cpu,omitempty" binding:"omitempty,min=0"`
+	MaxMemoryMB *int64  `json:"max_memory_mb,omitempty" binding:"omitempty,min=0"`
+}
+
+// ListNodePoolsRequest 节点池列表查询
+type ListNodePoolsRequest struct {
+	ClusterID int64 `form:"cluster_id" example:"1"`
+	NodeID    int64 `form:"node_id" example:"1"` // 只返回包含该节点的节点池
+}
+
+// NodePoolNode 节点池成员节点
+type NodePoolNode struct {
+	NodeID        int64  `json:"node_id"`
+	NodeName      string `json:"node_name"`
+	Status        string `json:"status"`
+	IsSchedulable bool   `json:"is_schedulable"`
+}
+
+// NodePoolUsage 池内节点上虚拟机的资源合计（不含模板）
+type NodePoolUsage struct {
+	VMs      int   `json:"vms"`
+	CPU      int   `json:"cpu"`
+	MemoryMB int64 `json:"memory_mb"`
+}
+
+// NodePoolItem 节点池
+type NodePoolItem struct {
+	Id          int64          `json:"id"`
+	ClusterID   int64          `json:"cluster_id"`
+	ClusterName string         `json:"cluster_name"`
+	Name        string         `json:"name"`
+	Describes   string         `json:"describes"`
+	Nodes       []NodePoolNode `json:"nodes"`
+	NodePoolQuota
+	Usage      NodePoolUsage `json:"usage"`
+	Creator    string        `json:"creator"`
+	Modifier   string        `json:"modifier"`
+	CreateTime time.Time     `json:"create_time"`
+	UpdateTime time.Time     `json:"update_time"`
+}
+
+type ListNodePoolsResponseData struct {
+	List []NodePoolItem `json:"list"`
+}
+
+// ListNodePoolsResponse 节点池列表响应
+type ListNodePoolsResponse struct {
+	Response
+	Data ListNodePoolsResponseData
+}
+
+// GetNodePoolResponse 节点池详情响应
+type GetNodePoolResponse struct {
+	Response
+	Data NodePoolItem
+}
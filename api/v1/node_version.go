@@ -0,0 +1,79 @@
+package v1
+
+import "time"
+
+// 节点版本矩阵与迁移兼容性相关 API 定义
+// 定期采集各节点的 pve-manager、内核、QEMU 版本及 CPU 型号和指令集，在线迁移前据此检查目标节点是否兼容，
+// 并可为集群设置统一的 CPU 型号基线（如 x86-64-v3），新建虚拟机未指定 CPU 类型时使用，避免因 CPU 指令集差异导致迁移失败。
+
+// NodeVersionItem 节点版本信息
+type NodeVersionItem struct {
+	NodeID        int64             `json:"node_id"`
+	NodeName      string            `json:"node_name"`
+	PveVersion    string            `json:"pve_version"`
+	KernelVersion string            `json:"kernel_version"`
+	QemuVersion   string            `json:"qemu_version"`
+	CPUModel      string            `json:"cpu_model"`
+	CPUVendor     string            `json:"cpu_vendor"`
+	CPULevel      string            `json:"cpu_level"` // 节点 CPU 支持的最高 x86-64 微架构级别，如 x86-64-v3
+	Packages      map[string]string `json:"packages"`  // 关键软件包版本
+	CollectTime   *time.Time        `json:"collect_time"`
+	CollectError  string            `json:"collect_error"`
+}
+
+// NodeVersionMatrix 集群版本矩阵
+type NodeVersionMatrix struct {
+	ClusterID           int64             `json:"cluster_id"`
+	ClusterName         string            `json:"cluster_name"`
+	CPUBaseline         string            `json:"cpu_baseline"`         // 当前设置的 CPU 型号基线
+	RecommendedBaseline string            `json:"recommended_baseline"` // 所有节点都支持的最高 x86-64 级别
+	Mixed               map[string]bool   `json:"mixed"`                // 各维度是否存在不一致：pve_version、kernel_version、qemu_version、cpu_model、cpu_vendor
+	Nodes               []NodeVersionItem `json:"nodes"`
+}
+
+// GetNodeVersionMatrixRequest 版本矩阵请求
+type GetNodeVersionMatrixRequest struct {
+	ClusterID int64 `form:"cluster_id" binding:"required" example:"1"`
+}
+
+// GetNodeVersionMatrixResponse 版本矩阵响应
+type GetNodeVersionMatrixResponse struct {
+	Response
+	Data NodeVersionMatrix
+}
+
+// RefreshNodeVersionsRequest 立即采集集群节点版本请求
+type RefreshNodeVersionsRequest struct {
+	ClusterID int64 `json:"cluster_id" binding:"required" example:"1"`
+}
+
+// MigrationCheckRequest 迁移兼容性检查请求
+type MigrationCheckRequest struct {
+	VmID         int64 `form:"vm_id" binding:"required" example:"1"`          // 虚拟机数据库ID
+	TargetNodeID int64 `form:"target_node_id" binding:"required" example:"2"` // 目标节点ID
+}
+
+// MigrationCheckIssue 兼容性问题
+type MigrationCheckIssue struct {
+	Code    string `json:"code"`    // qemu_downgrade、cpu_host_mismatch、cpu_flags_missing、cpu_vendor_mismatch、pve_major_mismatch、kernel_mismatch、version_unknown
+	Message string `json:"message"` // 问题说明
+}
+
+// MigrationCheckResult 迁移兼容性检查结果
+type MigrationCheckResult struct {
+	VmID       int64                 `json:"vm_id"`
+	VMID       uint32                `json:"vmid"`
+	SourceNode string                `json:"source_node"`
+	TargetNode string                `json:"target_node"`
+	Online     bool                  `json:"online"`     // 是否为在线迁移（仅在线迁移需要检查 QEMU 和 CPU 兼容性）
+	CPUType    string                `json:"cpu_type"`   // 虚拟机配置的 CPU 类型
+	Compatible bool                  `json:"compatible"` // 无阻断问题
+	Blockers   []MigrationCheckIssue `json:"blockers"`   // 会导致迁移失败的问题
+	Warnings   []MigrationCheckIssue `json:"warnings"`   // 不阻断但需要注意的问题
+}
+
+// MigrationCheckResponse 迁移兼容性检查响应
+type MigrationCheckResponse struct {
+	Response
+	Data MigrationCheckResult
+}
@@ -0,0 +1,99 @@
+package v1
+
+import "time"
+
+// CreateZFSPoolRequest 在节点上创建 ZFS 存储池
+type CreateZFSPoolRequest struct {
+	Name        string   `json:"name" binding:"required,max=64" example:"tank"`
+	RaidLevel   string   `json:"raid_level" binding:"required,oneof=single mirror raid10 raidz raidz2 raidz3" example:"mirror"`
+	Devices     []string `json:"devices" binding:"required,min=1" example:"/dev/sdb,/dev/sdc"`                      // 磁盘设备，需为未使用的整盘
+	Ashift      *int     `json:"ashift,omitempty" binding:"omitempty,min=9,max=16" example:"12"`                    // 默认 12（4K 扇区）
+	Compression string   `json:"compression" binding:"omitempty,oneof=on off gzip lz4 lzjb zle zstd" example:"lz4"` // 默认 on
+	AddStorage  bool     `json:"add_storage" example:"true"`                                                        // 同时在集群中添加同名的 zfspool 存储
+}
+
+// ZFSPoolDevice ZFS 存储池设备树节点（vdev 或磁盘）
+type ZFSPoolDevice struct {
+	Name           string          `json:"name"`
+	State          string          `json:"state"`
+	ReadErrors     int64           `json:"read_errors"`
+	WriteErrors    int64           `json:"write_errors"`
+	ChecksumErrors int64           `json:"checksum_errors"`
+	Message        string          `json:"message,omitempty"`
+	Children       []ZFSPoolDevice `json:"children,omitempty"`
+}
+
+// ZFSPoolDetail ZFS 存储池健康状态
+type ZFSPoolDetail struct {
+	NodeID          int64           `json:"node_id"`
+	NodeName        string          `json:"node_name"`
+	Name            string          `json:"name"`
+	State           string          `json:"state"`  // ONLINE / DEGRADED / FAULTED 等
+	Status          string          `json:"status"` // zpool status 给出的问题说明
+	Action          string          `json:"action"` // zpool status 给出的处理建议
+	Errors          string          `json:"errors"` // 数据错误说明，如 No known data errors
+	Scan            string          `json:"scan"`   // 最近一次 scrub / resilver 说明
+	ScrubInProgress bool            `json:"scrub_in_progress"`
+	LastScrubTime   *time.Time      `json:"last_scrub_time"` // 无法从 scan 解析时为空
+	ReadErrors      int64           `json:"read_errors"`     // 所有磁盘的错误计数之和
+	WriteErrors     int64           `json:"write_errors"`
+	ChecksumErrors  int64           `json:"checksum_errors"`
+	Devices         []ZFSPoolDevice `json:"devices"`
+}
+
+// GetZFSPoolResponse ZFS 存储池详情响应
+type GetZFSPoolResponse struct {
+	Response
+	Data ZFSPoolDetail
+}
+
+// ZFSDataset 节点上可用作存储的 ZFS 存储池或数据集
+type ZFSDataset struct {
+	Name string `json:"name"` // 如 rpool/data
+	Pool string `json:"pool"` // 所属存储池
+}
+
+// ListZFSDatasetsResponse ZFS 数据集列表响应
+type ListZFSDatasetsResponse struct {
+	Response
+	Data []ZFSDataset
+}
+
+// ListZFSPoolAlertsRequest ZFS 存储池告警查询
+type ListZFSPoolAlertsRequest struct {
+	Page      int   `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize  int   `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	ClusterID int64 `form:"cluster_id" example:"1"`
+	NodeID    int64 `form:"node_id" example:"1"`
+	Open      bool  `form:"open" example:"true"` // 只返回未恢复的告警
+}
+
+// ZFSPoolAlertItem ZFS 存储池健康告警
+type ZFSPoolAlertItem struct {
+	Id             int64      `json:"id"`
+	ClusterID      int64      `json:"cluster_id"`
+	NodeID         int64      `json:"node_id"`
+	NodeName       string     `json:"node_name"`
+	Pool           string     `json:"pool"`
+	Reasons        string     `json:"reasons"` // 逗号分隔：health / errors / scrub
+	Health         string     `json:"health"`
+	ReadErrors     int64      `json:"read_errors"`
+	WriteErrors    int64      `json:"write_errors"`
+	ChecksumErrors int64      `json:"checksum_errors"`
+	LastScrubTime  *time.Time `json:"last_scrub_time"`
+	Message        string     `json:"message"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+	CreateTime     time.Time  `json:"create_time"`
+	UpdateTime     time.Time  `json:"update_time"`
+}
+
+type ListZFSPoolAlertsResponseData struct {
+	Total int64              `json:"total"`
+	List  []ZFSPoolAlertItem `json:"list"`
+}
+
+// ListZFSPoolAlertsResponse ZFS 存储池告警列表响应
+type ListZFSPoolAlertsResponse struct {
+	Response
+	Data ListZFSPoolAlertsResponseData
+}
@@ -0,0 +1,67 @@
+package v1
+
+import "time"
+
+// ListNotificationsRequest 通知列表查询
+type ListNotificationsRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	Category string `form:"category" binding:"omitempty,oneof=task approval alert report" example:"alert"`
+	Unread   bool   `form:"unread" example:"true"` // 只返回未读通知
+}
+
+// NotificationItem 站内通知
+type NotificationItem struct {
+	Id         int64      `json:"id"`
+	Category   string     `json:"category"` // task / approval / alert / report
+	Level      string     `json:"level"`    // info / warning / critical
+	Event      string     `json:"event"`
+	Title      string     `json:"title"`
+	Content    string     `json:"content"`
+	TargetType string     `json:"target_type"`
+	TargetID   string     `json:"target_id"`
+	Read       bool       `json:"read"`
+	ReadAt     *time.Time `json:"read_at"`
+	CreateTime time.Time  `json:"create_time"`
+}
+
+type ListNotificationsResponseData struct {
+	Total  int64              `json:"total"`
+	Unread int64              `json:"unread"` // 当前用户全部未读数量
+	List   []NotificationItem `json:"list"`
+}
+
+// ListNotificationsResponse 通知列表响应
+type ListNotificationsResponse struct {
+	Response
+	Data ListNotificationsResponseData
+}
+
+// NotificationUnreadCount 未读通知数量
+type NotificationUnreadCount struct {
+	Total      int64            `json:"total" example:"3"`
+	ByCategory map[string]int64 `json:"by_category"` // task / approval / alert / report
+}
+
+// NotificationUnreadCountResponse 未读通知数量响应
+type NotificationUnreadCountResponse struct {
+	Response
+	Data NotificationUnreadCount
+}
+
+// MarkNotificationsReadRequest 标记通知已读，ids 为空时标记全部（可按类别）
+type MarkNotificationsReadRequest struct {
+	IDs      []int64 `json:"ids" binding:"omitempty,max=500" example:"1,2"`
+	Category string  `json:"category" binding:"omitempty,oneof=task approval alert report" example:"alert"`
+}
+
+// MarkNotificationsReadResponse 标记已读响应
+type MarkNotificationsReadResponse struct {
+	Response
+	Data MarkNotificationsReadData
+}
+
+type MarkNotificationsReadData struct {
+	Updated int64                   `json:"updated"` // 本次标记的数量
+	Unread  NotificationUnreadCount `json:"unread"`
+}
@@ -0,0 +1,66 @@
+package v1
+
+import "time"
+
+// 操作审计相关 API 定义
+// 每个变更请求（POST/PUT/PATCH/DELETE）在响应头 X-Operation-Id 中返回操作 ID，
+// 请求期间调用的 Proxmox API 及启动的任务（UPID）可通过 GET /api/v1/operations/{operation_id} 查询，
+// 便于与 Proxmox 任务日志对照
+
+// OperationIDHeader 操作 ID 响应头
+const OperationIDHeader = "X-Operation-Id"
+
+type ListOperationAuditsRequest struct {
+	Page     int    `form:"page" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	Username string `form:"username" example:"admin"` // 仅管理员可查询其他用户的操作
+	Method   string `form:"method" binding:"omitempty,oneof=POST PUT PATCH DELETE" example:"POST"`
+	Path     string `form:"path" example:"/api/v1/vms/create"` // 请求路径前缀
+	UPID     string `form:"upid" example:"UPID:pve-node-1:0000A1B2:..."`
+	HasTasks bool   `form:"has_tasks" example:"true"` // 只返回启动了 Proxmox 任务的操作
+}
+
+// ProxmoxCallItem 一次（或连续多次相同的）Proxmox API 调用
+type ProxmoxCallItem struct {
+	Method     string    `json:"method"`
+	Host       string    `json:"host"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	UPID       string    `json:"upid,omitempty"`
+	Count      int       `json:"count"` // 连续相同调用（如等待任务时轮询状态）合并后的次数
+	StartTime  time.Time `json:"start_time"`
+}
+
+// OperationAuditItem 操作记录，列表中不返回 calls
+type OperationAuditItem struct {
+	Id           int64             `json:"id"`
+	OperationID  string            `json:"operation_id"`
+	Method       string            `json:"method"`
+	Route        string            `json:"route"`
+	Path         string            `json:"path"`
+	StatusCode   int               `json:"status_code"`
+	Username     string            `json:"username"`
+	DurationMs   int64             `json:"duration_ms"`
+	CallCount    int               `json:"call_count"`
+	DroppedCalls int               `json:"dropped_calls"` // 超出记录上限未记录的调用数
+	Tasks        []string          `json:"tasks"`         // 启动的 Proxmox 任务 UPID
+	Calls        []ProxmoxCallItem `json:"calls,omitempty"`
+	CreateTime   time.Time         `json:"create_time"`
+}
+
+type OperationAuditResponse struct {
+	Response
+	Data OperationAuditItem
+}
+
+type ListOperationAuditsResponseData struct {
+	Total int64                `json:"total"`
+	List  []OperationAuditItem `json:"list"`
+}
+
+type ListOperationAuditsResponse struct {
+	Response
+	Data ListOperationAuditsResponseData
+}
@@ -0,0 +1,62 @@
+package v1
+
+import "time"
+
+// 操作系统停止维护（EOL）检测相关 API 定义
+// guest agent 采集的 osinfo 与内置的 EOL 数据库（Ubuntu / Debian / CentOS / RHEL / Windows，可通过 os_eol.rules 补充或覆盖）比对，
+// 结果保存在 vm_os_info 中，虚拟机列表通过 os_eol 过滤并返回 os_eol_status，合规报告按操作系统汇总。
+// 未安装 guest agent 或尚未采集的虚拟机无法识别，计入 unknown
+
+// 操作系统停止维护状态
+const (
+	OSEOLStatusEOL     = "eol"      // 已停止维护
+	OSEOLStatusEOLSoon = "eol_soon" // 将在 90 天内停止维护
+)
+
+// OSEOLReportRequest 合规报告查询
+type OSEOLReportRequest struct {
+	ClusterID int64  `form:"cluster_id" example:"1"`                                      // 只统计该集群，默认全部集群
+	Status    string `form:"status" binding:"omitempty,oneof=eol eol_soon" example:"eol"` // 只列出该状态的虚拟机，默认 eol 和 eol_soon 都列出
+}
+
+// OSEOLReportGroup 按操作系统版本汇总
+type OSEOLReportGroup struct {
+	OSName  string    `json:"os_name"`  // EOL 规则名称，如 Ubuntu 18.04
+	EOLDate time.Time `json:"eol_date"` // 停止维护日期
+	Status  string    `json:"status"`   // eol / eol_soon
+	VMCount int       `json:"vm_count"`
+}
+
+// OSEOLReportVM 运行已停止维护（或即将停止维护）操作系统的虚拟机
+type OSEOLReportVM struct {
+	Id          int64     `json:"id"`
+	VmName      string    `json:"vm_name"`
+	VMID        uint32    `json:"vmid"`
+	ClusterID   int64     `json:"cluster_id"`
+	ClusterName string    `json:"cluster_name"`
+	NodeName    string    `json:"node_name"`
+	Status      string    `json:"status"` // 虚拟机运行状态
+	Owner       string    `json:"owner"`
+	Team        string    `json:"team"`
+	PrettyName  string    `json:"pretty_name"` // guest agent 上报的操作系统名称
+	OSName      string    `json:"os_name"`     // 匹配到的 EOL 规则名称
+	EOLDate     time.Time `json:"eol_date"`
+	EOLStatus   string    `json:"eol_status"` // eol / eol_soon
+	CollectTime time.Time `json:"collect_time"`
+}
+
+type OSEOLReportData struct {
+	GeneratedAt time.Time           `json:"generated_at"`
+	TotalVMs    int                 `json:"total_vms"`  // 统计范围内的虚拟机数量（不含模板）
+	Identified  int                 `json:"identified"` // 已采集到操作系统信息的虚拟机数量
+	Unknown     int                 `json:"unknown"`    // 未采集到操作系统信息的虚拟机数量
+	EOL         int                 `json:"eol"`
+	EOLSoon     int                 `json:"eol_soon"`
+	Groups      []*OSEOLReportGroup `json:"groups"` // 已停止维护的在前，其次按停止维护日期升序
+	VMs         []*OSEOLReportVM    `json:"vms"`    // 按停止维护日期升序
+}
+
+type OSEOLReportResponse struct {
+	Response
+	Data OSEOLReportData
+}
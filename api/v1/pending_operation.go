@@ -0,0 +1,69 @@
+package v1
+
+import "time"
+
+// 待重试操作相关 API 定义
+// 集群暂时不可达时，修改配置、标签和 cloud-init 等操作会加入重试队列，连通后自动重试；
+// 可以查看队列并手动立即重试或取消
+
+// PendingOperationItem 待重试操作
+type PendingOperationItem struct {
+	Id            int64       `json:"id"`
+	ClusterID     int64       `json:"cluster_id"`
+	NodeID        int64       `json:"node_id"`
+	VmId          int64       `json:"vm_id"`
+	VMID          uint32      `json:"vmid"`
+	VmName        string      `json:"vm_name"`
+	Operation     string      `json:"operation"` // vm.config / vm.tags / vm.cloudinit
+	Payload       interface{} `json:"payload"`
+	Status        string      `json:"status"` // pending / running / succeeded / failed / cancelled
+	Attempts      int         `json:"attempts"`
+	LastError     string      `json:"last_error"`
+	NextRetryTime *time.Time  `json:"next_retry_time"`
+	FinishTime    *time.Time  `json:"finish_time"`
+	Creator       string      `json:"creator"`
+	CreateTime    time.Time   `json:"create_time"`
+	UpdateTime    time.Time   `json:"update_time"`
+}
+
+type PendingOperationResponse struct {
+	Response
+	Data PendingOperationItem
+}
+
+// ListPendingOperationsRequest 查询待重试操作
+type ListPendingOperationsRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"20"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	VmId      int64  `form:"vm_id" example:"1"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending running succeeded failed cancelled" example:"pending"`
+}
+
+type ListPendingOperationsResponseData struct {
+	Total int64                  `json:"total"`
+	List  []PendingOperationItem `json:"list"`
+}
+
+type ListPendingOperationsResponse struct {
+	Response
+	Data ListPendingOperationsResponseData
+}
+
+// FlushPendingOperationsRequest 立即重试集群上的待重试操作
+type FlushPendingOperationsRequest struct {
+	ClusterID int64 `json:"cluster_id" binding:"required" example:"1"`
+}
+
+// FlushPendingOperationsData 立即重试结果
+type FlushPendingOperationsData struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Pending   int `json:"pending"` // 仍不可达或被锁、变更窗口推迟，继续等待重试
+	Failed    int `json:"failed"`
+}
+
+type FlushPendingOperationsResponse struct {
+	Response
+	Data FlushPendingOperationsData
+}
@@ -0,0 +1,36 @@
+package v1
+
+import "time"
+
+// 创建资源预留相关 API 定义
+// 创建虚拟机期间按节点预留 CPU、内存和磁盘空间，同一节点上并发的创建请求在容量校验时会计入彼此的预留，
+// 避免各自校验通过但合计超出节点容量；创建结束（成功或失败）后释放
+
+type ListProvisionReservationsRequest struct {
+	ClusterID int64 `form:"cluster_id" example:"1"` // 不传则返回全部集群
+	NodeID    int64 `form:"node_id" example:"1"`
+}
+
+// ProvisionReservationItem 一个进行中的创建请求占用的资源
+type ProvisionReservationItem struct {
+	Id         int64            `json:"id"`
+	ClusterID  int64            `json:"cluster_id"`
+	NodeID     int64            `json:"node_id"`
+	NodeName   string           `json:"node_name"`
+	VMID       uint32           `json:"vmid"`
+	VmName     string           `json:"vm_name"`
+	CPUNum     int              `json:"cpu_num"`
+	MemoryMB   int              `json:"memory_mb"`
+	StorageGB  map[string]int64 `json:"storage_gb"` // 各存储预留的磁盘空间
+	CreateTime time.Time        `json:"create_time"`
+	ExpireTime time.Time        `json:"expire_time"` // 创建未正常结束时到期自动释放
+}
+
+type ListProvisionReservationsResponseData struct {
+	List []ProvisionReservationItem `json:"list"`
+}
+
+type ListProvisionReservationsResponse struct {
+	Response
+	Data ListProvisionReservationsResponseData
+}
@@ -0,0 +1,172 @@
+package v1
+
+// Proxmox 访问控制相关 API 定义
+// 在 PveSphere 中直接管理 PVE 侧的用户、API Token 和 ACL（/access/users、/access/acl），
+// 并提供「引导创建」：使用 root 等管理员账号密码一次性为 PveSphere 创建最小权限角色、用户和 API Token，
+// 以及对集群当前凭据的权限检查（定位 401 / 权限不足问题）
+
+// PveAccessClusterQuery 按集群查询 PVE 访问控制信息
+type PveAccessClusterQuery struct {
+	ClusterID int64 `form:"cluster_id" binding:"required" example:"1"`
+}
+
+// PveTokenItem PVE API Token
+type PveTokenItem struct {
+	TokenID string `json:"tokenid"`
+	Comment string `json:"comment,omitempty"`
+	Expire  int64  `json:"expire"`  // 过期时间（Unix 时间戳），0 表示永不过期
+	Privsep bool   `json:"privsep"` // 是否启用权限分离（启用时 Token 仅拥有单独授予的 ACL）
+}
+
+// PveUserItem PVE 用户
+type PveUserItem struct {
+	UserID    string         `json:"userid"` // name@realm
+	Enable    bool           `json:"enable"`
+	Expire    int64          `json:"expire"`
+	Email     string         `json:"email,omitempty"`
+	Comment   string         `json:"comment,omitempty"`
+	FirstName string         `json:"firstname,omitempty"`
+	LastName  string         `json:"lastname,omitempty"`
+	Groups    []string       `json:"groups"`
+	RealmType string         `json:"realm_type,omitempty"`
+	Tokens    []PveTokenItem `json:"tokens"`
+}
+
+type ListPveUsersResponse struct {
+	Response
+	Data []PveUserItem
+}
+
+// CreatePveUserRequest 创建 PVE 用户
+type CreatePveUserRequest struct {
+	ClusterID int64    `json:"cluster_id" binding:"required" example:"1"`
+	UserID    string   `json:"userid" binding:"required,max=128" example:"ops@pve"` // name@realm
+	Password  string   `json:"password,omitempty" binding:"omitempty,min=5,max=64"` // 仅 pve 域有效
+	Email     string   `json:"email,omitempty" binding:"omitempty,max=128" example:"ops@example.com"`
+	Comment   string   `json:"comment,omitempty" binding:"max=255"`
+	Groups    []string `json:"groups,omitempty"`
+	Enable    *bool    `json:"enable,omitempty" example:"true"` // 默认 true
+	Expire    int64    `json:"expire,omitempty" example:"0"`
+}
+
+// UpdatePveUserRequest 更新 PVE 用户，未传的字段保持不变
+type UpdatePveUserRequest struct {
+	ClusterID int64    `json:"cluster_id" binding:"required" example:"1"`
+	Email     *string  `json:"email,omitempty" binding:"omitempty,max=128"`
+	Comment   *string  `json:"comment,omitempty" binding:"omitempty,max=255"`
+	Groups    []string `json:"groups,omitempty"` // 传入时整体替换
+	Enable    *bool    `json:"enable,omitempty"`
+	Expire    *int64   `json:"expire,omitempty"`
+}
+
+type ListPveTokensResponse struct {
+	Response
+	Data []PveTokenItem
+}
+
+// CreatePveTokenRequest 创建 API Token
+type CreatePveTokenRequest struct {
+	ClusterID int64  `json:"cluster_id" binding:"required" example:"1"`
+	TokenID   string `json:"tokenid" binding:"required,max=64" example:"automation"`
+	Comment   string `json:"comment,omitempty" binding:"max=255"`
+	Expire    int64  `json:"expire,omitempty" example:"0"`
+	Privsep   *bool  `json:"privsep,omitempty" example:"true"` // 默认 true，与 Proxmox 一致
+}
+
+// CreatePveTokenData 创建 API Token 结果，secret 只在创建时返回一次
+type CreatePveTokenData struct {
+	FullTokenID string `json:"full_tokenid"` // user@realm!tokenid，即集群配置中的 user_id
+	Value       string `json:"value"`        // Token secret，即集群配置中的 user_token
+	Privsep     bool   `json:"privsep"`
+}
+
+type CreatePveTokenResponse struct {
+	Response
+	Data CreatePveTokenData
+}
+
+// PveACLItem ACL 条目
+type PveACLItem struct {
+	Path      string `json:"path"`
+	Type      string `json:"type"` // user / group / token
+	UGID      string `json:"ugid"` // 用户、组或 Token ID
+	RoleID    string `json:"roleid"`
+	Propagate bool   `json:"propagate"`
+}
+
+type ListPveACLResponse struct {
+	Response
+	Data []PveACLItem
+}
+
+// UpdatePveACLRequest 添加或删除 ACL，users / groups / tokens 至少传一项
+type UpdatePveACLRequest struct {
+	ClusterID int64    `json:"cluster_id" binding:"required" example:"1"`
+	Path      string   `json:"path" binding:"required,max=255" example:"/vms/100"`
+	Roles     []string `json:"roles" binding:"required,min=1" example:"PVEVMUser"`
+	Users     []string `json:"users,omitempty" example:"ops@pve"`
+	Groups    []string `json:"groups,omitempty"`
+	Tokens    []string `json:"tokens,omitempty" example:"ops@pve!automation"`
+	Propagate *bool    `json:"propagate,omitempty" example:"true"` // 默认 true
+	Delete    bool     `json:"delete,omitempty" example:"false"`   // true 表示删除匹配的 ACL
+}
+
+// PveRoleItem PVE 角色
+type PveRoleItem struct {
+	RoleID  string   `json:"roleid"`
+	Privs   []string `json:"privs"`
+	Special bool     `json:"special"` // 内置角色
+}
+
+type ListPveRolesResponse struct {
+	Response
+	Data []PveRoleItem
+}
+
+// CheckPvePermissionsData 集群当前凭据的权限检查结果
+type CheckPvePermissionsData struct {
+	Connected     bool     `json:"connected"`     // 是否可以连接 API
+	Authenticated bool     `json:"authenticated"` // 凭据是否有效（非 401）
+	UserID        string   `json:"user_id"`
+	Message       string   `json:"message,omitempty"`
+	Privileges    []string `json:"privileges"` // 在 / 上的有效权限
+	Missing       []string `json:"missing"`    // PveSphere 需要但缺少的权限
+	Hints         []string `json:"hints"`      // 排查提示
+}
+
+type CheckPvePermissionsResponse struct {
+	Response
+	Data CheckPvePermissionsData
+}
+
+// BootstrapPveAccessRequest 引导创建 PveSphere 专用的最小权限 API Token
+// 账号密码只用于本次登录，不会保存；传 cluster_id 时使用集群的 api_url，并在成功后更新集群凭据
+type BootstrapPveAccessRequest struct {
+	ClusterID        *int64 `json:"cluster_id,omitempty" example:"1"`
+	ApiUrl           string `json:"api_url,omitempty" binding:"omitempty,url" example:"https://10.0.0.1:8006"` // 未传 cluster_id 时必填
+	Username         string `json:"username" binding:"required" example:"root"`
+	Realm            string `json:"realm,omitempty" example:"pam"` // 默认 pam
+	Password         string `json:"password" binding:"required"`
+	PveUser          string `json:"pve_user,omitempty" example:"pvesphere@pve"` // 默认 pvesphere@pve
+	TokenID          string `json:"token_id,omitempty" example:"pvesphere"`     // 默认 pvesphere
+	RoleID           string `json:"role_id,omitempty" example:"PveSphere"`      // 默认 PveSphere
+	AccessManagement bool   `json:"access_management,omitempty"`                // 是否授予用户/ACL 管理权限（在 PveSphere 中管理 PVE 用户时需要）
+	RotateToken      bool   `json:"rotate_token,omitempty"`                     // Token 已存在时删除并重新创建
+}
+
+// BootstrapPveAccessData 引导创建结果，user_id / user_token 可直接填入集群配置
+type BootstrapPveAccessData struct {
+	UserID         string   `json:"user_id"`    // user@realm!tokenid
+	UserToken      string   `json:"user_token"` // Token secret，只返回一次
+	PveUser        string   `json:"pve_user"`
+	RoleID         string   `json:"role_id"`
+	Privileges     []string `json:"privileges"`
+	Verified       bool     `json:"verified"`        // 新 Token 是否已验证可用
+	ClusterUpdated bool     `json:"cluster_updated"` // 是否已写入集群凭据
+	Steps          []string `json:"steps"`           // 执行过程
+}
+
+type BootstrapPveAccessResponse struct {
+	Response
+	Data BootstrapPveAccessData
+}
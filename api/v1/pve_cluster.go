@@ -16,23 +16,36 @@ type CreateClusterRequest struct {
 	Dns              string `json:"dns" example:"8.8.8.8"`
 	Describes        string `json:"describes" example:"集群描述"`
 	Region           string `json:"region" example:"us-west-1"`
+	SiteID           int64  `json:"site_id" example:"1"` // 所属站点ID（可选）
 	IsSchedulable    int8   `json:"is_schedulable" example:"1"`
 	IsEnabled        int8   `json:"is_enabled" example:"1"`
+	ApiLogEnabled    int8   `json:"api_log_enabled" example:"0"`      // 是否记录 Proxmox API 调用日志
+	CPUBaseline      string `json:"cpu_baseline" example:"x86-64-v3"` // 集群 CPU 型号基线（可选）
+	ConsoleRecording int8   `json:"console_recording" example:"0"`    // 是否录制控制台会话
+	// 每个节点为宿主机、Ceph 和系统进程预留的 CPU / 内存百分比，调度和可用容量均扣除预留部分
+	ReservedCPUPercent    int `json:"reserved_cpu_percent" binding:"omitempty,min=0,max=90" example:"10"`
+	ReservedMemoryPercent int `json:"reserved_memory_percent" binding:"omitempty,min=0,max=90" example:"15"`
 }
 
 // UpdateClusterRequest 更新集群请求
 type UpdateClusterRequest struct {
-	ClusterNameAlias *string `json:"cluster_name_alias,omitempty"`
-	Env              *string `json:"env,omitempty"`
-	Datacenter       *string `json:"datacenter,omitempty"`
-	ApiUrl           *string `json:"api_url,omitempty"`
-	UserId           *string `json:"user_id,omitempty"`
-	UserToken        *string `json:"user_token,omitempty"`
-	Dns              *string `json:"dns,omitempty"`
-	Describes        *string `json:"describes,omitempty"`
-	Region           *string `json:"region,omitempty"`
-	IsSchedulable    *int8   `json:"is_schedulable,omitempty"`
-	IsEnabled        *int8   `json:"is_enabled,omitempty"`
+	ClusterNameAlias      *string `json:"cluster_name_alias,omitempty"`
+	Env                   *string `json:"env,omitempty"`
+	Datacenter            *string `json:"datacenter,omitempty"`
+	ApiUrl                *string `json:"api_url,omitempty"`
+	UserId                *string `json:"user_id,omitempty"`
+	UserToken             *string `json:"user_token,omitempty"`
+	Dns                   *string `json:"dns,omitempty"`
+	Describes             *string `json:"describes,omitempty"`
+	Region                *string `json:"region,omitempty"`
+	SiteID                *int64  `json:"site_id,omitempty"` // 所属站点ID，0 表示移出站点
+	IsSchedulable         *int8   `json:"is_schedulable,omitempty"`
+	IsEnabled             *int8   `json:"is_enabled,omitempty"`
+	ApiLogEnabled         *int8   `json:"api_log_enabled,omitempty"`
+	CPUBaseline           *string `json:"cpu_baseline,omitempty"` // 空字符串表示取消基线
+	ConsoleRecording      *int8   `json:"console_recording,omitempty"`
+	ReservedCPUPercent    *int    `json:"reserved_cpu_percent,omitempty" binding:"omitempty,min=0,max=90"`
+	ReservedMemoryPercent *int    `json:"reserved_memory_percent,omitempty" binding:"omitempty,min=0,max=90"`
 }
 
 // ListClusterRequest 列表查询请求
@@ -41,6 +54,7 @@ type ListClusterRequest struct {
 	PageSize int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
 	Env      string `form:"env" example:"prod"`
 	Region   string `form:"region" example:"us-west-1"`
+	SiteID   int64  `form:"site_id" example:"1"`
 }
 
 // ListClusterResponse 列表查询响应
@@ -55,15 +69,22 @@ type ListClusterResponseData struct {
 }
 
 type ClusterItem struct {
-	Id               int64  `json:"id"`
-	ClusterName      string `json:"cluster_name"`
-	ClusterNameAlias string `json:"cluster_name_alias"`
-	Env              string `json:"env"`
-	Datacenter       string `json:"datacenter"`
-	ApiUrl           string `json:"api_url"`
-	Region           string `json:"region"`
-	IsSchedulable    int8   `json:"is_schedulable"`
-	IsEnabled        int8   `json:"is_enabled"`
+	Id                    int64  `json:"id"`
+	ClusterName           string `json:"cluster_name"`
+	ClusterNameAlias      string `json:"cluster_name_alias"`
+	Env                   string `json:"env"`
+	Datacenter            string `json:"datacenter"`
+	ApiUrl                string `json:"api_url"`
+	Region                string `json:"region"`
+	SiteID                int64  `json:"site_id"`
+	SiteName              string `json:"site_name"`
+	IsSchedulable         int8   `json:"is_schedulable"`
+	IsEnabled             int8   `json:"is_enabled"`
+	ApiLogEnabled         int8   `json:"api_log_enabled"`
+	CPUBaseline           string `json:"cpu_baseline"`
+	ConsoleRecording      int8   `json:"console_recording"`
+	ReservedCPUPercent    int    `json:"reserved_cpu_percent"`
+	ReservedMemoryPercent int    `json:"reserved_memory_percent"`
 }
 
 // GetClusterResponse 详情查询响应
@@ -73,22 +94,29 @@ type GetClusterResponse struct {
 }
 
 type ClusterDetail struct {
-	Id               int64     `json:"id"`
-	ClusterName      string    `json:"cluster_name"`
-	ClusterNameAlias string    `json:"cluster_name_alias"`
-	Env              string    `json:"env"`
-	Datacenter       string    `json:"datacenter"`
-	ApiUrl           string    `json:"api_url"`
-	UserId           string    `json:"user_id"`
-	Dns              string    `json:"dns"`
-	Describes        string    `json:"describes"`
-	Region           string    `json:"region"`
-	IsSchedulable    int8      `json:"is_schedulable"`
-	IsEnabled        int8      `json:"is_enabled"`
-	CreateTime       time.Time `json:"create_time"` // 创建时间
-	UpdateTime       time.Time `json:"update_time"` // 更新时间
-	Creator          string    `json:"creator"`     // 创建者
-	Modifier         string    `json:"modifier"`    // 修改者
+	Id                    int64     `json:"id"`
+	ClusterName           string    `json:"cluster_name"`
+	ClusterNameAlias      string    `json:"cluster_name_alias"`
+	Env                   string    `json:"env"`
+	Datacenter            string    `json:"datacenter"`
+	ApiUrl                string    `json:"api_url"`
+	UserId                string    `json:"user_id"`
+	Dns                   string    `json:"dns"`
+	Describes             string    `json:"describes"`
+	Region                string    `json:"region"`
+	SiteID                int64     `json:"site_id"`
+	SiteName              string    `json:"site_name"`
+	IsSchedulable         int8      `json:"is_schedulable"`
+	IsEnabled             int8      `json:"is_enabled"`
+	ApiLogEnabled         int8      `json:"api_log_enabled"`
+	CPUBaseline           string    `json:"cpu_baseline"`
+	ConsoleRecording      int8      `json:"console_recording"`
+	ReservedCPUPercent    int       `json:"reserved_cpu_percent"`
+	ReservedMemoryPercent int       `json:"reserved_memory_percent"`
+	CreateTime            time.Time `json:"create_time"` // 创建时间
+	UpdateTime            time.Time `json:"update_time"` // 更新时间
+	Creator               string    `json:"creator"`     // 创建者
+	Modifier              string    `json:"modifier"`    // 修改者
 }
 
 // GetClusterStatusRequest 获取集群状态请求
@@ -104,7 +132,8 @@ type GetClusterStatusResponse struct {
 
 // GetClusterResourcesRequest 获取集群资源请求
 type GetClusterResourcesRequest struct {
-	ClusterID int64 `form:"cluster_id" binding:"required" example:"1"` // 集群ID
+	ClusterID int64  `form:"cluster_id" binding:"required" example:"1"`                                     // 集群ID
+	Type      string `form:"type" binding:"omitempty,oneof=vm qemu lxc node storage sdn pool" example:"vm"` // 资源类型（可选，vm 表示 qemu 和 lxc）
 }
 
 // GetClusterResourcesResponse 获取集群资源响应
@@ -27,12 +27,31 @@ type UpdateNodeRequest struct {
 }
 
 // ListNodeRequest 列表查询请求
+// 指定 with_metrics、实时指标过滤条件或按实时指标排序时，从 Proxmox 查询节点的实时指标并在服务端过滤、排序后分页
 type ListNodeRequest struct {
 	Page      int    `form:"page" example:"1"`
 	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
 	ClusterID int64  `form:"cluster_id" example:"1"`
 	Env       string `form:"env" example:"prod"`
 	Status    string `form:"status" example:"online"`
+
+	WithMetrics      bool    `form:"with_metrics" example:"true"`                                       // 返回实时指标
+	Storage          string  `form:"storage" example:"local-lvm"`                                       // 返回该存储在各节点上的剩余空间
+	MaxCPUPercent    float64 `form:"max_cpu_percent" binding:"omitempty,min=0,max=100" example:"80"`    // 只返回 CPU 使用率不高于该值的节点
+	MaxMemoryPercent float64 `form:"max_memory_percent" binding:"omitempty,min=0,max=100" example:"80"` // 只返回内存使用率不高于该值的节点
+	MinStorageFreeGB float64 `form:"min_storage_free_gb" binding:"omitempty,min=0" example:"100"`       // 只返回 storage 剩余空间不少于该值（GiB）的节点，需指定 storage
+	// SortBy 排序字段，默认 id 倒序；cpu、memory 按使用率，running_vms 按运行中虚拟机数，storage_free 按 storage 剩余空间（需指定 storage）
+	SortBy string `form:"sort_by" binding:"omitempty,oneof=id name cpu memory running_vms storage_free" example:"memory"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc" example:"asc"` // sort_by=id 时默认 desc，其余默认 asc
+}
+
+// NeedMetrics 是否需要查询实时指标
+func (r *ListNodeRequest) NeedMetrics() bool {
+	switch r.SortBy {
+	case "cpu", "memory", "running_vms", "storage_free":
+		return true
+	}
+	return r.WithMetrics || r.Storage != "" || r.MaxCPUPercent > 0 || r.MaxMemoryPercent > 0 || r.MinStorageFreeGB > 0
 }
 
 // ListNodeResponse 列表查询响应
@@ -56,6 +75,26 @@ type NodeItem struct {
 	Env           string `json:"env"`
 	Status        string `json:"status"`
 	VMLimit       int64  `json:"vm_limit"`
+	WolMAC        string `json:"wol_mac"` // Wake-on-LAN MAC 地址，为空表示不支持唤醒
+	Waking        bool   `json:"waking"`  // 已发送唤醒包、等待节点上线
+	// Metrics 实时指标，仅在请求需要时返回；集群无法连接时为空
+	Metrics *NodeLiveMetrics `json:"metrics,omitempty"`
+}
+
+// NodeLiveMetrics 节点实时指标（来自 Proxmox cluster/resources）
+type NodeLiveMetrics struct {
+	Online        bool    `json:"online"`
+	CPUPercent    float64 `json:"cpu_percent"`
+	CPUCores      int     `json:"cpu_cores"`
+	MemoryPercent float64 `json:"memory_percent"`
+	MemoryUsed    int64   `json:"memory_used"`  // 字节
+	MemoryTotal   int64   `json:"memory_total"` // 字节
+	// MemoryFree 可用内存（字节），已扣除集群为系统开销预留的内存
+	MemoryFree int64 `json:"memory_free"`
+	RunningVMs int   `json:"running_vms"` // 运行中的虚拟机数（不含模板）
+	// StorageFree/StorageTotal 请求中 storage 在该节点上的剩余/总空间（字节），节点上没有该存储时为空
+	StorageFree  *int64 `json:"storage_free,omitempty"`
+	StorageTotal *int64 `json:"storage_total,omitempty"`
 }
 
 // GetNodeResponse 详情查询响应
@@ -65,20 +104,23 @@ type GetNodeResponse struct {
 }
 
 type NodeDetail struct {
-	Id            int64     `json:"id"`
-	NodeName      string    `json:"node_name"`
-	IPAddress     string    `json:"ip_address"`
-	ClusterID     int64     `json:"cluster_id"`
-	ClusterName   string    `json:"cluster_name"` // 从关联表查询填充
-	IsSchedulable int8      `json:"is_schedulable"`
-	Env           string    `json:"env"`
-	Status        string    `json:"status"`
-	Annotations   string    `json:"annotations"`
-	VMLimit       int64     `json:"vm_limit"`
-	CreateTime    time.Time `json:"create_time"` // 创建时间
-	UpdateTime    time.Time `json:"update_time"` // 更新时间
-	Creator       string    `json:"creator"`     // 创建者
-	Modifier      string    `json:"modifier"`    // 修改者
+	Id            int64      `json:"id"`
+	NodeName      string     `json:"node_name"`
+	IPAddress     string     `json:"ip_address"`
+	ClusterID     int64      `json:"cluster_id"`
+	ClusterName   string     `json:"cluster_name"` // 从关联表查询填充
+	IsSchedulable int8       `json:"is_schedulable"`
+	Env           string     `json:"env"`
+	Status        string     `json:"status"`
+	Annotations   string     `json:"annotations"`
+	VMLimit       int64      `json:"vm_limit"`
+	WolMAC        string     `json:"wol_mac"`        // Wake-on-LAN MAC 地址，为空表示不支持唤醒
+	LastWakeTime  *time.Time `json:"last_wake_time"` // 最近一次发送唤醒包的时间
+	Waking        bool       `json:"waking"`         // 已发送唤醒包、等待节点上线
+	CreateTime    time.Time  `json:"create_time"`    // 创建时间
+	UpdateTime    time.Time  `json:"update_time"`    // 更新时间
+	Creator       string     `json:"creator"`        // 创建者
+	Modifier      string     `json:"modifier"`       // 修改者
 }
 
 // GetNodeStatusRequest 获取节点状态请求
@@ -222,12 +264,18 @@ type SetNodeStatusResponse struct {
 // GetNodeConsoleRequest 获取节点控制台请求
 type GetNodeConsoleRequest struct {
 	NodeID           int64  `json:"node_id" binding:"required" example:"1"`             // 节点ID（数据库ID）
-	ConsoleType      string `json:"console_type" binding:"required" example:"vncshell"` // 控制台类型：termproxy（终端）或 vncshell（VNC图形界面）
+	ConsoleType      string `json:"console_type" binding:"required" example:"vncshell"` // 控制台类型：termproxy（终端，客户端自行处理 termproxy 协议）、xtermjs（终端，由代理处理 termproxy 协议）或 vncshell（VNC图形界面）
 	Websocket        bool   `json:"websocket,omitempty" example:"true"`                 // 是否启用 websocket（仅 vncshell 有效）
 	GeneratePassword bool   `json:"generate_password,omitempty" example:"false"`        // 是否生成密码（仅 vncshell 有效）
+	// 终端尺寸和键盘布局（可选）
+	Cols     int    `json:"cols,omitempty" binding:"omitempty,min=1,max=1000" example:"120"`                                                                                           // 终端列数（仅 xtermjs 有效，默认 80）
+	Rows     int    `json:"rows,omitempty" binding:"omitempty,min=1,max=1000" example:"40"`                                                                                            // 终端行数（仅 xtermjs 有效，默认 24）
+	Width    int    `json:"width,omitempty" binding:"omitempty,min=16,max=4096" example:"1024"`                                                                                        // 终端像素宽度（仅 vncshell 有效）
+	Height   int    `json:"height,omitempty" binding:"omitempty,min=16,max=2048" example:"768"`                                                                                        // 终端像素高度（仅 vncshell 有效）
+	Keyboard string `json:"keyboard,omitempty" binding:"omitempty,oneof=de de-ch da en-gb en-us es fi fr fr-be fr-ca fr-ch hu is it ja lt mk nl no pl pt pt-br sv sl tr" example:"de"` // 键盘布局，默认使用数据中心选项中的 keyboard
 	// 高权限认证（可选）：如果提供了 ticket 和 csrf_token，将使用这些凭证而不是集群配置的 API Token
-	Ticket    string `json:"ticket,omitempty" example:"PVE:root@pam:..."`              // Proxmox 高权限票据（从 /api/v1/pve/access/ticket 获取）
-	CSRFToken string `json:"csrf_token,omitempty" example:"6948C80E:..."`              // CSRF 防护令牌（从 /api/v1/pve/access/ticket 获取）
+	Ticket    string `json:"ticket,omitempty" example:"PVE:root@pam:..."` // Proxmox 高权限票据（从 /api/v1/pve/access/ticket 获取）
+	CSRFToken string `json:"csrf_token,omitempty" example:"6948C80E:..."` // CSRF 防护令牌（从 /api/v1/pve/access/ticket 获取）
 }
 
 // GetNodeConsoleResponse 获取节点控制台响应
@@ -0,0 +1,64 @@
+package v1
+
+import "time"
+
+// PveSite 相关 API 定义
+// 站点用于对集群按机房/地域分组，Dashboard 和集群列表可按站点聚合或筛选
+
+// CreateSiteRequest 创建站点请求
+type CreateSiteRequest struct {
+	SiteName         string `json:"site_name" binding:"required" example:"dc-beijing"`
+	SiteNameAlias    string `json:"site_name_alias" example:"北京机房"`
+	Region           string `json:"region" example:"cn-north"`
+	Location         string `json:"location" example:"北京亦庄"`
+	Describes        string `json:"describes" example:"站点描述"`
+	CrossSiteBwlimit int    `json:"cross_site_bwlimit" binding:"min=0" example:"102400"` // 跨站点迁移默认带宽限制（KiB/s），0 表示不限制
+}
+
+// UpdateSiteRequest 更新站点请求
+type UpdateSiteRequest struct {
+	SiteNameAlias    *string `json:"site_name_alias,omitempty"`
+	Region           *string `json:"region,omitempty"`
+	Location         *string `json:"location,omitempty"`
+	Describes        *string `json:"describes,omitempty"`
+	CrossSiteBwlimit *int    `json:"cross_site_bwlimit,omitempty" binding:"omitempty,min=0"`
+}
+
+// SiteItem 站点信息
+type SiteItem struct {
+	Id               int64     `json:"id"`
+	SiteName         string    `json:"site_name"`
+	SiteNameAlias    string    `json:"site_name_alias"`
+	Region           string    `json:"region"`
+	Location         string    `json:"location"`
+	Describes        string    `json:"describes"`
+	CrossSiteBwlimit int       `json:"cross_site_bwlimit"`
+	ClusterCount     int       `json:"cluster_count"` // 站点下的集群数量
+	CreateTime       time.Time `json:"create_time"`   // 创建时间
+	UpdateTime       time.Time `json:"update_time"`   // 更新时间
+	Creator          string    `json:"creator"`       // 创建者
+	Modifier         string    `json:"modifier"`      // 修改者
+}
+
+// ListSitesResponse 站点列表响应
+type ListSitesResponse struct {
+	Response
+	Data ListSitesResponseData
+}
+
+type ListSitesResponseData struct {
+	Total int64      `json:"total"`
+	List  []SiteItem `json:"list"`
+}
+
+// SiteDetail 站点详情（包含站点下的集群）
+type SiteDetail struct {
+	SiteItem
+	Clusters []ClusterItem `json:"clusters"`
+}
+
+// GetSiteResponse 站点详情响应
+type GetSiteResponse struct {
+	Response
+	Data SiteDetail
+}
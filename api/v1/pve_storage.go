@@ -137,6 +137,39 @@ type GetStorageContentResponse struct {
 	Data []map[string]interface{} `json:"data"`
 }
 
+// ListStorageContentRequest 分页获取存储内容列表请求，分页、过滤和排序在服务端完成
+type ListStorageContentRequest struct {
+	NodeID   int64  `form:"node_id" binding:"required" example:"1"`                            // 节点ID
+	Storage  string `form:"storage" binding:"required" example:"local"`                        // 存储名称
+	Content  string `form:"content" example:"images"`                                          // 内容类型过滤: images,iso,backup 等
+	VMID     uint32 `form:"vmid" example:"100"`                                                // 只返回属于该虚拟机的卷（如某台虚拟机的备份）
+	Name     string `form:"name" example:"vzdump-qemu-100"`                                    // 按卷标识或备注模糊匹配，不区分大小写
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`                        // 默认 1
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=1000" example:"100"`        // 默认 100
+	SortBy   string `form:"sort_by" binding:"omitempty,oneof=name size ctime" example:"ctime"` // 默认 name
+	Order    string `form:"order" binding:"omitempty,oneof=asc desc" example:"desc"`           // sort_by=name 时默认 asc，其余默认 desc
+}
+
+// StorageContentSummary 按内容类型汇总（基于过滤后、分页前的全部结果）
+type StorageContentSummary struct {
+	Content string `json:"content"` // images / iso / backup / vztmpl 等
+	Count   int    `json:"count"`
+	Size    int64  `json:"size"` // 字节
+}
+
+type ListStorageContentData struct {
+	Total     int64                    `json:"total"`
+	TotalSize int64                    `json:"total_size"` // 过滤后全部卷的大小之和（字节）
+	Summary   []StorageContentSummary  `json:"summary"`
+	List      []map[string]interface{} `json:"list"` // Proxmox 返回的卷信息：volid、content、format、size、ctime、vmid、notes 等
+}
+
+// ListStorageContentResponse 分页获取存储内容列表响应
+type ListStorageContentResponse struct {
+	Response
+	Data ListStorageContentData `json:"data"`
+}
+
 // GetStorageVolumeRequest 获取卷属性请求
 type GetStorageVolumeRequest struct {
 	NodeID  int64  `form:"node_id" binding:"required" example:"1"`            // 节点ID
@@ -17,6 +17,29 @@ type UpdateTemplateRequest struct {
 	Description  *string `json:"description,omitempty"`
 }
 
+// DeleteTemplateRequest 删除模板请求
+type DeleteTemplateRequest struct {
+	KeepBackup bool `form:"keep_backup" example:"false"` // 保留导入模板所用的备份文件，默认一并删除
+}
+
+// DeleteTemplateResponse 删除模板响应
+type DeleteTemplateResponse struct {
+	Response
+	Data DeleteTemplateResponseData
+}
+
+type DeleteTemplateResponseData struct {
+	TemplateID    int64               `json:"template_id"`
+	DeletedVMs    []DeletedTemplateVM `json:"deleted_vms"`    // 已从 Proxmox 删除的模板虚拟机
+	BackupFile    string              `json:"backup_file"`    // 导入模板所用的备份文件
+	BackupDeleted bool                `json:"backup_deleted"` // 备份文件是否已删除
+}
+
+type DeletedTemplateVM struct {
+	NodeName string `json:"node_name"`
+	VMID     uint32 `json:"vmid"`
+}
+
 // ListTemplateRequest 列表查询请求
 type ListTemplateRequest struct {
 	Page      int   `form:"page" example:"1"`
@@ -36,11 +59,12 @@ type ListTemplateResponseData struct {
 }
 
 type TemplateItem struct {
-	Id           int64  `json:"id"`
-	TemplateName string `json:"template_name"`
-	ClusterID    int64  `json:"cluster_id"`
-	ClusterName  string `json:"cluster_name"` // 从关联表查询填充
-	Description  string `json:"description"`
+	Id           int64               `json:"id"`
+	TemplateName string              `json:"template_name"`
+	ClusterID    int64               `json:"cluster_id"`
+	ClusterName  string              `json:"cluster_name"` // 从关联表查询填充
+	Description  string              `json:"description"`
+	Usage        *TemplateUsageStats `json:"usage,omitempty"` // 使用统计和老化标记
 }
 
 // GetTemplateResponse 详情查询响应
@@ -71,11 +95,23 @@ type ImportTemplateRequest struct {
 	ClusterID       int64   `json:"cluster_id" binding:"required" example:"1"`
 	NodeID          int64   `json:"node_id" binding:"required" example:"1"`                                               // 导入节点ID
 	BackupStorageID int64   `json:"backup_storage_id" binding:"required" example:"6"`                                     // 备份文件所在的存储ID（通常是local）
-	BackupFile      string  `json:"backup_file" binding:"required" example:"vzdump-qemu-100-2024_01_01-00_00_00.vma.zst"` // 备份文件名
+	BackupFile      string  `json:"backup_file" binding:"required" example:"vzdump-qemu-100-2024_01_01-00_00_00.vma.zst"` // 备份文件名（vma 系列），或 import 目录下的 qcow2/raw/vmdk/ova 文件名
 	TargetStorageID int64   `json:"target_storage_id" binding:"required" example:"7"`                                     // VM磁盘要创建的目标存储ID（必须支持images，如local-lvm）
 	Description     string  `json:"description" example:"CentOS 7 模板"`
 	AutoSync        bool    `json:"auto_sync" example:"false"`   // local存储时是否自动同步到所有节点
 	SyncNodeIDs     []int64 `json:"sync_node_ids" example:"2,3"` // local存储时，指定要同步的节点ID列表
+
+	TemplateImageOptions
+}
+
+// TemplateImageOptions 从磁盘镜像（qcow2/raw/vmdk）或 OVA 导入模板时创建虚拟机的参数，导入 vzdump 备份时忽略
+// 磁盘镜像默认 1 核 / 1024MB / vmbr0；OVA 以包内配置为准，设置 cores / memory 时覆盖
+type TemplateImageOptions struct {
+	Cores     int    `json:"cores,omitempty" form:"cores" binding:"omitempty,min=1" example:"2"`
+	Memory    int    `json:"memory,omitempty" form:"memory" binding:"omitempty,min=128" example:"2048"` // MB
+	Bridge    string `json:"bridge,omitempty" form:"bridge" example:"vmbr0"`
+	OSType    string `json:"ostype,omitempty" form:"ostype" example:"l26"`
+	CloudInit bool   `json:"cloud_init,omitempty" form:"cloud_init" example:"true"` // 添加 cloud-init 盘并使用串口控制台（适用于云镜像）
 }
 
 // ImportTemplateResponse 导入模板响应
@@ -93,6 +129,44 @@ type ImportTemplateResponseData struct {
 	SyncTasks   []TemplateSyncTaskInfo `json:"sync_tasks,omitempty"`
 }
 
+// UploadImportTemplateRequest 上传并导入模板请求（参数通过 query 传递，文件通过 multipart 字段 file 流式上传）
+type UploadImportTemplateRequest struct {
+	TemplateName    string  `form:"template_name" binding:"required" example:"centos7-template"`
+	ClusterID       int64   `form:"cluster_id" binding:"required" example:"1"`
+	NodeID          int64   `form:"node_id" binding:"required" example:"1"`           // 上传并导入的节点ID
+	BackupStorageID int64   `form:"backup_storage_id" binding:"required" example:"6"` // 备份文件上传到的存储ID（需支持backup，通常是local）
+	TargetStorageID int64   `form:"target_storage_id" binding:"required" example:"7"` // VM磁盘要创建的目标存储ID（必须支持images，如local-lvm）
+	FileSize        int64   `form:"file_size" example:"1073741824"`                   // 文件大小（字节），用于计算上传进度；不传时按请求体大小估算
+	Description     string  `form:"description" example:"CentOS 7 模板"`
+	SyncNodeIDs     []int64 `form:"sync_node_ids" example:"2,3"` // local存储时，导入完成后要同步的节点ID列表
+
+	TemplateImageOptions
+}
+
+// GetTemplateImportResponse 查询模板导入进度响应
+type GetTemplateImportResponse struct {
+	Response
+	Data TemplateImportProgress `json:"data"`
+}
+
+// TemplateImportProgress 模板导入进度
+// 上传并导入时 progress 为统一进度：上传 0-50，恢复 50-95，转换为模板 95-100
+type TemplateImportProgress struct {
+	ImportID     int64     `json:"import_id"`
+	TemplateID   int64     `json:"template_id"`
+	NodeID       int64     `json:"node_id"`
+	NodeName     string    `json:"node_name"`
+	StorageName  string    `json:"storage_name"`
+	FileName     string    `json:"file_name"`
+	FilePath     string    `json:"file_path"`
+	FileSize     int64     `json:"file_size"`
+	Status       string    `json:"status"` // uploading, importing, imported, failed
+	Progress     int       `json:"progress"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	CreateTime   time.Time `json:"create_time"`
+	UpdateTime   time.Time `json:"update_time"`
+}
+
 type TemplateImportNode struct {
 	NodeID   int64  `json:"node_id"`
 	NodeName string `json:"node_name"`
@@ -220,6 +294,25 @@ type RetrySyncTaskResponseData struct {
 	Status string `json:"status"`
 }
 
+// CancelSyncTaskRequest 取消同步任务请求
+type CancelSyncTaskRequest struct {
+	Reason string `json:"reason,omitempty" binding:"omitempty,max=500" example:"migration stuck"` // 取消原因，记录到任务的错误信息
+}
+
+// CancelSyncTaskResponse 取消同步任务响应
+type CancelSyncTaskResponse struct {
+	Response
+	Data CancelSyncTaskResponseData `json:"data"`
+}
+
+type CancelSyncTaskResponseData struct {
+	TaskID      int64  `json:"task_id"`
+	Status      string `json:"status"`
+	StoppedUPID string `json:"stopped_upid,omitempty"` // 被终止的 Proxmox 任务
+	CleanedNode string `json:"cleaned_node,omitempty"` // 临时虚拟机被删除时所在的节点
+	CleanedVMID uint32 `json:"cleaned_vmid,omitempty"`
+}
+
 // ========================
 // 模板实例相关 API
 // ========================
@@ -10,14 +10,15 @@ type CreateVMRequest struct {
 	// - template：从模板克隆（默认，兼容旧前端不传 create_mode 的情况）
 	// - iso：创建空虚拟机并挂载 ISO，从光驱启动安装
 	// - empty：创建空虚拟机（不挂载 ISO），后续可通过 /api/v1/vms/config 再挂载 ISO
-	CreateMode string `json:"create_mode,omitempty" example:"template"`
+	CreateMode string `json:"create_mode,omitempty" binding:"omitempty,oneof=template iso empty" example:"template"`
 
 	VmName      string `json:"vm_name" binding:"required" example:"vm-001"` // 新虚拟机名称
 	ClusterID   int64  `json:"cluster_id" example:"1"`                      // 集群ID（推荐使用，优先级高于 cluster_name）
 	ClusterName string `json:"cluster_name,omitempty" example:"my-cluster"` // 集群名称（可选，向后兼容，如果提供了 cluster_id 则忽略此字段）
 	NodeID      int64  `json:"node_id" example:"1"`                         // 节点ID（推荐使用，优先级高于 node_name）
 	NodeName    string `json:"node_name,omitempty" example:"pve-node-1"`    // 目标节点名称（可选，向后兼容，如果提供了 node_id 则忽略此字段）
-	VMID        uint32 `json:"vmid,omitempty" example:"100"`                // 新虚拟机的 VM ID（可选，不传则自动生成8位数）
+	NodePoolID  int64  `json:"node_pool_id,omitempty" example:"1"`          // 节点池ID（可选，未指定 node_id / node_name 时由调度器在池内选择节点）
+	VMID        uint32 `json:"vmid,omitempty" example:"100"`                // 新虚拟机的 VM ID（可选，不传时从 VMID 段分配，未配置 VMID 段时自动生成8位数）
 	TemplateID  int64  `json:"template_id,omitempty" example:"1"`           // 模板ID（create_mode=template 时必填）
 	CPUNum      *int   `json:"cpu_num,omitempty" example:"2"`               // CPU核心数（可选，不设置则使用模板配置）
 	MemorySize  *int   `json:"memory_size,omitempty" example:"4096"`        // 内存大小MB（可选，不设置则使用模板配置）
@@ -30,20 +31,96 @@ type CreateVMRequest struct {
 	// 系统盘大小（GB），create_mode=iso/empty 时建议提供，不传则默认 32
 	DiskSizeGB *int `json:"disk_size_gb,omitempty" example:"32"`
 	// 磁盘格式（仅 dir 等文件存储需要，local-lvm 可忽略），默认 qcow2
-	DiskFormat string `json:"disk_format,omitempty" example:"qcow2"`
+	DiskFormat string `json:"disk_format,omitempty" binding:"omitempty,oneof=raw qcow2 vmdk" example:"qcow2"`
 	// 网桥名称，默认 vmbr0
 	Bridge string `json:"bridge,omitempty" example:"vmbr0"`
 	// 网卡模型，默认 virtio
 	NetModel string `json:"net_model,omitempty" example:"virtio"`
+	// net0 的 MAC 地址（可选），不传则由平台生成；可使用 /api/v1/mac-addresses 中预留的地址，已被使用的地址会被拒绝
+	MACAddress string `json:"mac_address,omitempty" example:"BC:24:11:00:00:01"`
+	// net0 的 VLAN 标签（Proxmox tag），用于租户隔离网络；template 模式下不传保持模板配置
+	VLANTag *int `json:"vlan_tag,omitempty" binding:"omitempty,min=1,max=4094" example:"100"`
+	// net0 是否启用 Proxmox 防火墙（firewall=1）；template 模式下不传保持模板配置
+	Firewall *bool `json:"firewall,omitempty" example:"true"`
 	// 操作系统类型（Proxmox ostype），默认 l26
 	OSType string `json:"os_type,omitempty" example:"l26"`
+	// CPU 类型（Proxmox cpu），不传则使用集群的 CPU 型号基线；集群未设置基线时保持 Proxmox/模板默认
+	CPUType string `json:"cpu_type,omitempty" example:"x86-64-v2-AES"`
+	// CPU 插槽数（Proxmox sockets），create_mode=iso/empty 时默认 1
+	Sockets *int `json:"sockets,omitempty" binding:"omitempty,min=1,max=4" example:"1"`
+	// 是否启用 NUMA（0/1），不传保持 Proxmox/模板默认
+	NUMA *int `json:"numa,omitempty" binding:"omitempty,oneof=0 1" example:"0"`
+	// 机器类型（Proxmox machine）：q35、i440fx（即 pc）或带版本的类型如 pc-q35-8.1；os_type=win11 时默认 q35
+	Machine string `json:"machine,omitempty" example:"q35"`
+	// 固件（Proxmox bios）：seabios 或 ovmf；os_type=win11 时默认 ovmf。
+	// create_mode=iso/empty 且为 ovmf 时自动创建 EFI 磁盘（efidisk0）
+	BIOS string `json:"bios,omitempty" binding:"omitempty,oneof=seabios ovmf" example:"ovmf"`
+	// EFI / TPM 磁盘所在存储，不传则使用 storage
+	EFIStorage string `json:"efi_storage,omitempty" example:"local-lvm"`
+	// 是否创建 TPM 2.0 状态盘（tpmstate0），仅 create_mode=iso/empty 生效；os_type=win11 时默认创建
+	TPM *bool `json:"tpm,omitempty" example:"true"`
+	// 最小内存 MB（Proxmox balloon），0 表示关闭 ballooning，不传保持 Proxmox/模板默认；大于 0 时不能超过 memory_size
+	BalloonMin *int `json:"balloon_min,omitempty" binding:"omitempty,min=0" example:"2048"`
+	// 自动 ballooning 的内存分配权重（Proxmox shares），默认 1000
+	Shares *int `json:"shares,omitempty" binding:"omitempty,min=0,max=50000" example:"1000"`
+	// SCSI 控制器类型（Proxmox scsihw），create_mode=iso/empty 时默认 virtio-scsi-pci
+	SCSIHw string `json:"scsihw,omitempty" example:"virtio-scsi-single"`
+	// guest agent 选项（Proxmox agent），create_mode=iso/empty 时默认 1
+	Agent string `json:"agent,omitempty" example:"enabled=1,fstrim_cloned_disks=1"`
+	// 标签，以分号或逗号分隔
+	Tags string `json:"tags,omitempty" example:"web;prod"`
+
+	// ProfileID 创建规格ID（见 /api/v1/vm-profiles），用于补全请求中未填写的配置，请求中显式传入的值优先；
+	// 不传时依次使用集群默认规格、全局默认规格
+	ProfileID *int64 `json:"profile_id,omitempty" example:"1"`
 
 	AppId       string `json:"app_id,omitempty" example:"app-001"`       // 应用ID（可选）
+	Team        string `json:"team,omitempty" binding:"omitempty,max=100" example:"team-a"` // 所属团队（可选），未传 vmid 时从该团队的 VMID 段分配
 	VmUser      string `json:"vm_user,omitempty" example:"root"`         // 虚拟机用户名（可选）
 	VmPassword  string `json:"vm_password,omitempty" example:"password"` // 虚拟机密码（可选）
 	Description string `json:"description,omitempty" example:"虚拟机描述"`    // 描述（可选）
-	FullClone   *int   `json:"full_clone,omitempty" example:"1"`         // 是否完整克隆（1=完整克隆，0=链接克隆，默认1）
+	FullClone   *int   `json:"full_clone,omitempty" binding:"omitempty,oneof=0 1" example:"1"`         // 是否完整克隆（1=完整克隆，0=链接克隆，默认1）
 	IPAddressID *int64 `json:"ip_address_id,omitempty" example:"1"`      // IP地址ID（从vm_ipaddress表，可选）
+
+	// 克隆方式（create_mode=template）：full 完整克隆、linked 链接克隆；设置后优先于 full_clone。
+	// 链接克隆必须使用模板所在存储且该存储支持链接克隆，跨节点时模板存储必须为共享存储
+	CloneMode string `json:"clone_mode,omitempty" binding:"omitempty,oneof=full linked" example:"full"`
+	// 完整克隆的磁盘格式（raw/qcow2/vmdk），仅文件存储（dir、nfs 等）支持 raw 以外的格式
+	CloneFormat string `json:"clone_format,omitempty" binding:"omitempty,oneof=raw qcow2 vmdk" example:"qcow2"`
+	// 完整克隆时按磁盘指定目标存储（key 为磁盘槽位，如 scsi1），未指定的磁盘使用 storage，storage 为空时保持模板磁盘所在存储
+	DiskStorages map[string]string `json:"disk_storages,omitempty" example:"scsi1:ceph-hdd"`
+
+	// 磁盘列表：create_mode=iso/empty 时第一块为系统盘（替代 disk_size_gb / disk_format），其余依次挂载；
+	// create_mode=template 时在克隆完成后作为新磁盘添加到对应总线的空闲槽位
+	Disks []VMDiskSpec `json:"disks,omitempty" binding:"omitempty,max=16,dive"`
+	// 串口数量（serial0..serialN-1，Proxmox serialN=socket），0 表示不添加串口；template 模式下会删除模板中多余的串口，
+	// 不传保持模板配置（模板带 cloud-init 盘且没有串口时默认添加 serial0 并将显示设为 serial0）
+	SerialNum *int `json:"serial_num,omitempty" binding:"omitempty,min=0,max=4" example:"1"`
+	// 显示设备（Proxmox vga）；为 serialN 时控制台输出到对应串口，未传 serial_num 时自动添加该串口
+	Display string `json:"display,omitempty" binding:"omitempty,oneof=std cirrus vmware qxl qxl2 qxl3 qxl4 virtio virtio-gl serial0 serial1 serial2 serial3 none" example:"serial0"`
+
+	// 网卡列表：第 N 个网卡对应 netN（替代 bridge / net_model / mac_address / vlan_tag / firewall）；
+	// create_mode=template 时覆盖模板中相同编号的网卡，其余网卡保持模板配置
+	NICs []VMNICSpec `json:"nics,omitempty" binding:"omitempty,max=8,dive"`
+}
+
+// VMDiskSpec 创建虚拟机时的磁盘
+type VMDiskSpec struct {
+	Storage  string `json:"storage,omitempty" example:"local-lvm"`                                       // 存储名称，默认使用 storage（template 模式下 storage 为空时使用模板所在存储）
+	SizeGB   int    `json:"size_gb" binding:"required,min=1,max=65536" example:"100"`                    // 大小（GB）
+	Format   string `json:"format,omitempty" binding:"omitempty,oneof=raw qcow2 vmdk" example:"qcow2"`   // 磁盘格式，仅文件存储支持 raw 以外的格式
+	Bus      string `json:"bus,omitempty" binding:"omitempty,oneof=scsi virtio sata ide" example:"scsi"` // 总线类型，默认 scsi
+	SSD      *bool  `json:"ssd,omitempty" example:"true"`                                                // 以 SSD 呈现给虚拟机（virtio 总线不支持）
+	IOThread *bool  `json:"iothread,omitempty" example:"true"`                                           // 独立 IO 线程（仅 scsi / virtio 总线，scsi 需配合 scsihw=virtio-scsi-single）
+}
+
+// VMNICSpec 创建虚拟机时的网卡
+type VMNICSpec struct {
+	Bridge     string `json:"bridge" binding:"required" example:"vmbr0"`                                                      // 网桥名称
+	VLANTag    *int   `json:"vlan_tag,omitempty" binding:"omitempty,min=1,max=4094" example:"100"`                            // VLAN 标签
+	Model      string `json:"model,omitempty" binding:"omitempty,oneof=virtio e1000 e1000e rtl8139 vmxnet3" example:"virtio"` // 网卡模型，默认 virtio
+	Firewall   *bool  `json:"firewall,omitempty" example:"true"`                                                              // 启用 Proxmox 防火墙
+	MACAddress string `json:"mac_address,omitempty" example:"BC:24:11:00:00:02"`                                              // MAC 地址，不传则由平台分配
 }
 
 // UpdateVMRequest 更新虚拟机请求
@@ -60,6 +137,23 @@ type UpdateVMRequest struct {
 	VmPassword   *string `json:"vm_password,omitempty"`
 	NodeIP       *string `json:"node_ip,omitempty"`
 	Description  *string `json:"description,omitempty"`
+
+	// 归属与生命周期元数据，传空字符串表示清空
+	Owner           *string    `json:"owner,omitempty" binding:"omitempty,max=100" example:"zhangsan"`
+	Team            *string    `json:"team,omitempty" binding:"omitempty,max=100" example:"payments"`
+	Contact         *string    `json:"contact,omitempty" binding:"omitempty,max=200" example:"payments-oncall@example.com"`
+	BusinessService *string    `json:"business_service,omitempty" binding:"omitempty,max=200" example:"checkout"`
+	Environment     *string    `json:"environment,omitempty" binding:"omitempty,oneof=prod stage dev" example:"prod"`
+	CostCenter      *string    `json:"cost_center,omitempty" binding:"omitempty,max=100" example:"CC-1001"`
+	ReviewDate      *time.Time `json:"review_date,omitempty" example:"2026-12-31T00:00:00Z"`
+	ClearReviewDate bool       `json:"clear_review_date,omitempty"` // 清除复核日期
+}
+
+// StopVMRequest 停止虚拟机请求，未传的字段使用 vm_stop.* 配置
+type StopVMRequest struct {
+	Mode      string `json:"mode,omitempty" binding:"omitempty,oneof=shutdown stop" example:"shutdown"` // shutdown: 通过 ACPI / guest agent 正常关机；stop: 直接断电
+	Timeout   *int   `json:"timeout,omitempty" binding:"omitempty,min=1,max=3600" example:"180"`        // 等待秒数：shutdown 为等待关机的时间，stop 为等待停止的时间
+	ForceStop *bool  `json:"force_stop,omitempty" example:"true"`                                       // shutdown 超时后是否强制断电
 }
 
 // ListVMRequest 列表查询请求
@@ -73,6 +167,14 @@ type ListVMRequest struct {
 	TemplateID  int64  `form:"template_id" example:"1"`           // 模板ID（可选）
 	Status      string `form:"status" example:"running"`
 	AppId       string `form:"app_id" example:"app-001"`
+
+	Owner           string `form:"owner" example:"zhangsan"`
+	Team            string `form:"team" example:"payments"`
+	BusinessService string `form:"business_service" example:"checkout"`
+	Environment     string `form:"environment" binding:"omitempty,oneof=prod stage dev" example:"prod"`
+	CostCenter      string `form:"cost_center" example:"CC-1001"`
+	ReviewDue       bool   `form:"review_due" example:"true"`                                   // 只返回复核日期已到期的虚拟机
+	OSEOL           string `form:"os_eol" binding:"omitempty,oneof=eol eol_soon" example:"eol"` // 只返回操作系统已停止维护（eol）或即将停止维护（eol_soon）的虚拟机
 }
 
 // ListVMResponse 列表查询响应
@@ -102,6 +204,18 @@ type VMItem struct {
 	Status       string `json:"status"`
 	AppId        string `json:"app_id"`
 	NodeIP       string `json:"node_ip"`
+
+	Owner           string     `json:"owner"`
+	Team            string     `json:"team"`
+	BusinessService string     `json:"business_service"`
+	Environment     string     `json:"environment"`
+	CostCenter      string     `json:"cost_center"`
+	ReviewDate      *time.Time `json:"review_date"`
+	Protected       bool       `json:"protected"` // 是否开启了 PveSphere 删除保护
+
+	OSName      string     `json:"os_name"`       // guest agent 上报的操作系统名称，未采集时为空
+	OSEOLDate   *time.Time `json:"os_eol_date"`   // 匹配到的操作系统停止维护日期
+	OSEOLStatus string     `json:"os_eol_status"` // eol / eol_soon，未停止维护或无法识别时为空
 }
 
 // GetVMResponse 详情查询响应
@@ -134,6 +248,17 @@ type VMDetail struct {
 	UpdateTime   time.Time `json:"update_time"` // 更新时间
 	Creator      string    `json:"creator"`     // 创建者
 	Modifier     string    `json:"modifier"`    // 修改者
+
+	Owner           string     `json:"owner"`            // 负责人
+	Team            string     `json:"team"`             // 所属团队
+	Contact         string     `json:"contact"`          // 联系方式
+	BusinessService string     `json:"business_service"` // 业务服务
+	Environment     string     `json:"environment"`      // 环境：prod / stage / dev
+	CostCenter      string     `json:"cost_center"`      // 成本中心
+	ReviewDate      *time.Time `json:"review_date"`      // 下次复核日期
+	Protected       bool       `json:"protected"`        // 是否开启了 PveSphere 删除保护
+	ProtectedBy     string     `json:"protected_by"`     // 开启保护的用户
+	ProtectedReason string     `json:"protected_reason"` // 保护原因
 }
 
 // ========================
@@ -146,7 +271,7 @@ type CreateBackupRequest struct {
 	VMID            uint32 `json:"vmid" binding:"required" example:"100"`              // 虚拟机ID（必填）
 	Storage         string `json:"storage,omitempty" example:"local"`                 // 存储名称（可选，默认使用配置的存储）
 	Compress        string `json:"compress,omitempty" example:"zstd"`                  // 压缩格式：zstd, lzo, gzip（可选，支持 zst 作为 zstd 的别名）
-	Mode            string `json:"mode,omitempty" example:"snapshot"`                 // 备份模式：snapshot, suspend, stop（可选，默认 snapshot）
+	Mode            string `json:"mode,omitempty" binding:"omitempty,oneof=snapshot suspend stop" example:"snapshot"`                 // 备份模式：snapshot, suspend, stop（可选，默认 snapshot）
 	Remove          *int   `json:"remove,omitempty" example:"0"`                        // 是否删除旧备份：0=否, 1=是（可选）
 	MailTo          string `json:"mailto,omitempty" example:"admin@example.com"`       // 备份完成后发送邮件到（可选）
 	MailNotification string `json:"mailnotification,omitempty" example:"always"`      // 邮件通知类型：always, failure（可选）
@@ -160,6 +285,7 @@ type CreateBackupRequest struct {
 	StopWait        *int   `json:"stopwait,omitempty" example:"300"`                  // 停止等待时间（秒）（可选）
 	DumpDir         string `json:"dumpdir,omitempty" example:"/var/lib/vz/dump"`      // 备份目录（可选，覆盖存储配置）
 	Zstd            *int   `json:"zstd,omitempty" example:"1"`                         // zstd 压缩级别 1-22（可选，仅当 compress=zst 时有效）
+	Force           bool   `json:"force,omitempty" example:"false"`                    // 预估目标存储空间不足时仍然发起备份（可选）
 }
 
 // CreateBackupResponse 创建备份响应
@@ -173,6 +299,7 @@ type CreateBackupResponseData struct {
 	VMID    uint32 `json:"vmid"`    // 虚拟机ID
 	NodeID  int64  `json:"node_id"` // 节点ID
 	NodeName string `json:"node_name"` // 节点名称
+	Forecast *StorageForecastData `json:"forecast,omitempty"` // 指定了 storage 时的空间预估结果
 }
 
 // DeleteBackupRequest 删除备份请求
@@ -35,6 +35,12 @@ type UpdateVMConfigResponse struct {
 	Response
 }
 
+// UpdateVMTagsRequest 更新虚拟机标签请求
+type UpdateVMTagsRequest struct {
+	VMID int64  `json:"vm_id" binding:"required" example:"1"` // 虚拟机ID（数据库ID）
+	Tags string `json:"tags" example:"web;prod"`              // 分号、逗号或空格分隔，为空时清除标签
+}
+
 // GetVMStatusRequest 获取虚拟机状态请求
 type GetVMStatusRequest struct {
 	VMID int64 `form:"vm_id" binding:"required" example:"1"` // 虚拟机ID（数据库ID）
@@ -58,3 +64,58 @@ type GetVMConsoleResponse struct {
 	Response
 	Data map[string]interface{} `json:"data"`
 }
+
+// VMConsoleSessionData 控制台会话信息
+// 会话在获取 Console 时创建，空闲超时前可续期；ws_token 仍为单次使用，断线后通过 reconnect 获取新的 ws_token
+type VMConsoleSessionData struct {
+	SessionID string `json:"session_id"`
+	VMID      int64  `json:"vm_id"`
+	ExpiresAt int64  `json:"session_expires_at"` // Unix 秒
+}
+
+// RenewVMConsoleSessionResponse 续期控制台会话响应
+type RenewVMConsoleSessionResponse struct {
+	Response
+	Data VMConsoleSessionData `json:"data"`
+}
+
+// ResizeVMMemoryRequest 调整虚拟机内存与 ballooning 配置请求，不传的字段保持不变
+type ResizeVMMemoryRequest struct {
+	VMID       int64 `json:"vm_id" binding:"required" example:"1"`                            // 虚拟机ID（数据库ID）
+	MemorySize *int  `json:"memory_size,omitempty" binding:"omitempty,min=16" example:"8192"` // 最大内存 MB（Proxmox memory）
+	// BalloonMin 最小内存 MB（Proxmox balloon），0 表示关闭 ballooning；大于 0 时不能超过 memory_size
+	BalloonMin *int `json:"balloon_min,omitempty" binding:"omitempty,min=0" example:"2048"`
+	// Shares 自动 ballooning 的内存分配权重（Proxmox shares，默认 1000），0 表示不参与自动 ballooning
+	Shares *int `json:"shares,omitempty" binding:"omitempty,min=0,max=50000" example:"1000"`
+}
+
+// ResizeVMMemoryResponseData 调整后的内存配置
+type ResizeVMMemoryResponseData struct {
+	MemorySize int `json:"memory_size"` // MB
+	BalloonMin int `json:"balloon_min"` // MB，0 表示关闭 ballooning
+	Shares     int `json:"shares"`
+	// PendingReboot 运行中的虚拟机未启用内存热插拔（hotplug 含 memory 且 numa=1）时，最大内存在重启后生效
+	PendingReboot bool `json:"pending_reboot"`
+}
+
+// ResizeVMMemoryResponse 调整虚拟机内存响应
+type ResizeVMMemoryResponse struct {
+	Response
+	Data ResizeVMMemoryResponseData
+}
+
+// VMBalloonMetrics 虚拟机 ballooning 指标，随 /api/v1/vms/status 以 balloon_metrics 返回；
+// guest_* 和 swapped_*、major_faults 来自 guest balloon 驱动上报，未上报时 driver_reported 为 false
+type VMBalloonMetrics struct {
+	Enabled        bool  `json:"enabled"`
+	MaxMemMB       int64 `json:"max_mem_mb"`      // 最大内存
+	MinMemMB       int64 `json:"min_mem_mb"`      // balloon 最小内存
+	Shares         int64 `json:"shares"`          // 自动 ballooning 权重
+	ActualMB       int64 `json:"actual_mb"`       // 当前实际分配给 guest 的内存
+	DriverReported bool  `json:"driver_reported"` // guest balloon 驱动是否上报了统计
+	GuestTotalMB   int64 `json:"guest_total_mb"`  // guest 可见内存
+	GuestFreeMB    int64 `json:"guest_free_mb"`   // guest 空闲内存
+	SwappedInMB    int64 `json:"swapped_in_mb"`   // guest 换入累计
+	SwappedOutMB   int64 `json:"swapped_out_mb"`  // guest 换出累计
+	MajorFaults    int64 `json:"major_faults"`    // guest 主缺页次数
+}
@@ -1,5 +1,7 @@
 package v1
 
+import "time"
+
 // PveVMMigrate 相关 API 定义
 
 // MigrateVMRequest 同集群迁移虚拟机请求
@@ -12,6 +14,7 @@ type MigrateVMRequest struct {
 	MigrationType    string `json:"migration_type,omitempty" example:"secure"`         // 迁移类型：secure（默认）或 insecure
 	MigrationNetwork string `json:"migration_network,omitempty" example:"10.0.0.0/24"` // 迁移网络CIDR
 	MapStorage       string `json:"map_storage,omitempty" example:"local:shared"`      // 存储映射，格式：FROM:TO
+	Force            *bool  `json:"force,omitempty" example:"false"`                   // 忽略兼容性检查发现的阻断问题，强制迁移
 }
 
 // MigrateVMResponse 同集群迁移虚拟机响应
@@ -29,8 +32,22 @@ type RemoteMigrateVMRequest struct {
 	TargetStorage   string `json:"target_storage" binding:"required" example:"local-lvm"` // 目标存储（必填）
 	TargetVMID      *int64 `json:"target_vmid,omitempty" example:"200"`                   // 目标虚拟机ID（可选，不指定则使用源VMID）
 	Online          *bool  `json:"online,omitempty" example:"true"`                       // 是否在线迁移
-	Bwlimit         *int   `json:"bwlimit,omitempty" example:"1000"`                      // 带宽限制（KiB/s）
+	Bwlimit         *int   `json:"bwlimit,omitempty" example:"1000"`                      // 带宽限制（KiB/s），未指定时依次使用当前迁移窗口的 bwlimit、站点的 cross_site_bwlimit
 	Delete          *bool  `json:"delete,omitempty" example:"false"`                      // 迁移成功后是否删除源VM（默认false）
+	Force           *bool  `json:"force,omitempty" example:"false"`                       // 忽略兼容性检查和预检发现的阻断问题，强制迁移
+
+	// 迁移完成后的切换：ReIP 中的网卡在目标网段重新分配地址并更新 cloud-init，其余地址保持不变；
+	// 配置了 remote_migration.dns_webhook 时默认通知 DNS，UpdateDNS=false 时跳过
+	ReIP      []RemoteMigrateReIP `json:"re_ip,omitempty" binding:"omitempty,dive"`
+	UpdateDNS *bool               `json:"update_dns,omitempty" example:"true"`
+}
+
+// RemoteMigrateReIP 跨集群迁移后需要更换地址的网卡
+type RemoteMigrateReIP struct {
+	NicName     string `json:"nic_name" binding:"required" example:"net0"`           // 网卡，对应 cloud-init 的 ipconfigN
+	Subnet      string `json:"subnet" binding:"required,cidr" example:"10.2.0.0/24"` // 目标网段
+	Gateway     string `json:"gateway,omitempty" binding:"omitempty,ip" example:"10.2.0.1"`
+	IPAddressID *int64 `json:"ip_address_id,omitempty" example:"12"` // 指定目标集群中空闲的 IP 地址记录，不指定时从网段内空闲地址中分配
 }
 
 // RemoteMigrateVMResponse 跨集群迁移虚拟机响应
@@ -38,3 +55,138 @@ type RemoteMigrateVMResponse struct {
 	Response
 	Data string `json:"data"` // UPID (任务ID)
 }
+
+// RemoteMigratePreflightData 跨集群迁移预检结果
+// 阻断问题：target_unreachable、target_storage、storage_insufficient、bridge_missing、fingerprint_changed、
+// fingerprint_unavailable、target_privileges、source_privileges、vmid_in_use；
+// 警告：privileges_unknown、source_config_unavailable、bridge_unknown、vmid_unknown
+type RemoteMigratePreflightData struct {
+	VmID            int64                 `json:"vm_id"`
+	VMID            uint32                `json:"vmid"`
+	TargetClusterID int64                 `json:"target_cluster_id"`
+	TargetNode      string                `json:"target_node"`
+	TargetVMID      uint32                `json:"target_vmid"`
+	RequiredBytes   int64                 `json:"required_bytes"`           // 需要复制到目标存储的磁盘总大小
+	Bwlimit         int                   `json:"bwlimit"`                  // 现在发起迁移时使用的带宽限制（KiB/s），0 表示不限制
+	BwlimitSource   string                `json:"bwlimit_source,omitempty"` // request / window / cross_site
+	Window          string                `json:"window,omitempty"`         // 当前所在的迁移窗口
+	Passed          bool                  `json:"passed"`                   // 无阻断问题
+	Blockers        []MigrationCheckIssue `json:"blockers"`
+	Warnings        []MigrationCheckIssue `json:"warnings"`
+}
+
+type RemoteMigratePreflightResponse struct {
+	Response
+	Data RemoteMigratePreflightData
+}
+
+// ScheduleRemoteMigrationRequest 计划跨集群迁移：在指定时间或下一个迁移窗口开始时发起，
+// 同时指定时在 scheduled_at 之后的第一个窗口发起
+type ScheduleRemoteMigrationRequest struct {
+	RemoteMigrateVMRequest
+	Window      string     `json:"window,omitempty" example:"night"` // remote_migration.windows 中的窗口名称
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty" example:"2026-01-02T22:00:00+08:00"`
+}
+
+// RemoteMigrationJobItem 计划的跨集群迁移
+type RemoteMigrationJobItem struct {
+	Id              int64                       `json:"id"`
+	VmId            int64                       `json:"vm_id"`
+	VMID            uint32                      `json:"vmid"`
+	VmName          string                      `json:"vm_name"`
+	ClusterID       int64                       `json:"cluster_id"`
+	TargetClusterID int64                       `json:"target_cluster_id"`
+	TargetNodeID    int64                       `json:"target_node_id"`
+	Window          string                      `json:"window"`
+	ScheduledAt     time.Time                   `json:"scheduled_at"`
+	Bwlimit         int                         `json:"bwlimit"` // 请求或迁移窗口指定的带宽限制（KiB/s），0 表示按站点配置或不限制
+	Status          string                      `json:"status"`  // scheduled / running / started / failed / cancelled
+	UPID            string                      `json:"upid"`
+	ErrorMessage    string                      `json:"error_message"`
+	StartTime       *time.Time                  `json:"start_time"`
+	Creator         string                      `json:"creator"`
+	CreateTime      time.Time                   `json:"create_time"`
+	Preflight       *RemoteMigratePreflightData `json:"preflight,omitempty"` // 计划时的预检结果
+}
+
+type RemoteMigrationJobResponse struct {
+	Response
+	Data RemoteMigrationJobItem
+}
+
+type ListRemoteMigrationJobsRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"20"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	VmId      int64  `form:"vm_id" example:"1"`
+	Status    string `form:"status" binding:"omitempty,oneof=scheduled running started failed cancelled" example:"scheduled"`
+}
+
+type ListRemoteMigrationJobsResponseData struct {
+	Total int64                    `json:"total"`
+	List  []RemoteMigrationJobItem `json:"list"`
+}
+
+type ListRemoteMigrationJobsResponse struct {
+	Response
+	Data ListRemoteMigrationJobsResponseData
+}
+
+// RemoteMigrationCutoverStep 切换步骤结果
+type RemoteMigrationCutoverStep struct {
+	Step    string `json:"step"`    // inventory / ip / cloudinit / mac / dns
+	Status  string `json:"status"`  // ok / skipped / failed
+	Message string `json:"message"` // 说明，如分配的地址或失败原因
+}
+
+// RemoteMigrationCutoverAddress 切换前后的地址
+type RemoteMigrationCutoverAddress struct {
+	NicName     string `json:"nic_name"`
+	OldAddress  string `json:"old_address"`
+	NewAddress  string `json:"new_address"`
+	IPAddressID int64  `json:"ip_address_id"`
+}
+
+// RemoteMigrationCutoverItem 跨集群迁移切换：迁移任务成功后更新虚拟机归属、IP 登记、cloud-init 和 DNS
+type RemoteMigrationCutoverItem struct {
+	Id              int64                           `json:"id"`
+	VmId            int64                           `json:"vm_id"`
+	VmName          string                          `json:"vm_name"`
+	SourceClusterID int64                           `json:"source_cluster_id"`
+	SourceNode      string                          `json:"source_node"`
+	SourceVMID      uint32                          `json:"source_vmid"`
+	TargetClusterID int64                           `json:"target_cluster_id"`
+	TargetNodeID    int64                           `json:"target_node_id"`
+	TargetNode      string                          `json:"target_node"`
+	TargetVMID      uint32                          `json:"target_vmid"`
+	UPID            string                          `json:"upid"`   // 迁移任务
+	Status          string                          `json:"status"` // waiting / completed / failed
+	Steps           []RemoteMigrationCutoverStep    `json:"steps"`
+	Addresses       []RemoteMigrationCutoverAddress `json:"addresses"`
+	ErrorMessage    string                          `json:"error_message"`
+	EndTime         *time.Time                      `json:"end_time"`
+	Creator         string                          `json:"creator"`
+	CreateTime      time.Time                       `json:"create_time"`
+}
+
+type RemoteMigrationCutoverResponse struct {
+	Response
+	Data RemoteMigrationCutoverItem
+}
+
+type ListRemoteMigrationCutoversRequest struct {
+	Page     int    `form:"page" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,max=100" example:"20"`
+	VmId     int64  `form:"vm_id" example:"1"`
+	Status   string `form:"status" binding:"omitempty,oneof=waiting completed failed" example:"failed"`
+}
+
+type ListRemoteMigrationCutoversResponseData struct {
+	Total int64                        `json:"total"`
+	List  []RemoteMigrationCutoverItem `json:"list"`
+}
+
+type ListRemoteMigrationCutoversResponse struct {
+	Response
+	Data ListRemoteMigrationCutoversResponseData
+}
@@ -0,0 +1,81 @@
+package v1
+
+import "time"
+
+// 集群负载再平衡相关 API 定义
+// 按节点 CPU / 内存利用率的差值判断集群是否失衡，贪心选出最少的在线迁移使差值降到阈值以下；
+// 方案确认后一键执行，迁移逐个进行并受带宽限制。
+
+// CreateRebalancePlanRequest 立即计算集群再平衡方案请求
+type CreateRebalancePlanRequest struct {
+	ClusterID int64 `json:"cluster_id" binding:"required" example:"1"`
+}
+
+// ApplyRebalancePlanRequest 执行再平衡方案请求
+type ApplyRebalancePlanRequest struct {
+	Bwlimit *int `json:"bwlimit,omitempty" binding:"omitempty,min=0" example:"102400"` // 每次迁移的带宽限制（KiB/s），不传时使用 rebalance.bwlimit，0 表示不限速
+}
+
+// ListRebalancePlansRequest 再平衡方案列表请求
+type ListRebalancePlansRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending applying completed failed superseded" example:"pending"`
+}
+
+// RebalanceMoveItem 方案中的单次迁移
+type RebalanceMoveItem struct {
+	VmId         int64   `json:"vm_id"`
+	VMID         uint32  `json:"vmid"`
+	VmName       string  `json:"vm_name"`
+	SourceNodeID int64   `json:"source_node_id"`
+	SourceNode   string  `json:"source_node"`
+	TargetNodeID int64   `json:"target_node_id"`
+	TargetNode   string  `json:"target_node"`
+	CPU          float64 `json:"cpu"`    // 当前占用的 CPU 核数
+	Memory       int64   `json:"memory"` // 当前占用的内存（字节）
+	Status       string  `json:"status"` // pending, running, completed, failed, skipped
+	UPID         string  `json:"upid"`
+	ErrorMessage string  `json:"error_message,omitempty"`
+}
+
+// RebalancePlanItem 再平衡方案信息
+type RebalancePlanItem struct {
+	Id              int64               `json:"id"`
+	ClusterID       int64               `json:"cluster_id"`
+	ClusterName     string              `json:"cluster_name"`
+	Source          string              `json:"source"`            // scheduled, manual
+	CPUSpreadBefore float64             `json:"cpu_spread_before"` // 节点 CPU 利用率最高与最低之差（0-1）
+	MemSpreadBefore float64             `json:"mem_spread_before"` // 节点内存利用率最高与最低之差（0-1）
+	CPUSpreadAfter  float64             `json:"cpu_spread_after"`  // 执行方案后的预估值
+	MemSpreadAfter  float64             `json:"mem_spread_after"`
+	Status          string              `json:"status"` // pending, applying, completed, failed, superseded
+	TotalMoves      int                 `json:"total_moves"`
+	DoneMoves       int                 `json:"done_moves"`
+	Moves           []RebalanceMoveItem `json:"moves"`
+	Bwlimit         int                 `json:"bwlimit"`
+	AppliedBy       string              `json:"applied_by"`
+	StartTime       *time.Time          `json:"start_time"`
+	EndTime         *time.Time          `json:"end_time"`
+	ErrorMessage    string              `json:"error_message"`
+	Creator         string              `json:"creator"`
+	CreateTime      time.Time           `json:"create_time"`
+}
+
+// GetRebalancePlanResponse 再平衡方案详情响应
+type GetRebalancePlanResponse struct {
+	Response
+	Data RebalancePlanItem
+}
+
+// ListRebalancePlansResponse 再平衡方案列表响应
+type ListRebalancePlansResponse struct {
+	Response
+	Data ListRebalancePlansResponseData
+}
+
+type ListRebalancePlansResponseData struct {
+	Total int64               `json:"total"`
+	List  []RebalancePlanItem `json:"list"`
+}
@@ -0,0 +1,110 @@
+package v1
+
+import "time"
+
+// 定期报告相关 API 定义
+// 管理员配置报告计划（频率、内容、接收人），到期后汇总容量、备份成功率、合规得分趋势，
+// 以站内通知发送给接收人，并在配置了 SMTP 时发送带 XLSX 附件的邮件。
+
+// CreateReportScheduleRequest 创建定期报告计划请求
+type CreateReportScheduleRequest struct {
+	Name       string   `json:"name" binding:"required,max=100" example:"每周容量与合规周报"`
+	ClusterID  int64    `json:"cluster_id" example:"0"`                                                                                       // 集群ID，0 表示全部集群
+	Sections   []string `json:"sections" binding:"required,min=1,dive,oneof=capacity backup compliance" example:"capacity,backup,compliance"` // 报告内容
+	Frequency  string   `json:"frequency" binding:"required,oneof=daily weekly monthly" example:"weekly"`
+	Weekday    *int     `json:"weekday,omitempty" binding:"omitempty,min=0,max=6" example:"1"`           // weekly：星期（0=周日 ... 6=周六），默认周一
+	MonthDay   *int     `json:"month_day,omitempty" binding:"omitempty,min=1,max=31" example:"1"`        // monthly：日期，超过当月天数时为月末，默认 1 号
+	SendTime   string   `json:"send_time" binding:"required" example:"08:00"`                            // 发送时间 HH:MM
+	Timezone   string   `json:"timezone,omitempty" example:"Asia/Shanghai"`                              // IANA 时区，默认服务器本地时区
+	Recipients []string `json:"recipients" binding:"omitempty,max=100" example:"admin,alice"`            // 接收人用户名：站内通知，并发送邮件到用户邮箱
+	Emails     []string `json:"emails" binding:"omitempty,max=100,dive,email" example:"ops@example.com"` // 额外的邮件地址
+	IsEnabled  *int8    `json:"is_enabled,omitempty" example:"1"`                                        // 默认启用
+}
+
+// UpdateReportScheduleRequest 更新定期报告计划请求（整体替换）
+type UpdateReportScheduleRequest = CreateReportScheduleRequest
+
+// ListReportSchedulesRequest 定期报告计划列表请求
+type ListReportSchedulesRequest struct {
+	Page     int `form:"page" example:"1"`
+	PageSize int `form:"page_size" binding:"omitempty,max=100" example:"10"`
+}
+
+// ReportScheduleItem 定期报告计划
+type ReportScheduleItem struct {
+	Id          int64      `json:"id"`
+	Name        string     `json:"name"`
+	ClusterID   int64      `json:"cluster_id"`
+	Sections    []string   `json:"sections"`
+	Frequency   string     `json:"frequency"`
+	Weekday     int        `json:"weekday"`
+	MonthDay    int        `json:"month_day"`
+	SendTime    string     `json:"send_time"`
+	Timezone    string     `json:"timezone"`
+	Recipients  []string   `json:"recipients"`
+	Emails      []string   `json:"emails"`
+	IsEnabled   int8       `json:"is_enabled"`
+	LastRunTime *time.Time `json:"last_run_time"`
+	NextRunTime *time.Time `json:"next_run_time"`
+	Creator     string     `json:"creator"`
+	Modifier    string     `json:"modifier"`
+	CreateTime  time.Time  `json:"create_time"`
+	UpdateTime  time.Time  `json:"update_time"`
+}
+
+// GetReportScheduleResponse 定期报告计划详情响应
+type GetReportScheduleResponse struct {
+	Response
+	Data ReportScheduleItem
+}
+
+type ListReportSchedulesResponseData struct {
+	Total int64                `json:"total"`
+	List  []ReportScheduleItem `json:"list"`
+}
+
+// ListReportSchedulesResponse 定期报告计划列表响应
+type ListReportSchedulesResponse struct {
+	Response
+	Data ListReportSchedulesResponseData
+}
+
+// ListReportRunsRequest 报告发送记录列表请求
+type ListReportRunsRequest struct {
+	Page     int `form:"page" example:"1"`
+	PageSize int `form:"page_size" binding:"omitempty,max=100" example:"10"`
+}
+
+// ReportRunItem 报告发送记录
+type ReportRunItem struct {
+	Id                int64     `json:"id"`
+	ScheduleID        int64     `json:"schedule_id"`
+	PeriodStart       time.Time `json:"period_start"`
+	PeriodEnd         time.Time `json:"period_end"`
+	Status            string    `json:"status"`              // sent / partial / failed
+	ComplianceScore   *float64  `json:"compliance_score"`    // 合规得分（0-100），报告不含合规内容时为空
+	BackupSuccessRate *float64  `json:"backup_success_rate"` // 备份成功率（0-100），没有备份任务时为空
+	Summary           string    `json:"summary"`
+	Notified          int       `json:"notified"` // 站内通知人数
+	Emailed           int       `json:"emailed"`  // 邮件收件人数
+	ErrorMessage      string    `json:"error_message"`
+	Operator          string    `json:"operator"` // 手动发送的操作人，定时发送为空
+	CreateTime        time.Time `json:"create_time"`
+}
+
+// SendReportResponse 立即发送报告响应
+type SendReportResponse struct {
+	Response
+	Data ReportRunItem
+}
+
+type ListReportRunsResponseData struct {
+	Total int64           `json:"total"`
+	List  []ReportRunItem `json:"list"`
+}
+
+// ListReportRunsResponse 报告发送记录列表响应
+type ListReportRunsResponse struct {
+	Response
+	Data ListReportRunsResponseData
+}
@@ -0,0 +1,26 @@
+package v1
+
+import "time"
+
+// 数据库表结构版本相关 API 定义
+
+// SchemaMigrationItem 版本化表结构迁移
+type SchemaMigrationItem struct {
+	Version   int64      `json:"version" example:"1"`
+	Name      string     `json:"name" example:"initial_schema"`
+	Status    string     `json:"status" example:"applied"` // applied / pending
+	AppliedAt *time.Time `json:"applied_at"`               // 未执行时为空
+}
+
+// SchemaVersionData 数据库表结构版本
+type SchemaVersionData struct {
+	CurrentVersion int64                 `json:"current_version" example:"1"` // 已执行的最大版本
+	LatestVersion  int64                 `json:"latest_version" example:"1"`  // 当前程序支持的最新版本
+	AutoMigrate    bool                  `json:"auto_migrate"`                // server 启动时是否自动迁移（migration.auto_migrate）
+	Migrations     []SchemaMigrationItem `json:"migrations"`
+}
+
+type GetSchemaVersionResponse struct {
+	Response
+	Data SchemaVersionData
+}
@@ -0,0 +1,37 @@
+package v1
+
+// 全局搜索相关 API 定义
+
+// GlobalSearchRequest 全局搜索请求
+type GlobalSearchRequest struct {
+	Q         string `form:"q" binding:"required,max=128" example:"web"`         // 关键字：虚拟机名称/VMID/IP/标签、节点名称/IP、模板名称、存储名称、任务 UPID/类型/用户
+	Types     string `form:"types" example:"vm,node"`                            // 搜索范围（可选，逗号分隔）：vm, node, template, storage, task；为空表示全部
+	ClusterID int64  `form:"cluster_id" example:"1"`                             // 限定集群（可选）
+	Limit     int    `form:"limit" binding:"omitempty,min=1,max=50" example:"5"` // 每种类型最多返回条数（默认 5）
+}
+
+// SearchResultItem 搜索结果项
+type SearchResultItem struct {
+	Type         string `json:"type"`                // 结果类型：vm, node, template, storage, task
+	ID           int64  `json:"id,omitempty"`        // 数据库ID（任务无）
+	Key          string `json:"key"`                 // 业务标识：虚拟机为 VMID，任务为 UPID，其它为名称
+	Title        string `json:"title"`               // 展示标题
+	Subtitle     string `json:"subtitle,omitempty"`  // 展示副标题
+	ClusterID    int64  `json:"cluster_id"`          // 集群ID
+	ClusterName  string `json:"cluster_name"`        // 集群名称
+	NodeName     string `json:"node_name,omitempty"` // 节点名称
+	Status       string `json:"status,omitempty"`    // 状态
+	MatchedField string `json:"matched_field"`       // 命中字段：name, vmid, ip, tag, description, type, upid, user
+}
+
+// GlobalSearchResponse 全局搜索响应
+type GlobalSearchResponse struct {
+	Response
+	Data GlobalSearchResponseData
+}
+
+type GlobalSearchResponseData struct {
+	Query string             `json:"query"`
+	Total int                `json:"total"`
+	Items []SearchResultItem `json:"items"` // 按类型分组、组内精确匹配优先
+}
@@ -0,0 +1,123 @@
+package v1
+
+// 存储浏览器相关 API 定义
+// 在节点存储内容列表的基础上按目录层级浏览：根目录按内容类型分组，images / rootdir 下再按 VMID 分组；
+// 虚拟机磁盘卷标注引用它的虚拟机和配置项，未被任何虚拟机 / 容器配置引用的磁盘标记为孤儿卷。
+// 批量删除分两步：先 prepare 校验所选卷并返回确认令牌，再携带令牌执行删除。
+
+// BrowseStorageRequest 浏览存储请求
+type BrowseStorageRequest struct {
+	NodeID  int64  `form:"node_id" binding:"required" example:"1"`     // 节点ID
+	Storage string `form:"storage" binding:"required" example:"local"` // 存储名称
+	Path    string `form:"path" example:"images/100"`                  // 目录路径：空为根目录，如 images、images/100、iso
+	// OrphanedOnly 只返回孤儿卷（根目录和分组目录的统计不受影响）
+	OrphanedOnly bool `form:"orphaned_only" example:"false"`
+}
+
+// StorageBrowserEntry 目录项（内容类型分组或虚拟机分组）
+type StorageBrowserEntry struct {
+	Name        string `json:"name"`              // images / iso / backup / 100 ...
+	Path        string `json:"path"`              // 进入该目录时使用的 path
+	Content     string `json:"content"`           // 内容类型
+	VMID        uint32 `json:"vmid,omitempty"`    // 虚拟机分组的 VMID
+	VmId        int64  `json:"vm_id,omitempty"`   // 平台虚拟机ID（未纳管时为 0）
+	VmName      string `json:"vm_name,omitempty"` // 平台虚拟机名称
+	VolumeCount int    `json:"volume_count"`
+	TotalSize   int64  `json:"total_size"`
+	OrphanCount int    `json:"orphan_count"`
+	OrphanSize  int64  `json:"orphan_size"`
+}
+
+// StorageBrowserVolume 存储卷
+type StorageBrowserVolume struct {
+	VolID      string `json:"volid"`
+	Name       string `json:"name"` // 卷文件名
+	Content    string `json:"content"`
+	Format     string `json:"format"`
+	Size       int64  `json:"size"`
+	Used       int64  `json:"used,omitempty"`
+	CTime      int64  `json:"ctime,omitempty"`
+	VMID       uint32 `json:"vmid,omitempty"`         // 卷名中的 VMID（所有者）
+	Protected  bool   `json:"protected,omitempty"`    // 受保护的备份
+	Notes      string `json:"notes,omitempty"`        // 备份备注
+	UsedByVMID uint32 `json:"used_by_vmid,omitempty"` // 引用该卷的虚拟机 VMID（链接克隆时可能与所有者不同）
+	UsedByNode string `json:"used_by_node,omitempty"`
+	UsedByKey  string `json:"used_by_key,omitempty"` // 引用该卷的配置项：scsi0 / unused0 / rootfs / base（链接克隆基础镜像）
+	VmId       int64  `json:"vm_id,omitempty"`       // 平台虚拟机ID（未纳管时为 0）
+	VmName     string `json:"vm_name,omitempty"`
+	Orphaned   bool   `json:"orphaned"` // 磁盘卷未被任何虚拟机 / 容器配置引用
+}
+
+// BrowseStorageResponseData 浏览存储结果
+type BrowseStorageResponseData struct {
+	NodeID      int64                  `json:"node_id"`
+	NodeName    string                 `json:"node_name"`
+	Storage     string                 `json:"storage"`
+	Path        string                 `json:"path"`
+	Parent      string                 `json:"parent"` // 上级目录 path，根目录为空
+	Entries     []StorageBrowserEntry  `json:"entries"`
+	Volumes     []StorageBrowserVolume `json:"volumes"`
+	VolumeCount int                    `json:"volume_count"` // 当前目录（含子目录）卷总数
+	TotalSize   int64                  `json:"total_size"`
+	OrphanCount int                    `json:"orphan_count"`
+	OrphanSize  int64                  `json:"orphan_size"`
+}
+
+// BrowseStorageResponse 浏览存储响应
+type BrowseStorageResponse struct {
+	Response
+	Data BrowseStorageResponseData
+}
+
+// PrepareStorageDeleteRequest 批量删除预检请求
+type PrepareStorageDeleteRequest struct {
+	NodeID  int64    `json:"node_id" binding:"required" example:"1"`
+	Storage string   `json:"storage" binding:"required" example:"local-lvm"`
+	Volumes []string `json:"volumes" binding:"required,min=1,max=200,dive,required" example:"local-lvm:vm-100-disk-1"`
+	// AllowInUse 允许删除仍被虚拟机配置引用的磁盘卷（默认拒绝）
+	AllowInUse bool `json:"allow_in_use" example:"false"`
+}
+
+// PrepareStorageDeleteResponseData 批量删除预检结果
+type PrepareStorageDeleteResponseData struct {
+	ConfirmToken string                 `json:"confirm_token"` // 确认令牌，单次使用
+	ExpiresAt    int64                  `json:"expires_at"`    // 令牌过期时间（Unix 秒）
+	NodeName     string                 `json:"node_name"`
+	Storage      string                 `json:"storage"`
+	Volumes      []StorageBrowserVolume `json:"volumes"`
+	TotalSize    int64                  `json:"total_size"`
+	InUseCount   int                    `json:"in_use_count"` // 仍被引用的磁盘卷数（仅 allow_in_use 时可能大于 0）
+}
+
+// PrepareStorageDeleteResponse 批量删除预检响应
+type PrepareStorageDeleteResponse struct {
+	Response
+	Data PrepareStorageDeleteResponseData
+}
+
+// ConfirmStorageDeleteRequest 批量删除执行请求
+type ConfirmStorageDeleteRequest struct {
+	ConfirmToken string `json:"confirm_token" binding:"required" example:"3f2a..."`
+}
+
+// StorageDeleteResult 单个卷的删除结果
+type StorageDeleteResult struct {
+	VolID        string `json:"volid"`
+	Size         int64  `json:"size"`
+	Deleted      bool   `json:"deleted"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// ConfirmStorageDeleteResponseData 批量删除执行结果
+type ConfirmStorageDeleteResponseData struct {
+	Deleted   int                   `json:"deleted"`
+	Failed    int                   `json:"failed"`
+	FreedSize int64                 `json:"freed_size"`
+	Results   []StorageDeleteResult `json:"results"`
+}
+
+// ConfirmStorageDeleteResponse 批量删除执行响应
+type ConfirmStorageDeleteResponse struct {
+	Response
+	Data ConfirmStorageDeleteResponseData
+}
@@ -0,0 +1,52 @@
+package v1
+
+// 存储空间预估相关 API 定义
+// 备份、克隆、恢复开始前按磁盘大小和历史压缩比估算目标存储需要的空间：
+// - backup：虚拟机磁盘总大小 × 压缩比，压缩比优先取该虚拟机在目标存储上最近几次备份的实际值，否则使用按压缩方式配置的默认值
+// - clone：完整克隆需要的磁盘总大小
+// - restore：备份文件大小 ÷ 压缩比（备份对应的虚拟机仍存在时直接使用其磁盘总大小）
+// 预估占用超过可用空间时为 insufficient，超过可用空间的 warn_ratio 时为 warning
+
+// 预估结论
+const (
+	StorageForecastOK           = "ok"
+	StorageForecastWarning      = "warning"
+	StorageForecastInsufficient = "insufficient"
+	StorageForecastUnknown      = "unknown" // 无法预估（如 PBS 存储去重后占用无法推算）
+)
+
+// 压缩比来源
+const (
+	CompressionRatioHistory = "history"
+	CompressionRatioDefault = "default"
+)
+
+// StorageForecastRequest 存储空间预估请求
+type StorageForecastRequest struct {
+	Operation string `json:"operation" binding:"required,oneof=backup clone restore" example:"backup"`
+	VmID      int64  `json:"vm_id,omitempty" example:"1"`                                      // backup / clone：虚拟机记录ID
+	NodeID    int64  `json:"node_id,omitempty" example:"1"`                                    // restore：执行恢复的节点（必填）；clone：目标节点，默认与虚拟机相同
+	Archive   string `json:"archive,omitempty" example:"local:backup/vzdump-qemu-100.vma.zst"` // restore：备份卷 ID
+	Storage   string `json:"storage" binding:"required" example:"local"`                       // 目标存储
+	Compress  string `json:"compress,omitempty" example:"zstd"`                                // backup：压缩方式 zstd / lzo / gzip，为空表示不压缩
+}
+
+// StorageForecastData 存储空间预估结果
+type StorageForecastData struct {
+	Operation      string  `json:"operation"`
+	NodeName       string  `json:"node_name"`
+	Storage        string  `json:"storage"`
+	SourceBytes    int64   `json:"source_bytes"`              // backup / clone 为磁盘总大小，restore 为备份文件大小
+	Ratio          float64 `json:"ratio"`                     // 使用的压缩比（备份大小 / 磁盘大小），clone 为 1
+	RatioSource    string  `json:"ratio_source,omitempty"`    // history / default
+	HistorySamples int     `json:"history_samples,omitempty"` // 计算历史压缩比使用的备份数
+	RequiredBytes  int64   `json:"required_bytes"`            // 预估需要的空间
+	AvailableBytes int64   `json:"available_bytes"`           // 目标存储当前可用空间
+	Verdict        string  `json:"verdict"`                   // ok / warning / insufficient / unknown
+	Message        string  `json:"message"`
+}
+
+type StorageForecastResponse struct {
+	Response
+	Data StorageForecastData
+}
@@ -0,0 +1,115 @@
+package v1
+
+import "time"
+
+// 存储垃圾回收（孤儿磁盘 / 陈旧 ISO / 过期备份）相关 API 定义
+
+// CreateStorageGCScanRequest 发起扫描请求
+type CreateStorageGCScanRequest struct {
+	ClusterID      int64 `json:"cluster_id" binding:"required" example:"1"` // 集群ID
+	IsoMaxAgeDays  *int  `json:"iso_max_age_days,omitempty" example:"90"`   // ISO/容器模板未被引用超过该天数视为陈旧（默认 90，0 表示不检查）
+	BackupKeepLast *int  `json:"backup_keep_last,omitempty" example:"7"`    // 每个虚拟机保留的最近备份数（默认 0 表示不检查）
+}
+
+// CreateStorageGCScanResponse 发起扫描响应
+type CreateStorageGCScanResponse struct {
+	Response
+	Data StorageGCScanItem
+}
+
+// ListStorageGCScansRequest 扫描任务列表请求
+type ListStorageGCScansRequest struct {
+	Page      int   `form:"page" example:"1"`
+	PageSize  int   `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	ClusterID int64 `form:"cluster_id" example:"1"`
+}
+
+// StorageGCScanItem 扫描任务信息
+type StorageGCScanItem struct {
+	Id             int64      `json:"id"`
+	ClusterID      int64      `json:"cluster_id"`
+	IsoMaxAgeDays  int        `json:"iso_max_age_days"`
+	BackupKeepLast int        `json:"backup_keep_last"`
+	Status         string     `json:"status"`
+	ItemCount      int        `json:"item_count"`
+	TotalSize      int64      `json:"total_size"`
+	StartTime      *time.Time `json:"start_time"`
+	EndTime        *time.Time `json:"end_time"`
+	ErrorMessage   string     `json:"error_message"`
+	CreateTime     time.Time  `json:"create_time"`
+}
+
+// ListStorageGCScansResponse 扫描任务列表响应
+type ListStorageGCScansResponse struct {
+	Response
+	Data ListStorageGCScansResponseData
+}
+
+type ListStorageGCScansResponseData struct {
+	Total int64               `json:"total"`
+	List  []StorageGCScanItem `json:"list"`
+}
+
+// ListStorageGCItemsRequest 可回收项列表请求
+type ListStorageGCItemsRequest struct {
+	Page     int    `form:"page" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	ScanID   int64  `form:"scan_id" binding:"required" example:"1"`
+	Reason   string `form:"reason" binding:"omitempty,oneof=orphan_disk stale_iso stale_template old_backup" example:"orphan_disk"` // orphan_disk, stale_iso, stale_template, old_backup
+	Status   string `form:"status" binding:"omitempty,oneof=pending approved ignored deleted failed" example:"pending"`             // pending, approved, ignored, deleted, failed
+}
+
+// StorageGCItem 可回收项信息
+type StorageGCItem struct {
+	Id           int64     `json:"id"`
+	ScanID       int64     `json:"scan_id"`
+	ClusterID    int64     `json:"cluster_id"`
+	NodeName     string    `json:"node_name"`
+	StorageName  string    `json:"storage_name"`
+	VolID        string    `json:"volid"`
+	Content      string    `json:"content"`
+	Format       string    `json:"format"`
+	Size         int64     `json:"size"`
+	VMID         uint32    `json:"vmid"`
+	CTime        int64     `json:"ctime"`
+	Reason       string    `json:"reason"`
+	Status       string    `json:"status"`
+	Reviewer     string    `json:"reviewer"`
+	ErrorMessage string    `json:"error_message"`
+	UpdateTime   time.Time `json:"update_time"`
+}
+
+// ListStorageGCItemsResponse 可回收项列表响应
+type ListStorageGCItemsResponse struct {
+	Response
+	Data ListStorageGCItemsResponseData
+}
+
+type ListStorageGCItemsResponseData struct {
+	Total int64           `json:"total"`
+	List  []StorageGCItem `json:"list"`
+}
+
+// ReviewStorageGCItemsRequest 审核可回收项请求
+type ReviewStorageGCItemsRequest struct {
+	ItemIDs []int64 `json:"item_ids" binding:"required,min=1" example:"1,2"`
+	Action  string  `json:"action" binding:"required,oneof=approve ignore" example:"approve"` // approve：确认可删除，ignore：忽略
+}
+
+// DeleteStorageGCItemsRequest 删除可回收项请求（仅删除已确认的项）
+type DeleteStorageGCItemsRequest struct {
+	ItemIDs []int64 `json:"item_ids" binding:"required,min=1" example:"1,2"`
+}
+
+// DeleteStorageGCItemsResponse 删除可回收项响应
+type DeleteStorageGCItemsResponse struct {
+	Response
+	Data DeleteStorageGCItemsResponseData
+}
+
+type DeleteStorageGCItemsResponseData struct {
+	Deleted int             `json:"deleted"`
+	Failed  int             `json:"failed"`
+	Skipped int             `json:"skipped"` // 未确认、已处理或删除前复核发现不能删除的项（标记为 ignored）
+	Items   []StorageGCItem `json:"items"`
+}
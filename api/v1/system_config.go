@@ -0,0 +1,78 @@
+package v1
+
+import "time"
+
+// 运行时配置（热更新）相关 API 定义
+
+// 配置变更来源
+const (
+	ConfigSourceAPI    = "api"    // 管理接口修改
+	ConfigSourceFile   = "file"   // 管理接口触发重新加载配置文件
+	ConfigSourceSIGHUP = "sighup" // 收到 SIGHUP 信号重新加载配置文件
+)
+
+// RuntimeConfigData 当前生效的可热更新配置
+type RuntimeConfigData struct {
+	LogLevel       string `json:"log_level" example:"info"`        // 日志级别（log.log_level）
+	ProxmoxTimeout string `json:"proxmox_timeout" example:"30s"`   // Proxmox API 请求超时（proxmox.timeout）
+	ProxmoxAPILog  bool   `json:"proxmox_api_log" example:"false"` // 记录所有集群的 Proxmox API 调用（proxmox.api_log.enabled）
+	ConfigFile     string `json:"config_file" example:"config/local.yml"`
+}
+
+type GetRuntimeConfigResponse struct {
+	Response
+	Data RuntimeConfigData
+}
+
+// UpdateRuntimeConfigRequest 修改运行时配置（仅修改提供的字段，重启或重新加载配置文件后以配置文件为准）
+type UpdateRuntimeConfigRequest struct {
+	LogLevel       *string `json:"log_level,omitempty" binding:"omitempty,oneof=debug info warn error" example:"debug"`
+	ProxmoxTimeout *string `json:"proxmox_timeout,omitempty" example:"60s"` // Go duration 格式，范围 1s ~ 10m
+	ProxmoxAPILog  *bool   `json:"proxmox_api_log,omitempty"`
+}
+
+// ConfigChange 单项配置变更
+type ConfigChange struct {
+	Key      string `json:"key" example:"log.log_level"`
+	OldValue string `json:"old_value" example:"info"`
+	NewValue string `json:"new_value" example:"debug"`
+}
+
+// UpdateRuntimeConfigResponseData 修改/重新加载结果
+type UpdateRuntimeConfigResponseData struct {
+	Changes []ConfigChange    `json:"changes"` // 实际发生变化的配置项（无变化时为空）
+	Current RuntimeConfigData `json:"current"`
+}
+
+type UpdateRuntimeConfigResponse struct {
+	Response
+	Data UpdateRuntimeConfigResponseData
+}
+
+// ListConfigAuditRequest 配置变更审计查询
+type ListConfigAuditRequest struct {
+	Page      int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	ConfigKey string `form:"config_key" example:"log.log_level"`
+}
+
+// ConfigAuditItem 配置变更审计记录
+type ConfigAuditItem struct {
+	Id         int64     `json:"id"`
+	Operator   string    `json:"operator"` // 操作人用户名（SIGHUP 触发时为 system）
+	Source     string    `json:"source"`   // api / file / sighup
+	ConfigKey  string    `json:"config_key"`
+	OldValue   string    `json:"old_value"`
+	NewValue   string    `json:"new_value"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+type ListConfigAuditResponseData struct {
+	Total int64              `json:"total"`
+	List  []*ConfigAuditItem `json:"list"`
+}
+
+type ListConfigAuditResponse struct {
+	Response
+	Data ListConfigAuditResponseData
+}
@@ -0,0 +1,158 @@
+package v1
+
+import "time"
+
+// 模板目录相关 API 定义
+// 订阅远程模板目录（HTTPS 上的 JSON 索引），列出其中的社区或内部黄金镜像；安装时由节点直接下载镜像到存储并校验校验和，
+// 然后导入磁盘创建虚拟机、转换为模板，并自动登记到模板管理中。
+//
+// 目录索引格式：
+//
+//	{
+//	  "images": [
+//	    {
+//	      "id": "debian-12",
+//	      "name": "Debian 12 (bookworm)",
+//	      "version": "20260101",
+//	      "description": "Debian 12 generic cloud image",
+//	      "os_type": "l26",
+//	      "url": "https://cdn.example.com/debian-12-genericcloud-amd64.qcow2",
+//	      "format": "qcow2",
+//	      "checksum": "5da2...",
+//	      "checksum_algorithm": "sha512",
+//	      "size": 343932928,
+//	      "cores": 2,
+//	      "memory": 2048,
+//	      "cloud_init": true
+//	    }
+//	  ]
+//	}
+//
+// url 可为相对于索引地址的路径；checksum 为必填，缺少校验和或格式不受支持的镜像会被忽略。
+
+// CreateTemplateCatalogRequest 订阅模板目录请求
+type CreateTemplateCatalogRequest struct {
+	Name        string `json:"name" binding:"required" example:"community"`
+	URL         string `json:"url" binding:"required" example:"https://images.example.com/catalog.json"`
+	Description string `json:"description,omitempty" example:"社区镜像"`
+}
+
+// TemplateCatalogItem 模板目录信息
+type TemplateCatalogItem struct {
+	Id            int64      `json:"id"`
+	Name          string     `json:"name"`
+	URL           string     `json:"url"`
+	Description   string     `json:"description"`
+	Enabled       int8       `json:"enabled"`
+	ImageCount    int        `json:"image_count"`
+	LastSyncTime  *time.Time `json:"last_sync_time"`
+	LastSyncError string     `json:"last_sync_error"`
+	Creator       string     `json:"creator"`
+	CreateTime    time.Time  `json:"create_time"`
+}
+
+// GetTemplateCatalogResponse 模板目录详情响应
+type GetTemplateCatalogResponse struct {
+	Response
+	Data TemplateCatalogItem
+}
+
+// ListTemplateCatalogsResponse 模板目录列表响应
+type ListTemplateCatalogsResponse struct {
+	Response
+	Data []TemplateCatalogItem
+}
+
+// ListCatalogImagesRequest 目录镜像列表请求
+type ListCatalogImagesRequest struct {
+	CatalogID int64  `form:"catalog_id" example:"1"`
+	Keyword   string `form:"keyword" example:"debian"`
+	OSType    string `form:"os_type" example:"l26"`
+}
+
+// CatalogImageItem 目录中的镜像
+type CatalogImageItem struct {
+	Id                int64  `json:"id"`
+	CatalogID         int64  `json:"catalog_id"`
+	CatalogName       string `json:"catalog_name"`
+	ImageKey          string `json:"image_key"`
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	Description       string `json:"description"`
+	OSType            string `json:"os_type"`
+	URL               string `json:"url"`
+	Format            string `json:"format"`
+	Checksum          string `json:"checksum"`
+	ChecksumAlgorithm string `json:"checksum_algorithm"`
+	Size              int64  `json:"size"`
+	Cores             int    `json:"cores"`
+	Memory            int    `json:"memory"`
+	CloudInit         bool   `json:"cloud_init"`
+}
+
+// ListCatalogImagesResponse 目录镜像列表响应
+type ListCatalogImagesResponse struct {
+	Response
+	Data []CatalogImageItem
+}
+
+// InstallCatalogImageRequest 安装目录镜像为模板请求
+type InstallCatalogImageRequest struct {
+	ImageID           int64  `json:"image_id" binding:"required" example:"1"`
+	NodeID            int64  `json:"node_id" binding:"required" example:"1"`             // 执行下载和创建的节点
+	DownloadStorageID int64  `json:"download_storage_id" binding:"required" example:"1"` // 镜像下载到的存储（content 需包含 import）
+	TargetStorageID   int64  `json:"target_storage_id" binding:"required" example:"2"`   // 模板磁盘存储（content 需包含 images）
+	TemplateName      string `json:"template_name,omitempty" example:"debian-12"`        // 不传则使用镜像名称和版本
+	Description       string `json:"description,omitempty" example:"Debian 12 golden image"`
+	Bridge            string `json:"bridge,omitempty" example:"vmbr0"` // 模板网卡桥接，默认 vmbr0
+}
+
+// ListCatalogInstallsRequest 安装任务列表请求
+type ListCatalogInstallsRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	CatalogID int64  `form:"catalog_id" example:"1"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	Status    string `form:"status" example:"running"`
+}
+
+// CatalogInstallItem 安装任务信息
+type CatalogInstallItem struct {
+	Id              int64      `json:"id"`
+	CatalogID       int64      `json:"catalog_id"`
+	ImageID         int64      `json:"image_id"`
+	ImageName       string     `json:"image_name"`
+	ClusterID       int64      `json:"cluster_id"`
+	NodeID          int64      `json:"node_id"`
+	NodeName        string     `json:"node_name"`
+	DownloadStorage string     `json:"download_storage"`
+	TargetStorage   string     `json:"target_storage"`
+	TemplateName    string     `json:"template_name"`
+	FileName        string     `json:"file_name"`
+	VMID            uint32     `json:"vmid"`
+	TemplateID      int64      `json:"template_id"`
+	Status          string     `json:"status"` // pending, running, completed, failed
+	Phase           string     `json:"phase"`  // download, create, convert, register
+	ErrorMessage    string     `json:"error_message"`
+	StartTime       *time.Time `json:"start_time"`
+	EndTime         *time.Time `json:"end_time"`
+	Creator         string     `json:"creator"`
+	CreateTime      time.Time  `json:"create_time"`
+}
+
+// GetCatalogInstallResponse 安装任务详情响应
+type GetCatalogInstallResponse struct {
+	Response
+	Data CatalogInstallItem
+}
+
+// ListCatalogInstallsResponse 安装任务列表响应
+type ListCatalogInstallsResponse struct {
+	Response
+	Data ListCatalogInstallsResponseData
+}
+
+type ListCatalogInstallsResponseData struct {
+	Total int64                `json:"total"`
+	List  []CatalogInstallItem `json:"list"`
+}
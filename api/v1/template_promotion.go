@@ -0,0 +1,94 @@
+package v1
+
+import "time"
+
+// 模板晋级相关 API 定义
+// 模板按集群环境（pve_cluster.env）逐级晋级，默认顺序为 dev -> staging -> prod，可通过 template_promotion.stages 调整。
+// 在 dev 集群验证通过的模板发起晋级申请，管理员审批后通过对象存储跨集群复制（导出 vzdump 备份 -> 导入到目标集群 -> 转换为模板），
+// 并在目标集群登记为新模板。每次晋级记录申请人、审批人、备份 SHA256 以及上一级晋级记录，
+// 非第一环境的模板只有由晋级产生时才能继续晋级，从而保证生产模板的来源可追溯。
+
+// CreateTemplatePromotionRequest 发起模板晋级申请
+type CreateTemplatePromotionRequest struct {
+	TemplateID           int64  `json:"template_id" binding:"required,min=1" example:"1"`               // 待晋级的模板（所在集群为当前环境）
+	TargetClusterID      int64  `json:"target_cluster_id" binding:"required,min=1" example:"2"`         // 目标集群，环境必须是下一环境
+	TargetNodeID         int64  `json:"target_node_id" binding:"required,min=1" example:"5"`            // 恢复模板的节点
+	TargetStorage        string `json:"target_storage" binding:"required" example:"local-lvm"`          // 模板磁盘目标存储
+	StoreID              int64  `json:"store_id" binding:"required,min=1" example:"1"`                  // 中转对象存储
+	StagingStorage       string `json:"staging_storage" binding:"required" example:"backup-nfs"`        // 源集群中转存储
+	TargetStagingStorage string `json:"target_staging_storage,omitempty" example:"backup-nfs-prod"`     // 目标集群中转存储，不传则与源集群相同
+	Reason               string `json:"reason" binding:"max=500" example:"通过 dev 环境回归测试，申请晋级到 staging"` // 申请说明
+}
+
+// ReviewTemplatePromotionRequest 审批模板晋级
+type ReviewTemplatePromotionRequest struct {
+	Comment string `json:"comment" binding:"max=500" example:"已确认测试报告"`
+}
+
+// ListTemplatePromotionsRequest 模板晋级列表请求
+type ListTemplatePromotionsRequest struct {
+	Page       int    `form:"page" example:"1"`
+	PageSize   int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	TemplateID int64  `form:"template_id" example:"1"` // 源模板或晋级产生的模板
+	ClusterID  int64  `form:"cluster_id" example:"1"`  // 源集群或目标集群
+	Status     string `form:"status" binding:"omitempty,oneof=pending_approval rejected replicating completed failed" example:"pending_approval"`
+}
+
+// TemplatePromotionItem 模板晋级记录
+type TemplatePromotionItem struct {
+	Id                   int64      `json:"id"`
+	ParentID             int64      `json:"parent_id"`          // 产生源模板的上一级晋级记录，0 表示源模板为第一环境的原始模板
+	OriginTemplateID     int64      `json:"origin_template_id"` // 晋级链最初的模板
+	TemplateID           int64      `json:"template_id"`
+	TemplateName         string     `json:"template_name"`
+	SourceClusterID      int64      `json:"source_cluster_id"`
+	SourceStage          string     `json:"source_stage"`
+	TargetClusterID      int64      `json:"target_cluster_id"`
+	TargetStage          string     `json:"target_stage"`
+	TargetNodeID         int64      `json:"target_node_id"`
+	TargetNodeName       string     `json:"target_node_name"`
+	TargetStorage        string     `json:"target_storage"`
+	StoreID              int64      `json:"store_id"`
+	StagingStorage       string     `json:"staging_storage"`
+	TargetStagingStorage string     `json:"target_staging_storage"`
+	Status               string     `json:"status"` // pending_approval, rejected, replicating, completed, failed
+	Phase                string     `json:"phase"`  // export, import, register
+	ExportTaskID         int64      `json:"export_task_id"`
+	ImportTaskID         int64      `json:"import_task_id"`
+	ObjectKey            string     `json:"object_key"`
+	Checksum             string     `json:"checksum"`             // 复制所用备份的 SHA256
+	PromotedTemplateID   int64      `json:"promoted_template_id"` // 目标集群中登记的模板
+	PromotedVMID         uint32     `json:"promoted_vmid"`
+	Reason               string     `json:"reason"`
+	Requester            string     `json:"requester"`
+	Reviewer             string     `json:"reviewer"`
+	ReviewComment        string     `json:"review_comment"`
+	ReviewTime           *time.Time `json:"review_time"`
+	ErrorMessage         string     `json:"error_message"`
+	StartTime            *time.Time `json:"start_time"`
+	EndTime              *time.Time `json:"end_time"`
+	CreateTime           time.Time  `json:"create_time"`
+}
+
+// GetTemplatePromotionResponse 模板晋级详情响应
+type GetTemplatePromotionResponse struct {
+	Response
+	Data TemplatePromotionItem
+}
+
+// ListTemplatePromotionsResponse 模板晋级列表响应
+type ListTemplatePromotionsResponse struct {
+	Response
+	Data ListTemplatePromotionsResponseData
+}
+
+type ListTemplatePromotionsResponseData struct {
+	Total int64                   `json:"total"`
+	List  []TemplatePromotionItem `json:"list"`
+}
+
+// GetTemplatePromotionChainResponse 晋级链响应，按环境顺序从最初的晋级到当前记录
+type GetTemplatePromotionChainResponse struct {
+	Response
+	Data []TemplatePromotionItem
+}
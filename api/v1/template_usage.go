@@ -0,0 +1,86 @@
+package v1
+
+import "time"
+
+// 模板老化标记
+const (
+	TemplateFlagUnused  = "unused"   // 超过 unused_months 个月未被克隆
+	TemplateFlagEOL     = "eol"      // 基于已停止维护的操作系统版本
+	TemplateFlagEOLSoon = "eol_soon" // 操作系统将在 90 天内停止维护
+)
+
+// TemplateUsageStats 模板使用统计，模板列表中的 usage 字段
+type TemplateUsageStats struct {
+	CloneCount   int64      `json:"clone_count"`            // 累计克隆次数（包括已删除的虚拟机）
+	RecentClones int64      `json:"recent_clones"`          // 最近 unused_months 个月内的克隆次数
+	ActiveVMs    int64      `json:"active_vms"`             // 现存的由该模板创建的虚拟机数量
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"` // 最近一次克隆时间，从未克隆时为空
+	LastUsedBy   string     `json:"last_used_by,omitempty"` // 最近一次克隆的操作人
+	OSName       string     `json:"os_name,omitempty"`      // 按 EOL 规则识别的操作系统，如 Ubuntu 18.04
+	EOLDate      string     `json:"eol_date,omitempty"`     // 操作系统停止维护日期（YYYY-MM-DD）
+	Flags        []string   `json:"flags"`                  // unused / eol / eol_soon
+}
+
+// TemplateVersionUsage 模板各版本（导入记录）的克隆统计
+type TemplateVersionUsage struct {
+	UploadID     int64      `json:"upload_id"` // 0 表示旧数据或无法确定版本
+	FileName     string     `json:"file_name,omitempty"`
+	ImportedAt   *time.Time `json:"imported_at,omitempty"`
+	CloneCount   int64      `json:"clone_count"`
+	RecentClones int64      `json:"recent_clones"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+}
+
+// TemplateAgingReportRequest 模板老化报告
+type TemplateAgingReportRequest struct {
+	ClusterID    int64 `form:"cluster_id" example:"1"`
+	UnusedMonths int   `form:"unused_months" binding:"omitempty,min=1,max=120" example:"6"` // 默认使用配置 template_usage.unused_months
+	FlaggedOnly  bool  `form:"flagged_only" example:"true"`                                 // 只返回带老化标记的模板
+}
+
+type TemplateAgingItem struct {
+	Id           int64     `json:"id"`
+	TemplateName string    `json:"template_name"`
+	ClusterID    int64     `json:"cluster_id"`
+	ClusterName  string    `json:"cluster_name"`
+	CreateTime   time.Time `json:"create_time"`
+	TemplateUsageStats
+	Versions []TemplateVersionUsage `json:"versions"`
+}
+
+type TemplateAgingReportData struct {
+	UnusedMonths int                  `json:"unused_months"`
+	GeneratedAt  time.Time            `json:"generated_at"`
+	Total        int                  `json:"total"`
+	Flagged      map[string]int       `json:"flagged"` // 按标记统计模板数量
+	Items        []*TemplateAgingItem `json:"items"`   // 带标记的在前，其次按最近使用时间升序（最久未用的在前）
+}
+
+type TemplateAgingReportResponse struct {
+	Response
+	Data TemplateAgingReportData
+}
+
+// TemplateUsageRecord 单次克隆记录
+type TemplateUsageRecord struct {
+	Id         int64     `json:"id"`
+	UploadID   int64     `json:"upload_id"`
+	ClusterID  int64     `json:"cluster_id"`
+	NodeName   string    `json:"node_name"`
+	VmId       int64     `json:"vm_id"`
+	VMID       uint32    `json:"vmid"`
+	VmName     string    `json:"vm_name"`
+	Source     string    `json:"source"` // create / stack
+	Creator    string    `json:"creator"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+type TemplateUsageDetail struct {
+	TemplateAgingItem
+	Recent []TemplateUsageRecord `json:"recent"` // 最近 50 次克隆
+}
+
+type TemplateUsageDetailResponse struct {
+	Response
+	Data TemplateUsageDetail
+}
@@ -0,0 +1,79 @@
+package v1
+
+import "time"
+
+// 拓扑图相关 API 定义
+// /api/v1/topology 以图的形式一次返回集群 -> 节点 -> 虚拟机的完整拓扑，存储和网桥作为节点的附属资源，
+// 前端可直接渲染交互式拓扑图，无需分别调用集群、节点、虚拟机、存储列表接口后在客户端关联。
+// 集群、节点、虚拟机和存储来自平台数据库（控制器同步结果）；网桥需要实时查询 Proxmox，按需通过 include_bridges 开启
+
+// 拓扑图顶点类型
+const (
+	TopologyKindCluster = "cluster"
+	TopologyKindNode    = "node"
+	TopologyKindVM      = "vm"
+	TopologyKindStorage = "storage"
+	TopologyKindBridge  = "bridge"
+)
+
+// 拓扑图边类型
+const (
+	TopologyEdgeContains = "contains" // 集群 -> 节点
+	TopologyEdgeHosts    = "hosts"    // 节点 -> 虚拟机
+	TopologyEdgeAttached = "attached" // 节点 -> 存储 / 网桥
+	TopologyEdgeUses     = "uses"     // 虚拟机 -> 存储（创建时记录的存储）
+)
+
+// GetTopologyRequest 拓扑查询
+type GetTopologyRequest struct {
+	ClusterID        int64 `form:"cluster_id" example:"1"`            // 只返回该集群，默认全部集群
+	IncludeTemplates bool  `form:"include_templates" example:"false"` // 是否包含模板虚拟机
+	IncludeBridges   bool  `form:"include_bridges" example:"false"`   // 是否实时查询各节点的网桥（每个节点一次 Proxmox 调用）
+}
+
+// TopologyVertex 拓扑图顶点，ID 在图内唯一（如 cluster:1、node:3、vm:10、storage:5、bridge:3:vmbr0）
+type TopologyVertex struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`   // cluster / node / vm / storage / bridge
+	RefID     int64  `json:"ref_id"` // 数据库 ID，网桥为 0
+	Name      string `json:"name"`
+	Status    string `json:"status,omitempty"` // 节点 online / offline，虚拟机 running / stopped，存储 active / inactive，网桥 active / inactive
+	ClusterID int64  `json:"cluster_id"`
+	NodeName  string `json:"node_name,omitempty"`
+
+	// 集群
+	Env string `json:"env,omitempty"`
+	// 虚拟机
+	VMID       uint32 `json:"vmid,omitempty"`
+	CPUNum     int    `json:"cpu_num,omitempty"`
+	MemorySize int    `json:"memory_size,omitempty"` // MB
+	Template   bool   `json:"template,omitempty"`
+	Owner      string `json:"owner,omitempty"`
+	// 存储
+	StorageType  string  `json:"storage_type,omitempty"`
+	Shared       bool    `json:"shared,omitempty"` // 共享存储在集群内只有一个顶点，连接到所有挂载它的节点
+	UsedFraction float64 `json:"used_fraction,omitempty"`
+	// 网桥
+	Ports string `json:"ports,omitempty"` // bridge_ports
+	CIDR  string `json:"cidr,omitempty"`
+}
+
+// TopologyEdge 拓扑图的边
+type TopologyEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Kind   string `json:"kind"` // contains / hosts / attached / uses
+}
+
+type TopologyData struct {
+	Vertices    []TopologyVertex `json:"vertices"`
+	Edges       []TopologyEdge   `json:"edges"`
+	Counts      map[string]int   `json:"counts"`             // 按顶点类型统计
+	Warnings    []string         `json:"warnings,omitempty"` // 网桥查询失败的节点
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+type GetTopologyResponse struct {
+	Response
+	Data TopologyData
+}
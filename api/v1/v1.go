@@ -28,10 +28,9 @@ func HandleError(ctx *gin.Context, httpCode int, err error, data interface{}) {
 	if data == nil {
 		data = map[string]string{}
 	}
-	resp := Response{Code: errorCodeMap[err], Message: err.Error(), Data: data}
-	if _, ok := errorCodeMap[err]; !ok {
-		resp = Response{Code: 500, Message: "unknown error", Data: data}
-	}
+	// message 按请求语言返回，code 不受语言影响
+	code, message, _ := Localize(err, RequestLanguage(ctx))
+	resp := Response{Code: code, Message: message, Data: data}
 	ctx.JSON(httpCode, resp)
 }
 
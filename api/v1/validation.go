@@ -0,0 +1,233 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`           // 请求中的字段名（json / form 名称），嵌套字段用点号连接，如 disks[0].size；请求体无法解析时为空
+	Rule    string `json:"rule"`            // 未通过的规则，如 required / oneof / min；类型不匹配为 type，JSON 格式错误为 json
+	Param   string `json:"param,omitempty"` // 规则参数，如 oneof 的可选值（以 | 分隔）、min 的下限
+	Message string `json:"message"`         // 按请求语言返回的说明
+}
+
+// ValidationErrorData 请求参数校验失败时响应中的 data
+type ValidationErrorData struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func init() {
+	// 校验错误使用请求中的字段名（json / form 标签），而不是 Go 结构体字段名
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(requestFieldName)
+	}
+}
+
+func requestFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "uri"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// HandleBindError 请求绑定失败时返回 400，message 汇总失败字段，data.errors 列出每个字段的规则和说明
+func HandleBindError(ctx *gin.Context, err error) {
+	fields := FieldErrors(err, RequestLanguage(ctx))
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f.Field == "" {
+			parts = append(parts, f.Message)
+			continue
+		}
+		parts = append(parts, f.Field+" "+f.Message)
+	}
+	HandleError(ctx, http.StatusBadRequest, WithDetail(ErrBadRequest, strings.Join(parts, "; ")), ValidationErrorData{Errors: fields})
+}
+
+// FieldErrors 将 gin 绑定错误转换为字段级错误
+func FieldErrors(err error, lang string) []FieldError {
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		fields := make([]FieldError, 0, len(verrs))
+		for _, fe := range verrs {
+			fields = append(fields, fieldError(fe, lang))
+		}
+		return fields
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		typeName := jsonTypeName(typeErr.Type)
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeName,
+			Message: ruleMessage(lang, "type", typeName),
+		}}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return []FieldError{{Rule: "json", Message: ruleMessage(lang, "json", fmt.Sprint(syntaxErr.Offset))}}
+	}
+	if errors.Is(err, io.EOF) {
+		return []FieldError{{Rule: "required", Message: ruleMessage(lang, "body", "")}}
+	}
+	return []FieldError{{Rule: "invalid", Message: err.Error()}}
+}
+
+func fieldError(fe validator.FieldError, lang string) FieldError {
+	// Namespace 以结构体名开头，如 CreateVMRequest.disks[0].size
+	field := fe.Namespace()
+	if _, rest, ok := strings.Cut(field, "."); ok {
+		field = rest
+	}
+
+	rule, param := fe.Tag(), fe.Param()
+	if rule == "oneof" {
+		param = strings.Join(strings.Fields(param), "|")
+	}
+
+	key := rule
+	switch rule {
+	case "min", "max", "len":
+		switch fe.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+			key = rule + "_len"
+		}
+	}
+	if _, ok := ruleMessages[LangEnUS][key]; !ok {
+		key = "default"
+		param = rule
+		if fe.Param() != "" {
+			param = rule + "=" + fe.Param()
+		}
+	}
+
+	return FieldError{
+		Field:   field,
+		Rule:    rule,
+		Param:   strings.Join(strings.Fields(fe.Param()), "|"),
+		Message: ruleMessage(lang, key, param),
+	}
+}
+
+func ruleMessage(lang, key, param string) string {
+	msgs, ok := ruleMessages[lang]
+	if !ok {
+		msgs = ruleMessages[LangEnUS]
+	}
+	format, ok := msgs[key]
+	if !ok {
+		format = ruleMessages[LangEnUS][key]
+	}
+	if strings.Contains(format, "%s") {
+		return fmt.Sprintf(format, param)
+	}
+	return format
+}
+
+// jsonTypeName 将 Go 类型转换为 JSON 中的类型名称
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return t.String()
+	}
+}
+
+// ruleMessages 校验规则说明，%s 为规则参数
+var ruleMessages = map[string]map[string]string{
+	LangEnUS: {
+		"required":         "is required",
+		"required_if":      "is required when %s",
+		"required_unless":  "is required unless %s",
+		"required_with":    "is required when %s is set",
+		"required_without": "is required when %s is not set",
+		"oneof":            "must be one of %s",
+		"min":              "must be at least %s",
+		"max":              "must be at most %s",
+		"len":              "must be %s",
+		"min_len":          "must contain at least %s characters or items",
+		"max_len":          "must contain at most %s characters or items",
+		"len_len":          "must contain exactly %s characters or items",
+		"gt":               "must be greater than %s",
+		"gte":              "must be at least %s",
+		"lt":               "must be less than %s",
+		"lte":              "must be at most %s",
+		"email":            "must be a valid email address",
+		"url":              "must be a valid URL",
+		"ip":               "must be a valid IP address",
+		"ipv4":             "must be a valid IPv4 address",
+		"ipv6":             "must be a valid IPv6 address",
+		"cidr":             "must be a valid CIDR",
+		"hostname":         "must be a valid hostname",
+		"mac":              "must be a valid MAC address",
+		"type":             "must be of type %s",
+		"json":             "request body is not valid JSON (error at offset %s)",
+		"body":             "request body is required",
+		"default":          "failed on the %s rule",
+	},
+	LangZhCN: {
+		"required":         "不能为空",
+		"required_if":      "在 %s 时不能为空",
+		"required_unless":  "除 %s 外不能为空",
+		"required_with":    "在设置了 %s 时不能为空",
+		"required_without": "在未设置 %s 时不能为空",
+		"oneof":            "必须是 %s 之一",
+		"min":              "不能小于 %s",
+		"max":              "不能大于 %s",
+		"len":              "必须等于 %s",
+		"min_len":          "长度不能少于 %s",
+		"max_len":          "长度不能超过 %s",
+		"len_len":          "长度必须为 %s",
+		"gt":               "必须大于 %s",
+		"gte":              "不能小于 %s",
+		"lt":               "必须小于 %s",
+		"lte":              "不能大于 %s",
+		"email":            "必须是有效的邮箱地址",
+		"url":              "必须是有效的 URL",
+		"ip":               "必须是有效的 IP 地址",
+		"ipv4":             "必须是有效的 IPv4 地址",
+		"ipv6":             "必须是有效的 IPv6 地址",
+		"cidr":             "必须是有效的 CIDR",
+		"hostname":         "必须是有效的主机名",
+		"mac":              "必须是有效的 MAC 地址",
+		"type":             "类型必须为 %s",
+		"json":             "请求体不是合法的 JSON（位置 %s）",
+		"body":             "请求体不能为空",
+		"default":          "未通过 %s 校验",
+	},
+}
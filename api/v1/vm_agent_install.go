@@ -0,0 +1,66 @@
+package v1
+
+import "time"
+
+// guest agent 安装辅助相关 API 定义
+// 为未运行 qemu-guest-agent 的虚拟机提供自动化的安装方式，每台虚拟机记录安装进度：
+// - cloudinit：将预先准备的 cloud-init snippet（如 packages: [qemu-guest-agent]）设为 vendor 数据，下次启动时由 cloud-init 安装
+// - iso：在空闲光驱挂载预先准备的安装 ISO（如 virtio-win），由用户在虚拟机内安装
+// 虚拟机未开启 agent 选项时会一并开启（需要关机后再启动才生效）。
+// 后台定期 ping agent，响应后标记为 succeeded 并卸载 ISO、恢复原 cicustom；超时未响应标记为 failed
+
+// guest agent 安装方式
+const (
+	VMAgentInstallMethodCloudInit = "cloudinit"
+	VMAgentInstallMethodISO       = "iso"
+)
+
+// StartVMAgentInstallRequest 为虚拟机安装 guest agent
+type StartVMAgentInstallRequest struct {
+	Method string `json:"method" binding:"required,oneof=cloudinit iso" example:"cloudinit"`
+	Volume string `json:"volume" binding:"max=255" example:"local:snippets/qemu-guest-agent.yaml"` // 安装介质卷，默认使用 agent_install.snippet / agent_install.iso
+}
+
+// VMAgentInstallItem guest agent 安装记录
+type VMAgentInstallItem struct {
+	Id            int64      `json:"id"`
+	ClusterID     int64      `json:"cluster_id"`
+	VmId          int64      `json:"vm_id"`
+	VMID          uint32     `json:"vmid"`
+	VmName        string     `json:"vm_name"`
+	Method        string     `json:"method"` // cloudinit / iso
+	Volume        string     `json:"volume"`
+	Slot          string     `json:"slot,omitempty"`  // 挂载 ISO 的光驱
+	AgentEnabled  bool       `json:"agent_enabled"`   // 本次安装开启了 agent 选项，需要关机后再启动
+	Status        string     `json:"status"`          // waiting / succeeded / failed / cancelled
+	Message       string     `json:"message"`         // 下一步操作提示或失败原因
+	Checks        int        `json:"checks"`          // 已检测次数
+	LastCheckTime *time.Time `json:"last_check_time"` // 最近一次 ping agent 的时间
+	FinishTime    *time.Time `json:"finish_time"`
+	Creator       string     `json:"creator"`
+	CreateTime    time.Time  `json:"create_time"`
+	UpdateTime    time.Time  `json:"update_time"`
+}
+
+type VMAgentInstallResponse struct {
+	Response
+	Data VMAgentInstallItem
+}
+
+// ListVMAgentInstallsRequest 查询 guest agent 安装记录
+type ListVMAgentInstallsRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"20"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	Status    string `form:"status" binding:"omitempty,oneof=waiting succeeded failed cancelled" example:"waiting"`
+}
+
+type ListVMAgentInstallsResponseData struct {
+	Total int64                `json:"total"`
+	List  []VMAgentInstallItem `json:"list"`
+}
+
+type ListVMAgentInstallsResponse struct {
+	Response
+	Data ListVMAgentInstallsResponseData
+}
@@ -0,0 +1,90 @@
+package v1
+
+import "time"
+
+// 批量删除虚拟机相关 API 定义
+// 1. POST /api/v1/vm-bulk-deletes/prepare 按虚拟机 ID、标签或项目（business_service）选出虚拟机，
+//    列出将删除的虚拟机和跳过的虚拟机（模板、开启删除保护、被 Proxmox 任务锁定），签发确认令牌和确认文本
+// 2. POST /api/v1/vm-bulk-deletes 提交令牌并原样输入确认文本（如 "delete 80 vms"），创建后台删除任务
+// 3. 任务按 vm_bulk_delete.concurrency 并发逐台停止并删除，执行时再次检查保护和锁定；
+//    GET /api/v1/vm-bulk-deletes/{id} 返回删除报告，结束时通过站内通知（vm_bulk_delete_completed / vm_bulk_delete_failed）告知提交人
+
+// PrepareVMBulkDeleteRequest 批量删除预检请求，vm_ids、tag、business_service 至少指定一项，同时指定时取交集
+type PrepareVMBulkDeleteRequest struct {
+	VmIDs           []int64 `json:"vm_ids,omitempty" binding:"omitempty,max=500" example:"1,2,3"`
+	ClusterID       int64   `json:"cluster_id,omitempty" example:"1"`                                           // 按 tag 选择时必填，其他方式时用于限定集群
+	Tag             string  `json:"tag,omitempty" example:"decommission"`                                       // Proxmox 标签
+	BusinessService string  `json:"business_service,omitempty" example:"legacy-billing"`                        // 项目（虚拟机的业务服务）
+	StopMode        string  `json:"stop_mode,omitempty" binding:"omitempty,oneof=shutdown stop" example:"stop"` // 运行中虚拟机的停止方式，默认 stop（直接断电）
+}
+
+// VMBulkDeleteItem 批量删除中的单台虚拟机
+type VMBulkDeleteItem struct {
+	VmId      int64  `json:"vm_id"`
+	VMID      uint32 `json:"vmid"`
+	VmName    string `json:"vm_name"`
+	ClusterID int64  `json:"cluster_id"`
+	NodeName  string `json:"node_name"`
+	Status    string `json:"status"`           // pending / stopping / deleting / deleted / skipped / failed
+	Reason    string `json:"reason,omitempty"` // 跳过原因或失败信息
+}
+
+// PrepareVMBulkDeleteResponseData 批量删除预检结果
+type PrepareVMBulkDeleteResponseData struct {
+	ConfirmToken string             `json:"confirm_token"` // 确认令牌，单次使用
+	ConfirmText  string             `json:"confirm_text"`  // 提交时需原样输入的确认文本
+	ExpiresAt    int64              `json:"expires_at"`    // 令牌过期时间（Unix 秒）
+	Targets      []VMBulkDeleteItem `json:"targets"`       // 将删除的虚拟机
+	Skipped      []VMBulkDeleteItem `json:"skipped"`       // 不会删除的虚拟机及原因
+}
+
+type PrepareVMBulkDeleteResponse struct {
+	Response
+	Data PrepareVMBulkDeleteResponseData
+}
+
+// ConfirmVMBulkDeleteRequest 提交批量删除
+type ConfirmVMBulkDeleteRequest struct {
+	ConfirmToken string `json:"confirm_token" binding:"required" example:"3f2a..."`
+	ConfirmText  string `json:"confirm_text" binding:"required" example:"delete 80 vms"`
+}
+
+// VMBulkDeleteJobItem 批量删除任务及删除报告
+type VMBulkDeleteJobItem struct {
+	Id         int64              `json:"id"`
+	Selector   string             `json:"selector"` // 预检时的选择条件
+	StopMode   string             `json:"stop_mode"`
+	Status     string             `json:"status"` // pending / running / completed
+	Total      int                `json:"total"`
+	Deleted    int                `json:"deleted"`
+	Skipped    int                `json:"skipped"`
+	Failed     int                `json:"failed"`
+	Items      []VMBulkDeleteItem `json:"items"`
+	StartTime  *time.Time         `json:"start_time"`
+	EndTime    *time.Time         `json:"end_time"`
+	Creator    string             `json:"creator"`
+	CreateTime time.Time          `json:"create_time"`
+}
+
+type VMBulkDeleteJobResponse struct {
+	Response
+	Data VMBulkDeleteJobItem
+}
+
+// ListVMBulkDeleteJobsRequest 批量删除任务列表查询
+type ListVMBulkDeleteJobsRequest struct {
+	Page     int    `form:"page" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	Status   string `form:"status" binding:"omitempty,oneof=pending running completed" example:"running"`
+	Creator  string `form:"creator" example:"admin"`
+}
+
+type ListVMBulkDeleteJobsResponseData struct {
+	Total int64                 `json:"total"`
+	List  []VMBulkDeleteJobItem `json:"list"`
+}
+
+type ListVMBulkDeleteJobsResponse struct {
+	Response
+	Data ListVMBulkDeleteJobsResponseData
+}
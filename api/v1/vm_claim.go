@@ -0,0 +1,90 @@
+package v1
+
+import "time"
+
+// 待认领虚拟机相关 API 定义
+// 认领扫描定期比对各集群 Proxmox 中的虚拟机与数据库记录：数据库没有记录的（untracked）和未设置负责人的（unowned）
+// 列入待认领列表，可一键认领（创建记录并设置负责人、应用等归属信息），未认领的按间隔推送提醒。
+
+// ListUnclaimedVMsRequest 待认领虚拟机列表查询
+type ListUnclaimedVMsRequest struct {
+	Page           int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize       int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	ClusterID      int64  `form:"cluster_id" example:"1"`
+	Reason         string `form:"reason" binding:"omitempty,oneof=untracked unowned" example:"untracked"`
+	IncludeIgnored bool   `form:"include_ignored" example:"false"` // 是否包含已忽略的虚拟机
+}
+
+// UnclaimedVMItem 待认领虚拟机
+type UnclaimedVMItem struct {
+	Id           int64      `json:"id"`
+	ClusterID    int64      `json:"cluster_id"`
+	ClusterName  string     `json:"cluster_name"`
+	NodeName     string     `json:"node_name"`
+	VMID         uint32     `json:"vmid"`
+	VmName       string     `json:"vm_name"`
+	Status       string     `json:"status"`
+	CPUNum       int        `json:"cpu_num"`
+	MemorySize   int        `json:"memory_size"` // MB
+	DiskSize     int        `json:"disk_size"`   // MB
+	Reason       string     `json:"reason"`      // untracked：数据库没有记录；unowned：未设置负责人
+	VmID         int64      `json:"vm_id"`       // 虚拟机记录ID，untracked 时为 0
+	Creator      string     `json:"creator"`
+	Ignored      bool       `json:"ignored"`
+	IgnoredBy    string     `json:"ignored_by"`
+	FirstSeen    time.Time  `json:"first_seen"`
+	LastSeen     time.Time  `json:"last_seen"`
+	LastRemindAt *time.Time `json:"last_remind_at"`
+}
+
+type ListUnclaimedVMsResponseData struct {
+	Total int64             `json:"total"`
+	List  []UnclaimedVMItem `json:"list"`
+}
+
+// ListUnclaimedVMsResponse 待认领虚拟机列表响应
+type ListUnclaimedVMsResponse struct {
+	Response
+	Data ListUnclaimedVMsResponseData
+}
+
+// AdoptUnclaimedVMRequest 认领虚拟机，owner 为空时认领给当前用户
+type AdoptUnclaimedVMRequest struct {
+	Owner           string     `json:"owner" binding:"max=100" example:"zhangsan"`
+	Team            string     `json:"team" binding:"max=100" example:"payments"`
+	Contact         string     `json:"contact" binding:"max=200" example:"payments-oncall@example.com"`
+	AppId           string     `json:"app_id" binding:"max=100" example:"app-001"`
+	BusinessService string     `json:"business_service" binding:"max=200" example:"checkout"`
+	Environment     string     `json:"environment" binding:"omitempty,oneof=prod stage dev" example:"prod"`
+	CostCenter      string     `json:"cost_center" binding:"max=100" example:"CC-1001"`
+	ReviewDate      *time.Time `json:"review_date,omitempty" example:"2026-12-31T00:00:00Z"`
+}
+
+// AdoptUnclaimedVMData 认领结果
+type AdoptUnclaimedVMData struct {
+	VmID  int64  `json:"vm_id"` // 虚拟机记录ID
+	Owner string `json:"owner"`
+}
+
+// AdoptUnclaimedVMResponse 认领响应
+type AdoptUnclaimedVMResponse struct {
+	Response
+	Data AdoptUnclaimedVMData
+}
+
+// IgnoreUnclaimedVMRequest 忽略或取消忽略待认领虚拟机
+type IgnoreUnclaimedVMRequest struct {
+	Ignored bool `json:"ignored" example:"true"`
+}
+
+// RefreshUnclaimedVMsData 立即扫描结果
+type RefreshUnclaimedVMsData struct {
+	Untracked int `json:"untracked"`
+	Unowned   int `json:"unowned"`
+}
+
+// RefreshUnclaimedVMsResponse 立即扫描响应
+type RefreshUnclaimedVMsResponse struct {
+	Response
+	Data RefreshUnclaimedVMsData
+}
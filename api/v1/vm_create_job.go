@@ -0,0 +1,51 @@
+package v1
+
+import "time"
+
+// 异步创建虚拟机相关 API 定义
+// POST /api/v1/vms/create?async=true 立即返回创建任务，创建流程在后台执行；
+// 调用方轮询任务状态，或通过站内通知（vm_create_completed / vm_create_failed）获知结果
+
+type ListVMCreateJobsRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending running completed failed" example:"running"`
+	Creator   string `form:"creator" example:"admin"`
+}
+
+// VMCreateJobItem 创建任务信息
+type VMCreateJobItem struct {
+	Id           int64      `json:"id"`
+	ClusterID    int64      `json:"cluster_id"`
+	ClusterName  string     `json:"cluster_name"`
+	NodeID       int64      `json:"node_id"`
+	NodeName     string     `json:"node_name"`
+	VMID         uint32     `json:"vmid"` // 提交时即已确定
+	VmName       string     `json:"vm_name"`
+	CreateMode   string     `json:"create_mode"`
+	TemplateID   int64      `json:"template_id"`
+	VmId         int64      `json:"vm_id"`  // 创建成功后的虚拟机 ID
+	Status       string     `json:"status"` // pending, running, completed, failed
+	ErrorMessage string     `json:"error_message"`
+	StartTime    *time.Time `json:"start_time"`
+	EndTime      *time.Time `json:"end_time"`
+	Creator      string     `json:"creator"`
+	CreateTime   time.Time  `json:"create_time"`
+	UpdateTime   time.Time  `json:"update_time"`
+}
+
+type VMCreateJobResponse struct {
+	Response
+	Data VMCreateJobItem
+}
+
+type ListVMCreateJobsResponseData struct {
+	Total int64             `json:"total"`
+	List  []VMCreateJobItem `json:"list"`
+}
+
+type ListVMCreateJobsResponse struct {
+	Response
+	Data ListVMCreateJobsResponseData
+}
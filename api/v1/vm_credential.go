@@ -0,0 +1,79 @@
+package v1
+
+import "time"
+
+// VMCredentialLeaseData 凭据租约：在有效期内凭 token 读取一次密码
+type VMCredentialLeaseData struct {
+	Token     string    `json:"token"`
+	VMId      int64     `json:"vm_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type VMCredentialLeaseResponse struct {
+	Response
+	Data VMCredentialLeaseData
+}
+
+// RetrieveVMCredentialRequest 读取凭据，token 放在请求体中，避免出现在访问日志里
+type RetrieveVMCredentialRequest struct {
+	Token string `json:"token" binding:"required" example:"3f1c..."`
+}
+
+type VMCredentialData struct {
+	VMId     int64  `json:"vm_id"`
+	VmName   string `json:"vm_name"`
+	VmUser   string `json:"vm_user"`
+	Password string `json:"password"`
+	Backend  string `json:"backend"` // local / vault / kubernetes / plaintext
+}
+
+type VMCredentialResponse struct {
+	Response
+	Data VMCredentialData
+}
+
+// MigrateVMCredentialsData 明文密码迁移结果
+type MigrateVMCredentialsData struct {
+	Backend  string   `json:"backend"`
+	Migrated int      `json:"migrated"`
+	Failed   int      `json:"failed"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+type MigrateVMCredentialsResponse struct {
+	Response
+	Data MigrateVMCredentialsData
+}
+
+// ListSecretAuditRequest 凭据审计查询
+type ListSecretAuditRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	VMId     int64  `form:"vm_id" example:"1"`
+	Action   string `form:"action" binding:"omitempty,oneof=write lease read delete migrate" example:"read"`
+	Operator string `form:"operator" example:"admin"`
+}
+
+// SecretAuditItem 凭据审计记录
+type SecretAuditItem struct {
+	Id         int64     `json:"id"`
+	VMId       int64     `json:"vm_id"`
+	VmName     string    `json:"vm_name"`
+	Action     string    `json:"action"`
+	Backend    string    `json:"backend"`
+	Operator   string    `json:"operator"`
+	ClientIP   string    `json:"client_ip"`
+	Success    bool      `json:"success"`
+	Message    string    `json:"message"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+type ListSecretAuditResponseData struct {
+	Total int64              `json:"total"`
+	List  []*SecretAuditItem `json:"list"`
+}
+
+type ListSecretAuditResponse struct {
+	Response
+	Data ListSecretAuditResponseData
+}
@@ -0,0 +1,50 @@
+package v1
+
+import "time"
+
+// 虚拟机事件时间线相关 API 定义
+// /api/v1/vms/{id}/events 按时间倒序合并三类事件：
+// - task：Proxmox 集群任务列表中该 VMID 的任务（启动、迁移、备份等，包括在 Proxmox 中直接执行的操作）
+// - audit：PveSphere 操作记录中针对该虚拟机的变更请求（非管理员只能看到自己的操作）
+// - status：控制器同步时检测到的状态变化（如虚拟机在 guest 内关机或被 HA 重启）
+// 由 PveSphere 发起的任务会带上对应的 operation_id，便于与操作记录对照
+
+// 事件来源
+const (
+	VMEventSourceTask   = "task"
+	VMEventSourceAudit  = "audit"
+	VMEventSourceStatus = "status"
+)
+
+// ListVMEventsRequest 虚拟机事件查询
+type ListVMEventsRequest struct {
+	Page     int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	Source   string `form:"source" binding:"omitempty,oneof=task audit status" example:"task"` // 为空时返回全部来源
+}
+
+// VMEventItem 虚拟机事件
+type VMEventItem struct {
+	Time        time.Time  `json:"time"`
+	Source      string     `json:"source"`  // task / audit / status
+	Type        string     `json:"type"`    // 任务类型（qmstart、qmigrate 等）、请求路由或 status_change
+	Summary     string     `json:"summary"` // 如 "qmstart by root@pam"、"POST /api/v1/vms/1/start"、"running -> stopped"
+	Status      string     `json:"status"`  // 任务结果（OK 或错误信息，运行中为空）、HTTP 状态码或新状态
+	User        string     `json:"user"`
+	Node        string     `json:"node"`
+	UPID        string     `json:"upid,omitempty"`
+	OperationID string     `json:"operation_id,omitempty"`
+	EndTime     *time.Time `json:"end_time,omitempty"` // 任务结束时间
+}
+
+type ListVMEventsResponseData struct {
+	Total    int64         `json:"total"`
+	List     []VMEventItem `json:"list"`
+	Warnings []string      `json:"warnings,omitempty"` // 获取失败的来源（如 Proxmox 不可达时没有任务事件）
+}
+
+// ListVMEventsResponse 虚拟机事件时间线响应
+type ListVMEventsResponse struct {
+	Response
+	Data ListVMEventsResponseData
+}
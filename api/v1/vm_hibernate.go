@@ -0,0 +1,63 @@
+package v1
+
+// 虚拟机休眠相关 API 定义
+// 休眠即 Proxmox 的挂起到磁盘（suspend todisk）：内存写入状态存储上的 vmstate 卷后虚拟机停止、lock 为 suspended，
+// 释放节点内存但保留运行状态；恢复时启动虚拟机，Proxmox 加载 vmstate 后删除该卷。适合维护期间腾出内存又不想关闭有状态的虚拟机
+
+// HibernateVMRequest 休眠虚拟机
+type HibernateVMRequest struct {
+	StateStorage string `json:"state_storage,omitempty" example:"local-lvm"` // 保存 vmstate 的存储，需支持 images；为空时由 Proxmox 选择
+}
+
+// HibernateVMData 休眠任务
+type HibernateVMData struct {
+	UPID         string `json:"upid"`
+	StateStorage string `json:"state_storage,omitempty"`
+}
+
+type HibernateVMResponse struct {
+	Response
+	Data HibernateVMData
+}
+
+// ResumeVMData 恢复任务
+type ResumeVMData struct {
+	UPID string `json:"upid"`
+}
+
+type ResumeVMResponse struct {
+	Response
+	Data ResumeVMData
+}
+
+// ListHibernatedVMsRequest 查询休眠中的虚拟机
+type ListHibernatedVMsRequest struct {
+	ClusterID int64 `form:"cluster_id" example:"1"` // 为空查询全部集群
+}
+
+// HibernatedVMItem 休眠中的虚拟机，以 Proxmox 当前状态为准
+type HibernatedVMItem struct {
+	VmId         int64  `json:"vm_id,omitempty"` // pve_vm 表 ID，尚未同步到 PveSphere 的虚拟机为 0
+	VMID         uint32 `json:"vmid"`
+	VmName       string `json:"vm_name"`
+	ClusterID    int64  `json:"cluster_id"`
+	ClusterName  string `json:"cluster_name"`
+	NodeID       int64  `json:"node_id,omitempty"`
+	NodeName     string `json:"node_name"`
+	StateStorage string `json:"state_storage"` // vmstate 卷所在存储
+	StateVolume  string `json:"state_volume"`  // vmstate 卷 ID
+	MemoryMB     int64  `json:"memory_mb"`     // 恢复时需要的内存
+	Owner        string `json:"owner,omitempty"`
+}
+
+// ListHibernatedVMsData 休眠中的虚拟机列表
+type ListHibernatedVMsData struct {
+	Items []HibernatedVMItem `json:"items"`
+	// FailedClusters 无法查询的集群，这些集群上的休眠虚拟机不在列表中
+	FailedClusters []string `json:"failed_clusters,omitempty"`
+}
+
+type ListHibernatedVMsResponse struct {
+	Response
+	Data ListHibernatedVMsData
+}
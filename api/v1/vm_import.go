@@ -0,0 +1,164 @@
+package v1
+
+import "time"
+
+// 虚拟机导入相关 API 定义（基于 Proxmox VE 8.2+ 导入向导接口）
+// ESXi：先注册导入源（Proxmox type=esxi 存储），再列出源上的虚拟机；
+// OVA / OVF：通过节点存储上传接口（content=import）上传到支持 import 内容的存储后，使用返回的卷导入。
+// 导入前可读取元数据，按源虚拟机的磁盘和网卡分别映射目标存储和网桥。
+
+// CreateVMImportSourceRequest 注册 ESXi 导入源请求
+type CreateVMImportSourceRequest struct {
+	ClusterID      int64  `json:"cluster_id" binding:"required" example:"1"`
+	Name           string `json:"name" binding:"required" example:"esxi-01"` // Proxmox 存储 ID（字母开头，字母、数字、-、_、.）
+	Server         string `json:"server" binding:"required" example:"10.0.0.50"`
+	Username       string `json:"username" binding:"required" example:"root"`
+	Password       string `json:"password" binding:"required" example:"password"`
+	SkipCertVerify *int8  `json:"skip_cert_verify,omitempty" example:"1"` // 是否跳过证书校验，默认 1
+}
+
+// ListVMImportSourcesRequest 导入源列表请求
+type ListVMImportSourcesRequest struct {
+	ClusterID int64 `form:"cluster_id" example:"1"`
+}
+
+// VMImportSourceItem 导入源信息（不返回密码）
+type VMImportSourceItem struct {
+	Id             int64     `json:"id"`
+	ClusterID      int64     `json:"cluster_id"`
+	Name           string    `json:"name"`
+	Server         string    `json:"server"`
+	Username       string    `json:"username"`
+	SkipCertVerify int8      `json:"skip_cert_verify"`
+	Creator        string    `json:"creator"`
+	CreateTime     time.Time `json:"create_time"`
+}
+
+// ListVMImportSourcesResponse 导入源列表响应
+type ListVMImportSourcesResponse struct {
+	Response
+	Data []VMImportSourceItem
+}
+
+// ListImportableVMsRequest 列出导入源上的虚拟机请求
+type ListImportableVMsRequest struct {
+	NodeID int64 `form:"node_id" binding:"required" example:"1"` // 通过该节点访问导入源
+}
+
+// ImportableVM 可导入的客户机
+type ImportableVM struct {
+	Volume string `json:"volume"` // 导入卷，用于读取元数据和创建导入任务
+	Name   string `json:"name"`
+	Format string `json:"format"` // vmx / ova / ovf
+}
+
+// ListImportableVMsResponse 可导入客户机列表响应
+type ListImportableVMsResponse struct {
+	Response
+	Data []ImportableVM
+}
+
+// GetVMImportMetadataRequest 读取导入元数据请求
+type GetVMImportMetadataRequest struct {
+	NodeID int64  `form:"node_id" binding:"required" example:"1"`
+	Volume string `form:"volume" binding:"required" example:"esxi-01:ha-datacenter/datastore1/web/web.vmx"`
+}
+
+// VMImportDisk 源虚拟机磁盘
+type VMImportDisk struct {
+	Key    string `json:"key"` // 磁盘槽位，如 scsi0
+	Volume string `json:"volume"`
+	Size   int64  `json:"size"` // 字节
+}
+
+// VMImportNet 源虚拟机网卡
+type VMImportNet struct {
+	Key     string `json:"key"` // 网卡槽位，如 net0
+	Model   string `json:"model"`
+	MACAddr string `json:"macaddr"`
+}
+
+// VMImportMetadata 导入元数据
+type VMImportMetadata struct {
+	Volume     string                 `json:"volume"`
+	SourceType string                 `json:"source_type"` // esxi / ova / ovf
+	CreateArgs map[string]interface{} `json:"create_args"` // 源虚拟机配置转换后的创建参数（name、memory、cores 等）
+	Disks      []VMImportDisk         `json:"disks"`
+	Nets       []VMImportNet          `json:"nets"`
+	Warnings   []string               `json:"warnings"` // 转换时需要注意的问题（如不支持的设备）
+}
+
+// GetVMImportMetadataResponse 导入元数据响应
+type GetVMImportMetadataResponse struct {
+	Response
+	Data VMImportMetadata
+}
+
+// CreateVMImportRequest 创建导入任务请求
+type CreateVMImportRequest struct {
+	NodeID         int64             `json:"node_id" binding:"required" example:"1"` // 目标节点
+	Volume         string            `json:"volume" binding:"required" example:"esxi-01:ha-datacenter/datastore1/web/web.vmx"`
+	VMID           uint32            `json:"vmid,omitempty" example:"10000001"`                               // 不传则自动生成
+	VmName         string            `json:"vm_name,omitempty" example:"web"`                                 // 不传则使用源虚拟机名称
+	TargetStorage  string            `json:"target_storage" binding:"required" example:"local-lvm"`           // 磁盘默认目标存储
+	DiskStorageMap map[string]string `json:"disk_storage_map,omitempty" example:"scsi1:ceph-hdd"`             // 按磁盘槽位覆盖目标存储
+	NetworkMap     map[string]string `json:"network_map,omitempty" example:"net0:vmbr1"`                      // 按网卡槽位指定网桥
+	DefaultBridge  string            `json:"default_bridge,omitempty" example:"vmbr0"`                        // 未映射网卡使用的网桥，默认 vmbr0
+	LiveImport     bool              `json:"live_import,omitempty" example:"false"`                           // 仅 ESXi：导入时立即启动虚拟机，磁盘在后台迁移
+	AppId          string            `json:"app_id,omitempty" example:"web"`                                  // 应用归属
+	Description    string            `json:"description,omitempty" example:"migrated from vcenter cluster A"` // 描述
+}
+
+// ListVMImportTasksRequest 导入任务列表请求
+type ListVMImportTasksRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	Status    string `form:"status" example:"importing"`
+}
+
+// VMImportTaskItem 导入任务信息
+type VMImportTaskItem struct {
+	Id            int64      `json:"id"`
+	ClusterID     int64      `json:"cluster_id"`
+	NodeID        int64      `json:"node_id"`
+	NodeName      string     `json:"node_name"`
+	SourceType    string     `json:"source_type"`
+	Volume        string     `json:"volume"`
+	VMID          uint32     `json:"vmid"`
+	VmName        string     `json:"vm_name"`
+	TargetStorage string     `json:"target_storage"`
+	LiveImport    bool       `json:"live_import"`
+	Status        string     `json:"status"`   // pending, importing, completed, failed
+	Progress      int        `json:"progress"` // 0-100，根据 Proxmox 任务日志估算
+	UPID          string     `json:"upid"`
+	VmId          int64      `json:"vm_id"` // 虚拟机数据库ID（导入完成后填充）
+	ErrorMessage  string     `json:"error_message"`
+	StartTime     *time.Time `json:"start_time"`
+	EndTime       *time.Time `json:"end_time"`
+	Creator       string     `json:"creator"`
+	CreateTime    time.Time  `json:"create_time"`
+}
+
+// VMImportTaskDetail 导入任务详情
+type VMImportTaskDetail struct {
+	VMImportTaskItem
+	CreateParams map[string]string `json:"create_params"` // 映射后的虚拟机创建参数
+}
+
+// GetVMImportTaskResponse 导入任务详情响应
+type GetVMImportTaskResponse struct {
+	Response
+	Data VMImportTaskDetail
+}
+
+// ListVMImportTasksResponse 导入任务列表响应
+type ListVMImportTasksResponse struct {
+	Response
+	Data ListVMImportTasksResponseData
+}
+
+type ListVMImportTasksResponseData struct {
+	Total int64              `json:"total"`
+	List  []VMImportTaskItem `json:"list"`
+}
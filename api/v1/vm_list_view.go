@@ -0,0 +1,75 @@
+package v1
+
+import "time"
+
+// 虚拟机列表视图（保存的筛选条件/排序/显示列）相关 API 定义
+
+// VMListViewFilters 视图保存的筛选条件，字段含义与 ListVMRequest 一致
+type VMListViewFilters struct {
+	ClusterID  int64  `json:"cluster_id,omitempty" example:"1"`
+	NodeID     int64  `json:"node_id,omitempty" example:"1"`
+	TemplateID int64  `json:"template_id,omitempty" example:"1"`
+	Status     string `json:"status,omitempty" example:"running"`
+	AppId      string `json:"app_id,omitempty" example:"app-001"`
+
+	Owner           string `json:"owner,omitempty" example:"zhangsan"`
+	Team            string `json:"team,omitempty" example:"payments"`
+	BusinessService string `json:"business_service,omitempty" example:"checkout"`
+	Environment     string `json:"environment,omitempty" example:"prod"`
+	CostCenter      string `json:"cost_center,omitempty" example:"CC-1001"`
+	ReviewDue       bool   `json:"review_due,omitempty"`
+}
+
+// CreateVMListViewRequest 创建视图请求
+type CreateVMListViewRequest struct {
+	Name      string            `json:"name" binding:"required,max=100" example:"生产环境运行中"`                     // 视图名称（同一用户下唯一）
+	Filters   VMListViewFilters `json:"filters"`                                                               // 筛选条件
+	SortBy    string            `json:"sort_by,omitempty" example:"vm_name"`                                   // 排序字段
+	SortOrder string            `json:"sort_order,omitempty" binding:"omitempty,oneof=asc desc" example:"asc"` // 排序方向
+	Columns   []string          `json:"columns,omitempty" example:"vm_name,vmid,status,node_name"`             // 显示列（按顺序）
+	IsDefault bool              `json:"is_default,omitempty"`                                                  // 是否设为默认视图
+	Shared    bool              `json:"shared,omitempty"`                                                      // 是否共享给所有用户（只读）
+}
+
+// UpdateVMListViewRequest 更新视图请求（仅视图所有者可修改）
+type UpdateVMListViewRequest struct {
+	Name      *string            `json:"name,omitempty" binding:"omitempty,max=100"`
+	Filters   *VMListViewFilters `json:"filters,omitempty"`
+	SortBy    *string            `json:"sort_by,omitempty"`
+	SortOrder *string            `json:"sort_order,omitempty" binding:"omitempty,oneof=asc desc"`
+	Columns   []string           `json:"columns,omitempty"`
+	IsDefault *bool              `json:"is_default,omitempty"`
+	Shared    *bool              `json:"shared,omitempty"`
+}
+
+// VMListViewItem 视图信息
+type VMListViewItem struct {
+	Id         int64             `json:"id"`
+	UserId     string            `json:"user_id"`
+	Name       string            `json:"name"`
+	Filters    VMListViewFilters `json:"filters"`
+	SortBy     string            `json:"sort_by"`
+	SortOrder  string            `json:"sort_order"`
+	Columns    []string          `json:"columns"`
+	IsDefault  bool              `json:"is_default"`
+	Shared     bool              `json:"shared"`
+	IsOwner    bool              `json:"is_owner"` // 当前用户是否为视图所有者（非所有者只能查看）
+	CreateTime time.Time         `json:"create_time"`
+	UpdateTime time.Time         `json:"update_time"`
+}
+
+// ListVMListViewsResponse 视图列表响应
+type ListVMListViewsResponse struct {
+	Response
+	Data ListVMListViewsResponseData
+}
+
+type ListVMListViewsResponseData struct {
+	List []VMListViewItem `json:"list"`
+}
+
+// GetVMListViewResponse 视图详情响应
+type GetVMListViewResponse struct {
+	Response
+	Data VMListViewItem
+}
@@ -0,0 +1,79 @@
+package v1
+
+import "time"
+
+// 虚拟机维护锁相关 API 定义
+// 在 PveSphere 中锁定虚拟机（原因、持有人、过期时间），加锁期间除持有人外任何人都不能对虚拟机执行
+// 开关机、修改配置、迁移和删除；同时返回 Proxmox 自身的 lock 字段（backup、migrate、snapshot 等）
+
+// LockVMRequest 加锁或续期（持有人再次调用时更新原因和过期时间）
+type LockVMRequest struct {
+	Reason   string `json:"reason" binding:"required,max=500" example:"数据库升级"`
+	Owner    string `json:"owner,omitempty" binding:"max=100" example:"alice"`                    // 持有人，默认当前用户；仅管理员可以为他人加锁
+	Duration int    `json:"duration,omitempty" binding:"omitempty,min=1,max=43200" example:"120"` // 有效期（分钟），默认见 vm_lock.default_duration，0 表示使用默认值
+	NoExpiry bool   `json:"no_expiry,omitempty" example:"false"`                                  // 不自动过期，需手动解锁
+}
+
+// VMLockItem 维护锁信息
+type VMLockItem struct {
+	Id         int64      `json:"id"`
+	VmId       int64      `json:"vm_id"`
+	ClusterID  int64      `json:"cluster_id"`
+	VMID       uint32     `json:"vmid"`
+	VmName     string     `json:"vm_name"`
+	Owner      string     `json:"owner"`
+	Reason     string     `json:"reason"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	Creator    string     `json:"creator"`
+	CreateTime time.Time  `json:"create_time"`
+	UpdateTime time.Time  `json:"update_time"`
+}
+
+type VMLockResponse struct {
+	Response
+	Data VMLockItem
+}
+
+// VMProxmoxTask 虚拟机上正在运行的 Proxmox 任务
+type VMProxmoxTask struct {
+	UPID      string `json:"upid"`
+	Type      string `json:"type"` // vzdump、qmclone、qmigrate、qmsnapshot 等
+	User      string `json:"user"`
+	StartTime int64  `json:"start_time"`
+}
+
+// VMLockStatusData 虚拟机锁状态
+type VMLockStatusData struct {
+	VmId              int64          `json:"vm_id"`
+	Locked            bool           `json:"locked"`
+	HeldByMe          bool           `json:"held_by_me"`
+	Lock              *VMLockItem    `json:"lock,omitempty"`
+	ProxmoxLock       string         `json:"proxmox_lock,omitempty"`        // Proxmox 配置中的 lock（backup、clone、migrate、snapshot 等）
+	ProxmoxLockReason string         `json:"proxmox_lock_reason,omitempty"` // lock 的说明
+	ProxmoxTask       *VMProxmoxTask `json:"proxmox_task,omitempty"`        // 持有 lock 的运行中任务
+	PendingChanges    []string       `json:"pending_changes"`               // 尚未生效（需要重启）的配置项
+}
+
+type VMLockStatusResponse struct {
+	Response
+	Data VMLockStatusData
+}
+
+// ListVMLocksRequest 查询生效中的维护锁
+type ListVMLocksRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"20"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	Owner     string `form:"owner" example:"alice"`
+	Mine      bool   `form:"mine" example:"false"` // 只看当前用户持有的锁
+}
+
+type ListVMLocksResponseData struct {
+	Total int64        `json:"total"`
+	List  []VMLockItem `json:"list"`
+}
+
+type ListVMLocksResponse struct {
+	Response
+	Data ListVMLocksResponseData
+}
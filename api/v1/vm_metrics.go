@@ -0,0 +1,80 @@
+package v1
+
+import "time"
+
+// 虚拟机实时指标相关 API 定义
+// /api/v1/vms/{id}/metrics 一次返回虚拟机详情页需要的监控数据：status/current 中的当前值、
+// RRD 近期曲线以及 guest agent 上报的系统信息和负载，替代分别调用 status、rrd 和 agent 接口。
+// 某一部分获取失败时不影响其他部分，失败原因记录在 warnings 中
+
+// GetVMMetricsRequest 获取虚拟机实时指标请求
+type GetVMMetricsRequest struct {
+	Timeframe string `form:"timeframe" binding:"omitempty,oneof=hour day week month year" example:"hour"` // RRD 时间范围，默认 hour
+	Cf        string `form:"cf" binding:"omitempty,oneof=AVERAGE MAX" example:"AVERAGE"`                  // RRD 聚合函数，默认 AVERAGE
+}
+
+// VMCurrentMetrics 虚拟机当前指标（status/current），磁盘和网络为启动以来的累计字节数
+type VMCurrentMetrics struct {
+	Status         string  `json:"status"`          // running / stopped / paused
+	Uptime         int64   `json:"uptime"`          // 运行时长（秒）
+	CPUUsage       float64 `json:"cpu_usage"`       // CPU 使用率，0-1，相对于 cpus
+	CPUs           int     `json:"cpus"`            // vCPU 数
+	MemUsedBytes   int64   `json:"mem_used_bytes"`  // 已用内存
+	MemTotalBytes  int64   `json:"mem_total_bytes"` // 最大内存
+	MemUsage       float64 `json:"mem_usage"`       // 内存使用率，0-1
+	DiskReadBytes  int64   `json:"disk_read_bytes"`
+	DiskWriteBytes int64   `json:"disk_write_bytes"`
+	NetInBytes     int64   `json:"net_in_bytes"`
+	NetOutBytes    int64   `json:"net_out_bytes"`
+}
+
+// VMMetricsPoint RRD 数据点，磁盘和网络为每秒字节数；该时间点没有数据的字段为 null
+type VMMetricsPoint struct {
+	Time           int64    `json:"time"` // Unix 时间戳（秒）
+	CPUUsage       *float64 `json:"cpu_usage"`
+	MemUsedBytes   *float64 `json:"mem_used_bytes"`
+	MemTotalBytes  *float64 `json:"mem_total_bytes"`
+	DiskReadBytes  *float64 `json:"disk_read_bytes"`
+	DiskWriteBytes *float64 `json:"disk_write_bytes"`
+	NetInBytes     *float64 `json:"net_in_bytes"`
+	NetOutBytes    *float64 `json:"net_out_bytes"`
+}
+
+// VMLoadAverage guest 内 /proc/loadavg 中的 1、5、15 分钟平均负载
+type VMLoadAverage struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// VMAgentMetrics guest agent 数据，虚拟机未运行或未启用 agent 时只返回 enabled
+type VMAgentMetrics struct {
+	Enabled     bool           `json:"enabled"`      // 虚拟机配置中是否启用 agent
+	Available   bool           `json:"available"`    // agent 是否响应
+	OS          string         `json:"os"`           // pretty-name，如 "Ubuntu 22.04.4 LTS"
+	OSID        string         `json:"os_id"`        // id，如 ubuntu / mswindows
+	Kernel      string         `json:"kernel"`       // kernel-release
+	LoadAverage *VMLoadAverage `json:"load_average"` // Windows 没有平均负载，为 null
+}
+
+// VMMetricsData 虚拟机实时指标
+type VMMetricsData struct {
+	VMID        int64            `json:"vm_id"`
+	Vmid        uint32           `json:"vmid"`
+	Name        string           `json:"name"`
+	NodeName    string           `json:"node_name"`
+	Current     VMCurrentMetrics `json:"current"`
+	Balloon     VMBalloonMetrics `json:"balloon"`
+	Timeframe   string           `json:"timeframe"`
+	Cf          string           `json:"cf"`
+	Series      []VMMetricsPoint `json:"series"`
+	Agent       VMAgentMetrics   `json:"agent"`
+	Warnings    []string         `json:"warnings,omitempty"` // 获取失败的部分
+	CollectedAt time.Time        `json:"collected_at"`
+}
+
+// GetVMMetricsResponse 获取虚拟机实时指标响应
+type GetVMMetricsResponse struct {
+	Response
+	Data VMMetricsData
+}
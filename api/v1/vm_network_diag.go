@@ -0,0 +1,80 @@
+package v1
+
+// 虚拟机网络诊断相关 API 定义
+// 用于排查「虚拟机网络不通」类问题：从 PveSphere 服务器（可选从虚拟机内部经 guest agent）探测目标地址，
+// 同时返回虚拟机网卡、所在节点网桥/VLAN 配置以及 guest agent 上报的 IP，无需登录宿主机
+
+// VMNetworkDiagnoseRequest 网络诊断请求
+type VMNetworkDiagnoseRequest struct {
+	Target    string `json:"target" binding:"required,max=253" example:"10.0.0.1"` // 探测目标（IP 或主机名）
+	Ports     []int  `json:"ports,omitempty" binding:"omitempty,max=10,dive,min=1,max=65535" example:"22,443"`
+	Ping      *bool  `json:"ping,omitempty" example:"true"`                                  // 是否执行 ping，默认 true
+	FromGuest bool   `json:"from_guest,omitempty" example:"false"`                           // 是否同时从虚拟机内部探测（需 guest agent，仅管理员）
+	Timeout   int    `json:"timeout,omitempty" binding:"omitempty,min=1,max=10" example:"3"` // 单项探测超时（秒），默认 3
+}
+
+// VMNetworkCheck 单项连通性探测结果
+type VMNetworkCheck struct {
+	Source    string   `json:"source"`         // server / guest
+	Type      string   `json:"type"`           // ping / tcp
+	Port      int      `json:"port,omitempty"` // tcp 探测端口
+	Success   bool     `json:"success"`
+	LatencyMs *float64 `json:"latency_ms,omitempty"` // tcp 建连耗时或 ping 平均往返时间
+	Output    string   `json:"output,omitempty"`     // 命令输出（ping）
+	Error     string   `json:"error,omitempty"`
+}
+
+// VMNetworkBridge 节点网桥信息
+type VMNetworkBridge struct {
+	Name      string `json:"name"`
+	Exists    bool   `json:"exists"`
+	Active    bool   `json:"active"`
+	VLANAware bool   `json:"vlan_aware"`
+	Ports     string `json:"ports,omitempty"` // bridge_ports
+	CIDR      string `json:"cidr,omitempty"`  // 网桥自身地址
+	Gateway   string `json:"gateway,omitempty"`
+	Comments  string `json:"comments,omitempty"`
+}
+
+// VMNetworkInterface 虚拟机网卡（Proxmox 配置 netN）及对应网桥、guest 内地址
+type VMNetworkInterface struct {
+	Name       string           `json:"name"` // net0、net1 ...
+	Model      string           `json:"model"`
+	MAC        string           `json:"mac"`
+	Bridge     string           `json:"bridge"`
+	VLANTag    *int             `json:"vlan_tag,omitempty"`
+	Trunks     string           `json:"trunks,omitempty"`
+	Firewall   bool             `json:"firewall"`
+	LinkDown   bool             `json:"link_down"`
+	Rate       string           `json:"rate,omitempty"`
+	BridgeInfo *VMNetworkBridge `json:"bridge_info,omitempty"`
+	GuestName  string           `json:"guest_name,omitempty"` // guest 内网卡名称（按 MAC 匹配）
+	GuestIPs   []string         `json:"guest_ips"`
+}
+
+// VMNetworkGuestAgent guest agent 状态
+type VMNetworkGuestAgent struct {
+	Enabled   bool   `json:"enabled"`   // 配置中是否启用 agent
+	Available bool   `json:"available"` // 是否可以通信
+	OS        string `json:"os,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// VMNetworkDiagnoseData 网络诊断结果
+type VMNetworkDiagnoseData struct {
+	VMID         int64                `json:"vm_id"`
+	VMName       string               `json:"vm_name"`
+	Node         string               `json:"node"`
+	Status       string               `json:"status"` // 虚拟机运行状态
+	Target       string               `json:"target"`
+	Interfaces   []VMNetworkInterface `json:"interfaces"`
+	GuestAgent   VMNetworkGuestAgent  `json:"guest_agent"`
+	ServerChecks []VMNetworkCheck     `json:"server_checks"`
+	GuestChecks  []VMNetworkCheck     `json:"guest_checks"`
+	Hints        []string             `json:"hints"` // 根据配置和探测结果给出的排查提示
+}
+
+type VMNetworkDiagnoseResponse struct {
+	Response
+	Data VMNetworkDiagnoseData
+}
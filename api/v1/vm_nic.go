@@ -0,0 +1,22 @@
+package v1
+
+// 虚拟机网卡管理相关 API 定义
+// 添加网卡复用 VMNICSpec（与创建虚拟机时的网卡参数一致），MAC 由平台登记分配
+
+// UpdateVMNICRequest 修改网卡请求，未传的字段保持不变，MAC 地址不会改变
+type UpdateVMNICRequest struct {
+	Bridge   string `json:"bridge,omitempty" example:"vmbr1"`                                                               // 网桥名称
+	VLANTag  *int   `json:"vlan_tag,omitempty" binding:"omitempty,min=0,max=4094" example:"100"`                            // VLAN 标签，0 表示移除标签
+	Model    string `json:"model,omitempty" binding:"omitempty,oneof=virtio e1000 e1000e rtl8139 vmxnet3" example:"virtio"` // 网卡模型
+	Firewall *bool  `json:"firewall,omitempty" example:"true"`                                                              // 启用 / 关闭 Proxmox 防火墙
+}
+
+type ListVMNICsResponse struct {
+	Response
+	Data []VMNetworkInterface
+}
+
+type VMNICResponse struct {
+	Response
+	Data VMNetworkInterface
+}
@@ -0,0 +1,31 @@
+package v1
+
+import "time"
+
+// 虚拟机备注相关 API 定义
+// 备注即 Proxmox 配置中的 description（Proxmox 界面的 "Notes"，按 Markdown 渲染），以 Proxmox 为准双向同步：
+// 通过 PveSphere 修改时写入 Proxmox，在 Proxmox 中修改后由后台定期同步（集群外部变更轮询发现修改时立即同步）。
+// 备注中单独成行的 key=value（如 "owner=alice"、"backup.policy=daily"）解析为结构化注解，代码块中的行除外；
+// 写入时注解按 key 排序放在正文之后
+
+// VMNotesData 虚拟机备注
+type VMNotesData struct {
+	VmId        int64             `json:"vm_id"`
+	Description string            `json:"description"` // 完整的 description 原文
+	Notes       string            `json:"notes"`       // 去掉注解行后的 Markdown 正文
+	Annotations map[string]string `json:"annotations"` // key=value 注解
+	Digest      string            `json:"digest"`      // Proxmox 配置摘要，修改时传回用于检测并发修改
+	SyncTime    time.Time         `json:"sync_time"`   // 读取 Proxmox 的时间
+}
+
+type VMNotesResponse struct {
+	Response
+	Data VMNotesData
+}
+
+// UpdateVMNotesRequest 修改虚拟机备注
+type UpdateVMNotesRequest struct {
+	Notes       *string           `json:"notes,omitempty" example:"## 用途\n订单服务数据库"` // Markdown 正文，不传时保留原正文
+	Annotations map[string]string `json:"annotations,omitempty"`                    // 整体替换注解，不传时保留原注解，传空对象时清除
+	Digest      string            `json:"digest,omitempty" example:"3f5c..."`       // 读取备注时返回的摘要，与 Proxmox 当前配置不一致时拒绝修改
+}
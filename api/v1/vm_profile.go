@@ -0,0 +1,93 @@
+package v1
+
+import "time"
+
+// 虚拟机创建规格相关 API 定义
+// 规格保存 CPU、内存、磁盘、网络、SCSI 控制器、guest agent、标签等底层 QEMU 参数，
+// 创建虚拟机时通过 profile_id 引用，请求中显式传入的字段优先于规格；
+// cluster_id 为 0 的规格为全局规格。未传 profile_id 时依次使用集群默认规格、全局默认规格。
+
+// VMProfileSpec 规格参数，字符串为空、数值为 0 表示不指定
+type VMProfileSpec struct {
+	CPUNum     int    `json:"cpu_num" binding:"min=0,max=512" example:"2"`
+	MemorySize int    `json:"memory_size" binding:"min=0" example:"4096"` // MB
+	CPUType    string `json:"cpu_type" binding:"max=100" example:"x86-64-v2-AES"`
+	Storage    string `json:"storage" binding:"max=100" example:"local-lvm"` // 系统盘存储，全局规格一般不指定
+	DiskSizeGB int    `json:"disk_size_gb" binding:"min=0" example:"32"`     // 系统盘大小，仅 create_mode=iso/empty 生效
+	DiskFormat string `json:"disk_format" binding:"omitempty,oneof=raw qcow2 vmdk" example:"qcow2"`
+	Bridge     string `json:"bridge" binding:"max=50" example:"vmbr0"`
+	NetModel   string `json:"net_model" binding:"omitempty,oneof=virtio e1000 e1000e rtl8139 vmxnet3" example:"virtio"`
+	SCSIHw     string `json:"scsihw" binding:"omitempty,oneof=lsi lsi53c810 virtio-scsi-pci virtio-scsi-single megasas pvscsi" example:"virtio-scsi-single"`
+	OSType     string `json:"os_type" binding:"max=20" example:"l26"`
+	Agent      string `json:"agent" binding:"max=200" example:"enabled=1,fstrim_cloned_disks=1"`                                                                              // Proxmox agent 选项
+	Tags       string `json:"tags" binding:"max=500" example:"web;prod"`                                                                                                      // 以分号或逗号分隔
+	SerialNum  int    `json:"serial_num" binding:"min=0,max=4" example:"1"`                                                                                                   // 串口数量，0 表示不指定
+	Display    string `json:"display" binding:"omitempty,oneof=std cirrus vmware qxl qxl2 qxl3 qxl4 virtio virtio-gl serial0 serial1 serial2 serial3 none" example:"serial0"` // 显示设备（Proxmox vga）
+}
+
+// CreateVMProfileRequest 新增创建规格
+type CreateVMProfileRequest struct {
+	ClusterID int64  `json:"cluster_id" binding:"min=0" example:"1"` // 0 表示全局规格
+	Name      string `json:"name" binding:"required,max=100" example:"standard-linux"`
+	IsDefault bool   `json:"is_default" example:"false"` // 设为默认后，同一集群原默认规格自动取消
+	Describes string `json:"describes" binding:"max=500" example:"通用 Linux 虚拟机"`
+	VMProfileSpec
+}
+
+// UpdateVMProfileRequest 更新创建规格，不传的字段保持不变
+type UpdateVMProfileRequest struct {
+	Name       *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	IsDefault  *bool   `json:"is_default,omitempty"`
+	Describes  *string `json:"describes,omitempty" binding:"omitempty,max=500"`
+	CPUNum     *int    `json:"cpu_num,omitempty" binding:"omitempty,min=0,max=512"`
+	MemorySize *int    `json:"memory_size,omitempty" binding:"omitempty,min=0"`
+	CPUType    *string `json:"cpu_type,omitempty" binding:"omitempty,max=100"`
+	Storage    *string `json:"storage,omitempty" binding:"omitempty,max=100"`
+	DiskSizeGB *int    `json:"disk_size_gb,omitempty" binding:"omitempty,min=0"`
+	DiskFormat *string `json:"disk_format,omitempty" binding:"omitempty,oneof='' raw qcow2 vmdk"`
+	Bridge     *string `json:"bridge,omitempty" binding:"omitempty,max=50"`
+	NetModel   *string `json:"net_model,omitempty" binding:"omitempty,oneof='' virtio e1000 e1000e rtl8139 vmxnet3"`
+	SCSIHw     *string `json:"scsihw,omitempty" binding:"omitempty,oneof='' lsi lsi53c810 virtio-scsi-pci virtio-scsi-single megasas pvscsi"`
+	OSType     *string `json:"os_type,omitempty" binding:"omitempty,max=20"`
+	Agent      *string `json:"agent,omitempty" binding:"omitempty,max=200"`
+	Tags       *string `json:"tags,omitempty" binding:"omitempty,max=500"`
+	SerialNum  *int    `json:"serial_num,omitempty" binding:"omitempty,min=0,max=4"`
+	Display    *string `json:"display,omitempty" binding:"omitempty,oneof='' std cirrus vmware qxl qxl2 qxl3 qxl4 virtio virtio-gl serial0 serial1 serial2 serial3 none"`
+}
+
+// ListVMProfilesRequest 创建规格列表查询
+type ListVMProfilesRequest struct {
+	// ClusterID 大于 0 时返回该集群可用的规格（集群规格和全局规格）；不传返回全部
+	ClusterID int64 `form:"cluster_id" example:"1"`
+}
+
+// VMProfileItem 创建规格
+type VMProfileItem struct {
+	Id          int64  `json:"id"`
+	ClusterID   int64  `json:"cluster_id"`
+	ClusterName string `json:"cluster_name"` // 全局规格为空
+	Name        string `json:"name"`
+	IsDefault   bool   `json:"is_default"`
+	Describes   string `json:"describes"`
+	VMProfileSpec
+	Creator    string    `json:"creator"`
+	Modifier   string    `json:"modifier"`
+	CreateTime time.Time `json:"create_time"`
+	UpdateTime time.Time `json:"update_time"`
+}
+
+type ListVMProfilesResponseData struct {
+	List []VMProfileItem `json:"list"`
+}
+
+// ListVMProfilesResponse 创建规格列表响应
+type ListVMProfilesResponse struct {
+	Response
+	Data ListVMProfilesResponseData
+}
+
+// GetVMProfileResponse 创建规格详情响应
+type GetVMProfileResponse struct {
+	Response
+	Data VMProfileItem
+}
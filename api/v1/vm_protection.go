@@ -0,0 +1,32 @@
+package v1
+
+// 虚拟机删除保护相关 API 定义
+// 开启保护后，通过 API（包括批量删除和后台任务）删除、停止、迁移虚拟机都会被拒绝，只有管理员可以解除保护；
+// 可同时设置 Proxmox 配置中的 protection 标志，避免直接在 Proxmox 界面删除虚拟机或磁盘。
+// Proxmox 的 protection 标志同样受尊重：删除前检查，标志存在时拒绝删除并说明原因
+
+// ProtectVMRequest 开启删除保护（已开启时更新原因）
+type ProtectVMRequest struct {
+	Reason  string `json:"reason" binding:"required,max=500" example:"生产数据库"`
+	Proxmox bool   `json:"proxmox,omitempty" example:"true"` // 同时设置 Proxmox 的 protection 标志
+}
+
+// UnprotectVMRequest 解除删除保护，仅管理员
+type UnprotectVMRequest struct {
+	Proxmox bool `form:"proxmox" example:"true"` // 同时清除 Proxmox 的 protection 标志
+}
+
+// VMProtectionData 虚拟机删除保护状态
+type VMProtectionData struct {
+	VmId              int64    `json:"vm_id"`
+	Protected         bool     `json:"protected"`          // 是否开启了 PveSphere 删除保护
+	ProtectedBy       string   `json:"protected_by"`       // 开启保护的用户
+	Reason            string   `json:"reason"`             // 保护原因
+	ProxmoxProtection bool     `json:"proxmox_protection"` // Proxmox 配置中的 protection 标志，无法读取时为 false
+	BlockedActions    []string `json:"blocked_actions"`    // 被拒绝的操作
+}
+
+type VMProtectionResponse struct {
+	Response
+	Data VMProtectionData
+}
@@ -0,0 +1,132 @@
+package v1
+
+import "time"
+
+// VM QoS（磁盘/网卡限速）相关 API 定义
+// 参考: https://pve.proxmox.com/pve-docs/qm.1.html 中磁盘的 mbps_rd/mbps_wr/iops_rd/iops_wr 以及网卡的 rate 选项
+
+// QosLimits 限速参数（0 或不传表示不限速）
+type QosLimits struct {
+	MbpsRd  *float64 `json:"mbps_rd,omitempty" example:"100"`  // 磁盘读带宽上限（MB/s）
+	MbpsWr  *float64 `json:"mbps_wr,omitempty" example:"100"`  // 磁盘写带宽上限（MB/s）
+	IopsRd  *int     `json:"iops_rd,omitempty" example:"2000"` // 磁盘读 IOPS 上限
+	IopsWr  *int     `json:"iops_wr,omitempty" example:"2000"` // 磁盘写 IOPS 上限
+	NetRate *float64 `json:"net_rate,omitempty" example:"50"`  // 网卡速率上限（MB/s）
+}
+
+// CreateQosProfileRequest 创建 QoS 模板请求
+type CreateQosProfileRequest struct {
+	Name        string `json:"name" binding:"required" example:"standard"` // 模板名称（唯一）
+	Description string `json:"description,omitempty" example:"普通业务限速"`     // 描述
+	QosLimits
+}
+
+// UpdateQosProfileRequest 更新 QoS 模板请求
+type UpdateQosProfileRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	QosLimits
+}
+
+// ListQosProfileRequest 列表查询请求
+type ListQosProfileRequest struct {
+	Page     int    `form:"page" example:"1"`
+	PageSize int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	Name     string `form:"name" example:"standard"` // 名称（模糊匹配）
+}
+
+// QosProfileItem QoS 模板信息
+type QosProfileItem struct {
+	Id          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	MbpsRd      float64   `json:"mbps_rd"`
+	MbpsWr      float64   `json:"mbps_wr"`
+	IopsRd      int       `json:"iops_rd"`
+	IopsWr      int       `json:"iops_wr"`
+	NetRate     float64   `json:"net_rate"`
+	CreateTime  time.Time `json:"create_time"`
+	UpdateTime  time.Time `json:"update_time"`
+	Creator     string    `json:"creator"`
+	Modifier    string    `json:"modifier"`
+}
+
+// ListQosProfileResponse 列表查询响应
+type ListQosProfileResponse struct {
+	Response
+	Data ListQosProfileResponseData
+}
+
+type ListQosProfileResponseData struct {
+	Total int64            `json:"total"`
+	List  []QosProfileItem `json:"list"`
+}
+
+// GetQosProfileResponse 详情查询响应
+type GetQosProfileResponse struct {
+	Response
+	Data QosProfileItem
+}
+
+// ApplyQosProfileRequest 将 QoS 模板批量应用到虚拟机
+type ApplyQosProfileRequest struct {
+	VMIDs []int64 `json:"vm_ids" binding:"required,min=1" example:"1,2,3"` // 虚拟机ID列表（数据库ID）
+}
+
+// ApplyQosProfileResponse 批量应用响应
+type ApplyQosProfileResponse struct {
+	Response
+	Data ApplyQosProfileResponseData
+}
+
+type ApplyQosProfileResponseData struct {
+	Success int              `json:"success"` // 成功数量
+	Failed  int              `json:"failed"`  // 失败数量
+	Results []VMQosApplyItem `json:"results"` // 每台虚拟机的结果
+}
+
+type VMQosApplyItem struct {
+	VMID    int64  `json:"vm_id"`             // 虚拟机ID（数据库ID）
+	Success bool   `json:"success"`           // 是否成功
+	Message string `json:"message,omitempty"` // 失败原因
+}
+
+// GetVMQosRequest 获取虚拟机限速配置请求
+type GetVMQosRequest struct {
+	VMID int64 `form:"vm_id" binding:"required" example:"1"` // 虚拟机ID（数据库ID）
+}
+
+// VMDeviceQos 单个设备的限速信息
+type VMDeviceQos struct {
+	Device  string  `json:"device"`             // 设备键，如 scsi0、net0
+	MbpsRd  float64 `json:"mbps_rd,omitempty"`  // 磁盘读带宽上限（MB/s）
+	MbpsWr  float64 `json:"mbps_wr,omitempty"`  // 磁盘写带宽上限（MB/s）
+	IopsRd  int     `json:"iops_rd,omitempty"`  // 磁盘读 IOPS 上限
+	IopsWr  int     `json:"iops_wr,omitempty"`  // 磁盘写 IOPS 上限
+	NetRate float64 `json:"net_rate,omitempty"` // 网卡速率上限（MB/s）
+}
+
+// GetVMQosResponse 获取虚拟机限速配置响应
+type GetVMQosResponse struct {
+	Response
+	Data GetVMQosResponseData
+}
+
+type GetVMQosResponseData struct {
+	VMID  int64         `json:"vm_id"`
+	Disks []VMDeviceQos `json:"disks"`
+	Nics  []VMDeviceQos `json:"nics"`
+}
+
+// UpdateVMQosRequest 更新虚拟机限速配置请求
+// Devices 为空时对所有磁盘/网卡生效；传 0 表示取消该项限速
+type UpdateVMQosRequest struct {
+	VMID    int64    `json:"vm_id" binding:"required" example:"1"`   // 虚拟机ID（数据库ID）
+	Devices []string `json:"devices,omitempty" example:"scsi0,net0"` // 目标设备（可选）
+	QosLimits
+}
+
+// UpdateVMQosResponse 更新虚拟机限速配置响应
+type UpdateVMQosResponse struct {
+	Response
+}
@@ -0,0 +1,100 @@
+package v1
+
+import "time"
+
+// 虚拟机配置安全检查相关 API 定义
+// 按规则检查虚拟机配置中的风险项，每个风险项带风险等级和修复建议；可自动修复的风险项通过 remediate 接口一键修复：
+// - agent_disabled：未开启 guest agent 选项（修复：开启，关机后再启动生效）
+// - firewall_disabled：网卡未开启 Proxmox 防火墙（修复：为网卡开启 firewall=1）
+// - vnc_no_password：args 中通过 -vnc 暴露了不需要密码的 VNC（需人工处理）
+// - outdated_machine：机器类型固定在低于 security_scan.min_machine_version 的版本（修复：改为不固定版本，重启后生效）
+// - installer_iso_mounted：光驱中仍挂载着 ISO（修复：弹出）
+// - cpu_kvm64_prod：生产环境虚拟机使用 kvm64 CPU 类型（修复：改为 security_scan.cpu_type，重启后生效）
+// 扫描结果保存在平台数据库，后台定期全量扫描，单台虚拟机查询时实时扫描
+
+// 安全检查项
+const (
+	SecurityFindingAgentDisabled    = "agent_disabled"
+	SecurityFindingFirewallDisabled = "firewall_disabled"
+	SecurityFindingVNCNoPassword    = "vnc_no_password"
+	SecurityFindingOutdatedMachine  = "outdated_machine"
+	SecurityFindingISOMounted       = "installer_iso_mounted"
+	SecurityFindingCPUKVM64Prod     = "cpu_kvm64_prod"
+)
+
+// 风险等级
+const (
+	SecuritySeverityLow    = "low"
+	SecuritySeverityMedium = "medium"
+	SecuritySeverityHigh   = "high"
+)
+
+// VMSecurityFindingItem 风险项
+type VMSecurityFindingItem struct {
+	ClusterID   int64     `json:"cluster_id"`
+	VmId        int64     `json:"vm_id"`
+	VMID        uint32    `json:"vmid"`
+	VmName      string    `json:"vm_name"`
+	Code        string    `json:"code"`
+	Severity    string    `json:"severity"` // low / medium / high
+	Target      string    `json:"target"`   // 涉及的配置项，如 net0、ide2
+	Message     string    `json:"message"`
+	Remediation string    `json:"remediation"` // 修复建议
+	Fixable     bool      `json:"fixable"`     // 能否通过 remediate 接口自动修复
+	ScanTime    time.Time `json:"scan_time"`
+}
+
+// VMSecurityScanData 单台虚拟机的扫描结果
+type VMSecurityScanData struct {
+	VmId     int64                   `json:"vm_id"`
+	ScanTime time.Time               `json:"scan_time"`
+	Findings []VMSecurityFindingItem `json:"findings"` // 按风险等级从高到低
+}
+
+type VMSecurityScanResponse struct {
+	Response
+	Data VMSecurityScanData
+}
+
+// ListVMSecurityFindingsRequest 查询全部虚拟机的风险项（最近一次扫描结果）
+type ListVMSecurityFindingsRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"20"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	Severity  string `form:"severity" binding:"omitempty,oneof=low medium high" example:"high"`
+	Code      string `form:"code" example:"firewall_disabled"`
+}
+
+type ListVMSecurityFindingsResponseData struct {
+	Total      int64                   `json:"total"`
+	BySeverity map[string]int64        `json:"by_severity"` // 按风险等级统计（受 cluster_id 过滤）
+	List       []VMSecurityFindingItem `json:"list"`
+}
+
+type ListVMSecurityFindingsResponse struct {
+	Response
+	Data ListVMSecurityFindingsResponseData
+}
+
+// RemediateVMSecurityRequest 自动修复风险项
+type RemediateVMSecurityRequest struct {
+	Codes []string `json:"codes" binding:"required,min=1,dive,oneof=agent_disabled firewall_disabled outdated_machine installer_iso_mounted cpu_kvm64_prod" example:"firewall_disabled"`
+}
+
+// VMSecuritySkipped 未修复的风险项及原因
+type VMSecuritySkipped struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+type RemediateVMSecurityData struct {
+	Applied         []string                `json:"applied"`          // 已修复的风险项
+	Skipped         []VMSecuritySkipped     `json:"skipped"`          // 未发现或无需修复的风险项
+	RestartRequired bool                    `json:"restart_required"` // 修改需要关机后再启动才能生效
+	Findings        []VMSecurityFindingItem `json:"findings"`         // 修复后重新扫描的结果
+}
+
+type RemediateVMSecurityResponse struct {
+	Response
+	Data RemediateVMSecurityData
+}
@@ -0,0 +1,129 @@
+package v1
+
+import "time"
+
+// 虚拟机编排（Stack）相关 API 定义
+// 一个 stack 由若干角色组成（如 3 个 master + N 个 worker），每个角色从模板批量创建，
+// 按角色 order 顺序逐台创建，完成后可获取全部虚拟机的 IP 列表。
+
+// CreateVMStackRequest 创建 stack 请求
+type CreateVMStackRequest struct {
+	Name        string            `json:"name" binding:"required" example:"k8s-prod"`  // stack 名称（小写字母、数字、-），同时作为虚拟机名称前缀
+	ClusterID   int64             `json:"cluster_id" binding:"required" example:"1"`   // 集群ID
+	TemplateID  int64             `json:"template_id,omitempty" example:"1"`           // 默认模板ID（角色未指定 template_id 时使用）
+	Description string            `json:"description,omitempty" example:"生产 k8s 集群节点"` // 描述
+	CloudInit   *VMStackCloudInit `json:"cloud_init,omitempty"`                        // 公共 cloud-init 配置
+	Roles       []VMStackRole     `json:"roles" binding:"required,min=1,dive"`         // 角色定义
+}
+
+// VMStackRole stack 中的角色（同一角色的虚拟机规格相同）
+type VMStackRole struct {
+	Name        string            `json:"name" binding:"required" example:"master"`      // 角色名称（小写字母、数字、-），虚拟机命名为 <stack>-<role>-<序号>
+	Count       int               `json:"count" binding:"required,min=1" example:"3"`    // 虚拟机数量
+	Order       int               `json:"order" example:"0"`                             // 创建顺序，数值小的角色先创建（相同时按定义顺序）
+	TemplateID  int64             `json:"template_id,omitempty" example:"1"`             // 模板ID（不传则使用 stack 的 template_id）
+	NodeIDs     []int64           `json:"node_ids,omitempty" example:"1,2,3"`            // 可放置的节点ID，按顺序轮流放置（不传则使用集群内所有可调度节点）
+	CPUNum      *int              `json:"cpu_num,omitempty" example:"4"`                 // CPU 核数（不传则保持模板配置）
+	MemorySize  *int              `json:"memory_size,omitempty" example:"8192"`          // 内存（MB，不传则保持模板配置）
+	Storage     string            `json:"storage,omitempty" example:"local-lvm"`         // 目标存储（不传则与模板相同）
+	IPAddresses []string          `json:"ip_addresses,omitempty" example:"10.0.0.11/24"` // 静态 IP（CIDR），数量必须与 count 一致；不传则使用 DHCP
+	CloudInit   *VMStackCloudInit `json:"cloud_init,omitempty"`                          // 角色级 cloud-init 配置，非空字段覆盖公共配置
+}
+
+// VMStackCloudInit cloud-init 配置
+type VMStackCloudInit struct {
+	User         string `json:"user,omitempty" example:"ubuntu"`
+	Password     string `json:"password,omitempty" example:"password"`
+	SSHKeys      string `json:"ssh_keys,omitempty" example:"ssh-ed25519 AAAA..."` // SSH 公钥（多个用换行分隔）
+	Gateway      string `json:"gateway,omitempty" example:"10.0.0.1"`             // 静态 IP 时的网关
+	Nameserver   string `json:"nameserver,omitempty" example:"10.0.0.1"`
+	Searchdomain string `json:"searchdomain,omitempty" example:"cluster.local"`
+}
+
+// CreateVMStackResponse 创建 stack 响应
+type CreateVMStackResponse struct {
+	Response
+	Data VMStackDetail
+}
+
+// ListVMStacksRequest stack 列表请求
+type ListVMStacksRequest struct {
+	Page      int   `form:"page" example:"1"`
+	PageSize  int   `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	ClusterID int64 `form:"cluster_id" example:"1"`
+}
+
+// VMStackItem stack 信息
+type VMStackItem struct {
+	Id           int64      `json:"id"`
+	Name         string     `json:"name"`
+	ClusterID    int64      `json:"cluster_id"`
+	Description  string     `json:"description"`
+	Status       string     `json:"status"` // pending, running, completed, failed
+	MemberCount  int        `json:"member_count"`
+	ErrorMessage string     `json:"error_message"`
+	StartTime    *time.Time `json:"start_time"`
+	EndTime      *time.Time `json:"end_time"`
+	Creator      string     `json:"creator"`
+	CreateTime   time.Time  `json:"create_time"`
+}
+
+// VMStackMember stack 中的虚拟机
+type VMStackMember struct {
+	Id           int64  `json:"id"`
+	Role         string `json:"role"`
+	Seq          int    `json:"seq"` // 角色内序号（从 1 开始）
+	VmName       string `json:"vm_name"`
+	NodeID       int64  `json:"node_id"`
+	NodeName     string `json:"node_name"`
+	TemplateID   int64  `json:"template_id"`
+	VMID         uint32 `json:"vmid"`  // Proxmox VMID
+	VmId         int64  `json:"vm_id"` // 虚拟机数据库ID（创建成功后填充）
+	IPAddress    string `json:"ip_address"`
+	Status       string `json:"status"` // pending, cloning, configuring, starting, completed, failed, skipped
+	ErrorMessage string `json:"error_message"`
+}
+
+// VMStackDetail stack 详情
+type VMStackDetail struct {
+	VMStackItem
+	Members []VMStackMember `json:"members"`
+}
+
+// GetVMStackResponse stack 详情响应
+type GetVMStackResponse struct {
+	Response
+	Data VMStackDetail
+}
+
+// ListVMStacksResponse stack 列表响应
+type ListVMStacksResponse struct {
+	Response
+	Data ListVMStacksResponseData
+}
+
+type ListVMStacksResponseData struct {
+	Total int64         `json:"total"`
+	List  []VMStackItem `json:"list"`
+}
+
+// VMStackIP stack 中虚拟机的 IP
+type VMStackIP struct {
+	Role      string `json:"role"`
+	VmName    string `json:"vm_name"`
+	IPAddress string `json:"ip_address"`
+}
+
+// GetVMStackIPsResponseData stack IP 列表，ByRole 便于直接生成 ansible/kubespray inventory
+type GetVMStackIPsResponseData struct {
+	Name   string              `json:"name"`
+	Status string              `json:"status"`
+	List   []VMStackIP         `json:"list"`
+	ByRole map[string][]string `json:"by_role"`
+}
+
+// GetVMStackIPsResponse stack IP 列表响应
+type GetVMStackIPsResponse struct {
+	Response
+	Data GetVMStackIPsResponseData
+}
@@ -0,0 +1,94 @@
+package v1
+
+import "time"
+
+// 虚拟机开机自启与启动顺序相关 API 定义
+// 对应 Proxmox 的 onboot 和 startup（order=<n>,up=<秒>,down=<秒>）：节点启动时按 order 从小到大启动开机自启的虚拟机，
+// 每台启动后等待 up 秒再启动下一台；关机时按相反顺序，每台最多等待 down 秒。
+// 启动顺序策略按 Proxmox 标签对集群内的虚拟机分组（如数据库 db 先于应用 app 启动），批量写入匹配的虚拟机
+
+// VMStartupConfig 开机自启和启动顺序；order / up / down 都不传时删除 startup，使用 Proxmox 默认顺序
+type VMStartupConfig struct {
+	OnBoot bool `json:"onboot" example:"true"`                                           // 节点启动时自动启动
+	Order  *int `json:"order,omitempty" binding:"omitempty,min=0,max=10000" example:"1"` // 启动顺序，越小越先启动，关机时相反
+	Up     *int `json:"up,omitempty" binding:"omitempty,min=0,max=3600" example:"30"`    // 启动后等待秒数
+	Down   *int `json:"down,omitempty" binding:"omitempty,min=0,max=3600" example:"60"`  // 关机超时秒数
+}
+
+type VMStartupResponse struct {
+	Response
+	Data VMStartupConfig
+}
+
+// BootOrderPolicyRequest 创建 / 更新启动顺序策略
+type BootOrderPolicyRequest struct {
+	ClusterID   int64  `json:"cluster_id" binding:"required,min=1" example:"1"`
+	Tag         string `json:"tag" binding:"required,max=100" example:"db"` // 匹配的 Proxmox 标签（不区分大小写）
+	Description string `json:"description" binding:"max=500" example:"数据库先于应用启动"`
+	VMStartupConfig
+}
+
+// ListBootOrderPoliciesRequest 启动顺序策略列表查询
+type ListBootOrderPoliciesRequest struct {
+	ClusterID int64 `form:"cluster_id" example:"1"` // 不传返回全部集群
+}
+
+// BootOrderPolicyItem 启动顺序策略
+type BootOrderPolicyItem struct {
+	Id          int64  `json:"id"`
+	ClusterID   int64  `json:"cluster_id"`
+	ClusterName string `json:"cluster_name"`
+	Tag         string `json:"tag"`
+	Description string `json:"description"`
+	VMStartupConfig
+	Creator    string    `json:"creator"`
+	Modifier   string    `json:"modifier"`
+	CreateTime time.Time `json:"create_time"`
+	UpdateTime time.Time `json:"update_time"`
+}
+
+type ListBootOrderPoliciesResponseData struct {
+	List []BootOrderPolicyItem `json:"list"`
+}
+
+type ListBootOrderPoliciesResponse struct {
+	Response
+	Data ListBootOrderPoliciesResponseData
+}
+
+type BootOrderPolicyResponse struct {
+	Response
+	Data BootOrderPolicyItem
+}
+
+// ApplyBootOrderPoliciesRequest 将集群的启动顺序策略写入匹配的虚拟机
+type ApplyBootOrderPoliciesRequest struct {
+	ClusterID int64 `json:"cluster_id" binding:"required,min=1" example:"1"`
+	DryRun    bool  `json:"dry_run" example:"true"` // 只返回将要修改的虚拟机，不写入
+}
+
+// BootOrderApplyItem 单台虚拟机的策略应用结果
+type BootOrderApplyItem struct {
+	VMId    int64  `json:"vm_id,omitempty"` // 平台虚拟机ID，未同步到平台时为 0
+	VMID    uint32 `json:"vmid"`
+	VmName  string `json:"vm_name"`
+	Node    string `json:"node"`
+	Tag     string `json:"tag"`     // 命中的策略标签，多个标签命中时取 order 最小的策略
+	OnBoot  bool   `json:"onboot"`  // 策略要求的 onboot
+	Startup string `json:"startup"` // 策略要求的 startup
+	Current string `json:"current"` // 当前配置，如 onboot=1 startup=order=2
+	Changed bool   `json:"changed"` // 是否需要修改（dry_run=false 时为已修改）
+	Error   string `json:"error,omitempty"`
+}
+
+type ApplyBootOrderPoliciesData struct {
+	Matched int                  `json:"matched"` // 命中策略的虚拟机数量
+	Changed int                  `json:"changed"`
+	Failed  int                  `json:"failed"`
+	Items   []BootOrderApplyItem `json:"items"`
+}
+
+type ApplyBootOrderPoliciesResponse struct {
+	Response
+	Data ApplyBootOrderPoliciesData
+}
@@ -0,0 +1,78 @@
+package v1
+
+import "time"
+
+// 虚拟机存储迁移相关 API 定义
+// 将虚拟机的全部磁盘（含 EFI / TPM 磁盘，不含 CD-ROM 和 cloud-init 盘）通过 Proxmox move_disk 移动到目标存储，
+// 已在目标存储上的磁盘跳过。全部磁盘移动成功后更新虚拟机记录的 storage / storage_cfg。
+
+// CreateVMStorageMoveRequest 创建存储迁移任务请求
+type CreateVMStorageMoveRequest struct {
+	VmId          int64  `json:"vm_id" binding:"required" example:"1"`                 // 虚拟机数据库ID
+	TargetStorage string `json:"target_storage" binding:"required" example:"ceph-rbd"` // 目标存储（需支持 images）
+	Format        string `json:"format,omitempty" binding:"omitempty,oneof=raw qcow2 vmdk" example:"raw"`
+	DeleteSource  bool   `json:"delete_source,omitempty" example:"true"`                       // 移动完成后删除源卷，否则保留为 unusedN
+	Parallel      bool   `json:"parallel,omitempty" example:"false"`                           // 各磁盘并行移动，默认逐个移动
+	Bwlimit       int    `json:"bwlimit,omitempty" binding:"omitempty,min=0" example:"102400"` // 单个磁盘的带宽限制 KiB/s
+}
+
+// ListVMStorageMovesRequest 存储迁移任务列表请求
+type ListVMStorageMovesRequest struct {
+	Page      int    `form:"page" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,max=100" example:"10"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	VmId      int64  `form:"vm_id" example:"1"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending running completed failed" example:"running"`
+}
+
+// VMStorageMoveDiskItem 单个磁盘的移动进度
+type VMStorageMoveDiskItem struct {
+	Disk         string `json:"disk"`
+	SourceVolume string `json:"source_volume"`
+	Status       string `json:"status"` // pending, running, completed, failed
+	UPID         string `json:"upid"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// VMStorageMoveItem 存储迁移任务信息
+type VMStorageMoveItem struct {
+	Id            int64                   `json:"id"`
+	ClusterID     int64                   `json:"cluster_id"`
+	NodeID        int64                   `json:"node_id"`
+	NodeName      string                  `json:"node_name"`
+	VmId          int64                   `json:"vm_id"`
+	VMID          uint32                  `json:"vmid"`
+	VmName        string                  `json:"vm_name"`
+	TargetStorage string                  `json:"target_storage"`
+	TargetFormat  string                  `json:"target_format"`
+	DeleteSource  bool                    `json:"delete_source"`
+	Parallel      bool                    `json:"parallel"`
+	Bwlimit       int                     `json:"bwlimit"`
+	Status        string                  `json:"status"` // pending, running, completed, failed
+	TotalDisks    int                     `json:"total_disks"`
+	MovedDisks    int                     `json:"moved_disks"`
+	Progress      int                     `json:"progress"` // 0-100，按已完成磁盘数计算
+	Disks         []VMStorageMoveDiskItem `json:"disks"`
+	ErrorMessage  string                  `json:"error_message"`
+	StartTime     *time.Time              `json:"start_time"`
+	EndTime       *time.Time              `json:"end_time"`
+	Creator       string                  `json:"creator"`
+	CreateTime    time.Time               `json:"create_time"`
+}
+
+// GetVMStorageMoveResponse 存储迁移任务详情响应
+type GetVMStorageMoveResponse struct {
+	Response
+	Data VMStorageMoveItem
+}
+
+// ListVMStorageMovesResponse 存储迁移任务列表响应
+type ListVMStorageMovesResponse struct {
+	Response
+	Data ListVMStorageMovesResponseData
+}
+
+type ListVMStorageMovesResponseData struct {
+	Total int64               `json:"total"`
+	List  []VMStorageMoveItem `json:"list"`
+}
@@ -0,0 +1,101 @@
+package v1
+
+import "time"
+
+// VMID 段相关 API 定义
+// 管理员可以把 VMID 段分配给团队或集群（如 1000-1999 给 team-a），创建虚拟机且未指定 vmid 时，
+// 平台从请求中 team 对应的 VMID 段分配（没有团队专属段时使用集群的默认段，即 team 为空的段），
+// 跳过集群内已被占用的 VMID，从而与手动创建的虚拟机错开。删除虚拟机后其 VMID 进入隔离期
+// （vmid_range.quarantine），隔离期满后才会被再次分配。没有配置任何 VMID 段时保持随机 8 位 VMID。
+
+// CreateVMIDRangeRequest 新增 VMID 段
+type CreateVMIDRangeRequest struct {
+	ClusterID   int64  `json:"cluster_id" binding:"min=0" example:"1"`  // 0 表示适用于全部集群
+	Team        string `json:"team" binding:"max=100" example:"team-a"` // 为空表示集群的默认段
+	Start       uint32 `json:"start" binding:"required,min=100,max=999999999" example:"1000"`
+	End         uint32 `json:"end" binding:"required,min=100,max=999999999" example:"1999"`
+	Description string `json:"description" binding:"max=500" example:"team-a 的虚拟机"`
+}
+
+// UpdateVMIDRangeRequest 更新 VMID 段，不传的字段保持不变
+type UpdateVMIDRangeRequest struct {
+	Team        *string `json:"team,omitempty" binding:"omitempty,max=100"`
+	Start       *uint32 `json:"start,omitempty" binding:"omitempty,min=100,max=999999999"`
+	End         *uint32 `json:"end,omitempty" binding:"omitempty,min=100,max=999999999"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=500"`
+}
+
+// ListVMIDRangesRequest VMID 段列表查询
+type ListVMIDRangesRequest struct {
+	ClusterID int64  `form:"cluster_id" example:"1"` // 返回该集群适用的段（含全部集群适用的段）
+	Team      string `form:"team" example:"team-a"`
+}
+
+// VMIDRangeItem VMID 段
+type VMIDRangeItem struct {
+	Id          int64     `json:"id"`
+	ClusterID   int64     `json:"cluster_id"`
+	ClusterName string    `json:"cluster_name"` // cluster_id 为 0 时为空
+	Team        string    `json:"team"`
+	Start       uint32    `json:"start"`
+	End         uint32    `json:"end"`
+	Size        int64     `json:"size"`
+	Allocated   int64     `json:"allocated"`   // 已分配给虚拟机的 VMID 数
+	Quarantined int64     `json:"quarantined"` // 处于隔离期的 VMID 数
+	Description string    `json:"description"`
+	Creator     string    `json:"creator"`
+	Modifier    string    `json:"modifier"`
+	CreateTime  time.Time `json:"create_time"`
+	UpdateTime  time.Time `json:"update_time"`
+}
+
+type ListVMIDRangesResponseData struct {
+	List []VMIDRangeItem `json:"list"`
+}
+
+// ListVMIDRangesResponse VMID 段列表响应
+type ListVMIDRangesResponse struct {
+	Response
+	Data ListVMIDRangesResponseData
+}
+
+// GetVMIDRangeResponse VMID 段详情响应
+type GetVMIDRangeResponse struct {
+	Response
+	Data VMIDRangeItem
+}
+
+// ListVMIDAllocationsRequest VMID 分配记录查询
+type ListVMIDAllocationsRequest struct {
+	Page      int    `form:"page" binding:"omitempty,min=1" example:"1"`
+	PageSize  int    `form:"page_size" binding:"omitempty,min=1,max=100" example:"20"`
+	ClusterID int64  `form:"cluster_id" example:"1"`
+	RangeID   int64  `form:"range_id" example:"1"`
+	Status    string `form:"status" binding:"omitempty,oneof=allocated released" example:"released"`
+}
+
+// VMIDAllocationItem VMID 分配记录
+type VMIDAllocationItem struct {
+	Id              int64      `json:"id"`
+	ClusterID       int64      `json:"cluster_id"`
+	VMID            uint32     `json:"vmid"`
+	RangeID         int64      `json:"range_id"`
+	Team            string     `json:"team"`
+	Status          string     `json:"status"` // allocated / released
+	VmName          string     `json:"vm_name"`
+	ReleasedAt      *time.Time `json:"released_at"`
+	QuarantineUntil *time.Time `json:"quarantine_until"` // 隔离期结束时间，之后可再次分配
+	CreateTime      time.Time  `json:"create_time"`
+	UpdateTime      time.Time  `json:"update_time"`
+}
+
+type ListVMIDAllocationsResponseData struct {
+	Total int64                `json:"total"`
+	List  []VMIDAllocationItem `json:"list"`
+}
+
+// ListVMIDAllocationsResponse VMID 分配记录列表响应
+type ListVMIDAllocationsResponse struct {
+	Response
+	Data ListVMIDAllocationsResponseData
+}
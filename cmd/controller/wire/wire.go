@@ -23,6 +23,7 @@ var repositorySet = wire.NewSet(
 	repository.NewPveNodeRepository,
 	repository.NewPveVMRepository,
 	repository.NewPveStorageRepository,
+	repository.NewVMStatusEventRepository,
 	repository.NewVMIPAddressRepository,
 	repository.NewVmTemplateRepository,
 )
@@ -27,7 +27,8 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 	pveVMRepository := repository.NewPveVMRepository(repositoryRepository)
 	pveStorageRepository := repository.NewPveStorageRepository(repositoryRepository)
 	duration := _wireDurationValue
-	pveController := controller.NewPveController(pveClusterRepository, pveNodeRepository, pveVMRepository, pveStorageRepository, logger, duration)
+	vmStatusEventRepository := repository.NewVMStatusEventRepository(repositoryRepository)
+	pveController := controller.NewPveController(pveClusterRepository, pveNodeRepository, pveVMRepository, pveStorageRepository, vmStatusEventRepository, logger, duration)
 	controllerServer := server.NewControllerServer(logger, pveController)
 	appApp := newApp(controllerServer)
 	return appApp, func() {
@@ -41,7 +42,7 @@ var (
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewPveClusterRepository, repository.NewPveNodeRepository, repository.NewPveVMRepository, repository.NewPveStorageRepository, repository.NewVMIPAddressRepository, repository.NewVmTemplateRepository)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewPveClusterRepository, repository.NewPveNodeRepository, repository.NewPveVMRepository, repository.NewPveStorageRepository, repository.NewVMStatusEventRepository, repository.NewVMIPAddressRepository, repository.NewVmTemplateRepository)
 
 var controllerSet = wire.NewSet(controller.NewPveController)
 
@@ -3,18 +3,52 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"pvesphere/cmd/migration/wire"
+	"pvesphere/internal/migration"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/server"
 	"pvesphere/pkg/config"
 	"pvesphere/pkg/log"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 func main() {
 	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
+	var status = flag.Bool("status", false, "print the schema version and pending migrations without migrating")
+	var copyFrom = flag.String("copy-from", "", "copy all data from another database into the -conf database, eg: -copy-from ./config/local.yml -conf ./config/prod.yml")
 	flag.Parse()
 	conf := config.NewConfig(*envConf)
 
 	logger := log.NewLog(conf)
 
+	if *status {
+		if err := printStatus(repository.NewDB(conf, logger)); err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	// 嵌入式 SQLite 迁移到 MySQL / PostgreSQL：先在目标库建表，再整库拷贝数据（不创建默认用户，用户随数据一并迁移）
+	if *copyFrom != "" {
+		srcConf := config.NewConfig(*copyFrom)
+		src := repository.NewDB(srcConf, logger)
+		dst := repository.NewDB(conf, logger)
+		if err := server.AutoMigrate(dst); err != nil {
+			panic(err)
+		}
+		if err := server.CopyDatabase(context.Background(), src, dst, logger); err != nil {
+			logger.Error("copy database failed", zap.Error(err))
+			panic(err)
+		}
+		logger.Info("copy database success",
+			zap.String("from", srcConf.GetString("data.db.user.driver")),
+			zap.String("to", conf.GetString("data.db.user.driver")))
+		return
+	}
+
 	app, cleanup, err := wire.NewWire(conf, logger)
 	defer cleanup()
 	if err != nil {
@@ -24,3 +58,20 @@ func main() {
 		panic(err)
 	}
 }
+
+// printStatus 输出表结构版本以及各迁移的执行情况
+func printStatus(db *gorm.DB) error {
+	current, statuses, err := migration.GetStatus(context.Background(), db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("current version: %d\nlatest version:  %d\n", current, migration.Latest())
+	for _, status := range statuses {
+		if status.Applied {
+			fmt.Printf("  %4d  %-8s %s  %s\n", status.Version, "applied", status.AppliedAt.Format("2006-01-02 15:04:05"), status.Name)
+		} else {
+			fmt.Printf("  %4d  %-8s %-19s  %s\n", status.Version, "pending", "", status.Name)
+		}
+	}
+	return nil
+}
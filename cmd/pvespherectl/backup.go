@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/spf13/cobra"
+)
+
+func newBackupCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Manage VM backups",
+	}
+	cmd.AddCommand(newBackupCreateCmd(), newBackupListCmd())
+	return cmd
+}
+
+func newBackupCreateCmd() *cobra.Command {
+	var (
+		req       = new(v1.CreateBackupRequest)
+		vmid      uint32
+		clusterID int64
+		wait      bool
+	)
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a vzdump backup of a virtual machine",
+		Example: `  pvespherectl backup create --vmid 100 --storage local --mode snapshot --compress zstd
+  pvespherectl backup create --vmid 100 --storage local --wait --cluster-id 1`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if vmid == 0 {
+				return errors.New("--vmid is required")
+			}
+			if wait && clusterID <= 0 {
+				return errors.New("--cluster-id is required with --wait")
+			}
+			req.VMID = vmid
+
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			var data v1.CreateBackupResponseData
+			if err := client.post("/vms/backup", req, &data); err != nil {
+				return err
+			}
+			if !wait {
+				rows := [][]string{{strconv.FormatUint(uint64(data.VMID), 10), data.NodeName, data.UPID}}
+				return printResult(data, []string{"VMID", "NODE", "UPID"}, rows)
+			}
+			return waitForTask(client, wait, clusterID, data.UPID)
+		},
+	}
+	cmd.Flags().Uint32Var(&vmid, "vmid", 0, "Proxmox VM ID (required)")
+	cmd.Flags().StringVar(&req.Storage, "storage", "", "backup storage name")
+	cmd.Flags().StringVar(&req.Mode, "mode", "", "backup mode: snapshot, suspend or stop")
+	cmd.Flags().StringVar(&req.Compress, "compress", "", "compression: zstd, lzo or gzip")
+	cmd.Flags().StringVar(&req.NotesTemplate, "notes", "", "notes template, eg: Backup of {name}")
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait for the backup task to finish")
+	cmd.Flags().Int64Var(&clusterID, "cluster-id", 0, "cluster ID of the VM (required with --wait)")
+	return cmd
+}
+
+func newBackupListCmd() *cobra.Command {
+	req := new(v1.ListStorageContentRequest)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List vzdump backups on a storage",
+		Example: `  pvespherectl backup list --node-id 1 --storage local
+  pvespherectl backup list --node-id 1 --storage pbs --vmid 100 --sort-by size`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if req.NodeID <= 0 || req.Storage == "" {
+				return errors.New("--node-id and --storage are required")
+			}
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			query.Set("node_id", strconv.FormatInt(req.NodeID, 10))
+			query.Set("storage", req.Storage)
+			query.Set("content", "backup")
+			query.Set("page", strconv.Itoa(req.Page))
+			query.Set("page_size", strconv.Itoa(req.PageSize))
+			query.Set("sort_by", req.SortBy)
+			if req.VMID > 0 {
+				query.Set("vmid", strconv.FormatUint(uint64(req.VMID), 10))
+			}
+			if req.Name != "" {
+				query.Set("name", req.Name)
+			}
+
+			var data v1.ListStorageContentData
+			if err := client.get("/nodes/storage/content/page", query, &data); err != nil {
+				return err
+			}
+
+			rows := make([][]string, 0, len(data.List))
+			for _, item := range data.List {
+				volid, _ := item["volid"].(string)
+				format, _ := item["format"].(string)
+				size, _ := item["size"].(float64)
+				ctime, _ := item["ctime"].(float64)
+				vmid, _ := item["vmid"].(float64)
+				notes, _ := item["notes"].(string)
+				rows = append(rows, []string{
+					volid,
+					strconv.FormatUint(uint64(vmid), 10),
+					format,
+					strconv.FormatInt(int64(size)/(1<<20), 10),
+					time.Unix(int64(ctime), 0).Format("2006-01-02 15:04:05"),
+					notes,
+				})
+			}
+			if err := printResult(data, []string{"VOLID", "VMID", "FORMAT", "SIZE(MB)", "CREATED", "NOTES"}, rows); err != nil {
+				return err
+			}
+			if flagOutput == outputTable {
+				fmt.Printf("\n%d backups, %d MB in total\n", data.Total, data.TotalSize/(1<<20))
+				if int64(req.Page*req.PageSize) < data.Total {
+					fmt.Printf("Showing page %d (%d of %d), use --page to see more\n", req.Page, len(data.List), data.Total)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().Int64Var(&req.NodeID, "node-id", 0, "node ID (required)")
+	cmd.Flags().StringVar(&req.Storage, "storage", "", "backup storage name (required)")
+	cmd.Flags().Uint32Var(&req.VMID, "vmid", 0, "only list backups of this Proxmox VM ID")
+	cmd.Flags().StringVar(&req.Name, "name", "", "filter by volume ID or notes")
+	cmd.Flags().StringVar(&req.SortBy, "sort-by", "ctime", "sort by name, size or ctime")
+	cmd.Flags().IntVar(&req.Page, "page", 1, "page number")
+	cmd.Flags().IntVar(&req.PageSize, "page-size", 100, "page size (max 1000)")
+	return cmd
+}
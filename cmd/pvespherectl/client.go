@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const apiPrefix = "/api/v1"
+
+// apiClient PveSphere HTTP API 客户端
+type apiClient struct {
+	server string
+	token  string
+	http   *http.Client
+}
+
+// apiError 服务端返回的业务错误（code 与 api/v1/errors.go 中的错误码一致）
+type apiError struct {
+	Status  int
+	Code    int
+	Message string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s (code=%d, http=%d)", e.Message, e.Code, e.Status)
+}
+
+// newClient 根据参数、环境变量和配置文件创建客户端；requireToken 为 true 时要求已登录
+func newClient(requireToken bool) (*apiClient, error) {
+	conf, err := loadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	c := &apiClient{
+		server: strings.TrimRight(resolveServer(conf), "/"),
+		token:  resolveToken(conf),
+		http:   &http.Client{Timeout: 60 * time.Second},
+	}
+	if requireToken && c.token == "" {
+		return nil, errors.New("not logged in, run `pvespherectl login` or set --token / PVESPHERE_TOKEN")
+	}
+	return c, nil
+}
+
+// do 发送请求并将响应中的 data 字段解析到 out（out 为 nil 时忽略 data）
+func (c *apiClient) do(method, path string, query url.Values, body interface{}, out interface{}) error {
+	u := c.server + apiPrefix + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if flagChangeOverride != "" {
+		// 服务端会对原因做 URL 解码，以支持非 ASCII 内容
+		req.Header.Set("X-Change-Override", url.QueryEscape(flagChangeOverride))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return fmt.Errorf("unexpected response (http %d): %s", resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+	if resp.StatusCode != http.StatusOK || result.Code != 0 {
+		return &apiError{Status: resp.StatusCode, Code: result.Code, Message: result.Message}
+	}
+	if out == nil || len(result.Data) == 0 {
+		return nil
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+func (c *apiClient) get(path string, query url.Values, out interface{}) error {
+	return c.do(http.MethodGet, path, query, nil, out)
+}
+
+func (c *apiClient) post(path string, body interface{}, out interface{}) error {
+	return c.do(http.MethodPost, path, nil, body, out)
+}
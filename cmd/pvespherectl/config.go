@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const (
+	envServer = "PVESPHERE_SERVER"
+	envToken  = "PVESPHERE_TOKEN"
+
+	defaultServer = "http://127.0.0.1:8000"
+)
+
+// ctlConfig login 成功后保存在本地的连接信息
+type ctlConfig struct {
+	Server string `json:"server"`
+	Token  string `json:"token"`
+}
+
+// configPath 配置文件路径：$XDG_CONFIG_HOME/pvespherectl/config.json（Linux 默认 ~/.config）
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pvespherectl", "config.json"), nil
+}
+
+func loadConfig() (*ctlConfig, error) {
+	conf := &ctlConfig{}
+	path, err := configPath()
+	if err != nil {
+		return conf, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return conf, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func saveConfig(conf *ctlConfig) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(conf, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// 文件中包含 token，仅当前用户可读
+	return path, os.WriteFile(path, data, 0o600)
+}
+
+// resolveServer 按 命令行参数 > 环境变量 > 配置文件 > 默认值 的顺序确定服务地址
+func resolveServer(conf *ctlConfig) string {
+	switch {
+	case flagServer != "":
+		return flagServer
+	case os.Getenv(envServer) != "":
+		return os.Getenv(envServer)
+	case conf.Server != "":
+		return conf.Server
+	}
+	return defaultServer
+}
+
+func resolveToken(conf *ctlConfig) string {
+	switch {
+	case flagToken != "":
+		return flagToken
+	case os.Getenv(envToken) != "":
+		return os.Getenv(envToken)
+	}
+	return conf.Token
+}
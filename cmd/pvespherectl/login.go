@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+func newLoginCmd() *cobra.Command {
+	var (
+		account       string
+		password      string
+		passwordStdin bool
+	)
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Log in and save the access token to the local config file",
+		Example: `  pvespherectl login --server http://127.0.0.1:8000 -u admin
+  echo "$PASSWORD" | pvespherectl login -u admin --password-stdin`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if account == "" {
+				return errors.New("--account is required")
+			}
+			if passwordStdin {
+				line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("read password from stdin: %w", err)
+				}
+				password = strings.TrimRight(line, "\r\n")
+			} else if password == "" {
+				fmt.Fprint(os.Stderr, "Password: ")
+				if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+					// 终端输入时不回显密码
+					b, err := term.ReadPassword(fd)
+					fmt.Fprintln(os.Stderr)
+					if err != nil {
+						return fmt.Errorf("read password: %w", err)
+					}
+					password = string(b)
+				} else {
+					line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+					if err != nil && line == "" {
+						return fmt.Errorf("read password: %w", err)
+					}
+					password = strings.TrimRight(line, "\r\n")
+				}
+			}
+
+			client, err := newClient(false)
+			if err != nil {
+				return err
+			}
+			var data v1.LoginResponseData
+			if err := client.post("/login", &v1.LoginRequest{Account: account, Password: password}, &data); err != nil {
+				return err
+			}
+
+			path, err := saveConfig(&ctlConfig{Server: client.server, Token: data.AccessToken})
+			if err != nil {
+				return fmt.Errorf("save config: %w", err)
+			}
+			return printMessage("Logged in to %s, token saved to %s", client.server, path)
+		},
+	}
+	cmd.Flags().StringVarP(&account, "account", "u", "", "username or email")
+	cmd.Flags().StringVarP(&password, "password", "p", "", "password (prompted when omitted)")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin")
+	return cmd
+}
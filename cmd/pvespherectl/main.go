@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// pvespherectl 命令行客户端，封装 PveSphere HTTP API，便于在终端和脚本中操作
+//
+// 服务地址与 token 的优先级：命令行参数 > 环境变量（PVESPHERE_SERVER / PVESPHERE_TOKEN）> login 保存的配置文件
+
+// 全局参数
+var (
+	flagServer         string
+	flagToken          string
+	flagOutput         string
+	flagChangeOverride string
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:           "pvespherectl",
+		Short:         "Command line client for the PveSphere API",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if flagOutput != outputTable && flagOutput != outputJSON {
+				return fmt.Errorf("unsupported output format %q, must be table or json", flagOutput)
+			}
+			return nil
+		},
+	}
+	root.PersistentFlags().StringVar(&flagServer, "server", "", "PveSphere server address, eg: http://127.0.0.1:8000 (env PVESPHERE_SERVER)")
+	root.PersistentFlags().StringVar(&flagToken, "token", "", "access token (env PVESPHERE_TOKEN)")
+	root.PersistentFlags().StringVarP(&flagOutput, "output", "o", outputTable, "output format: table or json")
+	root.PersistentFlags().StringVar(&flagChangeOverride, "change-override", "", "break-glass reason for changes blocked by a change freeze or outside maintenance windows (admin only, audited)")
+
+	root.AddCommand(
+		newLoginCmd(),
+		newVMCmd(),
+		newTemplateCmd(),
+		newBackupCmd(),
+		newTaskCmd(),
+	)
+	return root
+}
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
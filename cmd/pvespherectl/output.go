@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// printResult 按 -o 参数输出：json 时直接输出 v，table 时输出 headers/rows
+func printResult(v interface{}, headers []string, rows [][]string) error {
+	if flagOutput == outputJSON {
+		return printJSON(v)
+	}
+	printTable(headers, rows)
+	return nil
+}
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func printTable(headers []string, rows [][]string) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+}
+
+// printMessage 输出操作结果；json 模式下输出 {"message": ...} 便于脚本解析
+func printMessage(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if flagOutput == outputJSON {
+		return printJSON(map[string]string{"message": msg})
+	}
+	fmt.Println(msg)
+	return nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/spf13/cobra"
+)
+
+// taskLogPageSize 每次拉取的任务日志行数
+const taskLogPageSize = 500
+
+func newTaskCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "task",
+		Short: "Inspect Proxmox tasks",
+	}
+	cmd.AddCommand(newTaskStatusCmd(), newTaskWatchCmd())
+	return cmd
+}
+
+func newTaskStatusCmd() *cobra.Command {
+	var clusterID int64
+	cmd := &cobra.Command{
+		Use:   "status <upid>",
+		Short: "Show the status of a task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			status, err := getTaskStatus(client, clusterID, args[0])
+			if err != nil {
+				return err
+			}
+			return printResult(status, taskStatusHeaders, [][]string{taskStatusRow(status)})
+		},
+	}
+	cmd.Flags().Int64Var(&clusterID, "cluster-id", 0, "cluster ID the task belongs to (required)")
+	_ = cmd.MarkFlagRequired("cluster-id")
+	return cmd
+}
+
+func newTaskWatchCmd() *cobra.Command {
+	var (
+		clusterID int64
+		interval  time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "watch <upid>",
+		Short: "Follow a task log until it finishes; exits non-zero if the task fails",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			return watchTask(client, clusterID, args[0], interval)
+		},
+	}
+	cmd.Flags().Int64Var(&clusterID, "cluster-id", 0, "cluster ID the task belongs to (required)")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "poll interval")
+	_ = cmd.MarkFlagRequired("cluster-id")
+	return cmd
+}
+
+var taskStatusHeaders = []string{"UPID", "TYPE", "ID", "USER", "STATUS", "EXIT", "START", "END"}
+
+func taskStatusRow(s *v1.TaskStatusItem) []string {
+	return []string{
+		s.UPID,
+		s.Type,
+		s.ID,
+		s.User,
+		s.Status,
+		taskExitStatus(s),
+		formatUnix(s.StartTime),
+		formatUnix(s.EndTime),
+	}
+}
+
+// nodeFromUPID 从 UPID（UPID:node:pid:pstart:starttime:type:id:user:）中解析节点名称
+func nodeFromUPID(upid string) (string, error) {
+	parts := strings.Split(upid, ":")
+	if len(parts) < 3 || parts[0] != "UPID" || parts[1] == "" {
+		return "", fmt.Errorf("invalid UPID %q", upid)
+	}
+	return parts[1], nil
+}
+
+func getTaskStatus(client *apiClient, clusterID int64, upid string) (*v1.TaskStatusItem, error) {
+	node, err := nodeFromUPID(upid)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	query.Set("cluster_id", strconv.FormatInt(clusterID, 10))
+	query.Set("node_name", node)
+	query.Set("upid", upid)
+
+	status := new(v1.TaskStatusItem)
+	if err := client.get("/tasks/status", query, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// watchTask 轮询任务状态并增量输出任务日志，直到任务结束
+// table 模式逐行输出日志，json 模式仅在结束时输出最终状态
+func watchTask(client *apiClient, clusterID int64, upid string, interval time.Duration) error {
+	node, err := nodeFromUPID(upid)
+	if err != nil {
+		return err
+	}
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	start := 0
+	for {
+		status, err := getTaskStatus(client, clusterID, upid)
+		if err != nil {
+			return err
+		}
+
+		if flagOutput == outputTable {
+			if start, err = printTaskLog(client, clusterID, node, upid, start); err != nil {
+				return err
+			}
+		}
+
+		if status.Status != "running" {
+			if flagOutput == outputJSON {
+				if err := printJSON(status); err != nil {
+					return err
+				}
+			}
+			if exit := taskExitStatus(status); exit != "OK" {
+				return fmt.Errorf("task %s failed: %s", upid, exit)
+			}
+			return nil
+		}
+		time.Sleep(interval)
+	}
+}
+
+// printTaskLog 从第 start 行开始输出新增的任务日志，返回下一次拉取的起始行
+func printTaskLog(client *apiClient, clusterID int64, node, upid string, start int) (int, error) {
+	for {
+		query := url.Values{}
+		query.Set("cluster_id", strconv.FormatInt(clusterID, 10))
+		query.Set("node_name", node)
+		query.Set("upid", upid)
+		query.Set("start", strconv.Itoa(start))
+		query.Set("limit", strconv.Itoa(taskLogPageSize))
+
+		var lines []v1.TaskLogItem
+		if err := client.get("/tasks/log", query, &lines); err != nil {
+			return start, err
+		}
+		// 任务刚启动、日志为空时 Proxmox 返回单行 "no content"，不计入已读行数
+		if len(lines) == 1 && lines[0].T == "no content" {
+			return start, nil
+		}
+		for _, l := range lines {
+			fmt.Println(l.T)
+		}
+		start += len(lines)
+		if len(lines) < taskLogPageSize {
+			return start, nil
+		}
+	}
+}
+
+func taskExitStatus(s *v1.TaskStatusItem) string {
+	if s.ExitStatus == nil {
+		return ""
+	}
+	return fmt.Sprint(s.ExitStatus)
+}
+
+func formatUnix(ts int64) string {
+	if ts <= 0 {
+		return "-"
+	}
+	return time.Unix(ts, 0).Format(time.DateTime)
+}
+
+// waitForTask 在 --wait 时等待异步任务完成；未指定 --wait 时提示如何跟踪任务
+func waitForTask(client *apiClient, wait bool, clusterID int64, upid string) error {
+	if upid == "" {
+		return errors.New("server did not return a task UPID")
+	}
+	if !wait {
+		if flagOutput == outputTable {
+			fmt.Printf("Task: %s\n", upid)
+			if clusterID > 0 {
+				fmt.Printf("Follow with: pvespherectl task watch --cluster-id %d '%s'\n", clusterID, upid)
+			}
+		}
+		return nil
+	}
+	return watchTask(client, clusterID, upid, 2*time.Second)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/spf13/cobra"
+)
+
+func newTemplateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage VM templates",
+	}
+	cmd.AddCommand(newTemplateListCmd(), newTemplateImportCmd())
+	return cmd
+}
+
+func newTemplateListCmd() *cobra.Command {
+	var (
+		page      int
+		pageSize  int
+		clusterID int64
+	)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			query.Set("page", strconv.Itoa(page))
+			query.Set("page_size", strconv.Itoa(pageSize))
+			if clusterID > 0 {
+				query.Set("cluster_id", strconv.FormatInt(clusterID, 10))
+			}
+
+			var data v1.ListTemplateResponseData
+			if err := client.get("/templates", query, &data); err != nil {
+				return err
+			}
+			rows := make([][]string, 0, len(data.List))
+			for _, t := range data.List {
+				rows = append(rows, []string{
+					strconv.FormatInt(t.Id, 10),
+					t.TemplateName,
+					t.ClusterName,
+					t.Description,
+				})
+			}
+			return printResult(data, []string{"ID", "NAME", "CLUSTER", "DESCRIPTION"}, rows)
+		},
+	}
+	cmd.Flags().IntVar(&page, "page", 1, "page number")
+	cmd.Flags().IntVar(&pageSize, "page-size", 100, "page size")
+	cmd.Flags().Int64Var(&clusterID, "cluster-id", 0, "filter by cluster ID")
+	return cmd
+}
+
+func newTemplateImportCmd() *cobra.Command {
+	req := new(v1.ImportTemplateRequest)
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import a template from an existing backup file",
+		Example: `  pvespherectl template import --name centos7 --cluster-id 1 --node-id 1 \
+    --backup-storage-id 6 --backup-file vzdump-qemu-100-2024_01_01-00_00_00.vma.zst --target-storage-id 7 --auto-sync`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if req.TemplateName == "" || req.BackupFile == "" {
+				return errors.New("--name and --backup-file are required")
+			}
+			if req.ClusterID <= 0 || req.NodeID <= 0 || req.BackupStorageID <= 0 || req.TargetStorageID <= 0 {
+				return errors.New("--cluster-id, --node-id, --backup-storage-id and --target-storage-id are required")
+			}
+
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			var data v1.ImportTemplateResponseData
+			if err := client.post("/templates/import", req, &data); err != nil {
+				return err
+			}
+
+			rows := [][]string{{
+				strconv.FormatInt(data.TemplateID, 10),
+				strconv.FormatInt(data.ImportID, 10),
+				data.ImportNode.NodeName,
+				data.StorageType,
+				strconv.FormatBool(data.IsShared),
+				strconv.Itoa(len(data.SyncTasks)),
+			}}
+			return printResult(data, []string{"TEMPLATE ID", "IMPORT ID", "NODE", "STORAGE TYPE", "SHARED", "SYNC TASKS"}, rows)
+		},
+	}
+	cmd.Flags().StringVar(&req.TemplateName, "name", "", "template name (required)")
+	cmd.Flags().Int64Var(&req.ClusterID, "cluster-id", 0, "cluster ID (required)")
+	cmd.Flags().Int64Var(&req.NodeID, "node-id", 0, "node ID to import on (required)")
+	cmd.Flags().Int64Var(&req.BackupStorageID, "backup-storage-id", 0, "storage ID holding the backup file (required)")
+	cmd.Flags().StringVar(&req.BackupFile, "backup-file", "", "backup file name (required)")
+	cmd.Flags().Int64Var(&req.TargetStorageID, "target-storage-id", 0, "storage ID for VM disks (required)")
+	cmd.Flags().StringVar(&req.Description, "description", "", "description")
+	cmd.Flags().BoolVar(&req.AutoSync, "auto-sync", false, "sync to all nodes when the storage is local")
+	cmd.Flags().Int64SliceVar(&req.SyncNodeIDs, "sync-node-ids", nil, "node IDs to sync to when the storage is local")
+	return cmd
+}
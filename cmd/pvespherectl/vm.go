@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/spf13/cobra"
+)
+
+func newVMCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vm",
+		Short: "Manage virtual machines",
+	}
+	cmd.AddCommand(
+		newVMListCmd(),
+		newVMCreateCmd(),
+		newVMPowerCmd("start", "Start a virtual machine"),
+		newVMPowerCmd("stop", "Stop a virtual machine"),
+		newVMMigrateCmd(),
+	)
+	return cmd
+}
+
+func newVMListCmd() *cobra.Command {
+	req := new(v1.ListVMRequest)
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List virtual machines",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			query := url.Values{}
+			query.Set("page", strconv.Itoa(req.Page))
+			query.Set("page_size", strconv.Itoa(req.PageSize))
+			if req.ClusterID > 0 {
+				query.Set("cluster_id", strconv.FormatInt(req.ClusterID, 10))
+			}
+			if req.NodeID > 0 {
+				query.Set("node_id", strconv.FormatInt(req.NodeID, 10))
+			}
+			if req.TemplateID > 0 {
+				query.Set("template_id", strconv.FormatInt(req.TemplateID, 10))
+			}
+			if req.Status != "" {
+				query.Set("status", req.Status)
+			}
+			if req.AppId != "" {
+				query.Set("app_id", req.AppId)
+			}
+
+			var data v1.ListVMResponseData
+			if err := client.get("/vms", query, &data); err != nil {
+				return err
+			}
+
+			rows := make([][]string, 0, len(data.List))
+			for _, vm := range data.List {
+				rows = append(rows, []string{
+					strconv.FormatInt(vm.Id, 10),
+					vm.VmName,
+					strconv.FormatUint(uint64(vm.VMID), 10),
+					vm.Status,
+					vm.ClusterName,
+					vm.NodeName,
+					strconv.Itoa(vm.CPUNum),
+					strconv.Itoa(vm.MemorySize),
+					vm.NodeIP,
+				})
+			}
+			if err := printResult(data, []string{"ID", "NAME", "VMID", "STATUS", "CLUSTER", "NODE", "CPU", "MEMORY(MB)", "NODE IP"}, rows); err != nil {
+				return err
+			}
+			if flagOutput == outputTable && int64(req.Page*req.PageSize) < data.Total {
+				fmt.Printf("\nShowing page %d (%d of %d), use --page to see more\n", req.Page, len(data.List), data.Total)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&req.Page, "page", 1, "page number")
+	cmd.Flags().IntVar(&req.PageSize, "page-size", 100, "page size (max 100)")
+	cmd.Flags().Int64Var(&req.ClusterID, "cluster-id", 0, "filter by cluster ID")
+	cmd.Flags().Int64Var(&req.NodeID, "node-id", 0, "filter by node ID")
+	cmd.Flags().Int64Var(&req.TemplateID, "template-id", 0, "filter by template ID")
+	cmd.Flags().StringVar(&req.Status, "status", "", "filter by status, eg: running, stopped")
+	cmd.Flags().StringVar(&req.AppId, "app-id", "", "filter by app ID")
+	return cmd
+}
+
+func newVMCreateCmd() *cobra.Command {
+	var (
+		req        = new(v1.CreateVMRequest)
+		cpu        int
+		memory     int
+		diskSizeGB int
+		vmid       uint32
+	)
+	cmd := &cobra.Command{
+		Use:   "create",
+		Short: "Create a virtual machine in Proxmox (from template, ISO or empty)",
+		Example: `  pvespherectl vm create --name web-01 --cluster-id 1 --node-id 2 --template-id 3 --cpu 2 --memory 4096
+  pvespherectl vm create --name installer --cluster-id 1 --node-id 2 --mode iso --iso local:iso/ubuntu.iso --disk-size 32`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if req.VmName == "" {
+				return errors.New("--name is required")
+			}
+			if req.ClusterID <= 0 || req.NodeID <= 0 {
+				return errors.New("--cluster-id and --node-id are required")
+			}
+			if cmd.Flags().Changed("cpu") {
+				req.CPUNum = &cpu
+			}
+			if cmd.Flags().Changed("memory") {
+				req.MemorySize = &memory
+			}
+			if cmd.Flags().Changed("disk-size") {
+				req.DiskSizeGB = &diskSizeGB
+			}
+			req.VMID = vmid
+
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			if err := client.post("/vms/create", req, nil); err != nil {
+				return err
+			}
+			return printMessage("VM %s created", req.VmName)
+		},
+	}
+	cmd.Flags().StringVar(&req.VmName, "name", "", "VM name (required)")
+	cmd.Flags().StringVar(&req.CreateMode, "mode", "", "create mode: template (default), iso or empty")
+	cmd.Flags().Int64Var(&req.ClusterID, "cluster-id", 0, "cluster ID (required)")
+	cmd.Flags().Int64Var(&req.NodeID, "node-id", 0, "node ID (required)")
+	cmd.Flags().Int64Var(&req.TemplateID, "template-id", 0, "template ID (mode=template)")
+	cmd.Flags().Uint32Var(&vmid, "vmid", 0, "Proxmox VM ID (generated when omitted)")
+	cmd.Flags().IntVar(&cpu, "cpu", 0, "CPU cores")
+	cmd.Flags().IntVar(&memory, "memory", 0, "memory size in MB")
+	cmd.Flags().StringVar(&req.Storage, "storage", "", "storage name")
+	cmd.Flags().StringVar(&req.ISOVolume, "iso", "", "ISO volume, eg: local:iso/ubuntu.iso (mode=iso)")
+	cmd.Flags().IntVar(&diskSizeGB, "disk-size", 0, "system disk size in GB (mode=iso/empty)")
+	cmd.Flags().StringVar(&req.Bridge, "bridge", "", "network bridge, default vmbr0")
+	cmd.Flags().StringVar(&req.AppId, "app-id", "", "app ID")
+	cmd.Flags().StringVar(&req.Description, "description", "", "description")
+	return cmd
+}
+
+// newVMPowerCmd start / stop 命令（参数为 PveSphere 中的虚拟机 ID，而非 Proxmox VMID）
+func newVMPowerCmd(action, short string) *cobra.Command {
+	return &cobra.Command{
+		Use:   action + " <id>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			if err := client.post(fmt.Sprintf("/vms/%d/%s", id, action), nil, nil); err != nil {
+				return err
+			}
+			return printMessage("VM %d %s requested", id, action)
+		},
+	}
+}
+
+func newVMMigrateCmd() *cobra.Command {
+	var (
+		req            = new(v1.MigrateVMRequest)
+		online         bool
+		withLocalDisks bool
+		wait           bool
+	)
+	cmd := &cobra.Command{
+		Use:   "migrate <id>",
+		Short: "Migrate a virtual machine to another node in the same cluster",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			if req.TargetNodeID <= 0 {
+				return errors.New("--target-node-id is required")
+			}
+			req.VMID = id
+			if cmd.Flags().Changed("online") {
+				req.Online = &online
+			}
+			if cmd.Flags().Changed("with-local-disks") {
+				req.WithLocalDisks = &withLocalDisks
+			}
+
+			client, err := newClient(true)
+			if err != nil {
+				return err
+			}
+			// 任务跟踪需要集群 ID，迁移前先查询虚拟机所在集群
+			var vm v1.VMDetail
+			if err := client.get(fmt.Sprintf("/vms/%d", id), nil, &vm); err != nil {
+				return err
+			}
+
+			var upid string
+			if err := client.post("/vms/migrate", req, &upid); err != nil {
+				return err
+			}
+			if flagOutput == outputJSON && !wait {
+				return printJSON(map[string]interface{}{"upid": upid, "cluster_id": vm.ClusterID})
+			}
+			return waitForTask(client, wait, vm.ClusterID, upid)
+		},
+	}
+	cmd.Flags().Int64Var(&req.TargetNodeID, "target-node-id", 0, "target node ID (required)")
+	cmd.Flags().BoolVar(&online, "online", false, "live migration")
+	cmd.Flags().BoolVar(&withLocalDisks, "with-local-disks", false, "migrate local disks")
+	cmd.Flags().StringVar(&req.MapStorage, "map-storage", "", "storage mapping, format FROM:TO")
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait for the migration task to finish")
+	return cmd
+}
+
+func parseID(s string) (int64, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid id %q", s)
+	}
+	return id, nil
+}
@@ -8,6 +8,8 @@ import (
 	"pvesphere/cmd/server/wire"
 	"pvesphere/pkg/config"
 	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+	"pvesphere/pkg/tracing"
 
 	"go.uber.org/zap"
 )
@@ -33,12 +35,16 @@ func main() {
 	conf := config.NewConfig(*envConf)
 
 	logger := log.NewLog(conf)
+	proxmox.ConfigureRequestLog(logger.Logger, conf.GetBool("proxmox.api_log.enabled"))
+	proxmox.SetRequestTimeout(conf.GetDuration("proxmox.timeout"))
+	shutdownTracing := tracing.Init(conf, logger, "pvesphere-server")
+	defer shutdownTracing()
 
 	app, cleanup, err := wire.NewWire(conf, logger)
-	defer cleanup()
 	if err != nil {
 		panic(err)
 	}
+	defer cleanup()
 	logger.Info("server start", zap.String("host", fmt.Sprintf("http://%s:%d", conf.GetString("http.host"), conf.GetInt("http.port"))))
 	logger.Info("docs addr", zap.String("addr", fmt.Sprintf("http://%s:%d/swagger/index.html", conf.GetString("http.host"), conf.GetInt("http.port"))))
 	if err = app.Run(context.Background()); err != nil {
@@ -4,6 +4,9 @@
 package wire
 
 import (
+	"time"
+
+	"pvesphere/internal/controller"
 	"pvesphere/internal/handler"
 	"pvesphere/internal/job"
 	"pvesphere/internal/repository"
@@ -31,12 +34,66 @@ var repositorySet = wire.NewSet(
 	repository.NewPveNodeRepository,
 	repository.NewPveVMRepository,
 	repository.NewPveStorageRepository,
+	repository.NewVMStatusEventRepository,
 	repository.NewVmTemplateRepository,
 	repository.NewVMIPAddressRepository,
 	repository.NewPveTemplateRepository,
 	repository.NewTemplateUploadRepository,
 	repository.NewTemplateInstanceRepository,
 	repository.NewTemplateSyncTaskRepository,
+	repository.NewVmQosProfileRepository,
+	repository.NewStorageGCRepository,
+	repository.NewSearchRepository,
+	repository.NewVmListViewRepository,
+	repository.NewSchemaMigrationRepository,
+	repository.NewConfigAuditRepository,
+	repository.NewVmStackRepository,
+	repository.NewPveSiteRepository,
+	repository.NewChangeWindowRepository,
+	repository.NewCostRepository,
+	repository.NewNodeBMCRepository,
+	repository.NewEnergyRepository,
+	repository.NewVmImportRepository,
+	repository.NewImageTransferRepository,
+	repository.NewTemplateCatalogRepository,
+	repository.NewNodeVersionRepository,
+	repository.NewLicenseRepository,
+	repository.NewVMClaimRepository,
+	repository.NewVMProfileRepository,
+	repository.NewVMStorageMoveRepository,
+	repository.NewRebalanceRepository,
+	repository.NewConsoleSessionRepository,
+	repository.NewZFSPoolAlertRepository,
+	repository.NewNodeDiskHealthRepository,
+	repository.NewDashboardLayoutRepository,
+	repository.NewNotificationRepository,
+	repository.NewMACAddressRepository,
+	repository.NewClusterCapabilityRepository,
+	repository.NewVMLockRepository,
+	repository.NewPendingOperationRepository,
+	repository.NewSecretAuditRepository,
+	repository.NewTemplateUsageRepository,
+	repository.NewBootOrderPolicyRepository,
+	repository.NewVMCreateJobRepository,
+	repository.NewOperationAuditRepository,
+	repository.NewNodePoolRepository,
+	repository.NewTemplatePromotionRepository,
+	repository.NewMetadataBackupRepository,
+	repository.NewVMIDRangeRepository,
+	repository.NewTopologyRepository,
+	repository.NewVMAgentInstallRepository,
+	repository.NewVMSecurityRepository,
+	repository.NewPveNodeCertificateRepository,
+	repository.NewRemoteMigrationJobRepository,
+	repository.NewRemoteMigrationCutoverRepository,
+	repository.NewVMBulkDeleteJobRepository,
+	repository.NewClusterCapacitySnapshotRepository,
+	repository.NewSharedTokenRepository,
+	repository.NewJobLeaseRepository,
+	repository.NewClusterEventRepository,
+	repository.NewReportRepository,
+	repository.NewFirstBootHookRepository,
+	repository.NewContentReplicationRepository,
 )
 
 var serviceSet = wire.NewSet(
@@ -50,6 +107,73 @@ var serviceSet = wire.NewSet(
 	service.NewPveTaskService,
 	service.NewDashboardService,
 	service.NewTemplateManagementService,
+	service.NewVMQosService,
+	service.NewStorageGCService,
+	service.NewSearchService,
+	service.NewVMListViewService,
+	service.NewSchemaService,
+	service.NewSystemConfigService,
+	service.NewGrafanaService,
+	service.NewVMStackService,
+	service.NewPveSiteService,
+	service.NewChangeControlService,
+	service.NewCostService,
+	service.NewNodeBMCService,
+	service.NewEnergyService,
+	service.NewVMImportService,
+	service.NewImageTransferService,
+	service.NewTemplateCatalogService,
+	service.NewNodeVersionService,
+	service.NewLicenseService,
+	service.NewVMClaimService,
+	service.NewVMProfileService,
+	service.NewVMStorageMoveService,
+	service.NewRebalanceService,
+	service.NewConsoleAuditService,
+	service.NewStorageBrowserService,
+	service.NewNodeZFSService,
+	service.NewNodeDiskService,
+	service.NewNotificationService,
+	service.NewVMNetworkDiagService,
+	service.NewMACRegistryService,
+	service.NewPveAccessService,
+	service.NewClusterCapabilityService,
+	service.NewVMLockService,
+	service.NewPendingOperationService,
+	service.NewVMCredentialService,
+	service.NewDependencyService,
+	service.NewTemplateUsageService,
+	service.NewVMNICService,
+	service.NewVMStartupService,
+	service.NewVMTaskTracker,
+	service.NewProvisionReservationService,
+	service.NewVMCreateJobService,
+	service.NewOperationAuditService,
+	service.NewNodePoolService,
+	service.NewTemplatePromotionService,
+	service.NewMetadataBackupService,
+	service.NewVMIDRangeService,
+	service.NewVMEventService,
+	service.NewTopologyService,
+	service.NewVMAgentInstallService,
+	service.NewOSEOLService,
+	service.NewVMSecurityService,
+	service.NewClusterEndpointService,
+	service.NewRemoteMigrationJobService,
+	service.NewRemoteMigrationCutoverService,
+	service.NewVMBulkDeleteService,
+	service.NewVMProtectionService,
+	service.NewDashboardChangeService,
+	service.NewConsoleProxyService,
+	service.NewSharedTokenStore,
+	service.NewJobLeaseService,
+	service.NewClusterEventService,
+	service.NewVMNotesService,
+	service.NewReportService,
+	service.NewCapabilityService,
+	service.NewVMHibernateService,
+	service.NewFirstBootHookService,
+	service.NewContentReplicationService,
 )
 
 var handlerSet = wire.NewSet(
@@ -64,25 +188,141 @@ var handlerSet = wire.NewSet(
 	handler.NewTemplateManagementHandler,
 	handler.NewPveTaskHandler,
 	handler.NewDashboardHandler,
+	handler.NewVMQosHandler,
+	handler.NewStorageGCHandler,
+	handler.NewSearchHandler,
+	handler.NewVMListViewHandler,
+	handler.NewSchemaHandler,
+	handler.NewSystemConfigHandler,
+	handler.NewGrafanaHandler,
+	handler.NewVMStackHandler,
+	handler.NewPveSiteHandler,
+	handler.NewChangeWindowHandler,
+	handler.NewCostHandler,
+	handler.NewNodeBMCHandler,
+	handler.NewEnergyHandler,
+	handler.NewVMImportHandler,
+	handler.NewImageTransferHandler,
+	handler.NewTemplateCatalogHandler,
+	handler.NewNodeVersionHandler,
+	handler.NewLicenseHandler,
+	handler.NewVMClaimHandler,
+	handler.NewVMProfileHandler,
+	handler.NewVMStorageMoveHandler,
+	handler.NewRebalanceHandler,
+	handler.NewConsoleAuditHandler,
+	handler.NewStorageBrowserHandler,
+	handler.NewNodeZFSHandler,
+	handler.NewNodeDiskHandler,
+	handler.NewNotificationHandler,
+	handler.NewVMNetworkDiagHandler,
+	handler.NewMACAddressHandler,
+	handler.NewPveAccessHandler,
+	handler.NewVMLockHandler,
+	handler.NewPendingOperationHandler,
+	handler.NewVMCredentialHandler,
+	handler.NewDependencyHandler,
+	handler.NewTemplateUsageHandler,
+	handler.NewVMNICHandler,
+	handler.NewVMStartupHandler,
+	handler.NewReservationHandler,
+	handler.NewOperationAuditHandler,
+	handler.NewNodePoolHandler,
+	handler.NewTemplatePromotionHandler,
+	handler.NewMetadataBackupHandler,
+	handler.NewVMIDRangeHandler,
+	handler.NewVMEventHandler,
+	handler.NewTopologyHandler,
+	handler.NewVMAgentInstallHandler,
+	handler.NewOSEOLHandler,
+	handler.NewVMSecurityHandler,
+	handler.NewClusterEndpointHandler,
+	handler.NewRemoteMigrationHandler,
+	handler.NewVMBulkDeleteHandler,
+	handler.NewVMProtectionHandler,
+	handler.NewDashboardChangeHandler,
+	handler.NewClusterEventHandler,
+	handler.NewVMNotesHandler,
+	handler.NewReportHandler,
+	handler.NewCapabilityHandler,
+	handler.NewVMHibernateHandler,
+	handler.NewFirstBootHookHandler,
+	handler.NewContentReplicationHandler,
 )
 
 var jobSet = wire.NewSet(
 	job.NewJob,
 	job.NewUserJob,
 )
+var controllerSet = wire.NewSet(
+	controller.NewPveController,
+	wire.Value(time.Minute*5), // resyncPeriod
+)
+
 var serverSet = wire.NewSet(
 	server.NewHTTPServer,
 	server.NewJobServer,
+	server.NewSchemaServer,
+	server.NewMigrateServer,
+	server.NewEmbeddedServer,
+	server.NewConfigReloadServer,
+	server.NewCostCollectorServer,
+	server.NewEnergyCollectorServer,
+	server.NewTemplateCatalogSyncServer,
+	server.NewNodeVersionCollectorServer,
+	server.NewLicenseCollectorServer,
+	server.NewVMClaimScannerServer,
+	server.NewRebalanceAnalyzerServer,
+	server.NewConsoleRecordingCleanerServer,
+	server.NewZFSHealthCollectorServer,
+	server.NewDiskSMARTCollectorServer,
+	server.NewNotificationCleanerServer,
+	server.NewPendingOperationRetrierServer,
+	server.NewAgentInstallCheckerServer,
+	server.NewSecurityScanServer,
+	server.NewRemoteMigrationSchedulerServer,
+	server.NewCapacitySnapshotServer,
+	server.NewJobLeaseServer,
+	server.NewClusterEventPollerServer,
+	server.NewVMNotesSyncServer,
+	server.NewReportSchedulerServer,
+	server.NewFirstBootCheckerServer,
+	server.NewContentReplicationServer,
 )
 
 // build App
 func newApp(
 	httpServer *http.Server,
 	jobServer *server.JobServer,
+	schemaServer *server.SchemaServer,
+	embeddedServer *server.EmbeddedServer,
+	configReloadServer *server.ConfigReloadServer,
+	costCollectorServer *server.CostCollectorServer,
+	energyCollectorServer *server.EnergyCollectorServer,
+	catalogSyncServer *server.TemplateCatalogSyncServer,
+	nodeVersionServer *server.NodeVersionCollectorServer,
+	licenseCollectorServer *server.LicenseCollectorServer,
+	vmClaimScannerServer *server.VMClaimScannerServer,
+	rebalanceAnalyzerServer *server.RebalanceAnalyzerServer,
+	consoleRecordingCleanerServer *server.ConsoleRecordingCleanerServer,
+	zfsHealthCollectorServer *server.ZFSHealthCollectorServer,
+	diskSMARTCollectorServer *server.DiskSMARTCollectorServer,
+	notificationCleanerServer *server.NotificationCleanerServer,
+	pendingOperationRetrierServer *server.PendingOperationRetrierServer,
+	agentInstallCheckerServer *server.AgentInstallCheckerServer,
+	securityScanServer *server.SecurityScanServer,
+	remoteMigrationSchedulerServer *server.RemoteMigrationSchedulerServer,
+	capacitySnapshotServer *server.CapacitySnapshotServer,
+	jobLeaseServer *server.JobLeaseServer,
+	clusterEventPollerServer *server.ClusterEventPollerServer,
+	vmNotesSyncServer *server.VMNotesSyncServer,
+	reportSchedulerServer *server.ReportSchedulerServer,
+	firstBootCheckerServer *server.FirstBootCheckerServer,
+	contentReplicationServer *server.ContentReplicationServer,
 	// task *server.Task,
 ) *app.App {
 	return app.NewApp(
-		app.WithServer(httpServer, jobServer),
+		app.WithServer(httpServer, jobServer, schemaServer, embeddedServer, configReloadServer, costCollectorServer, energyCollectorServer, catalogSyncServer, nodeVersionServer, licenseCollectorServer, vmClaimScannerServer, rebalanceAnalyzerServer, consoleRecordingCleanerServer, zfsHealthCollectorServer, diskSMARTCollectorServer, notificationCleanerServer, pendingOperationRetrierServer, agentInstallCheckerServer, securityScanServer, remoteMigrationSchedulerServer, capacitySnapshotServer, jobLeaseServer, clusterEventPollerServer, vmNotesSyncServer, reportSchedulerServer, firstBootCheckerServer, contentReplicationServer),
 		app.WithName("demo-server"),
 	)
 }
@@ -93,6 +333,7 @@ func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
 		serviceSet,
 		handlerSet,
 		jobSet,
+		controllerSet,
 		serverSet,
 		wire.Struct(new(router.RouterDeps), "*"),
 		sid.NewSid,
@@ -7,6 +7,9 @@
 package wire
 
 import (
+	"time"
+
+	"pvesphere/internal/controller"
 	"pvesphere/internal/handler"
 	"pvesphere/internal/job"
 	"pvesphere/internal/repository"
@@ -34,35 +37,219 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 	sidSid := sid.NewSid()
 	serviceService := service.NewService(transaction, logger, sidSid, jwtJWT)
 	pveClusterRepository := repository.NewPveClusterRepository(repositoryRepository)
-	pveClusterService := service.NewPveClusterService(serviceService, pveClusterRepository, repositoryRepository, logger)
+	pveSiteRepository := repository.NewPveSiteRepository(repositoryRepository)
+	clusterCapabilityRepository := repository.NewClusterCapabilityRepository(repositoryRepository)
+	clusterCapabilityService := service.NewClusterCapabilityService(serviceService, pveClusterRepository, clusterCapabilityRepository, logger)
+	pveClusterService := service.NewPveClusterService(serviceService, pveClusterRepository, pveSiteRepository, repositoryRepository, clusterCapabilityService, logger)
 	pveAuthHandler := handler.NewPveAuthHandler(handlerHandler, pveClusterService)
 	userRepository := repository.NewUserRepository(repositoryRepository)
 	userService := service.NewUserService(serviceService, userRepository)
 	userHandler := handler.NewUserHandler(handlerHandler, userService)
-	pveClusterHandler := handler.NewPveClusterHandler(handlerHandler, pveClusterService)
+	pveClusterHandler := handler.NewPveClusterHandler(handlerHandler, pveClusterService, clusterCapabilityService)
 	pveNodeRepository := repository.NewPveNodeRepository(repositoryRepository)
-	pveNodeService := service.NewPveNodeService(serviceService, pveNodeRepository, pveClusterRepository, logger)
-	pveNodeHandler := handler.NewPveNodeHandler(handlerHandler, pveNodeService)
+	nodeDiskHealthRepository := repository.NewNodeDiskHealthRepository(repositoryRepository)
+	sharedTokenRepository := repository.NewSharedTokenRepository(repositoryRepository)
+	sharedTokenStore, err := service.NewSharedTokenStore(viperViper, sharedTokenRepository, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	pveNodeService := service.NewPveNodeService(serviceService, pveNodeRepository, pveClusterRepository, nodeDiskHealthRepository, sharedTokenStore, logger)
+	consoleSessionRepository := repository.NewConsoleSessionRepository(repositoryRepository)
+	imageTransferRepository := repository.NewImageTransferRepository(repositoryRepository)
+	consoleAuditService := service.NewConsoleAuditService(serviceService, viperViper, consoleSessionRepository, pveClusterRepository, imageTransferRepository, userRepository, logger)
+	consoleProxyService := service.NewConsoleProxyService(viperViper, userRepository, logger)
+	pveNodeHandler := handler.NewPveNodeHandler(handlerHandler, pveNodeService, consoleAuditService, consoleProxyService)
 	pveVMRepository := repository.NewPveVMRepository(repositoryRepository)
 	vmTemplateRepository := repository.NewVmTemplateRepository(repositoryRepository)
 	templateInstanceRepository := repository.NewTemplateInstanceRepository(repositoryRepository)
 	pveStorageRepository := repository.NewPveStorageRepository(repositoryRepository)
 	vmipAddressRepository := repository.NewVMIPAddressRepository(repositoryRepository)
-	pveVMService := service.NewPveVMService(serviceService, pveVMRepository, vmTemplateRepository, templateInstanceRepository, pveStorageRepository, vmipAddressRepository, pveClusterRepository, pveNodeRepository, logger)
-	pveVMHandler := handler.NewPveVMHandler(handlerHandler, pveVMService)
+	licenseRepository := repository.NewLicenseRepository(repositoryRepository)
+	pveNodeCertificateRepository := repository.NewPveNodeCertificateRepository(repositoryRepository)
+	changeWindowRepository := repository.NewChangeWindowRepository(repositoryRepository)
+	vmLockRepository := repository.NewVMLockRepository(repositoryRepository)
+	vmLockService := service.NewVMLockService(serviceService, viperViper, vmLockRepository, pveVMRepository, pveNodeRepository, pveClusterRepository, userRepository, logger)
+	changeControlService := service.NewChangeControlService(serviceService, viperViper, changeWindowRepository, pveClusterRepository, userRepository, vmLockService, logger)
+	nodeVersionRepository := repository.NewNodeVersionRepository(repositoryRepository)
+	nodeVersionService := service.NewNodeVersionService(serviceService, viperViper, nodeVersionRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, logger)
+	vmProfileRepository := repository.NewVMProfileRepository(repositoryRepository)
+	vmProfileService := service.NewVMProfileService(serviceService, viperViper, vmProfileRepository, pveClusterRepository, userRepository, logger)
+	macAddressRepository := repository.NewMACAddressRepository(repositoryRepository)
+	macRegistryService := service.NewMACRegistryService(serviceService, viperViper, macAddressRepository, vmipAddressRepository, pveVMRepository, pveClusterRepository, userRepository, logger)
+	pendingOperationRepository := repository.NewPendingOperationRepository(repositoryRepository)
+	notificationRepository := repository.NewNotificationRepository(repositoryRepository)
+	notificationService := service.NewNotificationService(serviceService, viperViper, notificationRepository, userRepository, logger)
+	pendingOperationService := service.NewPendingOperationService(serviceService, viperViper, pendingOperationRepository, pveVMRepository, pveNodeRepository, pveClusterRepository, userRepository, changeControlService, vmLockService, notificationService, logger)
+	secretAuditRepository := repository.NewSecretAuditRepository(repositoryRepository)
+	vmCredentialService := service.NewVMCredentialService(serviceService, viperViper, secretAuditRepository, pveVMRepository, userRepository, sharedTokenStore, logger)
+	templateUsageRepository := repository.NewTemplateUsageRepository(repositoryRepository)
+	templateUploadRepository := repository.NewTemplateUploadRepository(repositoryRepository)
+	firstBootHookRepository := repository.NewFirstBootHookRepository(repositoryRepository)
+	firstBootHookService := service.NewFirstBootHookService(serviceService, viperViper, firstBootHookRepository, vmTemplateRepository, pveVMRepository, pveNodeRepository, pveClusterRepository, userRepository, logger)
+	templateUsageService := service.NewTemplateUsageService(serviceService, viperViper, templateUsageRepository, vmTemplateRepository, templateUploadRepository, pveVMRepository, pveClusterRepository, userRepository, notificationService, firstBootHookService, logger)
+	vmTaskTracker := service.NewVMTaskTracker(viperViper, pveVMRepository, userRepository, notificationService, logger)
+	provisionReservationService := service.NewProvisionReservationService(viperViper)
+	nodePoolRepository := repository.NewNodePoolRepository(repositoryRepository)
+	nodePoolService := service.NewNodePoolService(serviceService, viperViper, nodePoolRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, userRepository, provisionReservationService, logger)
+	vmidRangeRepository := repository.NewVMIDRangeRepository(repositoryRepository)
+	vmidRangeService := service.NewVMIDRangeService(serviceService, viperViper, vmidRangeRepository, pveClusterRepository, pveVMRepository, userRepository, logger)
+	remoteMigrationCutoverRepository := repository.NewRemoteMigrationCutoverRepository(repositoryRepository)
+	remoteMigrationCutoverService := service.NewRemoteMigrationCutoverService(serviceService, viperViper, remoteMigrationCutoverRepository, pveVMRepository, vmipAddressRepository, macAddressRepository, pveClusterRepository, pveNodeRepository, userRepository, notificationService, logger)
+	pveVMService := service.NewPveVMService(serviceService, viperViper, pveVMRepository, vmTemplateRepository, templateInstanceRepository, pveStorageRepository, vmipAddressRepository, pveClusterRepository, pveNodeRepository, pveSiteRepository, licenseRepository, pveNodeCertificateRepository, changeControlService, nodeVersionService, vmProfileService, macRegistryService, vmLockService, pendingOperationService, vmCredentialService, templateUsageService, vmTaskTracker, provisionReservationService, nodePoolService, vmidRangeService, remoteMigrationCutoverService, sharedTokenStore, logger)
+	vmCreateJobRepository := repository.NewVMCreateJobRepository(repositoryRepository)
+	jobLeaseRepository := repository.NewJobLeaseRepository(repositoryRepository)
+	jobLeaseService := service.NewJobLeaseService(viperViper, jobLeaseRepository, logger)
+	vmCreateJobService := service.NewVMCreateJobService(serviceService, viperViper, vmCreateJobRepository, pveVMService, pveVMRepository, pveClusterRepository, pveNodeRepository, userRepository, notificationService, nodePoolService, vmidRangeService, jobLeaseService)
+	pveVMHandler := handler.NewPveVMHandler(handlerHandler, pveVMService, vmCreateJobService, consoleAuditService, consoleProxyService)
 	pveStorageService := service.NewPveStorageService(serviceService, pveStorageRepository, pveNodeRepository, logger)
 	pveStorageHandler := handler.NewPveStorageHandler(handlerHandler, pveStorageService)
 	pveTemplateRepository := repository.NewPveTemplateRepository(repositoryRepository)
-	templateUploadRepository := repository.NewTemplateUploadRepository(repositoryRepository)
 	templateSyncTaskRepository := repository.NewTemplateSyncTaskRepository(repositoryRepository)
-	pveTemplateService := service.NewPveTemplateService(serviceService, pveTemplateRepository, templateInstanceRepository, templateSyncTaskRepository, templateUploadRepository, pveNodeRepository, pveClusterRepository, logger)
+	pveTemplateService := service.NewPveTemplateService(serviceService, pveTemplateRepository, templateInstanceRepository, templateSyncTaskRepository, templateUploadRepository, pveNodeRepository, pveClusterRepository, templateUsageService, logger)
 	pveTemplateHandler := handler.NewPveTemplateHandler(handlerHandler, pveTemplateService)
-	templateManagementService := service.NewTemplateManagementService(serviceService, pveTemplateRepository, templateUploadRepository, templateInstanceRepository, templateSyncTaskRepository, pveVMRepository, pveStorageRepository, pveNodeRepository, pveClusterRepository, logger)
+	templateManagementService := service.NewTemplateManagementService(serviceService, pveTemplateRepository, templateUploadRepository, templateInstanceRepository, templateSyncTaskRepository, pveVMRepository, pveStorageRepository, pveNodeRepository, pveClusterRepository, jobLeaseService, logger)
 	templateManagementHandler := handler.NewTemplateManagementHandler(handlerHandler, templateManagementService)
 	pveTaskService := service.NewPveTaskService(serviceService, pveClusterRepository, logger)
 	pveTaskHandler := handler.NewPveTaskHandler(handlerHandler, pveTaskService)
-	dashboardService := service.NewDashboardService(serviceService, pveClusterRepository, pveNodeRepository, pveVMRepository, pveStorageRepository, logger)
+	nodeBMCRepository := repository.NewNodeBMCRepository(repositoryRepository)
+	energyRepository := repository.NewEnergyRepository(repositoryRepository)
+	dashboardLayoutRepository := repository.NewDashboardLayoutRepository(repositoryRepository)
+	zfsPoolAlertRepository := repository.NewZFSPoolAlertRepository(repositoryRepository)
+	dashboardService := service.NewDashboardService(serviceService, viperViper, pveClusterRepository, pveSiteRepository, pveNodeRepository, pveVMRepository, pveStorageRepository, nodeBMCRepository, energyRepository, dashboardLayoutRepository, licenseRepository, zfsPoolAlertRepository, nodeDiskHealthRepository, logger)
 	dashboardHandler := handler.NewDashboardHandler(handlerHandler, dashboardService)
+	vmQosProfileRepository := repository.NewVmQosProfileRepository(repositoryRepository)
+	vmQosService := service.NewVMQosService(serviceService, vmQosProfileRepository, pveVMRepository, pveNodeRepository, pveClusterRepository, vmLockService, logger)
+	vmQosHandler := handler.NewVMQosHandler(handlerHandler, vmQosService)
+	storageGCRepository := repository.NewStorageGCRepository(repositoryRepository)
+	storageGCService := service.NewStorageGCService(serviceService, viperViper, userRepository, storageGCRepository, pveClusterRepository, pveStorageRepository, notificationService, logger)
+	storageGCHandler := handler.NewStorageGCHandler(handlerHandler, storageGCService)
+	searchRepository := repository.NewSearchRepository(repositoryRepository)
+	searchService := service.NewSearchService(serviceService, searchRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, logger)
+	searchHandler := handler.NewSearchHandler(handlerHandler, searchService)
+	vmListViewRepository := repository.NewVmListViewRepository(repositoryRepository)
+	vmListViewService := service.NewVMListViewService(serviceService, vmListViewRepository, logger)
+	vmListViewHandler := handler.NewVMListViewHandler(handlerHandler, vmListViewService)
+	schemaMigrationRepository := repository.NewSchemaMigrationRepository(repositoryRepository)
+	schemaService := service.NewSchemaService(serviceService, viperViper, schemaMigrationRepository, userRepository, logger)
+	schemaHandler := handler.NewSchemaHandler(handlerHandler, schemaService)
+	configAuditRepository := repository.NewConfigAuditRepository(repositoryRepository)
+	systemConfigService := service.NewSystemConfigService(serviceService, viperViper, configAuditRepository, userRepository, logger)
+	systemConfigHandler := handler.NewSystemConfigHandler(handlerHandler, systemConfigService)
+	grafanaService := service.NewGrafanaService(serviceService, pveClusterRepository, pveNodeRepository, pveVMRepository, logger)
+	grafanaHandler := handler.NewGrafanaHandler(handlerHandler, grafanaService)
+	vmStackRepository := repository.NewVmStackRepository(repositoryRepository)
+	vmStackService := service.NewVMStackService(serviceService, vmStackRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, pveTemplateRepository, templateInstanceRepository, changeControlService, templateUsageService, logger)
+	vmStackHandler := handler.NewVMStackHandler(handlerHandler, vmStackService)
+	pveSiteService := service.NewPveSiteService(serviceService, pveSiteRepository, pveClusterRepository, logger)
+	pveSiteHandler := handler.NewPveSiteHandler(handlerHandler, pveSiteService)
+	changeWindowHandler := handler.NewChangeWindowHandler(handlerHandler, changeControlService)
+	costRepository := repository.NewCostRepository(repositoryRepository)
+	costService := service.NewCostService(serviceService, viperViper, costRepository, pveClusterRepository, pveVMRepository, userRepository, notificationService, logger)
+	costHandler := handler.NewCostHandler(handlerHandler, costService)
+	nodeBMCService := service.NewNodeBMCService(serviceService, viperViper, nodeBMCRepository, pveNodeRepository, pveClusterRepository, pveVMRepository, userRepository, changeControlService, logger)
+	nodeBMCHandler := handler.NewNodeBMCHandler(handlerHandler, nodeBMCService)
+	energyService := service.NewEnergyService(serviceService, viperViper, energyRepository, nodeBMCRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, logger)
+	energyHandler := handler.NewEnergyHandler(handlerHandler, energyService)
+	vmImportRepository := repository.NewVmImportRepository(repositoryRepository)
+	vmImportService := service.NewVMImportService(serviceService, viperViper, vmImportRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, userRepository, changeControlService, notificationService, logger)
+	vmImportHandler := handler.NewVMImportHandler(handlerHandler, vmImportService)
+	imageTransferService := service.NewImageTransferService(serviceService, viperViper, imageTransferRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, userRepository, changeControlService, notificationService, logger)
+	imageTransferHandler := handler.NewImageTransferHandler(handlerHandler, imageTransferService)
+	templateCatalogRepository := repository.NewTemplateCatalogRepository(repositoryRepository)
+	templateCatalogService := service.NewTemplateCatalogService(serviceService, viperViper, templateCatalogRepository, pveTemplateRepository, templateUploadRepository, templateInstanceRepository, pveStorageRepository, pveClusterRepository, pveNodeRepository, userRepository, notificationService, logger)
+	templateCatalogHandler := handler.NewTemplateCatalogHandler(handlerHandler, templateCatalogService)
+	nodeVersionHandler := handler.NewNodeVersionHandler(handlerHandler, nodeVersionService)
+	licenseService := service.NewLicenseService(serviceService, viperViper, licenseRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, vmTemplateRepository, userRepository, notificationService, logger)
+	licenseHandler := handler.NewLicenseHandler(handlerHandler, licenseService)
+	vmClaimRepository := repository.NewVMClaimRepository(repositoryRepository)
+	vmClaimService := service.NewVMClaimService(serviceService, viperViper, vmClaimRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, userRepository, logger)
+	vmClaimHandler := handler.NewVMClaimHandler(handlerHandler, vmClaimService)
+	vmProfileHandler := handler.NewVMProfileHandler(handlerHandler, vmProfileService)
+	vmStorageMoveRepository := repository.NewVMStorageMoveRepository(repositoryRepository)
+	vmStorageMoveService := service.NewVMStorageMoveService(serviceService, viperViper, vmStorageMoveRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, changeControlService, notificationService, vmLockService, logger)
+	vmStorageMoveHandler := handler.NewVMStorageMoveHandler(handlerHandler, vmStorageMoveService)
+	rebalanceRepository := repository.NewRebalanceRepository(repositoryRepository)
+	rebalanceService := service.NewRebalanceService(serviceService, viperViper, rebalanceRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, userRepository, pveVMService, changeControlService, provisionReservationService, logger)
+	rebalanceHandler := handler.NewRebalanceHandler(handlerHandler, rebalanceService)
+	consoleAuditHandler := handler.NewConsoleAuditHandler(handlerHandler, consoleAuditService, consoleProxyService)
+	storageBrowserService := service.NewStorageBrowserService(serviceService, viperViper, userRepository, pveNodeRepository, pveClusterRepository, pveVMRepository, changeControlService, sharedTokenStore, logger)
+	storageBrowserHandler := handler.NewStorageBrowserHandler(handlerHandler, storageBrowserService)
+	nodeZFSService := service.NewNodeZFSService(serviceService, viperViper, zfsPoolAlertRepository, pveNodeRepository, pveClusterRepository, userRepository, changeControlService, notificationService, logger)
+	nodeZFSHandler := handler.NewNodeZFSHandler(handlerHandler, nodeZFSService)
+	nodeDiskService := service.NewNodeDiskService(serviceService, viperViper, nodeDiskHealthRepository, pveNodeRepository, pveClusterRepository, userRepository, changeControlService, notificationService, logger)
+	nodeDiskHandler := handler.NewNodeDiskHandler(handlerHandler, nodeDiskService)
+	notificationHandler := handler.NewNotificationHandler(handlerHandler, notificationService)
+	vmNetworkDiagService := service.NewVMNetworkDiagService(serviceService, viperViper, pveVMRepository, pveNodeRepository, pveClusterRepository, userRepository, logger)
+	vmNetworkDiagHandler := handler.NewVMNetworkDiagHandler(handlerHandler, vmNetworkDiagService)
+	macAddressHandler := handler.NewMACAddressHandler(handlerHandler, macRegistryService)
+	pveAccessService := service.NewPveAccessService(serviceService, viperViper, pveClusterRepository, userRepository, clusterCapabilityService, logger)
+	pveAccessHandler := handler.NewPveAccessHandler(handlerHandler, pveAccessService)
+	vmLockHandler := handler.NewVMLockHandler(handlerHandler, vmLockService)
+	pendingOperationHandler := handler.NewPendingOperationHandler(handlerHandler, pendingOperationService)
+	vmCredentialHandler := handler.NewVMCredentialHandler(handlerHandler, vmCredentialService)
+	dependencyService := service.NewDependencyService(serviceService, pveVMRepository, pveNodeRepository, pveClusterRepository, pveStorageRepository, vmTemplateRepository, templateInstanceRepository, vmipAddressRepository, vmProfileRepository, logger)
+	dependencyHandler := handler.NewDependencyHandler(handlerHandler, dependencyService)
+	templateUsageHandler := handler.NewTemplateUsageHandler(handlerHandler, templateUsageService)
+	vmnicService := service.NewVMNICService(serviceService, pveVMRepository, pveNodeRepository, pveClusterRepository, macRegistryService, vmLockService, logger)
+	vmnicHandler := handler.NewVMNICHandler(handlerHandler, vmnicService)
+	bootOrderPolicyRepository := repository.NewBootOrderPolicyRepository(repositoryRepository)
+	vmStartupService := service.NewVMStartupService(serviceService, viperViper, bootOrderPolicyRepository, pveVMRepository, pveNodeRepository, pveClusterRepository, userRepository, vmLockService, logger)
+	vmStartupHandler := handler.NewVMStartupHandler(handlerHandler, vmStartupService)
+	reservationHandler := handler.NewReservationHandler(handlerHandler, provisionReservationService)
+	operationAuditRepository := repository.NewOperationAuditRepository(repositoryRepository)
+	operationAuditService := service.NewOperationAuditService(serviceService, viperViper, operationAuditRepository, userRepository)
+	operationAuditHandler := handler.NewOperationAuditHandler(handlerHandler, operationAuditService)
+	nodePoolHandler := handler.NewNodePoolHandler(handlerHandler, nodePoolService)
+	templatePromotionRepository := repository.NewTemplatePromotionRepository(repositoryRepository)
+	templatePromotionService := service.NewTemplatePromotionService(serviceService, viperViper, templatePromotionRepository, pveTemplateRepository, templateUploadRepository, templateInstanceRepository, pveClusterRepository, pveNodeRepository, pveStorageRepository, pveVMRepository, userRepository, imageTransferService, notificationService, logger)
+	templatePromotionHandler := handler.NewTemplatePromotionHandler(handlerHandler, templatePromotionService)
+	metadataBackupRepository := repository.NewMetadataBackupRepository(repositoryRepository)
+	metadataBackupService := service.NewMetadataBackupService(serviceService, viperViper, metadataBackupRepository, userRepository, vmCredentialService, logger)
+	metadataBackupHandler := handler.NewMetadataBackupHandler(handlerHandler, metadataBackupService)
+	vmidRangeHandler := handler.NewVMIDRangeHandler(handlerHandler, vmidRangeService)
+	vmStatusEventRepository := repository.NewVMStatusEventRepository(repositoryRepository)
+	vmEventService := service.NewVMEventService(serviceService, viperViper, pveVMRepository, pveClusterRepository, operationAuditRepository, vmStatusEventRepository, userRepository, logger)
+	vmEventHandler := handler.NewVMEventHandler(handlerHandler, vmEventService)
+	topologyRepository := repository.NewTopologyRepository(repositoryRepository)
+	topologyService := service.NewTopologyService(serviceService, topologyRepository, logger)
+	topologyHandler := handler.NewTopologyHandler(handlerHandler, topologyService)
+	vmAgentInstallRepository := repository.NewVMAgentInstallRepository(repositoryRepository)
+	vmAgentInstallService := service.NewVMAgentInstallService(serviceService, viperViper, vmAgentInstallRepository, pveVMRepository, pveNodeRepository, pveClusterRepository, userRepository, changeControlService, vmLockService, logger)
+	vmAgentInstallHandler := handler.NewVMAgentInstallHandler(handlerHandler, vmAgentInstallService)
+	oseolService := service.NewOSEOLService(serviceService, licenseRepository, pveVMRepository, pveClusterRepository, logger)
+	oseolHandler := handler.NewOSEOLHandler(handlerHandler, oseolService)
+	vmSecurityRepository := repository.NewVMSecurityRepository(repositoryRepository)
+	vmSecurityService := service.NewVMSecurityService(serviceService, viperViper, vmSecurityRepository, pveVMRepository, pveNodeRepository, pveClusterRepository, userRepository, changeControlService, vmLockService, logger)
+	vmSecurityHandler := handler.NewVMSecurityHandler(handlerHandler, vmSecurityService)
+	clusterEndpointService := service.NewClusterEndpointService(serviceService, pveClusterRepository, pveNodeRepository, pveNodeCertificateRepository, clusterCapabilityService, logger)
+	clusterEndpointHandler := handler.NewClusterEndpointHandler(handlerHandler, clusterEndpointService)
+	remoteMigrationJobRepository := repository.NewRemoteMigrationJobRepository(repositoryRepository)
+	remoteMigrationJobService := service.NewRemoteMigrationJobService(serviceService, viperViper, remoteMigrationJobRepository, pveVMRepository, userRepository, pveVMService, notificationService, logger)
+	remoteMigrationHandler := handler.NewRemoteMigrationHandler(handlerHandler, remoteMigrationJobService, remoteMigrationCutoverService)
+	vmBulkDeleteJobRepository := repository.NewVMBulkDeleteJobRepository(repositoryRepository)
+	vmBulkDeleteService := service.NewVMBulkDeleteService(serviceService, viperViper, vmBulkDeleteJobRepository, pveVMService, pveVMRepository, pveClusterRepository, pveNodeRepository, userRepository, notificationService, sharedTokenStore, jobLeaseService)
+	vmBulkDeleteHandler := handler.NewVMBulkDeleteHandler(handlerHandler, vmBulkDeleteService)
+	vmProtectionService := service.NewVMProtectionService(serviceService, viperViper, pveVMRepository, pveNodeRepository, pveClusterRepository, userRepository, logger)
+	vmProtectionHandler := handler.NewVMProtectionHandler(handlerHandler, vmProtectionService)
+	clusterCapacitySnapshotRepository := repository.NewClusterCapacitySnapshotRepository(repositoryRepository)
+	dashboardChangeService := service.NewDashboardChangeService(serviceService, pveClusterRepository, pveNodeRepository, pveVMRepository, pveStorageRepository, vmStatusEventRepository, operationAuditRepository, clusterCapacitySnapshotRepository, logger)
+	dashboardChangeHandler := handler.NewDashboardChangeHandler(handlerHandler, dashboardChangeService)
+	clusterEventRepository := repository.NewClusterEventRepository(repositoryRepository)
+	vmNotesService := service.NewVMNotesService(serviceService, viperViper, pveVMRepository, pveNodeRepository, pveClusterRepository, vmLockService, logger)
+	clusterEventService := service.NewClusterEventService(serviceService, viperViper, clusterEventRepository, pveClusterRepository, pveNodeRepository, pveVMRepository, vmClaimService, vmNotesService, notificationService, logger)
+	clusterEventHandler := handler.NewClusterEventHandler(handlerHandler, clusterEventService)
+	vmNotesHandler := handler.NewVMNotesHandler(handlerHandler, vmNotesService)
+	reportRepository := repository.NewReportRepository(repositoryRepository)
+	reportService := service.NewReportService(serviceService, viperViper, reportRepository, pveClusterRepository, pveVMRepository, userRepository, vmSecurityRepository, dashboardChangeService, oseolService, notificationService, logger)
+	reportHandler := handler.NewReportHandler(handlerHandler, reportService)
+	capabilityService := service.NewCapabilityService(serviceService, viperViper, userRepository, pveClusterRepository, pveVMRepository, vmLockRepository, clusterCapabilityService, logger)
+	capabilityHandler := handler.NewCapabilityHandler(handlerHandler, capabilityService)
+	vmHibernateService := service.NewVMHibernateService(serviceService, viperViper, pveVMRepository, pveNodeRepository, pveClusterRepository, changeControlService, vmLockService, vmTaskTracker, logger)
+	vmHibernateHandler := handler.NewVMHibernateHandler(handlerHandler, vmHibernateService)
+	firstBootHookHandler := handler.NewFirstBootHookHandler(handlerHandler, firstBootHookService)
+	contentReplicationRepository := repository.NewContentReplicationRepository(repositoryRepository)
+	contentReplicationService := service.NewContentReplicationService(serviceService, viperViper, contentReplicationRepository, pveClusterRepository, pveStorageRepository, userRepository, logger)
+	contentReplicationHandler := handler.NewContentReplicationHandler(handlerHandler, contentReplicationService)
 	routerDeps := router.RouterDeps{
 		Logger:                    logger,
 		Config:                    viperViper,
@@ -77,33 +264,159 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 		TemplateManagementHandler: templateManagementHandler,
 		PveTaskHandler:            pveTaskHandler,
 		DashboardHandler:          dashboardHandler,
+		VMQosHandler:              vmQosHandler,
+		StorageGCHandler:          storageGCHandler,
+		SearchHandler:             searchHandler,
+		VMListViewHandler:         vmListViewHandler,
+		SchemaHandler:             schemaHandler,
+		SystemConfigHandler:       systemConfigHandler,
+		GrafanaHandler:            grafanaHandler,
+		VMStackHandler:            vmStackHandler,
+		PveSiteHandler:            pveSiteHandler,
+		ChangeWindowHandler:       changeWindowHandler,
+		CostHandler:               costHandler,
+		NodeBMCHandler:            nodeBMCHandler,
+		EnergyHandler:             energyHandler,
+		VMImportHandler:           vmImportHandler,
+		ImageTransferHandler:      imageTransferHandler,
+		TemplateCatalogHandler:    templateCatalogHandler,
+		NodeVersionHandler:        nodeVersionHandler,
+		LicenseHandler:            licenseHandler,
+		VMClaimHandler:            vmClaimHandler,
+		VMProfileHandler:          vmProfileHandler,
+		VMStorageMoveHandler:      vmStorageMoveHandler,
+		RebalanceHandler:          rebalanceHandler,
+		ConsoleAuditHandler:       consoleAuditHandler,
+		StorageBrowserHandler:     storageBrowserHandler,
+		NodeZFSHandler:            nodeZFSHandler,
+		NodeDiskHandler:           nodeDiskHandler,
+		NotificationHandler:       notificationHandler,
+		VMNetworkDiagHandler:      vmNetworkDiagHandler,
+		MACAddressHandler:         macAddressHandler,
+		PveAccessHandler:          pveAccessHandler,
+		VMLockHandler:             vmLockHandler,
+		PendingOperationHandler:   pendingOperationHandler,
+		VMCredentialHandler:       vmCredentialHandler,
+		DependencyHandler:         dependencyHandler,
+		TemplateUsageHandler:      templateUsageHandler,
+		VMNICHandler:              vmnicHandler,
+		VMStartupHandler:          vmStartupHandler,
+		ReservationHandler:        reservationHandler,
+		OperationAuditHandler:     operationAuditHandler,
+		NodePoolHandler:           nodePoolHandler,
+		TemplatePromotionHandler:  templatePromotionHandler,
+		MetadataBackupHandler:     metadataBackupHandler,
+		VMIDRangeHandler:          vmidRangeHandler,
+		VMEventHandler:            vmEventHandler,
+		TopologyHandler:           topologyHandler,
+		VMAgentInstallHandler:     vmAgentInstallHandler,
+		OSEOLHandler:              oseolHandler,
+		VMSecurityHandler:         vmSecurityHandler,
+		ClusterEndpointHandler:    clusterEndpointHandler,
+		RemoteMigrationHandler:    remoteMigrationHandler,
+		VMBulkDeleteHandler:       vmBulkDeleteHandler,
+		VMProtectionHandler:       vmProtectionHandler,
+		DashboardChangeHandler:    dashboardChangeHandler,
+		ClusterEventHandler:       clusterEventHandler,
+		VMNotesHandler:            vmNotesHandler,
+		ReportHandler:             reportHandler,
+		CapabilityHandler:         capabilityHandler,
+		VMHibernateHandler:        vmHibernateHandler,
+		FirstBootHookHandler:      firstBootHookHandler,
+		ContentReplicationHandler: contentReplicationHandler,
 	}
 	httpServer := server.NewHTTPServer(routerDeps)
 	jobJob := job.NewJob(transaction, logger, sidSid)
 	userJob := job.NewUserJob(jobJob, userRepository)
 	jobServer := server.NewJobServer(logger, userJob)
-	appApp := newApp(httpServer, jobServer)
+	schemaServer, err := server.NewSchemaServer(viperViper, logger, db)
+	if err != nil {
+		return nil, nil, err
+	}
+	migrateServer := server.NewMigrateServer(db, logger, userRepository, sidSid)
+	duration := _wireDurationValue
+	pveController := controller.NewPveController(pveClusterRepository, pveNodeRepository, pveVMRepository, pveStorageRepository, vmStatusEventRepository, logger, duration)
+	embeddedServer, err := server.NewEmbeddedServer(viperViper, logger, migrateServer, pveController)
+	if err != nil {
+		return nil, nil, err
+	}
+	configReloadServer := server.NewConfigReloadServer(logger, systemConfigService)
+	costCollectorServer := server.NewCostCollectorServer(viperViper, logger, costService)
+	energyCollectorServer := server.NewEnergyCollectorServer(viperViper, logger, energyService)
+	templateCatalogSyncServer := server.NewTemplateCatalogSyncServer(viperViper, logger, templateCatalogService)
+	nodeVersionCollectorServer := server.NewNodeVersionCollectorServer(viperViper, logger, nodeVersionService)
+	licenseCollectorServer := server.NewLicenseCollectorServer(viperViper, logger, licenseService)
+	vmClaimScannerServer := server.NewVMClaimScannerServer(viperViper, logger, vmClaimService)
+	rebalanceAnalyzerServer := server.NewRebalanceAnalyzerServer(viperViper, logger, rebalanceService)
+	consoleRecordingCleanerServer := server.NewConsoleRecordingCleanerServer(viperViper, logger, consoleAuditService)
+	zfsHealthCollectorServer := server.NewZFSHealthCollectorServer(viperViper, logger, nodeZFSService)
+	diskSMARTCollectorServer := server.NewDiskSMARTCollectorServer(viperViper, logger, nodeDiskService)
+	notificationCleanerServer := server.NewNotificationCleanerServer(viperViper, logger, notificationService)
+	pendingOperationRetrierServer := server.NewPendingOperationRetrierServer(viperViper, logger, pendingOperationService)
+	agentInstallCheckerServer := server.NewAgentInstallCheckerServer(viperViper, logger, vmAgentInstallService)
+	securityScanServer := server.NewSecurityScanServer(viperViper, logger, vmSecurityService)
+	remoteMigrationSchedulerServer := server.NewRemoteMigrationSchedulerServer(viperViper, logger, remoteMigrationJobService, remoteMigrationCutoverService)
+	capacitySnapshotServer := server.NewCapacitySnapshotServer(viperViper, logger, dashboardChangeService)
+	jobLeaseServer := server.NewJobLeaseServer(viperViper, logger, jobLeaseService, sharedTokenStore)
+	clusterEventPollerServer := server.NewClusterEventPollerServer(viperViper, logger, clusterEventService)
+	vmNotesSyncServer := server.NewVMNotesSyncServer(viperViper, logger, vmNotesService)
+	reportSchedulerServer := server.NewReportSchedulerServer(viperViper, logger, reportService)
+	firstBootCheckerServer := server.NewFirstBootCheckerServer(viperViper, logger, firstBootHookService)
+	contentReplicationServer := server.NewContentReplicationServer(viperViper, logger, contentReplicationService)
+	appApp := newApp(httpServer, jobServer, schemaServer, embeddedServer, configReloadServer, costCollectorServer, energyCollectorServer, templateCatalogSyncServer, nodeVersionCollectorServer, licenseCollectorServer, vmClaimScannerServer, rebalanceAnalyzerServer, consoleRecordingCleanerServer, zfsHealthCollectorServer, diskSMARTCollectorServer, notificationCleanerServer, pendingOperationRetrierServer, agentInstallCheckerServer, securityScanServer, remoteMigrationSchedulerServer, capacitySnapshotServer, jobLeaseServer, clusterEventPollerServer, vmNotesSyncServer, reportSchedulerServer, firstBootCheckerServer, contentReplicationServer)
 	return appApp, func() {
 	}, nil
 }
 
+var (
+	_wireDurationValue = time.Minute *
+		5
+)
+
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewPveClusterRepository, repository.NewPveNodeRepository, repository.NewPveVMRepository, repository.NewPveStorageRepository, repository.NewVmTemplateRepository, repository.NewVMIPAddressRepository, repository.NewPveTemplateRepository, repository.NewTemplateUploadRepository, repository.NewTemplateInstanceRepository, repository.NewTemplateSyncTaskRepository)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewPveClusterRepository, repository.NewPveNodeRepository, repository.NewPveVMRepository, repository.NewPveStorageRepository, repository.NewVMStatusEventRepository, repository.NewVmTemplateRepository, repository.NewVMIPAddressRepository, repository.NewPveTemplateRepository, repository.NewTemplateUploadRepository, repository.NewTemplateInstanceRepository, repository.NewTemplateSyncTaskRepository, repository.NewVmQosProfileRepository, repository.NewStorageGCRepository, repository.NewSearchRepository, repository.NewVmListViewRepository, repository.NewSchemaMigrationRepository, repository.NewConfigAuditRepository, repository.NewVmStackRepository, repository.NewPveSiteRepository, repository.NewChangeWindowRepository, repository.NewCostRepository, repository.NewNodeBMCRepository, repository.NewEnergyRepository, repository.NewVmImportRepository, repository.NewImageTransferRepository, repository.NewTemplateCatalogRepository, repository.NewNodeVersionRepository, repository.NewLicenseRepository, repository.NewVMClaimRepository, repository.NewVMProfileRepository, repository.NewVMStorageMoveRepository, repository.NewRebalanceRepository, repository.NewConsoleSessionRepository, repository.NewZFSPoolAlertRepository, repository.NewNodeDiskHealthRepository, repository.NewDashboardLayoutRepository, repository.NewNotificationRepository, repository.NewMACAddressRepository, repository.NewClusterCapabilityRepository, repository.NewVMLockRepository, repository.NewPendingOperationRepository, repository.NewSecretAuditRepository, repository.NewTemplateUsageRepository, repository.NewBootOrderPolicyRepository, repository.NewVMCreateJobRepository, repository.NewOperationAuditRepository, repository.NewNodePoolRepository, repository.NewTemplatePromotionRepository, repository.NewMetadataBackupRepository, repository.NewVMIDRangeRepository, repository.NewTopologyRepository, repository.NewVMAgentInstallRepository, repository.NewVMSecurityRepository, repository.NewPveNodeCertificateRepository, repository.NewRemoteMigrationJobRepository, repository.NewRemoteMigrationCutoverRepository, repository.NewVMBulkDeleteJobRepository, repository.NewClusterCapacitySnapshotRepository, repository.NewSharedTokenRepository, repository.NewJobLeaseRepository, repository.NewClusterEventRepository, repository.NewReportRepository, repository.NewFirstBootHookRepository, repository.NewContentReplicationRepository)
 
-var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewPveClusterService, service.NewPveNodeService, service.NewPveVMService, service.NewPveStorageService, service.NewPveTemplateService, service.NewPveTaskService, service.NewDashboardService, service.NewTemplateManagementService)
+var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewPveClusterService, service.NewPveNodeService, service.NewPveVMService, service.NewPveStorageService, service.NewPveTemplateService, service.NewPveTaskService, service.NewDashboardService, service.NewTemplateManagementService, service.NewVMQosService, service.NewStorageGCService, service.NewSearchService, service.NewVMListViewService, service.NewSchemaService, service.NewSystemConfigService, service.NewGrafanaService, service.NewVMStackService, service.NewPveSiteService, service.NewChangeControlService, service.NewCostService, service.NewNodeBMCService, service.NewEnergyService, service.NewVMImportService, service.NewImageTransferService, service.NewTemplateCatalogService, service.NewNodeVersionService, service.NewLicenseService, service.NewVMClaimService, service.NewVMProfileService, service.NewVMStorageMoveService, service.NewRebalanceService, service.NewConsoleAuditService, service.NewStorageBrowserService, service.NewNodeZFSService, service.NewNodeDiskService, service.NewNotificationService, service.NewVMNetworkDiagService, service.NewMACRegistryService, service.NewPveAccessService, service.NewClusterCapabilityService, service.NewVMLockService, service.NewPendingOperationService, service.NewVMCredentialService, service.NewDependencyService, service.NewTemplateUsageService, service.NewVMNICService, service.NewVMStartupService, service.NewVMTaskTracker, service.NewProvisionReservationService, service.NewVMCreateJobService, service.NewOperationAuditService, service.NewNodePoolService, service.NewTemplatePromotionService, service.NewMetadataBackupService, service.NewVMIDRangeService, service.NewVMEventService, service.NewTopologyService, service.NewVMAgentInstallService, service.NewOSEOLService, service.NewVMSecurityService, service.NewClusterEndpointService, service.NewRemoteMigrationJobService, service.NewRemoteMigrationCutoverService, service.NewVMBulkDeleteService, service.NewVMProtectionService, service.NewDashboardChangeService, service.NewConsoleProxyService, service.NewSharedTokenStore, service.NewJobLeaseService, service.NewClusterEventService, service.NewVMNotesService, service.NewReportService, service.NewCapabilityService, service.NewVMHibernateService, service.NewFirstBootHookService, service.NewContentReplicationService)
 
-var handlerSet = wire.NewSet(handler.NewHandler, handler.NewPveAuthHandler, handler.NewUserHandler, handler.NewPveClusterHandler, handler.NewPveNodeHandler, handler.NewPveVMHandler, handler.NewPveStorageHandler, handler.NewPveTemplateHandler, handler.NewTemplateManagementHandler, handler.NewPveTaskHandler, handler.NewDashboardHandler)
+var handlerSet = wire.NewSet(handler.NewHandler, handler.NewPveAuthHandler, handler.NewUserHandler, handler.NewPveClusterHandler, handler.NewPveNodeHandler, handler.NewPveVMHandler, handler.NewPveStorageHandler, handler.NewPveTemplateHandler, handler.NewTemplateManagementHandler, handler.NewPveTaskHandler, handler.NewDashboardHandler, handler.NewVMQosHandler, handler.NewStorageGCHandler, handler.NewSearchHandler, handler.NewVMListViewHandler, handler.NewSchemaHandler, handler.NewSystemConfigHandler, handler.NewGrafanaHandler, handler.NewVMStackHandler, handler.NewPveSiteHandler, handler.NewChangeWindowHandler, handler.NewCostHandler, handler.NewNodeBMCHandler, handler.NewEnergyHandler, handler.NewVMImportHandler, handler.NewImageTransferHandler, handler.NewTemplateCatalogHandler, handler.NewNodeVersionHandler, handler.NewLicenseHandler, handler.NewVMClaimHandler, handler.NewVMProfileHandler, handler.NewVMStorageMoveHandler, handler.NewRebalanceHandler, handler.NewConsoleAuditHandler, handler.NewStorageBrowserHandler, handler.NewNodeZFSHandler, handler.NewNodeDiskHandler, handler.NewNotificationHandler, handler.NewVMNetworkDiagHandler, handler.NewMACAddressHandler, handler.NewPveAccessHandler, handler.NewVMLockHandler, handler.NewPendingOperationHandler, handler.NewVMCredentialHandler, handler.NewDependencyHandler, handler.NewTemplateUsageHandler, handler.NewVMNICHandler, handler.NewVMStartupHandler, handler.NewReservationHandler, handler.NewOperationAuditHandler, handler.NewNodePoolHandler, handler.NewTemplatePromotionHandler, handler.NewMetadataBackupHandler, handler.NewVMIDRangeHandler, handler.NewVMEventHandler, handler.NewTopologyHandler, handler.NewVMAgentInstallHandler, handler.NewOSEOLHandler, handler.NewVMSecurityHandler, handler.NewClusterEndpointHandler, handler.NewRemoteMigrationHandler, handler.NewVMBulkDeleteHandler, handler.NewVMProtectionHandler, handler.NewDashboardChangeHandler, handler.NewClusterEventHandler, handler.NewVMNotesHandler, handler.NewReportHandler, handler.NewCapabilityHandler, handler.NewVMHibernateHandler, handler.NewFirstBootHookHandler, handler.NewContentReplicationHandler)
 
 var jobSet = wire.NewSet(job.NewJob, job.NewUserJob)
 
-var serverSet = wire.NewSet(server.NewHTTPServer, server.NewJobServer)
+var controllerSet = wire.NewSet(controller.NewPveController, wire.Value(time.Minute*5))
+
+var serverSet = wire.NewSet(server.NewHTTPServer, server.NewJobServer, server.NewSchemaServer, server.NewMigrateServer, server.NewEmbeddedServer, server.NewConfigReloadServer, server.NewCostCollectorServer, server.NewEnergyCollectorServer, server.NewTemplateCatalogSyncServer, server.NewNodeVersionCollectorServer, server.NewLicenseCollectorServer, server.NewVMClaimScannerServer, server.NewRebalanceAnalyzerServer, server.NewConsoleRecordingCleanerServer, server.NewZFSHealthCollectorServer, server.NewDiskSMARTCollectorServer, server.NewNotificationCleanerServer, server.NewPendingOperationRetrierServer, server.NewAgentInstallCheckerServer, server.NewSecurityScanServer, server.NewRemoteMigrationSchedulerServer, server.NewCapacitySnapshotServer, server.NewJobLeaseServer, server.NewClusterEventPollerServer, server.NewVMNotesSyncServer, server.NewReportSchedulerServer, server.NewFirstBootCheckerServer, server.NewContentReplicationServer)
 
 // build App
 func newApp(
 	httpServer *http.Server,
 	jobServer *server.JobServer,
+	schemaServer *server.SchemaServer,
+	embeddedServer *server.EmbeddedServer,
+	configReloadServer *server.ConfigReloadServer,
+	costCollectorServer *server.CostCollectorServer,
+	energyCollectorServer *server.EnergyCollectorServer,
+	catalogSyncServer *server.TemplateCatalogSyncServer,
+	nodeVersionServer *server.NodeVersionCollectorServer,
+	licenseCollectorServer *server.LicenseCollectorServer,
+	vmClaimScannerServer *server.VMClaimScannerServer,
+	rebalanceAnalyzerServer *server.RebalanceAnalyzerServer,
+	consoleRecordingCleanerServer *server.ConsoleRecordingCleanerServer,
+	zfsHealthCollectorServer *server.ZFSHealthCollectorServer,
+	diskSMARTCollectorServer *server.DiskSMARTCollectorServer,
+	notificationCleanerServer *server.NotificationCleanerServer,
+	pendingOperationRetrierServer *server.PendingOperationRetrierServer,
+	agentInstallCheckerServer *server.AgentInstallCheckerServer,
+	securityScanServer *server.SecurityScanServer,
+	remoteMigrationSchedulerServer *server.RemoteMigrationSchedulerServer,
+	capacitySnapshotServer *server.CapacitySnapshotServer,
+	jobLeaseServer *server.JobLeaseServer,
+	clusterEventPollerServer *server.ClusterEventPollerServer,
+	vmNotesSyncServer *server.VMNotesSyncServer,
+	reportSchedulerServer *server.ReportSchedulerServer,
+	firstBootCheckerServer *server.FirstBootCheckerServer,
+	contentReplicationServer *server.ContentReplicationServer,
 
 ) *app.App {
-	return app.NewApp(app.WithServer(httpServer, jobServer), app.WithName("demo-server"))
+	return app.NewApp(app.WithServer(httpServer, jobServer, schemaServer, embeddedServer, configReloadServer, costCollectorServer, energyCollectorServer, catalogSyncServer, nodeVersionServer, licenseCollectorServer, vmClaimScannerServer, rebalanceAnalyzerServer, consoleRecordingCleanerServer, zfsHealthCollectorServer, diskSMARTCollectorServer, notificationCleanerServer, pendingOperationRetrierServer, agentInstallCheckerServer, securityScanServer, remoteMigrationSchedulerServer, capacitySnapshotServer, jobLeaseServer, clusterEventPollerServer, vmNotesSyncServer, reportSchedulerServer, firstBootCheckerServer, contentReplicationServer), app.WithName("demo-server"))
 }
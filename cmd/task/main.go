@@ -6,6 +6,7 @@ import (
 	"pvesphere/cmd/task/wire"
 	"pvesphere/pkg/config"
 	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
 )
 
 func main() {
@@ -14,6 +15,8 @@ func main() {
 	conf := config.NewConfig(*envConf)
 
 	logger := log.NewLog(conf)
+	proxmox.ConfigureRequestLog(logger.Logger, conf.GetBool("proxmox.api_log.enabled"))
+	proxmox.SetRequestTimeout(conf.GetDuration("proxmox.timeout"))
 	logger.Info("start task")
 	app, cleanup, err := wire.NewWire(conf, logger)
 	defer cleanup()
@@ -78,6 +78,13 @@ func (h *NodeEventHandler) OnAdd(obj interface{}) error {
 	node.Creator = ""
 	node.Modifier = ""
 
+	// 重新 List 时节点可能已存在，保留平台侧维护的字段
+	ctx := context.Background()
+	if existingNode, err := h.repo.GetByNodeName(ctx, node.NodeName, h.clusterID); err == nil && existingNode != nil {
+		node.CreateTime = existingNode.CreateTime
+		preserveNodeFields(node, existingNode)
+	}
+
 	// 计算资源 hash
 	resourceHash, err := hash.CalculateResourceHash(node)
 	if err != nil {
@@ -87,7 +94,6 @@ func (h *NodeEventHandler) OnAdd(obj interface{}) error {
 	node.ResourceHash = resourceHash
 	node.LastSyncTime = time.Now()
 
-	ctx := context.Background()
 	if err := h.repo.Upsert(ctx, node); err != nil {
 		h.logger.Error("failed to upsert node", zap.Error(err), zap.String("node", node.NodeName))
 		return err
@@ -111,6 +117,8 @@ func (h *NodeEventHandler) OnUpdate(oldObj, newObj interface{}) error {
 	existingNode, err := h.repo.GetByNodeName(ctx, node.NodeName, h.clusterID)
 	if err == nil && existingNode != nil {
 		node.Creator = existingNode.Creator // 保留已有的 Creator
+		node.CreateTime = existingNode.CreateTime
+		preserveNodeFields(node, existingNode)
 	}
 	node.Modifier = ""
 
@@ -132,6 +140,15 @@ func (h *NodeEventHandler) OnUpdate(oldObj, newObj interface{}) error {
 	return nil
 }
 
+// preserveNodeFields 保留平台侧维护的节点字段（Proxmox 侧没有这些信息，同步时不能覆盖）
+func preserveNodeFields(node, existing *model.PveNode) {
+	node.IsSchedulable = existing.IsSchedulable
+	node.Annotations = existing.Annotations
+	node.VMLimit = existing.VMLimit
+	node.WolMAC = existing.WolMAC
+	node.LastWakeTime = existing.LastWakeTime
+}
+
 func (h *NodeEventHandler) OnDelete(obj interface{}) error {
 	node, ok := obj.(*model.PveNode)
 	if !ok {
@@ -152,15 +169,17 @@ func (h *NodeEventHandler) OnDelete(obj interface{}) error {
 type VMEventHandler struct {
 	repo        repository.PveVMRepository
 	nodeRepo    repository.PveNodeRepository
+	eventRepo   repository.VMStatusEventRepository
 	logger      *log.Logger
 	clusterID   int64
 	clusterName string
 }
 
-func NewVMEventHandler(repo repository.PveVMRepository, nodeRepo repository.PveNodeRepository, logger *log.Logger, clusterID int64, clusterName string) *VMEventHandler {
+func NewVMEventHandler(repo repository.PveVMRepository, nodeRepo repository.PveNodeRepository, eventRepo repository.VMStatusEventRepository, logger *log.Logger, clusterID int64, clusterName string) *VMEventHandler {
 	return &VMEventHandler{
 		repo:        repo,
 		nodeRepo:    nodeRepo,
+		eventRepo:   eventRepo,
 		logger:      logger,
 		clusterID:   clusterID,
 		clusterName: clusterName,
@@ -238,6 +257,16 @@ func (h *VMEventHandler) OnAdd(obj interface{}) error {
 		return nil
 	}
 
+	// 重新 List 时虚拟机可能已存在，保留应用归属等元数据（Proxmox 侧没有该信息）
+	ctx := context.Background()
+	existingVM, err := h.repo.GetByVMID(ctx, vm.VMID, vm.NodeID)
+	if err == nil && existingVM != nil {
+		keepVMMetadata(vm, existingVM)
+		h.recordStatusChange(ctx, vm, existingVM)
+	} else if err == nil {
+		h.recordPresenceChange(ctx, vm, "", model.VMStatusEventAdded)
+	}
+
 	// 计算资源 hash
 	resourceHash, err := hash.CalculateResourceHash(vm)
 	if err != nil {
@@ -247,7 +276,6 @@ func (h *VMEventHandler) OnAdd(obj interface{}) error {
 	vm.ResourceHash = resourceHash
 	vm.LastSyncTime = time.Now()
 
-	ctx := context.Background()
 	if err := h.repo.Upsert(ctx, vm); err != nil {
 		h.logger.Error("failed to upsert vm", zap.Error(err), zap.Uint32("vmid", vm.VMID))
 		return err
@@ -329,6 +357,8 @@ func (h *VMEventHandler) OnUpdate(oldObj, newObj interface{}) error {
 	existingVM, err := h.repo.GetByVMID(ctx, vm.VMID, vm.NodeID)
 	if err == nil && existingVM != nil {
 		vm.Creator = existingVM.Creator // 保留已有的 Creator
+		keepVMMetadata(vm, existingVM)  // 保留应用归属等元数据（Proxmox 侧没有该信息，成本核算按应用汇总）
+		h.recordStatusChange(ctx, vm, existingVM)
 	}
 	vm.Modifier = ""
 
@@ -377,10 +407,45 @@ func (h *VMEventHandler) OnDelete(obj interface{}) error {
 		return err
 	}
 
+	h.recordPresenceChange(ctx, vm, existingVM.Status, model.VMStatusEventRemoved)
+
 	h.logger.Info("vm deleted", zap.String("vm", vm.VmName), zap.Uint32("vmid", vm.VMID), zap.String("node", vm.NodeName), zap.Int64("id", existingVM.Id))
 	return nil
 }
 
+// recordStatusChange 上报的状态与数据库中不同时记录状态变化，供虚拟机事件时间线使用；记录失败不影响同步
+func (h *VMEventHandler) recordStatusChange(ctx context.Context, vm, existing *model.PveVM) {
+	if existing.Status == "" || existing.Status == vm.Status {
+		return
+	}
+	event := &model.VMStatusEvent{
+		ClusterID: h.clusterID,
+		VMID:      vm.VMID,
+		VmName:    vm.VmName,
+		NodeName:  vm.NodeName,
+		OldStatus: existing.Status,
+		NewStatus: vm.Status,
+	}
+	if err := h.eventRepo.Create(ctx, event); err != nil {
+		h.logger.Error("failed to record vm status change", zap.Error(err), zap.Uint32("vmid", vm.VMID))
+	}
+}
+
+// recordPresenceChange 记录虚拟机出现在节点上或从节点上消失（删除或迁移走），供变化概览区分新建、删除和迁移；记录失败不影响同步
+func (h *VMEventHandler) recordPresenceChange(ctx context.Context, vm *model.PveVM, oldStatus, newStatus string) {
+	event := &model.VMStatusEvent{
+		ClusterID: h.clusterID,
+		VMID:      vm.VMID,
+		VmName:    vm.VmName,
+		NodeName:  vm.NodeName,
+		OldStatus: oldStatus,
+		NewStatus: newStatus,
+	}
+	if err := h.eventRepo.Create(ctx, event); err != nil {
+		h.logger.Error("failed to record vm presence change", zap.Error(err), zap.Uint32("vmid", vm.VMID))
+	}
+}
+
 // StorageEventHandler 存储事件处理器
 type StorageEventHandler struct {
 	repo      repository.PveStorageRepository
@@ -477,3 +542,23 @@ func (h *StorageEventHandler) OnDelete(obj interface{}) error {
 	h.logger.Info("storage deleted", zap.String("storage", storage.StorageName), zap.String("node", storage.NodeName))
 	return nil
 }
+
+// keepVMMetadata 保留只在平台维护的虚拟机元数据（应用、登录凭据、归属、环境、成本中心、复核日期、删除保护），避免被同步覆盖；
+// 虚拟机列表不含描述，描述由备注同步单独维护，这里同样保留
+func keepVMMetadata(vm, existing *model.PveVM) {
+	vm.Description = existing.Description
+	vm.AppId = existing.AppId
+	vm.VmUser = existing.VmUser
+	vm.VmPassword = existing.VmPassword
+	vm.VmPasswordRef = existing.VmPasswordRef
+	vm.Owner = existing.Owner
+	vm.Team = existing.Team
+	vm.Contact = existing.Contact
+	vm.BusinessService = existing.BusinessService
+	vm.Environment = existing.Environment
+	vm.CostCenter = existing.CostCenter
+	vm.ReviewDate = existing.ReviewDate
+	vm.Protected = existing.Protected
+	vm.ProtectedBy = existing.ProtectedBy
+	vm.ProtectedReason = existing.ProtectedReason
+}
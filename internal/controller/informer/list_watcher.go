@@ -164,6 +164,7 @@ func (w *VMListWatcher) List(ctx context.Context) ([]interface{}, error) {
 		Status   string `json:"status"`
 		Uptime   int64  `json:"uptime"`
 		Maxmem   int64  `json:"maxmem"`
+		Maxdisk  int64  `json:"maxdisk"`
 		Cpus     int    `json:"cpus"`
 		Template *int   `json:"template,omitempty"` // 模板标识：0=否, 1=是（如果字段存在）
 	}
@@ -207,7 +208,8 @@ func (w *VMListWatcher) List(ctx context.Context) ([]interface{}, error) {
 			ClusterName: w.clusterName,
 			Status:      v.Status,
 			CPUNum:      v.Cpus,
-			MemorySize:  int(v.Maxmem / 1024 / 1024), // 转换为 MB
+			MemorySize:  int(v.Maxmem / 1024 / 1024),  // 转换为 MB
+			DiskSize:    int(v.Maxdisk / 1024 / 1024), // 转换为 MB
 			IsTemplate:  isTemplate,
 		}
 
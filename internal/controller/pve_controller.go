@@ -20,12 +20,17 @@ type PveController struct {
 	nodeRepo     repository.PveNodeRepository
 	vmRepo       repository.PveVMRepository
 	storageRepo  repository.PveStorageRepository
+	eventRepo    repository.VMStatusEventRepository
 	logger       *log.Logger
 	informers    map[int64]*ClusterInformer
 	lock         sync.RWMutex
 	resyncPeriod time.Duration
+	lastCleanup  time.Time
 }
 
+// vmStatusEventRetention 虚拟机状态变化记录保留时长，每小时清理一次
+const vmStatusEventRetention = 90 * 24 * time.Hour
+
 type ClusterInformer struct {
 	Cluster          *model.PveCluster
 	Client           *proxmox.ProxmoxClient
@@ -41,6 +46,7 @@ func NewPveController(
 	nodeRepo repository.PveNodeRepository,
 	vmRepo repository.PveVMRepository,
 	storageRepo repository.PveStorageRepository,
+	eventRepo repository.VMStatusEventRepository,
 	logger *log.Logger,
 	resyncPeriod time.Duration,
 ) *PveController {
@@ -49,6 +55,7 @@ func NewPveController(
 		nodeRepo:     nodeRepo,
 		vmRepo:       vmRepo,
 		storageRepo:  storageRepo,
+		eventRepo:    eventRepo,
 		logger:       logger,
 		informers:    make(map[int64]*ClusterInformer),
 		resyncPeriod: resyncPeriod,
@@ -81,6 +88,7 @@ func (c *PveController) Start(ctx context.Context) error {
 			return ctx.Err()
 		case <-ticker.C:
 			c.syncClusters(ctx)
+			c.cleanupStatusEvents(ctx)
 		}
 	}
 }
@@ -108,6 +116,22 @@ func (c *PveController) Stop(ctx context.Context) error {
 	return nil
 }
 
+// cleanupStatusEvents 删除超过保留时长的虚拟机状态变化记录
+func (c *PveController) cleanupStatusEvents(ctx context.Context) {
+	if time.Since(c.lastCleanup) < time.Hour {
+		return
+	}
+	c.lastCleanup = time.Now()
+	removed, err := c.eventRepo.DeleteBefore(ctx, time.Now().Add(-vmStatusEventRetention))
+	if err != nil {
+		c.logger.Error("cleanup vm status events failed", zap.Error(err))
+		return
+	}
+	if removed > 0 {
+		c.logger.Info("expired vm status events removed", zap.Int64("count", removed))
+	}
+}
+
 func (c *PveController) syncClusters(ctx context.Context) {
 	// 加载所有启用的集群（is_enabled = 1 用于数据自动上报）
 	clusters, err := c.clusterRepo.GetAllEnabled(ctx)
@@ -179,7 +203,7 @@ func (c *PveController) startClusterInformer(ctx context.Context, cluster *model
 	c.lock.RUnlock()
 
 	// 在锁外创建客户端和 context（避免阻塞）
-	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		return fmt.Errorf("failed to create proxmox client: %w", err)
 	}
@@ -302,7 +326,7 @@ func (c *PveController) startVMAndStorageInformers(inf *ClusterInformer) {
 			c.resyncPeriod,
 		)
 
-		vmHandler := NewVMEventHandler(c.vmRepo, c.nodeRepo, c.logger, inf.Cluster.Id, inf.Cluster.ClusterName)
+		vmHandler := NewVMEventHandler(c.vmRepo, c.nodeRepo, c.eventRepo, c.logger, inf.Cluster.Id, inf.Cluster.ClusterName)
 		vmInf.AddEventHandler(vmHandler)
 
 		inf.VMInformers[node.NodeName] = vmInf
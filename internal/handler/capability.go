@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type CapabilityHandler struct {
+	*Handler
+	capabilityService service.CapabilityService
+}
+
+func NewCapabilityHandler(handler *Handler, capabilityService service.CapabilityService) *CapabilityHandler {
+	return &CapabilityHandler{
+		Handler:           handler,
+		capabilityService: capabilityService,
+	}
+}
+
+func capabilityErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetMyCapabilities godoc
+// @Summary 获取当前用户的操作权限
+// @Description 综合管理员名单、虚拟机负责人、删除保护、维护锁和集群能力矩阵，返回全局、各集群以及 vm_ids 中虚拟机上每个操作是否可用及不可用的原因。
+// @Description 前端据此隐藏或禁用按钮；结果只是提示，各接口仍会各自校验
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "只返回该集群"
+// @Param vm_ids query []int false "虚拟机ID（pve_vm 表 ID），最多 100 个" collectionFormat(multi)
+// @Success 200 {object} v1.MyCapabilitiesResponse
+// @Router /api/v1/me/capabilities [get]
+func (h *CapabilityHandler) GetMyCapabilities(ctx *gin.Context) {
+	req := new(v1.GetMyCapabilitiesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.capabilityService.GetMine(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("capabilityService.GetMine error", zap.Error(err))
+		v1.HandleError(ctx, capabilityErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
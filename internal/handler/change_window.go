@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ChangeWindowHandler struct {
+	*Handler
+	changeControlService service.ChangeControlService
+}
+
+func NewChangeWindowHandler(handler *Handler, changeControlService service.ChangeControlService) *ChangeWindowHandler {
+	return &ChangeWindowHandler{
+		Handler:              handler,
+		changeControlService: changeControlService,
+	}
+}
+
+// changeErrorStatus 变更被维护窗口/封网期或删除保护拦截时返回 403，其余错误返回 fallback
+func changeErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, v1.ErrChangeFrozen),
+		errors.Is(err, v1.ErrOutsideMaintenanceWindow),
+		errors.Is(err, v1.ErrChangeOverrideForbidden),
+		errors.Is(err, v1.ErrVMProtected):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMLocked):
+		return http.StatusLocked
+	case errors.Is(err, v1.ErrVMProxmoxLocked):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrOperationQueued):
+		return http.StatusAccepted
+	default:
+		return fallback
+	}
+}
+
+func changeWindowErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidChangeWindow), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateChangeWindow godoc
+// @Summary 创建变更窗口
+// @Description 仅管理员可操作。kind=maintenance 为维护窗口：存在维护窗口时，对应集群/应用的变更只能在窗口内执行；
+// @Description kind=freeze 为封网期：窗口内禁止一切变更。cluster_id 为 0 表示全部集群，app_id 为空表示全部应用。
+// @Tags 变更管控模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateChangeWindowRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/change-windows [post]
+func (h *ChangeWindowHandler) CreateChangeWindow(ctx *gin.Context) {
+	req := new(v1.CreateChangeWindowRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.changeControlService.CreateWindow(ctx, GetUserIdFromCtx(ctx), req); err != nil {
+		h.logger.WithContext(ctx).Error("changeControlService.CreateWindow error", zap.Error(err))
+		v1.HandleError(ctx, changeWindowErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdateChangeWindow godoc
+// @Summary 更新变更窗口
+// @Description 仅管理员可操作，整体替换窗口的作用范围和时间规则
+// @Tags 变更管控模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "变更窗口ID"
+// @Param request body v1.UpdateChangeWindowRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/change-windows/{id} [put]
+func (h *ChangeWindowHandler) UpdateChangeWindow(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.UpdateChangeWindowRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.changeControlService.UpdateWindow(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("changeControlService.UpdateWindow error", zap.Error(err))
+		v1.HandleError(ctx, changeWindowErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteChangeWindow godoc
+// @Summary 删除变更窗口
+// @Description 仅管理员可操作
+// @Tags 变更管控模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "变更窗口ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/change-windows/{id} [delete]
+func (h *ChangeWindowHandler) DeleteChangeWindow(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.changeControlService.DeleteWindow(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("changeControlService.DeleteWindow error", zap.Error(err))
+		v1.HandleError(ctx, changeWindowErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetChangeWindow godoc
+// @Summary 获取变更窗口详情
+// @Tags 变更管控模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "变更窗口ID"
+// @Success 200 {object} v1.GetChangeWindowResponse
+// @Router /api/v1/change-windows/{id} [get]
+func (h *ChangeWindowHandler) GetChangeWindow(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.changeControlService.GetWindow(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("changeControlService.GetWindow error", zap.Error(err))
+		v1.HandleError(ctx, changeWindowErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListChangeWindows godoc
+// @Summary 获取变更窗口列表
+// @Tags 变更管控模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param cluster_id query int false "集群ID（包含全局窗口）"
+// @Param kind query string false "类型：maintenance / freeze"
+// @Success 200 {object} v1.ListChangeWindowsResponse
+// @Router /api/v1/change-windows [get]
+func (h *ChangeWindowHandler) ListChangeWindows(ctx *gin.Context) {
+	req := new(v1.ListChangeWindowsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.changeControlService.ListWindows(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("changeControlService.ListWindows error", zap.Error(err))
+		v1.HandleError(ctx, changeWindowErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetChangeStatus godoc
+// @Summary 查询当前是否允许变更
+// @Description 按集群/应用计算当前生效的维护窗口和封网期，返回是否允许变更及原因
+// @Tags 变更管控模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int true "集群ID"
+// @Param app_id query string false "应用ID"
+// @Success 200 {object} v1.GetChangeStatusResponse
+// @Router /api/v1/change-windows/status [get]
+func (h *ChangeWindowHandler) GetChangeStatus(ctx *gin.Context) {
+	req := new(v1.GetChangeStatusRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.changeControlService.GetStatus(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("changeControlService.GetStatus error", zap.Error(err))
+		v1.HandleError(ctx, changeWindowErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListChangeOverrides godoc
+// @Summary 获取紧急放行审计记录
+// @Description 管理员通过 X-Change-Override 请求头在封网期/维护窗口外执行变更时产生的审计记录
+// @Tags 变更管控模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param cluster_id query int false "集群ID"
+// @Success 200 {object} v1.ListChangeOverridesResponse
+// @Router /api/v1/change-windows/overrides [get]
+func (h *ChangeWindowHandler) ListChangeOverrides(ctx *gin.Context) {
+	req := new(v1.ListChangeOverridesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.changeControlService.ListOverrides(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("changeControlService.ListOverrides error", zap.Error(err))
+		v1.HandleError(ctx, changeWindowErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
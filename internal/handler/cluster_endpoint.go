@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ClusterEndpointHandler struct {
+	*Handler
+	endpointService service.ClusterEndpointService
+}
+
+func NewClusterEndpointHandler(handler *Handler, endpointService service.ClusterEndpointService) *ClusterEndpointHandler {
+	return &ClusterEndpointHandler{
+		Handler:         handler,
+		endpointService: endpointService,
+	}
+}
+
+func clusterEndpointErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrClusterEndpointCheckFailed):
+		return http.StatusBadRequest
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// VerifyClusterEndpoint godoc
+// @Summary 预检集群接入信息
+// @Description 使用新的 API 地址 / Token 检查连通性、是否仍为同一集群、节点证书指纹变化、Token 权限、存储和任务列表，不保存任何修改；未填写的字段沿用当前配置
+// @Tags PVE集群模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "集群ID"
+// @Param request body v1.UpdateClusterEndpointRequest true "params"
+// @Success 200 {object} v1.ClusterEndpointResponse
+// @Router /api/v1/clusters/{id}/endpoint/verify [post]
+func (h *ClusterEndpointHandler) VerifyClusterEndpoint(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateClusterEndpointRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.endpointService.Verify(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("endpointService.Verify error", zap.Error(err))
+		v1.HandleError(ctx, clusterEndpointErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateClusterEndpoint godoc
+// @Summary 更新集群接入信息
+// @Description 执行与预检相同的检查，连通性和集群身份检查通过后保存新的 API 地址 / Token 和节点证书指纹，并重新探测能力矩阵；检查未通过时返回检查结果且不保存。请求体为空时仅重新验证并刷新证书指纹（节点证书续期后使用）
+// @Tags PVE集群模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "集群ID"
+// @Param request body v1.UpdateClusterEndpointRequest true "params"
+// @Success 200 {object} v1.ClusterEndpointResponse
+// @Router /api/v1/clusters/{id}/endpoint [put]
+func (h *ClusterEndpointHandler) UpdateClusterEndpoint(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateClusterEndpointRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.endpointService.Apply(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("endpointService.Apply error", zap.Error(err))
+		v1.HandleError(ctx, clusterEndpointErrorStatus(err), err, data)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListClusterCertificates godoc
+// @Summary 查询节点证书指纹
+// @Description 返回保存的各节点 pve-ssl.pem 证书指纹，跨集群迁移到该集群时用作 target-endpoint 的 fingerprint
+// @Tags PVE集群模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "集群ID"
+// @Success 200 {object} v1.ListClusterCertificatesResponse
+// @Router /api/v1/clusters/{id}/certificates [get]
+func (h *ClusterEndpointHandler) ListClusterCertificates(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.endpointService.ListCertificates(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("endpointService.ListCertificates error", zap.Error(err))
+		v1.HandleError(ctx, clusterEndpointErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ClusterEventHandler struct {
+	*Handler
+	eventService service.ClusterEventService
+}
+
+func NewClusterEventHandler(handler *Handler, eventService service.ClusterEventService) *ClusterEventHandler {
+	return &ClusterEventHandler{
+		Handler:      handler,
+		eventService: eventService,
+	}
+}
+
+// ListEvents godoc
+// @Summary 查询集群外部变更
+// @Description 列出在 PveSphere 之外（Proxmox 网页、qm 命令行、其他 API 用户）对集群所做的变更，按事件时间倒序；
+// @Description 事件由后台轮询 Proxmox 任务列表和节点 journal 得到，集群配置的 API 用户发起的操作不计入
+// @Tags 集群事件模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param cluster_id query int false "集群ID"
+// @Param vmid query int false "虚拟机 VMID"
+// @Param type query string false "事件类型: vm_created、vm_cloned、vm_restored、vm_deleted、vm_migrated、vm_started、vm_stopped、vm_config_changed"
+// @Param since query string false "起始时间（RFC3339）"
+// @Success 200 {object} v1.ListClusterEventsResponse
+// @Router /api/v1/cluster-events [get]
+func (h *ClusterEventHandler) ListEvents(ctx *gin.Context) {
+	req := new(v1.ListClusterEventsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.eventService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("eventService.List error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
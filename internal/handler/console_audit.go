@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ConsoleAuditHandler struct {
+	*Handler
+	auditService service.ConsoleAuditService
+	proxyService service.ConsoleProxyService
+}
+
+func NewConsoleAuditHandler(handler *Handler, auditService service.ConsoleAuditService, proxyService service.ConsoleProxyService) *ConsoleAuditHandler {
+	return &ConsoleAuditHandler{
+		Handler:      handler,
+		auditService: auditService,
+		proxyService: proxyService,
+	}
+}
+
+func consoleAuditErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrConsoleSessionNotFound), errors.Is(err, v1.ErrConsoleRecordingUnavailable),
+		errors.Is(err, v1.ErrObjectStoreNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrObjectStoreUnreachable):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// GetProxyMetrics godoc
+// @Summary 控制台代理运行指标
+// @Description 当前实例的控制台 websocket 代理指标：当前 / 峰值连接数、被并发上限拒绝和空闲超时关闭的连接数、双向流量，以及 console.proxy.* 配置，需要管理员权限
+// @Tags 控制台审计模块
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.GetConsoleProxyMetricsResponse
+// @Router /api/v1/console-sessions/metrics [get]
+func (h *ConsoleAuditHandler) GetProxyMetrics(ctx *gin.Context) {
+	data, err := h.proxyService.GetMetrics(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("proxyService.GetMetrics error", zap.Error(err))
+		v1.HandleError(ctx, consoleAuditErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListSessions godoc
+// @Summary 控制台会话审计列表
+// @Description 查询经由平台建立的虚拟机 / 节点控制台会话（操作人、目标、来源 IP、起止时间、流量及录制状态），需要管理员权限
+// @Tags 控制台审计模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param user_id query string false "用户ID"
+// @Param target_type query string false "目标类型：vm / node"
+// @Param target_id query int false "虚拟机或节点ID"
+// @Param cluster_id query int false "集群ID"
+// @Param recording_status query string false "录制状态：none / recording / uploaded / failed / expired"
+// @Param start_from query string false "开始时间下限（RFC3339）"
+// @Param start_to query string false "开始时间上限（RFC3339）"
+// @Success 200 {object} v1.ListConsoleSessionsResponse
+// @Router /api/v1/console-sessions [get]
+func (h *ConsoleAuditHandler) ListSessions(ctx *gin.Context) {
+	req := new(v1.ListConsoleSessionsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.auditService.ListSessions(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("auditService.ListSessions error", zap.Error(err))
+		v1.HandleError(ctx, consoleAuditErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetSession godoc
+// @Summary 控制台会话审计详情
+// @Tags 控制台审计模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "会话审计记录ID"
+// @Success 200 {object} v1.GetConsoleSessionResponse
+// @Router /api/v1/console-sessions/{id} [get]
+func (h *ConsoleAuditHandler) GetSession(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.auditService.GetSession(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		v1.HandleError(ctx, consoleAuditErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DownloadRecording godoc
+// @Summary 下载控制台会话录像
+// @Description 从对象存储读取会话录像（仅 Proxmox -> 浏览器方向的帧，不含键盘输入）。文件以 PVSCREC1 开头，之后每帧为 8 字节毫秒偏移 + 4 字节长度 + 原始 websocket 消息（均为大端）
+// @Tags 控制台审计模块
+// @Produce octet-stream
+// @Security Bearer
+// @Param id path int true "会话审计记录ID"
+// @Success 200 {file} binary
+// @Router /api/v1/console-sessions/{id}/recording [get]
+func (h *ConsoleAuditHandler) DownloadRecording(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	body, session, err := h.auditService.GetRecording(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("auditService.GetRecording error", zap.Error(err))
+		v1.HandleError(ctx, consoleAuditErrorStatus(err), err, nil)
+		return
+	}
+	defer body.Close()
+
+	ctx.DataFromReader(http.StatusOK, session.RecordingSize, "application/octet-stream", body, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=console-session-%d.pvrec", session.Id),
+	})
+}
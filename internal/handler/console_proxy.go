@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// consolePingInterval 控制台代理向两端发送 ping 的间隔，避免空闲连接被负载均衡或防火墙断开
+	consolePingInterval = 30 * time.Second
+	// consolePongWait 超过该时间未收到任何消息（含 pong）即认为对端已断开
+	consolePongWait = 75 * time.Second
+	// consoleWriteWait 控制帧写超时
+	consoleWriteWait = 10 * time.Second
+)
+
+// consoleWSURL 组装同域 websocket 连接地址，兼容反向代理的 X-Forwarded-Proto / X-Forwarded-Host
+func consoleWSURL(ctx *gin.Context, path, token string) string {
+	scheme := "ws"
+	proto := ctx.Request.Header.Get("X-Forwarded-Proto")
+	if proto == "https" || proto == "wss" {
+		scheme = "wss"
+	} else if ctx.Request.TLS != nil {
+		scheme = "wss"
+	}
+
+	host := ctx.Request.Host
+	if xfHost := ctx.Request.Header.Get("X-Forwarded-Host"); xfHost != "" {
+		host = xfHost
+	}
+
+	return fmt.Sprintf("%s://%s%s?token=%s", scheme, host, path, url.QueryEscape(token))
+}
+
+// upgradeConsoleWebsocket 占用连接名额后升级浏览器连接。超过并发上限时直接返回 429（不消耗 ws_token）；
+// 升级成功时由调用方在连接结束后调用 release
+func upgradeConsoleWebsocket(ctx *gin.Context, consoleProxy service.ConsoleProxyService) (*websocket.Conn, func(), error) {
+	release, err := consoleProxy.Acquire(ctx.ClientIP())
+	if err != nil {
+		v1.HandleError(ctx, http.StatusTooManyRequests, err, nil)
+		return nil, nil, err
+	}
+	opts := consoleProxy.Options()
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  opts.BufferSize,
+		WriteBufferSize: opts.BufferSize,
+		WriteBufferPool: consoleWriteBufferPool,
+		CheckOrigin:     func(r *http.Request) bool { return true },
+	}
+	conn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		release()
+		return nil, nil, err
+	}
+	return conn, release, nil
+}
+
+// consoleWriteBufferPool 控制台连接共用的写缓冲区，连接只在写消息期间持有缓冲区，空闲连接不占用
+var consoleWriteBufferPool = &sync.Pool{}
+
+// consoleMessagePool 转发消息用的缓冲区。超过 consoleMessagePoolMax 的缓冲区不放回，避免偶尔的大帧长期占用内存
+var consoleMessagePool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+const consoleMessagePoolMax = 1 << 20
+
+// errConsoleIdleTimeout 浏览器超过 console.proxy.idle_timeout 没有发送任何消息
+var errConsoleIdleTimeout = errors.New("idle timeout")
+
+// proxyConsoleWebsocket 在浏览器与 Proxmox 之间双向转发消息，直到任一端断开。
+// 两端都定期发送 ping，收到任何消息（含 pong）时顺延读超时，及时发现半开连接；
+// 浏览器超过空闲超时没有发送消息时断开（按 ping 间隔检查）；单条消息超过 read_limit 时断开。
+// 转发的消息同时交给 recorder 做会话审计（浏览器 -> Proxmox 方向只计数）。
+// clientTransform 不为空时，浏览器发来的消息经其转换后再发给 Proxmox，返回 nil 表示丢弃该消息
+func proxyConsoleWebsocket(consoleProxy service.ConsoleProxyService, clientConn, proxmoxConn *websocket.Conn, recorder *service.ConsoleRecorder, clientTransform func([]byte) []byte) error {
+	opts := consoleProxy.Options()
+	conns := []*websocket.Conn{clientConn, proxmoxConn}
+	for _, conn := range conns {
+		conn := conn
+		conn.SetReadLimit(opts.ReadLimit)
+		_ = conn.SetReadDeadline(time.Now().Add(consolePongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(consolePongWait))
+		})
+	}
+
+	errCh := make(chan error, 3)
+	done := make(chan struct{})
+	defer close(done)
+
+	var lastInput atomic.Int64
+	lastInput.Store(time.Now().UnixNano())
+
+	proxy := func(src, dst *websocket.Conn, observe func([]byte), record func(int), transform func([]byte) []byte) {
+		for {
+			mt, r, err := src.NextReader()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			buf := consoleMessagePool.Get().(*bytes.Buffer)
+			buf.Reset()
+			if _, err := buf.ReadFrom(r); err != nil {
+				putConsoleMessage(buf)
+				errCh <- err
+				return
+			}
+			_ = src.SetReadDeadline(time.Now().Add(consolePongWait))
+			msg := buf.Bytes()
+			observe(msg)
+			record(len(msg))
+			if src == clientConn {
+				lastInput.Store(time.Now().UnixNano())
+			}
+			if transform != nil {
+				if msg = transform(msg); msg == nil {
+					putConsoleMessage(buf)
+					continue
+				}
+			}
+			err = dst.WriteMessage(mt, msg)
+			putConsoleMessage(buf)
+			if err != nil {
+				errCh <- err
+				return
+			}
+		}
+	}
+
+	go proxy(clientConn, proxmoxConn, recorder.ClientMessage, consoleProxy.RecordClientMessage, clientTransform)
+	go proxy(proxmoxConn, clientConn, recorder.ServerMessage, consoleProxy.RecordServerMessage, nil)
+	// 空闲超时较短时按其一半的间隔检查（同时发送 ping）
+	interval := consolePingInterval
+	if opts.IdleTimeout > 0 && opts.IdleTimeout/2 < interval {
+		interval = opts.IdleTimeout / 2
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if opts.IdleTimeout > 0 && time.Since(time.Unix(0, lastInput.Load())) > opts.IdleTimeout {
+					consoleProxy.RecordIdleTimeout()
+					_ = clientConn.WriteControl(websocket.CloseMessage,
+						websocket.FormatCloseMessage(websocket.CloseNormalClosure, errConsoleIdleTimeout.Error()),
+						time.Now().Add(consoleWriteWait))
+					errCh <- errConsoleIdleTimeout
+					return
+				}
+				for _, conn := range conns {
+					// WriteControl 可与 WriteMessage 并发调用
+					if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(consoleWriteWait)); err != nil {
+						errCh <- err
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	return <-errCh
+}
+
+func putConsoleMessage(buf *bytes.Buffer) {
+	if buf.Cap() <= consoleMessagePoolMax {
+		consoleMessagePool.Put(buf)
+	}
+}
+
+// consoleCloseReason 将代理结束的原因转换为审计记录中的关闭原因
+func consoleCloseReason(err error) string {
+	var closeErr *websocket.CloseError
+	if errors.As(err, &closeErr) {
+		return fmt.Sprintf("closed: %d %s", closeErr.Code, closeErr.Text)
+	}
+	if err == nil {
+		return "closed"
+	}
+	return err.Error()
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ContentReplicationHandler struct {
+	*Handler
+	replicationService service.ContentReplicationService
+}
+
+func NewContentReplicationHandler(handler *Handler, replicationService service.ContentReplicationService) *ContentReplicationHandler {
+	return &ContentReplicationHandler{
+		Handler:            handler,
+		replicationService: replicationService,
+	}
+}
+
+func contentReplicationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrContentReplicationPolicyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrContentReplicationPolicyInvalid), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrContentReplicationPolicyExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateContentReplicationPolicy godoc
+// @Summary 创建存储内容复制策略
+// @Description 仅管理员可操作。源存储上的 ISO / 容器模板（可按文件名通配符过滤）必须同样存在于目标存储：
+// @Description 共享目标存储检查一份，节点本地目标存储检查每个拥有该存储的节点。auto_copy 时定期对账并复制缺少的文件
+// @Tags PVE存储模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateContentReplicationPolicyRequest true "params"
+// @Success 200 {object} v1.ContentReplicationPolicyResponse
+// @Router /api/v1/content-replication-policies [post]
+func (h *ContentReplicationHandler) CreateContentReplicationPolicy(ctx *gin.Context) {
+	req := new(v1.CreateContentReplicationPolicyRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.replicationService.CreatePolicy(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("replicationService.CreatePolicy error", zap.Error(err))
+		v1.HandleError(ctx, contentReplicationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListContentReplicationPolicies godoc
+// @Summary 存储内容复制策略列表
+// @Tags PVE存储模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ListContentReplicationPoliciesRequest false "params"
+// @Success 200 {object} v1.ListContentReplicationPoliciesResponse
+// @Router /api/v1/content-replication-policies [get]
+func (h *ContentReplicationHandler) ListContentReplicationPolicies(ctx *gin.Context) {
+	req := new(v1.ListContentReplicationPoliciesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.replicationService.ListPolicies(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("replicationService.ListPolicies error", zap.Error(err))
+		v1.HandleError(ctx, contentReplicationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetContentReplicationPolicy godoc
+// @Summary 存储内容复制策略详情
+// @Tags PVE存储模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} v1.ContentReplicationPolicyResponse
+// @Router /api/v1/content-replication-policies/{id} [get]
+func (h *ContentReplicationHandler) GetContentReplicationPolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.replicationService.GetPolicy(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("replicationService.GetPolicy error", zap.Error(err))
+		v1.HandleError(ctx, contentReplicationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateContentReplicationPolicy godoc
+// @Summary 修改存储内容复制策略
+// @Description 仅管理员可操作，未传的字段保持不变；修改源或目标后之前的对账结果清空
+// @Tags PVE存储模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Param request body v1.UpdateContentReplicationPolicyRequest true "params"
+// @Success 200 {object} v1.ContentReplicationPolicyResponse
+// @Router /api/v1/content-replication-policies/{id} [put]
+func (h *ContentReplicationHandler) UpdateContentReplicationPolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateContentReplicationPolicyRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.replicationService.UpdatePolicy(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("replicationService.UpdatePolicy error", zap.Error(err))
+		v1.HandleError(ctx, contentReplicationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteContentReplicationPolicy godoc
+// @Summary 删除存储内容复制策略
+// @Description 仅管理员可操作，已复制的文件保留
+// @Tags PVE存储模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/content-replication-policies/{id} [delete]
+func (h *ContentReplicationHandler) DeleteContentReplicationPolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.replicationService.DeletePolicy(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("replicationService.DeletePolicy error", zap.Error(err))
+		v1.HandleError(ctx, contentReplicationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetContentReplicationDivergence godoc
+// @Summary 存储内容复制策略的差异
+// @Description 立即对账，返回各目标存储缺少的文件，不复制
+// @Tags PVE存储模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} v1.ContentReplicationReportResponse
+// @Router /api/v1/content-replication-policies/{id}/divergence [get]
+func (h *ContentReplicationHandler) GetContentReplicationDivergence(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.replicationService.Check(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("replicationService.Check error", zap.Error(err))
+		v1.HandleError(ctx, contentReplicationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ReconcileContentReplicationPolicy godoc
+// @Summary 立即对账并复制缺少的文件
+// @Description 仅管理员可操作，不受策略的 auto_copy 限制。复制在后台进行，可通过复制任务列表查看进度
+// @Tags PVE存储模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} v1.ContentReplicationReportResponse
+// @Router /api/v1/content-replication-policies/{id}/reconcile [post]
+func (h *ContentReplicationHandler) ReconcileContentReplicationPolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.replicationService.Reconcile(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("replicationService.Reconcile error", zap.Error(err))
+		v1.HandleError(ctx, contentReplicationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListContentReplicationCopies godoc
+// @Summary 存储内容复制任务列表
+// @Tags PVE存储模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ListContentReplicationCopiesRequest false "params"
+// @Success 200 {object} v1.ListContentReplicationCopiesResponse
+// @Router /api/v1/content-replication-copies [get]
+func (h *ContentReplicationHandler) ListContentReplicationCopies(ctx *gin.Context) {
+	req := new(v1.ListContentReplicationCopiesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.replicationService.ListCopies(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("replicationService.ListCopies error", zap.Error(err))
+		v1.HandleError(ctx, contentReplicationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type CostHandler struct {
+	*Handler
+	costService service.CostService
+}
+
+func NewCostHandler(handler *Handler, costService service.CostService) *CostHandler {
+	return &CostHandler{
+		Handler:     handler,
+		costService: costService,
+	}
+}
+
+func costErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrCostBudgetExists):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrInvalidReportMonth), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListPrices godoc
+// @Summary 获取价格模型列表
+// @Description cluster_id 为 0 的价格模型为默认价格，集群未单独配置价格时使用
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListCostPricesResponse
+// @Router /api/v1/costs/prices [get]
+func (h *CostHandler) ListPrices(ctx *gin.Context) {
+	data, err := h.costService.ListPrices(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("costService.ListPrices error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// SetPrice godoc
+// @Summary 设置价格模型
+// @Description 仅管理员可操作。按 cluster_id 新增或覆盖价格模型（每 vCPU·小时、每 GB 内存·小时、每 GB 存储·月），cluster_id 为 0 表示默认价格。
+// @Description 价格修改对整月生效：报表按查询时的价格重新计算。
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.SetCostPriceRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/costs/prices [put]
+func (h *CostHandler) SetPrice(ctx *gin.Context) {
+	req := new(v1.SetCostPriceRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.costService.SetPrice(ctx, GetUserIdFromCtx(ctx), req); err != nil {
+		h.logger.WithContext(ctx).Error("costService.SetPrice error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeletePrice godoc
+// @Summary 删除价格模型
+// @Description 仅管理员可操作，删除后该集群使用默认价格
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "价格模型ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/costs/prices/{id} [delete]
+func (h *CostHandler) DeletePrice(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.costService.DeletePrice(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("costService.DeletePrice error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetReport godoc
+// @Summary 获取月度成本报表
+// @Description 按应用（默认）或集群汇总指定月份的资源用量和费用，按费用从高到低排序。
+// @Description vCPU 和内存仅在虚拟机运行时计量，存储按已分配磁盘容量计量。
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param month query string true "月份 YYYY-MM"
+// @Param cluster_id query int false "集群ID"
+// @Param app_id query string false "应用ID"
+// @Param cost_center query string false "成本中心"
+// @Param group_by query string false "分组方式：app / cluster / cost_center" default(app)
+// @Success 200 {object} v1.GetCostReportResponse
+// @Router /api/v1/costs/report [get]
+func (h *CostHandler) GetReport(ctx *gin.Context) {
+	req := new(v1.GetCostReportRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.costService.GetReport(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("costService.GetReport error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ExportReport godoc
+// @Summary 导出月度成本报表（CSV）
+// @Description 参数与月度成本报表一致，返回 CSV 文件
+// @Tags 成本核算模块
+// @Produce text/csv
+// @Security Bearer
+// @Param month query string true "月份 YYYY-MM"
+// @Param cluster_id query int false "集群ID"
+// @Param app_id query string false "应用ID"
+// @Param cost_center query string false "成本中心"
+// @Param group_by query string false "分组方式：app / cluster / cost_center" default(app)
+// @Success 200 {file} file
+// @Router /api/v1/costs/report/export [get]
+func (h *CostHandler) ExportReport(ctx *gin.Context) {
+	req := new(v1.GetCostReportRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.costService.ExportReportCSV(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("costService.ExportReportCSV error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=cost-report-%s.csv", req.Month))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
+
+// ListBudgets godoc
+// @Summary 获取应用预算列表
+// @Description 返回各应用月度预算及本月截至目前的费用和使用百分比
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListCostBudgetsResponse
+// @Router /api/v1/costs/budgets [get]
+func (h *CostHandler) ListBudgets(ctx *gin.Context) {
+	data, err := h.costService.ListBudgets(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("costService.ListBudgets error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CreateBudget godoc
+// @Summary 创建应用预算
+// @Description 仅管理员可操作。本月费用达到 alert_threshold（预算百分比）或超出预算时产生告警，每月每个级别只告警一次
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateCostBudgetRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/costs/budgets [post]
+func (h *CostHandler) CreateBudget(ctx *gin.Context) {
+	req := new(v1.CreateCostBudgetRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.costService.CreateBudget(ctx, GetUserIdFromCtx(ctx), req); err != nil {
+		h.logger.WithContext(ctx).Error("costService.CreateBudget error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdateBudget godoc
+// @Summary 更新应用预算
+// @Description 仅管理员可操作
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "预算ID"
+// @Param request body v1.UpdateCostBudgetRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/costs/budgets/{id} [put]
+func (h *CostHandler) UpdateBudget(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.UpdateCostBudgetRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.costService.UpdateBudget(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("costService.UpdateBudget error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteBudget godoc
+// @Summary 删除应用预算
+// @Description 仅管理员可操作，已产生的告警记录保留
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "预算ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/costs/budgets/{id} [delete]
+func (h *CostHandler) DeleteBudget(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.costService.DeleteBudget(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("costService.DeleteBudget error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListAlerts godoc
+// @Summary 获取预算告警记录
+// @Tags 成本核算模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param month query string false "月份 YYYY-MM"
+// @Param app_id query string false "应用ID"
+// @Success 200 {object} v1.ListCostAlertsResponse
+// @Router /api/v1/costs/alerts [get]
+func (h *CostHandler) ListAlerts(ctx *gin.Context) {
+	req := new(v1.ListCostAlertsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.costService.ListAlerts(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("costService.ListAlerts error", zap.Error(err))
+		v1.HandleError(ctx, costErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	v1 "pvesphere/api/v1"
@@ -22,8 +23,21 @@ func NewDashboardHandler(handler *Handler, dashboardService service.DashboardSer
 	}
 }
 
+func dashboardErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrNotFound), errors.Is(err, v1.ErrSiteNotFound), errors.Is(err, v1.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidParameter):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 // GetScopes godoc
-// @Summary 获取可选集群列表
+// @Summary 获取可选集群及站点列表
 // @Tags Dashboard模块
 // @Accept json
 // @Produce json
@@ -41,20 +55,41 @@ func (h *DashboardHandler) GetScopes(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, data)
 }
 
+// GetSites godoc
+// @Summary 按站点分组获取概览
+// @Description 返回每个站点的集群、节点、虚拟机、存储数量及健康状态，未分配站点的集群归入 site_id 为 0 的分组
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.DashboardSitesResponse
+// @Router /api/v1/dashboard/sites [get]
+func (h *DashboardHandler) GetSites(ctx *gin.Context) {
+	data, err := h.dashboardService.GetSites(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("dashboardService.GetSites error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // GetOverview godoc
 // @Summary 获取全局概览
 // @Tags Dashboard模块
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Param scope query string false "范围: all 或 cluster" default(all)
+// @Param scope query string false "范围: all、site 或 cluster" default(all)
 // @Param cluster_id query int false "集群ID（当 scope 为 cluster 时使用）"
+// @Param site_id query int false "站点ID（当 scope 为 site 时使用）"
 // @Success 200 {object} v1.DashboardOverviewResponse
 // @Router /api/v1/dashboard/overview [get]
 func (h *DashboardHandler) GetOverview(ctx *gin.Context) {
 	req := new(v1.DashboardOverviewRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -79,14 +114,20 @@ func (h *DashboardHandler) GetOverview(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Param scope query string false "范围: all 或 cluster" default(all)
+// @Param scope query string false "范围: all、site 或 cluster" default(all)
 // @Param cluster_id query int false "集群ID（当 scope 为 cluster 时使用）"
+// @Param site_id query int false "站点ID（当 scope 为 site 时使用）"
+// @Param app_id query string false "项目（应用ID），只统计该项目的虚拟机"
+// @Param team query string false "团队，只统计该团队的虚拟机"
+// @Param tag query string false "Proxmox 标签，只统计带该标签的虚拟机"
+// @Param environment query string false "环境: prod、stage 或 dev"
+// @Param node_id query int false "节点ID，只统计该节点"
 // @Success 200 {object} v1.DashboardResourcesResponse
 // @Router /api/v1/dashboard/resources [get]
 func (h *DashboardHandler) GetResources(ctx *gin.Context) {
 	req := new(v1.DashboardResourcesRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -98,7 +139,7 @@ func (h *DashboardHandler) GetResources(ctx *gin.Context) {
 	data, err := h.dashboardService.GetResources(ctx, req)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("dashboardService.GetResources error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, dashboardErrorStatus(err), err, nil)
 		return
 	}
 
@@ -111,15 +152,21 @@ func (h *DashboardHandler) GetResources(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Param scope query string false "范围: all 或 cluster" default(all)
+// @Param scope query string false "范围: all、site 或 cluster" default(all)
 // @Param cluster_id query int false "集群ID（当 scope 为 cluster 时使用）"
+// @Param site_id query int false "站点ID（当 scope 为 site 时使用）"
 // @Param limit query int false "Top N 数量" default(5)
+// @Param app_id query string false "项目（应用ID），只统计该项目的虚拟机"
+// @Param team query string false "团队，只统计该团队的虚拟机"
+// @Param tag query string false "Proxmox 标签，只统计带该标签的虚拟机"
+// @Param environment query string false "环境: prod、stage 或 dev"
+// @Param node_id query int false "节点ID，只统计该节点"
 // @Success 200 {object} v1.DashboardHotspotsResponse
 // @Router /api/v1/dashboard/hotspots [get]
 func (h *DashboardHandler) GetHotspots(ctx *gin.Context) {
 	req := new(v1.DashboardHotspotsRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -134,7 +181,7 @@ func (h *DashboardHandler) GetHotspots(ctx *gin.Context) {
 	data, err := h.dashboardService.GetHotspots(ctx, req)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("dashboardService.GetHotspots error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, dashboardErrorStatus(err), err, nil)
 		return
 	}
 
@@ -147,14 +194,15 @@ func (h *DashboardHandler) GetHotspots(ctx *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security Bearer
-// @Param scope query string false "范围: all 或 cluster" default(all)
+// @Param scope query string false "范围: all、site 或 cluster" default(all)
 // @Param cluster_id query int false "集群ID（当 scope 为 cluster 时使用）"
+// @Param site_id query int false "站点ID（当 scope 为 site 时使用）"
 // @Success 200 {object} v1.DashboardOperationsResponse
 // @Router /api/v1/dashboard/operations [get]
 func (h *DashboardHandler) GetOperations(ctx *gin.Context) {
 	req := new(v1.DashboardOperationsRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -173,3 +221,192 @@ func (h *DashboardHandler) GetOperations(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, data)
 }
 
+
+// GetEnergy godoc
+// @Summary 获取能耗概览
+// @Description 返回已配置 BMC 功率采集节点的当前功率、今日及本月能耗和本月碳排放估算
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param scope query string false "范围: all、site 或 cluster" default(all)
+// @Param cluster_id query int false "集群ID（当 scope 为 cluster 时使用）"
+// @Param site_id query int false "站点ID（当 scope 为 site 时使用）"
+// @Success 200 {object} v1.DashboardEnergyResponse
+// @Router /api/v1/dashboard/energy [get]
+func (h *DashboardHandler) GetEnergy(ctx *gin.Context) {
+	req := new(v1.DashboardEnergyRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Scope == "" {
+		req.Scope = "all"
+	}
+
+	data, err := h.dashboardService.GetEnergy(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("dashboardService.GetEnergy error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetRisks godoc
+// @Summary 获取最近风险
+// @Description 与 hotspots 中的 recent_risks 相同，供单独的风险面板使用
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param scope query string false "范围: all、site 或 cluster" default(all)
+// @Param cluster_id query int false "集群ID（当 scope 为 cluster 时使用）"
+// @Param site_id query int false "站点ID（当 scope 为 site 时使用）"
+// @Success 200 {object} v1.DashboardRisksResponse
+// @Router /api/v1/dashboard/risks [get]
+func (h *DashboardHandler) GetRisks(ctx *gin.Context) {
+	req := new(v1.DashboardRisksRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Scope == "" {
+		req.Scope = "all"
+	}
+
+	data, err := h.dashboardService.GetRisks(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("dashboardService.GetRisks error", zap.Error(err))
+		v1.HandleError(ctx, dashboardErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetAlerts godoc
+// @Summary 获取未恢复的告警
+// @Description 汇总许可证超用、ZFS 存储池健康和磁盘 SMART 告警，按发生时间从新到旧排列。许可证告警只在 scope 为 all 时返回
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param scope query string false "范围: all、site 或 cluster" default(all)
+// @Param cluster_id query int false "集群ID（当 scope 为 cluster 时使用）"
+// @Param site_id query int false "站点ID（当 scope 为 site 时使用）"
+// @Param limit query int false "返回的告警条数" default(20)
+// @Success 200 {object} v1.DashboardAlertsResponse
+// @Router /api/v1/dashboard/alerts [get]
+func (h *DashboardHandler) GetAlerts(ctx *gin.Context) {
+	req := new(v1.DashboardAlertsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	// 设置默认值
+	if req.Scope == "" {
+		req.Scope = "all"
+	}
+
+	data, err := h.dashboardService.GetAlerts(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("dashboardService.GetAlerts error", zap.Error(err))
+		v1.HandleError(ctx, dashboardErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetWidgets godoc
+// @Summary 获取可用面板及预设布局
+// @Description 每个面板对应一个独立的数据接口；预设布局 ops 面向运维值班，management 面向管理层
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.DashboardWidgetsResponse
+// @Router /api/v1/dashboard/widgets [get]
+func (h *DashboardHandler) GetWidgets(ctx *gin.Context) {
+	v1.HandleSuccess(ctx, h.dashboardService.GetWidgets(ctx))
+}
+
+// GetLayout godoc
+// @Summary 获取当前用户的 Dashboard 布局
+// @Description 指定 preset 时返回预设布局；用户未保存过布局时返回 ops 预设，customized 为 false
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param preset query string false "预设布局: ops 或 management"
+// @Success 200 {object} v1.DashboardLayoutResponse
+// @Router /api/v1/dashboard/layout [get]
+func (h *DashboardHandler) GetLayout(ctx *gin.Context) {
+	req := new(v1.GetDashboardLayoutRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.dashboardService.GetLayout(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("dashboardService.GetLayout error", zap.Error(err))
+		v1.HandleError(ctx, dashboardErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// SaveLayout godoc
+// @Summary 保存当前用户的 Dashboard 布局
+// @Description 覆盖保存面板位置、尺寸（12 栅格）和参数，以及默认的 scope
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.SaveDashboardLayoutRequest true "params"
+// @Success 200 {object} v1.DashboardLayoutResponse
+// @Router /api/v1/dashboard/layout [put]
+func (h *DashboardHandler) SaveLayout(ctx *gin.Context) {
+	req := new(v1.SaveDashboardLayoutRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.dashboardService.SaveLayout(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("dashboardService.SaveLayout error", zap.Error(err))
+		v1.HandleError(ctx, dashboardErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ResetLayout godoc
+// @Summary 重置当前用户的 Dashboard 布局
+// @Description 删除已保存的布局，之后返回预设布局
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.Response
+// @Router /api/v1/dashboard/layout [delete]
+func (h *DashboardHandler) ResetLayout(ctx *gin.Context) {
+	if err := h.dashboardService.ResetLayout(ctx, GetUserIdFromCtx(ctx)); err != nil {
+		h.logger.WithContext(ctx).Error("dashboardService.ResetLayout error", zap.Error(err))
+		v1.HandleError(ctx, dashboardErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
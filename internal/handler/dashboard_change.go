@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type DashboardChangeHandler struct {
+	*Handler
+	changeService service.DashboardChangeService
+}
+
+func NewDashboardChangeHandler(handler *Handler, changeService service.DashboardChangeService) *DashboardChangeHandler {
+	return &DashboardChangeHandler{
+		Handler:       handler,
+		changeService: changeService,
+	}
+}
+
+func dashboardChangeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidParameter):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetChanges godoc
+// @Summary 获取变化概览
+// @Description 按集群汇总一段时间内新建、删除、迁移的虚拟机，修改过配置的虚拟机，以及与时间段开始前容量快照相比的容量变化；
+// @Description 虚拟机变化来自同步记录，配置修改来自操作审计
+// @Tags Dashboard模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param period query string false "统计时间段: 24h、7d 或 30d" default(24h)
+// @Param since query string false "起始时间（RFC3339），指定时覆盖 period"
+// @Param cluster_id query int false "集群ID"
+// @Success 200 {object} v1.DashboardChangesResponse
+// @Router /api/v1/dashboard/changes [get]
+func (h *DashboardChangeHandler) GetChanges(ctx *gin.Context) {
+	req := new(v1.DashboardChangesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.changeService.GetChanges(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("changeService.GetChanges error", zap.Error(err))
+		v1.HandleError(ctx, dashboardChangeErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
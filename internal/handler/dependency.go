@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type DependencyHandler struct {
+	*Handler
+	dependencyService service.DependencyService
+}
+
+func NewDependencyHandler(handler *Handler, dependencyService service.DependencyService) *DependencyHandler {
+	return &DependencyHandler{
+		Handler:           handler,
+		dependencyService: dependencyService,
+	}
+}
+
+func dependencyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrStorageNotFound), errors.Is(err, v1.ErrNodeNotFound),
+		errors.Is(err, v1.ErrTemplateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrBadRequest):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Lookup godoc
+// @Summary 依赖查询（谁在用它）
+// @Description 查询依赖某个存储、节点、模板或 IP 网段的资源，用于下线前评估影响。存储：磁盘 / ISO 在该存储上的虚拟机（实时读取 Proxmox 配置，不可达时退回数据库记录）、模板实例和默认使用该存储的创建规格；节点：节点上的虚拟机、模板实例和本地存储；模板：各节点实例和从模板克隆的虚拟机；网段：网段内登记的 IP 及其虚拟机
+// @Tags 依赖查询模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param type query string true "查询对象类型" Enums(storage, node, template, subnet)
+// @Param id query int false "存储 / 节点 / 模板 ID，type=subnet 时不需要"
+// @Param cidr query string false "网段，type=subnet 时必填，如 10.0.0.0/24"
+// @Param cluster_id query int false "type=subnet 时只查询该集群"
+// @Success 200 {object} v1.DependencyLookupResponse
+// @Router /api/v1/dependencies [get]
+func (h *DependencyHandler) Lookup(ctx *gin.Context) {
+	req := new(v1.DependencyLookupRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.dependencyService.Lookup(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("dependencyService.Lookup error", zap.Error(err))
+		v1.HandleError(ctx, dependencyErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
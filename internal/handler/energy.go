@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type EnergyHandler struct {
+	*Handler
+	energyService service.EnergyService
+}
+
+func NewEnergyHandler(handler *Handler, energyService service.EnergyService) *EnergyHandler {
+	return &EnergyHandler{
+		Handler:       handler,
+		energyService: energyService,
+	}
+}
+
+func energyErrorStatus(err error) int {
+	if errors.Is(err, v1.ErrInvalidReportMonth) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// GetReport godoc
+// @Summary 获取月度能耗报表
+// @Description 按节点（默认）、集群、应用或虚拟机汇总指定月份的能耗（kWh）及碳排放估算，按能耗从高到低排序。
+// @Description 节点能耗来自 BMC 功率读数；虚拟机能耗按运行中虚拟机的 vCPU 数比例分摊节点能耗，为估算值。
+// @Tags 能耗统计模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param month query string true "月份 YYYY-MM"
+// @Param cluster_id query int false "集群ID"
+// @Param node_id query int false "节点ID"
+// @Param app_id query string false "应用ID（group_by=app / vm / cost_center 时生效）"
+// @Param group_by query string false "分组方式：node / cluster / app / vm / cost_center" default(node)
+// @Success 200 {object} v1.GetEnergyReportResponse
+// @Router /api/v1/energy/report [get]
+func (h *EnergyHandler) GetReport(ctx *gin.Context) {
+	req := new(v1.GetEnergyReportRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.energyService.GetReport(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("energyService.GetReport error", zap.Error(err))
+		v1.HandleError(ctx, energyErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ExportReport godoc
+// @Summary 导出月度能耗报表（CSV）
+// @Description 参数与月度能耗报表一致，返回 CSV 文件
+// @Tags 能耗统计模块
+// @Produce text/csv
+// @Security Bearer
+// @Param month query string true "月份 YYYY-MM"
+// @Param cluster_id query int false "集群ID"
+// @Param node_id query int false "节点ID"
+// @Param app_id query string false "应用ID（group_by=app / vm / cost_center 时生效）"
+// @Param group_by query string false "分组方式：node / cluster / app / vm / cost_center" default(node)
+// @Success 200 {file} file
+// @Router /api/v1/energy/report/export [get]
+func (h *EnergyHandler) ExportReport(ctx *gin.Context) {
+	req := new(v1.GetEnergyReportRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.energyService.ExportReportCSV(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("energyService.ExportReportCSV error", zap.Error(err))
+		v1.HandleError(ctx, energyErrorStatus(err), err, nil)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=energy-report-%s.csv", req.Month))
+	ctx.Data(http.StatusOK, "text/csv; charset=utf-8", data)
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type FirstBootHookHandler struct {
+	*Handler
+	firstBootService service.FirstBootHookService
+}
+
+func NewFirstBootHookHandler(handler *Handler, firstBootService service.FirstBootHookService) *FirstBootHookHandler {
+	return &FirstBootHookHandler{
+		Handler:          handler,
+		firstBootService: firstBootService,
+	}
+}
+
+func firstBootErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrTemplateNotFound),
+		errors.Is(err, v1.ErrFirstBootHookNotFound),
+		errors.Is(err, v1.ErrFirstBootRunNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrFirstBootHookInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrFirstBootRunActive):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetFirstBootHook godoc
+// @Summary 获取模板的首次启动钩子
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Success 200 {object} v1.FirstBootHookResponse
+// @Router /api/v1/templates/{id}/first-boot-hook [get]
+func (h *FirstBootHookHandler) GetFirstBootHook(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.firstBootService.GetHook(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("firstBootService.GetHook error", zap.Error(err))
+		v1.HandleError(ctx, firstBootErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// SaveFirstBootHook godoc
+// @Summary 设置模板的首次启动钩子
+// @Description 仅管理员可操作。从该模板创建的虚拟机首次 guest agent 上线后，调用 webhook（JSON POST，配置 secret 时携带 X-PveSphere-Signature）
+// @Description 或 first_boot.scripts 中登记的脚本（JSON 作为标准输入），传入虚拟机元数据和 IP。只对之后创建的虚拟机生效
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Param request body v1.SaveFirstBootHookRequest true "params"
+// @Success 200 {object} v1.FirstBootHookResponse
+// @Router /api/v1/templates/{id}/first-boot-hook [put]
+func (h *FirstBootHookHandler) SaveFirstBootHook(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.SaveFirstBootHookRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.firstBootService.SaveHook(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("firstBootService.SaveHook error", zap.Error(err))
+		v1.HandleError(ctx, firstBootErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteFirstBootHook godoc
+// @Summary 删除模板的首次启动钩子
+// @Description 仅管理员可操作，尚未执行的记录不再调用
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/templates/{id}/first-boot-hook [delete]
+func (h *FirstBootHookHandler) DeleteFirstBootHook(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.firstBootService.DeleteHook(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("firstBootService.DeleteHook error", zap.Error(err))
+		v1.HandleError(ctx, firstBootErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListFirstBootRuns godoc
+// @Summary 首次启动钩子执行记录
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ListFirstBootRunsRequest false "params"
+// @Success 200 {object} v1.ListFirstBootRunsResponse
+// @Router /api/v1/first-boot-runs [get]
+func (h *FirstBootHookHandler) ListFirstBootRuns(ctx *gin.Context) {
+	req := new(v1.ListFirstBootRunsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.firstBootService.ListRuns(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("firstBootService.ListRuns error", zap.Error(err))
+		v1.HandleError(ctx, firstBootErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetVMFirstBootRun godoc
+// @Summary 虚拟机的首次启动钩子执行记录
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.FirstBootRunResponse
+// @Router /api/v1/vms/{id}/first-boot [get]
+func (h *FirstBootHookHandler) GetVMFirstBootRun(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.firstBootService.GetRunByVM(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("firstBootService.GetRunByVM error", zap.Error(err))
+		v1.HandleError(ctx, firstBootErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// RetryFirstBootRun godoc
+// @Summary 重新执行首次启动钩子
+// @Description 重新执行已结束（成功或失败）的记录并立即检测一次，创建人或管理员可操作；等待中或重试中的记录返回 409
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "执行记录ID"
+// @Success 200 {object} v1.FirstBootRunResponse
+// @Router /api/v1/first-boot-runs/{id}/retry [post]
+func (h *FirstBootHookHandler) RetryFirstBootRun(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.firstBootService.Retry(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("firstBootService.Retry error", zap.Error(err))
+		v1.HandleError(ctx, firstBootErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
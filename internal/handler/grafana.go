@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// GrafanaHandler Grafana JSON 数据源接口
+// 成功响应直接返回 Grafana 协议要求的结构（不包装 code/message/data），错误仍使用统一格式，
+// Grafana 会展示其中的 message。
+type GrafanaHandler struct {
+	*Handler
+	grafanaService service.GrafanaService
+}
+
+func NewGrafanaHandler(handler *Handler, grafanaService service.GrafanaService) *GrafanaHandler {
+	return &GrafanaHandler{
+		Handler:        handler,
+		grafanaService: grafanaService,
+	}
+}
+
+// TestDatasource godoc
+// @Summary Grafana 数据源连通性检查
+// @Description Grafana 数据源 "Save & test" 调用，返回 200 表示可用
+// @Tags Grafana数据源模块
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} map[string]string
+// @Router /api/v1/grafana [get]
+func (h *GrafanaHandler) TestDatasource(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Search godoc
+// @Summary 搜索可用指标
+// @Description 返回可查询的虚拟机 / 节点指标，target 格式为 <vm|node>:<id>:<metric>[:AVERAGE|MAX]
+// @Tags Grafana数据源模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.GrafanaSearchRequest false "搜索关键字"
+// @Success 200 {array} v1.GrafanaSearchItem
+// @Router /api/v1/grafana/search [post]
+func (h *GrafanaHandler) Search(ctx *gin.Context) {
+	req := new(v1.GrafanaSearchRequest)
+	// Grafana 可能发送空 body，忽略解析错误按无关键字处理
+	_ = ctx.ShouldBindJSON(req)
+
+	items, err := h.grafanaService.Search(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("grafanaService.Search error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, items)
+}
+
+// Query godoc
+// @Summary 查询指标时序数据
+// @Description 从 Proxmox RRD 读取虚拟机 / 节点历史监控数据，按 Grafana 时间范围过滤，根据查询起点自动选择 hour/day/week/month/year 粒度
+// @Tags Grafana数据源模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.GrafanaQueryRequest true "查询参数"
+// @Success 200 {array} v1.GrafanaTimeSeries
+// @Router /api/v1/grafana/query [post]
+func (h *GrafanaHandler) Query(ctx *gin.Context) {
+	req := new(v1.GrafanaQueryRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	series, err := h.grafanaService.Query(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("grafanaService.Query error", zap.Error(err))
+		if errors.Is(err, v1.ErrInvalidMetricTarget) || errors.Is(err, v1.ErrUnknownMetric) {
+			v1.HandleError(ctx, http.StatusBadRequest, err, nil)
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, series)
+}
+
+// Annotations godoc
+// @Summary 查询注释
+// @Description 暂不提供注释数据，返回空数组以兼容 Grafana 数据源协议
+// @Tags Grafana数据源模块
+// @Produce json
+// @Security Bearer
+// @Success 200 {array} object
+// @Router /api/v1/grafana/annotations [post]
+func (h *GrafanaHandler) Annotations(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, []interface{}{})
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ImageTransferHandler struct {
+	*Handler
+	transferService service.ImageTransferService
+}
+
+func NewImageTransferHandler(handler *Handler, transferService service.ImageTransferService) *ImageTransferHandler {
+	return &ImageTransferHandler{
+		Handler:         handler,
+		transferService: transferService,
+	}
+}
+
+func imageTransferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrObjectStoreNotFound), errors.Is(err, v1.ErrTransferTaskNotFound),
+		errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrObjectStoreExists), errors.Is(err, v1.ErrObjectStoreInUse),
+		errors.Is(err, v1.ErrVMAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest),
+		errors.Is(err, v1.ErrTransferStagingNotConfigured),
+		errors.Is(err, v1.ErrTransferInvalidObject),
+		errors.Is(err, v1.ErrClusterNotFound),
+		errors.Is(err, v1.ErrClusterNotSchedulable):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrObjectStoreUnreachable):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// CreateStore godoc
+// @Summary 添加对象存储
+// @Description 仅管理员可操作。添加 S3 兼容对象存储（AWS S3、MinIO、Ceph RGW 等），保存前会校验存储桶是否可访问
+// @Tags 镜像传输模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateObjectStoreRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/image-transfers/stores [post]
+func (h *ImageTransferHandler) CreateStore(ctx *gin.Context) {
+	req := new(v1.CreateObjectStoreRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.transferService.CreateStore(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("transferService.CreateStore error", zap.Error(err))
+		v1.HandleError(ctx, imageTransferErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListStores godoc
+// @Summary 获取对象存储列表
+// @Tags 镜像传输模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListObjectStoresResponse
+// @Router /api/v1/image-transfers/stores [get]
+func (h *ImageTransferHandler) ListStores(ctx *gin.Context) {
+	data, err := h.transferService.ListStores(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("transferService.ListStores error", zap.Error(err))
+		v1.HandleError(ctx, imageTransferErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteStore godoc
+// @Summary 删除对象存储
+// @Description 仅管理员可操作，只删除配置，不删除存储桶中的对象；有进行中的传输任务时不允许删除
+// @Tags 镜像传输模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "对象存储ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/image-transfers/stores/{id} [delete]
+func (h *ImageTransferHandler) DeleteStore(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.transferService.DeleteStore(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("transferService.DeleteStore error", zap.Error(err))
+		v1.HandleError(ctx, imageTransferErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListStoreObjects godoc
+// @Summary 列出对象存储中的虚拟机备份
+// @Description 返回可导入的 vzdump 虚拟机备份，has_manifest 表示是否有导出清单（有清单时导入会校验 SHA256）
+// @Tags 镜像传输模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "对象存储ID"
+// @Param prefix query string false "对象键前缀（在对象存储前缀之下）"
+// @Success 200 {object} v1.ListStoreObjectsResponse
+// @Router /api/v1/image-transfers/stores/{id}/objects [get]
+func (h *ImageTransferHandler) ListStoreObjects(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.ListStoreObjectsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.transferService.ListStoreObjects(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("transferService.ListStoreObjects error", zap.Error(err))
+		v1.HandleError(ctx, imageTransferErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CreateExport godoc
+// @Summary 导出虚拟机到对象存储
+// @Description 将虚拟机 vzdump 备份到中转存储后分段上传到对象存储，并写入带 SHA256 的清单。请求校验通过后立即返回任务，传输在后台执行。
+// @Description mode 为 suspend / stop 时会中断虚拟机运行，需符合变更窗口
+// @Tags 镜像传输模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateImageExportRequest true "params"
+// @Success 200 {object} v1.GetImageTransferResponse
+// @Router /api/v1/image-transfers/export [post]
+func (h *ImageTransferHandler) CreateExport(ctx *gin.Context) {
+	req := new(v1.CreateImageExportRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.transferService.CreateExport(ctx, req, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("transferService.CreateExport error", zap.Error(err))
+		v1.HandleError(ctx, imageTransferErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CreateImport godoc
+// @Summary 从对象存储导入虚拟机
+// @Description 将对象存储中的虚拟机备份分段下载到中转存储，按导出清单校验 SHA256 后恢复为虚拟机，完成后自动创建虚拟机记录。请求校验通过后立即返回任务，传输在后台执行
+// @Tags 镜像传输模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateImageImportRequest true "params"
+// @Success 200 {object} v1.GetImageTransferResponse
+// @Router /api/v1/image-transfers/import [post]
+func (h *ImageTransferHandler) CreateImport(ctx *gin.Context) {
+	req := new(v1.CreateImageImportRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.transferService.CreateImport(ctx, req, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("transferService.CreateImport error", zap.Error(err))
+		v1.HandleError(ctx, imageTransferErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetTransfer godoc
+// @Summary 获取镜像传输任务详情
+// @Description 返回任务阶段、已传输字节数、进度及 SHA256
+// @Tags 镜像传输模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} v1.GetImageTransferResponse
+// @Router /api/v1/image-transfers/{id} [get]
+func (h *ImageTransferHandler) GetTransfer(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.transferService.GetTransfer(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("transferService.GetTransfer error", zap.Error(err))
+		v1.HandleError(ctx, imageTransferErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListTransfers godoc
+// @Summary 获取镜像传输任务列表
+// @Tags 镜像传输模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param direction query string false "方向（export, import）"
+// @Param cluster_id query int false "集群ID"
+// @Param status query string false "状态（pending, running, completed, failed）"
+// @Success 200 {object} v1.ListImageTransfersResponse
+// @Router /api/v1/image-transfers [get]
+func (h *ImageTransferHandler) ListTransfers(ctx *gin.Context) {
+	req := new(v1.ListImageTransfersRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.transferService.ListTransfers(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("transferService.ListTransfers error", zap.Error(err))
+		v1.HandleError(ctx, imageTransferErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type LicenseHandler struct {
+	*Handler
+	licenseService service.LicenseService
+}
+
+func NewLicenseHandler(handler *Handler, licenseService service.LicenseService) *LicenseHandler {
+	return &LicenseHandler{
+		Handler:        handler,
+		licenseService: licenseService,
+	}
+}
+
+func licenseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrLicenseNotFound), errors.Is(err, v1.ErrLicenseAssignmentNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrLicenseExists), errors.Is(err, v1.ErrLicenseAssignmentExists):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest), errors.Is(err, v1.ErrLicenseTargetNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateLicense godoc
+// @Summary 新增许可证
+// @Description 仅管理员可操作。os_type 需与 guest agent 识别的操作系统类型一致（windows，或 Linux 发行版 id 如 rhel、sles）
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateLicenseRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/licenses [post]
+func (h *LicenseHandler) CreateLicense(ctx *gin.Context) {
+	req := new(v1.CreateLicenseRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.licenseService.CreateLicense(ctx, GetUserIdFromCtx(ctx), req); err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.CreateLicense error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdateLicense godoc
+// @Summary 更新许可证
+// @Description 仅管理员可操作
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "许可证ID"
+// @Param request body v1.UpdateLicenseRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/licenses/{id} [put]
+func (h *LicenseHandler) UpdateLicense(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateLicenseRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.licenseService.UpdateLicense(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.UpdateLicense error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteLicense godoc
+// @Summary 删除许可证
+// @Description 仅管理员可操作，同时删除其分配记录
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "许可证ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/licenses/{id} [delete]
+func (h *LicenseHandler) DeleteLicense(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.licenseService.DeleteLicense(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.DeleteLicense error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListLicenses godoc
+// @Summary 获取许可证列表
+// @Description 返回许可证及当前占用数，密钥已脱敏
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param os_type query string false "操作系统类型"
+// @Success 200 {object} v1.ListLicensesResponse
+// @Router /api/v1/licenses [get]
+func (h *LicenseHandler) ListLicenses(ctx *gin.Context) {
+	req := new(v1.ListLicensesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.licenseService.ListLicenses(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.ListLicenses error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetLicense godoc
+// @Summary 获取许可证详情
+// @Description 返回许可证及其分配记录，密钥已脱敏
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "许可证ID"
+// @Success 200 {object} v1.GetLicenseResponse
+// @Router /api/v1/licenses/{id} [get]
+func (h *LicenseHandler) GetLicense(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.licenseService.GetLicense(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.GetLicense error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetLicenseKey godoc
+// @Summary 查看许可证完整密钥
+// @Description 仅管理员可操作，查看操作会记录日志
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "许可证ID"
+// @Success 200 {object} v1.GetLicenseKeyResponse
+// @Router /api/v1/licenses/{id}/key [get]
+func (h *LicenseHandler) GetLicenseKey(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.licenseService.GetLicenseKey(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.GetLicenseKey error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// Assign godoc
+// @Summary 分配许可证
+// @Description 仅管理员可操作。分配给模板时，从该模板创建的虚拟机都计入占用
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "许可证ID"
+// @Param request body v1.AssignLicenseRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/licenses/{id}/assignments [post]
+func (h *LicenseHandler) Assign(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.AssignLicenseRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.licenseService.Assign(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.Assign error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// Unassign godoc
+// @Summary 取消许可证分配
+// @Description 仅管理员可操作
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "许可证ID"
+// @Param assignment_id path int true "分配记录ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/licenses/{id}/assignments/{assignment_id} [delete]
+func (h *LicenseHandler) Unassign(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	assignmentID, err := strconv.ParseInt(ctx.Param("assignment_id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.licenseService.Unassign(ctx, GetUserIdFromCtx(ctx), id, assignmentID); err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.Unassign error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetUsage godoc
+// @Summary 按操作系统类型统计许可证用量
+// @Description 对比授权数量、分配占用数和 guest agent 识别到的虚拟机数，并给出未分配许可证的虚拟机数
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.GetLicenseUsageResponse
+// @Router /api/v1/licenses/usage [get]
+func (h *LicenseHandler) GetUsage(ctx *gin.Context) {
+	data, err := h.licenseService.GetUsage(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.GetUsage error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListAlerts godoc
+// @Summary 获取许可证超用告警
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param license_id query int false "许可证ID"
+// @Param open query bool false "只返回未恢复的告警"
+// @Success 200 {object} v1.ListLicenseAlertsResponse
+// @Router /api/v1/licenses/alerts [get]
+func (h *LicenseHandler) ListAlerts(ctx *gin.Context) {
+	req := new(v1.ListLicenseAlertsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.licenseService.ListAlerts(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.ListAlerts error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// Refresh godoc
+// @Summary 立即采集并检查许可证用量
+// @Description 仅管理员可操作。通过 guest agent 重新采集运行中虚拟机的操作系统信息，并检查是否超用
+// @Tags 许可证模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.Response
+// @Router /api/v1/licenses/refresh [post]
+func (h *LicenseHandler) Refresh(ctx *gin.Context) {
+	if err := h.licenseService.Refresh(ctx, GetUserIdFromCtx(ctx)); err != nil {
+		h.logger.WithContext(ctx).Error("licenseService.Refresh error", zap.Error(err))
+		v1.HandleError(ctx, licenseErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
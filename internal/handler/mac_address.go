@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type MACAddressHandler struct {
+	*Handler
+	macRegistry service.MACRegistryService
+}
+
+func NewMACAddressHandler(handler *Handler, macRegistry service.MACRegistryService) *MACAddressHandler {
+	return &MACAddressHandler{
+		Handler:     handler,
+		macRegistry: macRegistry,
+	}
+}
+
+func macAddressErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrMACAddressNotFound), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidMACAddress):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrMACAddressInUse):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListMACAddresses godoc
+// @Summary 获取 MAC 地址登记列表
+// @Tags MAC地址模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "集群ID"
+// @Param vm_id query int false "虚拟机ID"
+// @Param source query string false "来源（generated/user/reserved/discovered）"
+// @Param mac query string false "MAC（模糊匹配）"
+// @Success 200 {object} v1.ListMACAddressesResponse
+// @Router /api/v1/mac-addresses [get]
+func (h *MACAddressHandler) ListMACAddresses(ctx *gin.Context) {
+	req := new(v1.ListMACAddressesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.macRegistry.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("macRegistry.List error", zap.Error(err))
+		v1.HandleError(ctx, macAddressErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ReserveMACAddress godoc
+// @Summary 预留 MAC 地址
+// @Description 不传 mac 时由平台生成（前缀见 mac_registry.prefix）。创建虚拟机时通过 mac_address 使用预留的地址
+// @Tags MAC地址模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ReserveMACAddressRequest true "params"
+// @Success 200 {object} v1.MACAddressResponse
+// @Router /api/v1/mac-addresses [post]
+func (h *MACAddressHandler) ReserveMACAddress(ctx *gin.Context) {
+	req := new(v1.ReserveMACAddressRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.macRegistry.Reserve(ctx, req, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("macRegistry.Reserve error", zap.Error(err))
+		v1.HandleError(ctx, macAddressErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteMACAddress godoc
+// @Summary 删除 MAC 地址登记
+// @Description 只能删除未分配给虚拟机的登记，虚拟机的 MAC 随虚拟机删除一起释放
+// @Tags MAC地址模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "登记ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/mac-addresses/{id} [delete]
+func (h *MACAddressHandler) DeleteMACAddress(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.macRegistry.Delete(ctx, id); err != nil {
+		h.logger.WithContext(ctx).Error("macRegistry.Delete error", zap.Error(err))
+		v1.HandleError(ctx, macAddressErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// SyncMACAddresses godoc
+// @Summary 从 Proxmox 同步虚拟机网卡 MAC
+// @Description 仅管理员可操作。扫描集群内全部 QEMU 虚拟机的网卡配置，登记未登记的 MAC、修正位置变化，并报告重复的 MAC
+// @Tags MAC地址模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.SyncMACAddressesRequest false "params"
+// @Success 200 {object} v1.SyncMACAddressesResponse
+// @Router /api/v1/mac-addresses/sync [post]
+func (h *MACAddressHandler) SyncMACAddresses(ctx *gin.Context) {
+	req := new(v1.SyncMACAddressesRequest)
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			v1.HandleBindError(ctx, err)
+			return
+		}
+	}
+
+	data, err := h.macRegistry.Sync(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("macRegistry.Sync error", zap.Error(err))
+		v1.HandleError(ctx, macAddressErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ExportDHCPReservations godoc
+// @Summary 导出 DHCP 保留配置
+// @Description format=isc 返回 dhcpd.conf 的 host 声明；format=kea 返回 Kea Dhcp4 的 reservations JSON。IP 取自虚拟机 IP 地址表
+// @Tags MAC地址模块
+// @Produce plain
+// @Produce json
+// @Security Bearer
+// @Param format query string false "导出格式（isc/kea），默认 isc"
+// @Param cluster_id query int false "集群ID"
+// @Param only_with_ip query bool false "只导出已分配 IP 的网卡"
+// @Success 200 {string} string "DHCP 保留配置"
+// @Router /api/v1/mac-addresses/dhcp-export [get]
+func (h *MACAddressHandler) ExportDHCPReservations(ctx *gin.Context) {
+	req := new(v1.ExportDHCPReservationsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, contentType, err := h.macRegistry.ExportDHCP(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("macRegistry.ExportDHCP error", zap.Error(err))
+		v1.HandleError(ctx, macAddressErrorStatus(err), err, nil)
+		return
+	}
+
+	filename := "dhcpd-reservations.conf"
+	if req.Format == "kea" {
+		filename = "kea-reservations.json"
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	ctx.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxMetadataBundleSize 导入的元数据包大小上限
+const maxMetadataBundleSize = 256 << 20
+
+type MetadataBackupHandler struct {
+	*Handler
+	backupService service.MetadataBackupService
+}
+
+func NewMetadataBackupHandler(handler *Handler, backupService service.MetadataBackupService) *MetadataBackupHandler {
+	return &MetadataBackupHandler{
+		Handler:       handler,
+		backupService: backupService,
+	}
+}
+
+func metadataBackupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrMetadataTargetNotEmpty):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest), errors.Is(err, v1.ErrMetadataBundleInvalid),
+		errors.Is(err, v1.ErrMetadataBundleVersion), errors.Is(err, v1.ErrMetadataPassphrase):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrSecretBackendUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ExportMetadata godoc
+// @Summary 导出元数据包
+// @Description 仅管理员可操作。导出站点、集群、节点、存储、虚拟机、模板、IPAM 和策略等元数据，敏感字段（集群 Token、虚拟机密码、BMC 密码、对象存储密钥）用口令加密；用户账号不导出
+// @Tags 元数据备份模块
+// @Accept json
+// @Produce json
+// @Produce application/yaml
+// @Security Bearer
+// @Param request body v1.ExportMetadataRequest true "params"
+// @Success 200 {object} v1.MetadataBundle
+// @Router /api/v1/metadata/export [post]
+func (h *MetadataBackupHandler) ExportMetadata(ctx *gin.Context) {
+	req := new(v1.ExportMetadataRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, contentType, err := h.backupService.Export(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("backupService.Export error", zap.Error(err))
+		v1.HandleError(ctx, metadataBackupErrorStatus(err), err, nil)
+		return
+	}
+
+	ext := "json"
+	if req.Format == "yaml" {
+		ext = "yaml"
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=pvesphere-metadata-%s.%s", time.Now().Format("20060102-150405"), ext))
+	ctx.Data(http.StatusOK, contentType, data)
+}
+
+// ImportMetadata godoc
+// @Summary 导入元数据包
+// @Description 仅管理员可操作，只能导入到空的管理数据库（新实例），记录主键原样保留。敏感字段用导出口令解密后按当前实例的配置重新保存，虚拟机密码写入当前 secret.backend。dry_run 只校验包和口令
+// @Tags 元数据备份模块
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param file formData file true "元数据包（JSON 或 YAML）"
+// @Param passphrase formData string true "导出时使用的口令"
+// @Param dry_run formData bool false "只校验不写入"
+// @Success 200 {object} v1.ImportMetadataResponse
+// @Router /api/v1/metadata/import [post]
+func (h *MetadataBackupHandler) ImportMetadata(ctx *gin.Context) {
+	req := new(v1.ImportMetadataRequest)
+	if err := ctx.ShouldBind(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+	file, _, err := ctx.Request.FormFile("file")
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxMetadataBundleSize+1))
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	if len(data) > maxMetadataBundleSize {
+		v1.HandleError(ctx, http.StatusRequestEntityTooLarge, v1.WithDetailf(v1.ErrBadRequest, "bundle larger than %d bytes", maxMetadataBundleSize), nil)
+		return
+	}
+
+	result, err := h.backupService.Import(ctx, GetUserIdFromCtx(ctx), req, data)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("backupService.Import error", zap.Error(err))
+		v1.HandleError(ctx, metadataBackupErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, result)
+}
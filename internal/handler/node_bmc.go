@@ -0,0 +1,341 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type NodeBMCHandler struct {
+	*Handler
+	nodeBMCService service.NodeBMCService
+}
+
+func NewNodeBMCHandler(handler *Handler, nodeBMCService service.NodeBMCService) *NodeBMCHandler {
+	return &NodeBMCHandler{
+		Handler:        handler,
+		nodeBMCService: nodeBMCService,
+	}
+}
+
+func nodeBMCErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrNodeBMCNotConfigured),
+		errors.Is(err, v1.ErrNodeWolNotConfigured), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidMACAddress), errors.Is(err, v1.ErrInvalidWolRelayNode):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrNodeNotInMaintenance), errors.Is(err, v1.ErrNodeHasRunningVMs):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBMCRequestFailed), errors.Is(err, v1.ErrWolSendFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// GetNodeBMC godoc
+// @Summary 获取节点 BMC 配置
+// @Description 不返回 BMC 密码，has_password 表示是否已设置
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} v1.GetNodeBMCResponse
+// @Router /api/v1/nodes/{id}/bmc [get]
+func (h *NodeBMCHandler) GetNodeBMC(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.nodeBMCService.GetNodeBMC(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.GetNodeBMC error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// SetNodeBMC godoc
+// @Summary 设置节点 BMC 配置
+// @Description 仅管理员可操作。支持 Redfish 和 IPMI（需要服务所在主机安装 ipmitool），未配置时新增，已配置时覆盖，password 留空表示不修改
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param request body v1.SetNodeBMCRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/nodes/{id}/bmc [put]
+func (h *NodeBMCHandler) SetNodeBMC(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.SetNodeBMCRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.nodeBMCService.SetNodeBMC(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.SetNodeBMC error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteNodeBMC godoc
+// @Summary 删除节点 BMC 配置
+// @Description 仅管理员可操作，已统计的能耗数据保留
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/nodes/{id}/bmc [delete]
+func (h *NodeBMCHandler) DeleteNodeBMC(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.nodeBMCService.DeleteNodeBMC(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.DeleteNodeBMC error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetNodePower godoc
+// @Summary 实时读取节点功率
+// @Description 通过 BMC 读取整机当前功率（瓦），同时更新节点最近一次读数
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} v1.GetNodePowerResponse
+// @Router /api/v1/nodes/{id}/bmc/power [get]
+func (h *NodeBMCHandler) GetNodePower(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.nodeBMCService.GetNodePower(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.GetNodePower error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetNodePowerState godoc
+// @Summary 获取节点电源状态
+// @Description 通过 BMC 读取节点电源状态（on / off / unknown），节点断电或挂死时仍可读取
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} v1.GetNodePowerStateResponse
+// @Router /api/v1/nodes/{id}/bmc/power-state [get]
+func (h *NodeBMCHandler) GetNodePowerState(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.nodeBMCService.GetNodePowerState(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.GetNodePowerState error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// NodePowerAction godoc
+// @Summary 节点带外电源操作
+// @Description 仅管理员可操作，受变更窗口约束。action：on 开机，off 强制断电，cycle 强制重启（用于挂死节点）。
+// @Description off / cycle 要求节点已设置为不可调度且没有运行中的虚拟机，force=true 时跳过检查。
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param request body v1.NodePowerActionRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/nodes/{id}/bmc/power [post]
+func (h *NodeBMCHandler) NodePowerAction(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.NodePowerActionRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.nodeBMCService.NodePowerAction(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.NodePowerAction error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetNodeSensors godoc
+// @Summary 获取节点传感器读数
+// @Description 通过 BMC 读取温度、风扇、电压等传感器
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} v1.GetNodeSensorsResponse
+// @Router /api/v1/nodes/{id}/bmc/sensors [get]
+func (h *NodeBMCHandler) GetNodeSensors(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.nodeBMCService.GetNodeSensors(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.GetNodeSensors error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// SetNodeWol godoc
+// @Summary 设置节点 Wake-on-LAN MAC 地址
+// @Description 仅管理员可操作，同时尝试写入 Proxmox 节点配置（中继唤醒时使用）
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param request body v1.SetNodeWolRequest true "MAC 地址"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/nodes/{id}/wol [put]
+func (h *NodeBMCHandler) SetNodeWol(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.SetNodeWolRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.nodeBMCService.SetNodeWol(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.SetNodeWol error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteNodeWol godoc
+// @Summary 清除节点 Wake-on-LAN MAC 地址
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/nodes/{id}/wol [delete]
+func (h *NodeBMCHandler) DeleteNodeWol(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.nodeBMCService.DeleteNodeWol(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.DeleteNodeWol error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// WakeNode godoc
+// @Summary 唤醒节点（Wake-on-LAN）
+// @Description 不指定 relay_node_id 时由平台直接广播魔术包，否则经同集群的在线节点发送；仅管理员可操作
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param request body v1.WakeNodeRequest false "中继节点"
+// @Success 200 {object} v1.WakeNodeResponse
+// @Router /api/v1/nodes/{id}/wake [post]
+func (h *NodeBMCHandler) WakeNode(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	// 请求体可省略
+	req := new(v1.WakeNodeRequest)
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			v1.HandleBindError(ctx, err)
+			return
+		}
+	}
+
+	data, err := h.nodeBMCService.WakeNode(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeBMCService.WakeNode error", zap.Error(err))
+		v1.HandleError(ctx, nodeBMCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type NodeDiskHandler struct {
+	*Handler
+	nodeDiskService service.NodeDiskService
+}
+
+func NewNodeDiskHandler(handler *Handler, nodeDiskService service.NodeDiskService) *NodeDiskHandler {
+	return &NodeDiskHandler{
+		Handler:         handler,
+		nodeDiskService: nodeDiskService,
+	}
+}
+
+func nodeDiskErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrClusterNotFound), errors.Is(err, v1.ErrDiskNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidLVMSpec):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrDiskInUse):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// CreateNodeLVM godoc
+// @Summary 在节点磁盘上创建 LVM 卷组
+// @Description 仅管理员可操作，受变更窗口约束。磁盘必须未被使用（可先调用 /nodes/disks/wipedisk 擦除），返回 Proxmox 任务 UPID。
+// @Description add_storage=true 时同时在集群中添加同名的 lvm 存储
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param request body v1.CreateNodeLVMRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/nodes/{id}/lvm [post]
+func (h *NodeDiskHandler) CreateNodeLVM(ctx *gin.Context) {
+	h.createLVM(ctx, false)
+}
+
+// CreateNodeLVMThin godoc
+// @Summary 在节点磁盘上创建 LVM-thin 存储池
+// @Description 仅管理员可操作，受变更窗口约束。在磁盘上创建卷组及同名 thin pool，磁盘必须未被使用，返回 Proxmox 任务 UPID。
+// @Description add_storage=true 时同时在集群中添加同名的 lvmthin 存储
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param request body v1.CreateNodeLVMRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/nodes/{id}/lvmthin [post]
+func (h *NodeDiskHandler) CreateNodeLVMThin(ctx *gin.Context) {
+	h.createLVM(ctx, true)
+}
+
+func (h *NodeDiskHandler) createLVM(ctx *gin.Context, thin bool) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.CreateNodeLVMRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	var upid string
+	if thin {
+		upid, err = h.nodeDiskService.CreateLVMThin(ctx, GetUserIdFromCtx(ctx), id, req)
+	} else {
+		upid, err = h.nodeDiskService.CreateLVM(ctx, GetUserIdFromCtx(ctx), id, req)
+	}
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeDiskService.CreateLVM error", zap.Bool("thin", thin), zap.Error(err))
+		v1.HandleError(ctx, nodeDiskErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, upid)
+}
+
+// GetNodeDiskSMART godoc
+// @Summary 读取磁盘 SMART 信息
+// @Description 实时读取磁盘 SMART 信息，并提取重映射扇区、待映射扇区、不可修复错误、剩余寿命、通电时间和温度
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param disk query string true "磁盘设备名，如 /dev/sda"
+// @Success 200 {object} v1.GetNodeDiskSMARTResponse
+// @Router /api/v1/nodes/{id}/disks/smart [get]
+func (h *NodeDiskHandler) GetNodeDiskSMART(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.GetNodeDiskSMARTRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	smart, err := h.nodeDiskService.GetSMART(ctx, id, req.Disk)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeDiskService.GetSMART error", zap.Error(err))
+		v1.HandleError(ctx, nodeDiskErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, smart)
+}
+
+// ListNodeDiskAlerts godoc
+// @Summary 获取磁盘 SMART 告警
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "集群ID"
+// @Param node_id query int false "节点ID"
+// @Param open query bool false "只返回未恢复的告警"
+// @Success 200 {object} v1.ListNodeDiskAlertsResponse
+// @Router /api/v1/nodes/disks/alerts [get]
+func (h *NodeDiskHandler) ListNodeDiskAlerts(ctx *gin.Context) {
+	req := new(v1.ListNodeDiskAlertsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.nodeDiskService.ListAlerts(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeDiskService.ListAlerts error", zap.Error(err))
+		v1.HandleError(ctx, nodeDiskErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
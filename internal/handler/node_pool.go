@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type NodePoolHandler struct {
+	*Handler
+	poolService service.NodePoolService
+}
+
+func NewNodePoolHandler(handler *Handler, poolService service.NodePoolService) *NodePoolHandler {
+	return &NodePoolHandler{
+		Handler:     handler,
+		poolService: poolService,
+	}
+}
+
+func nodePoolErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrNodePoolNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrNodePoolExists):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest), errors.Is(err, v1.ErrClusterNotFound), errors.Is(err, v1.ErrNodeNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateNodePool godoc
+// @Summary 新增节点池
+// @Description 仅管理员可操作。成员节点必须属于该集群，一个节点可以属于多个节点池；配额为 0 表示不限制
+// @Tags 节点池模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateNodePoolRequest true "params"
+// @Success 200 {object} v1.GetNodePoolResponse
+// @Router /api/v1/node-pools [post]
+func (h *NodePoolHandler) CreateNodePool(ctx *gin.Context) {
+	req := new(v1.CreateNodePoolRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.poolService.CreatePool(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("poolService.CreatePool error", zap.Error(err))
+		v1.HandleError(ctx, nodePoolErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateNodePool godoc
+// @Summary 更新节点池
+// @Description 仅管理员可操作，不传的字段保持不变；传入 node_ids 时替换全部成员节点。配额调低不影响已有虚拟机，只限制新建
+// @Tags 节点池模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点池ID"
+// @Param request body v1.UpdateNodePoolRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/node-pools/{id} [put]
+func (h *NodePoolHandler) UpdateNodePool(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateNodePoolRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.poolService.UpdatePool(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("poolService.UpdatePool error", zap.Error(err))
+		v1.HandleError(ctx, nodePoolErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteNodePool godoc
+// @Summary 删除节点池
+// @Description 仅管理员可操作，成员节点和节点上的虚拟机不受影响
+// @Tags 节点池模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点池ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/node-pools/{id} [delete]
+func (h *NodePoolHandler) DeleteNodePool(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.poolService.DeletePool(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("poolService.DeletePool error", zap.Error(err))
+		v1.HandleError(ctx, nodePoolErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetNodePool godoc
+// @Summary 获取节点池详情
+// @Description 包含成员节点和池内虚拟机的资源用量
+// @Tags 节点池模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点池ID"
+// @Success 200 {object} v1.GetNodePoolResponse
+// @Router /api/v1/node-pools/{id} [get]
+func (h *NodePoolHandler) GetNodePool(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.poolService.GetPool(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("poolService.GetPool error", zap.Error(err))
+		v1.HandleError(ctx, nodePoolErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListNodePools godoc
+// @Summary 获取节点池列表
+// @Tags 节点池模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "集群ID"
+// @Param node_id query int false "只返回包含该节点的节点池"
+// @Success 200 {object} v1.ListNodePoolsResponse
+// @Router /api/v1/node-pools [get]
+func (h *NodePoolHandler) ListNodePools(ctx *gin.Context) {
+	req := new(v1.ListNodePoolsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.poolService.ListPools(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("poolService.ListPools error", zap.Error(err))
+		v1.HandleError(ctx, nodePoolErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type NodeVersionHandler struct {
+	*Handler
+	versionService service.NodeVersionService
+}
+
+func NewNodeVersionHandler(handler *Handler, versionService service.NodeVersionService) *NodeVersionHandler {
+	return &NodeVersionHandler{
+		Handler:        handler,
+		versionService: versionService,
+	}
+}
+
+func nodeVersionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrClusterNotFound), errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrVMNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// migrateErrorStatus 迁移接口的错误状态码：兼容性检查未通过返回 409
+func migrateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrMigrationIncompatible), errors.Is(err, v1.ErrNodeCertificateChanged),
+		errors.Is(err, v1.ErrRemoteMigratePreflightFailed):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrNodeCertificateUnavailable):
+		return http.StatusBadGateway
+	}
+	return changeErrorStatus(err, http.StatusInternalServerError)
+}
+
+// GetMatrix godoc
+// @Summary 获取集群节点版本矩阵
+// @Description 返回集群内各节点的 PVE、内核、QEMU 版本和 CPU 型号，标出不一致的维度，并给出所有节点都支持的推荐 CPU 型号基线
+// @Tags 节点版本模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int true "集群ID"
+// @Success 200 {object} v1.GetNodeVersionMatrixResponse
+// @Router /api/v1/node-versions [get]
+func (h *NodeVersionHandler) GetMatrix(ctx *gin.Context) {
+	req := new(v1.GetNodeVersionMatrixRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.versionService.GetMatrix(ctx, req.ClusterID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("versionService.GetMatrix error", zap.Error(err))
+		v1.HandleError(ctx, nodeVersionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// Refresh godoc
+// @Summary 立即采集集群节点版本
+// @Description 从 Proxmox 重新读取集群内所有节点的版本和 CPU 信息，返回最新的版本矩阵
+// @Tags 节点版本模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.RefreshNodeVersionsRequest true "params"
+// @Success 200 {object} v1.GetNodeVersionMatrixResponse
+// @Router /api/v1/node-versions/refresh [post]
+func (h *NodeVersionHandler) Refresh(ctx *gin.Context) {
+	req := new(v1.RefreshNodeVersionsRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.versionService.RefreshCluster(ctx, req.ClusterID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("versionService.RefreshCluster error", zap.Error(err))
+		v1.HandleError(ctx, nodeVersionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CheckMigration godoc
+// @Summary 迁移兼容性检查
+// @Description 检查虚拟机迁移到目标节点时的 QEMU 版本和 CPU 兼容性。虚拟机运行中按在线迁移检查，blockers 非空时迁移接口默认拒绝执行（可传 force 强制迁移）
+// @Tags 节点版本模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param vm_id query int true "虚拟机ID"
+// @Param target_node_id query int true "目标节点ID"
+// @Success 200 {object} v1.MigrationCheckResponse
+// @Router /api/v1/node-versions/migration-check [get]
+func (h *NodeVersionHandler) CheckMigration(ctx *gin.Context) {
+	req := new(v1.MigrationCheckRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.versionService.CheckMigration(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("versionService.CheckMigration error", zap.Error(err))
+		v1.HandleError(ctx, nodeVersionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
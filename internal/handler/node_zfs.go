@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type NodeZFSHandler struct {
+	*Handler
+	nodeZFSService service.NodeZFSService
+}
+
+func NewNodeZFSHandler(handler *Handler, nodeZFSService service.NodeZFSService) *NodeZFSHandler {
+	return &NodeZFSHandler{
+		Handler:        handler,
+		nodeZFSService: nodeZFSService,
+	}
+}
+
+func nodeZFSErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrClusterNotFound), errors.Is(err, v1.ErrZFSPoolNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidZFSPoolSpec):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// CreateZFSPool godoc
+// @Summary 在节点上创建 ZFS 存储池
+// @Description 仅管理员可操作，受变更窗口约束。磁盘需为未使用的整盘，创建在 Proxmox 任务中异步执行，返回任务 UPID。
+// @Description add_storage=true 时同时在集群中添加同名的 zfspool 存储
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param request body v1.CreateZFSPoolRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/nodes/{id}/zfs/pools [post]
+func (h *NodeZFSHandler) CreateZFSPool(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.CreateZFSPoolRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	upid, err := h.nodeZFSService.CreatePool(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeZFSService.CreatePool error", zap.Error(err))
+		v1.HandleError(ctx, nodeZFSErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, upid)
+}
+
+// GetZFSPool godoc
+// @Summary 获取 ZFS 存储池健康状态
+// @Description 返回 zpool status 的状态、设备树、读 / 写 / 校验错误计数和最近一次 scrub 时间
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Param name path string true "存储池名称"
+// @Success 200 {object} v1.GetZFSPoolResponse
+// @Router /api/v1/nodes/{id}/zfs/pools/{name} [get]
+func (h *NodeZFSHandler) GetZFSPool(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	detail, err := h.nodeZFSService.GetPool(ctx, id, ctx.Param("name"))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeZFSService.GetPool error", zap.Error(err))
+		v1.HandleError(ctx, nodeZFSErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, detail)
+}
+
+// ListZFSDatasets godoc
+// @Summary 获取节点 ZFS 数据集
+// @Description 返回节点上可用作存储的 ZFS 存储池和数据集
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "节点ID"
+// @Success 200 {object} v1.ListZFSDatasetsResponse
+// @Router /api/v1/nodes/{id}/zfs/datasets [get]
+func (h *NodeZFSHandler) ListZFSDatasets(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	list, err := h.nodeZFSService.ListDatasets(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeZFSService.ListDatasets error", zap.Error(err))
+		v1.HandleError(ctx, nodeZFSErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, list)
+}
+
+// ListZFSPoolAlerts godoc
+// @Summary 获取 ZFS 存储池健康告警
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "集群ID"
+// @Param node_id query int false "节点ID"
+// @Param open query bool false "只返回未恢复的告警"
+// @Success 200 {object} v1.ListZFSPoolAlertsResponse
+// @Router /api/v1/nodes/zfs/alerts [get]
+func (h *NodeZFSHandler) ListZFSPoolAlerts(ctx *gin.Context) {
+	req := new(v1.ListZFSPoolAlertsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.nodeZFSService.ListAlerts(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeZFSService.ListAlerts error", zap.Error(err))
+		v1.HandleError(ctx, nodeZFSErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
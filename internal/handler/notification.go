@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type NotificationHandler struct {
+	*Handler
+	notificationService service.NotificationService
+}
+
+func NewNotificationHandler(handler *Handler, notificationService service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		Handler:             handler,
+		notificationService: notificationService,
+	}
+}
+
+func notificationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrNotificationNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListNotifications godoc
+// @Summary 获取当前用户的通知
+// @Description 任务结束、等待审核、告警触发时产生的站内通知，按时间从新到旧，同时返回全部未读数量
+// @Tags 通知模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param category query string false "类别: task、approval、alert 或 report"
+// @Param unread query bool false "只返回未读通知"
+// @Success 200 {object} v1.ListNotificationsResponse
+// @Router /api/v1/notifications [get]
+func (h *NotificationHandler) ListNotifications(ctx *gin.Context) {
+	req := new(v1.ListNotificationsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.notificationService.List(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("notificationService.List error", zap.Error(err))
+		v1.HandleError(ctx, notificationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetUnreadCount godoc
+// @Summary 获取当前用户的未读通知数量
+// @Tags 通知模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.NotificationUnreadCountResponse
+// @Router /api/v1/notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(ctx *gin.Context) {
+	data, err := h.notificationService.UnreadCount(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("notificationService.UnreadCount error", zap.Error(err))
+		v1.HandleError(ctx, notificationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// MarkNotificationsRead godoc
+// @Summary 标记通知已读
+// @Description ids 为空时标记全部未读通知，可用 category 限定类别
+// @Tags 通知模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.MarkNotificationsReadRequest true "params"
+// @Success 200 {object} v1.MarkNotificationsReadResponse
+// @Router /api/v1/notifications/read [post]
+func (h *NotificationHandler) MarkNotificationsRead(ctx *gin.Context) {
+	req := new(v1.MarkNotificationsReadRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.notificationService.MarkRead(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("notificationService.MarkRead error", zap.Error(err))
+		v1.HandleError(ctx, notificationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteNotification godoc
+// @Summary 删除通知
+// @Tags 通知模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "通知ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/notifications/{id} [delete]
+func (h *NotificationHandler) DeleteNotification(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.notificationService.Delete(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("notificationService.Delete error", zap.Error(err))
+		v1.HandleError(ctx, notificationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OperationAuditHandler struct {
+	*Handler
+	auditService service.OperationAuditService
+}
+
+func NewOperationAuditHandler(handler *Handler, auditService service.OperationAuditService) *OperationAuditHandler {
+	return &OperationAuditHandler{
+		Handler:      handler,
+		auditService: auditService,
+	}
+}
+
+func operationAuditErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrOperationAuditNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RecordOperation 由 OperationAuditMiddleware 在请求结束时调用
+func (h *OperationAuditHandler) RecordOperation(ctx *gin.Context, operationID string, calls *proxmox.CallRecorder, duration time.Duration) {
+	h.auditService.Record(ctx, &service.OperationRecord{
+		OperationID: operationID,
+		Method:      ctx.Request.Method,
+		Route:       ctx.FullPath(),
+		Path:        ctx.Request.URL.Path,
+		StatusCode:  ctx.Writer.Status(),
+		UserID:      GetUserIdFromCtx(ctx),
+		Duration:    duration,
+		Calls:       calls,
+	})
+}
+
+// GetOperation godoc
+// @Summary 获取操作记录
+// @Description 返回变更请求（响应头 X-Operation-Id）期间调用的 Proxmox API 及启动的任务 UPID；非管理员只能查看自己的操作
+// @Tags 操作审计模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param operation_id path string true "操作ID"
+// @Success 200 {object} v1.OperationAuditResponse
+// @Router /api/v1/operations/{operation_id} [get]
+func (h *OperationAuditHandler) GetOperation(ctx *gin.Context) {
+	item, err := h.auditService.Get(ctx, ctx.Param("operation_id"))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("auditService.Get error", zap.Error(err))
+		v1.HandleError(ctx, operationAuditErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, item)
+}
+
+// ListOperations godoc
+// @Summary 获取操作记录列表
+// @Description 非管理员只返回自己的操作，列表不包含调用明细
+// @Tags 操作审计模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param username query string false "用户名"
+// @Param method query string false "请求方法（POST, PUT, PATCH, DELETE）"
+// @Param path query string false "请求路径前缀"
+// @Param upid query string false "Proxmox 任务 UPID"
+// @Param has_tasks query bool false "只返回启动了任务的操作"
+// @Success 200 {object} v1.ListOperationAuditsResponse
+// @Router /api/v1/operations [get]
+func (h *OperationAuditHandler) ListOperations(ctx *gin.Context) {
+	req := new(v1.ListOperationAuditsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.auditService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("auditService.List error", zap.Error(err))
+		v1.HandleError(ctx, operationAuditErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
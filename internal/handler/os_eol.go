@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type OSEOLHandler struct {
+	*Handler
+	osEOLService service.OSEOLService
+}
+
+func NewOSEOLHandler(handler *Handler, osEOLService service.OSEOLService) *OSEOLHandler {
+	return &OSEOLHandler{
+		Handler:      handler,
+		osEOLService: osEOLService,
+	}
+}
+
+// Report godoc
+// @Summary 操作系统停止维护合规报告
+// @Description 根据 guest agent 采集的操作系统信息与内置 EOL 数据库（可通过 os_eol.rules 补充）比对，统计运行已停止维护或 90 天内停止维护操作系统的虚拟机，并按操作系统版本汇总。未采集到操作系统信息的虚拟机计入 unknown
+// @Tags 操作系统生命周期
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "集群ID，默认全部集群"
+// @Param status query string false "只列出该状态的虚拟机：eol / eol_soon"
+// @Success 200 {object} v1.OSEOLReportResponse
+// @Router /api/v1/os-eol/report [get]
+func (h *OSEOLHandler) Report(ctx *gin.Context) {
+	req := new(v1.OSEOLReportRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.osEOLService.Report(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("osEOLService.Report error", zap.Error(err))
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrClusterNotFound) {
+			status = http.StatusNotFound
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
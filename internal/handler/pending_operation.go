@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PendingOperationHandler struct {
+	*Handler
+	opService service.PendingOperationService
+}
+
+func NewPendingOperationHandler(handler *Handler, opService service.PendingOperationService) *PendingOperationHandler {
+	return &PendingOperationHandler{
+		Handler:   handler,
+		opService: opService,
+	}
+}
+
+func pendingOperationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrPendingOperationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrPendingOperationFinished):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListPendingOperations godoc
+// @Summary 查询待重试操作
+// @Description 集群暂时不可达时排队的修改配置、标签和 cloud-init 操作，可按集群、虚拟机和状态过滤
+// @Tags 待重试操作模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "集群ID"
+// @Param vm_id query int false "虚拟机ID"
+// @Param status query string false "状态 pending/running/succeeded/failed/cancelled"
+// @Success 200 {object} v1.ListPendingOperationsResponse
+// @Router /api/v1/pending-operations [get]
+func (h *PendingOperationHandler) ListPendingOperations(ctx *gin.Context) {
+	req := new(v1.ListPendingOperationsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.opService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("opService.List error", zap.Error(err))
+		v1.HandleError(ctx, pendingOperationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetPendingOperation godoc
+// @Summary 获取待重试操作
+// @Tags 待重试操作模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "操作ID"
+// @Success 200 {object} v1.PendingOperationResponse
+// @Router /api/v1/pending-operations/{id} [get]
+func (h *PendingOperationHandler) GetPendingOperation(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.opService.Get(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("opService.Get error", zap.Error(err))
+		v1.HandleError(ctx, pendingOperationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// FlushPendingOperation godoc
+// @Summary 立即重试待重试操作
+// @Description 创建人或管理员可操作；集群仍不可达时保持 pending 并记录错误
+// @Tags 待重试操作模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "操作ID"
+// @Success 200 {object} v1.PendingOperationResponse
+// @Router /api/v1/pending-operations/{id}/flush [post]
+func (h *PendingOperationHandler) FlushPendingOperation(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.opService.Flush(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("opService.Flush error", zap.Error(err))
+		v1.HandleError(ctx, pendingOperationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// FlushClusterPendingOperations godoc
+// @Summary 立即重试集群上的待重试操作
+// @Description 按创建顺序重试当前用户可操作的全部 pending 操作（管理员为全部）；同一虚拟机前面的操作未完成时，后面的操作继续排队
+// @Tags 待重试操作模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.FlushPendingOperationsRequest true "params"
+// @Success 200 {object} v1.FlushPendingOperationsResponse
+// @Router /api/v1/pending-operations/flush [post]
+func (h *PendingOperationHandler) FlushClusterPendingOperations(ctx *gin.Context) {
+	req := new(v1.FlushPendingOperationsRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.opService.FlushCluster(ctx, GetUserIdFromCtx(ctx), req.ClusterID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("opService.FlushCluster error", zap.Error(err))
+		v1.HandleError(ctx, pendingOperationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CancelPendingOperation godoc
+// @Summary 取消待重试操作
+// @Description 创建人或管理员可操作
+// @Tags 待重试操作模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "操作ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/pending-operations/{id}/cancel [post]
+func (h *PendingOperationHandler) CancelPendingOperation(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.opService.Cancel(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("opService.Cancel error", zap.Error(err))
+		v1.HandleError(ctx, pendingOperationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
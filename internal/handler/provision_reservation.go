@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ReservationHandler struct {
+	*Handler
+	reservations service.ProvisionReservationService
+}
+
+func NewReservationHandler(handler *Handler, reservations service.ProvisionReservationService) *ReservationHandler {
+	return &ReservationHandler{
+		Handler:      handler,
+		reservations: reservations,
+	}
+}
+
+// ListReservations godoc
+// @Summary 获取创建资源预留
+// @Description 返回进行中的创建请求在各节点上预留的 CPU、内存和磁盘空间，创建或克隆任务结束后自动释放
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ListProvisionReservationsRequest true "params"
+// @Success 200 {object} v1.ListProvisionReservationsResponse
+// @Router /api/v1/provision-reservations [get]
+func (h *ReservationHandler) ListReservations(ctx *gin.Context) {
+	req := new(v1.ListProvisionReservationsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.reservations.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reservations.List error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
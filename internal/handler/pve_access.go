@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PveAccessHandler struct {
+	*Handler
+	pveAccessService service.PveAccessService
+}
+
+func NewPveAccessHandler(handler *Handler, pveAccessService service.PveAccessService) *PveAccessHandler {
+	return &PveAccessHandler{
+		Handler:          handler,
+		pveAccessService: pveAccessService,
+	}
+}
+
+func pveAccessErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidPveUserID), errors.Is(err, v1.ErrInvalidParameter), errors.Is(err, v1.ErrPveLoginFailed):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrPveTokenExists):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListPveUsers godoc
+// @Summary 获取 PVE 用户列表
+// @Description 仅管理员可操作。返回集群中的 PVE 用户及其 API Token
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int true "集群ID"
+// @Success 200 {object} v1.ListPveUsersResponse
+// @Router /api/v1/pve/access/users [get]
+func (h *PveAccessHandler) ListPveUsers(ctx *gin.Context) {
+	req := new(v1.PveAccessClusterQuery)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.pveAccessService.ListUsers(ctx, GetUserIdFromCtx(ctx), req.ClusterID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.ListUsers error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CreatePveUser godoc
+// @Summary 创建 PVE 用户
+// @Description 仅管理员可操作
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreatePveUserRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/pve/access/users [post]
+func (h *PveAccessHandler) CreatePveUser(ctx *gin.Context) {
+	req := new(v1.CreatePveUserRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.pveAccessService.CreateUser(ctx, GetUserIdFromCtx(ctx), req); err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.CreateUser error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdatePveUser godoc
+// @Summary 更新 PVE 用户
+// @Description 仅管理员可操作，未传的字段保持不变
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userid path string true "PVE 用户ID（name@realm）"
+// @Param request body v1.UpdatePveUserRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/pve/access/users/{userid} [put]
+func (h *PveAccessHandler) UpdatePveUser(ctx *gin.Context) {
+	req := new(v1.UpdatePveUserRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.pveAccessService.UpdateUser(ctx, GetUserIdFromCtx(ctx), ctx.Param("userid"), req); err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.UpdateUser error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeletePveUser godoc
+// @Summary 删除 PVE 用户
+// @Description 仅管理员可操作。不能删除 root@pam 以及集群当前凭据所属的用户
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userid path string true "PVE 用户ID（name@realm）"
+// @Param cluster_id query int true "集群ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/pve/access/users/{userid} [delete]
+func (h *PveAccessHandler) DeletePveUser(ctx *gin.Context) {
+	req := new(v1.PveAccessClusterQuery)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.pveAccessService.DeleteUser(ctx, GetUserIdFromCtx(ctx), req.ClusterID, ctx.Param("userid")); err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.DeleteUser error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListPveTokens godoc
+// @Summary 获取 PVE 用户的 API Token 列表
+// @Description 仅管理员可操作
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userid path string true "PVE 用户ID（name@realm）"
+// @Param cluster_id query int true "集群ID"
+// @Success 200 {object} v1.ListPveTokensResponse
+// @Router /api/v1/pve/access/users/{userid}/tokens [get]
+func (h *PveAccessHandler) ListPveTokens(ctx *gin.Context) {
+	req := new(v1.PveAccessClusterQuery)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.pveAccessService.ListTokens(ctx, GetUserIdFromCtx(ctx), req.ClusterID, ctx.Param("userid"))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.ListTokens error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CreatePveToken godoc
+// @Summary 创建 PVE API Token
+// @Description 仅管理员可操作。Token secret 只在本次响应中返回
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userid path string true "PVE 用户ID（name@realm）"
+// @Param request body v1.CreatePveTokenRequest true "params"
+// @Success 200 {object} v1.CreatePveTokenResponse
+// @Router /api/v1/pve/access/users/{userid}/tokens [post]
+func (h *PveAccessHandler) CreatePveToken(ctx *gin.Context) {
+	req := new(v1.CreatePveTokenRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.pveAccessService.CreateToken(ctx, GetUserIdFromCtx(ctx), ctx.Param("userid"), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.CreateToken error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeletePveToken godoc
+// @Summary 删除 PVE API Token
+// @Description 仅管理员可操作。不能删除集群当前使用的 Token
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param userid path string true "PVE 用户ID（name@realm）"
+// @Param tokenid path string true "Token 名称"
+// @Param cluster_id query int true "集群ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/pve/access/users/{userid}/tokens/{tokenid} [delete]
+func (h *PveAccessHandler) DeletePveToken(ctx *gin.Context) {
+	req := new(v1.PveAccessClusterQuery)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.pveAccessService.DeleteToken(ctx, GetUserIdFromCtx(ctx), req.ClusterID, ctx.Param("userid"), ctx.Param("tokenid")); err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.DeleteToken error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListPveACL godoc
+// @Summary 获取 PVE ACL 列表
+// @Description 仅管理员可操作
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int true "集群ID"
+// @Success 200 {object} v1.ListPveACLResponse
+// @Router /api/v1/pve/access/acl [get]
+func (h *PveAccessHandler) ListPveACL(ctx *gin.Context) {
+	req := new(v1.PveAccessClusterQuery)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.pveAccessService.ListACL(ctx, GetUserIdFromCtx(ctx), req.ClusterID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.ListACL error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdatePveACL godoc
+// @Summary 添加或删除 PVE ACL
+// @Description 仅管理员可操作。delete=true 时删除匹配的 ACL
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.UpdatePveACLRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/pve/access/acl [put]
+func (h *PveAccessHandler) UpdatePveACL(ctx *gin.Context) {
+	req := new(v1.UpdatePveACLRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.pveAccessService.UpdateACL(ctx, GetUserIdFromCtx(ctx), req); err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.UpdateACL error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListPveRoles godoc
+// @Summary 获取 PVE 角色列表
+// @Description 仅管理员可操作
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int true "集群ID"
+// @Success 200 {object} v1.ListPveRolesResponse
+// @Router /api/v1/pve/access/roles [get]
+func (h *PveAccessHandler) ListPveRoles(ctx *gin.Context) {
+	req := new(v1.PveAccessClusterQuery)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.pveAccessService.ListRoles(ctx, GetUserIdFromCtx(ctx), req.ClusterID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.ListRoles error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CheckPvePermissions godoc
+// @Summary 检查集群凭据的权限
+// @Description 仅管理员可操作。检查集群当前 API Token 能否认证（401 时给出排查提示）以及在 / 上缺少哪些 PveSphere 需要的权限
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int true "集群ID"
+// @Success 200 {object} v1.CheckPvePermissionsResponse
+// @Router /api/v1/pve/access/permissions/check [get]
+func (h *PveAccessHandler) CheckPvePermissions(ctx *gin.Context) {
+	req := new(v1.PveAccessClusterQuery)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.pveAccessService.CheckPermissions(ctx, GetUserIdFromCtx(ctx), req.ClusterID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.CheckPermissions error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// BootstrapPveAccess godoc
+// @Summary 引导创建 PveSphere 专用 API Token
+// @Description 仅管理员可操作。使用管理员账号密码登录 PVE，创建最小权限角色（默认 PveSphere）、用户（默认 pvesphere@pve）并在 / 上授权，
+// @Description 然后创建不启用权限分离的 API Token。账号密码不会保存；传 cluster_id 时验证通过后自动更新集群凭据
+// @Tags PVE访问控制模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.BootstrapPveAccessRequest true "params"
+// @Success 200 {object} v1.BootstrapPveAccessResponse
+// @Router /api/v1/pve/access/bootstrap [post]
+func (h *PveAccessHandler) BootstrapPveAccess(ctx *gin.Context) {
+	req := new(v1.BootstrapPveAccessRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.pveAccessService.Bootstrap(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("pveAccessService.Bootstrap error", zap.Error(err))
+		v1.HandleError(ctx, pveAccessErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -36,7 +36,7 @@ func NewPveAuthHandler(handler *Handler, clusterService service.PveClusterServic
 func (h *PveAuthHandler) GetAccessTicket(ctx *gin.Context) {
 	req := new(v1.GetAccessTicketRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 
 	"net/http"
@@ -13,39 +14,43 @@ import (
 
 type PveClusterHandler struct {
 	*Handler
-	clusterService service.PveClusterService
+	clusterService    service.PveClusterService
+	capabilityService service.ClusterCapabilityService
 }
 
-func NewPveClusterHandler(handler *Handler, clusterService service.PveClusterService) *PveClusterHandler {
+func NewPveClusterHandler(handler *Handler, clusterService service.PveClusterService, capabilityService service.ClusterCapabilityService) *PveClusterHandler {
 	return &PveClusterHandler{
-		Handler:        handler,
-		clusterService: clusterService,
+		Handler:           handler,
+		clusterService:    clusterService,
+		capabilityService: capabilityService,
 	}
 }
 
 // CreateCluster godoc
 // @Summary 创建集群
+// @Description 创建后立即探测 API Token 的权限，返回能力矩阵和缺少的权限（探测失败不影响创建）
 // @Tags PVE集群模块
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param request body v1.CreateClusterRequest true "params"
-// @Success 200 {object} v1.Response
+// @Success 200 {object} v1.CreateClusterResponse
 // @Router /api/v1/clusters [post]
 func (h *PveClusterHandler) CreateCluster(ctx *gin.Context) {
 	req := new(v1.CreateClusterRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
-	if err := h.clusterService.CreateCluster(ctx, req); err != nil {
+	data, err := h.clusterService.CreateCluster(ctx, req)
+	if err != nil {
 		h.logger.WithContext(ctx).Error("clusterService.CreateCluster error", zap.Error(err))
 		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
 		return
 	}
 
-	v1.HandleSuccess(ctx, nil)
+	v1.HandleSuccess(ctx, data)
 }
 
 // UpdateCluster godoc
@@ -68,7 +73,7 @@ func (h *PveClusterHandler) UpdateCluster(ctx *gin.Context) {
 
 	req := new(v1.UpdateClusterRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -144,12 +149,13 @@ func (h *PveClusterHandler) GetCluster(ctx *gin.Context) {
 // @Param page_size query int false "每页数量" default(10)
 // @Param env query string false "环境"
 // @Param region query string false "区域"
+// @Param site_id query int false "站点ID"
 // @Success 200 {object} v1.ListClusterResponse
 // @Router /api/v1/clusters [get]
 func (h *PveClusterHandler) ListClusters(ctx *gin.Context) {
 	req := new(v1.ListClusterRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -187,7 +193,7 @@ func (h *PveClusterHandler) ListClusters(ctx *gin.Context) {
 func (h *PveClusterHandler) GetClusterStatus(ctx *gin.Context) {
 	req := new(v1.GetClusterStatusRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -208,16 +214,17 @@ func (h *PveClusterHandler) GetClusterStatus(ctx *gin.Context) {
 // @Produce json
 // @Security Bearer
 // @Param cluster_id query int true "集群ID"
+// @Param type query string false "资源类型（vm, qemu, lxc, node, storage, sdn, pool）"
 // @Success 200 {object} v1.GetClusterResourcesResponse
 // @Router /api/v1/clusters/resources [get]
 func (h *PveClusterHandler) GetClusterResources(ctx *gin.Context) {
 	req := new(v1.GetClusterResourcesRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
-	resources, err := h.clusterService.GetClusterResources(ctx, req.ClusterID)
+	resources, err := h.clusterService.GetClusterResources(ctx, req.ClusterID, req.Type)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("clusterService.GetClusterResources error", zap.Error(err))
 		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
@@ -243,7 +250,7 @@ func (h *PveClusterHandler) GetClusterResources(ctx *gin.Context) {
 func (h *PveClusterHandler) VerifyCluster(ctx *gin.Context) {
 	req := new(v1.VerifyClusterRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -272,3 +279,95 @@ func (h *PveClusterHandler) VerifyCluster(ctx *gin.Context) {
 
 	v1.HandleSuccess(ctx, data)
 }
+
+// ProbeCluster godoc
+// @Summary 接入向导：探测集群能力
+// @Description 保存集群前使用 api_url + user_id + user_token 检查认证和 Token 在 / 上的权限，逐项报告各功能缺少的权限，结果不保存
+// @Tags PVE集群模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ProbeClusterRequest true "params"
+// @Success 200 {object} v1.ClusterCapabilityResponse
+// @Router /api/v1/clusters/probe [post]
+func (h *PveClusterHandler) ProbeCluster(ctx *gin.Context) {
+	req := new(v1.ProbeClusterRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.capabilityService.ProbeCredentials(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("capabilityService.ProbeCredentials error", zap.Error(err))
+		v1.HandleError(ctx, clusterCapabilityErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetClusterCapabilities godoc
+// @Summary 获取集群能力矩阵
+// @Description 返回最近一次探测保存的能力矩阵，前端据此禁用当前 Token 无权使用的功能；从未探测过时立即探测
+// @Tags PVE集群模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "集群ID"
+// @Success 200 {object} v1.ClusterCapabilityResponse
+// @Router /api/v1/clusters/{id}/capabilities [get]
+func (h *PveClusterHandler) GetClusterCapabilities(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.capabilityService.Get(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("capabilityService.Get error", zap.Error(err))
+		v1.HandleError(ctx, clusterCapabilityErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ProbeClusterCapabilities godoc
+// @Summary 重新探测集群能力
+// @Description 调整 PVE 侧权限后重新探测并保存能力矩阵
+// @Tags PVE集群模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "集群ID"
+// @Success 200 {object} v1.ClusterCapabilityResponse
+// @Router /api/v1/clusters/{id}/capabilities/probe [post]
+func (h *PveClusterHandler) ProbeClusterCapabilities(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.capabilityService.Probe(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("capabilityService.Probe error", zap.Error(err))
+		v1.HandleError(ctx, clusterCapabilityErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+func clusterCapabilityErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidParameter):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,13 +1,13 @@
 package handler
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"net/url"
 	"strconv"
 
 	v1 "pvesphere/api/v1"
 	"pvesphere/internal/service"
+	"pvesphere/pkg/proxmox"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -16,13 +16,17 @@ import (
 
 type PveNodeHandler struct {
 	*Handler
-	nodeService service.PveNodeService
+	nodeService  service.PveNodeService
+	consoleAudit service.ConsoleAuditService
+	consoleProxy service.ConsoleProxyService
 }
 
-func NewPveNodeHandler(handler *Handler, nodeService service.PveNodeService) *PveNodeHandler {
+func NewPveNodeHandler(handler *Handler, nodeService service.PveNodeService, consoleAudit service.ConsoleAuditService, consoleProxy service.ConsoleProxyService) *PveNodeHandler {
 	return &PveNodeHandler{
-		Handler:     handler,
-		nodeService: nodeService,
+		Handler:      handler,
+		nodeService:  nodeService,
+		consoleAudit: consoleAudit,
+		consoleProxy: consoleProxy,
 	}
 }
 
@@ -38,7 +42,7 @@ func NewPveNodeHandler(handler *Handler, nodeService service.PveNodeService) *Pv
 func (h *PveNodeHandler) CreateNode(ctx *gin.Context) {
 	req := new(v1.CreateNodeRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -71,7 +75,7 @@ func (h *PveNodeHandler) UpdateNode(ctx *gin.Context) {
 
 	req := new(v1.UpdateNodeRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -148,12 +152,19 @@ func (h *PveNodeHandler) GetNode(ctx *gin.Context) {
 // @Param cluster_id query int false "集群ID"
 // @Param env query string false "环境"
 // @Param status query string false "状态"
+// @Param with_metrics query bool false "返回实时指标（CPU、内存使用率、运行中虚拟机数）"
+// @Param storage query string false "返回该存储在各节点上的剩余空间"
+// @Param max_cpu_percent query number false "只返回 CPU 使用率不高于该值的节点"
+// @Param max_memory_percent query number false "只返回内存使用率不高于该值的节点"
+// @Param min_storage_free_gb query number false "只返回 storage 剩余空间不少于该值（GiB）的节点"
+// @Param sort_by query string false "排序字段" Enums(id, name, cpu, memory, running_vms, storage_free)
+// @Param order query string false "排序方向" Enums(asc, desc)
 // @Success 200 {object} v1.ListNodeResponse
 // @Router /api/v1/nodes [get]
 func (h *PveNodeHandler) ListNodes(ctx *gin.Context) {
 	req := new(v1.ListNodeRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -172,7 +183,11 @@ func (h *PveNodeHandler) ListNodes(ctx *gin.Context) {
 	data, err := h.nodeService.ListNodes(ctx, req)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("nodeService.ListNodes error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrBadRequest) {
+			status = http.StatusBadRequest
+		}
+		v1.HandleError(ctx, status, err, nil)
 		return
 	}
 
@@ -191,7 +206,7 @@ func (h *PveNodeHandler) ListNodes(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeStatus(ctx *gin.Context) {
 	req := new(v1.GetNodeStatusRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -217,7 +232,7 @@ func (h *PveNodeHandler) GetNodeStatus(ctx *gin.Context) {
 func (h *PveNodeHandler) SetNodeStatus(ctx *gin.Context) {
 	req := new(v1.SetNodeStatusRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -245,7 +260,7 @@ func (h *PveNodeHandler) SetNodeStatus(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeServices(ctx *gin.Context) {
 	req := new(v1.GetNodeServicesRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -273,7 +288,7 @@ func (h *PveNodeHandler) StartNodeService(ctx *gin.Context) {
 	req := new(v1.StartNodeServiceRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("StartNodeService bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -303,7 +318,7 @@ func (h *PveNodeHandler) StopNodeService(ctx *gin.Context) {
 	req := new(v1.StopNodeServiceRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("StopNodeService bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -333,7 +348,7 @@ func (h *PveNodeHandler) RestartNodeService(ctx *gin.Context) {
 	req := new(v1.RestartNodeServiceRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("RestartNodeService bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -363,7 +378,7 @@ func (h *PveNodeHandler) GetNodeNetworks(ctx *gin.Context) {
 	req := new(v1.GetNodeNetworksRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
 		h.logger.WithContext(ctx).Error("GetNodeNetworks bind query error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -391,7 +406,7 @@ func (h *PveNodeHandler) CreateNodeNetwork(ctx *gin.Context) {
 	req := new(v1.CreateNodeNetworkRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("CreateNodeNetwork bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -418,7 +433,7 @@ func (h *PveNodeHandler) ReloadNodeNetwork(ctx *gin.Context) {
 	req := new(v1.ReloadNodeNetworkRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("ReloadNodeNetwork bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -445,7 +460,7 @@ func (h *PveNodeHandler) RevertNodeNetwork(ctx *gin.Context) {
 	req := new(v1.RevertNodeNetworkRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("RevertNodeNetwork bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -472,7 +487,7 @@ func (h *PveNodeHandler) RevertNodeNetwork(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeRRDData(ctx *gin.Context) {
 	req := new(v1.GetNodeRRDDataRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -499,7 +514,7 @@ func (h *PveNodeHandler) GetNodeRRDData(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeDisksList(ctx *gin.Context) {
 	req := new(v1.GetNodeDisksListRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -525,7 +540,7 @@ func (h *PveNodeHandler) GetNodeDisksList(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeDisksDirectory(ctx *gin.Context) {
 	req := new(v1.GetNodeDisksDirectoryRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -551,7 +566,7 @@ func (h *PveNodeHandler) GetNodeDisksDirectory(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeDisksLVM(ctx *gin.Context) {
 	req := new(v1.GetNodeDisksLVMRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -577,7 +592,7 @@ func (h *PveNodeHandler) GetNodeDisksLVM(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeDisksLVMThin(ctx *gin.Context) {
 	req := new(v1.GetNodeDisksLVMThinRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -603,7 +618,7 @@ func (h *PveNodeHandler) GetNodeDisksLVMThin(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeDisksZFS(ctx *gin.Context) {
 	req := new(v1.GetNodeDisksZFSRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -629,7 +644,7 @@ func (h *PveNodeHandler) GetNodeDisksZFS(ctx *gin.Context) {
 func (h *PveNodeHandler) InitGPTDisk(ctx *gin.Context) {
 	req := new(v1.InitGPTDiskRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -655,7 +670,7 @@ func (h *PveNodeHandler) InitGPTDisk(ctx *gin.Context) {
 func (h *PveNodeHandler) WipeDisk(ctx *gin.Context) {
 	req := new(v1.WipeDiskRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -682,7 +697,7 @@ func (h *PveNodeHandler) WipeDisk(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeStorageStatus(ctx *gin.Context) {
 	req := new(v1.GetStorageStatusRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -711,7 +726,7 @@ func (h *PveNodeHandler) GetNodeStorageStatus(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeStorageRRDData(ctx *gin.Context) {
 	req := new(v1.GetStorageRRDDataRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -727,6 +742,7 @@ func (h *PveNodeHandler) GetNodeStorageRRDData(ctx *gin.Context) {
 
 // GetNodeStorageContent godoc
 // @Summary 获取节点存储内容列表
+// @Description 返回存储上的全部卷，需要分页、过滤或汇总时使用 /api/v1/nodes/storage/content/page
 // @Tags PVE节点模块
 // @Accept json
 // @Produce json
@@ -739,7 +755,7 @@ func (h *PveNodeHandler) GetNodeStorageRRDData(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeStorageContent(ctx *gin.Context) {
 	req := new(v1.GetStorageContentRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -753,6 +769,41 @@ func (h *PveNodeHandler) GetNodeStorageContent(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, items)
 }
 
+// ListNodeStorageContent godoc
+// @Summary 分页获取节点存储内容列表
+// @Description 在服务端过滤、排序和分页，并按内容类型汇总数量和大小
+// @Tags PVE节点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param node_id query int true "节点ID"
+// @Param storage query string true "存储名称"
+// @Param content query string false "内容类型过滤，如 images,iso,backup"
+// @Param vmid query int false "只返回属于该虚拟机的卷"
+// @Param name query string false "按卷标识或备注模糊匹配"
+// @Param page query int false "页码，默认 1"
+// @Param page_size query int false "每页数量，默认 100，最大 1000"
+// @Param sort_by query string false "排序字段 name/size/ctime，默认 name"
+// @Param order query string false "排序方向 asc/desc"
+// @Success 200 {object} v1.ListStorageContentResponse
+// @Router /api/v1/nodes/storage/content/page [get]
+func (h *PveNodeHandler) ListNodeStorageContent(ctx *gin.Context) {
+	req := new(v1.ListStorageContentRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.nodeService.ListNodeStorageContent(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nodeService.ListNodeStorageContent error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // GetNodeStorageVolume godoc
 // @Summary 获取节点存储卷属性
 // @Tags PVE节点模块
@@ -767,7 +818,7 @@ func (h *PveNodeHandler) GetNodeStorageContent(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeStorageVolume(ctx *gin.Context) {
 	req := new(v1.GetStorageVolumeRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -789,7 +840,7 @@ func (h *PveNodeHandler) GetNodeStorageVolume(ctx *gin.Context) {
 // @Security Bearer
 // @Param node_id formData int true "节点ID"
 // @Param storage formData string true "存储名称"
-// @Param content formData string false "内容类型" Enums(iso,vztmpl,backup,images)
+// @Param content formData string false "内容类型" Enums(iso,vztmpl,backup,images,import)
 // @Param file formData file true "上传文件"
 // @Success 200 {object} v1.Response
 // @Router /api/v1/nodes/storage/upload [post]
@@ -844,7 +895,7 @@ func (h *PveNodeHandler) UploadNodeStorageContent(ctx *gin.Context) {
 func (h *PveNodeHandler) DeleteNodeStorageContent(ctx *gin.Context) {
 	var req v1.DeleteStorageContentRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -875,11 +926,11 @@ func (h *PveNodeHandler) DeleteNodeStorageContent(ctx *gin.Context) {
 func (h *PveNodeHandler) GetNodeConsole(ctx *gin.Context) {
 	req := new(v1.GetNodeConsoleRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
-	data, err := h.nodeService.GetNodeConsole(ctx, req)
+	data, err := h.nodeService.GetNodeConsole(ctx, GetUserIdFromCtx(ctx), req)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("nodeService.GetNodeConsole error", zap.Error(err))
 		// 检查是否是预定义的错误类型
@@ -897,26 +948,11 @@ func (h *PveNodeHandler) GetNodeConsole(ctx *gin.Context) {
 	}
 
 	// 如果返回了 ws_token（无论是 vncshell 还是 termproxy），组装同域 websocket 连接地址（用于 noVNC/终端）
-		wsToken, _ := data["ws_token"].(string)
-		if wsToken != "" {
-			// 兼容前端可能使用 token 字段名
-			data["token"] = wsToken
-
-			scheme := "ws"
-			proto := ctx.Request.Header.Get("X-Forwarded-Proto")
-			if proto == "https" || proto == "wss" {
-				scheme = "wss"
-			} else if ctx.Request.TLS != nil {
-				scheme = "wss"
-			}
-
-			host := ctx.Request.Host
-			if xfHost := ctx.Request.Header.Get("X-Forwarded-Host"); xfHost != "" {
-				host = xfHost
-			}
-
-			wsURL := fmt.Sprintf("%s://%s/api/v1/nodes/console/ws?token=%s", scheme, host, url.QueryEscape(wsToken))
-			data["ws_url"] = wsURL
+	wsToken, _ := data["ws_token"].(string)
+	if wsToken != "" {
+		// 兼容前端可能使用 token 字段名
+		data["token"] = wsToken
+		data["ws_url"] = consoleWSURL(ctx, "/api/v1/nodes/console/ws", wsToken)
 	}
 
 	v1.HandleSuccess(ctx, data)
@@ -924,25 +960,24 @@ func (h *PveNodeHandler) GetNodeConsole(ctx *gin.Context) {
 
 // NodeConsoleWS godoc
 // @Summary 节点 Console WebSocket（VNC WebSocket 代理）
-// @Description 同域 WS 代理到 Proxmox vncwebsocket，供 noVNC 直接连接（仅 vncshell 模式）
+// @Description 同域 WS 代理到 Proxmox vncwebsocket。vncshell / termproxy 模式透明转发，供 noVNC / 终端直接连接；
+// @Description xtermjs 模式由服务端完成 termproxy 认证并设置初始终端大小，浏览器发送原始终端输入（UTF-8），
+// @Description 会话中可发送 {"type":"resize","cols":120,"rows":40} 调整终端大小，收到的消息为终端输出
 // @Tags PVE节点模块
 // @Security Bearer
 // @Param token query string true "ws_token（由 /api/v1/nodes/console 返回）"
 // @Router /api/v1/nodes/console/ws [get]
 func (h *PveNodeHandler) NodeConsoleWS(ctx *gin.Context) {
 	token := ctx.Query("token")
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
-	}
-
-	clientConn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	clientConn, release, err := upgradeConsoleWebsocket(ctx, h.consoleProxy)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("NodeConsoleWS: failed to upgrade websocket", zap.Error(err))
 		return
 	}
+	defer release()
 	defer clientConn.Close()
 
-	proxmoxConn, err := h.nodeService.DialNodeConsoleWebsocket(ctx, token)
+	proxmoxConn, target, err := h.nodeService.DialNodeConsoleWebsocket(ctx, token)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("NodeConsoleWS: failed to dial proxmox", zap.Error(err))
 		_ = clientConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "invalid console token"))
@@ -950,25 +985,21 @@ func (h *PveNodeHandler) NodeConsoleWS(ctx *gin.Context) {
 	}
 	defer proxmoxConn.Close()
 
-	h.logger.WithContext(ctx).Info("NodeConsoleWS: proxy established")
-
-	errCh := make(chan error, 2)
-	proxy := func(src, dst *websocket.Conn) {
-		for {
-			mt, msg, err := src.ReadMessage()
-			if err != nil {
-				errCh <- err
-				return
-			}
-			if err := dst.WriteMessage(mt, msg); err != nil {
-				errCh <- err
-				return
-			}
-		}
+	// 无法写入审计记录时拒绝连接，保证经由平台的控制台访问都可追溯
+	recorder, err := h.consoleAudit.StartSession(ctx, target, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		h.logger.WithContext(ctx).Error("NodeConsoleWS: failed to start console audit", zap.Error(err))
+		_ = clientConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "console audit unavailable"))
+		return
 	}
 
-	go proxy(clientConn, proxmoxConn)
-	go proxy(proxmoxConn, clientConn)
+	h.logger.WithContext(ctx).Info("NodeConsoleWS: proxy established")
 
-	<-errCh
+	// xtermjs 模式下认证与初始大小已由服务端完成，浏览器只需发送原始终端输入或 resize 控制消息
+	var transform func([]byte) []byte
+	if target.ConsoleType == "xtermjs" {
+		transform = proxmox.TermProxyClientMessage
+	}
+	err = proxyConsoleWebsocket(h.consoleProxy, clientConn, proxmoxConn, recorder, transform)
+	recorder.Close(consoleCloseReason(err))
 }
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type PveSiteHandler struct {
+	*Handler
+	siteService service.PveSiteService
+}
+
+func NewPveSiteHandler(handler *Handler, siteService service.PveSiteService) *PveSiteHandler {
+	return &PveSiteHandler{
+		Handler:     handler,
+		siteService: siteService,
+	}
+}
+
+func siteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrSiteNameExists), errors.Is(err, v1.ErrSiteHasClusters):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateSite godoc
+// @Summary 创建站点
+// @Description 站点用于按机房/地域对集群分组，集群通过 site_id 归属站点
+// @Tags PVE站点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateSiteRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/sites [post]
+func (h *PveSiteHandler) CreateSite(ctx *gin.Context) {
+	req := new(v1.CreateSiteRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.siteService.CreateSite(ctx, req, GetUserIdFromCtx(ctx)); err != nil {
+		h.logger.WithContext(ctx).Error("siteService.CreateSite error", zap.Error(err))
+		v1.HandleError(ctx, siteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdateSite godoc
+// @Summary 更新站点
+// @Tags PVE站点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "站点ID"
+// @Param request body v1.UpdateSiteRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/sites/{id} [put]
+func (h *PveSiteHandler) UpdateSite(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.UpdateSiteRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.siteService.UpdateSite(ctx, id, req, GetUserIdFromCtx(ctx)); err != nil {
+		h.logger.WithContext(ctx).Error("siteService.UpdateSite error", zap.Error(err))
+		v1.HandleError(ctx, siteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteSite godoc
+// @Summary 删除站点
+// @Description 站点下仍有集群时不允许删除
+// @Tags PVE站点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "站点ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/sites/{id} [delete]
+func (h *PveSiteHandler) DeleteSite(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.siteService.DeleteSite(ctx, id); err != nil {
+		h.logger.WithContext(ctx).Error("siteService.DeleteSite error", zap.Error(err))
+		v1.HandleError(ctx, siteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetSite godoc
+// @Summary 获取站点详情
+// @Tags PVE站点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "站点ID"
+// @Success 200 {object} v1.GetSiteResponse
+// @Router /api/v1/sites/{id} [get]
+func (h *PveSiteHandler) GetSite(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.siteService.GetSite(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("siteService.GetSite error", zap.Error(err))
+		v1.HandleError(ctx, siteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListSites godoc
+// @Summary 获取站点列表
+// @Tags PVE站点模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListSitesResponse
+// @Router /api/v1/sites [get]
+func (h *PveSiteHandler) ListSites(ctx *gin.Context) {
+	data, err := h.siteService.ListSites(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("siteService.ListSites error", zap.Error(err))
+		v1.HandleError(ctx, siteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -34,7 +34,7 @@ func NewPveStorageHandler(handler *Handler, storageService service.PveStorageSer
 func (h *PveStorageHandler) CreateStorage(ctx *gin.Context) {
 	req := new(v1.CreateStorageRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -67,7 +67,7 @@ func (h *PveStorageHandler) UpdateStorage(ctx *gin.Context) {
 
 	req := new(v1.UpdateStorageRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -150,7 +150,7 @@ func (h *PveStorageHandler) GetStorage(ctx *gin.Context) {
 func (h *PveStorageHandler) ListStorages(ctx *gin.Context) {
 	req := new(v1.ListStorageRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -34,7 +34,7 @@ func NewPveTaskHandler(handler *Handler, taskService service.PveTaskService) *Pv
 func (h *PveTaskHandler) ListClusterTasks(ctx *gin.Context) {
 	req := new(v1.ListClusterTasksRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -61,7 +61,7 @@ func (h *PveTaskHandler) ListClusterTasks(ctx *gin.Context) {
 func (h *PveTaskHandler) ListNodeTasks(ctx *gin.Context) {
 	req := new(v1.ListNodeTasksRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -91,7 +91,7 @@ func (h *PveTaskHandler) ListNodeTasks(ctx *gin.Context) {
 func (h *PveTaskHandler) GetTaskLog(ctx *gin.Context) {
 	req := new(v1.GetTaskLogRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -131,7 +131,7 @@ func (h *PveTaskHandler) GetTaskLog(ctx *gin.Context) {
 func (h *PveTaskHandler) GetTaskStatus(ctx *gin.Context) {
 	req := new(v1.GetTaskStatusRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -159,7 +159,7 @@ func (h *PveTaskHandler) GetTaskStatus(ctx *gin.Context) {
 func (h *PveTaskHandler) StopTask(ctx *gin.Context) {
 	req := new(v1.StopTaskRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
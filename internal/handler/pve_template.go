@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -35,7 +36,7 @@ func NewPveTemplateHandler(handler *Handler, templateService service.PveTemplate
 func (h *PveTemplateHandler) CreateTemplate(ctx *gin.Context) {
 	req := new(v1.CreateTemplateRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -68,7 +69,7 @@ func (h *PveTemplateHandler) UpdateTemplate(ctx *gin.Context) {
 
 	req := new(v1.UpdateTemplateRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -83,12 +84,14 @@ func (h *PveTemplateHandler) UpdateTemplate(ctx *gin.Context) {
 
 // DeleteTemplate godoc
 // @Summary 删除模板
+// @Description 删除各节点上的 Proxmox 模板虚拟机及同步任务、导入记录和实例记录，默认同时删除导入所用的备份文件。存在进行中的同步任务或链接克隆时返回 409 且不做任何改动
 // @Tags 模板管理
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path int true "模板ID"
-// @Success 200 {object} v1.Response
+// @Param keep_backup query bool false "保留备份文件"
+// @Success 200 {object} v1.DeleteTemplateResponse
 // @Router /api/v1/templates/{id} [delete]
 func (h *PveTemplateHandler) DeleteTemplate(ctx *gin.Context) {
 	idStr := ctx.Param("id")
@@ -97,14 +100,29 @@ func (h *PveTemplateHandler) DeleteTemplate(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
 		return
 	}
+	req := new(v1.DeleteTemplateRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
 
-	if err := h.templateService.DeleteTemplate(ctx, id); err != nil {
+	data, err := h.templateService.DeleteTemplate(ctx, id, req)
+	if err != nil {
 		h.logger.WithContext(ctx).Error("templateService.DeleteTemplate error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, v1.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, v1.ErrTemplateSyncInProgress), errors.Is(err, v1.ErrTemplateHasLinkedClones):
+			status = http.StatusConflict
+		case errors.Is(err, v1.ErrProxmoxRequestFailed):
+			status = http.StatusBadGateway
+		}
+		v1.HandleError(ctx, status, err, nil)
 		return
 	}
 
-	v1.HandleSuccess(ctx, nil)
+	v1.HandleSuccess(ctx, data)
 }
 
 // GetTemplate godoc
@@ -148,7 +166,7 @@ func (h *PveTemplateHandler) GetTemplate(ctx *gin.Context) {
 func (h *PveTemplateHandler) ListTemplates(ctx *gin.Context) {
 	req := new(v1.ListTemplateRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -1,8 +1,7 @@
 package handler
 
 import (
-	"fmt"
-	"net/url"
+	"errors"
 	"strconv"
 
 	"net/http"
@@ -16,13 +15,19 @@ import (
 
 type PveVMHandler struct {
 	*Handler
-	vmService service.PveVMService
+	vmService    service.PveVMService
+	createJobs   service.VMCreateJobService
+	consoleAudit service.ConsoleAuditService
+	consoleProxy service.ConsoleProxyService
 }
 
-func NewPveVMHandler(handler *Handler, vmService service.PveVMService) *PveVMHandler {
+func NewPveVMHandler(handler *Handler, vmService service.PveVMService, createJobs service.VMCreateJobService, consoleAudit service.ConsoleAuditService, consoleProxy service.ConsoleProxyService) *PveVMHandler {
 	return &PveVMHandler{
-		Handler:   handler,
-		vmService: vmService,
+		Handler:      handler,
+		vmService:    vmService,
+		createJobs:   createJobs,
+		consoleAudit: consoleAudit,
+		consoleProxy: consoleProxy,
 	}
 }
 
@@ -39,7 +44,7 @@ func NewPveVMHandler(handler *Handler, vmService service.PveVMService) *PveVMHan
 func (h *PveVMHandler) CreateVM(ctx *gin.Context) {
 	req := new(v1.CreateVMRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -52,32 +57,113 @@ func (h *PveVMHandler) CreateVM(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, nil)
 }
 
+// createVMErrorStatus 创建虚拟机接口的错误状态码：参数未通过集群校验或 ballooning 配置不合法返回 400
+func createVMErrorStatus(err error) int {
+	if errors.Is(err, v1.ErrCreateVMValidationFailed) || errors.Is(err, v1.ErrBalloonUnsupported) || errors.Is(err, v1.ErrInvalidParameter) {
+		return http.StatusBadRequest
+	}
+	return changeErrorStatus(err, http.StatusInternalServerError)
+}
+
 // CreateVMInProxmox godoc
 // @Summary 创建虚拟机（完整流程）
-// @Description 调用 Proxmox API 创建虚拟机并自动创建数据库记录，这是最常用的场景
+// @Description 调用 Proxmox API 创建虚拟机并自动创建数据库记录，这是最常用的场景。
+// @Description 创建前对照 Proxmox 校验存储（存在于节点且支持 images）、网桥、ISO 卷和 VMID 是否被占用，未通过时返回 400 并在 message 中列出全部问题
+// @Description async=true 时只校验集群和节点并立即返回创建任务，创建在后台执行；通过 /api/v1/vms/create-jobs/{id} 查询结果，完成后通知提交人
 // @Tags PVE虚拟机模块
 // @Accept json
 // @Produce json
 // @Security Bearer
+// @Param async query bool false "异步创建"
 // @Param request body v1.CreateVMRequest true "params"
-// @Success 200 {object} v1.Response
+// @Success 200 {object} v1.Response "async=true 时 data 为 v1.VMCreateJobItem"
 // @Router /api/v1/vms/create [post]
 func (h *PveVMHandler) CreateVMInProxmox(ctx *gin.Context) {
 	req := new(v1.CreateVMRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if async, _ := strconv.ParseBool(ctx.Query("async")); async {
+		job, err := h.createJobs.Submit(ctx, req)
+		if err != nil {
+			h.logger.WithContext(ctx).Error("createJobs.Submit error", zap.Error(err))
+			v1.HandleError(ctx, createVMErrorStatus(err), err, nil)
+			return
+		}
+		v1.HandleSuccess(ctx, job)
 		return
 	}
 
 	if err := h.vmService.CreateVMInProxmox(ctx, req); err != nil {
 		h.logger.WithContext(ctx).Error("vmService.CreateVMInProxmox error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, createVMErrorStatus(err), err, nil)
 		return
 	}
 
 	v1.HandleSuccess(ctx, nil)
 }
 
+// GetCreateJob godoc
+// @Summary 获取异步创建虚拟机任务
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} v1.VMCreateJobResponse
+// @Router /api/v1/vms/create-jobs/{id} [get]
+func (h *PveVMHandler) GetCreateJob(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	job, err := h.createJobs.Get(ctx, id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrVMCreateJobNotFound) {
+			status = http.StatusNotFound
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, job)
+}
+
+// ListCreateJobs godoc
+// @Summary 获取异步创建虚拟机任务列表
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param cluster_id query int false "集群ID"
+// @Param status query string false "状态（pending, running, completed, failed）"
+// @Param creator query string false "提交人"
+// @Success 200 {object} v1.ListVMCreateJobsResponse
+// @Router /api/v1/vms/create-jobs [get]
+func (h *PveVMHandler) ListCreateJobs(ctx *gin.Context) {
+	req := new(v1.ListVMCreateJobsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.createJobs.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("createJobs.List error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // UpdateVM godoc
 // @Summary 更新虚拟机
 // @Tags PVE虚拟机模块
@@ -98,7 +184,7 @@ func (h *PveVMHandler) UpdateVM(ctx *gin.Context) {
 
 	req := new(v1.UpdateVMRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -130,7 +216,7 @@ func (h *PveVMHandler) DeleteVM(ctx *gin.Context) {
 
 	if err := h.vmService.DeleteVM(ctx, id); err != nil {
 		h.logger.WithContext(ctx).Error("vmService.DeleteVM error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, changeErrorStatus(err, http.StatusInternalServerError), err, nil)
 		return
 	}
 
@@ -176,12 +262,18 @@ func (h *PveVMHandler) GetVM(ctx *gin.Context) {
 // @Param node_name query string false "节点名称"
 // @Param status query string false "状态"
 // @Param app_id query string false "应用ID"
+// @Param owner query string false "负责人"
+// @Param team query string false "所属团队"
+// @Param business_service query string false "业务服务"
+// @Param environment query string false "环境：prod / stage / dev"
+// @Param cost_center query string false "成本中心"
+// @Param review_due query bool false "只返回复核日期已到期的虚拟机"
 // @Success 200 {object} v1.ListVMResponse
 // @Router /api/v1/vms [get]
 func (h *PveVMHandler) ListVMs(ctx *gin.Context) {
 	req := new(v1.ListVMRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -226,7 +318,7 @@ func (h *PveVMHandler) StartVM(ctx *gin.Context) {
 
 	if err := h.vmService.StartVM(ctx, id); err != nil {
 		h.logger.WithContext(ctx).Error("vmService.StartVM error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, changeErrorStatus(err, http.StatusInternalServerError), err, nil)
 		return
 	}
 
@@ -235,11 +327,13 @@ func (h *PveVMHandler) StartVM(ctx *gin.Context) {
 
 // StopVM godoc
 // @Summary 停止虚拟机
+// @Description 默认正常关机（mode=shutdown），超时后是否强制断电由 force_stop 决定；mode=stop 直接断电。未传的参数使用 vm_stop.* 配置
 // @Tags PVE虚拟机模块
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path int true "虚拟机ID"
+// @Param request body v1.StopVMRequest false "params"
 // @Success 200 {object} v1.Response
 // @Router /api/v1/vms/{id}/stop [post]
 func (h *PveVMHandler) StopVM(ctx *gin.Context) {
@@ -250,9 +344,17 @@ func (h *PveVMHandler) StopVM(ctx *gin.Context) {
 		return
 	}
 
-	if err := h.vmService.StopVM(ctx, id); err != nil {
+	req := new(v1.StopVMRequest)
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			v1.HandleBindError(ctx, err)
+			return
+		}
+	}
+
+	if err := h.vmService.StopVM(ctx, id, req); err != nil {
 		h.logger.WithContext(ctx).Error("vmService.StopVM error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, changeErrorStatus(err, http.StatusInternalServerError), err, nil)
 		return
 	}
 
@@ -271,7 +373,7 @@ func (h *PveVMHandler) StopVM(ctx *gin.Context) {
 func (h *PveVMHandler) GetVMCurrentConfig(ctx *gin.Context) {
 	req := new(v1.GetVMCurrentConfigRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -297,7 +399,7 @@ func (h *PveVMHandler) GetVMCurrentConfig(ctx *gin.Context) {
 func (h *PveVMHandler) GetVMPendingConfig(ctx *gin.Context) {
 	req := new(v1.GetVMPendingConfigRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -323,21 +425,81 @@ func (h *PveVMHandler) GetVMPendingConfig(ctx *gin.Context) {
 func (h *PveVMHandler) UpdateVMConfig(ctx *gin.Context) {
 	req := new(v1.UpdateVMConfigRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
 	if err := h.vmService.UpdateVMConfig(ctx, req); err != nil {
 		h.logger.WithContext(ctx).Error("vmService.UpdateVMConfig error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, changeErrorStatus(err, http.StatusInternalServerError), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdateVMTags godoc
+// @Summary 更新虚拟机标签
+// @Description 写入 Proxmox tags（分号分隔，自动转小写并去重），tags 为空时删除标签。集群暂时不可达时加入重试队列，返回 202
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.UpdateVMTagsRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vms/tags [put]
+func (h *PveVMHandler) UpdateVMTags(ctx *gin.Context) {
+	req := new(v1.UpdateVMTagsRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.vmService.UpdateVMTags(ctx, req); err != nil {
+		h.logger.WithContext(ctx).Error("vmService.UpdateVMTags error", zap.Error(err))
+		v1.HandleError(ctx, changeErrorStatus(err, http.StatusInternalServerError), err, nil)
 		return
 	}
 
 	v1.HandleSuccess(ctx, nil)
 }
 
+// ResizeVMMemory godoc
+// @Summary 调整虚拟机内存与 ballooning
+// @Description 调整最大内存（memory_size）、balloon 最小内存（balloon_min，0 关闭 ballooning）和自动 ballooning 权重（shares）。
+// @Description 不支持 ballooning 的操作系统类型（l24、wxp、w2k、w2k3、solaris）开启 ballooning 时返回 400；
+// @Description 运行中的虚拟机未启用内存热插拔时，最大内存在重启后生效（pending_reboot=true）
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ResizeVMMemoryRequest true "params"
+// @Success 200 {object} v1.ResizeVMMemoryResponse
+// @Router /api/v1/vms/memory [put]
+func (h *PveVMHandler) ResizeVMMemory(ctx *gin.Context) {
+	req := new(v1.ResizeVMMemoryRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.vmService.ResizeVMMemory(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("vmService.ResizeVMMemory error", zap.Error(err))
+		status := changeErrorStatus(err, http.StatusInternalServerError)
+		if errors.Is(err, v1.ErrBalloonUnsupported) || errors.Is(err, v1.ErrInvalidParameter) {
+			status = http.StatusBadRequest
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // GetVMStatus godoc
 // @Summary 获取虚拟机状态
+// @Description 返回 Proxmox status/current，并附加 balloon_metrics（见 v1.VMBalloonMetrics）
 // @Tags PVE虚拟机模块
 // @Accept json
 // @Produce json
@@ -348,7 +510,7 @@ func (h *PveVMHandler) UpdateVMConfig(ctx *gin.Context) {
 func (h *PveVMHandler) GetVMStatus(ctx *gin.Context) {
 	req := new(v1.GetVMStatusRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -362,6 +524,44 @@ func (h *PveVMHandler) GetVMStatus(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, status)
 }
 
+// GetVMMetrics godoc
+// @Summary 获取虚拟机实时指标
+// @Description 一次返回 status/current 当前值、RRD 近期曲线和 guest agent 数据（系统信息、平均负载），替代分别调用 status、rrd 和 agent 接口。RRD 或 agent 获取失败时不影响其他部分，原因记录在 warnings 中
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param timeframe query string false "RRD 时间范围，默认 hour" Enums(hour, day, week, month, year)
+// @Param cf query string false "RRD 聚合函数，默认 AVERAGE" Enums(AVERAGE, MAX)
+// @Success 200 {object} v1.GetVMMetricsResponse
+// @Router /api/v1/vms/{id}/metrics [get]
+func (h *PveVMHandler) GetVMMetrics(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.GetVMMetricsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.vmService.GetVMMetrics(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("vmService.GetVMMetrics error", zap.Error(err))
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // GetVMRRDData godoc
 // @Summary 获取虚拟机RRD监控数据
 // @Tags PVE虚拟机模块
@@ -376,7 +576,7 @@ func (h *PveVMHandler) GetVMStatus(ctx *gin.Context) {
 func (h *PveVMHandler) GetVMRRDData(ctx *gin.Context) {
 	req := new(v1.GetVMRRDDataRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -403,44 +603,81 @@ func (h *PveVMHandler) GetVMRRDData(ctx *gin.Context) {
 func (h *PveVMHandler) GetVMConsole(ctx *gin.Context) {
 	req := new(v1.GetVMConsoleRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
-	data, err := h.vmService.GetVMConsole(ctx, req)
+	data, err := h.vmService.GetVMConsole(ctx, GetUserIdFromCtx(ctx), req)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("vmService.GetVMConsole error", zap.Error(err))
 		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
 		return
 	}
 
-	// 组装同域 websocket 连接地址（用于 noVNC）
-	// 这里返回我们后端的 ws 代理地址，避免跨域/证书/鉴权问题
-	wsToken, _ := data["ws_token"].(string)
-	if wsToken != "" {
-		// 兼容前端可能使用 token 字段名
-		data["token"] = wsToken
-
-		scheme := "ws"
-		proto := ctx.Request.Header.Get("X-Forwarded-Proto")
-		if proto == "https" || proto == "wss" {
-			scheme = "wss"
-		} else if ctx.Request.TLS != nil {
-			scheme = "wss"
-		}
+	h.fillVMConsoleWSURL(ctx, data)
+	v1.HandleSuccess(ctx, data)
+}
 
-		host := ctx.Request.Host
-		if xfHost := ctx.Request.Header.Get("X-Forwarded-Host"); xfHost != "" {
-			host = xfHost
-		}
+// RenewVMConsoleSession godoc
+// @Summary 续期虚拟机 Console 会话
+// @Description 顺延控制台会话的空闲有效期（30 分钟，最长 12 小时），控制台打开期间前端应定期调用
+// @Tags PVE虚拟机模块
+// @Produce json
+// @Security Bearer
+// @Param session_id path string true "会话ID（由 /api/v1/vms/console 返回）"
+// @Success 200 {object} v1.RenewVMConsoleSessionResponse
+// @Router /api/v1/vms/console/sessions/{session_id}/renew [post]
+func (h *PveVMHandler) RenewVMConsoleSession(ctx *gin.Context) {
+	data, err := h.vmService.RenewVMConsoleSession(ctx, GetUserIdFromCtx(ctx), ctx.Param("session_id"))
+	if err != nil {
+		v1.HandleError(ctx, consoleSessionErrorStatus(err), err, nil)
+		return
+	}
+	v1.HandleSuccess(ctx, data)
+}
 
-		wsURL := fmt.Sprintf("%s://%s/api/v1/vms/console/ws?token=%s", scheme, host, url.QueryEscape(wsToken))
-		data["ws_url"] = wsURL
+// ReconnectVMConsole godoc
+// @Summary 重连虚拟机 Console
+// @Description 连接断开后为会话签发新的 ws_token；vncproxy ticket 尚未使用且仍有效时复用，否则重新获取 port / ticket
+// @Tags PVE虚拟机模块
+// @Produce json
+// @Security Bearer
+// @Param session_id path string true "会话ID（由 /api/v1/vms/console 返回）"
+// @Success 200 {object} v1.GetVMConsoleResponse
+// @Router /api/v1/vms/console/sessions/{session_id}/reconnect [post]
+func (h *PveVMHandler) ReconnectVMConsole(ctx *gin.Context) {
+	data, err := h.vmService.ReconnectVMConsole(ctx, GetUserIdFromCtx(ctx), ctx.Param("session_id"))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("vmService.ReconnectVMConsole error", zap.Error(err))
+		v1.HandleError(ctx, consoleSessionErrorStatus(err), err, nil)
+		return
 	}
 
+	h.fillVMConsoleWSURL(ctx, data)
 	v1.HandleSuccess(ctx, data)
 }
 
+// fillVMConsoleWSURL 组装同域 websocket 连接地址（用于 noVNC）
+// 这里返回我们后端的 ws 代理地址，避免跨域/证书/鉴权问题
+func (h *PveVMHandler) fillVMConsoleWSURL(ctx *gin.Context, data map[string]interface{}) {
+	wsToken, _ := data["ws_token"].(string)
+	if wsToken == "" {
+		return
+	}
+	// 兼容前端可能使用 token 字段名
+	data["token"] = wsToken
+	data["ws_url"] = consoleWSURL(ctx, "/api/v1/vms/console/ws", wsToken)
+}
+
+func consoleSessionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrConsoleSessionNotFound), errors.Is(err, v1.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
 // VMConsoleWS godoc
 // @Summary 虚拟机 Console WebSocket（VNC WebSocket 代理）
 // @Description 同域 WS 代理到 Proxmox vncwebsocket，供 noVNC 直接连接
@@ -450,42 +687,29 @@ func (h *PveVMHandler) GetVMConsole(ctx *gin.Context) {
 // @Router /api/v1/vms/console/ws [get]
 func (h *PveVMHandler) VMConsoleWS(ctx *gin.Context) {
 	token := ctx.Query("token")
-	upgrader := websocket.Upgrader{
-		CheckOrigin: func(r *http.Request) bool { return true },
-	}
-
-	clientConn, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	clientConn, release, err := upgradeConsoleWebsocket(ctx, h.consoleProxy)
 	if err != nil {
 		return
 	}
+	defer release()
 	defer clientConn.Close()
 
-	proxmoxConn, err := h.vmService.DialVMConsoleWebsocket(ctx, token)
+	proxmoxConn, target, err := h.vmService.DialVMConsoleWebsocket(ctx, token)
 	if err != nil {
 		_ = clientConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "invalid console token"))
 		return
 	}
 	defer proxmoxConn.Close()
 
-	errCh := make(chan error, 2)
-	proxy := func(src, dst *websocket.Conn) {
-		for {
-			mt, msg, err := src.ReadMessage()
-			if err != nil {
-				errCh <- err
-				return
-			}
-			if err := dst.WriteMessage(mt, msg); err != nil {
-				errCh <- err
-				return
-			}
-		}
+	// 无法写入审计记录时拒绝连接，保证经由平台的控制台访问都可追溯
+	recorder, err := h.consoleAudit.StartSession(ctx, target, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		_ = clientConn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "console audit unavailable"))
+		return
 	}
 
-	go proxy(clientConn, proxmoxConn)
-	go proxy(proxmoxConn, clientConn)
-
-	<-errCh
+	err = proxyConsoleWebsocket(h.consoleProxy, clientConn, proxmoxConn, recorder, nil)
+	recorder.Close(consoleCloseReason(err))
 }
 
 // MigrateVM godoc
@@ -500,14 +724,14 @@ func (h *PveVMHandler) VMConsoleWS(ctx *gin.Context) {
 func (h *PveVMHandler) MigrateVM(ctx *gin.Context) {
 	req := new(v1.MigrateVMRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
 	result, err := h.vmService.MigrateVM(ctx, req)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("vmService.MigrateVM error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, migrateErrorStatus(err), err, nil)
 		return
 	}
 
@@ -526,20 +750,47 @@ func (h *PveVMHandler) MigrateVM(ctx *gin.Context) {
 func (h *PveVMHandler) RemoteMigrateVM(ctx *gin.Context) {
 	req := new(v1.RemoteMigrateVMRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
 	result, err := h.vmService.RemoteMigrateVM(ctx, req)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("vmService.RemoteMigrateVM error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, migrateErrorStatus(err), err, nil)
 		return
 	}
 
 	v1.HandleSuccess(ctx, result)
 }
 
+// RemoteMigratePreflight godoc
+// @Summary 跨集群迁移预检
+// @Description 检查目标存储是否存在及空间是否足够、目标网桥、目标节点证书指纹、两端 API Token 权限和目标 VMID，并返回现在发起迁移时使用的带宽限制
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.RemoteMigrateVMRequest true "远程迁移请求"
+// @Success 200 {object} v1.RemoteMigratePreflightResponse
+// @Router /api/v1/vms/remote-migrate/preflight [post]
+func (h *PveVMHandler) RemoteMigratePreflight(ctx *gin.Context) {
+	req := new(v1.RemoteMigrateVMRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.vmService.RemoteMigratePreflight(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("vmService.RemoteMigratePreflight error", zap.Error(err))
+		v1.HandleError(ctx, migrateErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // CreateBackup godoc
 // @Summary 创建虚拟机备份
 // @Description 使用 Proxmox vzdump API 创建虚拟机备份
@@ -554,7 +805,7 @@ func (h *PveVMHandler) CreateBackup(ctx *gin.Context) {
 	req := new(v1.CreateBackupRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("CreateBackup bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -565,6 +816,10 @@ func (h *PveVMHandler) CreateBackup(ctx *gin.Context) {
 			v1.HandleError(ctx, http.StatusNotFound, err, nil)
 			return
 		}
+		if errors.Is(err, v1.ErrStorageSpaceInsufficient) {
+			v1.HandleError(ctx, http.StatusConflict, err, nil)
+			return
+		}
 		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
 		return
 	}
@@ -572,6 +827,40 @@ func (h *PveVMHandler) CreateBackup(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, data)
 }
 
+// ForecastStorage godoc
+// @Summary 预估存储空间
+// @Description 在备份、完整克隆或恢复前，按磁盘大小和历史压缩比预估目标存储需要的空间，与可用空间对比给出 ok / warning / insufficient 结论
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.StorageForecastRequest true "存储空间预估请求"
+// @Success 200 {object} v1.StorageForecastResponse
+// @Router /api/v1/vms/storage-forecast [post]
+func (h *PveVMHandler) ForecastStorage(ctx *gin.Context) {
+	req := new(v1.StorageForecastRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.vmService.ForecastStorage(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("vmService.ForecastStorage error", zap.Error(err))
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, v1.ErrBadRequest):
+			status = http.StatusBadRequest
+		case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrStorageNotFound):
+			status = http.StatusNotFound
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // DeleteBackup godoc
 // @Summary 删除虚拟机备份
 // @Description 删除指定存储中的备份文件
@@ -586,7 +875,7 @@ func (h *PveVMHandler) DeleteBackup(ctx *gin.Context) {
 	req := new(v1.DeleteBackupRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("DeleteBackup bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -596,7 +885,7 @@ func (h *PveVMHandler) DeleteBackup(ctx *gin.Context) {
 			v1.HandleError(ctx, http.StatusNotFound, err, nil)
 			return
 		}
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, changeErrorStatus(err, http.StatusInternalServerError), err, nil)
 		return
 	}
 
@@ -618,7 +907,7 @@ func (h *PveVMHandler) GetVMCloudInit(ctx *gin.Context) {
 	req := new(v1.GetVMCloudInitRequest)
 	if err := ctx.ShouldBindQuery(req); err != nil {
 		h.logger.WithContext(ctx).Error("GetVMCloudInit bind query error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -650,7 +939,7 @@ func (h *PveVMHandler) UpdateVMCloudInit(ctx *gin.Context) {
 	req := new(v1.UpdateVMCloudInitRequest)
 	if err := ctx.ShouldBindJSON(req); err != nil {
 		h.logger.WithContext(ctx).Error("UpdateVMCloudInit bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -660,7 +949,7 @@ func (h *PveVMHandler) UpdateVMCloudInit(ctx *gin.Context) {
 			v1.HandleError(ctx, http.StatusNotFound, err, nil)
 			return
 		}
-		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		v1.HandleError(ctx, changeErrorStatus(err, http.StatusInternalServerError), err, nil)
 		return
 	}
 
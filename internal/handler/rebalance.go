@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RebalanceHandler struct {
+	*Handler
+	rebalanceService service.RebalanceService
+}
+
+func NewRebalanceHandler(handler *Handler, rebalanceService service.RebalanceService) *RebalanceHandler {
+	return &RebalanceHandler{
+		Handler:          handler,
+		rebalanceService: rebalanceService,
+	}
+}
+
+func rebalanceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrRebalancePlanNotFound), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrRebalancePlanNotPending), errors.Is(err, v1.ErrRebalancePlanExpired),
+		errors.Is(err, v1.ErrRebalanceInProgress), errors.Is(err, v1.ErrRebalanceNotNeeded):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// CreatePlan godoc
+// @Summary 计算集群再平衡方案
+// @Description 仅管理员可操作。立即读取集群节点的实时 CPU / 内存利用率，利用率差值超过 rebalance.threshold 时给出最少的在线迁移方案，并取代该集群之前待执行的方案；负载均衡时返回 409
+// @Tags 负载再平衡模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateRebalancePlanRequest true "params"
+// @Success 200 {object} v1.GetRebalancePlanResponse
+// @Router /api/v1/rebalance/plans [post]
+func (h *RebalanceHandler) CreatePlan(ctx *gin.Context) {
+	req := new(v1.CreateRebalancePlanRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.rebalanceService.CreatePlan(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rebalanceService.CreatePlan error", zap.Error(err))
+		v1.HandleError(ctx, rebalanceErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetPlan godoc
+// @Summary 获取再平衡方案详情
+// @Description 返回方案的迁移列表、执行前后的利用率差值以及各迁移的执行状态
+// @Tags 负载再平衡模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "方案ID"
+// @Success 200 {object} v1.GetRebalancePlanResponse
+// @Router /api/v1/rebalance/plans/{id} [get]
+func (h *RebalanceHandler) GetPlan(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.rebalanceService.GetPlan(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rebalanceService.GetPlan error", zap.Error(err))
+		v1.HandleError(ctx, rebalanceErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListPlans godoc
+// @Summary 获取再平衡方案列表
+// @Tags 负载再平衡模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param cluster_id query int false "集群ID"
+// @Param status query string false "状态（pending, applying, completed, failed, superseded）"
+// @Success 200 {object} v1.ListRebalancePlansResponse
+// @Router /api/v1/rebalance/plans [get]
+func (h *RebalanceHandler) ListPlans(ctx *gin.Context) {
+	req := new(v1.ListRebalancePlansRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.rebalanceService.ListPlans(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rebalanceService.ListPlans error", zap.Error(err))
+		v1.HandleError(ctx, rebalanceErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ApplyPlan godoc
+// @Summary 执行再平衡方案
+// @Description 仅管理员可操作。在后台逐个在线迁移方案中的虚拟机，每次迁移受带宽限制并经过迁移兼容性检查和变更窗口校验；某次迁移失败时停止执行。只能执行 pending 且未超过 rebalance.plan_ttl 的方案
+// @Tags 负载再平衡模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "方案ID"
+// @Param request body v1.ApplyRebalancePlanRequest false "params"
+// @Success 200 {object} v1.GetRebalancePlanResponse
+// @Router /api/v1/rebalance/plans/{id}/apply [post]
+func (h *RebalanceHandler) ApplyPlan(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.ApplyRebalancePlanRequest)
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			v1.HandleBindError(ctx, err)
+			return
+		}
+	}
+
+	data, err := h.rebalanceService.ApplyPlan(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rebalanceService.ApplyPlan error", zap.Error(err))
+		v1.HandleError(ctx, rebalanceErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
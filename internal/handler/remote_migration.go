@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type RemoteMigrationHandler struct {
+	*Handler
+	jobService     service.RemoteMigrationJobService
+	cutoverService service.RemoteMigrationCutoverService
+}
+
+func NewRemoteMigrationHandler(handler *Handler, jobService service.RemoteMigrationJobService,
+	cutoverService service.RemoteMigrationCutoverService) *RemoteMigrationHandler {
+	return &RemoteMigrationHandler{
+		Handler:        handler,
+		jobService:     jobService,
+		cutoverService: cutoverService,
+	}
+}
+
+func remoteMigrationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrRemoteMigrationJobNotFound), errors.Is(err, v1.ErrMigrationWindowNotFound),
+		errors.Is(err, v1.ErrRemoteMigrationCutoverNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrRemoteMigrationJobFinished), errors.Is(err, v1.ErrRemoteMigrationCutoverNotRetryable):
+		return http.StatusConflict
+	}
+	return migrateErrorStatus(err)
+}
+
+// ScheduleRemoteMigration godoc
+// @Summary 计划跨集群迁移
+// @Description 预检通过后在 scheduled_at 或迁移窗口（remote_migration.windows）开始时发起迁移；未指定 bwlimit 时使用窗口的带宽限制，force 时忽略预检阻断问题
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ScheduleRemoteMigrationRequest true "params"
+// @Success 200 {object} v1.RemoteMigrationJobResponse
+// @Router /api/v1/remote-migrations [post]
+func (h *RemoteMigrationHandler) ScheduleRemoteMigration(ctx *gin.Context) {
+	req := new(v1.ScheduleRemoteMigrationRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.jobService.Schedule(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("jobService.Schedule error", zap.Error(err))
+		v1.HandleError(ctx, remoteMigrationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListRemoteMigrations godoc
+// @Summary 查询计划的跨集群迁移
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "源集群ID"
+// @Param vm_id query int false "虚拟机ID"
+// @Param status query string false "状态 scheduled/running/started/failed/cancelled"
+// @Success 200 {object} v1.ListRemoteMigrationJobsResponse
+// @Router /api/v1/remote-migrations [get]
+func (h *RemoteMigrationHandler) ListRemoteMigrations(ctx *gin.Context) {
+	req := new(v1.ListRemoteMigrationJobsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.jobService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("jobService.List error", zap.Error(err))
+		v1.HandleError(ctx, remoteMigrationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CancelRemoteMigration godoc
+// @Summary 取消计划的跨集群迁移
+// @Description 创建人或管理员可取消尚未发起的计划迁移
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "计划ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/remote-migrations/{id}/cancel [post]
+func (h *RemoteMigrationHandler) CancelRemoteMigration(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.jobService.Cancel(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("jobService.Cancel error", zap.Error(err))
+		v1.HandleError(ctx, remoteMigrationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListCutovers godoc
+// @Summary 查询跨集群迁移切换
+// @Description 跨集群迁移任务成功后自动更新虚拟机所属集群和节点、重新绑定或分配 IP、更新 cloud-init 并通知 DNS，返回各步骤结果
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param vm_id query int false "虚拟机ID"
+// @Param status query string false "状态 waiting/completed/failed"
+// @Success 200 {object} v1.ListRemoteMigrationCutoversResponse
+// @Router /api/v1/remote-migrations/cutovers [get]
+func (h *RemoteMigrationHandler) ListCutovers(ctx *gin.Context) {
+	req := new(v1.ListRemoteMigrationCutoversRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.cutoverService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("cutoverService.List error", zap.Error(err))
+		v1.HandleError(ctx, remoteMigrationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetCutover godoc
+// @Summary 获取跨集群迁移切换详情
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "切换ID"
+// @Success 200 {object} v1.RemoteMigrationCutoverResponse
+// @Router /api/v1/remote-migrations/cutovers/{id} [get]
+func (h *RemoteMigrationHandler) GetCutover(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.cutoverService.Get(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("cutoverService.Get error", zap.Error(err))
+		v1.HandleError(ctx, remoteMigrationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// RetryCutover godoc
+// @Summary 重试跨集群迁移切换
+// @Description 迁移任务成功但切换步骤失败（如 IP 不足、DNS webhook 失败）时重新执行切换，已分配的地址沿用；创建人或管理员可操作
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "切换ID"
+// @Success 200 {object} v1.RemoteMigrationCutoverResponse
+// @Router /api/v1/remote-migrations/cutovers/{id}/retry [post]
+func (h *RemoteMigrationHandler) RetryCutover(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.cutoverService.Retry(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("cutoverService.Retry error", zap.Error(err))
+		v1.HandleError(ctx, remoteMigrationErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,272 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+	"pvesphere/pkg/xlsx"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type ReportHandler struct {
+	*Handler
+	reportService service.ReportService
+}
+
+func NewReportHandler(handler *Handler, reportService service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		Handler:       handler,
+		reportService: reportService,
+	}
+}
+
+func reportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrReportScheduleNotFound), errors.Is(err, v1.ErrReportRunNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrReportScheduleInvalid), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrReportScheduleExists):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateReportSchedule godoc
+// @Summary 创建定期报告计划
+// @Description 仅管理员可操作。按 daily / weekly / monthly 在 send_time 汇总容量、备份成功率、合规得分趋势，
+// @Description 以站内通知发送给 recipients 中的用户；配置 report.smtp 后同时向用户邮箱和 emails 发送带 XLSX 附件的邮件
+// @Tags 定期报告模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateReportScheduleRequest true "params"
+// @Success 200 {object} v1.GetReportScheduleResponse
+// @Router /api/v1/report-schedules [post]
+func (h *ReportHandler) CreateReportSchedule(ctx *gin.Context) {
+	req := new(v1.CreateReportScheduleRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.reportService.CreateSchedule(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reportService.CreateSchedule error", zap.Error(err))
+		v1.HandleError(ctx, reportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateReportSchedule godoc
+// @Summary 更新定期报告计划
+// @Description 仅管理员可操作，整体替换计划配置并重新计算下次发送时间
+// @Tags 定期报告模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "报告计划ID"
+// @Param request body v1.UpdateReportScheduleRequest true "params"
+// @Success 200 {object} v1.GetReportScheduleResponse
+// @Router /api/v1/report-schedules/{id} [put]
+func (h *ReportHandler) UpdateReportSchedule(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateReportScheduleRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.reportService.UpdateSchedule(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reportService.UpdateSchedule error", zap.Error(err))
+		v1.HandleError(ctx, reportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteReportSchedule godoc
+// @Summary 删除定期报告计划
+// @Description 仅管理员可操作，同时删除该计划的发送记录
+// @Tags 定期报告模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "报告计划ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/report-schedules/{id} [delete]
+func (h *ReportHandler) DeleteReportSchedule(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.reportService.DeleteSchedule(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("reportService.DeleteSchedule error", zap.Error(err))
+		v1.HandleError(ctx, reportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetReportSchedule godoc
+// @Summary 获取定期报告计划详情
+// @Tags 定期报告模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "报告计划ID"
+// @Success 200 {object} v1.GetReportScheduleResponse
+// @Router /api/v1/report-schedules/{id} [get]
+func (h *ReportHandler) GetReportSchedule(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.reportService.GetSchedule(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reportService.GetSchedule error", zap.Error(err))
+		v1.HandleError(ctx, reportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListReportSchedules godoc
+// @Summary 获取定期报告计划列表
+// @Tags 定期报告模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} v1.ListReportSchedulesResponse
+// @Router /api/v1/report-schedules [get]
+func (h *ReportHandler) ListReportSchedules(ctx *gin.Context) {
+	req := new(v1.ListReportSchedulesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.reportService.ListSchedules(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reportService.ListSchedules error", zap.Error(err))
+		v1.HandleError(ctx, reportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// SendReport godoc
+// @Summary 立即发送报告
+// @Description 仅管理员可操作。统计截至当前的一个周期并立即发送，不影响下次定时发送时间
+// @Tags 定期报告模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "报告计划ID"
+// @Success 200 {object} v1.SendReportResponse
+// @Router /api/v1/report-schedules/{id}/send [post]
+func (h *ReportHandler) SendReport(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.reportService.SendNow(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reportService.SendNow error", zap.Error(err))
+		v1.HandleError(ctx, reportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListReportRuns godoc
+// @Summary 获取报告发送记录
+// @Description 按时间倒序返回，包含每次发送的合规得分、备份成功率和摘要
+// @Tags 定期报告模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "报告计划ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Success 200 {object} v1.ListReportRunsResponse
+// @Router /api/v1/report-schedules/{id}/runs [get]
+func (h *ReportHandler) ListReportRuns(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.ListReportRunsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.reportService.ListRuns(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reportService.ListRuns error", zap.Error(err))
+		v1.HandleError(ctx, reportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DownloadReportRun godoc
+// @Summary 下载报告附件（XLSX）
+// @Description 管理员和该报告的接收人可下载
+// @Tags 定期报告模块
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security Bearer
+// @Param id path int true "发送记录ID"
+// @Success 200 {file} file
+// @Router /api/v1/report-runs/{id}/download [get]
+func (h *ReportHandler) DownloadReportRun(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	filename, data, err := h.reportService.GetRunAttachment(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reportService.GetRunAttachment error", zap.Error(err))
+		v1.HandleError(ctx, reportErrorStatus(err), err, nil)
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	ctx.Data(http.StatusOK, xlsx.ContentType, data)
+}
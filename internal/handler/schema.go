@@ -0,0 +1,41 @@
+package handler
+
+import (
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SchemaHandler struct {
+	*Handler
+	schemaService service.SchemaService
+}
+
+func NewSchemaHandler(handler *Handler, schemaService service.SchemaService) *SchemaHandler {
+	return &SchemaHandler{
+		Handler:       handler,
+		schemaService: schemaService,
+	}
+}
+
+// GetSchemaVersion godoc
+// @Summary 查询数据库表结构版本
+// @Description 返回当前和最新的表结构版本以及各版本化迁移的执行情况，仅管理员可用。存在 pending 迁移时需执行 migration（或开启 migration.auto_migrate 后重启）
+// @Tags 系统管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.GetSchemaVersionResponse
+// @Router /api/v1/admin/schema [get]
+func (h *SchemaHandler) GetSchemaVersion(ctx *gin.Context) {
+	data, err := h.schemaService.GetSchemaVersion(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("schemaService.GetSchemaVersion error", zap.Error(err))
+		v1.HandleError(ctx, configErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
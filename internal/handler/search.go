@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SearchHandler struct {
+	*Handler
+	searchService service.SearchService
+}
+
+func NewSearchHandler(handler *Handler, searchService service.SearchService) *SearchHandler {
+	return &SearchHandler{
+		Handler:       handler,
+		searchService: searchService,
+	}
+}
+
+// GlobalSearch godoc
+// @Summary 全局搜索
+// @Description 跨集群搜索虚拟机（名称/VMID/IP/标签）、节点、模板、存储和任务，结果按类型分组、精确匹配优先，适用于输入联想
+// @Tags 全局搜索模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param q query string true "关键字"
+// @Param types query string false "搜索范围（逗号分隔）：vm, node, template, storage, task"
+// @Param cluster_id query int false "集群ID"
+// @Param limit query int false "每种类型最多返回条数" default(5)
+// @Success 200 {object} v1.GlobalSearchResponse
+// @Router /api/v1/search [get]
+func (h *SearchHandler) GlobalSearch(ctx *gin.Context) {
+	req := new(v1.GlobalSearchRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.searchService.GlobalSearch(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("searchService.GlobalSearch error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
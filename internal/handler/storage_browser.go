@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type StorageBrowserHandler struct {
+	*Handler
+	browserService service.StorageBrowserService
+}
+
+func NewStorageBrowserHandler(handler *Handler, browserService service.StorageBrowserService) *StorageBrowserHandler {
+	return &StorageBrowserHandler{
+		Handler:        handler,
+		browserService: browserService,
+	}
+}
+
+func storageBrowserErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrClusterNotFound),
+		errors.Is(err, v1.ErrStorageVolumeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrStorageVolumeInUse):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest), errors.Is(err, v1.ErrInvalidParameter),
+		errors.Is(err, v1.ErrStorageDeleteTokenInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// Browse godoc
+// @Summary 浏览存储内容
+// @Description 按目录层级浏览节点存储：根目录按内容类型分组，images / rootdir 下按 VMID 分组。磁盘卷标注引用它的虚拟机和配置项，未被任何虚拟机 / 容器配置引用的磁盘标记为孤儿卷
+// @Tags 存储浏览器模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param node_id query int true "节点ID"
+// @Param storage query string true "存储名称"
+// @Param path query string false "目录路径，如 images、images/100、iso"
+// @Param orphaned_only query bool false "只返回孤儿卷"
+// @Success 200 {object} v1.BrowseStorageResponse
+// @Router /api/v1/storage-browser [get]
+func (h *StorageBrowserHandler) Browse(ctx *gin.Context) {
+	req := new(v1.BrowseStorageRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.browserService.Browse(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("browserService.Browse error", zap.Error(err))
+		v1.HandleError(ctx, storageBrowserErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// PrepareDelete godoc
+// @Summary 批量删除存储卷（预检）
+// @Description 校验所选卷存在于该存储且未被虚拟机配置引用（allow_in_use 时放行），返回卷明细、总大小和 5 分钟内有效的单次确认令牌，不执行删除。仅管理员可用
+// @Tags 存储浏览器模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.PrepareStorageDeleteRequest true "params"
+// @Success 200 {object} v1.PrepareStorageDeleteResponse
+// @Router /api/v1/storage-browser/delete/prepare [post]
+func (h *StorageBrowserHandler) PrepareDelete(ctx *gin.Context) {
+	req := new(v1.PrepareStorageDeleteRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.browserService.PrepareDelete(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("browserService.PrepareDelete error", zap.Error(err))
+		v1.HandleError(ctx, storageBrowserErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ConfirmDelete godoc
+// @Summary 批量删除存储卷（确认执行）
+// @Description 使用预检返回的确认令牌删除卷，令牌只能由申请人使用一次。单个卷删除失败不影响其他卷，结果中逐个返回。仅管理员可用
+// @Tags 存储浏览器模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfirmStorageDeleteRequest true "params"
+// @Success 200 {object} v1.ConfirmStorageDeleteResponse
+// @Router /api/v1/storage-browser/delete [post]
+func (h *StorageBrowserHandler) ConfirmDelete(ctx *gin.Context) {
+	req := new(v1.ConfirmStorageDeleteRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.browserService.ConfirmDelete(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("browserService.ConfirmDelete error", zap.Error(err))
+		v1.HandleError(ctx, storageBrowserErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
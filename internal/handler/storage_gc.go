@@ -0,0 +1,214 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type StorageGCHandler struct {
+	*Handler
+	gcService service.StorageGCService
+}
+
+func NewStorageGCHandler(handler *Handler, gcService service.StorageGCService) *StorageGCHandler {
+	return &StorageGCHandler{
+		Handler:   handler,
+		gcService: gcService,
+	}
+}
+
+func storageGCErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateScan godoc
+// @Summary 发起存储垃圾回收扫描
+// @Description 异步扫描集群内所有存储，识别孤儿磁盘、陈旧 ISO/容器模板和超出保留数量的备份，扫描结果需审核后才能删除
+// @Tags 存储垃圾回收模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateStorageGCScanRequest true "params"
+// @Success 200 {object} v1.CreateStorageGCScanResponse
+// @Router /api/v1/storage-gc/scans [post]
+func (h *StorageGCHandler) CreateScan(ctx *gin.Context) {
+	req := new(v1.CreateStorageGCScanRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.gcService.CreateScan(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("gcService.CreateScan error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetScan godoc
+// @Summary 获取存储垃圾回收扫描任务详情
+// @Tags 存储垃圾回收模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "扫描任务ID"
+// @Success 200 {object} v1.CreateStorageGCScanResponse
+// @Router /api/v1/storage-gc/scans/{id} [get]
+func (h *StorageGCHandler) GetScan(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.gcService.GetScan(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("gcService.GetScan error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListScans godoc
+// @Summary 获取存储垃圾回收扫描任务列表
+// @Tags 存储垃圾回收模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param cluster_id query int false "集群ID"
+// @Success 200 {object} v1.ListStorageGCScansResponse
+// @Router /api/v1/storage-gc/scans [get]
+func (h *StorageGCHandler) ListScans(ctx *gin.Context) {
+	req := new(v1.ListStorageGCScansRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	data, err := h.gcService.ListScans(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("gcService.ListScans error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListItems godoc
+// @Summary 获取可回收项列表
+// @Tags 存储垃圾回收模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param scan_id query int true "扫描任务ID"
+// @Param reason query string false "原因（orphan_disk, stale_iso, stale_template, old_backup）"
+// @Param status query string false "状态（pending, approved, ignored, deleted, failed）"
+// @Success 200 {object} v1.ListStorageGCItemsResponse
+// @Router /api/v1/storage-gc/items [get]
+func (h *StorageGCHandler) ListItems(ctx *gin.Context) {
+	req := new(v1.ListStorageGCItemsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	data, err := h.gcService.ListItems(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("gcService.ListItems error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ReviewItems godoc
+// @Summary 审核可回收项
+// @Description approve 表示确认可删除，ignore 表示忽略（不会被删除），仅管理员可用
+// @Tags 存储垃圾回收模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ReviewStorageGCItemsRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/storage-gc/items/review [post]
+func (h *StorageGCHandler) ReviewItems(ctx *gin.Context) {
+	req := new(v1.ReviewStorageGCItemsRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.gcService.ReviewItems(ctx, GetUserIdFromCtx(ctx), req); err != nil {
+		h.logger.WithContext(ctx).Error("gcService.ReviewItems error", zap.Error(err))
+		v1.HandleError(ctx, storageGCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteItems godoc
+// @Summary 删除已审核的可回收项
+// @Description 仅删除状态为 approved 或 failed 的项，其它状态的项会被跳过，仅管理员可用
+// @Tags 存储垃圾回收模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.DeleteStorageGCItemsRequest true "params"
+// @Success 200 {object} v1.DeleteStorageGCItemsResponse
+// @Router /api/v1/storage-gc/items/delete [post]
+func (h *StorageGCHandler) DeleteItems(ctx *gin.Context) {
+	req := new(v1.DeleteStorageGCItemsRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.gcService.DeleteItems(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("gcService.DeleteItems error", zap.Error(err))
+		v1.HandleError(ctx, storageGCErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
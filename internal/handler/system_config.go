@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type SystemConfigHandler struct {
+	*Handler
+	configService service.SystemConfigService
+}
+
+func NewSystemConfigHandler(handler *Handler, configService service.SystemConfigService) *SystemConfigHandler {
+	return &SystemConfigHandler{
+		Handler:       handler,
+		configService: configService,
+	}
+}
+
+// configErrorStatus 将配置管理错误映射为 HTTP 状态码
+func configErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrInvalidConfigValue), errors.Is(err, v1.ErrConfigReloadFailed):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetRuntimeConfig godoc
+// @Summary 获取当前生效的运行时配置
+// @Description 返回可热更新的配置项（日志级别、Proxmox API 超时、API 调用日志开关），仅管理员可用
+// @Tags 系统管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.GetRuntimeConfigResponse
+// @Router /api/v1/admin/config [get]
+func (h *SystemConfigHandler) GetRuntimeConfig(ctx *gin.Context) {
+	data, err := h.configService.GetRuntimeConfig(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("configService.GetRuntimeConfig error", zap.Error(err))
+		v1.HandleError(ctx, configErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateRuntimeConfig godoc
+// @Summary 修改运行时配置
+// @Description 立即生效，无需重启；仅修改提供的字段，重启或重新加载配置文件后以配置文件为准。每个变化的配置项都会记录审计日志
+// @Tags 系统管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.UpdateRuntimeConfigRequest true "params"
+// @Success 200 {object} v1.UpdateRuntimeConfigResponse
+// @Router /api/v1/admin/config [put]
+func (h *SystemConfigHandler) UpdateRuntimeConfig(ctx *gin.Context) {
+	req := new(v1.UpdateRuntimeConfigRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.configService.UpdateRuntimeConfig(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("configService.UpdateRuntimeConfig error", zap.Error(err))
+		v1.HandleError(ctx, configErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ReloadConfig godoc
+// @Summary 重新加载配置文件
+// @Description 重新读取配置文件并应用可热更新的配置项（与向进程发送 SIGHUP 等效），校验失败时不做任何修改
+// @Tags 系统管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.UpdateRuntimeConfigResponse
+// @Router /api/v1/admin/config/reload [post]
+func (h *SystemConfigHandler) ReloadConfig(ctx *gin.Context) {
+	data, err := h.configService.ReloadFromFile(ctx, GetUserIdFromCtx(ctx), v1.ConfigSourceFile)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("configService.ReloadFromFile error", zap.Error(err))
+		v1.HandleError(ctx, configErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListConfigAudits godoc
+// @Summary 查询配置变更审计记录
+// @Tags 系统管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param config_key query string false "配置项"
+// @Success 200 {object} v1.ListConfigAuditResponse
+// @Router /api/v1/admin/config/audits [get]
+func (h *SystemConfigHandler) ListConfigAudits(ctx *gin.Context) {
+	req := new(v1.ListConfigAuditRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.configService.ListAudits(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("configService.ListAudits error", zap.Error(err))
+		v1.HandleError(ctx, configErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
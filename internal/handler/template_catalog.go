@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TemplateCatalogHandler struct {
+	*Handler
+	catalogService service.TemplateCatalogService
+}
+
+func NewTemplateCatalogHandler(handler *Handler, catalogService service.TemplateCatalogService) *TemplateCatalogHandler {
+	return &TemplateCatalogHandler{
+		Handler:        handler,
+		catalogService: catalogService,
+	}
+}
+
+func templateCatalogErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrCatalogNotFound), errors.Is(err, v1.ErrCatalogImageNotFound),
+		errors.Is(err, v1.ErrCatalogInstallNotFound), errors.Is(err, v1.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrCatalogExists), errors.Is(err, v1.ErrCatalogInUse):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest),
+		errors.Is(err, v1.ErrStorageNotFound),
+		errors.Is(err, v1.ErrStorageContentUnsupported),
+		errors.Is(err, v1.ErrClusterNotFound),
+		errors.Is(err, v1.ErrClusterNotSchedulable):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrCatalogFetchFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// CreateCatalog godoc
+// @Summary 订阅模板目录
+// @Description 仅管理员可操作。订阅远程模板目录（HTTPS 上的 JSON 索引），保存前会拉取一次索引校验地址和格式
+// @Tags 模板目录模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateTemplateCatalogRequest true "params"
+// @Success 200 {object} v1.GetTemplateCatalogResponse
+// @Router /api/v1/template-catalogs [post]
+func (h *TemplateCatalogHandler) CreateCatalog(ctx *gin.Context) {
+	req := new(v1.CreateTemplateCatalogRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.catalogService.CreateCatalog(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("catalogService.CreateCatalog error", zap.Error(err))
+		v1.HandleError(ctx, templateCatalogErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListCatalogs godoc
+// @Summary 获取模板目录列表
+// @Tags 模板目录模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListTemplateCatalogsResponse
+// @Router /api/v1/template-catalogs [get]
+func (h *TemplateCatalogHandler) ListCatalogs(ctx *gin.Context) {
+	data, err := h.catalogService.ListCatalogs(ctx)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("catalogService.ListCatalogs error", zap.Error(err))
+		v1.HandleError(ctx, templateCatalogErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteCatalog godoc
+// @Summary 取消订阅模板目录
+// @Description 仅管理员可操作，已安装的模板不受影响；有进行中的安装任务时不允许删除
+// @Tags 模板目录模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "目录ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/template-catalogs/{id} [delete]
+func (h *TemplateCatalogHandler) DeleteCatalog(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.catalogService.DeleteCatalog(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("catalogService.DeleteCatalog error", zap.Error(err))
+		v1.HandleError(ctx, templateCatalogErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RefreshCatalog godoc
+// @Summary 刷新模板目录
+// @Description 仅管理员可操作。立即重新拉取目录索引；拉取失败时保留上次的镜像列表
+// @Tags 模板目录模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "目录ID"
+// @Success 200 {object} v1.GetTemplateCatalogResponse
+// @Router /api/v1/template-catalogs/{id}/refresh [post]
+func (h *TemplateCatalogHandler) RefreshCatalog(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.catalogService.RefreshCatalog(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("catalogService.RefreshCatalog error", zap.Error(err))
+		v1.HandleError(ctx, templateCatalogErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListImages godoc
+// @Summary 获取目录镜像列表
+// @Description 返回所有已启用目录中的镜像
+// @Tags 模板目录模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param catalog_id query int false "目录ID"
+// @Param keyword query string false "按名称或描述搜索"
+// @Param os_type query string false "操作系统类型（如 l26、win11）"
+// @Success 200 {object} v1.ListCatalogImagesResponse
+// @Router /api/v1/template-catalogs/images [get]
+func (h *TemplateCatalogHandler) ListImages(ctx *gin.Context) {
+	req := new(v1.ListCatalogImagesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.catalogService.ListImages(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("catalogService.ListImages error", zap.Error(err))
+		v1.HandleError(ctx, templateCatalogErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// InstallImage godoc
+// @Summary 安装目录镜像为模板
+// @Description 由节点直接下载镜像到下载存储（content 需包含 import，需要 Proxmox VE 8.4 及以上）并校验校验和，然后导入磁盘创建虚拟机、转换为模板并登记到模板管理。
+// @Description 请求校验通过后立即返回任务，安装在后台执行；同一版本的镜像已下载过时直接复用
+// @Tags 模板目录模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.InstallCatalogImageRequest true "params"
+// @Success 200 {object} v1.GetCatalogInstallResponse
+// @Router /api/v1/template-catalogs/installs [post]
+func (h *TemplateCatalogHandler) InstallImage(ctx *gin.Context) {
+	req := new(v1.InstallCatalogImageRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.catalogService.InstallImage(ctx, req, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("catalogService.InstallImage error", zap.Error(err))
+		v1.HandleError(ctx, templateCatalogErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetInstall godoc
+// @Summary 获取安装任务详情
+// @Tags 模板目录模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} v1.GetCatalogInstallResponse
+// @Router /api/v1/template-catalogs/installs/{id} [get]
+func (h *TemplateCatalogHandler) GetInstall(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.catalogService.GetInstall(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("catalogService.GetInstall error", zap.Error(err))
+		v1.HandleError(ctx, templateCatalogErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListInstalls godoc
+// @Summary 获取安装任务列表
+// @Tags 模板目录模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param catalog_id query int false "目录ID"
+// @Param cluster_id query int false "集群ID"
+// @Param status query string false "状态（pending, running, completed, failed）"
+// @Success 200 {object} v1.ListCatalogInstallsResponse
+// @Router /api/v1/template-catalogs/installs [get]
+func (h *TemplateCatalogHandler) ListInstalls(ctx *gin.Context) {
+	req := new(v1.ListCatalogInstallsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.catalogService.ListInstalls(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("catalogService.ListInstalls error", zap.Error(err))
+		v1.HandleError(ctx, templateCatalogErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
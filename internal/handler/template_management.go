@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -28,7 +29,8 @@ func NewTemplateManagementHandler(
 
 // ImportTemplate 从备份文件导入模板
 // @Summary 从备份文件导入模板
-// @Description 基于已有的虚拟机备份文件创建模板，支持共享存储和本地存储
+// @Description 基于已有的虚拟机备份文件创建模板，支持共享存储和本地存储；
+// @Description 也可导入存储 import 目录下的 qcow2/raw/vmdk 磁盘镜像或 OVA（创建虚拟机并通过 import-from 导入磁盘）
 // @Tags 模板管理
 // @Accept json
 // @Produce json
@@ -40,9 +42,7 @@ func (h *TemplateManagementHandler) ImportTemplate(ctx *gin.Context) {
 	var req v1.ImportTemplateRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		h.logger.WithContext(ctx).Error("ImportTemplate bind json error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, map[string]string{
-			"error": err.Error(),
-		})
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -56,6 +56,109 @@ func (h *TemplateManagementHandler) ImportTemplate(ctx *gin.Context) {
 	v1.HandleSuccess(ctx, data)
 }
 
+// UploadImportTemplate 上传备份文件并导入模板
+// @Summary 上传备份文件并导入模板
+// @Description 流式上传 vzdump 备份文件（vma/vma.zst/vma.lzo/vma.gz）或磁盘镜像 / OVA（qcow2/raw/vmdk/ova）到存储，上传完成后在后台自动导入并转换为模板；
+// @Description 导入参数通过 query 传递，文件通过 multipart 字段 file 上传，返回的 import_id 可用于查询统一进度
+// @Tags 模板管理
+// @Accept multipart/form-data
+// @Produce json
+// @Param template_name query string true "模板名称"
+// @Param cluster_id query int true "集群ID"
+// @Param node_id query int true "上传并导入的节点ID"
+// @Param backup_storage_id query int true "备份文件上传到的存储ID"
+// @Param target_storage_id query int true "VM磁盘目标存储ID"
+// @Param file_size query int false "文件大小（字节）"
+// @Param description query string false "模板描述"
+// @Param sync_node_ids query []int false "导入完成后要同步的节点ID列表" collectionFormat(multi)
+// @Param cores query int false "磁盘镜像 / OVA：CPU 核数"
+// @Param memory query int false "磁盘镜像 / OVA：内存（MB）"
+// @Param bridge query string false "磁盘镜像 / OVA：网桥，默认 vmbr0"
+// @Param ostype query string false "磁盘镜像 / OVA：操作系统类型"
+// @Param cloud_init query bool false "磁盘镜像：添加 cloud-init 盘并使用串口控制台"
+// @Param file formData file true "备份文件"
+// @Success 200 {object} v1.ImportTemplateResponse
+// @Router /api/v1/templates/upload-import [post]
+func (h *TemplateManagementHandler) UploadImportTemplate(ctx *gin.Context) {
+	var req v1.UploadImportTemplateRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	// 直接读取 multipart 流，避免先把整个备份文件落盘或缓存在内存
+	reader, err := ctx.Request.MultipartReader()
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			// 没有找到 file 字段
+			v1.HandleError(ctx, http.StatusBadRequest, v1.WithDetail(v1.ErrMissingParameter, "file"), nil)
+			return
+		}
+		if part.FormName() != "file" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		data, err := h.templateManagementService.UploadAndImportTemplate(ctx.Request.Context(), &req,
+			part.FileName(), part, ctx.Request.ContentLength)
+		part.Close()
+		if err != nil {
+			h.logger.WithContext(ctx).Error("templateManagementService.UploadAndImportTemplate error", zap.Error(err))
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, v1.ErrInvalidParameter),
+				errors.Is(err, v1.ErrStorageContentUnsupported):
+				status = http.StatusBadRequest
+			case errors.Is(err, v1.ErrStorageNotFound),
+				errors.Is(err, v1.ErrNodeNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, v1.ErrFileUploadFailed),
+				errors.Is(err, v1.ErrProxmoxRequestFailed):
+				status = http.StatusBadGateway
+			}
+			v1.HandleError(ctx, status, err, nil)
+			return
+		}
+
+		v1.HandleSuccess(ctx, data)
+		return
+	}
+}
+
+// GetTemplateImport 查询模板导入进度
+// @Summary 查询模板导入进度
+// @Description 查询导入记录的状态和统一进度（上传 0-50，恢复 50-95，转换为模板 95-100）
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Param import_id path int true "导入ID"
+// @Success 200 {object} v1.GetTemplateImportResponse
+// @Router /api/v1/templates/imports/{import_id} [get]
+func (h *TemplateManagementHandler) GetTemplateImport(ctx *gin.Context) {
+	importID, err := strconv.ParseInt(ctx.Param("import_id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.templateManagementService.GetTemplateImport(ctx.Request.Context(), importID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // GetTemplateDetail 查询模板详情（包含实例）
 // @Summary 查询模板详情
 // @Description 查询模板详细信息，包括上传信息和实例列表
@@ -121,7 +224,7 @@ func (h *TemplateManagementHandler) SyncTemplate(ctx *gin.Context) {
 	// 解析请求体
 	var req v1.SyncTemplateRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		v1.HandleBindError(ctx, err)
 		return
 	}
 
@@ -262,6 +365,47 @@ func (h *TemplateManagementHandler) RetrySyncTask(ctx *gin.Context) {
 	})
 }
 
+// CancelSyncTask 取消同步任务
+// @Summary 取消同步任务
+// @Description 取消排队中或执行中的同步任务：终止正在执行的 Proxmox 克隆/迁移任务，删除临时克隆的虚拟机（无论停留在源节点还是目标节点），并将任务和目标节点实例标记为失败
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Param task_id path int true "任务ID"
+// @Param request body v1.CancelSyncTaskRequest false "params"
+// @Success 200 {object} v1.CancelSyncTaskResponse
+// @Router /api/v1/templates/sync-tasks/{task_id}/cancel [post]
+func (h *TemplateManagementHandler) CancelSyncTask(ctx *gin.Context) {
+	taskID, err := strconv.ParseInt(ctx.Param("task_id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.CancelSyncTaskRequest)
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			v1.HandleBindError(ctx, err)
+			return
+		}
+	}
+
+	data, err := h.templateManagementService.CancelSyncTask(ctx.Request.Context(), taskID, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("templateManagementService.CancelSyncTask error", zap.Error(err))
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, v1.ErrNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, v1.ErrInvalidOperation):
+			status = http.StatusConflict
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
 // ListTemplateInstances 列出模板实例
 // @Summary 列出模板实例
 // @Description 列出指定模板的所有实例
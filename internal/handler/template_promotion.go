@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TemplatePromotionHandler struct {
+	*Handler
+	promotionService service.TemplatePromotionService
+}
+
+func NewTemplatePromotionHandler(handler *Handler, promotionService service.TemplatePromotionService) *TemplatePromotionHandler {
+	return &TemplatePromotionHandler{
+		Handler:          handler,
+		promotionService: promotionService,
+	}
+}
+
+func templatePromotionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired), errors.Is(err, v1.ErrTemplatePromotionSelfApprove):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrTemplatePromotionNotFound), errors.Is(err, v1.ErrTemplateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrTemplatePromotionInProgress), errors.Is(err, v1.ErrTemplatePromotionNotPending):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest), errors.Is(err, v1.ErrClusterNotFound), errors.Is(err, v1.ErrNodeNotFound),
+		errors.Is(err, v1.ErrTemplateNoInstance), errors.Is(err, v1.ErrTemplatePromotionStage), errors.Is(err, v1.ErrTemplatePromotionProvenance):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// RequestPromotion godoc
+// @Summary 发起模板晋级申请
+// @Description 目标集群的环境（env）必须是模板所在集群环境的下一环境（默认 dev -> staging -> prod）；非第一环境的模板必须由晋级产生。申请需管理员审批后才开始复制
+// @Tags 模板晋级模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateTemplatePromotionRequest true "params"
+// @Success 200 {object} v1.GetTemplatePromotionResponse
+// @Router /api/v1/template-promotions [post]
+func (h *TemplatePromotionHandler) RequestPromotion(ctx *gin.Context) {
+	req := new(v1.CreateTemplatePromotionRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.promotionService.Request(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("promotionService.Request error", zap.Error(err))
+		v1.HandleError(ctx, templatePromotionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ApprovePromotion godoc
+// @Summary 审批通过模板晋级
+// @Description 仅管理员可操作，默认不能审批自己的申请（template_promotion.allow_self_approval）。通过后异步导出、导入并在目标集群登记模板
+// @Tags 模板晋级模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "晋级记录ID"
+// @Param request body v1.ReviewTemplatePromotionRequest true "params"
+// @Success 200 {object} v1.GetTemplatePromotionResponse
+// @Router /api/v1/template-promotions/{id}/approve [post]
+func (h *TemplatePromotionHandler) ApprovePromotion(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.ReviewTemplatePromotionRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.promotionService.Approve(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("promotionService.Approve error", zap.Error(err))
+		v1.HandleError(ctx, templatePromotionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// RejectPromotion godoc
+// @Summary 驳回模板晋级
+// @Description 仅管理员可操作，驳回后通知申请人
+// @Tags 模板晋级模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "晋级记录ID"
+// @Param request body v1.ReviewTemplatePromotionRequest true "params"
+// @Success 200 {object} v1.GetTemplatePromotionResponse
+// @Router /api/v1/template-promotions/{id}/reject [post]
+func (h *TemplatePromotionHandler) RejectPromotion(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.ReviewTemplatePromotionRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.promotionService.Reject(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("promotionService.Reject error", zap.Error(err))
+		v1.HandleError(ctx, templatePromotionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetPromotion godoc
+// @Summary 获取模板晋级详情
+// @Tags 模板晋级模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "晋级记录ID"
+// @Success 200 {object} v1.GetTemplatePromotionResponse
+// @Router /api/v1/template-promotions/{id} [get]
+func (h *TemplatePromotionHandler) GetPromotion(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.promotionService.Get(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("promotionService.Get error", zap.Error(err))
+		v1.HandleError(ctx, templatePromotionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetPromotionChain godoc
+// @Summary 获取模板晋级链
+// @Description 按环境顺序返回从最初的晋级到该记录的所有晋级记录（含申请人、审批人和备份 SHA256），用于追溯模板来源
+// @Tags 模板晋级模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "晋级记录ID"
+// @Success 200 {object} v1.GetTemplatePromotionChainResponse
+// @Router /api/v1/template-promotions/{id}/chain [get]
+func (h *TemplatePromotionHandler) GetPromotionChain(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.promotionService.Chain(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("promotionService.Chain error", zap.Error(err))
+		v1.HandleError(ctx, templatePromotionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListPromotions godoc
+// @Summary 获取模板晋级列表
+// @Tags 模板晋级模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param template_id query int false "源模板或晋级产生的模板ID"
+// @Param cluster_id query int false "源集群或目标集群ID"
+// @Param status query string false "状态（pending_approval, rejected, replicating, completed, failed）"
+// @Success 200 {object} v1.ListTemplatePromotionsResponse
+// @Router /api/v1/template-promotions [get]
+func (h *TemplatePromotionHandler) ListPromotions(ctx *gin.Context) {
+	req := new(v1.ListTemplatePromotionsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.promotionService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("promotionService.List error", zap.Error(err))
+		v1.HandleError(ctx, templatePromotionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
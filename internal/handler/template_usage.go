@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TemplateUsageHandler struct {
+	*Handler
+	usageService service.TemplateUsageService
+}
+
+func NewTemplateUsageHandler(handler *Handler, usageService service.TemplateUsageService) *TemplateUsageHandler {
+	return &TemplateUsageHandler{
+		Handler:      handler,
+		usageService: usageService,
+	}
+}
+
+func templateUsageErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrTemplateNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrBadRequest):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// AgingReport godoc
+// @Summary 模板老化报告
+// @Description 列出模板的克隆次数、最近使用时间、现存虚拟机数量和各版本（导入记录）的使用情况，并标记超过 unused_months 个月未被克隆（unused）、基于已停止维护（eol）或 90 天内停止维护（eol_soon）操作系统的模板。带标记的模板在前，其次按最近使用时间升序
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "集群ID"
+// @Param unused_months query int false "未使用月数阈值，默认使用配置 template_usage.unused_months"
+// @Param flagged_only query bool false "只返回带老化标记的模板"
+// @Success 200 {object} v1.TemplateAgingReportResponse
+// @Router /api/v1/templates/aging-report [get]
+func (h *TemplateUsageHandler) AgingReport(ctx *gin.Context) {
+	req := new(v1.TemplateAgingReportRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.usageService.AgingReport(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("usageService.AgingReport error", zap.Error(err))
+		v1.HandleError(ctx, templateUsageErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetUsage godoc
+// @Summary 模板使用统计
+// @Description 返回单个模板的使用统计、老化标记、各版本的克隆次数和最近 50 次克隆记录
+// @Tags 模板管理
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Success 200 {object} v1.TemplateUsageDetailResponse
+// @Router /api/v1/templates/{id}/usage [get]
+func (h *TemplateUsageHandler) GetUsage(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.usageService.GetUsage(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("usageService.GetUsage error", zap.Error(err))
+		v1.HandleError(ctx, templateUsageErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
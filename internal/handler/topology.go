@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type TopologyHandler struct {
+	*Handler
+	topologyService service.TopologyService
+}
+
+func NewTopologyHandler(handler *Handler, topologyService service.TopologyService) *TopologyHandler {
+	return &TopologyHandler{
+		Handler:         handler,
+		topologyService: topologyService,
+	}
+}
+
+// GetTopology godoc
+// @Summary 获取拓扑图
+// @Description 以顶点和边的形式一次返回集群 -> 节点 -> 虚拟机的完整拓扑，存储和网桥作为节点的附属资源，共享存储在集群内合并为一个顶点。集群、节点、虚拟机和存储来自平台数据库；include_bridges 为 true 时实时查询各节点网桥，查询失败的节点记录在 warnings 中
+// @Tags 拓扑模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "集群ID，默认全部集群"
+// @Param include_templates query bool false "是否包含模板虚拟机"
+// @Param include_bridges query bool false "是否查询网桥"
+// @Success 200 {object} v1.GetTopologyResponse
+// @Router /api/v1/topology [get]
+func (h *TopologyHandler) GetTopology(ctx *gin.Context) {
+	req := new(v1.GetTopologyRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.topologyService.GetTopology(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("topologyService.GetTopology error", zap.Error(err))
+		status := http.StatusInternalServerError
+		if errors.Is(err, v1.ErrClusterNotFound) {
+			status = http.StatusNotFound
+		}
+		v1.HandleError(ctx, status, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMAgentInstallHandler struct {
+	*Handler
+	installService service.VMAgentInstallService
+}
+
+func NewVMAgentInstallHandler(handler *Handler, installService service.VMAgentInstallService) *VMAgentInstallHandler {
+	return &VMAgentInstallHandler{
+		Handler:        handler,
+		installService: installService,
+	}
+}
+
+func vmAgentInstallErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMNotFound),
+		errors.Is(err, v1.ErrAgentInstallNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrBadRequest),
+		errors.Is(err, v1.ErrAgentInstallSourceMissing),
+		errors.Is(err, v1.ErrCloudInitDriveMissing),
+		errors.Is(err, v1.ErrNoFreeCDROMSlot):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrAgentInstallInProgress),
+		errors.Is(err, v1.ErrAgentInstallFinished),
+		errors.Is(err, v1.ErrGuestAgentRunning):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// StartVMAgentInstall godoc
+// @Summary 为虚拟机安装 guest agent
+// @Description cloudinit：将预先准备的 snippet 设为 cloud-init vendor 数据，重启后由 cloud-init 安装 qemu-guest-agent（需要 cloud-init 驱动器）；
+// @Description iso：在空闲的 IDE/SATA 光驱挂载预先准备的安装 ISO，由用户在虚拟机内安装。
+// @Description 未开启 agent 选项时一并开启（需关机后再启动）。后台定期 ping agent，响应后标记成功并卸载 ISO、恢复原 cicustom
+// @Tags 虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.StartVMAgentInstallRequest true "params"
+// @Success 200 {object} v1.VMAgentInstallResponse
+// @Router /api/v1/vms/{id}/agent-install [post]
+func (h *VMAgentInstallHandler) StartVMAgentInstall(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.StartVMAgentInstallRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.installService.Start(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("installService.Start error", zap.Error(err))
+		v1.HandleError(ctx, vmAgentInstallErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetVMAgentInstall godoc
+// @Summary 获取虚拟机的 guest agent 安装进度
+// @Description 返回最近一次安装记录，等待中的记录会立即 ping 一次 agent
+// @Tags 虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.VMAgentInstallResponse
+// @Router /api/v1/vms/{id}/agent-install [get]
+func (h *VMAgentInstallHandler) GetVMAgentInstall(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.installService.GetByVM(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("installService.GetByVM error", zap.Error(err))
+		v1.HandleError(ctx, vmAgentInstallErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListVMAgentInstalls godoc
+// @Summary 查询 guest agent 安装记录
+// @Description 可按集群和状态过滤，用于跟踪 agent 覆盖率的整改进度
+// @Tags 虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "集群ID"
+// @Param status query string false "状态：waiting / succeeded / failed / cancelled"
+// @Success 200 {object} v1.ListVMAgentInstallsResponse
+// @Router /api/v1/agent-installs [get]
+func (h *VMAgentInstallHandler) ListVMAgentInstalls(ctx *gin.Context) {
+	req := new(v1.ListVMAgentInstallsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.installService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("installService.List error", zap.Error(err))
+		v1.HandleError(ctx, vmAgentInstallErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CancelVMAgentInstall godoc
+// @Summary 取消 guest agent 安装
+// @Description 创建人或管理员可操作；卸载安装 ISO，恢复原 cicustom 和 agent 选项
+// @Tags 虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "安装记录ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/agent-installs/{id}/cancel [post]
+func (h *VMAgentInstallHandler) CancelVMAgentInstall(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.installService.Cancel(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("installService.Cancel error", zap.Error(err))
+		v1.HandleError(ctx, vmAgentInstallErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMBulkDeleteHandler struct {
+	*Handler
+	bulkDeleteService service.VMBulkDeleteService
+}
+
+func NewVMBulkDeleteHandler(handler *Handler, bulkDeleteService service.VMBulkDeleteService) *VMBulkDeleteHandler {
+	return &VMBulkDeleteHandler{
+		Handler:           handler,
+		bulkDeleteService: bulkDeleteService,
+	}
+}
+
+func vmBulkDeleteErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrBadRequest), errors.Is(err, v1.ErrVMBulkDeleteConfirmText):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrClusterNotFound), errors.Is(err, v1.ErrVMBulkDeleteJobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrVMBulkDeleteNoTargets), errors.Is(err, v1.ErrVMBulkDeleteTokenInvalid):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// PrepareBulkDelete godoc
+// @Summary 批量删除虚拟机预检
+// @Description 按虚拟机 ID、Proxmox 标签（需 cluster_id）或项目（business_service）选出虚拟机，跳过模板、开启删除保护和被 Proxmox 任务锁定的虚拟机，返回确认令牌和需要原样输入的确认文本，不执行删除
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.PrepareVMBulkDeleteRequest true "params"
+// @Success 200 {object} v1.PrepareVMBulkDeleteResponse
+// @Router /api/v1/vm-bulk-deletes/prepare [post]
+func (h *VMBulkDeleteHandler) PrepareBulkDelete(ctx *gin.Context) {
+	req := new(v1.PrepareVMBulkDeleteRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.bulkDeleteService.Prepare(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("bulkDeleteService.Prepare error", zap.Error(err))
+		v1.HandleError(ctx, vmBulkDeleteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ConfirmBulkDelete godoc
+// @Summary 提交批量删除虚拟机
+// @Description 使用预检返回的确认令牌和确认文本创建后台删除任务，按 vm_bulk_delete.concurrency 并发逐台停止并删除；令牌单次使用，仅预检用户可提交
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ConfirmVMBulkDeleteRequest true "params"
+// @Success 200 {object} v1.VMBulkDeleteJobResponse
+// @Router /api/v1/vm-bulk-deletes [post]
+func (h *VMBulkDeleteHandler) ConfirmBulkDelete(ctx *gin.Context) {
+	req := new(v1.ConfirmVMBulkDeleteRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.bulkDeleteService.Confirm(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("bulkDeleteService.Confirm error", zap.Error(err))
+		v1.HandleError(ctx, vmBulkDeleteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListBulkDeletes godoc
+// @Summary 查询批量删除任务
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param status query string false "状态 pending/running/completed"
+// @Param creator query string false "提交人"
+// @Success 200 {object} v1.ListVMBulkDeleteJobsResponse
+// @Router /api/v1/vm-bulk-deletes [get]
+func (h *VMBulkDeleteHandler) ListBulkDeletes(ctx *gin.Context) {
+	req := new(v1.ListVMBulkDeleteJobsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.bulkDeleteService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("bulkDeleteService.List error", zap.Error(err))
+		v1.HandleError(ctx, vmBulkDeleteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetBulkDelete godoc
+// @Summary 获取批量删除报告
+// @Description 返回任务状态、已删除 / 跳过 / 失败数量，以及每台虚拟机的结果和原因
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} v1.VMBulkDeleteJobResponse
+// @Router /api/v1/vm-bulk-deletes/{id} [get]
+func (h *VMBulkDeleteHandler) GetBulkDelete(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.bulkDeleteService.Get(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("bulkDeleteService.Get error", zap.Error(err))
+		v1.HandleError(ctx, vmBulkDeleteErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMClaimHandler struct {
+	*Handler
+	claimService service.VMClaimService
+}
+
+func NewVMClaimHandler(handler *Handler, claimService service.VMClaimService) *VMClaimHandler {
+	return &VMClaimHandler{
+		Handler:      handler,
+		claimService: claimService,
+	}
+}
+
+func vmClaimErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrUnclaimedVMNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrUnclaimedVMGone), errors.Is(err, v1.ErrUnclaimedVMNodeSync):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListUnclaimedVMs godoc
+// @Summary 获取待认领虚拟机列表
+// @Description untracked：Proxmox 中存在但平台没有记录；unowned：已有记录但未设置负责人
+// @Tags 虚拟机认领模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param cluster_id query int false "集群ID"
+// @Param reason query string false "原因：untracked / unowned"
+// @Param include_ignored query bool false "是否包含已忽略的虚拟机"
+// @Success 200 {object} v1.ListUnclaimedVMsResponse
+// @Router /api/v1/unclaimed-vms [get]
+func (h *VMClaimHandler) ListUnclaimedVMs(ctx *gin.Context) {
+	req := new(v1.ListUnclaimedVMsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.claimService.ListUnclaimed(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("claimService.ListUnclaimed error", zap.Error(err))
+		v1.HandleError(ctx, vmClaimErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// AdoptUnclaimedVM godoc
+// @Summary 认领虚拟机
+// @Description 平台没有记录的虚拟机会创建记录；owner 为空时认领给当前用户，其余未填写的字段保留原值
+// @Tags 虚拟机认领模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "待认领记录ID"
+// @Param request body v1.AdoptUnclaimedVMRequest true "params"
+// @Success 200 {object} v1.AdoptUnclaimedVMResponse
+// @Router /api/v1/unclaimed-vms/{id}/adopt [post]
+func (h *VMClaimHandler) AdoptUnclaimedVM(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.AdoptUnclaimedVMRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.claimService.Adopt(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("claimService.Adopt error", zap.Error(err))
+		v1.HandleError(ctx, vmClaimErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// IgnoreUnclaimedVM godoc
+// @Summary 忽略或取消忽略待认领虚拟机
+// @Description 仅管理员可操作，已忽略的虚拟机不再提醒，默认不在列表中显示
+// @Tags 虚拟机认领模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "待认领记录ID"
+// @Param request body v1.IgnoreUnclaimedVMRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/unclaimed-vms/{id}/ignore [put]
+func (h *VMClaimHandler) IgnoreUnclaimedVM(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.IgnoreUnclaimedVMRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.claimService.SetIgnored(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("claimService.SetIgnored error", zap.Error(err))
+		v1.HandleError(ctx, vmClaimErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// RefreshUnclaimedVMs godoc
+// @Summary 立即扫描待认领虚拟机
+// @Description 仅管理员可操作
+// @Tags 虚拟机认领模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.RefreshUnclaimedVMsResponse
+// @Router /api/v1/unclaimed-vms/refresh [post]
+func (h *VMClaimHandler) RefreshUnclaimedVMs(ctx *gin.Context) {
+	data, err := h.claimService.Refresh(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("claimService.Refresh error", zap.Error(err))
+		v1.HandleError(ctx, vmClaimErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMCredentialHandler struct {
+	*Handler
+	credentialService service.VMCredentialService
+}
+
+func NewVMCredentialHandler(handler *Handler, credentialService service.VMCredentialService) *VMCredentialHandler {
+	return &VMCredentialHandler{
+		Handler:           handler,
+		credentialService: credentialService,
+	}
+}
+
+func vmCredentialErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired), errors.Is(err, v1.ErrVMCredentialForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrVMCredentialNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrVMCredentialLeaseInvalid):
+		return http.StatusGone
+	case errors.Is(err, v1.ErrSecretBackendUnavailable):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// LeaseVMCredential godoc
+// @Summary 申请虚拟机凭据租约
+// @Description 虚拟机负责人、创建人或管理员可申请；返回的 token 在 secret.lease_ttl 内有效且只能使用一次，每次申请都记录审计
+// @Tags 虚拟机凭据模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.VMCredentialLeaseResponse
+// @Router /api/v1/vms/{id}/credentials/lease [post]
+func (h *VMCredentialHandler) LeaseVMCredential(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.credentialService.Lease(ctx, GetUserIdFromCtx(ctx), id, ctx.ClientIP())
+	if err != nil {
+		h.logger.WithContext(ctx).Error("credentialService.Lease error", zap.Error(err))
+		v1.HandleError(ctx, vmCredentialErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// RetrieveVMCredential godoc
+// @Summary 读取虚拟机凭据
+// @Description 凭租约 token 读取密码，token 只能由申请人使用一次；每次读取（包括失败）都记录审计
+// @Tags 虚拟机凭据模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.RetrieveVMCredentialRequest true "params"
+// @Success 200 {object} v1.VMCredentialResponse
+// @Router /api/v1/vm-credentials/retrieve [post]
+func (h *VMCredentialHandler) RetrieveVMCredential(ctx *gin.Context) {
+	req := new(v1.RetrieveVMCredentialRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.credentialService.Retrieve(ctx, GetUserIdFromCtx(ctx), req.Token, ctx.ClientIP())
+	if err != nil {
+		h.logger.WithContext(ctx).Error("credentialService.Retrieve error", zap.Error(err))
+		v1.HandleError(ctx, vmCredentialErrorStatus(err), err, nil)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	v1.HandleSuccess(ctx, data)
+}
+
+// MigrateVMCredentials godoc
+// @Summary 迁移明文密码到密钥后端
+// @Description 仅管理员；把仍以明文保存在数据库中的虚拟机密码写入当前 secret.backend，单次最多处理 1000 台，可重复调用
+// @Tags 虚拟机凭据模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.MigrateVMCredentialsResponse
+// @Router /api/v1/vm-credentials/migrate [post]
+func (h *VMCredentialHandler) MigrateVMCredentials(ctx *gin.Context) {
+	data, err := h.credentialService.MigratePlaintext(ctx, GetUserIdFromCtx(ctx), ctx.ClientIP())
+	if err != nil {
+		h.logger.WithContext(ctx).Error("credentialService.MigratePlaintext error", zap.Error(err))
+		v1.HandleError(ctx, vmCredentialErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListVMCredentialAudit godoc
+// @Summary 查询凭据审计记录
+// @Description 仅管理员；记录密码写入、租约申请、读取、删除和迁移
+// @Tags 虚拟机凭据模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param vm_id query int false "虚拟机ID"
+// @Param action query string false "动作 write/lease/read/delete/migrate"
+// @Param operator query string false "操作人"
+// @Success 200 {object} v1.ListSecretAuditResponse
+// @Router /api/v1/vm-credentials/audit [get]
+func (h *VMCredentialHandler) ListVMCredentialAudit(ctx *gin.Context) {
+	req := new(v1.ListSecretAuditRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.credentialService.ListAudit(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("credentialService.ListAudit error", zap.Error(err))
+		v1.HandleError(ctx, vmCredentialErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMEventHandler struct {
+	*Handler
+	eventService service.VMEventService
+}
+
+func NewVMEventHandler(handler *Handler, eventService service.VMEventService) *VMEventHandler {
+	return &VMEventHandler{
+		Handler:      handler,
+		eventService: eventService,
+	}
+}
+
+func vmEventErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrVMNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// ListVMEvents godoc
+// @Summary 获取虚拟机事件时间线
+// @Description 按时间倒序合并 Proxmox 任务（task）、PveSphere 操作记录（audit，非管理员只能看到自己的操作）和控制器检测到的状态变化（status）。Proxmox 不可达时仍返回其他来源，原因记录在 warnings 中
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param source query string false "事件来源" Enums(task, audit, status)
+// @Success 200 {object} v1.ListVMEventsResponse
+// @Router /api/v1/vms/{id}/events [get]
+func (h *VMEventHandler) ListVMEvents(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.ListVMEventsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.eventService.ListEvents(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("eventService.ListEvents error", zap.Error(err))
+		v1.HandleError(ctx, vmEventErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMHibernateHandler struct {
+	*Handler
+	hibernateService service.VMHibernateService
+}
+
+func NewVMHibernateHandler(handler *Handler, hibernateService service.VMHibernateService) *VMHibernateHandler {
+	return &VMHibernateHandler{
+		Handler:          handler,
+		hibernateService: hibernateService,
+	}
+}
+
+func vmHibernateErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrClusterNotFound), errors.Is(err, v1.ErrVMStateStorageInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrVMAlreadyStopped), errors.Is(err, v1.ErrVMNotHibernated):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// HibernateVM godoc
+// @Summary 休眠虚拟机
+// @Description 将虚拟机挂起到磁盘：内存写入状态存储上的 vmstate 卷后虚拟机停止，释放节点内存但保留运行状态。
+// @Description 不指定 state_storage 时由 Proxmox 选择；指定的存储需存在于虚拟机所在节点、已启用且支持 images
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.HibernateVMRequest false "params"
+// @Success 200 {object} v1.HibernateVMResponse
+// @Router /api/v1/vms/{id}/hibernate [post]
+func (h *VMHibernateHandler) HibernateVM(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.HibernateVMRequest)
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(req); err != nil {
+			v1.HandleBindError(ctx, err)
+			return
+		}
+	}
+
+	data, err := h.hibernateService.Hibernate(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("hibernateService.Hibernate error", zap.Error(err))
+		v1.HandleError(ctx, vmHibernateErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ResumeVM godoc
+// @Summary 恢复休眠的虚拟机
+// @Description 启动休眠的虚拟机，Proxmox 从 vmstate 卷恢复内存后删除该卷；虚拟机未处于休眠状态时返回 409
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.ResumeVMResponse
+// @Router /api/v1/vms/{id}/resume [post]
+func (h *VMHibernateHandler) ResumeVM(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.hibernateService.Resume(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("hibernateService.Resume error", zap.Error(err))
+		v1.HandleError(ctx, vmHibernateErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListHibernatedVMs godoc
+// @Summary 休眠中的虚拟机
+// @Description 以 Proxmox 当前状态为准列出休眠中的虚拟机及其 vmstate 卷，无法查询的集群在 failed_clusters 中返回
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "集群ID"
+// @Success 200 {object} v1.ListHibernatedVMsResponse
+// @Router /api/v1/vms/hibernated [get]
+func (h *VMHibernateHandler) ListHibernatedVMs(ctx *gin.Context) {
+	req := new(v1.ListHibernatedVMsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.hibernateService.ListHibernated(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("hibernateService.ListHibernated error", zap.Error(err))
+		v1.HandleError(ctx, vmHibernateErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
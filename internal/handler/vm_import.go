@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMImportHandler struct {
+	*Handler
+	importService service.VMImportService
+}
+
+func NewVMImportHandler(handler *Handler, importService service.VMImportService) *VMImportHandler {
+	return &VMImportHandler{
+		Handler:       handler,
+		importService: importService,
+	}
+}
+
+func vmImportErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMImportSourceNotFound), errors.Is(err, v1.ErrVMImportTaskNotFound),
+		errors.Is(err, v1.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrVMImportSourceExists), errors.Is(err, v1.ErrVMAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrVMImportInvalidVolume),
+		errors.Is(err, v1.ErrVMImportInvalidSpec),
+		errors.Is(err, v1.ErrClusterNotFound),
+		errors.Is(err, v1.ErrClusterNotSchedulable),
+		errors.Is(err, v1.ErrTargetNodeNotInCluster):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// CreateSource godoc
+// @Summary 注册 ESXi 导入源
+// @Description 仅管理员可操作。在集群中创建 type=esxi 的 Proxmox 存储（需要 Proxmox VE 8.2+），用于列出和导入 ESXi / vCenter 上的虚拟机
+// @Tags 虚拟机导入模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateVMImportSourceRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vm-imports/sources [post]
+func (h *VMImportHandler) CreateSource(ctx *gin.Context) {
+	req := new(v1.CreateVMImportSourceRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.importService.CreateSource(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("importService.CreateSource error", zap.Error(err))
+		v1.HandleError(ctx, vmImportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListSources godoc
+// @Summary 获取 ESXi 导入源列表
+// @Tags 虚拟机导入模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "集群ID"
+// @Success 200 {object} v1.ListVMImportSourcesResponse
+// @Router /api/v1/vm-imports/sources [get]
+func (h *VMImportHandler) ListSources(ctx *gin.Context) {
+	req := new(v1.ListVMImportSourcesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.importService.ListSources(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("importService.ListSources error", zap.Error(err))
+		v1.HandleError(ctx, vmImportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteSource godoc
+// @Summary 删除 ESXi 导入源
+// @Description 仅管理员可操作，同时删除 Proxmox 中对应的存储配置，已导入的虚拟机不受影响
+// @Tags 虚拟机导入模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "导入源ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vm-imports/sources/{id} [delete]
+func (h *VMImportHandler) DeleteSource(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.importService.DeleteSource(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("importService.DeleteSource error", zap.Error(err))
+		v1.HandleError(ctx, vmImportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListSourceVMs godoc
+// @Summary 列出 ESXi 导入源上的虚拟机
+// @Description 通过指定节点访问导入源，返回可导入的虚拟机及其导入卷
+// @Tags 虚拟机导入模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "导入源ID"
+// @Param node_id query int true "节点ID"
+// @Success 200 {object} v1.ListImportableVMsResponse
+// @Router /api/v1/vm-imports/sources/{id}/vms [get]
+func (h *VMImportHandler) ListSourceVMs(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.ListImportableVMsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.importService.ListSourceVMs(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("importService.ListSourceVMs error", zap.Error(err))
+		v1.HandleError(ctx, vmImportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetMetadata godoc
+// @Summary 读取导入元数据
+// @Description 读取 ESXi 虚拟机或已上传 OVA / OVF 的配置，返回转换后的创建参数、磁盘、网卡及转换告警，用于配置存储和网络映射
+// @Tags 虚拟机导入模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param node_id query int true "节点ID"
+// @Param volume query string true "导入卷"
+// @Success 200 {object} v1.GetVMImportMetadataResponse
+// @Router /api/v1/vm-imports/metadata [get]
+func (h *VMImportHandler) GetMetadata(ctx *gin.Context) {
+	req := new(v1.GetVMImportMetadataRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.importService.GetMetadata(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("importService.GetMetadata error", zap.Error(err))
+		v1.HandleError(ctx, vmImportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CreateImport godoc
+// @Summary 创建虚拟机导入任务
+// @Description 按存储和网络映射将 ESXi 虚拟机或 OVA / OVF 转换为 Proxmox 虚拟机。请求校验通过后立即返回任务，导入在后台执行，完成后自动创建虚拟机记录。
+// @Description live_import 仅支持 ESXi 源：虚拟机创建后立即启动，磁盘数据在后台迁移
+// @Tags 虚拟机导入模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateVMImportRequest true "params"
+// @Success 200 {object} v1.GetVMImportTaskResponse
+// @Router /api/v1/vm-imports [post]
+func (h *VMImportHandler) CreateImport(ctx *gin.Context) {
+	req := new(v1.CreateVMImportRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.importService.CreateImport(ctx, req, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("importService.CreateImport error", zap.Error(err))
+		v1.HandleError(ctx, vmImportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetImport godoc
+// @Summary 获取虚拟机导入任务详情
+// @Description 返回任务状态、估算进度及映射后的创建参数
+// @Tags 虚拟机导入模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} v1.GetVMImportTaskResponse
+// @Router /api/v1/vm-imports/{id} [get]
+func (h *VMImportHandler) GetImport(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.importService.GetImport(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("importService.GetImport error", zap.Error(err))
+		v1.HandleError(ctx, vmImportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListImports godoc
+// @Summary 获取虚拟机导入任务列表
+// @Tags 虚拟机导入模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param cluster_id query int false "集群ID"
+// @Param status query string false "状态（pending, importing, completed, failed）"
+// @Success 200 {object} v1.ListVMImportTasksResponse
+// @Router /api/v1/vm-imports [get]
+func (h *VMImportHandler) ListImports(ctx *gin.Context) {
+	req := new(v1.ListVMImportTasksRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.importService.ListImports(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("importService.ListImports error", zap.Error(err))
+		v1.HandleError(ctx, vmImportErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMListViewHandler struct {
+	*Handler
+	viewService service.VMListViewService
+}
+
+func NewVMListViewHandler(handler *Handler, viewService service.VMListViewService) *VMListViewHandler {
+	return &VMListViewHandler{
+		Handler:     handler,
+		viewService: viewService,
+	}
+}
+
+// CreateView godoc
+// @Summary 保存虚拟机列表视图
+// @Description 保存筛选条件、排序和显示列，可设为默认视图或共享给所有用户（只读）
+// @Tags 用户偏好模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateVMListViewRequest true "params"
+// @Success 200 {object} v1.GetVMListViewResponse
+// @Router /api/v1/preferences/vm-views [post]
+func (h *VMListViewHandler) CreateView(ctx *gin.Context) {
+	req := new(v1.CreateVMListViewRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.viewService.CreateView(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("viewService.CreateView error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateView godoc
+// @Summary 更新虚拟机列表视图
+// @Description 仅视图所有者可修改
+// @Tags 用户偏好模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "视图ID"
+// @Param request body v1.UpdateVMListViewRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/preferences/vm-views/{id} [put]
+func (h *VMListViewHandler) UpdateView(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.UpdateVMListViewRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.viewService.UpdateView(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("viewService.UpdateView error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteView godoc
+// @Summary 删除虚拟机列表视图
+// @Description 仅视图所有者可删除
+// @Tags 用户偏好模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "视图ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/preferences/vm-views/{id} [delete]
+func (h *VMListViewHandler) DeleteView(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.viewService.DeleteView(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("viewService.DeleteView error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetView godoc
+// @Summary 获取虚拟机列表视图详情
+// @Tags 用户偏好模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "视图ID"
+// @Success 200 {object} v1.GetVMListViewResponse
+// @Router /api/v1/preferences/vm-views/{id} [get]
+func (h *VMListViewHandler) GetView(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.viewService.GetView(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("viewService.GetView error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListViews godoc
+// @Summary 获取虚拟机列表视图
+// @Description 返回当前用户自己的视图以及其他用户共享的视图
+// @Tags 用户偏好模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.ListVMListViewsResponse
+// @Router /api/v1/preferences/vm-views [get]
+func (h *VMListViewHandler) ListViews(ctx *gin.Context) {
+	data, err := h.viewService.ListViews(ctx, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("viewService.ListViews error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMLockHandler struct {
+	*Handler
+	lockService service.VMLockService
+}
+
+func NewVMLockHandler(handler *Handler, lockService service.VMLockService) *VMLockHandler {
+	return &VMLockHandler{
+		Handler:     handler,
+		lockService: lockService,
+	}
+}
+
+func vmLockErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrVMLockNotHeld):
+		return http.StatusLocked
+	case errors.Is(err, v1.ErrInvalidParameter):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetVMLock godoc
+// @Summary 获取虚拟机维护锁
+// @Description 返回 PveSphere 维护锁（持有人、原因、过期时间）以及 Proxmox 配置中的 lock 字段
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.VMLockStatusResponse
+// @Router /api/v1/vms/{id}/lock [get]
+func (h *VMLockHandler) GetVMLock(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.lockService.Get(ctx, GetUserIdFromCtx(ctx), id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("lockService.Get error", zap.Error(err))
+		v1.HandleError(ctx, vmLockErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// LockVM godoc
+// @Summary 锁定虚拟机维护
+// @Description 加锁期间除持有人外任何人（包括后台任务）都不能对虚拟机执行开关机、修改配置、迁移和删除；持有人再次调用可更新原因和续期。
+// @Description 仅管理员可以为他人加锁
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.LockVMRequest true "params"
+// @Success 200 {object} v1.VMLockResponse
+// @Router /api/v1/vms/{id}/lock [put]
+func (h *VMLockHandler) LockVM(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.LockVMRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.lockService.Lock(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("lockService.Lock error", zap.Error(err))
+		v1.HandleError(ctx, vmLockErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UnlockVM godoc
+// @Summary 解除虚拟机维护锁
+// @Description 持有人或管理员可以解锁
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vms/{id}/lock [delete]
+func (h *VMLockHandler) UnlockVM(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.lockService.Unlock(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("lockService.Unlock error", zap.Error(err))
+		v1.HandleError(ctx, vmLockErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListVMLocks godoc
+// @Summary 查询虚拟机维护锁
+// @Description 列出生效中的维护锁，可按集群、持有人过滤
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "集群ID"
+// @Param owner query string false "持有人"
+// @Param mine query bool false "只看当前用户持有的锁"
+// @Success 200 {object} v1.ListVMLocksResponse
+// @Router /api/v1/vm-locks [get]
+func (h *VMLockHandler) ListVMLocks(ctx *gin.Context) {
+	req := new(v1.ListVMLocksRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.lockService.List(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("lockService.List error", zap.Error(err))
+		v1.HandleError(ctx, vmLockErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
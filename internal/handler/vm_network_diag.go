@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMNetworkDiagHandler struct {
+	*Handler
+	diagService service.VMNetworkDiagService
+}
+
+func NewVMNetworkDiagHandler(handler *Handler, diagService service.VMNetworkDiagService) *VMNetworkDiagHandler {
+	return &VMNetworkDiagHandler{
+		Handler:     handler,
+		diagService: diagService,
+	}
+}
+
+func vmNetworkDiagErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidDiagTarget):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Diagnose godoc
+// @Summary 虚拟机网络诊断
+// @Description 从 PveSphere 服务器对目标执行 ping / TCP 端口探测，并返回虚拟机网卡、节点网桥/VLAN 配置和 guest agent 上报的 IP，给出排查提示。
+// @Description from_guest=true 时同时通过 qemu-guest-agent 在虚拟机内部执行探测（仅管理员，需虚拟机运行且 guest agent 可用）
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.VMNetworkDiagnoseRequest true "params"
+// @Success 200 {object} v1.VMNetworkDiagnoseResponse
+// @Router /api/v1/vms/{id}/network/diagnose [post]
+func (h *VMNetworkDiagHandler) Diagnose(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.VMNetworkDiagnoseRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.diagService.Diagnose(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("diagService.Diagnose error", zap.Error(err))
+		v1.HandleError(ctx, vmNetworkDiagErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
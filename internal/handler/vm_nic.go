@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMNICHandler struct {
+	*Handler
+	nicService service.VMNICService
+}
+
+func NewVMNICHandler(handler *Handler, nicService service.VMNICService) *VMNICHandler {
+	return &VMNICHandler{
+		Handler:    handler,
+		nicService: nicService,
+	}
+}
+
+func vmNICErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrInvalidParameter), errors.Is(err, v1.ErrInvalidMACAddress):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrMACAddressInUse):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// ListNICs godoc
+// @Summary 获取虚拟机网卡列表
+// @Description 从 Proxmox 读取虚拟机当前的 netN 配置，包括网桥、VLAN 标签、防火墙和 MAC
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.ListVMNICsResponse
+// @Router /api/v1/vms/{id}/nics [get]
+func (h *VMNICHandler) ListNICs(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.nicService.List(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nicService.List error", zap.Error(err))
+		v1.HandleError(ctx, vmNICErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// AddNIC godoc
+// @Summary 添加虚拟机网卡
+// @Description 在第一个空闲的 netN 上添加网卡，可指定 VLAN 标签和防火墙，MAC 由平台登记分配；运行中的虚拟机由 Proxmox 热插拔
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.VMNICSpec true "params"
+// @Success 200 {object} v1.VMNICResponse
+// @Router /api/v1/vms/{id}/nics [post]
+func (h *VMNICHandler) AddNIC(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.VMNICSpec)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.nicService.Add(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nicService.Add error", zap.Error(err))
+		v1.HandleError(ctx, vmNICErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateNIC godoc
+// @Summary 修改虚拟机网卡
+// @Description 修改网卡的网桥、VLAN 标签（0 表示移除）、模型和防火墙，未传的字段及 MAC、限速等其他选项保持不变
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param nic path string true "网卡，如 net0"
+// @Param request body v1.UpdateVMNICRequest true "params"
+// @Success 200 {object} v1.VMNICResponse
+// @Router /api/v1/vms/{id}/nics/{nic} [put]
+func (h *VMNICHandler) UpdateNIC(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.UpdateVMNICRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.nicService.Update(ctx, id, ctx.Param("nic"), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("nicService.Update error", zap.Error(err))
+		v1.HandleError(ctx, vmNICErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// RemoveNIC godoc
+// @Summary 移除虚拟机网卡
+// @Description 从虚拟机配置中删除网卡并释放登记的 MAC
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param nic path string true "网卡，如 net1"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vms/{id}/nics/{nic} [delete]
+func (h *VMNICHandler) RemoveNIC(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.nicService.Remove(ctx, id, ctx.Param("nic")); err != nil {
+		h.logger.WithContext(ctx).Error("nicService.Remove error", zap.Error(err))
+		v1.HandleError(ctx, vmNICErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
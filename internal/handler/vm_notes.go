@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMNotesHandler struct {
+	*Handler
+	notesService service.VMNotesService
+}
+
+func NewVMNotesHandler(handler *Handler, notesService service.VMNotesService) *VMNotesHandler {
+	return &VMNotesHandler{
+		Handler:      handler,
+		notesService: notesService,
+	}
+}
+
+func vmNotesErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrClusterNotFound), errors.Is(err, v1.ErrVMNotesInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrVMNotesConflict):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// GetVMNotes godoc
+// @Summary 获取虚拟机备注
+// @Description 从 Proxmox 读取备注（description），拆分为 Markdown 正文和 key=value 注解，并同步到虚拟机记录
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.VMNotesResponse
+// @Router /api/v1/vms/{id}/notes [get]
+func (h *VMNotesHandler) GetVMNotes(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.notesService.GetNotes(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("notesService.GetNotes error", zap.Error(err))
+		v1.HandleError(ctx, vmNotesErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateVMNotes godoc
+// @Summary 修改虚拟机备注
+// @Description 修改 Markdown 正文和/或注解并写入 Proxmox；备注不能超过 8 KiB，不能包含控制字符、未闭合的代码块和 script 等 HTML 标签。
+// @Description 传入读取时的 digest 可避免覆盖在 Proxmox 中同时进行的修改，不一致时返回 409
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.UpdateVMNotesRequest true "params"
+// @Success 200 {object} v1.VMNotesResponse
+// @Router /api/v1/vms/{id}/notes [put]
+func (h *VMNotesHandler) UpdateVMNotes(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateVMNotesRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.notesService.UpdateNotes(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("notesService.UpdateNotes error", zap.Error(err))
+		v1.HandleError(ctx, vmNotesErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMProfileHandler struct {
+	*Handler
+	profileService service.VMProfileService
+}
+
+func NewVMProfileHandler(handler *Handler, profileService service.VMProfileService) *VMProfileHandler {
+	return &VMProfileHandler{
+		Handler:        handler,
+		profileService: profileService,
+	}
+}
+
+func vmProfileErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMProfileNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrVMProfileExists):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateVMProfile godoc
+// @Summary 新增虚拟机创建规格
+// @Description 仅管理员可操作。cluster_id 为 0 表示全局规格；is_default 为 true 时取消同一集群原默认规格
+// @Tags 虚拟机创建规格模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateVMProfileRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vm-profiles [post]
+func (h *VMProfileHandler) CreateVMProfile(ctx *gin.Context) {
+	req := new(v1.CreateVMProfileRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.profileService.CreateProfile(ctx, GetUserIdFromCtx(ctx), req); err != nil {
+		h.logger.WithContext(ctx).Error("profileService.CreateProfile error", zap.Error(err))
+		v1.HandleError(ctx, vmProfileErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdateVMProfile godoc
+// @Summary 更新虚拟机创建规格
+// @Description 仅管理员可操作，不传的字段保持不变，传空字符串或 0 表示不再指定
+// @Tags 虚拟机创建规格模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规格ID"
+// @Param request body v1.UpdateVMProfileRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vm-profiles/{id} [put]
+func (h *VMProfileHandler) UpdateVMProfile(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateVMProfileRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.profileService.UpdateProfile(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("profileService.UpdateProfile error", zap.Error(err))
+		v1.HandleError(ctx, vmProfileErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteVMProfile godoc
+// @Summary 删除虚拟机创建规格
+// @Description 仅管理员可操作，已创建的虚拟机不受影响
+// @Tags 虚拟机创建规格模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规格ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vm-profiles/{id} [delete]
+func (h *VMProfileHandler) DeleteVMProfile(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.profileService.DeleteProfile(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("profileService.DeleteProfile error", zap.Error(err))
+		v1.HandleError(ctx, vmProfileErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetVMProfile godoc
+// @Summary 获取虚拟机创建规格详情
+// @Tags 虚拟机创建规格模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "规格ID"
+// @Success 200 {object} v1.GetVMProfileResponse
+// @Router /api/v1/vm-profiles/{id} [get]
+func (h *VMProfileHandler) GetVMProfile(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.profileService.GetProfile(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("profileService.GetProfile error", zap.Error(err))
+		v1.HandleError(ctx, vmProfileErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListVMProfiles godoc
+// @Summary 获取虚拟机创建规格列表
+// @Description 指定 cluster_id 时返回该集群可用的规格（集群规格和全局规格）
+// @Tags 虚拟机创建规格模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "集群ID"
+// @Success 200 {object} v1.ListVMProfilesResponse
+// @Router /api/v1/vm-profiles [get]
+func (h *VMProfileHandler) ListVMProfiles(ctx *gin.Context) {
+	req := new(v1.ListVMProfilesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.profileService.ListProfiles(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("profileService.ListProfiles error", zap.Error(err))
+		v1.HandleError(ctx, vmProfileErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMProtectionHandler struct {
+	*Handler
+	protectionService service.VMProtectionService
+}
+
+func NewVMProtectionHandler(handler *Handler, protectionService service.VMProtectionService) *VMProtectionHandler {
+	return &VMProtectionHandler{
+		Handler:           handler,
+		protectionService: protectionService,
+	}
+}
+
+func vmProtectionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GetVMProtection godoc
+// @Summary 获取虚拟机删除保护
+// @Description 返回 PveSphere 删除保护（开启人、原因、被拒绝的操作）以及 Proxmox 配置中的 protection 标志
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.VMProtectionResponse
+// @Router /api/v1/vms/{id}/protection [get]
+func (h *VMProtectionHandler) GetVMProtection(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.protectionService.Get(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("protectionService.Get error", zap.Error(err))
+		v1.HandleError(ctx, vmProtectionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ProtectVM godoc
+// @Summary 开启虚拟机删除保护
+// @Description 开启后通过 API 删除、停止、迁移虚拟机都会被拒绝（包括批量删除和后台任务），只有管理员可以解除；
+// @Description proxmox=true 时同时设置 Proxmox 的 protection 标志
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.ProtectVMRequest true "params"
+// @Success 200 {object} v1.VMProtectionResponse
+// @Router /api/v1/vms/{id}/protection [put]
+func (h *VMProtectionHandler) ProtectVM(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.ProtectVMRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.protectionService.Protect(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("protectionService.Protect error", zap.Error(err))
+		v1.HandleError(ctx, vmProtectionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UnprotectVM godoc
+// @Summary 解除虚拟机删除保护
+// @Description 仅管理员可以解除；proxmox=true 时同时清除 Proxmox 的 protection 标志
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param proxmox query bool false "同时清除 Proxmox 的 protection 标志"
+// @Success 200 {object} v1.VMProtectionResponse
+// @Router /api/v1/vms/{id}/protection [delete]
+func (h *VMProtectionHandler) UnprotectVM(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.UnprotectVMRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.protectionService.Unprotect(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("protectionService.Unprotect error", zap.Error(err))
+		v1.HandleError(ctx, vmProtectionErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMQosHandler struct {
+	*Handler
+	qosService service.VMQosService
+}
+
+func NewVMQosHandler(handler *Handler, qosService service.VMQosService) *VMQosHandler {
+	return &VMQosHandler{
+		Handler:    handler,
+		qosService: qosService,
+	}
+}
+
+// CreateProfile godoc
+// @Summary 创建 QoS 限速模板
+// @Tags VM QoS模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateQosProfileRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/qos-profiles [post]
+func (h *VMQosHandler) CreateProfile(ctx *gin.Context) {
+	req := new(v1.CreateQosProfileRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.qosService.CreateProfile(ctx, req); err != nil {
+		h.logger.WithContext(ctx).Error("qosService.CreateProfile error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// UpdateProfile godoc
+// @Summary 更新 QoS 限速模板
+// @Tags VM QoS模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Param request body v1.UpdateQosProfileRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/qos-profiles/{id} [put]
+func (h *VMQosHandler) UpdateProfile(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.UpdateQosProfileRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.qosService.UpdateProfile(ctx, id, req); err != nil {
+		h.logger.WithContext(ctx).Error("qosService.UpdateProfile error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteProfile godoc
+// @Summary 删除 QoS 限速模板
+// @Tags VM QoS模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/qos-profiles/{id} [delete]
+func (h *VMQosHandler) DeleteProfile(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.qosService.DeleteProfile(ctx, id); err != nil {
+		h.logger.WithContext(ctx).Error("qosService.DeleteProfile error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetProfile godoc
+// @Summary 获取 QoS 限速模板详情
+// @Tags VM QoS模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Success 200 {object} v1.GetQosProfileResponse
+// @Router /api/v1/qos-profiles/{id} [get]
+func (h *VMQosHandler) GetProfile(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.qosService.GetProfile(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("qosService.GetProfile error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListProfiles godoc
+// @Summary 获取 QoS 限速模板列表
+// @Tags VM QoS模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param name query string false "模板名称"
+// @Success 200 {object} v1.ListQosProfileResponse
+// @Router /api/v1/qos-profiles [get]
+func (h *VMQosHandler) ListProfiles(ctx *gin.Context) {
+	req := new(v1.ListQosProfileRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.PageSize <= 0 {
+		req.PageSize = 10
+	}
+
+	data, err := h.qosService.ListProfiles(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("qosService.ListProfiles error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ApplyProfile godoc
+// @Summary 将 QoS 限速模板批量应用到虚拟机
+// @Description 对每台虚拟机的所有磁盘（不含光驱）和网卡写入模板中的限速参数，逐台返回结果
+// @Tags VM QoS模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "模板ID"
+// @Param request body v1.ApplyQosProfileRequest true "params"
+// @Success 200 {object} v1.ApplyQosProfileResponse
+// @Router /api/v1/qos-profiles/{id}/apply [post]
+func (h *VMQosHandler) ApplyProfile(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.ApplyQosProfileRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.qosService.ApplyProfile(ctx, id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("qosService.ApplyProfile error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetVMQos godoc
+// @Summary 获取虚拟机磁盘/网卡限速配置
+// @Tags VM QoS模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param vm_id query int true "虚拟机ID"
+// @Success 200 {object} v1.GetVMQosResponse
+// @Router /api/v1/vms/qos [get]
+func (h *VMQosHandler) GetVMQos(ctx *gin.Context) {
+	req := new(v1.GetVMQosRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.qosService.GetVMQos(ctx, req.VMID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("qosService.GetVMQos error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateVMQos godoc
+// @Summary 更新虚拟机磁盘/网卡限速配置
+// @Tags VM QoS模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.UpdateVMQosRequest true "params"
+// @Success 200 {object} v1.UpdateVMQosResponse
+// @Router /api/v1/vms/qos [put]
+func (h *VMQosHandler) UpdateVMQos(ctx *gin.Context) {
+	req := new(v1.UpdateVMQosRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.qosService.UpdateVMQos(ctx, req); err != nil {
+		h.logger.WithContext(ctx).Error("qosService.UpdateVMQos error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMSecurityHandler struct {
+	*Handler
+	securityService service.VMSecurityService
+}
+
+func NewVMSecurityHandler(handler *Handler, securityService service.VMSecurityService) *VMSecurityHandler {
+	return &VMSecurityHandler{
+		Handler:         handler,
+		securityService: securityService,
+	}
+}
+
+func vmSecurityErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrVMNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrBadRequest):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// ScanVMSecurity godoc
+// @Summary 扫描虚拟机配置安全风险
+// @Description 实时读取虚拟机配置并检查风险项：agent 未开启、网卡未开启防火墙、VNC 无密码、机器类型过旧、光驱仍挂载 ISO、生产环境使用 kvm64 CPU。结果按风险等级从高到低返回并保存
+// @Tags 虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.VMSecurityScanResponse
+// @Router /api/v1/vms/{id}/security [get]
+func (h *VMSecurityHandler) ScanVMSecurity(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.securityService.Scan(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("securityService.Scan error", zap.Error(err))
+		v1.HandleError(ctx, vmSecurityErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// RemediateVMSecurity godoc
+// @Summary 自动修复虚拟机配置安全风险
+// @Description 修复指定的风险项后重新扫描。开启 agent、修改机器类型和 CPU 类型需要关机后再启动才生效（restart_required）；vnc_no_password 需要人工处理
+// @Tags 虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.RemediateVMSecurityRequest true "params"
+// @Success 200 {object} v1.RemediateVMSecurityResponse
+// @Router /api/v1/vms/{id}/security/remediate [post]
+func (h *VMSecurityHandler) RemediateVMSecurity(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.RemediateVMSecurityRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.securityService.Remediate(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("securityService.Remediate error", zap.Error(err))
+		v1.HandleError(ctx, vmSecurityErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListVMSecurityFindings godoc
+// @Summary 查询虚拟机配置安全风险
+// @Description 返回全部虚拟机最近一次扫描发现的风险项及按风险等级的统计，后台按 security_scan.interval 定期全量扫描
+// @Tags 虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "集群ID"
+// @Param severity query string false "风险等级：low / medium / high"
+// @Param code query string false "检查项"
+// @Success 200 {object} v1.ListVMSecurityFindingsResponse
+// @Router /api/v1/security/findings [get]
+func (h *VMSecurityHandler) ListVMSecurityFindings(ctx *gin.Context) {
+	req := new(v1.ListVMSecurityFindingsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.securityService.List(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("securityService.List error", zap.Error(err))
+		v1.HandleError(ctx, vmSecurityErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
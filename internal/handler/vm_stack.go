@@ -0,0 +1,179 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMStackHandler struct {
+	*Handler
+	stackService service.VMStackService
+}
+
+func NewVMStackHandler(handler *Handler, stackService service.VMStackService) *VMStackHandler {
+	return &VMStackHandler{
+		Handler:      handler,
+		stackService: stackService,
+	}
+}
+
+func vmStackErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrVMStackNameExists), errors.Is(err, v1.ErrVMStackProvisioning):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrVMStackInvalidSpec),
+		errors.Is(err, v1.ErrVMStackNoSchedulableNode),
+		errors.Is(err, v1.ErrClusterNotFound),
+		errors.Is(err, v1.ErrClusterNotSchedulable),
+		errors.Is(err, v1.ErrTemplateNotFound),
+		errors.Is(err, v1.ErrTargetNodeNotInCluster):
+		return http.StatusBadRequest
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// CreateStack godoc
+// @Summary 创建虚拟机编排
+// @Description 按角色从模板批量创建一组虚拟机（如 k8s 3 master + N worker），每个角色可单独指定规格、节点、静态 IP 和 cloud-init。
+// @Description 请求校验通过后立即返回规划结果（虚拟机名称、节点），随后按角色 order 顺序逐台克隆、配置、启动；任一台失败则停止，后续虚拟机标记为 skipped。
+// @Tags 虚拟机编排模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateVMStackRequest true "params"
+// @Success 200 {object} v1.CreateVMStackResponse
+// @Router /api/v1/stacks [post]
+func (h *VMStackHandler) CreateStack(ctx *gin.Context) {
+	req := new(v1.CreateVMStackRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.stackService.CreateStack(ctx, req, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("stackService.CreateStack error", zap.Error(err))
+		v1.HandleError(ctx, vmStackErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetStack godoc
+// @Summary 获取虚拟机编排详情
+// @Description 返回 stack 状态及每台虚拟机的创建进度
+// @Tags 虚拟机编排模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "stack ID"
+// @Success 200 {object} v1.GetVMStackResponse
+// @Router /api/v1/stacks/{id} [get]
+func (h *VMStackHandler) GetStack(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.stackService.GetStack(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("stackService.GetStack error", zap.Error(err))
+		v1.HandleError(ctx, vmStackErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListStacks godoc
+// @Summary 获取虚拟机编排列表
+// @Tags 虚拟机编排模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param cluster_id query int false "集群ID"
+// @Success 200 {object} v1.ListVMStacksResponse
+// @Router /api/v1/stacks [get]
+func (h *VMStackHandler) ListStacks(ctx *gin.Context) {
+	req := new(v1.ListVMStacksRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.stackService.ListStacks(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("stackService.ListStacks error", zap.Error(err))
+		v1.HandleError(ctx, vmStackErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetStackIPs godoc
+// @Summary 获取虚拟机编排的 IP 列表
+// @Description 返回 stack 内所有虚拟机的 IP（静态 IP 取配置值，DHCP 取 qemu-guest-agent 上报值），by_role 按角色分组，可直接用于生成 inventory
+// @Tags 虚拟机编排模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "stack ID"
+// @Success 200 {object} v1.GetVMStackIPsResponse
+// @Router /api/v1/stacks/{id}/ips [get]
+func (h *VMStackHandler) GetStackIPs(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.stackService.GetStackIPs(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("stackService.GetStackIPs error", zap.Error(err))
+		v1.HandleError(ctx, vmStackErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeleteStack godoc
+// @Summary 删除虚拟机编排
+// @Description 仅删除 stack 记录，已创建的虚拟机保留；创建中的 stack 不允许删除
+// @Tags 虚拟机编排模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "stack ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/stacks/{id} [delete]
+func (h *VMStackHandler) DeleteStack(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.stackService.DeleteStack(ctx, id); err != nil {
+		h.logger.WithContext(ctx).Error("stackService.DeleteStack error", zap.Error(err))
+		v1.HandleError(ctx, vmStackErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
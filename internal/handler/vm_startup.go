@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMStartupHandler struct {
+	*Handler
+	startupService service.VMStartupService
+}
+
+func NewVMStartupHandler(handler *Handler, startupService service.VMStartupService) *VMStartupHandler {
+	return &VMStartupHandler{
+		Handler:        handler,
+		startupService: startupService,
+	}
+}
+
+func vmStartupErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMNotFound), errors.Is(err, v1.ErrNodeNotFound), errors.Is(err, v1.ErrBootOrderPolicyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrBootOrderPolicyExists):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// GetVMStartup godoc
+// @Summary 获取虚拟机开机自启与启动顺序
+// @Description 从 Proxmox 读取 onboot 和 startup（order / up / down）
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Success 200 {object} v1.VMStartupResponse
+// @Router /api/v1/vms/{id}/startup [get]
+func (h *VMStartupHandler) GetVMStartup(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.startupService.GetVMStartup(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("startupService.GetVMStartup error", zap.Error(err))
+		v1.HandleError(ctx, vmStartupErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateVMStartup godoc
+// @Summary 设置虚拟机开机自启与启动顺序
+// @Description 整体替换 onboot 和 startup；order / up / down 都不传时删除 startup，使用 Proxmox 默认顺序
+// @Tags PVE虚拟机模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "虚拟机ID"
+// @Param request body v1.VMStartupConfig true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vms/{id}/startup [put]
+func (h *VMStartupHandler) UpdateVMStartup(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.VMStartupConfig)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.startupService.UpdateVMStartup(ctx, id, req); err != nil {
+		h.logger.WithContext(ctx).Error("startupService.UpdateVMStartup error", zap.Error(err))
+		v1.HandleError(ctx, vmStartupErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ListPolicies godoc
+// @Summary 获取启动顺序策略列表
+// @Tags 启动顺序策略模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request query v1.ListBootOrderPoliciesRequest true "params"
+// @Success 200 {object} v1.ListBootOrderPoliciesResponse
+// @Router /api/v1/boot-order-policies [get]
+func (h *VMStartupHandler) ListPolicies(ctx *gin.Context) {
+	req := new(v1.ListBootOrderPoliciesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.startupService.ListPolicies(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("startupService.ListPolicies error", zap.Error(err))
+		v1.HandleError(ctx, vmStartupErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// CreatePolicy godoc
+// @Summary 创建启动顺序策略
+// @Description 为集群内带有指定 Proxmox 标签的虚拟机定义 onboot 和启动顺序（仅管理员），通过 apply 接口写入虚拟机
+// @Tags 启动顺序策略模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.BootOrderPolicyRequest true "params"
+// @Success 200 {object} v1.BootOrderPolicyResponse
+// @Router /api/v1/boot-order-policies [post]
+func (h *VMStartupHandler) CreatePolicy(ctx *gin.Context) {
+	req := new(v1.BootOrderPolicyRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.startupService.CreatePolicy(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("startupService.CreatePolicy error", zap.Error(err))
+		v1.HandleError(ctx, vmStartupErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdatePolicy godoc
+// @Summary 更新启动顺序策略
+// @Description 整体替换策略内容（仅管理员），不会自动写入虚拟机
+// @Tags 启动顺序策略模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Param request body v1.BootOrderPolicyRequest true "params"
+// @Success 200 {object} v1.BootOrderPolicyResponse
+// @Router /api/v1/boot-order-policies/{id} [put]
+func (h *VMStartupHandler) UpdatePolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	req := new(v1.BootOrderPolicyRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.startupService.UpdatePolicy(ctx, GetUserIdFromCtx(ctx), id, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("startupService.UpdatePolicy error", zap.Error(err))
+		v1.HandleError(ctx, vmStartupErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// DeletePolicy godoc
+// @Summary 删除启动顺序策略
+// @Description 仅管理员；已写入虚拟机的配置保持不变
+// @Tags 启动顺序策略模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "策略ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/boot-order-policies/{id} [delete]
+func (h *VMStartupHandler) DeletePolicy(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.startupService.DeletePolicy(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("startupService.DeletePolicy error", zap.Error(err))
+		v1.HandleError(ctx, vmStartupErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// ApplyPolicies godoc
+// @Summary 应用启动顺序策略
+// @Description 按 Proxmox 标签匹配集群内的虚拟机，将策略的 onboot 和 startup 写入配置不一致的虚拟机（仅管理员）；
+// @Description 多个标签命中时使用 order 最小的策略，dry_run=true 时只返回将要修改的虚拟机
+// @Tags 启动顺序策略模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.ApplyBootOrderPoliciesRequest true "params"
+// @Success 200 {object} v1.ApplyBootOrderPoliciesResponse
+// @Router /api/v1/boot-order-policies/apply [post]
+func (h *VMStartupHandler) ApplyPolicies(ctx *gin.Context) {
+	req := new(v1.ApplyBootOrderPoliciesRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.startupService.ApplyPolicies(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("startupService.ApplyPolicies error", zap.Error(err))
+		v1.HandleError(ctx, vmStartupErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
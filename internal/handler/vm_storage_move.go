@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMStorageMoveHandler struct {
+	*Handler
+	moveService service.VMStorageMoveService
+}
+
+func NewVMStorageMoveHandler(handler *Handler, moveService service.VMStorageMoveService) *VMStorageMoveHandler {
+	return &VMStorageMoveHandler{
+		Handler:     handler,
+		moveService: moveService,
+	}
+}
+
+func vmStorageMoveErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrStorageMoveNotFound), errors.Is(err, v1.ErrVMNotFound),
+		errors.Is(err, v1.ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrStorageMoveInProgress):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest),
+		errors.Is(err, v1.ErrStorageMoveNoDisks),
+		errors.Is(err, v1.ErrStorageContentUnsupported):
+		return http.StatusBadRequest
+	case errors.Is(err, v1.ErrProxmoxRequestFailed):
+		return http.StatusBadGateway
+	default:
+		return changeErrorStatus(err, http.StatusInternalServerError)
+	}
+}
+
+// CreateMove godoc
+// @Summary 创建虚拟机存储迁移任务
+// @Description 将虚拟机全部磁盘（含 EFI / TPM 磁盘，不含 CD-ROM 和 cloud-init 盘）移动到目标存储，可选择逐个或并行移动、完成后删除源卷。全部磁盘移动成功后更新虚拟机记录的存储信息
+// @Tags 虚拟机存储迁移模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateVMStorageMoveRequest true "params"
+// @Success 200 {object} v1.GetVMStorageMoveResponse
+// @Router /api/v1/vm-storage-moves [post]
+func (h *VMStorageMoveHandler) CreateMove(ctx *gin.Context) {
+	req := new(v1.CreateVMStorageMoveRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.moveService.CreateMove(ctx, req, GetUserIdFromCtx(ctx))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("moveService.CreateMove error", zap.Error(err))
+		v1.HandleError(ctx, vmStorageMoveErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// GetMove godoc
+// @Summary 获取存储迁移任务详情
+// @Description 返回任务状态、进度及各磁盘的移动状态和 Proxmox 任务 UPID
+// @Tags 虚拟机存储迁移模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "任务ID"
+// @Success 200 {object} v1.GetVMStorageMoveResponse
+// @Router /api/v1/vm-storage-moves/{id} [get]
+func (h *VMStorageMoveHandler) GetMove(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.moveService.GetMove(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("moveService.GetMove error", zap.Error(err))
+		v1.HandleError(ctx, vmStorageMoveErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListMoves godoc
+// @Summary 获取存储迁移任务列表
+// @Tags 虚拟机存储迁移模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(10)
+// @Param cluster_id query int false "集群ID"
+// @Param vm_id query int false "虚拟机ID"
+// @Param status query string false "状态（pending, running, completed, failed）"
+// @Success 200 {object} v1.ListVMStorageMovesResponse
+// @Router /api/v1/vm-storage-moves [get]
+func (h *VMStorageMoveHandler) ListMoves(ctx *gin.Context) {
+	req := new(v1.ListVMStorageMovesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.moveService.ListMoves(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("moveService.ListMoves error", zap.Error(err))
+		v1.HandleError(ctx, vmStorageMoveErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
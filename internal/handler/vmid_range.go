@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+type VMIDRangeHandler struct {
+	*Handler
+	rangeService service.VMIDRangeService
+}
+
+func NewVMIDRangeHandler(handler *Handler, rangeService service.VMIDRangeService) *VMIDRangeHandler {
+	return &VMIDRangeHandler{
+		Handler:      handler,
+		rangeService: rangeService,
+	}
+}
+
+func vmidRangeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, v1.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, v1.ErrAdminRequired):
+		return http.StatusForbidden
+	case errors.Is(err, v1.ErrVMIDRangeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, v1.ErrVMIDRangeOverlap):
+		return http.StatusConflict
+	case errors.Is(err, v1.ErrBadRequest), errors.Is(err, v1.ErrVMIDRangeInvalid), errors.Is(err, v1.ErrClusterNotFound):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// CreateVMIDRange godoc
+// @Summary 新增 VMID 段
+// @Description 仅管理员可操作。cluster_id 为 0 表示适用于全部集群，team 为空表示集群的默认段；同一集群内的段不能重叠
+// @Tags VMID段模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CreateVMIDRangeRequest true "params"
+// @Success 200 {object} v1.GetVMIDRangeResponse
+// @Router /api/v1/vmid-ranges [post]
+func (h *VMIDRangeHandler) CreateVMIDRange(ctx *gin.Context) {
+	req := new(v1.CreateVMIDRangeRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.rangeService.CreateRange(ctx, GetUserIdFromCtx(ctx), req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rangeService.CreateRange error", zap.Error(err))
+		v1.HandleError(ctx, vmidRangeErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// UpdateVMIDRange godoc
+// @Summary 更新 VMID 段
+// @Description 仅管理员可操作，不传的字段保持不变。缩小段不影响已创建的虚拟机
+// @Tags VMID段模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "VMID段ID"
+// @Param request body v1.UpdateVMIDRangeRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vmid-ranges/{id} [put]
+func (h *VMIDRangeHandler) UpdateVMIDRange(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+	req := new(v1.UpdateVMIDRangeRequest)
+	if err := ctx.ShouldBindJSON(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	if err := h.rangeService.UpdateRange(ctx, GetUserIdFromCtx(ctx), id, req); err != nil {
+		h.logger.WithContext(ctx).Error("rangeService.UpdateRange error", zap.Error(err))
+		v1.HandleError(ctx, vmidRangeErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// DeleteVMIDRange godoc
+// @Summary 删除 VMID 段
+// @Description 仅管理员可操作。段内的分配记录保留，已释放 VMID 的隔离期仍然生效
+// @Tags VMID段模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "VMID段ID"
+// @Success 200 {object} v1.Response
+// @Router /api/v1/vmid-ranges/{id} [delete]
+func (h *VMIDRangeHandler) DeleteVMIDRange(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	if err := h.rangeService.DeleteRange(ctx, GetUserIdFromCtx(ctx), id); err != nil {
+		h.logger.WithContext(ctx).Error("rangeService.DeleteRange error", zap.Error(err))
+		v1.HandleError(ctx, vmidRangeErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, nil)
+}
+
+// GetVMIDRange godoc
+// @Summary 获取 VMID 段详情
+// @Description 包含段内已分配和处于隔离期的 VMID 数
+// @Tags VMID段模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path int true "VMID段ID"
+// @Success 200 {object} v1.GetVMIDRangeResponse
+// @Router /api/v1/vmid-ranges/{id} [get]
+func (h *VMIDRangeHandler) GetVMIDRange(ctx *gin.Context) {
+	id, err := strconv.ParseInt(ctx.Param("id"), 10, 64)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, nil)
+		return
+	}
+
+	data, err := h.rangeService.GetRange(ctx, id)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rangeService.GetRange error", zap.Error(err))
+		v1.HandleError(ctx, vmidRangeErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListVMIDRanges godoc
+// @Summary 获取 VMID 段列表
+// @Tags VMID段模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param cluster_id query int false "集群ID（含全部集群适用的段）"
+// @Param team query string false "团队"
+// @Success 200 {object} v1.ListVMIDRangesResponse
+// @Router /api/v1/vmid-ranges [get]
+func (h *VMIDRangeHandler) ListVMIDRanges(ctx *gin.Context) {
+	req := new(v1.ListVMIDRangesRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.rangeService.ListRanges(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rangeService.ListRanges error", zap.Error(err))
+		v1.HandleError(ctx, vmidRangeErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
+
+// ListVMIDAllocations godoc
+// @Summary 获取 VMID 分配记录
+// @Description 已分配和已释放的 VMID，已释放的记录包含隔离期结束时间
+// @Tags VMID段模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param page query int false "页码"
+// @Param page_size query int false "每页数量"
+// @Param cluster_id query int false "集群ID"
+// @Param range_id query int false "VMID段ID"
+// @Param status query string false "状态：allocated / released"
+// @Success 200 {object} v1.ListVMIDAllocationsResponse
+// @Router /api/v1/vmid-ranges/allocations [get]
+func (h *VMIDRangeHandler) ListVMIDAllocations(ctx *gin.Context) {
+	req := new(v1.ListVMIDAllocationsRequest)
+	if err := ctx.ShouldBindQuery(req); err != nil {
+		v1.HandleBindError(ctx, err)
+		return
+	}
+
+	data, err := h.rangeService.ListAllocations(ctx, req)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("rangeService.ListAllocations error", zap.Error(err))
+		v1.HandleError(ctx, vmidRangeErrorStatus(err), err, nil)
+		return
+	}
+
+	v1.HandleSuccess(ctx, data)
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/url"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangeOverrideMiddleware 读取 X-Change-Override 请求头中的紧急放行原因，供变更管控校验使用
+// 原因中包含非 ASCII 字符时，客户端应先进行 URL 编码
+func ChangeOverrideMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reason := strings.TrimSpace(ctx.GetHeader(v1.ChangeOverrideHeader))
+		if reason != "" {
+			if decoded, err := url.QueryUnescape(reason); err == nil {
+				reason = strings.TrimSpace(decoded)
+			}
+			ctx.Set(v1.ChangeOverrideCtxKey, reason)
+		}
+		ctx.Next()
+	}
+}
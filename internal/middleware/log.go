@@ -18,11 +18,15 @@ import (
 func RequestLogMiddleware(logger *log.Logger) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// The configuration is initialized once per request
-		uuid, err := random.UUIdV4()
-		if err != nil {
-			return
+		// 优先复用 TracingMiddleware 生成的 TraceID，便于日志与链路关联
+		trace := traceIDFromContext(ctx)
+		if trace == "" {
+			uuid, err := random.UUIdV4()
+			if err != nil {
+				return
+			}
+			trace = cryptor.Md5String(uuid)
 		}
-		trace := cryptor.Md5String(uuid)
 		logger.WithValue(ctx, zap.String("trace", trace))
 		logger.WithValue(ctx, zap.String("request_method", ctx.Request.Method))
 		logger.WithValue(ctx, zap.Any("request_headers", ctx.Request.Header))
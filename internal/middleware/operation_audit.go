@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/duke-git/lancet/v2/cryptor"
+	"github.com/duke-git/lancet/v2/random"
+	"github.com/gin-gonic/gin"
+)
+
+// OperationRecorder 保存变更请求期间的 Proxmox API 调用
+type OperationRecorder interface {
+	RecordOperation(ctx *gin.Context, operationID string, calls *proxmox.CallRecorder, duration time.Duration)
+}
+
+// OperationAuditMiddleware 为变更请求（POST/PUT/PATCH/DELETE）分配操作 ID 并通过 X-Operation-Id 响应头返回，
+// 请求期间的 Proxmox API 调用记录在 Request.Context 中，请求结束后交给 recorder 保存
+// 需放在 TracingMiddleware 之后，操作 ID 优先复用 TraceID
+func OperationAuditMiddleware(recorder OperationRecorder) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		switch ctx.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			ctx.Next()
+			return
+		}
+
+		operationID := traceIDFromContext(ctx)
+		if operationID == "" {
+			uuid, err := random.UUIdV4()
+			if err != nil {
+				ctx.Next()
+				return
+			}
+			operationID = cryptor.Md5String(uuid)
+		}
+		calls := proxmox.NewCallRecorder()
+		ctx.Request = ctx.Request.WithContext(proxmox.WithCallRecorder(ctx.Request.Context(), calls))
+		ctx.Header(v1.OperationIDHeader, operationID)
+
+		start := time.Now()
+		ctx.Next()
+		recorder.RecordOperation(ctx, operationID, calls, time.Since(start))
+	}
+}
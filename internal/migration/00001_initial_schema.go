@@ -0,0 +1,32 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 引入版本化迁移之前由 migration 自动建表的全部表；已有部署上各表已存在，只会补齐缺少的列和索引
+func init() {
+	register(1, "initial_schema", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.User{},
+			// PVE 相关表
+			&model.PveCluster{},
+			&model.PveNode{},
+			&model.PveVM{},
+			&model.PveStorage{},
+			&model.VMIPAddress{},
+			&model.VmTemplate{},
+			// 模板管理相关表
+			&model.TemplateUpload{},
+			&model.TemplateInstance{},
+			&model.TemplateSyncTask{},
+			// 虚拟机 QoS 限速模板
+			&model.VmQosProfile{},
+			&model.StorageGCScan{},
+			&model.StorageGCItem{},
+			&model.VmListView{},
+		)
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 运行时配置变更审计日志
+func init() {
+	register(2, "config_audit_log", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.ConfigAuditLog{})
+	})
+}
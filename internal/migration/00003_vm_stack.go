@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机编排
+func init() {
+	register(3, "vm_stack", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.VmStack{},
+			&model.VmStackMember{},
+		)
+	})
+}
@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 站点，集群增加所属站点
+func init() {
+	register(4, "pve_site", func(db *gorm.DB) error {
+		if err := db.AutoMigrate(&model.PveSite{}); err != nil {
+			return err
+		}
+		return addColumns(db, &model.PveCluster{}, "SiteID")
+	})
+}
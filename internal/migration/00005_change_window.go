@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 变更窗口与紧急放行审计
+func init() {
+	register(5, "change_window", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.ChangeWindow{},
+			&model.ChangeOverrideLog{},
+		)
+	})
+}
@@ -0,0 +1,22 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 成本核算，虚拟机增加已分配磁盘容量
+func init() {
+	register(6, "cost", func(db *gorm.DB) error {
+		if err := db.AutoMigrate(
+			&model.CostPriceModel{},
+			&model.CostUsageDaily{},
+			&model.CostBudget{},
+			&model.CostBudgetAlert{},
+		); err != nil {
+			return err
+		}
+		return addColumns(db, &model.PveVM{}, "DiskSize")
+	})
+}
@@ -0,0 +1,18 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 节点 BMC 与能耗统计
+func init() {
+	register(7, "energy", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.NodeBMC{},
+			&model.EnergyNodeDaily{},
+			&model.EnergyVMDaily{},
+		)
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 节点 Wake-on-LAN 开机
+func init() {
+	register(8, "pve_node_wol", func(db *gorm.DB) error {
+		return addColumns(db, &model.PveNode{}, "WolMAC", "LastWakeTime")
+	})
+}
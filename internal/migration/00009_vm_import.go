@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机导入
+func init() {
+	register(9, "vm_import", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.VmImportSource{},
+			&model.VmImportTask{},
+		)
+	})
+}
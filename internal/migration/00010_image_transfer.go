@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 镜像传输
+func init() {
+	register(10, "image_transfer", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.ObjectStore{},
+			&model.ImageTransferTask{},
+		)
+	})
+}
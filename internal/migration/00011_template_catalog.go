@@ -0,0 +1,18 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 模板目录
+func init() {
+	register(11, "template_catalog", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.TemplateCatalog{},
+			&model.TemplateCatalogImage{},
+			&model.TemplateCatalogInstall{},
+		)
+	})
+}
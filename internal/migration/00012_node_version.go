@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 节点版本，集群增加 CPU 型号基线
+func init() {
+	register(12, "node_version", func(db *gorm.DB) error {
+		if err := db.AutoMigrate(&model.NodeVersion{}); err != nil {
+			return err
+		}
+		return addColumns(db, &model.PveCluster{}, "CPUBaseline")
+	})
+}
@@ -0,0 +1,19 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 许可证
+func init() {
+	register(13, "license", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.License{},
+			&model.LicenseAssignment{},
+			&model.VMOSInfo{},
+			&model.LicenseAlert{},
+		)
+	})
+}
@@ -0,0 +1,30 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机归属与生命周期元数据，成本用量和能耗按成本中心统计
+func init() {
+	register(14, "vm_ownership", func(db *gorm.DB) error {
+		if err := addColumns(db, &model.PveVM{},
+			"Owner", "Team", "Contact", "BusinessService", "Environment", "CostCenter", "ReviewDate",
+		); err != nil {
+			return err
+		}
+
+		// 成本用量的唯一键增加了 cost_center 列，旧的唯一索引需先删除，由 addColumns 按新定义重建
+		usage := &model.CostUsageDaily{}
+		if m := db.Migrator(); m.HasTable(usage) && !m.HasColumn(usage, "CostCenter") && m.HasIndex(usage, "idx_cost_usage_key") {
+			if err := m.DropIndex(usage, "idx_cost_usage_key"); err != nil {
+				return err
+			}
+		}
+		if err := addColumns(db, usage, "CostCenter"); err != nil {
+			return err
+		}
+		return addColumns(db, &model.EnergyVMDaily{}, "CostCenter")
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机认领
+func init() {
+	register(15, "vm_claim", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.UnclaimedVM{})
+	})
+}
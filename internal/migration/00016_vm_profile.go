@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机创建规格
+func init() {
+	register(16, "vm_profile", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.VMProfile{})
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机存储迁移
+func init() {
+	register(17, "vm_storage_move", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.VMStorageMoveTask{})
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 负载再平衡
+func init() {
+	register(18, "rebalance", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.RebalancePlan{})
+	})
+}
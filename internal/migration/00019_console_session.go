@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 控制台会话审计，集群增加控制台录制开关
+func init() {
+	register(19, "console_session", func(db *gorm.DB) error {
+		if err := db.AutoMigrate(&model.ConsoleSession{}); err != nil {
+			return err
+		}
+		return addColumns(db, &model.PveCluster{}, "ConsoleRecording")
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// ZFS 存储池健康告警
+func init() {
+	register(20, "zfs_pool_alert", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.ZFSPoolAlert{})
+	})
+}
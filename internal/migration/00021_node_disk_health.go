@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 节点磁盘 SMART 指标及告警
+func init() {
+	register(21, "node_disk_health", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.NodeDiskHealth{},
+			&model.NodeDiskAlert{},
+		)
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// Dashboard 用户布局
+func init() {
+	register(22, "dashboard_layout", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.DashboardLayout{})
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 站内通知
+func init() {
+	register(23, "notification", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.Notification{})
+	})
+}
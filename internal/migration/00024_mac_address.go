@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// MAC 地址登记
+func init() {
+	register(24, "mac_address", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.MACAddress{})
+	})
+}
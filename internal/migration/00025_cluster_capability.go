@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 集群能力探测结果
+func init() {
+	register(25, "cluster_capability", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.ClusterCapability{})
+	})
+}
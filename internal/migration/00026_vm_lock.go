@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机维护锁
+func init() {
+	register(26, "vm_lock", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.VMLock{})
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 待重试操作
+func init() {
+	register(27, "pending_operation", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.PendingOperation{})
+	})
+}
@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机凭据审计，虚拟机增加密钥后端中的密码引用
+func init() {
+	register(28, "secret_audit", func(db *gorm.DB) error {
+		if err := db.AutoMigrate(&model.SecretAuditLog{}); err != nil {
+			return err
+		}
+		return addColumns(db, &model.PveVM{}, "VmPasswordRef")
+	})
+}
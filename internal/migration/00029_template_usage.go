@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 模板使用统计
+func init() {
+	register(29, "template_usage", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.TemplateUsageLog{})
+	})
+}
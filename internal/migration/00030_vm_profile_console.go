@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 创建规格增加串口数量和显示设备
+func init() {
+	register(30, "vm_profile_console", func(db *gorm.DB) error {
+		return addColumns(db, &model.VMProfile{}, "SerialNum", "Display")
+	})
+}
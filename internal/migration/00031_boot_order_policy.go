@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 启动顺序策略
+func init() {
+	register(31, "boot_order_policy", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.BootOrderPolicy{})
+	})
+}
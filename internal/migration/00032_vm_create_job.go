@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 异步创建虚拟机任务
+func init() {
+	register(32, "vm_create_job", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.VMCreateJob{})
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 操作审计（Proxmox API 调用记录）
+func init() {
+	register(33, "operation_audit", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.OperationAudit{})
+	})
+}
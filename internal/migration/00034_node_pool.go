@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 节点池及成员
+func init() {
+	register(34, "node_pool", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.NodePool{},
+			&model.NodePoolMember{},
+		)
+	})
+}
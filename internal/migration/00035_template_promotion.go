@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 模板晋级
+func init() {
+	register(35, "template_promotion", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.TemplatePromotion{})
+	})
+}
@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// VMID 段及分配记录
+func init() {
+	register(36, "vmid_range", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.VMIDRange{},
+			&model.VMIDAllocation{},
+		)
+	})
+}
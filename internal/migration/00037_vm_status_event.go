@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机状态变化记录
+func init() {
+	register(37, "vm_status_event", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.VMStatusEvent{})
+	})
+}
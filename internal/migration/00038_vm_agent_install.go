@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// guest agent 安装记录
+func init() {
+	register(38, "vm_agent_install", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.VMAgentInstall{})
+	})
+}
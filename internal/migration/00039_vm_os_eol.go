@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 操作系统停止维护信息
+func init() {
+	register(39, "vm_os_eol", func(db *gorm.DB) error {
+		return addColumns(db, &model.VMOSInfo{}, "EOLName", "EOLDate")
+	})
+}
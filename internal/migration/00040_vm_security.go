@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机配置安全检查风险项
+func init() {
+	register(40, "vm_security", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.VMSecurityFinding{})
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 节点证书指纹
+func init() {
+	register(41, "node_certificate", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.PveNodeCertificate{})
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 计划的跨集群迁移
+func init() {
+	register(42, "remote_migration", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.RemoteMigrationJob{})
+	})
+}
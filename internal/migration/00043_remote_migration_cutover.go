@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 跨集群迁移切换
+func init() {
+	register(43, "remote_migration_cutover", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.RemoteMigrationCutover{})
+	})
+}
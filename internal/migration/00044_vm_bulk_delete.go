@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 批量删除虚拟机任务
+func init() {
+	register(44, "vm_bulk_delete", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.VMBulkDeleteJob{})
+	})
+}
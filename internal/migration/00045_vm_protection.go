@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 虚拟机保护标记
+func init() {
+	register(45, "vm_protection", func(db *gorm.DB) error {
+		return addColumns(db, &model.PveVM{}, "Protected", "ProtectedBy", "ProtectedReason")
+	})
+}
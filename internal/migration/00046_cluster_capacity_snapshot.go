@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 集群容量快照
+func init() {
+	register(46, "cluster_capacity_snapshot", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.ClusterCapacitySnapshot{})
+	})
+}
@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 多实例共享的短期令牌、后台任务归属租约
+func init() {
+	register(47, "shared_token", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.SharedToken{},
+			&model.JobLease{},
+		)
+	})
+}
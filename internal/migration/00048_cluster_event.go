@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 在 PveSphere 之外对集群所做的变更
+func init() {
+	register(48, "cluster_event", func(db *gorm.DB) error {
+		return db.AutoMigrate(&model.ClusterEvent{})
+	})
+}
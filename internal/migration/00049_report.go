@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 定期报告计划及发送记录
+func init() {
+	register(49, "report", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.ReportSchedule{},
+			&model.ReportRun{},
+		)
+	})
+}
@@ -0,0 +1,14 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 集群增加节点资源预留比例
+func init() {
+	register(50, "node_reservation", func(db *gorm.DB) error {
+		return addColumns(db, &model.PveCluster{}, "ReservedCPUPercent", "ReservedMemoryPercent")
+	})
+}
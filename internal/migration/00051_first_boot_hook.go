@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 模板首次启动钩子及执行记录
+func init() {
+	register(51, "first_boot_hook", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.FirstBootHook{},
+			&model.FirstBootRun{},
+		)
+	})
+}
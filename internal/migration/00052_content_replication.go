@@ -0,0 +1,17 @@
+package migration
+
+import (
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// 存储内容复制策略及副本
+func init() {
+	register(52, "content_replication", func(db *gorm.DB) error {
+		return db.AutoMigrate(
+			&model.ContentReplicationPolicy{},
+			&model.ContentReplicationCopy{},
+		)
+	})
+}
@@ -0,0 +1,203 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// 版本化表结构迁移（基于 goose）
+//
+// 每个迁移一个文件，文件名为 <版本号>_<名称>.go，在 init 中通过 register 登记；版本号递增且不能复用。
+// 迁移按版本号顺序执行，每个版本只执行一次，执行记录保存在 goose_db_version 表。
+//
+// 迁移函数使用 gorm 的 Migrator 建表、加列、加索引，由 gorm 按当前数据库（MySQL / PostgreSQL / SQLite）生成 DDL，
+// 不需要为每种数据库分别维护 SQL。迁移不放在事务中执行（MySQL 的 DDL 会隐式提交），
+// 执行失败时该版本不会被记录，修复后重新执行即可，因此迁移函数需要可重复执行（如先判断列、索引是否存在）。
+//
+// 新增迁移：复制最新的迁移文件，版本号加一，只处理本次变更涉及的表、列和索引。
+// 新增表使用 db.AutoMigrate；已有表新增列使用 addColumns，会同时创建新列上的索引。
+
+// Migration 一个版本化迁移
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(db *gorm.DB) error
+}
+
+// Status 迁移的执行情况
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time // 未执行时为零值
+}
+
+var migrations = map[int64]Migration{}
+
+// register 登记迁移，在各迁移文件的 init 中调用
+func register(version int64, name string, up func(db *gorm.DB) error) {
+	if _, ok := migrations[version]; ok {
+		panic(fmt.Sprintf("duplicate schema migration version %d", version))
+	}
+	migrations[version] = Migration{Version: version, Name: name, Up: up}
+}
+
+// Migrations 全部已登记的迁移，按版本号升序
+func Migrations() []Migration {
+	list := make([]Migration, 0, len(migrations))
+	for _, m := range migrations {
+		list = append(list, m)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
+
+// Latest 当前程序支持的最新表结构版本
+func Latest() int64 {
+	var latest int64
+	for version := range migrations {
+		latest = max(latest, version)
+	}
+	return latest
+}
+
+// Up 依次执行尚未执行的迁移，返回本次执行的迁移
+func Up(ctx context.Context, db *gorm.DB) ([]Migration, error) {
+	provider, err := newProvider(db)
+	if err != nil {
+		return nil, err
+	}
+	results, err := provider.Up(ctx)
+	if err != nil {
+		var partial *goose.PartialError
+		if errors.As(err, &partial) && partial.Failed != nil {
+			m := migrations[partial.Failed.Source.Version]
+			return applied(partial.Applied), fmt.Errorf("schema migration %d (%s) failed: %w", m.Version, m.Name, partial.Err)
+		}
+		return nil, err
+	}
+	return applied(results), nil
+}
+
+// GetStatus 返回当前表结构版本（已执行的最大版本）和全部迁移的执行情况
+func GetStatus(ctx context.Context, db *gorm.DB) (int64, []Status, error) {
+	provider, err := newProvider(db)
+	if err != nil {
+		return 0, nil, err
+	}
+	current, err := provider.GetDBVersion(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	results, err := provider.Status(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	list := make([]Status, 0, len(results))
+	for _, result := range results {
+		list = append(list, Status{
+			Migration: migrations[result.Source.Version],
+			Applied:   result.State == goose.StateApplied,
+			AppliedAt: result.AppliedAt,
+		})
+	}
+	return current, list, nil
+}
+
+func applied(results []*goose.MigrationResult) []Migration {
+	done := make([]Migration, 0, len(results))
+	for _, result := range results {
+		done = append(done, migrations[result.Source.Version])
+	}
+	return done
+}
+
+// addColumns 为已有表补齐新增的列（fields 为模型字段名），并创建涉及这些列但尚不存在的索引；表不存在时按模型建表
+func addColumns(db *gorm.DB, value interface{}, fields ...string) error {
+	m := db.Migrator()
+	if !m.HasTable(value) {
+		return db.AutoMigrate(value)
+	}
+	for _, field := range fields {
+		if m.HasColumn(value, field) {
+			continue
+		}
+		if err := m.AddColumn(value, field); err != nil {
+			return err
+		}
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		return err
+	}
+	for _, idx := range stmt.Schema.ParseIndexes() {
+		if !indexCovers(idx, fields) || m.HasIndex(value, idx.Name) {
+			continue
+		}
+		if err := m.CreateIndex(value, idx.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexCovers 索引是否包含 fields 中的任一字段
+func indexCovers(idx *schema.Index, fields []string) bool {
+	for _, opt := range idx.Fields {
+		if opt.Field == nil {
+			continue
+		}
+		for _, field := range fields {
+			if opt.Field.Name == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newProvider 以 gorm 连接构造 goose provider，迁移函数通过闭包使用同一个 gorm 连接
+func newProvider(db *gorm.DB) (*goose.Provider, error) {
+	dialect, err := gooseDialect(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	var goMigrations []*goose.Migration
+	for _, m := range Migrations() {
+		up := m.Up
+		goMigrations = append(goMigrations, goose.NewGoMigration(m.Version, &goose.GoFunc{
+			RunDB: func(ctx context.Context, _ *sql.DB) error {
+				return up(db.WithContext(ctx))
+			},
+		}, nil))
+	}
+	return goose.NewProvider(dialect, sqlDB, nil,
+		goose.WithDisableGlobalRegistry(true),
+		goose.WithGoMigrations(goMigrations...),
+	)
+}
+
+func gooseDialect(name string) (goose.Dialect, error) {
+	switch name {
+	case "mysql":
+		return goose.DialectMySQL, nil
+	case "postgres":
+		return goose.DialectPostgres, nil
+	case "sqlite":
+		return goose.DialectSQLite3, nil
+	default:
+		return "", fmt.Errorf("schema migration does not support database %q", name)
+	}
+}
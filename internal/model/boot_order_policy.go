@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// BootOrderPolicy 集群启动顺序策略：带有 Tag 标签的虚拟机使用相同的 onboot 和 startup（order/up/down）
+type BootOrderPolicy struct {
+	Id          int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID   int64     `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex:idx_boot_order_policy_tag"`
+	Tag         string    `json:"tag" gorm:"column:tag;size:100;not null;uniqueIndex:idx_boot_order_policy_tag"` // 小写的 Proxmox 标签
+	OnBoot      bool      `json:"onboot" gorm:"column:onboot;default:false"`
+	StartOrder  *int      `json:"start_order" gorm:"column:start_order"`   // 为空表示不设置
+	UpDelay     *int      `json:"up_delay" gorm:"column:up_delay"`         // 秒，为空表示不设置
+	DownTimeout *int      `json:"down_timeout" gorm:"column:down_timeout"` // 秒，为空表示不设置
+	Description string    `json:"description" gorm:"column:description;size:500"`
+	Creator     string    `json:"creator" gorm:"column:creator;size:100"`
+	Modifier    string    `json:"modifier" gorm:"column:modifier;size:100"`
+	CreateTime  time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime  time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (BootOrderPolicy) TableName() string {
+	return "boot_order_policy"
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// ChangeWindow 变更窗口：maintenance 为允许变更的维护窗口，freeze 为禁止变更的封网期
+// 作用范围由 ClusterID（0 表示全部集群）和 AppId（空表示全部应用）共同决定
+type ChangeWindow struct {
+	Id          int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name        string `json:"name" gorm:"column:name;size:100;not null"`
+	Kind        string `json:"kind" gorm:"column:kind;size:20;not null;index"` // maintenance / freeze
+	ClusterID   int64  `json:"cluster_id" gorm:"column:cluster_id;default:0;index"`
+	AppId       string `json:"app_id" gorm:"column:appid;size:100"`
+	Description string `json:"description" gorm:"column:description;size:500"`
+
+	// 时间规则：once 使用 StartTime/EndTime；weekly 使用 Weekdays + DailyStart/DailyEnd（结束早于开始表示跨零点）
+	Recurrence string     `json:"recurrence" gorm:"column:recurrence;size:20;not null"` // once / weekly
+	StartTime  *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime    *time.Time `json:"end_time" gorm:"column:end_time"`
+	Weekdays   string     `json:"weekdays" gorm:"column:weekdays;size:50"`       // 逗号分隔，0=周日 ... 6=周六
+	DailyStart string     `json:"daily_start" gorm:"column:daily_start;size:5"`  // HH:MM
+	DailyEnd   string     `json:"daily_end" gorm:"column:daily_end;size:5"`      // HH:MM
+	Timezone   string     `json:"timezone" gorm:"column:timezone;size:64"`       // IANA 时区，空表示服务器本地时区
+	IsEnabled  int8       `json:"is_enabled" gorm:"column:is_enabled;default:1"` // 1-启用，0-禁用
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	Modifier   string    `json:"modifier" gorm:"column:modifier;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ChangeWindow) TableName() string {
+	return "change_window"
+}
+
+// ChangeWindowKind 变更窗口类型
+const (
+	ChangeWindowKindMaintenance = "maintenance"
+	ChangeWindowKindFreeze      = "freeze"
+)
+
+// ChangeWindowRecurrence 变更窗口时间规则
+const (
+	ChangeWindowRecurrenceOnce   = "once"
+	ChangeWindowRecurrenceWeekly = "weekly"
+)
+
+// ChangeOverrideLog 变更管控紧急放行（break-glass）审计记录
+type ChangeOverrideLog struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Operator   string    `json:"operator" gorm:"column:operator;size:100;not null"` // 操作人用户名
+	Action     string    `json:"action" gorm:"column:action;size:100;not null"`     // 被放行的操作，如 vm.stop
+	Target     string    `json:"target" gorm:"column:target;size:200"`              // 操作对象，如虚拟机名称
+	ClusterID  int64     `json:"cluster_id" gorm:"column:cluster_id;index"`
+	AppId      string    `json:"app_id" gorm:"column:appid;size:100"`
+	WindowID   int64     `json:"window_id" gorm:"column:window_id"` // 触发拦截的变更窗口，0 表示不在任何维护窗口内
+	WindowName string    `json:"window_name" gorm:"column:window_name;size:100"`
+	Reason     string    `json:"reason" gorm:"column:reason;size:500;not null"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+}
+
+func (ChangeOverrideLog) TableName() string {
+	return "change_override_log"
+}
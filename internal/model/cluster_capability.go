@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// ClusterCapability 集群 API Token 的能力探测结果，每个集群一条，注册集群、更换凭据或手动探测时更新
+type ClusterCapability struct {
+	Id            int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID     int64  `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex"`
+	UserID        string `json:"user_id" gorm:"column:user_id;size:255"` // 探测时使用的 Token ID
+	PveVersion    string `json:"pve_version" gorm:"column:pve_version;size:50"`
+	Connected     bool   `json:"connected" gorm:"column:connected;default:false"`
+	Authenticated bool   `json:"authenticated" gorm:"column:authenticated;default:false"`
+	Privileges    string `json:"privileges" gorm:"column:privileges;type:text"` // 有效权限（JSON 数组）
+	Missing       string `json:"missing" gorm:"column:missing;type:text"`       // 必需功能缺少的权限（JSON 数组）
+	Features      string `json:"features" gorm:"column:features;type:text"`     // 功能能力矩阵（JSON 数组）
+	Hints         string `json:"hints" gorm:"column:hints;type:text"`           // 排查提示（JSON 数组）
+	Message       string `json:"message" gorm:"column:message;type:text"`
+
+	ProbeTime  *time.Time `json:"probe_time" gorm:"column:probe_time"`
+	CreateTime time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time  `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ClusterCapability) TableName() string {
+	return "cluster_capability"
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// ClusterCapacitySnapshot 集群容量快照，由容量快照任务定期记录，用于对比一段时间内的容量变化（不含模板虚拟机）
+type ClusterCapacitySnapshot struct {
+	Id           int64 `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID    int64 `json:"cluster_id" gorm:"column:cluster_id;not null;index:idx_capacity_snapshot_cluster"`
+	VMCount      int   `json:"vm_count" gorm:"column:vm_count"`
+	RunningVMs   int   `json:"running_vms" gorm:"column:running_vms"`
+	VCPUs        int   `json:"vcpus" gorm:"column:vcpus"`
+	MemoryMB     int64 `json:"memory_mb" gorm:"column:memory_mb"` // 虚拟机分配的内存
+	DiskMB       int64 `json:"disk_mb" gorm:"column:disk_mb"`     // 虚拟机分配的磁盘
+	StorageUsed  int64 `json:"storage_used" gorm:"column:storage_used"`
+	StorageTotal int64 `json:"storage_total" gorm:"column:storage_total"` // 共享存储只计一次
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime;index:idx_capacity_snapshot_cluster"`
+}
+
+func (ClusterCapacitySnapshot) TableName() string {
+	return "cluster_capacity_snapshot"
+}
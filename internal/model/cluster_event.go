@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// ClusterEvent 在 PveSphere 之外对集群所做的变更（Proxmox 网页、qm 命令行、其他 API 用户），
+// 由轮询 Proxmox 集群任务列表和节点 journal 得到；SourceKey 为来源记录（任务 UPID 或 journal 行）的哈希，用于去重
+type ClusterEvent struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID  int64     `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex:idx_cluster_event_source;index:idx_cluster_event_vm"`
+	SourceKey  string    `json:"-" gorm:"column:source_key;size:64;not null;uniqueIndex:idx_cluster_event_source"`
+	Source     string    `json:"source" gorm:"column:source;size:20;not null"` // task / journal
+	Type       string    `json:"type" gorm:"column:type;size:50;not null;index"`
+	NodeName   string    `json:"node_name" gorm:"column:node_name;size:100"`
+	VMID       uint32    `json:"vmid" gorm:"column:vmid;index:idx_cluster_event_vm"`
+	VmName     string    `json:"vm_name" gorm:"column:vm_name;size:255"`
+	User       string    `json:"user" gorm:"column:user;size:100"`      // 执行变更的 Proxmox 用户
+	UPID       string    `json:"upid" gorm:"column:upid;size:255"`      // 来源任务，journal 事件为空
+	Status     string    `json:"status" gorm:"column:status;size:255"`  // 任务结果（OK 或错误信息）
+	Detail     string    `json:"detail" gorm:"column:detail;type:text"` // 任务类型或修改的配置项
+	EventTime  time.Time `json:"event_time" gorm:"column:event_time;index"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+}
+
+func (ClusterEvent) TableName() string {
+	return "cluster_event"
+}
+
+// ClusterEvent 来源
+const (
+	ClusterEventSourceTask    = "task"
+	ClusterEventSourceJournal = "journal"
+)
+
+// ClusterEvent 类型
+const (
+	ClusterEventVMCreated       = "vm_created"  // qmcreate
+	ClusterEventVMCloned        = "vm_cloned"   // qmclone，VMID 为源虚拟机
+	ClusterEventVMRestored      = "vm_restored" // qmrestore
+	ClusterEventVMDeleted       = "vm_deleted"  // qmdestroy
+	ClusterEventVMMigrated      = "vm_migrated" // qmigrate，NodeName 为源节点
+	ClusterEventVMStarted       = "vm_started"  // qmstart
+	ClusterEventVMStopped       = "vm_stopped"  // qmstop / qmshutdown
+	ClusterEventVMConfigChanged = "vm_config_changed"
+)
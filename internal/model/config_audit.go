@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// ConfigAuditLog 运行时配置变更审计记录（每个变化的配置项一条）
+type ConfigAuditLog struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Operator   string    `json:"operator" gorm:"column:operator;size:100;not null"`           // 操作人用户名，SIGHUP 触发时为 system
+	Source     string    `json:"source" gorm:"column:source;size:20;not null"`                // api / file / sighup
+	ConfigKey  string    `json:"config_key" gorm:"column:config_key;size:100;not null;index"` // 配置项，如 log.log_level
+	OldValue   string    `json:"old_value" gorm:"column:old_value;size:500"`
+	NewValue   string    `json:"new_value" gorm:"column:new_value;size:500"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+}
+
+func (ConfigAuditLog) TableName() string {
+	return "config_audit_log"
+}
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+// ConsoleSession 控制台会话审计记录：每次通过 PveSphere 建立的虚拟机 / 节点控制台 websocket 连接一条，
+// 开启录制时记录 Proxmox -> 浏览器方向的帧（不含键盘输入），关闭后上传到对象存储
+type ConsoleSession struct {
+	Id          int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	SessionID   string `json:"session_id" gorm:"column:session_id;size:64;index"` // 控制台会话 ID（虚拟机控制台重连时相同）
+	UserID      string `json:"user_id" gorm:"column:user_id;size:100;index"`
+	Username    string `json:"username" gorm:"column:username;size:100"`
+	TargetType  string `json:"target_type" gorm:"column:target_type;size:20;not null;index"` // vm / node
+	TargetID    int64  `json:"target_id" gorm:"column:target_id;not null;index"`             // pve_vm / pve_node 表 ID
+	TargetName  string `json:"target_name" gorm:"column:target_name;size:100"`
+	VMID        uint32 `json:"vmid" gorm:"column:vmid"`
+	ClusterID   int64  `json:"cluster_id" gorm:"column:cluster_id;index"`
+	NodeName    string `json:"node_name" gorm:"column:node_name;size:100"`
+	AppId       string `json:"app_id" gorm:"column:appid;size:100"`
+	ConsoleType string `json:"console_type" gorm:"column:console_type;size:20"` // vnc / vncshell / termproxy
+	ClientIP    string `json:"client_ip" gorm:"column:client_ip;size:64"`
+	UserAgent   string `json:"user_agent" gorm:"column:user_agent;size:255"`
+
+	StartTime   time.Time  `json:"start_time" gorm:"column:start_time;index"`
+	EndTime     *time.Time `json:"end_time" gorm:"column:end_time"`
+	BytesIn     int64      `json:"bytes_in" gorm:"column:bytes_in;default:0"`   // 浏览器 -> Proxmox
+	BytesOut    int64      `json:"bytes_out" gorm:"column:bytes_out;default:0"` // Proxmox -> 浏览器
+	CloseReason string     `json:"close_reason" gorm:"column:close_reason;size:255"`
+
+	RecordingStatus   string     `json:"recording_status" gorm:"column:recording_status;size:20;not null;default:'none';index"`
+	RecordingStoreID  int64      `json:"recording_store_id" gorm:"column:recording_store_id;default:0"`
+	RecordingKey      string     `json:"recording_key" gorm:"column:recording_key;size:500"`
+	RecordingSize     int64      `json:"recording_size" gorm:"column:recording_size;default:0"`
+	RecordingFrames   int64      `json:"recording_frames" gorm:"column:recording_frames;default:0"`
+	RecordingTrunc    int8       `json:"recording_truncated" gorm:"column:recording_truncated;default:0"` // 超过大小上限后停止录制
+	RecordingExpireAt *time.Time `json:"recording_expire_at" gorm:"column:recording_expire_at;index"`
+	ErrorMessage      string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ConsoleSession) TableName() string {
+	return "console_session"
+}
+
+// ConsoleSessionTarget 控制台目标类型常量
+const (
+	ConsoleSessionTargetVM   = "vm"
+	ConsoleSessionTargetNode = "node"
+)
+
+// ConsoleRecordingStatus 录制状态常量
+const (
+	ConsoleRecordingStatusNone      = "none"      // 未开启录制
+	ConsoleRecordingStatusRecording = "recording" // 会话进行中
+	ConsoleRecordingStatusUploaded  = "uploaded"  // 已上传到对象存储
+	ConsoleRecordingStatusFailed    = "failed"    // 录制或上传失败
+	ConsoleRecordingStatusExpired   = "expired"   // 超过保留期，对象已删除
+)
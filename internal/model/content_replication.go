@@ -0,0 +1,81 @@
+package model
+
+import "time"
+
+// ContentReplicationPolicy 存储内容复制策略：源存储上的 ISO / 容器模板必须同样存在于目标存储
+// 目标存储为共享存储时只检查一份，为节点本地存储时检查每个拥有该存储的节点
+type ContentReplicationPolicy struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name      string `json:"name" gorm:"column:name;size:100;not null;uniqueIndex"`
+	ClusterID int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	Content   string `json:"content" gorm:"column:content;size:20;not null"` // iso / vztmpl
+
+	SourceStorage  string `json:"source_storage" gorm:"column:source_storage;size:100;not null"`
+	SourceNode     string `json:"source_node" gorm:"column:source_node;size:100"`          // 为空时使用任一在线且能访问源存储的节点
+	Pattern        string `json:"pattern" gorm:"column:pattern;size:255"`                  // 文件名通配符，如 debian-*.iso，为空表示全部
+	TargetStorages string `json:"target_storages" gorm:"column:target_storages;size:1000"` // 逗号分隔的目标存储名称
+	AutoCopy       int8   `json:"auto_copy" gorm:"column:auto_copy"`                       // 定期对账时是否自动复制缺失的文件
+	Enabled        int8   `json:"enabled" gorm:"column:enabled"`
+	Description    string `json:"description" gorm:"column:description;size:500"`
+
+	// 最近一次对账结果
+	Status        string     `json:"status" gorm:"column:status;size:20;not null;default:'unknown'"`
+	MissingCount  int        `json:"missing_count" gorm:"column:missing_count;default:0"`
+	LastCheckTime *time.Time `json:"last_check_time" gorm:"column:last_check_time"`
+	LastError     string     `json:"last_error" gorm:"column:last_error;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	Modifier   string    `json:"modifier" gorm:"column:modifier;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ContentReplicationPolicy) TableName() string {
+	return "content_replication_policy"
+}
+
+// ContentReplicationPolicyStatus 对账结果常量
+const (
+	ContentReplicationStatusUnknown  = "unknown"  // 尚未对账
+	ContentReplicationStatusInSync   = "in_sync"  // 所有目标存储都已包含源存储的文件
+	ContentReplicationStatusDiverged = "diverged" // 有目标存储缺少文件
+	ContentReplicationStatusError    = "error"    // 源存储或部分目标存储无法读取
+)
+
+// ContentReplicationCopy 复制任务：把源存储上的一个文件复制到一个节点的目标存储
+type ContentReplicationCopy struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	PolicyID  int64  `json:"policy_id" gorm:"column:policy_id;not null;index"`
+	ClusterID int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	Content   string `json:"content" gorm:"column:content;size:20;not null"`
+	FileName  string `json:"file_name" gorm:"column:file_name;size:255;not null"`
+
+	SourceNode    string `json:"source_node" gorm:"column:source_node;size:100"`
+	SourceStorage string `json:"source_storage" gorm:"column:source_storage;size:100;not null"`
+	TargetNode    string `json:"target_node" gorm:"column:target_node;size:100;not null"`
+	TargetStorage string `json:"target_storage" gorm:"column:target_storage;size:100;not null"`
+
+	Status           string     `json:"status" gorm:"column:status;size:20;not null;default:'pending';index"`
+	TotalBytes       int64      `json:"total_bytes" gorm:"column:total_bytes;default:0"`
+	TransferredBytes int64      `json:"transferred_bytes" gorm:"column:transferred_bytes;default:0"`
+	Progress         int        `json:"progress" gorm:"column:progress;default:0"`
+	StartTime        *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime          *time.Time `json:"end_time" gorm:"column:end_time"`
+	ErrorMessage     string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ContentReplicationCopy) TableName() string {
+	return "content_replication_copy"
+}
+
+// ContentReplicationCopyStatus 复制任务状态常量
+const (
+	ContentReplicationCopyPending   = "pending"
+	ContentReplicationCopyCopying   = "copying"
+	ContentReplicationCopyCompleted = "completed"
+	ContentReplicationCopyFailed    = "failed"
+)
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"time"
+)
+
+// CostPriceModel 集群价格模型，cluster_id 为 0 表示默认价格（集群未单独配置时使用）
+type CostPriceModel struct {
+	Id                  int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID           int64     `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex"`
+	VcpuHourPrice       float64   `json:"vcpu_hour_price" gorm:"column:vcpu_hour_price;default:0"`               // 每 vCPU·小时价格
+	RamGbHourPrice      float64   `json:"ram_gb_hour_price" gorm:"column:ram_gb_hour_price;default:0"`           // 每 GB 内存·小时价格
+	StorageGbMonthPrice float64   `json:"storage_gb_month_price" gorm:"column:storage_gb_month_price;default:0"` // 每 GB 存储·月价格
+	Describes           string    `json:"describes" gorm:"column:describes;size:500"`
+	Creator             string    `json:"creator" gorm:"column:creator"`
+	Modifier            string    `json:"modifier" gorm:"column:modifier"`
+	CreateTime          time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime          time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (CostPriceModel) TableName() string {
+	return "cost_price_model"
+}
+
+// CostUsageDaily 按天、集群、应用、成本中心汇总的资源用量，由用量采集任务定期累加
+// vCPU 和内存仅在虚拟机运行时计量，存储按已分配磁盘容量持续计量
+type CostUsageDaily struct {
+	Id             int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UsageDate      string    `json:"usage_date" gorm:"column:usage_date;size:10;not null;uniqueIndex:idx_cost_usage_key"` // YYYY-MM-DD
+	ClusterID      int64     `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex:idx_cost_usage_key"`
+	AppId          string    `json:"app_id" gorm:"column:appid;size:100;not null;default:'';uniqueIndex:idx_cost_usage_key"`
+	CostCenter     string    `json:"cost_center" gorm:"column:cost_center;size:100;not null;default:'';uniqueIndex:idx_cost_usage_key"` // 采集时虚拟机的成本中心
+	VcpuHours      float64   `json:"vcpu_hours" gorm:"column:vcpu_hours;default:0"`
+	RamGbHours     float64   `json:"ram_gb_hours" gorm:"column:ram_gb_hours;default:0"`
+	StorageGbHours float64   `json:"storage_gb_hours" gorm:"column:storage_gb_hours;default:0"`
+	CreateTime     time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime     time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (CostUsageDaily) TableName() string {
+	return "cost_usage_daily"
+}
+
+// CostBudget 应用月度预算
+type CostBudget struct {
+	Id             int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	AppId          string    `json:"app_id" gorm:"column:appid;size:100;not null;uniqueIndex"`
+	MonthlyBudget  float64   `json:"monthly_budget" gorm:"column:monthly_budget;not null"`
+	AlertThreshold int       `json:"alert_threshold" gorm:"column:alert_threshold;default:80"` // 告警阈值（预算百分比）
+	Describes      string    `json:"describes" gorm:"column:describes;size:500"`
+	Creator        string    `json:"creator" gorm:"column:creator"`
+	Modifier       string    `json:"modifier" gorm:"column:modifier"`
+	CreateTime     time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime     time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (CostBudget) TableName() string {
+	return "cost_budget"
+}
+
+// 预算告警级别
+const (
+	CostBudgetAlertThreshold = "threshold" // 达到告警阈值
+	CostBudgetAlertExceeded  = "exceeded"  // 超出预算
+)
+
+// CostBudgetAlert 预算告警记录，同一预算每月每个级别只告警一次
+type CostBudgetAlert struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	BudgetID   int64     `json:"budget_id" gorm:"column:budget_id;not null;uniqueIndex:idx_cost_budget_alert_key"`
+	Month      string    `json:"month" gorm:"column:month;size:7;not null;uniqueIndex:idx_cost_budget_alert_key"` // YYYY-MM
+	Level      string    `json:"level" gorm:"column:level;size:20;not null;uniqueIndex:idx_cost_budget_alert_key"`
+	AppId      string    `json:"app_id" gorm:"column:appid;size:100;index"`
+	Cost       float64   `json:"cost" gorm:"column:cost"`
+	Budget     float64   `json:"budget" gorm:"column:budget"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+}
+
+func (CostBudgetAlert) TableName() string {
+	return "cost_budget_alert"
+}
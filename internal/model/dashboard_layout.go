@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	DashboardPresetOps        = "ops"        // 运维视角：热点、风险、告警、运行中任务
+	DashboardPresetManagement = "management" // 管理视角：总览、站点、容量、能耗
+	DashboardPresetCustom     = "custom"     // 用户自定义
+)
+
+// DashboardLayout 用户保存的 Dashboard 布局，每个用户一条
+type DashboardLayout struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId     string    `json:"user_id" gorm:"column:user_id;size:100;not null;uniqueIndex"`
+	Preset     string    `json:"preset" gorm:"column:preset;size:20"`     // 基于哪个预设布局调整
+	Scope      string    `json:"scope" gorm:"column:scope;size:20"`       // 默认范围：all、site 或 cluster
+	ClusterID  *int64    `json:"cluster_id" gorm:"column:cluster_id"`     // scope 为 cluster 时使用
+	SiteID     *int64    `json:"site_id" gorm:"column:site_id"`           // scope 为 site 时使用
+	Widgets    string    `json:"widgets" gorm:"column:widgets;type:text"` // 面板位置和参数（JSON 数组）
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (DashboardLayout) TableName() string {
+	return "dashboard_layout"
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+)
+
+// EnergyNodeDaily 节点每日能耗，由能耗采集任务按 BMC 功率读数 × 采集间隔累加
+type EnergyNodeDaily struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UsageDate  string    `json:"usage_date" gorm:"column:usage_date;size:10;not null;uniqueIndex:idx_energy_node_key"` // YYYY-MM-DD
+	NodeID     int64     `json:"node_id" gorm:"column:node_id;not null;uniqueIndex:idx_energy_node_key"`
+	ClusterID  int64     `json:"cluster_id" gorm:"column:cluster_id;index"`
+	EnergyWh   float64   `json:"energy_wh" gorm:"column:energy_wh;default:0"`
+	Samples    int64     `json:"samples" gorm:"column:samples;default:0"`
+	MaxWatts   float64   `json:"max_watts" gorm:"column:max_watts;default:0"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (EnergyNodeDaily) TableName() string {
+	return "energy_node_daily"
+}
+
+// EnergyVMDaily 虚拟机每日估算能耗：节点能耗按运行中虚拟机的 vCPU 数比例分摊
+type EnergyVMDaily struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UsageDate  string    `json:"usage_date" gorm:"column:usage_date;size:10;not null;uniqueIndex:idx_energy_vm_key"` // YYYY-MM-DD
+	VmId       int64     `json:"vm_id" gorm:"column:vm_id;not null;uniqueIndex:idx_energy_vm_key"`                   // pve_vm 表 ID
+	VmName     string    `json:"vm_name" gorm:"column:vm_name"`
+	NodeID     int64     `json:"node_id" gorm:"column:node_id;index"`
+	ClusterID  int64     `json:"cluster_id" gorm:"column:cluster_id;index"`
+	AppId      string    `json:"app_id" gorm:"column:appid;size:100;index"`
+	CostCenter string    `json:"cost_center" gorm:"column:cost_center;size:100;index"`
+	EnergyWh   float64   `json:"energy_wh" gorm:"column:energy_wh;default:0"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (EnergyVMDaily) TableName() string {
+	return "energy_vm_daily"
+}
@@ -0,0 +1,70 @@
+package model
+
+import "time"
+
+// FirstBootHook 模板的首次启动钩子：从模板创建的虚拟机首次 guest agent 上线后，PveSphere 调用 webhook
+// 或服务端登记的脚本并传入虚拟机元数据和 IP，用于自动登记到 CMDB、监控等系统。每个模板最多一个
+type FirstBootHook struct {
+	Id          int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	TemplateID  int64  `json:"template_id" gorm:"column:template_id;not null;uniqueIndex"`
+	Type        string `json:"type" gorm:"column:type;size:20;not null"` // webhook / script
+	URL         string `json:"url" gorm:"column:url;size:500"`           // webhook 地址
+	Secret      string `json:"-" gorm:"column:secret;size:255"`          // webhook 签名密钥，为空时不签名
+	Script      string `json:"script" gorm:"column:script;size:100"`     // first_boot.scripts 中登记的脚本名
+	Enabled     bool   `json:"enabled" gorm:"column:enabled;default:true"`
+	Description string `json:"description" gorm:"column:description;size:500"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	Modifier   string    `json:"modifier" gorm:"column:modifier;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (FirstBootHook) TableName() string {
+	return "first_boot_hook"
+}
+
+// 首次启动钩子类型
+const (
+	FirstBootHookTypeWebhook = "webhook"
+	FirstBootHookTypeScript  = "script"
+)
+
+// FirstBootRun 一台虚拟机的首次启动钩子执行记录：创建时登记钩子配置，等待 agent 上线后调用，失败时重试
+type FirstBootRun struct {
+	Id         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	HookID     int64  `json:"hook_id" gorm:"column:hook_id;not null;index"`
+	TemplateID int64  `json:"template_id" gorm:"column:template_id;not null;index"`
+	ClusterID  int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	VmId       int64  `json:"vm_id" gorm:"column:vm_id;not null;index"`
+	VMID       uint32 `json:"vmid" gorm:"column:vmid;not null"`
+	VmName     string `json:"vm_name" gorm:"column:vm_name;size:100"`
+	Type       string `json:"type" gorm:"column:type;size:20;not null"` // 登记时的钩子类型
+	Target     string `json:"target" gorm:"column:target;size:500"`     // webhook 地址或脚本名
+
+	Status          string     `json:"status" gorm:"column:status;size:20;not null;default:'waiting';index"`
+	Message         string     `json:"message" gorm:"column:message;type:text"`
+	IPAddresses     string     `json:"ip_addresses" gorm:"column:ip_addresses;size:500"` // agent 上报的 IP，逗号分隔
+	WaitStartTime   time.Time  `json:"wait_start_time" gorm:"column:wait_start_time"`    // 开始等待 agent 的时间，重试时重置
+	Checks          int        `json:"checks" gorm:"column:checks;default:0"`            // 已 ping agent 次数
+	Attempts        int        `json:"attempts" gorm:"column:attempts;default:0"`        // 已调用钩子次数
+	AgentOnlineTime *time.Time `json:"agent_online_time" gorm:"column:agent_online_time"`
+	NextAttemptTime *time.Time `json:"next_attempt_time" gorm:"column:next_attempt_time"`
+	FinishTime      *time.Time `json:"finish_time" gorm:"column:finish_time"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (FirstBootRun) TableName() string {
+	return "first_boot_run"
+}
+
+// FirstBootRun 状态常量
+const (
+	FirstBootRunStatusWaiting   = "waiting"  // 等待 guest agent 上线
+	FirstBootRunStatusRetrying  = "retrying" // agent 已上线，调用钩子失败，等待重试
+	FirstBootRunStatusSucceeded = "succeeded"
+	FirstBootRunStatusFailed    = "failed"
+)
@@ -0,0 +1,90 @@
+package model
+
+import "time"
+
+// ObjectStore S3 兼容对象存储（用于跨集群、跨站点传输虚拟机备份）
+type ObjectStore struct {
+	Id             int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name           string `json:"name" gorm:"column:name;size:100;not null;uniqueIndex"`
+	Endpoint       string `json:"endpoint" gorm:"column:endpoint;size:255;not null"`
+	Region         string `json:"region" gorm:"column:region;size:50"`
+	Bucket         string `json:"bucket" gorm:"column:bucket;size:100;not null"`
+	Prefix         string `json:"prefix" gorm:"column:prefix;size:255"` // 对象键前缀
+	AccessKey      string `json:"access_key" gorm:"column:access_key;size:255"`
+	SecretKey      string `json:"-" gorm:"column:secret_key;size:255"`
+	PathStyle      int8   `json:"path_style" gorm:"column:path_style;default:1"`
+	SkipCertVerify int8   `json:"skip_cert_verify" gorm:"column:skip_cert_verify;default:0"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ObjectStore) TableName() string {
+	return "object_store"
+}
+
+// ImageTransferTask 镜像传输任务
+// 导出：vzdump 备份到中转存储 -> 分段上传到对象存储；
+// 导入：从对象存储分段下载到中转存储并校验 -> 恢复为虚拟机。
+type ImageTransferTask struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Direction string `json:"direction" gorm:"column:direction;size:20;not null;index"` // export / import
+	StoreID   int64  `json:"store_id" gorm:"column:store_id;not null;index"`
+	ObjectKey string `json:"object_key" gorm:"column:object_key;size:1000"`
+
+	ClusterID      int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeID         int64  `json:"node_id" gorm:"column:node_id;not null"`
+	NodeName       string `json:"node_name" gorm:"column:node_name;size:100"`
+	VMID           uint32 `json:"vmid" gorm:"column:vmid;default:0"`
+	VmName         string `json:"vm_name" gorm:"column:vm_name;size:100"`
+	VmId           int64  `json:"vm_id" gorm:"column:vm_id;default:0"` // pve_vm 表 ID（导出源虚拟机 / 导入后创建的虚拟机）
+	StagingStorage string `json:"staging_storage" gorm:"column:staging_storage;size:100"`
+	TargetStorage  string `json:"target_storage" gorm:"column:target_storage;size:100"` // 导入时虚拟机磁盘的目标存储
+	Archive        string `json:"archive" gorm:"column:archive;size:500"`               // 中转存储上的备份卷
+	KeepStaging    int8   `json:"keep_staging" gorm:"column:keep_staging;default:0"`    // 完成后是否保留中转存储上的备份文件
+	BackupMode     string `json:"backup_mode" gorm:"column:backup_mode;size:20"`        // 导出时的 vzdump 备份模式
+	AppId          string `json:"app_id" gorm:"column:appid;size:100"`
+
+	Status           string     `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	Phase            string     `json:"phase" gorm:"column:phase;size:50"`
+	TotalBytes       int64      `json:"total_bytes" gorm:"column:total_bytes;default:0"`
+	TransferredBytes int64      `json:"transferred_bytes" gorm:"column:transferred_bytes;default:0"`
+	Progress         int        `json:"progress" gorm:"column:progress;default:0"`
+	Checksum         string     `json:"checksum" gorm:"column:checksum;size:64"` // 备份文件 SHA256
+	UPID             string     `json:"upid" gorm:"column:upid;size:255"`
+	StartTime        *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime          *time.Time `json:"end_time" gorm:"column:end_time"`
+	ErrorMessage     string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ImageTransferTask) TableName() string {
+	return "image_transfer_task"
+}
+
+// 传输方向
+const (
+	ImageTransferExport = "export"
+	ImageTransferImport = "import"
+)
+
+// ImageTransferStatus 传输任务状态常量
+const (
+	ImageTransferStatusPending   = "pending"
+	ImageTransferStatusRunning   = "running"
+	ImageTransferStatusCompleted = "completed"
+	ImageTransferStatusFailed    = "failed"
+)
+
+// ImageTransferPhase 传输任务阶段
+const (
+	ImageTransferPhaseBackup   = "backup"
+	ImageTransferPhaseUpload   = "upload"
+	ImageTransferPhaseDownload = "download"
+	ImageTransferPhaseVerify   = "verify"
+	ImageTransferPhaseRestore  = "restore"
+)
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// JobLease 后台任务的归属租约：执行任务的实例定期续约，实例崩溃或停止后租约过期，由其他实例接管（恢复或标记失败）
+type JobLease struct {
+	Id        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	JobType   string    `json:"job_type" gorm:"column:job_type;size:32;not null;uniqueIndex:idx_job_lease_job"`
+	JobID     int64     `json:"job_id" gorm:"column:job_id;not null;uniqueIndex:idx_job_lease_job"`
+	Owner     string    `json:"owner" gorm:"column:owner;size:128;not null;index"` // 实例标识（ha.instance_id）
+	ExpiresAt time.Time `json:"expires_at" gorm:"column:expires_at;not null;index"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (JobLease) TableName() string {
+	return "job_lease"
+}
+
+// JobLease 任务类型
+const (
+	JobTypeVMCreate     = "vm_create"
+	JobTypeVMBulkDelete = "vm_bulk_delete"
+	JobTypeTemplateSync = "template_sync"
+)
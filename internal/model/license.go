@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+)
+
+// License 许可证，记录许可证密钥或订阅编号及授权数量
+// os_type 与虚拟机 guest agent 上报的操作系统类型对应（如 windows、rhel、sles、ubuntu），用于按操作系统统计用量
+type License struct {
+	Id             int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name           string     `json:"name" gorm:"column:name;size:100;not null;uniqueIndex"`
+	OSType         string     `json:"os_type" gorm:"column:os_type;size:50;not null;index"`
+	Product        string     `json:"product" gorm:"column:product;size:200"`                 // 产品名称，如 Windows Server 2022 Datacenter
+	LicenseKey     string     `json:"-" gorm:"column:license_key;size:500"`                   // 许可证密钥
+	SubscriptionID string     `json:"subscription_id" gorm:"column:subscription_id;size:200"` // 订阅编号
+	Seats          int        `json:"seats" gorm:"column:seats;default:0"`                    // 授权数量，0 表示不限
+	ExpireAt       *time.Time `json:"expire_at" gorm:"column:expire_at"`
+	Describes      string     `json:"describes" gorm:"column:describes;size:500"`
+	Creator        string     `json:"creator" gorm:"column:creator"`
+	Modifier       string     `json:"modifier" gorm:"column:modifier"`
+	CreateTime     time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime     time.Time  `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (License) TableName() string {
+	return "license"
+}
+
+// 许可证分配对象类型
+const (
+	LicenseTargetVM       = "vm"
+	LicenseTargetTemplate = "template" // 分配给模板时，从该模板创建的虚拟机都占用许可证
+)
+
+// LicenseAssignment 许可证分配记录
+type LicenseAssignment struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	LicenseID  int64     `json:"license_id" gorm:"column:license_id;not null;uniqueIndex:idx_license_assignment_key"`
+	TargetType string    `json:"target_type" gorm:"column:target_type;size:20;not null;uniqueIndex:idx_license_assignment_key"`
+	TargetID   int64     `json:"target_id" gorm:"column:target_id;not null;uniqueIndex:idx_license_assignment_key"` // 虚拟机ID 或模板ID
+	Creator    string    `json:"creator" gorm:"column:creator"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+}
+
+func (LicenseAssignment) TableName() string {
+	return "license_assignment"
+}
+
+// VMOSInfo 虚拟机操作系统信息，由 guest agent 的 get-osinfo 定期采集
+type VMOSInfo struct {
+	Id            int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	VmID          int64      `json:"vm_id" gorm:"column:vm_id;not null;uniqueIndex"`
+	ClusterID     int64      `json:"cluster_id" gorm:"column:cluster_id;index"`
+	OSID          string     `json:"os_id" gorm:"column:os_id;size:50"`           // agent 上报的 id，如 mswindows、ubuntu
+	OSType        string     `json:"os_type" gorm:"column:os_type;size:50;index"` // 归一化后的操作系统类型，mswindows 记为 windows
+	Name          string     `json:"name" gorm:"column:name;size:100"`
+	PrettyName    string     `json:"pretty_name" gorm:"column:pretty_name;size:255"`
+	Version       string     `json:"version" gorm:"column:version;size:100"`
+	KernelRelease string     `json:"kernel_release" gorm:"column:kernel_release;size:100"`
+	EOLName       string     `json:"eol_name" gorm:"column:eol_name;size:100"` // 匹配到的停止维护规则名称，未匹配时为空
+	EOLDate       *time.Time `json:"eol_date" gorm:"column:eol_date;index"`    // 匹配到的停止维护日期
+	CollectTime   time.Time  `json:"collect_time" gorm:"column:collect_time"`
+}
+
+func (VMOSInfo) TableName() string {
+	return "vm_os_info"
+}
+
+// LicenseAlert 许可证超用告警。license_id 为 0 表示按操作系统类型汇总的超用；
+// 超用期间只保留一条未恢复的告警，用量回落后记录恢复时间
+type LicenseAlert struct {
+	Id         int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	LicenseID  int64      `json:"license_id" gorm:"column:license_id;not null;index"`
+	OSType     string     `json:"os_type" gorm:"column:os_type;size:50;index"`
+	Consumed   int        `json:"consumed" gorm:"column:consumed"`
+	Seats      int        `json:"seats" gorm:"column:seats"`
+	ResolvedAt *time.Time `json:"resolved_at" gorm:"column:resolved_at"`
+	CreateTime time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time  `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (LicenseAlert) TableName() string {
+	return "license_alert"
+}
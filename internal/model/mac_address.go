@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// MAC 地址来源
+const (
+	MACSourceGenerated  = "generated"  // 创建虚拟机时由平台生成
+	MACSourceUser       = "user"       // 创建虚拟机时用户指定
+	MACSourceReserved   = "reserved"   // 预留，尚未分配给虚拟机
+	MACSourceDiscovered = "discovered" // 从 Proxmox 虚拟机配置同步发现
+)
+
+// MACAddress 虚拟机网卡 MAC 地址登记，MAC 全局唯一（跨集群），用于防止重复并导出 DHCP 保留
+type MACAddress struct {
+	Id          int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	MAC         string    `json:"mac" gorm:"column:mac;size:17;not null;uniqueIndex"` // 大写冒号格式，如 BC:24:11:00:00:01
+	ClusterID   int64     `json:"cluster_id" gorm:"column:cluster_id;index"`
+	VMId        int64     `json:"vm_id" gorm:"column:vm_id;index"` // 平台虚拟机ID，预留或同步发现但平台无记录时为 0
+	VMID        uint32    `json:"vmid" gorm:"column:vmid"`         // Proxmox VMID
+	NicName     string    `json:"nic_name" gorm:"column:nic_name;size:20"`
+	Source      string    `json:"source" gorm:"column:source;size:20;not null"` // generated / user / reserved / discovered
+	Hostname    string    `json:"hostname" gorm:"column:hostname;size:255"`     // 导出 DHCP 保留时使用的主机名
+	Description string    `json:"description" gorm:"column:description;size:500"`
+	Creator     string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime  time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime  time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (MACAddress) TableName() string {
+	return "mac_address"
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// NodeBMC 节点带外管理（BMC）配置，每个节点最多一条
+type NodeBMC struct {
+	Id                 int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	NodeID             int64      `json:"node_id" gorm:"column:node_id;not null;uniqueIndex"`
+	ClusterID          int64      `json:"cluster_id" gorm:"column:cluster_id;index"`
+	Protocol           string     `json:"protocol" gorm:"column:protocol;size:20;not null"` // redfish / ipmi
+	Address            string     `json:"address" gorm:"column:address;size:255;not null"`
+	Username           string     `json:"username" gorm:"column:username;size:100"`
+	Password           string     `json:"-" gorm:"column:password;size:255"`
+	InsecureSkipVerify int8       `json:"insecure_skip_verify" gorm:"column:insecure_skip_verify;default:1"` // Redfish 是否跳过证书校验
+	PowerMonitor       int8       `json:"power_monitor" gorm:"column:power_monitor;default:1"`               // 是否采集功率用于能耗统计
+	LastPowerWatts     float64    `json:"last_power_watts" gorm:"column:last_power_watts;default:0"`
+	LastReadingTime    *time.Time `json:"last_reading_time" gorm:"column:last_reading_time"`
+	LastError          string     `json:"last_error" gorm:"column:last_error;size:500"`
+	Creator            string     `json:"creator" gorm:"column:creator"`
+	Modifier           string     `json:"modifier" gorm:"column:modifier"`
+	CreateTime         time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime         time.Time  `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (NodeBMC) TableName() string {
+	return "node_bmc"
+}
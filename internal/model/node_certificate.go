@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// PveNodeCertificate 节点 pve-ssl.pem 证书指纹，跨集群迁移时作为 target-endpoint 的 fingerprint 使用；
+// 首次迁移时自动记录，更换 API 地址或证书后通过集群接入地址更新流程刷新
+type PveNodeCertificate struct {
+	Id          int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID   int64      `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex:idx_node_cert_cluster_node"`
+	NodeName    string     `json:"node_name" gorm:"column:node_name;size:100;not null;uniqueIndex:idx_node_cert_cluster_node"`
+	Fingerprint string     `json:"fingerprint" gorm:"column:fingerprint;size:100;not null"` // SHA256 指纹，冒号分隔
+	Subject     string     `json:"subject" gorm:"column:subject;size:255"`
+	NotAfter    *time.Time `json:"not_after" gorm:"column:not_after"` // 证书到期时间
+	VerifyTime  time.Time  `json:"verify_time" gorm:"column:verify_time"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (PveNodeCertificate) TableName() string {
+	return "pve_node_certificate"
+}
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+const (
+	NodeDiskAlertReasonSmartFailed   = "smart_failed"  // SMART 总体自检未通过
+	NodeDiskAlertReasonReallocated   = "reallocated"   // 重映射扇区数超过阈值
+	NodeDiskAlertReasonPending       = "pending"       // 待映射扇区数超过阈值
+	NodeDiskAlertReasonUncorrectable = "uncorrectable" // 不可修复错误 / NVMe 介质错误超过阈值
+	NodeDiskAlertReasonWearout       = "wearout"       // SSD 剩余寿命低于阈值
+)
+
+// NodeDiskHealth 节点磁盘最近一次 SMART 采集结果，每个节点上的磁盘（devpath）一条记录
+type NodeDiskHealth struct {
+	Id                 int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID          int64     `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeID             int64     `json:"node_id" gorm:"column:node_id;not null;uniqueIndex:uk_node_disk"`
+	DevPath            string    `json:"devpath" gorm:"column:devpath;size:100;not null;uniqueIndex:uk_node_disk"`
+	Serial             string    `json:"serial" gorm:"column:serial;size:100"`
+	Model              string    `json:"model" gorm:"column:model;size:200"`
+	DiskType           string    `json:"disk_type" gorm:"column:disk_type;size:20"` // hdd / ssd / nvme
+	Health             string    `json:"health" gorm:"column:health;size:20"`       // PASSED / FAILED / UNKNOWN
+	Wearout            *int      `json:"wearout" gorm:"column:wearout"`             // SSD 剩余寿命百分比，HDD 为空
+	ReallocatedSectors int64     `json:"reallocated_sectors" gorm:"column:reallocated_sectors"`
+	PendingSectors     int64     `json:"pending_sectors" gorm:"column:pending_sectors"`
+	Uncorrectable      int64     `json:"uncorrectable" gorm:"column:uncorrectable"`
+	PowerOnHours       int64     `json:"power_on_hours" gorm:"column:power_on_hours"`
+	Temperature        int64     `json:"temperature" gorm:"column:temperature"`
+	CollectTime        time.Time `json:"collect_time" gorm:"column:collect_time"`
+	CreateTime         time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime         time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (NodeDiskHealth) TableName() string {
+	return "node_disk_health"
+}
+
+// NodeDiskAlert 节点磁盘 SMART 告警。每块磁盘只保留一条未恢复的告警，
+// 原因变化时更新告警内容，指标回到阈值内或磁盘被移除后记录恢复时间
+type NodeDiskAlert struct {
+	Id                 int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID          int64      `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeID             int64      `json:"node_id" gorm:"column:node_id;not null;index"`
+	NodeName           string     `json:"node_name" gorm:"column:node_name;size:100"`
+	DevPath            string     `json:"devpath" gorm:"column:devpath;size:100;index"`
+	Serial             string     `json:"serial" gorm:"column:serial;size:100"`
+	Model              string     `json:"model" gorm:"column:model;size:200"`
+	Reasons            string     `json:"reasons" gorm:"column:reasons;size:100"`
+	Health             string     `json:"health" gorm:"column:health;size:20"`
+	Wearout            *int       `json:"wearout" gorm:"column:wearout"`
+	ReallocatedSectors int64      `json:"reallocated_sectors" gorm:"column:reallocated_sectors"`
+	PendingSectors     int64      `json:"pending_sectors" gorm:"column:pending_sectors"`
+	Uncorrectable      int64      `json:"uncorrectable" gorm:"column:uncorrectable"`
+	ResolvedAt         *time.Time `json:"resolved_at" gorm:"column:resolved_at"`
+	CreateTime         time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime         time.Time  `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (NodeDiskAlert) TableName() string {
+	return "node_disk_alert"
+}
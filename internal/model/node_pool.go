@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// NodePool 节点池：集群内节点的分组，用于创建虚拟机时按池调度和池级配额；
+// 配额字段为 0 表示不限制
+type NodePool struct {
+	Id          int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID   int64     `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex:idx_node_pool_name"`
+	Name        string    `json:"name" gorm:"column:name;size:100;not null;uniqueIndex:idx_node_pool_name"`
+	Describes   string    `json:"describes" gorm:"column:describes;size:500"`
+	MaxVMs      int       `json:"max_vms" gorm:"column:max_vms;default:0"`
+	MaxCPU      int       `json:"max_cpu" gorm:"column:max_cpu;default:0"`             // vCPU 合计
+	MaxMemoryMB int64     `json:"max_memory_mb" gorm:"column:max_memory_mb;default:0"` // 内存合计（MB）
+	Creator     string    `json:"creator" gorm:"column:creator"`
+	Modifier    string    `json:"modifier" gorm:"column:modifier"`
+	CreateTime  time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime  time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (NodePool) TableName() string {
+	return "node_pool"
+}
+
+// NodePoolMember 节点池成员，一个节点可以属于多个节点池
+type NodePoolMember struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	PoolID     int64     `json:"pool_id" gorm:"column:pool_id;not null;uniqueIndex:idx_node_pool_member"`
+	NodeID     int64     `json:"node_id" gorm:"column:node_id;not null;uniqueIndex:idx_node_pool_member;index"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+}
+
+func (NodePoolMember) TableName() string {
+	return "node_pool_member"
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// NodeVersion 节点软件版本与 CPU 信息，用于版本矩阵展示和迁移兼容性检查
+type NodeVersion struct {
+	Id            int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	NodeID        int64  `json:"node_id" gorm:"column:node_id;not null;uniqueIndex"`
+	ClusterID     int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeName      string `json:"node_name" gorm:"column:node_name;size:100"`
+	PveVersion    string `json:"pve_version" gorm:"column:pve_version;size:50"`        // pve-manager 版本，如 8.2.4
+	KernelVersion string `json:"kernel_version" gorm:"column:kernel_version;size:100"` // 如 6.8.8-2-pve
+	QemuVersion   string `json:"qemu_version" gorm:"column:qemu_version;size:50"`      // pve-qemu-kvm 版本，如 9.0.0-6
+	CPUModel      string `json:"cpu_model" gorm:"column:cpu_model;size:255"`
+	CPUVendor     string `json:"cpu_vendor" gorm:"column:cpu_vendor;size:20"` // intel / amd
+	CPUFlags      string `json:"cpu_flags" gorm:"column:cpu_flags;type:text"` // 空格分隔
+	Packages      string `json:"packages" gorm:"column:packages;type:text"`   // 关键软件包版本（JSON，包名 -> 版本）
+
+	CollectTime  *time.Time `json:"collect_time" gorm:"column:collect_time"`
+	CollectError string     `json:"collect_error" gorm:"column:collect_error;type:text"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (NodeVersion) TableName() string {
+	return "node_version"
+}
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// 通知类别
+const (
+	NotificationCategoryTask     = "task"     // 异步任务结束
+	NotificationCategoryApproval = "approval" // 等待审核 / 确认
+	NotificationCategoryAlert    = "alert"    // 告警触发
+	NotificationCategoryReport   = "report"   // 定期报告
+)
+
+// 通知级别
+const (
+	NotificationLevelInfo     = "info"
+	NotificationLevelWarning  = "warning"
+	NotificationLevelCritical = "critical"
+)
+
+// Notification 站内通知，每个接收人一条；超过保留期后删除
+type Notification struct {
+	Id         int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Recipient  string     `json:"recipient" gorm:"column:recipient;size:100;not null;index:idx_notification_recipient"` // 接收人用户名
+	Category   string     `json:"category" gorm:"column:category;size:20;not null"`                                     // task / approval / alert / report
+	Level      string     `json:"level" gorm:"column:level;size:20;not null;default:'info'"`                            // info / warning / critical
+	Event      string     `json:"event" gorm:"column:event;size:50"`                                                    // 事件，如 vm_storage_move_completed
+	Title      string     `json:"title" gorm:"column:title;size:255;not null"`
+	Content    string     `json:"content" gorm:"column:content;type:text"`
+	TargetType string     `json:"target_type" gorm:"column:target_type;size:50"` // 关联对象类型，如 vm_storage_move、node
+	TargetID   string     `json:"target_id" gorm:"column:target_id;size:100"`    // 关联对象ID
+	ReadAt     *time.Time `json:"read_at" gorm:"column:read_at;index:idx_notification_recipient"`
+	CreateTime time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime;index"`
+}
+
+func (Notification) TableName() string {
+	return "notification"
+}
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// OperationAudit 变更请求期间调用的 Proxmox API 及启动的任务
+type OperationAudit struct {
+	Id           int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	OperationID  string `json:"operation_id" gorm:"column:operation_id;size:64;not null;uniqueIndex"`
+	Method       string `json:"method" gorm:"column:method;size:10"`
+	Route        string `json:"route" gorm:"column:route;size:255"`
+	Path         string `json:"path" gorm:"column:path;size:255;index"`
+	StatusCode   int    `json:"status_code" gorm:"column:status_code"`
+	Username     string `json:"username" gorm:"column:username;size:100;index"`
+	DurationMs   int64  `json:"duration_ms" gorm:"column:duration_ms"`
+	CallCount    int    `json:"call_count" gorm:"column:call_count"`
+	DroppedCalls int    `json:"dropped_calls" gorm:"column:dropped_calls"`
+	TaskCount    int    `json:"task_count" gorm:"column:task_count"`
+	Tasks        string `json:"tasks" gorm:"column:tasks;type:text"` // 启动的任务 UPID（JSON 数组）
+	Calls        string `json:"calls" gorm:"column:calls;type:text"` // 调用明细（JSON 数组）
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime;index"`
+}
+
+func (OperationAudit) TableName() string {
+	return "operation_audit"
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// PendingOperation 集群不可达时排队的操作，连通后按创建顺序自动重试
+type PendingOperation struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeID    int64  `json:"node_id" gorm:"column:node_id;not null"`
+	VmId      int64  `json:"vm_id" gorm:"column:vm_id;index"` // pve_vm 表 ID，未同步的虚拟机为 0
+	VMID      uint32 `json:"vmid" gorm:"column:vmid;not null"`
+	VmName    string `json:"vm_name" gorm:"column:vm_name;size:100"`
+	AppId     string `json:"app_id" gorm:"column:app_id;size:100"`
+	Operation string `json:"operation" gorm:"column:operation;size:50;not null"` // vm.config / vm.tags / vm.cloudinit
+	Payload   string `json:"payload" gorm:"column:payload;type:text"`            // 操作参数（JSON）
+
+	Status        string     `json:"status" gorm:"column:status;size:20;not null;default:'pending';index"`
+	Attempts      int        `json:"attempts" gorm:"column:attempts;default:0"`
+	LastError     string     `json:"last_error" gorm:"column:last_error;type:text"`
+	NextRetryTime *time.Time `json:"next_retry_time" gorm:"column:next_retry_time;index"`
+	FinishTime    *time.Time `json:"finish_time" gorm:"column:finish_time"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (PendingOperation) TableName() string {
+	return "pending_operation"
+}
+
+// PendingOperationStatus 待重试操作状态常量
+const (
+	PendingOperationStatusPending   = "pending"
+	PendingOperationStatusRunning   = "running"
+	PendingOperationStatusSucceeded = "succeeded"
+	PendingOperationStatusFailed    = "failed"
+	PendingOperationStatusCancelled = "cancelled"
+)
@@ -5,23 +5,29 @@ import (
 )
 
 type PveCluster struct {
-	Id               int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
-	ClusterName      string    `json:"cluster_name" gorm:"column:cluster_name"`
-	ClusterNameAlias string    `json:"cluster_name_alias" gorm:"column:cluster_name_alias"`
-	Env              string    `json:"env" gorm:"column:env"`
-	Datacenter       string    `json:"datacenter" gorm:"column:datacenter"`
-	ApiUrl           string    `json:"api_url" gorm:"column:api_url"`
-	UserId           string    `json:"user_id" gorm:"column:user_id"`
-	UserToken        string    `json:"user_token" gorm:"column:user_token"`
-	Dns              string    `json:"dns" gorm:"column:dns"`
-	Describes        string    `json:"describes" gorm:"column:describes"`
-	Region           string    `json:"region" gorm:"column:region"`
-	IsSchedulable    int8      `json:"is_schedulable" gorm:"column:is_schedulable"` // 是否可调度（用于虚拟机创建）
-	IsEnabled        int8      `json:"is_enabled" gorm:"column:is_enabled"`         // 是否启用数据自动上报，1-启用，0-禁用
-	CreateTime       time.Time `json:"create_time" gorm:"column:gmt_create"`        // 创建时间
-	UpdateTime       time.Time `json:"update_time" gorm:"column:gmt_modified"`      // 更新时间
-	Creator          string    `json:"creator" gorm:"column:creator"`               // 创建者
-	Modifier         string    `json:"modifier" gorm:"column:modifier"`             // 修改者
+	Id                    int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterName           string    `json:"cluster_name" gorm:"column:cluster_name"`
+	ClusterNameAlias      string    `json:"cluster_name_alias" gorm:"column:cluster_name_alias"`
+	Env                   string    `json:"env" gorm:"column:env"`
+	Datacenter            string    `json:"datacenter" gorm:"column:datacenter"`
+	ApiUrl                string    `json:"api_url" gorm:"column:api_url"`
+	UserId                string    `json:"user_id" gorm:"column:user_id"`
+	UserToken             string    `json:"user_token" gorm:"column:user_token"`
+	Dns                   string    `json:"dns" gorm:"column:dns"`
+	Describes             string    `json:"describes" gorm:"column:describes"`
+	Region                string    `json:"region" gorm:"column:region"`
+	SiteID                int64     `json:"site_id" gorm:"column:site_id;default:0;index"`                           // 所属站点ID，0 表示未分配
+	IsSchedulable         int8      `json:"is_schedulable" gorm:"column:is_schedulable"`                             // 是否可调度（用于虚拟机创建）
+	IsEnabled             int8      `json:"is_enabled" gorm:"column:is_enabled"`                                     // 是否启用数据自动上报，1-启用，0-禁用
+	ApiLogEnabled         int8      `json:"api_log_enabled" gorm:"column:api_log_enabled;default:0"`                 // 是否记录 Proxmox API 调用日志（debug 级别，敏感信息脱敏），1-启用，0-禁用
+	CPUBaseline           string    `json:"cpu_baseline" gorm:"column:cpu_baseline;size:100"`                        // 集群统一 CPU 型号基线（如 x86-64-v3），创建虚拟机未指定 CPU 类型时使用
+	ConsoleRecording      int8      `json:"console_recording" gorm:"column:console_recording;default:0"`             // 是否录制该集群的控制台会话（仅 Proxmox -> 浏览器方向，不含键盘输入），1-启用，0-禁用
+	ReservedCPUPercent    int       `json:"reserved_cpu_percent" gorm:"column:reserved_cpu_percent;default:0"`       // 每个节点为宿主机、Ceph 和系统进程预留的 CPU 百分比（0-90），调度和可用容量均扣除
+	ReservedMemoryPercent int       `json:"reserved_memory_percent" gorm:"column:reserved_memory_percent;default:0"` // 每个节点为宿主机、Ceph 和系统进程预留的内存百分比（0-90），调度、内存校验和可用容量均扣除
+	CreateTime            time.Time `json:"create_time" gorm:"column:gmt_create"`                                    // 创建时间
+	UpdateTime            time.Time `json:"update_time" gorm:"column:gmt_modified"`                                  // 更新时间
+	Creator               string    `json:"creator" gorm:"column:creator"`                                           // 创建者
+	Modifier              string    `json:"modifier" gorm:"column:modifier"`                                         // 修改者
 }
 
 func (PveCluster) TableName() string {
@@ -5,21 +5,23 @@ import (
 )
 
 type PveNode struct {
-	Id            int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
-	NodeName      string    `json:"node_name" gorm:"column:node_name"`
-	IPAddress     string    `json:"ip_address" gorm:"column:ip_address"`
-	ClusterID     int64     `json:"cluster_id" gorm:"column:cluster_id"`
-	IsSchedulable int8      `json:"is_schedulable" gorm:"column:is_schedulable"`
-	Env           string    `json:"env" gorm:"column:env"`
-	Status        string    `json:"status" gorm:"column:status"`
-	CreateTime    time.Time `json:"create_time" gorm:"column:gmt_create"`
-	UpdateTime    time.Time `json:"update_time" gorm:"column:gmt_modified"`
-	Creator       string    `json:"creator" gorm:"column:creator"`
-	Modifier      string    `json:"modifier" gorm:"column:modifier"`
-	Annotations   string    `json:"annotations" gorm:"column:annotations"`
-	VMLimit       int64     `json:"vm_limit" gorm:"column:vm_limit"`
-	ResourceHash  string    `json:"resource_hash" gorm:"column:resource_hash;index"`
-	LastSyncTime  time.Time `json:"last_sync_time" gorm:"column:last_sync_time"`
+	Id            int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	NodeName      string     `json:"node_name" gorm:"column:node_name"`
+	IPAddress     string     `json:"ip_address" gorm:"column:ip_address"`
+	ClusterID     int64      `json:"cluster_id" gorm:"column:cluster_id"`
+	IsSchedulable int8       `json:"is_schedulable" gorm:"column:is_schedulable"`
+	Env           string     `json:"env" gorm:"column:env"`
+	Status        string     `json:"status" gorm:"column:status"`
+	CreateTime    time.Time  `json:"create_time" gorm:"column:gmt_create"`
+	UpdateTime    time.Time  `json:"update_time" gorm:"column:gmt_modified"`
+	Creator       string     `json:"creator" gorm:"column:creator"`
+	Modifier      string     `json:"modifier" gorm:"column:modifier"`
+	Annotations   string     `json:"annotations" gorm:"column:annotations"`
+	VMLimit       int64      `json:"vm_limit" gorm:"column:vm_limit"`
+	ResourceHash  string     `json:"resource_hash" gorm:"column:resource_hash;index"`
+	LastSyncTime  time.Time  `json:"last_sync_time" gorm:"column:last_sync_time"`
+	WolMAC        string     `json:"wol_mac" gorm:"column:wol_mac;size:17"`       // Wake-on-LAN MAC 地址（无 BMC 节点开机）
+	LastWakeTime  *time.Time `json:"last_wake_time" gorm:"column:last_wake_time"` // 最近一次发送唤醒包的时间
 }
 
 func (PveNode) TableName() string {
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+)
+
+// PveSite 站点（机房/地域），用于对集群进行分组
+type PveSite struct {
+	Id               int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	SiteName         string    `json:"site_name" gorm:"column:site_name;size:100;not null;uniqueIndex"`
+	SiteNameAlias    string    `json:"site_name_alias" gorm:"column:site_name_alias;size:100"`
+	Region           string    `json:"region" gorm:"column:region;size:100"`
+	Location         string    `json:"location" gorm:"column:location;size:200"`
+	Describes        string    `json:"describes" gorm:"column:describes;size:500"`
+	CrossSiteBwlimit int       `json:"cross_site_bwlimit" gorm:"column:cross_site_bwlimit;default:0"` // 跨站点迁移默认带宽限制（KiB/s），0 表示不限制
+	CreateTime       time.Time `json:"create_time" gorm:"column:gmt_create"`                          // 创建时间
+	UpdateTime       time.Time `json:"update_time" gorm:"column:gmt_modified"`                        // 更新时间
+	Creator          string    `json:"creator" gorm:"column:creator"`                                 // 创建者
+	Modifier         string    `json:"modifier" gorm:"column:modifier"`                               // 修改者
+}
+
+func (PveSite) TableName() string {
+	return "pve_site"
+}
@@ -11,6 +11,7 @@ type PveVM struct {
 	VMID         uint32    `json:"vmid" gorm:"column:vmid"`
 	CPUNum       int       `json:"cpu_num" gorm:"column:cpu_num"`
 	MemorySize   int       `json:"memory_size" gorm:"column:memory_size"`
+	DiskSize     int       `json:"disk_size" gorm:"column:disk_size;default:0"` // 已分配磁盘容量（MB），由 controller 同步
 	Storage      string    `json:"storage" gorm:"column:storages"`
 	StorageCfg   string    `json:"storage_cfg" gorm:"column:storage_cfg"`
 	AppId        string    `json:"app_id" gorm:"column:appid"`
@@ -19,11 +20,27 @@ type PveVM struct {
 	IsTemplate   int8      `json:"is_template" gorm:"column:is_template;default:0"` // 是否为模板：0=否, 1=是
 	TemplateID   int64     `json:"template_id" gorm:"column:template_id;index"` // 模板ID（关联字段）
 	VmUser       string    `json:"vm_user" gorm:"column:vm_user"`
-	VmPassword   string    `json:"-" gorm:"column:vm_password"`
+	VmPassword   string    `json:"-" gorm:"column:vm_password"`                 // 明文密码，仅未配置 secret.backend 时使用
+	VmPasswordRef string   `json:"-" gorm:"column:vm_password_ref;size:1024"`   // 密钥后端中的密码引用（如 vault:secret/pvesphere/vm-1-100）
 	NodeIP       string    `json:"node_ip" gorm:"column:node_ip"`               // 节点IP（冗余，用于快速访问，IP 很少变化）
 	Creator      string    `json:"creator" gorm:"column:creator"`
 	Modifier     string    `json:"modifier" gorm:"column:modifier"`
 	Description  string    `json:"descriptions" gorm:"column:descriptions"`
+
+	// 归属与生命周期元数据（仅在平台维护，Proxmox 侧没有，同步时保留）
+	Owner           string     `json:"owner" gorm:"column:owner;size:100;index"`                       // 负责人（用户名）
+	Team            string     `json:"team" gorm:"column:team;size:100;index"`                         // 所属团队
+	Contact         string     `json:"contact" gorm:"column:contact;size:200"`                         // 联系方式（邮箱、电话或值班群）
+	BusinessService string     `json:"business_service" gorm:"column:business_service;size:200;index"` // 承载的业务服务
+	Environment     string     `json:"environment" gorm:"column:environment;size:20;index"`            // 环境：prod / stage / dev
+	CostCenter      string     `json:"cost_center" gorm:"column:cost_center;size:100;index"`           // 成本中心
+	ReviewDate      *time.Time `json:"review_date" gorm:"column:review_date"`                          // 下次复核日期，到期后需确认是否继续保留
+
+	// 删除保护（仅在平台维护，同步时保留）：开启后拒绝删除、停止和迁移，只有管理员可以解除
+	Protected       int8   `json:"protected" gorm:"column:protected;default:0"`              // 是否受保护：0=否, 1=是
+	ProtectedBy     string `json:"protected_by" gorm:"column:protected_by;size:100"`         // 开启保护的用户
+	ProtectedReason string `json:"protected_reason" gorm:"column:protected_reason;size:500"` // 保护原因
+
 	CreateTime   time.Time `json:"create_time" gorm:"column:gmt_create"`
 	UpdateTime   time.Time `json:"update_time" gorm:"column:gmt_modified"`
 	ResourceHash string    `json:"resource_hash" gorm:"column:resource_hash;index"`
@@ -38,3 +55,10 @@ type PveVM struct {
 func (PveVM) TableName() string {
 	return "pve_vm"
 }
+
+// 虚拟机环境
+const (
+	VMEnvironmentProd  = "prod"
+	VMEnvironmentStage = "stage"
+	VMEnvironmentDev   = "dev"
+)
@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// RebalancePlan 集群负载再平衡方案：为拉平节点 CPU / 内存利用率建议的一组在线迁移
+type RebalancePlan struct {
+	Id          int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID   int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	ClusterName string `json:"cluster_name" gorm:"column:cluster_name;size:100"`
+	Source      string `json:"source" gorm:"column:source;size:20"` // scheduled（定期分析） / manual（手动计算）
+
+	// 利用率差值（最高节点 - 最低节点，0-1），after 为按方案迁移后的预估值
+	CPUSpreadBefore float64 `json:"cpu_spread_before" gorm:"column:cpu_spread_before"`
+	MemSpreadBefore float64 `json:"mem_spread_before" gorm:"column:mem_spread_before"`
+	CPUSpreadAfter  float64 `json:"cpu_spread_after" gorm:"column:cpu_spread_after"`
+	MemSpreadAfter  float64 `json:"mem_spread_after" gorm:"column:mem_spread_after"`
+	Moves           string  `json:"moves" gorm:"column:moves;type:text"` // 迁移列表（RebalanceMove 的 JSON 数组）
+
+	Status       string     `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	TotalMoves   int        `json:"total_moves" gorm:"column:total_moves;default:0"`
+	DoneMoves    int        `json:"done_moves" gorm:"column:done_moves;default:0"`
+	Bwlimit      int        `json:"bwlimit" gorm:"column:bwlimit;default:0"` // 执行时每次迁移的带宽限制（KiB/s），0 表示不限速
+	AppliedBy    string     `json:"applied_by" gorm:"column:applied_by;size:100"`
+	StartTime    *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime      *time.Time `json:"end_time" gorm:"column:end_time"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (RebalancePlan) TableName() string {
+	return "rebalance_plan"
+}
+
+// RebalanceMove 再平衡方案中的单次迁移
+type RebalanceMove struct {
+	VmId         int64   `json:"vm_id"`
+	VMID         uint32  `json:"vmid"`
+	VmName       string  `json:"vm_name"`
+	SourceNodeID int64   `json:"source_node_id"`
+	SourceNode   string  `json:"source_node"`
+	TargetNodeID int64   `json:"target_node_id"`
+	TargetNode   string  `json:"target_node"`
+	CPU          float64 `json:"cpu"`    // 虚拟机当前占用的 CPU 核数
+	Memory       int64   `json:"memory"` // 虚拟机当前占用的内存（字节）
+	Status       string  `json:"status"` // pending / running / completed / failed / skipped
+	UPID         string  `json:"upid"`
+	ErrorMessage string  `json:"error_message,omitempty"`
+}
+
+// RebalancePlanStatus 再平衡方案状态常量
+const (
+	RebalancePlanStatusPending    = "pending"
+	RebalancePlanStatusApplying   = "applying"
+	RebalancePlanStatusCompleted  = "completed"
+	RebalancePlanStatusFailed     = "failed"
+	RebalancePlanStatusSuperseded = "superseded" // 被更新的分析结果取代
+)
+
+// RebalanceMoveStatus 单次迁移状态常量
+const (
+	RebalanceMoveStatusPending   = "pending"
+	RebalanceMoveStatusRunning   = "running"
+	RebalanceMoveStatusCompleted = "completed"
+	RebalanceMoveStatusFailed    = "failed"
+	RebalanceMoveStatusSkipped   = "skipped" // 虚拟机已不在源节点
+)
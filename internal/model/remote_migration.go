@@ -0,0 +1,80 @@
+package model
+
+import "time"
+
+// RemoteMigrationJob 计划的跨集群迁移，到达计划时间（或所选迁移窗口开始）后由后台发起
+type RemoteMigrationJob struct {
+	Id              int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID       int64     `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	VmId            int64     `json:"vm_id" gorm:"column:vm_id;not null;index"`
+	VMID            uint32    `json:"vmid" gorm:"column:vmid;not null"`
+	VmName          string    `json:"vm_name" gorm:"column:vm_name;size:100"`
+	TargetClusterID int64     `json:"target_cluster_id" gorm:"column:target_cluster_id;not null"`
+	TargetNodeID    int64     `json:"target_node_id" gorm:"column:target_node_id;not null"`
+	Request         string    `json:"request" gorm:"column:request;type:text"` // 迁移参数（RemoteMigrateVMRequest 的 JSON）
+	Window          string    `json:"window" gorm:"column:window;size:100"`    // 迁移窗口，为空时按 ScheduledAt 发起
+	ScheduledAt     time.Time `json:"scheduled_at" gorm:"column:scheduled_at;index"`
+	Bwlimit         int       `json:"bwlimit" gorm:"column:bwlimit;default:0"`     // KiB/s，发起时使用的带宽限制
+	Preflight       string    `json:"preflight" gorm:"column:preflight;type:text"` // 计划时的预检结果（JSON）
+
+	Status       string     `json:"status" gorm:"column:status;size:20;not null;default:'scheduled';index"`
+	UPID         string     `json:"upid" gorm:"column:upid;size:255"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+	StartTime    *time.Time `json:"start_time" gorm:"column:start_time"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (RemoteMigrationJob) TableName() string {
+	return "remote_migration_job"
+}
+
+// RemoteMigrationJobStatus 计划迁移状态常量
+const (
+	RemoteMigrationJobScheduled = "scheduled"
+	RemoteMigrationJobRunning   = "running"
+	RemoteMigrationJobStarted   = "started" // 已发起 Proxmox 迁移任务，进度见任务列表
+	RemoteMigrationJobFailed    = "failed"
+	RemoteMigrationJobCancelled = "cancelled"
+)
+
+// RemoteMigrationCutover 跨集群迁移切换：迁移任务成功后把虚拟机记录改到目标集群，重新绑定或分配 IP，
+// 按需更新 cloud-init 并通知 DNS
+type RemoteMigrationCutover struct {
+	Id              int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	VmId            int64  `json:"vm_id" gorm:"column:vm_id;not null;index"`
+	VmName          string `json:"vm_name" gorm:"column:vm_name;size:100"`
+	SourceClusterID int64  `json:"source_cluster_id" gorm:"column:source_cluster_id;not null"`
+	SourceNode      string `json:"source_node" gorm:"column:source_node;size:100"`
+	SourceVMID      uint32 `json:"source_vmid" gorm:"column:source_vmid;not null"`
+	TargetClusterID int64  `json:"target_cluster_id" gorm:"column:target_cluster_id;not null"`
+	TargetNodeID    int64  `json:"target_node_id" gorm:"column:target_node_id;not null"`
+	TargetNode      string `json:"target_node" gorm:"column:target_node;size:100"`
+	TargetVMID      uint32 `json:"target_vmid" gorm:"column:target_vmid;not null"`
+	UPID            string `json:"upid" gorm:"column:upid;size:255"`    // 源节点上的迁移任务
+	ReIP            string `json:"re_ip" gorm:"column:re_ip;type:text"` // 需要更换地址的网卡（RemoteMigrateReIP 的 JSON 数组）
+	UpdateDNS       int8   `json:"update_dns" gorm:"column:update_dns;default:1"`
+	Addresses       string `json:"addresses" gorm:"column:addresses;type:text"` // 已分配的地址（RemoteMigrationCutoverAddress 的 JSON 数组），重试时沿用
+	Steps           string `json:"steps" gorm:"column:steps;type:text"`         // 各步骤结果（RemoteMigrationCutoverStep 的 JSON 数组）
+
+	Status       string     `json:"status" gorm:"column:status;size:20;not null;default:'waiting';index"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+	EndTime      *time.Time `json:"end_time" gorm:"column:end_time"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (RemoteMigrationCutover) TableName() string {
+	return "remote_migration_cutover"
+}
+
+// RemoteMigrationCutoverStatus 切换状态常量
+const (
+	RemoteMigrationCutoverWaiting   = "waiting" // 等待迁移任务结束
+	RemoteMigrationCutoverCompleted = "completed"
+	RemoteMigrationCutoverFailed    = "failed"
+)
@@ -0,0 +1,82 @@
+package model
+
+import "time"
+
+// ReportSchedule 定期报告计划：按频率汇总容量、备份、合规数据，以站内通知和带 XLSX 附件的邮件发送给一组接收人
+type ReportSchedule struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name      string `json:"name" gorm:"column:name;size:100;not null;uniqueIndex"`
+	ClusterID int64  `json:"cluster_id" gorm:"column:cluster_id;default:0"`     // 0 表示全部集群
+	Sections  string `json:"sections" gorm:"column:sections;size:100;not null"` // 逗号分隔：capacity / backup / compliance
+
+	// 发送时间：daily 每天、weekly 每周 Weekday、monthly 每月 MonthDay（超过当月天数时为月末）的 SendTime
+	Frequency string `json:"frequency" gorm:"column:frequency;size:20;not null"` // daily / weekly / monthly
+	Weekday   int    `json:"weekday" gorm:"column:weekday;default:1"`            // 0=周日 ... 6=周六
+	MonthDay  int    `json:"month_day" gorm:"column:month_day;default:1"`        // 1-31
+	SendTime  string `json:"send_time" gorm:"column:send_time;size:5;not null"`  // HH:MM
+	Timezone  string `json:"timezone" gorm:"column:timezone;size:64"`            // IANA 时区，空表示服务器本地时区
+
+	// 接收人：Recipients 为用户名（站内通知 + 用户邮箱），Emails 为额外的邮件地址，均逗号分隔
+	Recipients string `json:"recipients" gorm:"column:recipients;size:1000"`
+	Emails     string `json:"emails" gorm:"column:emails;size:1000"`
+	IsEnabled  int8   `json:"is_enabled" gorm:"column:is_enabled;default:1"` // 1-启用，0-禁用
+
+	LastRunTime *time.Time `json:"last_run_time" gorm:"column:last_run_time"`
+	NextRunTime *time.Time `json:"next_run_time" gorm:"column:next_run_time;index"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	Modifier   string    `json:"modifier" gorm:"column:modifier;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ReportSchedule) TableName() string {
+	return "report_schedule"
+}
+
+// ReportFrequency 定期报告频率
+const (
+	ReportFrequencyDaily   = "daily"
+	ReportFrequencyWeekly  = "weekly"
+	ReportFrequencyMonthly = "monthly"
+)
+
+// ReportSection 定期报告内容
+const (
+	ReportSectionCapacity   = "capacity"   // 容量汇总及周期内变化
+	ReportSectionBackup     = "backup"     // 周期内备份任务成功率
+	ReportSectionCompliance = "compliance" // 合规得分及趋势
+)
+
+// ReportRun 定期报告的一次发送记录，保留 XLSX 附件供下载，合规得分用于计算趋势
+type ReportRun struct {
+	Id          int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ScheduleID  int64     `json:"schedule_id" gorm:"column:schedule_id;not null;index"`
+	PeriodStart time.Time `json:"period_start" gorm:"column:period_start"`
+	PeriodEnd   time.Time `json:"period_end" gorm:"column:period_end"`
+	Status      string    `json:"status" gorm:"column:status;size:20;not null"` // sent / partial / failed
+
+	// 关键指标，-1 表示报告不含该内容或没有数据
+	ComplianceScore   float64 `json:"compliance_score" gorm:"column:compliance_score;default:-1"`
+	BackupSuccessRate float64 `json:"backup_success_rate" gorm:"column:backup_success_rate;default:-1"`
+	Summary           string  `json:"summary" gorm:"column:summary;type:text"` // 报告摘要（通知和邮件正文）
+
+	Notified     int    `json:"notified" gorm:"column:notified"` // 站内通知人数
+	Emailed      int    `json:"emailed" gorm:"column:emailed"`   // 邮件收件人数
+	Attachment   []byte `json:"-" gorm:"column:attachment"`      // XLSX 附件
+	ErrorMessage string `json:"error_message" gorm:"column:error_message;size:1000"`
+	Operator     string `json:"operator" gorm:"column:operator;size:100"` // 手动发送的操作人，定时发送为空
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime;index"`
+}
+
+func (ReportRun) TableName() string {
+	return "report_run"
+}
+
+// ReportRunStatus 定期报告发送状态
+const (
+	ReportRunStatusSent    = "sent"
+	ReportRunStatusPartial = "partial" // 部分内容生成失败或邮件发送失败
+	ReportRunStatusFailed  = "failed"
+)
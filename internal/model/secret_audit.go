@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// SecretAuditLog 虚拟机密码读写审计记录，每次写入、租约签发、读取、删除和迁移各一条（包括失败和被拒绝的请求）
+type SecretAuditLog struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	VmId       int64     `json:"vm_id" gorm:"column:vm_id;not null;index"`
+	VmName     string    `json:"vm_name" gorm:"column:vm_name;size:200"`
+	Action     string    `json:"action" gorm:"column:action;size:20;not null;index"` // write / lease / read / delete / migrate
+	Backend    string    `json:"backend" gorm:"column:backend;size:20"`              // local / vault / kubernetes，明文存储时为 plaintext
+	Operator   string    `json:"operator" gorm:"column:operator;size:100;index"`     // 操作人用户名，后台任务为 system
+	ClientIP   string    `json:"client_ip" gorm:"column:client_ip;size:64"`
+	Success    bool      `json:"success" gorm:"column:success"`
+	Message    string    `json:"message" gorm:"column:message;size:500"` // 失败原因
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime;index"`
+}
+
+func (SecretAuditLog) TableName() string {
+	return "secret_audit_log"
+}
+
+// 密码审计动作
+const (
+	SecretActionWrite   = "write"
+	SecretActionLease   = "lease"
+	SecretActionRead    = "read"
+	SecretActionDelete  = "delete"
+	SecretActionMigrate = "migrate"
+)
+
+// SecretBackendPlaintext 未配置密钥后端时密码以明文保存在 pve_vm.vm_password（兼容旧数据）
+const SecretBackendPlaintext = "plaintext"
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// SharedToken 多实例共享的短期令牌（控制台 ws_token、控制台会话、批量删除确认令牌等），
+// 保存在数据库中，任一实例签发的令牌可由其他实例读取或消费；只保存令牌的 SHA-256
+type SharedToken struct {
+	Id        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Kind      string    `json:"kind" gorm:"column:kind;size:32;not null"`
+	TokenHash string    `json:"-" gorm:"column:token_hash;size:64;not null;uniqueIndex"`
+	Payload   string    `json:"-" gorm:"column:payload;type:text"` // JSON
+	ExpiresAt time.Time `json:"expires_at" gorm:"column:expires_at;not null;index"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (SharedToken) TableName() string {
+	return "shared_token"
+}
+
+// SharedToken 类型
+const (
+	SharedTokenNodeConsole    = "node_console"
+	SharedTokenVMConsole      = "vm_console"
+	SharedTokenVMConsoleLease = "vm_console_lease"
+	SharedTokenVMBulkDelete   = "vm_bulk_delete_confirm"
+	SharedTokenStorageDelete  = "storage_delete_confirm"
+	SharedTokenVMCredential   = "vm_credential_lease"
+)
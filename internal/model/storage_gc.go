@@ -0,0 +1,82 @@
+package model
+
+import "time"
+
+// StorageGCScan 存储垃圾扫描任务（一次对某个集群所有存储内容的扫描）
+type StorageGCScan struct {
+	Id        int64 `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID int64 `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+
+	IsoMaxAgeDays  int `json:"iso_max_age_days" gorm:"column:iso_max_age_days;default:0"` // ISO/容器模板未被引用且超过该天数视为陈旧，0 表示不检查
+	BackupKeepLast int `json:"backup_keep_last" gorm:"column:backup_keep_last;default:0"` // 每个虚拟机保留的最近备份数，0 表示不检查
+
+	Status    string `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	ItemCount int    `json:"item_count" gorm:"column:item_count;default:0"` // 发现的可回收项数量
+	TotalSize int64  `json:"total_size" gorm:"column:total_size;default:0"` // 可回收总大小（字节）
+
+	StartTime    *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime      *time.Time `json:"end_time" gorm:"column:end_time"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (StorageGCScan) TableName() string {
+	return "storage_gc_scan"
+}
+
+// StorageGCScanStatus 扫描任务状态常量
+const (
+	StorageGCScanStatusPending   = "pending"
+	StorageGCScanStatusRunning   = "running"
+	StorageGCScanStatusCompleted = "completed"
+	StorageGCScanStatusFailed    = "failed"
+)
+
+// StorageGCItem 扫描发现的可回收存储内容
+type StorageGCItem struct {
+	Id        int64 `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ScanID    int64 `json:"scan_id" gorm:"column:scan_id;not null;index"`
+	ClusterID int64 `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+
+	NodeName    string `json:"node_name" gorm:"column:node_name;size:100;not null"`
+	StorageName string `json:"storage_name" gorm:"column:storage_name;size:100;not null"`
+	VolID       string `json:"volid" gorm:"column:volid;size:500;not null"`
+	Content     string `json:"content" gorm:"column:content;size:50"`
+	Format      string `json:"format" gorm:"column:format;size:50"`
+	Size        int64  `json:"size" gorm:"column:size;default:0"`
+	VMID        uint32 `json:"vmid" gorm:"column:vmid;default:0"`
+	CTime       int64  `json:"ctime" gorm:"column:ctime;default:0"` // 卷创建时间（unix 秒）
+
+	Reason string `json:"reason" gorm:"column:reason;size:50;not null;index"`
+	Status string `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+
+	Reviewer     string `json:"reviewer" gorm:"column:reviewer;size:100"`
+	ErrorMessage string `json:"error_message" gorm:"column:error_message;type:text"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (StorageGCItem) TableName() string {
+	return "storage_gc_item"
+}
+
+// StorageGCItemReason 可回收原因常量
+const (
+	StorageGCReasonOrphanDisk    = "orphan_disk"    // 磁盘未被任何虚拟机配置引用
+	StorageGCReasonStaleISO      = "stale_iso"      // ISO 未被引用且超过保留天数
+	StorageGCReasonStaleTemplate = "stale_template" // 容器模板未被引用且超过保留天数
+	StorageGCReasonOldBackup     = "old_backup"     // 超出保留数量的旧备份
+)
+
+// StorageGCItemStatus 可回收项审核状态常量
+const (
+	StorageGCItemStatusPending  = "pending"  // 待审核
+	StorageGCItemStatusApproved = "approved" // 已确认，可删除
+	StorageGCItemStatusIgnored  = "ignored"  // 已忽略
+	StorageGCItemStatusDeleted  = "deleted"  // 已删除
+	StorageGCItemStatusFailed   = "failed"   // 删除失败
+)
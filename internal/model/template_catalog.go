@@ -0,0 +1,99 @@
+package model
+
+import "time"
+
+// TemplateCatalog 订阅的远程模板目录（HTTPS 上的 JSON 索引）
+type TemplateCatalog struct {
+	Id            int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name          string     `json:"name" gorm:"column:name;size:100;not null;uniqueIndex"`
+	URL           string     `json:"url" gorm:"column:url;size:500;not null"`
+	Description   string     `json:"description" gorm:"column:description;size:500"`
+	Enabled       int8       `json:"enabled" gorm:"column:enabled;default:1"`
+	ImageCount    int        `json:"image_count" gorm:"column:image_count;default:0"`
+	LastSyncTime  *time.Time `json:"last_sync_time" gorm:"column:last_sync_time"`
+	LastSyncError string     `json:"last_sync_error" gorm:"column:last_sync_error;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (TemplateCatalog) TableName() string {
+	return "template_catalog"
+}
+
+// TemplateCatalogImage 目录中的镜像（每次刷新目录时整体替换）
+type TemplateCatalogImage struct {
+	Id                int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	CatalogID         int64  `json:"catalog_id" gorm:"column:catalog_id;not null;uniqueIndex:idx_catalog_image"`
+	ImageKey          string `json:"image_key" gorm:"column:image_key;size:100;not null;uniqueIndex:idx_catalog_image"` // 目录内唯一标识
+	Name              string `json:"name" gorm:"column:name;size:200;not null"`
+	Version           string `json:"version" gorm:"column:version;size:50"`
+	Description       string `json:"description" gorm:"column:description;type:text"`
+	OSType            string `json:"os_type" gorm:"column:os_type;size:20"` // PVE ostype，如 l26 / win11
+	URL               string `json:"url" gorm:"column:url;size:1000;not null"`
+	Format            string `json:"format" gorm:"column:format;size:20"` // qcow2 / raw / vmdk
+	Checksum          string `json:"checksum" gorm:"column:checksum;size:128"`
+	ChecksumAlgorithm string `json:"checksum_algorithm" gorm:"column:checksum_algorithm;size:20"`
+	Size              int64  `json:"size" gorm:"column:size;default:0"`
+	Cores             int    `json:"cores" gorm:"column:cores;default:0"`
+	Memory            int    `json:"memory" gorm:"column:memory;default:0"` // MiB
+	CloudInit         int8   `json:"cloud_init" gorm:"column:cloud_init;default:0"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (TemplateCatalogImage) TableName() string {
+	return "template_catalog_image"
+}
+
+// TemplateCatalogInstall 目录镜像安装任务：节点下载并校验镜像 -> 导入磁盘创建虚拟机 -> 转换为模板并登记
+type TemplateCatalogInstall struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	CatalogID int64  `json:"catalog_id" gorm:"column:catalog_id;not null;index"`
+	ImageID   int64  `json:"image_id" gorm:"column:image_id;not null;index"`
+	ImageName string `json:"image_name" gorm:"column:image_name;size:200"`
+
+	ClusterID       int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeID          int64  `json:"node_id" gorm:"column:node_id;not null"`
+	NodeName        string `json:"node_name" gorm:"column:node_name;size:100"`
+	DownloadStorage string `json:"download_storage" gorm:"column:download_storage;size:100"` // 镜像下载到的存储（content 含 import）
+	TargetStorageID int64  `json:"target_storage_id" gorm:"column:target_storage_id;not null"`
+	TargetStorage   string `json:"target_storage" gorm:"column:target_storage;size:100"`
+	TemplateName    string `json:"template_name" gorm:"column:template_name;size:100"`
+	FileName        string `json:"file_name" gorm:"column:file_name;size:255"`
+	VMID            uint32 `json:"vmid" gorm:"column:vmid;default:0"`
+	TemplateID      int64  `json:"template_id" gorm:"column:template_id;default:0"` // 登记后的 vm_template ID
+
+	Status       string     `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	Phase        string     `json:"phase" gorm:"column:phase;size:50"`
+	UPID         string     `json:"upid" gorm:"column:upid;size:255"`
+	StartTime    *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime      *time.Time `json:"end_time" gorm:"column:end_time"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (TemplateCatalogInstall) TableName() string {
+	return "template_catalog_install"
+}
+
+// TemplateCatalogInstallStatus 安装任务状态常量
+const (
+	TemplateCatalogInstallPending   = "pending"
+	TemplateCatalogInstallRunning   = "running"
+	TemplateCatalogInstallCompleted = "completed"
+	TemplateCatalogInstallFailed    = "failed"
+)
+
+// TemplateCatalogInstallPhase 安装任务阶段
+const (
+	TemplateCatalogPhaseDownload = "download"
+	TemplateCatalogPhaseCreate   = "create"
+	TemplateCatalogPhaseConvert  = "convert"
+	TemplateCatalogPhaseRegister = "register"
+)
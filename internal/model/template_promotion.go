@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// TemplatePromotion 模板晋级记录：把模板从一个环境的集群复制到下一环境的集群，
+// 审批通过后经对象存储导出、导入并在目标集群登记为新模板；ParentID 指向产生源模板的上一级晋级记录
+type TemplatePromotion struct {
+	Id               int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ParentID         int64  `json:"parent_id" gorm:"column:parent_id;default:0;index"`
+	OriginTemplateID int64  `json:"origin_template_id" gorm:"column:origin_template_id;not null;index"` // 晋级链最初的模板
+	TemplateID       int64  `json:"template_id" gorm:"column:template_id;not null;index"`
+	TemplateName     string `json:"template_name" gorm:"column:template_name;size:100"`
+
+	SourceClusterID      int64  `json:"source_cluster_id" gorm:"column:source_cluster_id;not null;index"`
+	SourceStage          string `json:"source_stage" gorm:"column:source_stage;size:50"`
+	TargetClusterID      int64  `json:"target_cluster_id" gorm:"column:target_cluster_id;not null;index"`
+	TargetStage          string `json:"target_stage" gorm:"column:target_stage;size:50"`
+	TargetNodeID         int64  `json:"target_node_id" gorm:"column:target_node_id;not null"`
+	TargetNodeName       string `json:"target_node_name" gorm:"column:target_node_name;size:100"`
+	TargetStorage        string `json:"target_storage" gorm:"column:target_storage;size:100"`
+	StoreID              int64  `json:"store_id" gorm:"column:store_id;not null"`
+	StagingStorage       string `json:"staging_storage" gorm:"column:staging_storage;size:100"`
+	TargetStagingStorage string `json:"target_staging_storage" gorm:"column:target_staging_storage;size:100"`
+
+	Status             string `json:"status" gorm:"column:status;size:50;not null;default:'pending_approval';index"`
+	Phase              string `json:"phase" gorm:"column:phase;size:50"`
+	ExportTaskID       int64  `json:"export_task_id" gorm:"column:export_task_id;default:0"`
+	ImportTaskID       int64  `json:"import_task_id" gorm:"column:import_task_id;default:0"`
+	ObjectKey          string `json:"object_key" gorm:"column:object_key;size:1000"`
+	Checksum           string `json:"checksum" gorm:"column:checksum;size:64"` // 备份文件 SHA256
+	PromotedTemplateID int64  `json:"promoted_template_id" gorm:"column:promoted_template_id;default:0;index"`
+	PromotedVMID       uint32 `json:"promoted_vmid" gorm:"column:promoted_vmid;default:0"`
+
+	Reason        string     `json:"reason" gorm:"column:reason;size:500"`
+	Requester     string     `json:"requester" gorm:"column:requester;size:100"`
+	Reviewer      string     `json:"reviewer" gorm:"column:reviewer;size:100"`
+	ReviewComment string     `json:"review_comment" gorm:"column:review_comment;size:500"`
+	ReviewTime    *time.Time `json:"review_time" gorm:"column:review_time"`
+	ErrorMessage  string     `json:"error_message" gorm:"column:error_message;type:text"`
+	StartTime     *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime       *time.Time `json:"end_time" gorm:"column:end_time"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (TemplatePromotion) TableName() string {
+	return "template_promotion"
+}
+
+// TemplatePromotionStatus 晋级状态常量
+const (
+	TemplatePromotionStatusPending     = "pending_approval"
+	TemplatePromotionStatusRejected    = "rejected"
+	TemplatePromotionStatusReplicating = "replicating"
+	TemplatePromotionStatusCompleted   = "completed"
+	TemplatePromotionStatusFailed      = "failed"
+)
+
+// TemplatePromotionPhase 复制阶段
+const (
+	TemplatePromotionPhaseExport   = "export"
+	TemplatePromotionPhaseImport   = "import"
+	TemplatePromotionPhaseRegister = "register"
+)
@@ -20,7 +20,7 @@ type TemplateUpload struct {
 	FileSize   int64  `json:"file_size" gorm:"column:file_size;not null;default:0"`     // 备份文件大小
 	FileFormat string `json:"file_format" gorm:"column:file_format;size:50;not null"`   // 备份文件格式（vma, vma.zst, vma.lzo等）
 	
-	Status         string `json:"status" gorm:"column:status;size:50;not null;default:'importing';index"` // 状态：importing, imported, failed
+	Status         string `json:"status" gorm:"column:status;size:50;not null;default:'importing';index"` // 状态：uploading, importing, imported, failed
 	ImportProgress int    `json:"import_progress" gorm:"column:import_progress;default:0"`
 	ErrorMessage   string `json:"error_message" gorm:"column:error_message;type:text"`
 	
@@ -36,6 +36,7 @@ func (TemplateUpload) TableName() string {
 
 // TemplateUploadStatus 导入状态常量
 const (
+	TemplateUploadStatusUploading = "uploading"  // 上传中（上传并导入）
 	TemplateUploadStatusImporting = "importing"  // 导入中
 	TemplateUploadStatusImported  = "imported"   // 导入完成
 	TemplateUploadStatusFailed    = "failed"     // 导入失败
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// TemplateUsageLog 从模板克隆虚拟机的记录，每次克隆一条；虚拟机删除后保留，用于统计模板使用情况
+type TemplateUsageLog struct {
+	Id         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	TemplateID int64  `json:"template_id" gorm:"column:template_id;not null;index"`
+	UploadID   int64  `json:"upload_id" gorm:"column:upload_id;index"` // 模板版本（克隆所用实例对应的导入记录）
+	InstanceID int64  `json:"instance_id" gorm:"column:instance_id"`
+	ClusterID  int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeName   string `json:"node_name" gorm:"column:node_name;size:100"`
+	VmId       int64  `json:"vm_id" gorm:"column:vm_id;index"`
+	VMID       uint32 `json:"vmid" gorm:"column:vmid"`
+	VmName     string `json:"vm_name" gorm:"column:vm_name;size:200"`
+	Source     string `json:"source" gorm:"column:source;size:20"` // create / stack
+	Creator    string `json:"creator" gorm:"column:creator;size:100"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime;index"`
+}
+
+func (TemplateUsageLog) TableName() string {
+	return "template_usage_log"
+}
+
+// 模板克隆来源
+const (
+	TemplateUsageSourceCreate = "create"
+	TemplateUsageSourceStack  = "stack"
+)
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+// VMAgentInstall 为未运行 guest agent 的虚拟机安装 qemu-guest-agent 的记录：
+// 挂载安装 ISO 或通过 cloud-init vendor 数据在下次启动时安装，之后定期 ping agent 检测是否成功
+type VMAgentInstall struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	VmId      int64  `json:"vm_id" gorm:"column:vm_id;not null;index"`
+	VMID      uint32 `json:"vmid" gorm:"column:vmid;not null"`
+	VmName    string `json:"vm_name" gorm:"column:vm_name;size:100"`
+	Method    string `json:"method" gorm:"column:method;size:20;not null"` // cloudinit / iso
+	Volume    string `json:"volume" gorm:"column:volume;size:255"`         // 安装 ISO 或 cloud-init snippet 卷，如 local:iso/qemu-ga.iso
+	Slot      string `json:"slot" gorm:"column:slot;size:20"`              // 挂载 ISO 的光驱，如 ide3
+
+	// 安装前的配置，取消或结束后用于恢复；空字符串表示原来没有该配置
+	PrevAgent    string `json:"prev_agent" gorm:"column:prev_agent;size:200"`
+	PrevCicustom string `json:"prev_cicustom" gorm:"column:prev_cicustom;size:500"`
+	AgentEnabled bool   `json:"agent_enabled" gorm:"column:agent_enabled"` // 是否由本次安装开启了 agent 选项
+	Cleaned      bool   `json:"cleaned" gorm:"column:cleaned;index"`       // 结束后是否已卸载 ISO / 恢复 cicustom
+
+	Status        string     `json:"status" gorm:"column:status;size:20;not null;default:'waiting';index"`
+	Message       string     `json:"message" gorm:"column:message;type:text"`
+	Checks        int        `json:"checks" gorm:"column:checks;default:0"`
+	LastCheckTime *time.Time `json:"last_check_time" gorm:"column:last_check_time"`
+	FinishTime    *time.Time `json:"finish_time" gorm:"column:finish_time"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMAgentInstall) TableName() string {
+	return "vm_agent_install"
+}
+
+// VMAgentInstallStatus guest agent 安装状态常量
+const (
+	VMAgentInstallStatusWaiting   = "waiting" // 已挂载安装介质，等待 agent 响应
+	VMAgentInstallStatusSucceeded = "succeeded"
+	VMAgentInstallStatusFailed    = "failed"
+	VMAgentInstallStatusCancelled = "cancelled"
+)
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// VMBulkDeleteJob 批量删除虚拟机任务：逐台停止并删除预检确认过的虚拟机，Items 同时作为删除报告
+type VMBulkDeleteJob struct {
+	Id       int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Selector string `json:"selector" gorm:"column:selector;size:500"`  // 预检时的选择条件，如 tag=decommission cluster_id=1
+	StopMode string `json:"stop_mode" gorm:"column:stop_mode;size:20"` // 运行中虚拟机的停止方式：shutdown / stop
+	Items    string `json:"items" gorm:"column:items;type:text"`       // 各虚拟机进度（VMBulkDeleteItem 的 JSON 数组）
+
+	Status    string     `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	Total     int        `json:"total" gorm:"column:total;default:0"`
+	Deleted   int        `json:"deleted" gorm:"column:deleted;default:0"`
+	Skipped   int        `json:"skipped" gorm:"column:skipped;default:0"`
+	Failed    int        `json:"failed" gorm:"column:failed;default:0"`
+	StartTime *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime   *time.Time `json:"end_time" gorm:"column:end_time"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100;index"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMBulkDeleteJob) TableName() string {
+	return "vm_bulk_delete_job"
+}
+
+// VMBulkDeleteItem 单台虚拟机的删除进度
+type VMBulkDeleteItem struct {
+	VmId      int64  `json:"vm_id"`
+	VMID      uint32 `json:"vmid"`
+	VmName    string `json:"vm_name"`
+	ClusterID int64  `json:"cluster_id"`
+	NodeName  string `json:"node_name"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// VMBulkDeleteJobStatus 批量删除任务状态常量
+const (
+	VMBulkDeleteJobStatusPending   = "pending"
+	VMBulkDeleteJobStatusRunning   = "running"
+	VMBulkDeleteJobStatusCompleted = "completed"
+)
+
+// VMBulkDeleteItemStatus 单台虚拟机的删除状态常量
+const (
+	VMBulkDeleteItemPending  = "pending"
+	VMBulkDeleteItemStopping = "stopping"
+	VMBulkDeleteItemDeleting = "deleting"
+	VMBulkDeleteItemDeleted  = "deleted"
+	VMBulkDeleteItemSkipped  = "skipped"
+	VMBulkDeleteItemFailed   = "failed"
+)
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// 待认领原因
+const (
+	UnclaimedReasonUntracked = "untracked" // Proxmox 中存在但数据库没有记录
+	UnclaimedReasonUnowned   = "unowned"   // 数据库有记录但未设置负责人
+)
+
+// UnclaimedVM 待认领虚拟机，由认领扫描比对 Proxmox 实际虚拟机与数据库记录生成；
+// 虚拟机被认领或已从 Proxmox 删除后，下次扫描时移除
+type UnclaimedVM struct {
+	Id           int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID    int64      `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex:idx_unclaimed_vm_key"`
+	VMID         uint32     `json:"vmid" gorm:"column:vmid;not null;uniqueIndex:idx_unclaimed_vm_key"`
+	NodeName     string     `json:"node_name" gorm:"column:node_name;size:100"`
+	VmName       string     `json:"vm_name" gorm:"column:vm_name;size:200"`
+	Status       string     `json:"status" gorm:"column:status;size:20"`
+	CPUNum       int        `json:"cpu_num" gorm:"column:cpu_num"`
+	MemorySize   int        `json:"memory_size" gorm:"column:memory_size"` // MB
+	DiskSize     int        `json:"disk_size" gorm:"column:disk_size"`     // MB
+	Reason       string     `json:"reason" gorm:"column:reason;size:20;index"`
+	VmID         int64      `json:"vm_id" gorm:"column:vm_id"`                   // pve_vm 表 ID，untracked 时为 0
+	Creator      string     `json:"creator" gorm:"column:creator"`               // 虚拟机记录的创建者，用于提醒时定位责任人
+	Ignored      bool       `json:"ignored" gorm:"column:ignored;default:false"` // 已忽略（如基础设施虚拟机），不再提醒
+	IgnoredBy    string     `json:"ignored_by" gorm:"column:ignored_by"`
+	FirstSeen    time.Time  `json:"first_seen" gorm:"column:first_seen"`
+	LastSeen     time.Time  `json:"last_seen" gorm:"column:last_seen"`
+	LastRemindAt *time.Time `json:"last_remind_at" gorm:"column:last_remind_at"`
+	CreateTime   time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime   time.Time  `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (UnclaimedVM) TableName() string {
+	return "unclaimed_vm"
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// VMCreateJob 异步创建虚拟机任务，记录提交的目标和执行结果（不保存请求中的密码等参数）
+type VMCreateJob struct {
+	Id          int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID   int64  `json:"cluster_id" gorm:"column:cluster_id;default:0;index"`
+	ClusterName string `json:"cluster_name" gorm:"column:cluster_name;size:100"`
+	NodeID      int64  `json:"node_id" gorm:"column:node_id;default:0"`
+	NodeName    string `json:"node_name" gorm:"column:node_name;size:100"`
+	VMID        uint32 `json:"vmid" gorm:"column:vmid;not null"`
+	VmName      string `json:"vm_name" gorm:"column:vm_name;size:100"`
+	CreateMode  string `json:"create_mode" gorm:"column:create_mode;size:20"`
+	TemplateID  int64  `json:"template_id" gorm:"column:template_id;default:0"`
+	VmId        int64  `json:"vm_id" gorm:"column:vm_id;default:0"` // 创建成功后的 pve_vm 表 ID
+
+	Status       string     `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+	StartTime    *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime      *time.Time `json:"end_time" gorm:"column:end_time"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100;index"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMCreateJob) TableName() string {
+	return "vm_create_job"
+}
+
+// VMCreateJobStatus 创建任务状态常量
+const (
+	VMCreateJobStatusPending   = "pending"
+	VMCreateJobStatusRunning   = "running"
+	VMCreateJobStatusCompleted = "completed"
+	VMCreateJobStatusFailed    = "failed"
+)
@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// VmImportSource ESXi 导入源（对应 Proxmox 中 type=esxi 的存储）
+type VmImportSource struct {
+	Id             int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID      int64  `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex:idx_vm_import_source_cluster_name"`
+	Name           string `json:"name" gorm:"column:name;size:100;not null;uniqueIndex:idx_vm_import_source_cluster_name"` // Proxmox 存储 ID
+	Server         string `json:"server" gorm:"column:server;size:255;not null"`
+	Username       string `json:"username" gorm:"column:username;size:100;not null"`
+	Password       string `json:"-" gorm:"column:password;size:255"`
+	SkipCertVerify int8   `json:"skip_cert_verify" gorm:"column:skip_cert_verify;default:1"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VmImportSource) TableName() string {
+	return "vm_import_source"
+}
+
+// VmImportTask 虚拟机导入任务（ESXi 虚拟机或 OVA / OVF 转换为 Proxmox 虚拟机）
+type VmImportTask struct {
+	Id         int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID  int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeID     int64  `json:"node_id" gorm:"column:node_id;not null"`
+	NodeName   string `json:"node_name" gorm:"column:node_name;size:100"`
+	SourceType string `json:"source_type" gorm:"column:source_type;size:20;not null"` // esxi / ova / ovf
+	Volume     string `json:"volume" gorm:"column:volume;size:500;not null"`          // 导入卷，如 esxi1:ha-datacenter/datastore1/web/web.vmx
+
+	VMID          uint32 `json:"vmid" gorm:"column:vmid;default:0"`
+	VmName        string `json:"vm_name" gorm:"column:vm_name;size:100"`
+	TargetStorage string `json:"target_storage" gorm:"column:target_storage;size:100"`
+	LiveImport    int8   `json:"live_import" gorm:"column:live_import;default:0"`
+	CreateParams  string `json:"create_params" gorm:"column:create_params;type:text"` // 映射后的虚拟机创建参数（JSON）
+	AppId         string `json:"app_id" gorm:"column:appid;size:100"`
+	Description   string `json:"description" gorm:"column:description;size:500"`
+
+	Status       string     `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	Progress     int        `json:"progress" gorm:"column:progress;default:0"`
+	UPID         string     `json:"upid" gorm:"column:upid;size:255"`
+	VmId         int64      `json:"vm_id" gorm:"column:vm_id;default:0"` // pve_vm 表 ID
+	StartTime    *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime      *time.Time `json:"end_time" gorm:"column:end_time"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VmImportTask) TableName() string {
+	return "vm_import_task"
+}
+
+// VmImportTaskStatus 导入任务状态常量
+const (
+	VmImportTaskStatusPending   = "pending"
+	VmImportTaskStatusImporting = "importing"
+	VmImportTaskStatusCompleted = "completed"
+	VmImportTaskStatusFailed    = "failed"
+)
+
+// 导入源类型
+const (
+	VmImportSourceESXi = "esxi"
+	VmImportSourceOVA  = "ova"
+	VmImportSourceOVF  = "ovf"
+)
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// VmListView 用户保存的虚拟机列表视图（筛选条件、排序、显示列）
+// 平台用户没有所属团队，共享范围为全部用户，不按团队隔离
+type VmListView struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	UserId     string    `json:"user_id" gorm:"column:user_id;size:100;not null;uniqueIndex:idx_vm_list_view_user_name"` // 所属用户
+	Name       string    `json:"name" gorm:"column:name;size:100;not null;uniqueIndex:idx_vm_list_view_user_name"`       // 视图名称（同一用户下唯一）
+	Filters    string    `json:"filters" gorm:"column:filters;type:text"`                                                // 筛选条件（JSON）
+	SortBy     string    `json:"sort_by" gorm:"column:sort_by;size:50"`                                                  // 排序字段
+	SortOrder  string    `json:"sort_order" gorm:"column:sort_order;size:10"`                                            // 排序方向：asc, desc
+	Columns    string    `json:"columns" gorm:"column:columns;type:text"`                                                // 显示列（JSON 数组）
+	IsDefault  int8      `json:"is_default" gorm:"column:is_default;default:0"`                                          // 是否为该用户的默认视图
+	Shared     int8      `json:"shared" gorm:"column:shared;default:0;index"`                                            // 是否共享（所有用户可见、只读）
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VmListView) TableName() string {
+	return "vm_list_view"
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// VMLock 虚拟机维护锁，每台虚拟机最多一把；加锁期间只有持有人可以对虚拟机执行开关机、修改配置、迁移和删除
+type VMLock struct {
+	Id        int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	VmId      int64      `json:"vm_id" gorm:"column:vm_id;not null;uniqueIndex"` // pve_vm 表 ID
+	ClusterID int64      `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	VMID      uint32     `json:"vmid" gorm:"column:vmid"`
+	VmName    string     `json:"vm_name" gorm:"column:vm_name;size:200"`
+	Owner     string     `json:"owner" gorm:"column:owner;size:100;index"` // 持有人用户名
+	Reason    string     `json:"reason" gorm:"column:reason;size:500"`
+	ExpiresAt *time.Time `json:"expires_at" gorm:"column:expires_at;index"` // 为空表示不自动过期
+	Creator   string     `json:"creator" gorm:"column:creator"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMLock) TableName() string {
+	return "vm_lock"
+}
+
+// Active 是否仍然有效
+func (l *VMLock) Active(now time.Time) bool {
+	return l.ExpiresAt == nil || l.ExpiresAt.After(now)
+}
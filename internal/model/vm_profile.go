@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// VMProfile 虚拟机创建规格（CPU、内存、磁盘、网络、SCSI 控制器、guest agent、标签等）
+// cluster_id 为 0 表示全局规格，可用于所有集群；每个集群（含全局）最多一个默认规格，
+// 创建虚拟机未指定 profile_id 时依次使用集群默认规格、全局默认规格
+type VMProfile struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID  int64     `json:"cluster_id" gorm:"column:cluster_id;not null;default:0;uniqueIndex:idx_vm_profile_name"`
+	Name       string    `json:"name" gorm:"column:name;size:100;not null;uniqueIndex:idx_vm_profile_name"`
+	CPUNum     int       `json:"cpu_num" gorm:"column:cpu_num;default:0"`         // 0 表示不指定
+	MemorySize int       `json:"memory_size" gorm:"column:memory_size;default:0"` // MB，0 表示不指定
+	CPUType    string    `json:"cpu_type" gorm:"column:cpu_type;size:100"`
+	Storage    string    `json:"storage" gorm:"column:storage;size:100"`
+	DiskSizeGB int       `json:"disk_size_gb" gorm:"column:disk_size_gb;default:0"`
+	DiskFormat string    `json:"disk_format" gorm:"column:disk_format;size:20"`
+	Bridge     string    `json:"bridge" gorm:"column:bridge;size:50"`
+	NetModel   string    `json:"net_model" gorm:"column:net_model;size:20"`
+	SCSIHw     string    `json:"scsihw" gorm:"column:scsihw;size:50"`
+	OSType     string    `json:"os_type" gorm:"column:os_type;size:20"`
+	Agent      string    `json:"agent" gorm:"column:agent;size:200"`            // Proxmox agent 选项，如 enabled=1,fstrim_cloned_disks=1
+	Tags       string    `json:"tags" gorm:"column:tags;size:500"`              // 以分号分隔
+	SerialNum  int       `json:"serial_num" gorm:"column:serial_num;default:0"` // 串口数量，0 表示不指定
+	Display    string    `json:"display" gorm:"column:display;size:20"`         // 显示设备（Proxmox vga）
+	IsDefault  bool      `json:"is_default" gorm:"column:is_default;default:false;index"`
+	Describes  string    `json:"describes" gorm:"column:describes;size:500"`
+	Creator    string    `json:"creator" gorm:"column:creator"`
+	Modifier   string    `json:"modifier" gorm:"column:modifier"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMProfile) TableName() string {
+	return "vm_profile"
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// VmQosProfile 虚拟机 QoS 限速模板（可批量应用到一组虚拟机）
+type VmQosProfile struct {
+	Id          int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name        string    `json:"name" gorm:"column:name;size:100;not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"column:description;size:500"`
+	MbpsRd      float64   `json:"mbps_rd" gorm:"column:mbps_rd;default:0"`   // 磁盘读带宽上限（MB/s），0 表示不限
+	MbpsWr      float64   `json:"mbps_wr" gorm:"column:mbps_wr;default:0"`   // 磁盘写带宽上限（MB/s），0 表示不限
+	IopsRd      int       `json:"iops_rd" gorm:"column:iops_rd;default:0"`   // 磁盘读 IOPS 上限，0 表示不限
+	IopsWr      int       `json:"iops_wr" gorm:"column:iops_wr;default:0"`   // 磁盘写 IOPS 上限，0 表示不限
+	NetRate     float64   `json:"net_rate" gorm:"column:net_rate;default:0"` // 网卡速率上限（MB/s），0 表示不限
+	Creator     string    `json:"creator" gorm:"column:creator;size:100"`
+	Modifier    string    `json:"modifier" gorm:"column:modifier;size:100"`
+	CreateTime  time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime  time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VmQosProfile) TableName() string {
+	return "vm_qos_profile"
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// VMSecurityFinding 虚拟机配置安全检查发现的风险项，每次扫描后整体替换该虚拟机的记录
+type VMSecurityFinding struct {
+	Id        int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID int64     `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	VmId      int64     `json:"vm_id" gorm:"column:vm_id;not null;index"`
+	VMID      uint32    `json:"vmid" gorm:"column:vmid;not null"`
+	VmName    string    `json:"vm_name" gorm:"column:vm_name;size:100"`
+	Code      string    `json:"code" gorm:"column:code;size:50;not null;index"`   // agent_disabled / firewall_disabled / ...
+	Severity  string    `json:"severity" gorm:"column:severity;size:20;not null"` // low / medium / high
+	Target    string    `json:"target" gorm:"column:target;size:50"`              // 涉及的配置项，如 net0、ide2
+	Message   string    `json:"message" gorm:"column:message;size:500"`
+	ScanTime  time.Time `json:"scan_time" gorm:"column:scan_time"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMSecurityFinding) TableName() string {
+	return "vm_security_finding"
+}
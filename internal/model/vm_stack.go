@@ -0,0 +1,78 @@
+package model
+
+import "time"
+
+// VmStack 虚拟机编排（一组按角色批量创建的虚拟机，如 k8s master/worker）
+type VmStack struct {
+	Id          int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	Name        string `json:"name" gorm:"column:name;size:100;not null;uniqueIndex"`
+	ClusterID   int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	Description string `json:"description" gorm:"column:description;size:500"`
+	Spec        string `json:"spec" gorm:"column:spec;type:text"` // 创建请求（JSON），用于追溯
+
+	Status       string     `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	MemberCount  int        `json:"member_count" gorm:"column:member_count;default:0"`
+	StartTime    *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime      *time.Time `json:"end_time" gorm:"column:end_time"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VmStack) TableName() string {
+	return "vm_stack"
+}
+
+// VmStackStatus stack 状态常量
+const (
+	VmStackStatusPending   = "pending"
+	VmStackStatusRunning   = "running"
+	VmStackStatusCompleted = "completed"
+	VmStackStatusFailed    = "failed"
+)
+
+// VmStackMember stack 中的单台虚拟机（创建前即按顺序规划好名称和节点）
+type VmStackMember struct {
+	Id        int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	StackID   int64  `json:"stack_id" gorm:"column:stack_id;not null;index"`
+	Role      string `json:"role" gorm:"column:role;size:100;not null"`
+	RoleOrder int    `json:"role_order" gorm:"column:role_order;default:0"`
+	Seq       int    `json:"seq" gorm:"column:seq;default:0"` // 角色内序号（从 1 开始）
+
+	VmName     string `json:"vm_name" gorm:"column:vm_name;size:100;not null"`
+	NodeID     int64  `json:"node_id" gorm:"column:node_id;not null"`
+	NodeName   string `json:"node_name" gorm:"column:node_name;size:100"`
+	TemplateID int64  `json:"template_id" gorm:"column:template_id;not null"`
+	CPUNum     int    `json:"cpu_num" gorm:"column:cpu_num;default:0"`         // 0 表示保持模板配置
+	MemorySize int    `json:"memory_size" gorm:"column:memory_size;default:0"` // 0 表示保持模板配置
+	Storage    string `json:"storage" gorm:"column:storage;size:100"`
+	IPConfig   string `json:"ip_config" gorm:"column:ip_config;size:200"`    // cloud-init ipconfig0
+	CloudInit  string `json:"cloud_init" gorm:"column:cloud_init;type:text"` // 合并后的 cloud-init 配置（JSON）
+
+	VMID      uint32 `json:"vmid" gorm:"column:vmid;default:0"`
+	VmId      int64  `json:"vm_id" gorm:"column:vm_id;default:0"` // pve_vm 表 ID
+	IPAddress string `json:"ip_address" gorm:"column:ip_address;size:100"`
+
+	Status       string `json:"status" gorm:"column:status;size:50;not null;default:'pending'"`
+	ErrorMessage string `json:"error_message" gorm:"column:error_message;type:text"`
+
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VmStackMember) TableName() string {
+	return "vm_stack_member"
+}
+
+// VmStackMemberStatus 虚拟机创建状态常量
+const (
+	VmStackMemberStatusPending     = "pending"
+	VmStackMemberStatusCloning     = "cloning"
+	VmStackMemberStatusConfiguring = "configuring"
+	VmStackMemberStatusStarting    = "starting"
+	VmStackMemberStatusCompleted   = "completed"
+	VmStackMemberStatusFailed      = "failed"
+	VmStackMemberStatusSkipped     = "skipped" // 前序虚拟机失败，未创建
+)
@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+// VMStatusEvent 控制器同步时检测到的虚拟机状态变化（如 running -> stopped），按 (ClusterID, VMID) 关联虚拟机
+type VMStatusEvent struct {
+	Id         int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID  int64     `json:"cluster_id" gorm:"column:cluster_id;not null;index:idx_vm_status_event_vm"`
+	VMID       uint32    `json:"vmid" gorm:"column:vmid;not null;index:idx_vm_status_event_vm"`
+	VmName     string    `json:"vm_name" gorm:"column:vm_name;size:255"`
+	NodeName   string    `json:"node_name" gorm:"column:node_name;size:100"`
+	OldStatus  string    `json:"old_status" gorm:"column:old_status;size:20"`
+	NewStatus  string    `json:"new_status" gorm:"column:new_status;size:20"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime;index"`
+}
+
+// 同步时虚拟机出现在节点上或从节点上消失时记录的状态：
+// 新出现时 OldStatus 为空、NewStatus 为 added；消失（删除或迁移到其他节点）时 NewStatus 为 removed
+const (
+	VMStatusEventAdded   = "added"
+	VMStatusEventRemoved = "removed"
+)
+
+func (VMStatusEvent) TableName() string {
+	return "vm_status_event"
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// VMStorageMoveTask 虚拟机存储迁移任务：将虚拟机全部磁盘通过 Proxmox move_disk 移动到目标存储
+type VMStorageMoveTask struct {
+	Id            int64  `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID     int64  `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeID        int64  `json:"node_id" gorm:"column:node_id;not null"`
+	NodeName      string `json:"node_name" gorm:"column:node_name;size:100"`
+	VmId          int64  `json:"vm_id" gorm:"column:vm_id;not null;index"` // pve_vm 表 ID
+	VMID          uint32 `json:"vmid" gorm:"column:vmid;not null"`
+	VmName        string `json:"vm_name" gorm:"column:vm_name;size:100"`
+	TargetStorage string `json:"target_storage" gorm:"column:target_storage;size:100;not null"`
+	TargetFormat  string `json:"target_format" gorm:"column:target_format;size:20"`
+	DeleteSource  int8   `json:"delete_source" gorm:"column:delete_source;default:0"` // 移动完成后删除源卷，否则保留为 unusedN
+	Parallel      int8   `json:"parallel" gorm:"column:parallel;default:0"`           // 各磁盘并行移动，否则逐个移动
+	Bwlimit       int    `json:"bwlimit" gorm:"column:bwlimit;default:0"`             // KiB/s，0 表示不限速
+	Disks         string `json:"disks" gorm:"column:disks;type:text"`                 // 各磁盘进度（VMStorageMoveDisk 的 JSON 数组）
+
+	Status       string     `json:"status" gorm:"column:status;size:50;not null;default:'pending';index"`
+	TotalDisks   int        `json:"total_disks" gorm:"column:total_disks;default:0"`
+	MovedDisks   int        `json:"moved_disks" gorm:"column:moved_disks;default:0"`
+	StartTime    *time.Time `json:"start_time" gorm:"column:start_time"`
+	EndTime      *time.Time `json:"end_time" gorm:"column:end_time"`
+	ErrorMessage string     `json:"error_message" gorm:"column:error_message;type:text"`
+
+	Creator    string    `json:"creator" gorm:"column:creator;size:100"`
+	CreateTime time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMStorageMoveTask) TableName() string {
+	return "vm_storage_move_task"
+}
+
+// VMStorageMoveDisk 单个磁盘的移动进度
+type VMStorageMoveDisk struct {
+	Disk         string `json:"disk"`          // scsi0 / virtio1 / efidisk0 ...
+	SourceVolume string `json:"source_volume"` // local-lvm:vm-100-disk-0
+	Status       string `json:"status"`        // pending / running / completed / failed
+	UPID         string `json:"upid"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// VMStorageMoveStatus 存储迁移任务状态常量
+const (
+	VMStorageMoveStatusPending   = "pending"
+	VMStorageMoveStatusRunning   = "running"
+	VMStorageMoveStatusCompleted = "completed"
+	VMStorageMoveStatusFailed    = "failed"
+)
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// VMIDRange VMID 段，分配给团队或集群；ClusterID 为 0 表示适用于全部集群，Team 为空表示集群的默认段
+type VMIDRange struct {
+	Id          int64     `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID   int64     `json:"cluster_id" gorm:"column:cluster_id;not null;default:0;index"`
+	Team        string    `json:"team" gorm:"column:team;size:100;index"`
+	Start       uint32    `json:"start" gorm:"column:range_start;not null"`
+	End         uint32    `json:"end" gorm:"column:range_end;not null"`
+	Description string    `json:"description" gorm:"column:description;size:500"`
+	Creator     string    `json:"creator" gorm:"column:creator"`
+	Modifier    string    `json:"modifier" gorm:"column:modifier"`
+	CreateTime  time.Time `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime  time.Time `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMIDRange) TableName() string {
+	return "vmid_range"
+}
+
+// VMID 分配状态
+const (
+	VMIDAllocationStatusAllocated = "allocated" // 已分配给虚拟机（含创建中）
+	VMIDAllocationStatusReleased  = "released"  // 虚拟机已删除，隔离期满后可再次分配
+)
+
+// VMIDAllocation VMID 段内的分配记录，(ClusterID, VMID) 唯一
+type VMIDAllocation struct {
+	Id         int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID  int64      `json:"cluster_id" gorm:"column:cluster_id;not null;uniqueIndex:idx_vmid_allocation"`
+	VMID       uint32     `json:"vmid" gorm:"column:vmid;not null;uniqueIndex:idx_vmid_allocation"`
+	RangeID    int64      `json:"range_id" gorm:"column:range_id;index"`
+	Team       string     `json:"team" gorm:"column:team;size:100"`
+	Status     string     `json:"status" gorm:"column:status;size:20;not null;index"`
+	VmName     string     `json:"vm_name" gorm:"column:vm_name;size:255"`
+	ReleasedAt *time.Time `json:"released_at" gorm:"column:released_at"`
+	CreateTime time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime time.Time  `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (VMIDAllocation) TableName() string {
+	return "vmid_allocation"
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+const (
+	ZFSPoolAlertReasonHealth = "health" // 存储池状态不是 ONLINE
+	ZFSPoolAlertReasonErrors = "errors" // 磁盘出现读 / 写 / 校验错误
+	ZFSPoolAlertReasonScrub  = "scrub"  // 超过 node_zfs.scrub_max_age 未完成 scrub
+)
+
+// ZFSPoolAlert ZFS 存储池健康告警。每个节点上的存储池只保留一条未恢复的告警，
+// 原因变化时更新告警内容，恢复健康后记录恢复时间
+type ZFSPoolAlert struct {
+	Id             int64      `json:"id" gorm:"column:id;primaryKey;autoIncrement"`
+	ClusterID      int64      `json:"cluster_id" gorm:"column:cluster_id;not null;index"`
+	NodeID         int64      `json:"node_id" gorm:"column:node_id;not null;index"`
+	NodeName       string     `json:"node_name" gorm:"column:node_name;size:100"`
+	Pool           string     `json:"pool" gorm:"column:pool;size:100;index"`
+	Reasons        string     `json:"reasons" gorm:"column:reasons;size:50"`
+	Health         string     `json:"health" gorm:"column:health;size:20"`
+	ReadErrors     int64      `json:"read_errors" gorm:"column:read_errors"`
+	WriteErrors    int64      `json:"write_errors" gorm:"column:write_errors"`
+	ChecksumErrors int64      `json:"checksum_errors" gorm:"column:checksum_errors"`
+	LastScrubTime  *time.Time `json:"last_scrub_time" gorm:"column:last_scrub_time"`
+	Message        string     `json:"message" gorm:"column:message;type:text"`
+	ResolvedAt     *time.Time `json:"resolved_at" gorm:"column:resolved_at"`
+	CreateTime     time.Time  `json:"create_time" gorm:"column:gmt_create;autoCreateTime"`
+	UpdateTime     time.Time  `json:"update_time" gorm:"column:gmt_modified;autoUpdateTime"`
+}
+
+func (ZFSPoolAlert) TableName() string {
+	return "zfs_pool_alert"
+}
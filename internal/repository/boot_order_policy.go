@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type BootOrderPolicyRepository interface {
+	Create(ctx context.Context, policy *model.BootOrderPolicy) error
+	Update(ctx context.Context, policy *model.BootOrderPolicy) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.BootOrderPolicy, error)
+	GetByTag(ctx context.Context, clusterID int64, tag string) (*model.BootOrderPolicy, error)
+	// List clusterID 为 0 时返回全部集群的策略
+	List(ctx context.Context, clusterID int64) ([]*model.BootOrderPolicy, error)
+}
+
+func NewBootOrderPolicyRepository(r *Repository) BootOrderPolicyRepository {
+	return &bootOrderPolicyRepository{Repository: r}
+}
+
+type bootOrderPolicyRepository struct {
+	*Repository
+}
+
+func (r *bootOrderPolicyRepository) Create(ctx context.Context, policy *model.BootOrderPolicy) error {
+	return r.DB(ctx).Create(policy).Error
+}
+
+func (r *bootOrderPolicyRepository) Update(ctx context.Context, policy *model.BootOrderPolicy) error {
+	return r.DB(ctx).Save(policy).Error
+}
+
+func (r *bootOrderPolicyRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.BootOrderPolicy{}).Error
+}
+
+func (r *bootOrderPolicyRepository) GetByID(ctx context.Context, id int64) (*model.BootOrderPolicy, error) {
+	var policy model.BootOrderPolicy
+	if err := r.DB(ctx).Where("id = ?", id).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *bootOrderPolicyRepository) GetByTag(ctx context.Context, clusterID int64, tag string) (*model.BootOrderPolicy, error) {
+	var policy model.BootOrderPolicy
+	if err := r.DB(ctx).Where("cluster_id = ? AND tag = ?", clusterID, tag).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *bootOrderPolicyRepository) List(ctx context.Context, clusterID int64) ([]*model.BootOrderPolicy, error) {
+	var policies []*model.BootOrderPolicy
+	query := r.DB(ctx).Model(&model.BootOrderPolicy{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if err := query.Order("cluster_id ASC, start_order ASC, tag ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ChangeWindowRepository interface {
+	Create(ctx context.Context, window *model.ChangeWindow) error
+	Update(ctx context.Context, window *model.ChangeWindow) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.ChangeWindow, error)
+	ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, kind string) ([]*model.ChangeWindow, int64, error)
+	ListEnabledForScope(ctx context.Context, clusterID int64, appID string) ([]*model.ChangeWindow, error)
+	CreateOverrideLog(ctx context.Context, log *model.ChangeOverrideLog) error
+	ListOverrideLogs(ctx context.Context, page, pageSize int, clusterID int64) ([]*model.ChangeOverrideLog, int64, error)
+}
+
+func NewChangeWindowRepository(r *Repository) ChangeWindowRepository {
+	return &changeWindowRepository{Repository: r}
+}
+
+type changeWindowRepository struct {
+	*Repository
+}
+
+func (r *changeWindowRepository) Create(ctx context.Context, window *model.ChangeWindow) error {
+	return r.DB(ctx).Create(window).Error
+}
+
+func (r *changeWindowRepository) Update(ctx context.Context, window *model.ChangeWindow) error {
+	return r.DB(ctx).Save(window).Error
+}
+
+func (r *changeWindowRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.ChangeWindow{}).Error
+}
+
+func (r *changeWindowRepository) GetByID(ctx context.Context, id int64) (*model.ChangeWindow, error) {
+	var window model.ChangeWindow
+	if err := r.DB(ctx).Where("id = ?", id).First(&window).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &window, nil
+}
+
+func (r *changeWindowRepository) ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, kind string) ([]*model.ChangeWindow, int64, error) {
+	var windows []*model.ChangeWindow
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ChangeWindow{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id IN ?", []int64{0, clusterID})
+	}
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&windows).Error; err != nil {
+		return nil, 0, err
+	}
+	return windows, total, nil
+}
+
+// ListEnabledForScope 返回作用于指定集群和应用的已启用窗口（包括全局窗口）
+func (r *changeWindowRepository) ListEnabledForScope(ctx context.Context, clusterID int64, appID string) ([]*model.ChangeWindow, error) {
+	var windows []*model.ChangeWindow
+	err := r.DB(ctx).
+		Where("is_enabled = ?", 1).
+		Where("cluster_id IN ?", []int64{0, clusterID}).
+		Where("appid IN ?", []string{"", appID}).
+		Find(&windows).Error
+	if err != nil {
+		return nil, err
+	}
+	return windows, nil
+}
+
+func (r *changeWindowRepository) CreateOverrideLog(ctx context.Context, log *model.ChangeOverrideLog) error {
+	return r.DB(ctx).Create(log).Error
+}
+
+func (r *changeWindowRepository) ListOverrideLogs(ctx context.Context, page, pageSize int, clusterID int64) ([]*model.ChangeOverrideLog, int64, error) {
+	var logs []*model.ChangeOverrideLog
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ChangeOverrideLog{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
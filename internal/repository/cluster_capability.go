@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ClusterCapabilityRepository interface {
+	// Save 按 cluster_id 新增或覆盖
+	Save(ctx context.Context, capability *model.ClusterCapability) error
+	GetByClusterID(ctx context.Context, clusterID int64) (*model.ClusterCapability, error)
+	DeleteByClusterID(ctx context.Context, clusterID int64) error
+}
+
+func NewClusterCapabilityRepository(r *Repository) ClusterCapabilityRepository {
+	return &clusterCapabilityRepository{Repository: r}
+}
+
+type clusterCapabilityRepository struct {
+	*Repository
+}
+
+func (r *clusterCapabilityRepository) Save(ctx context.Context, capability *model.ClusterCapability) error {
+	if capability.Id == 0 {
+		existing, err := r.GetByClusterID(ctx, capability.ClusterID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			capability.Id = existing.Id
+			capability.CreateTime = existing.CreateTime
+		}
+	}
+	return r.DB(ctx).Save(capability).Error
+}
+
+func (r *clusterCapabilityRepository) GetByClusterID(ctx context.Context, clusterID int64) (*model.ClusterCapability, error) {
+	var capability model.ClusterCapability
+	if err := r.DB(ctx).Where("cluster_id = ?", clusterID).First(&capability).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &capability, nil
+}
+
+func (r *clusterCapabilityRepository) DeleteByClusterID(ctx context.Context, clusterID int64) error {
+	return r.DB(ctx).Where("cluster_id = ?", clusterID).Delete(&model.ClusterCapability{}).Error
+}
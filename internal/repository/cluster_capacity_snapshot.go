@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ClusterCapacitySnapshotRepository interface {
+	Create(ctx context.Context, snapshot *model.ClusterCapacitySnapshot) error
+	// GetLatestBefore 返回集群在 before 之前（含）最近的一次快照，没有时返回 nil
+	GetLatestBefore(ctx context.Context, clusterID int64, before time.Time) (*model.ClusterCapacitySnapshot, error)
+	// DeleteBefore 删除创建时间早于 before 的快照
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewClusterCapacitySnapshotRepository(r *Repository) ClusterCapacitySnapshotRepository {
+	return &clusterCapacitySnapshotRepository{Repository: r}
+}
+
+type clusterCapacitySnapshotRepository struct {
+	*Repository
+}
+
+func (r *clusterCapacitySnapshotRepository) Create(ctx context.Context, snapshot *model.ClusterCapacitySnapshot) error {
+	return r.DB(ctx).Create(snapshot).Error
+}
+
+func (r *clusterCapacitySnapshotRepository) GetLatestBefore(ctx context.Context, clusterID int64, before time.Time) (*model.ClusterCapacitySnapshot, error) {
+	var snapshot model.ClusterCapacitySnapshot
+	if err := r.DB(ctx).Where("cluster_id = ? AND gmt_create <= ?", clusterID, before).
+		Order("gmt_create DESC").First(&snapshot).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+func (r *clusterCapacitySnapshotRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("gmt_create < ?", before).Delete(&model.ClusterCapacitySnapshot{})
+	return result.RowsAffected, result.Error
+}
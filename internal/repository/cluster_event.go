@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/model"
+)
+
+// ClusterEventFilter 集群外部变更查询条件，零值字段不过滤
+type ClusterEventFilter struct {
+	ClusterID int64
+	VMID      uint32
+	Type      string
+	Since     *time.Time
+}
+
+type ClusterEventRepository interface {
+	// Create 记录事件，同一来源记录（cluster_id + source_key）已存在时返回 false
+	Create(ctx context.Context, event *model.ClusterEvent) (bool, error)
+	// ExistingKeys 返回 keys 中已记录的来源记录
+	ExistingKeys(ctx context.Context, clusterID int64, keys []string) (map[string]bool, error)
+	// List 按事件时间倒序分页查询
+	List(ctx context.Context, filter ClusterEventFilter, page, pageSize int) ([]*model.ClusterEvent, int64, error)
+	// DeleteBefore 删除事件时间早于 before 的记录
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewClusterEventRepository(r *Repository) ClusterEventRepository {
+	return &clusterEventRepository{Repository: r}
+}
+
+type clusterEventRepository struct {
+	*Repository
+}
+
+func (r *clusterEventRepository) Create(ctx context.Context, event *model.ClusterEvent) (bool, error) {
+	if err := r.DB(ctx).Create(event).Error; err != nil {
+		// 多个实例同时轮询时唯一索引冲突，事件已由其他实例记录
+		var count int64
+		if err := r.DB(ctx).Model(&model.ClusterEvent{}).Where("cluster_id = ? AND source_key = ?", event.ClusterID, event.SourceKey).
+			Count(&count).Error; err == nil && count > 0 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *clusterEventRepository) ExistingKeys(ctx context.Context, clusterID int64, keys []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(keys) == 0 {
+		return existing, nil
+	}
+	var found []string
+	if err := r.DB(ctx).Model(&model.ClusterEvent{}).Where("cluster_id = ? AND source_key IN ?", clusterID, keys).
+		Pluck("source_key", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, key := range found {
+		existing[key] = true
+	}
+	return existing, nil
+}
+
+func (r *clusterEventRepository) List(ctx context.Context, filter ClusterEventFilter, page, pageSize int) ([]*model.ClusterEvent, int64, error) {
+	query := r.DB(ctx).Model(&model.ClusterEvent{})
+	if filter.ClusterID > 0 {
+		query = query.Where("cluster_id = ?", filter.ClusterID)
+	}
+	if filter.VMID > 0 {
+		query = query.Where("vmid = ?", filter.VMID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.Since != nil {
+		query = query.Where("event_time >= ?", *filter.Since)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []*model.ClusterEvent
+	if err := query.Order("event_time DESC, id DESC").Offset((page - 1) * pageSize).Limit(pageSize).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+	return events, total, nil
+}
+
+func (r *clusterEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("event_time < ?", before).Delete(&model.ClusterEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+
+	"pvesphere/internal/model"
+)
+
+type ConfigAuditRepository interface {
+	BatchCreate(ctx context.Context, logs []*model.ConfigAuditLog) error
+	ListWithPagination(ctx context.Context, page, pageSize int, configKey string) ([]*model.ConfigAuditLog, int64, error)
+}
+
+func NewConfigAuditRepository(r *Repository) ConfigAuditRepository {
+	return &configAuditRepository{Repository: r}
+}
+
+type configAuditRepository struct {
+	*Repository
+}
+
+func (r *configAuditRepository) BatchCreate(ctx context.Context, logs []*model.ConfigAuditLog) error {
+	if len(logs) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Create(&logs).Error
+}
+
+func (r *configAuditRepository) ListWithPagination(ctx context.Context, page, pageSize int, configKey string) ([]*model.ConfigAuditLog, int64, error) {
+	var logs []*model.ConfigAuditLog
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ConfigAuditLog{})
+	if configKey != "" {
+		query = query.Where("config_key = ?", configKey)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("id DESC").Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
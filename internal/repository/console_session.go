@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ConsoleSessionRepository interface {
+	Create(ctx context.Context, session *model.ConsoleSession) error
+	Update(ctx context.Context, session *model.ConsoleSession) error
+	GetByID(ctx context.Context, id int64) (*model.ConsoleSession, error)
+	List(ctx context.Context, page, pageSize int, filter ConsoleSessionFilter) ([]*model.ConsoleSession, int64, error)
+	// ListExpiredRecordings 列出已上传且超过保留期的录制
+	ListExpiredRecordings(ctx context.Context, before time.Time, limit int) ([]*model.ConsoleSession, error)
+}
+
+// ConsoleSessionFilter 控制台会话审计查询条件，零值字段不参与过滤
+type ConsoleSessionFilter struct {
+	UserID          string
+	TargetType      string
+	TargetID        int64
+	ClusterID       int64
+	RecordingStatus string
+	StartFrom       *time.Time
+	StartTo         *time.Time
+}
+
+func NewConsoleSessionRepository(r *Repository) ConsoleSessionRepository {
+	return &consoleSessionRepository{Repository: r}
+}
+
+type consoleSessionRepository struct {
+	*Repository
+}
+
+func (r *consoleSessionRepository) Create(ctx context.Context, session *model.ConsoleSession) error {
+	return r.DB(ctx).Create(session).Error
+}
+
+func (r *consoleSessionRepository) Update(ctx context.Context, session *model.ConsoleSession) error {
+	return r.DB(ctx).Save(session).Error
+}
+
+func (r *consoleSessionRepository) GetByID(ctx context.Context, id int64) (*model.ConsoleSession, error) {
+	var session model.ConsoleSession
+	if err := r.DB(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *consoleSessionRepository) List(ctx context.Context, page, pageSize int, filter ConsoleSessionFilter) ([]*model.ConsoleSession, int64, error) {
+	var sessions []*model.ConsoleSession
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ConsoleSession{})
+	if filter.UserID != "" {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID > 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.ClusterID > 0 {
+		query = query.Where("cluster_id = ?", filter.ClusterID)
+	}
+	if filter.RecordingStatus != "" {
+		query = query.Where("recording_status = ?", filter.RecordingStatus)
+	}
+	if filter.StartFrom != nil {
+		query = query.Where("start_time >= ?", *filter.StartFrom)
+	}
+	if filter.StartTo != nil {
+		query = query.Where("start_time < ?", *filter.StartTo)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&sessions).Error; err != nil {
+		return nil, 0, err
+	}
+	return sessions, total, nil
+}
+
+func (r *consoleSessionRepository) ListExpiredRecordings(ctx context.Context, before time.Time, limit int) ([]*model.ConsoleSession, error) {
+	var sessions []*model.ConsoleSession
+	err := r.DB(ctx).
+		Where("recording_status = ? AND recording_expire_at IS NOT NULL AND recording_expire_at < ?", model.ConsoleRecordingStatusUploaded, before).
+		Order("id").Limit(limit).Find(&sessions).Error
+	return sessions, err
+}
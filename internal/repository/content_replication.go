@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ContentReplicationRepository interface {
+	CreatePolicy(ctx context.Context, policy *model.ContentReplicationPolicy) error
+	UpdatePolicy(ctx context.Context, policy *model.ContentReplicationPolicy) error
+	DeletePolicy(ctx context.Context, id int64) error
+	GetPolicyByID(ctx context.Context, id int64) (*model.ContentReplicationPolicy, error)
+	GetPolicyByName(ctx context.Context, name string) (*model.ContentReplicationPolicy, error)
+	// ListPolicies clusterID 为 0 时返回全部集群，enabledOnly 为 true 时只返回启用的策略
+	ListPolicies(ctx context.Context, clusterID int64, enabledOnly bool) ([]*model.ContentReplicationPolicy, error)
+
+	CreateCopy(ctx context.Context, c *model.ContentReplicationCopy) error
+	UpdateCopy(ctx context.Context, c *model.ContentReplicationCopy) error
+	GetCopyByID(ctx context.Context, id int64) (*model.ContentReplicationCopy, error)
+	// ClaimCopy 把 pending 状态的复制任务改为 copying，多实例部署时只有一个实例能领取成功
+	ClaimCopy(ctx context.Context, id int64) (bool, error)
+	// ListActiveCopies 策略下等待中和复制中的任务
+	ListActiveCopies(ctx context.Context, policyID int64) ([]*model.ContentReplicationCopy, error)
+	ListCopies(ctx context.Context, page, pageSize int, policyID int64, status string) ([]*model.ContentReplicationCopy, int64, error)
+}
+
+func NewContentReplicationRepository(r *Repository) ContentReplicationRepository {
+	return &contentReplicationRepository{Repository: r}
+}
+
+type contentReplicationRepository struct {
+	*Repository
+}
+
+func (r *contentReplicationRepository) CreatePolicy(ctx context.Context, policy *model.ContentReplicationPolicy) error {
+	return r.DB(ctx).Create(policy).Error
+}
+
+func (r *contentReplicationRepository) UpdatePolicy(ctx context.Context, policy *model.ContentReplicationPolicy) error {
+	return r.DB(ctx).Save(policy).Error
+}
+
+func (r *contentReplicationRepository) DeletePolicy(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.ContentReplicationPolicy{}).Error
+}
+
+func (r *contentReplicationRepository) GetPolicyByID(ctx context.Context, id int64) (*model.ContentReplicationPolicy, error) {
+	var policy model.ContentReplicationPolicy
+	if err := r.DB(ctx).Where("id = ?", id).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *contentReplicationRepository) GetPolicyByName(ctx context.Context, name string) (*model.ContentReplicationPolicy, error) {
+	var policy model.ContentReplicationPolicy
+	if err := r.DB(ctx).Where("name = ?", name).First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (r *contentReplicationRepository) ListPolicies(ctx context.Context, clusterID int64, enabledOnly bool) ([]*model.ContentReplicationPolicy, error) {
+	var policies []*model.ContentReplicationPolicy
+	query := r.DB(ctx).Model(&model.ContentReplicationPolicy{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if enabledOnly {
+		query = query.Where("enabled = ?", 1)
+	}
+	if err := query.Order("id ASC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (r *contentReplicationRepository) CreateCopy(ctx context.Context, c *model.ContentReplicationCopy) error {
+	return r.DB(ctx).Create(c).Error
+}
+
+func (r *contentReplicationRepository) UpdateCopy(ctx context.Context, c *model.ContentReplicationCopy) error {
+	return r.DB(ctx).Save(c).Error
+}
+
+func (r *contentReplicationRepository) GetCopyByID(ctx context.Context, id int64) (*model.ContentReplicationCopy, error) {
+	var c model.ContentReplicationCopy
+	if err := r.DB(ctx).Where("id = ?", id).First(&c).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *contentReplicationRepository) ClaimCopy(ctx context.Context, id int64) (bool, error) {
+	result := r.DB(ctx).Model(&model.ContentReplicationCopy{}).
+		Where("id = ? AND status = ?", id, model.ContentReplicationCopyPending).
+		Update("status", model.ContentReplicationCopyCopying)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *contentReplicationRepository) ListActiveCopies(ctx context.Context, policyID int64) ([]*model.ContentReplicationCopy, error) {
+	var copies []*model.ContentReplicationCopy
+	err := r.DB(ctx).Where("policy_id = ? AND status IN ?", policyID,
+		[]string{model.ContentReplicationCopyPending, model.ContentReplicationCopyCopying}).
+		Order("id ASC").Find(&copies).Error
+	return copies, err
+}
+
+func (r *contentReplicationRepository) ListCopies(ctx context.Context, page, pageSize int, policyID int64, status string) ([]*model.ContentReplicationCopy, int64, error) {
+	var copies []*model.ContentReplicationCopy
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ContentReplicationCopy{})
+	if policyID > 0 {
+		query = query.Where("policy_id = ?", policyID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&copies).Error; err != nil {
+		return nil, 0, err
+	}
+	return copies, total, nil
+}
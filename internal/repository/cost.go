@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type CostRepository interface {
+	CreatePrice(ctx context.Context, price *model.CostPriceModel) error
+	UpdatePrice(ctx context.Context, price *model.CostPriceModel) error
+	DeletePrice(ctx context.Context, id int64) error
+	GetPriceByID(ctx context.Context, id int64) (*model.CostPriceModel, error)
+	GetPriceByClusterID(ctx context.Context, clusterID int64) (*model.CostPriceModel, error)
+	ListPrices(ctx context.Context) ([]*model.CostPriceModel, error)
+
+	AddUsage(ctx context.Context, usage *model.CostUsageDaily) error // 按 (日期, 集群, 应用, 成本中心) 累加用量
+	ListUsage(ctx context.Context, startDate, endDate string, clusterID int64, appID, costCenter string) ([]*model.CostUsageDaily, error)
+
+	CreateBudget(ctx context.Context, budget *model.CostBudget) error
+	UpdateBudget(ctx context.Context, budget *model.CostBudget) error
+	DeleteBudget(ctx context.Context, id int64) error
+	GetBudgetByID(ctx context.Context, id int64) (*model.CostBudget, error)
+	GetBudgetByAppId(ctx context.Context, appID string) (*model.CostBudget, error)
+	ListBudgets(ctx context.Context) ([]*model.CostBudget, error)
+
+	CreateAlert(ctx context.Context, alert *model.CostBudgetAlert) error
+	GetAlert(ctx context.Context, budgetID int64, month, level string) (*model.CostBudgetAlert, error)
+	ListAlerts(ctx context.Context, page, pageSize int, month, appID string) ([]*model.CostBudgetAlert, int64, error)
+}
+
+func NewCostRepository(r *Repository) CostRepository {
+	return &costRepository{Repository: r}
+}
+
+type costRepository struct {
+	*Repository
+}
+
+func (r *costRepository) CreatePrice(ctx context.Context, price *model.CostPriceModel) error {
+	return r.DB(ctx).Create(price).Error
+}
+
+func (r *costRepository) UpdatePrice(ctx context.Context, price *model.CostPriceModel) error {
+	return r.DB(ctx).Save(price).Error
+}
+
+func (r *costRepository) DeletePrice(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.CostPriceModel{}).Error
+}
+
+func (r *costRepository) GetPriceByID(ctx context.Context, id int64) (*model.CostPriceModel, error) {
+	var price model.CostPriceModel
+	if err := r.DB(ctx).Where("id = ?", id).First(&price).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (r *costRepository) GetPriceByClusterID(ctx context.Context, clusterID int64) (*model.CostPriceModel, error) {
+	var price model.CostPriceModel
+	if err := r.DB(ctx).Where("cluster_id = ?", clusterID).First(&price).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (r *costRepository) ListPrices(ctx context.Context) ([]*model.CostPriceModel, error) {
+	var prices []*model.CostPriceModel
+	if err := r.DB(ctx).Order("cluster_id ASC").Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+func (r *costRepository) AddUsage(ctx context.Context, usage *model.CostUsageDaily) error {
+	var existing model.CostUsageDaily
+	err := r.DB(ctx).
+		Where("usage_date = ? AND cluster_id = ? AND appid = ? AND cost_center = ?", usage.UsageDate, usage.ClusterID, usage.AppId, usage.CostCenter).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.DB(ctx).Create(usage).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	// 在数据库侧累加，避免覆盖并发写入
+	return r.DB(ctx).
+		Model(&model.CostUsageDaily{}).
+		Where("id = ?", existing.Id).
+		Updates(map[string]interface{}{
+			"vcpu_hours":       gorm.Expr("vcpu_hours + ?", usage.VcpuHours),
+			"ram_gb_hours":     gorm.Expr("ram_gb_hours + ?", usage.RamGbHours),
+			"storage_gb_hours": gorm.Expr("storage_gb_hours + ?", usage.StorageGbHours),
+		}).Error
+}
+
+// ListUsage 查询日期区间 [startDate, endDate] 内的用量，clusterID 为 0、appID / costCenter 为空时不过滤
+func (r *costRepository) ListUsage(ctx context.Context, startDate, endDate string, clusterID int64, appID, costCenter string) ([]*model.CostUsageDaily, error) {
+	var usages []*model.CostUsageDaily
+	query := r.DB(ctx).Where("usage_date >= ? AND usage_date <= ?", startDate, endDate)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if appID != "" {
+		query = query.Where("appid = ?", appID)
+	}
+	if costCenter != "" {
+		query = query.Where("cost_center = ?", costCenter)
+	}
+	if err := query.Find(&usages).Error; err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+func (r *costRepository) CreateBudget(ctx context.Context, budget *model.CostBudget) error {
+	return r.DB(ctx).Create(budget).Error
+}
+
+func (r *costRepository) UpdateBudget(ctx context.Context, budget *model.CostBudget) error {
+	return r.DB(ctx).Save(budget).Error
+}
+
+func (r *costRepository) DeleteBudget(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.CostBudget{}).Error
+}
+
+func (r *costRepository) GetBudgetByID(ctx context.Context, id int64) (*model.CostBudget, error) {
+	var budget model.CostBudget
+	if err := r.DB(ctx).Where("id = ?", id).First(&budget).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *costRepository) GetBudgetByAppId(ctx context.Context, appID string) (*model.CostBudget, error) {
+	var budget model.CostBudget
+	if err := r.DB(ctx).Where("appid = ?", appID).First(&budget).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *costRepository) ListBudgets(ctx context.Context) ([]*model.CostBudget, error) {
+	var budgets []*model.CostBudget
+	if err := r.DB(ctx).Order("appid ASC").Find(&budgets).Error; err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+func (r *costRepository) CreateAlert(ctx context.Context, alert *model.CostBudgetAlert) error {
+	return r.DB(ctx).Create(alert).Error
+}
+
+func (r *costRepository) GetAlert(ctx context.Context, budgetID int64, month, level string) (*model.CostBudgetAlert, error) {
+	var alert model.CostBudgetAlert
+	err := r.DB(ctx).Where("budget_id = ? AND month = ? AND level = ?", budgetID, month, level).First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *costRepository) ListAlerts(ctx context.Context, page, pageSize int, month, appID string) ([]*model.CostBudgetAlert, int64, error) {
+	var alerts []*model.CostBudgetAlert
+	var total int64
+
+	query := r.DB(ctx).Model(&model.CostBudgetAlert{})
+	if month != "" {
+		query = query.Where("month = ?", month)
+	}
+	if appID != "" {
+		query = query.Where("appid = ?", appID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+	return alerts, total, nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type DashboardLayoutRepository interface {
+	GetByUser(ctx context.Context, userID string) (*model.DashboardLayout, error)
+	Save(ctx context.Context, layout *model.DashboardLayout) error
+	DeleteByUser(ctx context.Context, userID string) error
+}
+
+func NewDashboardLayoutRepository(r *Repository) DashboardLayoutRepository {
+	return &dashboardLayoutRepository{Repository: r}
+}
+
+type dashboardLayoutRepository struct {
+	*Repository
+}
+
+func (r *dashboardLayoutRepository) GetByUser(ctx context.Context, userID string) (*model.DashboardLayout, error) {
+	var layout model.DashboardLayout
+	if err := r.DB(ctx).Where("user_id = ?", userID).First(&layout).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &layout, nil
+}
+
+func (r *dashboardLayoutRepository) Save(ctx context.Context, layout *model.DashboardLayout) error {
+	return r.DB(ctx).Save(layout).Error
+}
+
+func (r *dashboardLayoutRepository) DeleteByUser(ctx context.Context, userID string) error {
+	return r.DB(ctx).Where("user_id = ?", userID).Delete(&model.DashboardLayout{}).Error
+}
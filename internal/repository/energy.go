@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type EnergyRepository interface {
+	// AddNodeEnergy 按 (日期, 节点) 累加能耗并更新峰值功率
+	AddNodeEnergy(ctx context.Context, usage *model.EnergyNodeDaily) error
+	// AddVMEnergy 按 (日期, 虚拟机) 累加估算能耗
+	AddVMEnergy(ctx context.Context, usage *model.EnergyVMDaily) error
+	// ListNodeEnergy 查询日期区间 [startDate, endDate] 内的节点能耗，clusterIDs 为 nil、nodeID 为 0 时不过滤
+	ListNodeEnergy(ctx context.Context, startDate, endDate string, clusterIDs []int64, nodeID int64) ([]*model.EnergyNodeDaily, error)
+	// ListVMEnergy 查询日期区间 [startDate, endDate] 内的虚拟机能耗，clusterIDs 为 nil、nodeID 为 0、appID 为空时不过滤
+	ListVMEnergy(ctx context.Context, startDate, endDate string, clusterIDs []int64, nodeID int64, appID string) ([]*model.EnergyVMDaily, error)
+}
+
+func NewEnergyRepository(r *Repository) EnergyRepository {
+	return &energyRepository{Repository: r}
+}
+
+type energyRepository struct {
+	*Repository
+}
+
+func (r *energyRepository) AddNodeEnergy(ctx context.Context, usage *model.EnergyNodeDaily) error {
+	var existing model.EnergyNodeDaily
+	err := r.DB(ctx).
+		Where("usage_date = ? AND node_id = ?", usage.UsageDate, usage.NodeID).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.DB(ctx).Create(usage).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	// 在数据库侧累加，避免覆盖并发写入
+	return r.DB(ctx).
+		Model(&model.EnergyNodeDaily{}).
+		Where("id = ?", existing.Id).
+		Updates(map[string]interface{}{
+			"energy_wh": gorm.Expr("energy_wh + ?", usage.EnergyWh),
+			"samples":   gorm.Expr("samples + ?", usage.Samples),
+			"max_watts": gorm.Expr("CASE WHEN max_watts < ? THEN ? ELSE max_watts END", usage.MaxWatts, usage.MaxWatts),
+		}).Error
+}
+
+func (r *energyRepository) AddVMEnergy(ctx context.Context, usage *model.EnergyVMDaily) error {
+	var existing model.EnergyVMDaily
+	err := r.DB(ctx).
+		Where("usage_date = ? AND vm_id = ?", usage.UsageDate, usage.VmId).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.DB(ctx).Create(usage).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	// 虚拟机可能在当天迁移或变更归属应用，记录最新的节点、应用和成本中心
+	return r.DB(ctx).
+		Model(&model.EnergyVMDaily{}).
+		Where("id = ?", existing.Id).
+		Updates(map[string]interface{}{
+			"energy_wh":   gorm.Expr("energy_wh + ?", usage.EnergyWh),
+			"vm_name":     usage.VmName,
+			"node_id":     usage.NodeID,
+			"cluster_id":  usage.ClusterID,
+			"appid":       usage.AppId,
+			"cost_center": usage.CostCenter,
+		}).Error
+}
+
+func (r *energyRepository) ListNodeEnergy(ctx context.Context, startDate, endDate string, clusterIDs []int64, nodeID int64) ([]*model.EnergyNodeDaily, error) {
+	var list []*model.EnergyNodeDaily
+	query := r.DB(ctx).Where("usage_date >= ? AND usage_date <= ?", startDate, endDate)
+	if clusterIDs != nil {
+		query = query.Where("cluster_id IN ?", clusterIDs)
+	}
+	if nodeID > 0 {
+		query = query.Where("node_id = ?", nodeID)
+	}
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *energyRepository) ListVMEnergy(ctx context.Context, startDate, endDate string, clusterIDs []int64, nodeID int64, appID string) ([]*model.EnergyVMDaily, error) {
+	var list []*model.EnergyVMDaily
+	query := r.DB(ctx).Where("usage_date >= ? AND usage_date <= ?", startDate, endDate)
+	if clusterIDs != nil {
+		query = query.Where("cluster_id IN ?", clusterIDs)
+	}
+	if nodeID > 0 {
+		query = query.Where("node_id = ?", nodeID)
+	}
+	if appID != "" {
+		query = query.Where("appid = ?", appID)
+	}
+	if err := query.Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type FirstBootHookRepository interface {
+	// Save 创建或更新模板的钩子
+	Save(ctx context.Context, hook *model.FirstBootHook) error
+	GetByTemplateID(ctx context.Context, templateID int64) (*model.FirstBootHook, error)
+	DeleteByTemplateID(ctx context.Context, templateID int64) error
+
+	CreateRun(ctx context.Context, run *model.FirstBootRun) error
+	UpdateRun(ctx context.Context, run *model.FirstBootRun) error
+	GetRunByID(ctx context.Context, id int64) (*model.FirstBootRun, error)
+	// GetRunByVM 虚拟机的执行记录，每台虚拟机只执行一次
+	GetRunByVM(ctx context.Context, vmID int64) (*model.FirstBootRun, error)
+	ListRuns(ctx context.Context, page, pageSize int, clusterID, templateID int64, status string) ([]*model.FirstBootRun, int64, error)
+	// ListDueRuns 列出等待 agent 上线和到达重试时间的记录
+	ListDueRuns(ctx context.Context, now time.Time, limit int) ([]*model.FirstBootRun, error)
+}
+
+func NewFirstBootHookRepository(r *Repository) FirstBootHookRepository {
+	return &firstBootHookRepository{Repository: r}
+}
+
+type firstBootHookRepository struct {
+	*Repository
+}
+
+func (r *firstBootHookRepository) Save(ctx context.Context, hook *model.FirstBootHook) error {
+	return r.DB(ctx).Save(hook).Error
+}
+
+func (r *firstBootHookRepository) GetByTemplateID(ctx context.Context, templateID int64) (*model.FirstBootHook, error) {
+	var hook model.FirstBootHook
+	if err := r.DB(ctx).Where("template_id = ?", templateID).First(&hook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func (r *firstBootHookRepository) DeleteByTemplateID(ctx context.Context, templateID int64) error {
+	return r.DB(ctx).Where("template_id = ?", templateID).Delete(&model.FirstBootHook{}).Error
+}
+
+func (r *firstBootHookRepository) CreateRun(ctx context.Context, run *model.FirstBootRun) error {
+	return r.DB(ctx).Create(run).Error
+}
+
+func (r *firstBootHookRepository) UpdateRun(ctx context.Context, run *model.FirstBootRun) error {
+	return r.DB(ctx).Save(run).Error
+}
+
+func (r *firstBootHookRepository) GetRunByID(ctx context.Context, id int64) (*model.FirstBootRun, error) {
+	var run model.FirstBootRun
+	if err := r.DB(ctx).Where("id = ?", id).First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *firstBootHookRepository) GetRunByVM(ctx context.Context, vmID int64) (*model.FirstBootRun, error) {
+	var run model.FirstBootRun
+	if err := r.DB(ctx).Where("vm_id = ?", vmID).Order("id DESC").First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *firstBootHookRepository) ListRuns(ctx context.Context, page, pageSize int, clusterID, templateID int64, status string) ([]*model.FirstBootRun, int64, error) {
+	var runs []*model.FirstBootRun
+	var total int64
+
+	query := r.DB(ctx).Model(&model.FirstBootRun{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if templateID > 0 {
+		query = query.Where("template_id = ?", templateID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+func (r *firstBootHookRepository) ListDueRuns(ctx context.Context, now time.Time, limit int) ([]*model.FirstBootRun, error) {
+	var runs []*model.FirstBootRun
+	err := r.DB(ctx).
+		Where("status = ? OR (status = ? AND (next_attempt_time IS NULL OR next_attempt_time <= ?))",
+			model.FirstBootRunStatusWaiting, model.FirstBootRunStatusRetrying, now).
+		Order("id ASC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
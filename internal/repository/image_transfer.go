@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ImageTransferRepository interface {
+	CreateStore(ctx context.Context, store *model.ObjectStore) error
+	DeleteStore(ctx context.Context, id int64) error
+	GetStoreByID(ctx context.Context, id int64) (*model.ObjectStore, error)
+	GetStoreByName(ctx context.Context, name string) (*model.ObjectStore, error)
+	ListStores(ctx context.Context) ([]*model.ObjectStore, error)
+
+	CreateTask(ctx context.Context, task *model.ImageTransferTask) error
+	UpdateTask(ctx context.Context, task *model.ImageTransferTask) error
+	GetTaskByID(ctx context.Context, id int64) (*model.ImageTransferTask, error)
+	ListTasks(ctx context.Context, page, pageSize int, direction string, clusterID int64, status string) ([]*model.ImageTransferTask, int64, error)
+	CountActiveTasksByStore(ctx context.Context, storeID int64) (int64, error)
+}
+
+func NewImageTransferRepository(r *Repository) ImageTransferRepository {
+	return &imageTransferRepository{Repository: r}
+}
+
+type imageTransferRepository struct {
+	*Repository
+}
+
+func (r *imageTransferRepository) CreateStore(ctx context.Context, store *model.ObjectStore) error {
+	return r.DB(ctx).Create(store).Error
+}
+
+func (r *imageTransferRepository) DeleteStore(ctx context.Context, id int64) error {
+	return r.DB(ctx).Delete(&model.ObjectStore{}, id).Error
+}
+
+func (r *imageTransferRepository) GetStoreByID(ctx context.Context, id int64) (*model.ObjectStore, error) {
+	var store model.ObjectStore
+	if err := r.DB(ctx).Where("id = ?", id).First(&store).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (r *imageTransferRepository) GetStoreByName(ctx context.Context, name string) (*model.ObjectStore, error) {
+	var store model.ObjectStore
+	if err := r.DB(ctx).Where("name = ?", name).First(&store).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &store, nil
+}
+
+func (r *imageTransferRepository) ListStores(ctx context.Context) ([]*model.ObjectStore, error) {
+	var stores []*model.ObjectStore
+	if err := r.DB(ctx).Order("id ASC").Find(&stores).Error; err != nil {
+		return nil, err
+	}
+	return stores, nil
+}
+
+func (r *imageTransferRepository) CreateTask(ctx context.Context, task *model.ImageTransferTask) error {
+	return r.DB(ctx).Create(task).Error
+}
+
+func (r *imageTransferRepository) UpdateTask(ctx context.Context, task *model.ImageTransferTask) error {
+	return r.DB(ctx).Save(task).Error
+}
+
+func (r *imageTransferRepository) GetTaskByID(ctx context.Context, id int64) (*model.ImageTransferTask, error) {
+	var task model.ImageTransferTask
+	if err := r.DB(ctx).Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *imageTransferRepository) ListTasks(ctx context.Context, page, pageSize int, direction string, clusterID int64, status string) ([]*model.ImageTransferTask, int64, error) {
+	var tasks []*model.ImageTransferTask
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ImageTransferTask{})
+	if direction != "" {
+		query = query.Where("direction = ?", direction)
+	}
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+func (r *imageTransferRepository) CountActiveTasksByStore(ctx context.Context, storeID int64) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.ImageTransferTask{}).
+		Where("store_id = ? AND status IN ?", storeID, []string{model.ImageTransferStatusPending, model.ImageTransferStatusRunning}).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/model"
+)
+
+type JobLeaseRepository interface {
+	// Acquire 获取任务租约：租约不存在、已属于 owner 或已过期时将其设为 owner 所有并返回 true，否则返回 false
+	Acquire(ctx context.Context, jobType string, jobID int64, owner string, expiresAt time.Time) (bool, error)
+	// Renew 顺延 owner 持有的全部租约，返回续约数量
+	Renew(ctx context.Context, owner string, expiresAt time.Time) (int64, error)
+	// Release 释放 owner 持有的租约
+	Release(ctx context.Context, jobType string, jobID int64, owner string) error
+	// ListExpired 查询某类任务在 now 之前过期的租约
+	ListExpired(ctx context.Context, jobType string, now time.Time) ([]*model.JobLease, error)
+}
+
+func NewJobLeaseRepository(r *Repository) JobLeaseRepository {
+	return &jobLeaseRepository{Repository: r}
+}
+
+type jobLeaseRepository struct {
+	*Repository
+}
+
+func (r *jobLeaseRepository) Acquire(ctx context.Context, jobType string, jobID int64, owner string, expiresAt time.Time) (bool, error) {
+	// 先尝试接管已有的租约（同一条件更新保证并发接管时只有一个实例成功）
+	result := r.DB(ctx).Model(&model.JobLease{}).
+		Where("job_type = ? AND job_id = ? AND (owner = ? OR expires_at < ?)", jobType, jobID, owner, time.Now()).
+		Updates(map[string]interface{}{"owner": owner, "expires_at": expiresAt})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	var count int64
+	if err := r.DB(ctx).Model(&model.JobLease{}).Where("job_type = ? AND job_id = ?", jobType, jobID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	if err := r.DB(ctx).Create(&model.JobLease{JobType: jobType, JobID: jobID, Owner: owner, ExpiresAt: expiresAt}).Error; err != nil {
+		// 并发创建时唯一索引冲突，租约已被其他实例获取
+		if err := r.DB(ctx).Model(&model.JobLease{}).Where("job_type = ? AND job_id = ?", jobType, jobID).Count(&count).Error; err == nil && count > 0 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *jobLeaseRepository) Renew(ctx context.Context, owner string, expiresAt time.Time) (int64, error) {
+	result := r.DB(ctx).Model(&model.JobLease{}).Where("owner = ?", owner).Update("expires_at", expiresAt)
+	return result.RowsAffected, result.Error
+}
+
+func (r *jobLeaseRepository) Release(ctx context.Context, jobType string, jobID int64, owner string) error {
+	return r.DB(ctx).Where("job_type = ? AND job_id = ? AND owner = ?", jobType, jobID, owner).Delete(&model.JobLease{}).Error
+}
+
+func (r *jobLeaseRepository) ListExpired(ctx context.Context, jobType string, now time.Time) ([]*model.JobLease, error) {
+	var leases []*model.JobLease
+	err := r.DB(ctx).Where("job_type = ? AND expires_at < ?", jobType, now).Order("id").Find(&leases).Error
+	return leases, err
+}
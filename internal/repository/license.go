@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type LicenseRepository interface {
+	CreateLicense(ctx context.Context, license *model.License) error
+	UpdateLicense(ctx context.Context, license *model.License) error
+	// DeleteLicense 删除许可证及其分配记录
+	DeleteLicense(ctx context.Context, id int64) error
+	GetLicenseByID(ctx context.Context, id int64) (*model.License, error)
+	GetLicenseByName(ctx context.Context, name string) (*model.License, error)
+	ListLicenses(ctx context.Context, osType string) ([]*model.License, error)
+
+	CreateAssignment(ctx context.Context, assignment *model.LicenseAssignment) error
+	DeleteAssignment(ctx context.Context, id int64) error
+	GetAssignmentByID(ctx context.Context, id int64) (*model.LicenseAssignment, error)
+	GetAssignment(ctx context.Context, licenseID int64, targetType string, targetID int64) (*model.LicenseAssignment, error)
+	ListAssignments(ctx context.Context) ([]*model.LicenseAssignment, error)
+
+	SaveOSInfo(ctx context.Context, info *model.VMOSInfo) error
+	GetOSInfoByVmID(ctx context.Context, vmID int64) (*model.VMOSInfo, error)
+	ListOSInfo(ctx context.Context) ([]*model.VMOSInfo, error)
+	ListOSInfoByVmIDs(ctx context.Context, vmIDs []int64) (map[int64]*model.VMOSInfo, error) // 按虚拟机 ID 批量查询，未采集的不在结果中
+
+	SaveAlert(ctx context.Context, alert *model.LicenseAlert) error
+	// GetOpenAlert 获取未恢复的告警
+	GetOpenAlert(ctx context.Context, licenseID int64, osType string) (*model.LicenseAlert, error)
+	ListOpenAlerts(ctx context.Context) ([]*model.LicenseAlert, error)
+	ListAlerts(ctx context.Context, page, pageSize int, licenseID int64, open bool) ([]*model.LicenseAlert, int64, error)
+}
+
+func NewLicenseRepository(r *Repository) LicenseRepository {
+	return &licenseRepository{Repository: r}
+}
+
+type licenseRepository struct {
+	*Repository
+}
+
+func (r *licenseRepository) CreateLicense(ctx context.Context, license *model.License) error {
+	return r.DB(ctx).Create(license).Error
+}
+
+func (r *licenseRepository) UpdateLicense(ctx context.Context, license *model.License) error {
+	return r.DB(ctx).Save(license).Error
+}
+
+func (r *licenseRepository) DeleteLicense(ctx context.Context, id int64) error {
+	return r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("license_id = ?", id).Delete(&model.LicenseAssignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.License{}, id).Error
+	})
+}
+
+func (r *licenseRepository) GetLicenseByID(ctx context.Context, id int64) (*model.License, error) {
+	var license model.License
+	if err := r.DB(ctx).Where("id = ?", id).First(&license).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &license, nil
+}
+
+func (r *licenseRepository) GetLicenseByName(ctx context.Context, name string) (*model.License, error) {
+	var license model.License
+	if err := r.DB(ctx).Where("name = ?", name).First(&license).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &license, nil
+}
+
+func (r *licenseRepository) ListLicenses(ctx context.Context, osType string) ([]*model.License, error) {
+	var licenses []*model.License
+	query := r.DB(ctx).Model(&model.License{})
+	if osType != "" {
+		query = query.Where("os_type = ?", osType)
+	}
+	if err := query.Order("os_type ASC, name ASC").Find(&licenses).Error; err != nil {
+		return nil, err
+	}
+	return licenses, nil
+}
+
+func (r *licenseRepository) CreateAssignment(ctx context.Context, assignment *model.LicenseAssignment) error {
+	return r.DB(ctx).Create(assignment).Error
+}
+
+func (r *licenseRepository) DeleteAssignment(ctx context.Context, id int64) error {
+	return r.DB(ctx).Delete(&model.LicenseAssignment{}, id).Error
+}
+
+func (r *licenseRepository) GetAssignmentByID(ctx context.Context, id int64) (*model.LicenseAssignment, error) {
+	var assignment model.LicenseAssignment
+	if err := r.DB(ctx).Where("id = ?", id).First(&assignment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *licenseRepository) GetAssignment(ctx context.Context, licenseID int64, targetType string, targetID int64) (*model.LicenseAssignment, error) {
+	var assignment model.LicenseAssignment
+	err := r.DB(ctx).Where("license_id = ? AND target_type = ? AND target_id = ?", licenseID, targetType, targetID).First(&assignment).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+func (r *licenseRepository) ListAssignments(ctx context.Context) ([]*model.LicenseAssignment, error) {
+	var assignments []*model.LicenseAssignment
+	if err := r.DB(ctx).Order("id ASC").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (r *licenseRepository) SaveOSInfo(ctx context.Context, info *model.VMOSInfo) error {
+	return r.DB(ctx).Save(info).Error
+}
+
+func (r *licenseRepository) GetOSInfoByVmID(ctx context.Context, vmID int64) (*model.VMOSInfo, error) {
+	var info model.VMOSInfo
+	if err := r.DB(ctx).Where("vm_id = ?", vmID).First(&info).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &info, nil
+}
+
+func (r *licenseRepository) ListOSInfo(ctx context.Context) ([]*model.VMOSInfo, error) {
+	var infos []*model.VMOSInfo
+	if err := r.DB(ctx).Find(&infos).Error; err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func (r *licenseRepository) ListOSInfoByVmIDs(ctx context.Context, vmIDs []int64) (map[int64]*model.VMOSInfo, error) {
+	result := make(map[int64]*model.VMOSInfo, len(vmIDs))
+	if len(vmIDs) == 0 {
+		return result, nil
+	}
+	var infos []*model.VMOSInfo
+	if err := r.DB(ctx).Where("vm_id IN ?", vmIDs).Find(&infos).Error; err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		result[info.VmID] = info
+	}
+	return result, nil
+}
+
+func (r *licenseRepository) SaveAlert(ctx context.Context, alert *model.LicenseAlert) error {
+	return r.DB(ctx).Save(alert).Error
+}
+
+func (r *licenseRepository) GetOpenAlert(ctx context.Context, licenseID int64, osType string) (*model.LicenseAlert, error) {
+	var alert model.LicenseAlert
+	err := r.DB(ctx).Where("license_id = ? AND os_type = ? AND resolved_at IS NULL", licenseID, osType).First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *licenseRepository) ListOpenAlerts(ctx context.Context) ([]*model.LicenseAlert, error) {
+	var alerts []*model.LicenseAlert
+	if err := r.DB(ctx).Where("resolved_at IS NULL").Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *licenseRepository) ListAlerts(ctx context.Context, page, pageSize int, licenseID int64, open bool) ([]*model.LicenseAlert, int64, error) {
+	var alerts []*model.LicenseAlert
+	var total int64
+
+	query := r.DB(ctx).Model(&model.LicenseAlert{})
+	if licenseID > 0 {
+		query = query.Where("license_id = ?", licenseID)
+	}
+	if open {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+	return alerts, total, nil
+}
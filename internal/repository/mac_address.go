@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type MACAddressRepository interface {
+	Create(ctx context.Context, mac *model.MACAddress) error
+	Update(ctx context.Context, mac *model.MACAddress) error
+	GetByID(ctx context.Context, id int64) (*model.MACAddress, error)
+	GetByMAC(ctx context.Context, mac string) (*model.MACAddress, error)
+	List(ctx context.Context, page, pageSize int, clusterID, vmID int64, source, mac string) ([]*model.MACAddress, int64, error)
+	// ListByCluster 列出集群内全部登记，clusterID 为 0 时返回全部
+	ListByCluster(ctx context.Context, clusterID int64) ([]*model.MACAddress, error)
+	Delete(ctx context.Context, id int64) error
+	DeleteByVMId(ctx context.Context, vmID int64) error
+}
+
+func NewMACAddressRepository(r *Repository) MACAddressRepository {
+	return &macAddressRepository{Repository: r}
+}
+
+type macAddressRepository struct {
+	*Repository
+}
+
+func (r *macAddressRepository) Create(ctx context.Context, mac *model.MACAddress) error {
+	return r.DB(ctx).Create(mac).Error
+}
+
+func (r *macAddressRepository) Update(ctx context.Context, mac *model.MACAddress) error {
+	return r.DB(ctx).Save(mac).Error
+}
+
+func (r *macAddressRepository) GetByID(ctx context.Context, id int64) (*model.MACAddress, error) {
+	var mac model.MACAddress
+	if err := r.DB(ctx).Where("id = ?", id).First(&mac).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mac, nil
+}
+
+func (r *macAddressRepository) GetByMAC(ctx context.Context, addr string) (*model.MACAddress, error) {
+	var mac model.MACAddress
+	if err := r.DB(ctx).Where("mac = ?", addr).First(&mac).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &mac, nil
+}
+
+func (r *macAddressRepository) List(ctx context.Context, page, pageSize int, clusterID, vmID int64, source, mac string) ([]*model.MACAddress, int64, error) {
+	var macs []*model.MACAddress
+	var total int64
+
+	query := r.DB(ctx).Model(&model.MACAddress{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if vmID > 0 {
+		query = query.Where("vm_id = ?", vmID)
+	}
+	if source != "" {
+		query = query.Where("source = ?", source)
+	}
+	if mac != "" {
+		query = query.Where("mac LIKE ?", "%"+mac+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&macs).Error; err != nil {
+		return nil, 0, err
+	}
+	return macs, total, nil
+}
+
+func (r *macAddressRepository) ListByCluster(ctx context.Context, clusterID int64) ([]*model.MACAddress, error) {
+	var macs []*model.MACAddress
+	query := r.DB(ctx)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if err := query.Order("id").Find(&macs).Error; err != nil {
+		return nil, err
+	}
+	return macs, nil
+}
+
+func (r *macAddressRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Delete(&model.MACAddress{}, id).Error
+}
+
+func (r *macAddressRepository) DeleteByVMId(ctx context.Context, vmID int64) error {
+	return r.DB(ctx).Where("vm_id = ?", vmID).Delete(&model.MACAddress{}).Error
+}
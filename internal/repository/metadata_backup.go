@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// metadataBatchSize 导入时每批写入的记录数
+const metadataBatchSize = 500
+
+// MetadataBackupRepository 按模型整表读写，用于元数据导出和导入
+type MetadataBackupRepository interface {
+	// Count 统计模型对应表的记录数
+	Count(ctx context.Context, model interface{}) (int64, error)
+	// Dump 按主键顺序读取模型对应表的全部记录，rows 为 *[]*Model
+	Dump(ctx context.Context, model interface{}, rows interface{}) error
+	// Restore 原样写入记录（保留主键），rows 为 *[]*Model
+	Restore(ctx context.Context, model interface{}, rows interface{}) error
+}
+
+func NewMetadataBackupRepository(r *Repository) MetadataBackupRepository {
+	return &metadataBackupRepository{Repository: r}
+}
+
+type metadataBackupRepository struct {
+	*Repository
+}
+
+func (r *metadataBackupRepository) Count(ctx context.Context, model interface{}) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(model).Count(&count).Error
+	return count, err
+}
+
+func (r *metadataBackupRepository) Dump(ctx context.Context, model interface{}, rows interface{}) error {
+	return r.DB(ctx).Model(model).Order("id ASC").Find(rows).Error
+}
+
+func (r *metadataBackupRepository) Restore(ctx context.Context, model interface{}, rows interface{}) error {
+	db := r.DB(ctx)
+	if err := db.Session(&gorm.Session{SkipHooks: true}).CreateInBatches(rows, metadataBatchSize).Error; err != nil {
+		return err
+	}
+	if r.Dialect() != DialectPostgres {
+		return nil
+	}
+	// 显式写入主键后 PostgreSQL 的序列不会自动前移，需要手动修正
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+	table := stmt.Schema.Table
+	return db.Exec(fmt.Sprintf(
+		"SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)",
+		table, table,
+	)).Error
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type NodeBMCRepository interface {
+	Create(ctx context.Context, bmc *model.NodeBMC) error
+	Update(ctx context.Context, bmc *model.NodeBMC) error
+	DeleteByNodeID(ctx context.Context, nodeID int64) error
+	GetByNodeID(ctx context.Context, nodeID int64) (*model.NodeBMC, error)
+	List(ctx context.Context) ([]*model.NodeBMC, error)
+	// UpdateReading 记录最近一次功率读数，errMsg 非空表示读取失败（保留上次读数）
+	UpdateReading(ctx context.Context, id int64, watts float64, readingTime time.Time, errMsg string) error
+}
+
+func NewNodeBMCRepository(r *Repository) NodeBMCRepository {
+	return &nodeBMCRepository{Repository: r}
+}
+
+type nodeBMCRepository struct {
+	*Repository
+}
+
+func (r *nodeBMCRepository) Create(ctx context.Context, bmc *model.NodeBMC) error {
+	return r.DB(ctx).Create(bmc).Error
+}
+
+func (r *nodeBMCRepository) Update(ctx context.Context, bmc *model.NodeBMC) error {
+	return r.DB(ctx).Save(bmc).Error
+}
+
+func (r *nodeBMCRepository) DeleteByNodeID(ctx context.Context, nodeID int64) error {
+	return r.DB(ctx).Where("node_id = ?", nodeID).Delete(&model.NodeBMC{}).Error
+}
+
+func (r *nodeBMCRepository) GetByNodeID(ctx context.Context, nodeID int64) (*model.NodeBMC, error) {
+	var bmc model.NodeBMC
+	if err := r.DB(ctx).Where("node_id = ?", nodeID).First(&bmc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bmc, nil
+}
+
+func (r *nodeBMCRepository) List(ctx context.Context) ([]*model.NodeBMC, error) {
+	var list []*model.NodeBMC
+	if err := r.DB(ctx).Order("node_id ASC").Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *nodeBMCRepository) UpdateReading(ctx context.Context, id int64, watts float64, readingTime time.Time, errMsg string) error {
+	updates := map[string]interface{}{"last_error": errMsg}
+	if errMsg == "" {
+		updates["last_power_watts"] = watts
+		updates["last_reading_time"] = readingTime
+	}
+	return r.DB(ctx).Model(&model.NodeBMC{}).Where("id = ?", id).Updates(updates).Error
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type PveNodeCertificateRepository interface {
+	// Save 按 cluster_id + node_name 新增或覆盖
+	Save(ctx context.Context, cert *model.PveNodeCertificate) error
+	GetByNode(ctx context.Context, clusterID int64, nodeName string) (*model.PveNodeCertificate, error)
+	ListByCluster(ctx context.Context, clusterID int64) ([]*model.PveNodeCertificate, error)
+	DeleteByClusterID(ctx context.Context, clusterID int64) error
+}
+
+func NewPveNodeCertificateRepository(r *Repository) PveNodeCertificateRepository {
+	return &pveNodeCertificateRepository{Repository: r}
+}
+
+type pveNodeCertificateRepository struct {
+	*Repository
+}
+
+func (r *pveNodeCertificateRepository) Save(ctx context.Context, cert *model.PveNodeCertificate) error {
+	if cert.Id == 0 {
+		existing, err := r.GetByNode(ctx, cert.ClusterID, cert.NodeName)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			cert.Id = existing.Id
+			cert.CreateTime = existing.CreateTime
+		}
+	}
+	return r.DB(ctx).Save(cert).Error
+}
+
+func (r *pveNodeCertificateRepository) GetByNode(ctx context.Context, clusterID int64, nodeName string) (*model.PveNodeCertificate, error) {
+	var cert model.PveNodeCertificate
+	if err := r.DB(ctx).Where("cluster_id = ? AND node_name = ?", clusterID, nodeName).First(&cert).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cert, nil
+}
+
+func (r *pveNodeCertificateRepository) ListByCluster(ctx context.Context, clusterID int64) ([]*model.PveNodeCertificate, error) {
+	var certs []*model.PveNodeCertificate
+	if err := r.DB(ctx).Where("cluster_id = ?", clusterID).Order("node_name ASC").Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+func (r *pveNodeCertificateRepository) DeleteByClusterID(ctx context.Context, clusterID int64) error {
+	return r.DB(ctx).Where("cluster_id = ?", clusterID).Delete(&model.PveNodeCertificate{}).Error
+}
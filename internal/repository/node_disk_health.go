@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type NodeDiskHealthRepository interface {
+	// SaveHealth 按节点和磁盘路径保存最近一次 SMART 采集结果
+	SaveHealth(ctx context.Context, health *model.NodeDiskHealth) error
+	GetHealth(ctx context.Context, nodeID int64, devPath string) (*model.NodeDiskHealth, error)
+	ListHealthByNode(ctx context.Context, nodeID int64) ([]*model.NodeDiskHealth, error)
+	SaveAlert(ctx context.Context, alert *model.NodeDiskAlert) error
+	// GetOpenAlert 获取磁盘未恢复的告警
+	GetOpenAlert(ctx context.Context, nodeID int64, devPath string) (*model.NodeDiskAlert, error)
+	ListOpenAlertsByNode(ctx context.Context, nodeID int64) ([]*model.NodeDiskAlert, error)
+	// ListOpenAlertsByClusters 列出指定集群未恢复的告警，clusterIDs 为空时返回全部
+	ListOpenAlertsByClusters(ctx context.Context, clusterIDs []int64) ([]*model.NodeDiskAlert, error)
+	ListAlerts(ctx context.Context, page, pageSize int, clusterID, nodeID int64, open bool) ([]*model.NodeDiskAlert, int64, error)
+}
+
+func NewNodeDiskHealthRepository(r *Repository) NodeDiskHealthRepository {
+	return &nodeDiskHealthRepository{Repository: r}
+}
+
+type nodeDiskHealthRepository struct {
+	*Repository
+}
+
+func (r *nodeDiskHealthRepository) SaveHealth(ctx context.Context, health *model.NodeDiskHealth) error {
+	return r.DB(ctx).Save(health).Error
+}
+
+func (r *nodeDiskHealthRepository) GetHealth(ctx context.Context, nodeID int64, devPath string) (*model.NodeDiskHealth, error) {
+	var health model.NodeDiskHealth
+	err := r.DB(ctx).Where("node_id = ? AND devpath = ?", nodeID, devPath).First(&health).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &health, nil
+}
+
+func (r *nodeDiskHealthRepository) ListHealthByNode(ctx context.Context, nodeID int64) ([]*model.NodeDiskHealth, error) {
+	var list []*model.NodeDiskHealth
+	if err := r.DB(ctx).Where("node_id = ?", nodeID).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (r *nodeDiskHealthRepository) SaveAlert(ctx context.Context, alert *model.NodeDiskAlert) error {
+	return r.DB(ctx).Save(alert).Error
+}
+
+func (r *nodeDiskHealthRepository) GetOpenAlert(ctx context.Context, nodeID int64, devPath string) (*model.NodeDiskAlert, error) {
+	var alert model.NodeDiskAlert
+	err := r.DB(ctx).Where("node_id = ? AND devpath = ? AND resolved_at IS NULL", nodeID, devPath).First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *nodeDiskHealthRepository) ListOpenAlertsByNode(ctx context.Context, nodeID int64) ([]*model.NodeDiskAlert, error) {
+	var alerts []*model.NodeDiskAlert
+	if err := r.DB(ctx).Where("node_id = ? AND resolved_at IS NULL", nodeID).Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *nodeDiskHealthRepository) ListAlerts(ctx context.Context, page, pageSize int, clusterID, nodeID int64, open bool) ([]*model.NodeDiskAlert, int64, error) {
+	var alerts []*model.NodeDiskAlert
+	var total int64
+
+	query := r.DB(ctx).Model(&model.NodeDiskAlert{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if nodeID > 0 {
+		query = query.Where("node_id = ?", nodeID)
+	}
+	if open {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+	return alerts, total, nil
+}
+
+func (r *nodeDiskHealthRepository) ListOpenAlertsByClusters(ctx context.Context, clusterIDs []int64) ([]*model.NodeDiskAlert, error) {
+	var alerts []*model.NodeDiskAlert
+	query := r.DB(ctx).Where("resolved_at IS NULL")
+	if len(clusterIDs) > 0 {
+		query = query.Where("cluster_id IN ?", clusterIDs)
+	}
+	if err := query.Order("id DESC").Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
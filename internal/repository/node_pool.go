@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type NodePoolRepository interface {
+	Create(ctx context.Context, pool *model.NodePool) error
+	Update(ctx context.Context, pool *model.NodePool) error
+	// Delete 删除节点池及其成员关系
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.NodePool, error)
+	GetByName(ctx context.Context, clusterID int64, name string) (*model.NodePool, error)
+	// List clusterID、nodeID 大于 0 时分别按集群、成员节点过滤
+	List(ctx context.Context, clusterID, nodeID int64) ([]*model.NodePool, error)
+	// ListMembers 返回节点池 -> 成员节点 ID
+	ListMembers(ctx context.Context, poolIDs []int64) (map[int64][]int64, error)
+	// ReplaceMembers 替换节点池的全部成员节点
+	ReplaceMembers(ctx context.Context, poolID int64, nodeIDs []int64) error
+}
+
+func NewNodePoolRepository(r *Repository) NodePoolRepository {
+	return &nodePoolRepository{Repository: r}
+}
+
+type nodePoolRepository struct {
+	*Repository
+}
+
+func (r *nodePoolRepository) Create(ctx context.Context, pool *model.NodePool) error {
+	return r.DB(ctx).Create(pool).Error
+}
+
+func (r *nodePoolRepository) Update(ctx context.Context, pool *model.NodePool) error {
+	return r.DB(ctx).Save(pool).Error
+}
+
+func (r *nodePoolRepository) Delete(ctx context.Context, id int64) error {
+	if err := r.DB(ctx).Where("pool_id = ?", id).Delete(&model.NodePoolMember{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.NodePool{}).Error
+}
+
+func (r *nodePoolRepository) GetByID(ctx context.Context, id int64) (*model.NodePool, error) {
+	var pool model.NodePool
+	if err := r.DB(ctx).Where("id = ?", id).First(&pool).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pool, nil
+}
+
+func (r *nodePoolRepository) GetByName(ctx context.Context, clusterID int64, name string) (*model.NodePool, error) {
+	var pool model.NodePool
+	if err := r.DB(ctx).Where("cluster_id = ? AND name = ?", clusterID, name).First(&pool).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pool, nil
+}
+
+func (r *nodePoolRepository) List(ctx context.Context, clusterID, nodeID int64) ([]*model.NodePool, error) {
+	var pools []*model.NodePool
+	query := r.DB(ctx).Model(&model.NodePool{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if nodeID > 0 {
+		query = query.Where("id IN (?)", r.DB(ctx).Model(&model.NodePoolMember{}).Select("pool_id").Where("node_id = ?", nodeID))
+	}
+	if err := query.Order("cluster_id ASC, name ASC").Find(&pools).Error; err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
+
+func (r *nodePoolRepository) ListMembers(ctx context.Context, poolIDs []int64) (map[int64][]int64, error) {
+	result := make(map[int64][]int64, len(poolIDs))
+	if len(poolIDs) == 0 {
+		return result, nil
+	}
+	var members []*model.NodePoolMember
+	if err := r.DB(ctx).Where("pool_id IN ?", poolIDs).Order("node_id ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		result[member.PoolID] = append(result[member.PoolID], member.NodeID)
+	}
+	return result, nil
+}
+
+func (r *nodePoolRepository) ReplaceMembers(ctx context.Context, poolID int64, nodeIDs []int64) error {
+	if err := r.DB(ctx).Where("pool_id = ?", poolID).Delete(&model.NodePoolMember{}).Error; err != nil {
+		return err
+	}
+	if len(nodeIDs) == 0 {
+		return nil
+	}
+	members := make([]*model.NodePoolMember, 0, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		members = append(members, &model.NodePoolMember{PoolID: poolID, NodeID: nodeID})
+	}
+	return r.DB(ctx).Create(&members).Error
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type NodeVersionRepository interface {
+	Save(ctx context.Context, version *model.NodeVersion) error
+	GetByNodeID(ctx context.Context, nodeID int64) (*model.NodeVersion, error)
+	ListByClusterID(ctx context.Context, clusterID int64) ([]*model.NodeVersion, error)
+}
+
+func NewNodeVersionRepository(r *Repository) NodeVersionRepository {
+	return &nodeVersionRepository{Repository: r}
+}
+
+type nodeVersionRepository struct {
+	*Repository
+}
+
+func (r *nodeVersionRepository) Save(ctx context.Context, version *model.NodeVersion) error {
+	return r.DB(ctx).Save(version).Error
+}
+
+func (r *nodeVersionRepository) GetByNodeID(ctx context.Context, nodeID int64) (*model.NodeVersion, error) {
+	var version model.NodeVersion
+	if err := r.DB(ctx).Where("node_id = ?", nodeID).First(&version).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &version, nil
+}
+
+func (r *nodeVersionRepository) ListByClusterID(ctx context.Context, clusterID int64) ([]*model.NodeVersion, error) {
+	var versions []*model.NodeVersion
+	if err := r.DB(ctx).Where("cluster_id = ?", clusterID).Order("node_name ASC").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
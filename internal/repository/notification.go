@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	CreateBatch(ctx context.Context, notifications []*model.Notification) error
+	GetByID(ctx context.Context, id int64) (*model.Notification, error)
+	List(ctx context.Context, page, pageSize int, recipient, category string, unreadOnly bool) ([]*model.Notification, int64, error)
+	// CountUnread 按类别统计接收人的未读通知
+	CountUnread(ctx context.Context, recipient string) (map[string]int64, error)
+	// MarkRead 将接收人的通知标记为已读，ids 为空时标记全部（category 不为空时只标记该类别）
+	MarkRead(ctx context.Context, recipient string, ids []int64, category string) (int64, error)
+	Delete(ctx context.Context, id int64) error
+	// DeleteBefore 删除创建时间早于 before 的通知
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewNotificationRepository(r *Repository) NotificationRepository {
+	return &notificationRepository{Repository: r}
+}
+
+type notificationRepository struct {
+	*Repository
+}
+
+func (r *notificationRepository) CreateBatch(ctx context.Context, notifications []*model.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Create(notifications).Error
+}
+
+func (r *notificationRepository) GetByID(ctx context.Context, id int64) (*model.Notification, error) {
+	var notification model.Notification
+	if err := r.DB(ctx).Where("id = ?", id).First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (r *notificationRepository) List(ctx context.Context, page, pageSize int, recipient, category string, unreadOnly bool) ([]*model.Notification, int64, error) {
+	var notifications []*model.Notification
+	var total int64
+
+	query := r.DB(ctx).Model(&model.Notification{}).Where("recipient = ?", recipient)
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, recipient string) (map[string]int64, error) {
+	var rows []struct {
+		Category string
+		Count    int64
+	}
+	err := r.DB(ctx).Model(&model.Notification{}).
+		Select("category, COUNT(*) AS count").
+		Where("recipient = ? AND read_at IS NULL", recipient).
+		Group("category").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Category] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *notificationRepository) MarkRead(ctx context.Context, recipient string, ids []int64, category string) (int64, error) {
+	query := r.DB(ctx).Model(&model.Notification{}).Where("recipient = ? AND read_at IS NULL", recipient)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	result := query.Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Delete(&model.Notification{}, id).Error
+}
+
+func (r *notificationRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("gmt_create < ?", before).Delete(&model.Notification{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type OperationAuditRepository interface {
+	Create(ctx context.Context, audit *model.OperationAudit) error
+	GetByOperationID(ctx context.Context, operationID string) (*model.OperationAudit, error)
+	List(ctx context.Context, page, pageSize int, filter OperationAuditFilter) ([]*model.OperationAudit, int64, error)
+	// ListForVM 返回请求路径为 vmPath（或其子路径）或启动了 upids 中任一任务的最近 limit 条操作，username 不为空时只返回该用户的操作
+	ListForVM(ctx context.Context, vmPath string, upids []string, username string, limit int) ([]*model.OperationAudit, error)
+	// ListChangesBetween 返回 [from, to) 内最早的 limit 条变更操作（非 GET，含调用明细），按时间正序
+	ListChangesBetween(ctx context.Context, from, to time.Time, limit int) ([]*model.OperationAudit, error)
+	// DeleteBefore 删除创建时间早于 before 的操作记录
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OperationAuditFilter 操作记录查询条件，零值字段不参与过滤
+type OperationAuditFilter struct {
+	Username   string
+	Method     string
+	PathPrefix string
+	UPID       string
+	HasTasks   bool
+}
+
+func NewOperationAuditRepository(r *Repository) OperationAuditRepository {
+	return &operationAuditRepository{Repository: r}
+}
+
+type operationAuditRepository struct {
+	*Repository
+}
+
+func (r *operationAuditRepository) Create(ctx context.Context, audit *model.OperationAudit) error {
+	return r.DB(ctx).Create(audit).Error
+}
+
+func (r *operationAuditRepository) GetByOperationID(ctx context.Context, operationID string) (*model.OperationAudit, error) {
+	var audit model.OperationAudit
+	if err := r.DB(ctx).Where("operation_id = ?", operationID).First(&audit).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &audit, nil
+}
+
+func (r *operationAuditRepository) List(ctx context.Context, page, pageSize int, filter OperationAuditFilter) ([]*model.OperationAudit, int64, error) {
+	var audits []*model.OperationAudit
+	var total int64
+
+	query := r.DB(ctx).Model(&model.OperationAudit{})
+	if filter.Username != "" {
+		query = query.Where("username = ?", filter.Username)
+	}
+	if filter.Method != "" {
+		query = query.Where("method = ?", filter.Method)
+	}
+	if filter.PathPrefix != "" {
+		query = query.Where("path LIKE ?", filter.PathPrefix+"%")
+	}
+	if filter.UPID != "" {
+		query = query.Where("tasks LIKE ?", "%"+filter.UPID+"%")
+	}
+	if filter.HasTasks {
+		query = query.Where("task_count > 0")
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 列表不返回调用明细
+	offset := (page - 1) * pageSize
+	if err := query.Omit("calls").Order("id DESC").Offset(offset).Limit(pageSize).Find(&audits).Error; err != nil {
+		return nil, 0, err
+	}
+	return audits, total, nil
+}
+
+func (r *operationAuditRepository) ListForVM(ctx context.Context, vmPath string, upids []string, username string, limit int) ([]*model.OperationAudit, error) {
+	var audits []*model.OperationAudit
+
+	match := r.DB(ctx).Where("path = ?", vmPath).Or("path LIKE ?", vmPath+"/%")
+	for _, upid := range upids {
+		match = match.Or("tasks LIKE ?", "%"+upid+"%")
+	}
+	query := r.DB(ctx).Model(&model.OperationAudit{}).Where(match)
+	if username != "" {
+		query = query.Where("username = ?", username)
+	}
+	if err := query.Omit("calls").Order("id DESC").Limit(limit).Find(&audits).Error; err != nil {
+		return nil, err
+	}
+	return audits, nil
+}
+
+func (r *operationAuditRepository) ListChangesBetween(ctx context.Context, from, to time.Time, limit int) ([]*model.OperationAudit, error) {
+	var audits []*model.OperationAudit
+	if err := r.DB(ctx).Where("gmt_create >= ? AND gmt_create < ? AND method <> ?", from, to, "GET").
+		Order("id ASC").Limit(limit).Find(&audits).Error; err != nil {
+		return nil, err
+	}
+	return audits, nil
+}
+
+func (r *operationAuditRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("gmt_create < ?", before).Delete(&model.OperationAudit{})
+	return result.RowsAffected, result.Error
+}
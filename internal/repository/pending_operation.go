@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type PendingOperationRepository interface {
+	Create(ctx context.Context, op *model.PendingOperation) error
+	Update(ctx context.Context, op *model.PendingOperation) error
+	GetByID(ctx context.Context, id int64) (*model.PendingOperation, error)
+	List(ctx context.Context, page, pageSize int, clusterID, vmID int64, status string) ([]*model.PendingOperation, int64, error)
+	// ListDue 列出到期需要重试的操作，按创建顺序返回
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*model.PendingOperation, error)
+	// ListPendingByCluster 列出集群上全部 pending 操作，按创建顺序返回
+	ListPendingByCluster(ctx context.Context, clusterID int64) ([]*model.PendingOperation, error)
+	// CancelByVM 取消虚拟机全部 pending 操作
+	CancelByVM(ctx context.Context, vmID int64, reason string) error
+}
+
+func NewPendingOperationRepository(r *Repository) PendingOperationRepository {
+	return &pendingOperationRepository{Repository: r}
+}
+
+type pendingOperationRepository struct {
+	*Repository
+}
+
+func (r *pendingOperationRepository) Create(ctx context.Context, op *model.PendingOperation) error {
+	return r.DB(ctx).Create(op).Error
+}
+
+func (r *pendingOperationRepository) Update(ctx context.Context, op *model.PendingOperation) error {
+	return r.DB(ctx).Save(op).Error
+}
+
+func (r *pendingOperationRepository) GetByID(ctx context.Context, id int64) (*model.PendingOperation, error) {
+	var op model.PendingOperation
+	if err := r.DB(ctx).Where("id = ?", id).First(&op).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &op, nil
+}
+
+func (r *pendingOperationRepository) List(ctx context.Context, page, pageSize int, clusterID, vmID int64, status string) ([]*model.PendingOperation, int64, error) {
+	var ops []*model.PendingOperation
+	var total int64
+
+	query := r.DB(ctx).Model(&model.PendingOperation{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if vmID > 0 {
+		query = query.Where("vm_id = ?", vmID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&ops).Error; err != nil {
+		return nil, 0, err
+	}
+	return ops, total, nil
+}
+
+func (r *pendingOperationRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*model.PendingOperation, error) {
+	var ops []*model.PendingOperation
+	err := r.DB(ctx).
+		Where("status = ? AND (next_retry_time IS NULL OR next_retry_time <= ?)", model.PendingOperationStatusPending, now).
+		Order("id ASC").Limit(limit).Find(&ops).Error
+	return ops, err
+}
+
+func (r *pendingOperationRepository) ListPendingByCluster(ctx context.Context, clusterID int64) ([]*model.PendingOperation, error) {
+	var ops []*model.PendingOperation
+	err := r.DB(ctx).
+		Where("cluster_id = ? AND status = ?", clusterID, model.PendingOperationStatusPending).
+		Order("id ASC").Find(&ops).Error
+	return ops, err
+}
+
+func (r *pendingOperationRepository) CancelByVM(ctx context.Context, vmID int64, reason string) error {
+	now := time.Now()
+	return r.DB(ctx).Model(&model.PendingOperation{}).
+		Where("vm_id = ? AND status = ?", vmID, model.PendingOperationStatusPending).
+		Updates(map[string]interface{}{
+			"status":      model.PendingOperationStatusCancelled,
+			"last_error":  reason,
+			"finish_time": now,
+		}).Error
+}
@@ -15,7 +15,8 @@ type PveClusterRepository interface {
 	GetByID(ctx context.Context, id int64) (*model.PveCluster, error)
 	GetByClusterName(ctx context.Context, clusterName string) (*model.PveCluster, error)
 	List(ctx context.Context) ([]*model.PveCluster, error)
-	ListWithPagination(ctx context.Context, page, pageSize int, env, region string) ([]*model.PveCluster, int64, error)
+	ListWithPagination(ctx context.Context, page, pageSize int, env, region string, siteID int64) ([]*model.PveCluster, int64, error)
+	GetBySiteID(ctx context.Context, siteID int64) ([]*model.PveCluster, error)
 	GetAllSchedulable(ctx context.Context) ([]*model.PveCluster, error)
 	GetAllEnabled(ctx context.Context) ([]*model.PveCluster, error) // 获取所有启用的集群（用于数据自动上报）
 	GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.PveCluster, error) // 批量查询集群，返回 map[id]*cluster
@@ -83,11 +84,19 @@ func (r *pveClusterRepository) GetAllEnabled(ctx context.Context) ([]*model.PveC
 	return clusters, nil
 }
 
+func (r *pveClusterRepository) GetBySiteID(ctx context.Context, siteID int64) ([]*model.PveCluster, error) {
+	var clusters []*model.PveCluster
+	if err := r.DB(ctx).Where("site_id = ?", siteID).Find(&clusters).Error; err != nil {
+		return nil, err
+	}
+	return clusters, nil
+}
+
 func (r *pveClusterRepository) Delete(ctx context.Context, id int64) error {
 	return r.DB(ctx).Where("id = ?", id).Delete(&model.PveCluster{}).Error
 }
 
-func (r *pveClusterRepository) ListWithPagination(ctx context.Context, page, pageSize int, env, region string) ([]*model.PveCluster, int64, error) {
+func (r *pveClusterRepository) ListWithPagination(ctx context.Context, page, pageSize int, env, region string, siteID int64) ([]*model.PveCluster, int64, error) {
 	var clusters []*model.PveCluster
 	var total int64
 
@@ -99,6 +108,9 @@ func (r *pveClusterRepository) ListWithPagination(ctx context.Context, page, pag
 	if region != "" {
 		query = query.Where("region = ?", region)
 	}
+	if siteID > 0 {
+		query = query.Where("site_id = ?", siteID)
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -17,6 +17,7 @@ type PveNodeRepository interface {
 	GetByNodeName(ctx context.Context, nodeName string, clusterID int64) (*model.PveNode, error)
 	GetByClusterID(ctx context.Context, clusterID int64) ([]*model.PveNode, error)
 	ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, env, status string) ([]*model.PveNode, int64, error)
+	List(ctx context.Context, clusterID int64, env, status string) ([]*model.PveNode, error) // 按条件查询全部节点（不分页），用于按实时指标排序
 	Upsert(ctx context.Context, node *model.PveNode) error
 	DeleteByNodeName(ctx context.Context, nodeName string, clusterID int64) error
 	GetHashByNodeName(ctx context.Context, nodeName string, clusterID int64) (string, int64, error) // 返回 hash 和 id
@@ -164,6 +165,26 @@ func (r *pveNodeRepository) ListWithPagination(ctx context.Context, page, pageSi
 	return nodes, total, nil
 }
 
+func (r *pveNodeRepository) List(ctx context.Context, clusterID int64, env, status string) ([]*model.PveNode, error) {
+	var nodes []*model.PveNode
+
+	query := r.DB(ctx).Model(&model.PveNode{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if env != "" {
+		query = query.Where("env = ?", env)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Order("id DESC").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
 // GetByIDs 批量查询节点，返回 map[id]*node，用于批量填充名称
 func (r *pveNodeRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.PveNode, error) {
 	if len(ids) == 0 {
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type PveSiteRepository interface {
+	Create(ctx context.Context, site *model.PveSite) error
+	Update(ctx context.Context, site *model.PveSite) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.PveSite, error)
+	GetBySiteName(ctx context.Context, siteName string) (*model.PveSite, error)
+	List(ctx context.Context) ([]*model.PveSite, error)
+	GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.PveSite, error) // 批量查询站点，返回 map[id]*site
+}
+
+func NewPveSiteRepository(r *Repository) PveSiteRepository {
+	return &pveSiteRepository{Repository: r}
+}
+
+type pveSiteRepository struct {
+	*Repository
+}
+
+func (r *pveSiteRepository) Create(ctx context.Context, site *model.PveSite) error {
+	return r.DB(ctx).Create(site).Error
+}
+
+func (r *pveSiteRepository) Update(ctx context.Context, site *model.PveSite) error {
+	return r.DB(ctx).Save(site).Error
+}
+
+func (r *pveSiteRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.PveSite{}).Error
+}
+
+func (r *pveSiteRepository) GetByID(ctx context.Context, id int64) (*model.PveSite, error) {
+	var site model.PveSite
+	if err := r.DB(ctx).Where("id = ?", id).First(&site).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &site, nil
+}
+
+func (r *pveSiteRepository) GetBySiteName(ctx context.Context, siteName string) (*model.PveSite, error) {
+	var site model.PveSite
+	if err := r.DB(ctx).Where("site_name = ?", siteName).First(&site).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &site, nil
+}
+
+func (r *pveSiteRepository) List(ctx context.Context) ([]*model.PveSite, error) {
+	var sites []*model.PveSite
+	if err := r.DB(ctx).Order("id ASC").Find(&sites).Error; err != nil {
+		return nil, err
+	}
+	return sites, nil
+}
+
+// GetByIDs 批量查询站点，用于批量填充站点名称
+func (r *pveSiteRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.PveSite, error) {
+	if len(ids) == 0 {
+		return make(map[int64]*model.PveSite), nil
+	}
+
+	var sites []*model.PveSite
+	if err := r.DB(ctx).Where("id IN ?", ids).Find(&sites).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[int64]*model.PveSite, len(sites))
+	for _, site := range sites {
+		result[site.Id] = site
+	}
+	return result, nil
+}
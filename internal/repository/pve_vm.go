@@ -18,13 +18,33 @@ type PveVMRepository interface {
 	GetByVMIDAndNodeName(ctx context.Context, vmid uint32, nodeName string) (*model.PveVM, error) // 通过 VM ID 和节点名称查询（向后兼容）
 	GetByClusterID(ctx context.Context, clusterID int64) ([]*model.PveVM, error)                         // 通过集群 ID 查询
 	GetByClusterName(ctx context.Context, clusterName string) ([]*model.PveVM, error)                    // 通过集群名称查询（向后兼容）
-	ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, clusterName string, nodeID int64, nodeName string, templateID int64, status, appId string) ([]*model.PveVM, int64, error)
+	ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, clusterName string, nodeID int64, nodeName string, templateID int64, status, appId string, meta *PveVMMetaFilter) ([]*model.PveVM, int64, error)
 	Upsert(ctx context.Context, vm *model.PveVM) error
 	DeleteByVMID(ctx context.Context, vmid uint32, nodeID int64) error
 	GetHashByVMID(ctx context.Context, vmid uint32, nodeID int64) (string, int64, error)
 	UpdateSyncTimeOnly(ctx context.Context, id int64) error
+	UpdateStatus(ctx context.Context, id int64, status string) error // 只更新运行状态
 	GetTemplateVMByID(ctx context.Context, templateID, clusterID int64, nodeName string) (*model.PveVM, error) // 根据模板 ID、集群 ID 和节点名称查找模板虚拟机
 	GetTemplateVM(ctx context.Context, templateName, clusterName string) (*model.PveVM, error)                 // 根据模板名称和集群名称查找模板虚拟机（向后兼容）
+	ListWithPlaintextPassword(ctx context.Context, limit int) ([]*model.PveVM, error)                          // 查询仍以明文保存密码的虚拟机
+	UpdatePassword(ctx context.Context, id int64, password, ref string) error                                 // 只更新密码和密码引用
+	UpdateDescription(ctx context.Context, id int64, description string) error                                // 只更新描述（Proxmox 备注）
+	ListByNodeID(ctx context.Context, nodeID int64) ([]*model.PveVM, error)
+	ListByTemplateID(ctx context.Context, templateID int64) ([]*model.PveVM, error)
+	ListByStorage(ctx context.Context, clusterID int64, storage string) ([]*model.PveVM, error) // 按创建时记录的存储名称查询
+	CountByTemplateIDs(ctx context.Context, templateIDs []int64) (map[int64]int64, error)       // 按模板统计现存虚拟机数量（不含模板虚拟机）
+}
+
+// PveVMMetaFilter 虚拟机归属元数据过滤条件，字段为空时不过滤
+type PveVMMetaFilter struct {
+	Owner           string
+	Team            string
+	BusinessService string
+	Environment     string
+	CostCenter      string
+	ReviewDueBefore *time.Time // 复核日期不晚于该时间（已到期）
+	OSEOLAfter      *time.Time // 操作系统停止维护日期晚于该时间（vm_os_info.eol_date）
+	OSEOLBefore     *time.Time // 操作系统停止维护日期不晚于该时间
 }
 
 func NewPveVMRepository(r *Repository) PveVMRepository {
@@ -197,15 +217,87 @@ func (r *pveVMRepository) UpdateSyncTimeOnly(ctx context.Context, id int64) erro
 		Update("last_sync_time", time.Now()).Error
 }
 
+func (r *pveVMRepository) UpdateStatus(ctx context.Context, id int64, status string) error {
+	return r.DB(ctx).
+		Model(&model.PveVM{}).
+		Where("id = ?", id).
+		Update("status", status).Error
+}
+
 func (r *pveVMRepository) DeleteByVMID(ctx context.Context, vmid uint32, nodeID int64) error {
 	return r.DB(ctx).Where("vmid = ? AND node_id = ?", vmid, nodeID).Delete(&model.PveVM{}).Error
 }
 
+func (r *pveVMRepository) ListWithPlaintextPassword(ctx context.Context, limit int) ([]*model.PveVM, error) {
+	var vms []*model.PveVM
+	err := r.DB(ctx).
+		Where("vm_password <> '' AND (vm_password_ref IS NULL OR vm_password_ref = '')").
+		Order("id ASC").
+		Limit(limit).
+		Find(&vms).Error
+	return vms, err
+}
+
+func (r *pveVMRepository) UpdatePassword(ctx context.Context, id int64, password, ref string) error {
+	return r.DB(ctx).
+		Model(&model.PveVM{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"vm_password": password, "vm_password_ref": ref}).Error
+}
+
+func (r *pveVMRepository) UpdateDescription(ctx context.Context, id int64, description string) error {
+	return r.DB(ctx).
+		Model(&model.PveVM{}).
+		Where("id = ?", id).
+		Update("descriptions", description).Error
+}
+
+func (r *pveVMRepository) ListByNodeID(ctx context.Context, nodeID int64) ([]*model.PveVM, error) {
+	var vms []*model.PveVM
+	err := r.DB(ctx).Where("node_id = ?", nodeID).Order("vmid ASC").Find(&vms).Error
+	return vms, err
+}
+
+func (r *pveVMRepository) ListByTemplateID(ctx context.Context, templateID int64) ([]*model.PveVM, error) {
+	var vms []*model.PveVM
+	err := r.DB(ctx).Where("template_id = ?", templateID).Order("vmid ASC").Find(&vms).Error
+	return vms, err
+}
+
+func (r *pveVMRepository) ListByStorage(ctx context.Context, clusterID int64, storage string) ([]*model.PveVM, error) {
+	var vms []*model.PveVM
+	err := r.DB(ctx).Where("cluster_id = ? AND storages = ?", clusterID, storage).Order("vmid ASC").Find(&vms).Error
+	return vms, err
+}
+
+func (r *pveVMRepository) CountByTemplateIDs(ctx context.Context, templateIDs []int64) (map[int64]int64, error) {
+	counts := make(map[int64]int64, len(templateIDs))
+	if len(templateIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		TemplateID int64
+		Count      int64
+	}
+	err := r.DB(ctx).Model(&model.PveVM{}).
+		Select("template_id, COUNT(*) AS count").
+		Where("template_id IN ? AND is_template = 0", templateIDs).
+		Group("template_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.TemplateID] = row.Count
+	}
+	return counts, nil
+}
+
 func (r *pveVMRepository) Delete(ctx context.Context, id int64) error {
 	return r.DB(ctx).Where("id = ?", id).Delete(&model.PveVM{}).Error
 }
 
-func (r *pveVMRepository) ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, clusterName string, nodeID int64, nodeName string, templateID int64, status, appId string) ([]*model.PveVM, int64, error) {
+func (r *pveVMRepository) ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, clusterName string, nodeID int64, nodeName string, templateID int64, status, appId string, meta *PveVMMetaFilter) ([]*model.PveVM, int64, error) {
 	var vms []*model.PveVM
 	var total int64
 
@@ -234,6 +326,36 @@ func (r *pveVMRepository) ListWithPagination(ctx context.Context, page, pageSize
 	if appId != "" {
 		query = query.Where("appid = ?", appId)
 	}
+	if meta != nil {
+		if meta.Owner != "" {
+			query = query.Where("owner = ?", meta.Owner)
+		}
+		if meta.Team != "" {
+			query = query.Where("team = ?", meta.Team)
+		}
+		if meta.BusinessService != "" {
+			query = query.Where("business_service = ?", meta.BusinessService)
+		}
+		if meta.Environment != "" {
+			query = query.Where("environment = ?", meta.Environment)
+		}
+		if meta.CostCenter != "" {
+			query = query.Where("cost_center = ?", meta.CostCenter)
+		}
+		if meta.ReviewDueBefore != nil {
+			query = query.Where("review_date IS NOT NULL AND review_date <= ?", *meta.ReviewDueBefore)
+		}
+		if meta.OSEOLAfter != nil || meta.OSEOLBefore != nil {
+			sub := r.DB(ctx).Model(&model.VMOSInfo{}).Select("vm_id").Where("eol_date IS NOT NULL")
+			if meta.OSEOLAfter != nil {
+				sub = sub.Where("eol_date > ?", *meta.OSEOLAfter)
+			}
+			if meta.OSEOLBefore != nil {
+				sub = sub.Where("eol_date <= ?", *meta.OSEOLBefore)
+			}
+			query = query.Where("id IN (?)", sub)
+		}
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
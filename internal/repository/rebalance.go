@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type RebalanceRepository interface {
+	Create(ctx context.Context, plan *model.RebalancePlan) error
+	Update(ctx context.Context, plan *model.RebalancePlan) error
+	GetByID(ctx context.Context, id int64) (*model.RebalancePlan, error)
+	List(ctx context.Context, page, pageSize int, clusterID int64, status string) ([]*model.RebalancePlan, int64, error)
+	// SupersedePending 将集群内仍为 pending 的方案标记为 superseded
+	SupersedePending(ctx context.Context, clusterID int64) error
+	// CountApplying 统计集群内正在执行的方案数
+	CountApplying(ctx context.Context, clusterID int64) (int64, error)
+}
+
+func NewRebalanceRepository(r *Repository) RebalanceRepository {
+	return &rebalanceRepository{Repository: r}
+}
+
+type rebalanceRepository struct {
+	*Repository
+}
+
+func (r *rebalanceRepository) Create(ctx context.Context, plan *model.RebalancePlan) error {
+	return r.DB(ctx).Create(plan).Error
+}
+
+func (r *rebalanceRepository) Update(ctx context.Context, plan *model.RebalancePlan) error {
+	return r.DB(ctx).Save(plan).Error
+}
+
+func (r *rebalanceRepository) GetByID(ctx context.Context, id int64) (*model.RebalancePlan, error) {
+	var plan model.RebalancePlan
+	if err := r.DB(ctx).Where("id = ?", id).First(&plan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (r *rebalanceRepository) List(ctx context.Context, page, pageSize int, clusterID int64, status string) ([]*model.RebalancePlan, int64, error) {
+	var plans []*model.RebalancePlan
+	var total int64
+
+	query := r.DB(ctx).Model(&model.RebalancePlan{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&plans).Error; err != nil {
+		return nil, 0, err
+	}
+	return plans, total, nil
+}
+
+func (r *rebalanceRepository) SupersedePending(ctx context.Context, clusterID int64) error {
+	return r.DB(ctx).Model(&model.RebalancePlan{}).
+		Where("cluster_id = ? AND status = ?", clusterID, model.RebalancePlanStatusPending).
+		Update("status", model.RebalancePlanStatusSuperseded).Error
+}
+
+func (r *rebalanceRepository) CountApplying(ctx context.Context, clusterID int64) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.RebalancePlan{}).
+		Where("cluster_id = ? AND status = ?", clusterID, model.RebalancePlanStatusApplying).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type RemoteMigrationJobRepository interface {
+	Create(ctx context.Context, job *model.RemoteMigrationJob) error
+	Update(ctx context.Context, job *model.RemoteMigrationJob) error
+	GetByID(ctx context.Context, id int64) (*model.RemoteMigrationJob, error)
+	List(ctx context.Context, page, pageSize int, clusterID, vmID int64, status string) ([]*model.RemoteMigrationJob, int64, error)
+	// ListDue 列出到期的计划迁移，按计划时间返回
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*model.RemoteMigrationJob, error)
+	// Claim 将 scheduled 状态的任务置为 running，已被处理或取消时返回 false
+	Claim(ctx context.Context, id int64) (bool, error)
+}
+
+func NewRemoteMigrationJobRepository(r *Repository) RemoteMigrationJobRepository {
+	return &remoteMigrationJobRepository{Repository: r}
+}
+
+type remoteMigrationJobRepository struct {
+	*Repository
+}
+
+func (r *remoteMigrationJobRepository) Create(ctx context.Context, job *model.RemoteMigrationJob) error {
+	return r.DB(ctx).Create(job).Error
+}
+
+func (r *remoteMigrationJobRepository) Update(ctx context.Context, job *model.RemoteMigrationJob) error {
+	return r.DB(ctx).Save(job).Error
+}
+
+func (r *remoteMigrationJobRepository) GetByID(ctx context.Context, id int64) (*model.RemoteMigrationJob, error) {
+	var job model.RemoteMigrationJob
+	if err := r.DB(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *remoteMigrationJobRepository) List(ctx context.Context, page, pageSize int, clusterID, vmID int64, status string) ([]*model.RemoteMigrationJob, int64, error) {
+	var jobs []*model.RemoteMigrationJob
+	var total int64
+
+	query := r.DB(ctx).Model(&model.RemoteMigrationJob{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if vmID > 0 {
+		query = query.Where("vm_id = ?", vmID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+func (r *remoteMigrationJobRepository) ListDue(ctx context.Context, now time.Time, limit int) ([]*model.RemoteMigrationJob, error) {
+	var jobs []*model.RemoteMigrationJob
+	err := r.DB(ctx).
+		Where("status = ? AND scheduled_at <= ?", model.RemoteMigrationJobScheduled, now).
+		Order("scheduled_at ASC, id ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *remoteMigrationJobRepository) Claim(ctx context.Context, id int64) (bool, error) {
+	result := r.DB(ctx).Model(&model.RemoteMigrationJob{}).
+		Where("id = ? AND status = ?", id, model.RemoteMigrationJobScheduled).
+		Update("status", model.RemoteMigrationJobRunning)
+	return result.RowsAffected > 0, result.Error
+}
+
+type RemoteMigrationCutoverRepository interface {
+	Create(ctx context.Context, cutover *model.RemoteMigrationCutover) error
+	Update(ctx context.Context, cutover *model.RemoteMigrationCutover) error
+	GetByID(ctx context.Context, id int64) (*model.RemoteMigrationCutover, error)
+	List(ctx context.Context, page, pageSize int, vmID int64, status string) ([]*model.RemoteMigrationCutover, int64, error)
+	// ListWaiting 列出等待迁移任务结束的切换
+	ListWaiting(ctx context.Context, limit int) ([]*model.RemoteMigrationCutover, error)
+}
+
+func NewRemoteMigrationCutoverRepository(r *Repository) RemoteMigrationCutoverRepository {
+	return &remoteMigrationCutoverRepository{Repository: r}
+}
+
+type remoteMigrationCutoverRepository struct {
+	*Repository
+}
+
+func (r *remoteMigrationCutoverRepository) Create(ctx context.Context, cutover *model.RemoteMigrationCutover) error {
+	return r.DB(ctx).Create(cutover).Error
+}
+
+func (r *remoteMigrationCutoverRepository) Update(ctx context.Context, cutover *model.RemoteMigrationCutover) error {
+	return r.DB(ctx).Save(cutover).Error
+}
+
+func (r *remoteMigrationCutoverRepository) GetByID(ctx context.Context, id int64) (*model.RemoteMigrationCutover, error) {
+	var cutover model.RemoteMigrationCutover
+	if err := r.DB(ctx).Where("id = ?", id).First(&cutover).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cutover, nil
+}
+
+func (r *remoteMigrationCutoverRepository) List(ctx context.Context, page, pageSize int, vmID int64, status string) ([]*model.RemoteMigrationCutover, int64, error) {
+	var cutovers []*model.RemoteMigrationCutover
+	var total int64
+
+	query := r.DB(ctx).Model(&model.RemoteMigrationCutover{})
+	if vmID > 0 {
+		query = query.Where("vm_id = ?", vmID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&cutovers).Error; err != nil {
+		return nil, 0, err
+	}
+	return cutovers, total, nil
+}
+
+func (r *remoteMigrationCutoverRepository) ListWaiting(ctx context.Context, limit int) ([]*model.RemoteMigrationCutover, error) {
+	var cutovers []*model.RemoteMigrationCutover
+	err := r.DB(ctx).
+		Where("status = ?", model.RemoteMigrationCutoverWaiting).
+		Order("id ASC").
+		Limit(limit).
+		Find(&cutovers).Error
+	if err != nil {
+		return nil, err
+	}
+	return cutovers, nil
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ReportRepository interface {
+	CreateSchedule(ctx context.Context, schedule *model.ReportSchedule) error
+	UpdateSchedule(ctx context.Context, schedule *model.ReportSchedule) error
+	// DeleteSchedule 删除计划及其发送记录
+	DeleteSchedule(ctx context.Context, id int64) error
+	GetSchedule(ctx context.Context, id int64) (*model.ReportSchedule, error)
+	GetScheduleByName(ctx context.Context, name string) (*model.ReportSchedule, error)
+	ListSchedules(ctx context.Context, page, pageSize int) ([]*model.ReportSchedule, int64, error)
+	// ListDue 返回已启用且下次发送时间不晚于 now 的计划
+	ListDue(ctx context.Context, now time.Time) ([]*model.ReportSchedule, error)
+	// ClaimDue 仅当下次发送时间仍为 expected 时更新为 next，返回是否更新成功；多实例部署时只有一个实例发送
+	ClaimDue(ctx context.Context, id int64, expected, next time.Time) (bool, error)
+
+	CreateRun(ctx context.Context, run *model.ReportRun) error
+	GetRun(ctx context.Context, id int64) (*model.ReportRun, error)
+	// ListRuns 按时间倒序返回计划的发送记录，不含附件内容
+	ListRuns(ctx context.Context, scheduleID int64, page, pageSize int) ([]*model.ReportRun, int64, error)
+	// CleanupRuns 删除 before 之前的发送记录
+	CleanupRuns(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewReportRepository(r *Repository) ReportRepository {
+	return &reportRepository{Repository: r}
+}
+
+type reportRepository struct {
+	*Repository
+}
+
+func (r *reportRepository) CreateSchedule(ctx context.Context, schedule *model.ReportSchedule) error {
+	return r.DB(ctx).Create(schedule).Error
+}
+
+func (r *reportRepository) UpdateSchedule(ctx context.Context, schedule *model.ReportSchedule) error {
+	return r.DB(ctx).Save(schedule).Error
+}
+
+func (r *reportRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		if err := r.DB(ctx).Where("schedule_id = ?", id).Delete(&model.ReportRun{}).Error; err != nil {
+			return err
+		}
+		return r.DB(ctx).Where("id = ?", id).Delete(&model.ReportSchedule{}).Error
+	})
+}
+
+func (r *reportRepository) GetSchedule(ctx context.Context, id int64) (*model.ReportSchedule, error) {
+	var schedule model.ReportSchedule
+	if err := r.DB(ctx).Where("id = ?", id).First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *reportRepository) GetScheduleByName(ctx context.Context, name string) (*model.ReportSchedule, error) {
+	var schedule model.ReportSchedule
+	if err := r.DB(ctx).Where("name = ?", name).First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *reportRepository) ListSchedules(ctx context.Context, page, pageSize int) ([]*model.ReportSchedule, int64, error) {
+	var schedules []*model.ReportSchedule
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ReportSchedule{})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&schedules).Error; err != nil {
+		return nil, 0, err
+	}
+	return schedules, total, nil
+}
+
+func (r *reportRepository) ListDue(ctx context.Context, now time.Time) ([]*model.ReportSchedule, error) {
+	var schedules []*model.ReportSchedule
+	err := r.DB(ctx).
+		Where("is_enabled = ? AND next_run_time IS NOT NULL AND next_run_time <= ?", 1, now).
+		Order("next_run_time ASC").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (r *reportRepository) ClaimDue(ctx context.Context, id int64, expected, next time.Time) (bool, error) {
+	result := r.DB(ctx).Model(&model.ReportSchedule{}).
+		Where("id = ? AND next_run_time = ?", id, expected).
+		Updates(map[string]interface{}{"next_run_time": next, "last_run_time": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *reportRepository) CreateRun(ctx context.Context, run *model.ReportRun) error {
+	return r.DB(ctx).Create(run).Error
+}
+
+func (r *reportRepository) GetRun(ctx context.Context, id int64) (*model.ReportRun, error) {
+	var run model.ReportRun
+	if err := r.DB(ctx).Where("id = ?", id).First(&run).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &run, nil
+}
+
+func (r *reportRepository) ListRuns(ctx context.Context, scheduleID int64, page, pageSize int) ([]*model.ReportRun, int64, error) {
+	var runs []*model.ReportRun
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ReportRun{}).Where("schedule_id = ?", scheduleID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Omit("attachment").Order("id DESC").Offset(offset).Limit(pageSize).Find(&runs).Error; err != nil {
+		return nil, 0, err
+	}
+	return runs, total, nil
+}
+
+func (r *reportRepository) CleanupRuns(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("gmt_create < ?", before).Delete(&model.ReportRun{})
+	return result.RowsAffected, result.Error
+}
@@ -3,8 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"pvesphere/pkg/log"
+	"pvesphere/pkg/tracing"
 	"pvesphere/pkg/zapgorm2"
+	"strings"
 	"time"
 
 	"github.com/glebarez/sqlite"
@@ -49,6 +53,27 @@ func NewTransaction(r *Repository) Transaction {
 	return r
 }
 
+// 支持的数据库类型（与 gorm Dialector.Name() 一致）
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+// Dialect 返回当前数据库类型
+func (r *Repository) Dialect() string {
+	return r.db.Dialector.Name()
+}
+
+// like 返回不区分大小写的模糊匹配条件，如 "name LIKE ?"
+// MySQL（默认排序规则）和 SQLite 的 LIKE 本身不区分大小写，PostgreSQL 需使用 ILIKE
+func (r *Repository) like(column string) string {
+	if r.Dialect() == DialectPostgres {
+		return column + " ILIKE ?"
+	}
+	return column + " LIKE ?"
+}
+
 // DB return tx
 // If you need to create a Transaction, you must call DB(ctx) and Transaction(ctx,fn)
 func (r *Repository) DB(ctx context.Context) *gorm.DB {
@@ -74,31 +99,40 @@ func NewDB(conf *viper.Viper, l *log.Logger) *gorm.DB {
 		err error
 	)
 
-	logger := zapgorm2.New(l.Logger)
 	driver := conf.GetString("data.db.user.driver")
 	dsn := conf.GetString("data.db.user.dsn")
+	gormConf := &gorm.Config{
+		Logger: zapgorm2.New(l.Logger),
+		// MySQL datetime(3) 只保留毫秒，统一截断，保证不同数据库写入后读出的时间一致
+		NowFunc: func() time.Time {
+			return time.Now().Truncate(time.Millisecond)
+		},
+	}
 
 	// GORM doc: https://gorm.io/docs/connecting_to_the_database.html
 	switch driver {
-	case "mysql":
-		db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-			Logger: logger,
-		})
-	case "postgres":
+	case DialectMySQL:
+		db, err = gorm.Open(mysql.Open(dsn), gormConf)
+	case DialectPostgres, "postgresql":
 		db, err = gorm.Open(postgres.New(postgres.Config{
 			DSN:                  dsn,
 			PreferSimpleProtocol: true, // disables implicit prepared statement usage
-		}), &gorm.Config{})
-	case "sqlite":
-		db, err = gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+		}), gormConf)
+	case DialectSQLite:
+		db, err = gorm.Open(sqlite.Open(sqliteDSN(dsn)), gormConf)
 	default:
-		panic("unknown db driver")
+		panic(fmt.Sprintf("unknown db driver: %s", driver))
 	}
 	if err != nil {
 		panic(err)
 	}
 	db = db.Debug()
 
+	// 为 repository 查询创建 span（仅在请求链路中生效）
+	if err := db.Use(tracing.NewGormPlugin()); err != nil {
+		panic(err)
+	}
+
 	// Connection Pool config
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -109,6 +143,35 @@ func NewDB(conf *viper.Viper, l *log.Logger) *gorm.DB {
 	sqlDB.SetConnMaxLifetime(time.Hour)
 	return db
 }
+
+// sqliteDSN 为嵌入式 SQLite 补齐默认参数，并自动创建数据库文件所在目录
+// server、controller 等在同一进程内并发写入，需开启 WAL 并设置 busy_timeout 以避免 "database is locked"
+func sqliteDSN(dsn string) string {
+	path, query, _ := strings.Cut(dsn, "?")
+	if path != "" && path != ":memory:" && !strings.HasPrefix(path, "file:") {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	pragmas := []string{}
+	if !strings.Contains(query, "busy_timeout(") {
+		pragmas = append(pragmas, "_pragma=busy_timeout(5000)")
+	}
+	if !strings.Contains(query, "journal_mode(") {
+		pragmas = append(pragmas, "_pragma=journal_mode(WAL)")
+	}
+	if len(pragmas) == 0 {
+		return dsn
+	}
+	if query != "" {
+		pragmas = append([]string{query}, pragmas...)
+	}
+	return path + "?" + strings.Join(pragmas, "&")
+}
+
 func NewRedis(conf *viper.Viper) *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     conf.GetString("data.redis.addr"),
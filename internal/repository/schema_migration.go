@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"context"
+
+	"pvesphere/internal/migration"
+)
+
+type SchemaMigrationRepository interface {
+	// Status 当前表结构版本及全部迁移的执行情况
+	Status(ctx context.Context) (int64, []migration.Status, error)
+}
+
+func NewSchemaMigrationRepository(r *Repository) SchemaMigrationRepository {
+	return &schemaMigrationRepository{Repository: r}
+}
+
+type schemaMigrationRepository struct {
+	*Repository
+}
+
+func (r *schemaMigrationRepository) Status(ctx context.Context) (int64, []migration.Status, error) {
+	return migration.GetStatus(ctx, r.DB(ctx))
+}
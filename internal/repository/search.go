@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"pvesphere/internal/model"
+)
+
+// SearchRepository 全局搜索（跨集群的模糊匹配查询）
+type SearchRepository interface {
+	SearchVMs(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.PveVM, error)
+	SearchVMIPAddresses(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.VMIPAddress, error)
+	SearchNodes(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.PveNode, error)
+	SearchTemplates(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.VmTemplate, error)
+	SearchStorages(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.PveStorage, error)
+}
+
+func NewSearchRepository(r *Repository) SearchRepository {
+	return &searchRepository{Repository: r}
+}
+
+type searchRepository struct {
+	*Repository
+}
+
+// SearchVMs 按名称、VMID、描述匹配虚拟机（不含模板虚拟机）
+func (r *searchRepository) SearchVMs(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.PveVM, error) {
+	var vms []*model.PveVM
+	like := "%" + keyword + "%"
+
+	query := r.DB(ctx).Model(&model.PveVM{}).Where("is_template = ?", 0)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if vmid, err := strconv.ParseUint(keyword, 10, 32); err == nil {
+		query = query.Where(r.like("vm_name")+" OR "+r.like("descriptions")+" OR vmid = ?", like, like, vmid)
+	} else {
+		query = query.Where(r.like("vm_name")+" OR "+r.like("descriptions"), like, like)
+	}
+
+	if err := query.Limit(limit).Order("id DESC").Find(&vms).Error; err != nil {
+		return nil, err
+	}
+	return vms, nil
+}
+
+// SearchVMIPAddresses 按 IP 地址匹配虚拟机网卡
+func (r *searchRepository) SearchVMIPAddresses(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.VMIPAddress, error) {
+	var ips []*model.VMIPAddress
+
+	query := r.DB(ctx).Model(&model.VMIPAddress{}).Where("ip_address LIKE ?", keyword+"%")
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+
+	if err := query.Limit(limit).Order("id DESC").Find(&ips).Error; err != nil {
+		return nil, err
+	}
+	return ips, nil
+}
+
+// SearchNodes 按节点名称、IP 匹配节点
+func (r *searchRepository) SearchNodes(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.PveNode, error) {
+	var nodes []*model.PveNode
+
+	query := r.DB(ctx).Model(&model.PveNode{}).Where(r.like("node_name")+" OR ip_address LIKE ?", "%"+keyword+"%", keyword+"%")
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+
+	if err := query.Limit(limit).Order("id DESC").Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// SearchTemplates 按模板名称、描述匹配模板
+func (r *searchRepository) SearchTemplates(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.VmTemplate, error) {
+	var templates []*model.VmTemplate
+	like := "%" + keyword + "%"
+
+	query := r.DB(ctx).Model(&model.VmTemplate{}).Where(r.like("template_name")+" OR "+r.like("description"), like, like)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+
+	if err := query.Limit(limit).Order("id DESC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// SearchStorages 按存储名称、类型匹配存储（每个节点一条记录）
+func (r *searchRepository) SearchStorages(ctx context.Context, keyword string, clusterID int64, limit int) ([]*model.PveStorage, error) {
+	var storages []*model.PveStorage
+	like := "%" + keyword + "%"
+
+	query := r.DB(ctx).Model(&model.PveStorage{}).Where(r.like("storage_name")+" OR "+r.like("type"), like, like)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+
+	if err := query.Limit(limit).Order("id DESC").Find(&storages).Error; err != nil {
+		return nil, err
+	}
+	return storages, nil
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+
+	"pvesphere/internal/model"
+)
+
+type SecretAuditRepository interface {
+	Create(ctx context.Context, log *model.SecretAuditLog) error
+	ListWithPagination(ctx context.Context, page, pageSize int, vmID int64, action, operator string) ([]*model.SecretAuditLog, int64, error)
+}
+
+func NewSecretAuditRepository(r *Repository) SecretAuditRepository {
+	return &secretAuditRepository{Repository: r}
+}
+
+type secretAuditRepository struct {
+	*Repository
+}
+
+func (r *secretAuditRepository) Create(ctx context.Context, log *model.SecretAuditLog) error {
+	return r.DB(ctx).Create(log).Error
+}
+
+func (r *secretAuditRepository) ListWithPagination(ctx context.Context, page, pageSize int, vmID int64, action, operator string) ([]*model.SecretAuditLog, int64, error) {
+	var logs []*model.SecretAuditLog
+	var total int64
+
+	query := r.DB(ctx).Model(&model.SecretAuditLog{})
+	if vmID > 0 {
+		query = query.Where("vm_id = ?", vmID)
+	}
+	if action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if operator != "" {
+		query = query.Where("operator = ?", operator)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("id DESC").Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
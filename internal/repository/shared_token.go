@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type SharedTokenRepository interface {
+	Create(ctx context.Context, token *model.SharedToken) error
+	// Get 按类型和哈希查询令牌（含已过期的），不存在时返回 nil
+	Get(ctx context.Context, kind, tokenHash string) (*model.SharedToken, error)
+	// Update 更新令牌内容和过期时间，令牌不存在时返回 false
+	Update(ctx context.Context, kind, tokenHash, payload string, expiresAt time.Time) (bool, error)
+	// Delete 删除令牌，返回是否由本次调用删除（并发消费同一令牌时只有一个调用返回 true）
+	Delete(ctx context.Context, kind, tokenHash string) (bool, error)
+	// DeleteExpired 删除过期时间早于 before 的令牌
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewSharedTokenRepository(r *Repository) SharedTokenRepository {
+	return &sharedTokenRepository{Repository: r}
+}
+
+type sharedTokenRepository struct {
+	*Repository
+}
+
+func (r *sharedTokenRepository) Create(ctx context.Context, token *model.SharedToken) error {
+	return r.DB(ctx).Create(token).Error
+}
+
+func (r *sharedTokenRepository) Get(ctx context.Context, kind, tokenHash string) (*model.SharedToken, error) {
+	var token model.SharedToken
+	if err := r.DB(ctx).Where("kind = ? AND token_hash = ?", kind, tokenHash).First(&token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *sharedTokenRepository) Update(ctx context.Context, kind, tokenHash, payload string, expiresAt time.Time) (bool, error) {
+	result := r.DB(ctx).Model(&model.SharedToken{}).Where("kind = ? AND token_hash = ?", kind, tokenHash).
+		Updates(map[string]interface{}{"payload": payload, "expires_at": expiresAt})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *sharedTokenRepository) Delete(ctx context.Context, kind, tokenHash string) (bool, error) {
+	result := r.DB(ctx).Where("kind = ? AND token_hash = ?", kind, tokenHash).Delete(&model.SharedToken{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *sharedTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("expires_at < ?", before).Delete(&model.SharedToken{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type StorageGCRepository interface {
+	CreateScan(ctx context.Context, scan *model.StorageGCScan) error
+	UpdateScan(ctx context.Context, scan *model.StorageGCScan) error
+	GetScanByID(ctx context.Context, id int64) (*model.StorageGCScan, error)
+	ListScans(ctx context.Context, page, pageSize int, clusterID int64) ([]*model.StorageGCScan, int64, error)
+	CreateItems(ctx context.Context, items []*model.StorageGCItem) error
+	GetItemsByIDs(ctx context.Context, ids []int64) ([]*model.StorageGCItem, error)
+	ListItems(ctx context.Context, page, pageSize int, scanID int64, reason, status string) ([]*model.StorageGCItem, int64, error)
+	UpdateItemStatus(ctx context.Context, id int64, status, reviewer, errorMsg string) error
+}
+
+func NewStorageGCRepository(r *Repository) StorageGCRepository {
+	return &storageGCRepository{Repository: r}
+}
+
+type storageGCRepository struct {
+	*Repository
+}
+
+func (r *storageGCRepository) CreateScan(ctx context.Context, scan *model.StorageGCScan) error {
+	return r.DB(ctx).Create(scan).Error
+}
+
+func (r *storageGCRepository) UpdateScan(ctx context.Context, scan *model.StorageGCScan) error {
+	return r.DB(ctx).Save(scan).Error
+}
+
+func (r *storageGCRepository) GetScanByID(ctx context.Context, id int64) (*model.StorageGCScan, error) {
+	var scan model.StorageGCScan
+	if err := r.DB(ctx).Where("id = ?", id).First(&scan).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &scan, nil
+}
+
+func (r *storageGCRepository) ListScans(ctx context.Context, page, pageSize int, clusterID int64) ([]*model.StorageGCScan, int64, error) {
+	var scans []*model.StorageGCScan
+	var total int64
+
+	query := r.DB(ctx).Model(&model.StorageGCScan{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&scans).Error; err != nil {
+		return nil, 0, err
+	}
+	return scans, total, nil
+}
+
+func (r *storageGCRepository) CreateItems(ctx context.Context, items []*model.StorageGCItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	return r.DB(ctx).CreateInBatches(items, 100).Error
+}
+
+func (r *storageGCRepository) GetItemsByIDs(ctx context.Context, ids []int64) ([]*model.StorageGCItem, error) {
+	var items []*model.StorageGCItem
+	if len(ids) == 0 {
+		return items, nil
+	}
+	if err := r.DB(ctx).Where("id IN ?", ids).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *storageGCRepository) ListItems(ctx context.Context, page, pageSize int, scanID int64, reason, status string) ([]*model.StorageGCItem, int64, error) {
+	var items []*model.StorageGCItem
+	var total int64
+
+	query := r.DB(ctx).Model(&model.StorageGCItem{})
+	if scanID > 0 {
+		query = query.Where("scan_id = ?", scanID)
+	}
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("size DESC, id ASC").Offset(offset).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *storageGCRepository) UpdateItemStatus(ctx context.Context, id int64, status, reviewer, errorMsg string) error {
+	updates := map[string]interface{}{
+		"status":        status,
+		"error_message": errorMsg,
+	}
+	if reviewer != "" {
+		updates["reviewer"] = reviewer
+	}
+	return r.DB(ctx).Model(&model.StorageGCItem{}).Where("id = ?", id).Updates(updates).Error
+}
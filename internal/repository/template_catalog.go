@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type TemplateCatalogRepository interface {
+	CreateCatalog(ctx context.Context, catalog *model.TemplateCatalog) error
+	UpdateCatalog(ctx context.Context, catalog *model.TemplateCatalog) error
+	DeleteCatalog(ctx context.Context, id int64) error
+	GetCatalogByID(ctx context.Context, id int64) (*model.TemplateCatalog, error)
+	GetCatalogByName(ctx context.Context, name string) (*model.TemplateCatalog, error)
+	ListCatalogs(ctx context.Context) ([]*model.TemplateCatalog, error)
+
+	// ReplaceImages 删除目录下原有镜像并写入新镜像，需在事务中调用
+	ReplaceImages(ctx context.Context, catalogID int64, images []*model.TemplateCatalogImage) error
+	GetImageByID(ctx context.Context, id int64) (*model.TemplateCatalogImage, error)
+	ListImages(ctx context.Context, catalogID int64, keyword, osType string) ([]*model.TemplateCatalogImage, error)
+
+	CreateInstall(ctx context.Context, install *model.TemplateCatalogInstall) error
+	UpdateInstall(ctx context.Context, install *model.TemplateCatalogInstall) error
+	GetInstallByID(ctx context.Context, id int64) (*model.TemplateCatalogInstall, error)
+	ListInstalls(ctx context.Context, page, pageSize int, catalogID, clusterID int64, status string) ([]*model.TemplateCatalogInstall, int64, error)
+	CountActiveInstallsByCatalog(ctx context.Context, catalogID int64) (int64, error)
+}
+
+func NewTemplateCatalogRepository(r *Repository) TemplateCatalogRepository {
+	return &templateCatalogRepository{Repository: r}
+}
+
+type templateCatalogRepository struct {
+	*Repository
+}
+
+func (r *templateCatalogRepository) CreateCatalog(ctx context.Context, catalog *model.TemplateCatalog) error {
+	return r.DB(ctx).Create(catalog).Error
+}
+
+func (r *templateCatalogRepository) UpdateCatalog(ctx context.Context, catalog *model.TemplateCatalog) error {
+	return r.DB(ctx).Save(catalog).Error
+}
+
+func (r *templateCatalogRepository) DeleteCatalog(ctx context.Context, id int64) error {
+	if err := r.DB(ctx).Where("catalog_id = ?", id).Delete(&model.TemplateCatalogImage{}).Error; err != nil {
+		return err
+	}
+	return r.DB(ctx).Delete(&model.TemplateCatalog{}, id).Error
+}
+
+func (r *templateCatalogRepository) GetCatalogByID(ctx context.Context, id int64) (*model.TemplateCatalog, error) {
+	var catalog model.TemplateCatalog
+	if err := r.DB(ctx).Where("id = ?", id).First(&catalog).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+func (r *templateCatalogRepository) GetCatalogByName(ctx context.Context, name string) (*model.TemplateCatalog, error) {
+	var catalog model.TemplateCatalog
+	if err := r.DB(ctx).Where("name = ?", name).First(&catalog).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+func (r *templateCatalogRepository) ListCatalogs(ctx context.Context) ([]*model.TemplateCatalog, error) {
+	var catalogs []*model.TemplateCatalog
+	if err := r.DB(ctx).Order("id ASC").Find(&catalogs).Error; err != nil {
+		return nil, err
+	}
+	return catalogs, nil
+}
+
+func (r *templateCatalogRepository) ReplaceImages(ctx context.Context, catalogID int64, images []*model.TemplateCatalogImage) error {
+	if err := r.DB(ctx).Where("catalog_id = ?", catalogID).Delete(&model.TemplateCatalogImage{}).Error; err != nil {
+		return err
+	}
+	if len(images) == 0 {
+		return nil
+	}
+	return r.DB(ctx).CreateInBatches(images, 100).Error
+}
+
+func (r *templateCatalogRepository) GetImageByID(ctx context.Context, id int64) (*model.TemplateCatalogImage, error) {
+	var image model.TemplateCatalogImage
+	if err := r.DB(ctx).Where("id = ?", id).First(&image).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *templateCatalogRepository) ListImages(ctx context.Context, catalogID int64, keyword, osType string) ([]*model.TemplateCatalogImage, error) {
+	var images []*model.TemplateCatalogImage
+	query := r.DB(ctx).Model(&model.TemplateCatalogImage{})
+	if catalogID > 0 {
+		query = query.Where("catalog_id = ?", catalogID)
+	}
+	if keyword != "" {
+		like := "%" + keyword + "%"
+		query = query.Where(r.like("name")+" OR "+r.like("description"), like, like)
+	}
+	if osType != "" {
+		query = query.Where("os_type = ?", osType)
+	}
+	if err := query.Order("catalog_id ASC, name ASC, version DESC").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r *templateCatalogRepository) CreateInstall(ctx context.Context, install *model.TemplateCatalogInstall) error {
+	return r.DB(ctx).Create(install).Error
+}
+
+func (r *templateCatalogRepository) UpdateInstall(ctx context.Context, install *model.TemplateCatalogInstall) error {
+	return r.DB(ctx).Save(install).Error
+}
+
+func (r *templateCatalogRepository) GetInstallByID(ctx context.Context, id int64) (*model.TemplateCatalogInstall, error) {
+	var install model.TemplateCatalogInstall
+	if err := r.DB(ctx).Where("id = ?", id).First(&install).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &install, nil
+}
+
+func (r *templateCatalogRepository) ListInstalls(ctx context.Context, page, pageSize int, catalogID, clusterID int64, status string) ([]*model.TemplateCatalogInstall, int64, error) {
+	var installs []*model.TemplateCatalogInstall
+	var total int64
+
+	query := r.DB(ctx).Model(&model.TemplateCatalogInstall{})
+	if catalogID > 0 {
+		query = query.Where("catalog_id = ?", catalogID)
+	}
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&installs).Error; err != nil {
+		return nil, 0, err
+	}
+	return installs, total, nil
+}
+
+func (r *templateCatalogRepository) CountActiveInstallsByCatalog(ctx context.Context, catalogID int64) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.TemplateCatalogInstall{}).
+		Where("catalog_id = ? AND status IN ?", catalogID, []string{model.TemplateCatalogInstallPending, model.TemplateCatalogInstallRunning}).
+		Count(&count).Error
+	return count, err
+}
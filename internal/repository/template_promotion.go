@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type TemplatePromotionRepository interface {
+	Create(ctx context.Context, promotion *model.TemplatePromotion) error
+	Update(ctx context.Context, promotion *model.TemplatePromotion) error
+	GetByID(ctx context.Context, id int64) (*model.TemplatePromotion, error)
+	// GetByPromotedTemplate 查询产生该模板的晋级记录
+	GetByPromotedTemplate(ctx context.Context, templateID int64) (*model.TemplatePromotion, error)
+	// GetActive 查询模板晋级到目标集群的待审批或复制中的记录
+	GetActive(ctx context.Context, templateID, targetClusterID int64) (*model.TemplatePromotion, error)
+	// List templateID、clusterID 分别匹配源模板或晋级产生的模板、源集群或目标集群
+	List(ctx context.Context, page, pageSize int, templateID, clusterID int64, status string) ([]*model.TemplatePromotion, int64, error)
+}
+
+func NewTemplatePromotionRepository(r *Repository) TemplatePromotionRepository {
+	return &templatePromotionRepository{Repository: r}
+}
+
+type templatePromotionRepository struct {
+	*Repository
+}
+
+func (r *templatePromotionRepository) Create(ctx context.Context, promotion *model.TemplatePromotion) error {
+	return r.DB(ctx).Create(promotion).Error
+}
+
+func (r *templatePromotionRepository) Update(ctx context.Context, promotion *model.TemplatePromotion) error {
+	return r.DB(ctx).Save(promotion).Error
+}
+
+func (r *templatePromotionRepository) GetByID(ctx context.Context, id int64) (*model.TemplatePromotion, error) {
+	return r.first(r.DB(ctx).Where("id = ?", id))
+}
+
+func (r *templatePromotionRepository) GetByPromotedTemplate(ctx context.Context, templateID int64) (*model.TemplatePromotion, error) {
+	return r.first(r.DB(ctx).Where("promoted_template_id = ? AND status = ?", templateID, model.TemplatePromotionStatusCompleted))
+}
+
+func (r *templatePromotionRepository) GetActive(ctx context.Context, templateID, targetClusterID int64) (*model.TemplatePromotion, error) {
+	return r.first(r.DB(ctx).Where("template_id = ? AND target_cluster_id = ? AND status IN ?", templateID, targetClusterID,
+		[]string{model.TemplatePromotionStatusPending, model.TemplatePromotionStatusReplicating}))
+}
+
+func (r *templatePromotionRepository) first(query *gorm.DB) (*model.TemplatePromotion, error) {
+	var promotion model.TemplatePromotion
+	if err := query.Order("id DESC").First(&promotion).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &promotion, nil
+}
+
+func (r *templatePromotionRepository) List(ctx context.Context, page, pageSize int, templateID, clusterID int64, status string) ([]*model.TemplatePromotion, int64, error) {
+	var promotions []*model.TemplatePromotion
+	var total int64
+
+	query := r.DB(ctx).Model(&model.TemplatePromotion{})
+	if templateID > 0 {
+		query = query.Where("template_id = ? OR promoted_template_id = ?", templateID, templateID)
+	}
+	if clusterID > 0 {
+		query = query.Where("source_cluster_id = ? OR target_cluster_id = ?", clusterID, clusterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&promotions).Error; err != nil {
+		return nil, 0, err
+	}
+	return promotions, total, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/model"
+)
+
+type TemplateUsageRepository interface {
+	Create(ctx context.Context, log *model.TemplateUsageLog) error
+	Summarize(ctx context.Context, templateIDs []int64, since time.Time) ([]*TemplateUsageSummary, error) // 按模板和版本汇总克隆次数
+	GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.TemplateUsageLog, error)
+	ListRecent(ctx context.Context, templateID int64, limit int) ([]*model.TemplateUsageLog, error)
+}
+
+// TemplateUsageSummary 单个模板版本的克隆统计
+type TemplateUsageSummary struct {
+	TemplateID  int64
+	UploadID    int64
+	Count       int64
+	RecentCount int64 // since 之后的克隆次数
+	LastID      int64 // 最近一次克隆记录的 ID
+}
+
+func NewTemplateUsageRepository(r *Repository) TemplateUsageRepository {
+	return &templateUsageRepository{Repository: r}
+}
+
+type templateUsageRepository struct {
+	*Repository
+}
+
+func (r *templateUsageRepository) Create(ctx context.Context, log *model.TemplateUsageLog) error {
+	return r.DB(ctx).Create(log).Error
+}
+
+func (r *templateUsageRepository) Summarize(ctx context.Context, templateIDs []int64, since time.Time) ([]*TemplateUsageSummary, error) {
+	var rows []*TemplateUsageSummary
+	if len(templateIDs) == 0 {
+		return rows, nil
+	}
+	// 最近一次克隆取 MAX(id) 再回查记录，避免各数据库 MAX(datetime) 返回类型不一致
+	err := r.DB(ctx).Model(&model.TemplateUsageLog{}).
+		Select("template_id, upload_id, COUNT(*) AS count, "+
+			"SUM(CASE WHEN gmt_create >= ? THEN 1 ELSE 0 END) AS recent_count, MAX(id) AS last_id", since).
+		Where("template_id IN ?", templateIDs).
+		Group("template_id, upload_id").
+		Scan(&rows).Error
+	return rows, err
+}
+
+func (r *templateUsageRepository) GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.TemplateUsageLog, error) {
+	result := make(map[int64]*model.TemplateUsageLog, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	var logs []*model.TemplateUsageLog
+	if err := r.DB(ctx).Where("id IN ?", ids).Find(&logs).Error; err != nil {
+		return nil, err
+	}
+	for _, l := range logs {
+		result[l.Id] = l
+	}
+	return result, nil
+}
+
+func (r *templateUsageRepository) ListRecent(ctx context.Context, templateID int64, limit int) ([]*model.TemplateUsageLog, error) {
+	var logs []*model.TemplateUsageLog
+	err := r.DB(ctx).Where("template_id = ?", templateID).Order("id DESC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
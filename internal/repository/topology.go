@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"pvesphere/internal/model"
+)
+
+// TopologySnapshot 拓扑图所需的集群、节点、虚拟机和存储
+type TopologySnapshot struct {
+	Clusters []*model.PveCluster
+	Nodes    []*model.PveNode
+	VMs      []*model.PveVM
+	Storages []*model.PveStorage
+}
+
+type TopologyRepository interface {
+	// Load 每类资源一次查询，clusterID 为 0 时加载全部集群
+	Load(ctx context.Context, clusterID int64, includeTemplates bool) (*TopologySnapshot, error)
+}
+
+func NewTopologyRepository(r *Repository) TopologyRepository {
+	return &topologyRepository{Repository: r}
+}
+
+type topologyRepository struct {
+	*Repository
+}
+
+func (r *topologyRepository) Load(ctx context.Context, clusterID int64, includeTemplates bool) (*TopologySnapshot, error) {
+	snapshot := &TopologySnapshot{}
+	db := r.DB(ctx)
+
+	clusters := db.Model(&model.PveCluster{})
+	if clusterID > 0 {
+		clusters = clusters.Where("id = ?", clusterID)
+	}
+	if err := clusters.Order("id ASC").Find(&snapshot.Clusters).Error; err != nil {
+		return nil, err
+	}
+
+	nodes := db.Model(&model.PveNode{})
+	if clusterID > 0 {
+		nodes = nodes.Where("cluster_id = ?", clusterID)
+	}
+	if err := nodes.Order("cluster_id ASC, node_name ASC").Find(&snapshot.Nodes).Error; err != nil {
+		return nil, err
+	}
+
+	// 虚拟机只取拓扑图需要的列
+	vms := db.Model(&model.PveVM{}).
+		Select("id, vm_name, node_id, vmid, cpu_num, memory_size, storages, cluster_id, status, is_template, owner")
+	if clusterID > 0 {
+		vms = vms.Where("cluster_id = ?", clusterID)
+	}
+	if !includeTemplates {
+		vms = vms.Where("is_template = 0")
+	}
+	if err := vms.Order("cluster_id ASC, vmid ASC").Find(&snapshot.VMs).Error; err != nil {
+		return nil, err
+	}
+
+	storages := db.Model(&model.PveStorage{})
+	if clusterID > 0 {
+		storages = storages.Where("cluster_id = ?", clusterID)
+	}
+	if err := storages.Order("cluster_id ASC, storage_name ASC, node_name ASC").Find(&snapshot.Storages).Error; err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
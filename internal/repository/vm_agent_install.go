@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VMAgentInstallRepository interface {
+	Create(ctx context.Context, install *model.VMAgentInstall) error
+	Update(ctx context.Context, install *model.VMAgentInstall) error
+	GetByID(ctx context.Context, id int64) (*model.VMAgentInstall, error)
+	// GetLatestByVM 虚拟机最近一次安装记录
+	GetLatestByVM(ctx context.Context, vmID int64) (*model.VMAgentInstall, error)
+	List(ctx context.Context, page, pageSize int, clusterID int64, status string) ([]*model.VMAgentInstall, int64, error)
+	// ListUnfinished 列出等待 agent 响应或结束后尚未清理安装介质的记录
+	ListUnfinished(ctx context.Context, limit int) ([]*model.VMAgentInstall, error)
+}
+
+func NewVMAgentInstallRepository(r *Repository) VMAgentInstallRepository {
+	return &vmAgentInstallRepository{Repository: r}
+}
+
+type vmAgentInstallRepository struct {
+	*Repository
+}
+
+func (r *vmAgentInstallRepository) Create(ctx context.Context, install *model.VMAgentInstall) error {
+	return r.DB(ctx).Create(install).Error
+}
+
+func (r *vmAgentInstallRepository) Update(ctx context.Context, install *model.VMAgentInstall) error {
+	return r.DB(ctx).Save(install).Error
+}
+
+func (r *vmAgentInstallRepository) GetByID(ctx context.Context, id int64) (*model.VMAgentInstall, error) {
+	var install model.VMAgentInstall
+	if err := r.DB(ctx).Where("id = ?", id).First(&install).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &install, nil
+}
+
+func (r *vmAgentInstallRepository) GetLatestByVM(ctx context.Context, vmID int64) (*model.VMAgentInstall, error) {
+	var install model.VMAgentInstall
+	if err := r.DB(ctx).Where("vm_id = ?", vmID).Order("id DESC").First(&install).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &install, nil
+}
+
+func (r *vmAgentInstallRepository) List(ctx context.Context, page, pageSize int, clusterID int64, status string) ([]*model.VMAgentInstall, int64, error) {
+	var installs []*model.VMAgentInstall
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VMAgentInstall{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&installs).Error; err != nil {
+		return nil, 0, err
+	}
+	return installs, total, nil
+}
+
+func (r *vmAgentInstallRepository) ListUnfinished(ctx context.Context, limit int) ([]*model.VMAgentInstall, error) {
+	var installs []*model.VMAgentInstall
+	err := r.DB(ctx).
+		Where("status = ? OR cleaned = ?", model.VMAgentInstallStatusWaiting, false).
+		Order("id ASC").Limit(limit).Find(&installs).Error
+	return installs, err
+}
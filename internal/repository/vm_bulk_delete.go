@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VMBulkDeleteJobRepository interface {
+	Create(ctx context.Context, job *model.VMBulkDeleteJob) error
+	Update(ctx context.Context, job *model.VMBulkDeleteJob) error
+	GetByID(ctx context.Context, id int64) (*model.VMBulkDeleteJob, error)
+	List(ctx context.Context, page, pageSize int, status, creator string) ([]*model.VMBulkDeleteJob, int64, error)
+}
+
+func NewVMBulkDeleteJobRepository(r *Repository) VMBulkDeleteJobRepository {
+	return &vmBulkDeleteJobRepository{Repository: r}
+}
+
+type vmBulkDeleteJobRepository struct {
+	*Repository
+}
+
+func (r *vmBulkDeleteJobRepository) Create(ctx context.Context, job *model.VMBulkDeleteJob) error {
+	return r.DB(ctx).Create(job).Error
+}
+
+func (r *vmBulkDeleteJobRepository) Update(ctx context.Context, job *model.VMBulkDeleteJob) error {
+	return r.DB(ctx).Save(job).Error
+}
+
+func (r *vmBulkDeleteJobRepository) GetByID(ctx context.Context, id int64) (*model.VMBulkDeleteJob, error) {
+	var job model.VMBulkDeleteJob
+	if err := r.DB(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *vmBulkDeleteJobRepository) List(ctx context.Context, page, pageSize int, status, creator string) ([]*model.VMBulkDeleteJob, int64, error) {
+	var jobs []*model.VMBulkDeleteJob
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VMBulkDeleteJob{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if creator != "" {
+		query = query.Where("creator = ?", creator)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VMClaimRepository interface {
+	Save(ctx context.Context, item *model.UnclaimedVM) error
+	GetByID(ctx context.Context, id int64) (*model.UnclaimedVM, error)
+	Delete(ctx context.Context, id int64) error
+	ListByClusterID(ctx context.Context, clusterID int64) ([]*model.UnclaimedVM, error)
+	// ListWithPagination clusterID 为 0、reason 为空时不过滤，includeIgnored 为 false 时排除已忽略的
+	ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, reason string, includeIgnored bool) ([]*model.UnclaimedVM, int64, error)
+	// ListRemindable 返回未忽略、首次发现早于 before，且从未提醒或上次提醒早于 before 的待认领虚拟机
+	ListRemindable(ctx context.Context, before time.Time) ([]*model.UnclaimedVM, error)
+	MarkReminded(ctx context.Context, ids []int64, at time.Time) error
+}
+
+func NewVMClaimRepository(r *Repository) VMClaimRepository {
+	return &vmClaimRepository{Repository: r}
+}
+
+type vmClaimRepository struct {
+	*Repository
+}
+
+func (r *vmClaimRepository) Save(ctx context.Context, item *model.UnclaimedVM) error {
+	return r.DB(ctx).Save(item).Error
+}
+
+func (r *vmClaimRepository) GetByID(ctx context.Context, id int64) (*model.UnclaimedVM, error) {
+	var item model.UnclaimedVM
+	if err := r.DB(ctx).Where("id = ?", id).First(&item).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *vmClaimRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.UnclaimedVM{}).Error
+}
+
+func (r *vmClaimRepository) ListByClusterID(ctx context.Context, clusterID int64) ([]*model.UnclaimedVM, error) {
+	var items []*model.UnclaimedVM
+	if err := r.DB(ctx).Where("cluster_id = ?", clusterID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *vmClaimRepository) ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64, reason string, includeIgnored bool) ([]*model.UnclaimedVM, int64, error) {
+	var items []*model.UnclaimedVM
+	var total int64
+
+	query := r.DB(ctx).Model(&model.UnclaimedVM{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if reason != "" {
+		query = query.Where("reason = ?", reason)
+	}
+	if !includeIgnored {
+		query = query.Where("ignored = ?", false)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("first_seen ASC, id ASC").Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *vmClaimRepository) ListRemindable(ctx context.Context, before time.Time) ([]*model.UnclaimedVM, error) {
+	var items []*model.UnclaimedVM
+	if err := r.DB(ctx).
+		Where("ignored = ? AND first_seen <= ?", false, before).
+		Where("last_remind_at IS NULL OR last_remind_at <= ?", before).
+		Order("cluster_id ASC, vmid ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *vmClaimRepository) MarkReminded(ctx context.Context, ids []int64, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.DB(ctx).Model(&model.UnclaimedVM{}).Where("id IN ?", ids).Update("last_remind_at", at).Error
+}
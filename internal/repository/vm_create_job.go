@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VMCreateJobRepository interface {
+	Create(ctx context.Context, job *model.VMCreateJob) error
+	Update(ctx context.Context, job *model.VMCreateJob) error
+	GetByID(ctx context.Context, id int64) (*model.VMCreateJob, error)
+	List(ctx context.Context, page, pageSize int, clusterID int64, status, creator string) ([]*model.VMCreateJob, int64, error)
+}
+
+func NewVMCreateJobRepository(r *Repository) VMCreateJobRepository {
+	return &vmCreateJobRepository{Repository: r}
+}
+
+type vmCreateJobRepository struct {
+	*Repository
+}
+
+func (r *vmCreateJobRepository) Create(ctx context.Context, job *model.VMCreateJob) error {
+	return r.DB(ctx).Create(job).Error
+}
+
+func (r *vmCreateJobRepository) Update(ctx context.Context, job *model.VMCreateJob) error {
+	return r.DB(ctx).Save(job).Error
+}
+
+func (r *vmCreateJobRepository) GetByID(ctx context.Context, id int64) (*model.VMCreateJob, error) {
+	var job model.VMCreateJob
+	if err := r.DB(ctx).Where("id = ?", id).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (r *vmCreateJobRepository) List(ctx context.Context, page, pageSize int, clusterID int64, status, creator string) ([]*model.VMCreateJob, int64, error) {
+	var jobs []*model.VMCreateJob
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VMCreateJob{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if creator != "" {
+		query = query.Where("creator = ?", creator)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&jobs).Error; err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
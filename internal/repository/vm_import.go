@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VmImportRepository interface {
+	CreateSource(ctx context.Context, source *model.VmImportSource) error
+	DeleteSource(ctx context.Context, id int64) error
+	GetSourceByID(ctx context.Context, id int64) (*model.VmImportSource, error)
+	GetSourceByName(ctx context.Context, clusterID int64, name string) (*model.VmImportSource, error)
+	ListSources(ctx context.Context, clusterID int64) ([]*model.VmImportSource, error)
+
+	CreateTask(ctx context.Context, task *model.VmImportTask) error
+	UpdateTask(ctx context.Context, task *model.VmImportTask) error
+	GetTaskByID(ctx context.Context, id int64) (*model.VmImportTask, error)
+	ListTasks(ctx context.Context, page, pageSize int, clusterID int64, status string) ([]*model.VmImportTask, int64, error)
+}
+
+func NewVmImportRepository(r *Repository) VmImportRepository {
+	return &vmImportRepository{Repository: r}
+}
+
+type vmImportRepository struct {
+	*Repository
+}
+
+func (r *vmImportRepository) CreateSource(ctx context.Context, source *model.VmImportSource) error {
+	return r.DB(ctx).Create(source).Error
+}
+
+func (r *vmImportRepository) DeleteSource(ctx context.Context, id int64) error {
+	return r.DB(ctx).Delete(&model.VmImportSource{}, id).Error
+}
+
+func (r *vmImportRepository) GetSourceByID(ctx context.Context, id int64) (*model.VmImportSource, error) {
+	var source model.VmImportSource
+	if err := r.DB(ctx).Where("id = ?", id).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+func (r *vmImportRepository) GetSourceByName(ctx context.Context, clusterID int64, name string) (*model.VmImportSource, error) {
+	var source model.VmImportSource
+	if err := r.DB(ctx).Where("cluster_id = ? AND name = ?", clusterID, name).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &source, nil
+}
+
+func (r *vmImportRepository) ListSources(ctx context.Context, clusterID int64) ([]*model.VmImportSource, error) {
+	var sources []*model.VmImportSource
+	query := r.DB(ctx).Model(&model.VmImportSource{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if err := query.Order("id ASC").Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+func (r *vmImportRepository) CreateTask(ctx context.Context, task *model.VmImportTask) error {
+	return r.DB(ctx).Create(task).Error
+}
+
+func (r *vmImportRepository) UpdateTask(ctx context.Context, task *model.VmImportTask) error {
+	return r.DB(ctx).Save(task).Error
+}
+
+func (r *vmImportRepository) GetTaskByID(ctx context.Context, id int64) (*model.VmImportTask, error) {
+	var task model.VmImportTask
+	if err := r.DB(ctx).Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *vmImportRepository) ListTasks(ctx context.Context, page, pageSize int, clusterID int64, status string) ([]*model.VmImportTask, int64, error) {
+	var tasks []*model.VmImportTask
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VmImportTask{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
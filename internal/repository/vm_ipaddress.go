@@ -13,7 +13,10 @@ type VMIPAddressRepository interface {
 	Update(ctx context.Context, ip *model.VMIPAddress) error
 	GetByID(ctx context.Context, id int64) (*model.VMIPAddress, error)
 	GetByVMID(ctx context.Context, vmID int64) ([]*model.VMIPAddress, error)
+	ListByVMIDs(ctx context.Context, vmIDs []int64) ([]*model.VMIPAddress, error)
 	DeleteByVMID(ctx context.Context, vmID int64) error
+	// List clusterID 大于 0 时只返回该集群的地址
+	List(ctx context.Context, clusterID int64) ([]*model.VMIPAddress, error)
 }
 
 func NewVMIPAddressRepository(r *Repository) VMIPAddressRepository {
@@ -51,6 +54,29 @@ func (r *vmIPAddressRepository) GetByVMID(ctx context.Context, vmID int64) ([]*m
 	return ips, nil
 }
 
+func (r *vmIPAddressRepository) ListByVMIDs(ctx context.Context, vmIDs []int64) ([]*model.VMIPAddress, error) {
+	var ips []*model.VMIPAddress
+	if len(vmIDs) == 0 {
+		return ips, nil
+	}
+	if err := r.DB(ctx).Where("vm_id IN ?", vmIDs).Find(&ips).Error; err != nil {
+		return nil, err
+	}
+	return ips, nil
+}
+
 func (r *vmIPAddressRepository) DeleteByVMID(ctx context.Context, vmID int64) error {
 	return r.DB(ctx).Where("vm_id = ?", vmID).Delete(&model.VMIPAddress{}).Error
 }
+
+func (r *vmIPAddressRepository) List(ctx context.Context, clusterID int64) ([]*model.VMIPAddress, error) {
+	var ips []*model.VMIPAddress
+	query := r.DB(ctx).Model(&model.VMIPAddress{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if err := query.Order("id ASC").Find(&ips).Error; err != nil {
+		return nil, err
+	}
+	return ips, nil
+}
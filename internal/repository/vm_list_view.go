@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VmListViewRepository interface {
+	Create(ctx context.Context, view *model.VmListView) error
+	Update(ctx context.Context, view *model.VmListView) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.VmListView, error)
+	GetByUserAndName(ctx context.Context, userId, name string) (*model.VmListView, error)
+	// ListVisible 返回用户自己的视图以及其他用户共享的视图（共享对全部用户可见）
+	ListVisible(ctx context.Context, userId string) ([]*model.VmListView, error)
+	ClearDefault(ctx context.Context, userId string) error
+}
+
+func NewVmListViewRepository(r *Repository) VmListViewRepository {
+	return &vmListViewRepository{Repository: r}
+}
+
+type vmListViewRepository struct {
+	*Repository
+}
+
+func (r *vmListViewRepository) Create(ctx context.Context, view *model.VmListView) error {
+	return r.DB(ctx).Create(view).Error
+}
+
+func (r *vmListViewRepository) Update(ctx context.Context, view *model.VmListView) error {
+	return r.DB(ctx).Save(view).Error
+}
+
+func (r *vmListViewRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.VmListView{}).Error
+}
+
+func (r *vmListViewRepository) GetByID(ctx context.Context, id int64) (*model.VmListView, error) {
+	var view model.VmListView
+	if err := r.DB(ctx).Where("id = ?", id).First(&view).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (r *vmListViewRepository) GetByUserAndName(ctx context.Context, userId, name string) (*model.VmListView, error) {
+	var view model.VmListView
+	if err := r.DB(ctx).Where("user_id = ? AND name = ?", userId, name).First(&view).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &view, nil
+}
+
+func (r *vmListViewRepository) ListVisible(ctx context.Context, userId string) ([]*model.VmListView, error) {
+	var views []*model.VmListView
+	if err := r.DB(ctx).
+		Where("user_id = ? OR shared = ?", userId, 1).
+		Order("id ASC").
+		Find(&views).Error; err != nil {
+		return nil, err
+	}
+	return views, nil
+}
+
+func (r *vmListViewRepository) ClearDefault(ctx context.Context, userId string) error {
+	return r.DB(ctx).Model(&model.VmListView{}).
+		Where("user_id = ? AND is_default = ?", userId, 1).
+		Update("is_default", 0).Error
+}
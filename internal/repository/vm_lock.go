@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VMLockRepository interface {
+	Save(ctx context.Context, lock *model.VMLock) error
+	GetByVMId(ctx context.Context, vmID int64) (*model.VMLock, error)
+	// ListActive 分页查询未过期的锁
+	ListActive(ctx context.Context, page, pageSize int, clusterID int64, owner string, now time.Time) ([]*model.VMLock, int64, error)
+	DeleteByVMId(ctx context.Context, vmID int64) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+func NewVMLockRepository(r *Repository) VMLockRepository {
+	return &vmLockRepository{Repository: r}
+}
+
+type vmLockRepository struct {
+	*Repository
+}
+
+func (r *vmLockRepository) Save(ctx context.Context, lock *model.VMLock) error {
+	return r.DB(ctx).Save(lock).Error
+}
+
+func (r *vmLockRepository) GetByVMId(ctx context.Context, vmID int64) (*model.VMLock, error) {
+	var lock model.VMLock
+	if err := r.DB(ctx).Where("vm_id = ?", vmID).First(&lock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &lock, nil
+}
+
+func (r *vmLockRepository) ListActive(ctx context.Context, page, pageSize int, clusterID int64, owner string, now time.Time) ([]*model.VMLock, int64, error) {
+	var locks []*model.VMLock
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VMLock{}).Where("expires_at IS NULL OR expires_at > ?", now)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if owner != "" {
+		query = query.Where("owner = ?", owner)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("gmt_create DESC, id DESC").Find(&locks).Error; err != nil {
+		return nil, 0, err
+	}
+	return locks, total, nil
+}
+
+func (r *vmLockRepository) DeleteByVMId(ctx context.Context, vmID int64) error {
+	return r.DB(ctx).Where("vm_id = ?", vmID).Delete(&model.VMLock{}).Error
+}
+
+func (r *vmLockRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := r.DB(ctx).Where("expires_at IS NOT NULL AND expires_at <= ?", now).Delete(&model.VMLock{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VMProfileRepository interface {
+	Create(ctx context.Context, profile *model.VMProfile) error
+	Update(ctx context.Context, profile *model.VMProfile) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.VMProfile, error)
+	GetByName(ctx context.Context, clusterID int64, name string) (*model.VMProfile, error)
+	// GetDefault 返回集群的默认规格，clusterID 为 0 时返回全局默认规格
+	GetDefault(ctx context.Context, clusterID int64) (*model.VMProfile, error)
+	// ClearDefault 取消集群（clusterID 为 0 时为全局）除 exceptID 外的默认规格
+	ClearDefault(ctx context.Context, clusterID, exceptID int64) error
+	// List clusterID 大于 0 时返回该集群规格和全局规格，否则返回全部
+	List(ctx context.Context, clusterID int64) ([]*model.VMProfile, error)
+}
+
+func NewVMProfileRepository(r *Repository) VMProfileRepository {
+	return &vmProfileRepository{Repository: r}
+}
+
+type vmProfileRepository struct {
+	*Repository
+}
+
+func (r *vmProfileRepository) Create(ctx context.Context, profile *model.VMProfile) error {
+	return r.DB(ctx).Create(profile).Error
+}
+
+func (r *vmProfileRepository) Update(ctx context.Context, profile *model.VMProfile) error {
+	return r.DB(ctx).Save(profile).Error
+}
+
+func (r *vmProfileRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.VMProfile{}).Error
+}
+
+func (r *vmProfileRepository) GetByID(ctx context.Context, id int64) (*model.VMProfile, error) {
+	var profile model.VMProfile
+	if err := r.DB(ctx).Where("id = ?", id).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *vmProfileRepository) GetByName(ctx context.Context, clusterID int64, name string) (*model.VMProfile, error) {
+	var profile model.VMProfile
+	if err := r.DB(ctx).Where("cluster_id = ? AND name = ?", clusterID, name).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *vmProfileRepository) GetDefault(ctx context.Context, clusterID int64) (*model.VMProfile, error) {
+	var profile model.VMProfile
+	if err := r.DB(ctx).Where("cluster_id = ? AND is_default = ?", clusterID, true).Order("id DESC").First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *vmProfileRepository) ClearDefault(ctx context.Context, clusterID, exceptID int64) error {
+	return r.DB(ctx).Model(&model.VMProfile{}).
+		Where("cluster_id = ? AND is_default = ? AND id <> ?", clusterID, true, exceptID).
+		Update("is_default", false).Error
+}
+
+func (r *vmProfileRepository) List(ctx context.Context, clusterID int64) ([]*model.VMProfile, error) {
+	var profiles []*model.VMProfile
+	query := r.DB(ctx).Model(&model.VMProfile{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id IN ?", []int64{0, clusterID})
+	}
+	if err := query.Order("cluster_id ASC, name ASC").Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VmQosProfileRepository interface {
+	Create(ctx context.Context, profile *model.VmQosProfile) error
+	Update(ctx context.Context, profile *model.VmQosProfile) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.VmQosProfile, error)
+	GetByName(ctx context.Context, name string) (*model.VmQosProfile, error)
+	ListWithPagination(ctx context.Context, page, pageSize int, name string) ([]*model.VmQosProfile, int64, error)
+}
+
+func NewVmQosProfileRepository(r *Repository) VmQosProfileRepository {
+	return &vmQosProfileRepository{Repository: r}
+}
+
+type vmQosProfileRepository struct {
+	*Repository
+}
+
+func (r *vmQosProfileRepository) Create(ctx context.Context, profile *model.VmQosProfile) error {
+	return r.DB(ctx).Create(profile).Error
+}
+
+func (r *vmQosProfileRepository) Update(ctx context.Context, profile *model.VmQosProfile) error {
+	return r.DB(ctx).Save(profile).Error
+}
+
+func (r *vmQosProfileRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.VmQosProfile{}).Error
+}
+
+func (r *vmQosProfileRepository) GetByID(ctx context.Context, id int64) (*model.VmQosProfile, error) {
+	var profile model.VmQosProfile
+	if err := r.DB(ctx).Where("id = ?", id).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *vmQosProfileRepository) GetByName(ctx context.Context, name string) (*model.VmQosProfile, error) {
+	var profile model.VmQosProfile
+	if err := r.DB(ctx).Where("name = ?", name).First(&profile).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+func (r *vmQosProfileRepository) ListWithPagination(ctx context.Context, page, pageSize int, name string) ([]*model.VmQosProfile, int64, error) {
+	var profiles []*model.VmQosProfile
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VmQosProfile{})
+	if name != "" {
+		query = query.Where(r.like("name"), "%"+name+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Offset(offset).Limit(pageSize).Order("id DESC").Find(&profiles).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return profiles, total, nil
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+
+	"pvesphere/internal/model"
+)
+
+type VMSecurityRepository interface {
+	// ReplaceByVM 用本次扫描结果替换虚拟机的全部风险项
+	ReplaceByVM(ctx context.Context, vmID int64, findings []*model.VMSecurityFinding) error
+	ListByVM(ctx context.Context, vmID int64) ([]*model.VMSecurityFinding, error)
+	List(ctx context.Context, page, pageSize int, clusterID int64, severity, code string) ([]*model.VMSecurityFinding, int64, error)
+	// CountBySeverity 按风险等级统计，clusterID 为 0 时统计全部集群
+	CountBySeverity(ctx context.Context, clusterID int64) (map[string]int64, error)
+	// VMIDsBySeverity 返回存在该等级风险项的虚拟机ID，clusterID 为 0 时统计全部集群
+	VMIDsBySeverity(ctx context.Context, clusterID int64, severity string) ([]int64, error)
+	// DeleteOrphaned 删除虚拟机已不存在的风险项
+	DeleteOrphaned(ctx context.Context) (int64, error)
+}
+
+func NewVMSecurityRepository(r *Repository) VMSecurityRepository {
+	return &vmSecurityRepository{Repository: r}
+}
+
+type vmSecurityRepository struct {
+	*Repository
+}
+
+func (r *vmSecurityRepository) ReplaceByVM(ctx context.Context, vmID int64, findings []*model.VMSecurityFinding) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		if err := r.DB(ctx).Where("vm_id = ?", vmID).Delete(&model.VMSecurityFinding{}).Error; err != nil {
+			return err
+		}
+		if len(findings) == 0 {
+			return nil
+		}
+		return r.DB(ctx).Create(&findings).Error
+	})
+}
+
+func (r *vmSecurityRepository) ListByVM(ctx context.Context, vmID int64) ([]*model.VMSecurityFinding, error) {
+	var findings []*model.VMSecurityFinding
+	if err := r.DB(ctx).Where("vm_id = ?", vmID).Order("id ASC").Find(&findings).Error; err != nil {
+		return nil, err
+	}
+	return findings, nil
+}
+
+func (r *vmSecurityRepository) List(ctx context.Context, page, pageSize int, clusterID int64, severity, code string) ([]*model.VMSecurityFinding, int64, error) {
+	var findings []*model.VMSecurityFinding
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VMSecurityFinding{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if severity != "" {
+		query = query.Where("severity = ?", severity)
+	}
+	if code != "" {
+		query = query.Where("code = ?", code)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("vm_id ASC, id ASC").Offset(offset).Limit(pageSize).Find(&findings).Error; err != nil {
+		return nil, 0, err
+	}
+	return findings, total, nil
+}
+
+func (r *vmSecurityRepository) CountBySeverity(ctx context.Context, clusterID int64) (map[string]int64, error) {
+	var rows []struct {
+		Severity string
+		Count    int64
+	}
+	query := r.DB(ctx).Model(&model.VMSecurityFinding{}).Select("severity, COUNT(*) AS count")
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if err := query.Group("severity").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Severity] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *vmSecurityRepository) VMIDsBySeverity(ctx context.Context, clusterID int64, severity string) ([]int64, error) {
+	var ids []int64
+	query := r.DB(ctx).Model(&model.VMSecurityFinding{}).Where("severity = ?", severity)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if err := query.Distinct("vm_id").Pluck("vm_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (r *vmSecurityRepository) DeleteOrphaned(ctx context.Context) (int64, error) {
+	result := r.DB(ctx).Where("vm_id NOT IN (?)", r.DB(ctx).Model(&model.PveVM{}).Select("id")).
+		Delete(&model.VMSecurityFinding{})
+	return result.RowsAffected, result.Error
+}
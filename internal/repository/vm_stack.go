@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VmStackRepository interface {
+	Create(ctx context.Context, stack *model.VmStack) error
+	Update(ctx context.Context, stack *model.VmStack) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.VmStack, error)
+	GetByName(ctx context.Context, name string) (*model.VmStack, error)
+	ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64) ([]*model.VmStack, int64, error)
+	CreateMembers(ctx context.Context, members []*model.VmStackMember) error
+	UpdateMember(ctx context.Context, member *model.VmStackMember) error
+	ListMembers(ctx context.Context, stackID int64) ([]*model.VmStackMember, error)
+}
+
+func NewVmStackRepository(r *Repository) VmStackRepository {
+	return &vmStackRepository{Repository: r}
+}
+
+type vmStackRepository struct {
+	*Repository
+}
+
+func (r *vmStackRepository) Create(ctx context.Context, stack *model.VmStack) error {
+	return r.DB(ctx).Create(stack).Error
+}
+
+func (r *vmStackRepository) Update(ctx context.Context, stack *model.VmStack) error {
+	return r.DB(ctx).Save(stack).Error
+}
+
+// Delete 删除 stack 及其成员记录（不删除已创建的虚拟机）
+func (r *vmStackRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("stack_id = ?", id).Delete(&model.VmStackMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.VmStack{}, id).Error
+	})
+}
+
+func (r *vmStackRepository) GetByID(ctx context.Context, id int64) (*model.VmStack, error) {
+	var stack model.VmStack
+	if err := r.DB(ctx).Where("id = ?", id).First(&stack).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stack, nil
+}
+
+func (r *vmStackRepository) GetByName(ctx context.Context, name string) (*model.VmStack, error) {
+	var stack model.VmStack
+	if err := r.DB(ctx).Where("name = ?", name).First(&stack).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &stack, nil
+}
+
+func (r *vmStackRepository) ListWithPagination(ctx context.Context, page, pageSize int, clusterID int64) ([]*model.VmStack, int64, error) {
+	var stacks []*model.VmStack
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VmStack{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&stacks).Error; err != nil {
+		return nil, 0, err
+	}
+	return stacks, total, nil
+}
+
+func (r *vmStackRepository) CreateMembers(ctx context.Context, members []*model.VmStackMember) error {
+	if len(members) == 0 {
+		return nil
+	}
+	return r.DB(ctx).CreateInBatches(members, 100).Error
+}
+
+func (r *vmStackRepository) UpdateMember(ctx context.Context, member *model.VmStackMember) error {
+	return r.DB(ctx).Save(member).Error
+}
+
+// ListMembers 按创建顺序返回成员
+func (r *vmStackRepository) ListMembers(ctx context.Context, stackID int64) ([]*model.VmStackMember, error) {
+	var members []*model.VmStackMember
+	if err := r.DB(ctx).Where("stack_id = ?", stackID).Order("role_order ASC, id ASC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
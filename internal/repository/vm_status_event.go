@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/model"
+)
+
+type VMStatusEventRepository interface {
+	Create(ctx context.Context, event *model.VMStatusEvent) error
+	// ListByVM 返回虚拟机最近的 limit 条状态变化，按时间倒序
+	ListByVM(ctx context.Context, clusterID int64, vmid uint32, limit int) ([]*model.VMStatusEvent, error)
+	// ListBetween 返回 [from, to) 内状态为 statuses 中任一的记录，按时间正序；clusterID 为 0 时不限集群
+	ListBetween(ctx context.Context, clusterID int64, statuses []string, from, to time.Time) ([]*model.VMStatusEvent, error)
+	// DeleteBefore 删除创建时间早于 before 的记录
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewVMStatusEventRepository(r *Repository) VMStatusEventRepository {
+	return &vmStatusEventRepository{Repository: r}
+}
+
+type vmStatusEventRepository struct {
+	*Repository
+}
+
+func (r *vmStatusEventRepository) Create(ctx context.Context, event *model.VMStatusEvent) error {
+	return r.DB(ctx).Create(event).Error
+}
+
+func (r *vmStatusEventRepository) ListByVM(ctx context.Context, clusterID int64, vmid uint32, limit int) ([]*model.VMStatusEvent, error) {
+	var events []*model.VMStatusEvent
+	if err := r.DB(ctx).Where("cluster_id = ? AND vmid = ?", clusterID, vmid).
+		Order("id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *vmStatusEventRepository) ListBetween(ctx context.Context, clusterID int64, statuses []string, from, to time.Time) ([]*model.VMStatusEvent, error) {
+	var events []*model.VMStatusEvent
+	query := r.DB(ctx).Where("gmt_create >= ? AND gmt_create < ? AND new_status IN ?", from, to, statuses)
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if err := query.Order("gmt_create ASC, id ASC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *vmStatusEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("gmt_create < ?", before).Delete(&model.VMStatusEvent{})
+	return result.RowsAffected, result.Error
+}
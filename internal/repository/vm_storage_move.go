@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VMStorageMoveRepository interface {
+	Create(ctx context.Context, task *model.VMStorageMoveTask) error
+	Update(ctx context.Context, task *model.VMStorageMoveTask) error
+	GetByID(ctx context.Context, id int64) (*model.VMStorageMoveTask, error)
+	List(ctx context.Context, page, pageSize int, clusterID, vmID int64, status string) ([]*model.VMStorageMoveTask, int64, error)
+	// CountActiveByVM 统计虚拟机 pending / running 的存储迁移任务数
+	CountActiveByVM(ctx context.Context, vmID int64) (int64, error)
+}
+
+func NewVMStorageMoveRepository(r *Repository) VMStorageMoveRepository {
+	return &vmStorageMoveRepository{Repository: r}
+}
+
+type vmStorageMoveRepository struct {
+	*Repository
+}
+
+func (r *vmStorageMoveRepository) Create(ctx context.Context, task *model.VMStorageMoveTask) error {
+	return r.DB(ctx).Create(task).Error
+}
+
+func (r *vmStorageMoveRepository) Update(ctx context.Context, task *model.VMStorageMoveTask) error {
+	return r.DB(ctx).Save(task).Error
+}
+
+func (r *vmStorageMoveRepository) GetByID(ctx context.Context, id int64) (*model.VMStorageMoveTask, error) {
+	var task model.VMStorageMoveTask
+	if err := r.DB(ctx).Where("id = ?", id).First(&task).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &task, nil
+}
+
+func (r *vmStorageMoveRepository) List(ctx context.Context, page, pageSize int, clusterID, vmID int64, status string) ([]*model.VMStorageMoveTask, int64, error) {
+	var tasks []*model.VMStorageMoveTask
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VMStorageMoveTask{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if vmID > 0 {
+		query = query.Where("vm_id = ?", vmID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&tasks).Error; err != nil {
+		return nil, 0, err
+	}
+	return tasks, total, nil
+}
+
+func (r *vmStorageMoveRepository) CountActiveByVM(ctx context.Context, vmID int64) (int64, error) {
+	var count int64
+	err := r.DB(ctx).Model(&model.VMStorageMoveTask{}).
+		Where("vm_id = ? AND status IN ?", vmID, []string{model.VMStorageMoveStatusPending, model.VMStorageMoveStatusRunning}).
+		Count(&count).Error
+	return count, err
+}
@@ -15,6 +15,7 @@ type VmTemplateRepository interface {
 	GetByTemplateName(ctx context.Context, templateName string, clusterID int64) (*model.VmTemplate, error)
 	GetByClusterID(ctx context.Context, clusterID int64) ([]*model.VmTemplate, error)
 	GetByIDs(ctx context.Context, ids []int64) (map[int64]*model.VmTemplate, error) // 批量查询模板，返回 map[id]*template
+	List(ctx context.Context, clusterID int64) ([]*model.VmTemplate, error)         // clusterID 为 0 时查询全部集群
 }
 
 func NewVmTemplateRepository(r *Repository) VmTemplateRepository {
@@ -80,3 +81,15 @@ func (r *vmTemplateRepository) GetByIDs(ctx context.Context, ids []int64) (map[i
 	}
 	return result, nil
 }
+
+func (r *vmTemplateRepository) List(ctx context.Context, clusterID int64) ([]*model.VmTemplate, error) {
+	var templates []*model.VmTemplate
+	query := r.DB(ctx).Model(&model.VmTemplate{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if err := query.Order("id ASC").Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type VMIDRangeRepository interface {
+	Create(ctx context.Context, rng *model.VMIDRange) error
+	Update(ctx context.Context, rng *model.VMIDRange) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.VMIDRange, error)
+	// List clusterID 大于 0 时返回该集群的段和全部集群适用的段；team 不为空时按团队过滤
+	List(ctx context.Context, clusterID int64, team string) ([]*model.VMIDRange, error)
+
+	CreateAllocation(ctx context.Context, alloc *model.VMIDAllocation) error
+	UpdateAllocation(ctx context.Context, alloc *model.VMIDAllocation) error
+	DeleteAllocation(ctx context.Context, id int64) error
+	GetAllocation(ctx context.Context, clusterID int64, vmid uint32) (*model.VMIDAllocation, error)
+	// ListAllocationsInRange 返回集群内 [start, end] 之间的分配记录
+	ListAllocationsInRange(ctx context.Context, clusterID int64, start, end uint32) ([]*model.VMIDAllocation, error)
+	// CountAllocations 统计 [start, end] 之间已分配和隔离期内（releasedAfter 之后释放）的记录数，clusterID 为 0 时统计全部集群
+	CountAllocations(ctx context.Context, clusterID int64, start, end uint32, releasedAfter time.Time) (allocated, quarantined int64, err error)
+	// ListAllocations rng 不为空时只返回该段内的记录
+	ListAllocations(ctx context.Context, page, pageSize int, clusterID int64, rng *model.VMIDRange, status string) ([]*model.VMIDAllocation, int64, error)
+}
+
+func NewVMIDRangeRepository(r *Repository) VMIDRangeRepository {
+	return &vmidRangeRepository{Repository: r}
+}
+
+type vmidRangeRepository struct {
+	*Repository
+}
+
+func (r *vmidRangeRepository) Create(ctx context.Context, rng *model.VMIDRange) error {
+	return r.DB(ctx).Create(rng).Error
+}
+
+func (r *vmidRangeRepository) Update(ctx context.Context, rng *model.VMIDRange) error {
+	return r.DB(ctx).Save(rng).Error
+}
+
+func (r *vmidRangeRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.VMIDRange{}).Error
+}
+
+func (r *vmidRangeRepository) GetByID(ctx context.Context, id int64) (*model.VMIDRange, error) {
+	var rng model.VMIDRange
+	if err := r.DB(ctx).Where("id = ?", id).First(&rng).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rng, nil
+}
+
+func (r *vmidRangeRepository) List(ctx context.Context, clusterID int64, team string) ([]*model.VMIDRange, error) {
+	var ranges []*model.VMIDRange
+	query := r.DB(ctx).Model(&model.VMIDRange{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id IN ?", []int64{clusterID, 0})
+	}
+	if team != "" {
+		query = query.Where("team = ?", team)
+	}
+	if err := query.Order("range_start ASC").Find(&ranges).Error; err != nil {
+		return nil, err
+	}
+	return ranges, nil
+}
+
+func (r *vmidRangeRepository) CreateAllocation(ctx context.Context, alloc *model.VMIDAllocation) error {
+	return r.DB(ctx).Create(alloc).Error
+}
+
+func (r *vmidRangeRepository) UpdateAllocation(ctx context.Context, alloc *model.VMIDAllocation) error {
+	return r.DB(ctx).Save(alloc).Error
+}
+
+func (r *vmidRangeRepository) DeleteAllocation(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.VMIDAllocation{}).Error
+}
+
+func (r *vmidRangeRepository) GetAllocation(ctx context.Context, clusterID int64, vmid uint32) (*model.VMIDAllocation, error) {
+	var alloc model.VMIDAllocation
+	if err := r.DB(ctx).Where("cluster_id = ? AND vmid = ?", clusterID, vmid).First(&alloc).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alloc, nil
+}
+
+func (r *vmidRangeRepository) ListAllocationsInRange(ctx context.Context, clusterID int64, start, end uint32) ([]*model.VMIDAllocation, error) {
+	var allocs []*model.VMIDAllocation
+	if err := r.DB(ctx).Where("cluster_id = ? AND vmid BETWEEN ? AND ?", clusterID, start, end).
+		Order("vmid ASC").Find(&allocs).Error; err != nil {
+		return nil, err
+	}
+	return allocs, nil
+}
+
+func (r *vmidRangeRepository) CountAllocations(ctx context.Context, clusterID int64, start, end uint32, releasedAfter time.Time) (int64, int64, error) {
+	query := func() *gorm.DB {
+		q := r.DB(ctx).Model(&model.VMIDAllocation{}).Where("vmid BETWEEN ? AND ?", start, end)
+		if clusterID > 0 {
+			q = q.Where("cluster_id = ?", clusterID)
+		}
+		return q
+	}
+	var allocated, quarantined int64
+	if err := query().Where("status = ?", model.VMIDAllocationStatusAllocated).Count(&allocated).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := query().Where("status = ? AND released_at > ?", model.VMIDAllocationStatusReleased, releasedAfter).Count(&quarantined).Error; err != nil {
+		return 0, 0, err
+	}
+	return allocated, quarantined, nil
+}
+
+func (r *vmidRangeRepository) ListAllocations(ctx context.Context, page, pageSize int, clusterID int64, rng *model.VMIDRange, status string) ([]*model.VMIDAllocation, int64, error) {
+	var allocs []*model.VMIDAllocation
+	var total int64
+
+	query := r.DB(ctx).Model(&model.VMIDAllocation{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if rng != nil {
+		query = query.Where("vmid BETWEEN ? AND ?", rng.Start, rng.End)
+		if rng.ClusterID > 0 {
+			query = query.Where("cluster_id = ?", rng.ClusterID)
+		}
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("cluster_id ASC, vmid ASC").Offset(offset).Limit(pageSize).Find(&allocs).Error; err != nil {
+		return nil, 0, err
+	}
+	return allocs, total, nil
+}
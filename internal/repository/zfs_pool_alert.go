@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"pvesphere/internal/model"
+
+	"gorm.io/gorm"
+)
+
+type ZFSPoolAlertRepository interface {
+	Save(ctx context.Context, alert *model.ZFSPoolAlert) error
+	// GetOpen 获取节点上存储池未恢复的告警
+	GetOpen(ctx context.Context, nodeID int64, pool string) (*model.ZFSPoolAlert, error)
+	ListOpenByNode(ctx context.Context, nodeID int64) ([]*model.ZFSPoolAlert, error)
+	// ListOpenByClusters 列出指定集群未恢复的告警，clusterIDs 为空时返回全部
+	ListOpenByClusters(ctx context.Context, clusterIDs []int64) ([]*model.ZFSPoolAlert, error)
+	List(ctx context.Context, page, pageSize int, clusterID, nodeID int64, open bool) ([]*model.ZFSPoolAlert, int64, error)
+}
+
+func NewZFSPoolAlertRepository(r *Repository) ZFSPoolAlertRepository {
+	return &zfsPoolAlertRepository{Repository: r}
+}
+
+type zfsPoolAlertRepository struct {
+	*Repository
+}
+
+func (r *zfsPoolAlertRepository) Save(ctx context.Context, alert *model.ZFSPoolAlert) error {
+	return r.DB(ctx).Save(alert).Error
+}
+
+func (r *zfsPoolAlertRepository) GetOpen(ctx context.Context, nodeID int64, pool string) (*model.ZFSPoolAlert, error) {
+	var alert model.ZFSPoolAlert
+	err := r.DB(ctx).Where("node_id = ? AND pool = ? AND resolved_at IS NULL", nodeID, pool).First(&alert).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (r *zfsPoolAlertRepository) ListOpenByNode(ctx context.Context, nodeID int64) ([]*model.ZFSPoolAlert, error) {
+	var alerts []*model.ZFSPoolAlert
+	if err := r.DB(ctx).Where("node_id = ? AND resolved_at IS NULL", nodeID).Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
+
+func (r *zfsPoolAlertRepository) List(ctx context.Context, page, pageSize int, clusterID, nodeID int64, open bool) ([]*model.ZFSPoolAlert, int64, error) {
+	var alerts []*model.ZFSPoolAlert
+	var total int64
+
+	query := r.DB(ctx).Model(&model.ZFSPoolAlert{})
+	if clusterID > 0 {
+		query = query.Where("cluster_id = ?", clusterID)
+	}
+	if nodeID > 0 {
+		query = query.Where("node_id = ?", nodeID)
+	}
+	if open {
+		query = query.Where("resolved_at IS NULL")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+	return alerts, total, nil
+}
+
+func (r *zfsPoolAlertRepository) ListOpenByClusters(ctx context.Context, clusterIDs []int64) ([]*model.ZFSPoolAlert, error) {
+	var alerts []*model.ZFSPoolAlert
+	query := r.DB(ctx).Where("resolved_at IS NULL")
+	if len(clusterIDs) > 0 {
+		query = query.Where("cluster_id IN ?", clusterIDs)
+	}
+	if err := query.Order("id DESC").Find(&alerts).Error; err != nil {
+		return nil, err
+	}
+	return alerts, nil
+}
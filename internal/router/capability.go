@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitCapabilityRouter 配置当前用户操作权限路由
+func InitCapabilityRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/me").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/capabilities", deps.CapabilityHandler.GetMyCapabilities)
+	}
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitChangeWindowRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/change-windows").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.ChangeWindowHandler.ListChangeWindows)
+		strictAuthRouter.GET("/status", deps.ChangeWindowHandler.GetChangeStatus)
+		strictAuthRouter.GET("/overrides", deps.ChangeWindowHandler.ListChangeOverrides)
+		strictAuthRouter.GET("/:id", deps.ChangeWindowHandler.GetChangeWindow)
+		strictAuthRouter.POST("", deps.ChangeWindowHandler.CreateChangeWindow)
+		strictAuthRouter.PUT("/:id", deps.ChangeWindowHandler.UpdateChangeWindow)
+		strictAuthRouter.DELETE("/:id", deps.ChangeWindowHandler.DeleteChangeWindow)
+	}
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitClusterEndpointRouter 配置集群接入地址 / 证书更换路由
+func InitClusterEndpointRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	clusterRouter := r.Group("/clusters").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		clusterRouter.POST("/:id/endpoint/verify", deps.ClusterEndpointHandler.VerifyClusterEndpoint)
+		clusterRouter.PUT("/:id/endpoint", deps.ClusterEndpointHandler.UpdateClusterEndpoint)
+		clusterRouter.GET("/:id/certificates", deps.ClusterEndpointHandler.ListClusterCertificates)
+	}
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitClusterEventRouter 配置集群外部变更路由
+func InitClusterEventRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/cluster-events").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.ClusterEventHandler.ListEvents)
+	}
+}
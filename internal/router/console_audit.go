@@ -0,0 +1,21 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitConsoleAuditRouter 配置控制台会话审计路由
+func InitConsoleAuditRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	auditRouter := r.Group("/console-sessions").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		auditRouter.GET("", deps.ConsoleAuditHandler.ListSessions)
+		auditRouter.GET("/metrics", deps.ConsoleAuditHandler.GetProxyMetrics)
+		auditRouter.GET("/:id", deps.ConsoleAuditHandler.GetSession)
+		auditRouter.GET("/:id/recording", deps.ConsoleAuditHandler.DownloadRecording)
+	}
+}
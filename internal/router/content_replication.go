@@ -0,0 +1,29 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitContentReplicationRouter 配置存储内容复制策略路由
+func InitContentReplicationRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	policyRouter := r.Group("/content-replication-policies").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		policyRouter.POST("", deps.ContentReplicationHandler.CreateContentReplicationPolicy)
+		policyRouter.GET("", deps.ContentReplicationHandler.ListContentReplicationPolicies)
+		policyRouter.GET("/:id", deps.ContentReplicationHandler.GetContentReplicationPolicy)
+		policyRouter.PUT("/:id", deps.ContentReplicationHandler.UpdateContentReplicationPolicy)
+		policyRouter.DELETE("/:id", deps.ContentReplicationHandler.DeleteContentReplicationPolicy)
+		policyRouter.GET("/:id/divergence", deps.ContentReplicationHandler.GetContentReplicationDivergence)
+		policyRouter.POST("/:id/reconcile", deps.ContentReplicationHandler.ReconcileContentReplicationPolicy)
+	}
+
+	copyRouter := r.Group("/content-replication-copies").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		copyRouter.GET("", deps.ContentReplicationHandler.ListContentReplicationCopies)
+	}
+}
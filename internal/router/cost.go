@@ -0,0 +1,31 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitCostRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/costs").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		// 价格模型
+		strictAuthRouter.GET("/prices", deps.CostHandler.ListPrices)
+		strictAuthRouter.PUT("/prices", deps.CostHandler.SetPrice)
+		strictAuthRouter.DELETE("/prices/:id", deps.CostHandler.DeletePrice)
+
+		// 月度报表
+		strictAuthRouter.GET("/report", deps.CostHandler.GetReport)
+		strictAuthRouter.GET("/report/export", deps.CostHandler.ExportReport)
+
+		// 预算与告警
+		strictAuthRouter.GET("/budgets", deps.CostHandler.ListBudgets)
+		strictAuthRouter.POST("/budgets", deps.CostHandler.CreateBudget)
+		strictAuthRouter.PUT("/budgets/:id", deps.CostHandler.UpdateBudget)
+		strictAuthRouter.DELETE("/budgets/:id", deps.CostHandler.DeleteBudget)
+		strictAuthRouter.GET("/alerts", deps.CostHandler.ListAlerts)
+	}
+}
@@ -20,6 +20,9 @@ func InitDashboardRouter(
 		// 获取全局概览
 		dashboardRouter.GET("/overview", deps.DashboardHandler.GetOverview)
 
+		// 按站点分组的概览
+		dashboardRouter.GET("/sites", deps.DashboardHandler.GetSites)
+
 		// 获取资源使用率
 		dashboardRouter.GET("/resources", deps.DashboardHandler.GetResources)
 
@@ -28,6 +31,26 @@ func InitDashboardRouter(
 
 		// 获取运行中的操作
 		dashboardRouter.GET("/operations", deps.DashboardHandler.GetOperations)
+
+		// 获取能耗概览
+		dashboardRouter.GET("/energy", deps.DashboardHandler.GetEnergy)
+
+		// 一段时间内的变化概览
+		dashboardRouter.GET("/changes", deps.DashboardChangeHandler.GetChanges)
+
+		// 最近风险
+		dashboardRouter.GET("/risks", deps.DashboardHandler.GetRisks)
+
+		// 未恢复的告警汇总
+		dashboardRouter.GET("/alerts", deps.DashboardHandler.GetAlerts)
+
+		// 可用面板及预设布局
+		dashboardRouter.GET("/widgets", deps.DashboardHandler.GetWidgets)
+
+		// 当前用户的布局
+		dashboardRouter.GET("/layout", deps.DashboardHandler.GetLayout)
+		dashboardRouter.PUT("/layout", deps.DashboardHandler.SaveLayout)
+		dashboardRouter.DELETE("/layout", deps.DashboardHandler.ResetLayout)
 	}
 }
 
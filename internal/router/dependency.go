@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitDependencyRouter 配置依赖查询路由
+func InitDependencyRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	dependencyRouter := r.Group("/dependencies").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		dependencyRouter.GET("", deps.DependencyHandler.Lookup)
+	}
+}
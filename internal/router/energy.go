@@ -0,0 +1,19 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitEnergyRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/energy").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		// 月度报表
+		strictAuthRouter.GET("/report", deps.EnergyHandler.GetReport)
+		strictAuthRouter.GET("/report/export", deps.EnergyHandler.ExportReport)
+	}
+}
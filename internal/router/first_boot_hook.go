@@ -0,0 +1,31 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitFirstBootHookRouter 配置模板首次启动钩子路由
+func InitFirstBootHookRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	templateRouter := r.Group("/templates").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		templateRouter.GET("/:id/first-boot-hook", deps.FirstBootHookHandler.GetFirstBootHook)
+		templateRouter.PUT("/:id/first-boot-hook", deps.FirstBootHookHandler.SaveFirstBootHook)
+		templateRouter.DELETE("/:id/first-boot-hook", deps.FirstBootHookHandler.DeleteFirstBootHook)
+	}
+
+	vmRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		vmRouter.GET("/:id/first-boot", deps.FirstBootHookHandler.GetVMFirstBootRun)
+	}
+
+	runRouter := r.Group("/first-boot-runs").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		runRouter.GET("", deps.FirstBootHookHandler.ListFirstBootRuns)
+		runRouter.POST("/:id/retry", deps.FirstBootHookHandler.RetryFirstBootRun)
+	}
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitGrafanaRouter 配置 Grafana JSON 数据源路由
+// Grafana 中添加 JSON 数据源，URL 填写 http://<server>/api/v1/grafana，
+// 并在 Custom HTTP Headers 中添加 Authorization: Bearer <token>
+func InitGrafanaRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	grafanaRouter := r.Group("/grafana").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		grafanaRouter.GET("", deps.GrafanaHandler.TestDatasource)
+		grafanaRouter.GET("/", deps.GrafanaHandler.TestDatasource)
+		grafanaRouter.POST("/search", deps.GrafanaHandler.Search)
+		grafanaRouter.POST("/query", deps.GrafanaHandler.Query)
+		grafanaRouter.POST("/annotations", deps.GrafanaHandler.Annotations)
+	}
+}
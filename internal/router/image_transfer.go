@@ -0,0 +1,28 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitImageTransferRouter 配置镜像传输路由
+func InitImageTransferRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	transferRouter := r.Group("/image-transfers").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		// 对象存储
+		transferRouter.POST("/stores", deps.ImageTransferHandler.CreateStore)
+		transferRouter.GET("/stores", deps.ImageTransferHandler.ListStores)
+		transferRouter.DELETE("/stores/:id", deps.ImageTransferHandler.DeleteStore)
+		transferRouter.GET("/stores/:id/objects", deps.ImageTransferHandler.ListStoreObjects)
+
+		// 导出 / 导入任务
+		transferRouter.POST("/export", deps.ImageTransferHandler.CreateExport)
+		transferRouter.POST("/import", deps.ImageTransferHandler.CreateImport)
+		transferRouter.GET("", deps.ImageTransferHandler.ListTransfers)
+		transferRouter.GET("/:id", deps.ImageTransferHandler.GetTransfer)
+	}
+}
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitLicenseRouter 配置许可证台账路由
+func InitLicenseRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	licenseRouter := r.Group("/licenses").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		// 用量与告警
+		licenseRouter.GET("/usage", deps.LicenseHandler.GetUsage)
+		licenseRouter.GET("/alerts", deps.LicenseHandler.ListAlerts)
+		licenseRouter.POST("/refresh", deps.LicenseHandler.Refresh)
+
+		// 许可证
+		licenseRouter.POST("", deps.LicenseHandler.CreateLicense)
+		licenseRouter.GET("", deps.LicenseHandler.ListLicenses)
+		licenseRouter.GET("/:id", deps.LicenseHandler.GetLicense)
+		licenseRouter.PUT("/:id", deps.LicenseHandler.UpdateLicense)
+		licenseRouter.DELETE("/:id", deps.LicenseHandler.DeleteLicense)
+		licenseRouter.GET("/:id/key", deps.LicenseHandler.GetLicenseKey)
+
+		// 分配
+		licenseRouter.POST("/:id/assignments", deps.LicenseHandler.Assign)
+		licenseRouter.DELETE("/:id/assignments/:assignment_id", deps.LicenseHandler.Unassign)
+	}
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitMACAddressRouter 配置 MAC 地址登记路由
+func InitMACAddressRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/mac-addresses").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.MACAddressHandler.ListMACAddresses)
+		strictAuthRouter.POST("", deps.MACAddressHandler.ReserveMACAddress)
+		strictAuthRouter.POST("/sync", deps.MACAddressHandler.SyncMACAddresses)
+		strictAuthRouter.GET("/dhcp-export", deps.MACAddressHandler.ExportDHCPReservations)
+		strictAuthRouter.DELETE("/:id", deps.MACAddressHandler.DeleteMACAddress)
+	}
+}
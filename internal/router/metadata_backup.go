@@ -0,0 +1,19 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitMetadataBackupRouter 配置元数据备份路由
+func InitMetadataBackupRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	metadataRouter := r.Group("/metadata").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		metadataRouter.POST("/export", deps.MetadataBackupHandler.ExportMetadata)
+		metadataRouter.POST("/import", deps.MetadataBackupHandler.ImportMetadata)
+	}
+}
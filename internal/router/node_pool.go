@@ -0,0 +1,22 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitNodePoolRouter 配置节点池路由
+func InitNodePoolRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	poolRouter := r.Group("/node-pools").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		poolRouter.GET("", deps.NodePoolHandler.ListNodePools)
+		poolRouter.POST("", deps.NodePoolHandler.CreateNodePool)
+		poolRouter.GET("/:id", deps.NodePoolHandler.GetNodePool)
+		poolRouter.PUT("/:id", deps.NodePoolHandler.UpdateNodePool)
+		poolRouter.DELETE("/:id", deps.NodePoolHandler.DeleteNodePool)
+	}
+}
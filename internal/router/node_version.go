@@ -0,0 +1,20 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitNodeVersionRouter 配置节点版本矩阵与迁移兼容性检查路由
+func InitNodeVersionRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	versionRouter := r.Group("/node-versions").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		versionRouter.GET("", deps.NodeVersionHandler.GetMatrix)
+		versionRouter.POST("/refresh", deps.NodeVersionHandler.Refresh)
+		versionRouter.GET("/migration-check", deps.NodeVersionHandler.CheckMigration)
+	}
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitNotificationRouter 配置站内通知路由
+func InitNotificationRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	notificationRouter := r.Group("/notifications").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		notificationRouter.GET("", deps.NotificationHandler.ListNotifications)
+		notificationRouter.GET("/unread-count", deps.NotificationHandler.GetUnreadCount)
+		notificationRouter.POST("/read", deps.NotificationHandler.MarkNotificationsRead)
+		notificationRouter.DELETE("/:id", deps.NotificationHandler.DeleteNotification)
+	}
+}
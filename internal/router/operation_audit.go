@@ -0,0 +1,19 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitOperationAuditRouter 配置操作审计路由
+func InitOperationAuditRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/operations").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.OperationAuditHandler.ListOperations)
+		strictAuthRouter.GET("/:operation_id", deps.OperationAuditHandler.GetOperation)
+	}
+}
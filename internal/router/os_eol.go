@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitOSEOLRouter 配置操作系统停止维护合规报告路由
+func InitOSEOLRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	osEOLRouter := r.Group("/os-eol").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		osEOLRouter.GET("/report", deps.OSEOLHandler.Report)
+	}
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitPendingOperationRouter 配置待重试操作路由
+func InitPendingOperationRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/pending-operations").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.PendingOperationHandler.ListPendingOperations)
+		strictAuthRouter.POST("/flush", deps.PendingOperationHandler.FlushClusterPendingOperations)
+		strictAuthRouter.GET("/:id", deps.PendingOperationHandler.GetPendingOperation)
+		strictAuthRouter.POST("/:id/flush", deps.PendingOperationHandler.FlushPendingOperation)
+		strictAuthRouter.POST("/:id/cancel", deps.PendingOperationHandler.CancelPendingOperation)
+	}
+}
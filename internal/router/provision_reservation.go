@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitProvisionReservationRouter 配置创建资源预留路由
+func InitProvisionReservationRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/provision-reservations").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.ReservationHandler.ListReservations)
+	}
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitPveAccessRouter 配置 PVE 用户、API Token 和 ACL 管理路由
+func InitPveAccessRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/pve/access").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/users", deps.PveAccessHandler.ListPveUsers)
+		strictAuthRouter.POST("/users", deps.PveAccessHandler.CreatePveUser)
+		strictAuthRouter.PUT("/users/:userid", deps.PveAccessHandler.UpdatePveUser)
+		strictAuthRouter.DELETE("/users/:userid", deps.PveAccessHandler.DeletePveUser)
+		strictAuthRouter.GET("/users/:userid/tokens", deps.PveAccessHandler.ListPveTokens)
+		strictAuthRouter.POST("/users/:userid/tokens", deps.PveAccessHandler.CreatePveToken)
+		strictAuthRouter.DELETE("/users/:userid/tokens/:tokenid", deps.PveAccessHandler.DeletePveToken)
+		strictAuthRouter.GET("/acl", deps.PveAccessHandler.ListPveACL)
+		strictAuthRouter.PUT("/acl", deps.PveAccessHandler.UpdatePveACL)
+		strictAuthRouter.GET("/roles", deps.PveAccessHandler.ListPveRoles)
+		strictAuthRouter.GET("/permissions/check", deps.PveAccessHandler.CheckPvePermissions)
+		strictAuthRouter.POST("/bootstrap", deps.PveAccessHandler.BootstrapPveAccess)
+	}
+}
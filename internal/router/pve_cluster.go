@@ -18,9 +18,12 @@ func InitPveClusterRouter(
 		strictAuthRouter.GET("/status", deps.PveClusterHandler.GetClusterStatus)
 		strictAuthRouter.GET("/resources", deps.PveClusterHandler.GetClusterResources)
 		strictAuthRouter.GET("/verify", deps.PveClusterHandler.VerifyCluster)
+		strictAuthRouter.POST("/probe", deps.PveClusterHandler.ProbeCluster)
 		strictAuthRouter.GET("/:id", deps.PveClusterHandler.GetCluster)
 		strictAuthRouter.POST("", deps.PveClusterHandler.CreateCluster)
 		strictAuthRouter.PUT("/:id", deps.PveClusterHandler.UpdateCluster)
 		strictAuthRouter.DELETE("/:id", deps.PveClusterHandler.DeleteCluster)
+		strictAuthRouter.GET("/:id/capabilities", deps.PveClusterHandler.GetClusterCapabilities)
+		strictAuthRouter.POST("/:id/capabilities/probe", deps.PveClusterHandler.ProbeClusterCapabilities)
 	}
 }
@@ -38,6 +38,7 @@ func InitPveNodeRouter(
 		strictAuthRouter.GET("/storage/status", deps.PveNodeHandler.GetNodeStorageStatus)
 		strictAuthRouter.GET("/storage/rrd", deps.PveNodeHandler.GetNodeStorageRRDData)
 		strictAuthRouter.GET("/storage/content", deps.PveNodeHandler.GetNodeStorageContent)
+		strictAuthRouter.GET("/storage/content/page", deps.PveNodeHandler.ListNodeStorageContent)
 		strictAuthRouter.GET("/storage/content/detail", deps.PveNodeHandler.GetNodeStorageVolume)
 		strictAuthRouter.POST("/storage/upload", deps.PveNodeHandler.UploadNodeStorageContent)
 		strictAuthRouter.DELETE("/storage/content", deps.PveNodeHandler.DeleteNodeStorageContent)
@@ -50,10 +51,34 @@ func InitPveNodeRouter(
 		strictAuthRouter.GET("/disks/zfs", deps.PveNodeHandler.GetNodeDisksZFS)
 		strictAuthRouter.POST("/disks/initgpt", deps.PveNodeHandler.InitGPTDisk)
 		strictAuthRouter.PUT("/disks/wipedisk", deps.PveNodeHandler.WipeDisk)
+		strictAuthRouter.GET("/disks/alerts", deps.NodeDiskHandler.ListNodeDiskAlerts)
+		strictAuthRouter.GET("/zfs/alerts", deps.NodeZFSHandler.ListZFSPoolAlerts)
 
 		strictAuthRouter.GET("/:id", deps.PveNodeHandler.GetNode)
 		strictAuthRouter.POST("", deps.PveNodeHandler.CreateNode)
 		strictAuthRouter.PUT("/:id", deps.PveNodeHandler.UpdateNode)
 		strictAuthRouter.DELETE("/:id", deps.PveNodeHandler.DeleteNode)
+
+		// 带外管理（BMC）
+		strictAuthRouter.GET("/:id/bmc", deps.NodeBMCHandler.GetNodeBMC)
+		strictAuthRouter.PUT("/:id/bmc", deps.NodeBMCHandler.SetNodeBMC)
+		strictAuthRouter.DELETE("/:id/bmc", deps.NodeBMCHandler.DeleteNodeBMC)
+		strictAuthRouter.GET("/:id/bmc/power", deps.NodeBMCHandler.GetNodePower)
+		strictAuthRouter.POST("/:id/bmc/power", deps.NodeBMCHandler.NodePowerAction)
+		strictAuthRouter.GET("/:id/bmc/power-state", deps.NodeBMCHandler.GetNodePowerState)
+		strictAuthRouter.GET("/:id/bmc/sensors", deps.NodeBMCHandler.GetNodeSensors)
+		strictAuthRouter.PUT("/:id/wol", deps.NodeBMCHandler.SetNodeWol)
+		strictAuthRouter.DELETE("/:id/wol", deps.NodeBMCHandler.DeleteNodeWol)
+		strictAuthRouter.POST("/:id/wake", deps.NodeBMCHandler.WakeNode)
+
+		// ZFS 存储池管理
+		strictAuthRouter.POST("/:id/zfs/pools", deps.NodeZFSHandler.CreateZFSPool)
+		strictAuthRouter.GET("/:id/zfs/pools/:name", deps.NodeZFSHandler.GetZFSPool)
+		strictAuthRouter.GET("/:id/zfs/datasets", deps.NodeZFSHandler.ListZFSDatasets)
+
+		// LVM / LVM-thin 存储创建
+		strictAuthRouter.POST("/:id/lvm", deps.NodeDiskHandler.CreateNodeLVM)
+		strictAuthRouter.POST("/:id/lvmthin", deps.NodeDiskHandler.CreateNodeLVMThin)
+		strictAuthRouter.GET("/:id/disks/smart", deps.NodeDiskHandler.GetNodeDiskSMART)
 	}
 }
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func InitPveSiteRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/sites").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.PveSiteHandler.ListSites)
+		strictAuthRouter.GET("/:id", deps.PveSiteHandler.GetSite)
+		strictAuthRouter.POST("", deps.PveSiteHandler.CreateSite)
+		strictAuthRouter.PUT("/:id", deps.PveSiteHandler.UpdateSite)
+		strictAuthRouter.DELETE("/:id", deps.PveSiteHandler.DeleteSite)
+	}
+}
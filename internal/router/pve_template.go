@@ -31,6 +31,10 @@ func InitTemplateManagementRouter(
 	{
 		// 模板导入（从备份文件）
 		strictAuthRouter.POST("/import", deps.TemplateManagementHandler.ImportTemplate)
+		// 上传备份文件并自动导入
+		strictAuthRouter.POST("/upload-import", deps.TemplateManagementHandler.UploadImportTemplate)
+		// 导入进度
+		strictAuthRouter.GET("/imports/:import_id", deps.TemplateManagementHandler.GetTemplateImport)
 		
 		// 模板详情（包含实例）
 		strictAuthRouter.GET("/:id/detail", deps.TemplateManagementHandler.GetTemplateDetail)
@@ -48,5 +52,6 @@ func InitTemplateManagementRouter(
 		syncTaskRouter.GET("", deps.TemplateManagementHandler.ListSyncTasks)
 		syncTaskRouter.GET("/:task_id", deps.TemplateManagementHandler.GetSyncTask)
 		syncTaskRouter.POST("/:task_id/retry", deps.TemplateManagementHandler.RetrySyncTask)
+		syncTaskRouter.POST("/:task_id/cancel", deps.TemplateManagementHandler.CancelSyncTask)
 	}
 }
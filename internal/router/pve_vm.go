@@ -21,21 +21,30 @@ func InitPveVMRouter(
 		strictAuthRouter.POST("", deps.PveVMHandler.CreateVM) // 仅创建数据库记录
 		// 注意：所有具体路径必须在 /:id 之前定义，避免路由冲突
 		strictAuthRouter.POST("/create", deps.PveVMHandler.CreateVMInProxmox) // 完整创建流程
+		strictAuthRouter.GET("/create-jobs", deps.PveVMHandler.ListCreateJobs)
+		strictAuthRouter.GET("/create-jobs/:id", deps.PveVMHandler.GetCreateJob)
 		strictAuthRouter.POST("/:id/start", deps.PveVMHandler.StartVM)
 		strictAuthRouter.POST("/:id/stop", deps.PveVMHandler.StopVM)
+		strictAuthRouter.GET("/:id/metrics", deps.PveVMHandler.GetVMMetrics)
 		// 配置相关路由必须在 /:id 之前定义
 		strictAuthRouter.GET("/config", deps.PveVMHandler.GetVMCurrentConfig)
 		strictAuthRouter.GET("/config/pending", deps.PveVMHandler.GetVMPendingConfig)
 		strictAuthRouter.PUT("/config", deps.PveVMHandler.UpdateVMConfig)
+		strictAuthRouter.PUT("/tags", deps.PveVMHandler.UpdateVMTags)
+		strictAuthRouter.PUT("/memory", deps.PveVMHandler.ResizeVMMemory)
 		strictAuthRouter.GET("/status", deps.PveVMHandler.GetVMStatus)
 		strictAuthRouter.POST("/console", deps.PveVMHandler.GetVMConsole)
+		strictAuthRouter.POST("/console/sessions/:session_id/renew", deps.PveVMHandler.RenewVMConsoleSession)
+		strictAuthRouter.POST("/console/sessions/:session_id/reconnect", deps.PveVMHandler.ReconnectVMConsole)
 		strictAuthRouter.GET("/rrd", deps.PveVMHandler.GetVMRRDData)
 		// 迁移相关路由必须在 /:id 之前定义
 		strictAuthRouter.POST("/migrate", deps.PveVMHandler.MigrateVM)
 		strictAuthRouter.POST("/remote-migrate", deps.PveVMHandler.RemoteMigrateVM)
+		strictAuthRouter.POST("/remote-migrate/preflight", deps.PveVMHandler.RemoteMigratePreflight)
 		// 备份相关路由必须在 /:id 之前定义
 		strictAuthRouter.POST("/backup", deps.PveVMHandler.CreateBackup)
 		strictAuthRouter.DELETE("/backup", deps.PveVMHandler.DeleteBackup)
+		strictAuthRouter.POST("/storage-forecast", deps.PveVMHandler.ForecastStorage)
 		// CloudInit 相关路由必须在 /:id 之前定义
 		strictAuthRouter.GET("/cloudinit", deps.PveVMHandler.GetVMCloudInit)
 		strictAuthRouter.PUT("/cloudinit", deps.PveVMHandler.UpdateVMCloudInit)
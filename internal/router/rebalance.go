@@ -0,0 +1,21 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitRebalanceRouter 配置负载再平衡路由
+func InitRebalanceRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	rebalanceRouter := r.Group("/rebalance").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		rebalanceRouter.POST("/plans", deps.RebalanceHandler.CreatePlan)
+		rebalanceRouter.GET("/plans", deps.RebalanceHandler.ListPlans)
+		rebalanceRouter.GET("/plans/:id", deps.RebalanceHandler.GetPlan)
+		rebalanceRouter.POST("/plans/:id/apply", deps.RebalanceHandler.ApplyPlan)
+	}
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitRemoteMigrationRouter 配置计划跨集群迁移和迁移切换路由
+func InitRemoteMigrationRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/remote-migrations").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.RemoteMigrationHandler.ListRemoteMigrations)
+		strictAuthRouter.POST("", deps.RemoteMigrationHandler.ScheduleRemoteMigration)
+		strictAuthRouter.POST("/:id/cancel", deps.RemoteMigrationHandler.CancelRemoteMigration)
+		strictAuthRouter.GET("/cutovers", deps.RemoteMigrationHandler.ListCutovers)
+		strictAuthRouter.GET("/cutovers/:id", deps.RemoteMigrationHandler.GetCutover)
+		strictAuthRouter.POST("/cutovers/:id/retry", deps.RemoteMigrationHandler.RetryCutover)
+	}
+}
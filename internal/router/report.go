@@ -0,0 +1,29 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitReportRouter 配置定期报告路由
+func InitReportRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/report-schedules").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("", deps.ReportHandler.ListReportSchedules)
+		strictAuthRouter.POST("", deps.ReportHandler.CreateReportSchedule)
+		strictAuthRouter.GET("/:id", deps.ReportHandler.GetReportSchedule)
+		strictAuthRouter.PUT("/:id", deps.ReportHandler.UpdateReportSchedule)
+		strictAuthRouter.DELETE("/:id", deps.ReportHandler.DeleteReportSchedule)
+		strictAuthRouter.POST("/:id/send", deps.ReportHandler.SendReport)
+		strictAuthRouter.GET("/:id/runs", deps.ReportHandler.ListReportRuns)
+	}
+
+	runRouter := r.Group("/report-runs").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		runRouter.GET("/:id/download", deps.ReportHandler.DownloadReportRun)
+	}
+}
@@ -22,4 +22,64 @@ type RouterDeps struct {
 	TemplateManagementHandler  *handler.TemplateManagementHandler
 	PveTaskHandler             *handler.PveTaskHandler
 	DashboardHandler           *handler.DashboardHandler
+	VMQosHandler               *handler.VMQosHandler
+	StorageGCHandler           *handler.StorageGCHandler
+	SearchHandler              *handler.SearchHandler
+	VMListViewHandler          *handler.VMListViewHandler
+	SchemaHandler              *handler.SchemaHandler
+	SystemConfigHandler        *handler.SystemConfigHandler
+	GrafanaHandler             *handler.GrafanaHandler
+	VMStackHandler             *handler.VMStackHandler
+	PveSiteHandler             *handler.PveSiteHandler
+	ChangeWindowHandler        *handler.ChangeWindowHandler
+	CostHandler                *handler.CostHandler
+	NodeBMCHandler             *handler.NodeBMCHandler
+	EnergyHandler              *handler.EnergyHandler
+	VMImportHandler            *handler.VMImportHandler
+	ImageTransferHandler       *handler.ImageTransferHandler
+	TemplateCatalogHandler     *handler.TemplateCatalogHandler
+	NodeVersionHandler         *handler.NodeVersionHandler
+	LicenseHandler             *handler.LicenseHandler
+	VMClaimHandler             *handler.VMClaimHandler
+	VMProfileHandler           *handler.VMProfileHandler
+	VMStorageMoveHandler       *handler.VMStorageMoveHandler
+	RebalanceHandler           *handler.RebalanceHandler
+	ConsoleAuditHandler        *handler.ConsoleAuditHandler
+	StorageBrowserHandler      *handler.StorageBrowserHandler
+	NodeZFSHandler             *handler.NodeZFSHandler
+	NodeDiskHandler            *handler.NodeDiskHandler
+	NotificationHandler        *handler.NotificationHandler
+	VMNetworkDiagHandler       *handler.VMNetworkDiagHandler
+	MACAddressHandler          *handler.MACAddressHandler
+	PveAccessHandler           *handler.PveAccessHandler
+	VMLockHandler              *handler.VMLockHandler
+	PendingOperationHandler    *handler.PendingOperationHandler
+	VMCredentialHandler        *handler.VMCredentialHandler
+	DependencyHandler          *handler.DependencyHandler
+	TemplateUsageHandler       *handler.TemplateUsageHandler
+	VMNICHandler               *handler.VMNICHandler
+	VMStartupHandler           *handler.VMStartupHandler
+	ReservationHandler         *handler.ReservationHandler
+	OperationAuditHandler      *handler.OperationAuditHandler
+	NodePoolHandler            *handler.NodePoolHandler
+	TemplatePromotionHandler   *handler.TemplatePromotionHandler
+	MetadataBackupHandler      *handler.MetadataBackupHandler
+	VMIDRangeHandler           *handler.VMIDRangeHandler
+	VMEventHandler             *handler.VMEventHandler
+	TopologyHandler            *handler.TopologyHandler
+	VMAgentInstallHandler      *handler.VMAgentInstallHandler
+	OSEOLHandler               *handler.OSEOLHandler
+	VMSecurityHandler          *handler.VMSecurityHandler
+	ClusterEndpointHandler     *handler.ClusterEndpointHandler
+	RemoteMigrationHandler     *handler.RemoteMigrationHandler
+	VMBulkDeleteHandler        *handler.VMBulkDeleteHandler
+	VMProtectionHandler        *handler.VMProtectionHandler
+	DashboardChangeHandler     *handler.DashboardChangeHandler
+	ClusterEventHandler        *handler.ClusterEventHandler
+	VMNotesHandler             *handler.VMNotesHandler
+	ReportHandler              *handler.ReportHandler
+	CapabilityHandler          *handler.CapabilityHandler
+	VMHibernateHandler         *handler.VMHibernateHandler
+	FirstBootHookHandler       *handler.FirstBootHookHandler
+	ContentReplicationHandler  *handler.ContentReplicationHandler
 }
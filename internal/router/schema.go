@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitSchemaRouter 配置数据库表结构版本路由
+func InitSchemaRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	schemaRouter := r.Group("/admin/schema").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		schemaRouter.GET("", deps.SchemaHandler.GetSchemaVersion)
+	}
+}
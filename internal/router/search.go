@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitSearchRouter 配置全局搜索路由
+func InitSearchRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	searchRouter := r.Group("/search").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		searchRouter.GET("", deps.SearchHandler.GlobalSearch)
+	}
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitStorageBrowserRouter 配置存储浏览器路由
+func InitStorageBrowserRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	browserRouter := r.Group("/storage-browser").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		browserRouter.GET("", deps.StorageBrowserHandler.Browse)
+		browserRouter.POST("/delete/prepare", deps.StorageBrowserHandler.PrepareDelete)
+		browserRouter.POST("/delete", deps.StorageBrowserHandler.ConfirmDelete)
+	}
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitStorageGCRouter 配置存储垃圾回收路由
+func InitStorageGCRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	gcRouter := r.Group("/storage-gc").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		gcRouter.GET("/scans", deps.StorageGCHandler.ListScans)
+		gcRouter.POST("/scans", deps.StorageGCHandler.CreateScan)
+		gcRouter.GET("/scans/:id", deps.StorageGCHandler.GetScan)
+		gcRouter.GET("/items", deps.StorageGCHandler.ListItems)
+		gcRouter.POST("/items/review", deps.StorageGCHandler.ReviewItems)
+		gcRouter.POST("/items/delete", deps.StorageGCHandler.DeleteItems)
+	}
+}
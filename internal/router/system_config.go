@@ -0,0 +1,21 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitSystemConfigRouter 配置系统管理（运行时配置热更新）路由，管理员权限在 service 层校验
+func InitSystemConfigRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	configRouter := r.Group("/admin/config").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		configRouter.GET("", deps.SystemConfigHandler.GetRuntimeConfig)
+		configRouter.PUT("", deps.SystemConfigHandler.UpdateRuntimeConfig)
+		configRouter.POST("/reload", deps.SystemConfigHandler.ReloadConfig)
+		configRouter.GET("/audits", deps.SystemConfigHandler.ListConfigAudits)
+	}
+}
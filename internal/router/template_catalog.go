@@ -0,0 +1,28 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitTemplateCatalogRouter 配置模板目录路由
+func InitTemplateCatalogRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	catalogRouter := r.Group("/template-catalogs").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		// 目录订阅
+		catalogRouter.POST("", deps.TemplateCatalogHandler.CreateCatalog)
+		catalogRouter.GET("", deps.TemplateCatalogHandler.ListCatalogs)
+		catalogRouter.DELETE("/:id", deps.TemplateCatalogHandler.DeleteCatalog)
+		catalogRouter.POST("/:id/refresh", deps.TemplateCatalogHandler.RefreshCatalog)
+
+		// 镜像与安装任务
+		catalogRouter.GET("/images", deps.TemplateCatalogHandler.ListImages)
+		catalogRouter.POST("/installs", deps.TemplateCatalogHandler.InstallImage)
+		catalogRouter.GET("/installs", deps.TemplateCatalogHandler.ListInstalls)
+		catalogRouter.GET("/installs/:id", deps.TemplateCatalogHandler.GetInstall)
+	}
+}
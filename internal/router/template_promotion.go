@@ -0,0 +1,23 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitTemplatePromotionRouter 配置模板晋级路由
+func InitTemplatePromotionRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	promotionRouter := r.Group("/template-promotions").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		promotionRouter.GET("", deps.TemplatePromotionHandler.ListPromotions)
+		promotionRouter.POST("", deps.TemplatePromotionHandler.RequestPromotion)
+		promotionRouter.GET("/:id", deps.TemplatePromotionHandler.GetPromotion)
+		promotionRouter.GET("/:id/chain", deps.TemplatePromotionHandler.GetPromotionChain)
+		promotionRouter.POST("/:id/approve", deps.TemplatePromotionHandler.ApprovePromotion)
+		promotionRouter.POST("/:id/reject", deps.TemplatePromotionHandler.RejectPromotion)
+	}
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitTemplateUsageRouter 配置模板使用统计路由
+func InitTemplateUsageRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	usageRouter := r.Group("/templates").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		usageRouter.GET("/aging-report", deps.TemplateUsageHandler.AgingReport)
+		usageRouter.GET("/:id/usage", deps.TemplateUsageHandler.GetUsage)
+	}
+}
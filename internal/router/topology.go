@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitTopologyRouter 配置拓扑图路由
+func InitTopologyRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	topologyRouter := r.Group("/topology").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		topologyRouter.GET("", deps.TopologyHandler.GetTopology)
+	}
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMAgentInstallRouter 配置 guest agent 安装辅助路由
+func InitVMAgentInstallRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	vmRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		vmRouter.POST("/:id/agent-install", deps.VMAgentInstallHandler.StartVMAgentInstall)
+		vmRouter.GET("/:id/agent-install", deps.VMAgentInstallHandler.GetVMAgentInstall)
+	}
+	installRouter := r.Group("/agent-installs").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		installRouter.GET("", deps.VMAgentInstallHandler.ListVMAgentInstalls)
+		installRouter.POST("/:id/cancel", deps.VMAgentInstallHandler.CancelVMAgentInstall)
+	}
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMBulkDeleteRouter 配置批量删除虚拟机路由
+func InitVMBulkDeleteRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/vm-bulk-deletes").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.POST("/prepare", deps.VMBulkDeleteHandler.PrepareBulkDelete)
+		strictAuthRouter.POST("", deps.VMBulkDeleteHandler.ConfirmBulkDelete)
+		strictAuthRouter.GET("", deps.VMBulkDeleteHandler.ListBulkDeletes)
+		strictAuthRouter.GET("/:id", deps.VMBulkDeleteHandler.GetBulkDelete)
+	}
+}
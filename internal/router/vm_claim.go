@@ -0,0 +1,21 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMClaimRouter 配置待认领虚拟机路由
+func InitVMClaimRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	claimRouter := r.Group("/unclaimed-vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		claimRouter.GET("", deps.VMClaimHandler.ListUnclaimedVMs)
+		claimRouter.POST("/refresh", deps.VMClaimHandler.RefreshUnclaimedVMs)
+		claimRouter.POST("/:id/adopt", deps.VMClaimHandler.AdoptUnclaimedVM)
+		claimRouter.PUT("/:id/ignore", deps.VMClaimHandler.IgnoreUnclaimedVM)
+	}
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMCredentialRouter 配置虚拟机凭据路由
+func InitVMCredentialRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	vmRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		vmRouter.POST("/:id/credentials/lease", deps.VMCredentialHandler.LeaseVMCredential)
+	}
+
+	strictAuthRouter := r.Group("/vm-credentials").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.POST("/retrieve", deps.VMCredentialHandler.RetrieveVMCredential)
+		strictAuthRouter.POST("/migrate", deps.VMCredentialHandler.MigrateVMCredentials)
+		strictAuthRouter.GET("/audit", deps.VMCredentialHandler.ListVMCredentialAudit)
+	}
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMHibernateRouter 配置虚拟机休眠路由
+func InitVMHibernateRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/hibernated", deps.VMHibernateHandler.ListHibernatedVMs)
+		strictAuthRouter.POST("/:id/hibernate", deps.VMHibernateHandler.HibernateVM)
+		strictAuthRouter.POST("/:id/resume", deps.VMHibernateHandler.ResumeVM)
+	}
+}
@@ -0,0 +1,28 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMImportRouter 配置虚拟机导入路由
+func InitVMImportRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	importRouter := r.Group("/vm-imports").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		// ESXi 导入源
+		importRouter.POST("/sources", deps.VMImportHandler.CreateSource)
+		importRouter.GET("/sources", deps.VMImportHandler.ListSources)
+		importRouter.DELETE("/sources/:id", deps.VMImportHandler.DeleteSource)
+		importRouter.GET("/sources/:id/vms", deps.VMImportHandler.ListSourceVMs)
+
+		// 导入元数据与导入任务
+		importRouter.GET("/metadata", deps.VMImportHandler.GetMetadata)
+		importRouter.POST("", deps.VMImportHandler.CreateImport)
+		importRouter.GET("", deps.VMImportHandler.ListImports)
+		importRouter.GET("/:id", deps.VMImportHandler.GetImport)
+	}
+}
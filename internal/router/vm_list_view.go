@@ -0,0 +1,22 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMListViewRouter 配置虚拟机列表视图（用户偏好）路由
+func InitVMListViewRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	viewRouter := r.Group("/preferences/vm-views").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		viewRouter.GET("", deps.VMListViewHandler.ListViews)
+		viewRouter.POST("", deps.VMListViewHandler.CreateView)
+		viewRouter.GET("/:id", deps.VMListViewHandler.GetView)
+		viewRouter.PUT("/:id", deps.VMListViewHandler.UpdateView)
+		viewRouter.DELETE("/:id", deps.VMListViewHandler.DeleteView)
+	}
+}
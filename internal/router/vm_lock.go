@@ -0,0 +1,25 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMLockRouter 配置虚拟机维护锁路由
+func InitVMLockRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/:id/lock", deps.VMLockHandler.GetVMLock)
+		strictAuthRouter.PUT("/:id/lock", deps.VMLockHandler.LockVM)
+		strictAuthRouter.DELETE("/:id/lock", deps.VMLockHandler.UnlockVM)
+	}
+
+	lockRouter := r.Group("/vm-locks").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		lockRouter.GET("", deps.VMLockHandler.ListVMLocks)
+	}
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMNetworkDiagRouter 配置虚拟机网络诊断路由
+func InitVMNetworkDiagRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.POST("/:id/network/diagnose", deps.VMNetworkDiagHandler.Diagnose)
+	}
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMNICRouter 配置虚拟机网卡管理路由
+func InitVMNICRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/:id/nics", deps.VMNICHandler.ListNICs)
+		strictAuthRouter.POST("/:id/nics", deps.VMNICHandler.AddNIC)
+		strictAuthRouter.PUT("/:id/nics/:nic", deps.VMNICHandler.UpdateNIC)
+		strictAuthRouter.DELETE("/:id/nics/:nic", deps.VMNICHandler.RemoveNIC)
+	}
+}
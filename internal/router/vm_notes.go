@@ -0,0 +1,19 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMNotesRouter 配置虚拟机备注路由
+func InitVMNotesRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/:id/notes", deps.VMNotesHandler.GetVMNotes)
+		strictAuthRouter.PUT("/:id/notes", deps.VMNotesHandler.UpdateVMNotes)
+	}
+}
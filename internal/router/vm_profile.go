@@ -0,0 +1,22 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMProfileRouter 配置虚拟机创建规格路由
+func InitVMProfileRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	profileRouter := r.Group("/vm-profiles").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		profileRouter.GET("", deps.VMProfileHandler.ListVMProfiles)
+		profileRouter.POST("", deps.VMProfileHandler.CreateVMProfile)
+		profileRouter.GET("/:id", deps.VMProfileHandler.GetVMProfile)
+		profileRouter.PUT("/:id", deps.VMProfileHandler.UpdateVMProfile)
+		profileRouter.DELETE("/:id", deps.VMProfileHandler.DeleteVMProfile)
+	}
+}
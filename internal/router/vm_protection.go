@@ -0,0 +1,20 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMProtectionRouter 配置虚拟机删除保护路由
+func InitVMProtectionRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	strictAuthRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/:id/protection", deps.VMProtectionHandler.GetVMProtection)
+		strictAuthRouter.PUT("/:id/protection", deps.VMProtectionHandler.ProtectVM)
+		strictAuthRouter.DELETE("/:id/protection", deps.VMProtectionHandler.UnprotectVM)
+	}
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMQosRouter 配置虚拟机 QoS（磁盘/网卡限速）路由
+func InitVMQosRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	profileRouter := r.Group("/qos-profiles").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		profileRouter.GET("", deps.VMQosHandler.ListProfiles)
+		profileRouter.POST("", deps.VMQosHandler.CreateProfile)
+		profileRouter.GET("/:id", deps.VMQosHandler.GetProfile)
+		profileRouter.PUT("/:id", deps.VMQosHandler.UpdateProfile)
+		profileRouter.DELETE("/:id", deps.VMQosHandler.DeleteProfile)
+		profileRouter.POST("/:id/apply", deps.VMQosHandler.ApplyProfile)
+	}
+
+	vmRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		vmRouter.GET("/qos", deps.VMQosHandler.GetVMQos)
+		vmRouter.PUT("/qos", deps.VMQosHandler.UpdateVMQos)
+	}
+}
@@ -0,0 +1,23 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMSecurityRouter 配置虚拟机配置安全检查路由
+func InitVMSecurityRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	vmRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		vmRouter.GET("/:id/security", deps.VMSecurityHandler.ScanVMSecurity)
+		vmRouter.POST("/:id/security/remediate", deps.VMSecurityHandler.RemediateVMSecurity)
+	}
+	securityRouter := r.Group("/security").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		securityRouter.GET("/findings", deps.VMSecurityHandler.ListVMSecurityFindings)
+	}
+}
@@ -0,0 +1,22 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMStackRouter 配置虚拟机编排路由
+func InitVMStackRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	stackRouter := r.Group("/stacks").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		stackRouter.POST("", deps.VMStackHandler.CreateStack)
+		stackRouter.GET("", deps.VMStackHandler.ListStacks)
+		stackRouter.GET("/:id", deps.VMStackHandler.GetStack)
+		stackRouter.GET("/:id/ips", deps.VMStackHandler.GetStackIPs)
+		stackRouter.DELETE("/:id", deps.VMStackHandler.DeleteStack)
+	}
+}
@@ -0,0 +1,28 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMStartupRouter 配置虚拟机开机自启 / 启动顺序及启动顺序策略路由
+func InitVMStartupRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	vmRouter := r.Group("/vms").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		vmRouter.GET("/:id/startup", deps.VMStartupHandler.GetVMStartup)
+		vmRouter.PUT("/:id/startup", deps.VMStartupHandler.UpdateVMStartup)
+	}
+
+	policyRouter := r.Group("/boot-order-policies").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		policyRouter.GET("", deps.VMStartupHandler.ListPolicies)
+		policyRouter.POST("", deps.VMStartupHandler.CreatePolicy)
+		policyRouter.POST("/apply", deps.VMStartupHandler.ApplyPolicies)
+		policyRouter.PUT("/:id", deps.VMStartupHandler.UpdatePolicy)
+		policyRouter.DELETE("/:id", deps.VMStartupHandler.DeletePolicy)
+	}
+}
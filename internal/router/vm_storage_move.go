@@ -0,0 +1,20 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMStorageMoveRouter 配置虚拟机存储迁移路由
+func InitVMStorageMoveRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	moveRouter := r.Group("/vm-storage-moves").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		moveRouter.POST("", deps.VMStorageMoveHandler.CreateMove)
+		moveRouter.GET("", deps.VMStorageMoveHandler.ListMoves)
+		moveRouter.GET("/:id", deps.VMStorageMoveHandler.GetMove)
+	}
+}
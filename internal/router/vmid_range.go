@@ -0,0 +1,23 @@
+package router
+
+import (
+	"pvesphere/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InitVMIDRangeRouter 配置 VMID 段路由
+func InitVMIDRangeRouter(
+	deps RouterDeps,
+	r *gin.RouterGroup,
+) {
+	rangeRouter := r.Group("/vmid-ranges").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		rangeRouter.GET("", deps.VMIDRangeHandler.ListVMIDRanges)
+		rangeRouter.POST("", deps.VMIDRangeHandler.CreateVMIDRange)
+		rangeRouter.GET("/allocations", deps.VMIDRangeHandler.ListVMIDAllocations)
+		rangeRouter.GET("/:id", deps.VMIDRangeHandler.GetVMIDRange)
+		rangeRouter.PUT("/:id", deps.VMIDRangeHandler.UpdateVMIDRange)
+		rangeRouter.DELETE("/:id", deps.VMIDRangeHandler.DeleteVMIDRange)
+	}
+}
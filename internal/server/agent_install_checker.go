@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 agent_install.checker.interval 时的默认检测间隔
+const defaultAgentInstallCheckInterval = 2 * time.Minute
+
+// AgentInstallCheckerServer 定期 ping 等待安装 guest agent 的虚拟机，成功或超时后卸载安装介质
+//
+// 配置示例：
+//
+//	agent_install:
+//	  iso: "local:iso/virtio-win.iso"
+//	  snippet: "local:snippets/qemu-guest-agent.yaml"
+//	  timeout: 72h
+//	  checker:
+//	    enabled: true
+//	    interval: 2m
+type AgentInstallCheckerServer struct {
+	installService service.VMAgentInstallService
+	log            *log.Logger
+	enabled        bool
+	interval       time.Duration
+	done           chan struct{}
+}
+
+func NewAgentInstallCheckerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	installService service.VMAgentInstallService,
+) *AgentInstallCheckerServer {
+	interval := conf.GetDuration("agent_install.checker.interval")
+	if interval <= 0 {
+		interval = defaultAgentInstallCheckInterval
+	}
+	return &AgentInstallCheckerServer{
+		installService: installService,
+		log:            log,
+		enabled:        conf.GetBool("agent_install.checker.enabled"),
+		interval:       interval,
+		done:           make(chan struct{}),
+	}
+}
+
+func (s *AgentInstallCheckerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("agent install checker started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.installService.CheckPending(ctx); err != nil {
+				s.log.Error("check guest agent installs failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *AgentInstallCheckerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 dashboard.capacity_snapshot.interval / retention_days 时的默认值
+const (
+	defaultCapacitySnapshotInterval  = time.Hour
+	defaultCapacitySnapshotRetention = 90
+)
+
+// CapacitySnapshotServer 定期记录各集群的容量快照（虚拟机数量、分配的 CPU/内存/磁盘、存储用量），
+// 变化概览用时间段开始前最近的快照计算容量变化；超过保留天数的快照在记录时一并删除
+//
+// 配置示例：
+//
+//	dashboard:
+//	  capacity_snapshot:
+//	    enabled: true
+//	    interval: 1h
+//	    retention_days: 90
+type CapacitySnapshotServer struct {
+	changeService service.DashboardChangeService
+	log           *log.Logger
+	enabled       bool
+	interval      time.Duration
+	retentionDays int
+	done          chan struct{}
+}
+
+func NewCapacitySnapshotServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	changeService service.DashboardChangeService,
+) *CapacitySnapshotServer {
+	interval := conf.GetDuration("dashboard.capacity_snapshot.interval")
+	if interval <= 0 {
+		interval = defaultCapacitySnapshotInterval
+	}
+	retentionDays := conf.GetInt("dashboard.capacity_snapshot.retention_days")
+	if retentionDays <= 0 {
+		retentionDays = defaultCapacitySnapshotRetention
+	}
+	return &CapacitySnapshotServer{
+		changeService: changeService,
+		log:           log,
+		enabled:       conf.GetBool("dashboard.capacity_snapshot.enabled"),
+		interval:      interval,
+		retentionDays: retentionDays,
+		done:          make(chan struct{}),
+	}
+}
+
+func (s *CapacitySnapshotServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("capacity snapshot started", zap.Duration("interval", s.interval), zap.Int("retention_days", s.retentionDays))
+
+	// 启动时先记录一次，尽早有可对比的基线
+	s.snapshot(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.snapshot(ctx)
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *CapacitySnapshotServer) snapshot(ctx context.Context) {
+	if err := s.changeService.RecordCapacitySnapshots(ctx); err != nil {
+		s.log.Error("record capacity snapshots failed", zap.Error(err))
+	}
+	removed, err := s.changeService.CleanupCapacitySnapshots(ctx, time.Now().AddDate(0, 0, -s.retentionDays))
+	if err != nil {
+		s.log.Error("cleanup capacity snapshots failed", zap.Error(err))
+		return
+	}
+	if removed > 0 {
+		s.log.Info("expired capacity snapshots removed", zap.Int64("count", removed))
+	}
+}
+
+func (s *CapacitySnapshotServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 cluster_event.poller.interval 时的默认轮询间隔；过期事件每小时清理一次
+const (
+	defaultClusterEventPollInterval = 10 * time.Second
+	clusterEventCleanupInterval     = time.Hour
+)
+
+// ClusterEventPollerServer 定期轮询各集群的任务列表和节点 journal，记录在 PveSphere 之外所做的变更，
+// 使外部变更在数秒内进入事件列表、通知和待认领扫描，而不是等到下一次全量同步。
+// 事件按来源记录去重，多实例同时开启时不会重复记录
+//
+// 配置示例：
+//
+//	cluster_event:
+//	  poller:
+//	    enabled: true
+//	    interval: 10s
+type ClusterEventPollerServer struct {
+	eventService service.ClusterEventService
+	log          *log.Logger
+	enabled      bool
+	interval     time.Duration
+	lastCleanup  time.Time
+	done         chan struct{}
+}
+
+func NewClusterEventPollerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	eventService service.ClusterEventService,
+) *ClusterEventPollerServer {
+	interval := conf.GetDuration("cluster_event.poller.interval")
+	if interval <= 0 {
+		interval = defaultClusterEventPollInterval
+	}
+	return &ClusterEventPollerServer{
+		eventService: eventService,
+		log:          log,
+		enabled:      conf.GetBool("cluster_event.poller.enabled"),
+		interval:     interval,
+		done:         make(chan struct{}),
+	}
+}
+
+func (s *ClusterEventPollerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("cluster event poller started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.poll(ctx)
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *ClusterEventPollerServer) poll(ctx context.Context) {
+	created, err := s.eventService.Poll(ctx)
+	if err != nil {
+		s.log.Error("poll cluster events failed", zap.Error(err))
+	} else if created > 0 {
+		s.log.Info("out-of-band cluster changes recorded", zap.Int("count", created))
+	}
+
+	if time.Since(s.lastCleanup) < clusterEventCleanupInterval {
+		return
+	}
+	s.lastCleanup = time.Now()
+	removed, err := s.eventService.Cleanup(ctx)
+	if err != nil {
+		s.log.Error("cleanup cluster events failed", zap.Error(err))
+		return
+	}
+	if removed > 0 {
+		s.log.Info("expired cluster events removed", zap.Int64("count", removed))
+	}
+}
+
+func (s *ClusterEventPollerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
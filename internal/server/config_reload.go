@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// ConfigReloadServer 监听 SIGHUP 信号，重新加载配置文件中可热更新的配置项
+// 用法：kill -HUP <server pid>
+type ConfigReloadServer struct {
+	configService service.SystemConfigService
+	log           *log.Logger
+	signals       chan os.Signal
+	done          chan struct{}
+}
+
+func NewConfigReloadServer(
+	log *log.Logger,
+	configService service.SystemConfigService,
+) *ConfigReloadServer {
+	return &ConfigReloadServer{
+		configService: configService,
+		log:           log,
+		signals:       make(chan os.Signal, 1),
+		done:          make(chan struct{}),
+	}
+}
+
+func (s *ConfigReloadServer) Start(ctx context.Context) error {
+	signal.Notify(s.signals, syscall.SIGHUP)
+	s.log.Info("config reload server started, send SIGHUP to reload config")
+
+	for {
+		select {
+		case <-s.signals:
+			s.log.Info("received SIGHUP, reloading config")
+			data, err := s.configService.ReloadFromFile(ctx, "", v1.ConfigSourceSIGHUP)
+			if err != nil {
+				// 校验失败时保持原有配置
+				s.log.Error("reload config failed, keep current config", zap.Error(err))
+				continue
+			}
+			s.log.Info("config reloaded", zap.Int("changes", len(data.Changes)))
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *ConfigReloadServer) Stop(ctx context.Context) error {
+	signal.Stop(s.signals)
+	close(s.done)
+	return nil
+}
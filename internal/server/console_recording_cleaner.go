@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 console_audit.recording.cleaner.interval 时的默认清理间隔
+const defaultConsoleRecordingCleanInterval = 6 * time.Hour
+
+// ConsoleRecordingCleanerServer 定期删除超过保留期的控制台会话录像（审计记录本身保留）
+//
+// 配置示例：
+//
+//	console_audit:
+//	  recording:
+//	    object_store_id: 1
+//	    retention_days: 90
+//	    cleaner:
+//	      enabled: true
+//	      interval: 6h
+type ConsoleRecordingCleanerServer struct {
+	auditService service.ConsoleAuditService
+	log          *log.Logger
+	enabled      bool
+	interval     time.Duration
+	done         chan struct{}
+}
+
+func NewConsoleRecordingCleanerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	auditService service.ConsoleAuditService,
+) *ConsoleRecordingCleanerServer {
+	interval := conf.GetDuration("console_audit.recording.cleaner.interval")
+	if interval <= 0 {
+		interval = defaultConsoleRecordingCleanInterval
+	}
+	return &ConsoleRecordingCleanerServer{
+		auditService: auditService,
+		log:          log,
+		enabled:      conf.GetBool("console_audit.recording.cleaner.enabled"),
+		interval:     interval,
+		done:         make(chan struct{}),
+	}
+}
+
+func (s *ConsoleRecordingCleanerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("console recording cleaner started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := s.auditService.CleanupExpiredRecordings(ctx)
+			if err != nil {
+				s.log.Error("cleanup console recordings failed", zap.Error(err))
+				continue
+			}
+			if removed > 0 {
+				s.log.Info("expired console recordings removed", zap.Int("count", removed))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *ConsoleRecordingCleanerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 content_replication.reconciler.interval 时的默认对账间隔
+const defaultContentReplicationInterval = time.Hour
+
+// ContentReplicationServer 定期对账存储内容复制策略，auto_copy 的策略同时复制缺少的文件
+// 复制任务由领取成功的实例执行，多实例同时开启不会重复复制
+//
+// 配置示例：
+//
+//	content_replication:
+//	  concurrency: 2
+//	  timeout: 2h
+//	  source_dirs:
+//	    iso-nfs: /mnt/pve/iso-nfs
+//	  reconciler:
+//	    enabled: true
+//	    interval: 1h
+type ContentReplicationServer struct {
+	replicationService service.ContentReplicationService
+	log                *log.Logger
+	enabled            bool
+	interval           time.Duration
+	done               chan struct{}
+}
+
+func NewContentReplicationServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	replicationService service.ContentReplicationService,
+) *ContentReplicationServer {
+	interval := conf.GetDuration("content_replication.reconciler.interval")
+	if interval <= 0 {
+		interval = defaultContentReplicationInterval
+	}
+	return &ContentReplicationServer{
+		replicationService: replicationService,
+		log:                log,
+		enabled:            conf.GetBool("content_replication.reconciler.enabled"),
+		interval:           interval,
+		done:               make(chan struct{}),
+	}
+}
+
+func (s *ContentReplicationServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("content replication reconciler started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.replicationService.ReconcileAll(ctx); err != nil {
+				s.log.Error("reconcile content replication policies failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *ContentReplicationServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 cost.collector.interval 时的默认采集间隔
+const defaultCostCollectInterval = 10 * time.Minute
+
+// CostCollectorServer 定期采集虚拟机资源分配用于成本核算，并检查应用月度预算
+// 多实例部署时只应在其中一个实例开启，否则用量会被重复累加
+//
+// 配置示例：
+//
+//	cost:
+//	  collector:
+//	    enabled: true
+//	    interval: 10m
+type CostCollectorServer struct {
+	costService service.CostService
+	log         *log.Logger
+	enabled     bool
+	interval    time.Duration
+	done        chan struct{}
+}
+
+func NewCostCollectorServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	costService service.CostService,
+) *CostCollectorServer {
+	interval := conf.GetDuration("cost.collector.interval")
+	if interval <= 0 {
+		interval = defaultCostCollectInterval
+	}
+	return &CostCollectorServer{
+		costService: costService,
+		log:         log,
+		enabled:     conf.GetBool("cost.collector.enabled"),
+		interval:    interval,
+		done:        make(chan struct{}),
+	}
+}
+
+func (s *CostCollectorServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("cost collector started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// 每次采集计入一个完整间隔的用量
+			if err := s.costService.CollectUsage(ctx, s.interval); err != nil {
+				s.log.Error("collect cost usage failed", zap.Error(err))
+				continue
+			}
+			if err := s.costService.CheckBudgets(ctx); err != nil {
+				s.log.Error("check cost budgets failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *CostCollectorServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 node_disk.smart.collector.interval 时的默认采集间隔
+const defaultDiskSMARTCollectInterval = time.Hour
+
+// DiskSMARTCollectorServer 定期采集在线节点磁盘的 SMART 指标，超过阈值时记录告警
+//
+// 配置示例：
+//
+//	node_disk:
+//	  smart:
+//	    collector:
+//	      enabled: true
+//	      interval: 1h
+type DiskSMARTCollectorServer struct {
+	nodeDiskService service.NodeDiskService
+	log             *log.Logger
+	enabled         bool
+	interval        time.Duration
+	done            chan struct{}
+}
+
+func NewDiskSMARTCollectorServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	nodeDiskService service.NodeDiskService,
+) *DiskSMARTCollectorServer {
+	interval := conf.GetDuration("node_disk.smart.collector.interval")
+	if interval <= 0 {
+		interval = defaultDiskSMARTCollectInterval
+	}
+	return &DiskSMARTCollectorServer{
+		nodeDiskService: nodeDiskService,
+		log:             log,
+		enabled:         conf.GetBool("node_disk.smart.collector.enabled"),
+		interval:        interval,
+		done:            make(chan struct{}),
+	}
+}
+
+func (s *DiskSMARTCollectorServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("disk smart collector started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.nodeDiskService.CollectSMART(ctx); err != nil {
+				s.log.Error("collect disk smart failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *DiskSMARTCollectorServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+
+	"pvesphere/internal/controller"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+)
+
+// EmbeddedServer 单进程（嵌入式）部署模式
+// 适用于 SQLite 等无外部依赖的家庭实验室场景：server 启动时自动建表，并在同一进程内运行 controller，
+// 无需再单独执行 migration 和 controller。
+//
+// 配置示例：
+//
+//	embedded:
+//	  auto_migrate: true # 启动时自动创建/更新表结构并初始化默认用户
+//	  controller: true   # 在 server 进程内运行集群同步 controller
+type EmbeddedServer struct {
+	controller        *controller.PveController
+	log               *log.Logger
+	controllerEnabled bool
+}
+
+// NewEmbeddedServer 在 HTTP 服务启动之前同步完成自动迁移，保证首次请求时表结构已就绪
+func NewEmbeddedServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	migrateServer *MigrateServer,
+	pveController *controller.PveController,
+) (*EmbeddedServer, error) {
+	if conf.GetBool("embedded.auto_migrate") {
+		log.Info("embedded mode: running auto migration")
+		if err := migrateServer.Migrate(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return &EmbeddedServer{
+		controller:        pveController,
+		log:               log,
+		controllerEnabled: conf.GetBool("embedded.controller"),
+	}, nil
+}
+
+func (s *EmbeddedServer) Start(ctx context.Context) error {
+	if !s.controllerEnabled {
+		return nil
+	}
+	s.log.Info("embedded mode: starting controller")
+	return s.controller.Start(ctx)
+}
+
+func (s *EmbeddedServer) Stop(ctx context.Context) error {
+	if !s.controllerEnabled {
+		return nil
+	}
+	s.log.Info("embedded mode: stopping controller")
+	return s.controller.Stop(ctx)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 energy.collector.interval 时的默认采集间隔
+const defaultEnergyCollectInterval = 5 * time.Minute
+
+// EnergyCollectorServer 定期通过节点 BMC 读取功率，累计节点能耗并分摊到虚拟机
+// 多实例部署时只应在其中一个实例开启，否则能耗会被重复累加
+//
+// 配置示例：
+//
+//	energy:
+//	  collector:
+//	    enabled: true
+//	    interval: 5m
+type EnergyCollectorServer struct {
+	energyService service.EnergyService
+	log           *log.Logger
+	enabled       bool
+	interval      time.Duration
+	done          chan struct{}
+}
+
+func NewEnergyCollectorServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	energyService service.EnergyService,
+) *EnergyCollectorServer {
+	interval := conf.GetDuration("energy.collector.interval")
+	if interval <= 0 {
+		interval = defaultEnergyCollectInterval
+	}
+	return &EnergyCollectorServer{
+		energyService: energyService,
+		log:           log,
+		enabled:       conf.GetBool("energy.collector.enabled"),
+		interval:      interval,
+		done:          make(chan struct{}),
+	}
+}
+
+func (s *EnergyCollectorServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("energy collector started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			// 每次读数代表一个完整间隔内的平均功率
+			if err := s.energyService.CollectPower(ctx, s.interval); err != nil {
+				s.log.Error("collect node power failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *EnergyCollectorServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 first_boot.checker.interval 时的默认检测间隔
+const defaultFirstBootCheckInterval = time.Minute
+
+// FirstBootCheckerServer 定期检测从带首次启动钩子的模板创建的虚拟机，guest agent 上线后调用钩子，失败时重试
+//
+// 配置示例：
+//
+//	first_boot:
+//	  agent_timeout: 24h
+//	  retry_interval: 5m
+//	  max_attempts: 5
+//	  script_timeout: 1m
+//	  scripts:
+//	    register-cmdb: /opt/pvesphere/hooks/register-cmdb.sh
+//	  checker:
+//	    enabled: true
+//	    interval: 1m
+type FirstBootCheckerServer struct {
+	firstBootService service.FirstBootHookService
+	log              *log.Logger
+	enabled          bool
+	interval         time.Duration
+	done             chan struct{}
+}
+
+func NewFirstBootCheckerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	firstBootService service.FirstBootHookService,
+) *FirstBootCheckerServer {
+	interval := conf.GetDuration("first_boot.checker.interval")
+	if interval <= 0 {
+		interval = defaultFirstBootCheckInterval
+	}
+	return &FirstBootCheckerServer{
+		firstBootService: firstBootService,
+		log:              log,
+		enabled:          conf.GetBool("first_boot.checker.enabled"),
+		interval:         interval,
+		done:             make(chan struct{}),
+	}
+}
+
+func (s *FirstBootCheckerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("first boot checker started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.firstBootService.CheckPending(ctx); err != nil {
+				s.log.Error("check first boot hooks failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *FirstBootCheckerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -18,8 +18,15 @@ func NewHTTPServer(
 	if deps.Config.GetString("env") == "prod" {
 		gin.SetMode(gin.ReleaseMode)
 	}
+	// 错误信息默认语言（请求可通过 Accept-Language 或 ?lang= 覆盖）
+	if lang := deps.Config.GetString("http.default_language"); apiV1.SupportedLanguage(lang) {
+		apiV1.DefaultLanguage = lang
+	}
+	engine := gin.Default()
+	// handler 直接将 *gin.Context 作为 context 传给 service，开启回退后可读取 Request.Context 中的 trace span
+	engine.ContextWithFallback = true
 	s := http.NewServer(
-		gin.Default(),
+		engine,
 		deps.Logger,
 		http.WithServerHost(deps.Config.GetString("http.host")),
 		http.WithServerPort(deps.Config.GetInt("http.port")),
@@ -36,8 +43,11 @@ func NewHTTPServer(
 
 	s.Use(
 		middleware.CORSMiddleware(),
+		middleware.TracingMiddleware(),
 		middleware.ResponseLogMiddleware(deps.Logger),
 		middleware.RequestLogMiddleware(deps.Logger),
+		middleware.ChangeOverrideMiddleware(),
+		middleware.OperationAuditMiddleware(deps.OperationAuditHandler),
 		//middleware.SignMiddleware(log),
 	)
 	s.GET("/", func(ctx *gin.Context) {
@@ -61,6 +71,62 @@ func NewHTTPServer(
 	router.InitTemplateManagementRouter(deps, apiV1)
 	router.InitPveTaskRouter(deps, apiV1)
 	router.InitDashboardRouter(deps, apiV1)
+	router.InitVMQosRouter(deps, apiV1)
+	router.InitStorageGCRouter(deps, apiV1)
+	router.InitSearchRouter(deps, apiV1)
+	router.InitVMListViewRouter(deps, apiV1)
+	router.InitSchemaRouter(deps, apiV1)
+	router.InitSystemConfigRouter(deps, apiV1)
+	router.InitGrafanaRouter(deps, apiV1)
+	router.InitVMStackRouter(deps, apiV1)
+	router.InitPveSiteRouter(deps, apiV1)
+	router.InitChangeWindowRouter(deps, apiV1)
+	router.InitCostRouter(deps, apiV1)
+	router.InitEnergyRouter(deps, apiV1)
+	router.InitVMImportRouter(deps, apiV1)
+	router.InitImageTransferRouter(deps, apiV1)
+	router.InitTemplateCatalogRouter(deps, apiV1)
+	router.InitNodeVersionRouter(deps, apiV1)
+	router.InitLicenseRouter(deps, apiV1)
+	router.InitVMClaimRouter(deps, apiV1)
+	router.InitVMProfileRouter(deps, apiV1)
+	router.InitVMStorageMoveRouter(deps, apiV1)
+	router.InitRebalanceRouter(deps, apiV1)
+	router.InitConsoleAuditRouter(deps, apiV1)
+	router.InitStorageBrowserRouter(deps, apiV1)
+	router.InitNotificationRouter(deps, apiV1)
+	router.InitVMNetworkDiagRouter(deps, apiV1)
+	router.InitMACAddressRouter(deps, apiV1)
+	router.InitPveAccessRouter(deps, apiV1)
+	router.InitVMLockRouter(deps, apiV1)
+	router.InitPendingOperationRouter(deps, apiV1)
+	router.InitVMCredentialRouter(deps, apiV1)
+	router.InitDependencyRouter(deps, apiV1)
+	router.InitTemplateUsageRouter(deps, apiV1)
+	router.InitVMNICRouter(deps, apiV1)
+	router.InitVMStartupRouter(deps, apiV1)
+	router.InitProvisionReservationRouter(deps, apiV1)
+	router.InitOperationAuditRouter(deps, apiV1)
+	router.InitNodePoolRouter(deps, apiV1)
+	router.InitTemplatePromotionRouter(deps, apiV1)
+	router.InitMetadataBackupRouter(deps, apiV1)
+	router.InitVMIDRangeRouter(deps, apiV1)
+	router.InitVMEventRouter(deps, apiV1)
+	router.InitTopologyRouter(deps, apiV1)
+	router.InitVMAgentInstallRouter(deps, apiV1)
+	router.InitOSEOLRouter(deps, apiV1)
+	router.InitVMSecurityRouter(deps, apiV1)
+	router.InitClusterEndpointRouter(deps, apiV1)
+	router.InitRemoteMigrationRouter(deps, apiV1)
+	router.InitVMBulkDeleteRouter(deps, apiV1)
+	router.InitVMProtectionRouter(deps, apiV1)
+	router.InitClusterEventRouter(deps, apiV1)
+	router.InitVMNotesRouter(deps, apiV1)
+	router.InitReportRouter(deps, apiV1)
+	router.InitCapabilityRouter(deps, apiV1)
+	router.InitVMHibernateRouter(deps, apiV1)
+	router.InitFirstBootHookRouter(deps, apiV1)
+	router.InitContentReplicationRouter(deps, apiV1)
 
 	return s
 }
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// defaultJobLeaseInterval 未配置 ha.lease.interval 时的续约间隔
+const defaultJobLeaseInterval = 30 * time.Second
+
+// JobLeaseServer 多实例部署时的后台任务协调：定期续约当前实例持有的任务租约，
+// 接管其他实例（崩溃或已停止）过期的租约并恢复任务，同时清理过期的共享令牌。
+// 续约是任务归属的依据，不能关闭；单实例部署时进程重启后由新进程接管上次未完成的任务
+//
+// 配置示例：
+//
+//	ha:
+//	  instance_id: ""
+//	  lease:
+//	    ttl: 2m
+//	    interval: 30s
+type JobLeaseServer struct {
+	leases   service.JobLeaseService
+	tokens   service.SharedTokenStore
+	log      *log.Logger
+	interval time.Duration
+	done     chan struct{}
+}
+
+func NewJobLeaseServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	leases service.JobLeaseService,
+	tokens service.SharedTokenStore,
+) *JobLeaseServer {
+	interval := conf.GetDuration("ha.lease.interval")
+	if interval <= 0 {
+		interval = defaultJobLeaseInterval
+	}
+	return &JobLeaseServer{
+		leases:   leases,
+		tokens:   tokens,
+		log:      log,
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *JobLeaseServer) Start(ctx context.Context) error {
+	s.log.Info("job lease coordinator started", zap.String("instance_id", s.leases.InstanceID()), zap.Duration("interval", s.interval))
+
+	// 启动时先检查一次，尽早接管上次未完成的任务
+	s.tick(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.tick(ctx)
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *JobLeaseServer) tick(ctx context.Context) {
+	if _, err := s.leases.RenewLeases(ctx); err != nil {
+		s.log.Error("renew job leases failed", zap.Error(err))
+	}
+	recovered, err := s.leases.RecoverExpired(ctx)
+	if err != nil {
+		s.log.Error("recover expired job leases failed", zap.Error(err))
+	}
+	if recovered > 0 {
+		s.log.Info("took over jobs from stopped instances", zap.Int("count", recovered))
+	}
+	if removed, err := s.tokens.CleanupExpired(ctx); err != nil {
+		s.log.Error("cleanup shared tokens failed", zap.Error(err))
+	} else if removed > 0 {
+		s.log.Debug("expired shared tokens removed", zap.Int64("count", removed))
+	}
+}
+
+func (s *JobLeaseServer) Stop(ctx context.Context) error {
+	close(s.done)
+	return nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 license.collector.interval 时的默认采集间隔
+const defaultLicenseCollectInterval = 6 * time.Hour
+
+// LicenseCollectorServer 定期通过 guest agent 采集虚拟机操作系统信息，并检查许可证是否超用
+//
+// 配置示例：
+//
+//	license:
+//	  collector:
+//	    enabled: true
+//	    interval: 6h
+type LicenseCollectorServer struct {
+	licenseService service.LicenseService
+	log            *log.Logger
+	enabled        bool
+	interval       time.Duration
+	done           chan struct{}
+}
+
+func NewLicenseCollectorServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	licenseService service.LicenseService,
+) *LicenseCollectorServer {
+	interval := conf.GetDuration("license.collector.interval")
+	if interval <= 0 {
+		interval = defaultLicenseCollectInterval
+	}
+	return &LicenseCollectorServer{
+		licenseService: licenseService,
+		log:            log,
+		enabled:        conf.GetBool("license.collector.enabled"),
+		interval:       interval,
+		done:           make(chan struct{}),
+	}
+}
+
+func (s *LicenseCollectorServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("license collector started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.licenseService.CollectOSInfo(ctx); err != nil {
+				s.log.Error("collect vm os info failed", zap.Error(err))
+			}
+			if err := s.licenseService.CheckConsumption(ctx); err != nil {
+				s.log.Error("check license consumption failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *LicenseCollectorServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"os"
+	"pvesphere/internal/migration"
 	"pvesphere/internal/model"
 	"pvesphere/internal/repository"
 	"pvesphere/pkg/log"
@@ -29,7 +30,35 @@ func NewMigrateServer(db *gorm.DB, log *log.Logger, userRepo repository.UserRepo
 	}
 }
 func (m *MigrateServer) Start(ctx context.Context) error {
-	if err := m.db.AutoMigrate(
+	if err := m.Migrate(ctx); err != nil {
+		return err
+	}
+
+	os.Exit(0)
+	return nil
+}
+
+// Migrate 创建/更新表结构并初始化默认用户（可重复执行）
+func (m *MigrateServer) Migrate(ctx context.Context) error {
+	done, err := migration.Up(ctx, m.db)
+	if err != nil {
+		m.log.Error("migrate error", zap.Error(err))
+		return err
+	}
+	m.log.Info("AutoMigrate success", zap.Int("applied", len(done)), zap.Int64("schema_version", migration.Latest()))
+
+	// 创建默认用户
+	if err := m.createDefaultUser(ctx); err != nil {
+		m.log.Error("create default user error", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// MigrateModels 全部业务表（顺序即数据拷贝顺序），表结构由 internal/migration 中的版本化迁移维护，
+// 新增表时需同时添加迁移并加入此列表
+func MigrateModels() []interface{} {
+	return []interface{}{
 		&model.User{},
 		// PVE 相关表
 		&model.PveCluster{},
@@ -42,20 +71,130 @@ func (m *MigrateServer) Start(ctx context.Context) error {
 		&model.TemplateUpload{},
 		&model.TemplateInstance{},
 		&model.TemplateSyncTask{},
-	); err != nil {
-		m.log.Error("migrate error", zap.Error(err))
-		return err
-	}
-	m.log.Info("AutoMigrate success")
-
-	// 创建默认用户
-	if err := m.createDefaultUser(ctx); err != nil {
-		m.log.Error("create default user error", zap.Error(err))
-		return err
+		// 虚拟机 QoS 限速模板
+		&model.VmQosProfile{},
+		&model.StorageGCScan{},
+		&model.StorageGCItem{},
+		&model.VmListView{},
+		&model.ConfigAuditLog{},
+		// 虚拟机编排
+		&model.VmStack{},
+		&model.VmStackMember{},
+		// 站点
+		&model.PveSite{},
+		// 变更窗口与紧急放行审计
+		&model.ChangeWindow{},
+		&model.ChangeOverrideLog{},
+		// 成本核算
+		&model.CostPriceModel{},
+		&model.CostUsageDaily{},
+		&model.CostBudget{},
+		&model.CostBudgetAlert{},
+		// 节点 BMC 与能耗统计
+		&model.NodeBMC{},
+		&model.EnergyNodeDaily{},
+		&model.EnergyVMDaily{},
+		// 虚拟机导入
+		&model.VmImportSource{},
+		&model.VmImportTask{},
+		// 镜像传输
+		&model.ObjectStore{},
+		&model.ImageTransferTask{},
+		// 模板目录
+		&model.TemplateCatalog{},
+		&model.TemplateCatalogImage{},
+		&model.TemplateCatalogInstall{},
+		// 节点版本
+		&model.NodeVersion{},
+		// 许可证
+		&model.License{},
+		&model.LicenseAssignment{},
+		&model.VMOSInfo{},
+		&model.LicenseAlert{},
+		// 虚拟机认领
+		&model.UnclaimedVM{},
+		// 虚拟机创建规格
+		&model.VMProfile{},
+		// 虚拟机存储迁移
+		&model.VMStorageMoveTask{},
+		// 负载再平衡
+		&model.RebalancePlan{},
+		// 控制台会话审计
+		&model.ConsoleSession{},
+		// ZFS 存储池健康告警
+		&model.ZFSPoolAlert{},
+		// 节点磁盘 SMART 指标
+		&model.NodeDiskHealth{},
+		// 节点磁盘 SMART 告警
+		&model.NodeDiskAlert{},
+		// Dashboard 用户布局
+		&model.DashboardLayout{},
+		// 站内通知
+		&model.Notification{},
+		// MAC 地址登记
+		&model.MACAddress{},
+		// 集群能力探测结果
+		&model.ClusterCapability{},
+		// 虚拟机维护锁
+		&model.VMLock{},
+		// 待重试操作
+		&model.PendingOperation{},
+		// 虚拟机凭据审计
+		&model.SecretAuditLog{},
+		// 模板使用统计
+		&model.TemplateUsageLog{},
+		// 启动顺序策略
+		&model.BootOrderPolicy{},
+		// 异步创建虚拟机任务
+		&model.VMCreateJob{},
+		// 操作审计（Proxmox API 调用记录）
+		&model.OperationAudit{},
+		// 节点池
+		&model.NodePool{},
+		// 节点池成员
+		&model.NodePoolMember{},
+		// 模板晋级
+		&model.TemplatePromotion{},
+		// VMID 段
+		&model.VMIDRange{},
+		// VMID 分配记录
+		&model.VMIDAllocation{},
+		// 虚拟机状态变化记录
+		&model.VMStatusEvent{},
+		// guest agent 安装记录
+		&model.VMAgentInstall{},
+		// 虚拟机配置安全检查风险项
+		&model.VMSecurityFinding{},
+		// 节点证书指纹
+		&model.PveNodeCertificate{},
+		// 计划的跨集群迁移
+		&model.RemoteMigrationJob{},
+		// 跨集群迁移切换
+		&model.RemoteMigrationCutover{},
+		// 批量删除虚拟机任务
+		&model.VMBulkDeleteJob{},
+		// 集群容量快照
+		&model.ClusterCapacitySnapshot{},
+		// 多实例共享的短期令牌、后台任务归属租约
+		&model.SharedToken{},
+		&model.JobLease{},
+		// 在 PveSphere 之外对集群所做的变更
+		&model.ClusterEvent{},
+		// 定期报告计划及发送记录
+		&model.ReportSchedule{},
+		&model.ReportRun{},
+		// 模板首次启动钩子及执行记录
+		&model.FirstBootHook{},
+		&model.FirstBootRun{},
+		&model.ContentReplicationPolicy{},
+		&model.ContentReplicationCopy{},
 	}
+}
 
-	os.Exit(0)
-	return nil
+// AutoMigrate 执行尚未执行的表结构迁移
+func AutoMigrate(db *gorm.DB) error {
+	_, err := migration.Up(context.Background(), db)
+	return err
 }
 
 // createDefaultUser 创建默认管理员用户
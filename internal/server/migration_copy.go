@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// copyBatchSize 每批拷贝的记录数
+const copyBatchSize = 500
+
+// CopyDatabase 将源库（通常是嵌入式 SQLite）的全部数据拷贝到目标库（MySQL / PostgreSQL）
+// 目标库需先执行 AutoMigrate；为避免覆盖已有数据，目标表非空时直接报错。
+// 记录主键原样保留，拷贝完成后修正 PostgreSQL 的自增序列。
+func CopyDatabase(ctx context.Context, src, dst *gorm.DB, logger *log.Logger) error {
+	for _, m := range MigrateModels() {
+		stmt := &gorm.Statement{DB: dst}
+		if err := stmt.Parse(m); err != nil {
+			return err
+		}
+		table := stmt.Schema.Table
+
+		var existing int64
+		if err := dst.WithContext(ctx).Model(m).Count(&existing).Error; err != nil {
+			return fmt.Errorf("统计目标表 %s 失败: %w", table, err)
+		}
+		if existing > 0 {
+			return fmt.Errorf("目标表 %s 已有 %d 条数据，请使用空库作为迁移目标", table, existing)
+		}
+
+		copied, err := copyTable(ctx, src, dst, m)
+		if err != nil {
+			return fmt.Errorf("拷贝表 %s 失败: %w", table, err)
+		}
+		if err := resetSequence(ctx, dst, table); err != nil {
+			return fmt.Errorf("重置表 %s 自增序列失败: %w", table, err)
+		}
+		logger.Info("table copied", zap.String("table", table), zap.Int64("rows", copied))
+	}
+	return nil
+}
+
+func copyTable(ctx context.Context, src, dst *gorm.DB, m interface{}) (int64, error) {
+	// 按模型类型构造 []*Model 作为批次容器
+	rows := reflect.New(reflect.SliceOf(reflect.TypeOf(m))).Interface()
+
+	var copied int64
+	err := dst.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := src.WithContext(ctx).Unscoped().Model(m).FindInBatches(rows, copyBatchSize, func(batch *gorm.DB, _ int) error {
+			if batch.RowsAffected == 0 {
+				return nil
+			}
+			if err := tx.Session(&gorm.Session{SkipHooks: true}).Create(rows).Error; err != nil {
+				return err
+			}
+			copied += batch.RowsAffected
+			return nil
+		})
+		return result.Error
+	})
+	return copied, err
+}
+
+// resetSequence 显式写入主键后 PostgreSQL 的序列不会自动前移，需要手动修正
+func resetSequence(ctx context.Context, db *gorm.DB, table string) error {
+	if db.Dialector.Name() != repository.DialectPostgres {
+		return nil
+	}
+	sql := fmt.Sprintf(
+		"SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)",
+		table, table,
+	)
+	return db.WithContext(ctx).Exec(sql).Error
+}
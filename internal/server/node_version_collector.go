@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 node_version.collector.interval 时的默认采集间隔
+const defaultNodeVersionInterval = time.Hour
+
+// NodeVersionCollectorServer 定期采集各节点的 PVE/内核/QEMU 版本和 CPU 信息，供迁移兼容性检查使用
+// 启动后立即采集一次，避免首次检查时缺少数据
+//
+// 配置示例：
+//
+//	node_version:
+//	  collector:
+//	    enabled: true
+//	    interval: 1h
+type NodeVersionCollectorServer struct {
+	versionService service.NodeVersionService
+	log            *log.Logger
+	enabled        bool
+	interval       time.Duration
+	done           chan struct{}
+}
+
+func NewNodeVersionCollectorServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	versionService service.NodeVersionService,
+) *NodeVersionCollectorServer {
+	interval := conf.GetDuration("node_version.collector.interval")
+	if interval <= 0 {
+		interval = defaultNodeVersionInterval
+	}
+	return &NodeVersionCollectorServer{
+		versionService: versionService,
+		log:            log,
+		enabled:        conf.GetBool("node_version.collector.enabled"),
+		interval:       interval,
+		done:           make(chan struct{}),
+	}
+}
+
+func (s *NodeVersionCollectorServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("node version collector started", zap.Duration("interval", s.interval))
+	s.collect(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.collect(ctx)
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *NodeVersionCollectorServer) collect(ctx context.Context) {
+	if err := s.versionService.CollectAll(ctx); err != nil {
+		s.log.Error("collect node versions failed", zap.Error(err))
+	}
+}
+
+func (s *NodeVersionCollectorServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
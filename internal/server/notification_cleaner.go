@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 notification.cleaner.interval 时的默认清理间隔
+const defaultNotificationCleanInterval = 6 * time.Hour
+
+// NotificationCleanerServer 定期删除超过保留期的站内通知
+//
+// 配置示例：
+//
+//	notification:
+//	  retention_days: 30
+//	  cleaner:
+//	    enabled: true
+//	    interval: 6h
+type NotificationCleanerServer struct {
+	notificationService service.NotificationService
+	log                 *log.Logger
+	enabled             bool
+	interval            time.Duration
+	done                chan struct{}
+}
+
+func NewNotificationCleanerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	notificationService service.NotificationService,
+) *NotificationCleanerServer {
+	interval := conf.GetDuration("notification.cleaner.interval")
+	if interval <= 0 {
+		interval = defaultNotificationCleanInterval
+	}
+	return &NotificationCleanerServer{
+		notificationService: notificationService,
+		log:                 log,
+		enabled:             conf.GetBool("notification.cleaner.enabled"),
+		interval:            interval,
+		done:                make(chan struct{}),
+	}
+}
+
+func (s *NotificationCleanerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("notification cleaner started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			removed, err := s.notificationService.Cleanup(ctx)
+			if err != nil {
+				s.log.Error("cleanup notifications failed", zap.Error(err))
+				continue
+			}
+			if removed > 0 {
+				s.log.Info("expired notifications removed", zap.Int64("count", removed))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *NotificationCleanerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 pending_operation.retry_interval 时的默认检查间隔
+const defaultPendingOperationRetryInterval = time.Minute
+
+// PendingOperationRetrierServer 定期重试集群不可达时排队的操作
+//
+// 配置示例：
+//
+//	pending_operation:
+//	  enabled: true
+//	  retry_interval: 1m
+//	  max_attempts: 60
+type PendingOperationRetrierServer struct {
+	opService service.PendingOperationService
+	log       *log.Logger
+	enabled   bool
+	interval  time.Duration
+	done      chan struct{}
+}
+
+func NewPendingOperationRetrierServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	opService service.PendingOperationService,
+) *PendingOperationRetrierServer {
+	interval := conf.GetDuration("pending_operation.retry_interval")
+	if interval <= 0 {
+		interval = defaultPendingOperationRetryInterval
+	}
+	return &PendingOperationRetrierServer{
+		opService: opService,
+		log:       log,
+		enabled:   conf.GetBool("pending_operation.enabled"),
+		interval:  interval,
+		done:      make(chan struct{}),
+	}
+}
+
+func (s *PendingOperationRetrierServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("pending operation retrier started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			processed, err := s.opService.RetryDue(ctx)
+			if err != nil {
+				s.log.Error("retry pending operations failed", zap.Error(err))
+				continue
+			}
+			if processed > 0 {
+				s.log.Info("pending operations retried", zap.Int("count", processed))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *PendingOperationRetrierServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 rebalance.analyzer.interval 时的默认分析间隔
+const defaultRebalanceAnalyzeInterval = 30 * time.Minute
+
+// RebalanceAnalyzerServer 定期分析各集群节点负载，失衡时生成再平衡方案供管理员确认执行
+//
+// 配置示例：
+//
+//	rebalance:
+//	  threshold: 0.2
+//	  max_moves: 5
+//	  bwlimit: 102400
+//	  analyzer:
+//	    enabled: true
+//	    interval: 30m
+type RebalanceAnalyzerServer struct {
+	rebalanceService service.RebalanceService
+	log              *log.Logger
+	enabled          bool
+	interval         time.Duration
+	done             chan struct{}
+}
+
+func NewRebalanceAnalyzerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	rebalanceService service.RebalanceService,
+) *RebalanceAnalyzerServer {
+	interval := conf.GetDuration("rebalance.analyzer.interval")
+	if interval <= 0 {
+		interval = defaultRebalanceAnalyzeInterval
+	}
+	return &RebalanceAnalyzerServer{
+		rebalanceService: rebalanceService,
+		log:              log,
+		enabled:          conf.GetBool("rebalance.analyzer.enabled"),
+		interval:         interval,
+		done:             make(chan struct{}),
+	}
+}
+
+func (s *RebalanceAnalyzerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("rebalance analyzer started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.rebalanceService.Analyze(ctx); err != nil {
+				s.log.Error("analyze cluster rebalance failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *RebalanceAnalyzerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
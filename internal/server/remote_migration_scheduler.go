@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 remote_migration.scheduler.interval 时的默认检查间隔
+const defaultRemoteMigrationSchedulerInterval = time.Minute
+
+// RemoteMigrationSchedulerServer 定期发起到期的计划跨集群迁移，并对已结束的跨集群迁移执行切换
+//
+// 配置示例：
+//
+//	remote_migration:
+//	  scheduler:
+//	    enabled: true
+//	    interval: 1m
+type RemoteMigrationSchedulerServer struct {
+	jobService     service.RemoteMigrationJobService
+	cutoverService service.RemoteMigrationCutoverService
+	log            *log.Logger
+	enabled        bool
+	interval       time.Duration
+	done           chan struct{}
+}
+
+func NewRemoteMigrationSchedulerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	jobService service.RemoteMigrationJobService,
+	cutoverService service.RemoteMigrationCutoverService,
+) *RemoteMigrationSchedulerServer {
+	interval := conf.GetDuration("remote_migration.scheduler.interval")
+	if interval <= 0 {
+		interval = defaultRemoteMigrationSchedulerInterval
+	}
+	return &RemoteMigrationSchedulerServer{
+		jobService:     jobService,
+		cutoverService: cutoverService,
+		log:            log,
+		enabled:        conf.GetBool("remote_migration.scheduler.enabled"),
+		interval:       interval,
+		done:           make(chan struct{}),
+	}
+}
+
+func (s *RemoteMigrationSchedulerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("remote migration scheduler started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if processed, err := s.jobService.RunDue(ctx); err != nil {
+				s.log.Error("run scheduled remote migrations failed", zap.Error(err))
+			} else if processed > 0 {
+				s.log.Info("scheduled remote migrations processed", zap.Int("count", processed))
+			}
+			if processed, err := s.cutoverService.RunPending(ctx); err != nil {
+				s.log.Error("run remote migration cutovers failed", zap.Error(err))
+			} else if processed > 0 {
+				s.log.Info("remote migration cutovers processed", zap.Int("count", processed))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *RemoteMigrationSchedulerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
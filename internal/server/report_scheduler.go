@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 report.scheduler.interval 时的默认检查间隔
+const defaultReportSchedulerInterval = time.Minute
+
+// ReportSchedulerServer 定期检查到期的报告计划并发送报告（站内通知 + 带 XLSX 附件的邮件），
+// 每小时清理一次超过保留期的发送记录；多实例部署时每次发送只由一个实例执行
+//
+// 配置示例：
+//
+//	report:
+//	  retention_days: 365
+//	  scheduler:
+//	    enabled: true
+//	    interval: 1m
+type ReportSchedulerServer struct {
+	reportService service.ReportService
+	log           *log.Logger
+	enabled       bool
+	interval      time.Duration
+	done          chan struct{}
+}
+
+func NewReportSchedulerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	reportService service.ReportService,
+) *ReportSchedulerServer {
+	interval := conf.GetDuration("report.scheduler.interval")
+	if interval <= 0 {
+		interval = defaultReportSchedulerInterval
+	}
+	return &ReportSchedulerServer{
+		reportService: reportService,
+		log:           log,
+		enabled:       conf.GetBool("report.scheduler.enabled"),
+		interval:      interval,
+		done:          make(chan struct{}),
+	}
+}
+
+func (s *ReportSchedulerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("report scheduler started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.run(ctx)
+		case <-cleanup.C:
+			s.cleanup(ctx)
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *ReportSchedulerServer) run(ctx context.Context) {
+	sent, err := s.reportService.RunDue(ctx)
+	if err != nil {
+		s.log.Error("send scheduled reports failed", zap.Error(err))
+		return
+	}
+	if sent > 0 {
+		s.log.Info("scheduled reports sent", zap.Int("sent", sent))
+	}
+}
+
+func (s *ReportSchedulerServer) cleanup(ctx context.Context) {
+	removed, err := s.reportService.Cleanup(ctx)
+	if err != nil {
+		s.log.Error("cleanup report runs failed", zap.Error(err))
+		return
+	}
+	if removed > 0 {
+		s.log.Info("expired report runs removed", zap.Int64("removed", removed))
+	}
+}
+
+func (s *ReportSchedulerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
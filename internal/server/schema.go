@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+
+	"pvesphere/internal/migration"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// SchemaServer server 启动时检查数据库表结构版本
+//
+// 配置示例：
+//
+//	migration:
+//	  auto_migrate: false # server 启动时执行未完成的表结构迁移
+//
+// 开启 auto_migrate 时在 HTTP 服务启动之前同步执行未完成的迁移，迁移失败则不启动；
+// 关闭时只在存在未执行的迁移时告警，由运维单独执行 migration。嵌入式模式开启 embedded.auto_migrate 时同样在启动时执行迁移。
+type SchemaServer struct {
+	log *log.Logger
+}
+
+func NewSchemaServer(conf *viper.Viper, log *log.Logger, db *gorm.DB) (*SchemaServer, error) {
+	ctx := context.Background()
+	if conf.GetBool("migration.auto_migrate") || conf.GetBool("embedded.auto_migrate") {
+		done, err := migration.Up(ctx, db)
+		if err != nil {
+			log.Error("schema migration failed", zap.Error(err))
+			return nil, err
+		}
+		log.Info("schema migration success", zap.Int("applied", len(done)), zap.Int64("schema_version", migration.Latest()))
+		return &SchemaServer{log: log}, nil
+	}
+
+	current, statuses, err := migration.GetStatus(ctx, db)
+	if err != nil {
+		// 版本检查失败不影响启动
+		log.Error("schema version check failed", zap.Error(err))
+		return &SchemaServer{log: log}, nil
+	}
+	pending := 0
+	for _, status := range statuses {
+		if !status.Applied {
+			pending++
+		}
+	}
+	if pending > 0 {
+		log.Warn("database schema is behind, run migration before using new features",
+			zap.Int64("current_version", current),
+			zap.Int64("latest_version", migration.Latest()),
+			zap.Int("pending", pending))
+	}
+	return &SchemaServer{log: log}, nil
+}
+
+func (s *SchemaServer) Start(ctx context.Context) error {
+	return nil
+}
+
+func (s *SchemaServer) Stop(ctx context.Context) error {
+	return nil
+}
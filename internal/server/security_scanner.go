@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 security_scan.interval 时的默认扫描间隔
+const defaultSecurityScanInterval = 24 * time.Hour
+
+// SecurityScanServer 定期扫描全部虚拟机配置中的安全风险项
+//
+// 配置示例：
+//
+//	security_scan:
+//	  enabled: true
+//	  interval: 24h
+//	  min_machine_version: "6.0"
+//	  cpu_type: "x86-64-v2-AES"
+//	  ignore: []
+type SecurityScanServer struct {
+	securityService service.VMSecurityService
+	log             *log.Logger
+	enabled         bool
+	interval        time.Duration
+	done            chan struct{}
+}
+
+func NewSecurityScanServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	securityService service.VMSecurityService,
+) *SecurityScanServer {
+	interval := conf.GetDuration("security_scan.interval")
+	if interval <= 0 {
+		interval = defaultSecurityScanInterval
+	}
+	return &SecurityScanServer{
+		securityService: securityService,
+		log:             log,
+		enabled:         conf.GetBool("security_scan.enabled"),
+		interval:        interval,
+		done:            make(chan struct{}),
+	}
+}
+
+func (s *SecurityScanServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("security scanner started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.securityService.ScanAll(ctx); err != nil {
+				s.log.Error("scan vm security failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *SecurityScanServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
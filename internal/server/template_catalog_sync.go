@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 template_catalog.sync.interval 时的默认同步间隔
+const defaultCatalogSyncInterval = 6 * time.Hour
+
+// TemplateCatalogSyncServer 定期刷新订阅的模板目录
+// 刷新会整体替换目录镜像，多实例同时开启不会产生重复数据
+//
+// 配置示例：
+//
+//	template_catalog:
+//	  sync:
+//	    enabled: true
+//	    interval: 6h
+type TemplateCatalogSyncServer struct {
+	catalogService service.TemplateCatalogService
+	log            *log.Logger
+	enabled        bool
+	interval       time.Duration
+	done           chan struct{}
+}
+
+func NewTemplateCatalogSyncServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	catalogService service.TemplateCatalogService,
+) *TemplateCatalogSyncServer {
+	interval := conf.GetDuration("template_catalog.sync.interval")
+	if interval <= 0 {
+		interval = defaultCatalogSyncInterval
+	}
+	return &TemplateCatalogSyncServer{
+		catalogService: catalogService,
+		log:            log,
+		enabled:        conf.GetBool("template_catalog.sync.enabled"),
+		interval:       interval,
+		done:           make(chan struct{}),
+	}
+}
+
+func (s *TemplateCatalogSyncServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("template catalog sync started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.catalogService.SyncCatalogs(ctx); err != nil {
+				s.log.Error("sync template catalogs failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *TemplateCatalogSyncServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
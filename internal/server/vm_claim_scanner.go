@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 vm_claim.scanner.interval 时的默认扫描间隔
+const defaultVMClaimScanInterval = time.Hour
+
+// VMClaimScannerServer 定期比对 Proxmox 中的虚拟机与平台记录，更新待认领列表并推送认领提醒
+//
+// 配置示例：
+//
+//	vm_claim:
+//	  reminder_webhook: "https://hooks.example.com/vm-claim"
+//	  reminder_interval: 24h
+//	  scanner:
+//	    enabled: true
+//	    interval: 1h
+type VMClaimScannerServer struct {
+	claimService service.VMClaimService
+	log          *log.Logger
+	enabled      bool
+	interval     time.Duration
+	done         chan struct{}
+}
+
+func NewVMClaimScannerServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	claimService service.VMClaimService,
+) *VMClaimScannerServer {
+	interval := conf.GetDuration("vm_claim.scanner.interval")
+	if interval <= 0 {
+		interval = defaultVMClaimScanInterval
+	}
+	return &VMClaimScannerServer{
+		claimService: claimService,
+		log:          log,
+		enabled:      conf.GetBool("vm_claim.scanner.enabled"),
+		interval:     interval,
+		done:         make(chan struct{}),
+	}
+}
+
+func (s *VMClaimScannerServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("vm claim scanner started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.claimService.Scan(ctx); err != nil {
+				s.log.Error("scan unclaimed vms failed", zap.Error(err))
+				continue
+			}
+			if err := s.claimService.SendReminders(ctx); err != nil {
+				s.log.Error("send vm claim reminders failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *VMClaimScannerServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
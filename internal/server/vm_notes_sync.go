@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 vm_notes.sync.interval 时的默认同步间隔
+const defaultVMNotesSyncInterval = 30 * time.Minute
+
+// VMNotesSyncServer 定期读取各虚拟机在 Proxmox 中的备注（description），同步在 Proxmox 中所做的修改；
+// 虚拟机列表接口不含备注，需要逐台读取配置，间隔不宜过短。开启集群外部变更轮询时，
+// 在 Proxmox 中修改的备注会立即同步，这里只作为兜底
+//
+// 配置示例：
+//
+//	vm_notes:
+//	  sync:
+//	    enabled: true
+//	    interval: 30m
+type VMNotesSyncServer struct {
+	notesService service.VMNotesService
+	log          *log.Logger
+	enabled      bool
+	interval     time.Duration
+	done         chan struct{}
+}
+
+func NewVMNotesSyncServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	notesService service.VMNotesService,
+) *VMNotesSyncServer {
+	interval := conf.GetDuration("vm_notes.sync.interval")
+	if interval <= 0 {
+		interval = defaultVMNotesSyncInterval
+	}
+	return &VMNotesSyncServer{
+		notesService: notesService,
+		log:          log,
+		enabled:      conf.GetBool("vm_notes.sync.enabled"),
+		interval:     interval,
+		done:         make(chan struct{}),
+	}
+}
+
+func (s *VMNotesSyncServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("vm notes sync started", zap.Duration("interval", s.interval))
+
+	// 启动时先同步一次，补齐升级前未同步的备注
+	s.sync(ctx)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sync(ctx)
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *VMNotesSyncServer) sync(ctx context.Context) {
+	updated, err := s.notesService.Sync(ctx)
+	if err != nil {
+		s.log.Error("sync vm notes failed", zap.Error(err))
+		return
+	}
+	if updated > 0 {
+		s.log.Info("vm notes synced from proxmox", zap.Int("updated", updated))
+	}
+}
+
+func (s *VMNotesSyncServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
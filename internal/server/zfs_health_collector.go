@@ -0,0 +1,79 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/internal/service"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 node_zfs.collector.interval 时的默认检查间隔
+const defaultZFSHealthCheckInterval = 10 * time.Minute
+
+// ZFSHealthCollectorServer 定期检查在线节点上 ZFS 存储池的健康状态和错误计数，异常时记录告警
+//
+// 配置示例：
+//
+//	node_zfs:
+//	  collector:
+//	    enabled: true
+//	    interval: 10m
+type ZFSHealthCollectorServer struct {
+	nodeZFSService service.NodeZFSService
+	log            *log.Logger
+	enabled        bool
+	interval       time.Duration
+	done           chan struct{}
+}
+
+func NewZFSHealthCollectorServer(
+	conf *viper.Viper,
+	log *log.Logger,
+	nodeZFSService service.NodeZFSService,
+) *ZFSHealthCollectorServer {
+	interval := conf.GetDuration("node_zfs.collector.interval")
+	if interval <= 0 {
+		interval = defaultZFSHealthCheckInterval
+	}
+	return &ZFSHealthCollectorServer{
+		nodeZFSService: nodeZFSService,
+		log:            log,
+		enabled:        conf.GetBool("node_zfs.collector.enabled"),
+		interval:       interval,
+		done:           make(chan struct{}),
+	}
+}
+
+func (s *ZFSHealthCollectorServer) Start(ctx context.Context) error {
+	if !s.enabled {
+		return nil
+	}
+	s.log.Info("zfs health collector started", zap.Duration("interval", s.interval))
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.nodeZFSService.CheckHealth(ctx); err != nil {
+				s.log.Error("check zfs pool health failed", zap.Error(err))
+			}
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *ZFSHealthCollectorServer) Stop(ctx context.Context) error {
+	if s.enabled {
+		close(s.done)
+	}
+	return nil
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 操作的作用范围
+const (
+	capabilityScopeGlobal  = "global"
+	capabilityScopeCluster = "cluster"
+	capabilityScopeVM      = "vm"
+)
+
+// capabilityOperation 一个可在前端触发的操作及其权限规则。
+// 各服务通过 authorizeOperation 按同一份规则校验，GetMine 只是把结果提前告诉前端
+type capabilityOperation struct {
+	Operation string
+	Name      string
+	Scope     string
+	Feature   string // 依赖的集群能力，为空表示不依赖 Proxmox 权限
+	AdminOnly bool   // 仅管理员（security.admin_users）
+	OwnerOnly bool   // 仅虚拟机负责人、创建人和管理员
+	Lockable  bool   // 虚拟机被他人锁定维护时拒绝（受 protectedVMActions 约束的操作由 checkVMProtection 判断）
+}
+
+var capabilityOperations = []capabilityOperation{
+	{Operation: "system.config", Name: "修改系统配置", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "change_window.manage", Name: "管理维护窗口和封网期", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "cost.manage", Name: "管理价格和预算", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "license.manage", Name: "管理许可证", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "metadata.backup", Name: "导出 / 导入元数据", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "node_pool.manage", Name: "管理节点池", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "vm_profile.manage", Name: "管理虚拟机规格", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "vmid_range.manage", Name: "管理 VMID 段", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "template_catalog.manage", Name: "管理模板目录", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "vm_startup.manage", Name: "管理开机顺序策略", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "vm_import.manage", Name: "管理虚拟机导入源", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "image_store.manage", Name: "管理镜像仓库", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "rebalance.manage", Name: "生成和执行负载均衡计划", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "console.audit", Name: "查看控制台审计", Scope: capabilityScopeGlobal, AdminOnly: true},
+	{Operation: "report.manage", Name: "管理定期报告", Scope: capabilityScopeGlobal, AdminOnly: true},
+
+	{Operation: "vm.create", Name: "创建虚拟机", Scope: capabilityScopeCluster, Feature: "vm.create"},
+	{Operation: "vm.clone", Name: "从模板克隆虚拟机", Scope: capabilityScopeCluster, Feature: "vm.clone"},
+	{Operation: "storage.upload", Name: "上传 ISO / 模板", Scope: capabilityScopeCluster, Feature: "storage.upload"},
+	{Operation: "storage.manage", Name: "节点磁盘与存储管理", Scope: capabilityScopeCluster, Feature: "storage.manage", AdminOnly: true},
+	{Operation: "node.console", Name: "节点控制台", Scope: capabilityScopeCluster, Feature: "node.console"},
+	{Operation: "node.power", Name: "节点开机 / 关机", Scope: capabilityScopeCluster, Feature: "node.power", AdminOnly: true},
+	{Operation: "node.syslog", Name: "节点日志与任务日志", Scope: capabilityScopeCluster, Feature: "node.syslog"},
+	{Operation: "network.sdn", Name: "SDN 网络", Scope: capabilityScopeCluster, Feature: "network.sdn"},
+	{Operation: "access.manage", Name: "PVE 用户、Token 和 ACL 管理", Scope: capabilityScopeCluster, Feature: "access.manage", AdminOnly: true},
+
+	{Operation: "vm.start", Name: "开机", Scope: capabilityScopeVM, Feature: "vm.power", Lockable: true},
+	{Operation: "vm.stop", Name: "关机", Scope: capabilityScopeVM, Feature: "vm.power", Lockable: true},
+	{Operation: "vm.hibernate", Name: "休眠", Scope: capabilityScopeVM, Feature: "vm.power", Lockable: true},
+	{Operation: "vm.config", Name: "修改配置", Scope: capabilityScopeVM, Feature: "vm.config", Lockable: true},
+	{Operation: "vm.console", Name: "控制台", Scope: capabilityScopeVM, Feature: "vm.console"},
+	{Operation: "vm.snapshot", Name: "快照", Scope: capabilityScopeVM, Feature: "vm.snapshot", Lockable: true},
+	{Operation: "vm.backup", Name: "备份", Scope: capabilityScopeVM, Feature: "vm.backup", Lockable: true},
+	{Operation: "vm.migrate", Name: "迁移", Scope: capabilityScopeVM, Feature: "vm.migrate", Lockable: true},
+	{Operation: "vm.delete", Name: "删除", Scope: capabilityScopeVM, Feature: "vm.delete", Lockable: true},
+	{Operation: "vm.guest_diag", Name: "从虚拟机内部进行网络诊断", Scope: capabilityScopeVM, Feature: "vm.guest_agent", AdminOnly: true},
+	{Operation: "vm.credential", Name: "查看虚拟机凭据", Scope: capabilityScopeVM, OwnerOnly: true},
+	{Operation: "vm.unprotect", Name: "解除删除保护", Scope: capabilityScopeVM, AdminOnly: true},
+}
+
+// CapabilityService 计算当前用户可以执行的操作，供前端隐藏或禁用按钮。
+// 结果只是提示，各接口仍会各自校验
+type CapabilityService interface {
+	GetMine(ctx context.Context, userID string, req *v1.GetMyCapabilitiesRequest) (*v1.MyCapabilitiesData, error)
+}
+
+func NewCapabilityService(
+	service *Service,
+	conf *viper.Viper,
+	userRepo repository.UserRepository,
+	clusterRepo repository.PveClusterRepository,
+	vmRepo repository.PveVMRepository,
+	lockRepo repository.VMLockRepository,
+	clusterCapability ClusterCapabilityService,
+	logger *log.Logger,
+) CapabilityService {
+	return &capabilityService{
+		Service:           service,
+		conf:              conf,
+		userRepo:          userRepo,
+		clusterRepo:       clusterRepo,
+		vmRepo:            vmRepo,
+		lockRepo:          lockRepo,
+		clusterCapability: clusterCapability,
+		logger:            logger,
+	}
+}
+
+type capabilityService struct {
+	*Service
+	conf              *viper.Viper
+	userRepo          repository.UserRepository
+	clusterRepo       repository.PveClusterRepository
+	vmRepo            repository.PveVMRepository
+	lockRepo          repository.VMLockRepository
+	clusterCapability ClusterCapabilityService
+	logger            *log.Logger
+}
+
+func (s *capabilityService) GetMine(ctx context.Context, userID string, req *v1.GetMyCapabilitiesRequest) (*v1.MyCapabilitiesData, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	isAdmin := isAdminUsername(s.conf, username)
+
+	data := &v1.MyCapabilitiesData{
+		Username: username,
+		IsAdmin:  isAdmin,
+		Global:   []v1.CapabilityItem{},
+		Clusters: []v1.ClusterCapabilities{},
+		VMs:      []v1.VMCapabilities{},
+	}
+	for _, op := range capabilityOperations {
+		if op.Scope == capabilityScopeGlobal {
+			data.Global = append(data.Global, newCapabilityItem(op, isAdmin))
+		}
+	}
+
+	clusters, err := s.listClusters(ctx, req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	features := make(map[int64]map[string]v1.ClusterFeatureCapability, len(clusters))
+	for _, cluster := range clusters {
+		matrix := s.clusterFeatures(ctx, cluster.Id)
+		features[cluster.Id] = matrix
+		item := v1.ClusterCapabilities{
+			ClusterID:   cluster.Id,
+			ClusterName: cluster.ClusterName,
+			Probed:      matrix != nil,
+			Operations:  []v1.CapabilityItem{},
+		}
+		for _, op := range capabilityOperations {
+			if op.Scope == capabilityScopeCluster {
+				item.Operations = append(item.Operations, applyClusterFeature(newCapabilityItem(op, isAdmin), matrix))
+			}
+		}
+		data.Clusters = append(data.Clusters, item)
+	}
+
+	now := time.Now()
+	for _, vmID := range req.VMIds {
+		vm, err := s.vmRepo.GetByID(ctx, vmID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err), zap.Int64("vm_id", vmID))
+			return nil, v1.ErrInternalServerError
+		}
+		if vm == nil {
+			return nil, v1.WithDetailf(v1.ErrVMNotFound, "vm_id=%d", vmID)
+		}
+		matrix, ok := features[vm.ClusterID]
+		if !ok {
+			matrix = s.clusterFeatures(ctx, vm.ClusterID)
+			features[vm.ClusterID] = matrix
+		}
+		lock, err := s.lockRepo.GetByVMId(ctx, vm.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm lock", zap.Error(err), zap.Int64("vm_id", vm.Id))
+			return nil, v1.ErrInternalServerError
+		}
+		if lock != nil && (!lock.Active(now) || lock.Owner == username) {
+			lock = nil
+		}
+
+		item := v1.VMCapabilities{
+			VmId:       vm.Id,
+			VMID:       vm.VMID,
+			VmName:     vm.VmName,
+			ClusterID:  vm.ClusterID,
+			Operations: []v1.CapabilityItem{},
+		}
+		for _, op := range capabilityOperations {
+			if op.Scope == capabilityScopeVM {
+				item.Operations = append(item.Operations, s.vmCapability(op, isAdmin, username, vm, lock, matrix))
+			}
+		}
+		data.VMs = append(data.VMs, item)
+	}
+	return data, nil
+}
+
+// vmCapability 依次按管理员、负责人、删除保护、维护锁和集群能力判断，返回第一个不满足的原因
+func (s *capabilityService) vmCapability(op capabilityOperation, isAdmin bool, username string, vm *model.PveVM,
+	lock *model.VMLock, matrix map[string]v1.ClusterFeatureCapability) v1.CapabilityItem {
+	item := newCapabilityItem(op, isAdmin)
+	if !item.Allowed {
+		return item
+	}
+	if op.OwnerOnly && !canReadVMCredential(s.conf, username, vm) {
+		item.Allowed = false
+		item.Reason = v1.CapabilityReasonNotVMOwner
+		return item
+	}
+	if err := checkVMProtection(vm, op.Operation); err != nil {
+		item.Allowed = false
+		item.Reason = v1.CapabilityReasonVMProtected
+		item.Detail = fmt.Sprintf("protected by %s: %s", vm.ProtectedBy, vm.ProtectedReason)
+		return item
+	}
+	if op.Lockable && lock != nil {
+		item.Allowed = false
+		item.Reason = v1.CapabilityReasonVMLocked
+		item.Detail = fmt.Sprintf("locked by %s: %s", lock.Owner, lock.Reason)
+		return item
+	}
+	return applyClusterFeature(item, matrix)
+}
+
+func newCapabilityItem(op capabilityOperation, isAdmin bool) v1.CapabilityItem {
+	item := v1.CapabilityItem{
+		Operation: op.Operation,
+		Name:      op.Name,
+		Allowed:   true,
+		Feature:   op.Feature,
+	}
+	if op.AdminOnly && !isAdmin {
+		item.Allowed = false
+		item.Reason = v1.CapabilityReasonAdminRequired
+	}
+	return item
+}
+
+// applyClusterFeature 集群 API Token 缺少功能需要的权限时拒绝；能力未探测或矩阵中没有该功能时不限制
+func applyClusterFeature(item v1.CapabilityItem, matrix map[string]v1.ClusterFeatureCapability) v1.CapabilityItem {
+	if !item.Allowed || item.Feature == "" || matrix == nil {
+		return item
+	}
+	feature, ok := matrix[item.Feature]
+	if !ok || feature.Supported {
+		return item
+	}
+	item.Allowed = false
+	item.Reason = v1.CapabilityReasonClusterPermission
+	item.Missing = feature.Missing
+	return item
+}
+
+// clusterFeatures 读取集群能力矩阵，按功能标识索引；无法探测或认证失败时返回 nil
+func (s *capabilityService) clusterFeatures(ctx context.Context, clusterID int64) map[string]v1.ClusterFeatureCapability {
+	capability, err := s.clusterCapability.Get(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get cluster capability", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil
+	}
+	if !capability.Authenticated {
+		return nil
+	}
+	matrix := make(map[string]v1.ClusterFeatureCapability, len(capability.Features))
+	for _, f := range capability.Features {
+		matrix[f.Feature] = f
+	}
+	return matrix
+}
+
+func (s *capabilityService) listClusters(ctx context.Context, clusterID int64) ([]*model.PveCluster, error) {
+	if clusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err), zap.Int64("cluster_id", clusterID))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+		}
+		return []*model.PveCluster{cluster}, nil
+	}
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return clusters, nil
+}
+
+// authorizeOperation 按 capabilityOperations 中的规则校验用户能否执行操作，返回用户名
+func authorizeOperation(ctx context.Context, conf *viper.Viper, userRepo repository.UserRepository, logger *log.Logger, userID, operation string) (string, error) {
+	i := slices.IndexFunc(capabilityOperations, func(op capabilityOperation) bool { return op.Operation == operation })
+	if i < 0 {
+		logger.WithContext(ctx).Error("unknown capability operation", zap.String("operation", operation))
+		return "", v1.ErrInternalServerError
+	}
+	if capabilityOperations[i].AdminOnly {
+		return requireAdminUser(ctx, conf, userRepo, logger, userID)
+	}
+	return lookupUsername(ctx, userRepo, logger, userID)
+}
@@ -0,0 +1,502 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/jwt"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 紧急放行原因最大长度（与 change_override_log.reason 列一致）
+const maxChangeOverrideReasonLen = 500
+
+// ChangeOperation 需要经过变更管控的操作
+type ChangeOperation struct {
+	Action    string // 操作，如 vm.start
+	Target    string // 操作对象，如虚拟机名称
+	ClusterID int64
+	AppId     string
+	VMId      int64 // 针对单台虚拟机的操作，用于检查维护锁
+}
+
+type ChangeControlService interface {
+	CreateWindow(ctx context.Context, userID string, req *v1.CreateChangeWindowRequest) error
+	UpdateWindow(ctx context.Context, userID string, id int64, req *v1.UpdateChangeWindowRequest) error
+	DeleteWindow(ctx context.Context, userID string, id int64) error
+	GetWindow(ctx context.Context, id int64) (*v1.ChangeWindowItem, error)
+	ListWindows(ctx context.Context, req *v1.ListChangeWindowsRequest) (*v1.ListChangeWindowsResponseData, error)
+	GetStatus(ctx context.Context, req *v1.GetChangeStatusRequest) (*v1.ChangeStatusData, error)
+	ListOverrides(ctx context.Context, req *v1.ListChangeOverridesRequest) (*v1.ListChangeOverridesResponseData, error)
+	// Authorize 校验当前是否允许执行变更操作；被拦截时若请求携带紧急放行原因且操作人为管理员，则记录审计后放行
+	Authorize(ctx context.Context, op *ChangeOperation) error
+}
+
+func NewChangeControlService(
+	service *Service,
+	conf *viper.Viper,
+	windowRepo repository.ChangeWindowRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	vmLock VMLockService,
+	logger *log.Logger,
+) ChangeControlService {
+	return &changeControlService{
+		conf:        conf,
+		windowRepo:  windowRepo,
+		clusterRepo: clusterRepo,
+		userRepo:    userRepo,
+		vmLock:      vmLock,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type changeControlService struct {
+	conf        *viper.Viper
+	windowRepo  repository.ChangeWindowRepository
+	clusterRepo repository.PveClusterRepository
+	userRepo    repository.UserRepository
+	vmLock      VMLockService
+	*Service
+	logger *log.Logger
+}
+
+func (s *changeControlService) CreateWindow(ctx context.Context, userID string, req *v1.CreateChangeWindowRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "change_window.manage")
+	if err != nil {
+		return err
+	}
+
+	window := &model.ChangeWindow{IsEnabled: 1, Creator: username}
+	if err := s.applyWindowRequest(ctx, window, req); err != nil {
+		return err
+	}
+	window.Modifier = username
+
+	if err := s.windowRepo.Create(ctx, window); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create change window", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("change window created",
+		zap.Int64("id", window.Id), zap.String("name", window.Name), zap.String("kind", window.Kind),
+		zap.Int64("cluster_id", window.ClusterID), zap.String("operator", username))
+	return nil
+}
+
+func (s *changeControlService) UpdateWindow(ctx context.Context, userID string, id int64, req *v1.UpdateChangeWindowRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "change_window.manage")
+	if err != nil {
+		return err
+	}
+
+	window, err := s.windowRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get change window", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if window == nil {
+		return v1.ErrNotFound
+	}
+
+	if err := s.applyWindowRequest(ctx, window, req); err != nil {
+		return err
+	}
+	window.Modifier = username
+
+	if err := s.windowRepo.Update(ctx, window); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update change window", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("change window updated",
+		zap.Int64("id", window.Id), zap.String("name", window.Name), zap.String("operator", username))
+	return nil
+}
+
+func (s *changeControlService) DeleteWindow(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "change_window.manage")
+	if err != nil {
+		return err
+	}
+
+	window, err := s.windowRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get change window", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if window == nil {
+		return v1.ErrNotFound
+	}
+
+	if err := s.windowRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete change window", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("change window deleted",
+		zap.Int64("id", window.Id), zap.String("name", window.Name), zap.String("operator", username))
+	return nil
+}
+
+// applyWindowRequest 校验请求并写入窗口字段（整体替换时间规则）
+func (s *changeControlService) applyWindowRequest(ctx context.Context, window *model.ChangeWindow, req *v1.CreateChangeWindowRequest) error {
+	if req.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+		}
+	}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return v1.WithDetailf(v1.ErrInvalidChangeWindow, "unknown timezone %q", req.Timezone)
+		}
+	}
+
+	window.Name = req.Name
+	window.Kind = req.Kind
+	window.ClusterID = req.ClusterID
+	window.AppId = req.AppId
+	window.Description = req.Description
+	window.Recurrence = req.Recurrence
+	window.Timezone = req.Timezone
+	window.StartTime, window.EndTime = nil, nil
+	window.Weekdays, window.DailyStart, window.DailyEnd = "", "", ""
+	if req.IsEnabled != nil {
+		window.IsEnabled = *req.IsEnabled
+	}
+
+	switch req.Recurrence {
+	case model.ChangeWindowRecurrenceOnce:
+		if req.StartTime == nil || req.EndTime == nil {
+			return v1.WithDetail(v1.ErrInvalidChangeWindow, "start_time and end_time are required for once")
+		}
+		if !req.EndTime.After(*req.StartTime) {
+			return v1.WithDetail(v1.ErrInvalidChangeWindow, "end_time must be after start_time")
+		}
+		window.StartTime, window.EndTime = req.StartTime, req.EndTime
+	case model.ChangeWindowRecurrenceWeekly:
+		if len(req.Weekdays) == 0 {
+			return v1.WithDetail(v1.ErrInvalidChangeWindow, "weekdays is required for weekly")
+		}
+		days := make([]string, 0, len(req.Weekdays))
+		for _, d := range req.Weekdays {
+			if d < 0 || d > 6 {
+				return v1.WithDetailf(v1.ErrInvalidChangeWindow, "invalid weekday %d, expected 0 (Sunday) to 6 (Saturday)", d)
+			}
+			days = append(days, strconv.Itoa(d))
+		}
+		if _, err := parseClockMinutes(req.DailyStart); err != nil {
+			return v1.WithDetailf(v1.ErrInvalidChangeWindow, "daily_start: %v", err)
+		}
+		if _, err := parseClockMinutes(req.DailyEnd); err != nil {
+			return v1.WithDetailf(v1.ErrInvalidChangeWindow, "daily_end: %v", err)
+		}
+		window.Weekdays = strings.Join(days, ",")
+		window.DailyStart, window.DailyEnd = req.DailyStart, req.DailyEnd
+	}
+	return nil
+}
+
+func (s *changeControlService) GetWindow(ctx context.Context, id int64) (*v1.ChangeWindowItem, error) {
+	window, err := s.windowRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get change window", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if window == nil {
+		return nil, v1.ErrNotFound
+	}
+	item := toChangeWindowItem(window, time.Now())
+	return &item, nil
+}
+
+func (s *changeControlService) ListWindows(ctx context.Context, req *v1.ListChangeWindowsRequest) (*v1.ListChangeWindowsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	windows, total, err := s.windowRepo.ListWithPagination(ctx, page, pageSize, req.ClusterID, req.Kind)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list change windows", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	now := time.Now()
+	list := make([]v1.ChangeWindowItem, 0, len(windows))
+	for _, w := range windows {
+		list = append(list, toChangeWindowItem(w, now))
+	}
+	return &v1.ListChangeWindowsResponseData{Total: total, List: list}, nil
+}
+
+func (s *changeControlService) GetStatus(ctx context.Context, req *v1.GetChangeStatusRequest) (*v1.ChangeStatusData, error) {
+	windows, err := s.windowRepo.ListEnabledForScope(ctx, req.ClusterID, req.AppId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list change windows", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	now := time.Now()
+	blockedBy, active, blockErr := evaluateChangeWindows(windows, now)
+	data := &v1.ChangeStatusData{
+		Allowed:       blockErr == nil,
+		ActiveWindows: make([]v1.ChangeWindowItem, 0, len(active)),
+	}
+	if blockErr != nil {
+		data.Reason = blockErr.Error()
+	}
+	if blockedBy != nil {
+		item := toChangeWindowItem(blockedBy, now)
+		data.BlockedBy = &item
+	}
+	for _, w := range active {
+		data.ActiveWindows = append(data.ActiveWindows, toChangeWindowItem(w, now))
+	}
+	return data, nil
+}
+
+func (s *changeControlService) ListOverrides(ctx context.Context, req *v1.ListChangeOverridesRequest) (*v1.ListChangeOverridesResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	logs, total, err := s.windowRepo.ListOverrideLogs(ctx, page, pageSize, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list change override logs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.ChangeOverrideItem, 0, len(logs))
+	for _, l := range logs {
+		list = append(list, v1.ChangeOverrideItem{
+			Id:         l.Id,
+			Operator:   l.Operator,
+			Action:     l.Action,
+			Target:     l.Target,
+			ClusterID:  l.ClusterID,
+			AppId:      l.AppId,
+			WindowID:   l.WindowID,
+			WindowName: l.WindowName,
+			Reason:     l.Reason,
+			CreateTime: l.CreateTime,
+		})
+	}
+	return &v1.ListChangeOverridesResponseData{Total: total, List: list}, nil
+}
+
+func (s *changeControlService) Authorize(ctx context.Context, op *ChangeOperation) error {
+	// 维护锁只能由持有人解除，不接受紧急放行
+	if op.VMId > 0 {
+		if err := s.vmLock.Check(ctx, op.VMId); err != nil {
+			return err
+		}
+	}
+
+	windows, err := s.windowRepo.ListEnabledForScope(ctx, op.ClusterID, op.AppId)
+	if err != nil {
+		// 无法确认变更窗口时拒绝变更
+		s.logger.WithContext(ctx).Error("failed to list change windows", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	blockedBy, _, blockErr := evaluateChangeWindows(windows, time.Now())
+	if blockErr == nil {
+		return nil
+	}
+
+	reason, _ := ctx.Value(v1.ChangeOverrideCtxKey).(string)
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return blockErr
+	}
+
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userIDFromCtx(ctx))
+	if err != nil {
+		if errors.Is(err, v1.ErrAdminRequired) || errors.Is(err, v1.ErrUnauthorized) {
+			return v1.ErrChangeOverrideForbidden
+		}
+		return err
+	}
+
+	if r := []rune(reason); len(r) > maxChangeOverrideReasonLen {
+		reason = string(r[:maxChangeOverrideReasonLen])
+	}
+	record := &model.ChangeOverrideLog{
+		Operator:  username,
+		Action:    op.Action,
+		Target:    op.Target,
+		ClusterID: op.ClusterID,
+		AppId:     op.AppId,
+		Reason:    reason,
+	}
+	if blockedBy != nil {
+		record.WindowID = blockedBy.Id
+		record.WindowName = blockedBy.Name
+	}
+	// 审计记录写入失败时不放行
+	if err := s.windowRepo.CreateOverrideLog(ctx, record); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create change override log", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Warn("change control overridden",
+		zap.String("operator", username),
+		zap.String("action", op.Action),
+		zap.String("target", op.Target),
+		zap.Int64("cluster_id", op.ClusterID),
+		zap.String("blocked_by", blockErr.Error()),
+		zap.String("reason", reason))
+	return nil
+}
+
+// userIDFromCtx 从请求上下文中读取 JWT 中的用户ID（handler 将 *gin.Context 直接传入 service）
+func userIDFromCtx(ctx context.Context) string {
+	if claims, ok := ctx.Value("claims").(*jwt.MyCustomClaims); ok {
+		return claims.UserId
+	}
+	return ""
+}
+
+// evaluateChangeWindows 判断当前是否允许变更：
+// 处于任一封网期则禁止；存在维护窗口时必须处于其中之一，否则禁止；没有任何维护窗口时允许。
+// 返回拦截的封网期窗口、当前生效的维护窗口，以及拦截错误
+func evaluateChangeWindows(windows []*model.ChangeWindow, now time.Time) (*model.ChangeWindow, []*model.ChangeWindow, error) {
+	var maintenance, active []*model.ChangeWindow
+	for _, w := range windows {
+		switch w.Kind {
+		case model.ChangeWindowKindFreeze:
+			if changeWindowActive(w, now) {
+				return w, nil, v1.WithDetail(v1.ErrChangeFrozen, w.Name)
+			}
+		case model.ChangeWindowKindMaintenance:
+			maintenance = append(maintenance, w)
+			if changeWindowActive(w, now) {
+				active = append(active, w)
+			}
+		}
+	}
+
+	if len(maintenance) > 0 && len(active) == 0 {
+		names := make([]string, 0, len(maintenance))
+		for _, w := range maintenance {
+			names = append(names, w.Name)
+		}
+		sort.Strings(names)
+		return nil, nil, v1.WithDetailf(v1.ErrOutsideMaintenanceWindow, "allowed windows: %s", strings.Join(names, ", "))
+	}
+	return nil, active, nil
+}
+
+// changeWindowActive 判断指定时刻是否处于窗口内
+func changeWindowActive(w *model.ChangeWindow, now time.Time) bool {
+	switch w.Recurrence {
+	case model.ChangeWindowRecurrenceOnce:
+		return w.StartTime != nil && w.EndTime != nil && !now.Before(*w.StartTime) && now.Before(*w.EndTime)
+	case model.ChangeWindowRecurrenceWeekly:
+		loc := time.Local
+		if w.Timezone != "" {
+			if l, err := time.LoadLocation(w.Timezone); err == nil {
+				loc = l
+			}
+		}
+		start, err := parseClockMinutes(w.DailyStart)
+		if err != nil {
+			return false
+		}
+		end, err := parseClockMinutes(w.DailyEnd)
+		if err != nil {
+			return false
+		}
+
+		t := now.In(loc)
+		days := parseWeekdays(w.Weekdays)
+		minute := t.Hour()*60 + t.Minute()
+		switch {
+		case start < end:
+			return days[t.Weekday()] && minute >= start && minute < end
+		case start == end:
+			// 开始与结束相同表示全天
+			return days[t.Weekday()]
+		default:
+			// 跨零点：开始当天的 start 之后，或次日的 end 之前
+			if minute >= start {
+				return days[t.Weekday()]
+			}
+			return minute < end && days[(t.Weekday()+6)%7]
+		}
+	}
+	return false
+}
+
+// parseClockMinutes 解析 HH:MM，返回当天的分钟数
+func parseClockMinutes(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+func parseWeekdays(s string) map[time.Weekday]bool {
+	days := make(map[time.Weekday]bool, 7)
+	for _, part := range strings.Split(s, ",") {
+		if d, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && d >= 0 && d <= 6 {
+			days[time.Weekday(d)] = true
+		}
+	}
+	return days
+}
+
+func toChangeWindowItem(w *model.ChangeWindow, now time.Time) v1.ChangeWindowItem {
+	weekdays := make([]int, 0)
+	if w.Weekdays != "" {
+		for _, part := range strings.Split(w.Weekdays, ",") {
+			if d, err := strconv.Atoi(part); err == nil {
+				weekdays = append(weekdays, d)
+			}
+		}
+	}
+	return v1.ChangeWindowItem{
+		Id:          w.Id,
+		Name:        w.Name,
+		Kind:        w.Kind,
+		ClusterID:   w.ClusterID,
+		AppId:       w.AppId,
+		Description: w.Description,
+		Recurrence:  w.Recurrence,
+		StartTime:   w.StartTime,
+		EndTime:     w.EndTime,
+		Weekdays:    weekdays,
+		DailyStart:  w.DailyStart,
+		DailyEnd:    w.DailyEnd,
+		Timezone:    w.Timezone,
+		IsEnabled:   w.IsEnabled,
+		Active:      w.IsEnabled == 1 && changeWindowActive(w, now),
+		Creator:     w.Creator,
+		Modifier:    w.Modifier,
+		CreateTime:  w.CreateTime,
+		UpdateTime:  w.UpdateTime,
+	}
+}
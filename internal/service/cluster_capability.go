@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// clusterFeature PveSphere 功能及其在 / 上需要的权限；当前 PVE 版本不存在的权限会被忽略，
+// 因此同一功能可以同时列出新旧版本的权限名（如 guest agent 在 PVE 9 之前使用 VM.Monitor）
+type clusterFeature struct {
+	Feature    string
+	Name       string
+	Privileges []string
+	Optional   bool
+}
+
+var clusterFeatures = []clusterFeature{
+	{Feature: "inventory.sync", Name: "同步节点、虚拟机和存储", Privileges: []string{"Sys.Audit", "VM.Audit", "Datastore.Audit"}},
+	{Feature: "vm.create", Name: "创建虚拟机", Privileges: []string{
+		"VM.Allocate", "VM.Config.CDROM", "VM.Config.CPU", "VM.Config.Disk", "VM.Config.HWType",
+		"VM.Config.Memory", "VM.Config.Network", "VM.Config.Options", "Datastore.AllocateSpace", "SDN.Use",
+	}},
+	{Feature: "vm.clone", Name: "从模板克隆虚拟机", Privileges: []string{"VM.Clone", "VM.Allocate", "VM.Config.Cloudinit", "Datastore.AllocateSpace"}},
+	{Feature: "vm.config", Name: "修改虚拟机配置", Privileges: []string{
+		"VM.Config.CDROM", "VM.Config.CPU", "VM.Config.Cloudinit", "VM.Config.Disk", "VM.Config.HWType",
+		"VM.Config.Memory", "VM.Config.Network", "VM.Config.Options",
+	}},
+	{Feature: "vm.delete", Name: "删除虚拟机", Privileges: []string{"VM.Allocate"}},
+	{Feature: "vm.power", Name: "虚拟机开关机", Privileges: []string{"VM.PowerMgmt"}},
+	{Feature: "vm.console", Name: "虚拟机控制台", Privileges: []string{"VM.Console"}},
+	{Feature: "vm.snapshot", Name: "虚拟机快照", Privileges: []string{"VM.Snapshot", "VM.Snapshot.Rollback"}, Optional: true},
+	{Feature: "vm.backup", Name: "虚拟机备份", Privileges: []string{"VM.Backup", "Datastore.AllocateSpace"}, Optional: true},
+	{Feature: "vm.migrate", Name: "虚拟机迁移", Privileges: []string{"VM.Migrate"}, Optional: true},
+	{Feature: "vm.guest_agent", Name: "Guest Agent（IP 上报、网络诊断）", Privileges: []string{"VM.Monitor", "VM.GuestAgent.Audit", "VM.GuestAgent.Unrestricted"}, Optional: true},
+	{Feature: "storage.upload", Name: "上传 ISO / 模板", Privileges: []string{"Datastore.AllocateTemplate"}, Optional: true},
+	{Feature: "storage.manage", Name: "节点磁盘与存储管理", Privileges: []string{"Datastore.Allocate", "Sys.Modify"}, Optional: true},
+	{Feature: "node.console", Name: "节点控制台", Privileges: []string{"Sys.Console"}, Optional: true},
+	{Feature: "node.power", Name: "节点重启 / 关机", Privileges: []string{"Sys.PowerMgmt"}, Optional: true},
+	{Feature: "node.syslog", Name: "节点日志与任务日志", Privileges: []string{"Sys.Syslog"}, Optional: true},
+	{Feature: "network.sdn", Name: "SDN 网络", Privileges: []string{"SDN.Audit", "SDN.Use"}, Optional: true},
+	{Feature: "access.manage", Name: "PVE 用户、Token 和 ACL 管理", Privileges: []string{"User.Modify", "Permissions.Modify"}, Optional: true},
+}
+
+// ClusterCapabilityService 集群接入时的能力探测：对照各功能需要的权限检查 API Token，保存能力矩阵供前端禁用不可用的功能
+type ClusterCapabilityService interface {
+	// Probe 使用集群凭据探测并保存能力矩阵
+	Probe(ctx context.Context, clusterID int64) (*v1.ClusterCapabilityData, error)
+	// ProbeCredentials 接入向导中保存集群前探测，不保存结果
+	ProbeCredentials(ctx context.Context, req *v1.ProbeClusterRequest) (*v1.ClusterCapabilityData, error)
+	// Get 获取保存的能力矩阵，从未探测过时立即探测
+	Get(ctx context.Context, clusterID int64) (*v1.ClusterCapabilityData, error)
+}
+
+func NewClusterCapabilityService(
+	service *Service,
+	clusterRepo repository.PveClusterRepository,
+	capabilityRepo repository.ClusterCapabilityRepository,
+	logger *log.Logger,
+) ClusterCapabilityService {
+	return &clusterCapabilityService{
+		Service:        service,
+		clusterRepo:    clusterRepo,
+		capabilityRepo: capabilityRepo,
+		logger:         logger,
+	}
+}
+
+type clusterCapabilityService struct {
+	*Service
+	clusterRepo    repository.PveClusterRepository
+	capabilityRepo repository.ClusterCapabilityRepository
+	logger         *log.Logger
+}
+
+func (s *clusterCapabilityService) Probe(ctx context.Context, clusterID int64) (*v1.ClusterCapabilityData, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+	data := probeClusterCapabilities(ctx, client, cluster.UserId)
+	data.ClusterID = clusterID
+
+	record := &model.ClusterCapability{
+		ClusterID:     clusterID,
+		UserID:        data.UserID,
+		PveVersion:    data.PveVersion,
+		Connected:     data.Connected,
+		Authenticated: data.Authenticated,
+		Privileges:    marshalCapabilityJSON(data.Privileges),
+		Missing:       marshalCapabilityJSON(data.Missing),
+		Features:      marshalCapabilityJSON(data.Features),
+		Hints:         marshalCapabilityJSON(data.Hints),
+		Message:       data.Message,
+		ProbeTime:     data.ProbeTime,
+	}
+	if err := s.capabilityRepo.Save(ctx, record); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save cluster capability", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("cluster capabilities probed",
+		zap.Int64("cluster_id", clusterID), zap.Bool("authenticated", data.Authenticated), zap.Strings("missing", data.Missing))
+	return data, nil
+}
+
+func (s *clusterCapabilityService) ProbeCredentials(ctx context.Context, req *v1.ProbeClusterRequest) (*v1.ClusterCapabilityData, error) {
+	client, err := proxmox.NewProxmoxClient(req.ApiUrl, req.UserId, req.UserToken)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrInvalidParameter, err.Error())
+	}
+	return probeClusterCapabilities(ctx, client, req.UserId), nil
+}
+
+func (s *clusterCapabilityService) Get(ctx context.Context, clusterID int64) (*v1.ClusterCapabilityData, error) {
+	record, err := s.capabilityRepo.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster capability", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+	if record == nil {
+		return s.Probe(ctx, clusterID)
+	}
+
+	data := &v1.ClusterCapabilityData{
+		ClusterID:     record.ClusterID,
+		UserID:        record.UserID,
+		Connected:     record.Connected,
+		Authenticated: record.Authenticated,
+		PveVersion:    record.PveVersion,
+		Privileges:    []string{},
+		Missing:       []string{},
+		Features:      []v1.ClusterFeatureCapability{},
+		Hints:         []string{},
+		Message:       record.Message,
+		ProbeTime:     record.ProbeTime,
+	}
+	unmarshalCapabilityJSON(record.Privileges, &data.Privileges)
+	unmarshalCapabilityJSON(record.Missing, &data.Missing)
+	unmarshalCapabilityJSON(record.Features, &data.Features)
+	unmarshalCapabilityJSON(record.Hints, &data.Hints)
+	return data, nil
+}
+
+// probeClusterCapabilities 检查凭据能否认证，并按功能对照 Token 在 / 上的有效权限
+func probeClusterCapabilities(ctx context.Context, client *proxmox.ProxmoxClient, userID string) *v1.ClusterCapabilityData {
+	now := time.Now()
+	data := &v1.ClusterCapabilityData{
+		UserID:     userID,
+		Privileges: []string{},
+		Missing:    []string{},
+		Features:   []v1.ClusterFeatureCapability{},
+		Hints:      tokenIDHints(userID),
+		ProbeTime:  &now,
+	}
+	if data.Hints == nil {
+		data.Hints = []string{}
+	}
+
+	version, err := client.GetVersion(ctx)
+	if err != nil {
+		data.Message = err.Error()
+		var hints []string
+		data.Connected, hints = versionErrorHints(err)
+		data.Hints = append(data.Hints, hints...)
+		data.Features = evaluateClusterFeatures(nil, nil)
+		return data
+	}
+	data.Connected = true
+	data.Authenticated = true
+	data.PveVersion, _ = version["version"].(string)
+
+	perms, err := client.GetPermissions(ctx, "/")
+	if err != nil {
+		data.Message = err.Error()
+		data.Features = evaluateClusterFeatures(nil, nil)
+		return data
+	}
+	granted := perms["/"]
+	for priv := range granted {
+		data.Privileges = append(data.Privileges, priv)
+	}
+	sort.Strings(data.Privileges)
+
+	data.Features = evaluateClusterFeatures(granted, privilegeCatalog(ctx, client))
+	missing := make(map[string]bool)
+	for _, feature := range data.Features {
+		if feature.Optional {
+			continue
+		}
+		for _, priv := range feature.Missing {
+			missing[priv] = true
+		}
+	}
+	for priv := range missing {
+		data.Missing = append(data.Missing, priv)
+	}
+	sort.Strings(data.Missing)
+
+	if len(granted) == 0 {
+		data.Hints = append(data.Hints,
+			"the token has no privileges on /; tokens with privilege separation need their own ACL entries, or recreate the token with privsep=0")
+	} else if len(data.Missing) > 0 {
+		data.Hints = append(data.Hints, "grant the missing privileges on / (propagate) or use the bootstrap endpoint to create a dedicated role")
+	}
+	return data
+}
+
+// evaluateClusterFeatures 计算各功能是否可用，granted 为 nil 时（未认证）全部不可用
+func evaluateClusterFeatures(granted map[string]interface{}, catalog map[string]interface{}) []v1.ClusterFeatureCapability {
+	features := make([]v1.ClusterFeatureCapability, 0, len(clusterFeatures))
+	for _, f := range clusterFeatures {
+		item := v1.ClusterFeatureCapability{
+			Feature:  f.Feature,
+			Name:     f.Name,
+			Optional: f.Optional,
+			Required: filterPrivileges(f.Privileges, catalog),
+			Missing:  []string{},
+		}
+		if granted != nil {
+			for _, priv := range item.Required {
+				if _, ok := granted[priv]; !ok {
+					item.Missing = append(item.Missing, priv)
+				}
+			}
+			item.Supported = len(item.Missing) == 0
+		}
+		features = append(features, item)
+	}
+	return features
+}
+
+func marshalCapabilityJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+func unmarshalCapabilityJSON(s string, v interface{}) {
+	if s == "" {
+		return
+	}
+	_ = json.Unmarshal([]byte(s), v)
+}
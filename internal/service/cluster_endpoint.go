@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// ClusterEndpointService 集群接入地址 / 凭据 / 节点证书更换流程：预检新的接入信息、刷新跨集群迁移使用的证书指纹，
+// 并重新检查依赖的功能，避免直接修改 API 地址后跨集群迁移等功能静默失效
+type ClusterEndpointService interface {
+	// Verify 使用新的接入信息执行全部检查，不保存
+	Verify(ctx context.Context, clusterID int64, req *v1.UpdateClusterEndpointRequest) (*v1.ClusterEndpointData, error)
+	// Apply 检查通过后保存接入信息和证书指纹，并重新探测能力矩阵
+	Apply(ctx context.Context, clusterID int64, req *v1.UpdateClusterEndpointRequest) (*v1.ClusterEndpointData, error)
+	// ListCertificates 已保存的节点证书指纹
+	ListCertificates(ctx context.Context, clusterID int64) ([]v1.ClusterNodeCertificate, error)
+}
+
+func NewClusterEndpointService(
+	service *Service,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	certRepo repository.PveNodeCertificateRepository,
+	capabilityService ClusterCapabilityService,
+	logger *log.Logger,
+) ClusterEndpointService {
+	return &clusterEndpointService{
+		Service:           service,
+		clusterRepo:       clusterRepo,
+		nodeRepo:          nodeRepo,
+		certRepo:          certRepo,
+		capabilityService: capabilityService,
+		logger:            logger,
+	}
+}
+
+type clusterEndpointService struct {
+	*Service
+	clusterRepo       repository.PveClusterRepository
+	nodeRepo          repository.PveNodeRepository
+	certRepo          repository.PveNodeCertificateRepository
+	capabilityService ClusterCapabilityService
+	logger            *log.Logger
+}
+
+// endpointCheckResult 一次检查的结果，certs 为读取到的节点证书（待保存）
+type endpointCheckResult struct {
+	data  *v1.ClusterEndpointData
+	certs []*model.PveNodeCertificate
+}
+
+func (s *clusterEndpointService) Verify(ctx context.Context, clusterID int64, req *v1.UpdateClusterEndpointRequest) (*v1.ClusterEndpointData, error) {
+	cluster, err := s.getCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.check(ctx, cluster, applyEndpointRequest(cluster, req))
+	if err != nil {
+		return nil, err
+	}
+	return result.data, nil
+}
+
+func (s *clusterEndpointService) Apply(ctx context.Context, clusterID int64, req *v1.UpdateClusterEndpointRequest) (*v1.ClusterEndpointData, error) {
+	cluster, err := s.getCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	updated := applyEndpointRequest(cluster, req)
+	result, err := s.check(ctx, cluster, updated)
+	if err != nil {
+		return nil, err
+	}
+	if !result.data.Passed {
+		var failed []string
+		for _, check := range result.data.Checks {
+			if check.Status == v1.EndpointCheckFailed {
+				failed = append(failed, check.Check+": "+check.Message)
+			}
+		}
+		return result.data, v1.WithDetailf(v1.ErrClusterEndpointCheckFailed, "%s", strings.Join(failed, "; "))
+	}
+
+	updated.UpdateTime = time.Now()
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.clusterRepo.Update(ctx, updated); err != nil {
+			return err
+		}
+		for _, cert := range result.certs {
+			if err := s.certRepo.Save(ctx, cert); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to save cluster endpoint", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+	result.data.Applied = true
+
+	// 连接信息已变更，清除集群资源缓存并保存新的能力矩阵
+	s.resources.Invalidate(clusterID)
+	if _, err := s.capabilityService.Probe(ctx, clusterID); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to probe cluster capabilities", zap.Error(err), zap.Int64("cluster_id", clusterID))
+	}
+
+	s.logger.WithContext(ctx).Info("cluster endpoint updated",
+		zap.Int64("cluster_id", clusterID),
+		zap.String("old_api_url", cluster.ApiUrl),
+		zap.String("api_url", updated.ApiUrl),
+		zap.Int("certificates", len(result.certs)))
+	return result.data, nil
+}
+
+func (s *clusterEndpointService) ListCertificates(ctx context.Context, clusterID int64) ([]v1.ClusterNodeCertificate, error) {
+	if _, err := s.getCluster(ctx, clusterID); err != nil {
+		return nil, err
+	}
+	certs, err := s.certRepo.ListByCluster(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node certificates", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.ClusterNodeCertificate, 0, len(certs))
+	for _, cert := range certs {
+		verifyTime := cert.VerifyTime
+		list = append(list, v1.ClusterNodeCertificate{
+			NodeName:    cert.NodeName,
+			Fingerprint: cert.Fingerprint,
+			NotAfter:    cert.NotAfter,
+			VerifyTime:  &verifyTime,
+		})
+	}
+	return list, nil
+}
+
+func (s *clusterEndpointService) getCluster(ctx context.Context, clusterID int64) (*model.PveCluster, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+	return cluster, nil
+}
+
+// applyEndpointRequest 返回应用请求后的集群副本，未填写的字段沿用当前配置
+func applyEndpointRequest(cluster *model.PveCluster, req *v1.UpdateClusterEndpointRequest) *model.PveCluster {
+	updated := *cluster
+	if req.ApiUrl != nil && strings.TrimSpace(*req.ApiUrl) != "" {
+		updated.ApiUrl = strings.TrimSpace(*req.ApiUrl)
+	}
+	if req.UserId != nil && strings.TrimSpace(*req.UserId) != "" {
+		updated.UserId = strings.TrimSpace(*req.UserId)
+	}
+	if req.UserToken != nil && *req.UserToken != "" {
+		updated.UserToken = *req.UserToken
+	}
+	return &updated
+}
+
+// check 使用 updated 的接入信息执行检查，cluster 为当前保存的配置（用于对比已登记的节点和证书指纹）
+func (s *clusterEndpointService) check(ctx context.Context, cluster, updated *model.PveCluster) (*endpointCheckResult, error) {
+	data := &v1.ClusterEndpointData{
+		ClusterID:    cluster.Id,
+		ApiUrl:       updated.ApiUrl,
+		Checks:       []v1.ClusterEndpointCheck{},
+		Certificates: []v1.ClusterNodeCertificate{},
+	}
+	result := &endpointCheckResult{data: data}
+	addCheck := func(check, status, format string, args ...interface{}) {
+		data.Checks = append(data.Checks, v1.ClusterEndpointCheck{Check: check, Status: status, Message: fmt.Sprintf(format, args...)})
+	}
+	defer func() {
+		data.Passed = true
+		for _, check := range data.Checks {
+			if check.Status == v1.EndpointCheckFailed {
+				data.Passed = false
+			}
+		}
+	}()
+
+	registered, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster nodes", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+		return nil, v1.ErrInternalServerError
+	}
+	stored, err := s.certRepo.ListByCluster(ctx, cluster.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node certificates", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+		return nil, v1.ErrInternalServerError
+	}
+	storedByNode := make(map[string]*model.PveNodeCertificate, len(stored))
+	for _, cert := range stored {
+		storedByNode[cert.NodeName] = cert
+	}
+
+	// 1. 连通性和认证
+	client, err := proxmox.NewProxmoxClient(updated.ApiUrl, updated.UserId, updated.UserToken, proxmox.WithRequestLog(updated.ApiLogEnabled == 1))
+	if err != nil {
+		addCheck("connect", v1.EndpointCheckFailed, "invalid endpoint: %v", err)
+		return result, nil
+	}
+	version, err := client.GetVersion(ctx)
+	if err != nil {
+		addCheck("connect", v1.EndpointCheckFailed, "connection failed: %v", err)
+		return result, nil
+	}
+	data.PveVersion, _ = version["version"].(string)
+	addCheck("connect", v1.EndpointCheckOK, "connected to Proxmox VE %s", data.PveVersion)
+
+	// 2. 确认新地址指向同一个集群：已登记的节点应出现在 cluster/status 中
+	var nodeNames []string
+	status, err := client.GetClusterStatus(ctx)
+	if err != nil {
+		addCheck("identity", v1.EndpointCheckFailed, "failed to get cluster status: %v", err)
+		return result, nil
+	}
+	present := make(map[string]bool)
+	for _, item := range status {
+		if t, _ := item["type"].(string); t != "node" {
+			continue
+		}
+		if name, _ := item["name"].(string); name != "" {
+			present[name] = true
+			nodeNames = append(nodeNames, name)
+		}
+	}
+	sort.Strings(nodeNames)
+	var matched, missing []string
+	for _, node := range registered {
+		if present[node.NodeName] {
+			matched = append(matched, node.NodeName)
+		} else {
+			missing = append(missing, node.NodeName)
+		}
+	}
+	switch {
+	case len(registered) == 0:
+		addCheck("identity", v1.EndpointCheckOK, "no registered nodes, found %d nodes", len(nodeNames))
+	case len(matched) == 0:
+		addCheck("identity", v1.EndpointCheckFailed, "none of the registered nodes (%s) found, the endpoint points to a different cluster",
+			strings.Join(nodeNamesOf(registered), ", "))
+		return result, nil
+	case len(missing) > 0:
+		addCheck("identity", v1.EndpointCheckWarning, "registered nodes not found: %s", strings.Join(missing, ", "))
+	default:
+		addCheck("identity", v1.EndpointCheckOK, "all %d registered nodes found", len(registered))
+	}
+
+	// 3. 刷新节点证书指纹
+	now := time.Now()
+	counts := make(map[string]int)
+	for _, name := range nodeNames {
+		item := v1.ClusterNodeCertificate{NodeName: name}
+		certificates, err := client.GetNodeCertificatesInfo(ctx, name)
+		fingerprint, subject, notAfter := pveSSLCertificate(certificates)
+		switch {
+		case err != nil:
+			item.Status = v1.NodeCertUnavailable
+			item.Message = err.Error()
+		case fingerprint == "":
+			item.Status = v1.NodeCertUnavailable
+			item.Message = "pve-ssl.pem not found"
+		default:
+			item.Fingerprint = fingerprint
+			item.NotAfter = notAfter
+			item.Status = v1.NodeCertNew
+			if prev := storedByNode[name]; prev != nil {
+				item.Status = v1.NodeCertUnchanged
+				if !strings.EqualFold(prev.Fingerprint, fingerprint) {
+					item.Status = v1.NodeCertChanged
+					item.PreviousFingerprint = prev.Fingerprint
+				}
+			}
+			result.certs = append(result.certs, &model.PveNodeCertificate{
+				ClusterID:   cluster.Id,
+				NodeName:    name,
+				Fingerprint: fingerprint,
+				Subject:     subject,
+				NotAfter:    notAfter,
+				VerifyTime:  now,
+			})
+		}
+		counts[item.Status]++
+		data.Certificates = append(data.Certificates, item)
+	}
+	certStatus := v1.EndpointCheckOK
+	if counts[v1.NodeCertUnavailable] > 0 {
+		certStatus = v1.EndpointCheckWarning
+	}
+	addCheck("certificates", certStatus, "%d nodes: %d unchanged, %d changed, %d new, %d unavailable", len(nodeNames),
+		counts[v1.NodeCertUnchanged], counts[v1.NodeCertChanged], counts[v1.NodeCertNew], counts[v1.NodeCertUnavailable])
+
+	// 4. 依赖的功能
+	capabilities := probeClusterCapabilities(ctx, client, updated.UserId)
+	switch {
+	case capabilities.Message != "":
+		addCheck("capabilities", v1.EndpointCheckWarning, "failed to check token privileges: %s", capabilities.Message)
+	case len(capabilities.Missing) > 0:
+		addCheck("capabilities", v1.EndpointCheckWarning, "missing privileges: %s", strings.Join(capabilities.Missing, ", "))
+	default:
+		addCheck("capabilities", v1.EndpointCheckOK, "all required privileges granted")
+	}
+
+	if len(nodeNames) > 0 {
+		if storages, err := client.GetNodeStorages(ctx, nodeNames[0], ""); err != nil {
+			addCheck("storage", v1.EndpointCheckWarning, "failed to list storage on %s: %v", nodeNames[0], err)
+		} else {
+			addCheck("storage", v1.EndpointCheckOK, "%d storages on %s", len(storages), nodeNames[0])
+		}
+	}
+	if _, err := client.GetClusterTasks(ctx); err != nil {
+		addCheck("tasks", v1.EndpointCheckWarning, "failed to list cluster tasks: %v", err)
+	} else {
+		addCheck("tasks", v1.EndpointCheckOK, "cluster tasks readable")
+	}
+
+	// 5. 跨集群迁移需要目标节点的证书指纹
+	if counts[v1.NodeCertUnavailable] > 0 {
+		addCheck("remote_migration", v1.EndpointCheckWarning, "%d nodes have no certificate fingerprint, remote migration to them will fail",
+			counts[v1.NodeCertUnavailable])
+	} else {
+		addCheck("remote_migration", v1.EndpointCheckOK, "certificate fingerprints available for all nodes")
+	}
+	return result, nil
+}
+
+func nodeNamesOf(nodes []*model.PveNode) []string {
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		names = append(names, node.NodeName)
+	}
+	return names
+}
+
+// pveSSLCertificate 从 certificates/info 的结果中取 pve-ssl.pem 的指纹（不使用 pve-root-ca.pem）
+func pveSSLCertificate(certificates []map[string]interface{}) (fingerprint, subject string, notAfter *time.Time) {
+	for _, cert := range certificates {
+		if filename, _ := cert["filename"].(string); filename != "pve-ssl.pem" {
+			continue
+		}
+		fingerprint, _ = cert["fingerprint"].(string)
+		subject, _ = cert["subject"].(string)
+		if ts, ok := cert["notafter"].(float64); ok && ts > 0 {
+			t := time.Unix(int64(ts), 0)
+			notAfter = &t
+		}
+		return fingerprint, subject, notAfter
+	}
+	return "", "", nil
+}
+
+// targetNodeFingerprint 跨集群迁移时获取目标节点的证书指纹，首次迁移到该节点时记录当前指纹
+func targetNodeFingerprint(ctx context.Context, certRepo repository.PveNodeCertificateRepository, client *proxmox.ProxmoxClient,
+	cluster *model.PveCluster, nodeName string, logger *log.Logger) (string, error) {
+	fingerprint, record, err := resolveNodeFingerprint(ctx, certRepo, client, cluster, nodeName, logger)
+	if err != nil {
+		return "", err
+	}
+	if record != nil {
+		if err := certRepo.Save(ctx, record); err != nil {
+			logger.WithContext(ctx).Warn("failed to save node certificate", zap.Error(err), zap.String("node", nodeName))
+		}
+	}
+	return fingerprint, nil
+}
+
+// resolveNodeFingerprint 确定目标节点用于跨集群迁移的证书指纹，不保存：
+// - 当前指纹与保存的不一致时返回 ErrNodeCertificateChanged，需要先通过集群接入地址更新流程确认新证书
+// - 无法读取证书时使用保存的指纹，都没有时返回 ErrNodeCertificateUnavailable
+// - 之前没有保存过指纹时，record 为待保存的当前证书
+func resolveNodeFingerprint(ctx context.Context, certRepo repository.PveNodeCertificateRepository, client *proxmox.ProxmoxClient,
+	cluster *model.PveCluster, nodeName string, logger *log.Logger) (string, *model.PveNodeCertificate, error) {
+	stored, err := certRepo.GetByNode(ctx, cluster.Id, nodeName)
+	if err != nil {
+		logger.WithContext(ctx).Error("failed to get node certificate", zap.Error(err), zap.String("node", nodeName))
+		return "", nil, v1.ErrInternalServerError
+	}
+
+	certificates, err := client.GetNodeCertificatesInfo(ctx, nodeName)
+	if err != nil {
+		if stored != nil {
+			logger.WithContext(ctx).Warn("failed to get target node certificates info, using stored fingerprint",
+				zap.Error(err), zap.String("node", nodeName))
+			return stored.Fingerprint, nil, nil
+		}
+		return "", nil, v1.WithDetailf(v1.ErrNodeCertificateUnavailable, "cluster=%s node=%s: %v", cluster.ClusterName, nodeName, err)
+	}
+	fingerprint, subject, notAfter := pveSSLCertificate(certificates)
+	if fingerprint == "" {
+		if stored != nil {
+			return stored.Fingerprint, nil, nil
+		}
+		return "", nil, v1.WithDetailf(v1.ErrNodeCertificateUnavailable, "cluster=%s node=%s: pve-ssl.pem not found", cluster.ClusterName, nodeName)
+	}
+	if stored != nil {
+		if !strings.EqualFold(stored.Fingerprint, fingerprint) {
+			return "", nil, v1.WithDetailf(v1.ErrNodeCertificateChanged, "cluster=%s node=%s stored=%s current=%s",
+				cluster.ClusterName, nodeName, stored.Fingerprint, fingerprint)
+		}
+		return stored.Fingerprint, nil, nil
+	}
+	return fingerprint, &model.PveNodeCertificate{
+		ClusterID:   cluster.Id,
+		NodeName:    nodeName,
+		Fingerprint: fingerprint,
+		Subject:     subject,
+		NotAfter:    notAfter,
+		VerifyTime:  time.Now(),
+	}, nil
+}
@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 集群外部变更默认参数，可通过 cluster_event.* 调整
+const (
+	defaultClusterEventLookback      = 10 * time.Minute
+	defaultClusterEventRetentionDays = 90
+	clusterEventJournalLastEntries   = 2000
+)
+
+// clusterEventTaskTypes Proxmox 任务类型与事件类型的对应关系，其他任务类型忽略
+var clusterEventTaskTypes = map[string]string{
+	"qmcreate":   model.ClusterEventVMCreated,
+	"qmclone":    model.ClusterEventVMCloned,
+	"qmrestore":  model.ClusterEventVMRestored,
+	"qmdestroy":  model.ClusterEventVMDeleted,
+	"qmigrate":   model.ClusterEventVMMigrated,
+	"qmstart":    model.ClusterEventVMStarted,
+	"qmstop":     model.ClusterEventVMStopped,
+	"qmshutdown": model.ClusterEventVMStopped,
+}
+
+// clusterEventJournalPattern pvedaemon / pveproxy 记录的虚拟机配置修改，如
+// "Jan 02 15:04:05 pve1 pvedaemon[1234]: <root@pam> update VM 100: -memory 4096"
+var clusterEventJournalPattern = regexp.MustCompile(`<([^>]+)> update VM (\d+): (.*)$`)
+
+// clusterEventNotifyTypes 需要通知管理员和虚拟机负责人的事件类型，启停等日常操作只记录
+var clusterEventNotifyTypes = map[string]string{
+	model.ClusterEventVMCreated:       "created",
+	model.ClusterEventVMCloned:        "cloned",
+	model.ClusterEventVMRestored:      "restored",
+	model.ClusterEventVMDeleted:       "deleted",
+	model.ClusterEventVMMigrated:      "migrated",
+	model.ClusterEventVMConfigChanged: "reconfigured",
+}
+
+type ClusterEventService interface {
+	// Poll 轮询各集群的任务列表和节点 journal，记录新的外部变更并通知、触发待认领扫描，返回新记录的事件数
+	Poll(ctx context.Context) (int, error)
+	List(ctx context.Context, req *v1.ListClusterEventsRequest) (*v1.ListClusterEventsResponseData, error)
+	// Cleanup 删除超过 cluster_event.retention_days 的事件，返回删除数量
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+func NewClusterEventService(
+	service *Service,
+	conf *viper.Viper,
+	eventRepo repository.ClusterEventRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	claimService VMClaimService,
+	notesService VMNotesService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) ClusterEventService {
+	return &clusterEventService{
+		Service:             service,
+		conf:                conf,
+		eventRepo:           eventRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		vmRepo:              vmRepo,
+		claimService:        claimService,
+		notesService:        notesService,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+type clusterEventService struct {
+	*Service
+	conf                *viper.Viper
+	eventRepo           repository.ClusterEventRepository
+	clusterRepo         repository.PveClusterRepository
+	nodeRepo            repository.PveNodeRepository
+	vmRepo              repository.PveVMRepository
+	claimService        VMClaimService
+	notesService        VMNotesService
+	notificationService NotificationService
+	logger              *log.Logger
+}
+
+func (s *clusterEventService) lookback() time.Duration {
+	lookback := s.conf.GetDuration("cluster_event.lookback")
+	if lookback <= 0 {
+		return defaultClusterEventLookback
+	}
+	return lookback
+}
+
+// enabled 未配置时默认开启
+func (s *clusterEventService) enabled(key string) bool {
+	return !s.conf.IsSet(key) || s.conf.GetBool(key)
+}
+
+func (s *clusterEventService) Poll(ctx context.Context) (int, error) {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	reconcile := false
+	for _, cluster := range clusters {
+		events, err := s.pollCluster(ctx, cluster)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to poll cluster events",
+				zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			continue
+		}
+		for _, event := range events {
+			created++
+			switch event.Type {
+			case model.ClusterEventVMCreated, model.ClusterEventVMCloned, model.ClusterEventVMRestored,
+				model.ClusterEventVMDeleted, model.ClusterEventVMMigrated:
+				reconcile = true
+			}
+		}
+	}
+
+	// 虚拟机增删、迁移后立即比对数据库记录，无需等待下一次全量同步
+	if reconcile && s.claimService != nil && s.enabled("cluster_event.reconcile") {
+		if _, err := s.claimService.Scan(ctx); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to reconcile vms after cluster events", zap.Error(err))
+		}
+	}
+	return created, nil
+}
+
+// pollCluster 读取单个集群 lookback 时间内的任务和 journal，返回本次新记录的事件
+func (s *clusterEventService) pollCluster(ctx context.Context, cluster *model.PveCluster) ([]*model.ClusterEvent, error) {
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return nil, err
+	}
+	since := time.Now().Add(-s.lookback())
+
+	tasks, err := client.GetClusterTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	candidates := taskClusterEvents(tasks, cluster, since)
+
+	nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, node := range nodes {
+		lines, err := client.GetNodeJournal(ctx, node.NodeName, since.Unix(), clusterEventJournalLastEntries)
+		if err != nil {
+			// 节点离线时跳过，任务列表中的事件不受影响
+			s.logger.WithContext(ctx).Debug("failed to get node journal",
+				zap.String("cluster", cluster.ClusterName), zap.String("node", node.NodeName), zap.Error(err))
+			continue
+		}
+		candidates = append(candidates, journalClusterEvents(lines, cluster, node.NodeName)...)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(candidates))
+	for _, event := range candidates {
+		keys = append(keys, event.SourceKey)
+	}
+	existing, err := s.eventRepo.ExistingKeys(ctx, cluster.Id, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	var vms map[uint32]*model.PveVM
+	events := make([]*model.ClusterEvent, 0)
+	for _, event := range candidates {
+		if existing[event.SourceKey] {
+			continue
+		}
+		existing[event.SourceKey] = true
+		if vms == nil {
+			vms = s.clusterVMs(ctx, cluster.Id)
+		}
+		vm := vms[event.VMID]
+		if vm != nil {
+			event.VmName = vm.VmName
+		}
+		ok, err := s.eventRepo.Create(ctx, event)
+		if err != nil {
+			return events, err
+		}
+		if !ok {
+			// 已由其他实例记录
+			continue
+		}
+		events = append(events, event)
+		s.notify(ctx, cluster, event, vm)
+		// 在 Proxmox 中修改了备注，立即同步，无需等待定期同步
+		if event.Type == model.ClusterEventVMConfigChanged && vm != nil && s.notesService != nil &&
+			strings.Contains(event.Detail, "-description") {
+			if _, err := s.notesService.SyncVM(ctx, vm); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to sync vm notes", zap.Uint32("vmid", vm.VMID), zap.Error(err))
+			}
+		}
+	}
+	return events, nil
+}
+
+func (s *clusterEventService) clusterVMs(ctx context.Context, clusterID int64) map[uint32]*model.PveVM {
+	vms := make(map[uint32]*model.PveVM)
+	list, err := s.vmRepo.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get cluster vms", zap.Int64("cluster_id", clusterID), zap.Error(err))
+		return vms
+	}
+	for _, vm := range list {
+		vms[vm.VMID] = vm
+	}
+	return vms
+}
+
+// notify 成功的创建、删除、迁移和配置修改通知管理员和虚拟机负责人
+func (s *clusterEventService) notify(ctx context.Context, cluster *model.PveCluster, event *model.ClusterEvent, vm *model.PveVM) {
+	action, ok := clusterEventNotifyTypes[event.Type]
+	if !ok || !s.enabled("cluster_event.notify") {
+		return
+	}
+	if event.Source == model.ClusterEventSourceTask && event.Status != "OK" {
+		return
+	}
+	subject := fmt.Sprintf("VM %d", event.VMID)
+	if event.VmName != "" {
+		subject = fmt.Sprintf("VM %s (%d)", event.VmName, event.VMID)
+	}
+	n := &model.Notification{
+		Category:   model.NotificationCategoryAlert,
+		Level:      model.NotificationLevelInfo,
+		Event:      "external_" + event.Type,
+		Title:      fmt.Sprintf("%s %s outside PveSphere", subject, action),
+		Content:    fmt.Sprintf("cluster %s, node %s, by %s", cluster.ClusterName, event.NodeName, event.User),
+		TargetType: "cluster_event",
+		TargetID:   strconv.FormatInt(event.Id, 10),
+	}
+	if event.Type == model.ClusterEventVMDeleted {
+		n.Level = model.NotificationLevelWarning
+	}
+	if event.Detail != "" && event.Source == model.ClusterEventSourceJournal {
+		n.Content += ": " + event.Detail
+	}
+	var also []string
+	if vm != nil && vm.Owner != "" {
+		also = append(also, vm.Owner)
+	}
+	s.notificationService.NotifyAdmins(ctx, n, also...)
+}
+
+// taskClusterEvents 从集群任务列表中提取 since 之后结束、不是由集群 API 用户（即 PveSphere）发起的虚拟机任务
+func taskClusterEvents(tasks []map[string]interface{}, cluster *model.PveCluster, since time.Time) []*model.ClusterEvent {
+	events := make([]*model.ClusterEvent, 0)
+	for _, task := range tasks {
+		taskType, _ := task["type"].(string)
+		eventType, ok := clusterEventTaskTypes[taskType]
+		if !ok {
+			continue
+		}
+		upid, _ := task["upid"].(string)
+		user, _ := task["user"].(string)
+		status, _ := task["status"].(string)
+		end, hasEnd := task["endtime"].(float64)
+		// 只处理已结束的任务，运行中的任务在结束后的下一次轮询中记录
+		if upid == "" || !hasEnd || status == "" || user == cluster.UserId {
+			continue
+		}
+		endTime := time.Unix(int64(end), 0)
+		if endTime.Before(since) {
+			continue
+		}
+		id, _ := task["id"].(string)
+		vmid, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			continue
+		}
+		node, _ := task["node"].(string)
+		events = append(events, &model.ClusterEvent{
+			ClusterID: cluster.Id,
+			SourceKey: clusterEventSourceKey(upid),
+			Source:    model.ClusterEventSourceTask,
+			Type:      eventType,
+			NodeName:  node,
+			VMID:      uint32(vmid),
+			User:      user,
+			UPID:      upid,
+			Status:    status,
+			Detail:    taskType,
+			EventTime: endTime,
+		})
+	}
+	return events
+}
+
+// journalClusterEvents 从节点 journal 中提取不是由集群 API 用户发起的虚拟机配置修改
+func journalClusterEvents(lines []string, cluster *model.PveCluster, nodeName string) []*model.ClusterEvent {
+	events := make([]*model.ClusterEvent, 0)
+	now := time.Now()
+	for _, line := range lines {
+		m := clusterEventJournalPattern.FindStringSubmatch(line)
+		if m == nil || m[1] == cluster.UserId {
+			continue
+		}
+		vmid, err := strconv.ParseUint(m[2], 10, 32)
+		if err != nil {
+			continue
+		}
+		events = append(events, &model.ClusterEvent{
+			ClusterID: cluster.Id,
+			SourceKey: clusterEventSourceKey(nodeName + "\n" + line),
+			Source:    model.ClusterEventSourceJournal,
+			Type:      model.ClusterEventVMConfigChanged,
+			NodeName:  nodeName,
+			VMID:      uint32(vmid),
+			User:      m[1],
+			Detail:    strings.TrimSpace(m[3]),
+			EventTime: journalLineTime(line, now),
+		})
+	}
+	return events
+}
+
+// journalLineTime 解析 journal 行首的时间（"Jan 02 15:04:05"，不含年份），解析失败时使用 now
+func journalLineTime(line string, now time.Time) time.Time {
+	if len(line) < len(time.Stamp) {
+		return now
+	}
+	t, err := time.ParseInLocation(time.Stamp, line[:len(time.Stamp)], time.Local)
+	if err != nil {
+		return now
+	}
+	t = t.AddDate(now.Year(), 0, 0)
+	// 跨年时行首日期晚于当前时间，属于上一年
+	if t.After(now.Add(time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+func clusterEventSourceKey(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *clusterEventService) List(ctx context.Context, req *v1.ListClusterEventsRequest) (*v1.ListClusterEventsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	filter := repository.ClusterEventFilter{
+		ClusterID: req.ClusterID,
+		VMID:      req.VMID,
+		Type:      strings.TrimSpace(req.Type),
+		Since:     req.Since,
+	}
+	events, total, err := s.eventRepo.List(ctx, filter, page, pageSize)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cluster events", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	clusterNames := make(map[int64]string)
+	if clusters, err := s.clusterRepo.List(ctx); err == nil {
+		for _, cluster := range clusters {
+			clusterNames[cluster.Id] = cluster.ClusterName
+		}
+	}
+	list := make([]v1.ClusterEventItem, 0, len(events))
+	for _, event := range events {
+		list = append(list, v1.ClusterEventItem{
+			Id:          event.Id,
+			ClusterID:   event.ClusterID,
+			ClusterName: clusterNames[event.ClusterID],
+			Source:      event.Source,
+			Type:        event.Type,
+			NodeName:    event.NodeName,
+			VMID:        event.VMID,
+			VmName:      event.VmName,
+			User:        event.User,
+			UPID:        event.UPID,
+			Status:      event.Status,
+			Detail:      event.Detail,
+			EventTime:   event.EventTime,
+			CreateTime:  event.CreateTime,
+		})
+	}
+	return &v1.ListClusterEventsResponseData{Total: total, List: list}, nil
+}
+
+func (s *clusterEventService) Cleanup(ctx context.Context) (int64, error) {
+	days := s.conf.GetInt("cluster_event.retention_days")
+	if days <= 0 {
+		days = defaultClusterEventRetentionDays
+	}
+	return s.eventRepo.DeleteBefore(ctx, time.Now().AddDate(0, 0, -days))
+}
@@ -0,0 +1,502 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 控制台录制默认参数，可通过 console_audit.recording.* 调整
+const (
+	defaultConsoleRecordingMaxBytes      = 64 << 20
+	defaultConsoleRecordingRetentionDays = 90
+	consoleRecordingCleanupBatch         = 100
+)
+
+// consoleRecordingMagic 录像文件头。文件头之后每一帧为：
+// 相对会话开始的毫秒数（uint64 大端）+ 帧长度（uint32 大端）+ 帧内容（Proxmox 发往浏览器的原始 websocket 消息）
+const consoleRecordingMagic = "PVSCREC1"
+
+// ConsoleTarget 控制台连接的归属信息，由 Dial 时根据 ws_token 解析，用于会话审计
+type ConsoleTarget struct {
+	SessionID   string
+	UserID      string
+	TargetType  string // vm / node
+	TargetID    int64
+	TargetName  string
+	VMID        uint32
+	ClusterID   int64
+	NodeName    string
+	AppId       string
+	ConsoleType string
+}
+
+type ConsoleAuditService interface {
+	// StartSession 记录控制台会话开始；无法写入审计记录时返回错误，调用方应拒绝连接
+	StartSession(ctx context.Context, target *ConsoleTarget, clientIP, userAgent string) (*ConsoleRecorder, error)
+	ListSessions(ctx context.Context, userID string, req *v1.ListConsoleSessionsRequest) (*v1.ListConsoleSessionsResponseData, error)
+	GetSession(ctx context.Context, userID string, id int64) (*v1.ConsoleSessionItem, error)
+	// GetRecording 读取会话录像，调用方负责关闭返回的 ReadCloser
+	GetRecording(ctx context.Context, userID string, id int64) (io.ReadCloser, *v1.ConsoleSessionItem, error)
+	// CleanupExpiredRecordings 删除超过保留期的录像，返回删除数量
+	CleanupExpiredRecordings(ctx context.Context) (int, error)
+}
+
+func NewConsoleAuditService(
+	service *Service,
+	conf *viper.Viper,
+	sessionRepo repository.ConsoleSessionRepository,
+	clusterRepo repository.PveClusterRepository,
+	transferRepo repository.ImageTransferRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) ConsoleAuditService {
+	return &consoleAuditService{
+		conf:         conf,
+		sessionRepo:  sessionRepo,
+		clusterRepo:  clusterRepo,
+		transferRepo: transferRepo,
+		userRepo:     userRepo,
+		Service:      service,
+		logger:       logger,
+	}
+}
+
+type consoleAuditService struct {
+	conf         *viper.Viper
+	sessionRepo  repository.ConsoleSessionRepository
+	clusterRepo  repository.PveClusterRepository
+	transferRepo repository.ImageTransferRepository
+	userRepo     repository.UserRepository
+	*Service
+	logger *log.Logger
+}
+
+// ConsoleRecorder 单个控制台连接的审计记录器：统计双向流量，开启录制时将 Proxmox -> 浏览器方向的帧写入本地临时文件，
+// 会话结束后上传到对象存储。浏览器 -> Proxmox 方向（键盘、鼠标输入）只计数不保存
+type ConsoleRecorder struct {
+	svc     *consoleAuditService
+	session *model.ConsoleSession
+
+	bytesIn  atomic.Int64
+	bytesOut atomic.Int64
+
+	mu        sync.Mutex
+	spool     *os.File
+	w         *bufio.Writer
+	maxBytes  int64
+	written   int64
+	frames    int64
+	truncated bool
+	closed    bool
+}
+
+// ClientMessage 记录浏览器发往 Proxmox 的消息（只计数，不录制内容）
+func (r *ConsoleRecorder) ClientMessage(data []byte) {
+	r.bytesIn.Add(int64(len(data)))
+}
+
+// ServerMessage 记录 Proxmox 发往浏览器的消息，开启录制时追加到录像
+func (r *ConsoleRecorder) ServerMessage(data []byte) {
+	r.bytesOut.Add(int64(len(data)))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.w == nil || r.truncated || r.closed {
+		return
+	}
+	frameSize := int64(12 + len(data))
+	if r.written+frameSize > r.maxBytes {
+		r.truncated = true
+		return
+	}
+	var head [12]byte
+	binary.BigEndian.PutUint64(head[:8], uint64(time.Since(r.session.StartTime).Milliseconds()))
+	binary.BigEndian.PutUint32(head[8:], uint32(len(data)))
+	if _, err := r.w.Write(head[:]); err != nil {
+		r.truncated = true
+		return
+	}
+	if _, err := r.w.Write(data); err != nil {
+		r.truncated = true
+		return
+	}
+	r.written += frameSize
+	r.frames++
+}
+
+// Close 记录会话结束，录像在后台上传
+func (r *ConsoleRecorder) Close(reason string) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return
+	}
+	r.closed = true
+	frames, truncated := r.frames, r.truncated
+	r.mu.Unlock()
+
+	now := time.Now()
+	session := r.session
+	session.EndTime = &now
+	session.BytesIn = r.bytesIn.Load()
+	session.BytesOut = r.bytesOut.Load()
+	session.CloseReason = truncateConsoleReason(reason)
+	session.RecordingFrames = frames
+	if truncated {
+		session.RecordingTrunc = 1
+	}
+	if err := r.svc.sessionRepo.Update(context.Background(), session); err != nil {
+		r.svc.logger.Error("failed to update console session", zap.Int64("id", session.Id), zap.Error(err))
+	}
+
+	if r.spool != nil {
+		go r.svc.uploadRecording(session, r.spool, r.w)
+	}
+}
+
+func truncateConsoleReason(reason string) string {
+	if len(reason) > 255 {
+		return reason[:255]
+	}
+	return reason
+}
+
+func (s *consoleAuditService) StartSession(ctx context.Context, target *ConsoleTarget, clientIP, userAgent string) (*ConsoleRecorder, error) {
+	session := &model.ConsoleSession{
+		SessionID:       target.SessionID,
+		UserID:          target.UserID,
+		TargetType:      target.TargetType,
+		TargetID:        target.TargetID,
+		TargetName:      target.TargetName,
+		VMID:            target.VMID,
+		ClusterID:       target.ClusterID,
+		NodeName:        target.NodeName,
+		AppId:           target.AppId,
+		ConsoleType:     target.ConsoleType,
+		ClientIP:        clientIP,
+		UserAgent:       userAgent,
+		StartTime:       time.Now(),
+		RecordingStatus: model.ConsoleRecordingStatusNone,
+	}
+	if len(session.UserAgent) > 255 {
+		session.UserAgent = session.UserAgent[:255]
+	}
+	if target.UserID != "" {
+		if user, err := s.userRepo.GetByID(ctx, target.UserID); err == nil && user != nil {
+			session.Username = user.Username
+		}
+	}
+
+	recorder := &ConsoleRecorder{svc: s, session: session}
+	record, err := s.shouldRecord(ctx, target)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to check console recording policy", zap.Error(err))
+		record = true
+	}
+	if record {
+		if err := s.openSpool(recorder); err != nil {
+			s.logger.WithContext(ctx).Error("failed to start console recording", zap.Error(err))
+			session.RecordingStatus = model.ConsoleRecordingStatusFailed
+			session.ErrorMessage = err.Error()
+			if s.conf.GetBool("console_audit.recording.required") {
+				return nil, v1.WithDetail(v1.ErrConsoleAuditUnavailable, err.Error())
+			}
+		}
+	}
+
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create console session", zap.Error(err))
+		recorder.discardSpool()
+		return nil, v1.ErrConsoleAuditUnavailable
+	}
+	s.logger.WithContext(ctx).Info("console session started",
+		zap.Int64("id", session.Id),
+		zap.String("user_id", session.UserID),
+		zap.String("target_type", session.TargetType),
+		zap.Int64("target_id", session.TargetID),
+		zap.String("client_ip", session.ClientIP),
+		zap.String("recording_status", session.RecordingStatus))
+	return recorder, nil
+}
+
+// shouldRecord 集群开启 console_recording，或虚拟机所属应用在 console_audit.recording.app_ids 中时录制
+func (s *consoleAuditService) shouldRecord(ctx context.Context, target *ConsoleTarget) (bool, error) {
+	if target.AppId != "" {
+		for _, appID := range s.conf.GetStringSlice("console_audit.recording.app_ids") {
+			if appID == target.AppId {
+				return true, nil
+			}
+		}
+	}
+	if target.ClusterID <= 0 {
+		return false, nil
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, target.ClusterID)
+	if err != nil {
+		return false, err
+	}
+	return cluster != nil && cluster.ConsoleRecording == 1, nil
+}
+
+// openSpool 检查录像对象存储配置并创建本地临时文件
+func (s *consoleAuditService) openSpool(r *ConsoleRecorder) error {
+	storeID := s.conf.GetInt64("console_audit.recording.object_store_id")
+	if storeID <= 0 {
+		return fmt.Errorf("console_audit.recording.object_store_id is not configured")
+	}
+	maxBytes := s.conf.GetInt64("console_audit.recording.max_bytes")
+	if maxBytes <= 0 {
+		maxBytes = defaultConsoleRecordingMaxBytes
+	}
+	spool, err := os.CreateTemp(s.conf.GetString("console_audit.recording.spool_dir"), "console-*.pvrec")
+	if err != nil {
+		return fmt.Errorf("create recording spool: %w", err)
+	}
+	w := bufio.NewWriter(spool)
+	if _, err := w.WriteString(consoleRecordingMagic); err != nil {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+		return fmt.Errorf("write recording header: %w", err)
+	}
+
+	r.spool, r.w = spool, w
+	r.maxBytes = maxBytes
+	r.written = int64(len(consoleRecordingMagic))
+	r.session.RecordingStatus = model.ConsoleRecordingStatusRecording
+	r.session.RecordingStoreID = storeID
+	return nil
+}
+
+func (r *ConsoleRecorder) discardSpool() {
+	if r.spool != nil {
+		_ = r.spool.Close()
+		_ = os.Remove(r.spool.Name())
+	}
+}
+
+// uploadRecording 将本地录像上传到对象存储并记录保留期限，完成后删除临时文件
+func (s *consoleAuditService) uploadRecording(session *model.ConsoleSession, spool *os.File, w *bufio.Writer) {
+	ctx := context.Background()
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+
+	err := func() error {
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("flush recording: %w", err)
+		}
+		data, err := os.ReadFile(spool.Name())
+		if err != nil {
+			return fmt.Errorf("read recording: %w", err)
+		}
+		store, err := s.transferRepo.GetStoreByID(ctx, session.RecordingStoreID)
+		if err != nil {
+			return fmt.Errorf("get object store: %w", err)
+		}
+		if store == nil {
+			return v1.WithDetailf(v1.ErrObjectStoreNotFound, "id=%d", session.RecordingStoreID)
+		}
+		client, err := newS3Client(store)
+		if err != nil {
+			return err
+		}
+		key := joinObjectKey(store.Prefix, "console-recordings", session.StartTime.Format("2006/01/02"),
+			fmt.Sprintf("%d-%s-%d.pvrec", session.Id, session.TargetType, session.TargetID))
+		if err := client.PutObject(ctx, key, data, "application/octet-stream"); err != nil {
+			return fmt.Errorf("upload recording: %w", err)
+		}
+		session.RecordingKey = key
+		session.RecordingSize = int64(len(data))
+		return nil
+	}()
+
+	if err != nil {
+		s.logger.Error("failed to upload console recording", zap.Int64("id", session.Id), zap.Error(err))
+		session.RecordingStatus = model.ConsoleRecordingStatusFailed
+		session.ErrorMessage = err.Error()
+	} else {
+		retentionDays := s.conf.GetInt("console_audit.recording.retention_days")
+		if retentionDays <= 0 {
+			retentionDays = defaultConsoleRecordingRetentionDays
+		}
+		expireAt := session.StartTime.AddDate(0, 0, retentionDays)
+		session.RecordingStatus = model.ConsoleRecordingStatusUploaded
+		session.RecordingExpireAt = &expireAt
+	}
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		s.logger.Error("failed to update console session", zap.Int64("id", session.Id), zap.Error(err))
+	}
+}
+
+func (s *consoleAuditService) ListSessions(ctx context.Context, userID string, req *v1.ListConsoleSessionsRequest) (*v1.ListConsoleSessionsResponseData, error) {
+	if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "console.audit"); err != nil {
+		return nil, err
+	}
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	sessions, total, err := s.sessionRepo.List(ctx, page, pageSize, repository.ConsoleSessionFilter{
+		UserID:          strings.TrimSpace(req.UserID),
+		TargetType:      req.TargetType,
+		TargetID:        req.TargetID,
+		ClusterID:       req.ClusterID,
+		RecordingStatus: req.RecordingStatus,
+		StartFrom:       req.StartFrom,
+		StartTo:         req.StartTo,
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list console sessions", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.ConsoleSessionItem, 0, len(sessions))
+	for _, session := range sessions {
+		list = append(list, toConsoleSessionItem(session))
+	}
+	return &v1.ListConsoleSessionsResponseData{Total: total, List: list}, nil
+}
+
+func (s *consoleAuditService) GetSession(ctx context.Context, userID string, id int64) (*v1.ConsoleSessionItem, error) {
+	session, err := s.getSession(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	item := toConsoleSessionItem(session)
+	return &item, nil
+}
+
+func (s *consoleAuditService) getSession(ctx context.Context, userID string, id int64) (*model.ConsoleSession, error) {
+	if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "console.audit"); err != nil {
+		return nil, err
+	}
+	session, err := s.sessionRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get console session", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if session == nil {
+		return nil, v1.ErrConsoleSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *consoleAuditService) GetRecording(ctx context.Context, userID string, id int64) (io.ReadCloser, *v1.ConsoleSessionItem, error) {
+	session, err := s.getSession(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session.RecordingStatus != model.ConsoleRecordingStatusUploaded || session.RecordingKey == "" {
+		return nil, nil, v1.WithDetail(v1.ErrConsoleRecordingUnavailable, session.RecordingStatus)
+	}
+
+	store, err := s.transferRepo.GetStoreByID(ctx, session.RecordingStoreID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get object store", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if store == nil {
+		return nil, nil, v1.WithDetailf(v1.ErrObjectStoreNotFound, "id=%d", session.RecordingStoreID)
+	}
+	client, err := newS3Client(store)
+	if err != nil {
+		return nil, nil, v1.WithDetail(v1.ErrObjectStoreUnreachable, err.Error())
+	}
+	body, err := client.GetObject(ctx, session.RecordingKey)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get console recording", zap.Int64("id", id), zap.Error(err))
+		return nil, nil, v1.WithDetail(v1.ErrObjectStoreUnreachable, err.Error())
+	}
+
+	s.logger.WithContext(ctx).Info("console recording downloaded",
+		zap.Int64("id", id), zap.String("user_id", userID))
+	item := toConsoleSessionItem(session)
+	return body, &item, nil
+}
+
+func (s *consoleAuditService) CleanupExpiredRecordings(ctx context.Context) (int, error) {
+	sessions, err := s.sessionRepo.ListExpiredRecordings(ctx, time.Now(), consoleRecordingCleanupBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, session := range sessions {
+		store, err := s.transferRepo.GetStoreByID(ctx, session.RecordingStoreID)
+		if err != nil {
+			return removed, err
+		}
+		if store != nil {
+			client, err := newS3Client(store)
+			if err != nil {
+				s.logger.Warn("failed to create object store client", zap.Int64("store_id", store.Id), zap.Error(err))
+				continue
+			}
+			if err := client.DeleteObject(ctx, session.RecordingKey); err != nil {
+				s.logger.Warn("failed to delete console recording", zap.Int64("id", session.Id), zap.Error(err))
+				continue
+			}
+		}
+		session.RecordingStatus = model.ConsoleRecordingStatusExpired
+		if err := s.sessionRepo.Update(ctx, session); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func toConsoleSessionItem(session *model.ConsoleSession) v1.ConsoleSessionItem {
+	var duration int64
+	if session.EndTime != nil {
+		duration = int64(session.EndTime.Sub(session.StartTime).Seconds())
+	}
+	return v1.ConsoleSessionItem{
+		Id:                 session.Id,
+		SessionID:          session.SessionID,
+		UserID:             session.UserID,
+		Username:           session.Username,
+		TargetType:         session.TargetType,
+		TargetID:           session.TargetID,
+		TargetName:         session.TargetName,
+		VMID:               session.VMID,
+		ClusterID:          session.ClusterID,
+		NodeName:           session.NodeName,
+		AppId:              session.AppId,
+		ConsoleType:        session.ConsoleType,
+		ClientIP:           session.ClientIP,
+		UserAgent:          session.UserAgent,
+		StartTime:          session.StartTime,
+		EndTime:            session.EndTime,
+		DurationSeconds:    duration,
+		BytesIn:            session.BytesIn,
+		BytesOut:           session.BytesOut,
+		CloseReason:        session.CloseReason,
+		RecordingStatus:    session.RecordingStatus,
+		RecordingSize:      session.RecordingSize,
+		RecordingFrames:    session.RecordingFrames,
+		RecordingTruncated: session.RecordingTrunc == 1,
+		RecordingExpireAt:  session.RecordingExpireAt,
+		ErrorMessage:       session.ErrorMessage,
+	}
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 控制台 websocket 代理默认参数，可通过 console.proxy.* 调整
+const (
+	defaultConsoleProxyBufferSize  = 16 << 10
+	defaultConsoleProxyReadLimit   = 8 << 20
+	defaultConsoleProxyIdleTimeout = 30 * time.Minute
+	defaultConsoleProxyMaxSessions = 500
+	defaultConsoleProxyMaxPerIP    = 20
+)
+
+// ConsoleProxyOptions 单个控制台连接的代理参数
+type ConsoleProxyOptions struct {
+	BufferSize  int           // websocket 读写缓冲区大小
+	ReadLimit   int64         // 单条消息的大小上限，超过时断开连接
+	IdleTimeout time.Duration // 浏览器超过该时间没有发送任何消息（键盘、鼠标输入）时断开，0 表示不限制
+}
+
+// ConsoleProxyService 控制台 websocket 代理的连接名额和运行指标。
+// 名额和指标只在当前实例内统计，多实例部署时每个实例分别限制
+type ConsoleProxyService interface {
+	Options() ConsoleProxyOptions
+	// Acquire 占用一个连接名额，超过总数或单 IP 并发上限时返回 ErrConsoleConnectionLimit；连接结束后调用返回的 release
+	Acquire(clientIP string) (release func(), err error)
+	// RecordClientMessage / RecordServerMessage 统计浏览器 -> Proxmox / Proxmox -> 浏览器方向转发的消息
+	RecordClientMessage(size int)
+	RecordServerMessage(size int)
+	RecordIdleTimeout()
+	// GetMetrics 代理运行指标，需要管理员权限
+	GetMetrics(ctx context.Context, userID string) (*v1.ConsoleProxyMetrics, error)
+}
+
+func NewConsoleProxyService(
+	conf *viper.Viper,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) ConsoleProxyService {
+	return &consoleProxyService{
+		conf:     conf,
+		userRepo: userRepo,
+		logger:   logger,
+		perIP:    make(map[string]int),
+	}
+}
+
+type consoleProxyService struct {
+	conf     *viper.Viper
+	userRepo repository.UserRepository
+	logger   *log.Logger
+
+	mu     sync.Mutex
+	active int64
+	peak   int64
+	perIP  map[string]int
+
+	total       atomic.Int64
+	rejected    atomic.Int64
+	idle        atomic.Int64
+	bytesIn     atomic.Int64
+	bytesOut    atomic.Int64
+	messagesIn  atomic.Int64
+	messagesOut atomic.Int64
+}
+
+func (s *consoleProxyService) Options() ConsoleProxyOptions {
+	opts := ConsoleProxyOptions{
+		BufferSize:  s.conf.GetInt("console.proxy.buffer_size"),
+		ReadLimit:   s.conf.GetInt64("console.proxy.read_limit"),
+		IdleTimeout: defaultConsoleProxyIdleTimeout,
+	}
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = defaultConsoleProxyBufferSize
+	}
+	if opts.ReadLimit <= 0 {
+		opts.ReadLimit = defaultConsoleProxyReadLimit
+	}
+	if s.conf.IsSet("console.proxy.idle_timeout") {
+		opts.IdleTimeout = s.conf.GetDuration("console.proxy.idle_timeout")
+	}
+	return opts
+}
+
+// limits 并发上限，未配置时使用默认值，配置为 0 表示不限制
+func (s *consoleProxyService) limits() (maxSessions, maxPerIP int) {
+	maxSessions, maxPerIP = defaultConsoleProxyMaxSessions, defaultConsoleProxyMaxPerIP
+	if s.conf.IsSet("console.proxy.max_sessions") {
+		maxSessions = s.conf.GetInt("console.proxy.max_sessions")
+	}
+	if s.conf.IsSet("console.proxy.max_per_ip") {
+		maxPerIP = s.conf.GetInt("console.proxy.max_per_ip")
+	}
+	return maxSessions, maxPerIP
+}
+
+func (s *consoleProxyService) Acquire(clientIP string) (func(), error) {
+	maxSessions, maxPerIP := s.limits()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if maxSessions > 0 && s.active >= int64(maxSessions) {
+		s.rejected.Add(1)
+		s.logger.Warn("console connection rejected: too many sessions",
+			zap.String("client_ip", clientIP), zap.Int64("active", s.active))
+		return nil, v1.WithDetailf(v1.ErrConsoleConnectionLimit, "max_sessions=%d", maxSessions)
+	}
+	if maxPerIP > 0 && s.perIP[clientIP] >= maxPerIP {
+		s.rejected.Add(1)
+		s.logger.Warn("console connection rejected: too many sessions from client ip",
+			zap.String("client_ip", clientIP), zap.Int("active", s.perIP[clientIP]))
+		return nil, v1.WithDetailf(v1.ErrConsoleConnectionLimit, "max_per_ip=%d", maxPerIP)
+	}
+	s.active++
+	if s.active > s.peak {
+		s.peak = s.active
+	}
+	s.perIP[clientIP]++
+	s.total.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.active--
+			if s.perIP[clientIP]--; s.perIP[clientIP] <= 0 {
+				delete(s.perIP, clientIP)
+			}
+		})
+	}, nil
+}
+
+func (s *consoleProxyService) RecordClientMessage(size int) {
+	s.bytesIn.Add(int64(size))
+	s.messagesIn.Add(1)
+}
+
+func (s *consoleProxyService) RecordServerMessage(size int) {
+	s.bytesOut.Add(int64(size))
+	s.messagesOut.Add(1)
+}
+
+func (s *consoleProxyService) RecordIdleTimeout() {
+	s.idle.Add(1)
+}
+
+func (s *consoleProxyService) GetMetrics(ctx context.Context, userID string) (*v1.ConsoleProxyMetrics, error) {
+	if _, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID); err != nil {
+		return nil, err
+	}
+	opts := s.Options()
+	maxSessions, maxPerIP := s.limits()
+
+	s.mu.Lock()
+	active, peak, ips := s.active, s.peak, len(s.perIP)
+	s.mu.Unlock()
+
+	return &v1.ConsoleProxyMetrics{
+		ActiveSessions:     active,
+		PeakSessions:       peak,
+		ActiveClientIPs:    ips,
+		TotalSessions:      s.total.Load(),
+		RejectedSessions:   s.rejected.Load(),
+		IdleTimeouts:       s.idle.Load(),
+		BytesIn:            s.bytesIn.Load(),
+		BytesOut:           s.bytesOut.Load(),
+		MessagesIn:         s.messagesIn.Load(),
+		MessagesOut:        s.messagesOut.Load(),
+		MaxSessions:        maxSessions,
+		MaxSessionsPerIP:   maxPerIP,
+		ReadLimit:          opts.ReadLimit,
+		BufferSize:         opts.BufferSize,
+		IdleTimeoutSeconds: int64(opts.IdleTimeout / time.Second),
+	}, nil
+}
@@ -0,0 +1,777 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultReplicationConcurrency = 2
+	defaultReplicationTimeout     = 2 * time.Hour
+)
+
+// replicationContentDirs 各内容类型在目录类存储中的子目录
+var replicationContentDirs = map[string]string{
+	v1.ContentReplicationContentISO:    "template/iso",
+	v1.ContentReplicationContentVZTmpl: "template/cache",
+}
+
+type ContentReplicationService interface {
+	// 复制策略（修改仅管理员）
+	CreatePolicy(ctx context.Context, userID string, req *v1.CreateContentReplicationPolicyRequest) (*v1.ContentReplicationPolicyItem, error)
+	UpdatePolicy(ctx context.Context, userID string, id int64, req *v1.UpdateContentReplicationPolicyRequest) (*v1.ContentReplicationPolicyItem, error)
+	DeletePolicy(ctx context.Context, userID string, id int64) error
+	GetPolicy(ctx context.Context, id int64) (*v1.ContentReplicationPolicyItem, error)
+	ListPolicies(ctx context.Context, req *v1.ListContentReplicationPoliciesRequest) (*v1.ListContentReplicationPoliciesResponseData, error)
+
+	// Check 对账并报告各目标缺少的文件，不复制
+	Check(ctx context.Context, id int64) (*v1.ContentReplicationReport, error)
+	// Reconcile 对账并为缺少的文件创建复制任务（仅管理员），不受策略的 auto_copy 限制
+	Reconcile(ctx context.Context, userID string, id int64) (*v1.ContentReplicationReport, error)
+	// ReconcileAll 对账全部启用的策略，auto_copy 的策略同时复制缺少的文件，由 ContentReplicationServer 定期调用
+	ReconcileAll(ctx context.Context) error
+	ListCopies(ctx context.Context, req *v1.ListContentReplicationCopiesRequest) (*v1.ListContentReplicationCopiesResponseData, error)
+}
+
+func NewContentReplicationService(
+	service *Service,
+	conf *viper.Viper,
+	replicationRepo repository.ContentReplicationRepository,
+	clusterRepo repository.PveClusterRepository,
+	storageRepo repository.PveStorageRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) ContentReplicationService {
+	concurrency := conf.GetInt("content_replication.concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultReplicationConcurrency
+	}
+	return &contentReplicationService{
+		Service:         service,
+		conf:            conf,
+		replicationRepo: replicationRepo,
+		clusterRepo:     clusterRepo,
+		storageRepo:     storageRepo,
+		userRepo:        userRepo,
+		logger:          logger,
+		slots:           make(chan struct{}, concurrency),
+	}
+}
+
+type contentReplicationService struct {
+	*Service
+	conf            *viper.Viper
+	replicationRepo repository.ContentReplicationRepository
+	clusterRepo     repository.PveClusterRepository
+	storageRepo     repository.PveStorageRepository
+	userRepo        repository.UserRepository
+	logger          *log.Logger
+
+	// 限制同时执行的复制任务数，其余任务保持 pending 排队
+	slots chan struct{}
+}
+
+func (s *contentReplicationService) timeout() time.Duration {
+	if timeout := s.conf.GetDuration("content_replication.timeout"); timeout > 0 {
+		return timeout
+	}
+	return defaultReplicationTimeout
+}
+
+// sourceDir 返回源存储在本机的挂载目录（viper 键不区分大小写）
+func (s *contentReplicationService) sourceDir(storage string) string {
+	return s.conf.GetStringMapString("content_replication.source_dirs")[strings.ToLower(storage)]
+}
+
+func (s *contentReplicationService) CreatePolicy(ctx context.Context, userID string, req *v1.CreateContentReplicationPolicyRequest) (*v1.ContentReplicationPolicyItem, error) {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	policy := &model.ContentReplicationPolicy{
+		Name:           strings.TrimSpace(req.Name),
+		ClusterID:      req.ClusterID,
+		Content:        req.Content,
+		SourceStorage:  strings.TrimSpace(req.SourceStorage),
+		SourceNode:     strings.TrimSpace(req.SourceNode),
+		Pattern:        strings.TrimSpace(req.Pattern),
+		TargetStorages: strings.Join(normalizeStorageNames(req.TargetStorages), ","),
+		AutoCopy:       1,
+		Enabled:        1,
+		Description:    req.Description,
+		Status:         model.ContentReplicationStatusUnknown,
+		Creator:        username,
+		Modifier:       username,
+	}
+	if req.AutoCopy != nil && !*req.AutoCopy {
+		policy.AutoCopy = 0
+	}
+	if req.Enabled != nil && !*req.Enabled {
+		policy.Enabled = 0
+	}
+	if err := s.validatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	if err := s.replicationRepo.CreatePolicy(ctx, policy); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create content replication policy", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("content replication policy created",
+		zap.Int64("policy_id", policy.Id), zap.String("name", policy.Name), zap.String("operator", username))
+	return s.GetPolicy(ctx, policy.Id)
+}
+
+func (s *contentReplicationService) UpdatePolicy(ctx context.Context, userID string, id int64, req *v1.UpdateContentReplicationPolicyRequest) (*v1.ContentReplicationPolicyItem, error) {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.getPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	changed := false
+	if req.Name != nil {
+		policy.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.SourceStorage != nil {
+		changed = changed || policy.SourceStorage != strings.TrimSpace(*req.SourceStorage)
+		policy.SourceStorage = strings.TrimSpace(*req.SourceStorage)
+	}
+	if req.SourceNode != nil {
+		changed = changed || policy.SourceNode != strings.TrimSpace(*req.SourceNode)
+		policy.SourceNode = strings.TrimSpace(*req.SourceNode)
+	}
+	if req.Pattern != nil {
+		changed = changed || policy.Pattern != strings.TrimSpace(*req.Pattern)
+		policy.Pattern = strings.TrimSpace(*req.Pattern)
+	}
+	if req.TargetStorages != nil {
+		targets := strings.Join(normalizeStorageNames(req.TargetStorages), ",")
+		changed = changed || policy.TargetStorages != targets
+		policy.TargetStorages = targets
+	}
+	if req.AutoCopy != nil {
+		policy.AutoCopy = boolToInt8(*req.AutoCopy)
+	}
+	if req.Enabled != nil {
+		policy.Enabled = boolToInt8(*req.Enabled)
+	}
+	if req.Description != nil {
+		policy.Description = *req.Description
+	}
+	if err := s.validatePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	// 源或目标变化后之前的对账结果不再有效
+	if changed {
+		policy.Status = model.ContentReplicationStatusUnknown
+		policy.MissingCount = 0
+		policy.LastError = ""
+	}
+	policy.Modifier = username
+	if err := s.replicationRepo.UpdatePolicy(ctx, policy); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update content replication policy", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return s.GetPolicy(ctx, policy.Id)
+}
+
+func (s *contentReplicationService) DeletePolicy(ctx context.Context, userID string, id int64) error {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return err
+	}
+	if _, err := s.getPolicy(ctx, id); err != nil {
+		return err
+	}
+	if err := s.replicationRepo.DeletePolicy(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete content replication policy", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("content replication policy deleted", zap.Int64("policy_id", id), zap.String("operator", username))
+	return nil
+}
+
+func (s *contentReplicationService) getPolicy(ctx context.Context, id int64) (*model.ContentReplicationPolicy, error) {
+	policy, err := s.replicationRepo.GetPolicyByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get content replication policy", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if policy == nil {
+		return nil, v1.ErrContentReplicationPolicyNotFound
+	}
+	return policy, nil
+}
+
+// validatePolicy 检查名称唯一、源存储和目标存储存在且允许该内容类型
+func (s *contentReplicationService) validatePolicy(ctx context.Context, policy *model.ContentReplicationPolicy) error {
+	if policy.Name == "" {
+		return v1.WithDetail(v1.ErrContentReplicationPolicyInvalid, "name is required")
+	}
+	if existing, err := s.replicationRepo.GetPolicyByName(ctx, policy.Name); err != nil {
+		s.logger.WithContext(ctx).Error("failed to get content replication policy", zap.Error(err))
+		return v1.ErrInternalServerError
+	} else if existing != nil && existing.Id != policy.Id {
+		return v1.ErrContentReplicationPolicyExists
+	}
+	if _, err := path.Match(policy.Pattern, ""); err != nil {
+		return v1.WithDetailf(v1.ErrContentReplicationPolicyInvalid, "invalid pattern %q", policy.Pattern)
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, policy.ClusterID)
+	if err != nil || cluster == nil {
+		return v1.ErrClusterNotFound
+	}
+
+	source, err := s.storageNodes(ctx, policy.ClusterID, policy.SourceStorage, policy.Content)
+	if err != nil {
+		return err
+	}
+	if policy.SourceNode != "" && !containsStorageNode(source, policy.SourceNode) {
+		return v1.WithDetailf(v1.ErrContentReplicationPolicyInvalid, "storage %s is not available on node %s", policy.SourceStorage, policy.SourceNode)
+	}
+	targets := splitStorageNames(policy.TargetStorages)
+	if len(targets) == 0 {
+		return v1.WithDetail(v1.ErrContentReplicationPolicyInvalid, "target_storages is required")
+	}
+	for _, name := range targets {
+		rows, err := s.storageNodes(ctx, policy.ClusterID, name, policy.Content)
+		if err != nil {
+			return err
+		}
+		// 共享存储复制到自身没有意义；节点本地存储可以作为目标，复制到其他节点的同名存储
+		if name == policy.SourceStorage && rows[0].Shared == 1 {
+			return v1.WithDetailf(v1.ErrContentReplicationPolicyInvalid, "shared storage %s cannot be both source and target", name)
+		}
+	}
+	return nil
+}
+
+// storageNodes 返回集群中启用了该存储的节点记录，存储不存在或不允许该内容类型时返回错误
+func (s *contentReplicationService) storageNodes(ctx context.Context, clusterID int64, storage, content string) ([]*model.PveStorage, error) {
+	rows, err := s.storageRepo.ListByStorageName(ctx, clusterID, storage)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list storages", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	enabled := make([]*model.PveStorage, 0, len(rows))
+	for _, row := range rows {
+		if row.Enabled == 1 {
+			enabled = append(enabled, row)
+		}
+	}
+	if len(enabled) == 0 {
+		return nil, v1.WithDetailf(v1.ErrContentReplicationPolicyInvalid, "storage %s not found in cluster", storage)
+	}
+	if !storageAllowsContent(enabled[0].Content, content) {
+		return nil, v1.WithDetailf(v1.ErrContentReplicationPolicyInvalid, "storage %s does not allow %s content", storage, content)
+	}
+	return enabled, nil
+}
+
+func storageAllowsContent(contents, content string) bool {
+	for _, c := range strings.Split(contents, ",") {
+		if strings.TrimSpace(c) == content {
+			return true
+		}
+	}
+	return false
+}
+
+func containsStorageNode(rows []*model.PveStorage, node string) bool {
+	for _, row := range rows {
+		if row.NodeName == node {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeStorageNames 去除空白和重复的存储名称，保持原有顺序
+func normalizeStorageNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+func splitStorageNames(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+func (s *contentReplicationService) GetPolicy(ctx context.Context, id int64) (*v1.ContentReplicationPolicyItem, error) {
+	policy, err := s.getPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	items := s.toPolicyItems(ctx, []*model.ContentReplicationPolicy{policy})
+	return &items[0], nil
+}
+
+func (s *contentReplicationService) ListPolicies(ctx context.Context, req *v1.ListContentReplicationPoliciesRequest) (*v1.ListContentReplicationPoliciesResponseData, error) {
+	policies, err := s.replicationRepo.ListPolicies(ctx, req.ClusterID, false)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list content replication policies", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return &v1.ListContentReplicationPoliciesResponseData{List: s.toPolicyItems(ctx, policies)}, nil
+}
+
+func (s *contentReplicationService) toPolicyItems(ctx context.Context, policies []*model.ContentReplicationPolicy) []v1.ContentReplicationPolicyItem {
+	clusterIDs := make([]int64, 0, len(policies))
+	for _, policy := range policies {
+		clusterIDs = append(clusterIDs, policy.ClusterID)
+	}
+	clusters, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get clusters", zap.Error(err))
+	}
+	items := make([]v1.ContentReplicationPolicyItem, 0, len(policies))
+	for _, policy := range policies {
+		item := v1.ContentReplicationPolicyItem{
+			Id:             policy.Id,
+			Name:           policy.Name,
+			ClusterID:      policy.ClusterID,
+			Content:        policy.Content,
+			SourceStorage:  policy.SourceStorage,
+			SourceNode:     policy.SourceNode,
+			Pattern:        policy.Pattern,
+			TargetStorages: splitStorageNames(policy.TargetStorages),
+			AutoCopy:       policy.AutoCopy == 1,
+			Enabled:        policy.Enabled == 1,
+			Description:    policy.Description,
+			Status:         policy.Status,
+			MissingCount:   policy.MissingCount,
+			LastCheckTime:  policy.LastCheckTime,
+			LastError:      policy.LastError,
+			Creator:        policy.Creator,
+			Modifier:       policy.Modifier,
+			CreateTime:     policy.CreateTime,
+			UpdateTime:     policy.UpdateTime,
+		}
+		if cluster, ok := clusters[policy.ClusterID]; ok {
+			item.ClusterName = cluster.ClusterName
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+func (s *contentReplicationService) Check(ctx context.Context, id int64) (*v1.ContentReplicationReport, error) {
+	policy, err := s.getPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.reconcile(ctx, policy, false, "")
+}
+
+func (s *contentReplicationService) Reconcile(ctx context.Context, userID string, id int64) (*v1.ContentReplicationReport, error) {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.getPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.reconcile(ctx, policy, true, username)
+}
+
+func (s *contentReplicationService) ReconcileAll(ctx context.Context) error {
+	policies, err := s.replicationRepo.ListPolicies(ctx, 0, true)
+	if err != nil {
+		return err
+	}
+	for _, policy := range policies {
+		report, err := s.reconcile(ctx, policy, policy.AutoCopy == 1, "system")
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("content replication reconcile failed", zap.Int64("policy_id", policy.Id), zap.Error(err))
+			continue
+		}
+		if report.MissingCount > 0 || report.Status == model.ContentReplicationStatusError {
+			s.logger.WithContext(ctx).Info("content replication diverged",
+				zap.String("policy", policy.Name), zap.String("status", report.Status),
+				zap.Int("missing", report.MissingCount), zap.Int("queued", report.QueuedCopies))
+		}
+	}
+	return nil
+}
+
+// reconcile 比较源存储和各目标存储的内容，更新策略的对账结果；copyMissing 为 true 时为缺少的文件创建复制任务
+func (s *contentReplicationService) reconcile(ctx context.Context, policy *model.ContentReplicationPolicy, copyMissing bool, creator string) (*v1.ContentReplicationReport, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, policy.ClusterID)
+	if err != nil || cluster == nil {
+		return nil, v1.ErrClusterNotFound
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	report := &v1.ContentReplicationReport{
+		PolicyID:      policy.Id,
+		SourceStorage: policy.SourceStorage,
+		SourceFiles:   []v1.ContentReplicationFile{},
+		Targets:       []v1.ContentReplicationTarget{},
+		CheckTime:     time.Now(),
+	}
+	defer s.saveReport(ctx, policy, report)
+
+	// 1. 源存储的文件
+	sourceRows, err := s.storageNodes(ctx, policy.ClusterID, policy.SourceStorage, policy.Content)
+	if err != nil {
+		report.Status, report.Error = model.ContentReplicationStatusError, err.Error()
+		return report, nil
+	}
+	var sourceErr error
+	for _, row := range sourceRows {
+		if policy.SourceNode != "" && row.NodeName != policy.SourceNode {
+			continue
+		}
+		var files map[string]int64
+		if files, sourceErr = s.listFiles(ctx, client, row.NodeName, policy.SourceStorage, policy.Content); sourceErr != nil {
+			continue
+		}
+		report.SourceNode = row.NodeName
+		for name, size := range files {
+			if matched, _ := path.Match(policy.Pattern, name); policy.Pattern == "" || matched {
+				report.SourceFiles = append(report.SourceFiles, v1.ContentReplicationFile{FileName: name, Size: size})
+			}
+		}
+		break
+	}
+	if report.SourceNode == "" {
+		if sourceErr == nil {
+			sourceErr = fmt.Errorf("storage %s is not available on node %s", policy.SourceStorage, policy.SourceNode)
+		}
+		report.Status, report.Error = model.ContentReplicationStatusError, v1.WithDetail(v1.ErrContentReplicationSourceFailed, sourceErr.Error()).Error()
+		return report, nil
+	}
+	sort.Slice(report.SourceFiles, func(i, j int) bool { return report.SourceFiles[i].FileName < report.SourceFiles[j].FileName })
+
+	// 2. 进行中的复制任务，超过任务超时仍未结束的视为已中断
+	active, err := s.replicationRepo.ListActiveCopies(ctx, policy.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list content replication copies", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	inProgress := make(map[string]bool, len(active))
+	for _, c := range active {
+		if time.Since(c.UpdateTime) > s.timeout() {
+			end := time.Now()
+			c.Status, c.EndTime, c.ErrorMessage = model.ContentReplicationCopyFailed, &end, "copy interrupted"
+			s.saveCopy(ctx, c)
+			continue
+		}
+		inProgress[replicationCopyKey(c.TargetNode, c.TargetStorage, c.FileName)] = true
+	}
+
+	// 3. 各目标存储缺少的文件
+	targetErrors := 0
+	var queue []*model.ContentReplicationCopy
+	for _, target := range s.replicationTargets(ctx, policy, report.SourceNode) {
+		if target.Error == "" {
+			existing, err := s.listFiles(ctx, client, target.NodeName, target.StorageName, policy.Content)
+			if err != nil {
+				target.Error = err.Error()
+			}
+			for _, file := range report.SourceFiles {
+				if err != nil {
+					break
+				}
+				if _, ok := existing[file.FileName]; ok {
+					continue
+				}
+				if inProgress[replicationCopyKey(target.NodeName, target.StorageName, file.FileName)] {
+					target.Copying = append(target.Copying, file.FileName)
+				} else {
+					target.Missing = append(target.Missing, file)
+					queue = append(queue, &model.ContentReplicationCopy{
+						PolicyID: policy.Id, ClusterID: policy.ClusterID, Content: policy.Content, FileName: file.FileName,
+						SourceNode: report.SourceNode, SourceStorage: policy.SourceStorage,
+						TargetNode: target.NodeName, TargetStorage: target.StorageName,
+						Status: model.ContentReplicationCopyPending, TotalBytes: file.Size, Creator: creator,
+					})
+				}
+				report.MissingCount++
+			}
+		}
+		if target.Error != "" {
+			targetErrors++
+		}
+		report.Targets = append(report.Targets, target)
+	}
+	switch {
+	case targetErrors > 0:
+		report.Status = model.ContentReplicationStatusError
+	case report.MissingCount > 0:
+		report.Status = model.ContentReplicationStatusDiverged
+	default:
+		report.Status = model.ContentReplicationStatusInSync
+	}
+
+	// 4. 复制缺少的文件，源存储需要挂载在本机
+	if !copyMissing || len(queue) == 0 {
+		return report, nil
+	}
+	if s.sourceDir(policy.SourceStorage) == "" {
+		report.Error = v1.WithDetailf(v1.ErrContentReplicationSourceFailed,
+			"storage %s is not mounted on this host (content_replication.source_dirs)", policy.SourceStorage).Error()
+		return report, nil
+	}
+	for _, c := range queue {
+		if err := s.replicationRepo.CreateCopy(ctx, c); err != nil {
+			s.logger.WithContext(ctx).Error("failed to create content replication copy", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		report.QueuedCopies++
+		go s.executeCopy(c.Id)
+	}
+	s.logger.WithContext(ctx).Info("content replication copies queued",
+		zap.String("policy", policy.Name), zap.Int("count", report.QueuedCopies))
+	return report, nil
+}
+
+// replicationTargets 展开目标存储：共享存储取一个节点，节点本地存储取每个节点（源节点上的源存储除外）
+func (s *contentReplicationService) replicationTargets(ctx context.Context, policy *model.ContentReplicationPolicy, sourceNode string) []v1.ContentReplicationTarget {
+	var targets []v1.ContentReplicationTarget
+	for _, name := range splitStorageNames(policy.TargetStorages) {
+		rows, err := s.storageNodes(ctx, policy.ClusterID, name, policy.Content)
+		if err != nil {
+			targets = append(targets, v1.ContentReplicationTarget{StorageName: name, Error: err.Error()})
+			continue
+		}
+		if rows[0].Shared == 1 {
+			// 优先使用源节点访问共享存储
+			row := rows[0]
+			for _, r := range rows {
+				if r.NodeName == sourceNode {
+					row = r
+				}
+			}
+			targets = append(targets, v1.ContentReplicationTarget{NodeName: row.NodeName, StorageName: name, Shared: true})
+			continue
+		}
+		for _, row := range rows {
+			if name == policy.SourceStorage && row.NodeName == sourceNode {
+				continue
+			}
+			targets = append(targets, v1.ContentReplicationTarget{NodeName: row.NodeName, StorageName: name})
+		}
+	}
+	for i := range targets {
+		targets[i].Missing = []v1.ContentReplicationFile{}
+		targets[i].Copying = []string{}
+	}
+	return targets
+}
+
+// listFiles 列出存储上某类内容的文件，返回 文件名 -> 大小
+func (s *contentReplicationService) listFiles(ctx context.Context, client *proxmox.ProxmoxClient, node, storage, content string) (map[string]int64, error) {
+	volumes, err := client.GetStorageContent(ctx, node, storage, content)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]int64, len(volumes))
+	for _, volume := range volumes {
+		volid, _ := volume["volid"].(string)
+		_, rest, _ := strings.Cut(volid, ":")
+		_, name, ok := strings.Cut(rest, "/")
+		if !ok || name == "" {
+			continue
+		}
+		size, _ := volume["size"].(float64)
+		files[name] = int64(size)
+	}
+	return files, nil
+}
+
+func replicationCopyKey(node, storage, file string) string {
+	return node + "/" + storage + "/" + file
+}
+
+func (s *contentReplicationService) saveReport(ctx context.Context, policy *model.ContentReplicationPolicy, report *v1.ContentReplicationReport) {
+	checkTime := report.CheckTime
+	policy.Status = report.Status
+	policy.MissingCount = report.MissingCount
+	policy.LastCheckTime = &checkTime
+	policy.LastError = report.Error
+	for _, target := range report.Targets {
+		if target.Error != "" && policy.LastError == "" {
+			policy.LastError = fmt.Sprintf("%s on %s: %s", target.StorageName, target.NodeName, target.Error)
+		}
+	}
+	if err := s.replicationRepo.UpdatePolicy(ctx, policy); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save content replication result", zap.Int64("policy_id", policy.Id), zap.Error(err))
+	}
+}
+
+func (s *contentReplicationService) saveCopy(ctx context.Context, c *model.ContentReplicationCopy) {
+	if err := s.replicationRepo.UpdateCopy(ctx, c); err != nil {
+		s.logger.Error("failed to update content replication copy", zap.Int64("copy_id", c.Id), zap.Error(err))
+	}
+}
+
+// executeCopy 从本机挂载的源存储目录读取文件并上传到目标存储
+func (s *contentReplicationService) executeCopy(copyID int64) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout())
+	defer cancel()
+
+	// 多实例部署时同一任务只由领取成功的实例执行
+	claimed, err := s.replicationRepo.ClaimCopy(ctx, copyID)
+	if err != nil || !claimed {
+		if err != nil {
+			s.logger.Error("failed to claim content replication copy", zap.Int64("copy_id", copyID), zap.Error(err))
+		}
+		return
+	}
+	c, err := s.replicationRepo.GetCopyByID(ctx, copyID)
+	if err != nil || c == nil {
+		s.logger.Error("failed to load content replication copy", zap.Int64("copy_id", copyID), zap.Error(err))
+		return
+	}
+	start := time.Now()
+	c.StartTime = &start
+	s.saveCopy(ctx, c)
+
+	err = s.runCopy(ctx, c)
+
+	// 任务上下文可能已超时，最终状态使用新的上下文保存
+	end := time.Now()
+	c.EndTime = &end
+	if err != nil {
+		s.logger.Error("content replication copy failed", zap.Int64("copy_id", c.Id), zap.String("file", c.FileName),
+			zap.String("target", c.TargetNode+"/"+c.TargetStorage), zap.Error(err))
+		c.Status = model.ContentReplicationCopyFailed
+		c.ErrorMessage = err.Error()
+	} else {
+		c.Status = model.ContentReplicationCopyCompleted
+		c.Progress = 100
+		c.TransferredBytes = c.TotalBytes
+		s.logger.Info("content replication copy completed", zap.Int64("copy_id", c.Id), zap.String("file", c.FileName),
+			zap.String("target", c.TargetNode+"/"+c.TargetStorage), zap.Int64("bytes", c.TotalBytes))
+	}
+	s.saveCopy(context.Background(), c)
+}
+
+func (s *contentReplicationService) runCopy(ctx context.Context, c *model.ContentReplicationCopy) error {
+	dir := s.sourceDir(c.SourceStorage)
+	if dir == "" {
+		return fmt.Errorf("storage %s is not mounted on this host (content_replication.source_dirs)", c.SourceStorage)
+	}
+	// 文件名来自 Proxmox 的内容列表，仍然拒绝可能跳出源目录的名称
+	if c.FileName != filepath.Base(c.FileName) || strings.HasPrefix(c.FileName, ".") {
+		return fmt.Errorf("invalid file name %q", c.FileName)
+	}
+	file, err := os.Open(filepath.Join(dir, replicationContentDirs[c.Content], c.FileName))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	c.TotalBytes = info.Size()
+
+	cluster, err := s.clusterRepo.GetByID(ctx, c.ClusterID)
+	if err != nil || cluster == nil {
+		return fmt.Errorf("cluster %d not found", c.ClusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return err
+	}
+
+	lastProgress := 0
+	onUpload := func(uploaded int64) {
+		progress := 0
+		if c.TotalBytes > 0 {
+			progress = min(int(uploaded*100/c.TotalBytes), 99)
+		}
+		if progress-lastProgress < 5 {
+			return
+		}
+		lastProgress = progress
+		c.TransferredBytes, c.Progress = uploaded, progress
+		s.saveCopy(ctx, c)
+	}
+	result, err := client.UploadStorageContentStream(ctx, c.TargetNode, c.TargetStorage, c.Content, c.FileName, file, c.TotalBytes, onUpload)
+	if err != nil {
+		return err
+	}
+	// upload 返回的是把临时文件移动到存储的任务，需要等待其完成后文件才可用
+	if upid, ok := result.(string); ok && upid != "" {
+		return client.WaitForTask(ctx, c.TargetNode, upid, s.timeout())
+	}
+	return nil
+}
+
+func (s *contentReplicationService) ListCopies(ctx context.Context, req *v1.ListContentReplicationCopiesRequest) (*v1.ListContentReplicationCopiesResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	copies, total, err := s.replicationRepo.ListCopies(ctx, page, pageSize, req.PolicyID, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list content replication copies", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	items := make([]v1.ContentReplicationCopyItem, 0, len(copies))
+	for _, c := range copies {
+		items = append(items, v1.ContentReplicationCopyItem{
+			Id:               c.Id,
+			PolicyID:         c.PolicyID,
+			ClusterID:        c.ClusterID,
+			Content:          c.Content,
+			FileName:         c.FileName,
+			SourceNode:       c.SourceNode,
+			SourceStorage:    c.SourceStorage,
+			TargetNode:       c.TargetNode,
+			TargetStorage:    c.TargetStorage,
+			Status:           c.Status,
+			TotalBytes:       c.TotalBytes,
+			TransferredBytes: c.TransferredBytes,
+			Progress:         c.Progress,
+			StartTime:        c.StartTime,
+			EndTime:          c.EndTime,
+			ErrorMessage:     c.ErrorMessage,
+			Creator:          c.Creator,
+			CreateTime:       c.CreateTime,
+		})
+	}
+	return &v1.ListContentReplicationCopiesResponseData{Total: total, List: items}, nil
+}
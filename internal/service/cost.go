@@ -0,0 +1,789 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 cost.currency 时使用的币种
+const defaultCostCurrency = "CNY"
+
+type CostService interface {
+	ListPrices(ctx context.Context) (*v1.ListCostPricesResponseData, error)
+	SetPrice(ctx context.Context, userID string, req *v1.SetCostPriceRequest) error
+	DeletePrice(ctx context.Context, userID string, id int64) error
+	GetReport(ctx context.Context, req *v1.GetCostReportRequest) (*v1.CostReportData, error)
+	ExportReportCSV(ctx context.Context, req *v1.GetCostReportRequest) ([]byte, error)
+	CreateBudget(ctx context.Context, userID string, req *v1.CreateCostBudgetRequest) error
+	UpdateBudget(ctx context.Context, userID string, id int64, req *v1.UpdateCostBudgetRequest) error
+	DeleteBudget(ctx context.Context, userID string, id int64) error
+	ListBudgets(ctx context.Context) (*v1.ListCostBudgetsResponseData, error)
+	ListAlerts(ctx context.Context, req *v1.ListCostAlertsRequest) (*v1.ListCostAlertsResponseData, error)
+	// CollectUsage 采集当前虚拟机资源分配，按采集间隔折算为用量累加到当天
+	CollectUsage(ctx context.Context, interval time.Duration) error
+	// CheckBudgets 检查本月各应用预算，达到告警阈值或超出预算时记录告警并通知
+	CheckBudgets(ctx context.Context) error
+}
+
+func NewCostService(
+	service *Service,
+	conf *viper.Viper,
+	costRepo repository.CostRepository,
+	clusterRepo repository.PveClusterRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationService,
+	logger *log.Logger,
+) CostService {
+	return &costService{
+		conf:                conf,
+		costRepo:            costRepo,
+		clusterRepo:         clusterRepo,
+		vmRepo:              vmRepo,
+		userRepo:            userRepo,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+		httpClient:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type costService struct {
+	conf        *viper.Viper
+	costRepo    repository.CostRepository
+	clusterRepo repository.PveClusterRepository
+	vmRepo      repository.PveVMRepository
+	userRepo    repository.UserRepository
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+	httpClient          *http.Client // 预算告警 webhook
+}
+
+// costAmount 一组用量及按价格模型计算出的费用
+type costAmount struct {
+	vcpuHours       float64
+	ramGbHours      float64
+	storageGbMonths float64
+	cpuCost         float64
+	ramCost         float64
+	storageCost     float64
+}
+
+func (a *costAmount) add(o costAmount) {
+	a.vcpuHours += o.vcpuHours
+	a.ramGbHours += o.ramGbHours
+	a.storageGbMonths += o.storageGbMonths
+	a.cpuCost += o.cpuCost
+	a.ramCost += o.ramCost
+	a.storageCost += o.storageCost
+}
+
+func (a *costAmount) total() float64 {
+	return a.cpuCost + a.ramCost + a.storageCost
+}
+
+func (s *costService) currency() string {
+	if c := s.conf.GetString("cost.currency"); c != "" {
+		return c
+	}
+	return defaultCostCurrency
+}
+
+func (s *costService) ListPrices(ctx context.Context) (*v1.ListCostPricesResponseData, error) {
+	prices, err := s.costRepo.ListPrices(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cost prices", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	clusterIDs := make([]int64, 0, len(prices))
+	for _, p := range prices {
+		if p.ClusterID > 0 {
+			clusterIDs = append(clusterIDs, p.ClusterID)
+		}
+	}
+	clusterMap, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.CostPriceItem, 0, len(prices))
+	for _, p := range prices {
+		item := v1.CostPriceItem{
+			Id:                  p.Id,
+			ClusterID:           p.ClusterID,
+			VcpuHourPrice:       p.VcpuHourPrice,
+			RamGbHourPrice:      p.RamGbHourPrice,
+			StorageGbMonthPrice: p.StorageGbMonthPrice,
+			Describes:           p.Describes,
+			Creator:             p.Creator,
+			Modifier:            p.Modifier,
+			CreateTime:          p.CreateTime,
+			UpdateTime:          p.UpdateTime,
+		}
+		if cluster, ok := clusterMap[p.ClusterID]; ok {
+			item.ClusterName = cluster.ClusterName
+		}
+		list = append(list, item)
+	}
+	return &v1.ListCostPricesResponseData{Currency: s.currency(), List: list}, nil
+}
+
+func (s *costService) SetPrice(ctx context.Context, userID string, req *v1.SetCostPriceRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "cost.manage")
+	if err != nil {
+		return err
+	}
+
+	if req.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+		}
+	}
+
+	price, err := s.costRepo.GetPriceByClusterID(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cost price", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if price == nil {
+		price = &model.CostPriceModel{ClusterID: req.ClusterID, Creator: username}
+	}
+	price.VcpuHourPrice = req.VcpuHourPrice
+	price.RamGbHourPrice = req.RamGbHourPrice
+	price.StorageGbMonthPrice = req.StorageGbMonthPrice
+	price.Describes = req.Describes
+	price.Modifier = username
+
+	if price.Id == 0 {
+		err = s.costRepo.CreatePrice(ctx, price)
+	} else {
+		err = s.costRepo.UpdatePrice(ctx, price)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to save cost price", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("cost price saved",
+		zap.Int64("cluster_id", price.ClusterID), zap.String("operator", username))
+	return nil
+}
+
+func (s *costService) DeletePrice(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "cost.manage")
+	if err != nil {
+		return err
+	}
+
+	price, err := s.costRepo.GetPriceByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cost price", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if price == nil {
+		return v1.ErrNotFound
+	}
+
+	if err := s.costRepo.DeletePrice(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete cost price", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("cost price deleted",
+		zap.Int64("cluster_id", price.ClusterID), zap.String("operator", username))
+	return nil
+}
+
+func (s *costService) GetReport(ctx context.Context, req *v1.GetCostReportRequest) (*v1.CostReportData, error) {
+	start, end, err := parseReportMonth(req.Month)
+	if err != nil {
+		return nil, err
+	}
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = v1.CostGroupByApp
+	}
+
+	usages, err := s.costRepo.ListUsage(ctx, start.Format(time.DateOnly), end.AddDate(0, 0, -1).Format(time.DateOnly), req.ClusterID, req.AppId, req.CostCenter)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cost usage", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	prices, err := s.loadPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	monthHours := end.Sub(start).Hours()
+	byApp := make(map[string]*costAmount)
+	byCluster := make(map[int64]*costAmount)
+	byCostCenter := make(map[string]*costAmount)
+	for _, u := range usages {
+		amount := calculateCost(u, priceForCluster(prices, u.ClusterID), monthHours)
+		switch groupBy {
+		case v1.CostGroupByCluster:
+			if byCluster[u.ClusterID] == nil {
+				byCluster[u.ClusterID] = &costAmount{}
+			}
+			byCluster[u.ClusterID].add(amount)
+		case v1.CostGroupByCostCenter:
+			if byCostCenter[u.CostCenter] == nil {
+				byCostCenter[u.CostCenter] = &costAmount{}
+			}
+			byCostCenter[u.CostCenter].add(amount)
+		default:
+			if byApp[u.AppId] == nil {
+				byApp[u.AppId] = &costAmount{}
+			}
+			byApp[u.AppId].add(amount)
+		}
+	}
+
+	data := &v1.CostReportData{
+		Month:    start.Format("2006-01"),
+		Currency: s.currency(),
+		GroupBy:  groupBy,
+		Items:    make([]v1.CostReportItem, 0),
+	}
+	switch groupBy {
+	case v1.CostGroupByCluster:
+		clusterIDs := make([]int64, 0, len(byCluster))
+		for id := range byCluster {
+			clusterIDs = append(clusterIDs, id)
+		}
+		clusterMap, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get clusters", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		for id, amount := range byCluster {
+			item := toCostReportItem(amount)
+			item.ClusterID = id
+			if cluster, ok := clusterMap[id]; ok {
+				item.ClusterName = cluster.ClusterName
+			}
+			data.Items = append(data.Items, item)
+		}
+	case v1.CostGroupByCostCenter:
+		for costCenter, amount := range byCostCenter {
+			item := toCostReportItem(amount)
+			item.CostCenter = costCenter
+			data.Items = append(data.Items, item)
+		}
+	default:
+		budgets, err := s.costRepo.ListBudgets(ctx)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list cost budgets", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		budgetMap := make(map[string]*model.CostBudget, len(budgets))
+		for _, b := range budgets {
+			budgetMap[b.AppId] = b
+		}
+		for appID, amount := range byApp {
+			item := toCostReportItem(amount)
+			item.AppId = appID
+			if b, ok := budgetMap[appID]; ok {
+				budget := b.MonthlyBudget
+				percent := roundCost(amount.total() / budget * 100)
+				item.Budget, item.BudgetUsedPercent = &budget, &percent
+			}
+			data.Items = append(data.Items, item)
+		}
+	}
+
+	// 费用从高到低
+	sort.Slice(data.Items, func(i, j int) bool {
+		if data.Items[i].TotalCost != data.Items[j].TotalCost {
+			return data.Items[i].TotalCost > data.Items[j].TotalCost
+		}
+		if data.Items[i].AppId != data.Items[j].AppId {
+			return data.Items[i].AppId < data.Items[j].AppId
+		}
+		if data.Items[i].CostCenter != data.Items[j].CostCenter {
+			return data.Items[i].CostCenter < data.Items[j].CostCenter
+		}
+		return data.Items[i].ClusterID < data.Items[j].ClusterID
+	})
+	var total float64
+	for _, item := range data.Items {
+		total += item.TotalCost
+	}
+	data.TotalCost = roundCost(total)
+	return data, nil
+}
+
+func (s *costService) ExportReportCSV(ctx context.Context, req *v1.GetCostReportRequest) ([]byte, error) {
+	report, err := s.GetReport(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := []string{"app_id"}
+	switch report.GroupBy {
+	case v1.CostGroupByCluster:
+		header = []string{"cluster_id", "cluster_name"}
+	case v1.CostGroupByCostCenter:
+		header = []string{"cost_center"}
+	}
+	header = append(header, "month", "currency", "vcpu_hours", "ram_gb_hours", "storage_gb_months",
+		"cpu_cost", "ram_cost", "storage_cost", "total_cost", "budget", "budget_used_percent")
+	_ = w.Write(header)
+
+	for _, item := range report.Items {
+		row := []string{item.AppId}
+		switch report.GroupBy {
+		case v1.CostGroupByCluster:
+			row = []string{strconv.FormatInt(item.ClusterID, 10), item.ClusterName}
+		case v1.CostGroupByCostCenter:
+			row = []string{item.CostCenter}
+		}
+		budget, percent := "", ""
+		if item.Budget != nil {
+			budget = formatCost(*item.Budget)
+			percent = formatCost(*item.BudgetUsedPercent)
+		}
+		row = append(row, report.Month, report.Currency,
+			formatCost(item.VcpuHours), formatCost(item.RamGbHours), formatCost(item.StorageGbMonths),
+			formatCost(item.CPUCost), formatCost(item.RAMCost), formatCost(item.StorageCost), formatCost(item.TotalCost),
+			budget, percent)
+		_ = w.Write(row)
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		s.logger.WithContext(ctx).Error("failed to write cost report csv", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *costService) CreateBudget(ctx context.Context, userID string, req *v1.CreateCostBudgetRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "cost.manage")
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.costRepo.GetBudgetByAppId(ctx, req.AppId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cost budget", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return v1.WithDetail(v1.ErrCostBudgetExists, req.AppId)
+	}
+
+	threshold := req.AlertThreshold
+	if threshold == 0 {
+		threshold = 80
+	}
+	budget := &model.CostBudget{
+		AppId:          req.AppId,
+		MonthlyBudget:  req.MonthlyBudget,
+		AlertThreshold: threshold,
+		Describes:      req.Describes,
+		Creator:        username,
+		Modifier:       username,
+	}
+	if err := s.costRepo.CreateBudget(ctx, budget); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create cost budget", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("cost budget created",
+		zap.String("app_id", budget.AppId), zap.Float64("monthly_budget", budget.MonthlyBudget), zap.String("operator", username))
+	return nil
+}
+
+func (s *costService) UpdateBudget(ctx context.Context, userID string, id int64, req *v1.UpdateCostBudgetRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "cost.manage")
+	if err != nil {
+		return err
+	}
+
+	budget, err := s.costRepo.GetBudgetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cost budget", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if budget == nil {
+		return v1.ErrNotFound
+	}
+
+	if req.MonthlyBudget != nil {
+		budget.MonthlyBudget = *req.MonthlyBudget
+	}
+	if req.AlertThreshold != nil {
+		budget.AlertThreshold = *req.AlertThreshold
+	}
+	if req.Describes != nil {
+		budget.Describes = *req.Describes
+	}
+	budget.Modifier = username
+
+	if err := s.costRepo.UpdateBudget(ctx, budget); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update cost budget", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *costService) DeleteBudget(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "cost.manage")
+	if err != nil {
+		return err
+	}
+
+	budget, err := s.costRepo.GetBudgetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cost budget", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if budget == nil {
+		return v1.ErrNotFound
+	}
+
+	if err := s.costRepo.DeleteBudget(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete cost budget", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("cost budget deleted", zap.String("app_id", budget.AppId), zap.String("operator", username))
+	return nil
+}
+
+func (s *costService) ListBudgets(ctx context.Context) (*v1.ListCostBudgetsResponseData, error) {
+	budgets, err := s.costRepo.ListBudgets(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cost budgets", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	month := time.Now().Format("2006-01")
+	costs, err := s.monthCostByApp(ctx, month)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]v1.CostBudgetItem, 0, len(budgets))
+	for _, b := range budgets {
+		cost := costs[b.AppId]
+		list = append(list, v1.CostBudgetItem{
+			Id:             b.Id,
+			AppId:          b.AppId,
+			MonthlyBudget:  b.MonthlyBudget,
+			AlertThreshold: b.AlertThreshold,
+			Describes:      b.Describes,
+			CurrentCost:    roundCost(cost),
+			UsedPercent:    roundCost(cost / b.MonthlyBudget * 100),
+			Creator:        b.Creator,
+			Modifier:       b.Modifier,
+			CreateTime:     b.CreateTime,
+			UpdateTime:     b.UpdateTime,
+		})
+	}
+	return &v1.ListCostBudgetsResponseData{Month: month, Currency: s.currency(), List: list}, nil
+}
+
+func (s *costService) ListAlerts(ctx context.Context, req *v1.ListCostAlertsRequest) (*v1.ListCostAlertsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	alerts, total, err := s.costRepo.ListAlerts(ctx, page, pageSize, req.Month, req.AppId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cost alerts", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.CostAlertItem, 0, len(alerts))
+	for _, a := range alerts {
+		list = append(list, v1.CostAlertItem{
+			Id:         a.Id,
+			BudgetID:   a.BudgetID,
+			AppId:      a.AppId,
+			Month:      a.Month,
+			Level:      a.Level,
+			Cost:       a.Cost,
+			Budget:     a.Budget,
+			CreateTime: a.CreateTime,
+		})
+	}
+	return &v1.ListCostAlertsResponseData{Total: total, List: list}, nil
+}
+
+func (s *costService) CollectUsage(ctx context.Context, interval time.Duration) error {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	hours := interval.Hours()
+	date := time.Now().Format(time.DateOnly)
+	for _, cluster := range clusters {
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list cluster vms", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			continue
+		}
+
+		type usageKey struct{ appID, costCenter string }
+		usageByApp := make(map[usageKey]*model.CostUsageDaily)
+		for _, vm := range vms {
+			if vm.IsTemplate == 1 {
+				continue
+			}
+			key := usageKey{vm.AppId, vm.CostCenter}
+			usage, ok := usageByApp[key]
+			if !ok {
+				usage = &model.CostUsageDaily{UsageDate: date, ClusterID: cluster.Id, AppId: vm.AppId, CostCenter: vm.CostCenter}
+				usageByApp[key] = usage
+			}
+			// vCPU 和内存只在运行时计量，磁盘一直占用
+			if vm.Status == "running" {
+				usage.VcpuHours += float64(vm.CPUNum) * hours
+				usage.RamGbHours += float64(vm.MemorySize) / 1024 * hours
+			}
+			usage.StorageGbHours += float64(vm.DiskSize) / 1024 * hours
+		}
+
+		for _, usage := range usageByApp {
+			if usage.VcpuHours == 0 && usage.RamGbHours == 0 && usage.StorageGbHours == 0 {
+				continue
+			}
+			if err := s.costRepo.AddUsage(ctx, usage); err != nil {
+				s.logger.WithContext(ctx).Error("failed to add cost usage", zap.Error(err),
+					zap.Int64("cluster_id", usage.ClusterID), zap.String("app_id", usage.AppId))
+			}
+		}
+	}
+	return nil
+}
+
+func (s *costService) CheckBudgets(ctx context.Context) error {
+	budgets, err := s.costRepo.ListBudgets(ctx)
+	if err != nil {
+		return err
+	}
+	if len(budgets) == 0 {
+		return nil
+	}
+
+	month := time.Now().Format("2006-01")
+	costs, err := s.monthCostByApp(ctx, month)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range budgets {
+		cost := roundCost(costs[b.AppId])
+		levels := make([]string, 0, 2)
+		if cost >= b.MonthlyBudget*float64(b.AlertThreshold)/100 {
+			levels = append(levels, model.CostBudgetAlertThreshold)
+		}
+		if cost >= b.MonthlyBudget {
+			levels = append(levels, model.CostBudgetAlertExceeded)
+		}
+
+		for _, level := range levels {
+			existing, err := s.costRepo.GetAlert(ctx, b.Id, month, level)
+			if err != nil {
+				return err
+			}
+			if existing != nil {
+				continue
+			}
+			alert := &model.CostBudgetAlert{
+				BudgetID: b.Id,
+				AppId:    b.AppId,
+				Month:    month,
+				Level:    level,
+				Cost:     cost,
+				Budget:   b.MonthlyBudget,
+			}
+			if err := s.costRepo.CreateAlert(ctx, alert); err != nil {
+				return err
+			}
+			s.logger.WithContext(ctx).Warn("cost budget alert",
+				zap.String("app_id", b.AppId),
+				zap.String("month", month),
+				zap.String("level", level),
+				zap.Float64("cost", cost),
+				zap.Float64("budget", b.MonthlyBudget))
+			s.notifyBudgetAlert(ctx, b, alert)
+			s.notifyBudgetInbox(ctx, b, alert)
+		}
+	}
+	return nil
+}
+
+// notifyBudgetAlert 配置了 cost.alert_webhook 时以 JSON POST 推送告警，失败只记录日志
+func (s *costService) notifyBudgetAlert(ctx context.Context, budget *model.CostBudget, alert *model.CostBudgetAlert) {
+	webhook := s.conf.GetString("cost.alert_webhook")
+	if webhook == "" {
+		return
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event":           "cost_budget_alert",
+		"app_id":          alert.AppId,
+		"month":           alert.Month,
+		"level":           alert.Level,
+		"cost":            alert.Cost,
+		"budget":          alert.Budget,
+		"alert_threshold": budget.AlertThreshold,
+		"currency":        s.currency(),
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to build cost alert webhook request", zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to send cost alert webhook", zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.logger.WithContext(ctx).Error("cost alert webhook returned error status", zap.Int("status", resp.StatusCode))
+	}
+}
+
+// notifyBudgetInbox 向管理员和预算创建人发送站内通知
+func (s *costService) notifyBudgetInbox(ctx context.Context, budget *model.CostBudget, alert *model.CostBudgetAlert) {
+	notification := &model.Notification{
+		Category:   model.NotificationCategoryAlert,
+		Level:      model.NotificationLevelWarning,
+		Event:      "cost_budget_alert",
+		Title:      fmt.Sprintf("App %s reached %d%% of its %s budget", alert.AppId, budget.AlertThreshold, alert.Month),
+		Content:    fmt.Sprintf("cost %.2f %s of budget %.2f", alert.Cost, s.currency(), alert.Budget),
+		TargetType: "cost_budget",
+		TargetID:   strconv.FormatInt(budget.Id, 10),
+	}
+	if alert.Level == model.CostBudgetAlertExceeded {
+		notification.Level = model.NotificationLevelCritical
+		notification.Title = fmt.Sprintf("App %s exceeded its %s budget", alert.AppId, alert.Month)
+	}
+	s.notificationService.NotifyAdmins(ctx, notification, budget.Creator)
+}
+
+// monthCostByApp 计算指定月份各应用的费用合计
+func (s *costService) monthCostByApp(ctx context.Context, month string) (map[string]float64, error) {
+	start, end, err := parseReportMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	usages, err := s.costRepo.ListUsage(ctx, start.Format(time.DateOnly), end.AddDate(0, 0, -1).Format(time.DateOnly), 0, "", "")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cost usage", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	prices, err := s.loadPrices(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	monthHours := end.Sub(start).Hours()
+	costs := make(map[string]float64)
+	for _, u := range usages {
+		amount := calculateCost(u, priceForCluster(prices, u.ClusterID), monthHours)
+		costs[u.AppId] += amount.total()
+	}
+	return costs, nil
+}
+
+func (s *costService) loadPrices(ctx context.Context) (map[int64]*model.CostPriceModel, error) {
+	prices, err := s.costRepo.ListPrices(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cost prices", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	result := make(map[int64]*model.CostPriceModel, len(prices))
+	for _, p := range prices {
+		result[p.ClusterID] = p
+	}
+	return result, nil
+}
+
+// priceForCluster 返回集群的价格模型，未配置时使用默认价格（cluster_id=0），都未配置时价格为 0
+func priceForCluster(prices map[int64]*model.CostPriceModel, clusterID int64) *model.CostPriceModel {
+	if p, ok := prices[clusterID]; ok {
+		return p
+	}
+	if p, ok := prices[0]; ok {
+		return p
+	}
+	return &model.CostPriceModel{}
+}
+
+// calculateCost 按价格模型计算用量费用，存储 GB·小时 按当月小时数折算为 GB·月
+func calculateCost(u *model.CostUsageDaily, price *model.CostPriceModel, monthHours float64) costAmount {
+	storageGbMonths := u.StorageGbHours / monthHours
+	return costAmount{
+		vcpuHours:       u.VcpuHours,
+		ramGbHours:      u.RamGbHours,
+		storageGbMonths: storageGbMonths,
+		cpuCost:         u.VcpuHours * price.VcpuHourPrice,
+		ramCost:         u.RamGbHours * price.RamGbHourPrice,
+		storageCost:     storageGbMonths * price.StorageGbMonthPrice,
+	}
+}
+
+func toCostReportItem(a *costAmount) v1.CostReportItem {
+	return v1.CostReportItem{
+		VcpuHours:       roundCost(a.vcpuHours),
+		RamGbHours:      roundCost(a.ramGbHours),
+		StorageGbMonths: roundCost(a.storageGbMonths),
+		CPUCost:         roundCost(a.cpuCost),
+		RAMCost:         roundCost(a.ramCost),
+		StorageCost:     roundCost(a.storageCost),
+		TotalCost:       roundCost(a.total()),
+	}
+}
+
+// parseReportMonth 解析 YYYY-MM，返回当月第一天 0 点和下月第一天 0 点（服务器本地时区）
+func parseReportMonth(month string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, v1.WithDetail(v1.ErrInvalidReportMonth, month)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+// roundCost 保留两位小数
+func roundCost(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func formatCost(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
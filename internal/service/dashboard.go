@@ -13,45 +13,78 @@ import (
 	"pvesphere/pkg/log"
 	"pvesphere/pkg/proxmox"
 
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
 type DashboardService interface {
 	GetScopes(ctx context.Context) (*v1.DashboardScopesData, error)
 	GetOverview(ctx context.Context, req *v1.DashboardOverviewRequest) (*v1.DashboardOverviewData, error)
+	GetSites(ctx context.Context) (*v1.DashboardSitesData, error)
 	GetResources(ctx context.Context, req *v1.DashboardResourcesRequest) (*v1.DashboardResourcesData, error)
 	GetHotspots(ctx context.Context, req *v1.DashboardHotspotsRequest) (*v1.DashboardHotspotsData, error)
 	GetOperations(ctx context.Context, req *v1.DashboardOperationsRequest) (*v1.DashboardOperationsData, error)
+	GetEnergy(ctx context.Context, req *v1.DashboardEnergyRequest) (*v1.DashboardEnergyData, error)
+	GetRisks(ctx context.Context, req *v1.DashboardRisksRequest) (*v1.DashboardRisksData, error)
+	GetAlerts(ctx context.Context, req *v1.DashboardAlertsRequest) (*v1.DashboardAlertsData, error)
+	GetWidgets(ctx context.Context) *v1.DashboardWidgetsData
+	GetLayout(ctx context.Context, userID string, req *v1.GetDashboardLayoutRequest) (*v1.DashboardLayoutData, error)
+	SaveLayout(ctx context.Context, userID string, req *v1.SaveDashboardLayoutRequest) (*v1.DashboardLayoutData, error)
+	ResetLayout(ctx context.Context, userID string) error
 }
 
 func NewDashboardService(
 	service *Service,
+	conf *viper.Viper,
 	clusterRepo repository.PveClusterRepository,
+	siteRepo repository.PveSiteRepository,
 	nodeRepo repository.PveNodeRepository,
 	vmRepo repository.PveVMRepository,
 	storageRepo repository.PveStorageRepository,
+	bmcRepo repository.NodeBMCRepository,
+	energyRepo repository.EnergyRepository,
+	layoutRepo repository.DashboardLayoutRepository,
+	licenseRepo repository.LicenseRepository,
+	zfsAlertRepo repository.ZFSPoolAlertRepository,
+	diskHealthRepo repository.NodeDiskHealthRepository,
 	logger *log.Logger,
 ) DashboardService {
 	return &dashboardService{
-		clusterRepo: clusterRepo,
-		nodeRepo:    nodeRepo,
-		vmRepo:      vmRepo,
-		storageRepo: storageRepo,
-		Service:     service,
-		logger:      logger,
+		conf:           conf,
+		clusterRepo:    clusterRepo,
+		siteRepo:       siteRepo,
+		nodeRepo:       nodeRepo,
+		vmRepo:         vmRepo,
+		storageRepo:    storageRepo,
+		bmcRepo:        bmcRepo,
+		energyRepo:     energyRepo,
+		layoutRepo:     layoutRepo,
+		licenseRepo:    licenseRepo,
+		zfsAlertRepo:   zfsAlertRepo,
+		diskHealthRepo: diskHealthRepo,
+		Service:        service,
+		logger:         logger,
 	}
 }
 
 type dashboardService struct {
-	clusterRepo repository.PveClusterRepository
-	nodeRepo    repository.PveNodeRepository
-	vmRepo      repository.PveVMRepository
-	storageRepo repository.PveStorageRepository
+	conf           *viper.Viper
+	clusterRepo    repository.PveClusterRepository
+	siteRepo       repository.PveSiteRepository
+	nodeRepo       repository.PveNodeRepository
+	vmRepo         repository.PveVMRepository
+	storageRepo    repository.PveStorageRepository
+	bmcRepo        repository.NodeBMCRepository
+	energyRepo     repository.EnergyRepository
+	layoutRepo     repository.DashboardLayoutRepository
+	licenseRepo    repository.LicenseRepository
+	zfsAlertRepo   repository.ZFSPoolAlertRepository
+	diskHealthRepo repository.NodeDiskHealthRepository
 	*Service
 	logger *log.Logger
 }
 
-// GetScopes 获取可选集群列表
+// GetScopes 获取可选集群及站点列表
 func (s *dashboardService) GetScopes(ctx context.Context) (*v1.DashboardScopesData, error) {
 	clusters, err := s.clusterRepo.List(ctx)
 	if err != nil {
@@ -65,11 +98,28 @@ func (s *dashboardService) GetScopes(ctx context.Context) (*v1.DashboardScopesDa
 			ClusterID:        cluster.Id,
 			ClusterName:      cluster.ClusterName,
 			ClusterNameAlias: cluster.ClusterNameAlias,
+			SiteID:           cluster.SiteID,
+		})
+	}
+
+	sites, err := s.siteRepo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list sites", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	siteItems := make([]v1.SiteScopeItem, 0, len(sites))
+	for _, site := range sites {
+		siteItems = append(siteItems, v1.SiteScopeItem{
+			SiteID:        site.Id,
+			SiteName:      site.SiteName,
+			SiteNameAlias: site.SiteNameAlias,
+			Region:        site.Region,
 		})
 	}
 
 	return &v1.DashboardScopesData{
 		Items: items,
+		Sites: siteItems,
 	}, nil
 }
 
@@ -79,25 +129,25 @@ func (s *dashboardService) GetOverview(ctx context.Context, req *v1.DashboardOve
 	var err error
 
 	// 根据 scope 获取集群列表
-	if req.Scope == "cluster" && req.ClusterID != nil {
-		cluster, err := s.clusterRepo.GetByID(ctx, *req.ClusterID)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
-			return nil, v1.ErrInternalServerError
-		}
-		if cluster == nil {
-			return nil, v1.ErrNotFound
-		}
-		clusters = []*model.PveCluster{cluster}
-	} else {
-		clusters, err = s.clusterRepo.List(ctx)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
-			return nil, v1.ErrInternalServerError
-		}
+	clusters, err = s.getScopeClusters(ctx, req.Scope, req.ClusterID, req.SiteID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 统计概览数据
+	summary, health := s.summarizeClusters(ctx, clusters)
+
+	return &v1.DashboardOverviewData{
+		Scope:     req.Scope,
+		ClusterID: req.ClusterID,
+		SiteID:    req.SiteID,
+		Summary:   summary,
+		Health:    health,
+	}, nil
+}
+
+// summarizeClusters 统计一组集群的节点、虚拟机、存储数量及健康状态
+func (s *dashboardService) summarizeClusters(ctx context.Context, clusters []*model.PveCluster) (v1.DashboardOverviewSummary, v1.DashboardOverviewHealth) {
 	summary := v1.DashboardOverviewSummary{
 		ClusterCount: int64(len(clusters)),
 	}
@@ -150,14 +200,99 @@ func (s *dashboardService) GetOverview(ctx context.Context, req *v1.DashboardOve
 		}
 	}
 
-	return &v1.DashboardOverviewData{
-		Scope:     req.Scope,
-		ClusterID: req.ClusterID,
-		Summary:   summary,
-		Health:    health,
+	return summary, health
+}
+
+// GetSites 按站点分组统计概览，未分配站点的集群归入 site_id 为 0 的分组
+func (s *dashboardService) GetSites(ctx context.Context) (*v1.DashboardSitesData, error) {
+	sites, err := s.siteRepo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list sites", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	clustersBySite := make(map[int64][]*model.PveCluster)
+	for _, cluster := range clusters {
+		clustersBySite[cluster.SiteID] = append(clustersBySite[cluster.SiteID], cluster)
+	}
+
+	items := make([]v1.SiteOverviewItem, 0, len(sites)+1)
+	for _, site := range sites {
+		summary, health := s.summarizeClusters(ctx, clustersBySite[site.Id])
+		delete(clustersBySite, site.Id)
+		items = append(items, v1.SiteOverviewItem{
+			SiteID:        site.Id,
+			SiteName:      site.SiteName,
+			SiteNameAlias: site.SiteNameAlias,
+			Region:        site.Region,
+			Summary:       summary,
+			Health:        health,
+		})
+	}
+
+	// 未分配站点（或站点已不存在）的集群
+	var unassigned []*model.PveCluster
+	for _, siteClusters := range clustersBySite {
+		unassigned = append(unassigned, siteClusters...)
+	}
+	if len(unassigned) > 0 {
+		summary, health := s.summarizeClusters(ctx, unassigned)
+		items = append(items, v1.SiteOverviewItem{
+			SiteID:   0,
+			SiteName: "unassigned",
+			Summary:  summary,
+			Health:   health,
+		})
+	}
+
+	return &v1.DashboardSitesData{
+		Items: items,
 	}, nil
 }
 
+// getScopeClusters 根据 scope 获取集群列表：cluster 返回单个集群，site 返回站点下的集群，其余返回全部集群
+func (s *dashboardService) getScopeClusters(ctx context.Context, scope string, clusterID, siteID *int64) ([]*model.PveCluster, error) {
+	switch {
+	case scope == "cluster" && clusterID != nil:
+		cluster, err := s.clusterRepo.GetByID(ctx, *clusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return nil, v1.ErrNotFound
+		}
+		return []*model.PveCluster{cluster}, nil
+	case scope == "site" && siteID != nil:
+		site, err := s.siteRepo.GetByID(ctx, *siteID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get site", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if site == nil {
+			return nil, v1.WithDetailf(v1.ErrSiteNotFound, "site_id=%d", *siteID)
+		}
+		clusters, err := s.clusterRepo.GetBySiteID(ctx, site.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list site clusters", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		return clusters, nil
+	default:
+		clusters, err := s.clusterRepo.List(ctx)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		return clusters, nil
+	}
+}
+
 // evaluateClusterHealth 评估集群健康状态
 func (s *dashboardService) evaluateClusterHealth(ctx context.Context, cluster *model.PveCluster, nodes []*model.PveNode) string {
 	// 简单的健康评估逻辑
@@ -189,33 +324,29 @@ func (s *dashboardService) GetResources(ctx context.Context, req *v1.DashboardRe
 	var err error
 
 	// 根据 scope 获取集群列表
-	if req.Scope == "cluster" && req.ClusterID != nil {
-		cluster, err := s.clusterRepo.GetByID(ctx, *req.ClusterID)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
-			return nil, v1.ErrInternalServerError
-		}
-		if cluster == nil {
-			return nil, v1.ErrNotFound
-		}
-		clusters = []*model.PveCluster{cluster}
-	} else {
-		clusters, err = s.clusterRepo.List(ctx)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
-			return nil, v1.ErrInternalServerError
-		}
+	clusters, err = s.getScopeClusters(ctx, req.Scope, req.ClusterID, req.SiteID)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err = s.applyNodeFilter(ctx, req.DashboardVMFilter, clusters)
+	if err != nil {
+		return nil, err
 	}
+	vmFilter := dashboardVMFilterActive(req.DashboardVMFilter)
+	var matchedVMs int64
 
 	// 统计资源使用情况
 	var totalCPUCores, usedCPUCores float64
 	var totalMemory, usedMemory int64
 	var totalStorage, usedStorage int64
+	// 集群为系统开销预留的 CPU 和内存，可用容量扣除这部分
+	var reservedCPUCores float64
+	var reservedMemory int64
 
 	// 遍历集群,通过 Proxmox API 获取实时资源数据
 	for _, cluster := range clusters {
 		// 创建 Proxmox 客户端
-		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 		if err != nil {
 			s.logger.WithContext(ctx).Warn("failed to create proxmox client",
 				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
@@ -223,23 +354,41 @@ func (s *dashboardService) GetResources(ctx context.Context, req *v1.DashboardRe
 		}
 
 		// 获取集群资源
-		resources, err := client.GetClusterResources(ctx)
+		resources, err := s.resources.Get(ctx, cluster.Id, client)
 		if err != nil {
 			s.logger.WithContext(ctx).Warn("failed to get cluster resources",
 				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
 			continue
 		}
 
-		// 聚合节点资源
+		matcher, err := s.newDashboardVMMatcher(ctx, cluster, req.DashboardVMFilter)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to load dashboard filter data",
+				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			continue
+		}
+
+		// 聚合节点资源；按虚拟机过滤时聚合匹配虚拟机分配的资源
 		for _, resource := range resources {
 			resourceType, _ := resource["type"].(string)
-			if resourceType != "node" {
-				continue
+			if vmFilter {
+				if !matcher.matchVM(resource) {
+					continue
+				}
+				matchedVMs++
+			} else {
+				nodeName, _ := resource["node"].(string)
+				if resourceType != "node" || !matcher.matchNode(nodeName) {
+					continue
+				}
 			}
 
 			// CPU
 			if maxcpu, ok := resource["maxcpu"].(float64); ok {
 				totalCPUCores += maxcpu
+				if !vmFilter {
+					reservedCPUCores += nodeReservedCPU(cluster, maxcpu)
+				}
 			}
 			if cpu, ok := resource["cpu"].(float64); ok {
 				if maxcpu, ok := resource["maxcpu"].(float64); ok {
@@ -250,6 +399,9 @@ func (s *dashboardService) GetResources(ctx context.Context, req *v1.DashboardRe
 			// 内存
 			if maxmem, ok := resource["maxmem"].(float64); ok {
 				totalMemory += int64(maxmem)
+				if !vmFilter {
+					reservedMemory += int64(nodeReservedMemory(cluster, maxmem))
+				}
 			}
 			if mem, ok := resource["mem"].(float64); ok {
 				usedMemory += int64(mem)
@@ -281,9 +433,10 @@ func (s *dashboardService) GetResources(ctx context.Context, req *v1.DashboardRe
 		storageUsagePercent = (float64(usedStorage) / float64(totalStorage)) * 100
 	}
 
-	return &v1.DashboardResourcesData{
+	data := &v1.DashboardResourcesData{
 		Scope:     req.Scope,
 		ClusterID: req.ClusterID,
+		SiteID:    req.SiteID,
 		CPU: v1.ResourceUsage{
 			UsedCores:    &usedCPUCores,
 			TotalCores:   &totalCPUCores,
@@ -299,7 +452,21 @@ func (s *dashboardService) GetResources(ctx context.Context, req *v1.DashboardRe
 			TotalBytes:   &totalStorage,
 			UsagePercent: storageUsagePercent,
 		},
-	}, nil
+	}
+	if dashboardFilterActive(req.DashboardVMFilter) {
+		data.Filter = &req.DashboardVMFilter
+	}
+	if vmFilter {
+		data.MatchedVMs = &matchedVMs
+	} else {
+		availableCPUCores := max(totalCPUCores-reservedCPUCores-usedCPUCores, 0)
+		availableMemory := max(totalMemory-reservedMemory-usedMemory, 0)
+		data.CPU.ReservedCores = &reservedCPUCores
+		data.CPU.AvailableCores = &availableCPUCores
+		data.Memory.ReservedBytes = &reservedMemory
+		data.Memory.AvailableBytes = &availableMemory
+	}
+	return data, nil
 }
 
 // GetHotspots 获取压力和风险焦点
@@ -313,22 +480,13 @@ func (s *dashboardService) GetHotspots(ctx context.Context, req *v1.DashboardHot
 	}
 
 	// 根据 scope 获取集群列表
-	if req.Scope == "cluster" && req.ClusterID != nil {
-		cluster, err := s.clusterRepo.GetByID(ctx, *req.ClusterID)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
-			return nil, v1.ErrInternalServerError
-		}
-		if cluster == nil {
-			return nil, v1.ErrNotFound
-		}
-		clusters = []*model.PveCluster{cluster}
-	} else {
-		clusters, err = s.clusterRepo.List(ctx)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
-			return nil, v1.ErrInternalServerError
-		}
+	clusters, err = s.getScopeClusters(ctx, req.Scope, req.ClusterID, req.SiteID)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err = s.applyNodeFilter(ctx, req.DashboardVMFilter, clusters)
+	if err != nil {
+		return nil, err
 	}
 
 	// 分别收集各类资源的使用率
@@ -371,23 +529,35 @@ func (s *dashboardService) GetHotspots(ctx context.Context, req *v1.DashboardHot
 	// 收集 Storage
 	var storages []storageResource
 
+	// 过滤范围内的节点，用于过滤最近风险
+	riskNodes := make(map[string]bool)
+
 	// 遍历集群,获取资源消耗数据
 	for _, cluster := range clusters {
 		// 创建 Proxmox 客户端
-		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 		if err != nil {
 			s.logger.WithContext(ctx).Warn("failed to create proxmox client",
 				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
 			continue
 		}
 
+		matcher, err := s.newDashboardVMMatcher(ctx, cluster, req.DashboardVMFilter)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to load dashboard filter data",
+				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			continue
+		}
+
 		// 1. 获取节点资源（CPU 和 Memory）
 		// 使用 GetClusterResources 获取节点数据，因为 GetNodeStatus 可能不包含完整的内存信息
-		nodeResources, err := client.GetClusterResources(ctx)
+		nodeResources, err := s.resources.Get(ctx, cluster.Id, client)
 		if err != nil {
 			s.logger.WithContext(ctx).Warn("failed to get cluster resources",
 				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
 		} else {
+			matcher.observe(nodeResources)
+
 			// 从数据库获取节点列表，用于匹配和填充集群信息
 			nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
 			if err != nil {
@@ -417,7 +587,7 @@ func (s *dashboardService) GetHotspots(ctx context.Context, req *v1.DashboardHot
 					}
 				}
 
-				if nodeName == "" {
+				if nodeName == "" || !matcher.matchNode(nodeName) {
 					continue
 				}
 
@@ -483,8 +653,10 @@ func (s *dashboardService) GetHotspots(ctx context.Context, req *v1.DashboardHot
 			s.logger.WithContext(ctx).Warn("failed to get storages from database",
 				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
 		} else {
-			resources, err := client.GetClusterResources(ctx)
+			resources, err := s.resources.Get(ctx, cluster.Id, client)
 			if err == nil {
+				matcher.observe(resources)
+
 				// 创建存储映射，用于快速查找
 				storageMap := make(map[string]*model.PveStorage)
 				for _, storage := range dbStorages {
@@ -501,7 +673,7 @@ func (s *dashboardService) GetHotspots(ctx context.Context, req *v1.DashboardHot
 
 					storageName, _ := resource["storage"].(string)
 					nodeName, _ := resource["node"].(string)
-					if storageName == "" || nodeName == "" {
+					if storageName == "" || nodeName == "" || !matcher.matchNode(nodeName) {
 						continue
 					}
 
@@ -530,16 +702,20 @@ func (s *dashboardService) GetHotspots(ctx context.Context, req *v1.DashboardHot
 		}
 
 		// 3. 获取 VM 资源（CPU、Memory）
-		resources, err := client.GetClusterResources(ctx)
+		resources, err := s.resources.Get(ctx, cluster.Id, client)
 		if err != nil {
 			s.logger.WithContext(ctx).Warn("failed to get cluster resources",
 				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
 			continue
 		}
 
+		matcher.observe(resources)
+		for _, id := range matcher.matchedNodeIDs() {
+			riskNodes[fmt.Sprintf("node-%d", id)] = true
+		}
+
 		for _, resource := range resources {
-			resourceType, _ := resource["type"].(string)
-			if resourceType != "qemu" && resourceType != "lxc" {
+			if !matcher.matchVM(resource) {
 				continue
 			}
 
@@ -666,10 +842,20 @@ func (s *dashboardService) GetHotspots(ctx context.Context, req *v1.DashboardHot
 
 	// 获取最近的风险
 	recentRisks := s.getRecentRisks(ctx, clusters)
+	if dashboardFilterActive(req.DashboardVMFilter) {
+		filtered := make([]v1.RecentRisk, 0, len(recentRisks))
+		for _, risk := range recentRisks {
+			if risk.TargetType == "node" && riskNodes[risk.TargetID] {
+				filtered = append(filtered, risk)
+			}
+		}
+		recentRisks = filtered
+	}
 
-	return &v1.DashboardHotspotsData{
+	data := &v1.DashboardHotspotsData{
 		Scope:     req.Scope,
 		ClusterID: req.ClusterID,
+		SiteID:    req.SiteID,
 		VMHotspots: v1.VMHotspots{
 			CPU:    vmCPUTopN,
 			Memory: vmMemoryTopN,
@@ -680,7 +866,11 @@ func (s *dashboardService) GetHotspots(ctx context.Context, req *v1.DashboardHot
 		},
 		StorageHotspots: storageTopN,
 		RecentRisks:     recentRisks,
-	}, nil
+	}
+	if dashboardFilterActive(req.DashboardVMFilter) {
+		data.Filter = &req.DashboardVMFilter
+	}
+	return data, nil
 }
 
 // getRecentRisks 获取最近的风险（简单实现，实际应该从监控系统获取）
@@ -745,22 +935,9 @@ func (s *dashboardService) GetOperations(ctx context.Context, req *v1.DashboardO
 	var err error
 
 	// 根据 scope 获取集群列表
-	if req.Scope == "cluster" && req.ClusterID != nil {
-		cluster, err := s.clusterRepo.GetByID(ctx, *req.ClusterID)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
-			return nil, v1.ErrInternalServerError
-		}
-		if cluster == nil {
-			return nil, v1.ErrNotFound
-		}
-		clusters = []*model.PveCluster{cluster}
-	} else {
-		clusters, err = s.clusterRepo.List(ctx)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
-			return nil, v1.ErrInternalServerError
-		}
+	clusters, err = s.getScopeClusters(ctx, req.Scope, req.ClusterID, req.SiteID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 统计各类操作
@@ -770,7 +947,7 @@ func (s *dashboardService) GetOperations(ctx context.Context, req *v1.DashboardO
 	// 遍历集群，获取正在运行的任务
 	for _, cluster := range clusters {
 		// 创建 Proxmox 客户端
-		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 		if err != nil {
 			s.logger.WithContext(ctx).Warn("failed to create proxmox client",
 				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
@@ -849,6 +1026,7 @@ func (s *dashboardService) GetOperations(ctx context.Context, req *v1.DashboardO
 	return &v1.DashboardOperationsData{
 		Scope:     req.Scope,
 		ClusterID: req.ClusterID,
+		SiteID:    req.SiteID,
 		Summary:   summary,
 		Items:     allItems,
 	}, nil
@@ -867,3 +1045,254 @@ func (s *dashboardService) classifyOperationType(taskType string) string {
 		return "other"
 	}
 }
+
+// GetEnergy 获取能耗概览：已配置 BMC 功率采集节点的当前功率及今日、本月能耗
+func (s *dashboardService) GetEnergy(ctx context.Context, req *v1.DashboardEnergyRequest) (*v1.DashboardEnergyData, error) {
+	clusters, err := s.getScopeClusters(ctx, req.Scope, req.ClusterID, req.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.DashboardEnergyData{
+		Scope:     req.Scope,
+		ClusterID: req.ClusterID,
+		SiteID:    req.SiteID,
+		Nodes:     make([]v1.NodeEnergyUsage, 0),
+	}
+
+	bmcs, err := s.bmcRepo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node bmc", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	bmcMap := make(map[int64]*model.NodeBMC, len(bmcs))
+	for _, b := range bmcs {
+		if b.PowerMonitor == 1 {
+			bmcMap[b.NodeID] = b
+		}
+	}
+
+	now := time.Now()
+	today := now.Format(time.DateOnly)
+	monthStart := now.Format("2006-01") + "-01"
+	clusterIDs := make([]int64, 0, len(clusters))
+	for _, cluster := range clusters {
+		clusterIDs = append(clusterIDs, cluster.Id)
+	}
+	usages, err := s.energyRepo.ListNodeEnergy(ctx, monthStart, today, clusterIDs, 0)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node energy", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	todayWh := make(map[int64]float64)
+	var monthWh float64
+	for _, u := range usages {
+		monthWh += u.EnergyWh
+		if u.UsageDate == today {
+			todayWh[u.NodeID] += u.EnergyWh
+			data.TodayKwh += u.EnergyWh
+		}
+	}
+	data.TodayKwh = roundEnergy(data.TodayKwh / 1000)
+	data.MonthKwh = roundEnergy(monthWh / 1000)
+	data.MonthCarbonKg = roundEnergy(monthWh / 1000 * s.conf.GetFloat64("energy.carbon_intensity") / 1000)
+
+	for _, cluster := range clusters {
+		nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get nodes for cluster",
+				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			continue
+		}
+		data.TotalNodes += int64(len(nodes))
+
+		for _, node := range nodes {
+			b, ok := bmcMap[node.Id]
+			if !ok {
+				continue
+			}
+			data.MonitoredNodes++
+			item := v1.NodeEnergyUsage{
+				NodeID:       node.Id,
+				NodeName:     node.NodeName,
+				ClusterID:    cluster.Id,
+				ClusterName:  cluster.ClusterName,
+				CurrentWatts: b.LastPowerWatts,
+				LastError:    b.LastError,
+				TodayKwh:     roundEnergy(todayWh[node.Id] / 1000),
+			}
+			if b.LastReadingTime != nil {
+				item.LastReadingTime = b.LastReadingTime.Format(time.RFC3339)
+			}
+			data.CurrentWatts += b.LastPowerWatts
+			data.Nodes = append(data.Nodes, item)
+		}
+	}
+
+	// 当前功率从高到低
+	sort.Slice(data.Nodes, func(i, j int) bool {
+		return data.Nodes[i].CurrentWatts > data.Nodes[j].CurrentWatts
+	})
+	return data, nil
+}
+
+// GetRisks 获取最近风险，与 hotspots 中的 recent_risks 相同，供单独的风险面板使用
+func (s *dashboardService) GetRisks(ctx context.Context, req *v1.DashboardRisksRequest) (*v1.DashboardRisksData, error) {
+	clusters, err := s.getScopeClusters(ctx, req.Scope, req.ClusterID, req.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1.DashboardRisksData{
+		Scope:     req.Scope,
+		ClusterID: req.ClusterID,
+		SiteID:    req.SiteID,
+		Risks:     s.getRecentRisks(ctx, clusters),
+	}, nil
+}
+
+// GetAlerts 汇总各模块未恢复的告警：许可证超用、ZFS 存储池健康、磁盘 SMART。
+// 许可证告警不属于具体集群，只在 scope 为 all 时返回
+func (s *dashboardService) GetAlerts(ctx context.Context, req *v1.DashboardAlertsRequest) (*v1.DashboardAlertsData, error) {
+	if req.Limit <= 0 {
+		req.Limit = 20
+	}
+
+	clusters, err := s.getScopeClusters(ctx, req.Scope, req.ClusterID, req.SiteID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.DashboardAlertsData{
+		Scope:     req.Scope,
+		ClusterID: req.ClusterID,
+		SiteID:    req.SiteID,
+		BySource:  map[string]int64{"license": 0, "zfs_pool": 0, "node_disk": 0},
+		Alerts:    make([]v1.DashboardAlertItem, 0),
+	}
+
+	allScope := req.Scope != "site" && req.Scope != "cluster"
+	if !allScope && len(clusters) == 0 {
+		return data, nil
+	}
+	// clusterIDs 为空表示不按集群过滤
+	var clusterIDs []int64
+	if !allScope {
+		clusterIDs = make([]int64, 0, len(clusters))
+		for _, cluster := range clusters {
+			clusterIDs = append(clusterIDs, cluster.Id)
+		}
+	}
+
+	type alertItem struct {
+		item       v1.DashboardAlertItem
+		occurredAt time.Time
+	}
+	items := make([]alertItem, 0)
+
+	if allScope {
+		licenseAlerts, err := s.licenseRepo.ListOpenAlerts(ctx)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list license alerts", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		licenseNames := make(map[int64]string)
+		if len(licenseAlerts) > 0 {
+			licenses, err := s.licenseRepo.ListLicenses(ctx, "")
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to list licenses", zap.Error(err))
+				return nil, v1.ErrInternalServerError
+			}
+			for _, l := range licenses {
+				licenseNames[l.Id] = l.Name
+			}
+		}
+		for _, a := range licenseAlerts {
+			item := v1.DashboardAlertItem{
+				Source:     "license",
+				ID:         a.Id,
+				Level:      "warning",
+				TargetType: "license",
+			}
+			if a.LicenseID > 0 {
+				item.TargetID = fmt.Sprintf("license-%d", a.LicenseID)
+				item.TargetName = licenseNames[a.LicenseID]
+				item.Message = fmt.Sprintf("License %s is over-allocated: %d/%d", item.TargetName, a.Consumed, a.Seats)
+			} else {
+				item.TargetID = fmt.Sprintf("os-%s", a.OSType)
+				item.TargetName = a.OSType
+				item.Message = fmt.Sprintf("Licenses for %s are over-allocated: %d/%d", a.OSType, a.Consumed, a.Seats)
+			}
+			items = append(items, alertItem{item: item, occurredAt: a.CreateTime})
+		}
+	}
+
+	zfsAlerts, err := s.zfsAlertRepo.ListOpenByClusters(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list zfs pool alerts", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, a := range zfsAlerts {
+		level := "warning"
+		if a.Health != "" && a.Health != "ONLINE" {
+			level = "critical"
+		}
+		items = append(items, alertItem{
+			item: v1.DashboardAlertItem{
+				Source:     "zfs_pool",
+				ID:         a.Id,
+				Level:      level,
+				Message:    fmt.Sprintf("ZFS pool %s on %s: %s", a.Pool, a.NodeName, a.Reasons),
+				ClusterID:  a.ClusterID,
+				TargetType: "node",
+				TargetID:   fmt.Sprintf("node-%d", a.NodeID),
+				TargetName: a.NodeName,
+			},
+			occurredAt: a.CreateTime,
+		})
+	}
+
+	diskAlerts, err := s.diskHealthRepo.ListOpenAlertsByClusters(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list disk alerts", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, a := range diskAlerts {
+		level := "warning"
+		if strings.Contains(a.Reasons, model.NodeDiskAlertReasonSmartFailed) {
+			level = "critical"
+		}
+		items = append(items, alertItem{
+			item: v1.DashboardAlertItem{
+				Source:     "node_disk",
+				ID:         a.Id,
+				Level:      level,
+				Message:    fmt.Sprintf("Disk %s on %s: %s", a.DevPath, a.NodeName, a.Reasons),
+				ClusterID:  a.ClusterID,
+				TargetType: "node",
+				TargetID:   fmt.Sprintf("node-%d", a.NodeID),
+				TargetName: a.NodeName,
+			},
+			occurredAt: a.CreateTime,
+		})
+	}
+
+	// 从新到旧
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].occurredAt.After(items[j].occurredAt)
+	})
+
+	data.Total = int64(len(items))
+	for _, it := range items {
+		data.BySource[it.item.Source]++
+	}
+	if len(items) > req.Limit {
+		items = items[:req.Limit]
+	}
+	for _, it := range items {
+		it.item.OccurredAt = it.occurredAt.Format(time.RFC3339)
+		it.item.RelativeTime = s.getRelativeTime(it.occurredAt)
+		data.Alerts = append(data.Alerts, it.item)
+	}
+	return data, nil
+}
@@ -0,0 +1,438 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// 变化概览最多读取的操作审计记录数
+const dashboardChangeAuditLimit = 5000
+
+// vmConfigCallPattern 修改虚拟机配置的 Proxmox 调用路径（/api2/json/nodes/{node}/qemu/{vmid}/config）
+var vmConfigCallPattern = regexp.MustCompile(`/nodes/([^/]+)/qemu/(\d+)/config$`)
+
+var dashboardChangePeriods = map[string]time.Duration{
+	v1.DashboardChangePeriod24h: 24 * time.Hour,
+	v1.DashboardChangePeriod7d:  7 * 24 * time.Hour,
+	v1.DashboardChangePeriod30d: 30 * 24 * time.Hour,
+}
+
+type DashboardChangeService interface {
+	// GetChanges 按集群汇总时间段内新建、删除、迁移、修改配置的虚拟机和容量变化
+	GetChanges(ctx context.Context, req *v1.DashboardChangesRequest) (*v1.DashboardChangesData, error)
+	// RecordCapacitySnapshots 为所有集群记录一次容量快照，供之后对比容量变化
+	RecordCapacitySnapshots(ctx context.Context) error
+	// CleanupCapacitySnapshots 删除 before 之前的容量快照
+	CleanupCapacitySnapshots(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewDashboardChangeService(
+	service *Service,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	storageRepo repository.PveStorageRepository,
+	statusRepo repository.VMStatusEventRepository,
+	auditRepo repository.OperationAuditRepository,
+	snapshotRepo repository.ClusterCapacitySnapshotRepository,
+	logger *log.Logger,
+) DashboardChangeService {
+	return &dashboardChangeService{
+		Service:      service,
+		clusterRepo:  clusterRepo,
+		nodeRepo:     nodeRepo,
+		vmRepo:       vmRepo,
+		storageRepo:  storageRepo,
+		statusRepo:   statusRepo,
+		auditRepo:    auditRepo,
+		snapshotRepo: snapshotRepo,
+		logger:       logger,
+	}
+}
+
+type dashboardChangeService struct {
+	*Service
+	clusterRepo  repository.PveClusterRepository
+	nodeRepo     repository.PveNodeRepository
+	vmRepo       repository.PveVMRepository
+	storageRepo  repository.PveStorageRepository
+	statusRepo   repository.VMStatusEventRepository
+	auditRepo    repository.OperationAuditRepository
+	snapshotRepo repository.ClusterCapacitySnapshotRepository
+	logger       *log.Logger
+}
+
+func (s *dashboardChangeService) GetChanges(ctx context.Context, req *v1.DashboardChangesRequest) (*v1.DashboardChangesData, error) {
+	to := time.Now()
+	from := to.Add(-dashboardChangePeriods[v1.DashboardChangePeriod24h])
+	if period, ok := dashboardChangePeriods[req.Period]; ok {
+		from = to.Add(-period)
+	}
+	if req.Since != nil {
+		if !req.Since.Before(to) {
+			return nil, v1.WithDetail(v1.ErrInvalidParameter, "since must be in the past")
+		}
+		from = *req.Since
+	}
+
+	var clusters []*model.PveCluster
+	var clusterID int64
+	if req.ClusterID != nil {
+		cluster, err := s.clusterRepo.GetByID(ctx, *req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return nil, v1.ErrClusterNotFound
+		}
+		clusters = []*model.PveCluster{cluster}
+		clusterID = cluster.Id
+	} else {
+		var err error
+		if clusters, err = s.clusterRepo.List(ctx); err != nil {
+			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+	}
+
+	events, err := s.statusRepo.ListBetween(ctx, clusterID,
+		[]string{model.VMStatusEventAdded, model.VMStatusEventRemoved}, from, to)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm presence events", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	eventsByCluster := make(map[int64][]*model.VMStatusEvent)
+	for _, event := range events {
+		eventsByCluster[event.ClusterID] = append(eventsByCluster[event.ClusterID], event)
+	}
+
+	audits, err := s.auditRepo.ListChangesBetween(ctx, from, to, dashboardChangeAuditLimit+1)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list operation audits", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	data := &v1.DashboardChangesData{From: from, To: to, Clusters: []v1.DashboardClusterChanges{}}
+	if len(audits) > dashboardChangeAuditLimit {
+		audits = audits[:dashboardChangeAuditLimit]
+		data.Truncated = true
+	}
+
+	vmsByCluster := make(map[int64][]*model.PveVM, len(clusters))
+	for _, cluster := range clusters {
+		vms, err := s.clusterVMs(ctx, cluster.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list cluster vms", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			return nil, v1.ErrInternalServerError
+		}
+		vmsByCluster[cluster.Id] = vms
+	}
+	configChanges := collectConfigChanges(audits, clusters, vmsByCluster)
+
+	for _, cluster := range clusters {
+		vms := vmsByCluster[cluster.Id]
+		item := classifyPresenceEvents(eventsByCluster[cluster.Id], vms)
+		item.ClusterID = cluster.Id
+		item.ClusterName = cluster.ClusterName
+		item.ConfigChanges = configChanges[cluster.Id]
+		if item.ConfigChanges == nil {
+			item.ConfigChanges = []v1.DashboardVMConfigChange{}
+		}
+		sort.Slice(item.ConfigChanges, func(i, j int) bool {
+			return item.ConfigChanges[i].LastChange.After(item.ConfigChanges[j].LastChange)
+		})
+
+		capacity, err := s.capacityDelta(ctx, cluster.Id, vms, from)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to compute capacity delta", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			return nil, v1.ErrInternalServerError
+		}
+		item.Capacity = *capacity
+
+		data.Summary.Created += len(item.Created)
+		data.Summary.Deleted += len(item.Deleted)
+		data.Summary.Migrated += len(item.Migrations)
+		data.Summary.ConfigChanged += len(item.ConfigChanges)
+		data.Clusters = append(data.Clusters, item)
+	}
+	return data, nil
+}
+
+// clusterVMs 集群当前的虚拟机，并填充所在节点名称
+func (s *dashboardChangeService) clusterVMs(ctx context.Context, clusterID int64) ([]*model.PveVM, error) {
+	vms, err := s.vmRepo.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeRepo.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[int64]string, len(nodes))
+	for _, node := range nodes {
+		nodeNames[node.Id] = node.NodeName
+	}
+	for _, vm := range vms {
+		vm.NodeName = nodeNames[vm.NodeID]
+	}
+	return vms, nil
+}
+
+// classifyPresenceEvents 将虚拟机在节点上出现/消失的事件归类为新建、删除和迁移：
+// 同一 VMID 相邻的一次消失和一次出现发生在不同节点且名称相同时为迁移（两个节点的同步先后不定）；
+// 消失后未在时间段内出现，但当前仍在集群的其他节点上时也计为迁移（出现早于时间段开始）
+func classifyPresenceEvents(events []*model.VMStatusEvent, vms []*model.PveVM) v1.DashboardClusterChanges {
+	item := v1.DashboardClusterChanges{
+		Created:    []v1.DashboardVMChange{},
+		Deleted:    []v1.DashboardVMChange{},
+		Migrations: []v1.DashboardVMMigration{},
+	}
+	current := make(map[uint32]*model.PveVM, len(vms))
+	for _, vm := range vms {
+		current[vm.VMID] = vm
+	}
+
+	var order []uint32
+	byVMID := make(map[uint32][]*model.VMStatusEvent)
+	for _, event := range events {
+		if _, ok := byVMID[event.VMID]; !ok {
+			order = append(order, event.VMID)
+		}
+		byVMID[event.VMID] = append(byVMID[event.VMID], event)
+	}
+
+	for _, vmid := range order {
+		list := byVMID[vmid]
+		for i := 0; i < len(list); i++ {
+			event := list[i]
+			if i+1 < len(list) {
+				next := list[i+1]
+				if next.NewStatus != event.NewStatus && next.NodeName != event.NodeName && next.VmName == event.VmName {
+					removed, added := event, next
+					if event.NewStatus == model.VMStatusEventAdded {
+						removed, added = next, event
+					}
+					item.Migrations = append(item.Migrations, v1.DashboardVMMigration{
+						VMID: vmid, VmName: added.VmName, FromNode: removed.NodeName, ToNode: added.NodeName, Time: next.CreateTime,
+					})
+					i++
+					continue
+				}
+			}
+
+			change := v1.DashboardVMChange{VMID: vmid, VmName: event.VmName, NodeName: event.NodeName, Time: event.CreateTime}
+			if event.NewStatus == model.VMStatusEventAdded {
+				item.Created = append(item.Created, change)
+				continue
+			}
+			if vm, ok := current[vmid]; ok && vm.NodeName != event.NodeName && vm.VmName == event.VmName {
+				item.Migrations = append(item.Migrations, v1.DashboardVMMigration{
+					VMID: vmid, VmName: vm.VmName, FromNode: event.NodeName, ToNode: vm.NodeName, Time: event.CreateTime,
+				})
+				continue
+			}
+			item.Deleted = append(item.Deleted, change)
+		}
+	}
+
+	sort.SliceStable(item.Created, func(i, j int) bool { return item.Created[i].Time.Before(item.Created[j].Time) })
+	sort.SliceStable(item.Deleted, func(i, j int) bool { return item.Deleted[i].Time.Before(item.Deleted[j].Time) })
+	sort.SliceStable(item.Migrations, func(i, j int) bool { return item.Migrations[i].Time.Before(item.Migrations[j].Time) })
+	return item
+}
+
+// collectConfigChanges 从操作审计的调用明细中找出成功的虚拟机配置修改，按集群和 VMID 合并；
+// 调用按 API 地址归属集群，地址无法对应时（如直连节点）按当前在该节点上的虚拟机归属
+func collectConfigChanges(audits []*model.OperationAudit, clusters []*model.PveCluster, vmsByCluster map[int64][]*model.PveVM) map[int64][]v1.DashboardVMConfigChange {
+	clusterByHost := make(map[string]int64, len(clusters))
+	for _, cluster := range clusters {
+		if u, err := url.Parse(cluster.ApiUrl); err == nil && u.Host != "" {
+			clusterByHost[u.Host] = cluster.Id
+		}
+	}
+	type vmKey struct {
+		node string
+		vmid uint32
+	}
+	clusterByVM := make(map[vmKey]int64)
+	vmByKey := make(map[int64]map[uint32]*model.PveVM, len(vmsByCluster))
+	for clusterID, vms := range vmsByCluster {
+		vmByKey[clusterID] = make(map[uint32]*model.PveVM, len(vms))
+		for _, vm := range vms {
+			clusterByVM[vmKey{vm.NodeName, vm.VMID}] = clusterID
+			vmByKey[clusterID][vm.VMID] = vm
+		}
+	}
+
+	changes := make(map[int64]map[uint32]*v1.DashboardVMConfigChange)
+	var order []struct {
+		clusterID int64
+		vmid      uint32
+	}
+	for _, audit := range audits {
+		var calls []proxmox.CallRecord
+		if audit.Calls == "" || json.Unmarshal([]byte(audit.Calls), &calls) != nil {
+			continue
+		}
+		// 同一请求内对同一虚拟机的多次配置调用只计一次
+		seen := make(map[vmKey]bool)
+		for _, call := range calls {
+			if call.Method == "GET" || call.Status < 200 || call.Status >= 300 {
+				continue
+			}
+			match := vmConfigCallPattern.FindStringSubmatch(call.Path)
+			if match == nil {
+				continue
+			}
+			vmid64, err := strconv.ParseUint(match[2], 10, 32)
+			if err != nil {
+				continue
+			}
+			key := vmKey{match[1], uint32(vmid64)}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			clusterID, ok := clusterByHost[call.Host]
+			if !ok {
+				if clusterID, ok = clusterByVM[key]; !ok {
+					continue
+				}
+			}
+			if changes[clusterID] == nil {
+				changes[clusterID] = make(map[uint32]*v1.DashboardVMConfigChange)
+			}
+			change := changes[clusterID][key.vmid]
+			if change == nil {
+				change = &v1.DashboardVMConfigChange{VMID: key.vmid, NodeName: key.node, Users: []string{}}
+				if vm, ok := vmByKey[clusterID][key.vmid]; ok {
+					change.VmName = vm.VmName
+					change.NodeName = vm.NodeName
+				}
+				changes[clusterID][key.vmid] = change
+				order = append(order, struct {
+					clusterID int64
+					vmid      uint32
+				}{clusterID, key.vmid})
+			}
+			change.Count++
+			change.LastChange = audit.CreateTime
+			if audit.Username != "" && !slices.Contains(change.Users, audit.Username) {
+				change.Users = append(change.Users, audit.Username)
+			}
+		}
+	}
+
+	result := make(map[int64][]v1.DashboardVMConfigChange, len(changes))
+	for _, key := range order {
+		result[key.clusterID] = append(result[key.clusterID], *changes[key.clusterID][key.vmid])
+	}
+	return result
+}
+
+// capacityDelta 当前容量与 from 之前最近一次快照对比
+func (s *dashboardChangeService) capacityDelta(ctx context.Context, clusterID int64, vms []*model.PveVM, from time.Time) (*v1.DashboardCapacityDelta, error) {
+	storages, err := s.storageRepo.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	current := clusterCapacity(clusterID, vms, storages)
+	baseline, err := s.snapshotRepo.GetLatestBefore(ctx, clusterID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	hasBaseline := baseline != nil
+	if !hasBaseline {
+		baseline = &model.ClusterCapacitySnapshot{}
+	}
+	value := func(before, current int64) v1.DashboardCapacityValue {
+		if !hasBaseline {
+			return v1.DashboardCapacityValue{Current: current}
+		}
+		return v1.DashboardCapacityValue{Before: before, Current: current, Delta: current - before}
+	}
+	delta := &v1.DashboardCapacityDelta{
+		VMs:          value(int64(baseline.VMCount), int64(current.VMCount)),
+		RunningVMs:   value(int64(baseline.RunningVMs), int64(current.RunningVMs)),
+		VCPUs:        value(int64(baseline.VCPUs), int64(current.VCPUs)),
+		MemoryMB:     value(baseline.MemoryMB, current.MemoryMB),
+		DiskMB:       value(baseline.DiskMB, current.DiskMB),
+		StorageUsed:  value(baseline.StorageUsed, current.StorageUsed),
+		StorageTotal: value(baseline.StorageTotal, current.StorageTotal),
+	}
+	if hasBaseline {
+		baselineTime := baseline.CreateTime
+		delta.BaselineTime = &baselineTime
+	}
+	return delta, nil
+}
+
+// clusterCapacity 根据同步到数据库的虚拟机和存储计算集群容量，模板不计入，共享存储只计一次
+func clusterCapacity(clusterID int64, vms []*model.PveVM, storages []*model.PveStorage) *model.ClusterCapacitySnapshot {
+	snapshot := &model.ClusterCapacitySnapshot{ClusterID: clusterID}
+	for _, vm := range vms {
+		if vm.IsTemplate == 1 {
+			continue
+		}
+		snapshot.VMCount++
+		if vm.Status == "running" {
+			snapshot.RunningVMs++
+		}
+		snapshot.VCPUs += vm.CPUNum
+		snapshot.MemoryMB += int64(vm.MemorySize)
+		snapshot.DiskMB += int64(vm.DiskSize)
+	}
+	shared := make(map[string]bool)
+	for _, storage := range storages {
+		if storage.Shared == 1 {
+			if shared[storage.StorageName] {
+				continue
+			}
+			shared[storage.StorageName] = true
+		}
+		snapshot.StorageUsed += storage.Used
+		snapshot.StorageTotal += storage.Total
+	}
+	return snapshot
+}
+
+func (s *dashboardChangeService) RecordCapacitySnapshots(ctx context.Context) error {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return err
+		}
+		storages, err := s.storageRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return err
+		}
+		if err := s.snapshotRepo.Create(ctx, clusterCapacity(cluster.Id, vms, storages)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *dashboardChangeService) CleanupCapacitySnapshots(ctx context.Context, before time.Time) (int64, error) {
+	return s.snapshotRepo.DeleteBefore(ctx, before)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// dashboardVMFilterActive 是否按虚拟机属性（项目、团队、标签、环境）过滤
+func dashboardVMFilterActive(f v1.DashboardVMFilter) bool {
+	return f.AppId != "" || f.Team != "" || f.Tag != "" || f.Environment != ""
+}
+
+// dashboardFilterActive 是否指定了任一过滤条件
+func dashboardFilterActive(f v1.DashboardVMFilter) bool {
+	return dashboardVMFilterActive(f) || f.NodeID != nil
+}
+
+// applyNodeFilter 指定 node_id 时校验节点存在，并把集群范围收窄到节点所在集群
+func (s *dashboardService) applyNodeFilter(ctx context.Context, f v1.DashboardVMFilter, clusters []*model.PveCluster) ([]*model.PveCluster, error) {
+	if f.NodeID == nil {
+		return clusters, nil
+	}
+	node, err := s.nodeRepo.GetByID(ctx, *f.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", *f.NodeID)
+	}
+	filtered := make([]*model.PveCluster, 0, 1)
+	for _, cluster := range clusters {
+		if cluster.Id == node.ClusterID {
+			filtered = append(filtered, cluster)
+		}
+	}
+	return filtered, nil
+}
+
+// dashboardVMMatcher 在单个集群内按过滤条件匹配 /cluster/resources 中的虚拟机和节点。
+// 项目、团队、环境来自平台数据库中的虚拟机记录，标签来自 Proxmox
+type dashboardVMMatcher struct {
+	filter    v1.DashboardVMFilter
+	vmFilter  bool
+	nodeIDs   map[string]int64        // 节点名称 -> 节点ID
+	vms       map[string]*model.PveVM // "节点名称/vmid" -> 虚拟机
+	nodeName  string                  // node_id 对应的节点名称
+	hostNodes map[string]bool         // 运行匹配虚拟机的节点
+	observed  bool
+}
+
+func (s *dashboardService) newDashboardVMMatcher(ctx context.Context, cluster *model.PveCluster, f v1.DashboardVMFilter) (*dashboardVMMatcher, error) {
+	m := &dashboardVMMatcher{
+		filter:    f,
+		vmFilter:  dashboardVMFilterActive(f),
+		nodeIDs:   make(map[string]int64),
+		vms:       make(map[string]*model.PveVM),
+		hostNodes: make(map[string]bool),
+	}
+	if !dashboardFilterActive(f) {
+		return m, nil
+	}
+
+	nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		return nil, err
+	}
+	nodeNames := make(map[int64]string, len(nodes))
+	for _, node := range nodes {
+		m.nodeIDs[node.NodeName] = node.Id
+		nodeNames[node.Id] = node.NodeName
+		if f.NodeID != nil && node.Id == *f.NodeID {
+			m.nodeName = node.NodeName
+		}
+	}
+
+	if f.AppId != "" || f.Team != "" || f.Environment != "" {
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range vms {
+			m.vms[fmt.Sprintf("%s/%d", nodeNames[vm.NodeID], vm.VMID)] = vm
+		}
+	}
+	return m, nil
+}
+
+// observe 根据集群资源记录运行匹配虚拟机的节点，只需调用一次
+func (m *dashboardVMMatcher) observe(resources []map[string]interface{}) {
+	if m.observed || !m.vmFilter {
+		return
+	}
+	m.observed = true
+	for _, resource := range resources {
+		if m.matchVM(resource) {
+			nodeName, _ := resource["node"].(string)
+			m.hostNodes[nodeName] = true
+		}
+	}
+}
+
+// matchVM 虚拟机（qemu / lxc，不含模板）是否满足过滤条件
+func (m *dashboardVMMatcher) matchVM(resource map[string]interface{}) bool {
+	resourceType, _ := resource["type"].(string)
+	if resourceType != "qemu" && resourceType != "lxc" {
+		return false
+	}
+	if !dashboardFilterActive(m.filter) {
+		return true
+	}
+	if template, _ := resource["template"].(float64); template == 1 {
+		return false
+	}
+
+	nodeName, _ := resource["node"].(string)
+	if m.filter.NodeID != nil && nodeName != m.nodeName {
+		return false
+	}
+
+	if m.filter.Tag != "" {
+		tags, _ := resource["tags"].(string)
+		matched := false
+		for _, tag := range strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+			if strings.EqualFold(tag, m.filter.Tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if m.filter.AppId != "" || m.filter.Team != "" || m.filter.Environment != "" {
+		vmid, _ := resource["vmid"].(float64)
+		vm := m.vms[fmt.Sprintf("%s/%d", nodeName, uint32(vmid))]
+		if vm == nil {
+			return false
+		}
+		if m.filter.AppId != "" && vm.AppId != m.filter.AppId {
+			return false
+		}
+		if m.filter.Team != "" && vm.Team != m.filter.Team {
+			return false
+		}
+		if m.filter.Environment != "" && vm.Environment != m.filter.Environment {
+			return false
+		}
+	}
+	return true
+}
+
+// matchNode 节点是否在过滤范围内：指定 node_id 时只匹配该节点，按虚拟机过滤时匹配运行这些虚拟机的节点
+func (m *dashboardVMMatcher) matchNode(nodeName string) bool {
+	if m.filter.NodeID != nil && nodeName != m.nodeName {
+		return false
+	}
+	if m.vmFilter {
+		return m.hostNodes[nodeName]
+	}
+	return true
+}
+
+// matchedNodeIDs 返回过滤范围内的节点ID
+func (m *dashboardVMMatcher) matchedNodeIDs() []int64 {
+	ids := make([]int64, 0)
+	for name, id := range m.nodeIDs {
+		if m.matchNode(name) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+
+	"go.uber.org/zap"
+)
+
+// dashboardWidgets 可用的 Dashboard 面板，每个面板对应一个独立的数据接口
+var dashboardWidgets = []v1.DashboardWidget{
+	{Key: "overview", Name: "总览", Endpoint: "/api/v1/dashboard/overview", DefaultWidth: 12, DefaultHeight: 3},
+	{Key: "sites", Name: "站点", Endpoint: "/api/v1/dashboard/sites", DefaultWidth: 12, DefaultHeight: 4},
+	{Key: "resources", Name: "资源容量", Endpoint: "/api/v1/dashboard/resources", DefaultWidth: 6, DefaultHeight: 4},
+	{Key: "hotspots", Name: "压力和风险焦点", Endpoint: "/api/v1/dashboard/hotspots", DefaultWidth: 6, DefaultHeight: 6},
+	{Key: "risks", Name: "最近风险", Endpoint: "/api/v1/dashboard/risks", DefaultWidth: 6, DefaultHeight: 4},
+	{Key: "tasks", Name: "运行中的任务", Endpoint: "/api/v1/dashboard/operations", DefaultWidth: 6, DefaultHeight: 4},
+	{Key: "alerts", Name: "告警", Endpoint: "/api/v1/dashboard/alerts", DefaultWidth: 6, DefaultHeight: 4},
+	{Key: "energy", Name: "能耗", Endpoint: "/api/v1/dashboard/energy", DefaultWidth: 6, DefaultHeight: 4},
+}
+
+// dashboardPresets 预设布局：ops 面向运维值班，management 面向管理层
+var dashboardPresets = map[string][]string{
+	model.DashboardPresetOps:        {"overview", "hotspots", "alerts", "risks", "tasks", "resources"},
+	model.DashboardPresetManagement: {"overview", "sites", "resources", "energy"},
+}
+
+func findDashboardWidget(key string) *v1.DashboardWidget {
+	for i := range dashboardWidgets {
+		if dashboardWidgets[i].Key == key {
+			return &dashboardWidgets[i]
+		}
+	}
+	return nil
+}
+
+// presetLayout 按面板默认尺寸在 12 栅格中从左到右、从上到下排列预设面板
+func presetLayout(preset string) v1.DashboardLayoutData {
+	items := make([]v1.DashboardLayoutItem, 0)
+	x, y, rowHeight := 0, 0, 0
+	for _, key := range dashboardPresets[preset] {
+		w := findDashboardWidget(key)
+		if x+w.DefaultWidth > 12 {
+			x, y, rowHeight = 0, y+rowHeight, 0
+		}
+		items = append(items, v1.DashboardLayoutItem{
+			Widget: key,
+			X:      x,
+			Y:      y,
+			W:      w.DefaultWidth,
+			H:      w.DefaultHeight,
+		})
+		x += w.DefaultWidth
+		if w.DefaultHeight > rowHeight {
+			rowHeight = w.DefaultHeight
+		}
+	}
+	return v1.DashboardLayoutData{
+		Preset:  preset,
+		Scope:   "all",
+		Widgets: items,
+	}
+}
+
+// GetWidgets 获取可用面板及预设布局
+func (s *dashboardService) GetWidgets(ctx context.Context) *v1.DashboardWidgetsData {
+	return &v1.DashboardWidgetsData{
+		Widgets: dashboardWidgets,
+		Presets: []v1.DashboardLayoutData{
+			presetLayout(model.DashboardPresetOps),
+			presetLayout(model.DashboardPresetManagement),
+		},
+	}
+}
+
+// GetLayout 获取用户布局。指定 preset 时返回预设布局；用户未保存过布局时返回 ops 预设
+func (s *dashboardService) GetLayout(ctx context.Context, userID string, req *v1.GetDashboardLayoutRequest) (*v1.DashboardLayoutData, error) {
+	if req.Preset != "" {
+		data := presetLayout(req.Preset)
+		return &data, nil
+	}
+
+	layout, err := s.layoutRepo.GetByUser(ctx, userID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get dashboard layout", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if layout == nil {
+		data := presetLayout(model.DashboardPresetOps)
+		return &data, nil
+	}
+	return s.convertLayout(ctx, layout), nil
+}
+
+// SaveLayout 保存用户布局，每个用户只保留一份
+func (s *dashboardService) SaveLayout(ctx context.Context, userID string, req *v1.SaveDashboardLayoutRequest) (*v1.DashboardLayoutData, error) {
+	if userID == "" {
+		return nil, v1.ErrUnauthorized
+	}
+
+	seen := make(map[string]bool, len(req.Widgets))
+	for i, item := range req.Widgets {
+		if findDashboardWidget(item.Widget) == nil {
+			return nil, v1.WithDetailf(v1.ErrInvalidParameter, "widgets[%d]: unknown widget %q", i, item.Widget)
+		}
+		if seen[item.Widget] {
+			return nil, v1.WithDetailf(v1.ErrInvalidParameter, "widgets[%d]: duplicate widget %q", i, item.Widget)
+		}
+		seen[item.Widget] = true
+		if item.X+item.W > 12 {
+			return nil, v1.WithDetailf(v1.ErrInvalidParameter, "widgets[%d]: x + w exceeds 12 columns", i)
+		}
+	}
+
+	scope := req.Scope
+	if scope == "" {
+		scope = "all"
+	}
+	if scope == "cluster" && req.ClusterID == nil {
+		return nil, v1.WithDetail(v1.ErrInvalidParameter, "cluster_id is required when scope is cluster")
+	}
+	if scope == "site" && req.SiteID == nil {
+		return nil, v1.WithDetail(v1.ErrInvalidParameter, "site_id is required when scope is site")
+	}
+	// 校验默认范围仍然存在
+	if _, err := s.getScopeClusters(ctx, scope, req.ClusterID, req.SiteID); err != nil {
+		return nil, err
+	}
+	preset := req.Preset
+	if preset == "" {
+		preset = model.DashboardPresetCustom
+	}
+
+	widgets, err := json.Marshal(req.Widgets)
+	if err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+
+	layout, err := s.layoutRepo.GetByUser(ctx, userID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get dashboard layout", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if layout == nil {
+		layout = &model.DashboardLayout{UserId: userID}
+	}
+	layout.Preset = preset
+	layout.Scope = scope
+	layout.ClusterID = nil
+	layout.SiteID = nil
+	switch scope {
+	case "cluster":
+		layout.ClusterID = req.ClusterID
+	case "site":
+		layout.SiteID = req.SiteID
+	}
+	layout.Widgets = string(widgets)
+
+	if err := s.layoutRepo.Save(ctx, layout); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save dashboard layout", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return s.convertLayout(ctx, layout), nil
+}
+
+// ResetLayout 删除用户布局，恢复为预设布局
+func (s *dashboardService) ResetLayout(ctx context.Context, userID string) error {
+	if userID == "" {
+		return v1.ErrUnauthorized
+	}
+	if err := s.layoutRepo.DeleteByUser(ctx, userID); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete dashboard layout", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *dashboardService) convertLayout(ctx context.Context, layout *model.DashboardLayout) *v1.DashboardLayoutData {
+	items := make([]v1.DashboardLayoutItem, 0)
+	if layout.Widgets != "" {
+		if err := json.Unmarshal([]byte(layout.Widgets), &items); err != nil {
+			s.logger.WithContext(ctx).Warn("invalid dashboard layout widgets", zap.String("user_id", layout.UserId), zap.Error(err))
+		}
+	}
+	// 面板下线后忽略已保存的旧面板
+	valid := make([]v1.DashboardLayoutItem, 0, len(items))
+	for _, item := range items {
+		if findDashboardWidget(item.Widget) != nil {
+			valid = append(valid, item)
+		}
+	}
+	updateTime := layout.UpdateTime
+	return &v1.DashboardLayoutData{
+		Preset:     layout.Preset,
+		Scope:      layout.Scope,
+		ClusterID:  layout.ClusterID,
+		SiteID:     layout.SiteID,
+		Widgets:    valid,
+		Customized: true,
+		UpdateTime: &updateTime,
+	}
+}
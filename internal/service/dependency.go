@@ -0,0 +1,508 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// DependencyService "谁在用它"：查询依赖某个存储、节点、模板或网段的资源，用于下线前评估影响
+type DependencyService interface {
+	Lookup(ctx context.Context, req *v1.DependencyLookupRequest) (*v1.DependencyLookupData, error)
+}
+
+func NewDependencyService(
+	service *Service,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	storageRepo repository.PveStorageRepository,
+	templateRepo repository.VmTemplateRepository,
+	templateInstanceRepo repository.TemplateInstanceRepository,
+	ipRepo repository.VMIPAddressRepository,
+	profileRepo repository.VMProfileRepository,
+	logger *log.Logger,
+) DependencyService {
+	return &dependencyService{
+		Service:              service,
+		vmRepo:               vmRepo,
+		nodeRepo:             nodeRepo,
+		clusterRepo:          clusterRepo,
+		storageRepo:          storageRepo,
+		templateRepo:         templateRepo,
+		templateInstanceRepo: templateInstanceRepo,
+		ipRepo:               ipRepo,
+		profileRepo:          profileRepo,
+		logger:               logger,
+	}
+}
+
+type dependencyService struct {
+	*Service
+	vmRepo               repository.PveVMRepository
+	nodeRepo             repository.PveNodeRepository
+	clusterRepo          repository.PveClusterRepository
+	storageRepo          repository.PveStorageRepository
+	templateRepo         repository.VmTemplateRepository
+	templateInstanceRepo repository.TemplateInstanceRepository
+	ipRepo               repository.VMIPAddressRepository
+	profileRepo          repository.VMProfileRepository
+	logger               *log.Logger
+}
+
+// dependencyResult 收集依赖项，同一资源只保留一条，多条依赖关系合并到 detail
+type dependencyResult struct {
+	data  *v1.DependencyLookupData
+	index map[string]*v1.DependencyItem
+}
+
+func newDependencyResult(targetType, target string) *dependencyResult {
+	return &dependencyResult{
+		data: &v1.DependencyLookupData{
+			Type:   targetType,
+			Target: target,
+			Counts: make(map[string]int),
+			Items:  []*v1.DependencyItem{},
+			Source: "database",
+		},
+		index: make(map[string]*v1.DependencyItem),
+	}
+}
+
+func (r *dependencyResult) add(item *v1.DependencyItem) {
+	key := fmt.Sprintf("%s/%d", item.Kind, item.Id)
+	if item.Id == 0 {
+		// Proxmox 上存在但平台未同步的虚拟机按 集群/VMID 区分
+		key = fmt.Sprintf("%s/%d/%d", item.Kind, item.ClusterID, item.VMID)
+	}
+	if existing, ok := r.index[key]; ok {
+		if item.Detail != "" && !strings.Contains(existing.Detail, item.Detail) {
+			if existing.Detail != "" {
+				existing.Detail += "; "
+			}
+			existing.Detail += item.Detail
+		}
+		return
+	}
+	r.index[key] = item
+	r.data.Items = append(r.data.Items, item)
+	r.data.Counts[item.Kind]++
+}
+
+func (r *dependencyResult) addVM(vm *model.PveVM, detail string) {
+	r.add(&v1.DependencyItem{
+		Kind:      v1.DependencyKindVM,
+		Id:        vm.Id,
+		Name:      vm.VmName,
+		VMID:      vm.VMID,
+		ClusterID: vm.ClusterID,
+		NodeName:  vm.NodeName,
+		Status:    vm.Status,
+		Owner:     vm.Owner,
+		Detail:    detail,
+	})
+}
+
+func (r *dependencyResult) warn(format string, args ...interface{}) {
+	r.data.Warnings = append(r.data.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (r *dependencyResult) finish() *v1.DependencyLookupData {
+	kindOrder := map[string]int{
+		v1.DependencyKindVM:               0,
+		v1.DependencyKindTemplateInstance: 1,
+		v1.DependencyKindIPAddress:        2,
+		v1.DependencyKindVMProfile:        3,
+		v1.DependencyKindStorage:          4,
+	}
+	sort.SliceStable(r.data.Items, func(i, j int) bool {
+		a, b := r.data.Items[i], r.data.Items[j]
+		if a.Kind != b.Kind {
+			return kindOrder[a.Kind] < kindOrder[b.Kind]
+		}
+		if a.VMID != b.VMID {
+			return a.VMID < b.VMID
+		}
+		return a.Name < b.Name
+	})
+	r.data.Total = len(r.data.Items)
+	return r.data
+}
+
+func (s *dependencyService) Lookup(ctx context.Context, req *v1.DependencyLookupRequest) (*v1.DependencyLookupData, error) {
+	switch req.Type {
+	case v1.DependencyTargetStorage:
+		return s.lookupStorage(ctx, req.ID)
+	case v1.DependencyTargetNode:
+		return s.lookupNode(ctx, req.ID)
+	case v1.DependencyTargetTemplate:
+		return s.lookupTemplate(ctx, req.ID)
+	case v1.DependencyTargetSubnet:
+		return s.lookupSubnet(ctx, req.CIDR, req.ClusterID)
+	default:
+		return nil, v1.WithDetailf(v1.ErrBadRequest, "unsupported type %q", req.Type)
+	}
+}
+
+// lookupStorage 存储上的虚拟机磁盘 / ISO、模板实例和引用该存储的创建规格。
+// 磁盘引用实时读取 Proxmox 中的虚拟机配置，集群不可达时退回数据库中创建时记录的存储
+func (s *dependencyService) lookupStorage(ctx context.Context, storageID int64) (*v1.DependencyLookupData, error) {
+	storage, err := s.storageRepo.GetByID(ctx, storageID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get storage", zap.Error(err), zap.Int64("storage_id", storageID))
+		return nil, v1.ErrInternalServerError
+	}
+	if storage == nil {
+		return nil, v1.WithDetailf(v1.ErrStorageNotFound, "storage_id=%d", storageID)
+	}
+	shared := storage.Shared == 1
+	target := storage.StorageName + "@" + storage.NodeName
+	if shared {
+		target = storage.StorageName + " (shared)"
+	}
+	result := newDependencyResult(v1.DependencyTargetStorage, target)
+
+	vms, err := s.vmRepo.GetByClusterID(ctx, storage.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster vms", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	vmsByVMID := make(map[uint32]*model.PveVM, len(vms))
+	for _, vm := range vms {
+		vmsByVMID[vm.VMID] = vm
+	}
+
+	if err := s.collectStorageReferences(ctx, storage, vmsByVMID, result); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to read volume references from proxmox, falling back to database",
+			zap.Error(err), zap.Int64("storage_id", storageID))
+		result.warn("could not read VM configs from Proxmox (%v); VM disks are taken from the storage recorded at creation and may be incomplete", err)
+		dbVMs, err := s.vmRepo.ListByStorage(ctx, storage.ClusterID, storage.StorageName)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list vms by storage", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		for _, vm := range dbVMs {
+			if shared || vm.NodeName == "" || vm.NodeName == storage.NodeName {
+				s.fillNodeName(ctx, vm)
+				if shared || vm.NodeName == storage.NodeName {
+					result.addVM(vm, "created on "+storage.StorageName)
+				}
+			}
+		}
+	}
+
+	// 模板实例：共享存储上的实例可能记录在任一节点的存储 ID 上，按集群 + 存储名称匹配
+	storages, err := s.storageRepo.ListByStorageName(ctx, storage.ClusterID, storage.StorageName)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list storages", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	storageIDs := map[int64]bool{storage.Id: true}
+	if shared {
+		for _, st := range storages {
+			storageIDs[st.Id] = true
+		}
+	}
+	if err := s.addTemplateInstances(ctx, storage.ClusterID, result, func(inst *model.TemplateInstance) bool {
+		return storageIDs[inst.StorageID]
+	}); err != nil {
+		return nil, err
+	}
+
+	profiles, err := s.profileRepo.List(ctx, storage.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm profiles", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, p := range profiles {
+		if p.Storage == storage.StorageName {
+			result.add(&v1.DependencyItem{
+				Kind:      v1.DependencyKindVMProfile,
+				Id:        p.Id,
+				Name:      p.Name,
+				ClusterID: p.ClusterID,
+				Detail:    "default storage",
+			})
+		}
+	}
+	return result.finish(), nil
+}
+
+// collectStorageReferences 从 Proxmox 虚拟机 / 容器配置中找出引用该存储的磁盘、ISO 和链接克隆基础镜像
+func (s *dependencyService) collectStorageReferences(ctx context.Context, storage *model.PveStorage, vmsByVMID map[uint32]*model.PveVM, result *dependencyResult) error {
+	cluster, err := s.clusterRepo.GetByID(ctx, storage.ClusterID)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %d not found", storage.ClusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return err
+	}
+	referenced, _, err := collectVolumeReferences(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	prefix := storage.StorageName + ":"
+	for volume, ref := range referenced {
+		if !strings.HasPrefix(volume, prefix) {
+			continue
+		}
+		// 本地存储只统计同一节点上的虚拟机
+		if storage.Shared != 1 && ref.Node != storage.NodeName {
+			continue
+		}
+		detail := ref.Key + " -> " + volume
+		if vm, ok := vmsByVMID[ref.VMID]; ok {
+			vm.NodeName = ref.Node
+			result.addVM(vm, detail)
+			continue
+		}
+		result.add(&v1.DependencyItem{
+			Kind:      v1.DependencyKindVM,
+			Name:      fmt.Sprintf("vmid %d (not synced)", ref.VMID),
+			VMID:      ref.VMID,
+			ClusterID: storage.ClusterID,
+			NodeName:  ref.Node,
+			Detail:    detail,
+		})
+	}
+	result.data.Source = "proxmox"
+	return nil
+}
+
+// lookupNode 节点上的虚拟机、模板实例和本地存储
+func (s *dependencyService) lookupNode(ctx context.Context, nodeID int64) (*v1.DependencyLookupData, error) {
+	node, err := s.nodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err), zap.Int64("node_id", nodeID))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", nodeID)
+	}
+	result := newDependencyResult(v1.DependencyTargetNode, node.NodeName)
+
+	vms, err := s.vmRepo.ListByNodeID(ctx, node.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node vms", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, vm := range vms {
+		vm.NodeName = node.NodeName
+		detail := "runs on node"
+		if vm.IsTemplate == 1 {
+			detail = "template on node"
+		}
+		result.addVM(vm, detail)
+	}
+
+	instances, err := s.templateInstanceRepo.ListByNodeID(ctx, node.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node template instances", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if err := s.addInstances(ctx, instances, result); err != nil {
+		return nil, err
+	}
+
+	storages, err := s.storageRepo.GetByClusterID(ctx, node.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list storages", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, st := range storages {
+		if st.NodeName != node.NodeName || st.Shared == 1 {
+			continue
+		}
+		result.add(&v1.DependencyItem{
+			Kind:      v1.DependencyKindStorage,
+			Id:        st.Id,
+			Name:      st.StorageName,
+			ClusterID: st.ClusterID,
+			NodeName:  st.NodeName,
+			Detail:    fmt.Sprintf("local %s storage, %d bytes used", st.Type, st.Used),
+		})
+	}
+	return result.finish(), nil
+}
+
+// lookupTemplate 模板的各节点实例和从模板克隆的虚拟机
+func (s *dependencyService) lookupTemplate(ctx context.Context, templateID int64) (*v1.DependencyLookupData, error) {
+	template, err := s.templateRepo.GetByID(ctx, templateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template", zap.Error(err), zap.Int64("template_id", templateID))
+		return nil, v1.ErrInternalServerError
+	}
+	if template == nil {
+		return nil, v1.WithDetailf(v1.ErrTemplateNotFound, "template_id=%d", templateID)
+	}
+	result := newDependencyResult(v1.DependencyTargetTemplate, template.TemplateName)
+
+	vms, err := s.vmRepo.ListByTemplateID(ctx, template.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list template vms", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, vm := range vms {
+		if vm.IsTemplate == 1 {
+			continue
+		}
+		s.fillNodeName(ctx, vm)
+		result.addVM(vm, "cloned from template")
+	}
+
+	instances, err := s.templateInstanceRepo.ListByTemplateID(ctx, template.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list template instances", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if err := s.addInstances(ctx, instances, result); err != nil {
+		return nil, err
+	}
+	return result.finish(), nil
+}
+
+// lookupSubnet 网段内登记的 IP 地址及其虚拟机
+func (s *dependencyService) lookupSubnet(ctx context.Context, cidr string, clusterID int64) (*v1.DependencyLookupData, error) {
+	_, subnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+	if err != nil {
+		return nil, v1.WithDetailf(v1.ErrBadRequest, "invalid cidr %q", cidr)
+	}
+	result := newDependencyResult(v1.DependencyTargetSubnet, subnet.String())
+
+	ips, err := s.ipRepo.List(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list ip addresses", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	vmCache := make(map[int64]*model.PveVM)
+	for _, addr := range ips {
+		ip := parseAddress(addr.IPAddress)
+		if ip == nil || !subnet.Contains(ip) {
+			continue
+		}
+		item := &v1.DependencyItem{
+			Kind:      v1.DependencyKindIPAddress,
+			Id:        addr.Id,
+			Name:      addr.IPAddress,
+			ClusterID: addr.ClusterID,
+			Detail:    strings.TrimSpace(addr.NicName + " " + addr.MacAddress),
+		}
+		result.add(item)
+		if addr.VMId == 0 {
+			continue
+		}
+		vm, ok := vmCache[addr.VMId]
+		if !ok {
+			if vm, err = s.vmRepo.GetByID(ctx, addr.VMId); err != nil {
+				s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err), zap.Int64("vm_id", addr.VMId))
+				return nil, v1.ErrInternalServerError
+			}
+			if vm != nil {
+				s.fillNodeName(ctx, vm)
+			}
+			vmCache[addr.VMId] = vm
+		}
+		if vm == nil {
+			continue
+		}
+		item.VMID = vm.VMID
+		item.NodeName = vm.NodeName
+		result.addVM(vm, "ip "+addr.IPAddress)
+	}
+	return result.finish(), nil
+}
+
+// parseAddress 解析登记的 IP 地址，兼容带前缀长度的写法（10.0.0.5/24）
+func parseAddress(raw string) net.IP {
+	raw = strings.TrimSpace(raw)
+	if ip, _, err := net.ParseCIDR(raw); err == nil {
+		return ip
+	}
+	return net.ParseIP(raw)
+}
+
+// addTemplateInstances 添加集群内满足条件的模板实例
+func (s *dependencyService) addTemplateInstances(ctx context.Context, clusterID int64, result *dependencyResult, match func(*model.TemplateInstance) bool) error {
+	templates, err := s.templateRepo.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list templates", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	var matched []*model.TemplateInstance
+	for _, t := range templates {
+		instances, err := s.templateInstanceRepo.ListByTemplateID(ctx, t.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list template instances", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		for _, inst := range instances {
+			if match(inst) {
+				matched = append(matched, inst)
+			}
+		}
+	}
+	return s.addInstances(ctx, matched, result)
+}
+
+func (s *dependencyService) addInstances(ctx context.Context, instances []*model.TemplateInstance, result *dependencyResult) error {
+	if len(instances) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(instances))
+	for _, inst := range instances {
+		ids = append(ids, inst.TemplateID)
+	}
+	templates, err := s.templateRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get templates", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	for _, inst := range instances {
+		name := fmt.Sprintf("template %d", inst.TemplateID)
+		if t, ok := templates[inst.TemplateID]; ok {
+			name = t.TemplateName
+		}
+		detail := "on " + inst.StorageName
+		if inst.IsPrimary == 1 {
+			detail = "primary instance " + detail
+		}
+		result.add(&v1.DependencyItem{
+			Kind:      v1.DependencyKindTemplateInstance,
+			Id:        inst.Id,
+			Name:      name,
+			VMID:      inst.VMID,
+			ClusterID: inst.ClusterID,
+			NodeName:  inst.NodeName,
+			Status:    inst.Status,
+			Detail:    detail,
+		})
+	}
+	return nil
+}
+
+// fillNodeName 数据库中虚拟机只记录 node_id，补全节点名称
+func (s *dependencyService) fillNodeName(ctx context.Context, vm *model.PveVM) {
+	if vm.NodeName != "" || vm.NodeID == 0 {
+		return
+	}
+	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
+	if err != nil || node == nil {
+		return
+	}
+	vm.NodeName = node.NodeName
+}
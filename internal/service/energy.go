@@ -0,0 +1,406 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 同时读取功率的 BMC 数量上限
+const energyCollectConcurrency = 8
+
+type EnergyService interface {
+	GetReport(ctx context.Context, req *v1.GetEnergyReportRequest) (*v1.EnergyReportData, error)
+	ExportReportCSV(ctx context.Context, req *v1.GetEnergyReportRequest) ([]byte, error)
+	// CollectPower 读取所有开启功率采集的节点 BMC，按采集间隔折算为能耗累加到当天，并分摊到运行中的虚拟机
+	CollectPower(ctx context.Context, interval time.Duration) error
+}
+
+func NewEnergyService(
+	service *Service,
+	conf *viper.Viper,
+	energyRepo repository.EnergyRepository,
+	bmcRepo repository.NodeBMCRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	logger *log.Logger,
+) EnergyService {
+	return &energyService{
+		conf:        conf,
+		energyRepo:  energyRepo,
+		bmcRepo:     bmcRepo,
+		clusterRepo: clusterRepo,
+		nodeRepo:    nodeRepo,
+		vmRepo:      vmRepo,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type energyService struct {
+	conf        *viper.Viper
+	energyRepo  repository.EnergyRepository
+	bmcRepo     repository.NodeBMCRepository
+	clusterRepo repository.PveClusterRepository
+	nodeRepo    repository.PveNodeRepository
+	vmRepo      repository.PveVMRepository
+	*Service
+	logger *log.Logger
+}
+
+// energyAmount 报表分组内的累计能耗
+type energyAmount struct {
+	item     v1.EnergyReportItem
+	energyWh float64
+	vmWh     float64 // 已分摊到虚拟机的能耗，用于计算节点空闲能耗
+	maxWatts float64
+}
+
+func (s *energyService) GetReport(ctx context.Context, req *v1.GetEnergyReportRequest) (*v1.EnergyReportData, error) {
+	start, end, err := parseReportMonth(req.Month)
+	if err != nil {
+		return nil, err
+	}
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = v1.EnergyGroupByNode
+	}
+	startDate, endDate := start.Format(time.DateOnly), end.AddDate(0, 0, -1).Format(time.DateOnly)
+	var clusterIDs []int64
+	if req.ClusterID > 0 {
+		clusterIDs = []int64{req.ClusterID}
+	}
+
+	groups := make(map[string]*energyAmount)
+	group := func(key string, init v1.EnergyReportItem) *energyAmount {
+		g, ok := groups[key]
+		if !ok {
+			g = &energyAmount{item: init}
+			groups[key] = g
+		}
+		return g
+	}
+
+	switch groupBy {
+	case v1.EnergyGroupByNode, v1.EnergyGroupByCluster:
+		nodeUsages, err := s.energyRepo.ListNodeEnergy(ctx, startDate, endDate, clusterIDs, req.NodeID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list node energy", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		vmUsages, err := s.energyRepo.ListVMEnergy(ctx, startDate, endDate, clusterIDs, req.NodeID, "")
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list vm energy", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		key := func(clusterID, nodeID int64) (string, v1.EnergyReportItem) {
+			if groupBy == v1.EnergyGroupByCluster {
+				return strconv.FormatInt(clusterID, 10), v1.EnergyReportItem{ClusterID: clusterID}
+			}
+			return strconv.FormatInt(nodeID, 10), v1.EnergyReportItem{ClusterID: clusterID, NodeID: nodeID}
+		}
+		for _, u := range nodeUsages {
+			g := group(key(u.ClusterID, u.NodeID))
+			g.energyWh += u.EnergyWh
+			g.maxWatts = math.Max(g.maxWatts, u.MaxWatts)
+		}
+		for _, u := range vmUsages {
+			k, _ := key(u.ClusterID, u.NodeID)
+			if g, ok := groups[k]; ok {
+				g.vmWh += u.EnergyWh
+			}
+		}
+	default:
+		vmUsages, err := s.energyRepo.ListVMEnergy(ctx, startDate, endDate, clusterIDs, req.NodeID, req.AppId)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list vm energy", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		for _, u := range vmUsages {
+			var g *energyAmount
+			switch groupBy {
+			case v1.EnergyGroupByApp:
+				g = group(u.AppId, v1.EnergyReportItem{AppId: u.AppId})
+			case v1.EnergyGroupByCostCenter:
+				g = group(u.CostCenter, v1.EnergyReportItem{CostCenter: u.CostCenter})
+			default:
+				g = group(strconv.FormatInt(u.VmId, 10), v1.EnergyReportItem{VmId: u.VmId})
+				// 同一虚拟机当月可能迁移过，展示最近一天的节点和名称
+				g.item.VmName, g.item.AppId, g.item.CostCenter, g.item.ClusterID, g.item.NodeID = u.VmName, u.AppId, u.CostCenter, u.ClusterID, u.NodeID
+			}
+			g.energyWh += u.EnergyWh
+		}
+	}
+
+	intensity := s.conf.GetFloat64("energy.carbon_intensity")
+	data := &v1.EnergyReportData{
+		Month:           req.Month,
+		GroupBy:         groupBy,
+		CarbonIntensity: intensity,
+		Items:           make([]v1.EnergyReportItem, 0, len(groups)),
+	}
+	var totalWh float64
+	for _, g := range groups {
+		item := g.item
+		item.EnergyKwh = roundEnergy(g.energyWh / 1000)
+		item.CarbonKg = roundEnergy(g.energyWh / 1000 * intensity / 1000)
+		if groupBy == v1.EnergyGroupByNode || groupBy == v1.EnergyGroupByCluster {
+			item.IdleKwh = roundEnergy(math.Max(g.energyWh-g.vmWh, 0) / 1000)
+		}
+		if groupBy == v1.EnergyGroupByNode {
+			item.MaxWatts = g.maxWatts
+		}
+		totalWh += g.energyWh
+		data.Items = append(data.Items, item)
+	}
+	data.TotalKwh = roundEnergy(totalWh / 1000)
+	data.TotalCarbonKg = roundEnergy(totalWh / 1000 * intensity / 1000)
+
+	if err := s.fillReportNames(ctx, data.Items); err != nil {
+		return nil, err
+	}
+
+	// 能耗从高到低
+	sort.Slice(data.Items, func(i, j int) bool {
+		return data.Items[i].EnergyKwh > data.Items[j].EnergyKwh
+	})
+	return data, nil
+}
+
+// fillReportNames 填充报表中的集群和节点名称
+func (s *energyService) fillReportNames(ctx context.Context, items []v1.EnergyReportItem) error {
+	clusterIDs := make([]int64, 0, len(items))
+	nodeIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		if item.ClusterID > 0 {
+			clusterIDs = append(clusterIDs, item.ClusterID)
+		}
+		if item.NodeID > 0 {
+			nodeIDs = append(nodeIDs, item.NodeID)
+		}
+	}
+	clusterMap, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get clusters", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	nodeMap, err := s.nodeRepo.GetByIDs(ctx, nodeIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get nodes", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	for i := range items {
+		if cluster, ok := clusterMap[items[i].ClusterID]; ok {
+			items[i].ClusterName = cluster.ClusterName
+		}
+		if node, ok := nodeMap[items[i].NodeID]; ok {
+			items[i].NodeName = node.NodeName
+		}
+	}
+	return nil
+}
+
+func (s *energyService) ExportReportCSV(ctx context.Context, req *v1.GetEnergyReportRequest) ([]byte, error) {
+	report, err := s.GetReport(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var header []string
+	switch report.GroupBy {
+	case v1.EnergyGroupByNode:
+		header = []string{"node_id", "node_name", "cluster_name", "energy_kwh", "idle_kwh", "max_watts", "carbon_kg"}
+	case v1.EnergyGroupByCluster:
+		header = []string{"cluster_id", "cluster_name", "energy_kwh", "idle_kwh", "carbon_kg"}
+	case v1.EnergyGroupByApp:
+		header = []string{"app_id", "energy_kwh", "carbon_kg"}
+	case v1.EnergyGroupByCostCenter:
+		header = []string{"cost_center", "energy_kwh", "carbon_kg"}
+	default:
+		header = []string{"vm_id", "vm_name", "app_id", "cost_center", "node_name", "cluster_name", "energy_kwh", "carbon_kg"}
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+	for _, item := range report.Items {
+		kwh, carbon := formatEnergy(item.EnergyKwh), formatEnergy(item.CarbonKg)
+		var row []string
+		switch report.GroupBy {
+		case v1.EnergyGroupByNode:
+			row = []string{strconv.FormatInt(item.NodeID, 10), item.NodeName, item.ClusterName, kwh,
+				formatEnergy(item.IdleKwh), strconv.FormatFloat(item.MaxWatts, 'f', -1, 64), carbon}
+		case v1.EnergyGroupByCluster:
+			row = []string{strconv.FormatInt(item.ClusterID, 10), item.ClusterName, kwh, formatEnergy(item.IdleKwh), carbon}
+		case v1.EnergyGroupByApp:
+			row = []string{item.AppId, kwh, carbon}
+		case v1.EnergyGroupByCostCenter:
+			row = []string{item.CostCenter, kwh, carbon}
+		default:
+			row = []string{strconv.FormatInt(item.VmId, 10), item.VmName, item.AppId, item.CostCenter, item.NodeName, item.ClusterName, kwh, carbon}
+		}
+		if err := w.Write(row); err != nil {
+			return nil, v1.ErrInternalServerError
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+	return buf.Bytes(), nil
+}
+
+// powerReading 单个节点一次采集的功率读数
+type powerReading struct {
+	bmc   *model.NodeBMC
+	watts float64
+	err   error
+}
+
+func (s *energyService) CollectPower(ctx context.Context, interval time.Duration) error {
+	bmcs, err := s.bmcRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+
+	monitored := make([]*model.NodeBMC, 0, len(bmcs))
+	nodeIDs := make([]int64, 0, len(bmcs))
+	for _, b := range bmcs {
+		if b.PowerMonitor == 1 {
+			monitored = append(monitored, b)
+			nodeIDs = append(nodeIDs, b.NodeID)
+		}
+	}
+	if len(monitored) == 0 {
+		return nil
+	}
+	nodeMap, err := s.nodeRepo.GetByIDs(ctx, nodeIDs)
+	if err != nil {
+		return err
+	}
+
+	// 并发读取 BMC，单个 BMC 超时不影响其他节点
+	readings := make([]powerReading, len(monitored))
+	sem := make(chan struct{}, energyCollectConcurrency)
+	var wg sync.WaitGroup
+	for i, b := range monitored {
+		wg.Add(1)
+		go func(i int, b *model.NodeBMC) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			readings[i].bmc = b
+			client, err := newNodeBMCClient(s.conf, b)
+			if err != nil {
+				readings[i].err = err
+				return
+			}
+			readings[i].watts, readings[i].err = client.PowerReading(ctx)
+		}(i, b)
+	}
+	wg.Wait()
+
+	now := time.Now()
+	date := now.Format(time.DateOnly)
+	hours := interval.Hours()
+	clusterVMs := make(map[int64][]*model.PveVM)
+	for _, r := range readings {
+		if r.err != nil {
+			s.logger.WithContext(ctx).Warn("failed to read node power", zap.Int64("node_id", r.bmc.NodeID), zap.Error(r.err))
+			if err := s.bmcRepo.UpdateReading(ctx, r.bmc.Id, 0, now, bmcErrorMessage(r.err)); err != nil {
+				s.logger.WithContext(ctx).Error("failed to update bmc reading", zap.Error(err))
+			}
+			continue
+		}
+		if err := s.bmcRepo.UpdateReading(ctx, r.bmc.Id, r.watts, now, ""); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update bmc reading", zap.Error(err))
+		}
+
+		// 节点已从集群中删除时只保留读数，不再计入能耗
+		node, ok := nodeMap[r.bmc.NodeID]
+		if !ok {
+			continue
+		}
+		energyWh := r.watts * hours
+		if err := s.energyRepo.AddNodeEnergy(ctx, &model.EnergyNodeDaily{
+			UsageDate: date,
+			NodeID:    node.Id,
+			ClusterID: node.ClusterID,
+			EnergyWh:  energyWh,
+			Samples:   1,
+			MaxWatts:  r.watts,
+		}); err != nil {
+			s.logger.WithContext(ctx).Error("failed to add node energy", zap.Error(err), zap.Int64("node_id", node.Id))
+			continue
+		}
+
+		vms, ok := clusterVMs[node.ClusterID]
+		if !ok {
+			vms, err = s.vmRepo.GetByClusterID(ctx, node.ClusterID)
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to list cluster vms", zap.Error(err), zap.Int64("cluster_id", node.ClusterID))
+				continue
+			}
+			clusterVMs[node.ClusterID] = vms
+		}
+		s.attributeEnergy(ctx, date, node, vms, energyWh)
+	}
+	return nil
+}
+
+// attributeEnergy 将节点能耗按运行中虚拟机的 vCPU 数比例分摊，没有运行中虚拟机时不分摊
+func (s *energyService) attributeEnergy(ctx context.Context, date string, node *model.PveNode, vms []*model.PveVM, energyWh float64) {
+	running := make([]*model.PveVM, 0)
+	totalCPU := 0
+	for _, vm := range vms {
+		if vm.NodeID != node.Id || vm.IsTemplate == 1 || vm.Status != "running" || vm.CPUNum <= 0 {
+			continue
+		}
+		running = append(running, vm)
+		totalCPU += vm.CPUNum
+	}
+	if totalCPU == 0 {
+		return
+	}
+
+	for _, vm := range running {
+		if err := s.energyRepo.AddVMEnergy(ctx, &model.EnergyVMDaily{
+			UsageDate:  date,
+			VmId:       vm.Id,
+			VmName:     vm.VmName,
+			NodeID:     node.Id,
+			ClusterID:  node.ClusterID,
+			AppId:      vm.AppId,
+			CostCenter: vm.CostCenter,
+			EnergyWh:   energyWh * float64(vm.CPUNum) / float64(totalCPU),
+		}); err != nil {
+			s.logger.WithContext(ctx).Error("failed to add vm energy", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		}
+	}
+}
+
+// roundEnergy 保留三位小数
+func roundEnergy(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+func formatEnergy(v float64) string {
+	return strconv.FormatFloat(v, 'f', 3, 64)
+}
@@ -0,0 +1,655 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 首次启动钩子默认参数，可通过 first_boot.* 调整
+const (
+	defaultFirstBootAgentTimeout  = 24 * time.Hour
+	defaultFirstBootRetryInterval = 5 * time.Minute
+	defaultFirstBootMaxAttempts   = 5
+	defaultFirstBootScriptTimeout = time.Minute
+	firstBootBatchSize            = 100
+	firstBootMessageLimit         = 2000
+)
+
+// FirstBootHookService 模板首次启动钩子：从模板创建的虚拟机首次 guest agent 上线后调用 webhook 或服务端脚本
+type FirstBootHookService interface {
+	GetHook(ctx context.Context, templateID int64) (*v1.FirstBootHookItem, error)
+	// SaveHook 创建或替换模板的钩子，仅管理员可操作；只对之后创建的虚拟机生效
+	SaveHook(ctx context.Context, userID string, templateID int64, req *v1.SaveFirstBootHookRequest) (*v1.FirstBootHookItem, error)
+	DeleteHook(ctx context.Context, userID string, templateID int64) error
+	// Enqueue 从模板创建虚拟机后登记执行记录，模板没有启用的钩子时忽略，失败只记录日志
+	Enqueue(ctx context.Context, vm *model.PveVM, creator string)
+
+	ListRuns(ctx context.Context, req *v1.ListFirstBootRunsRequest) (*v1.ListFirstBootRunsResponseData, error)
+	GetRunByVM(ctx context.Context, vmID int64) (*v1.FirstBootRunItem, error)
+	// Retry 重新执行已结束的记录并立即检测一次，创建人或管理员可操作
+	Retry(ctx context.Context, userID string, id int64) (*v1.FirstBootRunItem, error)
+	// CheckPending 检测等待 agent 上线和到达重试时间的记录，返回处理数量
+	CheckPending(ctx context.Context) (int, error)
+}
+
+func NewFirstBootHookService(
+	service *Service,
+	conf *viper.Viper,
+	hookRepo repository.FirstBootHookRepository,
+	templateRepo repository.VmTemplateRepository,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) FirstBootHookService {
+	return &firstBootHookService{
+		Service:      service,
+		conf:         conf,
+		hookRepo:     hookRepo,
+		templateRepo: templateRepo,
+		vmRepo:       vmRepo,
+		nodeRepo:     nodeRepo,
+		clusterRepo:  clusterRepo,
+		userRepo:     userRepo,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		logger:       logger,
+	}
+}
+
+type firstBootHookService struct {
+	*Service
+	conf         *viper.Viper
+	hookRepo     repository.FirstBootHookRepository
+	templateRepo repository.VmTemplateRepository
+	vmRepo       repository.PveVMRepository
+	nodeRepo     repository.PveNodeRepository
+	clusterRepo  repository.PveClusterRepository
+	userRepo     repository.UserRepository
+	httpClient   *http.Client
+	logger       *log.Logger
+
+	// 后台检测与手动重试串行执行，避免同一记录被重复调用
+	mu sync.Mutex
+}
+
+func (s *firstBootHookService) agentTimeout() time.Duration {
+	if d := s.conf.GetDuration("first_boot.agent_timeout"); d > 0 {
+		return d
+	}
+	return defaultFirstBootAgentTimeout
+}
+
+func (s *firstBootHookService) retryInterval() time.Duration {
+	if d := s.conf.GetDuration("first_boot.retry_interval"); d > 0 {
+		return d
+	}
+	return defaultFirstBootRetryInterval
+}
+
+func (s *firstBootHookService) maxAttempts() int {
+	if n := s.conf.GetInt("first_boot.max_attempts"); n > 0 {
+		return n
+	}
+	return defaultFirstBootMaxAttempts
+}
+
+func (s *firstBootHookService) scriptTimeout() time.Duration {
+	if d := s.conf.GetDuration("first_boot.script_timeout"); d > 0 {
+		return d
+	}
+	return defaultFirstBootScriptTimeout
+}
+
+// scriptPath 脚本名对应的路径，只能执行 first_boot.scripts 中登记的脚本
+func (s *firstBootHookService) scriptPath(name string) string {
+	return s.conf.GetStringMapString("first_boot.scripts")[name]
+}
+
+func (s *firstBootHookService) GetHook(ctx context.Context, templateID int64) (*v1.FirstBootHookItem, error) {
+	hook, err := s.hookRepo.GetByTemplateID(ctx, templateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get first boot hook", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if hook == nil {
+		return nil, v1.ErrFirstBootHookNotFound
+	}
+	item := toFirstBootHookItem(hook)
+	return &item, nil
+}
+
+func (s *firstBootHookService) SaveHook(ctx context.Context, userID string, templateID int64, req *v1.SaveFirstBootHookRequest) (*v1.FirstBootHookItem, error) {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	template, err := s.templateRepo.GetByID(ctx, templateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if template == nil {
+		return nil, v1.WithDetailf(v1.ErrTemplateNotFound, "template_id=%d", templateID)
+	}
+
+	hook, err := s.hookRepo.GetByTemplateID(ctx, templateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get first boot hook", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if hook == nil {
+		hook = &model.FirstBootHook{TemplateID: templateID, Creator: username}
+	}
+	hook.Type = req.Type
+	hook.URL, hook.Script = "", ""
+	switch req.Type {
+	case v1.FirstBootHookTypeWebhook:
+		target := strings.TrimSpace(req.URL)
+		if err := validateFirstBootURL(target); err != nil {
+			return nil, err
+		}
+		hook.URL = target
+		if req.ClearSecret {
+			hook.Secret = ""
+		} else if req.Secret != "" {
+			hook.Secret = req.Secret
+		}
+	case v1.FirstBootHookTypeScript:
+		name := strings.TrimSpace(req.Script)
+		if name == "" {
+			return nil, v1.WithDetail(v1.ErrFirstBootHookInvalid, "script is required")
+		}
+		if s.scriptPath(name) == "" {
+			return nil, v1.WithDetailf(v1.ErrFirstBootHookInvalid, "script %s is not registered in first_boot.scripts", name)
+		}
+		hook.Script = name
+		hook.Secret = ""
+	}
+	hook.Enabled = req.Enabled == nil || *req.Enabled
+	hook.Description = req.Description
+	hook.Modifier = username
+
+	if err := s.hookRepo.Save(ctx, hook); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save first boot hook", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("first boot hook saved", zap.Int64("template_id", templateID),
+		zap.String("type", hook.Type), zap.String("operator", username))
+	item := toFirstBootHookItem(hook)
+	return &item, nil
+}
+
+func validateFirstBootURL(target string) error {
+	if target == "" {
+		return v1.WithDetail(v1.ErrFirstBootHookInvalid, "url is required")
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return v1.WithDetailf(v1.ErrFirstBootHookInvalid, "url %s must be an http or https address", target)
+	}
+	return nil
+}
+
+func (s *firstBootHookService) DeleteHook(ctx context.Context, userID string, templateID int64) error {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return err
+	}
+	hook, err := s.hookRepo.GetByTemplateID(ctx, templateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get first boot hook", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if hook == nil {
+		return v1.ErrFirstBootHookNotFound
+	}
+	if err := s.hookRepo.DeleteByTemplateID(ctx, templateID); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete first boot hook", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("first boot hook deleted", zap.Int64("template_id", templateID), zap.String("operator", username))
+	return nil
+}
+
+func (s *firstBootHookService) Enqueue(ctx context.Context, vm *model.PveVM, creator string) {
+	if vm == nil || vm.TemplateID == 0 {
+		return
+	}
+	hook, err := s.hookRepo.GetByTemplateID(ctx, vm.TemplateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get first boot hook", zap.Error(err), zap.Int64("template_id", vm.TemplateID))
+		return
+	}
+	if hook == nil || !hook.Enabled {
+		return
+	}
+	run := &model.FirstBootRun{
+		HookID:        hook.Id,
+		TemplateID:    vm.TemplateID,
+		ClusterID:     vm.ClusterID,
+		VmId:          vm.Id,
+		VMID:          vm.VMID,
+		VmName:        vm.VmName,
+		Type:          hook.Type,
+		Target:        firstBootTarget(hook),
+		Status:        model.FirstBootRunStatusWaiting,
+		Message:       "waiting for the guest agent to come online",
+		WaitStartTime: time.Now(),
+		Creator:       creator,
+	}
+	if err := s.hookRepo.CreateRun(ctx, run); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create first boot run", zap.Error(err), zap.Int64("vm_id", vm.Id))
+	}
+}
+
+func (s *firstBootHookService) ListRuns(ctx context.Context, req *v1.ListFirstBootRunsRequest) (*v1.ListFirstBootRunsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	runs, total, err := s.hookRepo.ListRuns(ctx, page, pageSize, req.ClusterID, req.TemplateID, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list first boot runs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	data := &v1.ListFirstBootRunsResponseData{Total: total, List: make([]v1.FirstBootRunItem, 0, len(runs))}
+	for _, run := range runs {
+		data.List = append(data.List, toFirstBootRunItem(run))
+	}
+	return data, nil
+}
+
+func (s *firstBootHookService) GetRunByVM(ctx context.Context, vmID int64) (*v1.FirstBootRunItem, error) {
+	run, err := s.hookRepo.GetRunByVM(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get first boot run", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if run == nil {
+		return nil, v1.ErrFirstBootRunNotFound
+	}
+	item := toFirstBootRunItem(run)
+	return &item, nil
+}
+
+func (s *firstBootHookService) Retry(ctx context.Context, userID string, id int64) (*v1.FirstBootRunItem, error) {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	admin := err == nil
+	if err != nil {
+		if !errors.Is(err, v1.ErrAdminRequired) {
+			return nil, err
+		}
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil || user == nil {
+			return nil, v1.ErrUnauthorized
+		}
+		username = user.Username
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	run, err := s.hookRepo.GetRunByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get first boot run", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if run == nil {
+		return nil, v1.ErrFirstBootRunNotFound
+	}
+	if !admin && run.Creator != username {
+		return nil, v1.ErrAdminRequired
+	}
+	if run.Status == model.FirstBootRunStatusWaiting || run.Status == model.FirstBootRunStatusRetrying {
+		return nil, v1.WithDetailf(v1.ErrFirstBootRunActive, "status=%s", run.Status)
+	}
+
+	// 成功的记录也可以重新执行，用于下游系统丢失登记后补发
+	run.Status = model.FirstBootRunStatusWaiting
+	run.Attempts = 0
+	run.WaitStartTime = time.Now()
+	run.AgentOnlineTime, run.NextAttemptTime, run.FinishTime = nil, nil, nil
+	s.logger.WithContext(ctx).Info("first boot run retried", zap.Int64("id", id), zap.String("operator", username))
+	s.process(ctx, run)
+
+	item := toFirstBootRunItem(run)
+	return &item, nil
+}
+
+func (s *firstBootHookService) CheckPending(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	runs, err := s.hookRepo.ListDueRuns(ctx, time.Now(), firstBootBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, run := range runs {
+		s.process(ctx, run)
+	}
+	return len(runs), nil
+}
+
+// process 等待 agent 上线并取得 IP 后调用钩子：agent 超过 first_boot.agent_timeout 未上线时失败，
+// 上线后仍未取得 IP 时等到超时再以空 IP 调用；调用失败时按 first_boot.retry_interval 重试
+func (s *firstBootHookService) process(ctx context.Context, run *model.FirstBootRun) {
+	defer s.saveRun(ctx, run)
+
+	vm, err := s.vmRepo.GetByID(ctx, run.VmId)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vm", zap.Error(err), zap.Int64("vm_id", run.VmId))
+		return
+	}
+	if vm == nil {
+		s.finishRun(run, model.FirstBootRunStatusFailed, "vm deleted")
+		return
+	}
+	// 使用模板当前的钩子配置，钩子删除或停用后不再调用
+	hook, err := s.hookRepo.GetByTemplateID(ctx, run.TemplateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get first boot hook", zap.Error(err), zap.Int64("template_id", run.TemplateID))
+		return
+	}
+	if hook == nil || !hook.Enabled {
+		s.finishRun(run, model.FirstBootRunStatusFailed, "first boot hook was removed or disabled")
+		return
+	}
+	run.HookID, run.Type, run.Target = hook.Id, hook.Type, firstBootTarget(hook)
+
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get proxmox client", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		return
+	}
+
+	now := time.Now()
+	waitExpired := now.Sub(run.WaitStartTime) > s.agentTimeout()
+	if run.AgentOnlineTime == nil {
+		run.Checks++
+		if err := client.AgentPing(ctx, node.NodeName, vm.VMID); err != nil {
+			if waitExpired {
+				s.finishRun(run, model.FirstBootRunStatusFailed, fmt.Sprintf("guest agent did not come online within %s", s.agentTimeout()))
+			}
+			return
+		}
+		run.AgentOnlineTime = &now
+	}
+
+	payload, err := s.buildPayload(ctx, client, node.NodeName, vm, run)
+	if err != nil {
+		run.Message = err.Error()
+		s.failAttempt(run, now)
+		return
+	}
+	if len(payload.IPAddresses) == 0 && !waitExpired {
+		run.Message = "guest agent is online, waiting for an ip address"
+		return
+	}
+
+	run.Attempts++
+	message, err := s.invoke(ctx, hook, payload)
+	if err != nil {
+		run.Message = truncateFirstBootMessage(err.Error())
+		s.failAttempt(run, now)
+		s.logger.WithContext(ctx).Warn("first boot hook failed", zap.Error(err), zap.Int64("vm_id", vm.Id),
+			zap.Int("attempts", run.Attempts))
+		return
+	}
+	s.finishRun(run, model.FirstBootRunStatusSucceeded, truncateFirstBootMessage(message))
+	s.logger.WithContext(ctx).Info("first boot hook succeeded", zap.Int64("vm_id", vm.Id), zap.String("type", hook.Type))
+}
+
+// failAttempt 调用失败：未超过 first_boot.max_attempts 时等待重试，否则标记为失败
+func (s *firstBootHookService) failAttempt(run *model.FirstBootRun, now time.Time) {
+	if run.Attempts >= s.maxAttempts() {
+		run.Message = fmt.Sprintf("gave up after %d attempts: %s", run.Attempts, run.Message)
+		s.finishRun(run, model.FirstBootRunStatusFailed, run.Message)
+		return
+	}
+	next := now.Add(s.retryInterval())
+	run.Status = model.FirstBootRunStatusRetrying
+	run.NextAttemptTime = &next
+}
+
+func (s *firstBootHookService) finishRun(run *model.FirstBootRun, status, message string) {
+	now := time.Now()
+	run.Status = status
+	run.Message = message
+	run.NextAttemptTime = nil
+	run.FinishTime = &now
+}
+
+func (s *firstBootHookService) saveRun(ctx context.Context, run *model.FirstBootRun) {
+	if err := s.hookRepo.UpdateRun(ctx, run); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update first boot run", zap.Error(err), zap.Int64("id", run.Id))
+	}
+}
+
+// buildPayload 读取 agent 上报的网卡和操作系统，组装传给钩子的数据
+func (s *firstBootHookService) buildPayload(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vm *model.PveVM, run *model.FirstBootRun) (*v1.FirstBootPayload, error) {
+	ifaces, err := client.GetVMAgentNetworkInterfaces(ctx, nodeName, vm.VMID)
+	if err != nil {
+		return nil, fmt.Errorf("get guest network interfaces: %w", err)
+	}
+	payload := &v1.FirstBootPayload{
+		Event: v1.FirstBootEvent,
+		RunID: run.Id,
+		VM: v1.FirstBootVMInfo{
+			Id:              vm.Id,
+			VMID:            vm.VMID,
+			Name:            vm.VmName,
+			ClusterID:       vm.ClusterID,
+			NodeName:        nodeName,
+			CPUNum:          vm.CPUNum,
+			MemoryMB:        vm.MemorySize,
+			AppId:           vm.AppId,
+			Owner:           vm.Owner,
+			Team:            vm.Team,
+			Contact:         vm.Contact,
+			BusinessService: vm.BusinessService,
+			Environment:     vm.Environment,
+			CostCenter:      vm.CostCenter,
+			Creator:         vm.Creator,
+		},
+		Template:        v1.FirstBootTemplateInfo{Id: run.TemplateID},
+		IPAddresses:     make([]string, 0),
+		Interfaces:      firstBootInterfaces(ifaces),
+		AgentOnlineTime: *run.AgentOnlineTime,
+	}
+	for _, iface := range payload.Interfaces {
+		payload.IPAddresses = append(payload.IPAddresses, iface.IPAddresses...)
+	}
+	run.IPAddresses = strings.Join(payload.IPAddresses, ",")
+
+	if osInfo, err := client.GetVMAgentOSInfo(ctx, nodeName, vm.VMID); err == nil {
+		payload.OS, _ = osInfo["pretty-name"].(string)
+	}
+	if cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID); err == nil && cluster != nil {
+		payload.VM.ClusterName = cluster.ClusterName
+	}
+	if template, err := s.templateRepo.GetByID(ctx, run.TemplateID); err == nil && template != nil {
+		payload.Template.Name = template.TemplateName
+	}
+	return payload, nil
+}
+
+// firstBootInterfaces 整理 agent 上报的网卡，去掉回环网卡以及回环、链路本地地址
+func firstBootInterfaces(ifaces []map[string]interface{}) []v1.FirstBootInterfaceInfo {
+	items := make([]v1.FirstBootInterfaceInfo, 0, len(ifaces))
+	for _, iface := range ifaces {
+		name, _ := iface["name"].(string)
+		if name == "lo" {
+			continue
+		}
+		item := v1.FirstBootInterfaceInfo{Name: name, IPAddresses: make([]string, 0)}
+		item.MAC, _ = iface["hardware-address"].(string)
+		addresses, _ := iface["ip-addresses"].([]interface{})
+		for _, raw := range addresses {
+			addr, _ := raw.(map[string]interface{})
+			value, _ := addr["ip-address"].(string)
+			ip := net.ParseIP(value)
+			if ip == nil || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+				continue
+			}
+			item.IPAddresses = append(item.IPAddresses, value)
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// invoke 调用钩子，返回结果说明
+func (s *firstBootHookService) invoke(ctx context.Context, hook *model.FirstBootHook, payload *v1.FirstBootPayload) (string, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	if hook.Type == model.FirstBootHookTypeScript {
+		return s.runScript(ctx, hook.Script, payload, body)
+	}
+	return s.postWebhook(ctx, hook, body)
+}
+
+func (s *firstBootHookService) postWebhook(ctx context.Context, hook *model.FirstBootHook, body []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-PveSphere-Event", v1.FirstBootEvent)
+	if hook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(hook.Secret))
+		mac.Write(body)
+		req.Header.Set("X-PveSphere-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return fmt.Sprintf("webhook returned status %d", resp.StatusCode), nil
+}
+
+// runScript 以 JSON 作为标准输入执行 first_boot.scripts 中登记的脚本，并通过环境变量传入常用字段
+func (s *firstBootHookService) runScript(ctx context.Context, name string, payload *v1.FirstBootPayload, body []byte) (string, error) {
+	path := s.scriptPath(name)
+	if path == "" {
+		return "", fmt.Errorf("script %s is not registered in first_boot.scripts", name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.scriptTimeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"PVESPHERE_EVENT="+v1.FirstBootEvent,
+		"PVESPHERE_VM_ID="+strconv.FormatInt(payload.VM.Id, 10),
+		"PVESPHERE_VMID="+strconv.FormatUint(uint64(payload.VM.VMID), 10),
+		"PVESPHERE_VM_NAME="+payload.VM.Name,
+		"PVESPHERE_IP_ADDRESSES="+strings.Join(payload.IPAddresses, ","),
+	)
+	out, err := cmd.CombinedOutput()
+	output := strings.TrimSpace(string(out))
+	if err != nil {
+		if output != "" {
+			return "", fmt.Errorf("script %s: %v: %s", name, err, output)
+		}
+		return "", fmt.Errorf("script %s: %v", name, err)
+	}
+	if output == "" {
+		return fmt.Sprintf("script %s exited 0", name), nil
+	}
+	return fmt.Sprintf("script %s exited 0: %s", name, output), nil
+}
+
+func firstBootTarget(hook *model.FirstBootHook) string {
+	if hook.Type == model.FirstBootHookTypeScript {
+		return hook.Script
+	}
+	return hook.URL
+}
+
+func truncateFirstBootMessage(message string) string {
+	if len(message) > firstBootMessageLimit {
+		return message[:firstBootMessageLimit] + "..."
+	}
+	return message
+}
+
+func toFirstBootHookItem(hook *model.FirstBootHook) v1.FirstBootHookItem {
+	return v1.FirstBootHookItem{
+		Id:          hook.Id,
+		TemplateID:  hook.TemplateID,
+		Type:        hook.Type,
+		URL:         hook.URL,
+		HasSecret:   hook.Secret != "",
+		Script:      hook.Script,
+		Enabled:     hook.Enabled,
+		Description: hook.Description,
+		Creator:     hook.Creator,
+		Modifier:    hook.Modifier,
+		CreateTime:  hook.CreateTime,
+		UpdateTime:  hook.UpdateTime,
+	}
+}
+
+func toFirstBootRunItem(run *model.FirstBootRun) v1.FirstBootRunItem {
+	ips := make([]string, 0)
+	if run.IPAddresses != "" {
+		ips = strings.Split(run.IPAddresses, ",")
+	}
+	return v1.FirstBootRunItem{
+		Id:              run.Id,
+		HookID:          run.HookID,
+		TemplateID:      run.TemplateID,
+		ClusterID:       run.ClusterID,
+		VmId:            run.VmId,
+		VMID:            run.VMID,
+		VmName:          run.VmName,
+		Type:            run.Type,
+		Target:          run.Target,
+		Status:          run.Status,
+		Message:         run.Message,
+		IPAddresses:     ips,
+		Checks:          run.Checks,
+		Attempts:        run.Attempts,
+		AgentOnlineTime: run.AgentOnlineTime,
+		NextAttemptTime: run.NextAttemptTime,
+		FinishTime:      run.FinishTime,
+		Creator:         run.Creator,
+		CreateTime:      run.CreateTime,
+		UpdateTime:      run.UpdateTime,
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// Grafana 指标类型
+const (
+	metricKindVM   = "vm"
+	metricKindNode = "node"
+)
+
+// RRD 聚合函数
+const (
+	rrdCfAverage = "AVERAGE"
+	rrdCfMax     = "MAX"
+)
+
+// grafanaSearchLimit /search 最多返回的指标数（避免大规模集群下拉框过长）
+const grafanaSearchLimit = 200
+
+// grafanaResourceLimit /search 时每类资源最多加载的数量
+const grafanaResourceLimit = 1000
+
+// 可查询的 RRD 指标（与 Proxmox rrddata 返回字段一致）
+var (
+	vmMetrics   = []string{"cpu", "mem", "maxmem", "disk", "maxdisk", "netin", "netout", "diskread", "diskwrite"}
+	nodeMetrics = []string{"cpu", "iowait", "loadavg", "memused", "memtotal", "swapused", "swaptotal", "netin", "netout", "rootused", "roottotal"}
+)
+
+// rrdTimeframes 按时间跨度由小到大排列，选择能覆盖查询起点的最小粒度
+var rrdTimeframes = []struct {
+	name string
+	span time.Duration
+}{
+	{"hour", time.Hour},
+	{"day", 24 * time.Hour},
+	{"week", 7 * 24 * time.Hour},
+	{"month", 30 * 24 * time.Hour},
+	{"year", 365 * 24 * time.Hour},
+}
+
+type GrafanaService interface {
+	Search(ctx context.Context, req *v1.GrafanaSearchRequest) ([]v1.GrafanaSearchItem, error)
+	Query(ctx context.Context, req *v1.GrafanaQueryRequest) ([]v1.GrafanaTimeSeries, error)
+}
+
+func NewGrafanaService(
+	service *Service,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	logger *log.Logger,
+) GrafanaService {
+	return &grafanaService{
+		clusterRepo: clusterRepo,
+		nodeRepo:    nodeRepo,
+		vmRepo:      vmRepo,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type grafanaService struct {
+	clusterRepo repository.PveClusterRepository
+	nodeRepo    repository.PveNodeRepository
+	vmRepo      repository.PveVMRepository
+	*Service
+	logger *log.Logger
+}
+
+// metricTarget 解析后的 target
+type metricTarget struct {
+	kind   string
+	id     int64
+	metric string
+	cf     string
+}
+
+// parseMetricTarget 解析 <kind>:<id>:<metric>[:<cf>]
+func parseMetricTarget(target string) (*metricTarget, error) {
+	parts := strings.Split(strings.TrimSpace(target), ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return nil, v1.WithDetail(v1.ErrInvalidMetricTarget, target)
+	}
+	t := &metricTarget{kind: parts[0], metric: parts[2], cf: rrdCfAverage}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id <= 0 {
+		return nil, v1.WithDetail(v1.ErrInvalidMetricTarget, target)
+	}
+	t.id = id
+	if len(parts) == 4 {
+		t.cf = strings.ToUpper(parts[3])
+		if t.cf != rrdCfAverage && t.cf != rrdCfMax {
+			return nil, v1.WithDetail(v1.ErrInvalidMetricTarget, target)
+		}
+	}
+
+	var metrics []string
+	switch t.kind {
+	case metricKindVM:
+		metrics = vmMetrics
+	case metricKindNode:
+		metrics = nodeMetrics
+	default:
+		return nil, v1.WithDetail(v1.ErrInvalidMetricTarget, target)
+	}
+	for _, m := range metrics {
+		if m == t.metric {
+			return t, nil
+		}
+	}
+	return nil, v1.WithDetail(v1.ErrUnknownMetric, t.metric)
+}
+
+// rrdTimeframeFor 根据查询起点选择 RRD 时间范围：Proxmox 只返回"最近 N"的数据，需覆盖到 from
+func rrdTimeframeFor(from, now time.Time) string {
+	since := now.Sub(from)
+	for _, tf := range rrdTimeframes {
+		if since <= tf.span {
+			return tf.name
+		}
+	}
+	return rrdTimeframes[len(rrdTimeframes)-1].name
+}
+
+func (s *grafanaService) Search(ctx context.Context, req *v1.GrafanaSearchRequest) ([]v1.GrafanaSearchItem, error) {
+	keyword := strings.ToLower(strings.TrimSpace(req.Target))
+	items := make([]v1.GrafanaSearchItem, 0)
+
+	add := func(kind string, id int64, name string, metrics []string) bool {
+		for _, m := range metrics {
+			text := kind + " " + name + " " + m
+			if keyword != "" && !strings.Contains(strings.ToLower(text), keyword) {
+				continue
+			}
+			items = append(items, v1.GrafanaSearchItem{
+				Text:  text,
+				Value: kind + ":" + strconv.FormatInt(id, 10) + ":" + m,
+			})
+			if len(items) >= grafanaSearchLimit {
+				return false
+			}
+		}
+		return true
+	}
+
+	nodes, _, err := s.nodeRepo.ListWithPagination(ctx, 1, grafanaResourceLimit, 0, "", "")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, n := range nodes {
+		if !add(metricKindNode, n.Id, n.NodeName, nodeMetrics) {
+			return items, nil
+		}
+	}
+
+	vms, _, err := s.vmRepo.ListWithPagination(ctx, 1, grafanaResourceLimit, 0, "", 0, "", 0, "", "", nil)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vms", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, vm := range vms {
+		// 模板不会产生监控数据
+		if vm.IsTemplate == 1 {
+			continue
+		}
+		if !add(metricKindVM, vm.Id, vm.VmName, vmMetrics) {
+			return items, nil
+		}
+	}
+	return items, nil
+}
+
+// rrdSeries 一次 RRD 请求的结果，同一资源的多个指标共享
+type rrdSeries struct {
+	name string
+	rows []map[string]interface{}
+}
+
+func (s *grafanaService) Query(ctx context.Context, req *v1.GrafanaQueryRequest) ([]v1.GrafanaTimeSeries, error) {
+	now := time.Now()
+	from, to := req.Range.From, req.Range.To
+	if to.IsZero() || to.After(now) {
+		to = now
+	}
+	if from.IsZero() || !from.Before(to) {
+		from = to.Add(-time.Hour)
+	}
+	timeframe := rrdTimeframeFor(from, now)
+
+	// 同一资源、同一聚合函数只请求一次 Proxmox
+	cache := make(map[string]*rrdSeries)
+	result := make([]v1.GrafanaTimeSeries, 0, len(req.Targets))
+	for _, qt := range req.Targets {
+		if qt.Hide || strings.TrimSpace(qt.Target) == "" {
+			continue
+		}
+		t, err := parseMetricTarget(qt.Target)
+		if err != nil {
+			return nil, err
+		}
+
+		key := t.kind + ":" + strconv.FormatInt(t.id, 10) + ":" + t.cf
+		series, ok := cache[key]
+		if !ok {
+			if series, err = s.fetchRRD(ctx, t, timeframe); err != nil {
+				return nil, err
+			}
+			cache[key] = series
+		}
+
+		result = append(result, v1.GrafanaTimeSeries{
+			Target:     t.kind + " " + series.name + " " + t.metric,
+			RefID:      qt.RefID,
+			Datapoints: rrdDatapoints(series.rows, t.metric, from, to, req.MaxDataPoints),
+		})
+	}
+	return result, nil
+}
+
+// rrdDatapoints 提取指定字段并按时间范围过滤，超过 maxDataPoints 时等间隔抽样
+func rrdDatapoints(rows []map[string]interface{}, metric string, from, to time.Time, maxDataPoints int) [][2]float64 {
+	points := make([][2]float64, 0, len(rows))
+	fromSec, toSec := float64(from.Unix()), float64(to.Unix())
+	for _, row := range rows {
+		ts, ok := row["time"].(float64)
+		if !ok || ts < fromSec || ts > toSec {
+			continue
+		}
+		// 采集缺失的时间点 Proxmox 不返回该字段，跳过而不是补 0
+		value, ok := row[metric].(float64)
+		if !ok {
+			continue
+		}
+		points = append(points, [2]float64{value, ts * 1000})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i][1] < points[j][1] })
+
+	if maxDataPoints <= 0 || len(points) <= maxDataPoints {
+		return points
+	}
+	sampled := make([][2]float64, 0, maxDataPoints)
+	step := float64(len(points)) / float64(maxDataPoints)
+	for i := 0; i < maxDataPoints; i++ {
+		sampled = append(sampled, points[int(float64(i)*step)])
+	}
+	return sampled
+}
+
+func (s *grafanaService) fetchRRD(ctx context.Context, t *metricTarget, timeframe string) (*rrdSeries, error) {
+	switch t.kind {
+	case metricKindVM:
+		vm, err := s.vmRepo.GetByID(ctx, t.id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if vm == nil {
+			return nil, v1.WithDetailf(v1.ErrVMNotFound, "id=%d", t.id)
+		}
+		node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if node == nil {
+			return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
+		}
+		client, err := s.proxmoxClient(ctx, vm.ClusterID)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := client.GetVMRRDData(ctx, node.NodeName, vm.VMID, timeframe, t.cf)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm rrd data", zap.Error(err),
+				zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID),
+				zap.String("timeframe", timeframe), zap.String("cf", t.cf))
+			return nil, v1.ErrProxmoxRequestFailed
+		}
+		return &rrdSeries{name: vm.VmName, rows: rows}, nil
+
+	default:
+		node, err := s.nodeRepo.GetByID(ctx, t.id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if node == nil {
+			return nil, v1.WithDetailf(v1.ErrNodeNotFound, "id=%d", t.id)
+		}
+		client, err := s.proxmoxClient(ctx, node.ClusterID)
+		if err != nil {
+			return nil, err
+		}
+		rows, err := client.GetNodeRRDData(ctx, node.NodeName, timeframe, t.cf)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get node rrd data", zap.Error(err),
+				zap.String("node", node.NodeName), zap.Int64("node_id", node.Id),
+				zap.String("timeframe", timeframe), zap.String("cf", t.cf))
+			return nil, v1.ErrProxmoxRequestFailed
+		}
+		return &rrdSeries{name: node.NodeName, rows: rows}, nil
+	}
+}
+
+func (s *grafanaService) proxmoxClient(ctx context.Context, clusterID int64) (*proxmox.ProxmoxClient, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return client, nil
+}
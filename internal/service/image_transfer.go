@@ -0,0 +1,1001 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+	"pvesphere/pkg/s3"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 传输默认参数，可通过 image_transfer.* 调整
+const (
+	defaultTransferPartSize    = 64 << 20
+	minTransferPartSize        = 5 << 20 // S3 分段最小 5MiB（最后一段除外）
+	defaultTransferConcurrency = 2
+	defaultTransferTimeout     = 24 * time.Hour
+	transferPartRetries        = 3
+)
+
+// transferManifestSuffix 导出清单对象后缀，清单与备份对象同目录
+const transferManifestSuffix = ".manifest.json"
+
+// vzdumpQemuArchivePattern 可恢复为虚拟机的 vzdump 备份文件名
+var vzdumpQemuArchivePattern = regexp.MustCompile(`^vzdump-qemu-\d+-[0-9_-]+\.vma(\.(zst|gz|lzo))?$`)
+
+// transferManifest 导出清单，随备份一起写入对象存储，导入时用于完整性校验（可能由另一站点的实例读取）
+type transferManifest struct {
+	Version    int       `json:"version"`
+	Archive    string    `json:"archive"`
+	Size       int64     `json:"size"`
+	SHA256     string    `json:"sha256"`
+	PartSize   int64     `json:"part_size"`
+	Cluster    string    `json:"cluster"`
+	Node       string    `json:"node"`
+	VMID       uint32    `json:"vmid"`
+	VmName     string    `json:"vm_name"`
+	CreateTime time.Time `json:"create_time"`
+}
+
+type ImageTransferService interface {
+	// 对象存储（仅管理员）
+	CreateStore(ctx context.Context, userID string, req *v1.CreateObjectStoreRequest) (*v1.ObjectStoreItem, error)
+	ListStores(ctx context.Context) ([]v1.ObjectStoreItem, error)
+	DeleteStore(ctx context.Context, userID string, id int64) error
+	ListStoreObjects(ctx context.Context, id int64, req *v1.ListStoreObjectsRequest) ([]v1.StoreObjectItem, error)
+
+	// 导出 / 导入任务
+	CreateExport(ctx context.Context, req *v1.CreateImageExportRequest, creator string) (*v1.ImageTransferItem, error)
+	CreateImport(ctx context.Context, req *v1.CreateImageImportRequest, creator string) (*v1.ImageTransferItem, error)
+	GetTransfer(ctx context.Context, id int64) (*v1.ImageTransferItem, error)
+	ListTransfers(ctx context.Context, req *v1.ListImageTransfersRequest) (*v1.ListImageTransfersResponseData, error)
+}
+
+func NewImageTransferService(
+	service *Service,
+	conf *viper.Viper,
+	transferRepo repository.ImageTransferRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	changeControl ChangeControlService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) ImageTransferService {
+	concurrency := conf.GetInt("image_transfer.concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultTransferConcurrency
+	}
+	return &imageTransferService{
+		conf:                conf,
+		transferRepo:        transferRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		vmRepo:              vmRepo,
+		userRepo:            userRepo,
+		changeControl:       changeControl,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+		slots:               make(chan struct{}, concurrency),
+	}
+}
+
+type imageTransferService struct {
+	conf          *viper.Viper
+	transferRepo  repository.ImageTransferRepository
+	clusterRepo   repository.PveClusterRepository
+	nodeRepo      repository.PveNodeRepository
+	vmRepo        repository.PveVMRepository
+	userRepo      repository.UserRepository
+	changeControl ChangeControlService
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+
+	// 限制同时执行的传输任务数，其余任务保持 pending 排队
+	slots chan struct{}
+}
+
+func newS3Client(store *model.ObjectStore) (*s3.Client, error) {
+	return s3.New(s3.Config{
+		Endpoint:           store.Endpoint,
+		Region:             store.Region,
+		Bucket:             store.Bucket,
+		AccessKey:          store.AccessKey,
+		SecretKey:          store.SecretKey,
+		PathStyle:          store.PathStyle == 1,
+		InsecureSkipVerify: store.SkipCertVerify == 1,
+	})
+}
+
+// stagingDir 返回中转存储在本机的挂载目录（viper 键不区分大小写）
+func (s *imageTransferService) stagingDir(storage string) (string, error) {
+	dir := s.conf.GetStringMapString("image_transfer.staging_dirs")[strings.ToLower(storage)]
+	if dir == "" {
+		return "", v1.WithDetail(v1.ErrTransferStagingNotConfigured, storage)
+	}
+	return dir, nil
+}
+
+func (s *imageTransferService) partSize() int64 {
+	size := int64(s.conf.GetSizeInBytes("image_transfer.part_size"))
+	if size <= 0 {
+		return defaultTransferPartSize
+	}
+	return max(size, minTransferPartSize)
+}
+
+func (s *imageTransferService) CreateStore(ctx context.Context, userID string, req *v1.CreateObjectStoreRequest) (*v1.ObjectStoreItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "image_store.manage")
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.transferRepo.GetStoreByName(ctx, req.Name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get object store", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return nil, v1.WithDetail(v1.ErrObjectStoreExists, req.Name)
+	}
+
+	store := &model.ObjectStore{
+		Name:           req.Name,
+		Endpoint:       req.Endpoint,
+		Region:         req.Region,
+		Bucket:         req.Bucket,
+		Prefix:         strings.Trim(req.Prefix, "/"),
+		AccessKey:      req.AccessKey,
+		SecretKey:      req.SecretKey,
+		PathStyle:      1,
+		SkipCertVerify: req.SkipCertVerify,
+		Creator:        username,
+	}
+	if req.PathStyle != nil {
+		store.PathStyle = *req.PathStyle
+	}
+
+	// 保存前校验连通性和凭据
+	client, err := newS3Client(store)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrBadRequest, err.Error())
+	}
+	if err := client.HeadBucket(ctx); err != nil {
+		s.logger.WithContext(ctx).Warn("object store check failed", zap.Error(err), zap.String("endpoint", req.Endpoint))
+		return nil, v1.WithDetail(v1.ErrObjectStoreUnreachable, err.Error())
+	}
+
+	if err := s.transferRepo.CreateStore(ctx, store); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create object store", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("object store created",
+		zap.String("name", store.Name), zap.String("endpoint", store.Endpoint), zap.String("bucket", store.Bucket), zap.String("operator", username))
+	item := toObjectStoreItem(store)
+	return &item, nil
+}
+
+func (s *imageTransferService) ListStores(ctx context.Context) ([]v1.ObjectStoreItem, error) {
+	stores, err := s.transferRepo.ListStores(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list object stores", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.ObjectStoreItem, 0, len(stores))
+	for _, store := range stores {
+		list = append(list, toObjectStoreItem(store))
+	}
+	return list, nil
+}
+
+// DeleteStore 删除对象存储配置（不删除存储桶中的对象）
+func (s *imageTransferService) DeleteStore(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "image_store.manage")
+	if err != nil {
+		return err
+	}
+	store, err := s.getStore(ctx, id)
+	if err != nil {
+		return err
+	}
+	active, err := s.transferRepo.CountActiveTasksByStore(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to count transfer tasks", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if active > 0 {
+		return v1.WithDetailf(v1.ErrObjectStoreInUse, "%d task(s)", active)
+	}
+	if err := s.transferRepo.DeleteStore(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete object store", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("object store deleted", zap.Int64("id", id), zap.String("name", store.Name), zap.String("operator", username))
+	return nil
+}
+
+func (s *imageTransferService) getStore(ctx context.Context, id int64) (*model.ObjectStore, error) {
+	store, err := s.transferRepo.GetStoreByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get object store", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if store == nil {
+		return nil, v1.ErrObjectStoreNotFound
+	}
+	return store, nil
+}
+
+// ListStoreObjects 列出对象存储中可导入的虚拟机备份
+func (s *imageTransferService) ListStoreObjects(ctx context.Context, id int64, req *v1.ListStoreObjectsRequest) ([]v1.StoreObjectItem, error) {
+	store, err := s.getStore(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	client, err := newS3Client(store)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrObjectStoreUnreachable, err.Error())
+	}
+
+	prefix := store.Prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+	prefix += strings.TrimPrefix(req.Prefix, "/")
+	objects, err := client.ListObjects(ctx, prefix, 10000)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to list objects", zap.Error(err), zap.String("store", store.Name))
+		return nil, v1.WithDetail(v1.ErrObjectStoreUnreachable, err.Error())
+	}
+
+	manifests := make(map[string]bool)
+	for _, obj := range objects {
+		if strings.HasSuffix(obj.Key, transferManifestSuffix) {
+			manifests[strings.TrimSuffix(obj.Key, transferManifestSuffix)] = true
+		}
+	}
+	list := make([]v1.StoreObjectItem, 0, len(objects))
+	for _, obj := range objects {
+		if !vzdumpQemuArchivePattern.MatchString(path.Base(obj.Key)) {
+			continue
+		}
+		list = append(list, v1.StoreObjectItem{
+			Key:          obj.Key,
+			Size:         obj.Size,
+			LastModified: obj.LastModified,
+			HasManifest:  manifests[obj.Key],
+		})
+	}
+	return list, nil
+}
+
+// CreateExport 导出虚拟机：备份到中转存储后上传到对象存储
+func (s *imageTransferService) CreateExport(ctx context.Context, req *v1.CreateImageExportRequest, creator string) (*v1.ImageTransferItem, error) {
+	vm, err := s.vmRepo.GetByID(ctx, req.VmId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.ErrVMNotFound
+	}
+	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
+	}
+	if _, err := s.getStore(ctx, req.StoreID); err != nil {
+		return nil, err
+	}
+	if _, err := s.stagingDir(req.StagingStorage); err != nil {
+		return nil, err
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = "snapshot"
+	}
+	// snapshot 模式不影响虚拟机运行；suspend / stop 会中断业务，需符合变更窗口
+	if mode != "snapshot" {
+		if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+			Action:    "vm.export",
+			Target:    vm.VmName,
+			ClusterID: vm.ClusterID,
+			AppId:     vm.AppId,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	task := &model.ImageTransferTask{
+		Direction:      model.ImageTransferExport,
+		StoreID:        req.StoreID,
+		ClusterID:      vm.ClusterID,
+		NodeID:         node.Id,
+		NodeName:       node.NodeName,
+		VMID:           vm.VMID,
+		VmName:         vm.VmName,
+		VmId:           vm.Id,
+		StagingStorage: req.StagingStorage,
+		AppId:          vm.AppId,
+		BackupMode:     mode,
+		Status:         model.ImageTransferStatusPending,
+		Creator:        creator,
+	}
+	if req.KeepStaging {
+		task.KeepStaging = 1
+	}
+	if err := s.transferRepo.CreateTask(ctx, task); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create image transfer task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	go s.execute(task.Id)
+
+	s.logger.WithContext(ctx).Info("image export task created",
+		zap.Int64("task_id", task.Id), zap.String("vm_name", vm.VmName), zap.Int64("store_id", req.StoreID))
+	item := toImageTransferItem(task)
+	return &item, nil
+}
+
+// CreateImport 从对象存储导入虚拟机：下载到中转存储并校验后恢复
+func (s *imageTransferService) CreateImport(ctx context.Context, req *v1.CreateImageImportRequest, creator string) (*v1.ImageTransferItem, error) {
+	store, err := s.getStore(ctx, req.StoreID)
+	if err != nil {
+		return nil, err
+	}
+	if !vzdumpQemuArchivePattern.MatchString(path.Base(req.ObjectKey)) {
+		return nil, v1.WithDetail(v1.ErrTransferInvalidObject, req.ObjectKey)
+	}
+	if _, err := s.stagingDir(req.StagingStorage); err != nil {
+		return nil, err
+	}
+
+	node, err := s.nodeRepo.GetByID(ctx, req.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", node.ClusterID)
+	}
+	if cluster.IsSchedulable != 1 {
+		return nil, v1.WithDetailf(v1.ErrClusterNotSchedulable, "cluster=%s", cluster.ClusterName)
+	}
+
+	client, err := newS3Client(store)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrObjectStoreUnreachable, err.Error())
+	}
+	size, err := client.HeadObject(ctx, req.ObjectKey)
+	if err != nil {
+		if s3.IsNotFound(err) {
+			return nil, v1.WithDetailf(v1.ErrTransferInvalidObject, "%s: not found", req.ObjectKey)
+		}
+		return nil, v1.WithDetail(v1.ErrObjectStoreUnreachable, err.Error())
+	}
+
+	vmID := generateProxmoxVMID(req.VMID)
+	existing, err := s.vmRepo.GetByVMID(ctx, vmID, node.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to check vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return nil, v1.WithDetailf(v1.ErrVMAlreadyExists, "vmid=%d, node=%s", vmID, node.NodeName)
+	}
+
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "vm.import",
+		Target:    path.Base(req.ObjectKey),
+		ClusterID: cluster.Id,
+		AppId:     req.AppId,
+	}); err != nil {
+		return nil, err
+	}
+
+	task := &model.ImageTransferTask{
+		Direction:      model.ImageTransferImport,
+		StoreID:        store.Id,
+		ObjectKey:      req.ObjectKey,
+		ClusterID:      cluster.Id,
+		NodeID:         node.Id,
+		NodeName:       node.NodeName,
+		VMID:           vmID,
+		VmName:         req.VmName,
+		StagingStorage: req.StagingStorage,
+		TargetStorage:  req.TargetStorage,
+		AppId:          req.AppId,
+		TotalBytes:     size,
+		Status:         model.ImageTransferStatusPending,
+		Creator:        creator,
+	}
+	if req.KeepStaging {
+		task.KeepStaging = 1
+	}
+	if err := s.transferRepo.CreateTask(ctx, task); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create image transfer task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	go s.execute(task.Id)
+
+	s.logger.WithContext(ctx).Info("image import task created",
+		zap.Int64("task_id", task.Id), zap.String("object_key", req.ObjectKey), zap.String("node", node.NodeName), zap.Uint32("vmid", vmID))
+	item := toImageTransferItem(task)
+	return &item, nil
+}
+
+// execute 执行传输任务
+func (s *imageTransferService) execute(taskID int64) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	timeout := s.conf.GetDuration("image_transfer.timeout")
+	if timeout <= 0 {
+		timeout = defaultTransferTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	task, err := s.transferRepo.GetTaskByID(ctx, taskID)
+	if err != nil || task == nil {
+		s.logger.Error("failed to load image transfer task", zap.Int64("task_id", taskID), zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	task.Status = model.ImageTransferStatusRunning
+	task.StartTime = &now
+
+	if task.Direction == model.ImageTransferExport {
+		err = s.runExport(ctx, task)
+	} else {
+		err = s.runImport(ctx, task)
+	}
+
+	// 任务上下文可能已超时，最终状态使用新的上下文保存
+	end := time.Now()
+	task.EndTime = &end
+	if err != nil {
+		s.logger.Error("image transfer failed", zap.Int64("task_id", taskID), zap.String("direction", task.Direction), zap.Error(err))
+		task.Status = model.ImageTransferStatusFailed
+		task.ErrorMessage = err.Error()
+	} else {
+		task.Status = model.ImageTransferStatusCompleted
+		task.Progress = 100
+		s.logger.Info("image transfer completed", zap.Int64("task_id", taskID), zap.String("direction", task.Direction),
+			zap.String("object_key", task.ObjectKey), zap.Int64("bytes", task.TotalBytes))
+	}
+	s.saveTask(context.Background(), task)
+	s.notificationService.Notify(context.Background(), taskFinishedNotification("image_transfer", task.Id,
+		fmt.Sprintf("Image %s of %s", task.Direction, task.ObjectKey), err), task.Creator)
+}
+
+func (s *imageTransferService) saveTask(ctx context.Context, task *model.ImageTransferTask) {
+	if err := s.transferRepo.UpdateTask(ctx, task); err != nil {
+		s.logger.Error("failed to update image transfer task", zap.Int64("task_id", task.Id), zap.Error(err))
+	}
+}
+
+// setTransferred 更新已传输字节数及进度（完成前最多 99）
+func (s *imageTransferService) setTransferred(ctx context.Context, task *model.ImageTransferTask, n int64) {
+	task.TransferredBytes = n
+	if task.TotalBytes > 0 {
+		task.Progress = min(int(n*100/task.TotalBytes), 99)
+	}
+	s.saveTask(ctx, task)
+}
+
+func (s *imageTransferService) getClients(ctx context.Context, task *model.ImageTransferTask) (*model.PveCluster, *proxmox.ProxmoxClient, *model.ObjectStore, *s3.Client, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, task.ClusterID)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if cluster == nil {
+		return nil, nil, nil, nil, fmt.Errorf("cluster %d not found", task.ClusterID)
+	}
+	pveClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	store, err := s.transferRepo.GetStoreByID(ctx, task.StoreID)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if store == nil {
+		return nil, nil, nil, nil, fmt.Errorf("object store %d not found", task.StoreID)
+	}
+	s3Client, err := newS3Client(store)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	return cluster, pveClient, store, s3Client, nil
+}
+
+// removeStaging 删除中转存储上的备份（失败只记录日志）
+func (s *imageTransferService) removeStaging(ctx context.Context, client *proxmox.ProxmoxClient, task *model.ImageTransferTask) {
+	if task.Archive == "" || task.KeepStaging == 1 {
+		return
+	}
+	if err := client.DeleteStorageContent(ctx, task.NodeName, task.StagingStorage, task.Archive, nil); err != nil {
+		s.logger.Warn("failed to remove staging archive", zap.Int64("task_id", task.Id), zap.String("archive", task.Archive), zap.Error(err))
+	}
+}
+
+func (s *imageTransferService) runExport(ctx context.Context, task *model.ImageTransferTask) error {
+	cluster, pveClient, store, s3Client, err := s.getClients(ctx, task)
+	if err != nil {
+		return err
+	}
+	dir, err := s.stagingDir(task.StagingStorage)
+	if err != nil {
+		return err
+	}
+
+	// 1. 备份到中转存储
+	task.Phase = model.ImageTransferPhaseBackup
+	s.saveTask(ctx, task)
+	backupStart := time.Now()
+	upid, err := pveClient.CreateBackup(ctx, task.NodeName, &proxmox.CreateBackupRequest{
+		VMID:     task.VMID,
+		Storage:  task.StagingStorage,
+		Compress: "zstd",
+		Mode:     task.BackupMode,
+	})
+	if err != nil {
+		return fmt.Errorf("create backup: %w", err)
+	}
+	task.UPID = upid
+	s.saveTask(ctx, task)
+	if err := pveClient.WaitForTask(ctx, task.NodeName, upid, time.Until(deadline(ctx))); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	archive, err := findBackupArchive(ctx, pveClient, task, backupStart)
+	if err != nil {
+		return err
+	}
+	task.Archive = archive
+	s.saveTask(ctx, task)
+	defer s.removeStaging(context.Background(), pveClient, task)
+
+	// 2. 分段上传
+	basename := path.Base(archive[strings.Index(archive, ":")+1:])
+	file, err := os.Open(filepath.Join(dir, "dump", basename))
+	if err != nil {
+		return fmt.Errorf("open staging archive: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	task.ObjectKey = joinObjectKey(store.Prefix, cluster.ClusterName, basename)
+	task.Phase = model.ImageTransferPhaseUpload
+	task.TotalBytes = info.Size()
+	s.setTransferred(ctx, task, 0)
+
+	checksum, err := s.uploadMultipart(ctx, s3Client, task, file)
+	if err != nil {
+		return err
+	}
+	task.Checksum = checksum
+
+	// 3. 写入清单
+	manifest, err := json.MarshalIndent(transferManifest{
+		Version:    1,
+		Archive:    basename,
+		Size:       task.TotalBytes,
+		SHA256:     checksum,
+		PartSize:   s.partSize(),
+		Cluster:    cluster.ClusterName,
+		Node:       task.NodeName,
+		VMID:       task.VMID,
+		VmName:     task.VmName,
+		CreateTime: time.Now(),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := s3Client.PutObject(ctx, task.ObjectKey+transferManifestSuffix, manifest, "application/json"); err != nil {
+		return fmt.Errorf("put manifest: %w", err)
+	}
+	return nil
+}
+
+// uploadMultipart 分段上传文件并计算整体 SHA256，失败时放弃上传释放已传分段
+func (s *imageTransferService) uploadMultipart(ctx context.Context, client *s3.Client, task *model.ImageTransferTask, file io.Reader) (string, error) {
+	uploadID, err := client.CreateMultipartUpload(ctx, task.ObjectKey, "application/octet-stream")
+	if err != nil {
+		return "", fmt.Errorf("create multipart upload: %w", err)
+	}
+
+	hasher := sha256.New()
+	buf := make([]byte, s.partSize())
+	var parts []s3.CompletedPart
+	var transferred int64
+	err = func() error {
+		for partNumber := 1; ; partNumber++ {
+			n, rerr := io.ReadFull(file, buf)
+			if rerr != nil && !errors.Is(rerr, io.ErrUnexpectedEOF) && !errors.Is(rerr, io.EOF) {
+				return fmt.Errorf("read staging archive: %w", rerr)
+			}
+			if n == 0 && partNumber > 1 {
+				return nil
+			}
+			data := buf[:n]
+			hasher.Write(data)
+
+			etag, err := retryTransfer(ctx, func() (string, error) {
+				return client.UploadPart(ctx, task.ObjectKey, uploadID, partNumber, data)
+			})
+			if err != nil {
+				return fmt.Errorf("upload part %d: %w", partNumber, err)
+			}
+			parts = append(parts, s3.CompletedPart{PartNumber: partNumber, ETag: etag})
+			transferred += int64(n)
+			s.setTransferred(ctx, task, transferred)
+			if rerr != nil {
+				return nil
+			}
+		}
+	}()
+	if err == nil {
+		err = client.CompleteMultipartUpload(ctx, task.ObjectKey, uploadID, parts)
+	}
+	if err != nil {
+		if aerr := client.AbortMultipartUpload(context.Background(), task.ObjectKey, uploadID); aerr != nil {
+			s.logger.Warn("failed to abort multipart upload", zap.String("key", task.ObjectKey), zap.Error(aerr))
+		}
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+func (s *imageTransferService) runImport(ctx context.Context, task *model.ImageTransferTask) error {
+	cluster, pveClient, _, s3Client, err := s.getClients(ctx, task)
+	if err != nil {
+		return err
+	}
+	dir, err := s.stagingDir(task.StagingStorage)
+	if err != nil {
+		return err
+	}
+
+	// 读取清单（旧版本或手工上传的备份可能没有清单，此时不做校验）
+	var manifest *transferManifest
+	if body, err := s3Client.GetObject(ctx, task.ObjectKey+transferManifestSuffix); err == nil {
+		manifest = new(transferManifest)
+		err = json.NewDecoder(body).Decode(manifest)
+		body.Close()
+		if err != nil {
+			return fmt.Errorf("decode manifest: %w", err)
+		}
+		if manifest.Size != task.TotalBytes {
+			return fmt.Errorf("object size %d does not match manifest size %d", task.TotalBytes, manifest.Size)
+		}
+		if task.VmName == "" {
+			task.VmName = manifest.VmName
+		}
+	} else if !s3.IsNotFound(err) {
+		return fmt.Errorf("get manifest: %w", err)
+	}
+
+	// 1. 分段下载到中转存储（先写临时文件，校验通过后再改名，避免 Proxmox 看到不完整的备份）
+	basename := path.Base(task.ObjectKey)
+	target := filepath.Join(dir, "dump", basename)
+	partial := target + ".part"
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("staging archive %s already exists", basename)
+	}
+	task.Phase = model.ImageTransferPhaseDownload
+	s.setTransferred(ctx, task, 0)
+
+	checksum, err := s.downloadRanges(ctx, s3Client, task, partial)
+	if err != nil {
+		os.Remove(partial)
+		return err
+	}
+
+	// 2. 校验
+	task.Phase = model.ImageTransferPhaseVerify
+	task.Checksum = checksum
+	s.saveTask(ctx, task)
+	if manifest != nil && !strings.EqualFold(manifest.SHA256, checksum) {
+		os.Remove(partial)
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", manifest.SHA256, checksum)
+	}
+	if err := os.Rename(partial, target); err != nil {
+		os.Remove(partial)
+		return fmt.Errorf("rename staging archive: %w", err)
+	}
+	task.Archive = fmt.Sprintf("%s:backup/%s", task.StagingStorage, basename)
+	s.saveTask(ctx, task)
+	defer s.removeStaging(context.Background(), pveClient, task)
+
+	// 3. 恢复为虚拟机
+	task.Phase = model.ImageTransferPhaseRestore
+	s.saveTask(ctx, task)
+	if err := (storageForecaster{conf: s.conf}).checkRestore(ctx, s.logger, pveClient, task.NodeName, task.Archive, task.TargetStorage); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	params := url.Values{}
+	params.Set("vmid", strconv.FormatUint(uint64(task.VMID), 10))
+	params.Set("archive", task.Archive)
+	params.Set("storage", task.TargetStorage)
+	upid, err := pveClient.CreateQemuVM(ctx, task.NodeName, params)
+	if err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+	task.UPID = upid
+	s.saveTask(ctx, task)
+	if err := pveClient.WaitForTask(ctx, task.NodeName, upid, time.Until(deadline(ctx))); err != nil {
+		return fmt.Errorf("restore: %w", err)
+	}
+
+	config, err := pveClient.GetVMConfig(ctx, task.NodeName, task.VMID)
+	if err != nil {
+		return fmt.Errorf("get restored vm config: %w", err)
+	}
+	if name, _ := config["name"].(string); task.VmName != "" && name != task.VmName {
+		if err := pveClient.UpdateVMConfig(ctx, task.NodeName, task.VMID, map[string]interface{}{"name": task.VmName}); err != nil {
+			s.logger.Warn("failed to rename restored vm", zap.Uint32("vmid", task.VMID), zap.Error(err))
+			task.VmName = name
+		}
+	} else if task.VmName == "" {
+		task.VmName = name
+	}
+
+	// 虚拟机记录（controller 同步时会以 Proxmox 实际配置更新）
+	vm := &model.PveVM{
+		VmName:     task.VmName,
+		ClusterID:  cluster.Id,
+		NodeID:     task.NodeID,
+		VMID:       task.VMID,
+		CPUNum:     configInt(config, "cores") * max(configInt(config, "sockets"), 1),
+		MemorySize: configInt(config, "memory"),
+		Storage:    task.TargetStorage,
+		Status:     "stopped",
+		AppId:      task.AppId,
+		CreateTime: time.Now(),
+		UpdateTime: time.Now(),
+	}
+	if storageCfg, err := json.Marshal(map[string]interface{}{
+		"create_mode": "restore",
+		"object_key":  task.ObjectKey,
+	}); err == nil {
+		vm.StorageCfg = string(storageCfg)
+	}
+	if node, err := s.nodeRepo.GetByID(ctx, task.NodeID); err == nil && node != nil {
+		vm.NodeIP = node.IPAddress
+	}
+	if err := s.vmRepo.Create(ctx, vm); err != nil {
+		return fmt.Errorf("create vm record: %w", err)
+	}
+	task.VmId = vm.Id
+	return nil
+}
+
+// downloadRanges 按分段大小分区间下载对象，每段下载失败可单独重试，返回整体 SHA256
+func (s *imageTransferService) downloadRanges(ctx context.Context, client *s3.Client, task *model.ImageTransferTask, dst string) (string, error) {
+	file, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return "", fmt.Errorf("create staging archive: %w", err)
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	partSize := s.partSize()
+	for offset := int64(0); offset < task.TotalBytes; offset += partSize {
+		end := min(offset+partSize, task.TotalBytes) - 1
+		data, err := retryTransfer(ctx, func() ([]byte, error) {
+			body, err := client.GetObjectRange(ctx, task.ObjectKey, offset, end)
+			if err != nil {
+				return nil, err
+			}
+			defer body.Close()
+			data, err := io.ReadAll(body)
+			if err != nil {
+				return nil, err
+			}
+			if int64(len(data)) != end-offset+1 {
+				return nil, fmt.Errorf("short read at offset %d: %d bytes", offset, len(data))
+			}
+			return data, nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("download range %d-%d: %w", offset, end, err)
+		}
+		if _, err := io.Copy(io.MultiWriter(file, hasher), bytes.NewReader(data)); err != nil {
+			return "", fmt.Errorf("write staging archive: %w", err)
+		}
+		s.setTransferred(ctx, task, end+1)
+	}
+	if err := file.Sync(); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// retryTransfer 单个分段失败时重试
+func retryTransfer[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var result T
+	var err error
+	for attempt := 1; attempt <= transferPartRetries; attempt++ {
+		if result, err = fn(); err == nil {
+			return result, nil
+		}
+		if attempt < transferPartRetries {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-time.After(time.Duration(attempt) * 2 * time.Second):
+			}
+		}
+	}
+	return result, err
+}
+
+// findBackupArchive 在中转存储中查找本次备份生成的文件
+func findBackupArchive(ctx context.Context, client *proxmox.ProxmoxClient, task *model.ImageTransferTask, since time.Time) (string, error) {
+	contents, err := client.GetStorageContent(ctx, task.NodeName, task.StagingStorage, "backup")
+	if err != nil {
+		return "", fmt.Errorf("list staging storage: %w", err)
+	}
+	var archive string
+	var latest float64
+	for _, item := range contents {
+		vmid, _ := item["vmid"].(float64)
+		ctime, _ := item["ctime"].(float64)
+		volid, _ := item["volid"].(string)
+		if uint32(vmid) != task.VMID || volid == "" || ctime < float64(since.Add(-time.Minute).Unix()) {
+			continue
+		}
+		if !vzdumpQemuArchivePattern.MatchString(path.Base(volid[strings.Index(volid, ":")+1:])) {
+			continue
+		}
+		if ctime > latest {
+			latest, archive = ctime, volid
+		}
+	}
+	if archive == "" {
+		return "", fmt.Errorf("backup archive for vmid %d not found on %s", task.VMID, task.StagingStorage)
+	}
+	return archive, nil
+}
+
+// deadline 返回上下文截止时间，未设置时视为不限制
+func deadline(ctx context.Context) time.Time {
+	if d, ok := ctx.Deadline(); ok {
+		return d
+	}
+	return time.Now().Add(defaultTransferTimeout)
+}
+
+// joinObjectKey 拼接对象键，忽略空段
+func joinObjectKey(parts ...string) string {
+	segments := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.Trim(p, "/"); p != "" {
+			segments = append(segments, p)
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func (s *imageTransferService) GetTransfer(ctx context.Context, id int64) (*v1.ImageTransferItem, error) {
+	task, err := s.transferRepo.GetTaskByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get image transfer task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if task == nil {
+		return nil, v1.ErrTransferTaskNotFound
+	}
+	item := toImageTransferItem(task)
+	return &item, nil
+}
+
+func (s *imageTransferService) ListTransfers(ctx context.Context, req *v1.ListImageTransfersRequest) (*v1.ListImageTransfersResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	tasks, total, err := s.transferRepo.ListTasks(ctx, page, pageSize, req.Direction, req.ClusterID, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list image transfer tasks", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.ImageTransferItem, 0, len(tasks))
+	for _, task := range tasks {
+		list = append(list, toImageTransferItem(task))
+	}
+	return &v1.ListImageTransfersResponseData{Total: total, List: list}, nil
+}
+
+func toObjectStoreItem(store *model.ObjectStore) v1.ObjectStoreItem {
+	return v1.ObjectStoreItem{
+		Id:             store.Id,
+		Name:           store.Name,
+		Endpoint:       store.Endpoint,
+		Region:         store.Region,
+		Bucket:         store.Bucket,
+		Prefix:         store.Prefix,
+		AccessKey:      store.AccessKey,
+		PathStyle:      store.PathStyle,
+		SkipCertVerify: store.SkipCertVerify,
+		Creator:        store.Creator,
+		CreateTime:     store.CreateTime,
+	}
+}
+
+func toImageTransferItem(task *model.ImageTransferTask) v1.ImageTransferItem {
+	return v1.ImageTransferItem{
+		Id:               task.Id,
+		Direction:        task.Direction,
+		StoreID:          task.StoreID,
+		ObjectKey:        task.ObjectKey,
+		ClusterID:        task.ClusterID,
+		NodeID:           task.NodeID,
+		NodeName:         task.NodeName,
+		VMID:             task.VMID,
+		VmName:           task.VmName,
+		VmId:             task.VmId,
+		StagingStorage:   task.StagingStorage,
+		TargetStorage:    task.TargetStorage,
+		Archive:          task.Archive,
+		Status:           task.Status,
+		Phase:            task.Phase,
+		TotalBytes:       task.TotalBytes,
+		TransferredBytes: task.TransferredBytes,
+		Progress:         task.Progress,
+		Checksum:         task.Checksum,
+		ErrorMessage:     task.ErrorMessage,
+		StartTime:        task.StartTime,
+		EndTime:          task.EndTime,
+		Creator:          task.Creator,
+		CreateTime:       task.CreateTime,
+	}
+}
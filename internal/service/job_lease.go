@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// defaultJobLeaseTTL 后台任务租约有效期，可通过 ha.lease.ttl 调整；续约间隔（ha.lease.interval）应明显小于该值
+const defaultJobLeaseTTL = 2 * time.Minute
+
+// JobRecoverer 接管过期租约后的恢复逻辑：租约已由当前实例持有，恢复结束（或任务已结束）后应调用 Release；
+// 耗时较长的恢复（如继续执行任务）应在后台进行
+type JobRecoverer func(ctx context.Context, jobID int64, previousOwner string)
+
+// JobLeaseService 后台任务归属租约。实例提交或开始执行任务时获取租约并由 JobLeaseServer 定期续约；
+// 实例崩溃或停止后租约过期，其他实例接管并调用该类任务登记的 JobRecoverer（继续执行或标记失败）
+type JobLeaseService interface {
+	// InstanceID 当前实例标识（ha.instance_id，未配置时为主机名加随机后缀）
+	InstanceID() string
+	// Acquire 为当前实例获取任务租约，租约属于其他未过期的实例时返回 false
+	Acquire(ctx context.Context, jobType string, jobID int64) (bool, error)
+	Release(ctx context.Context, jobType string, jobID int64)
+	RegisterRecoverer(jobType string, recoverer JobRecoverer)
+	// RenewLeases 续约当前实例持有的全部租约
+	RenewLeases(ctx context.Context) (int64, error)
+	// RecoverExpired 接管已过期的租约并执行对应的恢复逻辑，返回接管数量
+	RecoverExpired(ctx context.Context) (int, error)
+}
+
+func NewJobLeaseService(
+	conf *viper.Viper,
+	leaseRepo repository.JobLeaseRepository,
+	logger *log.Logger,
+) JobLeaseService {
+	instanceID := strings.TrimSpace(conf.GetString("ha.instance_id"))
+	if instanceID == "" {
+		instanceID = defaultInstanceID()
+	}
+	return &jobLeaseService{
+		conf:       conf,
+		leaseRepo:  leaseRepo,
+		logger:     logger,
+		instanceID: instanceID,
+		recoverers: make(map[string]JobRecoverer),
+	}
+}
+
+type jobLeaseService struct {
+	conf       *viper.Viper
+	leaseRepo  repository.JobLeaseRepository
+	logger     *log.Logger
+	instanceID string
+
+	mu         sync.RWMutex
+	recoverers map[string]JobRecoverer
+}
+
+// defaultInstanceID 主机名加随机后缀，同一主机重启后的进程也视为不同实例
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "pvesphere"
+	}
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return host + "-" + hex.EncodeToString(b)
+}
+
+func (s *jobLeaseService) InstanceID() string {
+	return s.instanceID
+}
+
+func (s *jobLeaseService) ttl() time.Duration {
+	ttl := s.conf.GetDuration("ha.lease.ttl")
+	if ttl <= 0 {
+		ttl = defaultJobLeaseTTL
+	}
+	return ttl
+}
+
+func (s *jobLeaseService) Acquire(ctx context.Context, jobType string, jobID int64) (bool, error) {
+	return s.leaseRepo.Acquire(ctx, jobType, jobID, s.instanceID, time.Now().Add(s.ttl()))
+}
+
+func (s *jobLeaseService) Release(ctx context.Context, jobType string, jobID int64) {
+	if err := s.leaseRepo.Release(ctx, jobType, jobID, s.instanceID); err != nil {
+		s.logger.Warn("failed to release job lease", zap.String("job_type", jobType), zap.Int64("job_id", jobID), zap.Error(err))
+	}
+}
+
+func (s *jobLeaseService) RegisterRecoverer(jobType string, recoverer JobRecoverer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recoverers[jobType] = recoverer
+}
+
+func (s *jobLeaseService) RenewLeases(ctx context.Context) (int64, error) {
+	return s.leaseRepo.Renew(ctx, s.instanceID, time.Now().Add(s.ttl()))
+}
+
+func (s *jobLeaseService) RecoverExpired(ctx context.Context) (int, error) {
+	s.mu.RLock()
+	recoverers := make(map[string]JobRecoverer, len(s.recoverers))
+	for jobType, recoverer := range s.recoverers {
+		recoverers[jobType] = recoverer
+	}
+	s.mu.RUnlock()
+
+	recovered := 0
+	for jobType, recoverer := range recoverers {
+		leases, err := s.leaseRepo.ListExpired(ctx, jobType, time.Now())
+		if err != nil {
+			return recovered, err
+		}
+		for _, lease := range leases {
+			// 多个实例同时检查时只有一个能接管
+			ok, err := s.Acquire(ctx, jobType, lease.JobID)
+			if err != nil {
+				s.logger.Warn("failed to take over job lease", zap.String("job_type", jobType), zap.Int64("job_id", lease.JobID), zap.Error(err))
+				continue
+			}
+			if !ok {
+				continue
+			}
+			s.logger.Info("took over expired job lease", zap.String("job_type", jobType), zap.Int64("job_id", lease.JobID),
+				zap.String("previous_owner", lease.Owner), zap.String("owner", s.instanceID))
+			recoverer(ctx, lease.JobID, lease.Owner)
+			recovered++
+		}
+	}
+	return recovered, nil
+}
@@ -0,0 +1,821 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type LicenseService interface {
+	CreateLicense(ctx context.Context, userID string, req *v1.CreateLicenseRequest) error
+	UpdateLicense(ctx context.Context, userID string, id int64, req *v1.UpdateLicenseRequest) error
+	DeleteLicense(ctx context.Context, userID string, id int64) error
+	ListLicenses(ctx context.Context, req *v1.ListLicensesRequest) (*v1.ListLicensesResponseData, error)
+	GetLicense(ctx context.Context, id int64) (*v1.LicenseDetail, error)
+	// GetLicenseKey 返回完整密钥，仅管理员可查看
+	GetLicenseKey(ctx context.Context, userID string, id int64) (*v1.LicenseKeyData, error)
+	Assign(ctx context.Context, userID string, id int64, req *v1.AssignLicenseRequest) error
+	Unassign(ctx context.Context, userID string, id, assignmentID int64) error
+	GetUsage(ctx context.Context) (*v1.LicenseUsageData, error)
+	ListAlerts(ctx context.Context, req *v1.ListLicenseAlertsRequest) (*v1.ListLicenseAlertsResponseData, error)
+	// Refresh 立即采集虚拟机操作系统信息并检查超用，仅管理员可操作
+	Refresh(ctx context.Context, userID string) error
+	// CollectOSInfo 通过 guest agent 采集运行中虚拟机的操作系统信息
+	CollectOSInfo(ctx context.Context) error
+	// CheckConsumption 检查许可证占用，超用时记录告警并通知，回落后恢复告警
+	CheckConsumption(ctx context.Context) error
+}
+
+func NewLicenseService(
+	service *Service,
+	conf *viper.Viper,
+	licenseRepo repository.LicenseRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	templateRepo repository.VmTemplateRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationService,
+	logger *log.Logger,
+) LicenseService {
+	return &licenseService{
+		conf:                conf,
+		licenseRepo:         licenseRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		vmRepo:              vmRepo,
+		templateRepo:        templateRepo,
+		userRepo:            userRepo,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+		httpClient:          &http.Client{Timeout: 10 * time.Second},
+		eolRules:            loadOSEOLRules(conf, logger),
+	}
+}
+
+type licenseService struct {
+	conf         *viper.Viper
+	licenseRepo  repository.LicenseRepository
+	clusterRepo  repository.PveClusterRepository
+	nodeRepo     repository.PveNodeRepository
+	vmRepo       repository.PveVMRepository
+	templateRepo repository.VmTemplateRepository
+	userRepo     repository.UserRepository
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+	httpClient          *http.Client // 超用告警 webhook
+	eolRules            []osEOLRule  // 采集 osinfo 时识别停止维护的操作系统
+}
+
+// normalizeOSType 统一操作系统类型：小写，agent 上报的 mswindows 记为 windows
+func normalizeOSType(osType string) string {
+	osType = strings.ToLower(strings.TrimSpace(osType))
+	if osType == "mswindows" {
+		return "windows"
+	}
+	return osType
+}
+
+// maskLicenseKey 密钥脱敏，仅保留末 5 位
+func maskLicenseKey(key string) string {
+	runes := []rune(key)
+	if len(runes) == 0 {
+		return ""
+	}
+	if len(runes) <= 5 {
+		return "*****"
+	}
+	return "*****" + string(runes[len(runes)-5:])
+}
+
+func licenseExpired(license *model.License, now time.Time) bool {
+	return license.ExpireAt != nil && license.ExpireAt.Before(now)
+}
+
+func (s *licenseService) CreateLicense(ctx context.Context, userID string, req *v1.CreateLicenseRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "license.manage")
+	if err != nil {
+		return err
+	}
+
+	name := strings.TrimSpace(req.Name)
+	existing, err := s.licenseRepo.GetLicenseByName(ctx, name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get license", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return v1.WithDetail(v1.ErrLicenseExists, name)
+	}
+
+	license := &model.License{
+		Name:           name,
+		OSType:         normalizeOSType(req.OSType),
+		Product:        req.Product,
+		LicenseKey:     strings.TrimSpace(req.LicenseKey),
+		SubscriptionID: strings.TrimSpace(req.SubscriptionID),
+		Seats:          req.Seats,
+		ExpireAt:       req.ExpireAt,
+		Describes:      req.Describes,
+		Creator:        username,
+		Modifier:       username,
+	}
+	if err := s.licenseRepo.CreateLicense(ctx, license); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create license", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("license created",
+		zap.String("name", license.Name), zap.String("os_type", license.OSType), zap.Int("seats", license.Seats), zap.String("operator", username))
+	return nil
+}
+
+func (s *licenseService) getLicense(ctx context.Context, id int64) (*model.License, error) {
+	license, err := s.licenseRepo.GetLicenseByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get license", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if license == nil {
+		return nil, v1.ErrLicenseNotFound
+	}
+	return license, nil
+}
+
+func (s *licenseService) UpdateLicense(ctx context.Context, userID string, id int64, req *v1.UpdateLicenseRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "license.manage")
+	if err != nil {
+		return err
+	}
+	license, err := s.getLicense(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if req.Name != nil && strings.TrimSpace(*req.Name) != license.Name {
+		name := strings.TrimSpace(*req.Name)
+		existing, err := s.licenseRepo.GetLicenseByName(ctx, name)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get license", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if existing != nil {
+			return v1.WithDetail(v1.ErrLicenseExists, name)
+		}
+		license.Name = name
+	}
+	if req.OSType != nil {
+		license.OSType = normalizeOSType(*req.OSType)
+	}
+	if req.Product != nil {
+		license.Product = *req.Product
+	}
+	if req.LicenseKey != nil {
+		license.LicenseKey = strings.TrimSpace(*req.LicenseKey)
+	}
+	if req.SubscriptionID != nil {
+		license.SubscriptionID = strings.TrimSpace(*req.SubscriptionID)
+	}
+	if req.Seats != nil {
+		license.Seats = *req.Seats
+	}
+	if req.ExpireAt != nil {
+		license.ExpireAt = req.ExpireAt
+	}
+	if req.ClearExpireAt {
+		license.ExpireAt = nil
+	}
+	if req.Describes != nil {
+		license.Describes = *req.Describes
+	}
+	license.Modifier = username
+
+	if err := s.licenseRepo.UpdateLicense(ctx, license); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update license", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *licenseService) DeleteLicense(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "license.manage")
+	if err != nil {
+		return err
+	}
+	license, err := s.getLicense(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.licenseRepo.DeleteLicense(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete license", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("license deleted", zap.String("name", license.Name), zap.String("operator", username))
+	return nil
+}
+
+func (s *licenseService) GetLicenseKey(ctx context.Context, userID string, id int64) (*v1.LicenseKeyData, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "license.manage")
+	if err != nil {
+		return nil, err
+	}
+	license, err := s.getLicense(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.WithContext(ctx).Info("license key viewed", zap.String("name", license.Name), zap.String("operator", username))
+	return &v1.LicenseKeyData{LicenseKey: license.LicenseKey}, nil
+}
+
+func (s *licenseService) Assign(ctx context.Context, userID string, id int64, req *v1.AssignLicenseRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "license.manage")
+	if err != nil {
+		return err
+	}
+	license, err := s.getLicense(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	switch req.TargetType {
+	case model.LicenseTargetVM:
+		vm, err := s.vmRepo.GetByID(ctx, req.TargetID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if vm == nil {
+			return v1.WithDetailf(v1.ErrLicenseTargetNotFound, "vm_id=%d", req.TargetID)
+		}
+	case model.LicenseTargetTemplate:
+		template, err := s.templateRepo.GetByID(ctx, req.TargetID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get template", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if template == nil {
+			return v1.WithDetailf(v1.ErrLicenseTargetNotFound, "template_id=%d", req.TargetID)
+		}
+	default:
+		return v1.WithDetail(v1.ErrBadRequest, "target_type must be vm or template")
+	}
+
+	existing, err := s.licenseRepo.GetAssignment(ctx, id, req.TargetType, req.TargetID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get license assignment", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return v1.ErrLicenseAssignmentExists
+	}
+
+	assignment := &model.LicenseAssignment{
+		LicenseID:  id,
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Creator:    username,
+	}
+	if err := s.licenseRepo.CreateAssignment(ctx, assignment); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create license assignment", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("license assigned", zap.String("license", license.Name),
+		zap.String("target_type", req.TargetType), zap.Int64("target_id", req.TargetID), zap.String("operator", username))
+	return nil
+}
+
+func (s *licenseService) Unassign(ctx context.Context, userID string, id, assignmentID int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "license.manage")
+	if err != nil {
+		return err
+	}
+	assignment, err := s.licenseRepo.GetAssignmentByID(ctx, assignmentID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get license assignment", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if assignment == nil || assignment.LicenseID != id {
+		return v1.ErrLicenseAssignmentNotFound
+	}
+
+	if err := s.licenseRepo.DeleteAssignment(ctx, assignmentID); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete license assignment", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("license unassigned", zap.Int64("license_id", id),
+		zap.String("target_type", assignment.TargetType), zap.Int64("target_id", assignment.TargetID), zap.String("operator", username))
+	return nil
+}
+
+// licenseInventory 计算许可证占用所需的数据快照
+type licenseInventory struct {
+	licenses    []*model.License
+	assignments []*model.LicenseAssignment
+	vms         map[int64]*model.PveVM // 非模板虚拟机
+	vmsByTmpl   map[int64][]int64      // 模板ID -> 从其创建的虚拟机
+	osTypes     map[int64]string       // 虚拟机ID -> 操作系统类型
+	consumed    map[int64]map[int64]bool
+}
+
+func (s *licenseService) loadInventory(ctx context.Context) (*licenseInventory, error) {
+	licenses, err := s.licenseRepo.ListLicenses(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.licenseRepo.ListAssignments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inv := &licenseInventory{
+		licenses:    licenses,
+		assignments: assignments,
+		vms:         make(map[int64]*model.PveVM),
+		vmsByTmpl:   make(map[int64][]int64),
+		osTypes:     make(map[int64]string),
+		consumed:    make(map[int64]map[int64]bool),
+	}
+	for _, cluster := range clusters {
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range vms {
+			if vm.IsTemplate == 1 {
+				continue
+			}
+			inv.vms[vm.Id] = vm
+			if vm.TemplateID > 0 {
+				inv.vmsByTmpl[vm.TemplateID] = append(inv.vmsByTmpl[vm.TemplateID], vm.Id)
+			}
+		}
+	}
+	infos, err := s.licenseRepo.ListOSInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if _, ok := inv.vms[info.VmID]; ok && info.OSType != "" {
+			inv.osTypes[info.VmID] = info.OSType
+		}
+	}
+	for _, a := range assignments {
+		set := inv.consumed[a.LicenseID]
+		if set == nil {
+			set = make(map[int64]bool)
+			inv.consumed[a.LicenseID] = set
+		}
+		for _, vmID := range inv.assignmentVMs(a) {
+			set[vmID] = true
+		}
+	}
+	return inv, nil
+}
+
+// assignmentVMs 分配记录占用的虚拟机
+func (inv *licenseInventory) assignmentVMs(a *model.LicenseAssignment) []int64 {
+	switch a.TargetType {
+	case model.LicenseTargetVM:
+		if _, ok := inv.vms[a.TargetID]; ok {
+			return []int64{a.TargetID}
+		}
+	case model.LicenseTargetTemplate:
+		return inv.vmsByTmpl[a.TargetID]
+	}
+	return nil
+}
+
+func (s *licenseService) toLicenseItem(license *model.License, inv *licenseInventory, now time.Time) v1.LicenseItem {
+	consumed := len(inv.consumed[license.Id])
+	return v1.LicenseItem{
+		Id:             license.Id,
+		Name:           license.Name,
+		OSType:         license.OSType,
+		Product:        license.Product,
+		LicenseKey:     maskLicenseKey(license.LicenseKey),
+		SubscriptionID: license.SubscriptionID,
+		Seats:          license.Seats,
+		Consumed:       consumed,
+		OverConsumed:   license.Seats > 0 && consumed > license.Seats,
+		Expired:        licenseExpired(license, now),
+		ExpireAt:       license.ExpireAt,
+		Describes:      license.Describes,
+		Creator:        license.Creator,
+		Modifier:       license.Modifier,
+		CreateTime:     license.CreateTime,
+		UpdateTime:     license.UpdateTime,
+	}
+}
+
+func (s *licenseService) ListLicenses(ctx context.Context, req *v1.ListLicensesRequest) (*v1.ListLicensesResponseData, error) {
+	inv, err := s.loadInventory(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load license inventory", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	osType := normalizeOSType(req.OSType)
+	now := time.Now()
+	list := make([]v1.LicenseItem, 0, len(inv.licenses))
+	for _, license := range inv.licenses {
+		if osType != "" && license.OSType != osType {
+			continue
+		}
+		list = append(list, s.toLicenseItem(license, inv, now))
+	}
+	return &v1.ListLicensesResponseData{List: list}, nil
+}
+
+func (s *licenseService) GetLicense(ctx context.Context, id int64) (*v1.LicenseDetail, error) {
+	license, err := s.getLicense(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := s.loadInventory(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load license inventory", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	var templateIDs []int64
+	for _, a := range inv.assignments {
+		if a.LicenseID == id && a.TargetType == model.LicenseTargetTemplate {
+			templateIDs = append(templateIDs, a.TargetID)
+		}
+	}
+	templates, err := s.templateRepo.GetByIDs(ctx, templateIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get templates", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	detail := &v1.LicenseDetail{
+		LicenseItem: s.toLicenseItem(license, inv, time.Now()),
+		Assignments: make([]v1.LicenseAssignmentItem, 0),
+	}
+	for _, a := range inv.assignments {
+		if a.LicenseID != id {
+			continue
+		}
+		item := v1.LicenseAssignmentItem{
+			Id:         a.Id,
+			TargetType: a.TargetType,
+			TargetID:   a.TargetID,
+			Consumed:   len(inv.assignmentVMs(a)),
+			Creator:    a.Creator,
+			CreateTime: a.CreateTime,
+		}
+		switch a.TargetType {
+		case model.LicenseTargetVM:
+			if vm := inv.vms[a.TargetID]; vm != nil {
+				item.TargetName = vm.VmName
+			}
+		case model.LicenseTargetTemplate:
+			if t := templates[a.TargetID]; t != nil {
+				item.TargetName = t.TemplateName
+			}
+		}
+		detail.Assignments = append(detail.Assignments, item)
+	}
+	return detail, nil
+}
+
+// usage 按操作系统类型汇总用量，只统计存在许可证或被识别到的操作系统类型
+func (inv *licenseInventory) usage(now time.Time) []v1.LicenseUsageItem {
+	byType := make(map[string]*v1.LicenseUsageItem)
+	get := func(osType string) *v1.LicenseUsageItem {
+		item := byType[osType]
+		if item == nil {
+			item = &v1.LicenseUsageItem{OSType: osType}
+			byType[osType] = item
+		}
+		return item
+	}
+
+	assignedByType := make(map[string]map[int64]bool)
+	hasLicense := make(map[string]bool)
+	for _, license := range inv.licenses {
+		item := get(license.OSType)
+		hasLicense[license.OSType] = true
+		if !licenseExpired(license, now) {
+			if license.Seats == 0 {
+				item.Unlimited = true
+			}
+			item.Seats += license.Seats
+		}
+		set := assignedByType[license.OSType]
+		if set == nil {
+			set = make(map[int64]bool)
+			assignedByType[license.OSType] = set
+		}
+		for vmID := range inv.consumed[license.Id] {
+			set[vmID] = true
+		}
+	}
+	for osType, set := range assignedByType {
+		get(osType).Assigned = len(set)
+	}
+	for vmID, osType := range inv.osTypes {
+		item := get(osType)
+		item.Detected++
+		if !assignedByType[osType][vmID] {
+			item.Unlicensed++
+		}
+	}
+
+	list := make([]v1.LicenseUsageItem, 0, len(byType))
+	for osType, item := range byType {
+		// 没有登记任何许可证的操作系统（如免费发行版）不判定超用
+		item.Over = hasLicense[osType] && !item.Unlimited && (item.Assigned > item.Seats || item.Detected > item.Seats)
+		list = append(list, *item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].OSType < list[j].OSType })
+	return list
+}
+
+func (s *licenseService) GetUsage(ctx context.Context) (*v1.LicenseUsageData, error) {
+	inv, err := s.loadInventory(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load license inventory", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return &v1.LicenseUsageData{List: inv.usage(time.Now())}, nil
+}
+
+func (s *licenseService) ListAlerts(ctx context.Context, req *v1.ListLicenseAlertsRequest) (*v1.ListLicenseAlertsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	alerts, total, err := s.licenseRepo.ListAlerts(ctx, page, pageSize, req.LicenseID, req.Open)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list license alerts", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	licenses, err := s.licenseRepo.ListLicenses(ctx, "")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list licenses", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	names := make(map[int64]string, len(licenses))
+	for _, license := range licenses {
+		names[license.Id] = license.Name
+	}
+
+	list := make([]v1.LicenseAlertItem, 0, len(alerts))
+	for _, a := range alerts {
+		list = append(list, v1.LicenseAlertItem{
+			Id:          a.Id,
+			LicenseID:   a.LicenseID,
+			LicenseName: names[a.LicenseID],
+			OSType:      a.OSType,
+			Consumed:    a.Consumed,
+			Seats:       a.Seats,
+			ResolvedAt:  a.ResolvedAt,
+			CreateTime:  a.CreateTime,
+		})
+	}
+	return &v1.ListLicenseAlertsResponseData{Total: total, List: list}, nil
+}
+
+func (s *licenseService) Refresh(ctx context.Context, userID string) error {
+	if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "license.manage"); err != nil {
+		return err
+	}
+	if err := s.CollectOSInfo(ctx); err != nil {
+		s.logger.WithContext(ctx).Error("failed to collect vm os info", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if err := s.CheckConsumption(ctx); err != nil {
+		s.logger.WithContext(ctx).Error("failed to check license consumption", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *licenseService) CollectOSInfo(ctx context.Context) error {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to create proxmox client", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			continue
+		}
+		nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return err
+		}
+		nodeNames := make(map[int64]string, len(nodes))
+		for _, node := range nodes {
+			nodeNames[node.Id] = node.NodeName
+		}
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return err
+		}
+
+		var collected int
+		for _, vm := range vms {
+			// 只有运行中且安装了 guest agent 的虚拟机能返回系统信息，其余保留上次采集结果
+			if vm.IsTemplate == 1 || vm.Status != "running" || nodeNames[vm.NodeID] == "" {
+				continue
+			}
+			osinfo, err := client.GetVMAgentOSInfo(ctx, nodeNames[vm.NodeID], vm.VMID)
+			if err != nil {
+				s.logger.WithContext(ctx).Debug("guest agent osinfo unavailable", zap.String("vm", vm.VmName), zap.Error(err))
+				continue
+			}
+			if err := s.saveOSInfo(ctx, vm, osinfo); err != nil {
+				return err
+			}
+			collected++
+		}
+		s.logger.WithContext(ctx).Info("vm os info collected", zap.String("cluster", cluster.ClusterName), zap.Int("count", collected))
+	}
+	return nil
+}
+
+func (s *licenseService) saveOSInfo(ctx context.Context, vm *model.PveVM, osinfo map[string]interface{}) error {
+	info, err := s.licenseRepo.GetOSInfoByVmID(ctx, vm.Id)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		info = &model.VMOSInfo{VmID: vm.Id}
+	}
+	str := func(key string) string {
+		v, _ := osinfo[key].(string)
+		return v
+	}
+	info.ClusterID = vm.ClusterID
+	info.OSID = str("id")
+	info.OSType = normalizeOSType(info.OSID)
+	info.Name = str("name")
+	info.PrettyName = str("pretty-name")
+	info.Version = str("version-id")
+	info.KernelRelease = str("kernel-release")
+	info.CollectTime = time.Now()
+	if info.OSType == "" && strings.Contains(strings.ToLower(info.Name), "windows") {
+		info.OSType = "windows"
+	}
+	info.EOLName, info.EOLDate = "", nil
+	if rule := matchOSInfoEOL(s.eolRules, info); rule != nil {
+		eol := rule.eol
+		info.EOLName, info.EOLDate = rule.Name, &eol
+	}
+	return s.licenseRepo.SaveOSInfo(ctx, info)
+}
+
+func (s *licenseService) CheckConsumption(ctx context.Context) error {
+	inv, err := s.loadInventory(ctx)
+	if err != nil {
+		return err
+	}
+
+	type overKey struct {
+		licenseID int64
+		osType    string
+	}
+	over := make(map[overKey][2]int) // -> consumed, seats
+	now := time.Now()
+	for _, license := range inv.licenses {
+		consumed := len(inv.consumed[license.Id])
+		if license.Seats > 0 && !licenseExpired(license, now) && consumed > license.Seats {
+			over[overKey{license.Id, license.OSType}] = [2]int{consumed, license.Seats}
+		}
+	}
+	for _, item := range inv.usage(now) {
+		if item.Over {
+			over[overKey{0, item.OSType}] = [2]int{max(item.Assigned, item.Detected), item.Seats}
+		}
+	}
+
+	for key, counts := range over {
+		alert, err := s.licenseRepo.GetOpenAlert(ctx, key.licenseID, key.osType)
+		if err != nil {
+			return err
+		}
+		if alert != nil {
+			if alert.Consumed != counts[0] || alert.Seats != counts[1] {
+				alert.Consumed, alert.Seats = counts[0], counts[1]
+				if err := s.licenseRepo.SaveAlert(ctx, alert); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		alert = &model.LicenseAlert{
+			LicenseID: key.licenseID,
+			OSType:    key.osType,
+			Consumed:  counts[0],
+			Seats:     counts[1],
+		}
+		if err := s.licenseRepo.SaveAlert(ctx, alert); err != nil {
+			return err
+		}
+		s.logger.WithContext(ctx).Warn("license over-consumption",
+			zap.Int64("license_id", alert.LicenseID),
+			zap.String("os_type", alert.OSType),
+			zap.Int("consumed", alert.Consumed),
+			zap.Int("seats", alert.Seats))
+		s.notifyLicenseAlert(ctx, alert, inv)
+		notification := &model.Notification{
+			Category:   model.NotificationCategoryAlert,
+			Level:      model.NotificationLevelWarning,
+			Event:      "license_over_consumption",
+			Title:      fmt.Sprintf("Licenses for %s are over-consumed", alert.OSType),
+			Content:    fmt.Sprintf("consumed %d of %d seats", alert.Consumed, alert.Seats),
+			TargetType: "license",
+		}
+		for _, license := range inv.licenses {
+			if license.Id == alert.LicenseID {
+				notification.Title = fmt.Sprintf("License %s is over-consumed", license.Name)
+				notification.TargetID = strconv.FormatInt(license.Id, 10)
+			}
+		}
+		s.notificationService.NotifyAdmins(ctx, notification)
+	}
+
+	// 用量回落或许可证已删除的告警标记为恢复
+	open, err := s.licenseRepo.ListOpenAlerts(ctx)
+	if err != nil {
+		return err
+	}
+	for _, alert := range open {
+		if _, ok := over[overKey{alert.LicenseID, alert.OSType}]; ok {
+			continue
+		}
+		alert.ResolvedAt = &now
+		if err := s.licenseRepo.SaveAlert(ctx, alert); err != nil {
+			return err
+		}
+		s.logger.WithContext(ctx).Info("license over-consumption resolved",
+			zap.Int64("license_id", alert.LicenseID), zap.String("os_type", alert.OSType))
+	}
+	return nil
+}
+
+// notifyLicenseAlert 配置了 license.alert_webhook 时以 JSON POST 推送告警，失败只记录日志
+func (s *licenseService) notifyLicenseAlert(ctx context.Context, alert *model.LicenseAlert, inv *licenseInventory) {
+	webhook := s.conf.GetString("license.alert_webhook")
+	if webhook == "" {
+		return
+	}
+
+	var licenseName string
+	for _, license := range inv.licenses {
+		if license.Id == alert.LicenseID {
+			licenseName = license.Name
+		}
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event":        "license_over_consumption",
+		"license_id":   alert.LicenseID,
+		"license_name": licenseName,
+		"os_type":      alert.OSType,
+		"consumed":     alert.Consumed,
+		"seats":        alert.Seats,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to build license alert webhook request", zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to send license alert webhook", zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.logger.WithContext(ctx).Error("license alert webhook returned error status", zap.Int("status", resp.StatusCode))
+	}
+}
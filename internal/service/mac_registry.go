@@ -0,0 +1,663 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 mac_registry.prefix 时使用 Proxmox 默认的 OUI，与 Proxmox 自动生成的地址保持同一前缀
+const defaultMACPrefix = "BC:24:11"
+
+// 生成 MAC 时的最大重试次数
+const macGenerateAttempts = 16
+
+// dhcpHostnamePattern DHCP 主机名中不允许的字符
+var dhcpHostnamePattern = regexp.MustCompile(`[^A-Za-z0-9-]+`)
+
+// MACAllocation 为虚拟机网卡分配 MAC 的参数，MAC 为空时由平台生成
+type MACAllocation struct {
+	MAC       string
+	ClusterID int64
+	VMID      uint32
+	NicName   string
+	Hostname  string
+	Creator   string
+}
+
+// MACRegistryService MAC 地址登记：跨集群防止虚拟机网卡 MAC 重复，并导出外部 DHCP 服务器的保留配置
+type MACRegistryService interface {
+	// Allocate 为虚拟机网卡生成或登记 MAC，返回规范化（大写冒号格式）的地址
+	Allocate(ctx context.Context, alloc *MACAllocation) (string, error)
+	// BindVM 虚拟机记录创建后关联平台虚拟机ID
+	BindVM(ctx context.Context, mac string, vmID int64)
+	// Release 释放分配后未使用的 MAC（如创建虚拟机失败）
+	Release(ctx context.Context, mac string)
+	// ReleaseVM 删除虚拟机时释放其全部 MAC
+	ReleaseVM(ctx context.Context, vmID int64)
+
+	List(ctx context.Context, req *v1.ListMACAddressesRequest) (*v1.ListMACAddressesResponseData, error)
+	Reserve(ctx context.Context, req *v1.ReserveMACAddressRequest, creator string) (*v1.MACAddressItem, error)
+	Delete(ctx context.Context, id int64) error
+	Sync(ctx context.Context, userID string, req *v1.SyncMACAddressesRequest) (*v1.SyncMACAddressesData, error)
+	// ExportDHCP 导出 DHCP 保留配置，返回内容和 Content-Type
+	ExportDHCP(ctx context.Context, req *v1.ExportDHCPReservationsRequest) ([]byte, string, error)
+}
+
+func NewMACRegistryService(
+	service *Service,
+	conf *viper.Viper,
+	macRepo repository.MACAddressRepository,
+	ipRepo repository.VMIPAddressRepository,
+	vmRepo repository.PveVMRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) MACRegistryService {
+	return &macRegistryService{
+		Service:     service,
+		conf:        conf,
+		macRepo:     macRepo,
+		ipRepo:      ipRepo,
+		vmRepo:      vmRepo,
+		clusterRepo: clusterRepo,
+		userRepo:    userRepo,
+		logger:      logger,
+	}
+}
+
+type macRegistryService struct {
+	*Service
+	conf        *viper.Viper
+	macRepo     repository.MACAddressRepository
+	ipRepo      repository.VMIPAddressRepository
+	vmRepo      repository.PveVMRepository
+	clusterRepo repository.PveClusterRepository
+	userRepo    repository.UserRepository
+	logger      *log.Logger
+}
+
+// normalizeMAC 校验并转换为 Proxmox 使用的大写冒号格式，组播地址不能作为网卡地址
+func normalizeMAC(s string) (string, error) {
+	hw, err := net.ParseMAC(strings.TrimSpace(s))
+	if err != nil || len(hw) != 6 {
+		return "", v1.WithDetailf(v1.ErrInvalidMACAddress, "mac=%s", s)
+	}
+	if hw[0]&0x01 != 0 {
+		return "", v1.WithDetailf(v1.ErrInvalidMACAddress, "mac=%s is a multicast address", s)
+	}
+	return strings.ToUpper(hw.String()), nil
+}
+
+// generateMAC 使用配置的前缀（1~5 字节）生成随机地址
+func (s *macRegistryService) generateMAC() (string, error) {
+	prefix := strings.TrimSpace(s.conf.GetString("mac_registry.prefix"))
+	if prefix == "" {
+		prefix = defaultMACPrefix
+	}
+	octets := strings.Split(prefix, ":")
+	if len(octets) == 0 || len(octets) > 5 {
+		return "", fmt.Errorf("invalid mac_registry.prefix %q", prefix)
+	}
+	hw := make(net.HardwareAddr, 6)
+	if _, err := rand.Read(hw); err != nil {
+		return "", err
+	}
+	for i, octet := range octets {
+		var b byte
+		if _, err := fmt.Sscanf(octet, "%02x", &b); err != nil {
+			return "", fmt.Errorf("invalid mac_registry.prefix %q", prefix)
+		}
+		hw[i] = b
+	}
+	return normalizeMAC(hw.String())
+}
+
+func (s *macRegistryService) Allocate(ctx context.Context, alloc *MACAllocation) (string, error) {
+	if alloc.MAC != "" {
+		return s.allocateUserMAC(ctx, alloc)
+	}
+
+	for attempt := 0; attempt < macGenerateAttempts; attempt++ {
+		mac, err := s.generateMAC()
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to generate mac address", zap.Error(err))
+			return "", v1.WithDetail(v1.ErrMACPoolExhausted, err.Error())
+		}
+		existing, err := s.macRepo.GetByMAC(ctx, mac)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get mac address", zap.Error(err))
+			return "", v1.ErrInternalServerError
+		}
+		if existing != nil {
+			continue
+		}
+		// 唯一索引兜底：并发生成到同一地址时重新生成
+		if err := s.macRepo.Create(ctx, &model.MACAddress{
+			MAC:       mac,
+			ClusterID: alloc.ClusterID,
+			VMID:      alloc.VMID,
+			NicName:   alloc.NicName,
+			Source:    model.MACSourceGenerated,
+			Hostname:  alloc.Hostname,
+			Creator:   alloc.Creator,
+		}); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to register generated mac, retrying", zap.String("mac", mac), zap.Error(err))
+			continue
+		}
+		return mac, nil
+	}
+	return "", v1.ErrMACPoolExhausted
+}
+
+// allocateUserMAC 登记用户指定的 MAC；已预留且未被使用的地址可以直接使用
+func (s *macRegistryService) allocateUserMAC(ctx context.Context, alloc *MACAllocation) (string, error) {
+	mac, err := normalizeMAC(alloc.MAC)
+	if err != nil {
+		return "", err
+	}
+	existing, err := s.macRepo.GetByMAC(ctx, mac)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get mac address", zap.Error(err))
+		return "", v1.ErrInternalServerError
+	}
+	if existing != nil {
+		if existing.Source != model.MACSourceReserved || existing.VMID != 0 {
+			return "", v1.WithDetailf(v1.ErrMACAddressInUse, "mac=%s, cluster_id=%d, vmid=%d", mac, existing.ClusterID, existing.VMID)
+		}
+		existing.ClusterID = alloc.ClusterID
+		existing.VMID = alloc.VMID
+		existing.NicName = alloc.NicName
+		existing.Source = model.MACSourceUser
+		if existing.Hostname == "" {
+			existing.Hostname = alloc.Hostname
+		}
+		if err := s.macRepo.Update(ctx, existing); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update mac address", zap.Error(err))
+			return "", v1.ErrInternalServerError
+		}
+		return mac, nil
+	}
+
+	if err := s.macRepo.Create(ctx, &model.MACAddress{
+		MAC:       mac,
+		ClusterID: alloc.ClusterID,
+		VMID:      alloc.VMID,
+		NicName:   alloc.NicName,
+		Source:    model.MACSourceUser,
+		Hostname:  alloc.Hostname,
+		Creator:   alloc.Creator,
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to register mac address", zap.String("mac", mac), zap.Error(err))
+		return "", v1.WithDetailf(v1.ErrMACAddressInUse, "mac=%s", mac)
+	}
+	return mac, nil
+}
+
+func (s *macRegistryService) BindVM(ctx context.Context, mac string, vmID int64) {
+	entry, err := s.macRepo.GetByMAC(ctx, mac)
+	if err != nil || entry == nil {
+		s.logger.WithContext(ctx).Warn("failed to bind mac address to vm", zap.String("mac", mac), zap.Int64("vm_id", vmID), zap.Error(err))
+		return
+	}
+	entry.VMId = vmID
+	if err := s.macRepo.Update(ctx, entry); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to bind mac address to vm", zap.String("mac", mac), zap.Int64("vm_id", vmID), zap.Error(err))
+	}
+}
+
+func (s *macRegistryService) Release(ctx context.Context, mac string) {
+	entry, err := s.macRepo.GetByMAC(ctx, mac)
+	if err != nil || entry == nil {
+		return
+	}
+	if err := s.macRepo.Delete(ctx, entry.Id); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to release mac address", zap.String("mac", mac), zap.Error(err))
+	}
+}
+
+func (s *macRegistryService) ReleaseVM(ctx context.Context, vmID int64) {
+	if err := s.macRepo.DeleteByVMId(ctx, vmID); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to release vm mac addresses", zap.Int64("vm_id", vmID), zap.Error(err))
+	}
+}
+
+func (s *macRegistryService) List(ctx context.Context, req *v1.ListMACAddressesRequest) (*v1.ListMACAddressesResponseData, error) {
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	macs, total, err := s.macRepo.List(ctx, page, pageSize, req.ClusterID, req.VMId, req.Source, strings.ToUpper(strings.TrimSpace(req.MAC)))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list mac addresses", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.MACAddressItem, 0, len(macs))
+	for _, mac := range macs {
+		list = append(list, toMACAddressItem(mac))
+	}
+	return &v1.ListMACAddressesResponseData{Total: total, List: list}, nil
+}
+
+func (s *macRegistryService) Reserve(ctx context.Context, req *v1.ReserveMACAddressRequest, creator string) (*v1.MACAddressItem, error) {
+	if req.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+		}
+	}
+
+	// 已登记（包括已预留）的地址不能重复预留
+	if req.MAC != "" {
+		mac, err := normalizeMAC(req.MAC)
+		if err != nil {
+			return nil, err
+		}
+		existing, err := s.macRepo.GetByMAC(ctx, mac)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get mac address", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if existing != nil {
+			return nil, v1.WithDetailf(v1.ErrMACAddressInUse, "mac=%s", mac)
+		}
+	}
+
+	mac, err := s.Allocate(ctx, &MACAllocation{
+		MAC:       req.MAC,
+		ClusterID: req.ClusterID,
+		Hostname:  req.Hostname,
+		Creator:   creator,
+	})
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.macRepo.GetByMAC(ctx, mac)
+	if err != nil || entry == nil {
+		s.logger.WithContext(ctx).Error("failed to get mac address", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	entry.Source = model.MACSourceReserved
+	entry.Description = req.Description
+	if err := s.macRepo.Update(ctx, entry); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update mac address", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("mac address reserved", zap.String("mac", mac), zap.String("creator", creator))
+	item := toMACAddressItem(entry)
+	return &item, nil
+}
+
+// Delete 删除登记，仅允许删除未分配给虚拟机的记录；虚拟机的 MAC 随虚拟机删除一起释放
+func (s *macRegistryService) Delete(ctx context.Context, id int64) error {
+	entry, err := s.macRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get mac address", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if entry == nil {
+		return v1.ErrMACAddressNotFound
+	}
+	if entry.VMId != 0 {
+		return v1.WithDetailf(v1.ErrMACAddressInUse, "mac=%s, vm_id=%d", entry.MAC, entry.VMId)
+	}
+	if err := s.macRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete mac address", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+// macObservation 同步时在 Proxmox 中发现的 MAC
+type macObservation struct {
+	location v1.MACLocation
+	vmID     int64 // 平台虚拟机ID，平台无记录时为 0
+}
+
+// Sync 扫描集群内全部 QEMU 虚拟机的网卡配置，登记尚未登记的 MAC、修正位置变化并报告重复
+func (s *macRegistryService) Sync(ctx context.Context, userID string, req *v1.SyncMACAddressesRequest) (*v1.SyncMACAddressesData, error) {
+	if _, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID); err != nil {
+		return nil, err
+	}
+
+	var clusters []*model.PveCluster
+	if req.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+		}
+		clusters = append(clusters, cluster)
+	} else {
+		var err error
+		clusters, err = s.clusterRepo.List(ctx)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+	}
+
+	data := &v1.SyncMACAddressesData{
+		Conflicts: []v1.MACConflict{},
+		Errors:    []string{},
+	}
+	observed := make(map[string][]macObservation)
+	scanned := make(map[int64]bool, len(clusters))
+	for _, cluster := range clusters {
+		vms, err := s.scanClusterMACs(ctx, cluster, observed)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to scan cluster mac addresses", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			data.Errors = append(data.Errors, fmt.Sprintf("%s: %v", cluster.ClusterName, err))
+			continue
+		}
+		scanned[cluster.Id] = true
+		data.Clusters++
+		data.ScannedVMs += vms
+	}
+
+	macs := make([]string, 0, len(observed))
+	for mac := range observed {
+		macs = append(macs, mac)
+	}
+	sort.Strings(macs)
+
+	for _, mac := range macs {
+		observations := observed[mac]
+		if len(observations) > 1 {
+			conflict := v1.MACConflict{MAC: mac}
+			for _, o := range observations {
+				conflict.Locations = append(conflict.Locations, o.location)
+			}
+			data.Conflicts = append(data.Conflicts, conflict)
+		}
+		first := observations[0]
+
+		entry, err := s.macRepo.GetByMAC(ctx, mac)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get mac address", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if entry == nil {
+			if err := s.macRepo.Create(ctx, &model.MACAddress{
+				MAC:       mac,
+				ClusterID: first.location.ClusterID,
+				VMId:      first.vmID,
+				VMID:      first.location.VMID,
+				NicName:   first.location.NicName,
+				Source:    model.MACSourceDiscovered,
+				Hostname:  first.location.VMName,
+			}); err != nil {
+				s.logger.WithContext(ctx).Error("failed to register discovered mac address", zap.String("mac", mac), zap.Error(err))
+				return nil, v1.ErrInternalServerError
+			}
+			data.Discovered++
+			continue
+		}
+
+		sameVM := entry.ClusterID == first.location.ClusterID && entry.VMID == first.location.VMID
+		// 登记的位置在未同步的集群中，无法判断旧位置是否仍在使用，作为冲突报告
+		if !sameVM && entry.VMID != 0 && !scanned[entry.ClusterID] {
+			data.Conflicts = append(data.Conflicts, v1.MACConflict{
+				MAC: mac,
+				Locations: []v1.MACLocation{
+					{ClusterID: entry.ClusterID, VMID: entry.VMID, VMName: entry.Hostname, NicName: entry.NicName},
+					first.location,
+				},
+			})
+			continue
+		}
+		if sameVM && entry.NicName == first.location.NicName && (first.vmID == 0 || entry.VMId == first.vmID) {
+			continue
+		}
+
+		entry.ClusterID = first.location.ClusterID
+		entry.VMID = first.location.VMID
+		entry.NicName = first.location.NicName
+		if first.vmID != 0 {
+			entry.VMId = first.vmID
+		}
+		if entry.Hostname == "" {
+			entry.Hostname = first.location.VMName
+		}
+		if err := s.macRepo.Update(ctx, entry); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update mac address", zap.String("mac", mac), zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		data.Updated++
+	}
+
+	// 已同步集群中不再存在的同步记录
+	for clusterID := range scanned {
+		entries, err := s.macRepo.ListByCluster(ctx, clusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list mac addresses", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		for _, entry := range entries {
+			if entry.Source != model.MACSourceDiscovered || len(observed[entry.MAC]) > 0 {
+				continue
+			}
+			if err := s.macRepo.Delete(ctx, entry.Id); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to delete stale mac address", zap.String("mac", entry.MAC), zap.Error(err))
+				continue
+			}
+			data.Removed++
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("mac addresses synced", zap.Int("clusters", data.Clusters), zap.Int("vms", data.ScannedVMs),
+		zap.Int("discovered", data.Discovered), zap.Int("updated", data.Updated), zap.Int("removed", data.Removed),
+		zap.Int("conflicts", len(data.Conflicts)))
+	return data, nil
+}
+
+// scanClusterMACs 读取集群内 QEMU 虚拟机（不含模板）的网卡 MAC，返回扫描的虚拟机数量
+func (s *macRegistryService) scanClusterMACs(ctx context.Context, cluster *model.PveCluster, observed map[string][]macObservation) (int, error) {
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return 0, err
+	}
+	resources, err := client.GetClusterResourcesByType(ctx, "vm")
+	if err != nil {
+		return 0, err
+	}
+
+	vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		return 0, err
+	}
+	vmIDs := make(map[uint32]int64, len(vms))
+	for _, vm := range vms {
+		vmIDs[vm.VMID] = vm.Id
+	}
+
+	count := 0
+	for _, resource := range resources {
+		resourceType, _ := resource["type"].(string)
+		if resourceType != "qemu" {
+			continue
+		}
+		if template, _ := resource["template"].(float64); template == 1 {
+			continue
+		}
+		nodeName, _ := resource["node"].(string)
+		vmName, _ := resource["name"].(string)
+		vmidFloat, _ := resource["vmid"].(float64)
+		vmid := uint32(vmidFloat)
+
+		config, err := client.GetVMCurrentConfig(ctx, nodeName, vmid)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get vm config", zap.String("node", nodeName), zap.Uint32("vmid", vmid), zap.Error(err))
+			continue
+		}
+		count++
+		for _, nic := range parseVMNetworkInterfaces(config) {
+			mac, err := normalizeMAC(nic.MAC)
+			if err != nil {
+				continue
+			}
+			observed[mac] = append(observed[mac], macObservation{
+				location: v1.MACLocation{
+					ClusterID: cluster.Id,
+					Node:      nodeName,
+					VMID:      vmid,
+					VMName:    vmName,
+					NicName:   nic.Name,
+				},
+				vmID: vmIDs[vmid],
+			})
+		}
+	}
+	return count, nil
+}
+
+// dhcpReservation 导出的一条 DHCP 保留
+type dhcpReservation struct {
+	mac      string
+	ip       string
+	hostname string
+}
+
+func (s *macRegistryService) ExportDHCP(ctx context.Context, req *v1.ExportDHCPReservationsRequest) ([]byte, string, error) {
+	entries, err := s.macRepo.ListByCluster(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list mac addresses", zap.Error(err))
+		return nil, "", v1.ErrInternalServerError
+	}
+
+	// 关联 IP 地址表：优先按 MAC 匹配，其次按虚拟机 + 网卡名称匹配
+	vmIDs := make([]int64, 0)
+	for _, entry := range entries {
+		if entry.VMId != 0 {
+			vmIDs = append(vmIDs, entry.VMId)
+		}
+	}
+	ips, err := s.ipRepo.ListByVMIDs(ctx, vmIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list ip addresses", zap.Error(err))
+		return nil, "", v1.ErrInternalServerError
+	}
+	byMAC := make(map[string]string, len(ips))
+	byNic := make(map[string]string, len(ips))
+	for _, ip := range ips {
+		if ip.MacAddress != "" {
+			if mac, err := normalizeMAC(ip.MacAddress); err == nil {
+				byMAC[mac] = ip.IPAddress
+			}
+		}
+		if ip.NicName != "" {
+			byNic[fmt.Sprintf("%d/%s", ip.VMId, ip.NicName)] = ip.IPAddress
+		}
+	}
+
+	reservations := make([]dhcpReservation, 0, len(entries))
+	for _, entry := range entries {
+		ip := byMAC[entry.MAC]
+		if ip == "" && entry.VMId != 0 {
+			ip = byNic[fmt.Sprintf("%d/%s", entry.VMId, entry.NicName)]
+		}
+		if req.OnlyWithIP && ip == "" {
+			continue
+		}
+		reservations = append(reservations, dhcpReservation{
+			mac:      strings.ToLower(entry.MAC),
+			ip:       ip,
+			hostname: strings.Trim(dhcpHostnamePattern.ReplaceAllString(entry.Hostname, "-"), "-"),
+		})
+	}
+
+	if req.Format == "kea" {
+		export := v1.KeaReservationsExport{Reservations: make([]v1.KeaReservation, 0, len(reservations))}
+		for _, r := range reservations {
+			export.Reservations = append(export.Reservations, v1.KeaReservation{
+				HWAddress: r.mac,
+				IPAddress: r.ip,
+				Hostname:  r.hostname,
+			})
+		}
+		data, err := json.MarshalIndent(export, "", "  ")
+		if err != nil {
+			return nil, "", v1.ErrInternalServerError
+		}
+		return data, "application/json; charset=utf-8", nil
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# DHCP reservations exported by PveSphere at %s\n", time.Now().Format(time.RFC3339))
+	for _, r := range reservations {
+		fmt.Fprintf(&buf, "\nhost pvesphere-%s {\n", strings.ReplaceAll(r.mac, ":", ""))
+		fmt.Fprintf(&buf, "  hardware ethernet %s;\n", r.mac)
+		if r.ip != "" {
+			fmt.Fprintf(&buf, "  fixed-address %s;\n", r.ip)
+		}
+		if r.hostname != "" {
+			fmt.Fprintf(&buf, "  option host-name \"%s\";\n", r.hostname)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes(), "text/plain; charset=utf-8", nil
+}
+
+// netConfigWithMAC 替换网卡配置中的 MAC，例如 "virtio,bridge=vmbr0" 或 "virtio=AA:...,bridge=vmbr0"
+// 替换为 "virtio=<mac>,bridge=vmbr0"
+func netConfigWithMAC(value, mac string) string {
+	dev := proxmox.ParseDeviceConfig(value)
+	switch {
+	case dev.Head != "":
+		dev.Options = append([]proxmox.DeviceOption{{Key: dev.Head, Value: mac}}, dev.Options...)
+		dev.Head = ""
+	case len(dev.Options) > 0 && dev.Options[0].Key == "model":
+		// 另一种写法 "model=virtio,macaddr=..."
+		dev.Set("macaddr", mac)
+	case len(dev.Options) > 0:
+		dev.Options[0].Value = mac
+	}
+	return dev.String()
+}
+
+func toMACAddressItem(m *model.MACAddress) v1.MACAddressItem {
+	return v1.MACAddressItem{
+		Id:          m.Id,
+		MAC:         m.MAC,
+		ClusterID:   m.ClusterID,
+		VMId:        m.VMId,
+		VMID:        m.VMID,
+		NicName:     m.NicName,
+		Source:      m.Source,
+		Hostname:    m.Hostname,
+		Description: m.Description,
+		Creator:     m.Creator,
+		CreateTime:  m.CreateTime,
+		UpdateTime:  m.UpdateTime,
+	}
+}
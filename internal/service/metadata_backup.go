@@ -0,0 +1,398 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/secret"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+)
+
+// metadataEncryption 元数据包中敏感字段的加密方式（与本地密钥存储相同，口令经 SHA-256 派生密钥）
+const metadataEncryption = "aes-256-gcm"
+
+// metadataSection 元数据包中的一部分，对应一张表；导入按此顺序写入，被引用的表在前
+type metadataSection struct {
+	name  string
+	model interface{}
+}
+
+var metadataSections = []metadataSection{
+	{"sites", &model.PveSite{}},
+	{"clusters", &model.PveCluster{}},
+	{"nodes", &model.PveNode{}},
+	{"node_bmcs", &model.NodeBMC{}},
+	{"storages", &model.PveStorage{}},
+	{"templates", &model.PveTemplate{}},
+	{"template_uploads", &model.TemplateUpload{}},
+	{"template_instances", &model.TemplateInstance{}},
+	{"template_promotions", &model.TemplatePromotion{}},
+	{"vms", &model.PveVM{}},
+	{"vm_ip_addresses", &model.VMIPAddress{}},
+	{"mac_addresses", &model.MACAddress{}},
+	{"node_pools", &model.NodePool{}},
+	{"node_pool_members", &model.NodePoolMember{}},
+	{"change_windows", &model.ChangeWindow{}},
+	{"boot_order_policies", &model.BootOrderPolicy{}},
+	{"vm_profiles", &model.VMProfile{}},
+	{"vm_qos_profiles", &model.VmQosProfile{}},
+	{"object_stores", &model.ObjectStore{}},
+	{"vmid_ranges", &model.VMIDRange{}},
+	{"vmid_allocations", &model.VMIDAllocation{}},
+}
+
+// MetadataBackupService 导出 / 导入 PveSphere 自身的元数据，用于管理平台灾备（仅管理员）
+type MetadataBackupService interface {
+	// Export 导出元数据包，返回内容和 Content-Type
+	Export(ctx context.Context, userID string, req *v1.ExportMetadataRequest) ([]byte, string, error)
+	// Import 导入元数据包，只能导入到空的管理数据库；敏感字段用口令解密后按当前实例的配置重新保存
+	Import(ctx context.Context, userID string, req *v1.ImportMetadataRequest, data []byte) (*v1.ImportMetadataResponseData, error)
+}
+
+func NewMetadataBackupService(
+	service *Service,
+	conf *viper.Viper,
+	backupRepo repository.MetadataBackupRepository,
+	userRepo repository.UserRepository,
+	credentials VMCredentialService,
+	logger *log.Logger,
+) MetadataBackupService {
+	return &metadataBackupService{
+		Service:     service,
+		conf:        conf,
+		backupRepo:  backupRepo,
+		userRepo:    userRepo,
+		credentials: credentials,
+		logger:      logger,
+	}
+}
+
+type metadataBackupService struct {
+	*Service
+	conf        *viper.Viper
+	backupRepo  repository.MetadataBackupRepository
+	userRepo    repository.UserRepository
+	credentials VMCredentialService
+	logger      *log.Logger
+}
+
+// metadataSecretName 敏感字段在包中的名称，同时作为加密的附加认证数据，防止密文被挪用到其他记录
+func metadataSecretName(section string, id int64, field string) string {
+	return fmt.Sprintf("%s-%d-%s", section, id, field)
+}
+
+// newSectionRows 构造 *[]*Model 作为整表读写的容器
+func newSectionRows(section metadataSection) reflect.Value {
+	return reflect.New(reflect.SliceOf(reflect.TypeOf(section.model)))
+}
+
+func (s *metadataBackupService) Export(ctx context.Context, userID string, req *v1.ExportMetadataRequest) ([]byte, string, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "metadata.backup")
+	if err != nil {
+		return nil, "", err
+	}
+	store, err := secret.NewLocalStore(req.Passphrase)
+	if err != nil {
+		return nil, "", v1.WithDetail(v1.ErrBadRequest, err.Error())
+	}
+
+	bundle := &v1.MetadataBundle{
+		FormatVersion: v1.MetadataBundleVersion,
+		ExportedAt:    time.Now(),
+		ExportedBy:    username,
+		Encryption:    metadataEncryption,
+		Counts:        make(map[string]int, len(metadataSections)),
+		Sections:      make(map[string]json.RawMessage, len(metadataSections)),
+		Secrets:       []v1.MetadataSecret{},
+	}
+	for _, section := range metadataSections {
+		rows := newSectionRows(section)
+		if err := s.backupRepo.Dump(ctx, section.model, rows.Interface()); err != nil {
+			s.logger.WithContext(ctx).Error("failed to dump metadata", zap.String("section", section.name), zap.Error(err))
+			return nil, "", v1.ErrInternalServerError
+		}
+		secrets, err := s.takeSecrets(ctx, username, section.name, rows.Interface())
+		if err != nil {
+			return nil, "", err
+		}
+		for _, item := range secrets {
+			sealed, err := store.Put(ctx, metadataSecretName(item.Section, item.ID, item.Field), item.Value)
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to encrypt metadata secret", zap.Error(err))
+				return nil, "", v1.ErrInternalServerError
+			}
+			item.Value = sealed
+			bundle.Secrets = append(bundle.Secrets, item)
+		}
+		raw, err := json.Marshal(rows.Interface())
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to marshal metadata", zap.String("section", section.name), zap.Error(err))
+			return nil, "", v1.ErrInternalServerError
+		}
+		bundle.Sections[section.name] = raw
+		bundle.Counts[section.name] = rows.Elem().Len()
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to marshal metadata bundle", zap.Error(err))
+		return nil, "", v1.ErrInternalServerError
+	}
+	contentType := "application/json"
+	if req.Format == "yaml" {
+		if data, err = jsonToYAML(data); err != nil {
+			s.logger.WithContext(ctx).Error("failed to convert metadata bundle to yaml", zap.Error(err))
+			return nil, "", v1.ErrInternalServerError
+		}
+		contentType = "application/yaml"
+	}
+
+	s.logger.WithContext(ctx).Info("metadata exported", zap.String("operator", username),
+		zap.Any("counts", bundle.Counts), zap.Int("secrets", len(bundle.Secrets)))
+	return data, contentType, nil
+}
+
+// takeSecrets 取出记录中的敏感字段（明文）并从记录中清除，使其不以明文出现在包中
+func (s *metadataBackupService) takeSecrets(ctx context.Context, operator, section string, rows interface{}) ([]v1.MetadataSecret, error) {
+	var secrets []v1.MetadataSecret
+	add := func(id int64, field, value string) {
+		if value != "" {
+			secrets = append(secrets, v1.MetadataSecret{Section: section, ID: id, Field: field, Value: value})
+		}
+	}
+	switch rows := rows.(type) {
+	case *[]*model.PveCluster:
+		for _, cluster := range *rows {
+			add(cluster.Id, "user_token", cluster.UserToken)
+			cluster.UserToken = ""
+		}
+	case *[]*model.NodeBMC:
+		for _, bmc := range *rows {
+			add(bmc.Id, "password", bmc.Password)
+		}
+	case *[]*model.ObjectStore:
+		for _, store := range *rows {
+			add(store.Id, "secret_key", store.SecretKey)
+		}
+	case *[]*model.PveVM:
+		for _, vm := range *rows {
+			password, err := s.credentials.Reveal(ctx, operator, vm)
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to read vm password for export", zap.Int64("vm_id", vm.Id), zap.Error(err))
+				return nil, fmt.Errorf("vm %s (id=%d): %w", vm.VmName, vm.Id, err)
+			}
+			add(vm.Id, "vm_password", password)
+		}
+	}
+	return secrets, nil
+}
+
+// decodeMetadataBundle 解析 JSON 或 YAML 格式的元数据包
+func decodeMetadataBundle(data []byte) (*v1.MetadataBundle, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("empty bundle")
+	}
+	if trimmed[0] != '{' {
+		var err error
+		if trimmed, err = yamlToJSON(trimmed); err != nil {
+			return nil, err
+		}
+	}
+	bundle := new(v1.MetadataBundle)
+	if err := json.Unmarshal(trimmed, bundle); err != nil {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+func (s *metadataBackupService) Import(ctx context.Context, userID string, req *v1.ImportMetadataRequest, data []byte) (*v1.ImportMetadataResponseData, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "metadata.backup")
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := decodeMetadataBundle(data)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrMetadataBundleInvalid, err.Error())
+	}
+	if bundle.FormatVersion < 1 || bundle.FormatVersion > v1.MetadataBundleVersion {
+		return nil, v1.WithDetailf(v1.ErrMetadataBundleVersion, "bundle version %d, supported up to %d", bundle.FormatVersion, v1.MetadataBundleVersion)
+	}
+	store, err := secret.NewLocalStore(req.Passphrase)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrBadRequest, err.Error())
+	}
+
+	// 先解密全部敏感字段，口令错误时不做任何写入
+	secrets := make(map[string]string, len(bundle.Secrets))
+	for _, item := range bundle.Secrets {
+		name := metadataSecretName(item.Section, item.ID, item.Field)
+		value, err := store.Get(ctx, item.Value)
+		if err != nil {
+			return nil, v1.WithDetail(v1.ErrMetadataPassphrase, name)
+		}
+		if !strings.HasPrefix(item.Value, secret.BackendLocal+":v1:"+name+":") {
+			return nil, v1.WithDetailf(v1.ErrMetadataBundleInvalid, "secret %s does not match its record", name)
+		}
+		secrets[name] = value
+	}
+
+	result := &v1.ImportMetadataResponseData{
+		FormatVersion: bundle.FormatVersion,
+		ExportedAt:    bundle.ExportedAt,
+		ExportedBy:    bundle.ExportedBy,
+		DryRun:        req.DryRun,
+		Counts:        make(map[string]int, len(metadataSections)),
+		Secrets:       len(secrets),
+		Warnings:      []string{},
+	}
+	known := make(map[string]bool, len(metadataSections))
+	decoded := make([]reflect.Value, len(metadataSections))
+	for i, section := range metadataSections {
+		known[section.name] = true
+		rows := newSectionRows(section)
+		if raw, ok := bundle.Sections[section.name]; ok && len(raw) > 0 && string(raw) != "null" {
+			if err := json.Unmarshal(raw, rows.Interface()); err != nil {
+				return nil, v1.WithDetailf(v1.ErrMetadataBundleInvalid, "section %s: %v", section.name, err)
+			}
+		}
+		decoded[i] = rows
+		result.Counts[section.name] = rows.Elem().Len()
+	}
+	unknown := make([]string, 0)
+	for name := range bundle.Sections {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("unknown section %s ignored", name))
+	}
+	if req.DryRun {
+		return result, nil
+	}
+
+	// 保留原主键恢复，只允许导入到空库，避免与已有数据冲突
+	var occupied []string
+	for _, section := range metadataSections {
+		count, err := s.backupRepo.Count(ctx, section.model)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to count metadata", zap.String("section", section.name), zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if count > 0 {
+			occupied = append(occupied, fmt.Sprintf("%s (%d)", section.name, count))
+		}
+	}
+	if len(occupied) > 0 {
+		return nil, v1.WithDetail(v1.ErrMetadataTargetNotEmpty, strings.Join(occupied, ", "))
+	}
+
+	// 虚拟机密码在记录写入后通过凭据服务保存，使用当前实例的密钥后端重新加密
+	var passwords []*model.PveVM
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		for i, section := range metadataSections {
+			rows := decoded[i]
+			if rows.Elem().Len() == 0 {
+				continue
+			}
+			switch rows := rows.Interface().(type) {
+			case *[]*model.PveCluster:
+				for _, cluster := range *rows {
+					cluster.UserToken = secrets[metadataSecretName(section.name, cluster.Id, "user_token")]
+				}
+			case *[]*model.NodeBMC:
+				for _, bmc := range *rows {
+					bmc.Password = secrets[metadataSecretName(section.name, bmc.Id, "password")]
+				}
+			case *[]*model.ObjectStore:
+				for _, store := range *rows {
+					store.SecretKey = secrets[metadataSecretName(section.name, store.Id, "secret_key")]
+				}
+			case *[]*model.PveVM:
+				for _, vm := range *rows {
+					vm.VmPassword, vm.VmPasswordRef = "", ""
+					if _, ok := secrets[metadataSecretName(section.name, vm.Id, "vm_password")]; ok {
+						passwords = append(passwords, vm)
+					}
+				}
+			}
+			if err := s.backupRepo.Restore(ctx, section.model, rows.Interface()); err != nil {
+				return fmt.Errorf("restore %s: %w", section.name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to import metadata", zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrInternalServerError, err.Error())
+	}
+
+	for _, vm := range passwords {
+		password := secrets[metadataSecretName("vms", vm.Id, "vm_password")]
+		if err := s.credentials.SetPassword(ctx, vm, password); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to restore vm password", zap.Int64("vm_id", vm.Id), zap.Error(err))
+			result.Warnings = append(result.Warnings, fmt.Sprintf("vm %s (id=%d): password not restored: %v", vm.VmName, vm.Id, err))
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("metadata imported", zap.String("operator", username),
+		zap.String("exported_by", bundle.ExportedBy), zap.Time("exported_at", bundle.ExportedAt), zap.Any("counts", result.Counts))
+	return result, nil
+}
+
+// jsonToYAML 保持 JSON 字段名转换为 YAML；整数保持整数，避免大数字被写成科学计数法
+func jsonToYAML(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(yamlValue(value))
+}
+
+func yamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = yamlValue(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = yamlValue(item)
+		}
+		return v
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	default:
+		return v
+	}
+}
+
+func yamlToJSON(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
@@ -0,0 +1,529 @@
+package service
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/bmc"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+	"pvesphere/pkg/wol"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type NodeBMCService interface {
+	GetNodeBMC(ctx context.Context, nodeID int64) (*v1.NodeBMCDetail, error)
+	SetNodeBMC(ctx context.Context, userID string, nodeID int64, req *v1.SetNodeBMCRequest) error
+	DeleteNodeBMC(ctx context.Context, userID string, nodeID int64) error
+	// GetNodePower 通过 BMC 实时读取节点功率
+	GetNodePower(ctx context.Context, nodeID int64) (*v1.NodePowerReading, error)
+	GetNodePowerState(ctx context.Context, nodeID int64) (*v1.NodePowerState, error)
+	// NodePowerAction 通过 BMC 执行开机 / 断电 / 重启，断电和重启要求节点已进入维护（不可调度且无运行中虚拟机）
+	NodePowerAction(ctx context.Context, userID string, nodeID int64, req *v1.NodePowerActionRequest) error
+	GetNodeSensors(ctx context.Context, nodeID int64) ([]v1.NodeSensor, error)
+	// SetNodeWol 设置节点 Wake-on-LAN MAC 地址（用于没有 BMC 的节点开机）
+	SetNodeWol(ctx context.Context, userID string, nodeID int64, req *v1.SetNodeWolRequest) error
+	DeleteNodeWol(ctx context.Context, userID string, nodeID int64) error
+	// WakeNode 发送 Wake-on-LAN 魔术包唤醒节点，可由平台直接广播或经同集群节点中继
+	WakeNode(ctx context.Context, userID string, nodeID int64, req *v1.WakeNodeRequest) (*v1.NodeWakeResult, error)
+}
+
+func NewNodeBMCService(
+	service *Service,
+	conf *viper.Viper,
+	bmcRepo repository.NodeBMCRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	changeControl ChangeControlService,
+	logger *log.Logger,
+) NodeBMCService {
+	return &nodeBMCService{
+		conf:          conf,
+		bmcRepo:       bmcRepo,
+		nodeRepo:      nodeRepo,
+		clusterRepo:   clusterRepo,
+		vmRepo:        vmRepo,
+		userRepo:      userRepo,
+		changeControl: changeControl,
+		Service:       service,
+		logger:        logger,
+	}
+}
+
+type nodeBMCService struct {
+	conf          *viper.Viper
+	bmcRepo       repository.NodeBMCRepository
+	nodeRepo      repository.PveNodeRepository
+	clusterRepo   repository.PveClusterRepository
+	vmRepo        repository.PveVMRepository
+	userRepo      repository.UserRepository
+	changeControl ChangeControlService
+	*Service
+	logger *log.Logger
+}
+
+// newNodeBMCClient 根据节点 BMC 配置创建客户端
+// 可通过 bmc.timeout 和 bmc.ipmitool_path 调整请求超时和 ipmitool 路径
+func newNodeBMCClient(conf *viper.Viper, b *model.NodeBMC) (bmc.Client, error) {
+	return bmc.NewClient(bmc.Config{
+		Protocol:           b.Protocol,
+		Address:            b.Address,
+		Username:           b.Username,
+		Password:           b.Password,
+		InsecureSkipVerify: b.InsecureSkipVerify == 1,
+		Timeout:            conf.GetDuration("bmc.timeout"),
+		IPMIToolPath:       conf.GetString("bmc.ipmitool_path"),
+	})
+}
+
+// bmcErrorMessage 截断 BMC 错误信息以适配 last_error 字段长度
+func bmcErrorMessage(err error) string {
+	msg := []rune(err.Error())
+	if len(msg) > 500 {
+		msg = msg[:500]
+	}
+	return string(msg)
+}
+
+func toNodeBMCDetail(b *model.NodeBMC) *v1.NodeBMCDetail {
+	return &v1.NodeBMCDetail{
+		Id:                 b.Id,
+		NodeID:             b.NodeID,
+		ClusterID:          b.ClusterID,
+		Protocol:           b.Protocol,
+		Address:            b.Address,
+		Username:           b.Username,
+		HasPassword:        b.Password != "",
+		InsecureSkipVerify: b.InsecureSkipVerify,
+		PowerMonitor:       b.PowerMonitor,
+		LastPowerWatts:     b.LastPowerWatts,
+		LastReadingTime:    b.LastReadingTime,
+		LastError:          b.LastError,
+		Creator:            b.Creator,
+		Modifier:           b.Modifier,
+		CreateTime:         b.CreateTime,
+		UpdateTime:         b.UpdateTime,
+	}
+}
+
+// getNodeBMC 获取节点及其 BMC 配置，未配置时返回 ErrNodeBMCNotConfigured
+func (s *nodeBMCService) getNodeBMC(ctx context.Context, nodeID int64) (*model.PveNode, *model.NodeBMC, error) {
+	node, err := s.nodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, nil, v1.ErrNodeNotFound
+	}
+	b, err := s.bmcRepo.GetByNodeID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node bmc", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if b == nil {
+		return nil, nil, v1.ErrNodeBMCNotConfigured
+	}
+	return node, b, nil
+}
+
+func (s *nodeBMCService) GetNodeBMC(ctx context.Context, nodeID int64) (*v1.NodeBMCDetail, error) {
+	_, b, err := s.getNodeBMC(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	return toNodeBMCDetail(b), nil
+}
+
+func (s *nodeBMCService) SetNodeBMC(ctx context.Context, userID string, nodeID int64, req *v1.SetNodeBMCRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node.power")
+	if err != nil {
+		return err
+	}
+
+	node, err := s.nodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if node == nil {
+		return v1.ErrNodeNotFound
+	}
+
+	b, err := s.bmcRepo.GetByNodeID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node bmc", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if b == nil {
+		b = &model.NodeBMC{NodeID: nodeID, InsecureSkipVerify: 1, PowerMonitor: 1, Creator: username}
+	}
+	// 地址或协议变更后旧读数不再可信
+	if b.Protocol != req.Protocol || b.Address != req.Address {
+		b.LastPowerWatts = 0
+		b.LastReadingTime = nil
+		b.LastError = ""
+	}
+	b.ClusterID = node.ClusterID
+	b.Protocol = req.Protocol
+	b.Address = req.Address
+	b.Username = req.Username
+	if req.Password != "" {
+		b.Password = req.Password
+	}
+	if req.InsecureSkipVerify != nil {
+		b.InsecureSkipVerify = *req.InsecureSkipVerify
+	}
+	if req.PowerMonitor != nil {
+		b.PowerMonitor = *req.PowerMonitor
+	}
+	b.Modifier = username
+
+	if b.Id == 0 {
+		err = s.bmcRepo.Create(ctx, b)
+	} else {
+		err = s.bmcRepo.Update(ctx, b)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to save node bmc", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("node bmc saved",
+		zap.Int64("node_id", nodeID), zap.String("protocol", b.Protocol), zap.String("operator", username))
+	return nil
+}
+
+func (s *nodeBMCService) DeleteNodeBMC(ctx context.Context, userID string, nodeID int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node.power")
+	if err != nil {
+		return err
+	}
+
+	b, err := s.bmcRepo.GetByNodeID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node bmc", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if b == nil {
+		return v1.ErrNodeBMCNotConfigured
+	}
+
+	if err := s.bmcRepo.DeleteByNodeID(ctx, nodeID); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete node bmc", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("node bmc deleted", zap.Int64("node_id", nodeID), zap.String("operator", username))
+	return nil
+}
+
+func (s *nodeBMCService) GetNodePower(ctx context.Context, nodeID int64) (*v1.NodePowerReading, error) {
+	_, b, err := s.getNodeBMC(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newNodeBMCClient(s.conf, b)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrBMCRequestFailed, err.Error())
+	}
+	watts, err := client.PowerReading(ctx)
+	now := time.Now()
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to read node power", zap.Int64("node_id", nodeID), zap.Error(err))
+		if uerr := s.bmcRepo.UpdateReading(ctx, b.Id, 0, now, bmcErrorMessage(err)); uerr != nil {
+			s.logger.WithContext(ctx).Error("failed to update bmc reading", zap.Error(uerr))
+		}
+		return nil, v1.WithDetail(v1.ErrBMCRequestFailed, err.Error())
+	}
+	if err := s.bmcRepo.UpdateReading(ctx, b.Id, watts, now, ""); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update bmc reading", zap.Error(err))
+	}
+	return &v1.NodePowerReading{NodeID: nodeID, Watts: watts, ReadingAt: now}, nil
+}
+
+func (s *nodeBMCService) GetNodePowerState(ctx context.Context, nodeID int64) (*v1.NodePowerState, error) {
+	_, b, err := s.getNodeBMC(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newNodeBMCClient(s.conf, b)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrBMCRequestFailed, err.Error())
+	}
+	state, err := client.PowerState(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get node power state", zap.Int64("node_id", nodeID), zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrBMCRequestFailed, err.Error())
+	}
+	return &v1.NodePowerState{NodeID: nodeID, PowerState: state}, nil
+}
+
+func (s *nodeBMCService) NodePowerAction(ctx context.Context, userID string, nodeID int64, req *v1.NodePowerActionRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node.power")
+	if err != nil {
+		return err
+	}
+
+	node, b, err := s.getNodeBMC(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+
+	// 断电和重启会中断节点上的全部虚拟机，要求先将节点设为不可调度并迁走虚拟机；
+	// 节点挂死时平台记录的虚拟机状态可能已过时，可通过 force 跳过检查
+	if req.Action != bmc.PowerActionOn {
+		_, running, err := s.vmRepo.ListWithPagination(ctx, 1, 1, 0, "", node.Id, "", 0, "running", "", nil)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to count running vms", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if !req.Force {
+			if node.IsSchedulable != 0 {
+				return v1.WithDetailf(v1.ErrNodeNotInMaintenance, "node=%s", node.NodeName)
+			}
+			if running > 0 {
+				return v1.WithDetailf(v1.ErrNodeHasRunningVMs, "node=%s, running=%d", node.NodeName, running)
+			}
+		} else if node.IsSchedulable != 0 || running > 0 {
+			s.logger.WithContext(ctx).Warn("forcing node power action outside maintenance",
+				zap.Int64("node_id", node.Id), zap.String("action", req.Action),
+				zap.Int8("is_schedulable", node.IsSchedulable), zap.Int64("running_vms", running),
+				zap.String("operator", username))
+		}
+	}
+
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "node.power_" + req.Action,
+		Target:    node.NodeName,
+		ClusterID: node.ClusterID,
+	}); err != nil {
+		return err
+	}
+
+	client, err := newNodeBMCClient(s.conf, b)
+	if err != nil {
+		return v1.WithDetail(v1.ErrBMCRequestFailed, err.Error())
+	}
+	if err := client.Power(ctx, req.Action); err != nil {
+		s.logger.WithContext(ctx).Error("node power action failed",
+			zap.Int64("node_id", node.Id), zap.String("action", req.Action), zap.Error(err))
+		return v1.WithDetail(v1.ErrBMCRequestFailed, err.Error())
+	}
+	s.logger.WithContext(ctx).Info("node power action executed",
+		zap.Int64("node_id", node.Id), zap.String("node", node.NodeName),
+		zap.String("action", req.Action), zap.Bool("force", req.Force), zap.String("operator", username))
+	return nil
+}
+
+func (s *nodeBMCService) GetNodeSensors(ctx context.Context, nodeID int64) ([]v1.NodeSensor, error) {
+	_, b, err := s.getNodeBMC(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newNodeBMCClient(s.conf, b)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrBMCRequestFailed, err.Error())
+	}
+	sensors, err := client.Sensors(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to read node sensors", zap.Int64("node_id", nodeID), zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrBMCRequestFailed, err.Error())
+	}
+
+	list := make([]v1.NodeSensor, 0, len(sensors))
+	for _, sensor := range sensors {
+		list = append(list, v1.NodeSensor{
+			Name:    sensor.Name,
+			Type:    sensor.Type,
+			Reading: sensor.Reading,
+			Unit:    sensor.Unit,
+			Status:  sensor.Status,
+		})
+	}
+	return list, nil
+}
+
+// nodeWakeTimeout 发送唤醒包后等待节点上线的时间，超时后不再显示为唤醒中
+const nodeWakeTimeout = 10 * time.Minute
+
+// nodeWaking 节点是否处于唤醒中（已发送唤醒包且尚未上线）
+func nodeWaking(node *model.PveNode) bool {
+	return node.LastWakeTime != nil && node.Status != "online" && time.Since(*node.LastWakeTime) < nodeWakeTimeout
+}
+
+// newRelayProxmoxClient 创建直连中继节点的 Proxmox 客户端
+// wakeonlan 由接收请求的节点发出，因此将集群 API 地址的主机替换为中继节点 IP（保留端口，默认 8006）
+func newRelayProxmoxClient(cluster *model.PveCluster, relay *model.PveNode) (*proxmox.ProxmoxClient, error) {
+	u, err := url.Parse(cluster.ApiUrl)
+	if err != nil {
+		return nil, err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "8006"
+	}
+	u.Host = net.JoinHostPort(relay.IPAddress, port)
+	return proxmox.NewProxmoxClient(u.String(), cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+}
+
+func (s *nodeBMCService) getNode(ctx context.Context, nodeID int64) (*model.PveNode, error) {
+	node, err := s.nodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.ErrNodeNotFound
+	}
+	return node, nil
+}
+
+func (s *nodeBMCService) SetNodeWol(ctx context.Context, userID string, nodeID int64, req *v1.SetNodeWolRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node.power")
+	if err != nil {
+		return err
+	}
+	mac, err := wol.ParseMAC(req.MAC)
+	if err != nil {
+		return v1.WithDetailf(v1.ErrInvalidMACAddress, "mac=%s", req.MAC)
+	}
+	node, err := s.getNode(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	return s.saveNodeWol(ctx, node, mac, username)
+}
+
+func (s *nodeBMCService) DeleteNodeWol(ctx context.Context, userID string, nodeID int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node.power")
+	if err != nil {
+		return err
+	}
+	node, err := s.getNode(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	if node.WolMAC == "" {
+		return v1.ErrNodeWolNotConfigured
+	}
+	return s.saveNodeWol(ctx, node, "", username)
+}
+
+// saveNodeWol 保存 MAC 地址，并尽量同步到 Proxmox 节点配置（中继唤醒时 Proxmox 使用该配置）
+// 修改节点配置需要目标节点在线，同步失败只记录日志
+func (s *nodeBMCService) saveNodeWol(ctx context.Context, node *model.PveNode, mac, username string) error {
+	node.WolMAC = mac
+	node.Modifier = username
+	node.UpdateTime = time.Now()
+	if err := s.nodeRepo.Update(ctx, node); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update node", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
+	if err != nil || cluster == nil {
+		s.logger.WithContext(ctx).Warn("skip syncing wakeonlan to proxmox, cluster unavailable",
+			zap.Int64("cluster_id", node.ClusterID), zap.Error(err))
+	} else if client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1)); err == nil {
+		if err := client.SetNodeWakeOnLan(ctx, node.NodeName, mac); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to sync wakeonlan to proxmox node config",
+				zap.String("node", node.NodeName), zap.Error(err))
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("node wake-on-lan saved",
+		zap.Int64("node_id", node.Id), zap.String("mac", mac), zap.String("operator", username))
+	return nil
+}
+
+func (s *nodeBMCService) WakeNode(ctx context.Context, userID string, nodeID int64, req *v1.WakeNodeRequest) (*v1.NodeWakeResult, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node.power")
+	if err != nil {
+		return nil, err
+	}
+	node, err := s.getNode(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if node.WolMAC == "" {
+		return nil, v1.ErrNodeWolNotConfigured
+	}
+
+	// 中继节点需与目标节点处于同一集群且在线，由它在所在二层网络内广播
+	var relay *model.PveNode
+	if req.RelayNodeID > 0 {
+		relay, err = s.getNode(ctx, req.RelayNodeID)
+		if err != nil {
+			return nil, err
+		}
+		if relay.Id == node.Id || relay.ClusterID != node.ClusterID || relay.Status != "online" || relay.IPAddress == "" {
+			return nil, v1.WithDetailf(v1.ErrInvalidWolRelayNode, "relay=%s, status=%s", relay.NodeName, relay.Status)
+		}
+	}
+
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "node.wake",
+		Target:    node.NodeName,
+		ClusterID: node.ClusterID,
+	}); err != nil {
+		return nil, err
+	}
+
+	result := &v1.NodeWakeResult{NodeID: node.Id, MAC: node.WolMAC, Mode: v1.WakeModeDirect}
+	if relay == nil {
+		broadcast := s.conf.GetString("wol.broadcast")
+		if err := wol.Send(ctx, node.WolMAC, broadcast); err != nil {
+			s.logger.WithContext(ctx).Error("failed to send wake-on-lan packet",
+				zap.Int64("node_id", node.Id), zap.String("broadcast", broadcast), zap.Error(err))
+			return nil, v1.WithDetail(v1.ErrWolSendFailed, err.Error())
+		}
+	} else {
+		cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", node.ClusterID)
+		}
+		client, err := newRelayProxmoxClient(cluster, relay)
+		if err != nil {
+			return nil, v1.WithDetail(v1.ErrWolSendFailed, err.Error())
+		}
+		mac, err := client.WakeOnLan(ctx, node.NodeName)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to wake node via relay",
+				zap.Int64("node_id", node.Id), zap.String("relay", relay.NodeName), zap.Error(err))
+			return nil, v1.WithDetail(v1.ErrWolSendFailed, err.Error())
+		}
+		// Proxmox 使用节点配置中的 MAC，与平台记录不一致时提示重新保存以同步
+		if normalized, err := wol.ParseMAC(mac); err == nil && normalized != node.WolMAC {
+			s.logger.WithContext(ctx).Warn("proxmox wakeonlan mac differs from platform record",
+				zap.String("node", node.NodeName), zap.String("proxmox_mac", normalized), zap.String("mac", node.WolMAC))
+		}
+		result.Mode = v1.WakeModeRelay
+		result.RelayNode = relay.NodeName
+	}
+
+	now := time.Now()
+	result.SentAt = now
+	node.LastWakeTime = &now
+	if err := s.nodeRepo.Update(ctx, node); err != nil {
+		s.logger.WithContext(ctx).Error("failed to record node wake time", zap.Error(err))
+	}
+	s.logger.WithContext(ctx).Info("node wake-on-lan sent",
+		zap.Int64("node_id", node.Id), zap.String("node", node.NodeName),
+		zap.String("mode", result.Mode), zap.String("relay", result.RelayNode), zap.String("operator", username))
+	return result, nil
+}
@@ -0,0 +1,619 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// lvmNamePattern 与 Proxmox 对卷组 / thin pool 名称的限制一致
+var lvmNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._+][a-zA-Z0-9._+-]*$`)
+
+type NodeDiskService interface {
+	// CreateLVM 在未使用的磁盘上创建 LVM 卷组，仅管理员可操作，受变更窗口约束，返回 Proxmox 任务 UPID
+	CreateLVM(ctx context.Context, userID string, nodeID int64, req *v1.CreateNodeLVMRequest) (string, error)
+	// CreateLVMThin 在未使用的磁盘上创建 LVM 卷组及同名 thin pool
+	CreateLVMThin(ctx context.Context, userID string, nodeID int64, req *v1.CreateNodeLVMRequest) (string, error)
+	// GetSMART 实时读取磁盘 SMART 信息并提取关键指标
+	GetSMART(ctx context.Context, nodeID int64, disk string) (*v1.NodeDiskSMART, error)
+	ListAlerts(ctx context.Context, req *v1.ListNodeDiskAlertsRequest) (*v1.ListNodeDiskAlertsResponseData, error)
+	// CollectSMART 采集所有在线节点磁盘的 SMART 指标，超过阈值时记录告警并通知，回到阈值内后关闭告警
+	CollectSMART(ctx context.Context) error
+}
+
+func NewNodeDiskService(
+	service *Service,
+	conf *viper.Viper,
+	healthRepo repository.NodeDiskHealthRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	changeControl ChangeControlService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) NodeDiskService {
+	return &nodeDiskService{
+		conf:                conf,
+		healthRepo:          healthRepo,
+		nodeRepo:            nodeRepo,
+		clusterRepo:         clusterRepo,
+		userRepo:            userRepo,
+		changeControl:       changeControl,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+		httpClient:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type nodeDiskService struct {
+	conf          *viper.Viper
+	healthRepo    repository.NodeDiskHealthRepository
+	nodeRepo      repository.PveNodeRepository
+	clusterRepo   repository.PveClusterRepository
+	userRepo      repository.UserRepository
+	changeControl ChangeControlService
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+	httpClient          *http.Client // 告警 webhook
+}
+
+// nodeProxmoxClient 根据节点所属集群创建 Proxmox 客户端
+func nodeProxmoxClient(ctx context.Context, nodeRepo repository.PveNodeRepository, clusterRepo repository.PveClusterRepository, logger *log.Logger, nodeID int64) (*proxmox.ProxmoxClient, *model.PveNode, error) {
+	node, err := nodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", nodeID)
+	}
+	cluster, err := clusterRepo.GetByID(ctx, node.ClusterID)
+	if err != nil {
+		logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", node.ClusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	return client, node, nil
+}
+
+// checkUnusedDisks 确认磁盘存在且未被使用（无分区、LVM、ZFS、挂载等），避免误覆盖数据；
+// 已有数据的磁盘需要先通过 wipedisk 擦除
+func checkUnusedDisks(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, devices []string) error {
+	disks, err := client.GetNodeDisksList(ctx, nodeName, false)
+	if err != nil {
+		return v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	used := make(map[string]string, len(disks))
+	for _, disk := range disks {
+		devpath, _ := disk["devpath"].(string)
+		usage, _ := disk["used"].(string)
+		used[devpath] = usage
+	}
+	for _, dev := range devices {
+		usage, ok := used[dev]
+		if !ok {
+			return v1.WithDetailf(v1.ErrDiskNotFound, "node=%s, device=%s", nodeName, dev)
+		}
+		if usage != "" {
+			return v1.WithDetailf(v1.ErrDiskInUse, "device=%s, used=%s", dev, usage)
+		}
+	}
+	return nil
+}
+
+func (s *nodeDiskService) CreateLVM(ctx context.Context, userID string, nodeID int64, req *v1.CreateNodeLVMRequest) (string, error) {
+	return s.createLVM(ctx, userID, nodeID, req, false)
+}
+
+func (s *nodeDiskService) CreateLVMThin(ctx context.Context, userID string, nodeID int64, req *v1.CreateNodeLVMRequest) (string, error) {
+	return s.createLVM(ctx, userID, nodeID, req, true)
+}
+
+func (s *nodeDiskService) createLVM(ctx context.Context, userID string, nodeID int64, req *v1.CreateNodeLVMRequest, thin bool) (string, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "storage.manage")
+	if err != nil {
+		return "", err
+	}
+	if !lvmNamePattern.MatchString(req.Name) {
+		return "", v1.WithDetailf(v1.ErrInvalidLVMSpec, "invalid name %q", req.Name)
+	}
+
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, nodeID)
+	if err != nil {
+		return "", err
+	}
+
+	// 卷组名在节点上必须唯一（LVM-thin 也会创建同名卷组）
+	vgs, err := client.GetNodeDisksLVM(ctx, node.NodeName)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list lvm volume groups", zap.String("node", node.NodeName), zap.Error(err))
+		return "", v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	for _, vg := range vgs {
+		if name, _ := vg["vg_name"].(string); name == req.Name {
+			return "", v1.WithDetailf(v1.ErrInvalidLVMSpec, "volume group %s already exists on node %s", req.Name, node.NodeName)
+		}
+	}
+	if err := checkUnusedDisks(ctx, client, node.NodeName, []string{req.Device}); err != nil {
+		return "", err
+	}
+
+	action := "node.lvm_create"
+	if thin {
+		action = "node.lvmthin_create"
+	}
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    action,
+		Target:    node.NodeName + "/" + req.Name,
+		ClusterID: node.ClusterID,
+	}); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("name", req.Name)
+	params.Set("device", req.Device)
+	if req.AddStorage {
+		params.Set("add_storage", "1")
+	}
+
+	var upid string
+	if thin {
+		upid, err = client.CreateNodeLVMThin(ctx, node.NodeName, params)
+	} else {
+		upid, err = client.CreateNodeLVM(ctx, node.NodeName, params)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create lvm storage",
+			zap.String("node", node.NodeName), zap.String("name", req.Name), zap.Bool("thin", thin), zap.Error(err))
+		return "", v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	s.logger.WithContext(ctx).Info("lvm storage creation started",
+		zap.String("node", node.NodeName), zap.String("name", req.Name), zap.String("device", req.Device),
+		zap.Bool("thin", thin), zap.Bool("add_storage", req.AddStorage),
+		zap.String("upid", upid), zap.String("operator", username))
+	return upid, nil
+}
+
+func (s *nodeDiskService) GetSMART(ctx context.Context, nodeID int64, disk string) (*v1.NodeDiskSMART, error) {
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	disks, err := client.GetNodeDisksList(ctx, node.NodeName, false)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node disks", zap.String("node", node.NodeName), zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	var info map[string]interface{}
+	for _, d := range disks {
+		if devpath, _ := d["devpath"].(string); devpath == disk {
+			info = d
+			break
+		}
+	}
+	if info == nil {
+		return nil, v1.WithDetailf(v1.ErrDiskNotFound, "node=%s, device=%s", node.NodeName, disk)
+	}
+
+	raw, err := client.GetNodeDiskSMART(ctx, node.NodeName, disk)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get disk smart",
+			zap.String("node", node.NodeName), zap.String("disk", disk), zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	smart := parseDiskSMART(raw, diskWearout(info["wearout"]))
+	smart.NodeID = node.Id
+	smart.NodeName = node.NodeName
+	smart.Disk = disk
+	return smart, nil
+}
+
+func (s *nodeDiskService) ListAlerts(ctx context.Context, req *v1.ListNodeDiskAlertsRequest) (*v1.ListNodeDiskAlertsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	alerts, total, err := s.healthRepo.ListAlerts(ctx, page, pageSize, req.ClusterID, req.NodeID, req.Open)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node disk alerts", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.NodeDiskAlertItem, 0, len(alerts))
+	for _, a := range alerts {
+		list = append(list, v1.NodeDiskAlertItem{
+			Id:                 a.Id,
+			ClusterID:          a.ClusterID,
+			NodeID:             a.NodeID,
+			NodeName:           a.NodeName,
+			DevPath:            a.DevPath,
+			Serial:             a.Serial,
+			Model:              a.Model,
+			Reasons:            a.Reasons,
+			Health:             a.Health,
+			Wearout:            a.Wearout,
+			ReallocatedSectors: a.ReallocatedSectors,
+			PendingSectors:     a.PendingSectors,
+			Uncorrectable:      a.Uncorrectable,
+			ResolvedAt:         a.ResolvedAt,
+			CreateTime:         a.CreateTime,
+			UpdateTime:         a.UpdateTime,
+		})
+	}
+	return &v1.ListNodeDiskAlertsResponseData{Total: total, List: list}, nil
+}
+
+func (s *nodeDiskService) CollectSMART(ctx context.Context) error {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, cluster := range clusters {
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to create proxmox client", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			failed++
+			continue
+		}
+		nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			// 离线节点无法查询，保留已有数据和告警
+			if node.Status != "online" {
+				continue
+			}
+			if err := s.collectNode(ctx, client, node); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to collect disk smart",
+					zap.String("cluster", cluster.ClusterName), zap.String("node", node.NodeName), zap.Error(err))
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d node(s) failed smart collection", failed)
+	}
+	return nil
+}
+
+// smartThreshold 读取告警阈值，未配置时使用默认值，配置为 0 或负数表示不检查该项
+func (s *nodeDiskService) smartThreshold(key string, def int64) int64 {
+	key = "node_disk.smart." + key
+	if !s.conf.IsSet(key) {
+		return def
+	}
+	return s.conf.GetInt64(key)
+}
+
+// collectNode 采集节点上所有磁盘的 SMART 指标并更新告警。
+// 不支持 SMART 的磁盘（如部分 USB / RAID 卡直通盘）跳过，已有告警保持不变
+func (s *nodeDiskService) collectNode(ctx context.Context, client *proxmox.ProxmoxClient, node *model.PveNode) error {
+	disks, err := client.GetNodeDisksList(ctx, node.NodeName, false)
+	if err != nil {
+		return err
+	}
+
+	reallocatedLimit := s.smartThreshold("reallocated_threshold", 1)
+	pendingLimit := s.smartThreshold("pending_threshold", 1)
+	uncorrectableLimit := s.smartThreshold("uncorrectable_threshold", 1)
+	wearoutLimit := s.smartThreshold("wearout_threshold", 10)
+
+	now := time.Now()
+	present := make(map[string]bool, len(disks))
+	unhealthy := make(map[string]bool)
+	for _, disk := range disks {
+		devpath, _ := disk["devpath"].(string)
+		if devpath == "" {
+			continue
+		}
+		present[devpath] = true
+		raw, err := client.GetNodeDiskSMART(ctx, node.NodeName, devpath)
+		if err != nil {
+			s.logger.WithContext(ctx).Debug("disk smart unavailable",
+				zap.String("node", node.NodeName), zap.String("disk", devpath), zap.Error(err))
+			if alert, err := s.healthRepo.GetOpenAlert(ctx, node.Id, devpath); err == nil && alert != nil {
+				unhealthy[devpath] = true
+			}
+			continue
+		}
+		smart := parseDiskSMART(raw, diskWearout(disk["wearout"]))
+
+		health, err := s.healthRepo.GetHealth(ctx, node.Id, devpath)
+		if err != nil {
+			return err
+		}
+		if health == nil {
+			health = &model.NodeDiskHealth{NodeID: node.Id, DevPath: devpath}
+		}
+		health.ClusterID = node.ClusterID
+		health.Serial, _ = disk["serial"].(string)
+		health.Model, _ = disk["model"].(string)
+		health.DiskType, _ = disk["type"].(string)
+		health.Health = smart.Health
+		health.Wearout = smart.Wearout
+		health.ReallocatedSectors = smart.ReallocatedSectors
+		health.PendingSectors = smart.PendingSectors
+		health.Uncorrectable = smart.Uncorrectable
+		health.PowerOnHours = smart.PowerOnHours
+		health.Temperature = smart.Temperature
+		health.CollectTime = now
+		if err := s.healthRepo.SaveHealth(ctx, health); err != nil {
+			return err
+		}
+
+		var reasons []string
+		if smart.Health == "FAILED" {
+			reasons = append(reasons, model.NodeDiskAlertReasonSmartFailed)
+		}
+		if reallocatedLimit > 0 && smart.ReallocatedSectors >= reallocatedLimit {
+			reasons = append(reasons, model.NodeDiskAlertReasonReallocated)
+		}
+		if pendingLimit > 0 && smart.PendingSectors >= pendingLimit {
+			reasons = append(reasons, model.NodeDiskAlertReasonPending)
+		}
+		if uncorrectableLimit > 0 && smart.Uncorrectable >= uncorrectableLimit {
+			reasons = append(reasons, model.NodeDiskAlertReasonUncorrectable)
+		}
+		if wearoutLimit > 0 && smart.Wearout != nil && int64(*smart.Wearout) <= wearoutLimit {
+			reasons = append(reasons, model.NodeDiskAlertReasonWearout)
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		unhealthy[devpath] = true
+
+		alert, err := s.healthRepo.GetOpenAlert(ctx, node.Id, devpath)
+		if err != nil {
+			return err
+		}
+		notify := alert == nil
+		if alert == nil {
+			alert = &model.NodeDiskAlert{ClusterID: node.ClusterID, NodeID: node.Id, DevPath: devpath}
+		} else if alert.Reasons != strings.Join(reasons, ",") {
+			// 出现新的异常原因时重新通知
+			notify = true
+		}
+		alert.NodeName = node.NodeName
+		alert.Serial = health.Serial
+		alert.Model = health.Model
+		alert.Reasons = strings.Join(reasons, ",")
+		alert.Health = smart.Health
+		alert.Wearout = smart.Wearout
+		alert.ReallocatedSectors = smart.ReallocatedSectors
+		alert.PendingSectors = smart.PendingSectors
+		alert.Uncorrectable = smart.Uncorrectable
+		if err := s.healthRepo.SaveAlert(ctx, alert); err != nil {
+			return err
+		}
+		if notify {
+			s.logger.WithContext(ctx).Warn("node disk unhealthy",
+				zap.String("node", node.NodeName), zap.String("disk", devpath),
+				zap.String("serial", alert.Serial), zap.String("reasons", alert.Reasons))
+			s.notifyDiskAlert(ctx, alert)
+			level := model.NotificationLevelWarning
+			if strings.Contains(alert.Reasons, model.NodeDiskAlertReasonSmartFailed) {
+				level = model.NotificationLevelCritical
+			}
+			s.notificationService.NotifyAdmins(ctx, &model.Notification{
+				Category:   model.NotificationCategoryAlert,
+				Level:      level,
+				Event:      "node_disk_unhealthy",
+				Title:      fmt.Sprintf("Disk %s on %s is unhealthy", alert.DevPath, alert.NodeName),
+				Content:    fmt.Sprintf("serial=%s model=%s reasons=%s", alert.Serial, alert.Model, alert.Reasons),
+				TargetType: "node",
+				TargetID:   strconv.FormatInt(alert.NodeID, 10),
+			})
+		}
+	}
+
+	// 指标回到阈值内或已被移除的磁盘关闭告警
+	open, err := s.healthRepo.ListOpenAlertsByNode(ctx, node.Id)
+	if err != nil {
+		return err
+	}
+	for _, alert := range open {
+		if unhealthy[alert.DevPath] {
+			continue
+		}
+		alert.ResolvedAt = &now
+		if err := s.healthRepo.SaveAlert(ctx, alert); err != nil {
+			return err
+		}
+		s.logger.WithContext(ctx).Info("node disk alert resolved",
+			zap.String("node", node.NodeName), zap.String("disk", alert.DevPath), zap.Bool("removed", !present[alert.DevPath]))
+	}
+	return nil
+}
+
+// notifyDiskAlert 配置了 node_disk.smart.alert_webhook 时以 JSON POST 推送告警，失败只记录日志
+func (s *nodeDiskService) notifyDiskAlert(ctx context.Context, alert *model.NodeDiskAlert) {
+	webhook := s.conf.GetString("node_disk.smart.alert_webhook")
+	if webhook == "" {
+		return
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event":               "node_disk_unhealthy",
+		"cluster_id":          alert.ClusterID,
+		"node_id":             alert.NodeID,
+		"node_name":           alert.NodeName,
+		"devpath":             alert.DevPath,
+		"serial":              alert.Serial,
+		"model":               alert.Model,
+		"reasons":             alert.Reasons,
+		"health":              alert.Health,
+		"wearout":             alert.Wearout,
+		"reallocated_sectors": alert.ReallocatedSectors,
+		"pending_sectors":     alert.PendingSectors,
+		"uncorrectable":       alert.Uncorrectable,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to build disk alert webhook request", zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to send disk alert webhook", zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.logger.WithContext(ctx).Error("disk alert webhook returned error status", zap.Int("status", resp.StatusCode))
+	}
+}
+
+// smartLeadingInt 提取原始值开头的整数，如 "35 (Min/Max 20/45)" -> 35、"1,234" -> 1234
+var smartLeadingInt = regexp.MustCompile(`^\d[\d,]*`)
+
+func smartInt(v string) int64 {
+	m := smartLeadingInt.FindString(strings.TrimSpace(v))
+	n, _ := strconv.ParseInt(strings.ReplaceAll(m, ",", ""), 10, 64)
+	return n
+}
+
+// smartString SMART 属性值可能是字符串也可能是数字
+func smartString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return strings.TrimSpace(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// diskWearout 磁盘列表中的 wearout 为剩余寿命百分比，HDD 等不支持时为 "N/A"
+func diskWearout(v interface{}) *int {
+	switch val := v.(type) {
+	case float64:
+		w := int(val)
+		return &w
+	case string:
+		if w, err := strconv.Atoi(strings.TrimSpace(val)); err == nil {
+			return &w
+		}
+	}
+	return nil
+}
+
+// parseDiskSMART 从 Proxmox SMART 结果中提取关键指标：
+// ATA 磁盘读取属性表（5 重映射扇区、197 待映射扇区、198 不可修复扇区、9 通电时间、194 温度），
+// NVMe / SAS 磁盘解析 smartctl 文本输出
+func parseDiskSMART(raw map[string]interface{}, wearout *int) *v1.NodeDiskSMART {
+	smart := &v1.NodeDiskSMART{
+		Health:  smartString(raw["health"]),
+		Type:    smartString(raw["type"]),
+		Text:    smartString(raw["text"]),
+		Wearout: wearout,
+	}
+	if smart.Health == "" {
+		smart.Health = "UNKNOWN"
+	}
+
+	if attrs, ok := raw["attributes"].([]interface{}); ok {
+		for _, item := range attrs {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			attr := v1.SMARTAttribute{
+				ID:        smartInt(smartString(m["id"])),
+				Name:      smartString(m["name"]),
+				Value:     smartString(m["value"]),
+				Worst:     smartString(m["worst"]),
+				Threshold: smartString(m["threshold"]),
+				Raw:       smartString(m["raw"]),
+				Flags:     smartString(m["flags"]),
+				Fail:      smartString(m["fail"]),
+			}
+			if attr.Fail == "-" {
+				attr.Fail = ""
+			}
+			smart.Attributes = append(smart.Attributes, attr)
+			switch attr.ID {
+			case 5:
+				smart.ReallocatedSectors = smartInt(attr.Raw)
+			case 197:
+				smart.PendingSectors = smartInt(attr.Raw)
+			case 198:
+				smart.Uncorrectable = smartInt(attr.Raw)
+			case 9:
+				smart.PowerOnHours = smartInt(attr.Raw)
+			case 194:
+				smart.Temperature = smartInt(attr.Raw)
+			case 190:
+				if smart.Temperature == 0 {
+					smart.Temperature = smartInt(attr.Raw)
+				}
+			}
+		}
+	}
+
+	for _, line := range strings.Split(smart.Text, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "Percentage Used":
+			if smart.Wearout == nil {
+				w := 100 - int(smartInt(value))
+				if w < 0 {
+					w = 0
+				}
+				smart.Wearout = &w
+			}
+		case "Media and Data Integrity Errors":
+			smart.Uncorrectable = smartInt(value)
+		case "Power On Hours":
+			smart.PowerOnHours = smartInt(value)
+		case "Temperature", "Current Drive Temperature":
+			smart.Temperature = smartInt(value)
+		case "Elements in grown defect list":
+			smart.ReallocatedSectors = smartInt(value)
+		case "SMART Health Status":
+			// SAS 磁盘通过文本给出健康状态
+			if smart.Health == "UNKNOWN" && value == "OK" {
+				smart.Health = "PASSED"
+			}
+		}
+	}
+	return smart
+}
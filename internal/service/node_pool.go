@@ -0,0 +1,575 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type NodePoolService interface {
+	CreatePool(ctx context.Context, userID string, req *v1.CreateNodePoolRequest) (*v1.NodePoolItem, error)
+	UpdatePool(ctx context.Context, userID string, id int64, req *v1.UpdateNodePoolRequest) error
+	DeletePool(ctx context.Context, userID string, id int64) error
+	GetPool(ctx context.Context, id int64) (*v1.NodePoolItem, error)
+	ListPools(ctx context.Context, req *v1.ListNodePoolsRequest) (*v1.ListNodePoolsResponseData, error)
+	// Schedule 在节点池内为新虚拟机选择节点：可调度、Proxmox 中在线、未达到虚拟机数上限且内存足够的节点中，
+	// 选择放入新虚拟机后内存利用率最低的节点，节点内存扣除集群为系统开销预留的部分；节点池配额不足时直接返回错误
+	Schedule(ctx context.Context, cluster *model.PveCluster, poolID int64, cpuNum, memoryMB int) (*model.PveNode, error)
+	// CheckQuota 校验节点所属的全部节点池配额能否容纳新虚拟机，计入池内节点上其他进行中的创建预留，
+	// exclude 为调用方自身的预留
+	CheckQuota(ctx context.Context, nodeID int64, cpuNum, memoryMB int, exclude *ProvisionHold) error
+}
+
+func NewNodePoolService(
+	service *Service,
+	conf *viper.Viper,
+	poolRepo repository.NodePoolRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	reservations ProvisionReservationService,
+	logger *log.Logger,
+) NodePoolService {
+	return &nodePoolService{
+		conf:         conf,
+		poolRepo:     poolRepo,
+		clusterRepo:  clusterRepo,
+		nodeRepo:     nodeRepo,
+		vmRepo:       vmRepo,
+		userRepo:     userRepo,
+		reservations: reservations,
+		Service:      service,
+		logger:       logger,
+	}
+}
+
+type nodePoolService struct {
+	conf         *viper.Viper
+	poolRepo     repository.NodePoolRepository
+	clusterRepo  repository.PveClusterRepository
+	nodeRepo     repository.PveNodeRepository
+	vmRepo       repository.PveVMRepository
+	userRepo     repository.UserRepository
+	reservations ProvisionReservationService
+	*Service
+	logger *log.Logger
+}
+
+func (s *nodePoolService) CreatePool(ctx context.Context, userID string, req *v1.CreateNodePoolRequest) (*v1.NodePoolItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node_pool.manage")
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	existing, err := s.poolRepo.GetByName(ctx, cluster.Id, name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node pool", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return nil, v1.WithDetail(v1.ErrNodePoolExists, name)
+	}
+	nodeIDs, err := s.checkMembers(ctx, cluster.Id, req.NodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := &model.NodePool{
+		ClusterID:   cluster.Id,
+		Name:        name,
+		Describes:   req.Describes,
+		MaxVMs:      req.MaxVMs,
+		MaxCPU:      req.MaxCPU,
+		MaxMemoryMB: req.MaxMemoryMB,
+		Creator:     username,
+		Modifier:    username,
+	}
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.poolRepo.Create(ctx, pool); err != nil {
+			return err
+		}
+		return s.poolRepo.ReplaceMembers(ctx, pool.Id, nodeIDs)
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create node pool", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("node pool created",
+		zap.Int64("cluster_id", cluster.Id), zap.String("name", pool.Name), zap.Int64s("node_ids", nodeIDs), zap.String("operator", username))
+	return s.GetPool(ctx, pool.Id)
+}
+
+// checkMembers 校验成员节点存在且属于该集群，返回去重排序后的节点 ID
+func (s *nodePoolService) checkMembers(ctx context.Context, clusterID int64, nodeIDs []int64) ([]int64, error) {
+	unique := make([]int64, 0, len(nodeIDs))
+	seen := make(map[int64]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool { return unique[i] < unique[j] })
+	if len(unique) == 0 {
+		return unique, nil
+	}
+
+	nodes, err := s.nodeRepo.GetByIDs(ctx, unique)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, id := range unique {
+		if node, ok := nodes[id]; !ok || node.ClusterID != clusterID {
+			return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d, cluster_id=%d", id, clusterID)
+		}
+	}
+	return unique, nil
+}
+
+func (s *nodePoolService) getPool(ctx context.Context, id int64) (*model.NodePool, error) {
+	pool, err := s.poolRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node pool", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if pool == nil {
+		return nil, v1.WithDetailf(v1.ErrNodePoolNotFound, "node_pool_id=%d", id)
+	}
+	return pool, nil
+}
+
+func (s *nodePoolService) UpdatePool(ctx context.Context, userID string, id int64, req *v1.UpdateNodePoolRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node_pool.manage")
+	if err != nil {
+		return err
+	}
+	pool, err := s.getPool(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name != pool.Name {
+			existing, err := s.poolRepo.GetByName(ctx, pool.ClusterID, name)
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to get node pool", zap.Error(err))
+				return v1.ErrInternalServerError
+			}
+			if existing != nil {
+				return v1.WithDetail(v1.ErrNodePoolExists, name)
+			}
+			pool.Name = name
+		}
+	}
+	if req.Describes != nil {
+		pool.Describes = *req.Describes
+	}
+	if req.MaxVMs != nil {
+		pool.MaxVMs = *req.MaxVMs
+	}
+	if req.MaxCPU != nil {
+		pool.MaxCPU = *req.MaxCPU
+	}
+	if req.MaxMemoryMB != nil {
+		pool.MaxMemoryMB = *req.MaxMemoryMB
+	}
+	var nodeIDs []int64
+	if req.NodeIDs != nil {
+		if nodeIDs, err = s.checkMembers(ctx, pool.ClusterID, req.NodeIDs); err != nil {
+			return err
+		}
+	}
+	pool.Modifier = username
+
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.poolRepo.Update(ctx, pool); err != nil {
+			return err
+		}
+		if req.NodeIDs != nil {
+			return s.poolRepo.ReplaceMembers(ctx, pool.Id, nodeIDs)
+		}
+		return nil
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update node pool", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *nodePoolService) DeletePool(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "node_pool.manage")
+	if err != nil {
+		return err
+	}
+	pool, err := s.getPool(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.poolRepo.Delete(ctx, id)
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete node pool", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("node pool deleted",
+		zap.Int64("cluster_id", pool.ClusterID), zap.String("name", pool.Name), zap.String("operator", username))
+	return nil
+}
+
+func (s *nodePoolService) GetPool(ctx context.Context, id int64) (*v1.NodePoolItem, error) {
+	pool, err := s.getPool(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.toItems(ctx, []*model.NodePool{pool})
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func (s *nodePoolService) ListPools(ctx context.Context, req *v1.ListNodePoolsRequest) (*v1.ListNodePoolsResponseData, error) {
+	pools, err := s.poolRepo.List(ctx, req.ClusterID, req.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node pools", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	items, err := s.toItems(ctx, pools)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ListNodePoolsResponseData{List: items}, nil
+}
+
+// toItems 补全集群名称、成员节点和池内资源用量
+func (s *nodePoolService) toItems(ctx context.Context, pools []*model.NodePool) ([]v1.NodePoolItem, error) {
+	poolIDs := make([]int64, 0, len(pools))
+	clusterIDs := make([]int64, 0, len(pools))
+	for _, pool := range pools {
+		poolIDs = append(poolIDs, pool.Id)
+		clusterIDs = append(clusterIDs, pool.ClusterID)
+	}
+	members, err := s.poolRepo.ListMembers(ctx, poolIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node pool members", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	var nodeIDs []int64
+	for _, ids := range members {
+		nodeIDs = append(nodeIDs, ids...)
+	}
+	nodes, err := s.nodeRepo.GetByIDs(ctx, nodeIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	clusters, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	nodeUsage := make(map[int64]v1.NodePoolUsage)
+	items := make([]v1.NodePoolItem, 0, len(pools))
+	for _, pool := range pools {
+		item := v1.NodePoolItem{
+			Id:        pool.Id,
+			ClusterID: pool.ClusterID,
+			Name:      pool.Name,
+			Describes: pool.Describes,
+			Nodes:     make([]v1.NodePoolNode, 0, len(members[pool.Id])),
+			NodePoolQuota: v1.NodePoolQuota{
+				MaxVMs:      pool.MaxVMs,
+				MaxCPU:      pool.MaxCPU,
+				MaxMemoryMB: pool.MaxMemoryMB,
+			},
+			Creator:    pool.Creator,
+			Modifier:   pool.Modifier,
+			CreateTime: pool.CreateTime,
+			UpdateTime: pool.UpdateTime,
+		}
+		if cluster, ok := clusters[pool.ClusterID]; ok {
+			item.ClusterName = cluster.ClusterName
+		}
+		for _, nodeID := range members[pool.Id] {
+			// 节点已从集群中删除时不再列出
+			node, ok := nodes[nodeID]
+			if !ok {
+				continue
+			}
+			item.Nodes = append(item.Nodes, v1.NodePoolNode{
+				NodeID:        node.Id,
+				NodeName:      node.NodeName,
+				Status:        node.Status,
+				IsSchedulable: node.IsSchedulable == 1,
+			})
+			usage, ok := nodeUsage[nodeID]
+			if !ok {
+				if usage, err = s.nodeVMUsage(ctx, nodeID); err != nil {
+					return nil, err
+				}
+				nodeUsage[nodeID] = usage
+			}
+			item.Usage.VMs += usage.VMs
+			item.Usage.CPU += usage.CPU
+			item.Usage.MemoryMB += usage.MemoryMB
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// nodeVMUsage 节点上虚拟机（不含模板）的数量、vCPU 和内存合计
+func (s *nodePoolService) nodeVMUsage(ctx context.Context, nodeID int64) (v1.NodePoolUsage, error) {
+	usage, _, err := s.nodeUsage(ctx, nodeID, false, nil)
+	return usage, err
+}
+
+// nodeUsage 节点上虚拟机的合计，withReserved 时加上进行中的创建预留（exclude 除外）；
+// 创建任务结束前虚拟机记录可能已写入数据库，这部分预留不重复计入。第二个返回值为计入的预留内存（MB）
+func (s *nodePoolService) nodeUsage(ctx context.Context, nodeID int64, withReserved bool, exclude *ProvisionHold) (v1.NodePoolUsage, int, error) {
+	var usage v1.NodePoolUsage
+	vms, err := s.vmRepo.ListByNodeID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vms by node", zap.Error(err), zap.Int64("node_id", nodeID))
+		return usage, 0, v1.ErrInternalServerError
+	}
+	recorded := make(map[uint32]bool, len(vms))
+	for _, vm := range vms {
+		recorded[vm.VMID] = true
+		if vm.IsTemplate == 1 {
+			continue
+		}
+		usage.VMs++
+		usage.CPU += vm.CPUNum
+		usage.MemoryMB += int64(vm.MemorySize)
+	}
+	if !withReserved {
+		return usage, 0, nil
+	}
+
+	reservedMB := 0
+	reservations, _ := s.reservations.List(ctx, &v1.ListProvisionReservationsRequest{NodeID: nodeID})
+	for _, r := range reservations.List {
+		if (exclude != nil && exclude.id == r.Id) || recorded[r.VMID] {
+			continue
+		}
+		usage.VMs++
+		usage.CPU += r.CPUNum
+		usage.MemoryMB += int64(r.MemoryMB)
+		reservedMB += r.MemoryMB
+	}
+	return usage, reservedMB, nil
+}
+
+// poolUsage 池内节点上的虚拟机合计加上进行中的创建预留
+func (s *nodePoolService) poolUsage(ctx context.Context, nodeIDs []int64, exclude *ProvisionHold) (v1.NodePoolUsage, error) {
+	var total v1.NodePoolUsage
+	for _, nodeID := range nodeIDs {
+		usage, _, err := s.nodeUsage(ctx, nodeID, true, exclude)
+		if err != nil {
+			return total, err
+		}
+		total.VMs += usage.VMs
+		total.CPU += usage.CPU
+		total.MemoryMB += usage.MemoryMB
+	}
+	return total, nil
+}
+
+// quotaIssues 列出新虚拟机放入节点池后超出的配额
+func quotaIssues(pool *model.NodePool, usage v1.NodePoolUsage, cpuNum, memoryMB int) []string {
+	var issues []string
+	if pool.MaxVMs > 0 && usage.VMs+1 > pool.MaxVMs {
+		issues = append(issues, fmt.Sprintf("node pool %s already has %d of %d vms", pool.Name, usage.VMs, pool.MaxVMs))
+	}
+	if pool.MaxCPU > 0 && usage.CPU+cpuNum > pool.MaxCPU {
+		issues = append(issues, fmt.Sprintf("node pool %s needs %d vcpus but only %d of %d are left",
+			pool.Name, cpuNum, max(pool.MaxCPU-usage.CPU, 0), pool.MaxCPU))
+	}
+	if pool.MaxMemoryMB > 0 && usage.MemoryMB+int64(memoryMB) > pool.MaxMemoryMB {
+		issues = append(issues, fmt.Sprintf("node pool %s needs %d MiB of memory but only %d of %d MiB are left",
+			pool.Name, memoryMB, max(pool.MaxMemoryMB-usage.MemoryMB, 0), pool.MaxMemoryMB))
+	}
+	return issues
+}
+
+func (s *nodePoolService) checkPoolQuota(ctx context.Context, pool *model.NodePool, nodeIDs []int64, cpuNum, memoryMB int, exclude *ProvisionHold) error {
+	if pool.MaxVMs <= 0 && pool.MaxCPU <= 0 && pool.MaxMemoryMB <= 0 {
+		return nil
+	}
+	usage, err := s.poolUsage(ctx, nodeIDs, exclude)
+	if err != nil {
+		return err
+	}
+	if issues := quotaIssues(pool, usage, cpuNum, memoryMB); len(issues) > 0 {
+		return v1.WithDetail(v1.ErrNodePoolQuotaExceeded, strings.Join(issues, "; "))
+	}
+	return nil
+}
+
+func (s *nodePoolService) CheckQuota(ctx context.Context, nodeID int64, cpuNum, memoryMB int, exclude *ProvisionHold) error {
+	pools, err := s.poolRepo.List(ctx, 0, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node pools", zap.Error(err), zap.Int64("node_id", nodeID))
+		return v1.ErrInternalServerError
+	}
+	if len(pools) == 0 {
+		return nil
+	}
+	poolIDs := make([]int64, 0, len(pools))
+	for _, pool := range pools {
+		poolIDs = append(poolIDs, pool.Id)
+	}
+	members, err := s.poolRepo.ListMembers(ctx, poolIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node pool members", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	for _, pool := range pools {
+		if err := s.checkPoolQuota(ctx, pool, members[pool.Id], cpuNum, memoryMB, exclude); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nodeCandidate 调度时的候选节点
+type nodeCandidate struct {
+	node     *model.PveNode
+	vms      int
+	maxMem   float64 // 节点内存扣除集群为系统开销预留的部分（字节）
+	usedMem  float64 // Proxmox 上报的已用内存 + 进行中的创建预留（字节）
+	newRatio float64 // 放入新虚拟机后的内存利用率
+}
+
+func (s *nodePoolService) Schedule(ctx context.Context, cluster *model.PveCluster, poolID int64, cpuNum, memoryMB int) (*model.PveNode, error) {
+	pool, err := s.getPool(ctx, poolID)
+	if err != nil {
+		return nil, err
+	}
+	if pool.ClusterID != cluster.Id {
+		return nil, v1.WithDetailf(v1.ErrNodePoolClusterMismatch, "node_pool_id=%d, cluster_id=%d", pool.Id, cluster.Id)
+	}
+	members, err := s.poolRepo.ListMembers(ctx, []int64{pool.Id})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node pool members", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	nodeIDs := members[pool.Id]
+	if err := s.checkPoolQuota(ctx, pool, nodeIDs, cpuNum, memoryMB, nil); err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeRepo.GetByIDs(ctx, nodeIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	resources, err := client.GetClusterResourcesByType(ctx, "node")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node resources", zap.Error(err), zap.String("cluster", cluster.ClusterName))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	byName := make(map[string]map[string]interface{}, len(resources))
+	for _, resource := range resources {
+		if name, _ := resource["node"].(string); name != "" {
+			byName[name] = resource
+		}
+	}
+
+	limit := defaultNodeMemoryLimit
+	if s.conf.IsSet("provision_reservation.memory_limit") {
+		limit = s.conf.GetFloat64("provision_reservation.memory_limit")
+	}
+	memory := float64(int64(memoryMB) << 20)
+
+	var candidates []nodeCandidate
+	var skipped []string
+	for _, nodeID := range nodeIDs {
+		node, ok := nodes[nodeID]
+		if !ok {
+			continue
+		}
+		if node.IsSchedulable != 1 {
+			skipped = append(skipped, fmt.Sprintf("%s: not schedulable", node.NodeName))
+			continue
+		}
+		resource, ok := byName[node.NodeName]
+		if status, _ := resource["status"].(string); !ok || status != "online" {
+			skipped = append(skipped, fmt.Sprintf("%s: offline", node.NodeName))
+			continue
+		}
+		usage, reservedMB, err := s.nodeUsage(ctx, node.Id, true, nil)
+		if err != nil {
+			return nil, err
+		}
+		if node.VMLimit > 0 && int64(usage.VMs) >= node.VMLimit {
+			skipped = append(skipped, fmt.Sprintf("%s: vm limit %d reached", node.NodeName, node.VMLimit))
+			continue
+		}
+		// 集群为系统开销预留的内存不参与调度
+		maxMem := resourceFloat(resource, "maxmem")
+		c := nodeCandidate{
+			node:    node,
+			vms:     usage.VMs,
+			maxMem:  maxMem - nodeReservedMemory(cluster, maxMem),
+			usedMem: resourceFloat(resource, "mem") + float64(int64(reservedMB)<<20),
+		}
+		if c.maxMem <= 0 {
+			skipped = append(skipped, fmt.Sprintf("%s: memory unknown", node.NodeName))
+			continue
+		}
+		if limit > 0 && c.usedMem+memory > c.maxMem*limit {
+			skipped = append(skipped, fmt.Sprintf("%s: only %.0f MiB of memory available", node.NodeName, max(c.maxMem*limit-c.usedMem, 0)/(1<<20)))
+			continue
+		}
+		c.newRatio = (c.usedMem + memory) / c.maxMem
+		candidates = append(candidates, c)
+	}
+	if len(candidates) == 0 {
+		detail := fmt.Sprintf("node pool %s has no node that can host the vm", pool.Name)
+		if len(skipped) > 0 {
+			detail += ": " + strings.Join(skipped, "; ")
+		}
+		return nil, v1.WithDetail(v1.ErrNodePoolNoCapacity, detail)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].newRatio != candidates[j].newRatio {
+			return candidates[i].newRatio < candidates[j].newRatio
+		}
+		return candidates[i].vms < candidates[j].vms
+	})
+	chosen := candidates[0]
+	s.logger.WithContext(ctx).Info("node pool scheduled vm",
+		zap.String("node_pool", pool.Name), zap.String("node", chosen.node.NodeName),
+		zap.Float64("memory_ratio", roundRatio(chosen.newRatio)), zap.Strings("skipped", skipped))
+	return chosen.node, nil
+}
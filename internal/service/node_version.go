@@ -0,0 +1,604 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// nodeVersionPackages 版本矩阵中保留的关键软件包
+var nodeVersionPackages = []string{
+	"proxmox-ve", "pve-manager", "qemu-server", "pve-qemu-kvm", "proxmox-kernel-helper",
+	"pve-kernel-helper", "corosync", "ceph", "lxc-pve", "zfsutils-linux",
+}
+
+// cpuLevelFlags x86-64 微架构级别（与 Proxmox 的 x86-64-vN CPU 型号对应）需要的 CPU 指令集，按级别递增
+var cpuLevelFlags = []struct {
+	Level string
+	Flags []string
+}{
+	{"x86-64-v2", []string{"cx16", "lahf_lm", "popcnt", "pni", "sse4_1", "sse4_2", "ssse3"}},
+	{"x86-64-v3", []string{"avx", "avx2", "bmi1", "bmi2", "f16c", "fma", "abm", "movbe", "xsave"}},
+	{"x86-64-v4", []string{"avx512f", "avx512bw", "avx512cd", "avx512dq", "avx512vl"}},
+}
+
+// cpuModelVendors 厂商专用 CPU 型号前缀，这些型号只能在对应厂商的 CPU 上运行
+var cpuModelVendors = map[string]string{
+	"EPYC": "amd", "Opteron": "amd", "phenom": "amd", "athlon": "amd",
+	"Skylake": "intel", "Cascadelake": "intel", "Cooperlake": "intel", "Icelake": "intel",
+	"SapphireRapids": "intel", "GraniteRapids": "intel", "Haswell": "intel", "Broadwell": "intel",
+	"SandyBridge": "intel", "IvyBridge": "intel", "Nehalem": "intel", "Westmere": "intel",
+	"Penryn": "intel", "Conroe": "intel", "KnightsMill": "intel",
+}
+
+// defaultVMCPUType 虚拟机未配置 cpu 时 qemu-server 使用的默认型号
+const defaultVMCPUType = "kvm64"
+
+type NodeVersionService interface {
+	// CollectAll 采集所有集群节点的版本信息，供后台定时采集调用
+	CollectAll(ctx context.Context) error
+	RefreshCluster(ctx context.Context, clusterID int64) (*v1.NodeVersionMatrix, error)
+	GetMatrix(ctx context.Context, clusterID int64) (*v1.NodeVersionMatrix, error)
+	CheckMigration(ctx context.Context, req *v1.MigrationCheckRequest) (*v1.MigrationCheckResult, error)
+	// CheckVMMigration 检查虚拟机迁移到目标节点的兼容性，online 为空时按虚拟机当前是否运行判断
+	CheckVMMigration(ctx context.Context, client *proxmox.ProxmoxClient, vm *model.PveVM, sourceNode, targetNode *model.PveNode, online *bool) (*v1.MigrationCheckResult, error)
+	// EnforceMigrationCheck 是否在迁移存在阻断问题时拒绝迁移（否则只记录告警）
+	EnforceMigrationCheck() bool
+}
+
+func NewNodeVersionService(
+	service *Service,
+	conf *viper.Viper,
+	versionRepo repository.NodeVersionRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	logger *log.Logger,
+) NodeVersionService {
+	return &nodeVersionService{
+		conf:        conf,
+		versionRepo: versionRepo,
+		clusterRepo: clusterRepo,
+		nodeRepo:    nodeRepo,
+		vmRepo:      vmRepo,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type nodeVersionService struct {
+	conf        *viper.Viper
+	versionRepo repository.NodeVersionRepository
+	clusterRepo repository.PveClusterRepository
+	nodeRepo    repository.PveNodeRepository
+	vmRepo      repository.PveVMRepository
+	*Service
+	logger *log.Logger
+}
+
+func (s *nodeVersionService) EnforceMigrationCheck() bool {
+	if !s.conf.IsSet("node_version.enforce_migration_check") {
+		return true
+	}
+	return s.conf.GetBool("node_version.enforce_migration_check")
+}
+
+func (s *nodeVersionService) CollectAll(ctx context.Context) error {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, cluster := range clusters {
+		if err := s.collectCluster(ctx, cluster); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to collect node versions", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d cluster(s) failed to collect node versions", failed, len(clusters))
+	}
+	return nil
+}
+
+func (s *nodeVersionService) RefreshCluster(ctx context.Context, clusterID int64) (*v1.NodeVersionMatrix, error) {
+	cluster, err := s.getCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.collectCluster(ctx, cluster); err != nil {
+		s.logger.WithContext(ctx).Error("failed to collect node versions", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "collect node versions: %v", err)
+	}
+	return s.GetMatrix(ctx, clusterID)
+}
+
+func (s *nodeVersionService) getCluster(ctx context.Context, clusterID int64) (*model.PveCluster, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+	return cluster, nil
+}
+
+// collectCluster 采集集群内所有节点；单个节点失败只记录错误并保留上次数据
+func (s *nodeVersionService) collectCluster(ctx context.Context, cluster *model.PveCluster) error {
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return err
+	}
+	nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		return err
+	}
+
+	for _, node := range nodes {
+		version, err := s.versionRepo.GetByNodeID(ctx, node.Id)
+		if err != nil {
+			return err
+		}
+		if version == nil {
+			version = &model.NodeVersion{NodeID: node.Id}
+		}
+		version.ClusterID = cluster.Id
+		version.NodeName = node.NodeName
+
+		now := time.Now()
+		version.CollectTime = &now
+		if err := collectNodeVersion(ctx, client, node.NodeName, version); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to collect node version",
+				zap.String("cluster", cluster.ClusterName), zap.String("node", node.NodeName), zap.Error(err))
+			version.CollectError = err.Error()
+		} else {
+			version.CollectError = ""
+		}
+		if err := s.versionRepo.Save(ctx, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectNodeVersion 读取节点状态（pveversion、内核、CPU）和软件包版本
+func collectNodeVersion(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, version *model.NodeVersion) error {
+	status, err := client.GetNodeStatus(ctx, nodeName)
+	if err != nil {
+		return fmt.Errorf("get node status: %w", err)
+	}
+	// pveversion 形如 pve-manager/8.2.4/faa83925c9641325
+	if pveversion, _ := status["pveversion"].(string); pveversion != "" {
+		if parts := strings.Split(pveversion, "/"); len(parts) >= 2 {
+			version.PveVersion = parts[1]
+		}
+	}
+	if kernel, ok := status["current-kernel"].(map[string]interface{}); ok {
+		version.KernelVersion, _ = kernel["release"].(string)
+	} else if kversion, _ := status["kversion"].(string); kversion != "" {
+		// 旧版本只有 kversion，形如 Linux 6.5.13-5-pve #1 SMP ...
+		if fields := strings.Fields(kversion); len(fields) >= 2 {
+			version.KernelVersion = fields[1]
+		}
+	}
+	if cpuinfo, ok := status["cpuinfo"].(map[string]interface{}); ok {
+		version.CPUModel, _ = cpuinfo["model"].(string)
+		version.CPUFlags, _ = cpuinfo["flags"].(string)
+		version.CPUVendor = cpuVendor(version.CPUModel)
+	}
+
+	packages, err := client.GetNodePackageVersions(ctx, nodeName)
+	if err != nil {
+		return fmt.Errorf("get package versions: %w", err)
+	}
+	keep := make(map[string]string)
+	for _, pkg := range packages {
+		name, _ := pkg["Package"].(string)
+		ver, _ := pkg["Version"].(string)
+		for _, wanted := range nodeVersionPackages {
+			if name == wanted && ver != "" {
+				keep[name] = ver
+			}
+		}
+	}
+	version.QemuVersion = keep["pve-qemu-kvm"]
+	if data, err := json.Marshal(keep); err == nil {
+		version.Packages = string(data)
+	}
+	return nil
+}
+
+func cpuVendor(model string) string {
+	lower := strings.ToLower(model)
+	switch {
+	case strings.Contains(lower, "intel"):
+		return "intel"
+	case strings.Contains(lower, "amd"):
+		return "amd"
+	default:
+		return ""
+	}
+}
+
+func (s *nodeVersionService) GetMatrix(ctx context.Context, clusterID int64) (*v1.NodeVersionMatrix, error) {
+	cluster, err := s.getCluster(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.nodeRepo.GetByClusterID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	versions, err := s.versionRepo.ListByClusterID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node versions", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	byNode := make(map[int64]*model.NodeVersion, len(versions))
+	for _, version := range versions {
+		byNode[version.NodeID] = version
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeName < nodes[j].NodeName })
+
+	matrix := &v1.NodeVersionMatrix{
+		ClusterID:   cluster.Id,
+		ClusterName: cluster.ClusterName,
+		CPUBaseline: cluster.CPUBaseline,
+		Mixed:       make(map[string]bool),
+		Nodes:       make([]v1.NodeVersionItem, 0, len(nodes)),
+	}
+	distinct := map[string]map[string]bool{
+		"pve_version": {}, "kernel_version": {}, "qemu_version": {}, "cpu_model": {}, "cpu_vendor": {},
+	}
+	commonLevel := -1
+	for _, node := range nodes {
+		item := v1.NodeVersionItem{NodeID: node.Id, NodeName: node.NodeName}
+		version := byNode[node.Id]
+		if version == nil || version.PveVersion == "" {
+			if version != nil {
+				item.CollectTime, item.CollectError = version.CollectTime, version.CollectError
+			}
+			matrix.Nodes = append(matrix.Nodes, item)
+			continue
+		}
+		flags := cpuFlagSet(version.CPUFlags)
+		level := cpuLevel(flags)
+		item.PveVersion = version.PveVersion
+		item.KernelVersion = version.KernelVersion
+		item.QemuVersion = version.QemuVersion
+		item.CPUModel = version.CPUModel
+		item.CPUVendor = version.CPUVendor
+		item.CPULevel = cpuLevelName(level, flags)
+		item.CollectTime = version.CollectTime
+		item.CollectError = version.CollectError
+		if version.Packages != "" {
+			_ = json.Unmarshal([]byte(version.Packages), &item.Packages)
+		}
+		matrix.Nodes = append(matrix.Nodes, item)
+
+		distinct["pve_version"][version.PveVersion] = true
+		distinct["kernel_version"][version.KernelVersion] = true
+		distinct["qemu_version"][version.QemuVersion] = true
+		distinct["cpu_model"][version.CPUModel] = true
+		distinct["cpu_vendor"][version.CPUVendor] = true
+		if commonLevel < 0 || level < commonLevel {
+			commonLevel = level
+		}
+	}
+	for key, values := range distinct {
+		matrix.Mixed[key] = len(values) > 1
+	}
+	if commonLevel >= 0 {
+		matrix.RecommendedBaseline = s.recommendBaseline(matrix.Nodes, commonLevel, byNode)
+	}
+	return matrix, nil
+}
+
+// recommendBaseline 返回所有节点都支持的最高 x86-64 级别；v2 级别时所有节点都支持 AES 则推荐 x86-64-v2-AES
+func (s *nodeVersionService) recommendBaseline(items []v1.NodeVersionItem, level int, byNode map[int64]*model.NodeVersion) string {
+	if level == 0 {
+		return defaultVMCPUType
+	}
+	name := cpuLevelFlags[level-1].Level
+	if level == 1 {
+		for _, item := range items {
+			version := byNode[item.NodeID]
+			if version != nil && version.PveVersion != "" && !cpuFlagSet(version.CPUFlags)["aes"] {
+				return name
+			}
+		}
+		name += "-AES"
+	}
+	return name
+}
+
+func cpuFlagSet(flags string) map[string]bool {
+	set := make(map[string]bool)
+	for _, flag := range strings.Fields(flags) {
+		set[flag] = true
+	}
+	return set
+}
+
+// cpuLevel 返回 CPU 满足的最高 x86-64 级别序号（0 表示只满足基础 x86-64）
+func cpuLevel(flags map[string]bool) int {
+	level := 0
+	for i, l := range cpuLevelFlags {
+		if len(missingFlags(flags, l.Flags)) > 0 {
+			break
+		}
+		level = i + 1
+	}
+	return level
+}
+
+func cpuLevelName(level int, flags map[string]bool) string {
+	if level == 0 {
+		return "x86-64"
+	}
+	name := cpuLevelFlags[level-1].Level
+	if level == 1 && flags["aes"] {
+		name += "-AES"
+	}
+	return name
+}
+
+func missingFlags(flags map[string]bool, required []string) []string {
+	var missing []string
+	for _, flag := range required {
+		if !flags[flag] {
+			missing = append(missing, flag)
+		}
+	}
+	return missing
+}
+
+// vmCPUSpec 解析虚拟机 cpu 配置，如 "host"、"x86-64-v2-AES"、"cputype=kvm64,flags=+pcid;+aes"
+func vmCPUSpec(cpu string) (cpuType string, requiredFlags []string) {
+	cpuType = defaultVMCPUType
+	for i, part := range strings.Split(cpu, ",") {
+		key, value, found := strings.Cut(part, "=")
+		switch {
+		case i == 0 && !found:
+			cpuType = part
+		case key == "cputype":
+			cpuType = value
+		case key == "flags":
+			for _, flag := range strings.Split(value, ";") {
+				// 只有 +flag 需要宿主机支持；Hyper-V 增强由 QEMU 模拟，不依赖宿主机 CPU
+				if !strings.HasPrefix(flag, "+") || strings.HasPrefix(flag, "+hv-") {
+					continue
+				}
+				requiredFlags = append(requiredFlags, strings.ReplaceAll(strings.TrimPrefix(flag, "+"), "-", "_"))
+			}
+		}
+	}
+	return cpuType, requiredFlags
+}
+
+// requiredLevelFlags 返回 x86-64-vN 型号需要的全部 CPU 指令集
+func requiredLevelFlags(cpuType string) []string {
+	base, aes := strings.CutSuffix(cpuType, "-AES")
+	var flags []string
+	for _, l := range cpuLevelFlags {
+		flags = append(flags, l.Flags...)
+		if l.Level == base {
+			if aes {
+				flags = append(flags, "aes")
+			}
+			return flags
+		}
+	}
+	return nil
+}
+
+func (s *nodeVersionService) CheckMigration(ctx context.Context, req *v1.MigrationCheckRequest) (*v1.MigrationCheckResult, error) {
+	vm, err := s.vmRepo.GetByID(ctx, req.VmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.ErrVMNotFound
+	}
+	sourceNode, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if sourceNode == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
+	}
+	targetNode, err := s.nodeRepo.GetByID(ctx, req.TargetNodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get target node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if targetNode == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "target_node_id=%d", req.TargetNodeID)
+	}
+	cluster, err := s.getCluster(ctx, vm.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	result, err := s.CheckVMMigration(ctx, client, vm, sourceNode, targetNode, nil)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to check vm migration", zap.Error(err))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "check migration: %v", err)
+	}
+	return result, nil
+}
+
+func (s *nodeVersionService) CheckVMMigration(ctx context.Context, client *proxmox.ProxmoxClient, vm *model.PveVM, sourceNode, targetNode *model.PveNode, online *bool) (*v1.MigrationCheckResult, error) {
+	result := &v1.MigrationCheckResult{
+		VmID:       vm.Id,
+		VMID:       vm.VMID,
+		SourceNode: sourceNode.NodeName,
+		TargetNode: targetNode.NodeName,
+		Blockers:   []v1.MigrationCheckIssue{},
+		Warnings:   []v1.MigrationCheckIssue{},
+	}
+
+	status, err := client.GetVMStatus(ctx, sourceNode.NodeName, vm.VMID)
+	if err != nil {
+		return nil, fmt.Errorf("get vm status: %w", err)
+	}
+	running := status["status"] == "running"
+	result.Online = running
+	if online != nil {
+		result.Online = *online && running
+	}
+	config, err := client.GetVMConfig(ctx, sourceNode.NodeName, vm.VMID)
+	if err != nil {
+		return nil, fmt.Errorf("get vm config: %w", err)
+	}
+	cpu, _ := config["cpu"].(string)
+	cpuType, extraFlags := vmCPUSpec(cpu)
+	result.CPUType = cpuType
+
+	source, err := s.versionRepo.GetByNodeID(ctx, sourceNode.Id)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.versionRepo.GetByNodeID(ctx, targetNode.Id)
+	if err != nil {
+		return nil, err
+	}
+	addWarning := func(code, format string, args ...interface{}) {
+		result.Warnings = append(result.Warnings, v1.MigrationCheckIssue{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+	addBlocker := func(code, format string, args ...interface{}) {
+		result.Blockers = append(result.Blockers, v1.MigrationCheckIssue{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if source == nil || source.PveVersion == "" || target == nil || target.PveVersion == "" {
+		addWarning("version_unknown", "node version information has not been collected for %s or %s", sourceNode.NodeName, targetNode.NodeName)
+		result.Compatible = true
+		return result, nil
+	}
+
+	// Proxmox 不支持从高版本迁移到低版本
+	sourceMajor, targetMajor := majorVersion(source.PveVersion), majorVersion(target.PveVersion)
+	if targetMajor < sourceMajor {
+		addBlocker("pve_major_mismatch", "target runs Proxmox VE %s, older than source %s", target.PveVersion, source.PveVersion)
+	} else if targetMajor > sourceMajor {
+		addWarning("pve_major_mismatch", "target runs Proxmox VE %s, source runs %s; migrating back will not be possible", target.PveVersion, source.PveVersion)
+	}
+
+	// 离线迁移不涉及运行中的 QEMU 进程和 CPU 状态
+	if !result.Online {
+		result.Compatible = len(result.Blockers) == 0
+		return result, nil
+	}
+
+	// 运行中的 QEMU 版本不能高于目标节点安装的版本
+	runningQemu, _ := status["running-qemu"].(string)
+	if runningQemu == "" {
+		runningQemu = source.QemuVersion
+	}
+	if runningQemu != "" && target.QemuVersion != "" && compareVersions(upstreamVersion(runningQemu), upstreamVersion(target.QemuVersion)) > 0 {
+		addBlocker("qemu_downgrade", "vm runs QEMU %s but target %s has QEMU %s", runningQemu, targetNode.NodeName, target.QemuVersion)
+	}
+	if source.KernelVersion != target.KernelVersion {
+		addWarning("kernel_mismatch", "kernel differs: %s on %s, %s on %s", source.KernelVersion, sourceNode.NodeName, target.KernelVersion, targetNode.NodeName)
+	}
+
+	targetFlags := cpuFlagSet(target.CPUFlags)
+	switch {
+	case cpuType == "host" || cpuType == "max":
+		// 直通宿主机 CPU：目标节点必须具备源节点的全部指令集
+		if missing := missingFlags(targetFlags, strings.Fields(source.CPUFlags)); len(missing) > 0 {
+			addBlocker("cpu_host_mismatch", "cpu type %s requires identical host CPUs; target lacks %s", cpuType, summarizeFlags(missing))
+		} else if source.CPUModel != target.CPUModel {
+			addWarning("cpu_host_mismatch", "cpu type %s with different host CPUs (%s -> %s)", cpuType, source.CPUModel, target.CPUModel)
+		}
+	case strings.HasPrefix(cpuType, "x86-64-v"):
+		if missing := missingFlags(targetFlags, requiredLevelFlags(cpuType)); len(missing) > 0 {
+			addBlocker("cpu_flags_missing", "target CPU does not support %s, missing %s", cpuType, summarizeFlags(missing))
+		}
+	default:
+		for prefix, vendor := range cpuModelVendors {
+			if strings.HasPrefix(cpuType, prefix) && target.CPUVendor != "" && target.CPUVendor != vendor {
+				addBlocker("cpu_vendor_mismatch", "cpu type %s requires an %s CPU, target is %s", cpuType, vendor, target.CPUVendor)
+				break
+			}
+		}
+	}
+	if missing := missingFlags(targetFlags, extraFlags); len(missing) > 0 {
+		addBlocker("cpu_flags_missing", "vm enables CPU flags not supported by target: %s", summarizeFlags(missing))
+	}
+
+	result.Compatible = len(result.Blockers) == 0
+	return result, nil
+}
+
+// summarizeFlags 最多列出 10 个指令集
+func summarizeFlags(flags []string) string {
+	if len(flags) > 10 {
+		return fmt.Sprintf("%s and %d more", strings.Join(flags[:10], " "), len(flags)-10)
+	}
+	return strings.Join(flags, " ")
+}
+
+func majorVersion(version string) int {
+	major, _ := strconv.Atoi(strings.SplitN(version, ".", 2)[0])
+	return major
+}
+
+// upstreamVersion 去掉 Debian 打包版本后缀，如 9.0.2-3 -> 9.0.2
+func upstreamVersion(version string) string {
+	upstream, _, _ := strings.Cut(version, "-")
+	return upstream
+}
+
+// compareVersions 按数字逐段比较点分版本号
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(pa), len(pb)); i++ {
+		var x, y int
+		if i < len(pa) {
+			x, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			y, _ = strconv.Atoi(pb[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// migrationIssueSummary 将阻断问题拼接为错误详情
+func migrationIssueSummary(issues []v1.MigrationCheckIssue) string {
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,564 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// zfsPoolNamePattern 与 Proxmox 对存储池名称的限制一致
+var zfsPoolNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_.:-]*$`)
+
+// zfsMinDevices 各 RAID 级别要求的最少磁盘数
+var zfsMinDevices = map[string]int{
+	"single": 1,
+	"mirror": 2,
+	"raid10": 4,
+	"raidz":  3,
+	"raidz2": 4,
+	"raidz3": 5,
+}
+
+type NodeZFSService interface {
+	// CreatePool 在节点上创建 ZFS 存储池，仅管理员可操作，受变更窗口约束，返回 Proxmox 任务 UPID
+	CreatePool(ctx context.Context, userID string, nodeID int64, req *v1.CreateZFSPoolRequest) (string, error)
+	// GetPool 获取存储池健康状态、错误计数和最近一次 scrub 时间
+	GetPool(ctx context.Context, nodeID int64, name string) (*v1.ZFSPoolDetail, error)
+	ListDatasets(ctx context.Context, nodeID int64) ([]v1.ZFSDataset, error)
+	ListAlerts(ctx context.Context, req *v1.ListZFSPoolAlertsRequest) (*v1.ListZFSPoolAlertsResponseData, error)
+	// CheckHealth 检查所有在线节点的 ZFS 存储池，异常时记录告警并通知，恢复后关闭告警
+	CheckHealth(ctx context.Context) error
+}
+
+func NewNodeZFSService(
+	service *Service,
+	conf *viper.Viper,
+	alertRepo repository.ZFSPoolAlertRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	changeControl ChangeControlService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) NodeZFSService {
+	return &nodeZFSService{
+		conf:                conf,
+		alertRepo:           alertRepo,
+		nodeRepo:            nodeRepo,
+		clusterRepo:         clusterRepo,
+		userRepo:            userRepo,
+		changeControl:       changeControl,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+		httpClient:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type nodeZFSService struct {
+	conf          *viper.Viper
+	alertRepo     repository.ZFSPoolAlertRepository
+	nodeRepo      repository.PveNodeRepository
+	clusterRepo   repository.PveClusterRepository
+	userRepo      repository.UserRepository
+	changeControl ChangeControlService
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+	httpClient          *http.Client // 告警 webhook
+}
+
+func (s *nodeZFSService) CreatePool(ctx context.Context, userID string, nodeID int64, req *v1.CreateZFSPoolRequest) (string, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "storage.manage")
+	if err != nil {
+		return "", err
+	}
+
+	if !zfsPoolNamePattern.MatchString(req.Name) {
+		return "", v1.WithDetailf(v1.ErrInvalidZFSPoolSpec, "invalid pool name %q", req.Name)
+	}
+	devices := make([]string, 0, len(req.Devices))
+	seen := make(map[string]bool, len(req.Devices))
+	for _, dev := range req.Devices {
+		dev = strings.TrimSpace(dev)
+		if dev == "" || seen[dev] {
+			continue
+		}
+		seen[dev] = true
+		devices = append(devices, dev)
+	}
+	if minDevices := zfsMinDevices[req.RaidLevel]; len(devices) < minDevices {
+		return "", v1.WithDetailf(v1.ErrInvalidZFSPoolSpec, "raid level %s requires at least %d devices", req.RaidLevel, minDevices)
+	}
+	if req.RaidLevel == "single" && len(devices) != 1 {
+		return "", v1.WithDetail(v1.ErrInvalidZFSPoolSpec, "raid level single accepts exactly one device")
+	}
+	if req.RaidLevel == "raid10" && len(devices)%2 != 0 {
+		return "", v1.WithDetail(v1.ErrInvalidZFSPoolSpec, "raid level raid10 requires an even number of devices")
+	}
+
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, nodeID)
+	if err != nil {
+		return "", err
+	}
+
+	pools, err := client.GetNodeDisksZFS(ctx, node.NodeName)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list zfs pools", zap.String("node", node.NodeName), zap.Error(err))
+		return "", v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	for _, pool := range pools {
+		if name, _ := pool["name"].(string); name == req.Name {
+			return "", v1.WithDetailf(v1.ErrInvalidZFSPoolSpec, "pool %s already exists on node %s", req.Name, node.NodeName)
+		}
+	}
+	if err := checkUnusedDisks(ctx, client, node.NodeName, devices); err != nil {
+		return "", err
+	}
+
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "node.zfs_create",
+		Target:    node.NodeName + "/" + req.Name,
+		ClusterID: node.ClusterID,
+	}); err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("name", req.Name)
+	params.Set("raidlevel", req.RaidLevel)
+	params.Set("devices", strings.Join(devices, ","))
+	ashift := 12
+	if req.Ashift != nil {
+		ashift = *req.Ashift
+	}
+	params.Set("ashift", strconv.Itoa(ashift))
+	if req.Compression != "" {
+		params.Set("compression", req.Compression)
+	}
+	if req.AddStorage {
+		params.Set("add_storage", "1")
+	}
+
+	upid, err := client.CreateNodeZFSPool(ctx, node.NodeName, params)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create zfs pool",
+			zap.String("node", node.NodeName), zap.String("pool", req.Name), zap.Error(err))
+		return "", v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	s.logger.WithContext(ctx).Info("zfs pool creation started",
+		zap.String("node", node.NodeName), zap.String("pool", req.Name),
+		zap.String("raid_level", req.RaidLevel), zap.Strings("devices", devices),
+		zap.String("upid", upid), zap.String("operator", username))
+	return upid, nil
+}
+
+func (s *nodeZFSService) GetPool(ctx context.Context, nodeID int64, name string) (*v1.ZFSPoolDetail, error) {
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 存储池不存在时 Proxmox 只返回笼统的 500，先从列表中确认
+	pools, err := client.GetNodeDisksZFS(ctx, node.NodeName)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list zfs pools", zap.String("node", node.NodeName), zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	found := false
+	for _, pool := range pools {
+		if n, _ := pool["name"].(string); n == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, v1.WithDetailf(v1.ErrZFSPoolNotFound, "node=%s, pool=%s", node.NodeName, name)
+	}
+
+	raw, err := client.GetNodeZFSPoolDetail(ctx, node.NodeName, name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get zfs pool detail",
+			zap.String("node", node.NodeName), zap.String("pool", name), zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	detail := parseZFSPoolDetail(raw)
+	detail.NodeID = node.Id
+	detail.NodeName = node.NodeName
+	if detail.Name == "" {
+		detail.Name = name
+	}
+	return detail, nil
+}
+
+func (s *nodeZFSService) ListDatasets(ctx context.Context, nodeID int64) ([]v1.ZFSDataset, error) {
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := client.ScanNodeZFS(ctx, node.NodeName)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to scan zfs datasets", zap.String("node", node.NodeName), zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	list := make([]v1.ZFSDataset, 0, len(items))
+	for _, item := range items {
+		name, _ := item["pool"].(string)
+		if name == "" {
+			continue
+		}
+		pool, _, _ := strings.Cut(name, "/")
+		list = append(list, v1.ZFSDataset{Name: name, Pool: pool})
+	}
+	return list, nil
+}
+
+func (s *nodeZFSService) ListAlerts(ctx context.Context, req *v1.ListZFSPoolAlertsRequest) (*v1.ListZFSPoolAlertsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	alerts, total, err := s.alertRepo.List(ctx, page, pageSize, req.ClusterID, req.NodeID, req.Open)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list zfs pool alerts", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.ZFSPoolAlertItem, 0, len(alerts))
+	for _, a := range alerts {
+		list = append(list, v1.ZFSPoolAlertItem{
+			Id:             a.Id,
+			ClusterID:      a.ClusterID,
+			NodeID:         a.NodeID,
+			NodeName:       a.NodeName,
+			Pool:           a.Pool,
+			Reasons:        a.Reasons,
+			Health:         a.Health,
+			ReadErrors:     a.ReadErrors,
+			WriteErrors:    a.WriteErrors,
+			ChecksumErrors: a.ChecksumErrors,
+			LastScrubTime:  a.LastScrubTime,
+			Message:        a.Message,
+			ResolvedAt:     a.ResolvedAt,
+			CreateTime:     a.CreateTime,
+			UpdateTime:     a.UpdateTime,
+		})
+	}
+	return &v1.ListZFSPoolAlertsResponseData{Total: total, List: list}, nil
+}
+
+func (s *nodeZFSService) CheckHealth(ctx context.Context) error {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, cluster := range clusters {
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to create proxmox client", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			failed++
+			continue
+		}
+		nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return err
+		}
+		for _, node := range nodes {
+			// 离线节点无法查询，保留已有告警
+			if node.Status != "online" {
+				continue
+			}
+			if err := s.checkNode(ctx, client, node); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to check zfs pools",
+					zap.String("cluster", cluster.ClusterName), zap.String("node", node.NodeName), zap.Error(err))
+				failed++
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d node(s) failed zfs health check", failed)
+	}
+	return nil
+}
+
+// checkNode 检查节点上的所有存储池并更新告警，单个存储池查询失败时整个节点本轮不做恢复判断
+func (s *nodeZFSService) checkNode(ctx context.Context, client *proxmox.ProxmoxClient, node *model.PveNode) error {
+	pools, err := client.GetNodeDisksZFS(ctx, node.NodeName)
+	if err != nil {
+		return err
+	}
+
+	scrubMaxAge := s.conf.GetDuration("node_zfs.scrub_max_age")
+	now := time.Now()
+	unhealthy := make(map[string]bool)
+	for _, pool := range pools {
+		name, _ := pool["name"].(string)
+		if name == "" {
+			continue
+		}
+		raw, err := client.GetNodeZFSPoolDetail(ctx, node.NodeName, name)
+		if err != nil {
+			return fmt.Errorf("get pool %s: %w", name, err)
+		}
+		detail := parseZFSPoolDetail(raw)
+		health, _ := pool["health"].(string)
+		if health == "" {
+			health = detail.State
+		}
+
+		var reasons, messages []string
+		if health != "ONLINE" {
+			reasons = append(reasons, model.ZFSPoolAlertReasonHealth)
+			messages = append(messages, fmt.Sprintf("pool state is %s", health))
+			if detail.Status != "" {
+				messages = append(messages, detail.Status)
+			}
+		}
+		if detail.ReadErrors+detail.WriteErrors+detail.ChecksumErrors > 0 {
+			reasons = append(reasons, model.ZFSPoolAlertReasonErrors)
+			messages = append(messages, fmt.Sprintf("device errors: read=%d write=%d cksum=%d",
+				detail.ReadErrors, detail.WriteErrors, detail.ChecksumErrors))
+		}
+		// 从未 scrub 过（如新建的存储池）时无法判断，不告警
+		if scrubMaxAge > 0 && !detail.ScrubInProgress && detail.LastScrubTime != nil && now.Sub(*detail.LastScrubTime) > scrubMaxAge {
+			reasons = append(reasons, model.ZFSPoolAlertReasonScrub)
+			messages = append(messages, fmt.Sprintf("last scrub finished at %s", detail.LastScrubTime.Format(time.RFC3339)))
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		unhealthy[name] = true
+
+		alert, err := s.alertRepo.GetOpen(ctx, node.Id, name)
+		if err != nil {
+			return err
+		}
+		notify := alert == nil
+		if alert == nil {
+			alert = &model.ZFSPoolAlert{ClusterID: node.ClusterID, NodeID: node.Id, Pool: name}
+		} else if alert.Reasons != strings.Join(reasons, ",") {
+			// 出现新的异常原因时重新通知
+			notify = true
+		}
+		alert.NodeName = node.NodeName
+		alert.Reasons = strings.Join(reasons, ",")
+		alert.Health = health
+		alert.ReadErrors = detail.ReadErrors
+		alert.WriteErrors = detail.WriteErrors
+		alert.ChecksumErrors = detail.ChecksumErrors
+		alert.LastScrubTime = detail.LastScrubTime
+		alert.Message = strings.Join(messages, "; ")
+		if err := s.alertRepo.Save(ctx, alert); err != nil {
+			return err
+		}
+		if notify {
+			s.logger.WithContext(ctx).Warn("zfs pool unhealthy",
+				zap.String("node", node.NodeName), zap.String("pool", name),
+				zap.String("reasons", alert.Reasons), zap.String("message", alert.Message))
+			s.notifyZFSPoolAlert(ctx, alert)
+			s.notificationService.NotifyAdmins(ctx, &model.Notification{
+				Category:   model.NotificationCategoryAlert,
+				Level:      zfsAlertLevel(alert),
+				Event:      "zfs_pool_unhealthy",
+				Title:      fmt.Sprintf("ZFS pool %s on %s is unhealthy", alert.Pool, alert.NodeName),
+				Content:    alert.Message,
+				TargetType: "node",
+				TargetID:   strconv.FormatInt(alert.NodeID, 10),
+			})
+		}
+	}
+
+	// 恢复健康或已被销毁的存储池关闭告警
+	open, err := s.alertRepo.ListOpenByNode(ctx, node.Id)
+	if err != nil {
+		return err
+	}
+	for _, alert := range open {
+		if unhealthy[alert.Pool] {
+			continue
+		}
+		alert.ResolvedAt = &now
+		if err := s.alertRepo.Save(ctx, alert); err != nil {
+			return err
+		}
+		s.logger.WithContext(ctx).Info("zfs pool alert resolved",
+			zap.String("node", node.NodeName), zap.String("pool", alert.Pool))
+	}
+	return nil
+}
+
+// notifyZFSPoolAlert 配置了 node_zfs.alert_webhook 时以 JSON POST 推送告警，失败只记录日志
+func (s *nodeZFSService) notifyZFSPoolAlert(ctx context.Context, alert *model.ZFSPoolAlert) {
+	webhook := s.conf.GetString("node_zfs.alert_webhook")
+	if webhook == "" {
+		return
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event":           "zfs_pool_unhealthy",
+		"cluster_id":      alert.ClusterID,
+		"node_id":         alert.NodeID,
+		"node_name":       alert.NodeName,
+		"pool":            alert.Pool,
+		"reasons":         alert.Reasons,
+		"health":          alert.Health,
+		"read_errors":     alert.ReadErrors,
+		"write_errors":    alert.WriteErrors,
+		"checksum_errors": alert.ChecksumErrors,
+		"message":         alert.Message,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to build zfs alert webhook request", zap.Error(err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to send zfs alert webhook", zap.Error(err))
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.logger.WithContext(ctx).Error("zfs alert webhook returned error status", zap.Int("status", resp.StatusCode))
+	}
+}
+
+// parseZFSPoolDetail 解析 zpool status 结果，错误计数取所有叶子设备（磁盘）之和
+func parseZFSPoolDetail(raw map[string]interface{}) *v1.ZFSPoolDetail {
+	str := func(key string) string {
+		v, _ := raw[key].(string)
+		return strings.TrimSpace(v)
+	}
+	detail := &v1.ZFSPoolDetail{
+		Name:   str("name"),
+		State:  str("state"),
+		Status: str("status"),
+		Action: str("action"),
+		Errors: str("errors"),
+		Scan:   str("scan"),
+	}
+	detail.LastScrubTime, detail.ScrubInProgress = parseZFSScan(detail.Scan)
+
+	children, _ := raw["children"].([]interface{})
+	detail.Devices = parseZFSDevices(children)
+	var sum func(devices []v1.ZFSPoolDevice)
+	sum = func(devices []v1.ZFSPoolDevice) {
+		for _, dev := range devices {
+			if len(dev.Children) > 0 {
+				sum(dev.Children)
+				continue
+			}
+			detail.ReadErrors += dev.ReadErrors
+			detail.WriteErrors += dev.WriteErrors
+			detail.ChecksumErrors += dev.ChecksumErrors
+		}
+	}
+	sum(detail.Devices)
+	return detail
+}
+
+func parseZFSDevices(items []interface{}) []v1.ZFSPoolDevice {
+	devices := make([]v1.ZFSPoolDevice, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := m["name"].(string)
+		state, _ := m["state"].(string)
+		msg, _ := m["msg"].(string)
+		dev := v1.ZFSPoolDevice{
+			Name:           name,
+			State:          state,
+			ReadErrors:     zfsErrorCount(m["read"]),
+			WriteErrors:    zfsErrorCount(m["write"]),
+			ChecksumErrors: zfsErrorCount(m["cksum"]),
+			Message:        strings.TrimSpace(msg),
+		}
+		if children, ok := m["children"].([]interface{}); ok {
+			dev.Children = parseZFSDevices(children)
+		}
+		devices = append(devices, dev)
+	}
+	return devices
+}
+
+// zfsErrorCount 错误计数可能是数字，也可能是 zpool 输出的字符串（如 "0"、"1.2K"）
+func zfsErrorCount(v interface{}) int64 {
+	switch val := v.(type) {
+	case float64:
+		return int64(val)
+	case string:
+		val = strings.TrimSpace(val)
+		if val == "" {
+			return 0
+		}
+		multiplier := float64(1)
+		switch val[len(val)-1] {
+		case 'K':
+			multiplier = 1e3
+		case 'M':
+			multiplier = 1e6
+		}
+		if multiplier > 1 {
+			val = val[:len(val)-1]
+		}
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return 0
+		}
+		return int64(f * multiplier)
+	}
+	return 0
+}
+
+// parseZFSScan 从 scan 说明中解析最近一次完成 scrub 的时间，如
+// "scrub repaired 0B in 00:00:05 with 0 errors on Sun Oct  8 00:24:06 2023"。
+// zpool 输出的是节点本地时间，这里按平台时区解析
+func parseZFSScan(scan string) (*time.Time, bool) {
+	if strings.HasPrefix(scan, "scrub in progress") {
+		return nil, true
+	}
+	if !strings.HasPrefix(scan, "scrub repaired") {
+		return nil, false
+	}
+	idx := strings.LastIndex(scan, " on ")
+	if idx < 0 {
+		return nil, false
+	}
+	value := strings.Join(strings.Fields(scan[idx+len(" on "):]), " ")
+	t, err := time.ParseInLocation("Mon Jan 2 15:04:05 2006", value, time.Local)
+	if err != nil {
+		return nil, false
+	}
+	return &t, false
+}
+
+// zfsAlertLevel 存储池不是 ONLINE 时为 critical，仅有错误计数或 scrub 过期时为 warning
+func zfsAlertLevel(alert *model.ZFSPoolAlert) string {
+	if alert.Health != "" && alert.Health != "ONLINE" {
+		return model.NotificationLevelCritical
+	}
+	return model.NotificationLevelWarning
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 notification.retention_days 时的默认通知保留天数
+const defaultNotificationRetentionDays = 30
+
+// NotificationService 站内通知：任务结束、等待审核、告警触发、定期报告发送时写入接收人的收件箱，供前端铃铛图标展示
+type NotificationService interface {
+	// Notify 向指定用户（用户名）发送通知，写入失败只记录日志，不影响调用方
+	Notify(ctx context.Context, n *model.Notification, recipients ...string)
+	// NotifyAdmins 向 security.admin_users 中的管理员以及 also 中的用户发送通知
+	NotifyAdmins(ctx context.Context, n *model.Notification, also ...string)
+
+	List(ctx context.Context, userID string, req *v1.ListNotificationsRequest) (*v1.ListNotificationsResponseData, error)
+	UnreadCount(ctx context.Context, userID string) (*v1.NotificationUnreadCount, error)
+	MarkRead(ctx context.Context, userID string, req *v1.MarkNotificationsReadRequest) (*v1.MarkNotificationsReadData, error)
+	Delete(ctx context.Context, userID string, id int64) error
+	// Cleanup 删除超过保留期的通知，返回删除数量
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+func NewNotificationService(
+	service *Service,
+	conf *viper.Viper,
+	notificationRepo repository.NotificationRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) NotificationService {
+	return &notificationService{
+		Service:          service,
+		conf:             conf,
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		logger:           logger,
+	}
+}
+
+type notificationService struct {
+	*Service
+	conf             *viper.Viper
+	notificationRepo repository.NotificationRepository
+	userRepo         repository.UserRepository
+	logger           *log.Logger
+}
+
+func (s *notificationService) Notify(ctx context.Context, n *model.Notification, recipients ...string) {
+	if n.Level == "" {
+		n.Level = model.NotificationLevelInfo
+	}
+
+	seen := make(map[string]bool, len(recipients))
+	notifications := make([]*model.Notification, 0, len(recipients))
+	for _, recipient := range recipients {
+		if recipient == "" || seen[recipient] {
+			continue
+		}
+		seen[recipient] = true
+		item := *n
+		item.Id = 0
+		item.Recipient = recipient
+		notifications = append(notifications, &item)
+	}
+
+	if err := s.notificationRepo.CreateBatch(ctx, notifications); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create notifications",
+			zap.String("event", n.Event), zap.Strings("recipients", recipients), zap.Error(err))
+	}
+}
+
+func (s *notificationService) NotifyAdmins(ctx context.Context, n *model.Notification, also ...string) {
+	admins := adminUsernames(s.conf)
+	recipients := make([]string, 0, len(admins)+len(also))
+	recipients = append(recipients, admins...)
+	s.Notify(ctx, n, append(recipients, also...)...)
+}
+
+func (s *notificationService) List(ctx context.Context, userID string, req *v1.ListNotificationsRequest) (*v1.ListNotificationsResponseData, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	notifications, total, err := s.notificationRepo.List(ctx, page, pageSize, username, req.Category, req.Unread)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list notifications", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	unread, err := s.countUnread(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]v1.NotificationItem, 0, len(notifications))
+	for _, n := range notifications {
+		list = append(list, toNotificationItem(n))
+	}
+	return &v1.ListNotificationsResponseData{
+		Total:  total,
+		Unread: unread.Total,
+		List:   list,
+	}, nil
+}
+
+func (s *notificationService) UnreadCount(ctx context.Context, userID string) (*v1.NotificationUnreadCount, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.countUnread(ctx, username)
+}
+
+func (s *notificationService) countUnread(ctx context.Context, username string) (*v1.NotificationUnreadCount, error) {
+	counts, err := s.notificationRepo.CountUnread(ctx, username)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to count unread notifications", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	data := &v1.NotificationUnreadCount{
+		ByCategory: map[string]int64{
+			model.NotificationCategoryTask:     0,
+			model.NotificationCategoryApproval: 0,
+			model.NotificationCategoryAlert:    0,
+			model.NotificationCategoryReport:   0,
+		},
+	}
+	for category, count := range counts {
+		data.ByCategory[category] = count
+		data.Total += count
+	}
+	return data, nil
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, userID string, req *v1.MarkNotificationsReadRequest) (*v1.MarkNotificationsReadData, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只会更新当前用户自己的通知，其他用户的 ID 会被忽略
+	updated, err := s.notificationRepo.MarkRead(ctx, username, req.IDs, req.Category)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to mark notifications read", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	unread, err := s.countUnread(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.MarkNotificationsReadData{
+		Updated: updated,
+		Unread:  *unread,
+	}, nil
+}
+
+func (s *notificationService) Delete(ctx context.Context, userID string, id int64) error {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return err
+	}
+
+	notification, err := s.notificationRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get notification", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if notification == nil || notification.Recipient != username {
+		return v1.ErrNotificationNotFound
+	}
+	if err := s.notificationRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete notification", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *notificationService) Cleanup(ctx context.Context) (int64, error) {
+	retentionDays := s.conf.GetInt("notification.retention_days")
+	if retentionDays <= 0 {
+		retentionDays = defaultNotificationRetentionDays
+	}
+	return s.notificationRepo.DeleteBefore(ctx, time.Now().AddDate(0, 0, -retentionDays))
+}
+
+// taskFinishedNotification 异步任务结束的通知，失败时为 warning 级别并附带错误信息
+func taskFinishedNotification(targetType string, targetID int64, subject string, err error) *model.Notification {
+	n := &model.Notification{
+		Category:   model.NotificationCategoryTask,
+		Level:      model.NotificationLevelInfo,
+		Event:      targetType + "_completed",
+		Title:      subject + " completed",
+		TargetType: targetType,
+		TargetID:   strconv.FormatInt(targetID, 10),
+	}
+	if err != nil {
+		n.Level = model.NotificationLevelWarning
+		n.Event = targetType + "_failed"
+		n.Title = subject + " failed"
+		n.Content = err.Error()
+	}
+	return n
+}
+
+func toNotificationItem(n *model.Notification) v1.NotificationItem {
+	return v1.NotificationItem{
+		Id:         n.Id,
+		Category:   n.Category,
+		Level:      n.Level,
+		Event:      n.Event,
+		Title:      n.Title,
+		Content:    n.Content,
+		TargetType: n.TargetType,
+		TargetID:   n.TargetID,
+		Read:       n.ReadAt != nil,
+		ReadAt:     n.ReadAt,
+		CreateTime: n.CreateTime,
+	}
+}
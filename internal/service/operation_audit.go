@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultOperationAuditRetentionDays 未配置 operation_audit.retention_days 时的默认保留天数
+	defaultOperationAuditRetentionDays = 30
+	// operationAuditCleanupInterval 写入记录时顺带清理过期记录的最小间隔
+	operationAuditCleanupInterval = time.Hour
+)
+
+// OperationRecord 一次变更请求的信息，由中间件在请求结束时提交
+type OperationRecord struct {
+	OperationID string
+	Method      string
+	Route       string
+	Path        string
+	StatusCode  int
+	UserID      string
+	Duration    time.Duration
+	Calls       *proxmox.CallRecorder
+}
+
+// OperationAuditService 记录变更请求期间调用的 Proxmox API 及启动的任务（UPID），
+// 没有调用 Proxmox 的请求不记录
+type OperationAuditService interface {
+	Record(ctx context.Context, record *OperationRecord)
+	Get(ctx context.Context, operationID string) (*v1.OperationAuditItem, error)
+	List(ctx context.Context, req *v1.ListOperationAuditsRequest) (*v1.ListOperationAuditsResponseData, error)
+}
+
+func NewOperationAuditService(
+	service *Service,
+	conf *viper.Viper,
+	auditRepo repository.OperationAuditRepository,
+	userRepo repository.UserRepository,
+) OperationAuditService {
+	return &operationAuditService{
+		Service:   service,
+		conf:      conf,
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+	}
+}
+
+type operationAuditService struct {
+	*Service
+	conf      *viper.Viper
+	auditRepo repository.OperationAuditRepository
+	userRepo  repository.UserRepository
+
+	mu          sync.Mutex
+	lastCleanup time.Time
+}
+
+func (s *operationAuditService) enabled() bool {
+	if !s.conf.IsSet("operation_audit.enabled") {
+		return true
+	}
+	return s.conf.GetBool("operation_audit.enabled")
+}
+
+func (s *operationAuditService) Record(ctx context.Context, record *OperationRecord) {
+	if record.Calls == nil || !s.enabled() {
+		return
+	}
+	calls, dropped := record.Calls.Calls()
+	if len(calls) == 0 {
+		return
+	}
+	// 请求上下文在响应后被回收，保存在后台进行
+	go s.save(record, calls, dropped)
+}
+
+func (s *operationAuditService) save(record *OperationRecord, calls []proxmox.CallRecord, dropped int) {
+	ctx := context.Background()
+	username := ""
+	if record.UserID != "" {
+		if user, err := s.userRepo.GetByID(ctx, record.UserID); err == nil && user != nil {
+			username = user.Username
+		}
+	}
+
+	callCount := dropped
+	tasks := make([]string, 0)
+	for _, call := range calls {
+		callCount += call.Count
+		if call.UPID != "" {
+			tasks = append(tasks, call.UPID)
+		}
+	}
+	tasksJSON, _ := json.Marshal(tasks)
+	callsJSON, _ := json.Marshal(calls)
+
+	audit := &model.OperationAudit{
+		OperationID:  record.OperationID,
+		Method:       record.Method,
+		Route:        record.Route,
+		Path:         record.Path,
+		StatusCode:   record.StatusCode,
+		Username:     username,
+		DurationMs:   record.Duration.Milliseconds(),
+		CallCount:    callCount,
+		DroppedCalls: dropped,
+		TaskCount:    len(tasks),
+		Tasks:        string(tasksJSON),
+		Calls:        string(callsJSON),
+	}
+	if err := s.auditRepo.Create(ctx, audit); err != nil {
+		s.logger.Error("failed to save operation audit", zap.String("operation_id", record.OperationID), zap.Error(err))
+		return
+	}
+	s.cleanup(ctx)
+}
+
+// cleanup 删除超过 operation_audit.retention_days 的记录，每小时最多执行一次
+func (s *operationAuditService) cleanup(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastCleanup) < operationAuditCleanupInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastCleanup = time.Now()
+	s.mu.Unlock()
+
+	retentionDays := s.conf.GetInt("operation_audit.retention_days")
+	if retentionDays <= 0 {
+		retentionDays = defaultOperationAuditRetentionDays
+	}
+	removed, err := s.auditRepo.DeleteBefore(ctx, time.Now().AddDate(0, 0, -retentionDays))
+	if err != nil {
+		s.logger.Error("cleanup operation audits failed", zap.Error(err))
+		return
+	}
+	if removed > 0 {
+		s.logger.Info("expired operation audits removed", zap.Int64("count", removed))
+	}
+}
+
+// viewer 返回当前用户名，非管理员时 own 为 true（只能查看自己的操作）
+func (s *operationAuditService) viewer(ctx context.Context) (username string, own bool, err error) {
+	return operationViewer(ctx, s.conf, s.userRepo, s.logger)
+}
+
+// operationViewer 操作记录的查看范围：管理员可以查看所有人的操作，其他用户只能查看自己的（own 为 true）
+func operationViewer(ctx context.Context, conf *viper.Viper, userRepo repository.UserRepository, logger *log.Logger) (username string, own bool, err error) {
+	userID := userIDFromCtx(ctx)
+	username, err = requireAdminUser(ctx, conf, userRepo, logger, userID)
+	if err == nil {
+		return username, false, nil
+	}
+	if !errors.Is(err, v1.ErrAdminRequired) {
+		return "", false, err
+	}
+	user, err := userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return "", false, v1.ErrUnauthorized
+	}
+	return user.Username, true, nil
+}
+
+func (s *operationAuditService) Get(ctx context.Context, operationID string) (*v1.OperationAuditItem, error) {
+	username, own, err := s.viewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	audit, err := s.auditRepo.GetByOperationID(ctx, operationID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get operation audit", zap.String("operation_id", operationID), zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if audit == nil || (own && audit.Username != username) {
+		return nil, v1.ErrOperationAuditNotFound
+	}
+
+	item := toOperationAuditItem(audit)
+	var calls []proxmox.CallRecord
+	if err := json.Unmarshal([]byte(audit.Calls), &calls); err == nil {
+		item.Calls = make([]v1.ProxmoxCallItem, 0, len(calls))
+		for _, call := range calls {
+			item.Calls = append(item.Calls, v1.ProxmoxCallItem{
+				Method:     call.Method,
+				Host:       call.Host,
+				Path:       call.Path,
+				Status:     call.Status,
+				Error:      call.Error,
+				DurationMs: call.DurationMs,
+				UPID:       call.UPID,
+				Count:      call.Count,
+				StartTime:  call.StartTime,
+			})
+		}
+	}
+	return &item, nil
+}
+
+func (s *operationAuditService) List(ctx context.Context, req *v1.ListOperationAuditsRequest) (*v1.ListOperationAuditsResponseData, error) {
+	username, own, err := s.viewer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	filter := repository.OperationAuditFilter{
+		Username:   strings.TrimSpace(req.Username),
+		Method:     req.Method,
+		PathPrefix: strings.TrimSpace(req.Path),
+		UPID:       strings.TrimSpace(req.UPID),
+		HasTasks:   req.HasTasks,
+	}
+	if own {
+		filter.Username = username
+	}
+
+	audits, total, err := s.auditRepo.List(ctx, page, pageSize, filter)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list operation audits", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.OperationAuditItem, 0, len(audits))
+	for _, audit := range audits {
+		list = append(list, toOperationAuditItem(audit))
+	}
+	return &v1.ListOperationAuditsResponseData{Total: total, List: list}, nil
+}
+
+func toOperationAuditItem(audit *model.OperationAudit) v1.OperationAuditItem {
+	tasks := make([]string, 0, audit.TaskCount)
+	_ = json.Unmarshal([]byte(audit.Tasks), &tasks)
+	return v1.OperationAuditItem{
+		Id:           audit.Id,
+		OperationID:  audit.OperationID,
+		Method:       audit.Method,
+		Route:        audit.Route,
+		Path:         audit.Path,
+		StatusCode:   audit.StatusCode,
+		Username:     audit.Username,
+		DurationMs:   audit.DurationMs,
+		CallCount:    audit.CallCount,
+		DroppedCalls: audit.DroppedCalls,
+		Tasks:        tasks,
+		CreateTime:   audit.CreateTime,
+	}
+}
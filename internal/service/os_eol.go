@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const osEOLSoonWindow = 90 * 24 * time.Hour
+
+// osEOLRule 识别操作系统版本的规则，用于模板名称 / 描述以及 guest agent 上报的 osinfo
+type osEOLRule struct {
+	Name  string `mapstructure:"name"`
+	Match string `mapstructure:"match"` // 正则，不区分大小写
+	EOL   string `mapstructure:"eol"`   // YYYY-MM-DD
+
+	re  *regexp.Regexp
+	eol time.Time
+}
+
+// status 返回 now 时刻的停止维护状态：eol / eol_soon，仍在维护期内返回空
+func (r *osEOLRule) status(now time.Time) string {
+	switch {
+	case !now.Before(r.eol):
+		return v1.OSEOLStatusEOL
+	case r.eol.Sub(now) <= osEOLSoonWindow:
+		return v1.OSEOLStatusEOLSoon
+	}
+	return ""
+}
+
+// defaultOSEOLRules 内置的常见发行版停止维护日期（标准支持结束），可通过 os_eol.rules 补充或覆盖。
+// 按顺序匹配，更具体的规则（如 CentOS Stream）需排在前面
+var defaultOSEOLRules = []osEOLRule{
+	{Name: "Ubuntu 14.04", Match: `ubuntu[-_ ]?14\.?04|trusty`, EOL: "2019-04-30"},
+	{Name: "Ubuntu 16.04", Match: `ubuntu[-_ ]?16\.?04|xenial`, EOL: "2021-04-30"},
+	{Name: "Ubuntu 18.04", Match: `ubuntu[-_ ]?18\.?04|bionic`, EOL: "2023-05-31"},
+	{Name: "Ubuntu 20.04", Match: `ubuntu[-_ ]?20\.?04|focal`, EOL: "2025-05-31"},
+	{Name: "Ubuntu 22.04", Match: `ubuntu[-_ ]?22\.?04|jammy`, EOL: "2027-06-01"},
+	{Name: "Ubuntu 24.04", Match: `ubuntu[-_ ]?24\.?04|noble`, EOL: "2029-05-31"},
+	{Name: "CentOS Stream 8", Match: `centos[-_ ]?stream[-_ ]?8(\D|$)`, EOL: "2024-05-31"},
+	{Name: "CentOS Stream 9", Match: `centos[-_ ]?stream[-_ ]?9(\D|$)`, EOL: "2027-05-31"},
+	{Name: "CentOS 6", Match: `centos[-_ ]?(linux[-_ ]?)?6(\D|$)`, EOL: "2020-11-30"},
+	{Name: "CentOS 7", Match: `centos[-_ ]?(linux[-_ ]?)?7(\D|$)`, EOL: "2024-06-30"},
+	{Name: "CentOS 8", Match: `centos[-_ ]?(linux[-_ ]?)?8(\D|$)`, EOL: "2021-12-31"},
+	{Name: "RHEL 7", Match: `(rhel|redhat)[-_ ]?7(\D|$)`, EOL: "2024-06-30"},
+	{Name: "Debian 8", Match: `debian[-_ ]?8(\D|$)|jessie`, EOL: "2020-06-30"},
+	{Name: "Debian 9", Match: `debian[-_ ]?9(\D|$)|stretch`, EOL: "2022-06-30"},
+	{Name: "Debian 10", Match: `debian[-_ ]?10(\D|$)|buster`, EOL: "2024-06-30"},
+	{Name: "Debian 11", Match: `debian[-_ ]?11(\D|$)|bullseye`, EOL: "2026-08-31"},
+	{Name: "Debian 12", Match: `debian[-_ ]?12(\D|$)|bookworm`, EOL: "2028-06-30"},
+	{Name: "Windows Server 2008", Match: `(win|windows)[-_ ]?(server)?[-_ ]?2008`, EOL: "2020-01-14"},
+	{Name: "Windows Server 2012", Match: `(win|windows)[-_ ]?(server)?[-_ ]?2012`, EOL: "2023-10-10"},
+	{Name: "Windows Server 2016", Match: `(win|windows)[-_ ]?(server)?[-_ ]?2016`, EOL: "2027-01-12"},
+	{Name: "Windows Server 2019", Match: `(win|windows)[-_ ]?(server)?[-_ ]?2019`, EOL: "2029-01-09"},
+	{Name: "Windows 7", Match: `(win|windows)[-_ ]?7(\D|$)`, EOL: "2020-01-14"},
+	{Name: "Windows 10", Match: `(win|windows)[-_ ]?10(\D|$)`, EOL: "2025-10-14"},
+}
+
+// loadOSEOLRules 配置的规则优先匹配（os_eol.rules，其后是兼容旧配置的 template_usage.eol_os），最后是内置规则；
+// 无效的规则忽略并记录日志
+func loadOSEOLRules(conf *viper.Viper, logger *log.Logger) []osEOLRule {
+	var configured []osEOLRule
+	for _, key := range []string{"os_eol.rules", "template_usage.eol_os"} {
+		var rules []osEOLRule
+		if err := conf.UnmarshalKey(key, &rules); err != nil {
+			logger.Warn("invalid os eol rules, ignored", zap.String("key", key), zap.Error(err))
+			continue
+		}
+		configured = append(configured, rules...)
+	}
+	rules := make([]osEOLRule, 0, len(configured)+len(defaultOSEOLRules))
+	for _, r := range append(configured, defaultOSEOLRules...) {
+		re, err := regexp.Compile("(?i)" + r.Match)
+		if err != nil || r.Match == "" {
+			logger.Warn("invalid os eol rule", zap.String("name", r.Name), zap.String("match", r.Match), zap.Error(err))
+			continue
+		}
+		eol, err := time.Parse("2006-01-02", r.EOL)
+		if err != nil {
+			logger.Warn("invalid os eol date", zap.String("name", r.Name), zap.String("eol", r.EOL), zap.Error(err))
+			continue
+		}
+		r.re, r.eol = re, eol
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// matchOSEOL 返回第一条匹配任一文本的规则，规则顺序即优先级
+func matchOSEOL(rules []osEOLRule, texts ...string) *osEOLRule {
+	for i := range rules {
+		for _, text := range texts {
+			if strings.TrimSpace(text) != "" && rules[i].re.MatchString(text) {
+				return &rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+// matchOSInfoEOL 按 guest agent 上报的 osinfo 匹配规则：pretty-name、name + version-id、id + version-id
+func matchOSInfoEOL(rules []osEOLRule, info *model.VMOSInfo) *osEOLRule {
+	return matchOSEOL(rules,
+		info.PrettyName,
+		info.Name+" "+info.Version,
+		info.OSID+" "+info.Version,
+	)
+}
+
+// osEOLStatus 根据已保存的停止维护日期计算状态
+func osEOLStatus(eolDate *time.Time, now time.Time) string {
+	if eolDate == nil {
+		return ""
+	}
+	rule := osEOLRule{eol: *eolDate}
+	return rule.status(now)
+}
+
+// osEOLFilter 虚拟机列表 os_eol 过滤条件对应的停止维护日期区间
+func osEOLFilter(status string, now time.Time) (after, before *time.Time) {
+	soon := now.Add(osEOLSoonWindow)
+	switch status {
+	case v1.OSEOLStatusEOL:
+		return nil, &now
+	case v1.OSEOLStatusEOLSoon:
+		return &now, &soon
+	}
+	return nil, nil
+}
+
+// OSEOLService 操作系统停止维护合规报告，数据来自许可证采集器保存的 vm_os_info
+type OSEOLService interface {
+	Report(ctx context.Context, req *v1.OSEOLReportRequest) (*v1.OSEOLReportData, error)
+}
+
+func NewOSEOLService(
+	service *Service,
+	licenseRepo repository.LicenseRepository,
+	vmRepo repository.PveVMRepository,
+	clusterRepo repository.PveClusterRepository,
+	logger *log.Logger,
+) OSEOLService {
+	return &osEOLService{
+		Service:     service,
+		licenseRepo: licenseRepo,
+		vmRepo:      vmRepo,
+		clusterRepo: clusterRepo,
+		logger:      logger,
+	}
+}
+
+type osEOLService struct {
+	*Service
+	licenseRepo repository.LicenseRepository
+	vmRepo      repository.PveVMRepository
+	clusterRepo repository.PveClusterRepository
+	logger      *log.Logger
+}
+
+func (s *osEOLService) Report(ctx context.Context, req *v1.OSEOLReportRequest) (*v1.OSEOLReportData, error) {
+	var clusters []*model.PveCluster
+	if req.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err), zap.Int64("cluster_id", req.ClusterID))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return nil, v1.ErrClusterNotFound
+		}
+		clusters = []*model.PveCluster{cluster}
+	} else {
+		var err error
+		if clusters, err = s.clusterRepo.List(ctx); err != nil {
+			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+	}
+
+	now := time.Now()
+	data := &v1.OSEOLReportData{
+		GeneratedAt: now,
+		Groups:      make([]*v1.OSEOLReportGroup, 0),
+		VMs:         make([]*v1.OSEOLReportVM, 0),
+	}
+	groups := make(map[string]*v1.OSEOLReportGroup)
+	for _, cluster := range clusters {
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list cluster vms", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			return nil, v1.ErrInternalServerError
+		}
+		ids := make([]int64, 0, len(vms))
+		for _, vm := range vms {
+			if vm.IsTemplate != 1 {
+				ids = append(ids, vm.Id)
+			}
+		}
+		infos, err := s.licenseRepo.ListOSInfoByVmIDs(ctx, ids)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list vm os info", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			return nil, v1.ErrInternalServerError
+		}
+
+		for _, vm := range vms {
+			if vm.IsTemplate == 1 {
+				continue
+			}
+			data.TotalVMs++
+			info := infos[vm.Id]
+			if info == nil {
+				data.Unknown++
+				continue
+			}
+			data.Identified++
+			status := osEOLStatus(info.EOLDate, now)
+			switch status {
+			case v1.OSEOLStatusEOL:
+				data.EOL++
+			case v1.OSEOLStatusEOLSoon:
+				data.EOLSoon++
+			default:
+				continue
+			}
+
+			group := groups[info.EOLName]
+			if group == nil {
+				group = &v1.OSEOLReportGroup{OSName: info.EOLName, EOLDate: *info.EOLDate, Status: status}
+				groups[info.EOLName] = group
+				data.Groups = append(data.Groups, group)
+			}
+			group.VMCount++
+
+			if req.Status != "" && req.Status != status {
+				continue
+			}
+			data.VMs = append(data.VMs, &v1.OSEOLReportVM{
+				Id:          vm.Id,
+				VmName:      vm.VmName,
+				VMID:        vm.VMID,
+				ClusterID:   vm.ClusterID,
+				ClusterName: cluster.ClusterName,
+				NodeName:    vm.NodeName,
+				Status:      vm.Status,
+				Owner:       vm.Owner,
+				Team:        vm.Team,
+				PrettyName:  info.PrettyName,
+				OSName:      info.EOLName,
+				EOLDate:     *info.EOLDate,
+				EOLStatus:   status,
+				CollectTime: info.CollectTime,
+			})
+		}
+	}
+
+	sort.SliceStable(data.Groups, func(i, j int) bool {
+		return data.Groups[i].EOLDate.Before(data.Groups[j].EOLDate)
+	})
+	sort.SliceStable(data.VMs, func(i, j int) bool {
+		if !data.VMs[i].EOLDate.Equal(data.VMs[j].EOLDate) {
+			return data.VMs[i].EOLDate.Before(data.VMs[j].EOLDate)
+		}
+		return data.VMs[i].Id < data.VMs[j].Id
+	})
+	return data, nil
+}
@@ -0,0 +1,549 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 待重试操作默认参数，可通过 pending_operation.* 调整
+const (
+	defaultPendingOperationRetryInterval = time.Minute
+	defaultPendingOperationMaxAttempts   = 60
+	maxPendingOperationBackoff           = 30 * time.Minute
+	pendingOperationBatchSize            = 100
+)
+
+// 支持排队重试的操作
+const (
+	PendingOperationVMConfig    = "vm.config"
+	PendingOperationVMTags      = "vm.tags"
+	PendingOperationVMCloudInit = "vm.cloudinit"
+)
+
+// pendingOperationOutcome 单次执行结果
+type pendingOperationOutcome int
+
+const (
+	pendingOutcomeSucceeded pendingOperationOutcome = iota
+	pendingOutcomeRetry                             // 集群不可达，计入重试次数
+	pendingOutcomeDeferred                          // 维护锁、Proxmox lock 或变更窗口阻止，稍后再试，不计入重试次数
+	pendingOutcomeFailed                            // 不可恢复的错误
+)
+
+// PendingOperationInput 排队的操作
+type PendingOperationInput struct {
+	Operation string
+	ClusterID int64
+	NodeID    int64
+	VM        *model.PveVM // 数据库中的虚拟机记录，未同步时 Id 为 0
+	Payload   interface{}  // vm.config / vm.tags 为配置 map，vm.cloudinit 为 url.Values
+}
+
+// PendingOperationService 集群暂时不可达时排队修改配置、标签和 cloud-init 等操作，连通后自动按顺序重试
+type PendingOperationService interface {
+	// QueueIfUnreachable cause 为集群不可达的错误且启用了重试队列时登记操作，返回 ErrOperationQueued；否则返回 nil，由调用方按原错误处理
+	QueueIfUnreachable(ctx context.Context, input *PendingOperationInput, cause error) error
+	List(ctx context.Context, req *v1.ListPendingOperationsRequest) (*v1.ListPendingOperationsResponseData, error)
+	Get(ctx context.Context, id int64) (*v1.PendingOperationItem, error)
+	// Flush 立即重试，创建人或管理员可操作
+	Flush(ctx context.Context, userID string, id int64) (*v1.PendingOperationItem, error)
+	// FlushCluster 立即按顺序重试集群上当前用户可操作的全部 pending 操作
+	FlushCluster(ctx context.Context, userID string, clusterID int64) (*v1.FlushPendingOperationsData, error)
+	// Cancel 取消，创建人或管理员可操作
+	Cancel(ctx context.Context, userID string, id int64) error
+	// CancelByVM 删除虚拟机时取消其 pending 操作
+	CancelByVM(ctx context.Context, vmID int64)
+	// RetryDue 重试到期的操作，返回处理数量
+	RetryDue(ctx context.Context) (int, error)
+}
+
+func NewPendingOperationService(
+	service *Service,
+	conf *viper.Viper,
+	opRepo repository.PendingOperationRepository,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	changeControl ChangeControlService,
+	vmLock VMLockService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) PendingOperationService {
+	return &pendingOperationService{
+		Service:             service,
+		conf:                conf,
+		opRepo:              opRepo,
+		vmRepo:              vmRepo,
+		nodeRepo:            nodeRepo,
+		clusterRepo:         clusterRepo,
+		userRepo:            userRepo,
+		changeControl:       changeControl,
+		vmLock:              vmLock,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+type pendingOperationService struct {
+	*Service
+	conf                *viper.Viper
+	opRepo              repository.PendingOperationRepository
+	vmRepo              repository.PveVMRepository
+	nodeRepo            repository.PveNodeRepository
+	clusterRepo         repository.PveClusterRepository
+	userRepo            repository.UserRepository
+	changeControl       ChangeControlService
+	vmLock              VMLockService
+	notificationService NotificationService
+	logger              *log.Logger
+
+	// 后台重试与手动重试串行执行，保证同一虚拟机的操作按顺序生效
+	mu sync.Mutex
+}
+
+// isProxmoxUnreachable 判断错误是否为集群暂时不可达（连接失败、超时、代理返回 502/503/504 或 pveproxy 返回 595/596）
+func isProxmoxUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := err.Error()
+	for _, code := range []int{502, 503, 504, 595, 596} {
+		if strings.Contains(msg, fmt.Sprintf("(status %d)", code)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *pendingOperationService) retryInterval() time.Duration {
+	interval := s.conf.GetDuration("pending_operation.retry_interval")
+	if interval <= 0 {
+		interval = defaultPendingOperationRetryInterval
+	}
+	return interval
+}
+
+func (s *pendingOperationService) maxAttempts() int {
+	attempts := s.conf.GetInt("pending_operation.max_attempts")
+	if attempts <= 0 {
+		attempts = defaultPendingOperationMaxAttempts
+	}
+	return attempts
+}
+
+// operator 返回当前用户名以及是否为管理员
+func (s *pendingOperationService) operator(ctx context.Context, userID string) (string, bool, error) {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err == nil {
+		return username, true, nil
+	}
+	if !errors.Is(err, v1.ErrAdminRequired) {
+		return "", false, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get user", zap.Error(err))
+		return "", false, v1.ErrInternalServerError
+	}
+	return user.Username, false, nil
+}
+
+func (s *pendingOperationService) QueueIfUnreachable(ctx context.Context, input *PendingOperationInput, cause error) error {
+	if !s.conf.GetBool("pending_operation.enabled") || !isProxmoxUnreachable(cause) {
+		return nil
+	}
+
+	payload, err := json.Marshal(input.Payload)
+	if err != nil {
+		return nil
+	}
+	creator := ""
+	if userID := userIDFromCtx(ctx); userID != "" {
+		if user, err := s.userRepo.GetByID(ctx, userID); err == nil && user != nil {
+			creator = user.Username
+		}
+	}
+	next := time.Now().Add(s.retryInterval())
+	op := &model.PendingOperation{
+		ClusterID:     input.ClusterID,
+		NodeID:        input.NodeID,
+		VmId:          input.VM.Id,
+		VMID:          input.VM.VMID,
+		VmName:        input.VM.VmName,
+		AppId:         input.VM.AppId,
+		Operation:     input.Operation,
+		Payload:       string(payload),
+		Status:        model.PendingOperationStatusPending,
+		LastError:     cause.Error(),
+		NextRetryTime: &next,
+		Creator:       creator,
+	}
+	if err := s.opRepo.Create(ctx, op); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create pending operation", zap.Error(err))
+		return nil
+	}
+
+	s.logger.WithContext(ctx).Warn("proxmox cluster unreachable, operation queued",
+		zap.Int64("pending_operation_id", op.Id), zap.String("operation", op.Operation),
+		zap.Int64("cluster_id", op.ClusterID), zap.Uint32("vmid", op.VMID), zap.Error(cause))
+	return v1.WithDetailf(v1.ErrOperationQueued, "pending_operation_id=%d", op.Id)
+}
+
+func (s *pendingOperationService) List(ctx context.Context, req *v1.ListPendingOperationsRequest) (*v1.ListPendingOperationsResponseData, error) {
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	ops, total, err := s.opRepo.List(ctx, page, pageSize, req.ClusterID, req.VmId, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list pending operations", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.PendingOperationItem, 0, len(ops))
+	for _, op := range ops {
+		list = append(list, toPendingOperationItem(op))
+	}
+	return &v1.ListPendingOperationsResponseData{Total: total, List: list}, nil
+}
+
+func (s *pendingOperationService) getOperation(ctx context.Context, id int64) (*model.PendingOperation, error) {
+	op, err := s.opRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get pending operation", zap.Error(err), zap.Int64("id", id))
+		return nil, v1.ErrInternalServerError
+	}
+	if op == nil {
+		return nil, v1.WithDetailf(v1.ErrPendingOperationNotFound, "id=%d", id)
+	}
+	return op, nil
+}
+
+func (s *pendingOperationService) Get(ctx context.Context, id int64) (*v1.PendingOperationItem, error) {
+	op, err := s.getOperation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item := toPendingOperationItem(op)
+	return &item, nil
+}
+
+// manageable 获取当前用户可操作的 pending 操作
+func (s *pendingOperationService) manageable(ctx context.Context, userID string, id int64) (*model.PendingOperation, error) {
+	username, admin, err := s.operator(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	op, err := s.getOperation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !admin && op.Creator != username {
+		return nil, v1.ErrAdminRequired
+	}
+	if op.Status != model.PendingOperationStatusPending {
+		return nil, v1.WithDetailf(v1.ErrPendingOperationFinished, "status=%s", op.Status)
+	}
+	return op, nil
+}
+
+func (s *pendingOperationService) Flush(ctx context.Context, userID string, id int64) (*v1.PendingOperationItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, err := s.manageable(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	s.attempt(ctx, op)
+	item := toPendingOperationItem(op)
+	return &item, nil
+}
+
+func (s *pendingOperationService) FlushCluster(ctx context.Context, userID string, clusterID int64) (*v1.FlushPendingOperationsData, error) {
+	username, admin, err := s.operator(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ops, err := s.opRepo.ListPendingByCluster(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list pending operations", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+	data := &v1.FlushPendingOperationsData{}
+	blocked := make(map[string]bool)
+	for _, op := range ops {
+		if !admin && op.Creator != username {
+			continue
+		}
+		data.Total++
+		// 同一虚拟机前面的操作未完成时，后面的操作保持排队
+		if blocked[pendingOperationVMKey(op)] {
+			data.Pending++
+			continue
+		}
+		switch s.attempt(ctx, op) {
+		case pendingOutcomeSucceeded:
+			data.Succeeded++
+		case pendingOutcomeFailed:
+			data.Failed++
+		default:
+			data.Pending++
+			blocked[pendingOperationVMKey(op)] = true
+		}
+	}
+	return data, nil
+}
+
+func (s *pendingOperationService) Cancel(ctx context.Context, userID string, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, err := s.manageable(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	op.Status = model.PendingOperationStatusCancelled
+	op.FinishTime = &now
+	op.NextRetryTime = nil
+	if err := s.opRepo.Update(ctx, op); err != nil {
+		s.logger.WithContext(ctx).Error("failed to cancel pending operation", zap.Error(err), zap.Int64("id", id))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("pending operation cancelled", zap.Int64("id", id), zap.String("operation", op.Operation))
+	return nil
+}
+
+func (s *pendingOperationService) CancelByVM(ctx context.Context, vmID int64) {
+	if err := s.opRepo.CancelByVM(ctx, vmID, "vm deleted"); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to cancel pending operations", zap.Error(err), zap.Int64("vm_id", vmID))
+	}
+}
+
+func (s *pendingOperationService) RetryDue(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ops, err := s.opRepo.ListDue(ctx, time.Now(), pendingOperationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	blocked := make(map[string]bool)
+	unreachable := make(map[int64]bool)
+	processed := 0
+	for _, op := range ops {
+		// 本轮已确认不可达的集群不再逐个尝试；同一虚拟机前面的操作未完成时保持顺序
+		if unreachable[op.ClusterID] || blocked[pendingOperationVMKey(op)] {
+			continue
+		}
+		processed++
+		switch s.attempt(ctx, op) {
+		case pendingOutcomeRetry:
+			unreachable[op.ClusterID] = true
+			blocked[pendingOperationVMKey(op)] = true
+		case pendingOutcomeDeferred:
+			blocked[pendingOperationVMKey(op)] = true
+		}
+	}
+	return processed, nil
+}
+
+func pendingOperationVMKey(op *model.PendingOperation) string {
+	return fmt.Sprintf("%d/%d", op.ClusterID, op.VMID)
+}
+
+// attempt 执行一次并保存结果，最终成功或失败时通知创建人
+func (s *pendingOperationService) attempt(ctx context.Context, op *model.PendingOperation) pendingOperationOutcome {
+	outcome, err := s.execute(ctx, op)
+	now := time.Now()
+	if outcome != pendingOutcomeDeferred {
+		op.Attempts++
+	}
+	switch {
+	case outcome == pendingOutcomeSucceeded:
+		op.Status = model.PendingOperationStatusSucceeded
+		op.LastError = ""
+	case outcome == pendingOutcomeDeferred:
+		op.LastError = err.Error()
+		next := now.Add(s.retryInterval())
+		op.NextRetryTime = &next
+	case outcome == pendingOutcomeRetry && op.Attempts < s.maxAttempts():
+		op.LastError = err.Error()
+		backoff := s.retryInterval() * time.Duration(op.Attempts)
+		if backoff > maxPendingOperationBackoff {
+			backoff = maxPendingOperationBackoff
+		}
+		next := now.Add(backoff)
+		op.NextRetryTime = &next
+	default:
+		outcome = pendingOutcomeFailed
+		op.Status = model.PendingOperationStatusFailed
+		op.LastError = err.Error()
+	}
+	if op.Status != model.PendingOperationStatusPending {
+		op.FinishTime = &now
+		op.NextRetryTime = nil
+	}
+	if err := s.opRepo.Update(ctx, op); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update pending operation", zap.Error(err), zap.Int64("id", op.Id))
+	}
+
+	if op.Status != model.PendingOperationStatusPending {
+		s.logger.WithContext(ctx).Info("pending operation finished",
+			zap.Int64("id", op.Id), zap.String("operation", op.Operation), zap.String("status", op.Status),
+			zap.Int("attempts", op.Attempts), zap.String("error", op.LastError))
+		if op.Creator != "" {
+			var finishErr error
+			if op.Status == model.PendingOperationStatusFailed {
+				finishErr = errors.New(op.LastError)
+			}
+			s.notificationService.Notify(ctx, taskFinishedNotification("pending_operation", op.Id,
+				fmt.Sprintf("Queued %s of VM %d", op.Operation, op.VMID), finishErr), op.Creator)
+		}
+	}
+	return outcome
+}
+
+// execute 执行排队的操作：虚拟机已迁移时按数据库中的当前节点执行，执行前重新检查维护锁、变更窗口和 Proxmox lock
+func (s *pendingOperationService) execute(ctx context.Context, op *model.PendingOperation) (pendingOperationOutcome, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, op.ClusterID)
+	if err != nil {
+		return pendingOutcomeRetry, err
+	}
+	if cluster == nil {
+		return pendingOutcomeFailed, fmt.Errorf("cluster %d not found", op.ClusterID)
+	}
+
+	nodeID, vmid := op.NodeID, op.VMID
+	if op.VmId > 0 {
+		vm, err := s.vmRepo.GetByID(ctx, op.VmId)
+		if err != nil {
+			return pendingOutcomeRetry, err
+		}
+		if vm == nil {
+			return pendingOutcomeFailed, fmt.Errorf("vm %d not found", op.VmId)
+		}
+		nodeID, vmid = vm.NodeID, vm.VMID
+	}
+	node, err := s.nodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return pendingOutcomeRetry, err
+	}
+	if node == nil {
+		return pendingOutcomeFailed, fmt.Errorf("node %d not found", nodeID)
+	}
+
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    op.Operation,
+		Target:    op.VmName,
+		ClusterID: op.ClusterID,
+		AppId:     op.AppId,
+		VMId:      op.VmId,
+	}); err != nil {
+		if errors.Is(err, v1.ErrVMLocked) || errors.Is(err, v1.ErrChangeFrozen) || errors.Is(err, v1.ErrOutsideMaintenanceWindow) {
+			return pendingOutcomeDeferred, err
+		}
+		return pendingOutcomeFailed, err
+	}
+
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return pendingOutcomeFailed, err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vmid, op.Operation); err != nil {
+		return pendingOutcomeDeferred, err
+	}
+
+	switch op.Operation {
+	case PendingOperationVMConfig, PendingOperationVMTags:
+		var config map[string]interface{}
+		if err := json.Unmarshal([]byte(op.Payload), &config); err != nil {
+			return pendingOutcomeFailed, err
+		}
+		err = client.UpdateVMConfig(ctx, node.NodeName, vmid, config)
+	case PendingOperationVMCloudInit:
+		var params url.Values
+		if err := json.Unmarshal([]byte(op.Payload), &params); err != nil {
+			return pendingOutcomeFailed, err
+		}
+		err = client.UpdateVMCloudInitConfig(ctx, node.NodeName, vmid, params)
+	default:
+		return pendingOutcomeFailed, fmt.Errorf("unsupported operation %s", op.Operation)
+	}
+	if err != nil {
+		if isProxmoxUnreachable(err) {
+			return pendingOutcomeRetry, err
+		}
+		return pendingOutcomeFailed, err
+	}
+	return pendingOutcomeSucceeded, nil
+}
+
+func toPendingOperationItem(op *model.PendingOperation) v1.PendingOperationItem {
+	item := v1.PendingOperationItem{
+		Id:            op.Id,
+		ClusterID:     op.ClusterID,
+		NodeID:        op.NodeID,
+		VmId:          op.VmId,
+		VMID:          op.VMID,
+		VmName:        op.VmName,
+		Operation:     op.Operation,
+		Status:        op.Status,
+		Attempts:      op.Attempts,
+		LastError:     op.LastError,
+		NextRetryTime: op.NextRetryTime,
+		FinishTime:    op.FinishTime,
+		Creator:       op.Creator,
+		CreateTime:    op.CreateTime,
+		UpdateTime:    op.UpdateTime,
+	}
+	var payload interface{}
+	if json.Unmarshal([]byte(op.Payload), &payload) == nil {
+		// cloud-init 密码不在列表中展示
+		if m, ok := payload.(map[string]interface{}); ok {
+			if _, exists := m["cipassword"]; exists {
+				m["cipassword"] = "******"
+			}
+		}
+		item.Payload = payload
+	}
+	return item
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/spf13/viper"
+)
+
+// defaultProvisionReservationTTL 预留的最长保留时间，可通过 provision_reservation.ttl 调整；
+// 正常情况下创建结束时即释放，到期只用于兜底（如创建流程异常退出）
+const defaultProvisionReservationTTL = 30 * time.Minute
+
+// ProvisionReservation 创建虚拟机期间在目标节点上预留的资源
+type ProvisionReservation struct {
+	ClusterID int64
+	NodeID    int64
+	NodeName  string
+	VMID      uint32
+	VmName    string
+	CPUNum    int
+	MemoryMB  int
+	Storage   map[string]int64 // 存储名称 -> 字节数
+}
+
+// ProvisionUsage 节点上进行中的创建请求预留的资源合计
+type ProvisionUsage struct {
+	CPUNum   int
+	MemoryMB int
+	Storage  map[string]int64
+}
+
+// ProvisionReservationService 创建资源预留台账：创建请求在容量校验前登记所需资源，
+// 校验时计入同一节点上其他进行中的创建请求，避免并发创建各自通过校验后合计超出节点容量。
+// 台账保存在内存中，只对当前实例内的并发请求生效
+type ProvisionReservationService interface {
+	// Reserve 登记预留，调用方在创建结束后调用 Release
+	Reserve(ctx context.Context, r *ProvisionReservation) *ProvisionHold
+	// NodeUsage 节点上的预留合计，exclude 为调用方自身的预留（不计入）
+	NodeUsage(nodeID int64, exclude *ProvisionHold) ProvisionUsage
+	List(ctx context.Context, req *v1.ListProvisionReservationsRequest) (*v1.ListProvisionReservationsResponseData, error)
+}
+
+func NewProvisionReservationService(conf *viper.Viper) ProvisionReservationService {
+	return &provisionReservationService{
+		conf:    conf,
+		entries: make(map[int64]*provisionReservationEntry),
+	}
+}
+
+type provisionReservationEntry struct {
+	*ProvisionReservation
+	createTime time.Time
+	expireTime time.Time
+}
+
+type provisionReservationService struct {
+	conf *viper.Viper
+
+	mu      sync.Mutex
+	nextID  int64
+	entries map[int64]*provisionReservationEntry
+}
+
+func (s *provisionReservationService) Reserve(ctx context.Context, r *ProvisionReservation) *ProvisionHold {
+	ttl := s.conf.GetDuration("provision_reservation.ttl")
+	if ttl <= 0 {
+		ttl = defaultProvisionReservationTTL
+	}
+	now := time.Now()
+
+	if r.Storage == nil {
+		r.Storage = make(map[string]int64)
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := s.nextID
+	s.entries[id] = &provisionReservationEntry{ProvisionReservation: r, createTime: now, expireTime: now.Add(ttl)}
+	s.mu.Unlock()
+	return &ProvisionHold{service: s, id: id}
+}
+
+func (s *provisionReservationService) NodeUsage(nodeID int64, exclude *ProvisionHold) ProvisionUsage {
+	usage := ProvisionUsage{Storage: make(map[string]int64)}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.expireLocked()
+	for id, entry := range s.entries {
+		if entry.NodeID != nodeID || (exclude != nil && exclude.id == id) {
+			continue
+		}
+		usage.CPUNum += entry.CPUNum
+		usage.MemoryMB += entry.MemoryMB
+		for storage, size := range entry.Storage {
+			usage.Storage[storage] += size
+		}
+	}
+	return usage
+}
+
+func (s *provisionReservationService) List(ctx context.Context, req *v1.ListProvisionReservationsRequest) (*v1.ListProvisionReservationsResponseData, error) {
+	s.mu.Lock()
+	s.expireLocked()
+	items := make([]v1.ProvisionReservationItem, 0, len(s.entries))
+	for id, entry := range s.entries {
+		if (req.ClusterID > 0 && entry.ClusterID != req.ClusterID) || (req.NodeID > 0 && entry.NodeID != req.NodeID) {
+			continue
+		}
+		storageGB := make(map[string]int64, len(entry.Storage))
+		for storage, size := range entry.Storage {
+			storageGB[storage] = size >> 30
+		}
+		items = append(items, v1.ProvisionReservationItem{
+			Id:         id,
+			ClusterID:  entry.ClusterID,
+			NodeID:     entry.NodeID,
+			NodeName:   entry.NodeName,
+			VMID:       entry.VMID,
+			VmName:     entry.VmName,
+			CPUNum:     entry.CPUNum,
+			MemoryMB:   entry.MemoryMB,
+			StorageGB:  storageGB,
+			CreateTime: entry.createTime,
+			ExpireTime: entry.expireTime,
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(items, func(i, j int) bool { return items[i].Id < items[j].Id })
+	return &v1.ListProvisionReservationsResponseData{List: items}, nil
+}
+
+// ProvisionHold 一次创建请求的预留
+type ProvisionHold struct {
+	service *provisionReservationService
+	id      int64
+	once    sync.Once
+}
+
+// Add 追加预留，用于校验后才能确定的资源（如克隆的模板磁盘、沿用的模板内存）
+func (h *ProvisionHold) Add(memoryMB int, storage map[string]int64) {
+	h.service.mu.Lock()
+	defer h.service.mu.Unlock()
+	entry := h.service.entries[h.id]
+	if entry == nil {
+		return
+	}
+	entry.MemoryMB += memoryMB
+	for name, size := range storage {
+		entry.Storage[name] += size
+	}
+}
+
+// Release 释放预留，可重复调用
+func (h *ProvisionHold) Release() {
+	h.once.Do(func() {
+		h.service.mu.Lock()
+		delete(h.service.entries, h.id)
+		h.service.mu.Unlock()
+	})
+}
+
+// expireLocked 删除已到期的预留，调用方需持有锁
+func (s *provisionReservationService) expireLocked() {
+	now := time.Now()
+	for id, entry := range s.entries {
+		if now.After(entry.expireTime) {
+			delete(s.entries, id)
+		}
+	}
+}
+
+// createVMReservation 创建请求需要预留的资源：iso/empty 模式未指定时按创建默认值（2 核、2048 MB、32 GB 系统盘），
+// template 模式只计入请求中指定的部分，克隆的模板磁盘和沿用的模板内存在取得模板配置后追加
+func createVMReservation(req *v1.CreateVMRequest, createMode string, clusterID, nodeID int64, nodeName string) *ProvisionReservation {
+	r := &ProvisionReservation{
+		ClusterID: clusterID,
+		NodeID:    nodeID,
+		NodeName:  nodeName,
+		VMID:      req.VMID,
+		VmName:    req.VmName,
+		Storage:   make(map[string]int64),
+	}
+	if req.CPUNum != nil && *req.CPUNum > 0 {
+		r.CPUNum = *req.CPUNum
+	}
+	if createMode != "template" {
+		if r.CPUNum == 0 {
+			r.CPUNum = 2
+		}
+		if req.Sockets != nil && *req.Sockets > 1 {
+			r.CPUNum *= *req.Sockets
+		}
+	}
+	r.MemoryMB = createVMMemory(req, createMode)
+	for storage, size := range createVMDiskUsage(req, createMode) {
+		r.Storage[storage] = size
+	}
+	return r
+}
+
+// createVMMemory 新虚拟机的内存（MB），template 模式未指定时为 0（沿用模板配置）
+func createVMMemory(req *v1.CreateVMRequest, createMode string) int {
+	if req.MemorySize != nil && *req.MemorySize > 0 {
+		return *req.MemorySize
+	}
+	if createMode != "template" {
+		return 2048
+	}
+	return 0
+}
+
+// createVMDiskUsage 新建磁盘按存储汇总的字节数：disks 中的磁盘，以及 iso/empty 模式未传 disks 时的系统盘
+func createVMDiskUsage(req *v1.CreateVMRequest, createMode string) map[string]int64 {
+	usage := make(map[string]int64)
+	if len(req.Disks) == 0 {
+		if createMode != "template" {
+			if storage := strings.TrimSpace(req.Storage); storage != "" {
+				diskGB := 32
+				if req.DiskSizeGB != nil && *req.DiskSizeGB > 0 {
+					diskGB = *req.DiskSizeGB
+				}
+				usage[storage] += int64(diskGB) << 30
+			}
+		}
+		return usage
+	}
+	for _, disk := range req.Disks {
+		if storage := diskSpecStorage(disk, req.Storage); storage != "" {
+			usage[storage] += int64(disk.SizeGB) << 30
+		}
+	}
+	return usage
+}
@@ -0,0 +1,778 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 引导创建时的默认值
+const (
+	defaultPveAccessRealm   = "pam"
+	defaultPveSphereUser    = "pvesphere@pve"
+	defaultPveSphereTokenID = "pvesphere"
+	defaultPveSphereRoleID  = "PveSphere"
+)
+
+// pveSphereRolePrivileges PveSphere 日常运行（同步资源、虚拟机生命周期、控制台、备份、迁移、存储上传）需要的权限，
+// 可通过 pve_access.role_privileges 覆盖
+var pveSphereRolePrivileges = []string{
+	"Sys.Audit", "Sys.Modify", "Sys.Console", "Sys.PowerMgmt", "Sys.Syslog",
+	"Datastore.Audit", "Datastore.Allocate", "Datastore.AllocateSpace", "Datastore.AllocateTemplate",
+	"VM.Audit", "VM.Allocate", "VM.Clone", "VM.Backup", "VM.Console", "VM.Migrate", "VM.Monitor", "VM.PowerMgmt",
+	"VM.Snapshot", "VM.Snapshot.Rollback",
+	"VM.Config.CDROM", "VM.Config.CPU", "VM.Config.Cloudinit", "VM.Config.Disk", "VM.Config.HWType",
+	"VM.Config.Memory", "VM.Config.Network", "VM.Config.Options",
+	"VM.GuestAgent.Audit", "VM.GuestAgent.Unrestricted",
+	"SDN.Audit", "SDN.Use", "Pool.Audit",
+}
+
+// pveAccessManagementPrivileges 在 PveSphere 中管理 PVE 用户、Token 和 ACL 时额外需要的权限
+var pveAccessManagementPrivileges = []string{
+	"User.Modify", "Permissions.Modify", "Realm.AllocateUser", "Group.Allocate",
+}
+
+// pveUserIDPattern PVE 用户ID（name@realm）
+var pveUserIDPattern = regexp.MustCompile(`^[^\s:/@!]+@[A-Za-z][A-Za-z0-9._-]*$`)
+
+// pveTokenIDPattern API Token 名称，与 Proxmox 的 pve-tokenid 格式一致
+var pveTokenIDPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+
+// PveAccessService 管理 PVE 侧的用户、API Token 和 ACL，全部操作仅管理员可用
+type PveAccessService interface {
+	ListUsers(ctx context.Context, userID string, clusterID int64) ([]v1.PveUserItem, error)
+	CreateUser(ctx context.Context, userID string, req *v1.CreatePveUserRequest) error
+	UpdateUser(ctx context.Context, userID, pveUser string, req *v1.UpdatePveUserRequest) error
+	DeleteUser(ctx context.Context, userID string, clusterID int64, pveUser string) error
+
+	ListTokens(ctx context.Context, userID string, clusterID int64, pveUser string) ([]v1.PveTokenItem, error)
+	CreateToken(ctx context.Context, userID, pveUser string, req *v1.CreatePveTokenRequest) (*v1.CreatePveTokenData, error)
+	DeleteToken(ctx context.Context, userID string, clusterID int64, pveUser, tokenID string) error
+
+	ListACL(ctx context.Context, userID string, clusterID int64) ([]v1.PveACLItem, error)
+	UpdateACL(ctx context.Context, userID string, req *v1.UpdatePveACLRequest) error
+	ListRoles(ctx context.Context, userID string, clusterID int64) ([]v1.PveRoleItem, error)
+
+	// CheckPermissions 检查集群当前凭据能否认证以及是否具备 PveSphere 需要的权限
+	CheckPermissions(ctx context.Context, userID string, clusterID int64) (*v1.CheckPvePermissionsData, error)
+	// Bootstrap 使用管理员账号密码为 PveSphere 创建最小权限角色、用户和 API Token
+	Bootstrap(ctx context.Context, userID string, req *v1.BootstrapPveAccessRequest) (*v1.BootstrapPveAccessData, error)
+}
+
+func NewPveAccessService(
+	service *Service,
+	conf *viper.Viper,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	capabilityService ClusterCapabilityService,
+	logger *log.Logger,
+) PveAccessService {
+	return &pveAccessService{
+		Service:           service,
+		conf:              conf,
+		clusterRepo:       clusterRepo,
+		userRepo:          userRepo,
+		capabilityService: capabilityService,
+		logger:            logger,
+	}
+}
+
+type pveAccessService struct {
+	*Service
+	conf              *viper.Viper
+	clusterRepo       repository.PveClusterRepository
+	userRepo          repository.UserRepository
+	capabilityService ClusterCapabilityService
+	logger            *log.Logger
+}
+
+func (s *pveAccessService) getCluster(ctx context.Context, clusterID int64) (*model.PveCluster, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+	return cluster, nil
+}
+
+// adminClient 校验管理员身份并使用集群凭据创建客户端
+func (s *pveAccessService) adminClient(ctx context.Context, userID string, clusterID int64) (*proxmox.ProxmoxClient, *model.PveCluster, error) {
+	if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "access.manage"); err != nil {
+		return nil, nil, err
+	}
+	cluster, err := s.getCluster(ctx, clusterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err), zap.Int64("cluster_id", clusterID))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	return client, cluster, nil
+}
+
+func checkPveUserID(pveUser string) error {
+	if !pveUserIDPattern.MatchString(pveUser) {
+		return v1.WithDetailf(v1.ErrInvalidPveUserID, "userid=%s, expected name@realm", pveUser)
+	}
+	return nil
+}
+
+func checkPveTokenID(tokenID string) error {
+	if !pveTokenIDPattern.MatchString(tokenID) {
+		return v1.WithDetailf(v1.ErrInvalidParameter, "tokenid=%s", tokenID)
+	}
+	return nil
+}
+
+func (s *pveAccessService) ListUsers(ctx context.Context, userID string, clusterID int64) ([]v1.PveUserItem, error) {
+	client, _, err := s.adminClient(ctx, userID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	users, err := client.ListAccessUsers(ctx)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+
+	list := make([]v1.PveUserItem, 0, len(users))
+	for _, user := range users {
+		item := v1.PveUserItem{
+			UserID:    pveString(user["userid"]),
+			Enable:    pveFlag(user["enable"], true),
+			Expire:    pveInt64(user["expire"]),
+			Email:     pveString(user["email"]),
+			Comment:   pveString(user["comment"]),
+			FirstName: pveString(user["firstname"]),
+			LastName:  pveString(user["lastname"]),
+			Groups:    splitPveList(user["groups"]),
+			RealmType: pveString(user["realm-type"]),
+			Tokens:    []v1.PveTokenItem{},
+		}
+		if tokens, ok := user["tokens"].([]interface{}); ok {
+			for _, t := range tokens {
+				if token, ok := t.(map[string]interface{}); ok {
+					item.Tokens = append(item.Tokens, toPveTokenItem(token))
+				}
+			}
+		}
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].UserID < list[j].UserID })
+	return list, nil
+}
+
+func (s *pveAccessService) CreateUser(ctx context.Context, userID string, req *v1.CreatePveUserRequest) error {
+	if err := checkPveUserID(req.UserID); err != nil {
+		return err
+	}
+	client, _, err := s.adminClient(ctx, userID, req.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("userid", req.UserID)
+	if req.Password != "" {
+		params.Set("password", req.Password)
+	}
+	if req.Email != "" {
+		params.Set("email", req.Email)
+	}
+	if req.Comment != "" {
+		params.Set("comment", req.Comment)
+	}
+	if len(req.Groups) > 0 {
+		params.Set("groups", strings.Join(req.Groups, ","))
+	}
+	params.Set("enable", proxmoxFlag(req.Enable == nil || *req.Enable))
+	if req.Expire > 0 {
+		params.Set("expire", strconv.FormatInt(req.Expire, 10))
+	}
+	if err := client.CreateAccessUser(ctx, params); err != nil {
+		return v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+
+	s.logger.WithContext(ctx).Info("proxmox user created",
+		zap.Int64("cluster_id", req.ClusterID), zap.String("pve_user", req.UserID))
+	return nil
+}
+
+func (s *pveAccessService) UpdateUser(ctx context.Context, userID, pveUser string, req *v1.UpdatePveUserRequest) error {
+	if err := checkPveUserID(pveUser); err != nil {
+		return err
+	}
+	client, _, err := s.adminClient(ctx, userID, req.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	if req.Email != nil {
+		params.Set("email", *req.Email)
+	}
+	if req.Comment != nil {
+		params.Set("comment", *req.Comment)
+	}
+	if req.Groups != nil {
+		params.Set("groups", strings.Join(req.Groups, ","))
+	}
+	if req.Enable != nil {
+		params.Set("enable", proxmoxFlag(*req.Enable))
+	}
+	if req.Expire != nil {
+		params.Set("expire", strconv.FormatInt(*req.Expire, 10))
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	if err := client.UpdateAccessUser(ctx, pveUser, params); err != nil {
+		return v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	return nil
+}
+
+func (s *pveAccessService) DeleteUser(ctx context.Context, userID string, clusterID int64, pveUser string) error {
+	if err := checkPveUserID(pveUser); err != nil {
+		return err
+	}
+	if pveUser == "root@pam" {
+		return v1.WithDetail(v1.ErrInvalidParameter, "root@pam cannot be deleted")
+	}
+	client, cluster, err := s.adminClient(ctx, userID, clusterID)
+	if err != nil {
+		return err
+	}
+	// 删除 PveSphere 自身使用的用户会导致集群立即失联
+	if strings.HasPrefix(cluster.UserId, pveUser+"!") {
+		return v1.WithDetailf(v1.ErrInvalidParameter, "%s is used by this cluster's api token", pveUser)
+	}
+	if err := client.DeleteAccessUser(ctx, pveUser); err != nil {
+		return v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+
+	s.logger.WithContext(ctx).Info("proxmox user deleted",
+		zap.Int64("cluster_id", clusterID), zap.String("pve_user", pveUser))
+	return nil
+}
+
+func (s *pveAccessService) ListTokens(ctx context.Context, userID string, clusterID int64, pveUser string) ([]v1.PveTokenItem, error) {
+	if err := checkPveUserID(pveUser); err != nil {
+		return nil, err
+	}
+	client, _, err := s.adminClient(ctx, userID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := client.ListUserTokens(ctx, pveUser)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	list := make([]v1.PveTokenItem, 0, len(tokens))
+	for _, token := range tokens {
+		list = append(list, toPveTokenItem(token))
+	}
+	return list, nil
+}
+
+func (s *pveAccessService) CreateToken(ctx context.Context, userID, pveUser string, req *v1.CreatePveTokenRequest) (*v1.CreatePveTokenData, error) {
+	if err := checkPveUserID(pveUser); err != nil {
+		return nil, err
+	}
+	if err := checkPveTokenID(req.TokenID); err != nil {
+		return nil, err
+	}
+	client, _, err := s.adminClient(ctx, userID, req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	privsep := req.Privsep == nil || *req.Privsep
+	params := url.Values{}
+	params.Set("privsep", proxmoxFlag(privsep))
+	if req.Comment != "" {
+		params.Set("comment", req.Comment)
+	}
+	if req.Expire > 0 {
+		params.Set("expire", strconv.FormatInt(req.Expire, 10))
+	}
+	result, err := client.CreateUserToken(ctx, pveUser, req.TokenID, params)
+	if err != nil {
+		if strings.Contains(err.Error(), "already exists") {
+			return nil, v1.WithDetailf(v1.ErrPveTokenExists, "%s!%s", pveUser, req.TokenID)
+		}
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+
+	s.logger.WithContext(ctx).Info("proxmox api token created",
+		zap.Int64("cluster_id", req.ClusterID), zap.String("pve_user", pveUser), zap.String("token_id", req.TokenID))
+	return &v1.CreatePveTokenData{
+		FullTokenID: pveString(result["full-tokenid"]),
+		Value:       pveString(result["value"]),
+		Privsep:     privsep,
+	}, nil
+}
+
+func (s *pveAccessService) DeleteToken(ctx context.Context, userID string, clusterID int64, pveUser, tokenID string) error {
+	if err := checkPveUserID(pveUser); err != nil {
+		return err
+	}
+	if err := checkPveTokenID(tokenID); err != nil {
+		return err
+	}
+	client, cluster, err := s.adminClient(ctx, userID, clusterID)
+	if err != nil {
+		return err
+	}
+	if cluster.UserId == pveUser+"!"+tokenID {
+		return v1.WithDetailf(v1.ErrInvalidParameter, "%s is used by this cluster", cluster.UserId)
+	}
+	if err := client.DeleteUserToken(ctx, pveUser, tokenID); err != nil {
+		return v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	return nil
+}
+
+func (s *pveAccessService) ListACL(ctx context.Context, userID string, clusterID int64) ([]v1.PveACLItem, error) {
+	client, _, err := s.adminClient(ctx, userID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	acl, err := client.GetACL(ctx)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	list := make([]v1.PveACLItem, 0, len(acl))
+	for _, entry := range acl {
+		list = append(list, v1.PveACLItem{
+			Path:      pveString(entry["path"]),
+			Type:      pveString(entry["type"]),
+			UGID:      pveString(entry["ugid"]),
+			RoleID:    pveString(entry["roleid"]),
+			Propagate: pveFlag(entry["propagate"], true),
+		})
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list, nil
+}
+
+func (s *pveAccessService) UpdateACL(ctx context.Context, userID string, req *v1.UpdatePveACLRequest) error {
+	if len(req.Users) == 0 && len(req.Groups) == 0 && len(req.Tokens) == 0 {
+		return v1.WithDetail(v1.ErrInvalidParameter, "one of users, groups or tokens is required")
+	}
+	if !strings.HasPrefix(req.Path, "/") {
+		return v1.WithDetailf(v1.ErrInvalidParameter, "path=%s", req.Path)
+	}
+	for _, user := range req.Users {
+		if err := checkPveUserID(user); err != nil {
+			return err
+		}
+	}
+	client, _, err := s.adminClient(ctx, userID, req.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("path", req.Path)
+	params.Set("roles", strings.Join(req.Roles, ","))
+	if len(req.Users) > 0 {
+		params.Set("users", strings.Join(req.Users, ","))
+	}
+	if len(req.Groups) > 0 {
+		params.Set("groups", strings.Join(req.Groups, ","))
+	}
+	if len(req.Tokens) > 0 {
+		params.Set("tokens", strings.Join(req.Tokens, ","))
+	}
+	params.Set("propagate", proxmoxFlag(req.Propagate == nil || *req.Propagate))
+	if req.Delete {
+		params.Set("delete", "1")
+	}
+	if err := client.UpdateACL(ctx, params); err != nil {
+		return v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+
+	s.logger.WithContext(ctx).Info("proxmox acl updated",
+		zap.Int64("cluster_id", req.ClusterID), zap.String("path", req.Path),
+		zap.Strings("roles", req.Roles), zap.Bool("delete", req.Delete))
+	return nil
+}
+
+func (s *pveAccessService) ListRoles(ctx context.Context, userID string, clusterID int64) ([]v1.PveRoleItem, error) {
+	client, _, err := s.adminClient(ctx, userID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := client.ListRoles(ctx)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	list := make([]v1.PveRoleItem, 0, len(roles))
+	for _, role := range roles {
+		list = append(list, v1.PveRoleItem{
+			RoleID:  pveString(role["roleid"]),
+			Privs:   splitPveList(role["privs"]),
+			Special: pveFlag(role["special"], false),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].RoleID < list[j].RoleID })
+	return list, nil
+}
+
+// requiredPrivileges PveSphere 需要的权限，accessManagement 为 true 时包含用户/ACL 管理权限
+func (s *pveAccessService) requiredPrivileges(accessManagement bool) []string {
+	privs := s.conf.GetStringSlice("pve_access.role_privileges")
+	if len(privs) == 0 {
+		privs = pveSphereRolePrivileges
+	}
+	required := append([]string{}, privs...)
+	if accessManagement {
+		required = append(required, pveAccessManagementPrivileges...)
+	}
+	return required
+}
+
+// privilegeCatalog 当前 PVE 版本支持的全部权限（内置 Administrator 角色的权限），获取失败时返回 nil
+func privilegeCatalog(ctx context.Context, client *proxmox.ProxmoxClient) map[string]interface{} {
+	admin, err := client.GetRole(ctx, "Administrator")
+	if err != nil || len(admin) == 0 {
+		return nil
+	}
+	return admin
+}
+
+// filterPrivileges 过滤掉当前 PVE 版本不存在的权限（如 PVE 7 没有 SDN.Use、VM.GuestAgent.*，PVE 9 去掉了 VM.Monitor），
+// catalog 为 nil 时原样返回
+func filterPrivileges(privs []string, catalog map[string]interface{}) []string {
+	if catalog == nil {
+		return privs
+	}
+	supported := make([]string, 0, len(privs))
+	for _, priv := range privs {
+		if _, ok := catalog[priv]; ok {
+			supported = append(supported, priv)
+		}
+	}
+	return supported
+}
+
+func supportedPrivileges(ctx context.Context, client *proxmox.ProxmoxClient, privs []string) []string {
+	return filterPrivileges(privs, privilegeCatalog(ctx, client))
+}
+
+// tokenIDHints user_id 不是完整 Token ID（user@realm!tokenid）时的提示
+func tokenIDHints(userID string) []string {
+	parts := strings.SplitN(userID, "!", 2)
+	if len(parts) == 2 && pveUserIDPattern.MatchString(parts[0]) && pveTokenIDPattern.MatchString(parts[1]) {
+		return nil
+	}
+	return []string{fmt.Sprintf("user_id %q should be the full token id, e.g. pvesphere@pve!pvesphere", userID)}
+}
+
+// versionErrorHints 调用 /version 失败时的排查提示，401 表示 API 可达但凭据无效
+func versionErrorHints(err error) (bool, []string) {
+	if strings.Contains(err.Error(), "status 401") {
+		return true, []string{
+			"user_token must be the token secret (UUID) shown once when the token was created",
+			"check that the token and its user still exist, are enabled and have not expired",
+			"use the bootstrap endpoint to create a new token for PveSphere",
+		}
+	}
+	return false, []string{"check api_url and that port 8006 is reachable from the PveSphere server"}
+}
+
+func (s *pveAccessService) CheckPermissions(ctx context.Context, userID string, clusterID int64) (*v1.CheckPvePermissionsData, error) {
+	client, cluster, err := s.adminClient(ctx, userID, clusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.CheckPvePermissionsData{
+		UserID:     cluster.UserId,
+		Privileges: []string{},
+		Missing:    []string{},
+		Hints:      []string{},
+	}
+	data.Hints = append(data.Hints, tokenIDHints(cluster.UserId)...)
+
+	if _, err := client.GetVersion(ctx); err != nil {
+		data.Message = err.Error()
+		var hints []string
+		data.Connected, hints = versionErrorHints(err)
+		data.Hints = append(data.Hints, hints...)
+		return data, nil
+	}
+	data.Connected = true
+	data.Authenticated = true
+
+	perms, err := client.GetPermissions(ctx, "/")
+	if err != nil {
+		data.Message = err.Error()
+		return data, nil
+	}
+	granted := perms["/"]
+	for priv := range granted {
+		data.Privileges = append(data.Privileges, priv)
+	}
+	sort.Strings(data.Privileges)
+
+	for _, priv := range supportedPrivileges(ctx, client, s.requiredPrivileges(false)) {
+		if _, ok := granted[priv]; !ok {
+			data.Missing = append(data.Missing, priv)
+		}
+	}
+	if len(granted) == 0 {
+		data.Hints = append(data.Hints,
+			"the token has no privileges on /; tokens with privilege separation need their own ACL entries, or recreate the token with privsep=0")
+	} else if len(data.Missing) > 0 {
+		data.Hints = append(data.Hints, "grant the missing privileges on / (propagate) or use the bootstrap endpoint to create a dedicated role")
+	}
+	return data, nil
+}
+
+func (s *pveAccessService) Bootstrap(ctx context.Context, userID string, req *v1.BootstrapPveAccessRequest) (*v1.BootstrapPveAccessData, error) {
+	operator, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "access.manage")
+	if err != nil {
+		return nil, err
+	}
+
+	var cluster *model.PveCluster
+	apiURL := req.ApiUrl
+	if req.ClusterID != nil {
+		cluster, err = s.getCluster(ctx, *req.ClusterID)
+		if err != nil {
+			return nil, err
+		}
+		apiURL = cluster.ApiUrl
+	}
+	if apiURL == "" {
+		return nil, v1.WithDetail(v1.ErrInvalidParameter, "cluster_id or api_url is required")
+	}
+
+	realm := req.Realm
+	if realm == "" {
+		realm = defaultPveAccessRealm
+	}
+	pveUser := req.PveUser
+	if pveUser == "" {
+		pveUser = defaultPveSphereUser
+	}
+	tokenID := req.TokenID
+	if tokenID == "" {
+		tokenID = defaultPveSphereTokenID
+	}
+	roleID := req.RoleID
+	if roleID == "" {
+		roleID = defaultPveSphereRoleID
+	}
+	if err := checkPveUserID(pveUser); err != nil {
+		return nil, err
+	}
+	if err := checkPveTokenID(tokenID); err != nil {
+		return nil, err
+	}
+	if !pveTokenIDPattern.MatchString(roleID) {
+		return nil, v1.WithDetailf(v1.ErrInvalidParameter, "role_id=%s", roleID)
+	}
+
+	// 1. 使用账号密码登录，密码只用于本次请求
+	ticket, err := proxmox.GetAccessTicket(ctx, apiURL, req.Username, realm, req.Password)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrPveLoginFailed, err.Error())
+	}
+	client, err := proxmox.NewProxmoxClientWithTicket(apiURL, ticket.Ticket, ticket.CSRFPreventionToken)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	data := &v1.BootstrapPveAccessData{
+		PveUser: pveUser,
+		RoleID:  roleID,
+		Steps:   []string{fmt.Sprintf("logged in as %s", ticket.Username)},
+	}
+
+	// 2. 创建或更新角色
+	data.Privileges = supportedPrivileges(ctx, client, s.requiredPrivileges(req.AccessManagement))
+	privs := strings.Join(data.Privileges, ",")
+	if _, err := client.GetRole(ctx, roleID); err == nil {
+		if err := client.UpdateRole(ctx, roleID, privs); err != nil {
+			return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update role %s: %v", roleID, err)
+		}
+		data.Steps = append(data.Steps, fmt.Sprintf("updated role %s", roleID))
+	} else {
+		if err := client.CreateRole(ctx, roleID, privs); err != nil {
+			return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "create role %s: %v", roleID, err)
+		}
+		data.Steps = append(data.Steps, fmt.Sprintf("created role %s", roleID))
+	}
+
+	// 3. 创建用户（已存在则沿用）
+	users, err := client.ListAccessUsers(ctx)
+	if err != nil {
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "list users: %v", err)
+	}
+	userExists := false
+	var existingTokens []interface{}
+	for _, user := range users {
+		if pveString(user["userid"]) == pveUser {
+			userExists = true
+			existingTokens, _ = user["tokens"].([]interface{})
+			break
+		}
+	}
+	if !userExists {
+		params := url.Values{}
+		params.Set("userid", pveUser)
+		params.Set("comment", "managed by PveSphere")
+		if err := client.CreateAccessUser(ctx, params); err != nil {
+			return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "create user %s: %v", pveUser, err)
+		}
+		data.Steps = append(data.Steps, fmt.Sprintf("created user %s", pveUser))
+	} else {
+		data.Steps = append(data.Steps, fmt.Sprintf("user %s already exists", pveUser))
+	}
+
+	// 4. 在 / 上授予角色（向下继承）
+	aclParams := url.Values{}
+	aclParams.Set("path", "/")
+	aclParams.Set("roles", roleID)
+	aclParams.Set("users", pveUser)
+	aclParams.Set("propagate", "1")
+	if err := client.UpdateACL(ctx, aclParams); err != nil {
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update acl: %v", err)
+	}
+	data.Steps = append(data.Steps, fmt.Sprintf("granted %s on / to %s", roleID, pveUser))
+
+	// 5. 创建 Token（不启用权限分离，直接继承用户权限）
+	for _, t := range existingTokens {
+		token, ok := t.(map[string]interface{})
+		if !ok || pveString(token["tokenid"]) != tokenID {
+			continue
+		}
+		if !req.RotateToken {
+			return nil, v1.WithDetailf(v1.ErrPveTokenExists, "%s!%s, set rotate_token to recreate it", pveUser, tokenID)
+		}
+		if err := client.DeleteUserToken(ctx, pveUser, tokenID); err != nil {
+			return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "delete token %s: %v", tokenID, err)
+		}
+		data.Steps = append(data.Steps, fmt.Sprintf("deleted existing token %s!%s", pveUser, tokenID))
+	}
+	tokenParams := url.Values{}
+	tokenParams.Set("privsep", "0")
+	tokenParams.Set("comment", "PveSphere, created by "+operator)
+	result, err := client.CreateUserToken(ctx, pveUser, tokenID, tokenParams)
+	if err != nil {
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "create token %s: %v", tokenID, err)
+	}
+	data.UserID = pveString(result["full-tokenid"])
+	if data.UserID == "" {
+		data.UserID = pveUser + "!" + tokenID
+	}
+	data.UserToken = pveString(result["value"])
+	data.Steps = append(data.Steps, fmt.Sprintf("created token %s", data.UserID))
+
+	// 6. 使用新 Token 验证
+	if tokenClient, err := proxmox.NewProxmoxClient(apiURL, data.UserID, data.UserToken); err == nil {
+		if _, err := tokenClient.GetVersion(ctx); err == nil {
+			data.Verified = true
+			data.Steps = append(data.Steps, "verified new token")
+		} else {
+			data.Steps = append(data.Steps, "token verification failed: "+err.Error())
+		}
+	}
+
+	// 7. 写入集群凭据
+	if cluster != nil && data.Verified {
+		cluster.UserId = data.UserID
+		cluster.UserToken = data.UserToken
+		if err := s.clusterRepo.Update(ctx, cluster); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update cluster credentials", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			data.Steps = append(data.Steps, "failed to update cluster credentials, update them manually")
+		} else {
+			data.ClusterUpdated = true
+			data.Steps = append(data.Steps, fmt.Sprintf("updated credentials of cluster %s", cluster.ClusterName))
+			s.resources.Invalidate(cluster.Id)
+			if _, err := s.capabilityService.Probe(ctx, cluster.Id); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to probe cluster capabilities", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+			}
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("proxmox access bootstrapped",
+		zap.String("operator", operator), zap.String("api_url", apiURL),
+		zap.String("token", data.UserID), zap.Bool("cluster_updated", data.ClusterUpdated))
+	return data, nil
+}
+
+func toPveTokenItem(token map[string]interface{}) v1.PveTokenItem {
+	return v1.PveTokenItem{
+		TokenID: pveString(token["tokenid"]),
+		Comment: pveString(token["comment"]),
+		Expire:  pveInt64(token["expire"]),
+		Privsep: pveFlag(token["privsep"], true),
+	}
+}
+
+// splitPveList 解析 Proxmox 返回的逗号分隔列表（部分版本返回数组）
+func splitPveList(v interface{}) []string {
+	list := []string{}
+	switch value := v.(type) {
+	case string:
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	case []interface{}:
+		for _, item := range value {
+			if str, ok := item.(string); ok && str != "" {
+				list = append(list, str)
+			}
+		}
+	}
+	return list
+}
+
+func pveString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func pveInt64(v interface{}) int64 {
+	n, _ := v.(float64)
+	return int64(n)
+}
+
+// pveFlag 解析 0/1 标志，字段缺省时取 Proxmox 的默认值
+func pveFlag(v interface{}, def bool) bool {
+	if v == nil {
+		return def
+	}
+	return proxmoxBool(v)
+}
+
+func proxmoxFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
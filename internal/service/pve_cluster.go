@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	v1 "pvesphere/api/v1"
@@ -14,13 +15,13 @@ import (
 )
 
 type PveClusterService interface {
-	CreateCluster(ctx context.Context, req *v1.CreateClusterRequest) error
+	CreateCluster(ctx context.Context, req *v1.CreateClusterRequest) (*v1.CreateClusterData, error)
 	UpdateCluster(ctx context.Context, id int64, req *v1.UpdateClusterRequest) error
 	DeleteCluster(ctx context.Context, id int64) error
 	GetCluster(ctx context.Context, id int64) (*v1.ClusterDetail, error)
 	ListClusters(ctx context.Context, req *v1.ListClusterRequest) (*v1.ListClusterResponseData, error)
 	GetClusterStatus(ctx context.Context, clusterID int64) ([]map[string]interface{}, error)
-	GetClusterResources(ctx context.Context, clusterID int64) ([]map[string]interface{}, error)
+	GetClusterResources(ctx context.Context, clusterID int64, resourceType string) ([]map[string]interface{}, error)
 	VerifyCluster(ctx context.Context, clusterID *int64) (*v1.VerifyClusterData, error)
 	VerifyClusterWithCredentials(ctx context.Context, apiUrl, userId, userToken string) (*v1.VerifyClusterData, error)
 }
@@ -28,58 +29,81 @@ type PveClusterService interface {
 func NewPveClusterService(
 	service *Service,
 	clusterRepo repository.PveClusterRepository,
+	siteRepo repository.PveSiteRepository,
 	repo *repository.Repository,
+	capabilityService ClusterCapabilityService,
 	logger *log.Logger,
 ) PveClusterService {
 	return &pveClusterService{
-		clusterRepo: clusterRepo,
-		repo:        repo,
-		Service:     service,
-		logger:      logger,
+		clusterRepo:       clusterRepo,
+		siteRepo:          siteRepo,
+		repo:              repo,
+		capabilityService: capabilityService,
+		Service:           service,
+		logger:            logger,
 	}
 }
 
 type pveClusterService struct {
-	clusterRepo repository.PveClusterRepository
-	repo        *repository.Repository
+	clusterRepo       repository.PveClusterRepository
+	siteRepo          repository.PveSiteRepository
+	repo              *repository.Repository
+	capabilityService ClusterCapabilityService
 	*Service
 	logger *log.Logger
 }
 
-func (s *pveClusterService) CreateCluster(ctx context.Context, req *v1.CreateClusterRequest) error {
+func (s *pveClusterService) CreateCluster(ctx context.Context, req *v1.CreateClusterRequest) (*v1.CreateClusterData, error) {
 	// 检查集群名称是否已存在
 	existing, err := s.clusterRepo.GetByClusterName(ctx, req.ClusterName)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to check cluster name", zap.Error(err))
-		return v1.ErrInternalServerError
+		return nil, v1.ErrInternalServerError
 	}
 	if existing != nil {
-		return v1.ErrBadRequest
+		return nil, v1.ErrBadRequest
+	}
+	if err := s.checkSite(ctx, req.SiteID); err != nil {
+		return nil, err
 	}
 
 	cluster := &model.PveCluster{
-		ClusterName:      req.ClusterName,
-		ClusterNameAlias: req.ClusterNameAlias,
-		Env:              req.Env,
-		Datacenter:       req.Datacenter,
-		ApiUrl:           req.ApiUrl,
-		UserId:           req.UserId,
-		UserToken:        req.UserToken,
-		Dns:              req.Dns,
-		Describes:        req.Describes,
-		Region:           req.Region,
-		IsSchedulable:    req.IsSchedulable,
-		IsEnabled:        req.IsEnabled,
-		CreateTime:       time.Now(),
-		UpdateTime:       time.Now(),
+		ClusterName:           req.ClusterName,
+		ClusterNameAlias:      req.ClusterNameAlias,
+		Env:                   req.Env,
+		Datacenter:            req.Datacenter,
+		ApiUrl:                req.ApiUrl,
+		UserId:                req.UserId,
+		UserToken:             req.UserToken,
+		Dns:                   req.Dns,
+		Describes:             req.Describes,
+		Region:                req.Region,
+		SiteID:                req.SiteID,
+		IsSchedulable:         req.IsSchedulable,
+		IsEnabled:             req.IsEnabled,
+		ApiLogEnabled:         req.ApiLogEnabled,
+		CPUBaseline:           req.CPUBaseline,
+		ConsoleRecording:      req.ConsoleRecording,
+		ReservedCPUPercent:    req.ReservedCPUPercent,
+		ReservedMemoryPercent: req.ReservedMemoryPercent,
+		CreateTime:            time.Now(),
+		UpdateTime:            time.Now(),
 	}
 
 	if err := s.clusterRepo.Create(ctx, cluster); err != nil {
 		s.logger.WithContext(ctx).Error("failed to create cluster", zap.Error(err))
-		return v1.ErrInternalServerError
+		return nil, v1.ErrInternalServerError
 	}
 
-	return nil
+	// 注册时探测 Token 权限，探测失败不影响集群创建，可稍后重新探测
+	data := &v1.CreateClusterData{ID: cluster.Id}
+	capabilities, err := s.capabilityService.Probe(ctx, cluster.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to probe cluster capabilities", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+	} else {
+		data.Capabilities = capabilities
+	}
+	return data, nil
 }
 
 func (s *pveClusterService) UpdateCluster(ctx context.Context, id int64, req *v1.UpdateClusterRequest) error {
@@ -92,6 +116,10 @@ func (s *pveClusterService) UpdateCluster(ctx context.Context, id int64, req *v1
 		return v1.ErrNotFound
 	}
 
+	credentialsChanged := (req.ApiUrl != nil && *req.ApiUrl != cluster.ApiUrl) ||
+		(req.UserId != nil && *req.UserId != cluster.UserId) ||
+		(req.UserToken != nil && *req.UserToken != cluster.UserToken)
+
 	// 更新字段
 	if req.ClusterNameAlias != nil {
 		cluster.ClusterNameAlias = *req.ClusterNameAlias
@@ -120,12 +148,33 @@ func (s *pveClusterService) UpdateCluster(ctx context.Context, id int64, req *v1
 	if req.Region != nil {
 		cluster.Region = *req.Region
 	}
+	if req.SiteID != nil {
+		if err := s.checkSite(ctx, *req.SiteID); err != nil {
+			return err
+		}
+		cluster.SiteID = *req.SiteID
+	}
 	if req.IsSchedulable != nil {
 		cluster.IsSchedulable = *req.IsSchedulable
 	}
 	if req.IsEnabled != nil {
 		cluster.IsEnabled = *req.IsEnabled
 	}
+	if req.ApiLogEnabled != nil {
+		cluster.ApiLogEnabled = *req.ApiLogEnabled
+	}
+	if req.CPUBaseline != nil {
+		cluster.CPUBaseline = strings.TrimSpace(*req.CPUBaseline)
+	}
+	if req.ConsoleRecording != nil {
+		cluster.ConsoleRecording = *req.ConsoleRecording
+	}
+	if req.ReservedCPUPercent != nil {
+		cluster.ReservedCPUPercent = *req.ReservedCPUPercent
+	}
+	if req.ReservedMemoryPercent != nil {
+		cluster.ReservedMemoryPercent = *req.ReservedMemoryPercent
+	}
 	cluster.UpdateTime = time.Now()
 
 	if err := s.clusterRepo.Update(ctx, cluster); err != nil {
@@ -133,6 +182,32 @@ func (s *pveClusterService) UpdateCluster(ctx context.Context, id int64, req *v1
 		return v1.ErrInternalServerError
 	}
 
+	// 连接信息可能已变更，清除集群资源缓存
+	s.resources.Invalidate(id)
+
+	// 更换凭据后重新探测能力
+	if credentialsChanged {
+		if _, err := s.capabilityService.Probe(ctx, id); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to probe cluster capabilities", zap.Error(err), zap.Int64("cluster_id", id))
+		}
+	}
+
+	return nil
+}
+
+// checkSite 校验站点是否存在，0 表示不归属任何站点
+func (s *pveClusterService) checkSite(ctx context.Context, siteID int64) error {
+	if siteID == 0 {
+		return nil
+	}
+	site, err := s.siteRepo.GetByID(ctx, siteID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get site", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if site == nil {
+		return v1.WithDetailf(v1.ErrSiteNotFound, "site_id=%d", siteID)
+	}
 	return nil
 }
 
@@ -229,7 +304,32 @@ func (s *pveClusterService) DeleteCluster(ctx context.Context, id int64) error {
 		}
 		s.logger.WithContext(ctx).Debug("deleted nodes", zap.Int64("rows_affected", result.RowsAffected))
 
-		// 9. 最后删除集群本身
+		// 9. 删除虚拟机维护锁、待重试操作、能力探测结果和节点证书指纹
+		result = db.Table("vm_lock").Where("cluster_id = ?", id).Delete(&model.VMLock{})
+		if result.Error != nil {
+			s.logger.WithContext(ctx).Error("failed to delete vm locks", zap.Error(result.Error))
+			return result.Error
+		}
+
+		result = db.Table("pending_operation").Where("cluster_id = ?", id).Delete(&model.PendingOperation{})
+		if result.Error != nil {
+			s.logger.WithContext(ctx).Error("failed to delete pending operations", zap.Error(result.Error))
+			return result.Error
+		}
+
+		result = db.Table("cluster_capability").Where("cluster_id = ?", id).Delete(&model.ClusterCapability{})
+		if result.Error != nil {
+			s.logger.WithContext(ctx).Error("failed to delete cluster capability", zap.Error(result.Error))
+			return result.Error
+		}
+
+		result = db.Table("pve_node_certificate").Where("cluster_id = ?", id).Delete(&model.PveNodeCertificate{})
+		if result.Error != nil {
+			s.logger.WithContext(ctx).Error("failed to delete node certificates", zap.Error(result.Error))
+			return result.Error
+		}
+
+		// 10. 最后删除集群本身
 		if err := s.clusterRepo.Delete(ctx, id); err != nil {
 			s.logger.WithContext(ctx).Error("failed to delete cluster", zap.Error(err))
 			return err
@@ -245,6 +345,7 @@ func (s *pveClusterService) DeleteCluster(ctx context.Context, id int64) error {
 		s.logger.WithContext(ctx).Error("failed to delete cluster with cascade", zap.Error(err), zap.Int64("cluster_id", id))
 		return v1.ErrInternalServerError
 	}
+	s.resources.Invalidate(id)
 
 	return nil
 }
@@ -259,46 +360,85 @@ func (s *pveClusterService) GetCluster(ctx context.Context, id int64) (*v1.Clust
 		return nil, v1.ErrNotFound
 	}
 
+	var siteName string
+	if cluster.SiteID > 0 {
+		site, err := s.siteRepo.GetByID(ctx, cluster.SiteID)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get site", zap.Error(err), zap.Int64("site_id", cluster.SiteID))
+		} else if site != nil {
+			siteName = site.SiteName
+		}
+	}
+
 	return &v1.ClusterDetail{
-		Id:               cluster.Id,
-		ClusterName:      cluster.ClusterName,
-		ClusterNameAlias: cluster.ClusterNameAlias,
-		Env:              cluster.Env,
-		Datacenter:       cluster.Datacenter,
-		ApiUrl:           cluster.ApiUrl,
-		UserId:           cluster.UserId,
-		Dns:              cluster.Dns,
-		Describes:        cluster.Describes,
-		Region:           cluster.Region,
-		IsSchedulable:    cluster.IsSchedulable,
-		IsEnabled:        cluster.IsEnabled,
-		CreateTime:       cluster.CreateTime,
-		UpdateTime:       cluster.UpdateTime,
-		Creator:          cluster.Creator,
-		Modifier:         cluster.Modifier,
+		Id:                    cluster.Id,
+		ClusterName:           cluster.ClusterName,
+		ClusterNameAlias:      cluster.ClusterNameAlias,
+		Env:                   cluster.Env,
+		Datacenter:            cluster.Datacenter,
+		ApiUrl:                cluster.ApiUrl,
+		UserId:                cluster.UserId,
+		Dns:                   cluster.Dns,
+		Describes:             cluster.Describes,
+		Region:                cluster.Region,
+		SiteID:                cluster.SiteID,
+		SiteName:              siteName,
+		IsSchedulable:         cluster.IsSchedulable,
+		IsEnabled:             cluster.IsEnabled,
+		ApiLogEnabled:         cluster.ApiLogEnabled,
+		CPUBaseline:           cluster.CPUBaseline,
+		ConsoleRecording:      cluster.ConsoleRecording,
+		ReservedCPUPercent:    cluster.ReservedCPUPercent,
+		ReservedMemoryPercent: cluster.ReservedMemoryPercent,
+		CreateTime:            cluster.CreateTime,
+		UpdateTime:            cluster.UpdateTime,
+		Creator:               cluster.Creator,
+		Modifier:              cluster.Modifier,
 	}, nil
 }
 
 func (s *pveClusterService) ListClusters(ctx context.Context, req *v1.ListClusterRequest) (*v1.ListClusterResponseData, error) {
-	clusters, total, err := s.clusterRepo.ListWithPagination(ctx, req.Page, req.PageSize, req.Env, req.Region)
+	clusters, total, err := s.clusterRepo.ListWithPagination(ctx, req.Page, req.PageSize, req.Env, req.Region, req.SiteID)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
 		return nil, v1.ErrInternalServerError
 	}
 
+	// 批量填充站点名称
+	siteIDs := make([]int64, 0, len(clusters))
+	for _, cluster := range clusters {
+		if cluster.SiteID > 0 {
+			siteIDs = append(siteIDs, cluster.SiteID)
+		}
+	}
+	sites, err := s.siteRepo.GetByIDs(ctx, siteIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get sites", zap.Error(err))
+		sites = nil
+	}
+
 	items := make([]v1.ClusterItem, 0, len(clusters))
 	for _, cluster := range clusters {
 		items = append(items, v1.ClusterItem{
-			Id:               cluster.Id,
-			ClusterName:      cluster.ClusterName,
-			ClusterNameAlias: cluster.ClusterNameAlias,
-			Env:              cluster.Env,
-			Datacenter:       cluster.Datacenter,
-			ApiUrl:           cluster.ApiUrl,
-			Region:           cluster.Region,
-			IsSchedulable:    cluster.IsSchedulable,
-			IsEnabled:        cluster.IsEnabled,
+			Id:                    cluster.Id,
+			ClusterName:           cluster.ClusterName,
+			ClusterNameAlias:      cluster.ClusterNameAlias,
+			Env:                   cluster.Env,
+			Datacenter:            cluster.Datacenter,
+			ApiUrl:                cluster.ApiUrl,
+			Region:                cluster.Region,
+			SiteID:                cluster.SiteID,
+			IsSchedulable:         cluster.IsSchedulable,
+			IsEnabled:             cluster.IsEnabled,
+			ApiLogEnabled:         cluster.ApiLogEnabled,
+			CPUBaseline:           cluster.CPUBaseline,
+			ConsoleRecording:      cluster.ConsoleRecording,
+			ReservedCPUPercent:    cluster.ReservedCPUPercent,
+			ReservedMemoryPercent: cluster.ReservedMemoryPercent,
 		})
+		if site, ok := sites[cluster.SiteID]; ok {
+			items[len(items)-1].SiteName = site.SiteName
+		}
 	}
 
 	return &v1.ListClusterResponseData{
@@ -319,7 +459,7 @@ func (s *pveClusterService) GetClusterStatus(ctx context.Context, clusterID int6
 	}
 
 	// 2. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
 		return nil, v1.ErrInternalServerError
@@ -335,7 +475,7 @@ func (s *pveClusterService) GetClusterStatus(ctx context.Context, clusterID int6
 	return status, nil
 }
 
-func (s *pveClusterService) GetClusterResources(ctx context.Context, clusterID int64) ([]map[string]interface{}, error) {
+func (s *pveClusterService) GetClusterResources(ctx context.Context, clusterID int64, resourceType string) ([]map[string]interface{}, error) {
 	// 1. 获取集群信息
 	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
 	if err != nil {
@@ -347,14 +487,22 @@ func (s *pveClusterService) GetClusterResources(ctx context.Context, clusterID i
 	}
 
 	// 2. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
 		return nil, v1.ErrInternalServerError
 	}
 
-	// 3. 获取集群资源
-	resources, err := proxmoxClient.GetClusterResources(ctx)
+	// 3. 获取集群资源（走增量缓存，vm 类型同时包含 qemu 和 lxc）
+	var resources []map[string]interface{}
+	switch resourceType {
+	case "":
+		resources, err = s.resources.Get(ctx, clusterID, proxmoxClient)
+	case "vm":
+		resources, err = s.resources.GetByType(ctx, clusterID, proxmoxClient, "qemu", "lxc")
+	default:
+		resources, err = s.resources.GetByType(ctx, clusterID, proxmoxClient, resourceType)
+	}
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to get cluster resources", zap.Error(err))
 		return nil, v1.ErrInternalServerError
@@ -413,3 +561,19 @@ func (s *pveClusterService) VerifyClusterWithCredentials(ctx context.Context, ap
 		Message:   "connection successful",
 	}, nil
 }
+
+// nodeReservedCPU 按集群的预留比例计算节点为宿主机、Ceph 和系统进程预留的 CPU 核数
+func nodeReservedCPU(cluster *model.PveCluster, maxCPU float64) float64 {
+	if cluster == nil || cluster.ReservedCPUPercent <= 0 {
+		return 0
+	}
+	return maxCPU * float64(cluster.ReservedCPUPercent) / 100
+}
+
+// nodeReservedMemory 按集群的预留比例计算节点为宿主机、Ceph 和系统进程预留的内存（字节）
+func nodeReservedMemory(cluster *model.PveCluster, maxMem float64) float64 {
+	if cluster == nil || cluster.ReservedMemoryPercent <= 0 {
+		return 0
+	}
+	return maxMem * float64(cluster.ReservedMemoryPercent) / 100
+}
@@ -1,15 +1,16 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
 	"mime/multipart"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	v1 "pvesphere/api/v1"
@@ -49,46 +50,103 @@ type PveNodeService interface {
 	GetNodeStorageStatus(ctx context.Context, nodeID int64, storage string) (map[string]interface{}, error)
 	GetNodeStorageRRDData(ctx context.Context, nodeID int64, storage, timeframe, cf string) ([]map[string]interface{}, error)
 	GetNodeStorageContent(ctx context.Context, nodeID int64, storage, content string) ([]map[string]interface{}, error)
+	// ListNodeStorageContent 分页获取节点存储内容，支持过滤、排序和按内容类型汇总
+	ListNodeStorageContent(ctx context.Context, req *v1.ListStorageContentRequest) (*v1.ListStorageContentData, error)
 	GetNodeStorageVolume(ctx context.Context, nodeID int64, storage, volume string) (map[string]interface{}, error)
 	UploadNodeStorageContent(ctx context.Context, nodeID int64, storage, content, filename string, file multipart.File) (interface{}, error)
 	DeleteNodeStorageContent(ctx context.Context, nodeID int64, storage, volume string, delay *int) error
-	GetNodeConsole(ctx context.Context, req *v1.GetNodeConsoleRequest) (map[string]interface{}, error)
-	DialNodeConsoleWebsocket(ctx context.Context, token string) (*websocket.Conn, error)
+	GetNodeConsole(ctx context.Context, userID string, req *v1.GetNodeConsoleRequest) (map[string]interface{}, error)
+	DialNodeConsoleWebsocket(ctx context.Context, token string) (*websocket.Conn, *ConsoleTarget, error)
 }
 
 func NewPveNodeService(
 	service *Service,
 	nodeRepo repository.PveNodeRepository,
 	clusterRepo repository.PveClusterRepository,
+	diskHealthRepo repository.NodeDiskHealthRepository,
+	sharedTokens SharedTokenStore,
 	logger *log.Logger,
 ) PveNodeService {
 	return &pveNodeService{
-		nodeRepo:    nodeRepo,
-		clusterRepo: clusterRepo,
-		Service:     service,
-		logger:      logger,
+		nodeRepo:       nodeRepo,
+		clusterRepo:    clusterRepo,
+		diskHealthRepo: diskHealthRepo,
+		sharedTokens:   sharedTokens,
+		Service:        service,
+		logger:         logger,
 	}
 }
 
 type pveNodeService struct {
-	nodeRepo    repository.PveNodeRepository
-	clusterRepo repository.PveClusterRepository
+	nodeRepo       repository.PveNodeRepository
+	clusterRepo    repository.PveClusterRepository
+	diskHealthRepo repository.NodeDiskHealthRepository
+	// sharedTokens 保存 ws_token -> nodeConsoleSession，任一实例签发的 token 可在其他实例上连接
+	sharedTokens SharedTokenStore
 	*Service
 	logger *log.Logger
-
-	consoleSessions sync.Map // token -> nodeConsoleSession
 }
 
 type nodeConsoleSession struct {
-	NodeID    int64
-	NodeName  string
-	Port      int
-	Ticket    string // VNC ticket（用于 vncwebsocket 连接）
-	ExpiresAt time.Time
+	UserID      string // 申请控制台的用户（用于会话审计）
+	NodeID      int64
+	NodeName    string
+	ClusterID   int64
+	ConsoleType string
+	Port        int
+	Ticket      string // VNC ticket（用于 vncwebsocket 连接）
+	ExpiresAt   time.Time
 	// 高权限认证信息（可选）：如果原始请求使用了 ticket + csrf_token，保存这些信息用于 WebSocket 连接
 	AuthTicket    string // Proxmox 高权限认证 ticket
 	AuthCSRFToken string // CSRF 防护令牌
 	ClusterApiURL string // 集群 API URL（用于创建 ProxmoxClient）
+	// xtermjs 模式：代理完成 termproxy 认证后按 Cols x Rows 设置初始终端大小
+	TermUser string
+	Cols     int
+	Rows     int
+}
+
+const (
+	// nodeConsoleXtermjs 终端控制台，由代理处理 termproxy 协议，客户端直接收发终端数据
+	nodeConsoleXtermjs = "xtermjs"
+	defaultTermCols    = 80
+	defaultTermRows    = 24
+	// termProxyHandshakeTimeout 等待 termproxy 认证结果的时间
+	termProxyHandshakeTimeout = 10 * time.Second
+)
+
+// termProxyHandshake 代替客户端完成 termproxy 认证，并设置初始终端大小
+func termProxyHandshake(conn *websocket.Conn, session nodeConsoleSession) error {
+	if err := conn.WriteMessage(websocket.BinaryMessage, proxmox.TermProxyAuthMessage(session.TermUser, session.Ticket)); err != nil {
+		return err
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(termProxyHandshakeTimeout))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	if !bytes.HasPrefix(msg, []byte("OK")) {
+		return fmt.Errorf("termproxy authentication failed: %q", msg)
+	}
+	return conn.WriteMessage(websocket.BinaryMessage, proxmox.TermProxyResizeMessage(session.Cols, session.Rows))
+}
+
+// consoleKeyboard 控制台键盘布局：优先使用请求中的布局，否则使用数据中心选项中的 keyboard，都没有时为 en-us。
+// 节点 shell 没有服务端键盘映射，布局返回给客户端（noVNC / xterm.js）用于按键转换
+func (s *pveNodeService) consoleKeyboard(ctx context.Context, client *proxmox.ProxmoxClient, keyboard string) string {
+	if keyboard != "" {
+		return keyboard
+	}
+	options, err := client.GetClusterOptions(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get datacenter options for console keyboard", zap.Error(err))
+		return "en-us"
+	}
+	if layout, _ := options["keyboard"].(string); layout != "" {
+		return layout
+	}
+	return "en-us"
 }
 
 func newNodeConsoleToken() (string, error) {
@@ -222,6 +280,9 @@ func (s *pveNodeService) GetNode(ctx context.Context, id int64) (*v1.NodeDetail,
 		Status:        node.Status,
 		Annotations:   node.Annotations,
 		VMLimit:       node.VMLimit,
+		WolMAC:        node.WolMAC,
+		LastWakeTime:  node.LastWakeTime,
+		Waking:        nodeWaking(node),
 		CreateTime:    node.CreateTime,
 		UpdateTime:    node.UpdateTime,
 		Creator:       node.Creator,
@@ -230,12 +291,28 @@ func (s *pveNodeService) GetNode(ctx context.Context, id int64) (*v1.NodeDetail,
 }
 
 func (s *pveNodeService) ListNodes(ctx context.Context, req *v1.ListNodeRequest) (*v1.ListNodeResponseData, error) {
+	if req.Storage == "" && (req.MinStorageFreeGB > 0 || req.SortBy == "storage_free") {
+		return nil, v1.WithDetail(v1.ErrBadRequest, "storage is required when filtering or sorting by storage free space")
+	}
+	if req.NeedMetrics() {
+		return s.listNodesWithMetrics(ctx, req)
+	}
+
 	nodes, total, err := s.nodeRepo.ListWithPagination(ctx, req.Page, req.PageSize, req.ClusterID, req.Env, req.Status)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to list nodes", zap.Error(err))
 		return nil, v1.ErrInternalServerError
 	}
 
+	items, _ := s.nodeItems(ctx, nodes)
+	return &v1.ListNodeResponseData{
+		Total: total,
+		List:  items,
+	}, nil
+}
+
+// nodeItems 构建节点列表项并填充 cluster_name，同时返回涉及的集群
+func (s *pveNodeService) nodeItems(ctx context.Context, nodes []*model.PveNode) ([]v1.NodeItem, map[int64]*model.PveCluster) {
 	// 1. 提取所有唯一的 cluster_id
 	clusterIDs := make([]int64, 0)
 	clusterIDSet := make(map[int64]struct{})
@@ -273,6 +350,8 @@ func (s *pveNodeService) ListNodes(ctx context.Context, req *v1.ListNodeRequest)
 			Env:           node.Env,
 			Status:        node.Status,
 			VMLimit:       node.VMLimit,
+			WolMAC:        node.WolMAC,
+			Waking:        nodeWaking(node),
 		}
 
 		// 填充 cluster_name
@@ -282,13 +361,169 @@ func (s *pveNodeService) ListNodes(ctx context.Context, req *v1.ListNodeRequest)
 
 		items = append(items, item)
 	}
+	return items, clusterMap
+}
+
+// listNodesWithMetrics 查询全部符合条件的节点并关联实时指标，在服务端过滤、排序后分页
+func (s *pveNodeService) listNodesWithMetrics(ctx context.Context, req *v1.ListNodeRequest) (*v1.ListNodeResponseData, error) {
+	nodes, err := s.nodeRepo.List(ctx, req.ClusterID, req.Env, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	items, clusterMap := s.nodeItems(ctx, nodes)
+
+	// 每个集群只查询一次 cluster/resources
+	metrics := make(map[int64]map[string]*v1.NodeLiveMetrics, len(clusterMap))
+	for id, cluster := range clusterMap {
+		metrics[id] = s.clusterNodeMetrics(ctx, cluster, req.Storage)
+	}
+
+	minStorageFree := int64(req.MinStorageFreeGB * (1 << 30))
+	filtered := make([]v1.NodeItem, 0, len(items))
+	for _, item := range items {
+		item.Metrics = metrics[item.ClusterID][item.NodeName]
+		m := item.Metrics
+		// 没有实时指标或离线的节点不满足任何实时指标过滤条件
+		usable := m != nil && m.Online
+		if req.MaxCPUPercent > 0 && (!usable || m.CPUPercent > req.MaxCPUPercent) {
+			continue
+		}
+		if req.MaxMemoryPercent > 0 && (!usable || m.MemoryPercent > req.MaxMemoryPercent) {
+			continue
+		}
+		if req.MinStorageFreeGB > 0 && (!usable || m.StorageFree == nil || *m.StorageFree < minStorageFree) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
 
+	sortNodeItems(filtered, req.SortBy, req.Order)
+
+	total := int64(len(filtered))
+	start := (req.Page - 1) * req.PageSize
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	end := min(start+req.PageSize, len(filtered))
 	return &v1.ListNodeResponseData{
 		Total: total,
-		List:  items,
+		List:  filtered[start:end],
 	}, nil
 }
 
+// clusterNodeMetrics 从 cluster/resources 汇总集群内各节点的实时指标，key 为节点名
+// 集群无法连接时返回空，节点列表仍然返回，只是不带实时指标
+func (s *pveNodeService) clusterNodeMetrics(ctx context.Context, cluster *model.PveCluster, storage string) map[string]*v1.NodeLiveMetrics {
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to create proxmox client", zap.Error(err), zap.String("cluster", cluster.ClusterName))
+		return nil
+	}
+	resources, err := client.GetClusterResources(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get cluster resources", zap.Error(err), zap.String("cluster", cluster.ClusterName))
+		return nil
+	}
+
+	result := make(map[string]*v1.NodeLiveMetrics)
+	get := func(name string) *v1.NodeLiveMetrics {
+		m, ok := result[name]
+		if !ok {
+			m = &v1.NodeLiveMetrics{}
+			result[name] = m
+		}
+		return m
+	}
+	for _, resource := range resources {
+		name, _ := resource["node"].(string)
+		if name == "" {
+			continue
+		}
+		switch resource["type"] {
+		case "node":
+			m := get(name)
+			status, _ := resource["status"].(string)
+			m.Online = status == "online"
+			m.CPUCores = int(resourceFloat(resource, "maxcpu"))
+			m.CPUPercent = roundPercent(resourceFloat(resource, "cpu") * 100)
+			maxMem, mem := resourceFloat(resource, "maxmem"), resourceFloat(resource, "mem")
+			m.MemoryTotal, m.MemoryUsed = int64(maxMem), int64(mem)
+			if maxMem > 0 {
+				m.MemoryPercent = roundPercent(mem / maxMem * 100)
+			}
+			// 集群为系统开销预留的内存不算可用内存
+			m.MemoryFree = int64(max(maxMem-nodeReservedMemory(cluster, maxMem)-mem, 0))
+		case "qemu":
+			status, _ := resource["status"].(string)
+			if status == "running" && resourceFloat(resource, "template") != 1 {
+				get(name).RunningVMs++
+			}
+		case "storage":
+			if storage == "" {
+				continue
+			}
+			if id, _ := resource["storage"].(string); id != storage {
+				continue
+			}
+			maxDisk, disk := int64(resourceFloat(resource, "maxdisk")), int64(resourceFloat(resource, "disk"))
+			free := max(maxDisk-disk, 0)
+			m := get(name)
+			m.StorageTotal, m.StorageFree = &maxDisk, &free
+		}
+	}
+	return result
+}
+
+// sortNodeItems 按排序字段排序，sort_by=id 时默认倒序，其余默认正序；没有对应指标的节点始终排在最后
+func sortNodeItems(items []v1.NodeItem, sortBy, order string) {
+	if sortBy == "" {
+		sortBy = "id"
+	}
+	desc := order == "desc" || (order == "" && sortBy == "id")
+	value := func(item v1.NodeItem) (float64, bool) {
+		if sortBy == "id" {
+			return float64(item.Id), true
+		}
+		m := item.Metrics
+		if m == nil || !m.Online {
+			return 0, false
+		}
+		switch sortBy {
+		case "cpu":
+			return m.CPUPercent, true
+		case "memory":
+			return m.MemoryPercent, true
+		case "running_vms":
+			return float64(m.RunningVMs), true
+		}
+		if m.StorageFree == nil {
+			return 0, false
+		}
+		return float64(*m.StorageFree), true
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if sortBy == "name" {
+			if desc {
+				return items[i].NodeName > items[j].NodeName
+			}
+			return items[i].NodeName < items[j].NodeName
+		}
+		vi, oki := value(items[i])
+		vj, okj := value(items[j])
+		if oki != okj {
+			return oki
+		}
+		if vi == vj {
+			return items[i].Id > items[j].Id
+		}
+		if desc {
+			return vi > vj
+		}
+		return vi < vj
+	})
+}
+
 // getProxmoxClientForNode 根据节点ID获取ProxmoxClient
 func (s *pveNodeService) getProxmoxClientForNode(ctx context.Context, nodeID int64) (*proxmox.ProxmoxClient, *model.PveNode, error) {
 	// 1. 获取节点信息
@@ -303,7 +538,7 @@ func (s *pveNodeService) getProxmoxClientForNode(ctx context.Context, nodeID int
 
 	// 2. 获取集群信息
 	if node.ClusterID <= 0 {
-		return nil, nil, fmt.Errorf("节点的集群 ID 无效")
+		return nil, nil, v1.ErrInvalidClusterID
 	}
 	cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
 	if err != nil {
@@ -311,11 +546,11 @@ func (s *pveNodeService) getProxmoxClientForNode(ctx context.Context, nodeID int
 		return nil, nil, v1.ErrInternalServerError
 	}
 	if cluster == nil {
-		return nil, nil, fmt.Errorf("集群 ID %d 不存在", node.ClusterID)
+		return nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", node.ClusterID)
 	}
 
 	// 3. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
 		return nil, nil, v1.ErrInternalServerError
@@ -621,9 +856,51 @@ func (s *pveNodeService) GetNodeDisksList(ctx context.Context, nodeID int64, inc
 		return nil, v1.ErrInternalServerError
 	}
 
+	s.attachDiskHealth(ctx, nodeID, disks)
 	return disks, nil
 }
 
+// attachDiskHealth 为磁盘列表补充最近一次 SMART 采集的关键指标（smart）和未恢复告警的原因（smart_alert），
+// 查询失败只记录日志，不影响磁盘列表
+func (s *pveNodeService) attachDiskHealth(ctx context.Context, nodeID int64, disks []map[string]interface{}) {
+	healths, err := s.diskHealthRepo.ListHealthByNode(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to list node disk health", zap.Int64("node_id", nodeID), zap.Error(err))
+		return
+	}
+	alerts, err := s.diskHealthRepo.ListOpenAlertsByNode(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to list node disk alerts", zap.Int64("node_id", nodeID), zap.Error(err))
+		return
+	}
+	byPath := make(map[string]*model.NodeDiskHealth, len(healths))
+	for _, h := range healths {
+		byPath[h.DevPath] = h
+	}
+	alertReasons := make(map[string]string, len(alerts))
+	for _, a := range alerts {
+		alertReasons[a.DevPath] = a.Reasons
+	}
+
+	for _, disk := range disks {
+		devpath, _ := disk["devpath"].(string)
+		if h := byPath[devpath]; h != nil {
+			disk["smart"] = map[string]interface{}{
+				"health":              h.Health,
+				"reallocated_sectors": h.ReallocatedSectors,
+				"pending_sectors":     h.PendingSectors,
+				"uncorrectable":       h.Uncorrectable,
+				"power_on_hours":      h.PowerOnHours,
+				"temperature":         h.Temperature,
+				"collect_time":        h.CollectTime,
+			}
+		}
+		if reasons, ok := alertReasons[devpath]; ok {
+			disk["smart_alert"] = reasons
+		}
+	}
+}
+
 func (s *pveNodeService) GetNodeDisksDirectory(ctx context.Context, nodeID int64) ([]map[string]interface{}, error) {
 	client, node, err := s.getProxmoxClientForNode(ctx, nodeID)
 	if err != nil {
@@ -795,6 +1072,100 @@ func (s *pveNodeService) GetNodeStorageContent(ctx context.Context, nodeID int64
 	return items, nil
 }
 
+// ListNodeStorageContent 分页获取节点存储内容，Proxmox 只能一次返回全部卷，过滤、排序、汇总和分页在这里完成
+func (s *pveNodeService) ListNodeStorageContent(ctx context.Context, req *v1.ListStorageContentRequest) (*v1.ListStorageContentData, error) {
+	items, err := s.GetNodeStorageContent(ctx, req.NodeID, req.Storage, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	return pageStorageContent(items, req), nil
+}
+
+// 存储内容列表默认每页数量
+const defaultStorageContentPageSize = 100
+
+// pageStorageContent 按 vmid、名称过滤存储内容，汇总各内容类型的数量和大小后排序分页
+func pageStorageContent(items []map[string]interface{}, req *v1.ListStorageContentRequest) *v1.ListStorageContentData {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	filtered := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if req.VMID > 0 && parseContentVMID(item["vmid"]) != req.VMID {
+			continue
+		}
+		if name != "" {
+			volid, _ := item["volid"].(string)
+			notes, _ := item["notes"].(string)
+			if !strings.Contains(strings.ToLower(volid), name) && !strings.Contains(strings.ToLower(notes), name) {
+				continue
+			}
+		}
+		filtered = append(filtered, item)
+	}
+
+	data := &v1.ListStorageContentData{Total: int64(len(filtered))}
+	summary := make(map[string]*v1.StorageContentSummary)
+	for _, item := range filtered {
+		content, _ := item["content"].(string)
+		size, _ := item["size"].(float64)
+		sum, ok := summary[content]
+		if !ok {
+			sum = &v1.StorageContentSummary{Content: content}
+			summary[content] = sum
+		}
+		sum.Count++
+		sum.Size += int64(size)
+		data.TotalSize += int64(size)
+	}
+	data.Summary = make([]v1.StorageContentSummary, 0, len(summary))
+	for _, sum := range summary {
+		data.Summary = append(data.Summary, *sum)
+	}
+	sort.Slice(data.Summary, func(i, j int) bool { return data.Summary[i].Content < data.Summary[j].Content })
+
+	sortBy, desc := req.SortBy, req.Order == "desc"
+	if sortBy == "" {
+		sortBy = "name"
+	}
+	if req.Order == "" {
+		desc = sortBy != "name"
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i], filtered[j]
+		if desc {
+			a, b = b, a
+		}
+		switch sortBy {
+		case "size", "ctime":
+			x, _ := a[sortBy].(float64)
+			y, _ := b[sortBy].(float64)
+			if x != y {
+				return x < y
+			}
+		}
+		x, _ := a["volid"].(string)
+		y, _ := b["volid"].(string)
+		return x < y
+	})
+
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = defaultStorageContentPageSize
+	}
+	start := (page - 1) * pageSize
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	end := start + pageSize
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+	data.List = filtered[start:end]
+	return data
+}
+
 // GetNodeStorageVolume 获取节点存储卷属性
 func (s *pveNodeService) GetNodeStorageVolume(ctx context.Context, nodeID int64, storage, volume string) (map[string]interface{}, error) {
 	client, node, err := s.getProxmoxClientForNode(ctx, nodeID)
@@ -867,11 +1238,11 @@ func (s *pveNodeService) DeleteNodeStorageContent(
 }
 
 // GetNodeConsole 获取节点控制台信息
-func (s *pveNodeService) GetNodeConsole(ctx context.Context, req *v1.GetNodeConsoleRequest) (map[string]interface{}, error) {
+func (s *pveNodeService) GetNodeConsole(ctx context.Context, userID string, req *v1.GetNodeConsoleRequest) (map[string]interface{}, error) {
 	// 验证控制台类型
 	req.ConsoleType = strings.ToLower(strings.TrimSpace(req.ConsoleType))
-	if req.ConsoleType != "termproxy" && req.ConsoleType != "vncshell" {
-		return nil, fmt.Errorf("invalid console_type: %s (must be 'termproxy' or 'vncshell')", req.ConsoleType)
+	if req.ConsoleType != "termproxy" && req.ConsoleType != nodeConsoleXtermjs && req.ConsoleType != "vncshell" {
+		return nil, fmt.Errorf("invalid console_type: %s (must be 'termproxy', 'xtermjs' or 'vncshell')", req.ConsoleType)
 	}
 
 	// 获取节点信息
@@ -886,7 +1257,7 @@ func (s *pveNodeService) GetNodeConsole(ctx context.Context, req *v1.GetNodeCons
 
 	// 获取集群信息（用于获取 api_url）
 	if node.ClusterID <= 0 {
-		return nil, fmt.Errorf("节点的集群 ID 无效")
+		return nil, v1.ErrInvalidClusterID
 	}
 	cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
 	if err != nil {
@@ -894,14 +1265,14 @@ func (s *pveNodeService) GetNodeConsole(ctx context.Context, req *v1.GetNodeCons
 		return nil, v1.ErrInternalServerError
 	}
 	if cluster == nil {
-		return nil, fmt.Errorf("集群 ID %d 不存在", node.ClusterID)
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", node.ClusterID)
 	}
 
 	// 如果提供了 ticket 和 csrf_token，使用高权限认证方式；否则使用集群配置的 API Token
 	var client *proxmox.ProxmoxClient
 	if strings.TrimSpace(req.Ticket) != "" && strings.TrimSpace(req.CSRFToken) != "" {
 		// 使用高权限 ticket 和 CSRF token 创建客户端
-		client, err = proxmox.NewProxmoxClientWithTicket(cluster.ApiUrl, req.Ticket, req.CSRFToken)
+		client, err = proxmox.NewProxmoxClientWithTicket(cluster.ApiUrl, req.Ticket, req.CSRFToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 		if err != nil {
 			s.logger.WithContext(ctx).Error("failed to create proxmox client with ticket", zap.Error(err))
 			return nil, v1.ErrInternalServerError
@@ -911,7 +1282,7 @@ func (s *pveNodeService) GetNodeConsole(ctx context.Context, req *v1.GetNodeCons
 			zap.String("node_name", node.NodeName))
 	} else {
 		// 使用集群配置的 API Token
-		client, err = proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+		client, err = proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 		if err != nil {
 			s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
 			return nil, v1.ErrInternalServerError
@@ -942,7 +1313,7 @@ func (s *pveNodeService) GetNodeConsole(ctx context.Context, req *v1.GetNodeCons
 
 	var result map[string]interface{}
 
-	if req.ConsoleType == "termproxy" {
+	if req.ConsoleType == "termproxy" || req.ConsoleType == nodeConsoleXtermjs {
 		// 终端代理模式
 		// 注意：termproxy 返回的数据结构与 vncshell 相同（包含 port、ticket、user、upid 等）
 		result, err = client.NodeTermProxy(ctx, node.NodeName)
@@ -965,8 +1336,10 @@ func (s *pveNodeService) GetNodeConsole(ctx context.Context, req *v1.GetNodeCons
 		s.logger.WithContext(ctx).Debug("calling NodeVncShell",
 			zap.String("node_name", node.NodeName),
 			zap.Bool("websocket", websocket),
-			zap.Bool("generate_password", req.GeneratePassword))
-		result, err = client.NodeVncShell(ctx, node.NodeName, websocket, req.GeneratePassword)
+			zap.Bool("generate_password", req.GeneratePassword),
+			zap.Int("width", req.Width),
+			zap.Int("height", req.Height))
+		result, err = client.NodeVncShell(ctx, node.NodeName, websocket, req.GeneratePassword, req.Width, req.Height)
 		if err != nil {
 			s.logger.WithContext(ctx).Error("failed to get node vncshell",
 				zap.Error(err),
@@ -1019,8 +1392,11 @@ func (s *pveNodeService) GetNodeConsole(ctx context.Context, req *v1.GetNodeCons
 	}
 	exp := time.Now().Add(2 * time.Minute)
 	session := nodeConsoleSession{
+		UserID:        userID,
 		NodeID:        req.NodeID,
 		NodeName:      node.NodeName,
+		ClusterID:     node.ClusterID,
+		ConsoleType:   req.ConsoleType,
 		Port:          port,
 		Ticket:        ticket, // VNC ticket（用于 vncwebsocket）
 		ExpiresAt:     exp,
@@ -1031,46 +1407,61 @@ func (s *pveNodeService) GetNodeConsole(ctx context.Context, req *v1.GetNodeCons
 		session.AuthTicket = req.Ticket
 		session.AuthCSRFToken = req.CSRFToken
 	}
-	s.consoleSessions.Store(token, session)
+	// xtermjs 模式由代理完成 termproxy 认证并设置初始终端大小
+	if req.ConsoleType == nodeConsoleXtermjs {
+		session.TermUser, _ = result["user"].(string)
+		session.Cols, session.Rows = req.Cols, req.Rows
+		if session.Cols == 0 {
+			session.Cols = defaultTermCols
+		}
+		if session.Rows == 0 {
+			session.Rows = defaultTermRows
+		}
+		result["cols"] = session.Cols
+		result["rows"] = session.Rows
+	}
+	if err := s.sharedTokens.Put(ctx, model.SharedTokenNodeConsole, token, session, exp); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save console token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
 	result["ws_token"] = token
 	result["ws_expires_at"] = exp.Unix()
+	result["console_type"] = req.ConsoleType
+	result["keyboard"] = s.consoleKeyboard(ctx, client, req.Keyboard)
 
 	return result, nil
 }
 
-// DialNodeConsoleWebsocket 通过 ws_token 建立到 Proxmox vncwebsocket 的连接（单次使用/短期有效）
-func (s *pveNodeService) DialNodeConsoleWebsocket(ctx context.Context, token string) (*websocket.Conn, error) {
+// DialNodeConsoleWebsocket 通过 ws_token 建立到 Proxmox vncwebsocket 的连接（单次使用/短期有效），同时返回用于会话审计的归属信息
+func (s *pveNodeService) DialNodeConsoleWebsocket(ctx context.Context, token string) (*websocket.Conn, *ConsoleTarget, error) {
 	if strings.TrimSpace(token) == "" {
-		return nil, v1.ErrBadRequest
+		return nil, nil, v1.ErrBadRequest
 	}
 
-	val, ok := s.consoleSessions.LoadAndDelete(token)
-	if !ok {
-		return nil, v1.ErrNotFound
+	var session nodeConsoleSession
+	ok, err := s.sharedTokens.Consume(ctx, model.SharedTokenNodeConsole, token, &session)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load console token", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
 	}
-	session, ok := val.(nodeConsoleSession)
 	if !ok {
-		return nil, v1.ErrInternalServerError
-	}
-	if time.Now().After(session.ExpiresAt) {
-		return nil, v1.ErrUnauthorized
+		return nil, nil, v1.ErrNotFound
 	}
 
 	// 如果 session 中保存了高权限认证信息，使用这些信息创建客户端；否则使用集群配置的 API Token
 	var client *proxmox.ProxmoxClient
-	var err error
 	if session.AuthTicket != "" && session.AuthCSRFToken != "" {
 		// 使用高权限 ticket 和 CSRF token
 		client, err = proxmox.NewProxmoxClientWithTicket(session.ClusterApiURL, session.AuthTicket, session.AuthCSRFToken)
 		if err != nil {
 			s.logger.WithContext(ctx).Error("failed to create proxmox client with ticket for websocket", zap.Error(err))
-			return nil, v1.ErrInternalServerError
+			return nil, nil, v1.ErrInternalServerError
 		}
 	} else {
 		// 使用集群配置的 API Token
 		client, _, err = s.getProxmoxClientForNode(ctx, session.NodeID)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
@@ -1089,7 +1480,24 @@ func (s *pveNodeService) DialNodeConsoleWebsocket(ctx context.Context, token str
 			zap.String("node", session.NodeName),
 			zap.Int64("node_id", session.NodeID),
 			zap.Int("response_status", statusCode))
-		return nil, v1.ErrInternalServerError
+		return nil, nil, v1.ErrInternalServerError
 	}
-	return conn, nil
+	if session.ConsoleType == nodeConsoleXtermjs {
+		if err := termProxyHandshake(conn, session); err != nil {
+			conn.Close()
+			s.logger.WithContext(ctx).Error("termproxy handshake failed", zap.Error(err),
+				zap.String("node", session.NodeName),
+				zap.Int64("node_id", session.NodeID))
+			return nil, nil, v1.ErrInternalServerError
+		}
+	}
+	return conn, &ConsoleTarget{
+		UserID:      session.UserID,
+		TargetType:  model.ConsoleSessionTargetNode,
+		TargetID:    session.NodeID,
+		TargetName:  session.NodeName,
+		ClusterID:   session.ClusterID,
+		NodeName:    session.NodeName,
+		ConsoleType: session.ConsoleType,
+	}, nil
 }
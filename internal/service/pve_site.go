@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+type PveSiteService interface {
+	CreateSite(ctx context.Context, req *v1.CreateSiteRequest, creator string) error
+	UpdateSite(ctx context.Context, id int64, req *v1.UpdateSiteRequest, modifier string) error
+	DeleteSite(ctx context.Context, id int64) error
+	GetSite(ctx context.Context, id int64) (*v1.SiteDetail, error)
+	ListSites(ctx context.Context) (*v1.ListSitesResponseData, error)
+}
+
+func NewPveSiteService(
+	service *Service,
+	siteRepo repository.PveSiteRepository,
+	clusterRepo repository.PveClusterRepository,
+	logger *log.Logger,
+) PveSiteService {
+	return &pveSiteService{
+		siteRepo:    siteRepo,
+		clusterRepo: clusterRepo,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type pveSiteService struct {
+	siteRepo    repository.PveSiteRepository
+	clusterRepo repository.PveClusterRepository
+	*Service
+	logger *log.Logger
+}
+
+func (s *pveSiteService) CreateSite(ctx context.Context, req *v1.CreateSiteRequest, creator string) error {
+	existing, err := s.siteRepo.GetBySiteName(ctx, req.SiteName)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to check site name", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return v1.WithDetail(v1.ErrSiteNameExists, req.SiteName)
+	}
+
+	site := &model.PveSite{
+		SiteName:         req.SiteName,
+		SiteNameAlias:    req.SiteNameAlias,
+		Region:           req.Region,
+		Location:         req.Location,
+		Describes:        req.Describes,
+		CrossSiteBwlimit: req.CrossSiteBwlimit,
+		CreateTime:       time.Now(),
+		UpdateTime:       time.Now(),
+		Creator:          creator,
+		Modifier:         creator,
+	}
+	if err := s.siteRepo.Create(ctx, site); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create site", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (s *pveSiteService) UpdateSite(ctx context.Context, id int64, req *v1.UpdateSiteRequest, modifier string) error {
+	site, err := s.siteRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get site", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if site == nil {
+		return v1.ErrNotFound
+	}
+
+	if req.SiteNameAlias != nil {
+		site.SiteNameAlias = *req.SiteNameAlias
+	}
+	if req.Region != nil {
+		site.Region = *req.Region
+	}
+	if req.Location != nil {
+		site.Location = *req.Location
+	}
+	if req.Describes != nil {
+		site.Describes = *req.Describes
+	}
+	if req.CrossSiteBwlimit != nil {
+		site.CrossSiteBwlimit = *req.CrossSiteBwlimit
+	}
+	site.Modifier = modifier
+	site.UpdateTime = time.Now()
+
+	if err := s.siteRepo.Update(ctx, site); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update site", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// DeleteSite 删除站点，站点下仍有集群时拒绝删除
+func (s *pveSiteService) DeleteSite(ctx context.Context, id int64) error {
+	site, err := s.siteRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get site", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if site == nil {
+		return v1.ErrNotFound
+	}
+
+	clusters, err := s.clusterRepo.GetBySiteID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list site clusters", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if len(clusters) > 0 {
+		return v1.WithDetailf(v1.ErrSiteHasClusters, "cluster_count=%d", len(clusters))
+	}
+
+	if err := s.siteRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete site", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (s *pveSiteService) GetSite(ctx context.Context, id int64) (*v1.SiteDetail, error) {
+	site, err := s.siteRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get site", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if site == nil {
+		return nil, v1.ErrNotFound
+	}
+
+	clusters, err := s.clusterRepo.GetBySiteID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list site clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	items := make([]v1.ClusterItem, 0, len(clusters))
+	for _, cluster := range clusters {
+		items = append(items, v1.ClusterItem{
+			Id:               cluster.Id,
+			ClusterName:      cluster.ClusterName,
+			ClusterNameAlias: cluster.ClusterNameAlias,
+			Env:              cluster.Env,
+			Datacenter:       cluster.Datacenter,
+			ApiUrl:           cluster.ApiUrl,
+			Region:           cluster.Region,
+			SiteID:           cluster.SiteID,
+			SiteName:         site.SiteName,
+			IsSchedulable:    cluster.IsSchedulable,
+			IsEnabled:        cluster.IsEnabled,
+			ApiLogEnabled:    cluster.ApiLogEnabled,
+		})
+	}
+
+	return &v1.SiteDetail{
+		SiteItem: toSiteItem(site, len(clusters)),
+		Clusters: items,
+	}, nil
+}
+
+func (s *pveSiteService) ListSites(ctx context.Context) (*v1.ListSitesResponseData, error) {
+	sites, err := s.siteRepo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list sites", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	clusterCount := make(map[int64]int, len(sites))
+	for _, cluster := range clusters {
+		clusterCount[cluster.SiteID]++
+	}
+
+	items := make([]v1.SiteItem, 0, len(sites))
+	for _, site := range sites {
+		items = append(items, toSiteItem(site, clusterCount[site.Id]))
+	}
+
+	return &v1.ListSitesResponseData{
+		Total: int64(len(items)),
+		List:  items,
+	}, nil
+}
+
+func toSiteItem(site *model.PveSite, clusterCount int) v1.SiteItem {
+	return v1.SiteItem{
+		Id:               site.Id,
+		SiteName:         site.SiteName,
+		SiteNameAlias:    site.SiteNameAlias,
+		Region:           site.Region,
+		Location:         site.Location,
+		Describes:        site.Describes,
+		CrossSiteBwlimit: site.CrossSiteBwlimit,
+		ClusterCount:     clusterCount,
+		CreateTime:       site.CreateTime,
+		UpdateTime:       site.UpdateTime,
+		Creator:          site.Creator,
+		Modifier:         site.Modifier,
+	}
+}
@@ -48,7 +48,7 @@ func (s *pveTaskService) getProxmoxClient(ctx context.Context, clusterID int64)
 		return nil, v1.ErrNotFound
 	}
 
-	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err), zap.Int64("cluster_id", clusterID))
 		return nil, v1.ErrInternalServerError
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	v1 "pvesphere/api/v1"
@@ -16,7 +18,7 @@ import (
 type PveTemplateService interface {
 	CreateTemplate(ctx context.Context, req *v1.CreateTemplateRequest) error
 	UpdateTemplate(ctx context.Context, id int64, req *v1.UpdateTemplateRequest) error
-	DeleteTemplate(ctx context.Context, id int64) error
+	DeleteTemplate(ctx context.Context, id int64, req *v1.DeleteTemplateRequest) (*v1.DeleteTemplateResponseData, error)
 	GetTemplate(ctx context.Context, id int64) (*v1.TemplateDetail, error)
 	ListTemplates(ctx context.Context, req *v1.ListTemplateRequest) (*v1.ListTemplateResponseData, error)
 }
@@ -29,6 +31,7 @@ func NewPveTemplateService(
 	uploadRepo repository.TemplateUploadRepository,
 	nodeRepo repository.PveNodeRepository,
 	clusterRepo repository.PveClusterRepository,
+	usage TemplateUsageService,
 	logger *log.Logger,
 ) PveTemplateService {
 	return &pveTemplateService{
@@ -38,6 +41,7 @@ func NewPveTemplateService(
 		uploadRepo:   uploadRepo,
 		nodeRepo:     nodeRepo,
 		clusterRepo:  clusterRepo,
+		usage:        usage,
 		Service:      service,
 		logger:       logger,
 	}
@@ -50,6 +54,7 @@ type pveTemplateService struct {
 	uploadRepo   repository.TemplateUploadRepository
 	nodeRepo     repository.PveNodeRepository
 	clusterRepo  repository.PveClusterRepository
+	usage        TemplateUsageService
 	*Service
 	logger *log.Logger
 }
@@ -95,116 +100,199 @@ func (s *pveTemplateService) UpdateTemplate(ctx context.Context, id int64, req *
 	return nil
 }
 
-func (s *pveTemplateService) DeleteTemplate(ctx context.Context, id int64) error {
+// DeleteTemplate 级联删除模板：删除各节点实例对应的 Proxmox 模板虚拟机，清理同步任务、导入记录和实例记录，
+// 并按需删除导入所用的备份文件。存在进行中的同步任务或链接克隆时拒绝删除，此时不做任何改动；
+// 部分模板虚拟机删除失败时保留模板及未删除的实例，可再次调用删除。
+func (s *pveTemplateService) DeleteTemplate(ctx context.Context, id int64, req *v1.DeleteTemplateRequest) (*v1.DeleteTemplateResponseData, error) {
 	tpl, err := s.tplRepo.GetByID(ctx, id)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to get template", zap.Error(err))
-		return v1.ErrInternalServerError
+		return nil, v1.ErrInternalServerError
 	}
 	if tpl == nil {
-		return v1.ErrNotFound
+		return nil, v1.ErrNotFound
+	}
+
+	// 1. 进行中的同步任务会继续创建实例，需先完成或取消
+	syncTasks, err := s.syncTaskRepo.ListByTemplateID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list sync tasks", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, task := range syncTasks {
+		switch task.Status {
+		case model.TemplateSyncTaskStatusPending, model.TemplateSyncTaskStatusSyncing, model.TemplateSyncTaskStatusImporting:
+			return nil, v1.WithDetailf(v1.ErrTemplateSyncInProgress, "sync task %d is %s", task.Id, task.Status)
+		}
 	}
 
-	// 1. 查询所有模板实例
 	instances, err := s.instanceRepo.ListByTemplateID(ctx, id)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to list template instances", zap.Error(err))
-		return v1.ErrInternalServerError
+		return nil, v1.ErrInternalServerError
 	}
 
-	// 2. 删除所有节点上的 Proxmox 模板
-	for _, instance := range instances {
-		if instance.VMID == 0 {
-			s.logger.WithContext(ctx).Warn("instance has no VMID, skip deletion",
-				zap.Int64("instance_id", instance.Id),
-				zap.String("node_name", instance.NodeName))
-			continue
+	// 2. 找出 Proxmox 中仍存在的模板虚拟机，并检查是否有链接克隆引用其基础卷
+	client, err := s.getClusterClient(ctx, tpl.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	type templateVM struct {
+		instance *model.TemplateInstance
+		node     string
+	}
+	var targets []templateVM
+	var gone []*model.TemplateInstance
+	if client != nil {
+		resources, err := client.GetClusterResourcesByType(ctx, "vm")
+		if err != nil {
+			return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "list cluster vms: %v", err)
 		}
-
-		// 获取节点信息
-		node, err := s.nodeRepo.GetByID(ctx, instance.NodeID)
-		if err != nil || node == nil {
-			s.logger.WithContext(ctx).Error("failed to get node",
-				zap.Error(err),
-				zap.Int64("node_id", instance.NodeID))
-			continue
+		byVMID := make(map[uint32]map[string]interface{}, len(resources))
+		for _, resource := range resources {
+			if vmid, ok := resource["vmid"].(float64); ok {
+				byVMID[uint32(vmid)] = resource
+			}
 		}
 
-		// 获取集群信息
-		cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
-		if err != nil || cluster == nil {
-			s.logger.WithContext(ctx).Error("failed to get cluster",
-				zap.Error(err),
-				zap.Int64("cluster_id", node.ClusterID))
-			continue
+		contents := make(map[string][]map[string]interface{})
+		var clones []string
+		for _, instance := range instances {
+			resource := byVMID[instance.VMID]
+			// VMID 已不存在或已被普通虚拟机复用时，不再删除
+			if instance.VMID == 0 || resource == nil {
+				gone = append(gone, instance)
+				continue
+			}
+			if isTemplate, _ := resource["template"].(float64); isTemplate != 1 {
+				s.logger.WithContext(ctx).Warn("instance vmid is no longer a template, skip deletion",
+					zap.Int64("instance_id", instance.Id),
+					zap.Uint32("vmid", instance.VMID))
+				gone = append(gone, instance)
+				continue
+			}
+			node, _ := resource["node"].(string)
+			key := node + "/" + instance.StorageName
+			items, ok := contents[key]
+			if !ok {
+				items, err = client.GetStorageContent(ctx, node, instance.StorageName, "images")
+				if err != nil {
+					return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "list storage %s on node %s: %v", instance.StorageName, node, err)
+				}
+				contents[key] = items
+			}
+			clones = append(clones, linkedClonesOf(items, instance.VMID)...)
+			targets = append(targets, templateVM{instance: instance, node: node})
 		}
-
-		// 创建 Proxmox 客户端
-		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
-		if err != nil {
-			s.logger.WithContext(ctx).Error("failed to create proxmox client",
-				zap.Error(err),
-				zap.String("node_name", node.NodeName))
-			continue
+		if len(clones) > 0 {
+			return nil, v1.WithDetailf(v1.ErrTemplateHasLinkedClones, "linked clones: %s", strings.Join(clones, ", "))
 		}
+	} else {
+		gone = instances
+	}
 
-		// 删除 Proxmox 模板（purge=true 表示同时删除磁盘）
-		err = client.DeleteVM(ctx, node.NodeName, instance.VMID, true)
-		if err != nil {
+	// 3. 删除 Proxmox 模板虚拟机（purge=true 同时删除磁盘），成功的实例记录随即删除
+	data := &v1.DeleteTemplateResponseData{TemplateID: id, DeletedVMs: []v1.DeletedTemplateVM{}}
+	var failures []string
+	for _, target := range targets {
+		if err := client.DeleteVM(ctx, target.node, target.instance.VMID, true); err != nil {
 			s.logger.WithContext(ctx).Error("failed to delete proxmox template",
 				zap.Error(err),
-				zap.String("node_name", node.NodeName),
-				zap.Uint32("vmid", instance.VMID))
-			// 继续删除其他实例，不中断流程
-		} else {
-			s.logger.WithContext(ctx).Info("deleted proxmox template",
-				zap.String("node_name", node.NodeName),
-				zap.Uint32("vmid", instance.VMID))
+				zap.String("node_name", target.node),
+				zap.Uint32("vmid", target.instance.VMID))
+			failures = append(failures, fmt.Sprintf("%d@%s: %v", target.instance.VMID, target.node, err))
+			continue
 		}
+		s.logger.WithContext(ctx).Info("deleted proxmox template",
+			zap.String("node_name", target.node),
+			zap.Uint32("vmid", target.instance.VMID))
+		data.DeletedVMs = append(data.DeletedVMs, v1.DeletedTemplateVM{NodeName: target.node, VMID: target.instance.VMID})
+		gone = append(gone, target.instance)
 	}
-
-	// 3. 删除所有同步任务
-	syncTasks, err := s.syncTaskRepo.ListByTemplateID(ctx, id)
-	if err != nil {
-		s.logger.WithContext(ctx).Error("failed to list sync tasks", zap.Error(err))
-		// 继续执行，不中断流程
-	} else {
-		for _, task := range syncTasks {
-			if err := s.syncTaskRepo.Delete(ctx, task.Id); err != nil {
-				s.logger.WithContext(ctx).Error("failed to delete sync task",
-					zap.Error(err),
-					zap.Int64("task_id", task.Id))
-			}
+	for _, instance := range gone {
+		if err := s.instanceRepo.Delete(ctx, instance.Id); err != nil {
+			s.logger.WithContext(ctx).Error("failed to delete template instance", zap.Error(err), zap.Int64("instance_id", instance.Id))
 		}
 	}
+	if len(failures) > 0 {
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "failed to delete template vms: %s", strings.Join(failures, "; "))
+	}
 
-	// 4. 删除所有模板实例
-	if err := s.instanceRepo.DeleteByTemplateID(ctx, id); err != nil {
-		s.logger.WithContext(ctx).Error("failed to delete template instances", zap.Error(err))
-		return v1.ErrInternalServerError
+	// 4. 删除同步任务
+	for _, task := range syncTasks {
+		if err := s.syncTaskRepo.Delete(ctx, task.Id); err != nil {
+			s.logger.WithContext(ctx).Error("failed to delete sync task",
+				zap.Error(err),
+				zap.Int64("task_id", task.Id))
+		}
 	}
 
-	// 5. 删除上传记录（可选，保留历史记录）
-	// 如果需要保留历史记录，可以注释掉这部分
+	// 5. 删除导入记录，并按需删除备份文件（删除失败只记录日志）
 	upload, err := s.uploadRepo.GetByTemplateID(ctx, id)
 	if err == nil && upload != nil {
-		// 可以选择删除或保留上传记录
-		// 这里选择保留，只删除关联关系
-		s.logger.WithContext(ctx).Info("keeping upload record for history",
-			zap.Int64("upload_id", upload.Id))
+		data.BackupFile = upload.FilePath
+		if !req.KeepBackup && client != nil && upload.FilePath != "" {
+			storage, _, _ := strings.Cut(upload.FilePath, ":")
+			if err := client.DeleteStorageContent(ctx, upload.UploadNodeName, storage, upload.FilePath, nil); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to delete template backup file",
+					zap.Error(err),
+					zap.String("file_path", upload.FilePath))
+			} else {
+				data.BackupDeleted = true
+			}
+		}
+		if err := s.uploadRepo.Delete(ctx, upload.Id); err != nil {
+			s.logger.WithContext(ctx).Error("failed to delete template upload", zap.Error(err), zap.Int64("upload_id", upload.Id))
+		}
 	}
 
 	// 6. 最后删除模板记录
 	if err := s.tplRepo.Delete(ctx, id); err != nil {
 		s.logger.WithContext(ctx).Error("failed to delete template", zap.Error(err))
-		return v1.ErrInternalServerError
+		return nil, v1.ErrInternalServerError
 	}
 
 	s.logger.WithContext(ctx).Info("template deleted successfully",
 		zap.Int64("template_id", id),
-		zap.String("template_name", tpl.TemplateName))
+		zap.String("template_name", tpl.TemplateName),
+		zap.Int("deleted_vms", len(data.DeletedVMs)),
+		zap.Bool("backup_deleted", data.BackupDeleted))
 
-	return nil
+	return data, nil
+}
+
+// getClusterClient 获取集群的 Proxmox 客户端，集群已不存在时返回 nil
+func (s *pveTemplateService) getClusterClient(ctx context.Context, clusterID int64) (*proxmox.ProxmoxClient, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, nil
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return client, nil
+}
+
+// linkedClonesOf 从存储内容中找出基于模板基础卷（base-{vmid}-*）的链接克隆磁盘，返回 "vmid:volid" 列表
+func linkedClonesOf(items []map[string]interface{}, templateVMID uint32) []string {
+	base := fmt.Sprintf("base-%d-", templateVMID)
+	var clones []string
+	for _, item := range items {
+		parent, _ := item["parent"].(string)
+		if parent == "" || !strings.Contains(parent, base) {
+			continue
+		}
+		volid, _ := item["volid"].(string)
+		vmid, _ := item["vmid"].(float64)
+		clones = append(clones, fmt.Sprintf("%d:%s", uint32(vmid), volid))
+	}
+	return clones
 }
 
 func (s *pveTemplateService) GetTemplate(ctx context.Context, id int64) (*v1.TemplateDetail, error) {
@@ -250,6 +338,16 @@ func (s *pveTemplateService) ListTemplates(ctx context.Context, req *v1.ListTemp
 		return nil, v1.ErrInternalServerError
 	}
 
+	ids := make([]int64, 0, len(tpls))
+	for _, tpl := range tpls {
+		ids = append(ids, tpl.Id)
+	}
+	// 使用统计失败不影响列表
+	usage, err := s.usage.Stats(ctx, ids)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get template usage", zap.Error(err))
+	}
+
 	items := make([]v1.TemplateItem, 0, len(tpls))
 	for _, tpl := range tpls {
 		items = append(items, v1.TemplateItem{
@@ -257,6 +355,7 @@ func (s *pveTemplateService) ListTemplates(ctx context.Context, req *v1.ListTemp
 			TemplateName: tpl.TemplateName,
 			ClusterID:    tpl.ClusterID,
 			Description:  tpl.Description,
+			Usage:        usage[tpl.Id],
 		})
 	}
 
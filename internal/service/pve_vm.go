@@ -5,12 +5,14 @@ import (
 	crand "crypto/rand"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	mrand "math/rand"
 	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	v1 "pvesphere/api/v1"
@@ -20,6 +22,7 @@ import (
 	"pvesphere/pkg/proxmox"
 
 	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
 	"go.uber.org/zap"
 )
 
@@ -31,17 +34,26 @@ type PveVMService interface {
 	GetVM(ctx context.Context, id int64) (*v1.VMDetail, error)
 	ListVMs(ctx context.Context, req *v1.ListVMRequest) (*v1.ListVMResponseData, error)
 	StartVM(ctx context.Context, id int64) error
-	StopVM(ctx context.Context, id int64) error
+	StopVM(ctx context.Context, id int64, req *v1.StopVMRequest) error
 	GetVMCurrentConfig(ctx context.Context, vmID int64) (map[string]interface{}, error)
 	GetVMPendingConfig(ctx context.Context, vmID int64) ([]map[string]interface{}, error)
 	UpdateVMConfig(ctx context.Context, req *v1.UpdateVMConfigRequest) error
+	UpdateVMTags(ctx context.Context, req *v1.UpdateVMTagsRequest) error
 	GetVMStatus(ctx context.Context, vmID int64) (map[string]interface{}, error)
-	GetVMConsole(ctx context.Context, req *v1.GetVMConsoleRequest) (map[string]interface{}, error)
-	DialVMConsoleWebsocket(ctx context.Context, token string) (*websocket.Conn, error)
+	ResizeVMMemory(ctx context.Context, req *v1.ResizeVMMemoryRequest) (*v1.ResizeVMMemoryResponseData, error)
+	GetVMConsole(ctx context.Context, userID string, req *v1.GetVMConsoleRequest) (map[string]interface{}, error)
+	RenewVMConsoleSession(ctx context.Context, userID, sessionID string) (*v1.VMConsoleSessionData, error)
+	ReconnectVMConsole(ctx context.Context, userID, sessionID string) (map[string]interface{}, error)
+	DialVMConsoleWebsocket(ctx context.Context, token string) (*websocket.Conn, *ConsoleTarget, error)
 	GetVMRRDData(ctx context.Context, vmID int64, timeframe, cf string) ([]map[string]interface{}, error)
+	// GetVMMetrics 汇总 status/current、RRD 曲线和 guest agent 数据，部分失败时记录在 warnings 中
+	GetVMMetrics(ctx context.Context, vmID int64, req *v1.GetVMMetricsRequest) (*v1.VMMetricsData, error)
 	MigrateVM(ctx context.Context, req *v1.MigrateVMRequest) (string, error)
 	RemoteMigrateVM(ctx context.Context, req *v1.RemoteMigrateVMRequest) (string, error)
+	RemoteMigratePreflight(ctx context.Context, req *v1.RemoteMigrateVMRequest) (*v1.RemoteMigratePreflightData, error)
 	CreateBackup(ctx context.Context, req *v1.CreateBackupRequest) (*v1.CreateBackupResponseData, error)
+	// ForecastStorage 预估备份、克隆或恢复需要的目标存储空间
+	ForecastStorage(ctx context.Context, req *v1.StorageForecastRequest) (*v1.StorageForecastData, error)
 	DeleteBackup(ctx context.Context, req *v1.DeleteBackupRequest) error
 	GetVMCloudInit(ctx context.Context, req *v1.GetVMCloudInitRequest) (map[string]interface{}, error)
 	UpdateVMCloudInit(ctx context.Context, req *v1.UpdateVMCloudInitRequest) error
@@ -49,6 +61,7 @@ type PveVMService interface {
 
 func NewPveVMService(
 	service *Service,
+	conf *viper.Viper,
 	vmRepo repository.PveVMRepository,
 	templateRepo repository.VmTemplateRepository,
 	templateInstanceRepo repository.TemplateInstanceRepository,
@@ -56,9 +69,27 @@ func NewPveVMService(
 	ipRepo repository.VMIPAddressRepository,
 	clusterRepo repository.PveClusterRepository,
 	nodeRepo repository.PveNodeRepository,
+	siteRepo repository.PveSiteRepository,
+	licenseRepo repository.LicenseRepository,
+	certRepo repository.PveNodeCertificateRepository,
+	changeControl ChangeControlService,
+	nodeVersion NodeVersionService,
+	vmProfile VMProfileService,
+	macRegistry MACRegistryService,
+	vmLock VMLockService,
+	pendingOps PendingOperationService,
+	credentials VMCredentialService,
+	templateUsage TemplateUsageService,
+	taskTracker VMTaskTracker,
+	reservations ProvisionReservationService,
+	nodePools NodePoolService,
+	vmidRanges VMIDRangeService,
+	cutovers RemoteMigrationCutoverService,
+	sharedTokens SharedTokenStore,
 	logger *log.Logger,
 ) PveVMService {
 	return &pveVMService{
+		conf:                 conf,
 		vmRepo:               vmRepo,
 		templateRepo:         templateRepo,
 		templateInstanceRepo: templateInstanceRepo,
@@ -66,12 +97,30 @@ func NewPveVMService(
 		ipRepo:               ipRepo,
 		clusterRepo:          clusterRepo,
 		nodeRepo:             nodeRepo,
+		siteRepo:             siteRepo,
+		licenseRepo:          licenseRepo,
+		certRepo:             certRepo,
+		changeControl:        changeControl,
+		nodeVersion:          nodeVersion,
+		vmProfile:            vmProfile,
+		macRegistry:          macRegistry,
+		vmLock:               vmLock,
+		pendingOps:           pendingOps,
+		credentials:          credentials,
+		templateUsage:        templateUsage,
+		taskTracker:          taskTracker,
+		reservations:         reservations,
+		nodePools:            nodePools,
+		vmidRanges:           vmidRanges,
+		cutovers:             cutovers,
+		sharedTokens:         sharedTokens,
 		Service:              service,
 		logger:               logger,
 	}
 }
 
 type pveVMService struct {
+	conf                 *viper.Viper
 	vmRepo               repository.PveVMRepository
 	templateRepo         repository.VmTemplateRepository
 	templateInstanceRepo repository.TemplateInstanceRepository
@@ -79,19 +128,72 @@ type pveVMService struct {
 	ipRepo               repository.VMIPAddressRepository
 	clusterRepo          repository.PveClusterRepository
 	nodeRepo             repository.PveNodeRepository
+	siteRepo             repository.PveSiteRepository
+	licenseRepo          repository.LicenseRepository // 虚拟机列表返回操作系统停止维护状态
+	certRepo             repository.PveNodeCertificateRepository
+	changeControl        ChangeControlService
+	nodeVersion          NodeVersionService
+	vmProfile            VMProfileService
+	macRegistry          MACRegistryService
+	vmLock               VMLockService
+	pendingOps           PendingOperationService
+	credentials          VMCredentialService
+	templateUsage        TemplateUsageService
+	taskTracker          VMTaskTracker
+	reservations         ProvisionReservationService
+	nodePools            NodePoolService
+	vmidRanges           VMIDRangeService
+	cutovers             RemoteMigrationCutoverService
+	// sharedTokens 保存 ws_token -> vmConsoleSession 和 session_id -> vmConsoleLease，
+	// 控制台会话可在任一实例上续期、重连
+	sharedTokens SharedTokenStore
 	*Service
 	logger *log.Logger
-
-	consoleSessions sync.Map // token -> vmConsoleSession
 }
 
+const (
+	// consoleTokenTTL ws_token 有效期，token 单次使用
+	consoleTokenTTL = 2 * time.Minute
+	// consoleSessionIdleTTL 控制台会话空闲有效期，连接、续期、重连时顺延
+	consoleSessionIdleTTL = 30 * time.Minute
+	// consoleSessionMaxAge 控制台会话最长有效期，超过后需重新获取 Console
+	consoleSessionMaxAge = 12 * time.Hour
+	// consoleTicketReuseWindow vncproxy 打开的端口只等待很短时间的首次连接，且只接受一次连接；
+	// 重连时只有未被使用且在该时间内签发的 ticket 可以复用，否则重新调用 vncproxy
+	consoleTicketReuseWindow = 10 * time.Second
+)
+
 type vmConsoleSession struct {
+	SessionID string
 	VMID      int64
 	Port      int
 	Ticket    string
 	ExpiresAt time.Time
 }
 
+// vmConsoleLease 控制台会话，记录当前 vncproxy ticket 以便断线后重连。
+// 以 session_id 为令牌保存在共享令牌中，不同实例同时修改同一会话时以最后一次写入为准
+type vmConsoleLease struct {
+	UserID           string
+	VMID             int64
+	GeneratePassword bool
+	Proxy            map[string]interface{} // 最近一次 vncproxy 返回（port / ticket / password 等）
+	Port             int
+	Ticket           string
+	TicketIssuedAt   time.Time
+	TicketUsed       bool
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+}
+
+// touch 顺延会话空闲有效期，不超过最长有效期
+func (l *vmConsoleLease) touch(now time.Time) {
+	l.ExpiresAt = now.Add(consoleSessionIdleTTL)
+	if maxAt := l.CreatedAt.Add(consoleSessionMaxAge); l.ExpiresAt.After(maxAt) {
+		l.ExpiresAt = maxAt
+	}
+}
+
 func newConsoleToken() (string, error) {
 	b := make([]byte, 24)
 	if _, err := crand.Read(b); err != nil {
@@ -134,7 +236,7 @@ func (s *pveVMService) CreateVM(ctx context.Context, req *v1.CreateVMRequest) er
 		return v1.ErrInternalServerError
 	}
 	if template == nil {
-		return fmt.Errorf("模板 %d 不存在", req.TemplateID)
+		return v1.WithDetailf(v1.ErrTemplateNotFound, "template_id=%d", req.TemplateID)
 	}
 
 	// 2. 获取集群信息（优先使用 ID，如果没有则使用名称）
@@ -152,14 +254,14 @@ func (s *pveVMService) CreateVM(ctx context.Context, req *v1.CreateVMRequest) er
 			return v1.ErrInternalServerError
 		}
 	} else {
-		return fmt.Errorf("必须提供 cluster_id 或 cluster_name")
+		return v1.ErrClusterRequired
 	}
 
 	if cluster == nil {
 		if req.ClusterID > 0 {
-			return fmt.Errorf("集群 ID %d 不存在", req.ClusterID)
+			return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
 		}
-		return fmt.Errorf("集群 %s 不存在", req.ClusterName)
+		return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_name=%s", req.ClusterName)
 	}
 
 	// 3. 获取节点信息（优先使用 ID，如果没有则使用名称）
@@ -177,14 +279,14 @@ func (s *pveVMService) CreateVM(ctx context.Context, req *v1.CreateVMRequest) er
 			return v1.ErrInternalServerError
 		}
 	} else {
-		return fmt.Errorf("必须提供 node_id 或 node_name")
+		return v1.ErrNodeRequired
 	}
 
 	if node == nil {
 		if req.NodeID > 0 {
-			return fmt.Errorf("节点 ID %d 不存在", req.NodeID)
+			return v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
 		}
-		return fmt.Errorf("节点 %s 在集群 %s 中不存在", req.NodeName, cluster.ClusterName)
+		return v1.WithDetailf(v1.ErrNodeNotFound, "node_name=%s, cluster=%s", req.NodeName, cluster.ClusterName)
 	}
 
 	// 4. 检查新虚拟机是否已存在（使用 NodeID）
@@ -195,7 +297,7 @@ func (s *pveVMService) CreateVM(ctx context.Context, req *v1.CreateVMRequest) er
 	}
 	if existing != nil {
 		s.logger.WithContext(ctx).Warn("vm already exists", zap.Uint32("vmid", vmID), zap.Int64("node_id", node.Id))
-		return fmt.Errorf("虚拟机 %d 在节点 %s 上已存在", vmID, node.NodeName)
+		return v1.WithDetailf(v1.ErrVMAlreadyExists, "vmid=%d, node=%s", vmID, node.NodeName)
 	}
 
 	// 5. 查找模板实例（优先查找目标节点上的实例，如果没有则查找主实例或其他可用实例）
@@ -237,7 +339,7 @@ func (s *pveVMService) CreateVM(ctx context.Context, req *v1.CreateVMRequest) er
 	}
 
 	if templateInstance == nil {
-		return fmt.Errorf("模板 ID %d 没有可用的模板实例", template.Id)
+		return v1.WithDetailf(v1.ErrTemplateNoInstance, "template_id=%d", template.Id)
 	}
 
 	// 6. 创建数据库记录（仅数据库操作，不调用 Proxmox API）
@@ -279,9 +381,6 @@ func (s *pveVMService) CreateVM(ctx context.Context, req *v1.CreateVMRequest) er
 	if req.VmUser != "" {
 		vm.VmUser = req.VmUser
 	}
-	if req.VmPassword != "" {
-		vm.VmPassword = req.VmPassword
-	}
 	if node.IPAddress != "" {
 		vm.NodeIP = node.IPAddress
 	}
@@ -293,6 +392,12 @@ func (s *pveVMService) CreateVM(ctx context.Context, req *v1.CreateVMRequest) er
 		s.logger.WithContext(ctx).Error("failed to create vm record", zap.Error(err))
 		return v1.ErrInternalServerError
 	}
+	// 密码写入密钥后端，失败不影响虚拟机创建，只记录日志
+	if req.VmPassword != "" {
+		if err := s.credentials.SetPassword(ctx, vm, req.VmPassword); err != nil {
+			s.logger.WithContext(ctx).Error("failed to store vm password", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		}
+	}
 
 	// 7. 如果提供了 IP 地址 ID，创建 IP 地址记录
 	if req.IPAddressID != nil {
@@ -316,11 +421,7 @@ func (s *pveVMService) CreateVM(ctx context.Context, req *v1.CreateVMRequest) er
 
 // CreateVMInProxmox 完整创建流程：调用 Proxmox API 创建虚拟机 + 自动创建数据库记录
 func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRequest) error {
-	// 0. 如果未显式传入 VMID，则自动生成一个 8 位数的 VM ID
-	vmID := generateProxmoxVMID(req.VMID)
-	req.VMID = vmID
-
-	// 0.1 创建模式（默认 template，兼容旧前端不传 create_mode 的情况）
+	// 0. 创建模式（默认 template，兼容旧前端不传 create_mode 的情况）
 	createMode := strings.ToLower(strings.TrimSpace(req.CreateMode))
 	if createMode == "" {
 		createMode = "template"
@@ -342,20 +443,56 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 			return v1.ErrInternalServerError
 		}
 	} else {
-		return fmt.Errorf("必须提供 cluster_id 或 cluster_name")
+		return v1.ErrClusterRequired
 	}
 
 	if cluster == nil {
 		if req.ClusterID > 0 {
-			return fmt.Errorf("集群 ID %d 不存在", req.ClusterID)
+			return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
 		}
-		return fmt.Errorf("集群 %s 不存在", req.ClusterName)
+		return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_name=%s", req.ClusterName)
 	}
 
 	// 检查集群是否可调度
 	if cluster.IsSchedulable != 1 {
-		return fmt.Errorf("集群 %s 不可调度", cluster.ClusterName)
+		return v1.WithDetailf(v1.ErrClusterNotSchedulable, "cluster=%s", cluster.ClusterName)
+	}
+
+	// 变更管控校验
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "vm.create",
+		Target:    req.VmName,
+		ClusterID: cluster.Id,
+		AppId:     req.AppId,
+	}); err != nil {
+		return err
+	}
+
+	// 确定 VMID：未显式传入时从团队 / 集群的 VMID 段分配，没有适用的段时自动生成 8 位 VM ID；
+	// Proxmox 创建任务提交前失败时撤销分配
+	vmID, err := s.vmidRanges.Allocate(ctx, cluster, req.Team, req.VmName, req.VMID)
+	if err != nil {
+		return err
 	}
+	req.VMID = vmID
+	vmIDSubmitted := false
+	defer func() {
+		if !vmIDSubmitted {
+			// 异步创建任务超时时 ctx 已取消，撤销使用不受取消影响的上下文
+			s.vmidRanges.Cancel(context.WithoutCancel(ctx), cluster.Id, vmID)
+		}
+	}()
+
+	// 使用创建规格补全未填写的配置
+	profile, err := s.vmProfile.Resolve(ctx, cluster.Id, req.ProfileID)
+	if err != nil {
+		return err
+	}
+	if profile != nil {
+		applyVMProfile(req, profile)
+		s.logger.WithContext(ctx).Info("apply vm profile", zap.Int64("profile_id", profile.Id), zap.String("profile", profile.Name), zap.String("vm_name", req.VmName))
+	}
+	tags := normalizeVMTags(req.Tags)
 
 	// 2. 获取节点信息（优先使用 ID，如果没有则使用名称）
 	var node *model.PveNode
@@ -371,15 +508,23 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 			s.logger.WithContext(ctx).Error("failed to get node by name", zap.Error(err))
 			return v1.ErrInternalServerError
 		}
+	} else if req.NodePoolID > 0 {
+		// 未指定节点时由节点池调度
+		reservation := createVMReservation(req, createMode, cluster.Id, 0, "")
+		node, err = s.nodePools.Schedule(ctx, cluster, req.NodePoolID, reservation.CPUNum, reservation.MemoryMB)
+		if err != nil {
+			return err
+		}
+		req.NodeID = node.Id
 	} else {
-		return fmt.Errorf("必须提供 node_id 或 node_name")
+		return v1.ErrNodeRequired
 	}
 
 	if node == nil {
 		if req.NodeID > 0 {
-			return fmt.Errorf("节点 ID %d 不存在", req.NodeID)
+			return v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
 		}
-		return fmt.Errorf("节点 %s 在集群 %s 中不存在", req.NodeName, cluster.ClusterName)
+		return v1.WithDetailf(v1.ErrNodeNotFound, "node_name=%s, cluster=%s", req.NodeName, cluster.ClusterName)
 	}
 
 	// 3. 检查新虚拟机是否已存在（使用 NodeID）
@@ -390,21 +535,64 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 	}
 	if existing != nil {
 		s.logger.WithContext(ctx).Warn("vm already exists", zap.Uint32("vmid", vmID), zap.Int64("node_id", node.Id))
-		return fmt.Errorf("虚拟机 %d 在节点 %s 上已存在", vmID, node.NodeName)
+		return v1.WithDetailf(v1.ErrVMAlreadyExists, "vmid=%d, node=%s", vmID, node.NodeName)
 	}
 
 	// 4. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
 		return v1.ErrInternalServerError
 	}
 
+	// 未指定 CPU 类型时使用集群的 CPU 型号基线，保证虚拟机可在集群内各节点间在线迁移
+	cpuType := strings.TrimSpace(req.CPUType)
+	if cpuType == "" {
+		cpuType = cluster.CPUBaseline
+	}
+
+	// 传入 disks 时系统盘所在存储作为默认存储（EFI / TPM 盘等未单独指定存储时使用）
+	if createMode != "template" && strings.TrimSpace(req.Storage) == "" && len(req.Disks) > 0 {
+		req.Storage = strings.TrimSpace(req.Disks[0].Storage)
+	}
+
+	// 登记本次创建所需的资源，容量校验时计入同一节点上其他进行中的创建请求；
+	// Proxmox 任务提交成功后保留到任务结束，其余情况在返回时释放
+	hold := s.reservations.Reserve(ctx, createVMReservation(req, createMode, cluster.Id, node.Id, node.NodeName))
+	holdUntilTask := false
+	defer func() {
+		if !holdUntilTask {
+			hold.Release()
+		}
+	}()
+	reserved := s.reservations.NodeUsage(node.Id, hold)
+
+	// 节点所属节点池的配额（计入池内其他进行中的创建请求）
+	reservation := createVMReservation(req, createMode, cluster.Id, node.Id, node.NodeName)
+	if err := s.nodePools.CheckQuota(ctx, node.Id, reservation.CPUNum, reservation.MemoryMB, hold); err != nil {
+		return err
+	}
+
+	// 创建前对照 Proxmox 校验存储、网桥、ISO、内存和 VMID，一次返回全部问题
+	if err := s.validateCreateVM(ctx, proxmoxClient, cluster, node, req, createMode, vmID, reserved); err != nil {
+		return err
+	}
+
 	switch createMode {
 	case "template":
 		// 5.template 分支：从模板克隆
 		if req.TemplateID <= 0 {
-			return fmt.Errorf("create_mode=template 时必须提供 template_id")
+			return v1.WithDetail(v1.ErrMissingParameter, "template_id (create_mode=template)")
+		}
+		// 未传 os_type / memory_size 时沿用模板配置，只校验请求中给出的部分
+		if req.BalloonMin != nil {
+			mem := 0
+			if req.MemorySize != nil {
+				mem = *req.MemorySize
+			}
+			if err := checkVMBalloon(strings.TrimSpace(req.OSType), mem, *req.BalloonMin); err != nil {
+				return err
+			}
 		}
 
 		// 5.1 获取模板信息
@@ -414,7 +602,7 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 			return v1.ErrInternalServerError
 		}
 		if template == nil {
-			return fmt.Errorf("模板 %d 不存在", req.TemplateID)
+			return v1.WithDetailf(v1.ErrTemplateNotFound, "template_id=%d", req.TemplateID)
 		}
 
 		// 5.2 查找模板实例（优先查找目标节点上的实例，如果没有则查找主实例或其他可用实例）
@@ -468,20 +656,27 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 		}
 
 		if templateInstance == nil || templateInstance.VMID == 0 {
-			return fmt.Errorf("模板 ID %d 没有可用的模板实例", template.Id)
+			return v1.WithDetailf(v1.ErrTemplateNoInstance, "template_id=%d", template.Id)
 		}
 
 		// 获取模板实例所在的节点
 		sourceNode, err := s.nodeRepo.GetByID(ctx, templateInstance.NodeID)
 		if err != nil || sourceNode == nil {
-			return fmt.Errorf("无法获取模板实例的节点信息")
+			return v1.WithDetail(v1.ErrNodeNotFound, "template instance node")
 		}
 		sourceNodeName := sourceNode.NodeName
 
-		// 5.3 准备克隆请求参数
+		// 5.3 校验克隆方式与模板 / 目标存储的兼容性并预估空间
+		linkedClone := isLinkedClone(req)
+		diskMoves, cloneDisks, err := s.validateCloneStorage(ctx, proxmoxClient, node, sourceNode, templateInstance, req, linkedClone, reserved.Storage)
+		if err != nil {
+			return err
+		}
+
+		// 5.4 准备克隆请求参数
 		fullClone := 1 // 默认完整克隆
-		if req.FullClone != nil {
-			fullClone = *req.FullClone
+		if linkedClone {
+			fullClone = 0
 		}
 
 		// 如果目标节点和源节点相同，则不设置 target 参数
@@ -495,27 +690,155 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 			Name:        req.VmName,
 			Target:      targetNode, // 目标节点（如果不同才设置）
 			Full:        fullClone,
-			Storage:     req.Storage,
 			Description: req.Description,
 		}
+		// storage / format 仅对完整克隆有效
+		if !linkedClone {
+			cloneReq.Storage = req.Storage
+			cloneReq.Format = req.CloneFormat
+		}
+
+		// 模板当前配置：用于沿用 net0、为新磁盘分配槽位以及串口 / 显示设备的默认值
+		templateConfig, err := proxmoxClient.GetVMCurrentConfig(ctx, sourceNodeName, templateInstance.VMID)
+		if err != nil {
+			if len(req.Disks) > 0 {
+				s.logger.WithContext(ctx).Error("failed to get template config", zap.Error(err),
+					zap.String("template_node", sourceNodeName), zap.Uint32("template_vmid", templateInstance.VMID))
+				return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get template config: %v", err)
+			}
+			s.logger.WithContext(ctx).Warn("failed to get template config, keep template nic and console settings", zap.Error(err),
+				zap.String("template_node", sourceNodeName), zap.Uint32("template_vmid", templateInstance.VMID))
+			templateConfig = nil
+		}
+		// 完整克隆复制的模板磁盘以及未指定时沿用的模板内存计入预留
+		templateMemory := 0
+		if req.MemorySize == nil || *req.MemorySize <= 0 {
+			templateMemory = vmConfigInt(templateConfig["memory"], 0)
+		}
+		hold.Add(templateMemory, cloneDisks)
+
+		// 5.4.1 为网卡分配登记过的 MAC，避免跨集群重复。传入 nics 时按序覆盖 net0..netN；
+		// 否则只处理 net0，未指定网桥时沿用模板的网卡配置，只替换 MAC
+		nets := make(map[string]string)
+		var macs []string
+		if len(req.NICs) > 0 {
+			macs, err = s.allocateNICMACs(ctx, cluster.Id, vmID, req.VmName, req.NICs)
+			if err != nil {
+				return err
+			}
+			for i, nic := range req.NICs {
+				nets[fmt.Sprintf("net%d", i)] = nicSpecValue(nic, macs[i])
+			}
+		} else {
+			net0, mac, err := s.allocateTemplateNet0(ctx, templateConfig, req, cluster.Id, vmID)
+			if err != nil {
+				return err
+			}
+			if net0 != "" {
+				nets["net0"] = net0
+				macs = append(macs, mac)
+			}
+		}
+
+		// 5.4.2 克隆后新增的磁盘，占用模板未使用的槽位；未指定存储时使用 storage，再使用模板所在存储
+		newDisks := make(map[string]string)
+		if len(req.Disks) > 0 {
+			slots, err := assignDiskSlots(usedDeviceSlots(templateConfig), req.Disks)
+			if err != nil {
+				s.releaseMACs(ctx, macs)
+				return err
+			}
+			defaultStorage := strings.TrimSpace(req.Storage)
+			if defaultStorage == "" {
+				defaultStorage = templateInstance.StorageName
+			}
+			for i, disk := range req.Disks {
+				newDisks[slots[i]] = diskSpecValue(disk, diskSpecStorage(disk, defaultStorage))
+			}
+		}
+
+		// 5.4.3 串口与显示设备：请求或规格中指定时按指定配置，否则云镜像模板默认向 serial0 输出控制台
+		consoleConfig := cloudImageConsoleDefaults(templateConfig)
+		if req.SerialNum != nil || req.Display != "" {
+			consoleConfig = vmConsoleConfig(req.SerialNum, req.Display, templateConfig)
+		}
 
-		// 5.4 调用 Proxmox API 克隆虚拟机
+		// 5.5 调用 Proxmox API 克隆虚拟机
 		upid, err := proxmoxClient.CloneVM(ctx, sourceNodeName, templateInstance.VMID, cloneReq)
 		if err != nil {
+			s.releaseMACs(ctx, macs)
 			s.logger.WithContext(ctx).Error("failed to clone vm", zap.Error(err),
 				zap.String("template_node", sourceNodeName),
 				zap.Uint32("template_vmid", templateInstance.VMID))
-			return fmt.Errorf("克隆虚拟机失败: %v", err)
+			return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "clone vm: %v", err)
 		}
 		s.logger.WithContext(ctx).Info("vm cloned", zap.String("upid", upid), zap.Uint32("vmid", vmID))
+		holdUntilTask = true
+		vmIDSubmitted = true
+		go s.releaseAfterTask(proxmoxClient, sourceNodeName, upid, hold)
+
+		// 克隆接口不支持修改配置，等待克隆完成后再设置请求或规格中指定的配置，未指定的保持模板配置
+		cloneConfig := make(map[string]interface{})
+		if cpuType != "" {
+			cloneConfig["cpu"] = cpuType
+		}
+		if req.CPUNum != nil && *req.CPUNum > 0 {
+			cloneConfig["cores"] = *req.CPUNum
+		}
+		if req.MemorySize != nil && *req.MemorySize > 0 {
+			cloneConfig["memory"] = *req.MemorySize
+		}
+		if scsihw := strings.TrimSpace(req.SCSIHw); scsihw != "" {
+			cloneConfig["scsihw"] = scsihw
+		}
+		if ostype := strings.TrimSpace(req.OSType); ostype != "" {
+			cloneConfig["ostype"] = ostype
+		}
+		if agent := strings.TrimSpace(req.Agent); agent != "" {
+			cloneConfig["agent"] = agent
+		}
+		if req.Sockets != nil && *req.Sockets > 0 {
+			cloneConfig["sockets"] = *req.Sockets
+		}
+		if req.BalloonMin != nil {
+			cloneConfig["balloon"] = *req.BalloonMin
+		}
+		if req.Shares != nil {
+			cloneConfig["shares"] = *req.Shares
+		}
+		if req.NUMA != nil {
+			cloneConfig["numa"] = *req.NUMA
+		}
+		if machine := normalizeMachineType(req.Machine); machine != "" {
+			cloneConfig["machine"] = machine
+		}
+		if bios := strings.TrimSpace(req.BIOS); bios != "" {
+			cloneConfig["bios"] = bios
+		}
+		if tags != "" {
+			cloneConfig["tags"] = tags
+		}
+		for key, value := range nets {
+			cloneConfig[key] = value
+		}
+		for key, value := range newDisks {
+			cloneConfig[key] = value
+		}
+		for key, value := range consoleConfig {
+			cloneConfig[key] = value
+		}
+		if len(cloneConfig) > 0 || len(diskMoves) > 0 {
+			go s.applyClonedVMConfig(proxmoxClient, sourceNodeName, node.NodeName, upid, vmID, cloneConfig, diskMoves, req.CloneFormat)
+		}
 
-		// 5.5 创建数据库记录
+		// 5.6 创建数据库记录
 		vm := &model.PveVM{
 			VmName:     req.VmName,
 			ClusterID:  cluster.Id,
 			NodeID:     node.Id,
 			TemplateID: template.Id,
 			VMID:       vmID,
+			Team:       req.Team,
 			Status:     "stopped", // 克隆后默认停止状态
 			CreateTime: time.Now(),
 			UpdateTime: time.Now(),
@@ -548,9 +871,6 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 		if req.VmUser != "" {
 			vm.VmUser = req.VmUser
 		}
-		if req.VmPassword != "" {
-			vm.VmPassword = req.VmPassword
-		}
 		if node.IPAddress != "" {
 			vm.NodeIP = node.IPAddress
 		}
@@ -563,6 +883,14 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 			// 注意：如果数据库创建失败，可以考虑回滚 Proxmox 的克隆操作
 			return v1.ErrInternalServerError
 		}
+		if req.VmPassword != "" {
+			if err := s.credentials.SetPassword(ctx, vm, req.VmPassword); err != nil {
+				s.logger.WithContext(ctx).Error("failed to store vm password", zap.Error(err), zap.Int64("vm_id", vm.Id))
+			}
+		}
+		s.bindMACs(ctx, macs, vm.Id)
+		vm.NodeName = node.NodeName
+		s.templateUsage.RecordClone(ctx, vm, templateInstance, model.TemplateUsageSourceCreate, "")
 
 		// 10. 如果提供了 IP 地址 ID，创建 IP 地址记录
 		if req.IPAddressID != nil {
@@ -586,7 +914,7 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 	case "iso", "empty":
 		// 5.iso/empty 分支：创建空机（iso 会额外挂载 ISO 并从光驱启动）
 		if req.Storage == "" {
-			return fmt.Errorf("create_mode=%s 时必须提供 storage", createMode)
+			return v1.WithDetailf(v1.ErrMissingParameter, "storage (create_mode=%s)", createMode)
 		}
 
 		// 默认值
@@ -614,12 +942,34 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 		if strings.TrimSpace(req.OSType) != "" {
 			ostype = strings.TrimSpace(req.OSType)
 		}
+		scsihw := "virtio-scsi-pci"
+		if strings.TrimSpace(req.SCSIHw) != "" {
+			scsihw = strings.TrimSpace(req.SCSIHw)
+		}
+		agent := "1"
+		if strings.TrimSpace(req.Agent) != "" {
+			agent = strings.TrimSpace(req.Agent)
+		}
+		sockets := 1
+		if req.Sockets != nil && *req.Sockets > 0 {
+			sockets = *req.Sockets
+		}
+		if req.BalloonMin != nil {
+			if err := checkVMBalloon(ostype, mem, *req.BalloonMin); err != nil {
+				return err
+			}
+		}
+		machine, bios, tpm := vmFirmwareDefaults(req, ostype)
+		efiStorage := strings.TrimSpace(req.EFIStorage)
+		if efiStorage == "" {
+			efiStorage = req.Storage
+		}
 
 		// iso 模式必须提供 iso_volume
 		isoVol := strings.TrimSpace(req.ISOVolume)
 		if createMode == "iso" {
 			if isoVol == "" {
-				return fmt.Errorf("create_mode=iso 时必须提供 iso_volume")
+				return v1.WithDetail(v1.ErrMissingParameter, "iso_volume (create_mode=iso)")
 			}
 			// 兼容前端可能传入以 / 开头的 volume
 			isoVol = strings.TrimPrefix(isoVol, "/")
@@ -631,28 +981,79 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 		params.Set("name", req.VmName)
 		params.Set("cores", fmt.Sprintf("%d", cpu))
 		params.Set("memory", fmt.Sprintf("%d", mem))
-		params.Set("sockets", "1")
+		params.Set("sockets", fmt.Sprintf("%d", sockets))
 		params.Set("ostype", ostype)
-		params.Set("scsihw", "virtio-scsi-pci")
-		params.Set("agent", "1")
+		params.Set("scsihw", scsihw)
+		params.Set("agent", agent)
+		if cpuType != "" {
+			params.Set("cpu", cpuType)
+		}
+		if req.NUMA != nil {
+			params.Set("numa", fmt.Sprintf("%d", *req.NUMA))
+		}
+		if req.BalloonMin != nil {
+			params.Set("balloon", fmt.Sprintf("%d", *req.BalloonMin))
+		}
+		if req.Shares != nil {
+			params.Set("shares", fmt.Sprintf("%d", *req.Shares))
+		}
+		if machine != "" {
+			params.Set("machine", machine)
+		}
+		if bios != "" {
+			params.Set("bios", bios)
+		}
+		// OVMF 需要 EFI 磁盘保存 EFI 变量，预置微软密钥以支持安全启动
+		if bios == "ovmf" {
+			params.Set("efidisk0", fmt.Sprintf("%s:1,efitype=4m,pre-enrolled-keys=1", efiStorage))
+		}
+		if tpm {
+			params.Set("tpmstate0", fmt.Sprintf("%s:1,version=v2.0", efiStorage))
+		}
+		if tags != "" {
+			params.Set("tags", tags)
+		}
+
+		// 磁盘：<bus><n>=<storage>:<sizeGB>[,format=xxx]，第一块为系统盘；未传 disks 时按 disk_size_gb / disk_format 创建 scsi0
+		// 注意：某些后端存储（如 lvmthin/zfs）不支持 qcow2，若报错请前端不传 format
+		disks := req.Disks
+		if len(disks) == 0 {
+			disks = []v1.VMDiskSpec{{SizeGB: diskGB, Format: strings.TrimSpace(req.DiskFormat)}}
+		}
+		slots, err := assignDiskSlots(map[string]bool{"ide2": createMode == "iso"}, disks)
+		if err != nil {
+			return err
+		}
+		for i, disk := range disks {
+			params.Set(slots[i], diskSpecValue(disk, diskSpecStorage(disk, req.Storage)))
+		}
+		diskGB = disks[0].SizeGB
 
-		// 系统盘：scsi0=<storage>:<sizeGB>[,format=xxx]
-		disk := fmt.Sprintf("%s:%d", req.Storage, diskGB)
-		if strings.TrimSpace(req.DiskFormat) != "" {
-			// 注意：某些后端存储（如 lvmthin/zfs）不支持 qcow2，若报错请前端不传 disk_format
-			disk += fmt.Sprintf(",format=%s", strings.TrimSpace(req.DiskFormat))
+		// 网卡：net<n>=<model>=<mac>,bridge=<bridge>，MAC 由平台分配并登记，避免跨集群重复
+		nics := req.NICs
+		if len(nics) == 0 {
+			nics = []v1.VMNICSpec{{Bridge: bridge, Model: netModel, MACAddress: req.MACAddress, VLANTag: req.VLANTag, Firewall: req.Firewall}}
 		}
-		params.Set("scsi0", disk)
+		macs, err := s.allocateNICMACs(ctx, cluster.Id, vmID, req.VmName, nics)
+		if err != nil {
+			return err
+		}
+		for i, nic := range nics {
+			params.Set(fmt.Sprintf("net%d", i), nicSpecValue(nic, macs[i]))
+		}
+		bridge, netModel = strings.TrimSpace(nics[0].Bridge), nicSpecModel(nics[0])
 
-		// 网卡：net0=<model>,bridge=<bridge>
-		params.Set("net0", fmt.Sprintf("%s,bridge=%s", netModel, bridge))
+		// 串口与显示设备
+		for key, value := range vmConsoleConfig(req.SerialNum, req.Display, nil) {
+			params.Set(key, fmt.Sprint(value))
+		}
 
 		// ISO 挂载与启动顺序
 		if createMode == "iso" {
 			params.Set("ide2", fmt.Sprintf("%s,media=cdrom", isoVol))
-			params.Set("boot", "order=ide2;scsi0;net0")
+			params.Set("boot", fmt.Sprintf("order=ide2;%s;net0", slots[0]))
 		} else {
-			params.Set("boot", "order=scsi0;net0")
+			params.Set("boot", fmt.Sprintf("order=%s;net0", slots[0]))
 		}
 
 		if strings.TrimSpace(req.Description) != "" {
@@ -661,13 +1062,17 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 
 		upid, err := proxmoxClient.CreateQemuVM(ctx, node.NodeName, params)
 		if err != nil {
+			s.releaseMACs(ctx, macs)
 			s.logger.WithContext(ctx).Error("failed to create qemu vm", zap.Error(err),
 				zap.String("node", node.NodeName),
 				zap.Uint32("vmid", vmID),
 				zap.String("create_mode", createMode))
-			return fmt.Errorf("创建虚拟机失败: %v", err)
+			return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "create vm: %v", err)
 		}
 		s.logger.WithContext(ctx).Info("vm created", zap.String("upid", upid), zap.Uint32("vmid", vmID), zap.String("create_mode", createMode))
+		holdUntilTask = true
+		vmIDSubmitted = true
+		go s.releaseAfterTask(proxmoxClient, node.NodeName, upid, hold)
 
 		// 记录创建信息到 storage_cfg（若前端未显式传入）
 		storageCfg := req.StorageCfg
@@ -679,10 +1084,38 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 				"bridge":       bridge,
 				"net_model":    netModel,
 				"os_type":      ostype,
+				"scsihw":       scsihw,
+				"sockets":      sockets,
+			}
+			if machine != "" {
+				cfg["machine"] = machine
+			}
+			if bios != "" {
+				cfg["bios"] = bios
+			}
+			if tpm {
+				cfg["tpm"] = true
+			}
+			if profile != nil {
+				cfg["profile_id"] = profile.Id
 			}
 			if createMode == "iso" {
 				cfg["iso_volume"] = isoVol
 			}
+			if len(req.Disks) > 0 {
+				cfg["disks"] = req.Disks
+			}
+			if len(req.NICs) > 0 {
+				cfg["nics"] = req.NICs
+			} else if req.VLANTag != nil {
+				cfg["vlan_tag"] = *req.VLANTag
+			}
+			if req.SerialNum != nil {
+				cfg["serial_num"] = *req.SerialNum
+			}
+			if req.Display != "" {
+				cfg["display"] = req.Display
+			}
 			if b, err := json.Marshal(cfg); err == nil {
 				storageCfg = string(b)
 			}
@@ -701,8 +1134,8 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 			StorageCfg: storageCfg,
 			Status:     "stopped",
 			AppId:      req.AppId,
+			Team:       req.Team,
 			VmUser:     req.VmUser,
-			VmPassword: req.VmPassword,
 			CreateTime: time.Now(),
 			UpdateTime: time.Now(),
 		}
@@ -717,6 +1150,12 @@ func (s *pveVMService) CreateVMInProxmox(ctx context.Context, req *v1.CreateVMRe
 			s.logger.WithContext(ctx).Error("failed to create vm record", zap.Error(err))
 			return v1.ErrInternalServerError
 		}
+		if req.VmPassword != "" {
+			if err := s.credentials.SetPassword(ctx, vm, req.VmPassword); err != nil {
+				s.logger.WithContext(ctx).Error("failed to store vm password", zap.Error(err), zap.Int64("vm_id", vm.Id))
+			}
+		}
+		s.bindMACs(ctx, macs, vm.Id)
 
 		// IP 地址绑定（可选）
 		if req.IPAddressID != nil {
@@ -779,14 +1218,51 @@ func (s *pveVMService) UpdateVM(ctx context.Context, id int64, req *v1.UpdateVMR
 		vm.VmUser = *req.VmUser
 	}
 	if req.VmPassword != nil {
-		vm.VmPassword = *req.VmPassword
+		if err := s.credentials.SetPassword(ctx, vm, *req.VmPassword); err != nil {
+			return err
+		}
 	}
 	if req.NodeIP != nil {
 		vm.NodeIP = *req.NodeIP
 	}
-	if req.Description != nil {
+	if req.Description != nil && *req.Description != vm.Description {
+		// 描述即 Proxmox 备注，以 Proxmox 为准，先写入 Proxmox，避免下次同步时被覆盖
+		if err := validateVMNotes(*req.Description, vmNotesMaxLength(s.conf)); err != nil {
+			return err
+		}
+		client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+		if err != nil {
+			return err
+		}
+		if err := setVMDescription(ctx, client, node.NodeName, vm.VMID, *req.Description, ""); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to update vm description", zap.Error(err), zap.Uint32("vmid", vm.VMID))
+			return err
+		}
 		vm.Description = *req.Description
 	}
+	if req.Owner != nil {
+		vm.Owner = strings.TrimSpace(*req.Owner)
+	}
+	if req.Team != nil {
+		vm.Team = strings.TrimSpace(*req.Team)
+	}
+	if req.Contact != nil {
+		vm.Contact = strings.TrimSpace(*req.Contact)
+	}
+	if req.BusinessService != nil {
+		vm.BusinessService = strings.TrimSpace(*req.BusinessService)
+	}
+	if req.Environment != nil {
+		vm.Environment = *req.Environment
+	}
+	if req.CostCenter != nil {
+		vm.CostCenter = strings.TrimSpace(*req.CostCenter)
+	}
+	if req.ClearReviewDate {
+		vm.ReviewDate = nil
+	} else if req.ReviewDate != nil {
+		vm.ReviewDate = req.ReviewDate
+	}
 	vm.UpdateTime = time.Now()
 
 	if err := s.vmRepo.Update(ctx, vm); err != nil {
@@ -810,15 +1286,18 @@ func (s *pveVMService) DeleteVM(ctx context.Context, id int64) error {
 
 	// 2. 基于数据库状态进行前置校验，要求先在上层手动停止
 	if vm.Status == "offline" || vm.Status == "orphan" {
-		return fmt.Errorf("虚拟机已经销毁，请勿重复销毁")
+		return v1.ErrVMAlreadyDestroyed
 	}
 	if vm.Status != "stopped" {
-		return fmt.Errorf("虚拟机未停止，请先停止虚拟机")
+		return v1.ErrVMNotStopped
+	}
+	if err := s.authorizeVMChange(ctx, "vm.delete", vm); err != nil {
+		return err
 	}
 
 	// 3. 获取集群信息（通过 ID）
 	if vm.ClusterID <= 0 {
-		return fmt.Errorf("虚拟机的集群 ID 无效，无法删除 Proxmox 虚拟机")
+		return v1.ErrInvalidClusterID
 	}
 	cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID)
 	if err != nil {
@@ -826,12 +1305,12 @@ func (s *pveVMService) DeleteVM(ctx context.Context, id int64) error {
 		return v1.ErrInternalServerError
 	}
 	if cluster == nil {
-		return fmt.Errorf("集群 ID %d 不存在，无法删除 Proxmox 虚拟机", vm.ClusterID)
+		return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", vm.ClusterID)
 	}
 
 	// 4. 获取节点信息（通过 ID）
 	if vm.NodeID <= 0 {
-		return fmt.Errorf("虚拟机的节点 ID 无效，无法删除 Proxmox 虚拟机")
+		return v1.ErrInvalidNodeID
 	}
 	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
 	if err != nil {
@@ -839,19 +1318,23 @@ func (s *pveVMService) DeleteVM(ctx context.Context, id int64) error {
 		return v1.ErrInternalServerError
 	}
 	if node == nil {
-		return fmt.Errorf("节点 ID %d 不存在，无法删除 Proxmox 虚拟机", vm.NodeID)
+		return v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
 	}
 
 	// 5. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
-		return fmt.Errorf("创建 Proxmox 客户端失败: %v", err)
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "create client: %v", err)
 	}
 
 	// 6. 先获取虚拟机配置，检查虚拟机是否存在
 	vmExistsInProxmox := true
-	_, err = proxmoxClient.GetVMConfig(ctx, node.NodeName, vm.VMID)
+	config, err := proxmoxClient.GetVMConfig(ctx, node.NodeName, vm.VMID)
+	if err == nil && fmt.Sprint(config["protection"]) == "1" {
+		// Proxmox 自身的 protection 标志同样会拒绝删除，提前给出明确原因
+		return v1.WithDetail(v1.ErrVMProtected, "protection flag is set in proxmox")
+	}
 	if err != nil {
 		// 检查错误是否是 404/500（虚拟机不存在或已删除）
 		errStr := err.Error()
@@ -865,7 +1348,7 @@ func (s *pveVMService) DeleteVM(ctx context.Context, id int64) error {
 			s.logger.WithContext(ctx).Error("failed to get vm config from proxmox", zap.Error(err),
 				zap.String("node", node.NodeName),
 				zap.Uint32("vmid", vm.VMID))
-			return fmt.Errorf("从 Proxmox 获取虚拟机配置失败: %v", err)
+			return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm config: %v", err)
 		}
 	}
 
@@ -887,7 +1370,7 @@ func (s *pveVMService) DeleteVM(ctx context.Context, id int64) error {
 				s.logger.WithContext(ctx).Error("failed to get vm status from proxmox", zap.Error(err),
 					zap.String("node", node.NodeName),
 					zap.Uint32("vmid", vm.VMID))
-				return fmt.Errorf("从 Proxmox 获取虚拟机状态失败: %v", err)
+				return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm status: %v", err)
 			}
 
 			// 从返回的 map 中提取 status 字段
@@ -907,7 +1390,12 @@ func (s *pveVMService) DeleteVM(ctx context.Context, id int64) error {
 		}
 
 		if vmExistsInProxmox && vmStatus != "stopped" {
-			return fmt.Errorf("proxmox 虚拟机当前状态为 %s，无法执行销毁操作，请先在 PVE 中停止虚拟机", vmStatus)
+			return v1.WithDetailf(v1.ErrVMNotStopped, "proxmox status=%s", vmStatus)
+		}
+		if vmExistsInProxmox {
+			if err := s.vmLock.CheckProxmox(ctx, proxmoxClient, node.NodeName, vm.VMID, "vm.delete"); err != nil {
+				return err
+			}
 		}
 
 		// 8. 调用 Proxmox API 删除虚拟机（purge=true 表示完全删除，包括磁盘）
@@ -919,7 +1407,7 @@ func (s *pveVMService) DeleteVM(ctx context.Context, id int64) error {
 					zap.Uint32("vmid", vm.VMID),
 					zap.String("vm_name", vm.VmName),
 					zap.String("vm_status", vmStatus))
-				return fmt.Errorf("从 Proxmox 删除虚拟机失败: %v", err)
+				return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "delete vm: %v", err)
 			}
 			s.logger.WithContext(ctx).Info("vm deleted from proxmox", zap.Uint32("vmid", vm.VMID))
 		}
@@ -930,6 +1418,13 @@ func (s *pveVMService) DeleteVM(ctx context.Context, id int64) error {
 		s.logger.WithContext(ctx).Error("failed to delete ip addresses", zap.Error(err))
 		// IP 地址删除失败不影响虚拟机删除，只记录日志
 	}
+	// 释放 MAC 地址登记和维护锁，取消待重试操作，清理密钥后端中的密码
+	s.macRegistry.ReleaseVM(ctx, id)
+	s.vmLock.Release(ctx, id)
+	s.pendingOps.CancelByVM(ctx, id)
+	s.credentials.Remove(ctx, vm)
+	// VMID 进入隔离期，期满前不会再次分配
+	s.vmidRanges.Release(ctx, vm.ClusterID, vm.VMID)
 
 	// 8. 删除数据库记录
 	if err := s.vmRepo.Delete(ctx, id); err != nil {
@@ -971,6 +1466,17 @@ func (s *pveVMService) GetVM(ctx context.Context, id int64) (*v1.VMDetail, error
 		UpdateTime:  vm.UpdateTime,
 		Creator:     vm.Creator,
 		Modifier:    vm.Modifier,
+
+		Owner:           vm.Owner,
+		Team:            vm.Team,
+		Contact:         vm.Contact,
+		BusinessService: vm.BusinessService,
+		Environment:     vm.Environment,
+		CostCenter:      vm.CostCenter,
+		ReviewDate:      vm.ReviewDate,
+		Protected:       vm.Protected == 1,
+		ProtectedBy:     vm.ProtectedBy,
+		ProtectedReason: vm.ProtectedReason,
 	}
 
 	// 填充名称字段
@@ -997,7 +1503,19 @@ func (s *pveVMService) GetVM(ctx context.Context, id int64) (*v1.VMDetail, error
 }
 
 func (s *pveVMService) ListVMs(ctx context.Context, req *v1.ListVMRequest) (*v1.ListVMResponseData, error) {
-	vms, total, err := s.vmRepo.ListWithPagination(ctx, req.Page, req.PageSize, req.ClusterID, req.ClusterName, req.NodeID, req.NodeName, req.TemplateID, req.Status, req.AppId)
+	meta := &repository.PveVMMetaFilter{
+		Owner:           req.Owner,
+		Team:            req.Team,
+		BusinessService: req.BusinessService,
+		Environment:     req.Environment,
+		CostCenter:      req.CostCenter,
+	}
+	now := time.Now()
+	if req.ReviewDue {
+		meta.ReviewDueBefore = &now
+	}
+	meta.OSEOLAfter, meta.OSEOLBefore = osEOLFilter(req.OSEOL, now)
+	vms, total, err := s.vmRepo.ListWithPagination(ctx, req.Page, req.PageSize, req.ClusterID, req.ClusterName, req.NodeID, req.NodeName, req.TemplateID, req.Status, req.AppId, meta)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to list vms", zap.Error(err))
 		return nil, v1.ErrInternalServerError
@@ -1007,8 +1525,10 @@ func (s *pveVMService) ListVMs(ctx context.Context, req *v1.ListVMRequest) (*v1.
 	clusterIDs := make([]int64, 0)
 	nodeIDs := make([]int64, 0)
 	templateIDs := make([]int64, 0)
+	vmIDs := make([]int64, 0, len(vms))
 
 	for _, vm := range vms {
+		vmIDs = append(vmIDs, vm.Id)
 		if vm.ClusterID > 0 {
 			clusterIDs = append(clusterIDs, vm.ClusterID)
 		}
@@ -1024,6 +1544,10 @@ func (s *pveVMService) ListVMs(ctx context.Context, req *v1.ListVMRequest) (*v1.
 	clusterMap, _ := s.clusterRepo.GetByIDs(ctx, clusterIDs)
 	nodeMap, _ := s.nodeRepo.GetByIDs(ctx, nodeIDs)
 	templateMap, _ := s.templateRepo.GetByIDs(ctx, templateIDs)
+	osInfoMap, err := s.licenseRepo.ListOSInfoByVmIDs(ctx, vmIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to list vm os info", zap.Error(err))
+	}
 
 	items := make([]v1.VMItem, 0, len(vms))
 	for _, vm := range vms {
@@ -1040,6 +1564,14 @@ func (s *pveVMService) ListVMs(ctx context.Context, req *v1.ListVMRequest) (*v1.
 			Status:     vm.Status,
 			AppId:      vm.AppId,
 			NodeIP:     vm.NodeIP,
+
+			Owner:           vm.Owner,
+			Team:            vm.Team,
+			BusinessService: vm.BusinessService,
+			Environment:     vm.Environment,
+			CostCenter:      vm.CostCenter,
+			ReviewDate:      vm.ReviewDate,
+			Protected:       vm.Protected == 1,
 		}
 
 		// 从 map 中填充名称
@@ -1052,6 +1584,11 @@ func (s *pveVMService) ListVMs(ctx context.Context, req *v1.ListVMRequest) (*v1.
 		if template, ok := templateMap[vm.TemplateID]; ok {
 			item.TemplateName = template.TemplateName
 		}
+		if info, ok := osInfoMap[vm.Id]; ok {
+			item.OSName = info.PrettyName
+			item.OSEOLDate = info.EOLDate
+			item.OSEOLStatus = osEOLStatus(info.EOLDate, now)
+		}
 
 		items = append(items, item)
 	}
@@ -1075,12 +1612,15 @@ func (s *pveVMService) StartVM(ctx context.Context, id int64) error {
 
 	// 2. 检查虚拟机当前状态
 	if vm.Status == "running" {
-		return fmt.Errorf("虚拟机已在运行中，无需启动")
+		return v1.ErrVMAlreadyRunning
+	}
+	if err := s.authorizeVMChange(ctx, "vm.start", vm); err != nil {
+		return err
 	}
 
 	// 3. 获取集群信息（通过 ID）
 	if vm.ClusterID <= 0 {
-		return fmt.Errorf("虚拟机的集群 ID 无效")
+		return v1.ErrInvalidClusterID
 	}
 	cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID)
 	if err != nil {
@@ -1088,12 +1628,12 @@ func (s *pveVMService) StartVM(ctx context.Context, id int64) error {
 		return v1.ErrInternalServerError
 	}
 	if cluster == nil {
-		return fmt.Errorf("集群 ID %d 不存在", vm.ClusterID)
+		return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", vm.ClusterID)
 	}
 
 	// 4. 获取节点信息（通过 ID）
 	if vm.NodeID <= 0 {
-		return fmt.Errorf("虚拟机的节点 ID 无效")
+		return v1.ErrInvalidNodeID
 	}
 	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
 	if err != nil {
@@ -1101,17 +1641,22 @@ func (s *pveVMService) StartVM(ctx context.Context, id int64) error {
 		return v1.ErrInternalServerError
 	}
 	if node == nil {
-		return fmt.Errorf("节点 ID %d 不存在", vm.NodeID)
+		return v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
 	}
 
 	// 5. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
-		return fmt.Errorf("创建 Proxmox 客户端失败: %v", err)
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "create client: %v", err)
+	}
+
+	// 6. 检查 Proxmox lock（备份、迁移等任务进行中时直接说明原因）
+	if err := s.vmLock.CheckProxmox(ctx, proxmoxClient, node.NodeName, vm.VMID, "vm.start"); err != nil {
+		return err
 	}
 
-	// 6. 调用 Proxmox API 启动虚拟机
+	// 7. 调用 Proxmox API 启动虚拟机
 	s.logger.WithContext(ctx).Info("starting vm from proxmox", zap.Uint32("vmid", vm.VMID), zap.String("node", node.NodeName))
 	upid, err := proxmoxClient.StartVM(ctx, node.NodeName, vm.VMID)
 	if err != nil {
@@ -1119,14 +1664,24 @@ func (s *pveVMService) StartVM(ctx context.Context, id int64) error {
 			zap.String("node", node.NodeName),
 			zap.Uint32("vmid", vm.VMID),
 			zap.String("vm_name", vm.VmName))
-		return fmt.Errorf("从 Proxmox 启动虚拟机失败: %v", err)
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "start vm: %v", err)
 	}
 	s.logger.WithContext(ctx).Info("vm started from proxmox", zap.Uint32("vmid", vm.VMID), zap.String("upid", upid))
 
+	// 8. 任务结束后更新数据库中的状态
+	s.taskTracker.Track(ctx, &VMPowerTask{
+		VM:       vm,
+		NodeName: node.NodeName,
+		Client:   proxmoxClient,
+		UPID:     upid,
+		Action:   "start",
+		Expected: "running",
+	})
+
 	return nil
 }
 
-func (s *pveVMService) StopVM(ctx context.Context, id int64) error {
+func (s *pveVMService) StopVM(ctx context.Context, id int64, req *v1.StopVMRequest) error {
 	// 1. 获取虚拟机信息
 	vm, err := s.vmRepo.GetByID(ctx, id)
 	if err != nil {
@@ -1139,12 +1694,15 @@ func (s *pveVMService) StopVM(ctx context.Context, id int64) error {
 
 	// 2. 检查虚拟机当前状态
 	if vm.Status == "stopped" {
-		return fmt.Errorf("虚拟机已停止，无需关机")
+		return v1.ErrVMAlreadyStopped
+	}
+	if err := s.authorizeVMChange(ctx, "vm.stop", vm); err != nil {
+		return err
 	}
 
 	// 3. 获取集群信息（通过 ID）
 	if vm.ClusterID <= 0 {
-		return fmt.Errorf("虚拟机的集群 ID 无效")
+		return v1.ErrInvalidClusterID
 	}
 	cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID)
 	if err != nil {
@@ -1152,12 +1710,12 @@ func (s *pveVMService) StopVM(ctx context.Context, id int64) error {
 		return v1.ErrInternalServerError
 	}
 	if cluster == nil {
-		return fmt.Errorf("集群 ID %d 不存在", vm.ClusterID)
+		return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", vm.ClusterID)
 	}
 
 	// 4. 获取节点信息（通过 ID）
 	if vm.NodeID <= 0 {
-		return fmt.Errorf("虚拟机的节点 ID 无效")
+		return v1.ErrInvalidNodeID
 	}
 	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
 	if err != nil {
@@ -1165,31 +1723,112 @@ func (s *pveVMService) StopVM(ctx context.Context, id int64) error {
 		return v1.ErrInternalServerError
 	}
 	if node == nil {
-		return fmt.Errorf("节点 ID %d 不存在", vm.NodeID)
+		return v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
 	}
 
 	// 5. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
-		return fmt.Errorf("创建 Proxmox 客户端失败: %v", err)
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "create client: %v", err)
+	}
+
+	// 6. 检查 Proxmox lock（备份、迁移等任务进行中时直接说明原因）
+	if err := s.vmLock.CheckProxmox(ctx, proxmoxClient, node.NodeName, vm.VMID, "vm.stop"); err != nil {
+		return err
 	}
 
-	// 6. 调用 Proxmox API 停止虚拟机
-	s.logger.WithContext(ctx).Info("stopping vm from proxmox", zap.Uint32("vmid", vm.VMID), zap.String("node", node.NodeName))
-	upid, err := proxmoxClient.StopVM(ctx, node.NodeName, vm.VMID)
+	// 7. 调用 Proxmox API 关机或停止虚拟机
+	mode, timeout, forceStop := s.stopOptions(req)
+	s.logger.WithContext(ctx).Info("stopping vm from proxmox", zap.Uint32("vmid", vm.VMID), zap.String("node", node.NodeName),
+		zap.String("mode", mode), zap.Int("timeout", timeout), zap.Bool("force_stop", forceStop))
+	var upid string
+	if mode == vmStopModeShutdown {
+		upid, err = proxmoxClient.ShutdownVM(ctx, node.NodeName, vm.VMID, timeout, forceStop)
+	} else {
+		upid, err = proxmoxClient.StopVM(ctx, node.NodeName, vm.VMID, timeout)
+	}
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to stop vm from proxmox", zap.Error(err),
 			zap.String("node", node.NodeName),
 			zap.Uint32("vmid", vm.VMID),
 			zap.String("vm_name", vm.VmName))
-		return fmt.Errorf("从 Proxmox 停止虚拟机失败: %v", err)
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "stop vm: %v", err)
 	}
 	s.logger.WithContext(ctx).Info("vm stopped from proxmox", zap.Uint32("vmid", vm.VMID), zap.String("upid", upid))
 
+	// 8. 任务结束后更新数据库中的状态
+	s.taskTracker.Track(ctx, &VMPowerTask{
+		VM:       vm,
+		NodeName: node.NodeName,
+		Client:   proxmoxClient,
+		UPID:     upid,
+		Action:   mode,
+		Expected: "stopped",
+		Timeout:  time.Duration(timeout)*time.Second + defaultVMTaskWaitTimeout,
+	})
+
 	return nil
 }
 
+// 停止虚拟机的方式：shutdown 正常关机，stop 直接断电
+const (
+	vmStopModeShutdown = "shutdown"
+	vmStopModeStop     = "stop"
+)
+
+// 停止虚拟机的默认等待秒数，可通过 vm_stop.timeout 统一调整
+const (
+	defaultVMShutdownTimeout = 180
+	defaultVMStopTimeout     = 30
+)
+
+// stopOptions 停止方式、等待秒数和超时后是否强制断电：请求参数优先，其次为 vm_stop.* 配置；
+// 默认正常关机且不强制断电，避免数据库等服务被直接断电
+func (s *pveVMService) stopOptions(req *v1.StopVMRequest) (string, int, bool) {
+	if req == nil {
+		req = &v1.StopVMRequest{}
+	}
+	mode := req.Mode
+	if mode == "" {
+		mode = s.conf.GetString("vm_stop.mode")
+	}
+	if mode != vmStopModeStop {
+		mode = vmStopModeShutdown
+	}
+
+	timeout := s.conf.GetInt("vm_stop.timeout")
+	if req.Timeout != nil {
+		timeout = *req.Timeout
+	}
+	if timeout <= 0 {
+		timeout = defaultVMShutdownTimeout
+		if mode == vmStopModeStop {
+			timeout = defaultVMStopTimeout
+		}
+	}
+
+	forceStop := s.conf.GetBool("vm_stop.force_stop")
+	if req.ForceStop != nil {
+		forceStop = *req.ForceStop
+	}
+	return mode, timeout, forceStop
+}
+
+// authorizeVMChange 校验虚拟机是否被他人锁定维护，以及所在集群/应用当前是否允许变更（维护窗口、封网期）
+func (s *pveVMService) authorizeVMChange(ctx context.Context, action string, vm *model.PveVM) error {
+	if err := checkVMProtection(vm, action); err != nil {
+		return err
+	}
+	return s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    action,
+		Target:    vm.VmName,
+		ClusterID: vm.ClusterID,
+		AppId:     vm.AppId,
+		VMId:      vm.Id,
+	})
+}
+
 // getProxmoxClientForVM 根据虚拟机ID获取ProxmoxClient和节点信息
 func (s *pveVMService) getProxmoxClientForVM(ctx context.Context, vmID int64) (*proxmox.ProxmoxClient, *model.PveNode, error) {
 	// 1. 获取虚拟机信息
@@ -1204,7 +1843,7 @@ func (s *pveVMService) getProxmoxClientForVM(ctx context.Context, vmID int64) (*
 
 	// 2. 获取集群信息
 	if vm.ClusterID <= 0 {
-		return nil, nil, fmt.Errorf("虚拟机的集群 ID 无效")
+		return nil, nil, v1.ErrInvalidClusterID
 	}
 	cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID)
 	if err != nil {
@@ -1212,12 +1851,12 @@ func (s *pveVMService) getProxmoxClientForVM(ctx context.Context, vmID int64) (*
 		return nil, nil, v1.ErrInternalServerError
 	}
 	if cluster == nil {
-		return nil, nil, fmt.Errorf("集群 ID %d 不存在", vm.ClusterID)
+		return nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", vm.ClusterID)
 	}
 
 	// 3. 获取节点信息
 	if vm.NodeID <= 0 {
-		return nil, nil, fmt.Errorf("虚拟机的节点 ID 无效")
+		return nil, nil, v1.ErrInvalidNodeID
 	}
 	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
 	if err != nil {
@@ -1225,11 +1864,11 @@ func (s *pveVMService) getProxmoxClientForVM(ctx context.Context, vmID int64) (*
 		return nil, nil, v1.ErrInternalServerError
 	}
 	if node == nil {
-		return nil, nil, fmt.Errorf("节点 ID %d 不存在", vm.NodeID)
+		return nil, nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
 	}
 
 	// 4. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
 		return nil, nil, v1.ErrInternalServerError
@@ -1247,12 +1886,12 @@ func (s *pveVMService) getProxmoxClientForNode(ctx context.Context, nodeID int64
 		return nil, v1.ErrInternalServerError
 	}
 	if node == nil {
-		return nil, fmt.Errorf("节点 ID %d 不存在", nodeID)
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", nodeID)
 	}
 
 	// 2. 获取集群信息
 	if node.ClusterID <= 0 {
-		return nil, fmt.Errorf("节点的集群 ID 无效")
+		return nil, v1.ErrInvalidClusterID
 	}
 	cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
 	if err != nil {
@@ -1260,11 +1899,11 @@ func (s *pveVMService) getProxmoxClientForNode(ctx context.Context, nodeID int64
 		return nil, v1.ErrInternalServerError
 	}
 	if cluster == nil {
-		return nil, fmt.Errorf("集群 ID %d 不存在", node.ClusterID)
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", node.ClusterID)
 	}
 
 	// 3. 创建 Proxmox 客户端
-	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	proxmoxClient, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
 		return nil, v1.ErrInternalServerError
@@ -1325,8 +1964,23 @@ func (s *pveVMService) UpdateVMConfig(ctx context.Context, req *v1.UpdateVMConfi
 	if err != nil {
 		return v1.ErrInternalServerError
 	}
+	if err := s.authorizeVMChange(ctx, "vm.config", vm); err != nil {
+		return err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.config"); err != nil {
+		return err
+	}
 
 	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, req.Config); err != nil {
+		if queued := s.pendingOps.QueueIfUnreachable(ctx, &PendingOperationInput{
+			Operation: PendingOperationVMConfig,
+			ClusterID: vm.ClusterID,
+			NodeID:    node.Id,
+			VM:        vm,
+			Payload:   req.Config,
+		}, err); queued != nil {
+			return queued
+		}
 		s.logger.WithContext(ctx).Error("failed to update vm config", zap.Error(err),
 			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
 		return v1.ErrInternalServerError
@@ -1336,6 +1990,47 @@ func (s *pveVMService) UpdateVMConfig(ctx context.Context, req *v1.UpdateVMConfi
 	return nil
 }
 
+// UpdateVMTags 更新虚拟机在 Proxmox 中的标签，标签为空时删除
+func (s *pveVMService) UpdateVMTags(ctx context.Context, req *v1.UpdateVMTagsRequest) error {
+	client, node, err := s.getProxmoxClientForVM(ctx, req.VMID)
+	if err != nil {
+		return err
+	}
+
+	vm, err := s.vmRepo.GetByID(ctx, req.VMID)
+	if err != nil {
+		return v1.ErrInternalServerError
+	}
+	if err := s.authorizeVMChange(ctx, "vm.tags", vm); err != nil {
+		return err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.tags"); err != nil {
+		return err
+	}
+
+	config := map[string]interface{}{"delete": "tags"}
+	if tags := normalizeVMTags(req.Tags); tags != "" {
+		config = map[string]interface{}{"tags": tags}
+	}
+	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, config); err != nil {
+		if queued := s.pendingOps.QueueIfUnreachable(ctx, &PendingOperationInput{
+			Operation: PendingOperationVMTags,
+			ClusterID: vm.ClusterID,
+			NodeID:    node.Id,
+			VM:        vm,
+			Payload:   config,
+		}, err); queued != nil {
+			return queued
+		}
+		s.logger.WithContext(ctx).Error("failed to update vm tags", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update vm tags: %v", err)
+	}
+
+	s.logger.WithContext(ctx).Info("vm tags updated", zap.Uint32("vmid", vm.VMID), zap.Any("config", config))
+	return nil
+}
+
 func (s *pveVMService) GetVMStatus(ctx context.Context, vmID int64) (map[string]interface{}, error) {
 	client, node, err := s.getProxmoxClientForVM(ctx, vmID)
 	if err != nil {
@@ -1353,11 +2048,14 @@ func (s *pveVMService) GetVMStatus(ctx context.Context, vmID int64) (map[string]
 			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
 		return nil, v1.ErrInternalServerError
 	}
+	status["balloon_metrics"] = vmBalloonMetrics(status)
 
 	return status, nil
 }
 
-func (s *pveVMService) GetVMConsole(ctx context.Context, req *v1.GetVMConsoleRequest) (map[string]interface{}, error) {
+// ResizeVMMemory 调整虚拟机最大内存、balloon 最小内存和 shares。
+// 运行中的虚拟机未启用内存热插拔时，最大内存的修改在重启后生效；balloon 和 shares 可在线调整
+func (s *pveVMService) ResizeVMMemory(ctx context.Context, req *v1.ResizeVMMemoryRequest) (*v1.ResizeVMMemoryResponseData, error) {
 	client, node, err := s.getProxmoxClientForVM(ctx, req.VMID)
 	if err != nil {
 		return nil, err
@@ -1367,83 +2065,407 @@ func (s *pveVMService) GetVMConsole(ctx context.Context, req *v1.GetVMConsoleReq
 	if err != nil {
 		return nil, v1.ErrInternalServerError
 	}
-	if vm == nil {
-		return nil, v1.ErrNotFound
+	if err := s.authorizeVMChange(ctx, "vm.config", vm); err != nil {
+		return nil, err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.config"); err != nil {
+		return nil, err
 	}
 
-	// noVNC 需要 vncproxy 的 port/ticket 再去连 vncwebsocket；这里默认强制开启 websocket=1
-	// 避免前端未传 websocket 导致返回字段不全（port/ticket 缺失）
-	result, err := client.QemuVNCProxy(ctx, node.NodeName, vm.VMID, true, req.GeneratePassword)
+	config, err := client.GetVMConfig(ctx, node.NodeName, vm.VMID)
 	if err != nil {
-		s.logger.WithContext(ctx).Error("failed to get vm vncproxy", zap.Error(err),
-			zap.String("node", node.NodeName),
-			zap.Uint32("vmid", vm.VMID))
-		return nil, v1.ErrInternalServerError
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm config: %v", err)
 	}
 
-	// vncproxy 返回 data 通常包含 port(int) 和 ticket(string)
-	var port int
-	switch v := result["port"].(type) {
-	case float64:
-		port = int(v)
-	case int:
-		port = v
-	case int64:
-		port = int(v)
-	case json.Number:
-		if p, err := v.Int64(); err == nil {
-			port = int(p)
-		}
-	case string:
-		if p, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
-			port = p
+	// Proxmox 默认 memory=512、balloon 与 memory 相同（开启 ballooning 但不回收）、shares=1000
+	memory := vmConfigInt(config["memory"], 512)
+	shares := vmConfigInt(config["shares"], 1000)
+	ostype, _ := config["ostype"].(string)
+
+	update := make(map[string]interface{})
+	if req.MemorySize != nil && *req.MemorySize != memory {
+		memory = *req.MemorySize
+		update["memory"] = memory
+	}
+	// 未设置 balloon 时随最大内存变化
+	balloon := vmConfigInt(config["balloon"], memory)
+	if req.BalloonMin != nil {
+		balloon = *req.BalloonMin
+		update["balloon"] = balloon
+	}
+	if req.Shares != nil {
+		shares = *req.Shares
+		update["shares"] = shares
+	}
+	if balloon > 0 {
+		if err := checkVMBalloon(ostype, memory, balloon); err != nil {
+			return nil, err
 		}
 	}
-	ticket, _ := result["ticket"].(string)
+	if len(update) == 0 {
+		return &v1.ResizeVMMemoryResponseData{MemorySize: memory, BalloonMin: balloon, Shares: shares}, nil
+	}
+
+	pendingReboot := false
+	if _, ok := update["memory"]; ok {
+		status, err := client.GetVMStatus(ctx, node.NodeName, vm.VMID)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get vm status", zap.Error(err), zap.Uint32("vmid", vm.VMID))
+		} else if state, _ := status["status"].(string); state == "running" && !vmMemoryHotplugEnabled(config) {
+			pendingReboot = true
+		}
+	}
+
+	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, update); err != nil {
+		s.logger.WithContext(ctx).Error("failed to resize vm memory", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update vm config: %v", err)
+	}
+
+	if vm.MemorySize != memory {
+		vm.MemorySize = memory
+		vm.UpdateTime = time.Now()
+		if err := s.vmRepo.Update(ctx, vm); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update vm memory size", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("vm memory resized", zap.Uint32("vmid", vm.VMID), zap.String("node", node.NodeName),
+		zap.Int("memory", memory), zap.Int("balloon", balloon), zap.Int("shares", shares), zap.Bool("pending_reboot", pendingReboot))
+	return &v1.ResizeVMMemoryResponseData{
+		MemorySize:    memory,
+		BalloonMin:    balloon,
+		Shares:        shares,
+		PendingReboot: pendingReboot,
+	}, nil
+}
+
+// balloonUnsupportedOSTypes 没有可用 virtio-balloon 驱动的 Proxmox ostype
+var balloonUnsupportedOSTypes = map[string]bool{
+	"l24":     true,
+	"wxp":     true,
+	"w2k":     true,
+	"w2k3":    true,
+	"solaris": true,
+}
+
+// checkVMBalloon 校验 ballooning 配置：guest 操作系统需有 balloon 驱动，最小内存不能超过最大内存。
+// ostype 或 memory 未知（空 / 0）时跳过对应检查；balloon 为 0 表示关闭 ballooning，总是合法
+func checkVMBalloon(ostype string, memory, balloon int) error {
+	if balloon == 0 {
+		return nil
+	}
+	if balloonUnsupportedOSTypes[ostype] {
+		return v1.WithDetailf(v1.ErrBalloonUnsupported, "os_type=%s", ostype)
+	}
+	if memory > 0 && balloon > memory {
+		return v1.WithDetailf(v1.ErrInvalidParameter, "balloon_min=%d exceeds memory_size=%d", balloon, memory)
+	}
+	return nil
+}
+
+// vmMemoryHotplugEnabled 内存热插拔需要 hotplug 包含 memory 且启用 NUMA
+func vmMemoryHotplugEnabled(config map[string]interface{}) bool {
+	hotplug, _ := config["hotplug"].(string)
+	memoryHotplug := false
+	for _, item := range strings.Split(hotplug, ",") {
+		if strings.TrimSpace(item) == "memory" {
+			memoryHotplug = true
+			break
+		}
+	}
+	return memoryHotplug && vmConfigInt(config["numa"], 0) == 1
+}
+
+// vmConfigInt 解析 Proxmox 配置中的整数，兼容数字、字符串和 PVE 8 的 current=<n> 格式
+func vmConfigInt(value interface{}, fallback int) int {
+	switch v := value.(type) {
+	case float64:
+		return int(v)
+	case string:
+		v = strings.TrimPrefix(strings.TrimSpace(v), "current=")
+		if i := strings.Index(v, ","); i >= 0 {
+			v = v[:i]
+		}
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// vmBalloonMetrics 从 Proxmox status/current 中提取 ballooning 指标（字节转换为 MB）
+func vmBalloonMetrics(status map[string]interface{}) v1.VMBalloonMetrics {
+	mb := func(value interface{}) int64 {
+		v, _ := value.(float64)
+		return int64(v) / (1024 * 1024)
+	}
+	metrics := v1.VMBalloonMetrics{
+		MaxMemMB: mb(status["maxmem"]),
+		MinMemMB: mb(status["balloon_min"]),
+		ActualMB: mb(status["balloon"]),
+	}
+	_, metrics.Enabled = status["balloon"]
+	if shares, ok := status["shares"].(float64); ok {
+		metrics.Shares = int64(shares)
+	}
+	if info, ok := status["ballooninfo"].(map[string]interface{}); ok {
+		metrics.Enabled = true
+		metrics.DriverReported = info["total_mem"] != nil
+		if actual, ok := info["actual"]; ok {
+			metrics.ActualMB = mb(actual)
+		}
+		metrics.GuestTotalMB = mb(info["total_mem"])
+		metrics.GuestFreeMB = mb(info["free_mem"])
+		metrics.SwappedInMB = mb(info["mem_swapped_in"])
+		metrics.SwappedOutMB = mb(info["mem_swapped_out"])
+		if faults, ok := info["major_page_faults"].(float64); ok {
+			metrics.MajorFaults = int64(faults)
+		}
+	}
+	return metrics
+}
+
+func (s *pveVMService) GetVMConsole(ctx context.Context, userID string, req *v1.GetVMConsoleRequest) (map[string]interface{}, error) {
+	client, node, err := s.getProxmoxClientForVM(ctx, req.VMID)
+	if err != nil {
+		return nil, err
+	}
+
+	vm, err := s.vmRepo.GetByID(ctx, req.VMID)
+	if err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.ErrNotFound
+	}
+
+	result, port, ticket, err := s.requestVNCProxy(ctx, client, node.NodeName, vm.VMID, req.GeneratePassword)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := newConsoleToken()
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to generate console session id", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	now := time.Now()
+	lease := &vmConsoleLease{
+		UserID:           userID,
+		VMID:             req.VMID,
+		GeneratePassword: req.GeneratePassword,
+		Proxy:            result,
+		Port:             port,
+		Ticket:           ticket,
+		TicketIssuedAt:   now,
+		CreatedAt:        now,
+	}
+	lease.touch(now)
+	if err := s.sharedTokens.Put(ctx, model.SharedTokenVMConsoleLease, sessionID, lease, lease.ExpiresAt); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save console session", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	return s.issueConsoleToken(ctx, sessionID, lease)
+}
+
+// RenewVMConsoleSession 续期控制台会话（顺延空闲有效期，不超过最长有效期）
+func (s *pveVMService) RenewVMConsoleSession(ctx context.Context, userID, sessionID string) (*v1.VMConsoleSessionData, error) {
+	lease, err := s.loadConsoleLease(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	lease.touch(time.Now())
+	if err := s.saveConsoleLease(ctx, sessionID, lease); err != nil {
+		return nil, err
+	}
+	return &v1.VMConsoleSessionData{
+		SessionID: sessionID,
+		VMID:      lease.VMID,
+		ExpiresAt: lease.ExpiresAt.Unix(),
+	}, nil
+}
+
+// ReconnectVMConsole 断线后为会话签发新的 ws_token：
+// 当前 vncproxy ticket 尚未被使用且仍在可复用时间内时直接复用，否则重新调用 vncproxy 获取新的 port / ticket
+func (s *pveVMService) ReconnectVMConsole(ctx context.Context, userID, sessionID string) (map[string]interface{}, error) {
+	lease, err := s.loadConsoleLease(ctx, userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	reuse := !lease.TicketUsed && time.Since(lease.TicketIssuedAt) < consoleTicketReuseWindow
+	vmID, generatePassword := lease.VMID, lease.GeneratePassword
+
+	if !reuse {
+		client, node, err := s.getProxmoxClientForVM(ctx, vmID)
+		if err != nil {
+			return nil, err
+		}
+		vm, err := s.vmRepo.GetByID(ctx, vmID)
+		if err != nil {
+			return nil, v1.ErrInternalServerError
+		}
+		if vm == nil {
+			return nil, v1.ErrNotFound
+		}
+		result, port, ticket, err := s.requestVNCProxy(ctx, client, node.NodeName, vm.VMID, generatePassword)
+		if err != nil {
+			return nil, err
+		}
+
+		lease.Proxy, lease.Port, lease.Ticket = result, port, ticket
+		lease.TicketIssuedAt = time.Now()
+		lease.TicketUsed = false
+	}
+
+	lease.touch(time.Now())
+	if err := s.saveConsoleLease(ctx, sessionID, lease); err != nil {
+		return nil, err
+	}
+
+	data, err := s.issueConsoleToken(ctx, sessionID, lease)
+	if err != nil {
+		return nil, err
+	}
+	data["reused_ticket"] = reuse
+	s.logger.WithContext(ctx).Info("vm console reconnected",
+		zap.String("user_id", userID), zap.Int64("vm_id", vmID), zap.Bool("reused_ticket", reuse))
+	return data, nil
+}
+
+// requestVNCProxy 调用 vncproxy 并解析 port / ticket
+func (s *pveVMService) requestVNCProxy(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, generatePassword bool) (map[string]interface{}, int, string, error) {
+	// noVNC 需要 vncproxy 的 port/ticket 再去连 vncwebsocket；这里默认强制开启 websocket=1
+	// 避免前端未传 websocket 导致返回字段不全（port/ticket 缺失）
+	result, err := client.QemuVNCProxy(ctx, nodeName, vmid, true, generatePassword)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm vncproxy", zap.Error(err),
+			zap.String("node", nodeName),
+			zap.Uint32("vmid", vmid))
+		return nil, 0, "", v1.ErrInternalServerError
+	}
+
+	// vncproxy 返回 data 通常包含 port(int) 和 ticket(string)
+	var port int
+	switch v := result["port"].(type) {
+	case float64:
+		port = int(v)
+	case int:
+		port = v
+	case int64:
+		port = int(v)
+	case json.Number:
+		if p, err := v.Int64(); err == nil {
+			port = int(p)
+		}
+	case string:
+		if p, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			port = p
+		}
+	}
+	ticket, _ := result["ticket"].(string)
 	if port <= 0 || strings.TrimSpace(ticket) == "" {
 		s.logger.WithContext(ctx).Warn("vncproxy response missing port/ticket", zap.Any("data", result))
-		return nil, v1.ErrInternalServerError
+		return nil, 0, "", v1.ErrInternalServerError
 	}
+	return result, port, ticket, nil
+}
 
+// issueConsoleToken 为会话当前的 vncproxy ticket 签发单次使用的 ws_token，返回前端连接所需的全部字段
+func (s *pveVMService) issueConsoleToken(ctx context.Context, sessionID string, lease *vmConsoleLease) (map[string]interface{}, error) {
 	token, err := newConsoleToken()
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to generate console token", zap.Error(err))
 		return nil, v1.ErrInternalServerError
 	}
-	exp := time.Now().Add(2 * time.Minute)
-	s.consoleSessions.Store(token, vmConsoleSession{
-		VMID:      req.VMID,
-		Port:      port,
-		Ticket:    ticket,
+
+	exp := time.Now().Add(consoleTokenTTL)
+	if err := s.sharedTokens.Put(ctx, model.SharedTokenVMConsole, token, vmConsoleSession{
+		SessionID: sessionID,
+		VMID:      lease.VMID,
+		Port:      lease.Port,
+		Ticket:    lease.Ticket,
 		ExpiresAt: exp,
-	})
+	}, exp); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save console token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	result := make(map[string]interface{}, len(lease.Proxy)+4)
+	for k, v := range lease.Proxy {
+		result[k] = v
+	}
 	result["ws_token"] = token
 	result["ws_expires_at"] = exp.Unix()
-
+	result["session_id"] = sessionID
+	result["session_expires_at"] = lease.ExpiresAt.Unix()
 	return result, nil
 }
 
-// DialVMConsoleWebsocket 通过 ws_token 建立到 Proxmox vncwebsocket 的连接（单次使用/短期有效）
-func (s *pveVMService) DialVMConsoleWebsocket(ctx context.Context, token string) (*websocket.Conn, error) {
-	if strings.TrimSpace(token) == "" {
-		return nil, v1.ErrBadRequest
+// loadConsoleLease 读取控制台会话，会话不存在、已过期或不属于当前用户时返回 ErrConsoleSessionNotFound
+func (s *pveVMService) loadConsoleLease(ctx context.Context, userID, sessionID string) (*vmConsoleLease, error) {
+	var lease vmConsoleLease
+	ok, err := s.sharedTokens.Get(ctx, model.SharedTokenVMConsoleLease, sessionID, &lease)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load console session", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if !ok || lease.UserID != userID {
+		return nil, v1.ErrConsoleSessionNotFound
 	}
+	return &lease, nil
+}
 
-	val, ok := s.consoleSessions.LoadAndDelete(token)
+// saveConsoleLease 写回控制台会话，会话已被清理时返回 ErrConsoleSessionNotFound
+func (s *pveVMService) saveConsoleLease(ctx context.Context, sessionID string, lease *vmConsoleLease) error {
+	ok, err := s.sharedTokens.Update(ctx, model.SharedTokenVMConsoleLease, sessionID, lease, lease.ExpiresAt)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to save console session", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
 	if !ok {
-		return nil, v1.ErrNotFound
+		return v1.ErrConsoleSessionNotFound
+	}
+	return nil
+}
+
+// DialVMConsoleWebsocket 通过 ws_token 建立到 Proxmox vncwebsocket 的连接（单次使用/短期有效），同时返回用于会话审计的归属信息
+func (s *pveVMService) DialVMConsoleWebsocket(ctx context.Context, token string) (*websocket.Conn, *ConsoleTarget, error) {
+	if strings.TrimSpace(token) == "" {
+		return nil, nil, v1.ErrBadRequest
+	}
+
+	var session vmConsoleSession
+	ok, err := s.sharedTokens.Consume(ctx, model.SharedTokenVMConsole, token, &session)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load console token", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
 	}
-	session, ok := val.(vmConsoleSession)
 	if !ok {
-		return nil, v1.ErrInternalServerError
+		return nil, nil, v1.ErrNotFound
 	}
-	if time.Now().After(session.ExpiresAt) {
-		return nil, v1.ErrUnauthorized
+
+	// vncproxy 端口只接受一次连接，标记 ticket 已使用，之后的重连需要重新获取
+	var userID string
+	var lease vmConsoleLease
+	if ok, err := s.sharedTokens.Get(ctx, model.SharedTokenVMConsoleLease, session.SessionID, &lease); err == nil && ok {
+		if lease.Ticket == session.Ticket {
+			lease.TicketUsed = true
+		}
+		lease.touch(time.Now())
+		userID = lease.UserID
+		if _, err := s.sharedTokens.Update(ctx, model.SharedTokenVMConsoleLease, session.SessionID, &lease, lease.ExpiresAt); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to update console session", zap.Error(err))
+		}
 	}
 
 	client, node, err := s.getProxmoxClientForVM(ctx, session.VMID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	params := url.Values{}
@@ -1454,10 +2476,10 @@ func (s *pveVMService) DialVMConsoleWebsocket(ctx context.Context, token string)
 	vm, err := s.vmRepo.GetByID(ctx, session.VMID)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to get vm for websocket", zap.Error(err))
-		return nil, v1.ErrInternalServerError
+		return nil, nil, v1.ErrInternalServerError
 	}
 	if vm == nil {
-		return nil, v1.ErrNotFound
+		return nil, nil, v1.ErrNotFound
 	}
 
 	path := fmt.Sprintf("/nodes/%s/qemu/%d/vncwebsocket", node.NodeName, vm.VMID)
@@ -1466,9 +2488,20 @@ func (s *pveVMService) DialVMConsoleWebsocket(ctx context.Context, token string)
 		s.logger.WithContext(ctx).Error("failed to dial proxmox vncwebsocket", zap.Error(err),
 			zap.String("node", node.NodeName),
 			zap.Uint32("vmid", vm.VMID))
-		return nil, v1.ErrInternalServerError
+		return nil, nil, v1.ErrInternalServerError
 	}
-	return conn, nil
+	return conn, &ConsoleTarget{
+		SessionID:   session.SessionID,
+		UserID:      userID,
+		TargetType:  model.ConsoleSessionTargetVM,
+		TargetID:    vm.Id,
+		TargetName:  vm.VmName,
+		VMID:        vm.VMID,
+		ClusterID:   vm.ClusterID,
+		NodeName:    node.NodeName,
+		AppId:       vm.AppId,
+		ConsoleType: "vnc",
+	}, nil
 }
 
 func (s *pveVMService) GetVMRRDData(ctx context.Context, vmID int64, timeframe, cf string) ([]map[string]interface{}, error) {
@@ -1517,12 +2550,21 @@ func (s *pveVMService) MigrateVM(ctx context.Context, req *v1.MigrateVMRequest)
 		return "", v1.ErrInternalServerError
 	}
 	if targetNode == nil {
-		return "", fmt.Errorf("目标节点 ID %d 不存在", req.TargetNodeID)
+		return "", v1.WithDetailf(v1.ErrNodeNotFound, "target_node_id=%d", req.TargetNodeID)
 	}
 
 	// 验证目标节点是否在同一集群
 	if targetNode.ClusterID != vm.ClusterID {
-		return "", fmt.Errorf("目标节点不在同一集群内，请使用远程迁移接口")
+		return "", v1.ErrMigrateCrossCluster
+	}
+	if err := s.authorizeVMChange(ctx, "vm.migrate", vm); err != nil {
+		return "", err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, sourceNode.NodeName, vm.VMID, "vm.migrate"); err != nil {
+		return "", err
+	}
+	if err := s.checkMigrationCompat(ctx, client, vm, sourceNode, targetNode, req.Online, req.Force); err != nil {
+		return "", err
 	}
 
 	// 4. 构建迁移参数
@@ -1567,81 +2609,690 @@ func (s *pveVMService) MigrateVM(ctx context.Context, req *v1.MigrateVMRequest)
 	return upid, nil
 }
 
-func (s *pveVMService) RemoteMigrateVM(ctx context.Context, req *v1.RemoteMigrateVMRequest) (string, error) {
-	// 1. 获取源虚拟机信息
-	vm, err := s.vmRepo.GetByID(ctx, req.VMID)
+// releaseAfterTask 创建 / 克隆任务结束后释放资源预留（任务失败或等待超时同样释放）
+func (s *pveVMService) releaseAfterTask(client *proxmox.ProxmoxClient, nodeName, upid string, hold *ProvisionHold) {
+	defer hold.Release()
+	if err := client.WaitForTask(context.Background(), nodeName, upid, defaultProvisionReservationTTL); err != nil {
+		s.logger.Warn("provisioning task did not finish successfully, release reservation", zap.String("upid", upid), zap.Error(err))
+	}
+}
+
+// applyClonedVMConfig 等待克隆任务完成后把需要单独放置的磁盘移动到指定存储，再设置虚拟机配置（CPU 类型、规格等）
+func (s *pveVMService) applyClonedVMConfig(client *proxmox.ProxmoxClient, taskNode, vmNode, upid string, vmID uint32, config map[string]interface{}, diskMoves map[string]string, format string) {
+	ctx := context.Background()
+	if err := client.WaitForTask(ctx, taskNode, upid, 30*time.Minute); err != nil {
+		s.logger.Warn("clone task did not finish, config not applied", zap.Uint32("vmid", vmID), zap.String("upid", upid), zap.Error(err))
+		return
+	}
+	disks := make([]string, 0, len(diskMoves))
+	for disk := range diskMoves {
+		disks = append(disks, disk)
+	}
+	sort.Strings(disks)
+	for _, disk := range disks {
+		params := url.Values{}
+		params.Set("disk", disk)
+		params.Set("storage", diskMoves[disk])
+		params.Set("delete", "1")
+		if format != "" {
+			params.Set("format", format)
+		}
+		moveUPID, err := client.MoveVMDisk(ctx, vmNode, vmID, params)
+		if err == nil {
+			err = client.WaitForTask(ctx, vmNode, moveUPID, 60*time.Minute)
+		}
+		if err != nil {
+			s.logger.Warn("failed to move cloned disk", zap.Uint32("vmid", vmID), zap.String("disk", disk),
+				zap.String("storage", diskMoves[disk]), zap.Error(err))
+			continue
+		}
+		s.logger.Info("moved cloned disk", zap.Uint32("vmid", vmID), zap.String("disk", disk), zap.String("storage", diskMoves[disk]))
+	}
+	if len(config) == 0 {
+		return
+	}
+	if err := client.UpdateVMConfig(ctx, vmNode, vmID, config); err != nil {
+		s.logger.Warn("failed to apply config to cloned vm", zap.Uint32("vmid", vmID), zap.Any("config", config), zap.Error(err))
+		return
+	}
+	s.logger.Info("applied config to cloned vm", zap.Uint32("vmid", vmID), zap.Any("config", config))
+}
+
+// checkMigrationCompat 迁移前检查目标节点的 QEMU 版本和 CPU 兼容性。
+// 存在阻断问题时按配置拒绝迁移（force 可跳过）；检查本身失败不影响迁移。
+func (s *pveVMService) checkMigrationCompat(ctx context.Context, client *proxmox.ProxmoxClient, vm *model.PveVM, sourceNode, targetNode *model.PveNode, online, force *bool) error {
+	result, err := s.nodeVersion.CheckVMMigration(ctx, client, vm, sourceNode, targetNode, online)
 	if err != nil {
-		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
-		return "", v1.ErrInternalServerError
+		s.logger.WithContext(ctx).Warn("failed to check migration compatibility, proceeding", zap.Uint32("vmid", vm.VMID), zap.Error(err))
+		return nil
 	}
-	if vm == nil {
-		return "", v1.ErrNotFound
+	for _, issue := range result.Warnings {
+		s.logger.WithContext(ctx).Warn("migration compatibility warning", zap.Uint32("vmid", vm.VMID),
+			zap.String("target_node", targetNode.NodeName), zap.String("code", issue.Code), zap.String("message", issue.Message))
+	}
+	if result.Compatible {
+		return nil
+	}
+	if !s.nodeVersion.EnforceMigrationCheck() || (force != nil && *force) {
+		s.logger.WithContext(ctx).Warn("migrating vm despite compatibility blockers", zap.Uint32("vmid", vm.VMID),
+			zap.String("target_node", targetNode.NodeName), zap.String("blockers", migrationIssueSummary(result.Blockers)))
+		return nil
 	}
+	return v1.WithDetail(v1.ErrMigrationIncompatible, migrationIssueSummary(result.Blockers))
+}
 
-	// 2. 获取源集群和节点信息
-	client, sourceNode, err := s.getProxmoxClientForVM(ctx, req.VMID)
+// validateCreateVM 创建前对照 Proxmox 校验请求参数，收集全部问题后一次返回：
+// 存储存在于节点且支持 images、网桥存在、ISO 卷存在、VMID 未被集群内虚拟机或容器占用。
+// 无法从 Proxmox 获取数据的检查项跳过，由后续创建调用报错
+func (s *pveVMService) validateCreateVM(ctx context.Context, client *proxmox.ProxmoxClient, cluster *model.PveCluster, node *model.PveNode, req *v1.CreateVMRequest, createMode string, vmID uint32, reserved ProvisionUsage) error {
+	var issues []string
+
+	storageName := strings.TrimSpace(req.Storage)
+	bridge := strings.TrimSpace(req.Bridge)
+	isoVol := strings.TrimPrefix(strings.TrimSpace(req.ISOVolume), "/")
+	efiStorage := ""
+	if machine := normalizeMachineType(req.Machine); machine != "" && !machineTypePattern.MatchString(machine) {
+		issues = append(issues, fmt.Sprintf("machine %s is not a valid machine type, expected q35, i440fx or a versioned type like pc-q35-8.1", req.Machine))
+	}
+	switch createMode {
+	case "template":
+		// 链接克隆使用模板所在存储，不校验 storage（与模板存储的一致性在克隆前单独校验）
+		if isLinkedClone(req) {
+			storageName = ""
+		}
+	case "iso", "empty":
+		if storageName == "" {
+			issues = append(issues, fmt.Sprintf("storage is required (create_mode=%s)", createMode))
+		}
+		if bridge == "" {
+			bridge = "vmbr0"
+		}
+		if createMode == "iso" && isoVol == "" {
+			issues = append(issues, "iso_volume is required (create_mode=iso)")
+		}
+		ostype := strings.TrimSpace(req.OSType)
+		if ostype == "" {
+			ostype = "l26"
+		}
+		if _, bios, tpm := vmFirmwareDefaults(req, ostype); bios == "ovmf" || tpm {
+			efiStorage = strings.TrimSpace(req.EFIStorage)
+			if efiStorage == storageName {
+				efiStorage = ""
+			}
+		}
+	}
+	if createMode != "iso" {
+		isoVol = ""
+	}
+
+	// 磁盘列表：iso/empty 时包含系统盘，template 时为克隆后新增的磁盘；未指定存储时使用 storage，
+	// template 模式下 storage 也为空时使用模板所在存储（不在此校验）
+	diskStorages := make(map[string]int64)
+	var diskFormats []string
+	if len(req.Disks) > 0 {
+		issues = append(issues, checkDiskSpecs(req.Disks)...)
+		for i, disk := range req.Disks {
+			storage := diskSpecStorage(disk, req.Storage)
+			if storage == "" {
+				if createMode != "template" {
+					issues = append(issues, fmt.Sprintf("disks[%d]: storage is required", i))
+				}
+				continue
+			}
+			diskStorages[storage] += int64(disk.SizeGB) << 30
+			if format := strings.TrimSpace(disk.Format); format != "" && format != "raw" {
+				diskFormats = append(diskFormats, fmt.Sprintf("%d:%s:%s", i, storage, format))
+			}
+		}
+	} else if createMode != "template" && storageName != "" {
+		for storage, size := range createVMDiskUsage(req, createMode) {
+			diskStorages[storage] += size
+		}
+	}
+
+	if issue := checkConsoleOptions(req.SerialNum, req.Display); issue != "" {
+		issues = append(issues, issue)
+	}
+
+	// 网卡列表替代 bridge / net_model / mac_address
+	bridges := []string{bridge}
+	if len(req.NICs) > 0 {
+		if strings.TrimSpace(req.MACAddress) != "" {
+			issues = append(issues, "mac_address cannot be combined with nics, set nics[].mac_address instead")
+		}
+		if req.VLANTag != nil || req.Firewall != nil {
+			issues = append(issues, "vlan_tag and firewall cannot be combined with nics, set nics[].vlan_tag / nics[].firewall instead")
+		}
+		bridges = bridges[:0]
+		seen := make(map[string]bool)
+		for _, nic := range req.NICs {
+			if b := strings.TrimSpace(nic.Bridge); b != "" && !seen[b] {
+				seen[b] = true
+				bridges = append(bridges, b)
+			}
+		}
+	} else if bridge == "" {
+		bridges = nil
+	}
+
+	if storageName != "" || isoVol != "" || efiStorage != "" || len(diskStorages) > 0 {
+		storages, err := client.GetNodeStorages(ctx, node.NodeName, "")
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to list node storages, skip storage validation", zap.String("node", node.NodeName), zap.Error(err))
+		} else {
+			byName := make(map[string]map[string]interface{}, len(storages))
+			for _, item := range storages {
+				if name, _ := item["storage"].(string); name != "" {
+					byName[name] = item
+				}
+			}
+			if storageName != "" {
+				issues = append(issues, checkCreateVMStorage(byName[storageName], storageName, node.NodeName, "images")...)
+			}
+			if efiStorage != "" {
+				issues = append(issues, checkCreateVMStorage(byName[efiStorage], efiStorage, node.NodeName, "images")...)
+			}
+			issues = append(issues, checkDiskStorages(byName, diskStorages, reserved.Storage, diskFormats, node.NodeName, storageName)...)
+			if isoVol != "" {
+				isoStorage, _, ok := strings.Cut(isoVol, ":")
+				if !ok || isoStorage == "" {
+					issues = append(issues, fmt.Sprintf("iso_volume %s is not a valid volume id, expected <storage>:iso/<file>", isoVol))
+				} else if storageIssues := checkCreateVMStorage(byName[isoStorage], isoStorage, node.NodeName, "iso"); len(storageIssues) > 0 {
+					issues = append(issues, storageIssues...)
+				} else if found, err := storageHasVolume(ctx, client, node.NodeName, isoStorage, "iso", isoVol); err != nil {
+					s.logger.WithContext(ctx).Warn("failed to list iso volumes, skip iso validation", zap.String("storage", isoStorage), zap.Error(err))
+				} else if !found {
+					issues = append(issues, fmt.Sprintf("iso volume %s does not exist on node %s", isoVol, node.NodeName))
+				}
+			}
+		}
+	}
+
+	if len(bridges) > 0 {
+		networks, err := client.GetNodeNetworks(ctx, node.NodeName)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to list node networks, skip bridge validation", zap.String("node", node.NodeName), zap.Error(err))
+		} else {
+			existing := make(map[string]bool)
+			for _, item := range networks {
+				iface, _ := item["iface"].(string)
+				ifaceType, _ := item["type"].(string)
+				if ifaceType == "bridge" || ifaceType == "OVSBridge" {
+					existing[iface] = true
+				}
+			}
+			for _, b := range bridges {
+				if !existing[b] {
+					issues = append(issues, fmt.Sprintf("bridge %s does not exist on node %s", b, node.NodeName))
+				}
+			}
+		}
+	}
+
+	if memory := createVMMemory(req, createMode); memory > 0 {
+		if issue, err := s.checkNodeMemory(ctx, client, cluster, node.NodeName, memory, reserved.MemoryMB); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get node memory, skip memory validation", zap.String("node", node.NodeName), zap.Error(err))
+		} else if issue != "" {
+			issues = append(issues, issue)
+		}
+	}
+
+	resources, err := client.GetClusterResourcesByType(ctx, "vm")
 	if err != nil {
-		return "", err
+		s.logger.WithContext(ctx).Warn("failed to list cluster resources, skip vmid validation", zap.Error(err))
+	} else {
+		for _, resource := range resources {
+			vmidFloat, _ := resource["vmid"].(float64)
+			if uint32(vmidFloat) != vmID {
+				continue
+			}
+			resourceNode, _ := resource["node"].(string)
+			name, _ := resource["name"].(string)
+			issues = append(issues, fmt.Sprintf("vmid %d is already in use by %s on node %s", vmID, name, resourceNode))
+			break
+		}
+	}
+
+	if len(issues) > 0 {
+		s.logger.WithContext(ctx).Warn("create vm validation failed", zap.String("vm_name", req.VmName),
+			zap.Uint32("vmid", vmID), zap.Strings("issues", issues))
+		return v1.WithDetail(v1.ErrCreateVMValidationFailed, strings.Join(issues, "; "))
 	}
+	return nil
+}
 
-	// 3. 获取目标集群信息
-	targetCluster, err := s.clusterRepo.GetByID(ctx, req.TargetClusterID)
+// allocateTemplateNet0 为模板克隆的 net0 分配 MAC：指定网桥时按 bridge / net_model 重建 net0，
+// 否则沿用模板的 net0 只替换 MAC；vlan_tag / firewall 覆盖模板中的设置。模板没有 net0（或未取到模板配置）时返回空
+func (s *pveVMService) allocateTemplateNet0(ctx context.Context, templateConfig map[string]interface{},
+	req *v1.CreateVMRequest, clusterID int64, vmID uint32) (string, string, error) {
+	net0 := ""
+	if bridge := strings.TrimSpace(req.Bridge); bridge != "" {
+		netModel := "virtio"
+		if strings.TrimSpace(req.NetModel) != "" {
+			netModel = strings.TrimSpace(req.NetModel)
+		}
+		net0 = fmt.Sprintf("%s,bridge=%s", netModel, bridge)
+	} else {
+		net0, _ = templateConfig["net0"].(string)
+	}
+	if net0 == "" {
+		if strings.TrimSpace(req.MACAddress) != "" || req.VLANTag != nil || req.Firewall != nil {
+			return "", "", v1.WithDetail(v1.ErrInvalidParameter, "mac_address, vlan_tag and firewall require bridge or a template with net0")
+		}
+		return "", "", nil
+	}
+	mac, err := s.macRegistry.Allocate(ctx, &MACAllocation{
+		MAC:       strings.TrimSpace(req.MACAddress),
+		ClusterID: clusterID,
+		VMID:      vmID,
+		NicName:   "net0",
+		Hostname:  req.VmName,
+	})
 	if err != nil {
-		s.logger.WithContext(ctx).Error("failed to get target cluster", zap.Error(err))
-		return "", v1.ErrInternalServerError
+		return "", "", err
 	}
-	if targetCluster == nil {
-		return "", fmt.Errorf("目标集群 ID %d 不存在", req.TargetClusterID)
+	dev := proxmox.ParseDeviceConfig(netConfigWithMAC(net0, mac))
+	setNICOptions(dev, req.VLANTag, req.Firewall)
+	return dev.String(), mac, nil
+}
+
+// checkDiskStorages 校验 disks 中用到的存储：存在于节点且支持 images、格式与存储类型匹配、可用空间足够。
+// 与 storage 相同的存储已单独校验过存在性，不重复报告
+func checkDiskStorages(byName map[string]map[string]interface{}, required, reserved map[string]int64, formats []string, nodeName, checked string) []string {
+	var issues []string
+	names := make([]string, 0, len(required))
+	for name := range required {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		info := byName[name]
+		if name != checked {
+			if storageIssues := checkCreateVMStorage(info, name, nodeName, "images"); len(storageIssues) > 0 {
+				issues = append(issues, storageIssues...)
+				continue
+			}
+		} else if info == nil {
+			continue
+		}
+		if avail, ok := info["avail"].(float64); ok && required[name]+reserved[name] > int64(avail) {
+			issues = append(issues, fmt.Sprintf("storage %s needs %.1f GiB for the new disks but only %.1f GiB is available%s",
+				name, float64(required[name])/(1<<30), (avail-float64(reserved[name]))/(1<<30), reservedNote(reserved[name], nodeName)))
+		}
 	}
+	for _, item := range formats {
+		parts := strings.SplitN(item, ":", 3)
+		info := byName[parts[1]]
+		if info == nil {
+			continue
+		}
+		if storageType, _ := info["type"].(string); !fileStorageTypes[storageType] {
+			issues = append(issues, fmt.Sprintf("disks[%s]: storage %s (type %s) only supports raw format, got %s", parts[0], parts[1], storageType, parts[2]))
+		}
+	}
+	return issues
+}
 
-	// 4. 获取目标节点信息
-	targetNode, err := s.nodeRepo.GetByID(ctx, req.TargetNodeID)
+// defaultNodeMemoryLimit 新虚拟机、进行中的创建与节点已用内存合计占节点内存的上限，可通过 provision_reservation.memory_limit 调整
+const defaultNodeMemoryLimit = 1.0
+
+// checkNodeMemory 校验节点剩余内存能否容纳新虚拟机：节点已用内存 + 其他进行中的创建预留 + 新虚拟机内存
+// 不超过（节点内存 - 集群为系统开销预留的内存）× memory_limit；memory_limit <= 0 时不校验
+func (s *pveVMService) checkNodeMemory(ctx context.Context, client *proxmox.ProxmoxClient, cluster *model.PveCluster, nodeName string, memoryMB, reservedMB int) (string, error) {
+	limit := defaultNodeMemoryLimit
+	if s.conf.IsSet("provision_reservation.memory_limit") {
+		limit = s.conf.GetFloat64("provision_reservation.memory_limit")
+	}
+	if limit <= 0 {
+		return "", nil
+	}
+	resources, err := client.GetClusterResourcesByType(ctx, "node")
 	if err != nil {
-		s.logger.WithContext(ctx).Error("failed to get target node", zap.Error(err))
-		return "", v1.ErrInternalServerError
+		return "", err
 	}
-	if targetNode == nil {
-		return "", fmt.Errorf("目标节点 ID %d 不存在", req.TargetNodeID)
+	for _, resource := range resources {
+		if name, _ := resource["node"].(string); name != nodeName {
+			continue
+		}
+		maxMem, used := resourceFloat(resource, "maxmem"), resourceFloat(resource, "mem")
+		if maxMem <= 0 {
+			return "", nil
+		}
+		reserved := float64(int64(reservedMB) << 20)
+		capacity := (maxMem - nodeReservedMemory(cluster, maxMem)) * limit
+		if used+reserved+float64(int64(memoryMB)<<20) > capacity {
+			return fmt.Sprintf("node %s needs %d MiB of memory for the new vm but only %.0f MiB is available%s%s",
+				nodeName, memoryMB, (capacity-used-reserved)/(1<<20), reservedNote(int64(reservedMB)<<20, nodeName),
+				systemReservedNote(cluster)), nil
+		}
+		return "", nil
 	}
+	return "", nil
+}
 
-	// 验证目标节点是否在目标集群内
-	if targetNode.ClusterID != req.TargetClusterID {
-		return "", fmt.Errorf("目标节点不在指定的目标集群内")
+// systemReservedNote 容量不足时说明集群为系统开销预留的内存比例
+func systemReservedNote(cluster *model.PveCluster) string {
+	if cluster == nil || cluster.ReservedMemoryPercent <= 0 {
+		return ""
 	}
+	return fmt.Sprintf(" (%d%% of node memory is reserved for system overhead)", cluster.ReservedMemoryPercent)
+}
+
+// reservedNote 容量不足时说明其中被其他进行中的创建请求预留的部分
+func reservedNote(reserved int64, nodeName string) string {
+	if reserved <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%.1f GiB is reserved by other vms being created on node %s)", float64(reserved)/(1<<30), nodeName)
+}
+
+// linkedCloneStorageTypes 支持链接克隆的存储类型（文件存储要求模板磁盘为 qcow2）
+var linkedCloneStorageTypes = map[string]bool{
+	"dir": true, "nfs": true, "cifs": true, "glusterfs": true,
+	"lvmthin": true, "zfspool": true, "zfs": true, "rbd": true,
+}
+
+// fileStorageTypes 以文件保存磁盘、可选择 qcow2 / vmdk 格式的存储类型，其余块存储只支持 raw
+var fileStorageTypes = map[string]bool{"dir": true, "nfs": true, "cifs": true, "glusterfs": true, "cephfs": true}
+
+// isLinkedClone 判断模板克隆是否为链接克隆：clone_mode 优先，未设置时沿用 full_clone=0
+func isLinkedClone(req *v1.CreateVMRequest) bool {
+	if req.CloneMode != "" {
+		return req.CloneMode == "linked"
+	}
+	return req.FullClone != nil && *req.FullClone == 0
+}
 
-	// 5. 获取目标集群的 fingerprint
-	// 创建目标集群的客户端来获取证书信息
-	targetClient, err := proxmox.NewProxmoxClient(targetCluster.ApiUrl, targetCluster.UserId, targetCluster.UserToken)
+// cloneSourceDisk 模板中的一块磁盘
+type cloneSourceDisk struct {
+	key     string
+	volume  string
+	storage string
+	size    int64
+}
+
+// validateCloneStorage 克隆前校验克隆方式与存储的兼容性，收集全部问题后一次返回：
+// 链接克隆要求模板存储支持链接克隆、跨节点时为共享存储，且不能改变存储或格式；
+// 完整克隆校验按盘指定的存储和格式，并按模板磁盘大小预估目标存储的可用空间。
+// 返回克隆完成后需要单独移动的磁盘（磁盘槽位 -> 目标存储）。无法从 Proxmox 获取数据时跳过校验
+// 返回需要移动的磁盘，以及完整克隆时各目标存储需要的空间（字节）
+func (s *pveVMService) validateCloneStorage(ctx context.Context, client *proxmox.ProxmoxClient, node, sourceNode *model.PveNode, instance *model.TemplateInstance, req *v1.CreateVMRequest, linked bool, reserved map[string]int64) (map[string]string, map[string]int64, error) {
+	config, err := client.GetVMConfig(ctx, sourceNode.NodeName, instance.VMID)
 	if err != nil {
-		s.logger.WithContext(ctx).Error("failed to create target cluster client", zap.Error(err))
-		return "", v1.ErrInternalServerError
+		s.logger.WithContext(ctx).Warn("failed to get template config, skip clone storage validation",
+			zap.Uint32("template_vmid", instance.VMID), zap.Error(err))
+		return nil, nil, nil
+	}
+	var disks []cloneSourceDisk
+	for key, value := range config {
+		if !movableDiskPattern.MatchString(key) {
+			continue
+		}
+		spec, _ := value.(string)
+		volume, opts, _ := strings.Cut(spec, ",")
+		if volume == "" || volume == "none" || strings.Contains(volume, "cloudinit") ||
+			strings.Contains(","+opts+",", ",media=cdrom,") {
+			continue
+		}
+		storage, _, ok := strings.Cut(volume, ":")
+		if !ok {
+			continue
+		}
+		disks = append(disks, cloneSourceDisk{key: key, volume: volume, storage: storage, size: parseDiskSize(opts)})
 	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].key < disks[j].key })
 
-	var fingerprint string
-	certificates, err := targetClient.GetNodeCertificatesInfo(ctx, targetNode.NodeName)
+	sourceStorages, err := nodeStoragesByName(ctx, client, sourceNode.NodeName)
 	if err != nil {
-		s.logger.WithContext(ctx).Warn("failed to get target node certificates info, will proceed without fingerprint", zap.Error(err))
-		// 如果获取失败，继续执行但不使用 fingerprint
+		s.logger.WithContext(ctx).Warn("failed to list template node storages, skip clone storage validation",
+			zap.String("node", sourceNode.NodeName), zap.Error(err))
+		return nil, nil, nil
+	}
+	targetStorages := sourceStorages
+	if node.NodeName != sourceNode.NodeName {
+		if targetStorages, err = nodeStoragesByName(ctx, client, node.NodeName); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to list node storages, skip clone storage validation",
+				zap.String("node", node.NodeName), zap.Error(err))
+			return nil, nil, nil
+		}
+	}
+
+	var issues []string
+	diskMoves := make(map[string]string)
+	required := make(map[string]int64)
+	if linked {
+		if req.CloneFormat != "" {
+			issues = append(issues, "clone_format is only supported for full clones")
+		}
+		if len(req.DiskStorages) > 0 {
+			issues = append(issues, "disk_storages is only supported for full clones")
+		}
+		checked := make(map[string]bool)
+		for _, disk := range disks {
+			if storage := strings.TrimSpace(req.Storage); storage != "" && storage != disk.storage {
+				issues = append(issues, fmt.Sprintf("linked clone must stay on template storage %s, got storage %s", disk.storage, storage))
+			}
+			if checked[disk.storage] {
+				continue
+			}
+			checked[disk.storage] = true
+			info := sourceStorages[disk.storage]
+			storageType, _ := info["type"].(string)
+			if info != nil && !linkedCloneStorageTypes[storageType] {
+				issues = append(issues, fmt.Sprintf("storage %s (type %s) does not support linked clones", disk.storage, storageType))
+			} else if fileStorageTypes[storageType] && !strings.HasSuffix(disk.volume, ".qcow2") {
+				issues = append(issues, fmt.Sprintf("template disk %s is not qcow2, linked clones on storage %s require qcow2", disk.volume, disk.storage))
+			}
+			if shared, _ := info["shared"].(float64); info != nil && shared == 0 && node.NodeName != sourceNode.NodeName {
+				issues = append(issues, fmt.Sprintf("template storage %s is local to node %s, linked clones to node %s require shared storage",
+					disk.storage, sourceNode.NodeName, node.NodeName))
+			}
+		}
 	} else {
-		// 查找 filename == "pve-ssl.pem" 的证书的 fingerprint（不要使用 pve-root-ca.pem）
-		for _, cert := range certificates {
-			if filename, ok := cert["filename"].(string); ok && filename == "pve-ssl.pem" {
-				if fp, ok := cert["fingerprint"].(string); ok && fp != "" {
-					fingerprint = fp
-					s.logger.WithContext(ctx).Info("found pve-ssl.pem fingerprint",
-						zap.String("fingerprint", fingerprint))
-					break
+		known := make(map[string]bool, len(disks))
+		for _, disk := range disks {
+			known[disk.key] = true
+		}
+		for key := range req.DiskStorages {
+			if !known[key] {
+				issues = append(issues, fmt.Sprintf("disk %s does not exist in template", key))
+			}
+		}
+
+		// 每块磁盘的目标存储：按盘指定 > storage > 模板磁盘所在存储
+		for _, disk := range disks {
+			target := disk.storage
+			if storage := strings.TrimSpace(req.Storage); storage != "" {
+				target = storage
+			}
+			if mapped := strings.TrimSpace(req.DiskStorages[disk.key]); mapped != "" {
+				if mapped != target {
+					diskMoves[disk.key] = mapped
 				}
+				target = mapped
 			}
+			required[target] += disk.size
 		}
-		if fingerprint == "" {
-			s.logger.WithContext(ctx).Warn("pve-ssl.pem certificate not found, will proceed without fingerprint",
-				zap.Int("cert_count", len(certificates)))
+
+		targets := make([]string, 0, len(required))
+		for name := range required {
+			targets = append(targets, name)
+		}
+		sort.Strings(targets)
+		for _, name := range targets {
+			info := targetStorages[name]
+			if storageIssues := checkCreateVMStorage(info, name, node.NodeName, "images"); len(storageIssues) > 0 {
+				issues = append(issues, storageIssues...)
+				continue
+			}
+			storageType, _ := info["type"].(string)
+			if req.CloneFormat != "" && req.CloneFormat != "raw" && !fileStorageTypes[storageType] {
+				issues = append(issues, fmt.Sprintf("storage %s (type %s) only supports raw format, got clone_format %s", name, storageType, req.CloneFormat))
+			}
+			if avail, ok := info["avail"].(float64); ok && required[name]+reserved[name] > int64(avail) {
+				issues = append(issues, fmt.Sprintf("storage %s needs about %.1f GiB for the cloned disks but only %.1f GiB is available%s",
+					name, float64(required[name])/(1<<30), (avail-float64(reserved[name]))/(1<<30), reservedNote(reserved[name], node.NodeName)))
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		s.logger.WithContext(ctx).Warn("clone storage validation failed", zap.String("vm_name", req.VmName),
+			zap.Uint32("template_vmid", instance.VMID), zap.Strings("issues", issues))
+		return nil, nil, v1.WithDetail(v1.ErrCreateVMValidationFailed, strings.Join(issues, "; "))
+	}
+	return diskMoves, required, nil
+}
+
+// nodeStoragesByName 获取节点存储列表并按存储名称索引
+func nodeStoragesByName(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string) (map[string]map[string]interface{}, error) {
+	storages, err := client.GetNodeStorages(ctx, nodeName, "")
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]map[string]interface{}, len(storages))
+	for _, item := range storages {
+		if name, _ := item["storage"].(string); name != "" {
+			byName[name] = item
+		}
+	}
+	return byName, nil
+}
+
+// parseDiskSize 解析磁盘配置中的 size 选项（如 size=32G），返回字节数，无法解析时返回 0
+func parseDiskSize(opts string) int64 {
+	for _, opt := range strings.Split(opts, ",") {
+		value, ok := strings.CutPrefix(opt, "size=")
+		if !ok || value == "" {
+			continue
+		}
+		unit := int64(1)
+		switch value[len(value)-1] {
+		case 'K', 'k':
+			unit = 1 << 10
+		case 'M', 'm':
+			unit = 1 << 20
+		case 'G', 'g':
+			unit = 1 << 30
+		case 'T', 't':
+			unit = 1 << 40
+		}
+		if unit > 1 {
+			value = value[:len(value)-1]
+		}
+		size, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0
+		}
+		return int64(size * float64(unit))
+	}
+	return 0
+}
+
+// vmFirmwareDefaults 返回空机创建使用的机器类型、固件和是否创建 TPM 状态盘，
+// 请求中显式传入的值优先；os_type=win11 时默认 q35 + OVMF + TPM 2.0，满足 Windows 11 安装要求
+func vmFirmwareDefaults(req *v1.CreateVMRequest, ostype string) (machine, bios string, tpm bool) {
+	machine = normalizeMachineType(req.Machine)
+	bios = strings.TrimSpace(req.BIOS)
+	if ostype == "win11" {
+		if machine == "" {
+			machine = "q35"
+		}
+		if bios == "" {
+			bios = "ovmf"
+		}
+		tpm = true
+	}
+	if req.TPM != nil {
+		tpm = *req.TPM
+	}
+	return machine, bios, tpm
+}
+
+// machineTypePattern Proxmox 支持的机器类型：pc / q35 / virt 及带版本的 pc-i440fx-x.y、pc-q35-x.y
+var machineTypePattern = regexp.MustCompile(`^(pc|q35|virt|pc-(i440fx-|q35-)?\d+(\.\d+)+|virt-\d+(\.\d+)+)(\+pve\d+)?(\.pxe)?$`)
+
+// normalizeMachineType 规范化机器类型，i440fx 转为 Proxmox 使用的 pc
+func normalizeMachineType(machine string) string {
+	machine = strings.ToLower(strings.TrimSpace(machine))
+	if machine == "i440fx" {
+		return "pc"
+	}
+	return machine
+}
+
+// checkCreateVMStorage 校验节点存储列表中的存储已启用、处于活动状态并支持指定内容类型
+func checkCreateVMStorage(storage map[string]interface{}, name, nodeName, content string) []string {
+	if storage == nil {
+		return []string{fmt.Sprintf("storage %s does not exist on node %s", name, nodeName)}
+	}
+	var issues []string
+	if enabled, ok := storage["enabled"].(float64); ok && enabled == 0 {
+		issues = append(issues, fmt.Sprintf("storage %s is disabled on node %s", name, nodeName))
+	} else if active, ok := storage["active"].(float64); ok && active == 0 {
+		issues = append(issues, fmt.Sprintf("storage %s is not active on node %s", name, nodeName))
+	}
+	contents, _ := storage["content"].(string)
+	supported := false
+	for _, item := range strings.Split(contents, ",") {
+		if strings.TrimSpace(item) == content {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		issues = append(issues, fmt.Sprintf("storage %s does not support %s (content=%s)", name, content, contents))
+	}
+	return issues
+}
+
+func (s *pveVMService) RemoteMigrateVM(ctx context.Context, req *v1.RemoteMigrateVMRequest) (string, error) {
+	// 1. 获取源虚拟机、源节点、目标集群和目标节点
+	m, err := s.loadRemoteMigration(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	vm, client, sourceNode := m.vm, m.client, m.sourceNode
+	targetCluster, targetNode, targetClient := m.targetCluster, m.targetNode, m.targetClient
+
+	// 变更管控校验：源集群和目标集群都需要允许变更
+	if err := s.authorizeVMChange(ctx, "vm.remote_migrate", vm); err != nil {
+		return "", err
+	}
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "vm.remote_migrate",
+		Target:    vm.VmName,
+		ClusterID: targetCluster.Id,
+		AppId:     vm.AppId,
+	}); err != nil {
+		return "", err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, sourceNode.NodeName, vm.VMID, "vm.remote_migrate"); err != nil {
+		return "", err
+	}
+	if err := s.checkMigrationCompat(ctx, client, vm, sourceNode, targetNode, req.Online, req.Force); err != nil {
+		return "", err
+	}
+
+	// 5. 获取目标节点的 fingerprint
+	// 使用保存的证书指纹，目标节点证书变更时拒绝迁移，避免迁移任务因指纹不匹配失败
+	fingerprint, err := targetNodeFingerprint(ctx, s.certRepo, targetClient, targetCluster, targetNode.NodeName, s.logger)
+	if err != nil {
+		return "", err
+	}
+
+	// 迁移前检查目标存储、网桥和 Token 权限，force 时仅记录
+	preflight := s.remoteMigratePreflight(ctx, m, req)
+	if !preflight.Passed {
+		if req.Force == nil || !*req.Force {
+			return "", v1.WithDetail(v1.ErrRemoteMigratePreflightFailed, migrationIssueSummary(preflight.Blockers))
 		}
+		s.logger.WithContext(ctx).Warn("remote migration pre-flight failed, forced",
+			zap.Uint32("vmid", vm.VMID), zap.String("blockers", migrationIssueSummary(preflight.Blockers)))
 	}
 
 	// 6. 构建 target-endpoint
-	// 格式：host=<TARGET_IP>,apitoken=<API_TOKEN>[,port=<PORT>][,fingerprint=<FINGERPRINT>]
+	// 格式：host=<TARGET_IP>,apitoken=<API_TOKEN>,fingerprint=<FINGERPRINT>[,port=<PORT>]
 	// API_TOKEN 格式：PVEAPIToken=<UserId>=<UserToken>
 	apiToken := fmt.Sprintf("PVEAPIToken=%s=%s", targetCluster.UserId, targetCluster.UserToken)
 
@@ -1649,7 +3300,7 @@ func (s *pveVMService) RemoteMigrateVM(ctx context.Context, req *v1.RemoteMigrat
 	targetURL, err := url.Parse(targetCluster.ApiUrl)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to parse target cluster api url", zap.Error(err))
-		return "", fmt.Errorf("目标集群 API URL 格式错误")
+		return "", v1.ErrInvalidClusterAPIURL
 	}
 
 	targetHost := targetURL.Hostname()
@@ -1663,13 +3314,9 @@ func (s *pveVMService) RemoteMigrateVM(ctx context.Context, req *v1.RemoteMigrat
 		}
 	}
 
-	// 构建 target-endpoint，格式：host=<HOST>,apitoken=<TOKEN>[,fingerprint=<FINGERPRINT>],port=<PORT>
+	// 构建 target-endpoint，格式：host=<HOST>,apitoken=<TOKEN>,fingerprint=<FINGERPRINT>,port=<PORT>
 	// 注意：参数顺序可能重要，按照 Proxmox 文档格式
-	targetEndpoint := fmt.Sprintf("host=%s,apitoken=%s", targetHost, apiToken)
-	if fingerprint != "" {
-		targetEndpoint += fmt.Sprintf(",fingerprint=%s", fingerprint)
-	}
-	targetEndpoint += fmt.Sprintf(",port=%s", targetPort)
+	targetEndpoint := fmt.Sprintf("host=%s,apitoken=%s,fingerprint=%s,port=%s", targetHost, apiToken, fingerprint, targetPort)
 
 	// 7. 构建迁移参数
 	params := make(map[string]interface{})
@@ -1684,8 +3331,9 @@ func (s *pveVMService) RemoteMigrateVM(ctx context.Context, req *v1.RemoteMigrat
 	if req.Online != nil {
 		params["online"] = *req.Online
 	}
-	if req.Bwlimit != nil {
-		params["bwlimit"] = *req.Bwlimit
+	// 未指定带宽限制时依次使用当前迁移窗口和跨站点配置
+	if preflight.BwlimitSource != "" {
+		params["bwlimit"] = preflight.Bwlimit
 	}
 	if req.Delete != nil {
 		params["delete"] = *req.Delete
@@ -1697,6 +3345,8 @@ func (s *pveVMService) RemoteMigrateVM(ctx context.Context, req *v1.RemoteMigrat
 		zap.String("target_node", targetNode.NodeName),
 		zap.String("target_cluster", targetCluster.ClusterName),
 		zap.Uint32("vmid", vm.VMID),
+		zap.Any("bwlimit", params["bwlimit"]),
+		zap.String("bwlimit_source", preflight.BwlimitSource),
 		zap.String("target_bridge", req.TargetBridge),
 		zap.String("target_storage", req.TargetStorage),
 		zap.String("target_host", targetHost),
@@ -1722,9 +3372,63 @@ func (s *pveVMService) RemoteMigrateVM(ctx context.Context, req *v1.RemoteMigrat
 		zap.String("target_cluster", targetCluster.ClusterName),
 		zap.String("upid", upid))
 
+	// 9. 登记切换：迁移任务成功后更新虚拟机归属、IP、cloud-init 和 DNS，登记失败不影响迁移
+	cutover := &model.RemoteMigrationCutover{
+		VmId:            vm.Id,
+		VmName:          vm.VmName,
+		SourceClusterID: vm.ClusterID,
+		SourceNode:      sourceNode.NodeName,
+		SourceVMID:      vm.VMID,
+		TargetClusterID: targetCluster.Id,
+		TargetNodeID:    targetNode.Id,
+		TargetNode:      targetNode.NodeName,
+		TargetVMID:      preflight.TargetVMID,
+		UPID:            upid,
+		UpdateDNS:       1,
+	}
+	if len(req.ReIP) > 0 {
+		reIP, _ := json.Marshal(req.ReIP)
+		cutover.ReIP = string(reIP)
+	}
+	if req.UpdateDNS != nil && !*req.UpdateDNS {
+		cutover.UpdateDNS = 0
+	}
+	_ = s.cutovers.Register(ctx, cutover)
+
 	return upid, nil
 }
 
+// crossSiteBwlimit 源集群与目标集群分属不同站点时，返回两个站点配置的跨站点带宽限制中较小的非零值；
+// 同站点或任一集群未分配站点时返回 0
+func (s *pveVMService) crossSiteBwlimit(ctx context.Context, sourceClusterID int64, targetCluster *model.PveCluster) int {
+	sourceCluster, err := s.clusterRepo.GetByID(ctx, sourceClusterID)
+	if err != nil || sourceCluster == nil {
+		return 0
+	}
+	if sourceCluster.SiteID == 0 || targetCluster.SiteID == 0 || sourceCluster.SiteID == targetCluster.SiteID {
+		return 0
+	}
+
+	sites, err := s.siteRepo.GetByIDs(ctx, []int64{sourceCluster.SiteID, targetCluster.SiteID})
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get sites for cross-site migration", zap.Error(err))
+		return 0
+	}
+	bwlimit := 0
+	for _, site := range sites {
+		if site.CrossSiteBwlimit > 0 && (bwlimit == 0 || site.CrossSiteBwlimit < bwlimit) {
+			bwlimit = site.CrossSiteBwlimit
+		}
+	}
+	if bwlimit > 0 {
+		s.logger.WithContext(ctx).Info("cross-site migration, applying site bwlimit",
+			zap.String("source_cluster", sourceCluster.ClusterName),
+			zap.String("target_cluster", targetCluster.ClusterName),
+			zap.Int("bwlimit", bwlimit))
+	}
+	return bwlimit
+}
+
 // CreateBackup 创建虚拟机备份
 // 参考: https://pve.proxmox.com/pve-docs/api-viewer/#/nodes/{node}/vzdump
 func (s *pveVMService) CreateBackup(ctx context.Context, req *v1.CreateBackupRequest) (*v1.CreateBackupResponseData, error) {
@@ -1738,7 +3442,7 @@ func (s *pveVMService) CreateBackup(ctx context.Context, req *v1.CreateBackupReq
 
 	// 方案：查询所有 VM，找到匹配的 VMID
 	var vm *model.PveVM
-	allVMs, _, err := s.vmRepo.ListWithPagination(ctx, 1, 1000, 0, "", 0, "", 0, "", "")
+	allVMs, _, err := s.vmRepo.ListWithPagination(ctx, 1, 1000, 0, "", 0, "", 0, "", "", nil)
 	if err == nil {
 		for _, v := range allVMs {
 			if v.VMID == req.VMID {
@@ -1749,7 +3453,7 @@ func (s *pveVMService) CreateBackup(ctx context.Context, req *v1.CreateBackupReq
 	}
 
 	if vm == nil {
-		return nil, fmt.Errorf("虚拟机 VMID %d 不存在", req.VMID)
+		return nil, v1.WithDetailf(v1.ErrVMNotFound, "vmid=%d", req.VMID)
 	}
 
 	// 2. 获取节点信息
@@ -1759,7 +3463,7 @@ func (s *pveVMService) CreateBackup(ctx context.Context, req *v1.CreateBackupReq
 		return nil, v1.ErrInternalServerError
 	}
 	if node == nil {
-		return nil, fmt.Errorf("节点不存在")
+		return nil, v1.ErrNodeNotFound
 	}
 
 	// 3. 获取 Proxmox 客户端
@@ -1767,8 +3471,27 @@ func (s *pveVMService) CreateBackup(ctx context.Context, req *v1.CreateBackupReq
 	if err != nil {
 		return nil, err
 	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.backup"); err != nil {
+		return nil, err
+	}
 
-	// 4. 构建备份请求参数
+	// 4. 预估目标存储空间：预估不足时拒绝（force 时仍然发起），无法预估时不阻断
+	var forecast *v1.StorageForecastData
+	if req.Storage != "" {
+		forecast, err = storageForecaster{conf: s.conf}.backup(ctx, client, node.NodeName, vm.VMID, req.Storage, req.Compress)
+		switch {
+		case errors.Is(err, v1.ErrStorageNotFound):
+			return nil, err
+		case err != nil:
+			s.logger.WithContext(ctx).Warn("failed to forecast backup space, skip check",
+				zap.Uint32("vmid", vm.VMID), zap.String("storage", req.Storage), zap.Error(err))
+			forecast = nil
+		case forecast.Verdict == v1.StorageForecastInsufficient && !req.Force:
+			return nil, v1.WithDetail(v1.ErrStorageSpaceInsufficient, forecast.Message)
+		}
+	}
+
+	// 5. 构建备份请求参数
 	backupReq := &proxmox.CreateBackupRequest{
 		VMID: req.VMID,
 	}
@@ -1832,7 +3555,7 @@ func (s *pveVMService) CreateBackup(ctx context.Context, req *v1.CreateBackupReq
 		backupReq.Zstd = *req.Zstd
 	}
 
-	// 5. 调用 Proxmox API 创建备份
+	// 6. 调用 Proxmox API 创建备份
 	upid, err := client.CreateBackup(ctx, node.NodeName, backupReq)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create backup",
@@ -1854,6 +3577,7 @@ func (s *pveVMService) CreateBackup(ctx context.Context, req *v1.CreateBackupReq
 		VMID:     req.VMID,
 		NodeID:   node.Id,
 		NodeName: node.NodeName,
+		Forecast: forecast,
 	}, nil
 }
 
@@ -1867,7 +3591,7 @@ func (s *pveVMService) DeleteBackup(ctx context.Context, req *v1.DeleteBackupReq
 		return v1.ErrInternalServerError
 	}
 	if node == nil {
-		return fmt.Errorf("节点 ID %d 不存在", req.NodeID)
+		return v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
 	}
 
 	// 2. 获取存储信息
@@ -1877,7 +3601,16 @@ func (s *pveVMService) DeleteBackup(ctx context.Context, req *v1.DeleteBackupReq
 		return v1.ErrInternalServerError
 	}
 	if storage == nil {
-		return fmt.Errorf("存储 ID %d 不存在", req.StorageID)
+		return v1.WithDetailf(v1.ErrStorageNotFound, "storage_id=%d", req.StorageID)
+	}
+
+	// 变更管控校验（备份不归属具体应用，按集群判断）
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "backup.delete",
+		Target:    req.Volume,
+		ClusterID: node.ClusterID,
+	}); err != nil {
+		return err
 	}
 
 	// 3. 获取 Proxmox 客户端
@@ -1915,7 +3648,7 @@ func (s *pveVMService) GetVMCloudInit(ctx context.Context, req *v1.GetVMCloudIni
 		return nil, v1.ErrInternalServerError
 	}
 	if node == nil {
-		return nil, fmt.Errorf("节点 ID %d 不存在", req.NodeID)
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
 	}
 
 	// 2. 获取 Proxmox 客户端
@@ -1950,7 +3683,20 @@ func (s *pveVMService) UpdateVMCloudInit(ctx context.Context, req *v1.UpdateVMCl
 		return v1.ErrInternalServerError
 	}
 	if node == nil {
-		return fmt.Errorf("节点 ID %d 不存在", req.NodeID)
+		return v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
+	}
+
+	// 变更管控校验：优先按数据库中的虚拟机记录判断应用，未同步时按节点所在集群判断
+	vm, err := s.vmRepo.GetByVMID(ctx, req.VMID, node.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if vm == nil {
+		vm = &model.PveVM{VmName: fmt.Sprintf("vmid=%d", req.VMID), ClusterID: node.ClusterID, VMID: req.VMID}
+	}
+	if err := s.authorizeVMChange(ctx, "vm.cloudinit", vm); err != nil {
+		return err
 	}
 
 	// 2. 获取 Proxmox 客户端
@@ -1958,6 +3704,9 @@ func (s *pveVMService) UpdateVMCloudInit(ctx context.Context, req *v1.UpdateVMCl
 	if err != nil {
 		return err
 	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, req.VMID, "vm.cloudinit"); err != nil {
+		return err
+	}
 
 	// 3. 构建 form 参数
 	params := url.Values{}
@@ -1992,6 +3741,15 @@ func (s *pveVMService) UpdateVMCloudInit(ctx context.Context, req *v1.UpdateVMCl
 	// 4. 调用 Proxmox API 更新 CloudInit 配置
 	err = client.UpdateVMCloudInitConfig(ctx, node.NodeName, req.VMID, params)
 	if err != nil {
+		if queued := s.pendingOps.QueueIfUnreachable(ctx, &PendingOperationInput{
+			Operation: PendingOperationVMCloudInit,
+			ClusterID: node.ClusterID,
+			NodeID:    node.Id,
+			VM:        vm,
+			Payload:   params,
+		}, err); queued != nil {
+			return queued
+		}
 		s.logger.WithContext(ctx).Error("failed to update vm cloudinit config",
 			zap.Error(err),
 			zap.Uint32("vmid", req.VMID),
@@ -0,0 +1,627 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 再平衡默认参数，可通过 rebalance.* 调整
+const (
+	defaultRebalanceThreshold        = 0.2
+	defaultRebalanceMaxMoves         = 5
+	defaultRebalanceMemoryLimit      = 0.9
+	defaultRebalanceMigrationTimeout = 2 * time.Hour
+	defaultRebalancePlanTTL          = time.Hour
+
+	// 单次迁移至少降低的利用率差值，避免为微小改善迁移虚拟机
+	minRebalanceGain = 0.01
+)
+
+type RebalanceService interface {
+	// Analyze 定期分析所有集群，失衡时生成新的待执行方案并取代旧方案
+	Analyze(ctx context.Context) error
+	CreatePlan(ctx context.Context, userID string, req *v1.CreateRebalancePlanRequest) (*v1.RebalancePlanItem, error)
+	GetPlan(ctx context.Context, id int64) (*v1.RebalancePlanItem, error)
+	ListPlans(ctx context.Context, req *v1.ListRebalancePlansRequest) (*v1.ListRebalancePlansResponseData, error)
+	ApplyPlan(ctx context.Context, userID string, id int64, req *v1.ApplyRebalancePlanRequest) (*v1.RebalancePlanItem, error)
+}
+
+func NewRebalanceService(
+	service *Service,
+	conf *viper.Viper,
+	planRepo repository.RebalanceRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	vmService PveVMService,
+	changeControl ChangeControlService,
+	reservations ProvisionReservationService,
+	logger *log.Logger,
+) RebalanceService {
+	return &rebalanceService{
+		conf:          conf,
+		planRepo:      planRepo,
+		clusterRepo:   clusterRepo,
+		nodeRepo:      nodeRepo,
+		vmRepo:        vmRepo,
+		userRepo:      userRepo,
+		vmService:     vmService,
+		changeControl: changeControl,
+		reservations:  reservations,
+		Service:       service,
+		logger:        logger,
+	}
+}
+
+type rebalanceService struct {
+	conf          *viper.Viper
+	planRepo      repository.RebalanceRepository
+	clusterRepo   repository.PveClusterRepository
+	nodeRepo      repository.PveNodeRepository
+	vmRepo        repository.PveVMRepository
+	userRepo      repository.UserRepository
+	vmService     PveVMService
+	changeControl ChangeControlService
+	reservations  ProvisionReservationService
+	*Service
+	logger *log.Logger
+}
+
+// rebalanceNode 参与再平衡的节点负载（CPU 以核数计，内存以字节计）
+type rebalanceNode struct {
+	id     int64
+	name   string
+	cpu    float64
+	maxCPU float64
+	mem    float64
+	maxMem float64
+}
+
+// rebalanceVM 可迁移的运行中虚拟机
+type rebalanceVM struct {
+	vm   *model.PveVM
+	node string
+	cpu  float64
+	mem  float64
+}
+
+func (s *rebalanceService) Analyze(ctx context.Context) error {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, cluster := range clusters {
+		// 正在执行方案的集群负载处于变化中，跳过本轮分析
+		applying, err := s.planRepo.CountApplying(ctx, cluster.Id)
+		if err != nil {
+			return err
+		}
+		if applying > 0 {
+			continue
+		}
+		plan, err := s.computePlan(ctx, cluster, "scheduled", "system")
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to compute rebalance plan", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			continue
+		}
+		if err := s.planRepo.SupersedePending(ctx, cluster.Id); err != nil {
+			return err
+		}
+		if plan == nil {
+			continue
+		}
+		if err := s.planRepo.Create(ctx, plan); err != nil {
+			return err
+		}
+		s.logger.WithContext(ctx).Info("rebalance plan suggested", zap.String("cluster", cluster.ClusterName),
+			zap.Int64("plan_id", plan.Id), zap.Int("moves", plan.TotalMoves),
+			zap.Float64("cpu_spread", plan.CPUSpreadBefore), zap.Float64("mem_spread", plan.MemSpreadBefore))
+	}
+	return nil
+}
+
+func (s *rebalanceService) CreatePlan(ctx context.Context, userID string, req *v1.CreateRebalancePlanRequest) (*v1.RebalancePlanItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "rebalance.manage")
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.ErrClusterNotFound
+	}
+
+	plan, err := s.computePlan(ctx, cluster, "manual", username)
+	if err != nil {
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	if plan == nil {
+		return nil, v1.WithDetailf(v1.ErrRebalanceNotNeeded, "cluster=%s", cluster.ClusterName)
+	}
+	if err := s.planRepo.SupersedePending(ctx, cluster.Id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to supersede rebalance plans", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if err := s.planRepo.Create(ctx, plan); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create rebalance plan", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	item := toRebalancePlanItem(plan)
+	return &item, nil
+}
+
+// computePlan 读取集群实时负载并计算再平衡方案；负载已均衡或找不到有效迁移时返回 nil
+func (s *rebalanceService) computePlan(ctx context.Context, cluster *model.PveCluster, source, creator string) (*model.RebalancePlan, error) {
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return nil, err
+	}
+	nodeResources, err := client.GetClusterResourcesByType(ctx, "node")
+	if err != nil {
+		return nil, fmt.Errorf("get cluster nodes: %w", err)
+	}
+	vmResources, err := client.GetClusterResourcesByType(ctx, "vm")
+	if err != nil {
+		return nil, fmt.Errorf("get cluster vms: %w", err)
+	}
+	dbNodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		return nil, err
+	}
+	dbVMs, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 只有在线且可调度（未进入维护）的节点参与再平衡
+	nodeByName := make(map[string]*model.PveNode, len(dbNodes))
+	for _, node := range dbNodes {
+		nodeByName[node.NodeName] = node
+	}
+	nodes := make(map[string]*rebalanceNode)
+	for _, resource := range nodeResources {
+		name, _ := resource["node"].(string)
+		status, _ := resource["status"].(string)
+		dbNode := nodeByName[name]
+		if status != "online" || dbNode == nil || dbNode.IsSchedulable == 0 {
+			continue
+		}
+		maxCPU, maxMem := resourceFloat(resource, "maxcpu"), resourceFloat(resource, "maxmem")
+		// 利用率按扣除集群为系统开销预留的 CPU 和内存之后的容量计算
+		node := &rebalanceNode{
+			id:     dbNode.Id,
+			name:   name,
+			cpu:    resourceFloat(resource, "cpu") * maxCPU,
+			maxCPU: maxCPU - nodeReservedCPU(cluster, maxCPU),
+			mem:    resourceFloat(resource, "mem"),
+			maxMem: maxMem - nodeReservedMemory(cluster, maxMem),
+		}
+		// 进行中的创建请求预留的内存视为已占用，避免把虚拟机迁往即将被填满的节点
+		node.mem += float64(int64(s.reservations.NodeUsage(dbNode.Id, nil).MemoryMB) << 20)
+		if node.maxCPU <= 0 || node.maxMem <= 0 {
+			continue
+		}
+		nodes[name] = node
+	}
+	if len(nodes) < 2 {
+		return nil, nil
+	}
+
+	vmByVMID := make(map[uint32]*model.PveVM, len(dbVMs))
+	for _, vm := range dbVMs {
+		vmByVMID[vm.VMID] = vm
+	}
+	var vms []*rebalanceVM
+	for _, resource := range vmResources {
+		resourceType, _ := resource["type"].(string)
+		status, _ := resource["status"].(string)
+		lock, _ := resource["lock"].(string)
+		nodeName, _ := resource["node"].(string)
+		if resourceType != "qemu" || status != "running" || lock != "" ||
+			resourceFloat(resource, "template") == 1 || nodes[nodeName] == nil {
+			continue
+		}
+		vm := vmByVMID[uint32(resourceFloat(resource, "vmid"))]
+		if vm == nil {
+			continue
+		}
+		vms = append(vms, &rebalanceVM{
+			vm:   vm,
+			node: nodeName,
+			cpu:  resourceFloat(resource, "cpu") * resourceFloat(resource, "maxcpu"),
+			mem:  resourceFloat(resource, "mem"),
+		})
+	}
+
+	threshold := s.conf.GetFloat64("rebalance.threshold")
+	if threshold <= 0 {
+		threshold = defaultRebalanceThreshold
+	}
+	maxMoves := s.conf.GetInt("rebalance.max_moves")
+	if maxMoves <= 0 {
+		maxMoves = defaultRebalanceMaxMoves
+	}
+	memLimit := s.conf.GetFloat64("rebalance.memory_limit")
+	if memLimit <= 0 {
+		memLimit = defaultRebalanceMemoryLimit
+	}
+
+	cpuBefore, memBefore := rebalanceSpread(nodes)
+	if math.Max(cpuBefore, memBefore) <= threshold {
+		return nil, nil
+	}
+	moves := planRebalanceMoves(nodes, vms, threshold, memLimit, maxMoves)
+	if len(moves) == 0 {
+		return nil, nil
+	}
+	cpuAfter, memAfter := rebalanceSpread(nodes)
+
+	movesJSON, err := json.Marshal(moves)
+	if err != nil {
+		return nil, err
+	}
+	return &model.RebalancePlan{
+		ClusterID:       cluster.Id,
+		ClusterName:     cluster.ClusterName,
+		Source:          source,
+		CPUSpreadBefore: roundRatio(cpuBefore),
+		MemSpreadBefore: roundRatio(memBefore),
+		CPUSpreadAfter:  roundRatio(cpuAfter),
+		MemSpreadAfter:  roundRatio(memAfter),
+		Moves:           string(movesJSON),
+		Status:          model.RebalancePlanStatusPending,
+		TotalMoves:      len(moves),
+		Creator:         creator,
+	}, nil
+}
+
+// planRebalanceMoves 贪心选择迁移：每轮选出使利用率差值下降最多的一次迁移（相同时选内存更小的虚拟机），
+// 直到差值不超过阈值、达到迁移上限或没有可改善的迁移。每台虚拟机最多迁移一次，
+// 目标节点迁入后的内存利用率不超过 memLimit。nodes 会被更新为执行方案后的预估负载。
+func planRebalanceMoves(nodes map[string]*rebalanceNode, vms []*rebalanceVM, threshold, memLimit float64, maxMoves int) []model.RebalanceMove {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	moved := make(map[*rebalanceVM]bool)
+	moves := make([]model.RebalanceMove, 0)
+	for len(moves) < maxMoves {
+		cpuSpread, memSpread := rebalanceSpread(nodes)
+		current := math.Max(cpuSpread, memSpread)
+		if current <= threshold {
+			break
+		}
+
+		var bestVM *rebalanceVM
+		var bestTarget *rebalanceNode
+		bestScore := current - minRebalanceGain
+		for _, candidate := range vms {
+			if moved[candidate] {
+				continue
+			}
+			source := nodes[candidate.node]
+			for _, name := range names {
+				target := nodes[name]
+				if target == source || (target.mem+candidate.mem)/target.maxMem > memLimit {
+					continue
+				}
+				shiftLoad(source, target, candidate)
+				score := math.Max(rebalanceSpread(nodes))
+				shiftLoad(target, source, candidate)
+				if score < bestScore || (score == bestScore && bestVM != nil && candidate.mem < bestVM.mem) {
+					bestVM, bestTarget, bestScore = candidate, target, score
+				}
+			}
+		}
+		if bestVM == nil {
+			break
+		}
+
+		source := nodes[bestVM.node]
+		shiftLoad(source, bestTarget, bestVM)
+		moved[bestVM] = true
+		moves = append(moves, model.RebalanceMove{
+			VmId:         bestVM.vm.Id,
+			VMID:         bestVM.vm.VMID,
+			VmName:       bestVM.vm.VmName,
+			SourceNodeID: source.id,
+			SourceNode:   source.name,
+			TargetNodeID: bestTarget.id,
+			TargetNode:   bestTarget.name,
+			CPU:          math.Round(bestVM.cpu*100) / 100,
+			Memory:       int64(bestVM.mem),
+			Status:       model.RebalanceMoveStatusPending,
+		})
+	}
+	return moves
+}
+
+func shiftLoad(from, to *rebalanceNode, vm *rebalanceVM) {
+	from.cpu -= vm.cpu
+	from.mem -= vm.mem
+	to.cpu += vm.cpu
+	to.mem += vm.mem
+}
+
+// rebalanceSpread 返回节点间 CPU 和内存利用率的最大差值
+func rebalanceSpread(nodes map[string]*rebalanceNode) (float64, float64) {
+	minCPU, maxCPU := math.Inf(1), math.Inf(-1)
+	minMem, maxMem := math.Inf(1), math.Inf(-1)
+	for _, node := range nodes {
+		cpu := node.cpu / node.maxCPU
+		mem := node.mem / node.maxMem
+		minCPU, maxCPU = math.Min(minCPU, cpu), math.Max(maxCPU, cpu)
+		minMem, maxMem = math.Min(minMem, mem), math.Max(maxMem, mem)
+	}
+	if len(nodes) == 0 {
+		return 0, 0
+	}
+	return maxCPU - minCPU, maxMem - minMem
+}
+
+func resourceFloat(resource map[string]interface{}, key string) float64 {
+	value, _ := resource[key].(float64)
+	return value
+}
+
+func roundRatio(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// ApplyPlan 执行待执行的再平衡方案，迁移在后台逐个进行
+func (s *rebalanceService) ApplyPlan(ctx context.Context, userID string, id int64, req *v1.ApplyRebalancePlanRequest) (*v1.RebalancePlanItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "rebalance.manage")
+	if err != nil {
+		return nil, err
+	}
+	plan, err := s.planRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get rebalance plan", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if plan == nil {
+		return nil, v1.ErrRebalancePlanNotFound
+	}
+	if plan.Status != model.RebalancePlanStatusPending {
+		return nil, v1.WithDetailf(v1.ErrRebalancePlanNotPending, "status=%s", plan.Status)
+	}
+	ttl := s.conf.GetDuration("rebalance.plan_ttl")
+	if ttl <= 0 {
+		ttl = defaultRebalancePlanTTL
+	}
+	if time.Since(plan.CreateTime) > ttl {
+		return nil, v1.WithDetailf(v1.ErrRebalancePlanExpired, "created_at=%s", plan.CreateTime.Format(time.RFC3339))
+	}
+	applying, err := s.planRepo.CountApplying(ctx, plan.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to count applying rebalance plans", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if applying > 0 {
+		return nil, v1.ErrRebalanceInProgress
+	}
+	// 整体校验一次变更窗口；每次迁移开始前还会按虚拟机再次校验
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "cluster.rebalance",
+		Target:    plan.ClusterName,
+		ClusterID: plan.ClusterID,
+	}); err != nil {
+		return nil, err
+	}
+
+	bwlimit := s.conf.GetInt("rebalance.bwlimit")
+	if req.Bwlimit != nil {
+		bwlimit = *req.Bwlimit
+	}
+	now := time.Now()
+	plan.Status = model.RebalancePlanStatusApplying
+	plan.Bwlimit = bwlimit
+	plan.AppliedBy = username
+	plan.StartTime = &now
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update rebalance plan", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	go s.execute(plan.Id)
+
+	s.logger.WithContext(ctx).Info("rebalance plan applied", zap.Int64("plan_id", plan.Id),
+		zap.String("cluster", plan.ClusterName), zap.String("operator", username), zap.Int("bwlimit", bwlimit))
+	item := toRebalancePlanItem(plan)
+	return &item, nil
+}
+
+// execute 逐个执行方案中的迁移，遇到失败即停止，剩余迁移保持 pending
+func (s *rebalanceService) execute(planID int64) {
+	ctx := context.Background()
+	plan, err := s.planRepo.GetByID(ctx, planID)
+	if err != nil || plan == nil {
+		s.logger.Error("failed to load rebalance plan", zap.Int64("plan_id", planID), zap.Error(err))
+		return
+	}
+
+	var moves []model.RebalanceMove
+	if err := json.Unmarshal([]byte(plan.Moves), &moves); err != nil {
+		s.finish(ctx, plan, nil, fmt.Errorf("invalid move list: %w", err))
+		return
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, plan.ClusterID)
+	if err != nil || cluster == nil {
+		s.finish(ctx, plan, moves, fmt.Errorf("cluster %d not found", plan.ClusterID))
+		return
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.finish(ctx, plan, moves, err)
+		return
+	}
+	timeout := s.conf.GetDuration("rebalance.migration_timeout")
+	if timeout <= 0 {
+		timeout = defaultRebalanceMigrationTimeout
+	}
+
+	online := true
+	for i := range moves {
+		move := &moves[i]
+		// 虚拟机已被手动迁走时跳过，不影响后续迁移
+		vm, err := s.vmRepo.GetByID(ctx, move.VmId)
+		if err != nil || vm == nil || vm.NodeID != move.SourceNodeID {
+			move.Status = model.RebalanceMoveStatusSkipped
+			s.saveMoves(ctx, plan, moves)
+			continue
+		}
+
+		move.Status = model.RebalanceMoveStatusRunning
+		s.saveMoves(ctx, plan, moves)
+
+		migrateReq := &v1.MigrateVMRequest{VMID: move.VmId, TargetNodeID: move.TargetNodeID, Online: &online}
+		if plan.Bwlimit > 0 {
+			migrateReq.Bwlimit = &plan.Bwlimit
+		}
+		upid, err := s.vmService.MigrateVM(ctx, migrateReq)
+		if err == nil {
+			move.UPID = upid
+			s.saveMoves(ctx, plan, moves)
+			err = client.WaitForTask(ctx, move.SourceNode, upid, timeout)
+		}
+		if err != nil {
+			move.Status = model.RebalanceMoveStatusFailed
+			move.ErrorMessage = err.Error()
+			s.finish(ctx, plan, moves, fmt.Errorf("migrate %s to %s: %w", move.VmName, move.TargetNode, err))
+			return
+		}
+
+		move.Status = model.RebalanceMoveStatusCompleted
+		plan.DoneMoves++
+		s.saveMoves(ctx, plan, moves)
+		s.logger.Info("rebalance migration completed", zap.Int64("plan_id", plan.Id), zap.Uint32("vmid", move.VMID),
+			zap.String("source_node", move.SourceNode), zap.String("target_node", move.TargetNode))
+	}
+	s.finish(ctx, plan, moves, nil)
+}
+
+func (s *rebalanceService) saveMoves(ctx context.Context, plan *model.RebalancePlan, moves []model.RebalanceMove) {
+	if b, err := json.Marshal(moves); err == nil {
+		plan.Moves = string(b)
+	}
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		s.logger.Error("failed to update rebalance plan", zap.Int64("plan_id", plan.Id), zap.Error(err))
+	}
+}
+
+func (s *rebalanceService) finish(ctx context.Context, plan *model.RebalancePlan, moves []model.RebalanceMove, err error) {
+	end := time.Now()
+	plan.EndTime = &end
+	if err != nil {
+		s.logger.Error("rebalance plan failed", zap.Int64("plan_id", plan.Id), zap.String("cluster", plan.ClusterName), zap.Error(err))
+		plan.Status = model.RebalancePlanStatusFailed
+		plan.ErrorMessage = err.Error()
+	} else {
+		plan.Status = model.RebalancePlanStatusCompleted
+		s.logger.Info("rebalance plan completed", zap.Int64("plan_id", plan.Id), zap.String("cluster", plan.ClusterName),
+			zap.Int("moves", plan.DoneMoves))
+	}
+	if moves != nil {
+		s.saveMoves(ctx, plan, moves)
+		return
+	}
+	if err := s.planRepo.Update(ctx, plan); err != nil {
+		s.logger.Error("failed to update rebalance plan", zap.Int64("plan_id", plan.Id), zap.Error(err))
+	}
+}
+
+func (s *rebalanceService) GetPlan(ctx context.Context, id int64) (*v1.RebalancePlanItem, error) {
+	plan, err := s.planRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get rebalance plan", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if plan == nil {
+		return nil, v1.ErrRebalancePlanNotFound
+	}
+	item := toRebalancePlanItem(plan)
+	return &item, nil
+}
+
+func (s *rebalanceService) ListPlans(ctx context.Context, req *v1.ListRebalancePlansRequest) (*v1.ListRebalancePlansResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	plans, total, err := s.planRepo.List(ctx, page, pageSize, req.ClusterID, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list rebalance plans", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.RebalancePlanItem, 0, len(plans))
+	for _, plan := range plans {
+		list = append(list, toRebalancePlanItem(plan))
+	}
+	return &v1.ListRebalancePlansResponseData{Total: total, List: list}, nil
+}
+
+func toRebalancePlanItem(plan *model.RebalancePlan) v1.RebalancePlanItem {
+	var moves []model.RebalanceMove
+	_ = json.Unmarshal([]byte(plan.Moves), &moves)
+	items := make([]v1.RebalanceMoveItem, 0, len(moves))
+	for _, move := range moves {
+		items = append(items, v1.RebalanceMoveItem{
+			VmId:         move.VmId,
+			VMID:         move.VMID,
+			VmName:       move.VmName,
+			SourceNodeID: move.SourceNodeID,
+			SourceNode:   move.SourceNode,
+			TargetNodeID: move.TargetNodeID,
+			TargetNode:   move.TargetNode,
+			CPU:          move.CPU,
+			Memory:       move.Memory,
+			Status:       move.Status,
+			UPID:         move.UPID,
+			ErrorMessage: move.ErrorMessage,
+		})
+	}
+	return v1.RebalancePlanItem{
+		Id:              plan.Id,
+		ClusterID:       plan.ClusterID,
+		ClusterName:     plan.ClusterName,
+		Source:          plan.Source,
+		CPUSpreadBefore: plan.CPUSpreadBefore,
+		MemSpreadBefore: plan.MemSpreadBefore,
+		CPUSpreadAfter:  plan.CPUSpreadAfter,
+		MemSpreadAfter:  plan.MemSpreadAfter,
+		Status:          plan.Status,
+		TotalMoves:      plan.TotalMoves,
+		DoneMoves:       plan.DoneMoves,
+		Moves:           items,
+		Bwlimit:         plan.Bwlimit,
+		AppliedBy:       plan.AppliedBy,
+		StartTime:       plan.StartTime,
+		EndTime:         plan.EndTime,
+		ErrorMessage:    plan.ErrorMessage,
+		Creator:         plan.Creator,
+		CreateTime:      plan.CreateTime,
+	}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 remote_migration.scheduler.interval 时的默认检查间隔，也是变更管控阻止发起后的重试间隔
+const (
+	defaultRemoteMigrationSchedulerInterval = time.Minute
+	remoteMigrationBatchSize                = 20
+)
+
+// RemoteMigrationJobService 计划的跨集群迁移：在指定时间或迁移窗口开始时发起，并使用窗口的带宽限制
+type RemoteMigrationJobService interface {
+	// Schedule 预检通过后登记计划迁移，force 时忽略预检阻断问题
+	Schedule(ctx context.Context, userID string, req *v1.ScheduleRemoteMigrationRequest) (*v1.RemoteMigrationJobItem, error)
+	List(ctx context.Context, req *v1.ListRemoteMigrationJobsRequest) (*v1.ListRemoteMigrationJobsResponseData, error)
+	// Cancel 取消尚未发起的计划迁移，创建人或管理员可操作
+	Cancel(ctx context.Context, userID string, id int64) error
+	// RunDue 发起到期的计划迁移，返回处理数量
+	RunDue(ctx context.Context) (int, error)
+}
+
+func NewRemoteMigrationJobService(
+	service *Service,
+	conf *viper.Viper,
+	jobRepo repository.RemoteMigrationJobRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	vmService PveVMService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) RemoteMigrationJobService {
+	return &remoteMigrationJobService{
+		Service:             service,
+		conf:                conf,
+		jobRepo:             jobRepo,
+		vmRepo:              vmRepo,
+		userRepo:            userRepo,
+		vmService:           vmService,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+type remoteMigrationJobService struct {
+	*Service
+	conf                *viper.Viper
+	jobRepo             repository.RemoteMigrationJobRepository
+	vmRepo              repository.PveVMRepository
+	userRepo            repository.UserRepository
+	vmService           PveVMService
+	notificationService NotificationService
+	logger              *log.Logger
+
+	mu sync.Mutex
+}
+
+func (s *remoteMigrationJobService) retryInterval() time.Duration {
+	interval := s.conf.GetDuration("remote_migration.scheduler.interval")
+	if interval <= 0 {
+		interval = defaultRemoteMigrationSchedulerInterval
+	}
+	return interval
+}
+
+func (s *remoteMigrationJobService) Schedule(ctx context.Context, userID string, req *v1.ScheduleRemoteMigrationRequest) (*v1.RemoteMigrationJobItem, error) {
+	if req.Window == "" && req.ScheduledAt == nil {
+		return nil, v1.WithDetail(v1.ErrBadRequest, "window or scheduled_at is required")
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get user", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	now := time.Now()
+	scheduledAt := now
+	if req.ScheduledAt != nil && req.ScheduledAt.After(now) {
+		scheduledAt = *req.ScheduledAt
+	}
+	if req.Window != "" {
+		window := findMigrationWindow(loadMigrationWindows(s.conf, s.logger), req.Window)
+		if window == nil {
+			return nil, v1.WithDetailf(v1.ErrMigrationWindowNotFound, "window=%s", req.Window)
+		}
+		next, ok := nextMigrationWindowStart(window, scheduledAt)
+		if !ok {
+			return nil, v1.WithDetailf(v1.ErrMigrationWindowNotFound, "window %s never opens", req.Window)
+		}
+		scheduledAt = next
+	}
+
+	vm, err := s.vmRepo.GetByID(ctx, req.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.ErrNotFound
+	}
+	preflight, err := s.vmService.RemoteMigratePreflight(ctx, &req.RemoteMigrateVMRequest)
+	if err != nil {
+		return nil, err
+	}
+	if !preflight.Passed && (req.Force == nil || !*req.Force) {
+		return nil, v1.WithDetail(v1.ErrRemoteMigratePreflightFailed, migrationIssueSummary(preflight.Blockers))
+	}
+
+	request, _ := json.Marshal(req.RemoteMigrateVMRequest)
+	result, _ := json.Marshal(preflight)
+	job := &model.RemoteMigrationJob{
+		ClusterID:       vm.ClusterID,
+		VmId:            vm.Id,
+		VMID:            vm.VMID,
+		VmName:          vm.VmName,
+		TargetClusterID: req.TargetClusterID,
+		TargetNodeID:    req.TargetNodeID,
+		Request:         string(request),
+		Window:          req.Window,
+		ScheduledAt:     scheduledAt,
+		Preflight:       string(result),
+		Status:          model.RemoteMigrationJobScheduled,
+		Creator:         user.Username,
+	}
+	if req.Bwlimit != nil {
+		job.Bwlimit = *req.Bwlimit
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create remote migration job", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("remote migration scheduled", zap.Int64("id", job.Id), zap.Uint32("vmid", job.VMID),
+		zap.String("window", job.Window), zap.Time("scheduled_at", job.ScheduledAt), zap.String("creator", job.Creator))
+	item := toRemoteMigrationJobItem(job)
+	return &item, nil
+}
+
+func (s *remoteMigrationJobService) List(ctx context.Context, req *v1.ListRemoteMigrationJobsRequest) (*v1.ListRemoteMigrationJobsResponseData, error) {
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	jobs, total, err := s.jobRepo.List(ctx, page, pageSize, req.ClusterID, req.VmId, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list remote migration jobs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.RemoteMigrationJobItem, 0, len(jobs))
+	for _, job := range jobs {
+		list = append(list, toRemoteMigrationJobItem(job))
+	}
+	return &v1.ListRemoteMigrationJobsResponseData{Total: total, List: list}, nil
+}
+
+func (s *remoteMigrationJobService) Cancel(ctx context.Context, userID string, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get remote migration job", zap.Error(err), zap.Int64("id", id))
+		return v1.ErrInternalServerError
+	}
+	if job == nil {
+		return v1.WithDetailf(v1.ErrRemoteMigrationJobNotFound, "id=%d", id)
+	}
+	username, err := creatorOrAdmin(ctx, s.conf, s.userRepo, s.logger, userID, job.Creator)
+	if err != nil {
+		return err
+	}
+	if job.Status != model.RemoteMigrationJobScheduled {
+		return v1.WithDetailf(v1.ErrRemoteMigrationJobFinished, "status=%s", job.Status)
+	}
+	job.Status = model.RemoteMigrationJobCancelled
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		s.logger.WithContext(ctx).Error("failed to cancel remote migration job", zap.Error(err), zap.Int64("id", id))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("remote migration job cancelled", zap.Int64("id", id), zap.String("operator", username))
+	return nil
+}
+
+func (s *remoteMigrationJobService) RunDue(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	jobs, err := s.jobRepo.ListDue(ctx, now, remoteMigrationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	windows := loadMigrationWindows(s.conf, s.logger)
+	processed := 0
+	for _, job := range jobs {
+		var window *migrationWindow
+		if job.Window != "" {
+			window = findMigrationWindow(windows, job.Window)
+			if window == nil {
+				s.finish(ctx, job, "", fmt.Errorf("migration window %s no longer exists", job.Window))
+				processed++
+				continue
+			}
+			// 错过窗口（如服务停机）时顺延到下一次窗口开始
+			if !changeWindowActive(window.changeWindow(), now) {
+				s.reschedule(ctx, job, window, now, "")
+				continue
+			}
+		}
+		claimed, err := s.jobRepo.Claim(ctx, job.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to claim remote migration job", zap.Error(err), zap.Int64("id", job.Id))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+		processed++
+		s.run(ctx, job, window, now)
+	}
+	return processed, nil
+}
+
+// run 发起迁移：未指定带宽限制时使用所选窗口的 bwlimit，变更管控或锁阻止时保持计划状态稍后重试
+func (s *remoteMigrationJobService) run(ctx context.Context, job *model.RemoteMigrationJob, window *migrationWindow, now time.Time) {
+	var req v1.RemoteMigrateVMRequest
+	if err := json.Unmarshal([]byte(job.Request), &req); err != nil {
+		s.finish(ctx, job, "", err)
+		return
+	}
+	if req.Bwlimit == nil && window != nil && window.Bwlimit > 0 {
+		bwlimit := window.Bwlimit
+		req.Bwlimit = &bwlimit
+	}
+	if req.Bwlimit != nil {
+		job.Bwlimit = *req.Bwlimit
+	}
+
+	upid, err := s.vmService.RemoteMigrateVM(ctx, &req)
+	if err != nil && (errors.Is(err, v1.ErrVMLocked) || errors.Is(err, v1.ErrChangeFrozen) || errors.Is(err, v1.ErrOutsideMaintenanceWindow)) {
+		job.Status = model.RemoteMigrationJobScheduled
+		s.reschedule(ctx, job, window, now.Add(s.retryInterval()), err.Error())
+		return
+	}
+	s.finish(ctx, job, upid, err)
+}
+
+// reschedule 将计划时间顺延到 after 之后的窗口时刻（无窗口时为 after）
+func (s *remoteMigrationJobService) reschedule(ctx context.Context, job *model.RemoteMigrationJob, window *migrationWindow, after time.Time, reason string) {
+	job.ScheduledAt = after
+	if window != nil {
+		next, ok := nextMigrationWindowStart(window, after)
+		if !ok {
+			s.finish(ctx, job, "", fmt.Errorf("migration window %s never opens", window.Name))
+			return
+		}
+		job.ScheduledAt = next
+	}
+	job.ErrorMessage = reason
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		s.logger.WithContext(ctx).Error("failed to reschedule remote migration job", zap.Error(err), zap.Int64("id", job.Id))
+		return
+	}
+	s.logger.WithContext(ctx).Info("remote migration job rescheduled", zap.Int64("id", job.Id),
+		zap.Time("scheduled_at", job.ScheduledAt), zap.String("reason", reason))
+}
+
+// finish 保存发起结果并通知创建人
+func (s *remoteMigrationJobService) finish(ctx context.Context, job *model.RemoteMigrationJob, upid string, err error) {
+	now := time.Now()
+	job.StartTime = &now
+	subject := fmt.Sprintf("Scheduled migration of VM %s (%d)", job.VmName, job.VMID)
+	var n *model.Notification
+	if err != nil {
+		job.Status = model.RemoteMigrationJobFailed
+		job.ErrorMessage = err.Error()
+		n = taskFinishedNotification("remote_migration", job.Id, subject, err)
+	} else {
+		job.Status = model.RemoteMigrationJobStarted
+		job.UPID = upid
+		job.ErrorMessage = ""
+		n = &model.Notification{
+			Category:   model.NotificationCategoryTask,
+			Level:      model.NotificationLevelInfo,
+			Event:      "remote_migration_started",
+			Title:      subject + " started",
+			Content:    upid,
+			TargetType: "remote_migration",
+			TargetID:   fmt.Sprintf("%d", job.Id),
+		}
+	}
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update remote migration job", zap.Error(err), zap.Int64("id", job.Id))
+	}
+	s.logger.WithContext(ctx).Info("remote migration job finished", zap.Int64("id", job.Id),
+		zap.String("status", job.Status), zap.String("upid", job.UPID), zap.String("error", job.ErrorMessage))
+	if job.Creator != "" {
+		s.notificationService.Notify(ctx, n, job.Creator)
+	}
+}
+
+// creatorOrAdmin 返回当前用户名，当前用户既不是创建人也不是管理员时返回 ErrAdminRequired
+func creatorOrAdmin(ctx context.Context, conf *viper.Viper, userRepo repository.UserRepository, logger *log.Logger,
+	userID, creator string) (string, error) {
+	username, err := requireAdminUser(ctx, conf, userRepo, logger, userID)
+	if err == nil {
+		return username, nil
+	}
+	if !errors.Is(err, v1.ErrAdminRequired) {
+		return "", err
+	}
+	user, err := userRepo.GetByID(ctx, userID)
+	if err != nil {
+		logger.WithContext(ctx).Error("failed to get user", zap.Error(err))
+		return "", v1.ErrInternalServerError
+	}
+	if user == nil || user.Username != creator {
+		return "", v1.ErrAdminRequired
+	}
+	return user.Username, nil
+}
+
+func toRemoteMigrationJobItem(job *model.RemoteMigrationJob) v1.RemoteMigrationJobItem {
+	item := v1.RemoteMigrationJobItem{
+		Id:              job.Id,
+		VmId:            job.VmId,
+		VMID:            job.VMID,
+		VmName:          job.VmName,
+		ClusterID:       job.ClusterID,
+		TargetClusterID: job.TargetClusterID,
+		TargetNodeID:    job.TargetNodeID,
+		Window:          job.Window,
+		ScheduledAt:     job.ScheduledAt,
+		Bwlimit:         job.Bwlimit,
+		Status:          job.Status,
+		UPID:            job.UPID,
+		ErrorMessage:    job.ErrorMessage,
+		StartTime:       job.StartTime,
+		Creator:         job.Creator,
+		CreateTime:      job.CreateTime,
+	}
+	var preflight v1.RemoteMigratePreflightData
+	if json.Unmarshal([]byte(job.Preflight), &preflight) == nil && job.Preflight != "" {
+		item.Preflight = &preflight
+	}
+	return item
+}
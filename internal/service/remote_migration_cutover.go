@@ -0,0 +1,656 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 每轮检查的等待中切换数量
+const remoteMigrationCutoverBatchSize = 20
+
+// 切换步骤结果
+const (
+	cutoverStepOK      = "ok"
+	cutoverStepSkipped = "skipped"
+	cutoverStepFailed  = "failed"
+)
+
+// RemoteMigrationCutoverService 跨集群迁移切换：迁移任务成功后把虚拟机记录改到目标集群和节点，
+// 重新绑定或在目标网段重新分配 IP，需要换地址时更新 cloud-init，并通过 remote_migration.dns_webhook 通知 DNS
+type RemoteMigrationCutoverService interface {
+	// Register 登记已发起的跨集群迁移，迁移任务结束后由 RunPending 执行切换
+	Register(ctx context.Context, cutover *model.RemoteMigrationCutover) error
+	List(ctx context.Context, req *v1.ListRemoteMigrationCutoversRequest) (*v1.ListRemoteMigrationCutoversResponseData, error)
+	Get(ctx context.Context, id int64) (*v1.RemoteMigrationCutoverItem, error)
+	// Retry 重新执行迁移成功但切换失败的步骤，创建人或管理员可操作
+	Retry(ctx context.Context, userID string, id int64) (*v1.RemoteMigrationCutoverItem, error)
+	// RunPending 检查等待中的迁移任务，已结束的执行切换，返回处理数量
+	RunPending(ctx context.Context) (int, error)
+}
+
+func NewRemoteMigrationCutoverService(
+	service *Service,
+	conf *viper.Viper,
+	cutoverRepo repository.RemoteMigrationCutoverRepository,
+	vmRepo repository.PveVMRepository,
+	ipRepo repository.VMIPAddressRepository,
+	macRepo repository.MACAddressRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationService,
+	logger *log.Logger,
+) RemoteMigrationCutoverService {
+	return &remoteMigrationCutoverService{
+		Service:             service,
+		conf:                conf,
+		cutoverRepo:         cutoverRepo,
+		vmRepo:              vmRepo,
+		ipRepo:              ipRepo,
+		macRepo:             macRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		logger:              logger,
+		httpClient:          &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type remoteMigrationCutoverService struct {
+	*Service
+	conf                *viper.Viper
+	cutoverRepo         repository.RemoteMigrationCutoverRepository
+	vmRepo              repository.PveVMRepository
+	ipRepo              repository.VMIPAddressRepository
+	macRepo             repository.MACAddressRepository
+	clusterRepo         repository.PveClusterRepository
+	nodeRepo            repository.PveNodeRepository
+	userRepo            repository.UserRepository
+	notificationService NotificationService
+	logger              *log.Logger
+	httpClient          *http.Client // DNS webhook
+}
+
+func (s *remoteMigrationCutoverService) Register(ctx context.Context, cutover *model.RemoteMigrationCutover) error {
+	if cutover.Creator == "" {
+		if userID := userIDFromCtx(ctx); userID != "" {
+			if user, err := s.userRepo.GetByID(ctx, userID); err == nil && user != nil {
+				cutover.Creator = user.Username
+			}
+		}
+	}
+	cutover.Status = model.RemoteMigrationCutoverWaiting
+	if err := s.cutoverRepo.Create(ctx, cutover); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create remote migration cutover", zap.Error(err), zap.Int64("vm_id", cutover.VmId))
+		return err
+	}
+	return nil
+}
+
+func (s *remoteMigrationCutoverService) List(ctx context.Context, req *v1.ListRemoteMigrationCutoversRequest) (*v1.ListRemoteMigrationCutoversResponseData, error) {
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	cutovers, total, err := s.cutoverRepo.List(ctx, page, pageSize, req.VmId, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list remote migration cutovers", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.RemoteMigrationCutoverItem, 0, len(cutovers))
+	for _, cutover := range cutovers {
+		list = append(list, toRemoteMigrationCutoverItem(cutover))
+	}
+	return &v1.ListRemoteMigrationCutoversResponseData{Total: total, List: list}, nil
+}
+
+func (s *remoteMigrationCutoverService) Get(ctx context.Context, id int64) (*v1.RemoteMigrationCutoverItem, error) {
+	cutover, err := s.getCutover(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item := toRemoteMigrationCutoverItem(cutover)
+	return &item, nil
+}
+
+func (s *remoteMigrationCutoverService) getCutover(ctx context.Context, id int64) (*model.RemoteMigrationCutover, error) {
+	cutover, err := s.cutoverRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get remote migration cutover", zap.Error(err), zap.Int64("id", id))
+		return nil, v1.ErrInternalServerError
+	}
+	if cutover == nil {
+		return nil, v1.WithDetailf(v1.ErrRemoteMigrationCutoverNotFound, "id=%d", id)
+	}
+	return cutover, nil
+}
+
+func (s *remoteMigrationCutoverService) Retry(ctx context.Context, userID string, id int64) (*v1.RemoteMigrationCutoverItem, error) {
+	cutover, err := s.getCutover(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	username, err := creatorOrAdmin(ctx, s.conf, s.userRepo, s.logger, userID, cutover.Creator)
+	if err != nil {
+		return nil, err
+	}
+	steps := decodeCutoverSteps(cutover.Steps)
+	if cutover.Status != model.RemoteMigrationCutoverFailed || len(steps) == 0 || steps[0].Status != cutoverStepOK {
+		return nil, v1.WithDetailf(v1.ErrRemoteMigrationCutoverNotRetryable, "status=%s", cutover.Status)
+	}
+
+	s.logger.WithContext(ctx).Info("retrying remote migration cutover", zap.Int64("id", id), zap.String("operator", username))
+	s.cutover(ctx, cutover)
+	item := toRemoteMigrationCutoverItem(cutover)
+	return &item, nil
+}
+
+func (s *remoteMigrationCutoverService) RunPending(ctx context.Context) (int, error) {
+	cutovers, err := s.cutoverRepo.ListWaiting(ctx, remoteMigrationCutoverBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	processed := 0
+	for _, cutover := range cutovers {
+		client, err := s.clusterClient(ctx, cutover.SourceClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to create source cluster client for cutover", zap.Error(err), zap.Int64("id", cutover.Id))
+			continue
+		}
+		status, err := client.GetTaskStatus(ctx, cutover.SourceNode, cutover.UPID)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get remote migration task status", zap.Error(err),
+				zap.Int64("id", cutover.Id), zap.String("upid", cutover.UPID))
+			continue
+		}
+		if state, _ := status["status"].(string); state != "stopped" {
+			continue
+		}
+		processed++
+		if exitStatus, _ := status["exitstatus"].(string); exitStatus != "OK" {
+			err := fmt.Errorf("migration task %s failed: %s", cutover.UPID, exitStatus)
+			s.finish(ctx, cutover, []v1.RemoteMigrationCutoverStep{{Step: "migration", Status: cutoverStepFailed, Message: err.Error()}}, err)
+			continue
+		}
+		s.cutover(ctx, cutover)
+	}
+	return processed, nil
+}
+
+func (s *remoteMigrationCutoverService) clusterClient(ctx context.Context, clusterID int64) (*proxmox.ProxmoxClient, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster %d not found", clusterID)
+	}
+	return proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+}
+
+// cutover 依次执行切换步骤：虚拟机记录失败时不再继续，其余步骤失败时记录后继续，最终状态为 failed 可重试
+func (s *remoteMigrationCutoverService) cutover(ctx context.Context, cutover *model.RemoteMigrationCutover) {
+	steps := []v1.RemoteMigrationCutoverStep{{Step: "migration", Status: cutoverStepOK, Message: cutover.UPID}}
+	var failed []string
+	record := func(step string, message string, err error) {
+		if err != nil {
+			steps = append(steps, v1.RemoteMigrationCutoverStep{Step: step, Status: cutoverStepFailed, Message: err.Error()})
+			failed = append(failed, step)
+			return
+		}
+		status := cutoverStepOK
+		if strings.HasPrefix(message, "skipped: ") {
+			status, message = cutoverStepSkipped, strings.TrimPrefix(message, "skipped: ")
+		}
+		steps = append(steps, v1.RemoteMigrationCutoverStep{Step: step, Status: status, Message: message})
+	}
+
+	vm, targetCluster, err := s.moveInventory(ctx, cutover)
+	if err != nil {
+		record("inventory", "", err)
+		s.finish(ctx, cutover, steps, err)
+		return
+	}
+	record("inventory", fmt.Sprintf("vm moved to cluster %s node %s as %d", targetCluster.ClusterName, cutover.TargetNode, cutover.TargetVMID), nil)
+
+	targetClient, err := proxmox.NewProxmoxClient(targetCluster.ApiUrl, targetCluster.UserId, targetCluster.UserToken, proxmox.WithRequestLog(targetCluster.ApiLogEnabled == 1))
+	if err != nil {
+		record("cloudinit", "", err)
+	}
+
+	addresses, message, ipErr := s.rebindAddresses(ctx, cutover, vm)
+	record("ip", message, ipErr)
+	if ipErr == nil && targetClient != nil {
+		message, err := s.updateCloudInit(ctx, targetClient, cutover, addresses)
+		record("cloudinit", message, err)
+	}
+
+	message, err = s.rebindMACs(ctx, cutover, vm)
+	record("mac", message, err)
+
+	if ipErr == nil {
+		message, err = s.notifyDNS(ctx, cutover, vm, targetCluster, addresses)
+		record("dns", message, err)
+	}
+
+	if len(failed) > 0 {
+		s.finish(ctx, cutover, steps, fmt.Errorf("cutover steps failed: %s", strings.Join(failed, ", ")))
+		return
+	}
+	s.finish(ctx, cutover, steps, nil)
+}
+
+// moveInventory 将虚拟机记录改到目标集群、节点和 VMID；目标集群同步时已发现该虚拟机的重复记录会被删除，保留原记录的元数据
+func (s *remoteMigrationCutoverService) moveInventory(ctx context.Context, cutover *model.RemoteMigrationCutover) (*model.PveVM, *model.PveCluster, error) {
+	vm, err := s.vmRepo.GetByID(ctx, cutover.VmId)
+	if err != nil {
+		return nil, nil, err
+	}
+	if vm == nil {
+		return nil, nil, fmt.Errorf("vm %d no longer exists", cutover.VmId)
+	}
+	targetCluster, err := s.clusterRepo.GetByID(ctx, cutover.TargetClusterID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if targetCluster == nil {
+		return nil, nil, fmt.Errorf("target cluster %d not found", cutover.TargetClusterID)
+	}
+	targetNode, err := s.nodeRepo.GetByID(ctx, cutover.TargetNodeID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if targetNode == nil {
+		return nil, nil, fmt.Errorf("target node %d not found", cutover.TargetNodeID)
+	}
+
+	client, err := proxmox.NewProxmoxClient(targetCluster.ApiUrl, targetCluster.UserId, targetCluster.UserToken, proxmox.WithRequestLog(targetCluster.ApiLogEnabled == 1))
+	if err != nil {
+		return nil, nil, err
+	}
+	current, err := client.GetVMStatus(ctx, targetNode.NodeName, cutover.TargetVMID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("vm %d not found on target node %s: %w", cutover.TargetVMID, targetNode.NodeName, err)
+	}
+
+	discovered, err := s.vmRepo.GetByVMID(ctx, cutover.TargetVMID, targetNode.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if discovered != nil && discovered.Id != vm.Id {
+		if err := s.vmRepo.Delete(ctx, discovered.Id); err != nil {
+			return nil, nil, err
+		}
+		s.logger.WithContext(ctx).Info("removed vm record discovered on target cluster before cutover",
+			zap.Int64("vm_id", discovered.Id), zap.Uint32("vmid", cutover.TargetVMID))
+	}
+
+	vm.ClusterID = targetCluster.Id
+	vm.NodeID = targetNode.Id
+	vm.NodeIP = targetNode.IPAddress
+	vm.VMID = cutover.TargetVMID
+	if status, _ := current["status"].(string); isStableVMStatus(status) {
+		vm.Status = status
+	}
+	vm.UpdateTime = time.Now()
+	if err := s.vmRepo.Update(ctx, vm); err != nil {
+		return nil, nil, err
+	}
+	return vm, targetCluster, nil
+}
+
+// rebindAddresses 不换地址的网卡把 IP 登记改到目标集群；换地址的网卡在目标网段分配空闲地址并释放原地址，
+// 已分配过的网卡（重试时）沿用之前的地址
+func (s *remoteMigrationCutoverService) rebindAddresses(ctx context.Context, cutover *model.RemoteMigrationCutover, vm *model.PveVM) ([]v1.RemoteMigrationCutoverAddress, string, error) {
+	var reIP []v1.RemoteMigrateReIP
+	if cutover.ReIP != "" {
+		if err := json.Unmarshal([]byte(cutover.ReIP), &reIP); err != nil {
+			return nil, "", err
+		}
+	}
+	var addresses []v1.RemoteMigrationCutoverAddress
+	if cutover.Addresses != "" {
+		_ = json.Unmarshal([]byte(cutover.Addresses), &addresses)
+	}
+	allocated := make(map[string]bool, len(addresses))
+	for _, addr := range addresses {
+		allocated[addr.NicName] = true
+	}
+	changing := make(map[string]bool, len(reIP))
+	for _, item := range reIP {
+		changing[item.NicName] = true
+	}
+
+	current, err := s.ipRepo.GetByVMID(ctx, vm.Id)
+	if err != nil {
+		return nil, "", err
+	}
+	rebound := 0
+	for _, ip := range current {
+		if changing[ip.NicName] || ip.ClusterID == cutover.TargetClusterID {
+			continue
+		}
+		ip.ClusterID = cutover.TargetClusterID
+		if err := s.ipRepo.Update(ctx, ip); err != nil {
+			return nil, "", err
+		}
+		rebound++
+	}
+
+	for _, item := range reIP {
+		if allocated[item.NicName] {
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(item.Subnet)
+		if err != nil {
+			return nil, "", err
+		}
+		addr, err := s.allocateAddress(ctx, cutover, vm, item, subnet)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", item.NicName, err)
+		}
+
+		old := ""
+		for _, ip := range current {
+			if ip.NicName != item.NicName || ip.Id == addr.Id {
+				continue
+			}
+			old = ip.IPAddress
+			addr.MacAddress = ip.MacAddress
+			// 原地址留在源集群的地址池中
+			ip.VMId, ip.NicName, ip.MacAddress = 0, "", ""
+			if err := s.ipRepo.Update(ctx, ip); err != nil {
+				return nil, "", err
+			}
+		}
+		addr.VMId = vm.Id
+		addr.NicName = item.NicName
+		addr.ClusterID = cutover.TargetClusterID
+		if err := s.ipRepo.Update(ctx, addr); err != nil {
+			return nil, "", err
+		}
+		addresses = append(addresses, v1.RemoteMigrationCutoverAddress{
+			NicName: item.NicName, OldAddress: old, NewAddress: addr.IPAddress, IPAddressID: addr.Id,
+		})
+		// 每分配一个地址就保存，后续步骤失败重试时不重复分配
+		raw, _ := json.Marshal(addresses)
+		cutover.Addresses = string(raw)
+		if err := s.cutoverRepo.Update(ctx, cutover); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if len(reIP) == 0 {
+		return addresses, fmt.Sprintf("%d address(es) rebound to target cluster", rebound), nil
+	}
+	parts := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		parts = append(parts, addr.NicName+"="+addr.NewAddress)
+	}
+	return addresses, fmt.Sprintf("%d address(es) rebound, re-addressed %s", rebound, strings.Join(parts, ", ")), nil
+}
+
+// allocateAddress 使用指定的地址记录，或目标集群地址池中网段内第一个空闲地址；地址池中没有时登记网段内第一个未使用的地址（仅 IPv4）
+func (s *remoteMigrationCutoverService) allocateAddress(ctx context.Context, cutover *model.RemoteMigrationCutover, vm *model.PveVM,
+	item v1.RemoteMigrateReIP, subnet *net.IPNet) (*model.VMIPAddress, error) {
+	if item.IPAddressID != nil {
+		addr, err := s.ipRepo.GetByID(ctx, *item.IPAddressID)
+		if err != nil {
+			return nil, err
+		}
+		if addr == nil {
+			return nil, fmt.Errorf("ip address %d not found", *item.IPAddressID)
+		}
+		if addr.ClusterID != cutover.TargetClusterID || (addr.VMId != 0 && addr.VMId != vm.Id) {
+			return nil, fmt.Errorf("ip address %s is not free in the target cluster", addr.IPAddress)
+		}
+		if ip := parseAddress(addr.IPAddress); ip == nil || !subnet.Contains(ip) {
+			return nil, fmt.Errorf("ip address %s is not in %s", addr.IPAddress, subnet)
+		}
+		return addr, nil
+	}
+
+	pool, err := s.ipRepo.List(ctx, cutover.TargetClusterID)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool, len(pool))
+	for _, addr := range pool {
+		ip := parseAddress(addr.IPAddress)
+		if ip == nil || !subnet.Contains(ip) {
+			continue
+		}
+		if addr.VMId == 0 && ip.String() != item.Gateway {
+			return addr, nil
+		}
+		used[ip.String()] = true
+	}
+	if item.Gateway != "" {
+		used[item.Gateway] = true
+	}
+
+	ip := firstFreeAddress(subnet, used)
+	if ip == nil {
+		return nil, fmt.Errorf("no free address in %s", subnet)
+	}
+	addr := &model.VMIPAddress{
+		IPAddress: ip.String(),
+		ClusterID: cutover.TargetClusterID,
+		Creator:   cutover.Creator,
+	}
+	if err := s.ipRepo.Create(ctx, addr); err != nil {
+		return nil, err
+	}
+	return addr, nil
+}
+
+// firstFreeAddress 返回 IPv4 网段内第一个未使用的主机地址（跳过网络地址和广播地址）
+func firstFreeAddress(subnet *net.IPNet, used map[string]bool) net.IP {
+	base := subnet.IP.To4()
+	if base == nil {
+		return nil
+	}
+	ones, bits := subnet.Mask.Size()
+	size := uint32(1) << uint(bits-ones)
+	start := binary.BigEndian.Uint32(base)
+	first, last := uint32(1), size-1
+	if size <= 2 {
+		first, last = 0, size
+	}
+	for i := first; i < last; i++ {
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, start+i)
+		if !used[ip.String()] {
+			return ip
+		}
+	}
+	return nil
+}
+
+// updateCloudInit 为换地址的网卡写入 ipconfigN，虚拟机下次启动时由 cloud-init 应用
+func (s *remoteMigrationCutoverService) updateCloudInit(ctx context.Context, client *proxmox.ProxmoxClient,
+	cutover *model.RemoteMigrationCutover, addresses []v1.RemoteMigrationCutoverAddress) (string, error) {
+	if len(addresses) == 0 {
+		return "skipped: no re-addressed nic", nil
+	}
+	var reIP []v1.RemoteMigrateReIP
+	_ = json.Unmarshal([]byte(cutover.ReIP), &reIP)
+	gateways := make(map[string]string, len(reIP))
+	prefixes := make(map[string]int, len(reIP))
+	for _, item := range reIP {
+		if _, subnet, err := net.ParseCIDR(item.Subnet); err == nil {
+			prefixes[item.NicName], _ = subnet.Mask.Size()
+		}
+		gateways[item.NicName] = item.Gateway
+	}
+
+	config := make(map[string]interface{}, len(addresses))
+	for _, addr := range addresses {
+		index, err := strconv.Atoi(strings.TrimPrefix(addr.NicName, "net"))
+		if err != nil || !strings.HasPrefix(addr.NicName, "net") {
+			return "", fmt.Errorf("invalid nic name %s", addr.NicName)
+		}
+		value := fmt.Sprintf("ip=%s/%d", parseAddress(addr.NewAddress), prefixes[addr.NicName])
+		if gw := gateways[addr.NicName]; gw != "" {
+			value += ",gw=" + gw
+		}
+		config[fmt.Sprintf("ipconfig%d", index)] = value
+	}
+	if err := client.UpdateVMConfig(ctx, cutover.TargetNode, cutover.TargetVMID, config); err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(config))
+	for key, value := range config {
+		keys = append(keys, fmt.Sprintf("%s=%v", key, value))
+	}
+	return strings.Join(keys, "; ") + " (applied on next boot)", nil
+}
+
+// rebindMACs 将虚拟机网卡的 MAC 登记改到目标集群和目标 VMID
+func (s *remoteMigrationCutoverService) rebindMACs(ctx context.Context, cutover *model.RemoteMigrationCutover, vm *model.PveVM) (string, error) {
+	macs, _, err := s.macRepo.List(ctx, 1, 1000, 0, vm.Id, "", "")
+	if err != nil {
+		return "", err
+	}
+	for _, mac := range macs {
+		mac.ClusterID = cutover.TargetClusterID
+		mac.VMID = cutover.TargetVMID
+		if err := s.macRepo.Update(ctx, mac); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%d mac address(es) rebound", len(macs)), nil
+}
+
+// notifyDNS 配置了 remote_migration.dns_webhook 时以 JSON POST 通知 DNS 更新记录
+func (s *remoteMigrationCutoverService) notifyDNS(ctx context.Context, cutover *model.RemoteMigrationCutover, vm *model.PveVM,
+	targetCluster *model.PveCluster, addresses []v1.RemoteMigrationCutoverAddress) (string, error) {
+	if cutover.UpdateDNS == 0 {
+		return "skipped: disabled by request", nil
+	}
+	webhook := s.conf.GetString("remote_migration.dns_webhook")
+	if webhook == "" {
+		return "skipped: remote_migration.dns_webhook not configured", nil
+	}
+
+	ips, err := s.ipRepo.GetByVMID(ctx, vm.Id)
+	if err != nil {
+		return "", err
+	}
+	current := make([]map[string]string, 0, len(ips))
+	for _, ip := range ips {
+		current = append(current, map[string]string{"nic_name": ip.NicName, "ip_address": ip.IPAddress})
+	}
+	sourceCluster := ""
+	if cluster, err := s.clusterRepo.GetByID(ctx, cutover.SourceClusterID); err == nil && cluster != nil {
+		sourceCluster = cluster.ClusterName
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event":          "vm_remote_migrated",
+		"vm_id":          vm.Id,
+		"vm_name":        vm.VmName,
+		"vmid":           vm.VMID,
+		"source_cluster": sourceCluster,
+		"target_cluster": targetCluster.ClusterName,
+		"target_node":    cutover.TargetNode,
+		"addresses":      current,
+		"changes":        addresses,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("dns webhook returned status %d", resp.StatusCode)
+	}
+	return fmt.Sprintf("dns webhook notified (%d)", resp.StatusCode), nil
+}
+
+// finish 保存切换结果并通知创建人
+func (s *remoteMigrationCutoverService) finish(ctx context.Context, cutover *model.RemoteMigrationCutover, steps []v1.RemoteMigrationCutoverStep, err error) {
+	now := time.Now()
+	cutover.EndTime = &now
+	raw, _ := json.Marshal(steps)
+	cutover.Steps = string(raw)
+	if err != nil {
+		cutover.Status = model.RemoteMigrationCutoverFailed
+		cutover.ErrorMessage = err.Error()
+	} else {
+		cutover.Status = model.RemoteMigrationCutoverCompleted
+		cutover.ErrorMessage = ""
+	}
+	if err := s.cutoverRepo.Update(ctx, cutover); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update remote migration cutover", zap.Error(err), zap.Int64("id", cutover.Id))
+	}
+	s.logger.WithContext(ctx).Info("remote migration cutover finished", zap.Int64("id", cutover.Id), zap.Int64("vm_id", cutover.VmId),
+		zap.String("status", cutover.Status), zap.String("error", cutover.ErrorMessage))
+	if cutover.Creator != "" {
+		s.notificationService.Notify(ctx, taskFinishedNotification("remote_migration_cutover", cutover.Id,
+			fmt.Sprintf("Cut-over of VM %s to cluster %d", cutover.VmName, cutover.TargetClusterID), err), cutover.Creator)
+	}
+}
+
+func decodeCutoverSteps(raw string) []v1.RemoteMigrationCutoverStep {
+	steps := []v1.RemoteMigrationCutoverStep{}
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &steps)
+	}
+	return steps
+}
+
+func toRemoteMigrationCutoverItem(cutover *model.RemoteMigrationCutover) v1.RemoteMigrationCutoverItem {
+	item := v1.RemoteMigrationCutoverItem{
+		Id:              cutover.Id,
+		VmId:            cutover.VmId,
+		VmName:          cutover.VmName,
+		SourceClusterID: cutover.SourceClusterID,
+		SourceNode:      cutover.SourceNode,
+		SourceVMID:      cutover.SourceVMID,
+		TargetClusterID: cutover.TargetClusterID,
+		TargetNodeID:    cutover.TargetNodeID,
+		TargetNode:      cutover.TargetNode,
+		TargetVMID:      cutover.TargetVMID,
+		UPID:            cutover.UPID,
+		Status:          cutover.Status,
+		Steps:           decodeCutoverSteps(cutover.Steps),
+		Addresses:       []v1.RemoteMigrationCutoverAddress{},
+		ErrorMessage:    cutover.ErrorMessage,
+		EndTime:         cutover.EndTime,
+		Creator:         cutover.Creator,
+		CreateTime:      cutover.CreateTime,
+	}
+	if cutover.Addresses != "" {
+		_ = json.Unmarshal([]byte(cutover.Addresses), &item.Addresses)
+	}
+	return item
+}
@@ -0,0 +1,921 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/mail"
+	"pvesphere/pkg/proxmox"
+	"pvesphere/pkg/xlsx"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 report.retention_days 时的默认发送记录保留天数
+const defaultReportRetentionDays = 365
+
+// reportTrendRuns 合规得分趋势包含的历史发送次数（不含本次）
+const reportTrendRuns = 8
+
+// reportSectionOrder 报告内容的固定顺序
+var reportSectionOrder = []string{model.ReportSectionCapacity, model.ReportSectionBackup, model.ReportSectionCompliance}
+
+// ReportService 定期报告：按计划汇总容量、备份成功率和合规得分趋势，
+// 以站内通知发送给接收人，配置 report.smtp 后同时发送带 XLSX 附件的邮件
+type ReportService interface {
+	CreateSchedule(ctx context.Context, userID string, req *v1.CreateReportScheduleRequest) (*v1.ReportScheduleItem, error)
+	UpdateSchedule(ctx context.Context, userID string, id int64, req *v1.UpdateReportScheduleRequest) (*v1.ReportScheduleItem, error)
+	DeleteSchedule(ctx context.Context, userID string, id int64) error
+	GetSchedule(ctx context.Context, id int64) (*v1.ReportScheduleItem, error)
+	ListSchedules(ctx context.Context, req *v1.ListReportSchedulesRequest) (*v1.ListReportSchedulesResponseData, error)
+	// SendNow 立即生成并发送一次报告（统计截至当前的一个周期），不影响下次定时发送时间
+	SendNow(ctx context.Context, userID string, id int64) (*v1.ReportRunItem, error)
+	ListRuns(ctx context.Context, scheduleID int64, req *v1.ListReportRunsRequest) (*v1.ListReportRunsResponseData, error)
+	// GetRunAttachment 返回发送记录的 XLSX 附件，只有管理员和该计划的接收人可以下载
+	GetRunAttachment(ctx context.Context, userID string, runID int64) (string, []byte, error)
+	// RunDue 发送所有到期的报告，返回发送数量
+	RunDue(ctx context.Context) (int, error)
+	// Cleanup 删除超过保留期的发送记录，返回删除数量
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+func NewReportService(
+	service *Service,
+	conf *viper.Viper,
+	reportRepo repository.ReportRepository,
+	clusterRepo repository.PveClusterRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	securityRepo repository.VMSecurityRepository,
+	changeService DashboardChangeService,
+	osEOLService OSEOLService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) ReportService {
+	return &reportService{
+		Service:             service,
+		conf:                conf,
+		reportRepo:          reportRepo,
+		clusterRepo:         clusterRepo,
+		vmRepo:              vmRepo,
+		userRepo:            userRepo,
+		securityRepo:        securityRepo,
+		changeService:       changeService,
+		osEOLService:        osEOLService,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+type reportService struct {
+	*Service
+	conf                *viper.Viper
+	reportRepo          repository.ReportRepository
+	clusterRepo         repository.PveClusterRepository
+	vmRepo              repository.PveVMRepository
+	userRepo            repository.UserRepository
+	securityRepo        repository.VMSecurityRepository
+	changeService       DashboardChangeService
+	osEOLService        OSEOLService
+	notificationService NotificationService
+	logger              *log.Logger
+}
+
+func (s *reportService) CreateSchedule(ctx context.Context, userID string, req *v1.CreateReportScheduleRequest) (*v1.ReportScheduleItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "report.manage")
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &model.ReportSchedule{IsEnabled: 1, Creator: username}
+	if err := s.applyScheduleRequest(ctx, schedule, req); err != nil {
+		return nil, err
+	}
+	schedule.Modifier = username
+
+	if err := s.reportRepo.CreateSchedule(ctx, schedule); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create report schedule", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("report schedule created",
+		zap.Int64("id", schedule.Id), zap.String("name", schedule.Name), zap.String("operator", username))
+	item := toReportScheduleItem(schedule)
+	return &item, nil
+}
+
+func (s *reportService) UpdateSchedule(ctx context.Context, userID string, id int64, req *v1.UpdateReportScheduleRequest) (*v1.ReportScheduleItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "report.manage")
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.getSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.applyScheduleRequest(ctx, schedule, req); err != nil {
+		return nil, err
+	}
+	schedule.Modifier = username
+
+	if err := s.reportRepo.UpdateSchedule(ctx, schedule); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update report schedule", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("report schedule updated",
+		zap.Int64("id", schedule.Id), zap.String("name", schedule.Name), zap.String("operator", username))
+	item := toReportScheduleItem(schedule)
+	return &item, nil
+}
+
+func (s *reportService) DeleteSchedule(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "report.manage")
+	if err != nil {
+		return err
+	}
+	schedule, err := s.getSchedule(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.reportRepo.DeleteSchedule(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete report schedule", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("report schedule deleted",
+		zap.Int64("id", schedule.Id), zap.String("name", schedule.Name), zap.String("operator", username))
+	return nil
+}
+
+func (s *reportService) getSchedule(ctx context.Context, id int64) (*model.ReportSchedule, error) {
+	schedule, err := s.reportRepo.GetSchedule(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get report schedule", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if schedule == nil {
+		return nil, v1.ErrReportScheduleNotFound
+	}
+	return schedule, nil
+}
+
+// applyScheduleRequest 校验请求并写入计划字段，重新计算下次发送时间
+func (s *reportService) applyScheduleRequest(ctx context.Context, schedule *model.ReportSchedule, req *v1.CreateReportScheduleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return v1.WithDetail(v1.ErrReportScheduleInvalid, "name is required")
+	}
+	existing, err := s.reportRepo.GetScheduleByName(ctx, name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get report schedule", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if existing != nil && existing.Id != schedule.Id {
+		return v1.WithDetailf(v1.ErrReportScheduleExists, "name=%s", name)
+	}
+	if req.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+		}
+	}
+	if _, err := parseClockMinutes(req.SendTime); err != nil {
+		return v1.WithDetailf(v1.ErrReportScheduleInvalid, "send_time: %v", err)
+	}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return v1.WithDetailf(v1.ErrReportScheduleInvalid, "unknown timezone %q", req.Timezone)
+		}
+	}
+
+	recipients := uniqueReportList(req.Recipients)
+	for _, username := range recipients {
+		user, err := s.userRepo.GetByUsername(ctx, username)
+		if err != nil && !errors.Is(err, v1.ErrNotFound) {
+			s.logger.WithContext(ctx).Error("failed to get user", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if user == nil {
+			return v1.WithDetailf(v1.ErrReportScheduleInvalid, "user %q not found", username)
+		}
+	}
+	emails := uniqueReportList(req.Emails)
+	if len(recipients) == 0 && len(emails) == 0 {
+		return v1.WithDetail(v1.ErrReportScheduleInvalid, "recipients or emails is required")
+	}
+
+	schedule.Name = name
+	schedule.ClusterID = req.ClusterID
+	schedule.Sections = strings.Join(normalizeReportSections(req.Sections), ",")
+	schedule.Frequency = req.Frequency
+	schedule.Weekday, schedule.MonthDay = int(time.Monday), 1
+	if req.Weekday != nil {
+		schedule.Weekday = *req.Weekday
+	}
+	if req.MonthDay != nil {
+		schedule.MonthDay = *req.MonthDay
+	}
+	schedule.SendTime = req.SendTime
+	schedule.Timezone = req.Timezone
+	schedule.Recipients = strings.Join(recipients, ",")
+	schedule.Emails = strings.Join(emails, ",")
+	if req.IsEnabled != nil {
+		schedule.IsEnabled = *req.IsEnabled
+	}
+
+	schedule.NextRunTime = nil
+	if schedule.IsEnabled == 1 {
+		next := nextReportRunTime(schedule, time.Now())
+		schedule.NextRunTime = &next
+	}
+	return nil
+}
+
+func (s *reportService) GetSchedule(ctx context.Context, id int64) (*v1.ReportScheduleItem, error) {
+	schedule, err := s.getSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item := toReportScheduleItem(schedule)
+	return &item, nil
+}
+
+func (s *reportService) ListSchedules(ctx context.Context, req *v1.ListReportSchedulesRequest) (*v1.ListReportSchedulesResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	schedules, total, err := s.reportRepo.ListSchedules(ctx, page, pageSize)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list report schedules", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.ReportScheduleItem, 0, len(schedules))
+	for _, schedule := range schedules {
+		list = append(list, toReportScheduleItem(schedule))
+	}
+	return &v1.ListReportSchedulesResponseData{Total: total, List: list}, nil
+}
+
+func (s *reportService) SendNow(ctx context.Context, userID string, id int64) (*v1.ReportRunItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "report.manage")
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.getSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.send(ctx, schedule, time.Now(), username)
+	if err != nil {
+		return nil, err
+	}
+	item := toReportRunItem(run)
+	return &item, nil
+}
+
+func (s *reportService) ListRuns(ctx context.Context, scheduleID int64, req *v1.ListReportRunsRequest) (*v1.ListReportRunsResponseData, error) {
+	if _, err := s.getSchedule(ctx, scheduleID); err != nil {
+		return nil, err
+	}
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	runs, total, err := s.reportRepo.ListRuns(ctx, scheduleID, page, pageSize)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list report runs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.ReportRunItem, 0, len(runs))
+	for _, run := range runs {
+		list = append(list, toReportRunItem(run))
+	}
+	return &v1.ListReportRunsResponseData{Total: total, List: list}, nil
+}
+
+func (s *reportService) GetRunAttachment(ctx context.Context, userID string, runID int64) (string, []byte, error) {
+	_, adminErr := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "report.manage")
+	if adminErr != nil && !errors.Is(adminErr, v1.ErrAdminRequired) {
+		return "", nil, adminErr
+	}
+
+	run, err := s.reportRepo.GetRun(ctx, runID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get report run", zap.Error(err))
+		return "", nil, v1.ErrInternalServerError
+	}
+	if run == nil || len(run.Attachment) == 0 {
+		return "", nil, v1.ErrReportRunNotFound
+	}
+	schedule, err := s.getSchedule(ctx, run.ScheduleID)
+	if err != nil {
+		return "", nil, err
+	}
+	if adminErr != nil {
+		// 非管理员只能下载自己收到的报告
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil || user == nil || !slices.Contains(splitReportList(schedule.Recipients), user.Username) {
+			return "", nil, v1.WithDetail(v1.ErrAdminRequired, "only administrators and report recipients can download the report")
+		}
+	}
+	return reportFilename(schedule, run.PeriodEnd), run.Attachment, nil
+}
+
+func (s *reportService) RunDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	schedules, err := s.reportRepo.ListDue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, schedule := range schedules {
+		scheduled := *schedule.NextRunTime
+		// 错过的发送（如服务停机）只补发一次，下次发送时间从当前时间算起
+		next := nextReportRunTime(schedule, now)
+		ok, err := s.reportRepo.ClaimDue(ctx, schedule.Id, scheduled, next)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to claim report schedule", zap.Int64("id", schedule.Id), zap.Error(err))
+			continue
+		}
+		if !ok {
+			// 已由其他实例发送或计划已修改
+			continue
+		}
+		if _, err := s.send(ctx, schedule, scheduled, ""); err != nil {
+			s.logger.WithContext(ctx).Error("failed to send report",
+				zap.Int64("id", schedule.Id), zap.String("name", schedule.Name), zap.Error(err))
+			continue
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *reportService) Cleanup(ctx context.Context) (int64, error) {
+	days := defaultReportRetentionDays
+	if s.conf.IsSet("report.retention_days") {
+		days = s.conf.GetInt("report.retention_days")
+	}
+	if days <= 0 {
+		return 0, nil
+	}
+	return s.reportRepo.CleanupRuns(ctx, time.Now().AddDate(0, 0, -days))
+}
+
+// reportContent 一次报告的内容
+type reportContent struct {
+	workbook          xlsx.Workbook
+	summary           []string
+	complianceScore   float64
+	backupSuccessRate float64
+	errors            []string
+}
+
+// send 生成截至 end 的一个周期的报告，投递后保存发送记录
+func (s *reportService) send(ctx context.Context, schedule *model.ReportSchedule, end time.Time, operator string) (*model.ReportRun, error) {
+	start := reportPeriodStart(schedule, end)
+	content := s.generate(ctx, schedule, start, end)
+
+	run := &model.ReportRun{
+		ScheduleID:        schedule.Id,
+		PeriodStart:       start,
+		PeriodEnd:         end,
+		Status:            model.ReportRunStatusSent,
+		ComplianceScore:   content.complianceScore,
+		BackupSuccessRate: content.backupSuccessRate,
+		Summary:           strings.Join(content.summary, "\n"),
+		Operator:          operator,
+	}
+	var buf bytes.Buffer
+	if err := content.workbook.Write(&buf); err != nil {
+		content.errors = append(content.errors, fmt.Sprintf("xlsx: %v", err))
+	} else {
+		run.Attachment = buf.Bytes()
+	}
+	if len(content.summary) == 0 {
+		run.Status = model.ReportRunStatusFailed
+	} else if len(content.errors) > 0 {
+		run.Status = model.ReportRunStatusPartial
+	}
+
+	if run.Status != model.ReportRunStatusFailed {
+		emailed, err := s.email(ctx, schedule, run)
+		if err != nil {
+			content.errors = append(content.errors, err.Error())
+			run.Status = model.ReportRunStatusPartial
+		}
+		run.Emailed = emailed
+		run.Notified = len(splitReportList(schedule.Recipients))
+	}
+	run.ErrorMessage = truncateReportError(strings.Join(content.errors, "; "))
+
+	if err := s.reportRepo.CreateRun(ctx, run); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create report run", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.notify(ctx, schedule, run)
+	s.logger.WithContext(ctx).Info("report sent",
+		zap.Int64("schedule_id", schedule.Id), zap.String("name", schedule.Name), zap.String("status", run.Status),
+		zap.Int("notified", run.Notified), zap.Int("emailed", run.Emailed), zap.String("operator", operator))
+	return run, nil
+}
+
+// notify 向接收人发送站内通知；生成失败时通知管理员
+func (s *reportService) notify(ctx context.Context, schedule *model.ReportSchedule, run *model.ReportRun) {
+	period := fmt.Sprintf("%s - %s", run.PeriodStart.Format("2006-01-02"), run.PeriodEnd.Format("2006-01-02"))
+	n := &model.Notification{
+		Category:   model.NotificationCategoryReport,
+		Level:      model.NotificationLevelInfo,
+		Event:      "report_" + run.Status,
+		Title:      fmt.Sprintf("Report %s (%s)", schedule.Name, period),
+		Content:    run.Summary,
+		TargetType: "report_run",
+		TargetID:   strconv.FormatInt(run.Id, 10),
+	}
+	switch run.Status {
+	case model.ReportRunStatusFailed:
+		n.Level = model.NotificationLevelWarning
+		n.Title = fmt.Sprintf("Report %s (%s) failed", schedule.Name, period)
+		n.Content = run.ErrorMessage
+		s.notificationService.NotifyAdmins(ctx, n)
+		return
+	case model.ReportRunStatusPartial:
+		n.Level = model.NotificationLevelWarning
+		n.Content += "\n\nErrors: " + run.ErrorMessage
+	}
+	s.notificationService.Notify(ctx, n, splitReportList(schedule.Recipients)...)
+}
+
+// email 未配置 report.smtp.host 时不发送邮件；收件人为接收人的邮箱和额外的邮件地址
+func (s *reportService) email(ctx context.Context, schedule *model.ReportSchedule, run *model.ReportRun) (int, error) {
+	host := s.conf.GetString("report.smtp.host")
+	if host == "" {
+		return 0, nil
+	}
+
+	to := make([]string, 0)
+	for _, username := range splitReportList(schedule.Recipients) {
+		user, err := s.userRepo.GetByUsername(ctx, username)
+		if err != nil || user == nil || user.Email == "" {
+			continue
+		}
+		to = append(to, user.Email)
+	}
+	to = uniqueReportList(append(to, splitReportList(schedule.Emails)...))
+	if len(to) == 0 {
+		return 0, nil
+	}
+
+	sender, err := mail.NewSender(mail.Config{
+		Host:               host,
+		Port:               s.conf.GetInt("report.smtp.port"),
+		Username:           s.conf.GetString("report.smtp.username"),
+		Password:           s.conf.GetString("report.smtp.password"),
+		From:               s.conf.GetString("report.smtp.from"),
+		TLS:                s.conf.GetBool("report.smtp.tls"),
+		InsecureSkipVerify: s.conf.GetBool("report.smtp.insecure_skip_verify"),
+		Timeout:            s.conf.GetDuration("report.smtp.timeout"),
+	})
+	if err != nil {
+		return 0, err
+	}
+	msg := &mail.Message{
+		To: to,
+		Subject: fmt.Sprintf("[PveSphere] %s (%s - %s)", schedule.Name,
+			run.PeriodStart.Format("2006-01-02"), run.PeriodEnd.Format("2006-01-02")),
+		Body: run.Summary + "\n\nThe full report is attached.\n",
+	}
+	if len(run.Attachment) > 0 {
+		msg.Attachments = []mail.Attachment{{
+			Filename:    reportFilename(schedule, run.PeriodEnd),
+			ContentType: xlsx.ContentType,
+			Data:        run.Attachment,
+		}}
+	}
+	if err := sender.Send(ctx, msg); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to send report email", zap.Strings("to", to), zap.Error(err))
+		return 0, err
+	}
+	return len(to), nil
+}
+
+// generate 按计划的内容生成摘要和工作簿；单项内容生成失败时记录错误，其余内容照常发送
+func (s *reportService) generate(ctx context.Context, schedule *model.ReportSchedule, start, end time.Time) *reportContent {
+	content := &reportContent{complianceScore: -1, backupSuccessRate: -1}
+	overview := content.workbook.AddSheet("Summary")
+	overview.AddRow("Item", "Value")
+	overview.AddRow("Report", schedule.Name)
+	overview.AddRow("Period start", start)
+	overview.AddRow("Period end", end)
+	scope := "all clusters"
+	if schedule.ClusterID > 0 {
+		if cluster, err := s.clusterRepo.GetByID(ctx, schedule.ClusterID); err == nil && cluster != nil {
+			scope = cluster.ClusterName
+		}
+	}
+	overview.AddRow("Scope", scope)
+
+	sections := splitReportList(schedule.Sections)
+	for _, section := range reportSectionOrder {
+		if !slices.Contains(sections, section) {
+			continue
+		}
+		var err error
+		switch section {
+		case model.ReportSectionCapacity:
+			err = s.capacitySection(ctx, schedule, start, content)
+		case model.ReportSectionBackup:
+			err = s.backupSection(ctx, schedule, start, end, content)
+		case model.ReportSectionCompliance:
+			err = s.complianceSection(ctx, schedule, end, content)
+		}
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to generate report section",
+				zap.Int64("schedule_id", schedule.Id), zap.String("section", section), zap.Error(err))
+			content.errors = append(content.errors, fmt.Sprintf("%s: %v", section, err))
+		}
+	}
+	for _, line := range content.summary {
+		overview.AddRow("Summary", line)
+	}
+	return content
+}
+
+// capacitySection 容量汇总：基于容量快照对比周期开始时和当前的虚拟机数量、分配资源和存储用量
+func (s *reportService) capacitySection(ctx context.Context, schedule *model.ReportSchedule, start time.Time, content *reportContent) error {
+	req := &v1.DashboardChangesRequest{Since: &start}
+	if schedule.ClusterID > 0 {
+		req.ClusterID = &schedule.ClusterID
+	}
+	changes, err := s.changeService.GetChanges(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	sheet := content.workbook.AddSheet("Capacity")
+	sheet.AddRow("Cluster", "Metric", "Before", "Current", "Delta")
+	var vms, vcpus, memoryMB, storageUsed, storageTotal int64
+	for _, cluster := range changes.Clusters {
+		c := cluster.Capacity
+		for _, metric := range []struct {
+			name  string
+			value v1.DashboardCapacityValue
+		}{
+			{"VMs", c.VMs},
+			{"Running VMs", c.RunningVMs},
+			{"vCPUs", c.VCPUs},
+			{"Memory (MB)", c.MemoryMB},
+			{"Disk (MB)", c.DiskMB},
+			{"Storage used (bytes)", c.StorageUsed},
+			{"Storage total (bytes)", c.StorageTotal},
+		} {
+			sheet.AddRow(cluster.ClusterName, metric.name, metric.value.Before, metric.value.Current, metric.value.Delta)
+		}
+		vms += c.VMs.Current
+		vcpus += c.VCPUs.Current
+		memoryMB += c.MemoryMB.Current
+		storageUsed += c.StorageUsed.Current
+		storageTotal += c.StorageTotal.Current
+	}
+
+	line := fmt.Sprintf("Capacity: %d VMs, %d vCPUs, %.1f GiB memory allocated", vms, vcpus, float64(memoryMB)/1024)
+	if storageTotal > 0 {
+		line += fmt.Sprintf(", storage %.1f%% used", float64(storageUsed)*100/float64(storageTotal))
+	}
+	content.summary = append(content.summary, line)
+	content.summary = append(content.summary, fmt.Sprintf("Changes: %d created, %d deleted, %d migrated, %d reconfigured",
+		changes.Summary.Created, changes.Summary.Deleted, changes.Summary.Migrated, changes.Summary.ConfigChanged))
+	return nil
+}
+
+// backupSection 备份成功率：周期内开始并已结束的 vzdump 任务中状态为 OK 的比例
+func (s *reportService) backupSection(ctx context.Context, schedule *model.ReportSchedule, start, end time.Time, content *reportContent) error {
+	clusters, err := s.reportClusters(ctx, schedule)
+	if err != nil {
+		return err
+	}
+
+	sheet := content.workbook.AddSheet("Backups")
+	sheet.AddRow("Cluster", "Node", "VMID", "Start", "End", "Status")
+	var total, succeeded int
+	var failures []string
+	for _, cluster := range clusters {
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", cluster.ClusterName, err))
+			continue
+		}
+		tasks, err := client.GetClusterTasks(ctx)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", cluster.ClusterName, err))
+			continue
+		}
+		for _, task := range tasks {
+			if taskType, _ := task["type"].(string); taskType != "vzdump" {
+				continue
+			}
+			startTime, _ := task["starttime"].(float64)
+			endTime, finished := task["endtime"].(float64)
+			status, _ := task["status"].(string)
+			begin := time.Unix(int64(startTime), 0)
+			if !finished || status == "" || begin.Before(start) || !begin.Before(end) {
+				continue
+			}
+			node, _ := task["node"].(string)
+			id, _ := task["id"].(string)
+			sheet.AddRow(cluster.ClusterName, node, id, begin, time.Unix(int64(endTime), 0), status)
+			total++
+			if status == "OK" {
+				succeeded++
+			}
+		}
+	}
+
+	if total > 0 {
+		content.backupSuccessRate = roundPercent(float64(succeeded) * 100 / float64(total))
+		content.summary = append(content.summary, fmt.Sprintf("Backups: %.1f%% succeeded (%d of %d jobs, %d failed)",
+			content.backupSuccessRate, succeeded, total, total-succeeded))
+	} else if len(failures) < len(clusters) {
+		content.summary = append(content.summary, "Backups: no backup jobs in this period")
+	}
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// complianceSection 合规得分：每台虚拟机检查负责人、复核日期、高危配置风险和操作系统维护状态，
+// 得分为通过的检查项占比；趋势为同一计划最近几次发送的得分
+func (s *reportService) complianceSection(ctx context.Context, schedule *model.ReportSchedule, end time.Time, content *reportContent) error {
+	clusters, err := s.reportClusters(ctx, schedule)
+	if err != nil {
+		return err
+	}
+	highRisk, err := s.securityRepo.VMIDsBySeverity(ctx, schedule.ClusterID, "high")
+	if err != nil {
+		return err
+	}
+	highRiskVMs := make(map[int64]bool, len(highRisk))
+	for _, id := range highRisk {
+		highRiskVMs[id] = true
+	}
+	eol, err := s.osEOLService.Report(ctx, &v1.OSEOLReportRequest{ClusterID: schedule.ClusterID, Status: "eol"})
+	if err != nil {
+		return err
+	}
+	eolVMs := make(map[int64]string, len(eol.VMs))
+	for _, vm := range eol.VMs {
+		eolVMs[vm.Id] = vm.OSName
+	}
+
+	sheet := content.workbook.AddSheet("Compliance")
+	sheet.AddRow("Cluster", "VMID", "VM", "Owner", "Has owner", "Review current", "No high risk findings", "OS supported", "Passed checks")
+	const checks = 4
+	var vmCount, passed int
+	for _, cluster := range clusters {
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return err
+		}
+		sort.Slice(vms, func(i, j int) bool { return vms[i].VMID < vms[j].VMID })
+		for _, vm := range vms {
+			if vm.IsTemplate == 1 {
+				continue
+			}
+			results := []bool{
+				vm.Owner != "",
+				vm.ReviewDate != nil && !vm.ReviewDate.Before(end),
+				!highRiskVMs[vm.Id],
+				eolVMs[vm.Id] == "",
+			}
+			n := 0
+			for _, ok := range results {
+				if ok {
+					n++
+				}
+			}
+			vmCount++
+			passed += n
+			sheet.AddRow(cluster.ClusterName, vm.VMID, vm.VmName, vm.Owner, results[0], results[1], results[2], results[3], n)
+		}
+	}
+	if vmCount == 0 {
+		content.summary = append(content.summary, "Compliance: no virtual machines")
+		return nil
+	}
+	content.complianceScore = roundPercent(float64(passed) * 100 / float64(vmCount*checks))
+
+	trend := content.workbook.AddSheet("Compliance Trend")
+	trend.AddRow("Period end", "Score")
+	previous, _, err := s.reportRepo.ListRuns(ctx, schedule.Id, 1, reportTrendRuns)
+	if err != nil {
+		return err
+	}
+	var last *model.ReportRun
+	for i := len(previous) - 1; i >= 0; i-- {
+		if previous[i].ComplianceScore < 0 {
+			continue
+		}
+		trend.AddRow(previous[i].PeriodEnd, previous[i].ComplianceScore)
+		last = previous[i]
+	}
+	trend.AddRow(end, content.complianceScore)
+
+	line := fmt.Sprintf("Compliance: score %.1f (%d VMs)", content.complianceScore, vmCount)
+	if last != nil {
+		line += fmt.Sprintf(", %+.1f since %s", content.complianceScore-last.ComplianceScore, last.PeriodEnd.Format("2006-01-02"))
+	}
+	content.summary = append(content.summary, line)
+	return nil
+}
+
+func (s *reportService) reportClusters(ctx context.Context, schedule *model.ReportSchedule) ([]*model.PveCluster, error) {
+	if schedule.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, schedule.ClusterID)
+		if err != nil {
+			return nil, err
+		}
+		if cluster == nil {
+			return nil, fmt.Errorf("cluster %d not found", schedule.ClusterID)
+		}
+		return []*model.PveCluster{cluster}, nil
+	}
+	return s.clusterRepo.List(ctx)
+}
+
+// nextReportRunTime 计算 after 之后（不含）的下一次发送时间
+func nextReportRunTime(schedule *model.ReportSchedule, after time.Time) time.Time {
+	loc := time.Local
+	if schedule.Timezone != "" {
+		if l, err := time.LoadLocation(schedule.Timezone); err == nil {
+			loc = l
+		}
+	}
+	minutes, _ := parseClockMinutes(schedule.SendTime)
+	local := after.In(loc)
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, minutes/60, minutes%60, 0, 0, loc)
+	}
+
+	switch schedule.Frequency {
+	case model.ReportFrequencyWeekly:
+		for i := 0; i <= 7; i++ {
+			candidate := at(local.Year(), local.Month(), local.Day()+i)
+			if int(candidate.Weekday()) == schedule.Weekday && candidate.After(after) {
+				return candidate
+			}
+		}
+	case model.ReportFrequencyMonthly:
+		for i := 0; i <= 12; i++ {
+			first := time.Date(local.Year(), local.Month()+time.Month(i), 1, 0, 0, 0, 0, loc)
+			day := schedule.MonthDay
+			if last := first.AddDate(0, 1, -1).Day(); day > last {
+				day = last
+			}
+			candidate := at(first.Year(), first.Month(), day)
+			if candidate.After(after) {
+				return candidate
+			}
+		}
+	default:
+		candidate := at(local.Year(), local.Month(), local.Day())
+		if !candidate.After(after) {
+			candidate = at(local.Year(), local.Month(), local.Day()+1)
+		}
+		return candidate
+	}
+	return after.Add(24 * time.Hour)
+}
+
+// reportPeriodStart 报告统计周期：截至 end 的一天、一周或一个月
+func reportPeriodStart(schedule *model.ReportSchedule, end time.Time) time.Time {
+	switch schedule.Frequency {
+	case model.ReportFrequencyWeekly:
+		return end.AddDate(0, 0, -7)
+	case model.ReportFrequencyMonthly:
+		return end.AddDate(0, -1, 0)
+	default:
+		return end.AddDate(0, 0, -1)
+	}
+}
+
+func reportFilename(schedule *model.ReportSchedule, end time.Time) string {
+	return fmt.Sprintf("report-%d-%s.xlsx", schedule.Id, end.Format("20060102"))
+}
+
+// normalizeReportSections 去重并按固定顺序排列
+func normalizeReportSections(sections []string) []string {
+	result := make([]string, 0, len(reportSectionOrder))
+	for _, section := range reportSectionOrder {
+		if slices.Contains(sections, section) {
+			result = append(result, section)
+		}
+	}
+	return result
+}
+
+// splitReportList 拆分逗号分隔的列表
+func splitReportList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, ",")
+}
+
+// uniqueReportList 去掉空白和重复项，保持原有顺序
+func uniqueReportList(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !slices.Contains(result, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+func truncateReportError(msg string) string {
+	if len(msg) > 1000 {
+		return msg[:1000]
+	}
+	return msg
+}
+
+func roundPercent(v float64) float64 {
+	return float64(int64(v*10+0.5)) / 10
+}
+
+func toReportScheduleItem(schedule *model.ReportSchedule) v1.ReportScheduleItem {
+	return v1.ReportScheduleItem{
+		Id:          schedule.Id,
+		Name:        schedule.Name,
+		ClusterID:   schedule.ClusterID,
+		Sections:    splitReportList(schedule.Sections),
+		Frequency:   schedule.Frequency,
+		Weekday:     schedule.Weekday,
+		MonthDay:    schedule.MonthDay,
+		SendTime:    schedule.SendTime,
+		Timezone:    schedule.Timezone,
+		Recipients:  splitReportList(schedule.Recipients),
+		Emails:      splitReportList(schedule.Emails),
+		IsEnabled:   schedule.IsEnabled,
+		LastRunTime: schedule.LastRunTime,
+		NextRunTime: schedule.NextRunTime,
+		Creator:     schedule.Creator,
+		Modifier:    schedule.Modifier,
+		CreateTime:  schedule.CreateTime,
+		UpdateTime:  schedule.UpdateTime,
+	}
+}
+
+func toReportRunItem(run *model.ReportRun) v1.ReportRunItem {
+	item := v1.ReportRunItem{
+		Id:           run.Id,
+		ScheduleID:   run.ScheduleID,
+		PeriodStart:  run.PeriodStart,
+		PeriodEnd:    run.PeriodEnd,
+		Status:       run.Status,
+		Summary:      run.Summary,
+		Notified:     run.Notified,
+		Emailed:      run.Emailed,
+		ErrorMessage: run.ErrorMessage,
+		Operator:     run.Operator,
+		CreateTime:   run.CreateTime,
+	}
+	if run.ComplianceScore >= 0 {
+		score := run.ComplianceScore
+		item.ComplianceScore = &score
+	}
+	if run.BackupSuccessRate >= 0 {
+		rate := run.BackupSuccessRate
+		item.BackupSuccessRate = &rate
+	}
+	return item
+}
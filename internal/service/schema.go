@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/migration"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type SchemaService interface {
+	// GetSchemaVersion 数据库表结构版本及各迁移的执行情况，仅管理员可用
+	GetSchemaVersion(ctx context.Context, userID string) (*v1.SchemaVersionData, error)
+}
+
+func NewSchemaService(
+	service *Service,
+	conf *viper.Viper,
+	schemaRepo repository.SchemaMigrationRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) SchemaService {
+	return &schemaService{
+		conf:       conf,
+		schemaRepo: schemaRepo,
+		userRepo:   userRepo,
+		Service:    service,
+		logger:     logger,
+	}
+}
+
+type schemaService struct {
+	conf       *viper.Viper
+	schemaRepo repository.SchemaMigrationRepository
+	userRepo   repository.UserRepository
+	*Service
+	logger *log.Logger
+}
+
+func (s *schemaService) GetSchemaVersion(ctx context.Context, userID string) (*v1.SchemaVersionData, error) {
+	if _, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID); err != nil {
+		return nil, err
+	}
+
+	current, statuses, err := s.schemaRepo.Status(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get schema migration status", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	data := &v1.SchemaVersionData{
+		CurrentVersion: current,
+		LatestVersion:  migration.Latest(),
+		AutoMigrate:    s.conf.GetBool("migration.auto_migrate"),
+		Migrations:     make([]v1.SchemaMigrationItem, 0, len(statuses)),
+	}
+	for _, status := range statuses {
+		item := v1.SchemaMigrationItem{
+			Version: status.Version,
+			Name:    status.Name,
+			Status:  "pending",
+		}
+		if status.Applied {
+			appliedAt := status.AppliedAt
+			item.Status = "applied"
+			item.AppliedAt = &appliedAt
+		}
+		data.Migrations = append(data.Migrations, item)
+	}
+	return data, nil
+}
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// 每种类型默认返回条数
+	defaultSearchLimit = 5
+	// 标签、任务需要实时查询 Proxmox，单个集群的超时时间
+	searchLiveQueryTimeout = 3 * time.Second
+)
+
+// 搜索结果类型（同时决定返回顺序）
+var searchTypes = []string{"vm", "node", "template", "storage", "task"}
+
+type SearchService interface {
+	GlobalSearch(ctx context.Context, req *v1.GlobalSearchRequest) (*v1.GlobalSearchResponseData, error)
+}
+
+func NewSearchService(
+	service *Service,
+	searchRepo repository.SearchRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	logger *log.Logger,
+) SearchService {
+	return &searchService{
+		searchRepo:  searchRepo,
+		clusterRepo: clusterRepo,
+		nodeRepo:    nodeRepo,
+		vmRepo:      vmRepo,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type searchService struct {
+	searchRepo  repository.SearchRepository
+	clusterRepo repository.PveClusterRepository
+	nodeRepo    repository.PveNodeRepository
+	vmRepo      repository.PveVMRepository
+	*Service
+	logger *log.Logger
+}
+
+// liveSearchResult 单个集群的实时查询结果（虚拟机标签、任务）
+type liveSearchResult struct {
+	tagVMs []v1.SearchResultItem
+	tasks  []v1.SearchResultItem
+}
+
+// GlobalSearch 跨集群搜索虚拟机、节点、模板、存储和任务
+// 虚拟机名称/VMID/IP、节点、模板、存储从数据库查询；虚拟机标签和任务从 Proxmox 实时查询，单个集群失败不影响整体结果
+func (s *searchService) GlobalSearch(ctx context.Context, req *v1.GlobalSearchRequest) (*v1.GlobalSearchResponseData, error) {
+	keyword := strings.TrimSpace(req.Q)
+	if keyword == "" {
+		return nil, v1.ErrBadRequest
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+
+	types, err := parseSearchTypes(req.Types)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	clusterMap := make(map[int64]*model.PveCluster, len(clusters))
+	var targetClusters []*model.PveCluster
+	for _, cluster := range clusters {
+		clusterMap[cluster.Id] = cluster
+		if req.ClusterID == 0 || cluster.Id == req.ClusterID {
+			targetClusters = append(targetClusters, cluster)
+		}
+	}
+
+	// 实时查询与数据库查询并行执行
+	var liveResults []liveSearchResult
+	var wg sync.WaitGroup
+	if types["vm"] || types["task"] {
+		liveResults = make([]liveSearchResult, len(targetClusters))
+		for i, cluster := range targetClusters {
+			wg.Add(1)
+			go func(i int, cluster *model.PveCluster) {
+				defer wg.Done()
+				liveResults[i] = s.liveSearch(ctx, cluster, keyword, types, limit)
+			}(i, cluster)
+		}
+	}
+
+	grouped := make(map[string][]v1.SearchResultItem)
+	if types["vm"] {
+		grouped["vm"], err = s.searchVMs(ctx, keyword, req.ClusterID, limit)
+		if err != nil {
+			wg.Wait()
+			return nil, err
+		}
+	}
+	if types["node"] {
+		grouped["node"], err = s.searchNodes(ctx, keyword, req.ClusterID, limit)
+		if err != nil {
+			wg.Wait()
+			return nil, err
+		}
+	}
+	if types["template"] {
+		grouped["template"], err = s.searchTemplates(ctx, keyword, req.ClusterID, limit)
+		if err != nil {
+			wg.Wait()
+			return nil, err
+		}
+	}
+	if types["storage"] {
+		grouped["storage"], err = s.searchStorages(ctx, keyword, req.ClusterID, limit)
+		if err != nil {
+			wg.Wait()
+			return nil, err
+		}
+	}
+	wg.Wait()
+
+	// 合并实时查询结果（按标签命中的虚拟机排在数据库命中结果之后，并去重）
+	for _, live := range liveResults {
+		grouped["vm"] = appendSearchItems(grouped["vm"], live.tagVMs)
+		grouped["task"] = append(grouped["task"], live.tasks...)
+	}
+
+	result := &v1.GlobalSearchResponseData{
+		Query: keyword,
+		Items: make([]v1.SearchResultItem, 0),
+	}
+	for _, t := range searchTypes {
+		items := grouped[t]
+		sortSearchItems(items, keyword)
+		if len(items) > limit {
+			items = items[:limit]
+		}
+		for i := range items {
+			if cluster, ok := clusterMap[items[i].ClusterID]; ok {
+				items[i].ClusterName = cluster.ClusterName
+			}
+		}
+		result.Items = append(result.Items, items...)
+	}
+	result.Total = len(result.Items)
+
+	return result, nil
+}
+
+// parseSearchTypes 解析搜索范围，为空表示全部类型
+func parseSearchTypes(raw string) (map[string]bool, error) {
+	types := make(map[string]bool)
+	if strings.TrimSpace(raw) == "" {
+		for _, t := range searchTypes {
+			types[t] = true
+		}
+		return types, nil
+	}
+
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" {
+			continue
+		}
+		valid := false
+		for _, st := range searchTypes {
+			if st == t {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, v1.WithDetailf(v1.ErrInvalidParameter, "types=%s", t)
+		}
+		types[t] = true
+	}
+	return types, nil
+}
+
+func (s *searchService) searchVMs(ctx context.Context, keyword string, clusterID int64, limit int) ([]v1.SearchResultItem, error) {
+	vms, err := s.searchRepo.SearchVMs(ctx, keyword, clusterID, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to search vms", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	matched := make(map[int64]string, len(vms))
+	for _, vm := range vms {
+		field := "name"
+		if fmt.Sprint(vm.VMID) == keyword {
+			field = "vmid"
+		} else if !containsFold(vm.VmName, keyword) {
+			field = "description"
+		}
+		matched[vm.Id] = field
+	}
+
+	// IP 命中的虚拟机
+	ips, err := s.searchRepo.SearchVMIPAddresses(ctx, keyword, clusterID, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to search vm ip addresses", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	for _, ip := range ips {
+		if _, ok := matched[ip.VMId]; ok {
+			continue
+		}
+		vm, err := s.vmRepo.GetByID(ctx, ip.VMId)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err), zap.Int64("vm_id", ip.VMId))
+			return nil, v1.ErrInternalServerError
+		}
+		if vm == nil || vm.IsTemplate == 1 {
+			continue
+		}
+		vms = append(vms, vm)
+		matched[vm.Id] = "ip"
+	}
+
+	nodeIDs := make([]int64, 0, len(vms))
+	for _, vm := range vms {
+		nodeIDs = append(nodeIDs, vm.NodeID)
+	}
+	nodes, err := s.nodeRepo.GetByIDs(ctx, nodeIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	items := make([]v1.SearchResultItem, 0, len(vms))
+	for _, vm := range vms {
+		item := v1.SearchResultItem{
+			Type:         "vm",
+			ID:           vm.Id,
+			Key:          fmt.Sprint(vm.VMID),
+			Title:        vm.VmName,
+			Subtitle:     fmt.Sprintf("VMID %d", vm.VMID),
+			ClusterID:    vm.ClusterID,
+			Status:       vm.Status,
+			MatchedField: matched[vm.Id],
+		}
+		if node, ok := nodes[vm.NodeID]; ok {
+			item.NodeName = node.NodeName
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *searchService) searchNodes(ctx context.Context, keyword string, clusterID int64, limit int) ([]v1.SearchResultItem, error) {
+	nodes, err := s.searchRepo.SearchNodes(ctx, keyword, clusterID, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to search nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	items := make([]v1.SearchResultItem, 0, len(nodes))
+	for _, node := range nodes {
+		field := "name"
+		if !containsFold(node.NodeName, keyword) {
+			field = "ip"
+		}
+		items = append(items, v1.SearchResultItem{
+			Type:         "node",
+			ID:           node.Id,
+			Key:          node.NodeName,
+			Title:        node.NodeName,
+			Subtitle:     node.IPAddress,
+			ClusterID:    node.ClusterID,
+			NodeName:     node.NodeName,
+			Status:       node.Status,
+			MatchedField: field,
+		})
+	}
+	return items, nil
+}
+
+func (s *searchService) searchTemplates(ctx context.Context, keyword string, clusterID int64, limit int) ([]v1.SearchResultItem, error) {
+	templates, err := s.searchRepo.SearchTemplates(ctx, keyword, clusterID, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to search templates", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	items := make([]v1.SearchResultItem, 0, len(templates))
+	for _, template := range templates {
+		field := "name"
+		if !containsFold(template.TemplateName, keyword) {
+			field = "description"
+		}
+		items = append(items, v1.SearchResultItem{
+			Type:         "template",
+			ID:           template.Id,
+			Key:          template.TemplateName,
+			Title:        template.TemplateName,
+			Subtitle:     template.Description,
+			ClusterID:    template.ClusterID,
+			MatchedField: field,
+		})
+	}
+	return items, nil
+}
+
+func (s *searchService) searchStorages(ctx context.Context, keyword string, clusterID int64, limit int) ([]v1.SearchResultItem, error) {
+	// 存储按节点存储多条记录，共享存储需要去重，因此多取一些
+	storages, err := s.searchRepo.SearchStorages(ctx, keyword, clusterID, limit*4)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to search storages", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	seenShared := make(map[string]bool)
+	items := make([]v1.SearchResultItem, 0, len(storages))
+	for _, storage := range storages {
+		nodeName := storage.NodeName
+		if storage.Shared == 1 {
+			key := fmt.Sprintf("%d/%s", storage.ClusterID, storage.StorageName)
+			if seenShared[key] {
+				continue
+			}
+			seenShared[key] = true
+			nodeName = ""
+		}
+
+		field := "name"
+		if !containsFold(storage.StorageName, keyword) {
+			field = "type"
+		}
+		items = append(items, v1.SearchResultItem{
+			Type:         "storage",
+			ID:           storage.Id,
+			Key:          storage.StorageName,
+			Title:        storage.StorageName,
+			Subtitle:     storage.Type,
+			ClusterID:    storage.ClusterID,
+			NodeName:     nodeName,
+			MatchedField: field,
+		})
+	}
+	return items, nil
+}
+
+// liveSearch 实时查询单个集群的虚拟机标签和任务
+func (s *searchService) liveSearch(ctx context.Context, cluster *model.PveCluster, keyword string, types map[string]bool, limit int) liveSearchResult {
+	var result liveSearchResult
+
+	ctx, cancel := context.WithTimeout(ctx, searchLiveQueryTimeout)
+	defer cancel()
+
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to create proxmox client", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+		return result
+	}
+
+	if types["vm"] {
+		resources, err := s.resources.Get(ctx, cluster.Id, client)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get cluster resources for search",
+				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+		} else {
+			result.tagVMs = s.matchVMTags(ctx, cluster, resources, keyword, limit)
+		}
+	}
+
+	if types["task"] {
+		tasks, err := client.GetClusterTasks(ctx)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get cluster tasks for search",
+				zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+		} else {
+			result.tasks = matchTasks(cluster, tasks, keyword, limit)
+		}
+	}
+
+	return result
+}
+
+// matchVMTags 按标签匹配虚拟机，并关联数据库中的虚拟机记录
+func (s *searchService) matchVMTags(ctx context.Context, cluster *model.PveCluster, resources []map[string]interface{}, keyword string, limit int) []v1.SearchResultItem {
+	var items []v1.SearchResultItem
+	for _, resource := range resources {
+		if len(items) >= limit {
+			break
+		}
+		resourceType, _ := resource["type"].(string)
+		if resourceType != "qemu" {
+			continue
+		}
+		if template, _ := resource["template"].(float64); template == 1 {
+			continue
+		}
+		tags, _ := resource["tags"].(string)
+		if tags == "" {
+			continue
+		}
+		matched := false
+		for _, tag := range strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+			if containsFold(tag, keyword) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		nodeName, _ := resource["node"].(string)
+		vmidFloat, _ := resource["vmid"].(float64)
+		name, _ := resource["name"].(string)
+		status, _ := resource["status"].(string)
+		item := v1.SearchResultItem{
+			Type:         "vm",
+			Key:          fmt.Sprint(uint32(vmidFloat)),
+			Title:        name,
+			Subtitle:     tags,
+			ClusterID:    cluster.Id,
+			NodeName:     nodeName,
+			Status:       status,
+			MatchedField: "tag",
+		}
+
+		node, err := s.nodeRepo.GetByNodeName(ctx, nodeName, cluster.Id)
+		if err == nil && node != nil {
+			if vm, err := s.vmRepo.GetByVMID(ctx, uint32(vmidFloat), node.Id); err == nil && vm != nil {
+				item.ID = vm.Id
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// matchTasks 按 UPID、类型、对象ID、用户匹配任务
+func matchTasks(cluster *model.PveCluster, tasks []map[string]interface{}, keyword string, limit int) []v1.SearchResultItem {
+	var items []v1.SearchResultItem
+	for _, task := range tasks {
+		if len(items) >= limit {
+			break
+		}
+		upid, _ := task["upid"].(string)
+		taskType, _ := task["type"].(string)
+		id, _ := task["id"].(string)
+		user, _ := task["user"].(string)
+		node, _ := task["node"].(string)
+		status, _ := task["status"].(string)
+
+		var field string
+		switch {
+		case containsFold(upid, keyword):
+			field = "upid"
+		case containsFold(taskType, keyword):
+			field = "type"
+		case containsFold(id, keyword):
+			field = "vmid"
+		case containsFold(user, keyword):
+			field = "user"
+		default:
+			continue
+		}
+
+		subtitle := user
+		if startTime, ok := task["starttime"].(float64); ok {
+			subtitle = fmt.Sprintf("%s %s", user, time.Unix(int64(startTime), 0).Format("2006-01-02 15:04:05"))
+		}
+		title := taskType
+		if id != "" {
+			title = fmt.Sprintf("%s %s", taskType, id)
+		}
+		items = append(items, v1.SearchResultItem{
+			Type:         "task",
+			Key:          upid,
+			Title:        title,
+			Subtitle:     subtitle,
+			ClusterID:    cluster.Id,
+			NodeName:     node,
+			Status:       status,
+			MatchedField: field,
+		})
+	}
+	return items
+}
+
+// appendSearchItems 追加结果并按 类型+集群+Key 去重
+func appendSearchItems(items, extra []v1.SearchResultItem) []v1.SearchResultItem {
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		seen[fmt.Sprintf("%d/%s", item.ClusterID, item.Key)] = true
+	}
+	for _, item := range extra {
+		key := fmt.Sprintf("%d/%s", item.ClusterID, item.Key)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		items = append(items, item)
+	}
+	return items
+}
+
+// sortSearchItems 精确匹配优先，其次前缀匹配，其余保持原顺序，便于输入联想
+func sortSearchItems(items []v1.SearchResultItem, keyword string) {
+	rank := func(item v1.SearchResultItem) int {
+		switch {
+		case strings.EqualFold(item.Title, keyword) || strings.EqualFold(item.Key, keyword):
+			return 0
+		case strings.HasPrefix(strings.ToLower(item.Title), strings.ToLower(keyword)):
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return rank(items[i]) < rank(items[j])
+	})
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -1,17 +1,25 @@
 package service
 
 import (
+	"time"
+
 	"pvesphere/internal/repository"
 	"pvesphere/pkg/jwt"
 	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
 	"pvesphere/pkg/sid"
 )
 
+// clusterResourcesCacheTTL /cluster/resources 缓存有效期，同一请求内多次读取以及短时间内的重复刷新会复用结果
+const clusterResourcesCacheTTL = 5 * time.Second
+
 type Service struct {
 	logger *log.Logger
 	sid    *sid.Sid
 	jwt    *jwt.JWT
 	tm     repository.Transaction
+	// 各服务共享的集群资源缓存（增量解析）
+	resources *proxmox.ClusterResourceCache
 }
 
 func NewService(
@@ -25,5 +33,7 @@ func NewService(
 		sid:    sid,
 		jwt:    jwt,
 		tm:     tm,
+
+		resources: proxmox.NewClusterResourceCache(clusterResourcesCacheTTL),
 	}
 }
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/secret"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// SharedTokenStore 多实例共享的短期令牌存储。令牌内容以 JSON 保存在数据库中，
+// 由任一实例签发的 ws_token、控制台会话等可以在负载均衡到其他实例时继续使用。
+// 内容可能包含 Proxmox 票据，始终使用 secret.local.key 加密保存（各实例需使用相同的密钥）
+type SharedTokenStore interface {
+	// Put 保存令牌，value 序列化为 JSON
+	Put(ctx context.Context, kind, token string, value interface{}, expiresAt time.Time) error
+	// Get 读取未过期的令牌到 value，令牌不存在或已过期时返回 false
+	Get(ctx context.Context, kind, token string, value interface{}) (bool, error)
+	// Update 更新令牌内容和过期时间，令牌不存在时返回 false
+	Update(ctx context.Context, kind, token string, value interface{}, expiresAt time.Time) (bool, error)
+	// Consume 读取并删除令牌（单次使用），并发消费时只有一个调用返回 true
+	Consume(ctx context.Context, kind, token string, value interface{}) (bool, error)
+	// Delete 删除令牌
+	Delete(ctx context.Context, kind, token string) error
+	// CleanupExpired 删除已过期的令牌，返回删除数量
+	CleanupExpired(ctx context.Context) (int64, error)
+}
+
+// NewSharedTokenStore 未配置 secret.local.key 时返回错误，服务启动失败，避免票据以明文写入数据库
+func NewSharedTokenStore(
+	conf *viper.Viper,
+	tokenRepo repository.SharedTokenRepository,
+	logger *log.Logger,
+) (SharedTokenStore, error) {
+	key := conf.GetString("secret.local.key")
+	if key == "" {
+		return nil, errors.New("secret.local.key is required: shared tokens may contain Proxmox tickets and are stored encrypted")
+	}
+	sealer, err := secret.NewLocalStore(key)
+	if err != nil {
+		return nil, fmt.Errorf("init shared token encryption: %w", err)
+	}
+	return &sharedTokenStore{
+		tokenRepo: tokenRepo,
+		sealer:    sealer,
+		logger:    logger,
+	}, nil
+}
+
+type sharedTokenStore struct {
+	tokenRepo repository.SharedTokenRepository
+	sealer    secret.Store
+	logger    *log.Logger
+}
+
+// seal 序列化并加密令牌内容，kind 作为附加认证数据
+func (s *sharedTokenStore) seal(ctx context.Context, kind string, value interface{}) (string, error) {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return s.sealer.Put(ctx, kind, string(payload))
+}
+
+func (s *sharedTokenStore) open(ctx context.Context, record *model.SharedToken, value interface{}) error {
+	// 升级前以明文保存的令牌不再接受，令牌有效期很短，过期后自动清理
+	if backend, _, ok := secret.SplitRef(record.Payload); !ok || backend != secret.BackendLocal {
+		return errors.New("shared token payload is not encrypted")
+	}
+	payload, err := s.sealer.Get(ctx, record.Payload)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(payload), value)
+}
+
+func sharedTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *sharedTokenStore) Put(ctx context.Context, kind, token string, value interface{}, expiresAt time.Time) error {
+	payload, err := s.seal(ctx, kind, value)
+	if err != nil {
+		return err
+	}
+	return s.tokenRepo.Create(ctx, &model.SharedToken{
+		Kind:      kind,
+		TokenHash: sharedTokenHash(token),
+		Payload:   payload,
+		ExpiresAt: expiresAt,
+	})
+}
+
+func (s *sharedTokenStore) Get(ctx context.Context, kind, token string, value interface{}) (bool, error) {
+	record, err := s.tokenRepo.Get(ctx, kind, sharedTokenHash(token))
+	if err != nil || record == nil || time.Now().After(record.ExpiresAt) {
+		return false, err
+	}
+	if err := s.open(ctx, record, value); err != nil {
+		s.logger.WithContext(ctx).Error("invalid shared token payload", zap.String("kind", kind), zap.Error(err))
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *sharedTokenStore) Update(ctx context.Context, kind, token string, value interface{}, expiresAt time.Time) (bool, error) {
+	payload, err := s.seal(ctx, kind, value)
+	if err != nil {
+		return false, err
+	}
+	return s.tokenRepo.Update(ctx, kind, sharedTokenHash(token), payload, expiresAt)
+}
+
+func (s *sharedTokenStore) Consume(ctx context.Context, kind, token string, value interface{}) (bool, error) {
+	hash := sharedTokenHash(token)
+	record, err := s.tokenRepo.Get(ctx, kind, hash)
+	if err != nil || record == nil {
+		return false, err
+	}
+	deleted, err := s.tokenRepo.Delete(ctx, kind, hash)
+	if err != nil || !deleted || time.Now().After(record.ExpiresAt) {
+		return false, err
+	}
+	if err := s.open(ctx, record, value); err != nil {
+		s.logger.WithContext(ctx).Error("invalid shared token payload", zap.String("kind", kind), zap.Error(err))
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *sharedTokenStore) Delete(ctx context.Context, kind, token string) error {
+	_, err := s.tokenRepo.Delete(ctx, kind, sharedTokenHash(token))
+	return err
+}
+
+func (s *sharedTokenStore) CleanupExpired(ctx context.Context) (int64, error) {
+	return s.tokenRepo.DeleteExpired(ctx, time.Now())
+}
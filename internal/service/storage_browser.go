@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// storageDeleteTokenTTL 批量删除确认令牌有效期
+const storageDeleteTokenTTL = 5 * time.Minute
+
+type StorageBrowserService interface {
+	Browse(ctx context.Context, req *v1.BrowseStorageRequest) (*v1.BrowseStorageResponseData, error)
+	// PrepareDelete 校验所选卷并签发确认令牌，不执行删除
+	PrepareDelete(ctx context.Context, userID string, req *v1.PrepareStorageDeleteRequest) (*v1.PrepareStorageDeleteResponseData, error)
+	// ConfirmDelete 使用确认令牌删除预检时的卷，令牌单次使用
+	ConfirmDelete(ctx context.Context, userID string, req *v1.ConfirmStorageDeleteRequest) (*v1.ConfirmStorageDeleteResponseData, error)
+}
+
+func NewStorageBrowserService(
+	service *Service,
+	conf *viper.Viper,
+	userRepo repository.UserRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	vmRepo repository.PveVMRepository,
+	changeControl ChangeControlService,
+	sharedTokens SharedTokenStore,
+	logger *log.Logger,
+) StorageBrowserService {
+	return &storageBrowserService{
+		conf:          conf,
+		userRepo:      userRepo,
+		nodeRepo:      nodeRepo,
+		clusterRepo:   clusterRepo,
+		vmRepo:        vmRepo,
+		changeControl: changeControl,
+		sharedTokens:  sharedTokens,
+		Service:       service,
+		logger:        logger,
+	}
+}
+
+type storageBrowserService struct {
+	conf          *viper.Viper
+	userRepo      repository.UserRepository
+	nodeRepo      repository.PveNodeRepository
+	clusterRepo   repository.PveClusterRepository
+	vmRepo        repository.PveVMRepository
+	changeControl ChangeControlService
+	// sharedTokens 保存确认令牌，任一实例签发的令牌可在其他实例上确认
+	sharedTokens SharedTokenStore
+	*Service
+	logger *log.Logger
+}
+
+// storageDeleteToken 预检通过的批量删除请求，确认时按预检结果删除
+type storageDeleteToken struct {
+	UserID    string
+	NodeID    int64
+	ClusterID int64
+	Storage   string
+	Volumes   []v1.StorageBrowserVolume
+}
+
+// storageListing 一次读取的存储内容及卷归属
+type storageListing struct {
+	node    *model.PveNode
+	cluster *model.PveCluster
+	volumes []v1.StorageBrowserVolume
+}
+
+func (s *storageBrowserService) Browse(ctx context.Context, req *v1.BrowseStorageRequest) (*v1.BrowseStorageResponseData, error) {
+	segments := splitStoragePath(req.Path)
+	if len(segments) > 2 {
+		return nil, v1.WithDetailf(v1.ErrInvalidParameter, "path=%s", req.Path)
+	}
+	var vmidFilter uint32
+	if len(segments) == 2 {
+		vmid, err := strconv.ParseUint(segments[1], 10, 32)
+		if err != nil || !isVMDiskContent(segments[0]) {
+			return nil, v1.WithDetailf(v1.ErrInvalidParameter, "path=%s", req.Path)
+		}
+		vmidFilter = uint32(vmid)
+	}
+
+	listing, err := s.listStorage(ctx, req.NodeID, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	// 当前目录（含子目录）下的卷
+	var scoped []v1.StorageBrowserVolume
+	for _, volume := range listing.volumes {
+		if len(segments) >= 1 && volume.Content != segments[0] {
+			continue
+		}
+		if len(segments) == 2 && volume.VMID != vmidFilter {
+			continue
+		}
+		scoped = append(scoped, volume)
+	}
+
+	result := &v1.BrowseStorageResponseData{
+		NodeID:   listing.node.Id,
+		NodeName: listing.node.NodeName,
+		Storage:  req.Storage,
+		Path:     strings.Join(segments, "/"),
+		Entries:  []v1.StorageBrowserEntry{},
+		Volumes:  []v1.StorageBrowserVolume{},
+	}
+	if len(segments) > 0 {
+		result.Parent = strings.Join(segments[:len(segments)-1], "/")
+	}
+
+	entries := make(map[string]*v1.StorageBrowserEntry)
+	for _, volume := range scoped {
+		result.VolumeCount++
+		result.TotalSize += volume.Size
+		if volume.Orphaned {
+			result.OrphanCount++
+			result.OrphanSize += volume.Size
+		}
+
+		// 根目录按内容类型分组；磁盘类内容再按 VMID 分组，无 VMID 的卷直接列出
+		var entry *v1.StorageBrowserEntry
+		switch {
+		case len(segments) == 0:
+			entry = entries[volume.Content]
+			if entry == nil {
+				entry = &v1.StorageBrowserEntry{Name: volume.Content, Path: volume.Content, Content: volume.Content}
+				entries[volume.Content] = entry
+			}
+		case len(segments) == 1 && isVMDiskContent(volume.Content) && volume.VMID > 0:
+			name := strconv.FormatUint(uint64(volume.VMID), 10)
+			entry = entries[name]
+			if entry == nil {
+				entry = &v1.StorageBrowserEntry{
+					Name:    name,
+					Path:    volume.Content + "/" + name,
+					Content: volume.Content,
+					VMID:    volume.VMID,
+					VmId:    volume.VmId,
+					VmName:  volume.VmName,
+				}
+				entries[name] = entry
+			}
+		}
+		if entry != nil {
+			entry.VolumeCount++
+			entry.TotalSize += volume.Size
+			if volume.Orphaned {
+				entry.OrphanCount++
+				entry.OrphanSize += volume.Size
+			}
+			continue
+		}
+		if req.OrphanedOnly && !volume.Orphaned {
+			continue
+		}
+		result.Volumes = append(result.Volumes, volume)
+	}
+
+	for _, entry := range entries {
+		result.Entries = append(result.Entries, *entry)
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		if result.Entries[i].VMID != result.Entries[j].VMID {
+			return result.Entries[i].VMID < result.Entries[j].VMID
+		}
+		return result.Entries[i].Name < result.Entries[j].Name
+	})
+	sort.Slice(result.Volumes, func(i, j int) bool { return result.Volumes[i].VolID < result.Volumes[j].VolID })
+	return result, nil
+}
+
+func (s *storageBrowserService) PrepareDelete(ctx context.Context, userID string, req *v1.PrepareStorageDeleteRequest) (*v1.PrepareStorageDeleteResponseData, error) {
+	if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "storage.manage"); err != nil {
+		return nil, err
+	}
+	listing, err := s.listStorage(ctx, req.NodeID, req.Storage)
+	if err != nil {
+		return nil, err
+	}
+	byVolID := make(map[string]v1.StorageBrowserVolume, len(listing.volumes))
+	for _, volume := range listing.volumes {
+		byVolID[volume.VolID] = volume
+	}
+
+	result := &v1.PrepareStorageDeleteResponseData{
+		NodeName: listing.node.NodeName,
+		Storage:  req.Storage,
+		Volumes:  make([]v1.StorageBrowserVolume, 0, len(req.Volumes)),
+	}
+	seen := make(map[string]bool, len(req.Volumes))
+	for _, volid := range req.Volumes {
+		volid = strings.TrimSpace(volid)
+		if seen[volid] {
+			continue
+		}
+		seen[volid] = true
+
+		volume, ok := byVolID[volid]
+		if !ok {
+			return nil, v1.WithDetail(v1.ErrStorageVolumeNotFound, volid)
+		}
+		if volume.UsedByVMID > 0 {
+			if !req.AllowInUse {
+				return nil, v1.WithDetailf(v1.ErrStorageVolumeInUse, "%s (vmid %d %s)", volid, volume.UsedByVMID, volume.UsedByKey)
+			}
+			result.InUseCount++
+		}
+		result.Volumes = append(result.Volumes, volume)
+		result.TotalSize += volume.Size
+	}
+
+	token, err := newStorageDeleteToken()
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to generate delete token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	expiresAt := time.Now().Add(storageDeleteTokenTTL)
+	if err := s.sharedTokens.Put(ctx, model.SharedTokenStorageDelete, token, &storageDeleteToken{
+		UserID:    userID,
+		NodeID:    listing.node.Id,
+		ClusterID: listing.cluster.Id,
+		Storage:   req.Storage,
+		Volumes:   result.Volumes,
+	}, expiresAt); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save storage delete token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	result.ConfirmToken = token
+	result.ExpiresAt = expiresAt.Unix()
+	return result, nil
+}
+
+func (s *storageBrowserService) ConfirmDelete(ctx context.Context, userID string, req *v1.ConfirmStorageDeleteRequest) (*v1.ConfirmStorageDeleteResponseData, error) {
+	// 签发令牌后被移出管理员的用户不能继续删除
+	if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "storage.manage"); err != nil {
+		return nil, err
+	}
+	var token storageDeleteToken
+	ok, err := s.sharedTokens.Get(ctx, model.SharedTokenStorageDelete, req.ConfirmToken, &token)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load storage delete token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if !ok || token.UserID != userID {
+		return nil, v1.ErrStorageDeleteTokenInvalid
+	}
+	// 令牌单次使用：并发确认时只有一个请求能取到
+	if ok, err := s.sharedTokens.Consume(ctx, model.SharedTokenStorageDelete, req.ConfirmToken, &token); err != nil || !ok {
+		return nil, v1.ErrStorageDeleteTokenInvalid
+	}
+
+	node, err := s.nodeRepo.GetByID(ctx, token.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", token.NodeID)
+	}
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "storage.bulk_delete",
+		Target:    node.NodeName + "/" + token.Storage,
+		ClusterID: token.ClusterID,
+	}); err != nil {
+		return nil, err
+	}
+	client, _, err := s.clientForCluster(ctx, token.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &v1.ConfirmStorageDeleteResponseData{Results: make([]v1.StorageDeleteResult, 0, len(token.Volumes))}
+	for _, volume := range token.Volumes {
+		item := v1.StorageDeleteResult{VolID: volume.VolID, Size: volume.Size}
+		if err := client.DeleteStorageContent(ctx, node.NodeName, token.Storage, volume.VolID, nil); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to delete storage content", zap.Error(err),
+				zap.String("node", node.NodeName), zap.String("volid", volume.VolID))
+			item.ErrorMessage = err.Error()
+			result.Failed++
+		} else {
+			item.Deleted = true
+			result.Deleted++
+			result.FreedSize += volume.Size
+		}
+		result.Results = append(result.Results, item)
+	}
+	s.logger.WithContext(ctx).Info("storage volumes bulk deleted",
+		zap.String("user_id", userID),
+		zap.String("node", node.NodeName),
+		zap.String("storage", token.Storage),
+		zap.Int("deleted", result.Deleted),
+		zap.Int("failed", result.Failed),
+		zap.Int64("freed_size", result.FreedSize))
+	return result, nil
+}
+
+// listStorage 读取节点存储内容，并根据集群内虚拟机 / 容器配置标注每个卷的引用方和是否为孤儿卷
+func (s *storageBrowserService) listStorage(ctx context.Context, nodeID int64, storage string) (*storageListing, error) {
+	node, err := s.nodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", nodeID)
+	}
+	client, cluster, err := s.clientForCluster(ctx, node.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := client.GetStorageContent(ctx, node.NodeName, storage, "")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get storage content", zap.Error(err),
+			zap.String("node", node.NodeName), zap.String("storage", storage))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	referenced, existingVMIDs, err := collectVolumeReferences(ctx, client)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to collect volume references", zap.Error(err))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+
+	vmsByVMID := make(map[uint32]*model.PveVM)
+	if vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get cluster vms", zap.Error(err))
+	} else {
+		for _, vm := range vms {
+			vmsByVMID[vm.VMID] = vm
+		}
+	}
+
+	listing := &storageListing{node: node, cluster: cluster}
+	for _, content := range contents {
+		volid, _ := content["volid"].(string)
+		if volid == "" {
+			continue
+		}
+		contentType, _ := content["content"].(string)
+		format, _ := content["format"].(string)
+		size, _ := content["size"].(float64)
+		used, _ := content["used"].(float64)
+		ctime, _ := content["ctime"].(float64)
+		protected, _ := content["protected"].(float64)
+		notes, _ := content["notes"].(string)
+		vmid := parseContentVMID(content["vmid"])
+
+		volume := v1.StorageBrowserVolume{
+			VolID:     volid,
+			Name:      storageVolumeName(volid),
+			Content:   contentType,
+			Format:    format,
+			Size:      int64(size),
+			Used:      int64(used),
+			CTime:     int64(ctime),
+			VMID:      vmid,
+			Protected: protected == 1,
+			Notes:     notes,
+		}
+		if ref, ok := referenced[storageGCVolumeKey(volid)]; ok {
+			volume.UsedByVMID = ref.VMID
+			volume.UsedByNode = ref.Node
+			volume.UsedByKey = ref.Key
+		}
+		if isVMDiskContent(contentType) {
+			volume.Orphaned = isOrphanVolume(volid, vmid, referenced, existingVMIDs)
+		}
+		ownerVMID := volume.UsedByVMID
+		if ownerVMID == 0 {
+			ownerVMID = vmid
+		}
+		if vm, ok := vmsByVMID[ownerVMID]; ok {
+			volume.VmId = vm.Id
+			volume.VmName = vm.VmName
+		}
+		listing.volumes = append(listing.volumes, volume)
+	}
+	return listing, nil
+}
+
+func (s *storageBrowserService) clientForCluster(ctx context.Context, clusterID int64) (*proxmox.ProxmoxClient, *model.PveCluster, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	return client, cluster, nil
+}
+
+func newStorageDeleteToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// splitStoragePath 规范化存储浏览路径，去掉首尾和重复的 "/"
+func splitStoragePath(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// storageVolumeName 卷标识中的文件名部分，如 local:iso/debian.iso -> debian.iso
+func storageVolumeName(volid string) string {
+	if _, volPath, ok := strings.Cut(volid, ":"); ok {
+		return path.Base(volPath)
+	}
+	return volid
+}
+
+// isVMDiskContent 虚拟机 / 容器磁盘类内容，可按 VMID 分组并判断是否为孤儿卷
+func isVMDiskContent(content string) bool {
+	return content == "images" || content == "rootdir"
+}
+
+// parseContentVMID 存储内容中的 vmid 可能是数字或字符串
+func parseContentVMID(raw interface{}) uint32 {
+	switch v := raw.(type) {
+	case float64:
+		return uint32(v)
+	case string:
+		if n, err := strconv.ParseUint(v, 10, 32); err == nil {
+			return uint32(n)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// backupArchivePattern vzdump 备份卷名，如 local:backup/vzdump-qemu-100-2026_01_02-03_04_05.vma.zst
+var backupArchivePattern = regexp.MustCompile(`vzdump-qemu-(\d+)-[^/]*\.vma(\.(zst|lzo|gz))?$`)
+
+// defaultCompressionRatios 没有历史备份时按压缩方式估算的压缩比（备份大小 / 磁盘大小），偏保守
+var defaultCompressionRatios = map[string]float64{
+	"zstd": 0.5,
+	"lzo":  0.65,
+	"gzip": 0.55,
+	"none": 1,
+}
+
+// storageForecaster 备份、克隆、恢复前的存储空间预估（storage_forecast），conf 为 nil 时使用默认值
+//
+//	storage_forecast:
+//	  warn_ratio: 0.9     # 预估占用超过可用空间的该比例时告警
+//	  history_samples: 5  # 计算历史压缩比使用的最近备份数
+//	  compression_ratios: # 没有历史备份时按压缩方式使用的压缩比
+//	    zstd: 0.5
+//	    lzo: 0.65
+//	    gzip: 0.55
+type storageForecaster struct {
+	conf *viper.Viper
+}
+
+func (f storageForecaster) warnRatio() float64 {
+	if f.conf != nil {
+		if ratio := f.conf.GetFloat64("storage_forecast.warn_ratio"); ratio > 0 {
+			return ratio
+		}
+	}
+	return 0.9
+}
+
+func (f storageForecaster) historySamples() int {
+	if f.conf != nil {
+		if n := f.conf.GetInt("storage_forecast.history_samples"); n > 0 {
+			return n
+		}
+	}
+	return 5
+}
+
+func (f storageForecaster) defaultRatio(compressor string) float64 {
+	if f.conf != nil {
+		if ratio := f.conf.GetFloat64("storage_forecast.compression_ratios." + compressor); ratio > 0 {
+			return ratio
+		}
+	}
+	if ratio, ok := defaultCompressionRatios[compressor]; ok {
+		return ratio
+	}
+	return 1
+}
+
+// normalizeCompressor 统一压缩方式名称：zstd / lzo / gzip / none
+func normalizeCompressor(compress string) string {
+	switch strings.ToLower(strings.TrimSpace(compress)) {
+	case "zstd", "zst":
+		return "zstd"
+	case "lzo", "1":
+		return "lzo"
+	case "gzip", "gz":
+		return "gzip"
+	}
+	return "none"
+}
+
+// archiveCompressor 按备份文件扩展名判断压缩方式
+func archiveCompressor(volid string) string {
+	switch {
+	case strings.HasSuffix(volid, ".zst"):
+		return "zstd"
+	case strings.HasSuffix(volid, ".lzo"):
+		return "lzo"
+	case strings.HasSuffix(volid, ".gz"):
+		return "gzip"
+	}
+	return "none"
+}
+
+// vmDiskBytes 虚拟机配置中各磁盘大小之和，跳过 CD-ROM、cloud-init 盘和未挂载的槽位
+func vmDiskBytes(config map[string]interface{}) int64 {
+	var total int64
+	for key, value := range config {
+		if !movableDiskPattern.MatchString(key) {
+			continue
+		}
+		spec, _ := value.(string)
+		volume, opts, _ := strings.Cut(spec, ",")
+		if volume == "" || volume == "none" || strings.Contains(volume, "cloudinit") ||
+			strings.Contains(","+opts+",", ",media=cdrom,") {
+			continue
+		}
+		total += parseDiskSize(opts)
+	}
+	return total
+}
+
+// historyRatio 目标存储上该虚拟机最近几次同压缩方式备份的平均压缩比，没有可用的备份时返回 0
+func (f storageForecaster) historyRatio(ctx context.Context, client *proxmox.ProxmoxClient, nodeName, storage string, vmid uint32, compressor string, diskBytes int64) (float64, int) {
+	if diskBytes <= 0 {
+		return 0, 0
+	}
+	content, err := client.GetStorageContent(ctx, nodeName, storage, "backup")
+	if err != nil {
+		return 0, 0
+	}
+	type sample struct {
+		volid string
+		ctime float64
+		size  float64
+	}
+	var samples []sample
+	for _, item := range content {
+		volid, _ := item["volid"].(string)
+		match := backupArchivePattern.FindStringSubmatch(volid)
+		if match == nil || match[1] != strconv.FormatUint(uint64(vmid), 10) || archiveCompressor(volid) != compressor {
+			continue
+		}
+		size, _ := item["size"].(float64)
+		if size <= 0 {
+			continue
+		}
+		ctime, _ := item["ctime"].(float64)
+		samples = append(samples, sample{volid: volid, ctime: ctime, size: size})
+	}
+	// 最近的备份在前：优先按 ctime，没有 ctime 时按卷名中的时间戳
+	sort.Slice(samples, func(i, j int) bool {
+		if samples[i].ctime != samples[j].ctime {
+			return samples[i].ctime > samples[j].ctime
+		}
+		return samples[i].volid > samples[j].volid
+	})
+	if limit := f.historySamples(); len(samples) > limit {
+		samples = samples[:limit]
+	}
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += s.size / float64(diskBytes)
+	}
+	return sum / float64(len(samples)), len(samples)
+}
+
+// backup 预估备份到 storage 需要的空间
+func (f storageForecaster) backup(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, storage, compress string) (*v1.StorageForecastData, error) {
+	config, err := client.GetVMConfig(ctx, nodeName, vmid)
+	if err != nil {
+		return nil, fmt.Errorf("get vm config: %w", err)
+	}
+	data := &v1.StorageForecastData{Operation: "backup", NodeName: nodeName, Storage: storage, SourceBytes: vmDiskBytes(config)}
+	compressor := normalizeCompressor(compress)
+	if ratio, samples := f.historyRatio(ctx, client, nodeName, storage, vmid, compressor, data.SourceBytes); samples > 0 {
+		data.Ratio, data.RatioSource, data.HistorySamples = ratio, v1.CompressionRatioHistory, samples
+	} else {
+		data.Ratio, data.RatioSource = f.defaultRatio(compressor), v1.CompressionRatioDefault
+	}
+	data.RequiredBytes = int64(float64(data.SourceBytes) * data.Ratio)
+	return data, f.evaluate(ctx, client, data)
+}
+
+// clone 预估完整克隆到 targetNode 的 storage 需要的空间
+func (f storageForecaster) clone(ctx context.Context, client *proxmox.ProxmoxClient, sourceNode, targetNode string, vmid uint32, storage string) (*v1.StorageForecastData, error) {
+	config, err := client.GetVMConfig(ctx, sourceNode, vmid)
+	if err != nil {
+		return nil, fmt.Errorf("get vm config: %w", err)
+	}
+	data := &v1.StorageForecastData{Operation: "clone", NodeName: targetNode, Storage: storage, SourceBytes: vmDiskBytes(config), Ratio: 1}
+	data.RequiredBytes = data.SourceBytes
+	return data, f.evaluate(ctx, client, data)
+}
+
+// restore 预估在 nodeName 上把 archive 恢复到 storage 需要的空间：
+// 备份对应的虚拟机仍在该节点上时使用其磁盘总大小，否则按备份大小和压缩方式的默认压缩比推算
+func (f storageForecaster) restore(ctx context.Context, client *proxmox.ProxmoxClient, nodeName, archive, storage string) (*v1.StorageForecastData, error) {
+	archiveStorage, _, ok := strings.Cut(archive, ":")
+	if !ok || archiveStorage == "" {
+		return nil, v1.WithDetailf(v1.ErrBadRequest, "archive %s is not a storage volume", archive)
+	}
+	content, err := client.GetStorageContent(ctx, nodeName, archiveStorage, "backup")
+	if err != nil {
+		return nil, fmt.Errorf("list backups on %s: %w", archiveStorage, err)
+	}
+	data := &v1.StorageForecastData{Operation: "restore", NodeName: nodeName, Storage: storage}
+	for _, item := range content {
+		if volid, _ := item["volid"].(string); volid == archive {
+			size, _ := item["size"].(float64)
+			data.SourceBytes = int64(size)
+			break
+		}
+	}
+	if data.SourceBytes == 0 {
+		return nil, v1.WithDetailf(v1.ErrBadRequest, "backup %s not found on node %s", archive, nodeName)
+	}
+
+	if match := backupArchivePattern.FindStringSubmatch(archive); match != nil {
+		if vmid, err := strconv.ParseUint(match[1], 10, 32); err == nil {
+			if config, err := client.GetVMConfig(ctx, nodeName, uint32(vmid)); err == nil {
+				if diskBytes := vmDiskBytes(config); diskBytes > 0 {
+					data.RequiredBytes = diskBytes
+					data.Ratio, data.RatioSource, data.HistorySamples = float64(data.SourceBytes)/float64(diskBytes), v1.CompressionRatioHistory, 1
+				}
+			}
+		}
+	}
+	if data.RequiredBytes == 0 {
+		data.Ratio, data.RatioSource = f.defaultRatio(archiveCompressor(archive)), v1.CompressionRatioDefault
+		data.RequiredBytes = int64(float64(data.SourceBytes) / data.Ratio)
+	}
+	return data, f.evaluate(ctx, client, data)
+}
+
+// evaluate 读取目标存储的可用空间并给出结论
+func (f storageForecaster) evaluate(ctx context.Context, client *proxmox.ProxmoxClient, data *v1.StorageForecastData) error {
+	storages, err := nodeStoragesByName(ctx, client, data.NodeName)
+	if err != nil {
+		return fmt.Errorf("list storages on %s: %w", data.NodeName, err)
+	}
+	info := storages[data.Storage]
+	if info == nil {
+		return v1.WithDetailf(v1.ErrStorageNotFound, "storage %s on node %s", data.Storage, data.NodeName)
+	}
+	if storageType, _ := info["type"].(string); storageType == "pbs" {
+		data.Verdict = v1.StorageForecastUnknown
+		data.Message = fmt.Sprintf("storage %s is a proxmox backup server, deduplicated usage cannot be forecast", data.Storage)
+		return nil
+	}
+	avail, _ := info["avail"].(float64)
+	data.AvailableBytes = int64(avail)
+
+	required, available := float64(data.RequiredBytes)/(1<<30), float64(data.AvailableBytes)/(1<<30)
+	switch {
+	case data.RequiredBytes > data.AvailableBytes:
+		data.Verdict = v1.StorageForecastInsufficient
+		data.Message = fmt.Sprintf("%s needs about %.1f GiB on storage %s but only %.1f GiB is available",
+			data.Operation, required, data.Storage, available)
+	case float64(data.RequiredBytes) > float64(data.AvailableBytes)*f.warnRatio():
+		data.Verdict = v1.StorageForecastWarning
+		data.Message = fmt.Sprintf("%s needs about %.1f GiB on storage %s, leaving less than %.0f%% of the %.1f GiB available",
+			data.Operation, required, data.Storage, (1-f.warnRatio())*100, available)
+	default:
+		data.Verdict = v1.StorageForecastOK
+		data.Message = fmt.Sprintf("%s needs about %.1f GiB on storage %s, %.1f GiB available",
+			data.Operation, required, data.Storage, available)
+	}
+	return nil
+}
+
+// checkRestore 恢复备份前的空间检查：预估空间不足时返回错误，无法预估时只记录日志。
+// storage 为空时磁盘恢复到备份中记录的原存储，不做检查
+func (f storageForecaster) checkRestore(ctx context.Context, logger *log.Logger, client *proxmox.ProxmoxClient, nodeName, archive, storage string) error {
+	if storage == "" {
+		return nil
+	}
+	forecast, err := f.restore(ctx, client, nodeName, archive, storage)
+	if err != nil {
+		logger.WithContext(ctx).Warn("failed to forecast restore space, skip check",
+			zap.String("archive", archive), zap.String("storage", storage), zap.Error(err))
+		return nil
+	}
+	if forecast.Verdict == v1.StorageForecastInsufficient {
+		return v1.WithDetail(v1.ErrStorageSpaceInsufficient, forecast.Message)
+	}
+	if forecast.Verdict == v1.StorageForecastWarning {
+		logger.WithContext(ctx).Warn("restore target storage is nearly full",
+			zap.String("archive", archive), zap.String("storage", storage), zap.String("forecast", forecast.Message))
+	}
+	return nil
+}
+
+// ForecastStorage 预估备份、克隆或恢复需要的目标存储空间
+func (s *pveVMService) ForecastStorage(ctx context.Context, req *v1.StorageForecastRequest) (*v1.StorageForecastData, error) {
+	forecaster := storageForecaster{conf: s.conf}
+	if req.Operation == "restore" {
+		if req.NodeID == 0 || req.Archive == "" {
+			return nil, v1.WithDetail(v1.ErrBadRequest, "node_id and archive are required for restore")
+		}
+		node, err := s.nodeRepo.GetByID(ctx, req.NodeID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if node == nil {
+			return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
+		}
+		client, err := s.getProxmoxClientForNode(ctx, node.Id)
+		if err != nil {
+			return nil, err
+		}
+		return forecaster.restore(ctx, client, node.NodeName, req.Archive, req.Storage)
+	}
+
+	if req.VmID == 0 {
+		return nil, v1.WithDetailf(v1.ErrBadRequest, "vm_id is required for %s", req.Operation)
+	}
+	vm, err := s.vmRepo.GetByID(ctx, req.VmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.WithDetailf(v1.ErrVMNotFound, "vm_id=%d", req.VmID)
+	}
+	client, node, err := s.getProxmoxClientForVM(ctx, vm.Id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Operation == "backup" {
+		return forecaster.backup(ctx, client, node.NodeName, vm.VMID, req.Storage, req.Compress)
+	}
+	targetNode := node.NodeName
+	if req.NodeID != 0 && req.NodeID != node.Id {
+		target, err := s.nodeRepo.GetByID(ctx, req.NodeID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if target == nil || target.ClusterID != vm.ClusterID {
+			return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
+		}
+		targetNode = target.NodeName
+	}
+	return forecaster.clone(ctx, client, node.NodeName, targetNode, vm.VMID, req.Storage)
+}
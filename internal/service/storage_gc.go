@@ -0,0 +1,642 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 默认 ISO/容器模板陈旧天数
+const defaultStorageGCIsoMaxAgeDays = 90
+
+type StorageGCService interface {
+	CreateScan(ctx context.Context, req *v1.CreateStorageGCScanRequest) (*v1.StorageGCScanItem, error)
+	GetScan(ctx context.Context, id int64) (*v1.StorageGCScanItem, error)
+	ListScans(ctx context.Context, req *v1.ListStorageGCScansRequest) (*v1.ListStorageGCScansResponseData, error)
+	ListItems(ctx context.Context, req *v1.ListStorageGCItemsRequest) (*v1.ListStorageGCItemsResponseData, error)
+	// ReviewItems 审核可回收项，仅管理员可用
+	ReviewItems(ctx context.Context, userID string, req *v1.ReviewStorageGCItemsRequest) error
+	// DeleteItems 删除已审核的可回收项，仅管理员可用
+	DeleteItems(ctx context.Context, userID string, req *v1.DeleteStorageGCItemsRequest) (*v1.DeleteStorageGCItemsResponseData, error)
+}
+
+func NewStorageGCService(
+	service *Service,
+	conf *viper.Viper,
+	userRepo repository.UserRepository,
+	gcRepo repository.StorageGCRepository,
+	clusterRepo repository.PveClusterRepository,
+	storageRepo repository.PveStorageRepository,
+	notificationService NotificationService,
+	logger *log.Logger,
+) StorageGCService {
+	return &storageGCService{
+		conf:                conf,
+		userRepo:            userRepo,
+		gcRepo:              gcRepo,
+		clusterRepo:         clusterRepo,
+		storageRepo:         storageRepo,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+type storageGCService struct {
+	conf        *viper.Viper
+	userRepo    repository.UserRepository
+	gcRepo      repository.StorageGCRepository
+	clusterRepo repository.PveClusterRepository
+	storageRepo repository.PveStorageRepository
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+
+	// 正在扫描的集群，避免同一集群并发扫描
+	runningClusters sync.Map // map[int64]struct{}
+}
+
+// CreateScan 发起扫描任务（异步执行，立即返回任务信息）
+func (s *storageGCService) CreateScan(ctx context.Context, req *v1.CreateStorageGCScanRequest) (*v1.StorageGCScanItem, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.ErrNotFound
+	}
+
+	if _, loaded := s.runningClusters.LoadOrStore(cluster.Id, struct{}{}); loaded {
+		return nil, v1.ErrStorageGCScanRunning
+	}
+
+	scan := &model.StorageGCScan{
+		ClusterID:     cluster.Id,
+		IsoMaxAgeDays: defaultStorageGCIsoMaxAgeDays,
+		Status:        model.StorageGCScanStatusPending,
+	}
+	if req.IsoMaxAgeDays != nil && *req.IsoMaxAgeDays >= 0 {
+		scan.IsoMaxAgeDays = *req.IsoMaxAgeDays
+	}
+	if req.BackupKeepLast != nil && *req.BackupKeepLast >= 0 {
+		scan.BackupKeepLast = *req.BackupKeepLast
+	}
+
+	if err := s.gcRepo.CreateScan(ctx, scan); err != nil {
+		s.runningClusters.Delete(cluster.Id)
+		s.logger.WithContext(ctx).Error("failed to create storage gc scan", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	go s.executeScan(scan.Id, cluster)
+
+	item := toStorageGCScanItem(scan)
+	return &item, nil
+}
+
+func (s *storageGCService) GetScan(ctx context.Context, id int64) (*v1.StorageGCScanItem, error) {
+	scan, err := s.gcRepo.GetScanByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get storage gc scan", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if scan == nil {
+		return nil, v1.ErrNotFound
+	}
+	item := toStorageGCScanItem(scan)
+	return &item, nil
+}
+
+func (s *storageGCService) ListScans(ctx context.Context, req *v1.ListStorageGCScansRequest) (*v1.ListStorageGCScansResponseData, error) {
+	scans, total, err := s.gcRepo.ListScans(ctx, req.Page, req.PageSize, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list storage gc scans", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	items := make([]v1.StorageGCScanItem, 0, len(scans))
+	for _, scan := range scans {
+		items = append(items, toStorageGCScanItem(scan))
+	}
+	return &v1.ListStorageGCScansResponseData{Total: total, List: items}, nil
+}
+
+func (s *storageGCService) ListItems(ctx context.Context, req *v1.ListStorageGCItemsRequest) (*v1.ListStorageGCItemsResponseData, error) {
+	items, total, err := s.gcRepo.ListItems(ctx, req.Page, req.PageSize, req.ScanID, req.Reason, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list storage gc items", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.StorageGCItem, 0, len(items))
+	for _, item := range items {
+		list = append(list, toStorageGCItem(item))
+	}
+	return &v1.ListStorageGCItemsResponseData{Total: total, List: list}, nil
+}
+
+// ReviewItems 审核可回收项：approve 后才允许删除，ignore 的项不会再被删除
+func (s *storageGCService) ReviewItems(ctx context.Context, userID string, req *v1.ReviewStorageGCItemsRequest) error {
+	reviewer, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "storage.manage")
+	if err != nil {
+		return err
+	}
+	status := model.StorageGCItemStatusApproved
+	if req.Action == "ignore" {
+		status = model.StorageGCItemStatusIgnored
+	}
+
+	items, err := s.gcRepo.GetItemsByIDs(ctx, req.ItemIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get storage gc items", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	for _, item := range items {
+		// 已删除的项不可再审核
+		if item.Status == model.StorageGCItemStatusDeleted {
+			continue
+		}
+		if err := s.gcRepo.UpdateItemStatus(ctx, item.Id, status, reviewer, ""); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update storage gc item", zap.Error(err), zap.Int64("item_id", item.Id))
+			return v1.ErrInternalServerError
+		}
+	}
+	return nil
+}
+
+// DeleteItems 删除已确认（approved）的可回收项，删除失败的项保留错误信息可重试
+func (s *storageGCService) DeleteItems(ctx context.Context, userID string, req *v1.DeleteStorageGCItemsRequest) (*v1.DeleteStorageGCItemsResponseData, error) {
+	if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "storage.manage"); err != nil {
+		return nil, err
+	}
+	items, err := s.gcRepo.GetItemsByIDs(ctx, req.ItemIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get storage gc items", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	result := &v1.DeleteStorageGCItemsResponseData{
+		Items:   make([]v1.StorageGCItem, 0, len(items)),
+		Skipped: len(req.ItemIDs) - len(items),
+	}
+	clients := make(map[int64]*proxmox.ProxmoxClient)
+
+	for _, item := range items {
+		if item.Status != model.StorageGCItemStatusApproved && item.Status != model.StorageGCItemStatusFailed {
+			result.Skipped++
+			continue
+		}
+
+		client, ok := clients[item.ClusterID]
+		if !ok {
+			cluster, err := s.clusterRepo.GetByID(ctx, item.ClusterID)
+			if err != nil || cluster == nil {
+				s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err), zap.Int64("cluster_id", item.ClusterID))
+				return nil, v1.ErrInternalServerError
+			}
+			client, err = proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+				return nil, v1.ErrInternalServerError
+			}
+			clients[item.ClusterID] = client
+		}
+
+		// 扫描到删除之间卷可能被重新挂载或被快照引用，删除前重新读取存储内容和虚拟机配置复核
+		reason, err := recheckStorageGCItem(ctx, client, item)
+		switch {
+		case err != nil:
+			s.logger.WithContext(ctx).Warn("failed to recheck storage gc item", zap.Error(err),
+				zap.String("node", item.NodeName), zap.String("volid", item.VolID))
+			item.Status = model.StorageGCItemStatusFailed
+			item.ErrorMessage = err.Error()
+			result.Failed++
+		case reason != "":
+			s.logger.WithContext(ctx).Info("storage gc item skipped on recheck",
+				zap.String("node", item.NodeName), zap.String("volid", item.VolID), zap.String("reason", reason))
+			item.Status = model.StorageGCItemStatusIgnored
+			item.ErrorMessage = reason
+			result.Skipped++
+		default:
+			if err := client.DeleteStorageContent(ctx, item.NodeName, item.StorageName, item.VolID, nil); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to delete storage content", zap.Error(err),
+					zap.String("node", item.NodeName), zap.String("volid", item.VolID))
+				item.Status = model.StorageGCItemStatusFailed
+				item.ErrorMessage = err.Error()
+				result.Failed++
+			} else {
+				s.logger.WithContext(ctx).Info("storage content deleted by gc",
+					zap.String("node", item.NodeName), zap.String("volid", item.VolID), zap.Int64("size", item.Size))
+				item.Status = model.StorageGCItemStatusDeleted
+				item.ErrorMessage = ""
+				result.Deleted++
+			}
+		}
+		if err := s.gcRepo.UpdateItemStatus(ctx, item.Id, item.Status, "", item.ErrorMessage); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update storage gc item", zap.Error(err), zap.Int64("item_id", item.Id))
+		}
+		result.Items = append(result.Items, toStorageGCItem(item))
+	}
+	return result, nil
+}
+
+// recheckStorageGCItem 删除前复核可回收项，返回不能删除的原因（为空表示可以删除）：
+// 卷已不在存储上、备份已被设为保护，或卷重新被虚拟机/容器的当前配置或快照引用
+func recheckStorageGCItem(ctx context.Context, client *proxmox.ProxmoxClient, item *model.StorageGCItem) (string, error) {
+	contents, err := client.GetStorageContent(ctx, item.NodeName, item.StorageName, item.Content)
+	if err != nil {
+		return "", fmt.Errorf("get storage content: %w", err)
+	}
+	var content map[string]interface{}
+	for _, c := range contents {
+		if volid, _ := c["volid"].(string); volid == item.VolID {
+			content = c
+			break
+		}
+	}
+	if content == nil {
+		return "volume no longer exists on storage", nil
+	}
+	if item.Content == "backup" {
+		if protected, _ := content["protected"].(float64); protected == 1 {
+			return "backup is protected", nil
+		}
+		return "", nil
+	}
+
+	// 已知所属虚拟机的磁盘只需复核该虚拟机，其余卷（ISO、容器模板等）复核全部虚拟机/容器
+	referenced, existingVMIDs, err := collectGuestVolumeReferences(ctx, client, item.VMID)
+	if err != nil {
+		return "", err
+	}
+	switch item.Content {
+	case "images", "rootdir":
+		if !isOrphanVolume(item.VolID, item.VMID, referenced, existingVMIDs) {
+			if ref, ok := referenced[storageGCVolumeKey(item.VolID)]; ok {
+				return fmt.Sprintf("volume is referenced by %d (%s)", ref.VMID, ref.Key), nil
+			}
+			return fmt.Sprintf("volume is a state volume of existing guest %d", item.VMID), nil
+		}
+	default:
+		if ref, ok := referenced[storageGCVolumeKey(item.VolID)]; ok {
+			return fmt.Sprintf("volume is referenced by %d (%s)", ref.VMID, ref.Key), nil
+		}
+	}
+	return "", nil
+}
+
+// executeScan 执行扫描（在后台 goroutine 中运行）
+func (s *storageGCService) executeScan(scanID int64, cluster *model.PveCluster) {
+	ctx := context.Background()
+	defer s.runningClusters.Delete(cluster.Id)
+
+	scan, err := s.gcRepo.GetScanByID(ctx, scanID)
+	if err != nil || scan == nil {
+		s.logger.Error("failed to get storage gc scan", zap.Error(err), zap.Int64("scan_id", scanID))
+		return
+	}
+
+	now := time.Now()
+	scan.Status = model.StorageGCScanStatusRunning
+	scan.StartTime = &now
+	if err := s.gcRepo.UpdateScan(ctx, scan); err != nil {
+		s.logger.Error("failed to update storage gc scan", zap.Error(err), zap.Int64("scan_id", scanID))
+		return
+	}
+
+	items, err := s.scanCluster(ctx, scan, cluster)
+	if err == nil {
+		err = s.gcRepo.CreateItems(ctx, items)
+	}
+
+	end := time.Now()
+	scan.EndTime = &end
+	if err != nil {
+		s.logger.Error("storage gc scan failed", zap.Error(err), zap.Int64("scan_id", scanID))
+		scan.Status = model.StorageGCScanStatusFailed
+		scan.ErrorMessage = err.Error()
+	} else {
+		scan.Status = model.StorageGCScanStatusCompleted
+		scan.ItemCount = len(items)
+		for _, item := range items {
+			scan.TotalSize += item.Size
+		}
+		s.logger.Info("storage gc scan completed", zap.Int64("scan_id", scanID),
+			zap.Int("items", scan.ItemCount), zap.Int64("total_size", scan.TotalSize))
+	}
+	if err := s.gcRepo.UpdateScan(ctx, scan); err != nil {
+		s.logger.Error("failed to update storage gc scan", zap.Error(err), zap.Int64("scan_id", scanID))
+	}
+
+	if err != nil || scan.ItemCount == 0 {
+		s.notificationService.Notify(ctx, taskFinishedNotification("storage_gc_scan", scan.Id,
+			fmt.Sprintf("Storage GC scan of cluster %s", cluster.ClusterName), err), scan.Creator)
+		return
+	}
+	// 发现可回收项时需要管理员审核后才能删除
+	s.notificationService.NotifyAdmins(ctx, &model.Notification{
+		Category:   model.NotificationCategoryApproval,
+		Level:      model.NotificationLevelInfo,
+		Event:      "storage_gc_review_pending",
+		Title:      fmt.Sprintf("Storage GC scan of cluster %s found %d items to review", cluster.ClusterName, scan.ItemCount),
+		Content:    fmt.Sprintf("%d bytes reclaimable; approve or ignore the items before deleting", scan.TotalSize),
+		TargetType: "storage_gc_scan",
+		TargetID:   strconv.FormatInt(scan.Id, 10),
+	}, scan.Creator)
+}
+
+// scanCluster 扫描集群内所有存储，返回可回收项
+// 任一虚拟机配置读取失败都会终止扫描，避免把仍在使用的磁盘误判为孤儿磁盘
+func (s *storageGCService) scanCluster(ctx context.Context, scan *model.StorageGCScan, cluster *model.PveCluster) ([]*model.StorageGCItem, error) {
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return nil, fmt.Errorf("create proxmox client: %w", err)
+	}
+
+	// 1. 收集所有虚拟机/容器配置中引用的卷
+	referenced, existingVMIDs, err := collectVolumeReferences(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 遍历存储（共享存储只扫描一次）
+	storages, err := s.storageRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		return nil, fmt.Errorf("list storages: %w", err)
+	}
+
+	var items []*model.StorageGCItem
+	backups := make(map[string][]*model.StorageGCItem) // storage/vmid -> backups
+	scannedShared := make(map[string]bool)
+	staleBefore := time.Now().AddDate(0, 0, -scan.IsoMaxAgeDays).Unix()
+
+	for _, storage := range storages {
+		if storage.Active != 1 || storage.Enabled != 1 {
+			continue
+		}
+		if storage.Shared == 1 {
+			if scannedShared[storage.StorageName] {
+				continue
+			}
+			scannedShared[storage.StorageName] = true
+		}
+
+		contents, err := client.GetStorageContent(ctx, storage.NodeName, storage.StorageName, "")
+		if err != nil {
+			s.logger.Warn("failed to get storage content, skip", zap.Error(err),
+				zap.String("node", storage.NodeName), zap.String("storage", storage.StorageName))
+			continue
+		}
+
+		for _, content := range contents {
+			volid, _ := content["volid"].(string)
+			if volid == "" {
+				continue
+			}
+			if _, ok := referenced[storageGCVolumeKey(volid)]; ok {
+				continue
+			}
+			contentType, _ := content["content"].(string)
+			format, _ := content["format"].(string)
+			size, _ := content["size"].(float64)
+			ctime, _ := content["ctime"].(float64)
+			vmid, _ := content["vmid"].(float64)
+			protected, _ := content["protected"].(float64)
+
+			item := &model.StorageGCItem{
+				ScanID:      scan.Id,
+				ClusterID:   cluster.Id,
+				NodeName:    storage.NodeName,
+				StorageName: storage.StorageName,
+				VolID:       volid,
+				Content:     contentType,
+				Format:      format,
+				Size:        int64(size),
+				VMID:        uint32(vmid),
+				CTime:       int64(ctime),
+				Status:      model.StorageGCItemStatusPending,
+			}
+
+			switch contentType {
+			case "images", "rootdir":
+				if !isOrphanVolume(volid, item.VMID, referenced, existingVMIDs) {
+					continue
+				}
+				item.Reason = model.StorageGCReasonOrphanDisk
+				items = append(items, item)
+			case "iso", "vztmpl":
+				if scan.IsoMaxAgeDays <= 0 || item.CTime == 0 || item.CTime > staleBefore {
+					continue
+				}
+				item.Reason = model.StorageGCReasonStaleISO
+				if contentType == "vztmpl" {
+					item.Reason = model.StorageGCReasonStaleTemplate
+				}
+				items = append(items, item)
+			case "backup":
+				if scan.BackupKeepLast <= 0 || protected == 1 || item.VMID == 0 {
+					continue
+				}
+				key := fmt.Sprintf("%s/%d", storage.StorageName, item.VMID)
+				backups[key] = append(backups[key], item)
+			}
+		}
+	}
+
+	// 3. 每个虚拟机按时间倒序保留最近 N 个备份，其余标记为过期
+	for _, list := range backups {
+		if len(list) <= scan.BackupKeepLast {
+			continue
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].CTime > list[j].CTime })
+		for _, item := range list[scan.BackupKeepLast:] {
+			item.Reason = model.StorageGCReasonOldBackup
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// volumeReference 虚拟机/容器配置对存储卷的引用
+type volumeReference struct {
+	VMID uint32
+	Node string
+	// Key 配置键（scsi0 / unused0 / rootfs 等），快照中的引用为 "配置键@快照名"，链接克隆引用的基础镜像为 "base"
+	Key string
+}
+
+// collectVolumeReferences 读取集群内所有虚拟机/容器的当前配置和快照配置，返回按 storageGCVolumeKey 归一化的卷引用和现存的 VMID。
+// 任一配置读取失败都返回错误，避免把仍在使用的磁盘误判为孤儿磁盘
+func collectVolumeReferences(ctx context.Context, client *proxmox.ProxmoxClient) (map[string]volumeReference, map[uint32]bool, error) {
+	return collectGuestVolumeReferences(ctx, client, 0)
+}
+
+// collectGuestVolumeReferences 同 collectVolumeReferences，vmid 不为 0 时只读取该虚拟机/容器的配置
+func collectGuestVolumeReferences(ctx context.Context, client *proxmox.ProxmoxClient, vmid uint32) (map[string]volumeReference, map[uint32]bool, error) {
+	resources, err := client.GetClusterResources(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get cluster resources: %w", err)
+	}
+
+	referenced := make(map[string]volumeReference)
+	existingVMIDs := make(map[uint32]bool)
+	for _, resource := range resources {
+		resourceType, _ := resource["type"].(string)
+		if resourceType != "qemu" && resourceType != "lxc" {
+			continue
+		}
+		nodeName, _ := resource["node"].(string)
+		vmidFloat, _ := resource["vmid"].(float64)
+		guestID := uint32(vmidFloat)
+		existingVMIDs[guestID] = true
+		if vmid != 0 && guestID != vmid {
+			continue
+		}
+
+		getConfig, getSnapshots, getSnapshotConfig := client.GetVMConfig, client.GetVMSnapshots, client.GetVMSnapshotConfig
+		if resourceType == "lxc" {
+			getConfig, getSnapshots, getSnapshotConfig = client.GetLXCConfig, client.GetLXCSnapshots, client.GetLXCSnapshotConfig
+		}
+		config, err := getConfig(ctx, nodeName, guestID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("get config of guest %d: %w", guestID, err)
+		}
+		addVolumeReferences(referenced, config, guestID, nodeName, "")
+
+		// 快照保存的配置中的卷在快照删除前不能回收（如快照后 detach 的磁盘）
+		snapshots, err := getSnapshots(ctx, nodeName, guestID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("list snapshots of guest %d: %w", guestID, err)
+		}
+		for _, snapshot := range snapshots {
+			name, _ := snapshot["name"].(string)
+			if name == "" || name == "current" {
+				continue
+			}
+			snapConfig, err := getSnapshotConfig(ctx, nodeName, guestID, name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("get config of guest %d snapshot %s: %w", guestID, name, err)
+			}
+			addVolumeReferences(referenced, snapConfig, guestID, nodeName, "@"+name)
+		}
+	}
+	return referenced, existingVMIDs, nil
+}
+
+// addVolumeReferences 将配置中引用的卷加入 referenced，suffix 为快照配置的 "@快照名"；
+// 同一个卷同时被当前配置和快照引用时以当前配置为准
+func addVolumeReferences(referenced map[string]volumeReference, config map[string]interface{}, vmid uint32, nodeName, suffix string) {
+	for key, raw := range config {
+		value, ok := raw.(string)
+		if !ok || !isVolumeConfigKey(key) {
+			continue
+		}
+		head := proxmox.ParseDeviceConfig(value).Head
+		if head == "" {
+			continue
+		}
+		if _, exists := referenced[storageGCVolumeKey(head)]; !exists || suffix == "" {
+			referenced[storageGCVolumeKey(head)] = volumeReference{VMID: vmid, Node: nodeName, Key: key + suffix}
+		}
+		// 链接克隆的卷形如 local:100/base-100-disk-0.qcow2/101/vm-101-disk-0.qcow2，基础镜像同样视为被引用
+		if strings.Contains(head, "/base-") {
+			if storageID, volPath, ok := strings.Cut(head, ":"); ok {
+				parts := strings.Split(volPath, "/")
+				for _, part := range parts {
+					if strings.HasPrefix(part, "base-") {
+						if _, exists := referenced[storageID+":"+part]; !exists {
+							referenced[storageID+":"+part] = volumeReference{VMID: vmid, Node: nodeName, Key: "base"}
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+// isOrphanVolume 判断虚拟机磁盘卷（images / rootdir）是否未被任何配置引用
+func isOrphanVolume(volid string, vmid uint32, referenced map[string]volumeReference, existingVMIDs map[uint32]bool) bool {
+	if _, ok := referenced[storageGCVolumeKey(volid)]; ok {
+		return false
+	}
+	// 虚拟机仍存在时，休眠/快照状态卷只被快照配置引用，不视为孤儿
+	if existingVMIDs[vmid] && strings.Contains(volid, "-state-") {
+		return false
+	}
+	return true
+}
+
+// storageGCVolumeKey 将卷标识归一化为 "存储:文件名"，兼容目录存储中带 vmid 子目录和链接克隆的路径
+func storageGCVolumeKey(volid string) string {
+	storageID, volPath, ok := strings.Cut(volid, ":")
+	if !ok {
+		return volid
+	}
+	return storageID + ":" + path.Base(volPath)
+}
+
+// isVolumeConfigKey 判断虚拟机/容器配置键是否可能引用存储卷
+func isVolumeConfigKey(key string) bool {
+	if proxmox.IsDiskKey(key) {
+		return true
+	}
+	switch key {
+	case "efidisk0", "tpmstate0", "rootfs", "vmstate":
+		return true
+	}
+	return strings.HasPrefix(key, "unused") || strings.HasPrefix(key, "mp")
+}
+
+func toStorageGCScanItem(scan *model.StorageGCScan) v1.StorageGCScanItem {
+	return v1.StorageGCScanItem{
+		Id:             scan.Id,
+		ClusterID:      scan.ClusterID,
+		IsoMaxAgeDays:  scan.IsoMaxAgeDays,
+		BackupKeepLast: scan.BackupKeepLast,
+		Status:         scan.Status,
+		ItemCount:      scan.ItemCount,
+		TotalSize:      scan.TotalSize,
+		StartTime:      scan.StartTime,
+		EndTime:        scan.EndTime,
+		ErrorMessage:   scan.ErrorMessage,
+		CreateTime:     scan.CreateTime,
+	}
+}
+
+func toStorageGCItem(item *model.StorageGCItem) v1.StorageGCItem {
+	return v1.StorageGCItem{
+		Id:           item.Id,
+		ScanID:       item.ScanID,
+		ClusterID:    item.ClusterID,
+		NodeName:     item.NodeName,
+		StorageName:  item.StorageName,
+		VolID:        item.VolID,
+		Content:      item.Content,
+		Format:       item.Format,
+		Size:         item.Size,
+		VMID:         item.VMID,
+		CTime:        item.CTime,
+		Reason:       item.Reason,
+		Status:       item.Status,
+		Reviewer:     item.Reviewer,
+		ErrorMessage: item.ErrorMessage,
+		UpdateTime:   item.UpdateTime,
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 可热更新的配置项
+const (
+	configKeyLogLevel       = "log.log_level"
+	configKeyProxmoxTimeout = "proxmox.timeout"
+	configKeyProxmoxAPILog  = "proxmox.api_log.enabled"
+)
+
+// Proxmox API 超时允许范围
+const (
+	minProxmoxTimeout = time.Second
+	maxProxmoxTimeout = 10 * time.Minute
+)
+
+// configOperatorSystem SIGHUP 等非用户触发的变更记录的操作人
+const configOperatorSystem = "system"
+
+// defaultAdminUser 未配置 security.admin_users 时的管理员用户名（与 migration 创建的默认用户一致）
+const defaultAdminUser = "admin"
+
+type SystemConfigService interface {
+	GetRuntimeConfig(ctx context.Context, userID string) (*v1.RuntimeConfigData, error)
+	UpdateRuntimeConfig(ctx context.Context, userID string, req *v1.UpdateRuntimeConfigRequest) (*v1.UpdateRuntimeConfigResponseData, error)
+	// ReloadFromFile 重新读取配置文件并应用可热更新的配置项；userID 为空表示系统触发（SIGHUP）
+	ReloadFromFile(ctx context.Context, userID string, source string) (*v1.UpdateRuntimeConfigResponseData, error)
+	ListAudits(ctx context.Context, userID string, req *v1.ListConfigAuditRequest) (*v1.ListConfigAuditResponseData, error)
+}
+
+func NewSystemConfigService(
+	service *Service,
+	conf *viper.Viper,
+	auditRepo repository.ConfigAuditRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) SystemConfigService {
+	return &systemConfigService{
+		conf:      conf,
+		auditRepo: auditRepo,
+		userRepo:  userRepo,
+		Service:   service,
+		logger:    logger,
+	}
+}
+
+type systemConfigService struct {
+	// mu 保证同一时间只有一次配置变更（API 与 SIGHUP 可能并发）
+	mu        sync.Mutex
+	conf      *viper.Viper
+	auditRepo repository.ConfigAuditRepository
+	userRepo  repository.UserRepository
+	*Service
+	logger *log.Logger
+}
+
+// runtimeConfig 可热更新配置的快照（直接从各组件读取当前生效值）
+type runtimeConfig struct {
+	logLevel       string
+	proxmoxTimeout time.Duration
+	proxmoxAPILog  bool
+}
+
+func currentRuntimeConfig() runtimeConfig {
+	return runtimeConfig{
+		logLevel:       log.GetLevel(),
+		proxmoxTimeout: proxmox.RequestTimeout(),
+		proxmoxAPILog:  proxmox.RequestLogAll(),
+	}
+}
+
+func (s *systemConfigService) toData(c runtimeConfig) *v1.RuntimeConfigData {
+	return &v1.RuntimeConfigData{
+		LogLevel:       c.logLevel,
+		ProxmoxTimeout: c.proxmoxTimeout.String(),
+		ProxmoxAPILog:  c.proxmoxAPILog,
+		ConfigFile:     s.conf.ConfigFileUsed(),
+	}
+}
+
+func (s *systemConfigService) GetRuntimeConfig(ctx context.Context, userID string) (*v1.RuntimeConfigData, error) {
+	if _, err := s.requireAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.toData(currentRuntimeConfig()), nil
+}
+
+func (s *systemConfigService) UpdateRuntimeConfig(ctx context.Context, userID string, req *v1.UpdateRuntimeConfigRequest) (*v1.UpdateRuntimeConfigResponseData, error) {
+	operator, err := s.requireAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	target := currentRuntimeConfig()
+	if req.LogLevel != nil {
+		target.logLevel = *req.LogLevel
+	}
+	if req.ProxmoxTimeout != nil {
+		d, err := time.ParseDuration(*req.ProxmoxTimeout)
+		if err != nil {
+			return nil, v1.WithDetailf(v1.ErrInvalidConfigValue, "%s: invalid duration %q", configKeyProxmoxTimeout, *req.ProxmoxTimeout)
+		}
+		target.proxmoxTimeout = d
+	}
+	if req.ProxmoxAPILog != nil {
+		target.proxmoxAPILog = *req.ProxmoxAPILog
+	}
+
+	return s.apply(ctx, operator, v1.ConfigSourceAPI, target)
+}
+
+func (s *systemConfigService) ReloadFromFile(ctx context.Context, userID string, source string) (*v1.UpdateRuntimeConfigResponseData, error) {
+	operator := configOperatorSystem
+	if userID != "" {
+		var err error
+		if operator, err = s.requireAdmin(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 读取到独立的 viper 实例，避免修改启动时加载的全局配置
+	fileConf := viper.New()
+	fileConf.SetConfigFile(s.conf.ConfigFileUsed())
+	if err := fileConf.ReadInConfig(); err != nil {
+		s.logger.WithContext(ctx).Error("failed to read config file", zap.String("file", s.conf.ConfigFileUsed()), zap.Error(err))
+		return nil, v1.ErrConfigReloadFailed
+	}
+
+	target := currentRuntimeConfig()
+	if fileConf.IsSet(configKeyLogLevel) {
+		target.logLevel = fileConf.GetString(configKeyLogLevel)
+	}
+	if fileConf.IsSet(configKeyProxmoxTimeout) {
+		target.proxmoxTimeout = fileConf.GetDuration(configKeyProxmoxTimeout)
+	} else {
+		target.proxmoxTimeout = proxmox.DefaultRequestTimeout
+	}
+	target.proxmoxAPILog = fileConf.GetBool(configKeyProxmoxAPILog)
+
+	return s.apply(ctx, operator, source, target)
+}
+
+// apply 校验全部配置项后再统一生效（任一项非法则不做任何修改），并为每个变化的配置项写审计记录
+func (s *systemConfigService) apply(ctx context.Context, operator, source string, target runtimeConfig) (*v1.UpdateRuntimeConfigResponseData, error) {
+	if err := validateRuntimeConfig(target); err != nil {
+		s.logger.WithContext(ctx).Warn("invalid runtime config",
+			zap.String("operator", operator),
+			zap.String("source", source),
+			zap.Error(err))
+		return nil, err
+	}
+
+	current := currentRuntimeConfig()
+	changes := make([]v1.ConfigChange, 0)
+	if target.logLevel != current.logLevel {
+		if err := log.SetLevel(target.logLevel); err != nil {
+			return nil, v1.WithDetailf(v1.ErrInvalidConfigValue, "%s: %v", configKeyLogLevel, err)
+		}
+		changes = append(changes, v1.ConfigChange{Key: configKeyLogLevel, OldValue: current.logLevel, NewValue: target.logLevel})
+	}
+	if target.proxmoxTimeout != current.proxmoxTimeout {
+		proxmox.SetRequestTimeout(target.proxmoxTimeout)
+		changes = append(changes, v1.ConfigChange{Key: configKeyProxmoxTimeout, OldValue: current.proxmoxTimeout.String(), NewValue: target.proxmoxTimeout.String()})
+	}
+	if target.proxmoxAPILog != current.proxmoxAPILog {
+		proxmox.SetRequestLogAll(target.proxmoxAPILog)
+		changes = append(changes, v1.ConfigChange{
+			Key:      configKeyProxmoxAPILog,
+			OldValue: strconv.FormatBool(current.proxmoxAPILog),
+			NewValue: strconv.FormatBool(target.proxmoxAPILog),
+		})
+	}
+
+	if len(changes) > 0 {
+		audits := make([]*model.ConfigAuditLog, 0, len(changes))
+		for _, c := range changes {
+			audits = append(audits, &model.ConfigAuditLog{
+				Operator:  operator,
+				Source:    source,
+				ConfigKey: c.Key,
+				OldValue:  c.OldValue,
+				NewValue:  c.NewValue,
+			})
+		}
+		// 配置已生效，审计写入失败只记录日志，不回滚
+		if err := s.auditRepo.BatchCreate(ctx, audits); err != nil {
+			s.logger.WithContext(ctx).Error("failed to save config audit log", zap.Error(err))
+		}
+		s.logger.WithContext(ctx).Info("runtime config changed",
+			zap.String("operator", operator),
+			zap.String("source", source),
+			zap.Any("changes", changes))
+	}
+
+	return &v1.UpdateRuntimeConfigResponseData{
+		Changes: changes,
+		Current: *s.toData(currentRuntimeConfig()),
+	}, nil
+}
+
+func validateRuntimeConfig(c runtimeConfig) error {
+	if !log.ValidLevel(c.logLevel) {
+		return v1.WithDetailf(v1.ErrInvalidConfigValue, "%s: must be debug/info/warn/error, got %q", configKeyLogLevel, c.logLevel)
+	}
+	if c.proxmoxTimeout < minProxmoxTimeout || c.proxmoxTimeout > maxProxmoxTimeout {
+		return v1.WithDetailf(v1.ErrInvalidConfigValue, "%s: must be between %s and %s, got %s", configKeyProxmoxTimeout, minProxmoxTimeout, maxProxmoxTimeout, c.proxmoxTimeout)
+	}
+	return nil
+}
+
+func (s *systemConfigService) ListAudits(ctx context.Context, userID string, req *v1.ListConfigAuditRequest) (*v1.ListConfigAuditResponseData, error) {
+	if _, err := s.requireAdmin(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	logs, total, err := s.auditRepo.ListWithPagination(ctx, page, pageSize, req.ConfigKey)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list config audit logs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]*v1.ConfigAuditItem, 0, len(logs))
+	for _, l := range logs {
+		list = append(list, &v1.ConfigAuditItem{
+			Id:         l.Id,
+			Operator:   l.Operator,
+			Source:     l.Source,
+			ConfigKey:  l.ConfigKey,
+			OldValue:   l.OldValue,
+			NewValue:   l.NewValue,
+			CreateTime: l.CreateTime,
+		})
+	}
+	return &v1.ListConfigAuditResponseData{Total: total, List: list}, nil
+}
+
+// requireAdmin 校验当前用户是否为管理员（security.admin_users），返回用户名
+func (s *systemConfigService) requireAdmin(ctx context.Context, userID string) (string, error) {
+	return authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "system.config")
+}
+
+// requireAdminUser 校验用户是否在 security.admin_users 中，返回用户名
+func requireAdminUser(ctx context.Context, conf *viper.Viper, userRepo repository.UserRepository, logger *log.Logger, userID string) (string, error) {
+	username, err := lookupUsername(ctx, userRepo, logger, userID)
+	if err != nil {
+		return "", err
+	}
+	if !isAdminUsername(conf, username) {
+		return "", v1.ErrAdminRequired
+	}
+	return username, nil
+}
+
+// lookupUsername 获取登录用户的用户名，未登录或用户不存在时返回 ErrUnauthorized
+func lookupUsername(ctx context.Context, userRepo repository.UserRepository, logger *log.Logger, userID string) (string, error) {
+	if userID == "" {
+		return "", v1.ErrUnauthorized
+	}
+	user, err := userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, v1.ErrNotFound) {
+			return "", v1.ErrUnauthorized
+		}
+		logger.WithContext(ctx).Error("failed to get user", zap.Error(err))
+		return "", v1.ErrInternalServerError
+	}
+	if user == nil {
+		return "", v1.ErrUnauthorized
+	}
+	return user.Username, nil
+}
+
+// adminUsernames 管理员用户名（security.admin_users，未配置时为默认管理员）
+func adminUsernames(conf *viper.Viper) []string {
+	admins := conf.GetStringSlice("security.admin_users")
+	if len(admins) == 0 {
+		admins = []string{defaultAdminUser}
+	}
+	return admins
+}
+
+// isAdminUsername 用户名是否为管理员
+func isAdminUsername(conf *viper.Viper, username string) bool {
+	return slices.Contains(adminUsernames(conf), username)
+}
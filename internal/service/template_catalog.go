@@ -0,0 +1,933 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 模板目录默认参数，可通过 template_catalog.* 调整
+const (
+	defaultCatalogInstallConcurrency = 2
+	defaultCatalogInstallTimeout     = 2 * time.Hour
+	catalogFetchTimeout              = 30 * time.Second
+	maxCatalogIndexSize              = 8 << 20
+)
+
+var (
+	// catalogImageKeyPattern 目录内镜像标识，同时用于生成下载文件名
+	catalogImageKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,99}$`)
+	// catalogFileNameInvalid 文件名 / 虚拟机名中不允许的字符
+	catalogFileNameInvalid = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+	catalogVMNameInvalid   = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+// catalogImageFormats 可通过 import-from 导入的磁盘格式
+var catalogImageFormats = map[string]bool{"qcow2": true, "raw": true, "vmdk": true}
+
+// catalogChecksumAlgorithms Proxmox download-url 支持的校验算法及对应的十六进制长度
+var catalogChecksumAlgorithms = map[string]int{
+	"md5": 32, "sha1": 40, "sha224": 56, "sha256": 64, "sha384": 96, "sha512": 128,
+}
+
+// catalogIndex 远程目录索引
+type catalogIndex struct {
+	Images []catalogIndexImage `json:"images"`
+}
+
+type catalogIndexImage struct {
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	Version           string `json:"version"`
+	Description       string `json:"description"`
+	OSType            string `json:"os_type"`
+	URL               string `json:"url"`
+	Format            string `json:"format"`
+	Checksum          string `json:"checksum"`
+	ChecksumAlgorithm string `json:"checksum_algorithm"`
+	Size              int64  `json:"size"`
+	Cores             int    `json:"cores"`
+	Memory            int    `json:"memory"`
+	CloudInit         bool   `json:"cloud_init"`
+}
+
+type TemplateCatalogService interface {
+	// 目录订阅（仅管理员）
+	CreateCatalog(ctx context.Context, userID string, req *v1.CreateTemplateCatalogRequest) (*v1.TemplateCatalogItem, error)
+	ListCatalogs(ctx context.Context) ([]v1.TemplateCatalogItem, error)
+	DeleteCatalog(ctx context.Context, userID string, id int64) error
+	RefreshCatalog(ctx context.Context, userID string, id int64) (*v1.TemplateCatalogItem, error)
+	// SyncCatalogs 刷新所有启用的目录，供后台定时同步调用
+	SyncCatalogs(ctx context.Context) error
+
+	ListImages(ctx context.Context, req *v1.ListCatalogImagesRequest) ([]v1.CatalogImageItem, error)
+
+	// 安装任务
+	InstallImage(ctx context.Context, req *v1.InstallCatalogImageRequest, creator string) (*v1.CatalogInstallItem, error)
+	GetInstall(ctx context.Context, id int64) (*v1.CatalogInstallItem, error)
+	ListInstalls(ctx context.Context, req *v1.ListCatalogInstallsRequest) (*v1.ListCatalogInstallsResponseData, error)
+}
+
+func NewTemplateCatalogService(
+	service *Service,
+	conf *viper.Viper,
+	catalogRepo repository.TemplateCatalogRepository,
+	templateRepo repository.PveTemplateRepository,
+	uploadRepo repository.TemplateUploadRepository,
+	instanceRepo repository.TemplateInstanceRepository,
+	storageRepo repository.PveStorageRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationService,
+	logger *log.Logger,
+) TemplateCatalogService {
+	concurrency := conf.GetInt("template_catalog.concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultCatalogInstallConcurrency
+	}
+	return &templateCatalogService{
+		conf:                conf,
+		catalogRepo:         catalogRepo,
+		templateRepo:        templateRepo,
+		uploadRepo:          uploadRepo,
+		instanceRepo:        instanceRepo,
+		storageRepo:         storageRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		userRepo:            userRepo,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+		httpClient:          &http.Client{Timeout: catalogFetchTimeout},
+		slots:               make(chan struct{}, concurrency),
+	}
+}
+
+type templateCatalogService struct {
+	conf         *viper.Viper
+	catalogRepo  repository.TemplateCatalogRepository
+	templateRepo repository.PveTemplateRepository
+	uploadRepo   repository.TemplateUploadRepository
+	instanceRepo repository.TemplateInstanceRepository
+	storageRepo  repository.PveStorageRepository
+	clusterRepo  repository.PveClusterRepository
+	nodeRepo     repository.PveNodeRepository
+	userRepo     repository.UserRepository
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+	httpClient          *http.Client // 拉取目录索引
+
+	// 限制同时执行的安装任务数，其余任务保持 pending 排队
+	slots chan struct{}
+}
+
+func (s *templateCatalogService) CreateCatalog(ctx context.Context, userID string, req *v1.CreateTemplateCatalogRequest) (*v1.TemplateCatalogItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "template_catalog.manage")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateCatalogURL(req.URL); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.catalogRepo.GetCatalogByName(ctx, req.Name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template catalog", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return nil, v1.WithDetail(v1.ErrCatalogExists, req.Name)
+	}
+
+	// 订阅前先拉取一次索引，地址或格式有误时直接拒绝
+	images, err := s.fetchCatalog(ctx, req.URL)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("template catalog fetch failed", zap.Error(err), zap.String("url", req.URL))
+		return nil, v1.WithDetail(v1.ErrCatalogFetchFailed, err.Error())
+	}
+
+	now := time.Now()
+	catalog := &model.TemplateCatalog{
+		Name:         req.Name,
+		URL:          req.URL,
+		Description:  req.Description,
+		Enabled:      1,
+		ImageCount:   len(images),
+		LastSyncTime: &now,
+		Creator:      username,
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.catalogRepo.CreateCatalog(ctx, catalog); err != nil {
+			return err
+		}
+		for _, image := range images {
+			image.CatalogID = catalog.Id
+		}
+		return s.catalogRepo.ReplaceImages(ctx, catalog.Id, images)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create template catalog", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("template catalog subscribed",
+		zap.String("name", catalog.Name), zap.String("url", catalog.URL), zap.Int("images", len(images)), zap.String("operator", username))
+	item := toTemplateCatalogItem(catalog)
+	return &item, nil
+}
+
+func (s *templateCatalogService) ListCatalogs(ctx context.Context) ([]v1.TemplateCatalogItem, error) {
+	catalogs, err := s.catalogRepo.ListCatalogs(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list template catalogs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.TemplateCatalogItem, 0, len(catalogs))
+	for _, catalog := range catalogs {
+		list = append(list, toTemplateCatalogItem(catalog))
+	}
+	return list, nil
+}
+
+// DeleteCatalog 取消订阅（已安装的模板不受影响）
+func (s *templateCatalogService) DeleteCatalog(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "template_catalog.manage")
+	if err != nil {
+		return err
+	}
+	catalog, err := s.getCatalog(ctx, id)
+	if err != nil {
+		return err
+	}
+	active, err := s.catalogRepo.CountActiveInstallsByCatalog(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to count catalog installs", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if active > 0 {
+		return v1.WithDetailf(v1.ErrCatalogInUse, "%d task(s)", active)
+	}
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		return s.catalogRepo.DeleteCatalog(ctx, id)
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete template catalog", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("template catalog deleted", zap.Int64("id", id), zap.String("name", catalog.Name), zap.String("operator", username))
+	return nil
+}
+
+// RefreshCatalog 立即重新拉取目录索引
+func (s *templateCatalogService) RefreshCatalog(ctx context.Context, userID string, id int64) (*v1.TemplateCatalogItem, error) {
+	if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "template_catalog.manage"); err != nil {
+		return nil, err
+	}
+	catalog, err := s.getCatalog(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncCatalog(ctx, catalog); err != nil {
+		return nil, v1.WithDetail(v1.ErrCatalogFetchFailed, err.Error())
+	}
+	item := toTemplateCatalogItem(catalog)
+	return &item, nil
+}
+
+func (s *templateCatalogService) SyncCatalogs(ctx context.Context) error {
+	catalogs, err := s.catalogRepo.ListCatalogs(ctx)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, catalog := range catalogs {
+		if catalog.Enabled != 1 {
+			continue
+		}
+		if err := s.syncCatalog(ctx, catalog); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d catalog(s) failed to sync", failed, len(catalogs))
+	}
+	return nil
+}
+
+// syncCatalog 拉取索引并整体替换目录镜像；拉取失败时保留上次的镜像列表，只记录错误
+func (s *templateCatalogService) syncCatalog(ctx context.Context, catalog *model.TemplateCatalog) error {
+	now := time.Now()
+	catalog.LastSyncTime = &now
+
+	images, fetchErr := s.fetchCatalog(ctx, catalog.URL)
+	if fetchErr != nil {
+		s.logger.WithContext(ctx).Warn("template catalog sync failed",
+			zap.String("catalog", catalog.Name), zap.String("url", catalog.URL), zap.Error(fetchErr))
+		catalog.LastSyncError = fetchErr.Error()
+		if err := s.catalogRepo.UpdateCatalog(ctx, catalog); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update template catalog", zap.Error(err))
+		}
+		return fetchErr
+	}
+
+	for _, image := range images {
+		image.CatalogID = catalog.Id
+	}
+	catalog.LastSyncError = ""
+	catalog.ImageCount = len(images)
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.catalogRepo.ReplaceImages(ctx, catalog.Id, images); err != nil {
+			return err
+		}
+		return s.catalogRepo.UpdateCatalog(ctx, catalog)
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save template catalog images", zap.Error(err))
+		return err
+	}
+
+	s.logger.WithContext(ctx).Info("template catalog synced", zap.String("catalog", catalog.Name), zap.Int("images", len(images)))
+	return nil
+}
+
+func (s *templateCatalogService) validateCatalogURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return v1.WithDetail(v1.ErrBadRequest, "invalid catalog url")
+	}
+	// 仅在内网测试等场景下允许明文 HTTP
+	if u.Scheme == "https" || (u.Scheme == "http" && s.conf.GetBool("template_catalog.allow_http")) {
+		return nil
+	}
+	return v1.WithDetail(v1.ErrBadRequest, "catalog url must use https")
+}
+
+// fetchCatalog 拉取并解析目录索引，忽略不合法的镜像条目
+func (s *templateCatalogService) fetchCatalog(ctx context.Context, indexURL string) ([]*model.TemplateCatalogImage, error) {
+	base, err := url.Parse(indexURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("catalog returned status %d", resp.StatusCode)
+	}
+
+	var index catalogIndex
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxCatalogIndexSize)).Decode(&index); err != nil {
+		return nil, fmt.Errorf("decode catalog index: %w", err)
+	}
+
+	images := make([]*model.TemplateCatalogImage, 0, len(index.Images))
+	seen := make(map[string]bool, len(index.Images))
+	for _, entry := range index.Images {
+		image, err := parseCatalogImage(base, entry)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("skip invalid catalog image",
+				zap.String("catalog_url", indexURL), zap.String("image", entry.ID), zap.Error(err))
+			continue
+		}
+		if seen[image.ImageKey] {
+			s.logger.WithContext(ctx).Warn("skip duplicate catalog image", zap.String("catalog_url", indexURL), zap.String("image", entry.ID))
+			continue
+		}
+		seen[image.ImageKey] = true
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func parseCatalogImage(base *url.URL, entry catalogIndexImage) (*model.TemplateCatalogImage, error) {
+	if !catalogImageKeyPattern.MatchString(entry.ID) {
+		return nil, errors.New("invalid id")
+	}
+	if entry.Name == "" {
+		entry.Name = entry.ID
+	}
+
+	ref, err := url.Parse(entry.URL)
+	if err != nil || entry.URL == "" {
+		return nil, errors.New("invalid url")
+	}
+	imageURL := base.ResolveReference(ref)
+	if imageURL.Scheme != "http" && imageURL.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme %q", imageURL.Scheme)
+	}
+
+	format := strings.ToLower(entry.Format)
+	if format == "" {
+		format = strings.TrimPrefix(path.Ext(imageURL.Path), ".")
+	}
+	if !catalogImageFormats[format] {
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	algorithm := strings.ToLower(entry.ChecksumAlgorithm)
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	length, ok := catalogChecksumAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported checksum algorithm %q", algorithm)
+	}
+	checksum := strings.ToLower(entry.Checksum)
+	if _, err := hex.DecodeString(checksum); err != nil || len(checksum) != length {
+		return nil, errors.New("missing or invalid checksum")
+	}
+
+	image := &model.TemplateCatalogImage{
+		ImageKey:          entry.ID,
+		Name:              entry.Name,
+		Version:           entry.Version,
+		Description:       entry.Description,
+		OSType:            entry.OSType,
+		URL:               imageURL.String(),
+		Format:            format,
+		Checksum:          checksum,
+		ChecksumAlgorithm: algorithm,
+		Size:              entry.Size,
+		Cores:             entry.Cores,
+		Memory:            entry.Memory,
+	}
+	if entry.CloudInit {
+		image.CloudInit = 1
+	}
+	return image, nil
+}
+
+func (s *templateCatalogService) getCatalog(ctx context.Context, id int64) (*model.TemplateCatalog, error) {
+	catalog, err := s.catalogRepo.GetCatalogByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template catalog", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if catalog == nil {
+		return nil, v1.ErrCatalogNotFound
+	}
+	return catalog, nil
+}
+
+func (s *templateCatalogService) ListImages(ctx context.Context, req *v1.ListCatalogImagesRequest) ([]v1.CatalogImageItem, error) {
+	catalogs, err := s.catalogRepo.ListCatalogs(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list template catalogs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	enabled := make(map[int64]string, len(catalogs))
+	for _, catalog := range catalogs {
+		if catalog.Enabled == 1 {
+			enabled[catalog.Id] = catalog.Name
+		}
+	}
+
+	images, err := s.catalogRepo.ListImages(ctx, req.CatalogID, req.Keyword, req.OSType)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list catalog images", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.CatalogImageItem, 0, len(images))
+	for _, image := range images {
+		name, ok := enabled[image.CatalogID]
+		if !ok {
+			continue
+		}
+		list = append(list, toCatalogImageItem(image, name))
+	}
+	return list, nil
+}
+
+// InstallImage 安装目录镜像为模板：校验参数后创建任务，下载和创建在后台执行
+func (s *templateCatalogService) InstallImage(ctx context.Context, req *v1.InstallCatalogImageRequest, creator string) (*v1.CatalogInstallItem, error) {
+	image, err := s.catalogRepo.GetImageByID(ctx, req.ImageID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get catalog image", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if image == nil {
+		return nil, v1.ErrCatalogImageNotFound
+	}
+	catalog, err := s.getCatalog(ctx, image.CatalogID)
+	if err != nil {
+		return nil, err
+	}
+	if catalog.Enabled != 1 {
+		return nil, v1.WithDetailf(v1.ErrCatalogNotFound, "catalog %s is disabled", catalog.Name)
+	}
+
+	node, err := s.nodeRepo.GetByID(ctx, req.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", node.ClusterID)
+	}
+	if cluster.IsSchedulable != 1 {
+		return nil, v1.WithDetailf(v1.ErrClusterNotSchedulable, "cluster=%s", cluster.ClusterName)
+	}
+
+	downloadStorage, err := s.getNodeStorage(ctx, req.DownloadStorageID, node, "import")
+	if err != nil {
+		return nil, err
+	}
+	targetStorage, err := s.getNodeStorage(ctx, req.TargetStorageID, node, "images")
+	if err != nil {
+		return nil, err
+	}
+
+	templateName := req.TemplateName
+	if templateName == "" {
+		templateName = strings.TrimSpace(image.Name + " " + image.Version)
+	}
+
+	install := &model.TemplateCatalogInstall{
+		CatalogID:       catalog.Id,
+		ImageID:         image.Id,
+		ImageName:       image.Name,
+		ClusterID:       cluster.Id,
+		NodeID:          node.Id,
+		NodeName:        node.NodeName,
+		DownloadStorage: downloadStorage.StorageName,
+		TargetStorageID: targetStorage.Id,
+		TargetStorage:   targetStorage.StorageName,
+		TemplateName:    templateName,
+		FileName:        catalogFileName(image),
+		Status:          model.TemplateCatalogInstallPending,
+		Creator:         creator,
+	}
+	if err := s.catalogRepo.CreateInstall(ctx, install); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create catalog install task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	go s.execute(install.Id, image, req.Description, req.Bridge)
+
+	s.logger.WithContext(ctx).Info("catalog install task created",
+		zap.Int64("task_id", install.Id), zap.String("image", image.Name), zap.String("node", node.NodeName))
+	item := toCatalogInstallItem(install)
+	return &item, nil
+}
+
+// getNodeStorage 校验存储属于节点所在集群、对节点可见并支持指定内容类型
+func (s *templateCatalogService) getNodeStorage(ctx context.Context, id int64, node *model.PveNode, content string) (*model.PveStorage, error) {
+	storage, err := s.storageRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get storage", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if storage == nil || storage.ClusterID != node.ClusterID {
+		return nil, v1.WithDetailf(v1.ErrStorageNotFound, "storage_id=%d", id)
+	}
+	if storage.Shared != 1 && storage.NodeName != node.NodeName {
+		return nil, v1.WithDetailf(v1.ErrBadRequest, "storage %s is not available on node %s", storage.StorageName, node.NodeName)
+	}
+	if !strings.Contains(storage.Content, content) {
+		return nil, v1.WithDetailf(v1.ErrStorageContentUnsupported, "storage=%s, content=%s, required=%s",
+			storage.StorageName, storage.Content, content)
+	}
+	return storage, nil
+}
+
+// catalogFileName 下载文件名，包含版本以便不同版本共存、同一版本复用
+func catalogFileName(image *model.TemplateCatalogImage) string {
+	name := image.ImageKey
+	if image.Version != "" {
+		name += "-" + image.Version
+	}
+	return catalogFileNameInvalid.ReplaceAllString(name, "_") + "." + image.Format
+}
+
+// catalogVMName 模板虚拟机名称需符合 DNS 名称规则
+func catalogVMName(name string) string {
+	vmName := strings.Trim(catalogVMNameInvalid.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(vmName) > 63 {
+		vmName = strings.TrimRight(vmName[:63], "-")
+	}
+	if vmName == "" {
+		vmName = "template"
+	}
+	return vmName
+}
+
+// execute 执行安装任务
+func (s *templateCatalogService) execute(installID int64, image *model.TemplateCatalogImage, description, bridge string) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	timeout := s.conf.GetDuration("template_catalog.timeout")
+	if timeout <= 0 {
+		timeout = defaultCatalogInstallTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	install, err := s.catalogRepo.GetInstallByID(ctx, installID)
+	if err != nil || install == nil {
+		s.logger.Error("failed to load catalog install task", zap.Int64("task_id", installID), zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	install.Status = model.TemplateCatalogInstallRunning
+	install.StartTime = &now
+	s.saveInstall(ctx, install)
+
+	err = s.runInstall(ctx, install, image, description, bridge)
+
+	// 任务上下文可能已超时，最终状态使用新的上下文保存
+	end := time.Now()
+	install.EndTime = &end
+	if err != nil {
+		s.logger.Error("catalog install failed", zap.Int64("task_id", installID), zap.String("phase", install.Phase), zap.Error(err))
+		install.Status = model.TemplateCatalogInstallFailed
+		install.ErrorMessage = err.Error()
+	} else {
+		install.Status = model.TemplateCatalogInstallCompleted
+		s.logger.Info("catalog install completed", zap.Int64("task_id", installID),
+			zap.String("template", install.TemplateName), zap.Uint32("vmid", install.VMID))
+	}
+	s.saveInstall(context.Background(), install)
+	s.notificationService.Notify(context.Background(), taskFinishedNotification("template_catalog_install", install.Id,
+		fmt.Sprintf("Install of template %s", install.TemplateName), err), install.Creator)
+}
+
+func (s *templateCatalogService) saveInstall(ctx context.Context, install *model.TemplateCatalogInstall) {
+	if err := s.catalogRepo.UpdateInstall(ctx, install); err != nil {
+		s.logger.Error("failed to update catalog install task", zap.Int64("task_id", install.Id), zap.Error(err))
+	}
+}
+
+func (s *templateCatalogService) runInstall(ctx context.Context, install *model.TemplateCatalogInstall, image *model.TemplateCatalogImage, description, bridge string) error {
+	cluster, err := s.clusterRepo.GetByID(ctx, install.ClusterID)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %d not found", install.ClusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return err
+	}
+
+	// 1. 由节点下载镜像并校验校验和；同名文件已存在（之前安装过同一版本）时直接复用
+	install.Phase = model.TemplateCatalogPhaseDownload
+	s.saveInstall(ctx, install)
+	volume := fmt.Sprintf("%s:import/%s", install.DownloadStorage, install.FileName)
+	exists, err := storageHasVolume(ctx, client, install.NodeName, install.DownloadStorage, "import", volume)
+	if err != nil {
+		return fmt.Errorf("list download storage: %w", err)
+	}
+	if !exists {
+		upid, err := client.DownloadURL(ctx, install.NodeName, install.DownloadStorage, &proxmox.DownloadURLRequest{
+			URL:               image.URL,
+			Content:           "import",
+			Filename:          install.FileName,
+			Checksum:          image.Checksum,
+			ChecksumAlgorithm: image.ChecksumAlgorithm,
+			VerifyCertificate: true,
+		})
+		if err != nil {
+			return fmt.Errorf("download: %w", err)
+		}
+		install.UPID = upid
+		s.saveInstall(ctx, install)
+		if err := client.WaitForTask(ctx, install.NodeName, upid, time.Until(deadline(ctx))); err != nil {
+			return fmt.Errorf("download: %w", err)
+		}
+	}
+
+	// 2. 导入磁盘创建虚拟机
+	install.Phase = model.TemplateCatalogPhaseCreate
+	s.saveInstall(ctx, install)
+	vmid, err := client.GetNextFreeVMID(ctx)
+	if err != nil {
+		return fmt.Errorf("get next free vmid: %w", err)
+	}
+	install.VMID = vmid
+	if bridge == "" {
+		bridge = "vmbr0"
+	}
+	params := url.Values{}
+	params.Set("vmid", strconv.FormatUint(uint64(vmid), 10))
+	params.Set("name", catalogVMName(install.TemplateName))
+	params.Set("cores", strconv.Itoa(max(image.Cores, 1)))
+	params.Set("memory", strconv.Itoa(max(image.Memory, 512)))
+	params.Set("scsihw", "virtio-scsi-single")
+	params.Set("scsi0", fmt.Sprintf("%s:0,import-from=%s", install.TargetStorage, volume))
+	params.Set("boot", "order=scsi0")
+	params.Set("net0", "virtio,bridge="+bridge)
+	params.Set("agent", "1")
+	if image.OSType != "" {
+		params.Set("ostype", image.OSType)
+	}
+	if image.CloudInit == 1 {
+		// 云镜像通常只输出串口控制台
+		params.Set("ide2", install.TargetStorage+":cloudinit")
+		params.Set("serial0", "socket")
+		params.Set("vga", "serial0")
+	}
+	if description != "" {
+		params.Set("description", description)
+	}
+	upid, err := client.CreateQemuVM(ctx, install.NodeName, params)
+	if err != nil {
+		return fmt.Errorf("create vm: %w", err)
+	}
+	install.UPID = upid
+	s.saveInstall(ctx, install)
+	if err := client.WaitForTask(ctx, install.NodeName, upid, time.Until(deadline(ctx))); err != nil {
+		return fmt.Errorf("create vm: %w", err)
+	}
+
+	// 3. 转换为模板
+	install.Phase = model.TemplateCatalogPhaseConvert
+	s.saveInstall(ctx, install)
+	if err := client.ConvertToTemplate(ctx, install.NodeName, vmid, ""); err != nil {
+		return fmt.Errorf("convert to template: %w", err)
+	}
+
+	// 4. 登记到模板管理
+	install.Phase = model.TemplateCatalogPhaseRegister
+	s.saveInstall(ctx, install)
+	return s.registerTemplate(ctx, install, image, description, volume)
+}
+
+// registerTemplate 创建模板、导入记录和实例，与从备份导入模板的登记方式一致：
+// 共享存储为所有可见节点创建实例，本地存储只为安装节点创建主实例
+func (s *templateCatalogService) registerTemplate(ctx context.Context, install *model.TemplateCatalogInstall, image *model.TemplateCatalogImage, description, volume string) error {
+	targetStorage, err := s.storageRepo.GetByID(ctx, install.TargetStorageID)
+	if err != nil {
+		return err
+	}
+	if targetStorage == nil {
+		return fmt.Errorf("storage %d not found", install.TargetStorageID)
+	}
+	if description == "" {
+		description = image.Description
+	}
+
+	nodes := []*model.PveNode{{Id: install.NodeID, NodeName: install.NodeName}}
+	if targetStorage.Shared == 1 {
+		storages, err := s.storageRepo.ListByStorageName(ctx, install.ClusterID, targetStorage.StorageName)
+		if err != nil {
+			return err
+		}
+		for _, storage := range storages {
+			if storage.NodeName == install.NodeName {
+				continue
+			}
+			node, err := s.nodeRepo.GetByNodeName(ctx, storage.NodeName, install.ClusterID)
+			if err != nil || node == nil {
+				continue
+			}
+			nodes = append(nodes, node)
+		}
+	}
+
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		template := &model.PveTemplate{
+			TemplateName: install.TemplateName,
+			ClusterID:    install.ClusterID,
+			Description:  description,
+			CreateTime:   time.Now(),
+			UpdateTime:   time.Now(),
+			Creator:      install.Creator,
+		}
+		if err := s.templateRepo.Create(ctx, template); err != nil {
+			return fmt.Errorf("create template: %w", err)
+		}
+
+		upload := &model.TemplateUpload{
+			TemplateID:     template.Id,
+			ClusterID:      install.ClusterID,
+			StorageID:      targetStorage.Id,
+			StorageName:    targetStorage.StorageName,
+			StorageType:    targetStorage.Type,
+			IsShared:       int8(targetStorage.Shared),
+			UploadNodeID:   install.NodeID,
+			UploadNodeName: install.NodeName,
+			FileName:       install.FileName,
+			FilePath:       volume,
+			FileSize:       image.Size,
+			FileFormat:     image.Format,
+			Status:         model.TemplateUploadStatusImported,
+			ImportProgress: 100,
+			Creator:        install.Creator,
+		}
+		if err := s.uploadRepo.Create(ctx, upload); err != nil {
+			return fmt.Errorf("create template upload: %w", err)
+		}
+
+		for i, node := range nodes {
+			instance := &model.TemplateInstance{
+				TemplateID:  template.Id,
+				UploadID:    upload.Id,
+				ClusterID:   install.ClusterID,
+				NodeID:      node.Id,
+				NodeName:    node.NodeName,
+				StorageID:   targetStorage.Id,
+				StorageName: targetStorage.StorageName,
+				IsShared:    int8(targetStorage.Shared),
+				VMID:        install.VMID,
+				Status:      model.TemplateInstanceStatusAvailable,
+				CreateTime:  time.Now(),
+				UpdateTime:  time.Now(),
+			}
+			if i == 0 {
+				instance.IsPrimary = 1
+			}
+			if err := s.instanceRepo.Create(ctx, instance); err != nil {
+				return fmt.Errorf("create template instance: %w", err)
+			}
+		}
+		install.TemplateID = template.Id
+		return nil
+	})
+}
+
+// storageHasVolume 判断存储上是否已存在指定卷
+func storageHasVolume(ctx context.Context, client *proxmox.ProxmoxClient, nodeName, storage, content, volume string) (bool, error) {
+	items, err := client.GetStorageContent(ctx, nodeName, storage, content)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range items {
+		if volid, _ := item["volid"].(string); volid == volume {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *templateCatalogService) GetInstall(ctx context.Context, id int64) (*v1.CatalogInstallItem, error) {
+	install, err := s.catalogRepo.GetInstallByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get catalog install task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if install == nil {
+		return nil, v1.ErrCatalogInstallNotFound
+	}
+	item := toCatalogInstallItem(install)
+	return &item, nil
+}
+
+func (s *templateCatalogService) ListInstalls(ctx context.Context, req *v1.ListCatalogInstallsRequest) (*v1.ListCatalogInstallsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	installs, total, err := s.catalogRepo.ListInstalls(ctx, page, pageSize, req.CatalogID, req.ClusterID, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list catalog install tasks", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.CatalogInstallItem, 0, len(installs))
+	for _, install := range installs {
+		list = append(list, toCatalogInstallItem(install))
+	}
+	return &v1.ListCatalogInstallsResponseData{Total: total, List: list}, nil
+}
+
+func toTemplateCatalogItem(catalog *model.TemplateCatalog) v1.TemplateCatalogItem {
+	return v1.TemplateCatalogItem{
+		Id:            catalog.Id,
+		Name:          catalog.Name,
+		URL:           catalog.URL,
+		Description:   catalog.Description,
+		Enabled:       catalog.Enabled,
+		ImageCount:    catalog.ImageCount,
+		LastSyncTime:  catalog.LastSyncTime,
+		LastSyncError: catalog.LastSyncError,
+		Creator:       catalog.Creator,
+		CreateTime:    catalog.CreateTime,
+	}
+}
+
+func toCatalogImageItem(image *model.TemplateCatalogImage, catalogName string) v1.CatalogImageItem {
+	return v1.CatalogImageItem{
+		Id:                image.Id,
+		CatalogID:         image.CatalogID,
+		CatalogName:       catalogName,
+		ImageKey:          image.ImageKey,
+		Name:              image.Name,
+		Version:           image.Version,
+		Description:       image.Description,
+		OSType:            image.OSType,
+		URL:               image.URL,
+		Format:            image.Format,
+		Checksum:          image.Checksum,
+		ChecksumAlgorithm: image.ChecksumAlgorithm,
+		Size:              image.Size,
+		Cores:             image.Cores,
+		Memory:            image.Memory,
+		CloudInit:         image.CloudInit == 1,
+	}
+}
+
+func toCatalogInstallItem(install *model.TemplateCatalogInstall) v1.CatalogInstallItem {
+	return v1.CatalogInstallItem{
+		Id:              install.Id,
+		CatalogID:       install.CatalogID,
+		ImageID:         install.ImageID,
+		ImageName:       install.ImageName,
+		ClusterID:       install.ClusterID,
+		NodeID:          install.NodeID,
+		NodeName:        install.NodeName,
+		DownloadStorage: install.DownloadStorage,
+		TargetStorage:   install.TargetStorage,
+		TemplateName:    install.TemplateName,
+		FileName:        install.FileName,
+		VMID:            install.VMID,
+		TemplateID:      install.TemplateID,
+		Status:          install.Status,
+		Phase:           install.Phase,
+		ErrorMessage:    install.ErrorMessage,
+		StartTime:       install.StartTime,
+		EndTime:         install.EndTime,
+		Creator:         install.Creator,
+		CreateTime:      install.CreateTime,
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"crypto/md5"
 	"fmt"
+	"io"
 	"net/url"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,6 +24,10 @@ import (
 type TemplateManagementService interface {
 	// 模板导入（基于已有备份文件）
 	ImportTemplateFromBackup(ctx context.Context, req *v1.ImportTemplateRequest) (*v1.ImportTemplateResponseData, error)
+	// 上传备份文件并自动导入为模板
+	UploadAndImportTemplate(ctx context.Context, req *v1.UploadImportTemplateRequest, fileName string, file io.Reader, sizeHint int64) (*v1.ImportTemplateResponseData, error)
+	// 查询模板导入进度
+	GetTemplateImport(ctx context.Context, importID int64) (*v1.TemplateImportProgress, error)
 
 	// 查询模板详情（包含实例）
 	GetTemplateDetailWithInstances(ctx context.Context, templateID int64, includeInstances bool) (*v1.TemplateDetailWithInstances, error)
@@ -33,6 +39,7 @@ type TemplateManagementService interface {
 	GetSyncTask(ctx context.Context, taskID int64) (*v1.SyncTaskDetail, error)
 	ListSyncTasks(ctx context.Context, req *v1.ListSyncTasksRequest) (*v1.ListSyncTasksResponseData, error)
 	RetrySyncTask(ctx context.Context, taskID int64) error
+	CancelSyncTask(ctx context.Context, taskID int64, req *v1.CancelSyncTaskRequest) (*v1.CancelSyncTaskResponseData, error)
 
 	// 实例管理
 	ListTemplateInstances(ctx context.Context, templateID int64) (*v1.ListTemplateInstancesResponseData, error)
@@ -48,6 +55,7 @@ func NewTemplateManagementService(
 	storageRepo repository.PveStorageRepository,
 	nodeRepo repository.PveNodeRepository,
 	clusterRepo repository.PveClusterRepository,
+	jobLeases JobLeaseService,
 	logger *log.Logger,
 ) TemplateManagementService {
 	s := &templateManagementService{
@@ -60,9 +68,11 @@ func NewTemplateManagementService(
 		storageRepo:   storageRepo,
 		nodeRepo:      nodeRepo,
 		clusterRepo:   clusterRepo,
+		jobLeases:     jobLeases,
 		logger:        logger,
 		syncTaskQueue: make(chan int64, 100), // 缓冲队列，最多100个任务
 	}
+	jobLeases.RegisterRecoverer(model.JobTypeTemplateSync, s.recoverSyncTask)
 
 	// 启动任务队列处理器（串行执行）
 	go s.processSyncTaskQueue()
@@ -74,9 +84,44 @@ func NewTemplateManagementService(
 func (s *templateManagementService) processSyncTaskQueue() {
 	for taskID := range s.syncTaskQueue {
 		s.executeSyncTask(context.Background(), taskID)
+		s.jobLeases.Release(context.Background(), model.JobTypeTemplateSync, taskID)
 	}
 }
 
+// acquireSyncTaskLease 入队时登记任务归属当前实例，实例退出后队列中的任务由其他实例接管
+func (s *templateManagementService) acquireSyncTaskLease(ctx context.Context, taskID int64) {
+	if _, err := s.jobLeases.Acquire(ctx, model.JobTypeTemplateSync, taskID); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to acquire sync task lease", zap.Int64("task_id", taskID), zap.Error(err))
+	}
+}
+
+// recoverSyncTask 接管其他实例的同步任务：未开始的任务重新入队；
+// 执行中的任务中途中断，克隆出的虚拟机状态未知，标记为失败，可在清理后重试
+func (s *templateManagementService) recoverSyncTask(ctx context.Context, taskID int64, previousOwner string) {
+	task, err := s.syncTaskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		s.logger.Error("failed to get sync task", zap.Int64("task_id", taskID), zap.Error(err))
+		return
+	}
+	if task != nil && task.Status == model.TemplateSyncTaskStatusPending {
+		s.logger.Info("requeue sync task", zap.Int64("task_id", taskID), zap.String("previous_owner", previousOwner))
+		go func() {
+			s.syncTaskQueue <- taskID
+		}()
+		return
+	}
+	defer s.jobLeases.Release(ctx, model.JobTypeTemplateSync, taskID)
+	if task == nil || (task.Status != model.TemplateSyncTaskStatusSyncing && task.Status != model.TemplateSyncTaskStatusImporting) {
+		return
+	}
+	msg := fmt.Sprintf("interrupted: instance %s stopped while the task was running", previousOwner)
+	if err := s.syncTaskRepo.UpdateStatus(ctx, taskID, model.TemplateSyncTaskStatusFailed, task.Progress, msg); err != nil {
+		s.logger.Error("failed to update sync task status", zap.Int64("task_id", taskID), zap.Error(err))
+		return
+	}
+	s.logger.Warn("sync task interrupted", zap.Int64("task_id", taskID), zap.String("previous_owner", previousOwner))
+}
+
 type templateManagementService struct {
 	*Service
 	templateRepo repository.PveTemplateRepository
@@ -87,12 +132,41 @@ type templateManagementService struct {
 	storageRepo  repository.PveStorageRepository
 	nodeRepo     repository.PveNodeRepository
 	clusterRepo  repository.PveClusterRepository
+	jobLeases    JobLeaseService
 	logger       *log.Logger
 
 	// 同步任务队列：用于串行化执行，避免并发克隆冲突
 	syncTaskQueue chan int64
 	// 模板级别的锁：确保同一模板的同步任务串行执行
 	templateLocks sync.Map // map[int64]*sync.Mutex
+	// 正在执行的同步任务，用于取消
+	runningSyncs sync.Map // map[int64]*syncTaskRun
+}
+
+// syncTaskCancelWait 取消同步任务时等待执行协程退出的最长时间
+const syncTaskCancelWait = 30 * time.Second
+
+// syncTaskRun 正在执行的同步任务，记录当前等待的 Proxmox 任务以便取消时终止
+type syncTaskRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu     sync.Mutex
+	client *proxmox.ProxmoxClient
+	node   string
+	upid   string
+}
+
+func (r *syncTaskRun) track(client *proxmox.ProxmoxClient, node, upid string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.client, r.node, r.upid = client, node, upid
+}
+
+func (r *syncTaskRun) current() (*proxmox.ProxmoxClient, string, string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.client, r.node, r.upid
 }
 
 // ImportTemplateFromBackup 从已有备份文件导入模板
@@ -100,53 +174,26 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 	ctx context.Context,
 	req *v1.ImportTemplateRequest,
 ) (*v1.ImportTemplateResponseData, error) {
-	// 1. 验证备份存储是否存在（存放备份文件的存储，通常是 local）
-	backupStorage, err := s.storageRepo.GetByID(ctx, req.BackupStorageID)
-	if err != nil {
-		s.logger.WithContext(ctx).Error("failed to get backup storage", zap.Error(err))
-		return nil, v1.ErrInternalServerError
-	}
-	if backupStorage == nil {
-		return nil, v1.ErrStorageNotFound
-	}
-
-	// 2. 验证目标存储是否存在（创建 VM 磁盘的存储，必须支持 images）
-	targetStorage, err := s.storageRepo.GetByID(ctx, req.TargetStorageID)
+	// 1. 验证备份存储、目标存储和导入节点
+	backupStorage, targetStorage, importNode, err := s.resolveImportTargets(ctx, req.BackupStorageID, req.TargetStorageID, req.NodeID)
 	if err != nil {
-		s.logger.WithContext(ctx).Error("failed to get target storage", zap.Error(err))
-		return nil, v1.ErrInternalServerError
-	}
-	if targetStorage == nil {
-		return nil, fmt.Errorf("目标存储不存在")
-	}
-
-	// 3. 验证目标存储是否支持 images 内容类型
-	if !strings.Contains(targetStorage.Content, "images") {
-		s.logger.WithContext(ctx).Error("target storage does not support images",
-			zap.String("storage_name", targetStorage.StorageName),
-			zap.String("content", targetStorage.Content))
-		return nil, fmt.Errorf("目标存储 '%s' 不支持 VM 磁盘镜像(images)，当前支持的内容类型：%s。请选择支持 'images' 的存储（如 local-lvm）",
-			targetStorage.StorageName, targetStorage.Content)
+		return nil, err
 	}
 
-	// 4. 防止使用 local 存储作为目标存储
-	if targetStorage.Type == "dir" && targetStorage.StorageName == "local" {
-		s.logger.WithContext(ctx).Error("cannot use local storage as target",
-			zap.String("storage_name", targetStorage.StorageName))
-		return nil, fmt.Errorf("不能使用 'local' 存储作为目标存储，请选择支持 VM 磁盘的存储（如 local-lvm）")
+	fileName := req.BackupFile
+	fileFormat := templateImportFormat(fileName)
+	if fileFormat == "" {
+		return nil, v1.WithDetailf(v1.ErrInvalidParameter, "file=%s, supported=%s",
+			fileName, supportedTemplateImportSuffixes())
 	}
-
-	// 5. 验证导入节点是否存在
-	importNode, err := s.nodeRepo.GetByID(ctx, req.NodeID)
-	if err != nil || importNode == nil {
-		s.logger.WithContext(ctx).Error("failed to get import node", zap.Error(err))
-		return nil, v1.ErrNodeNotFound
+	if err := checkTemplateImportStorage(backupStorage, fileFormat); err != nil {
+		return nil, err
 	}
 
-	// 6. 判断目标存储类型（用于后续同步逻辑）
+	// 2. 判断目标存储类型（用于后续同步逻辑）
 	isShared := targetStorage.Shared == 1
 
-	// 7. 创建模板记录
+	// 3. 创建模板记录
 	template := &model.PveTemplate{
 		TemplateName: req.TemplateName,
 		ClusterID:    req.ClusterID,
@@ -159,14 +206,10 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 		return nil, v1.ErrInternalServerError
 	}
 
-	// 8. 解析备份文件信息
-	fileName := req.BackupFile
-	fileFormat := s.getBackupFileFormat(fileName)
-
-	// 构建备份文件完整路径（从备份存储读取）
+	// 4. 构建备份文件完整路径（从备份存储读取）
 	filePath := s.buildBackupFilePath(backupStorage.StorageName, fileName)
 
-	// 9. 查询备份文件大小
+	// 5. 查询备份文件大小
 	fileSize, err := s.getBackupFileSize(ctx, importNode, backupStorage.StorageName, fileName)
 	if err != nil {
 		s.logger.WithContext(ctx).Warn("failed to get backup file size, using 0",
@@ -175,7 +218,7 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 		fileSize = 0 // 如果查询失败，使用 0
 	}
 
-	// 10. 创建导入记录（记录目标存储信息）
+	// 6. 创建导入记录（记录目标存储信息）
 	upload := &model.TemplateUpload{
 		TemplateID:     template.Id,
 		ClusterID:      req.ClusterID,
@@ -199,8 +242,8 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 		return nil, v1.ErrInternalServerError
 	}
 
-	// 10. 从备份文件导入模板到 PVE（备份文件从 backupStorage 读取，VM 磁盘创建在 targetStorage）
-	vmid, err := s.importTemplateFromBackup(ctx, importNode, backupStorage, targetStorage, filePath, fileName, template.TemplateName)
+	// 7. 从备份文件导入模板到 PVE（备份文件从 backupStorage 读取，VM 磁盘创建在 targetStorage）
+	vmid, err := s.importTemplateFromBackup(ctx, importNode, backupStorage, targetStorage, filePath, fileName, template.TemplateName, &req.TemplateImageOptions, nil)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to import template from backup", zap.Error(err))
 		_ = s.uploadRepo.UpdateStatus(ctx, upload.Id, model.TemplateUploadStatusFailed, 0, err.Error())
@@ -215,11 +258,370 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 	}
 
 	// 9. 根据存储类型创建实例
+	syncTasks, err := s.createImportedInstances(ctx, template, upload, importNode, targetStorage, vmid, req.SyncNodeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 10. 返回响应
+	return &v1.ImportTemplateResponseData{
+		TemplateID:  template.Id,
+		ImportID:    upload.Id,
+		StorageType: targetStorage.Type,
+		IsShared:    isShared,
+		ImportNode: v1.TemplateImportNode{
+			NodeID:   importNode.Id,
+			NodeName: importNode.NodeName,
+		},
+		SyncTasks: syncTasks,
+	}, nil
+}
+
+// 上传并导入模板时统一进度的分段：上传 0-50，恢复 50-95，转换为模板 95-100
+const (
+	uploadImportUploadEnd  = 50
+	uploadImportRestoreEnd = 95
+)
+
+// templateImportFormats 可导入为模板的文件后缀及对应格式：vzdump 备份通过恢复导入，
+// 磁盘镜像和 OVA 通过创建虚拟机并 import-from 导入磁盘
+var templateImportFormats = []struct{ suffix, format string }{
+	{".vma.zst", "vma.zst"},
+	{".vma.lzo", "vma.lzo"},
+	{".vma.gz", "vma.gz"},
+	{".vma", "vma"},
+	{".qcow2", "qcow2"},
+	{".raw", "raw"},
+	{".vmdk", "vmdk"},
+	{".ova", "ova"},
+}
+
+// UploadAndImportTemplate 上传备份文件并自动导入为模板
+// 上传阶段与请求同步进行（文件流式转发到 Proxmox），上传完成后恢复和转换在后台执行，
+// 调用方通过 GetTemplateImport 查询统一进度
+func (s *templateManagementService) UploadAndImportTemplate(
+	ctx context.Context,
+	req *v1.UploadImportTemplateRequest,
+	fileName string,
+	file io.Reader,
+	sizeHint int64,
+) (*v1.ImportTemplateResponseData, error) {
+	fileName = path.Base(fileName)
+	format := templateImportFormat(fileName)
+	if format == "" {
+		return nil, v1.WithDetailf(v1.ErrInvalidParameter, "file=%s, supported=%s",
+			fileName, supportedTemplateImportSuffixes())
+	}
+
+	// 1. 验证备份存储、目标存储和导入节点
+	backupStorage, targetStorage, importNode, err := s.resolveImportTargets(ctx, req.BackupStorageID, req.TargetStorageID, req.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTemplateImportStorage(backupStorage, format); err != nil {
+		return nil, err
+	}
+
+	client, _, err := s.getProxmoxClientForNode(ctx, importNode.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get proxmox client", zap.Error(err))
+		return nil, v1.ErrProxmoxRequestFailed
+	}
+
+	// 2. 创建模板记录和导入记录（状态 uploading）
+	template := &model.PveTemplate{
+		TemplateName: req.TemplateName,
+		ClusterID:    req.ClusterID,
+		Description:  req.Description,
+		CreateTime:   time.Now(),
+		UpdateTime:   time.Now(),
+	}
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create template", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	filePath := s.buildBackupFilePath(backupStorage.StorageName, fileName)
+	upload := &model.TemplateUpload{
+		TemplateID:     template.Id,
+		ClusterID:      req.ClusterID,
+		StorageID:      targetStorage.Id,
+		StorageName:    targetStorage.StorageName,
+		StorageType:    targetStorage.Type,
+		IsShared:       int8(targetStorage.Shared),
+		UploadNodeID:   importNode.Id,
+		UploadNodeName: importNode.NodeName,
+		FileName:       fileName,
+		FilePath:       filePath,
+		FileSize:       req.FileSize,
+		FileFormat:     format,
+		Status:         model.TemplateUploadStatusUploading,
+		ImportProgress: 0,
+		CreateTime:     time.Now(),
+		UpdateTime:     time.Now(),
+	}
+	if err := s.uploadRepo.Create(ctx, upload); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create import record", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	// 3. 流式上传到备份存储，按字节数折算上传阶段进度
+	total := req.FileSize
+	if total <= 0 {
+		total = sizeHint
+	}
+	lastProgress := 0
+	onUpload := func(uploaded int64) {
+		if total <= 0 {
+			return
+		}
+		progress := int(uploaded * uploadImportUploadEnd / total)
+		if progress > uploadImportUploadEnd-1 {
+			progress = uploadImportUploadEnd - 1
+		}
+		if progress <= lastProgress {
+			return
+		}
+		lastProgress = progress
+		if err := s.uploadRepo.UpdateStatus(ctx, upload.Id, model.TemplateUploadStatusUploading, progress, ""); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to update upload progress", zap.Error(err))
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("uploading backup file for template import",
+		zap.Int64("import_id", upload.Id),
+		zap.String("node", importNode.NodeName),
+		zap.String("storage", backupStorage.StorageName),
+		zap.String("file", fileName),
+		zap.Int64("size", total))
+
+	result, err := client.UploadStorageContentStream(ctx, importNode.NodeName, backupStorage.StorageName,
+		templateImportContent(format), fileName, file, req.FileSize, onUpload)
+	if err == nil {
+		// upload 返回的是把临时文件移动到存储的任务，需要等待其完成后文件才可用
+		if upid, ok := result.(string); ok && upid != "" {
+			err = s.waitForTask(ctx, client, importNode.NodeName, upid, 30*time.Minute, nil)
+		}
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to upload backup file",
+			zap.Error(err),
+			zap.Int64("import_id", upload.Id),
+			zap.String("file", fileName))
+		_ = s.uploadRepo.UpdateStatus(context.Background(), upload.Id, model.TemplateUploadStatusFailed, lastProgress, err.Error())
+		return nil, v1.WithDetail(v1.ErrFileUploadFailed, err.Error())
+	}
+
+	// 4. 记录实际文件大小（未传 file_size 时以存储中的文件为准）
+	if upload.FileSize <= 0 {
+		if size, err := s.getBackupFileSize(ctx, importNode, backupStorage.StorageName, fileName); err == nil {
+			upload.FileSize = size
+		}
+	}
+	upload.Status = model.TemplateUploadStatusImporting
+	upload.ImportProgress = uploadImportUploadEnd
+	if err := s.uploadRepo.Update(ctx, upload); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update import status", zap.Error(err))
+	}
+
+	// 5. 后台恢复并转换为模板
+	go s.runUploadImport(template, upload, importNode, backupStorage, targetStorage, &req.TemplateImageOptions, req.SyncNodeIDs)
+
+	return &v1.ImportTemplateResponseData{
+		TemplateID:  template.Id,
+		ImportID:    upload.Id,
+		StorageType: targetStorage.Type,
+		IsShared:    targetStorage.Shared == 1,
+		ImportNode: v1.TemplateImportNode{
+			NodeID:   importNode.Id,
+			NodeName: importNode.NodeName,
+		},
+	}, nil
+}
+
+// runUploadImport 上传完成后恢复备份、转换为模板并创建实例
+func (s *templateManagementService) runUploadImport(
+	template *model.PveTemplate,
+	upload *model.TemplateUpload,
+	importNode *model.PveNode,
+	backupStorage *model.PveStorage,
+	targetStorage *model.PveStorage,
+	opts *v1.TemplateImageOptions,
+	syncNodeIDs []int64,
+) {
+	ctx := context.Background()
+
+	lastProgress := uploadImportUploadEnd
+	onRestore := func(progress int) {
+		unified := uploadImportUploadEnd + progress*(uploadImportRestoreEnd-uploadImportUploadEnd)/100
+		if unified <= lastProgress {
+			return
+		}
+		lastProgress = unified
+		if err := s.uploadRepo.UpdateStatus(ctx, upload.Id, model.TemplateUploadStatusImporting, unified, ""); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to update import progress", zap.Error(err))
+		}
+	}
+
+	vmid, err := s.importTemplateFromBackup(ctx, importNode, backupStorage, targetStorage,
+		upload.FilePath, upload.FileName, template.TemplateName, opts, onRestore)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to import uploaded backup",
+			zap.Error(err),
+			zap.Int64("import_id", upload.Id))
+		_ = s.uploadRepo.UpdateStatus(ctx, upload.Id, model.TemplateUploadStatusFailed, lastProgress, err.Error())
+		return
+	}
+
+	upload.Status = model.TemplateUploadStatusImported
+	upload.ImportProgress = 100
+	if err := s.uploadRepo.Update(ctx, upload); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update import status", zap.Error(err))
+	}
+
+	if _, err := s.createImportedInstances(ctx, template, upload, importNode, targetStorage, vmid, syncNodeIDs); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create template instances",
+			zap.Error(err),
+			zap.Int64("import_id", upload.Id))
+	}
+
+	s.logger.WithContext(ctx).Info("upload-and-import template completed",
+		zap.Int64("import_id", upload.Id),
+		zap.Int64("template_id", template.Id),
+		zap.Uint32("vmid", vmid))
+}
+
+// GetTemplateImport 查询模板导入记录及进度
+func (s *templateManagementService) GetTemplateImport(ctx context.Context, importID int64) (*v1.TemplateImportProgress, error) {
+	upload, err := s.uploadRepo.GetByID(ctx, importID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get import record", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if upload == nil {
+		return nil, v1.ErrNotFound
+	}
+
+	return &v1.TemplateImportProgress{
+		ImportID:     upload.Id,
+		TemplateID:   upload.TemplateID,
+		NodeID:       upload.UploadNodeID,
+		NodeName:     upload.UploadNodeName,
+		StorageName:  upload.StorageName,
+		FileName:     upload.FileName,
+		FilePath:     upload.FilePath,
+		FileSize:     upload.FileSize,
+		Status:       upload.Status,
+		Progress:     upload.ImportProgress,
+		ErrorMessage: upload.ErrorMessage,
+		CreateTime:   upload.CreateTime,
+		UpdateTime:   upload.UpdateTime,
+	}, nil
+}
+
+// templateImportFormat 根据文件后缀返回导入格式，不支持的文件返回空字符串
+func templateImportFormat(fileName string) string {
+	lower := strings.ToLower(fileName)
+	for _, f := range templateImportFormats {
+		if strings.HasSuffix(lower, f.suffix) {
+			return f.format
+		}
+	}
+	return ""
+}
+
+// templateImportContent 返回导入文件所在的存储内容类型：vzdump 备份为 backup，磁盘镜像和 OVA 为 import
+func templateImportContent(format string) string {
+	if strings.HasPrefix(format, "vma") {
+		return "backup"
+	}
+	return "import"
+}
+
+func supportedTemplateImportSuffixes() string {
+	suffixes := make([]string, 0, len(templateImportFormats))
+	for _, f := range templateImportFormats {
+		suffixes = append(suffixes, f.suffix)
+	}
+	return strings.Join(suffixes, ",")
+}
+
+// checkTemplateImportStorage 校验导入文件所在的存储支持对应的内容类型
+func checkTemplateImportStorage(storage *model.PveStorage, format string) error {
+	content := templateImportContent(format)
+	if !strings.Contains(storage.Content, content) {
+		return v1.WithDetailf(v1.ErrInvalidParameter, "storage %s does not support %s content",
+			storage.StorageName, content)
+	}
+	return nil
+}
+
+// resolveImportTargets 校验导入使用的备份存储、目标存储和导入节点
+func (s *templateManagementService) resolveImportTargets(
+	ctx context.Context,
+	backupStorageID, targetStorageID, nodeID int64,
+) (*model.PveStorage, *model.PveStorage, *model.PveNode, error) {
+	// 1. 验证备份存储是否存在（存放备份文件的存储，通常是 local）
+	backupStorage, err := s.storageRepo.GetByID(ctx, backupStorageID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get backup storage", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if backupStorage == nil {
+		return nil, nil, nil, v1.ErrStorageNotFound
+	}
+
+	// 2. 验证目标存储是否存在（创建 VM 磁盘的存储，必须支持 images）
+	targetStorage, err := s.storageRepo.GetByID(ctx, targetStorageID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get target storage", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if targetStorage == nil {
+		return nil, nil, nil, v1.ErrStorageNotFound
+	}
+
+	// 3. 验证目标存储是否支持 images 内容类型
+	if !strings.Contains(targetStorage.Content, "images") {
+		s.logger.WithContext(ctx).Error("target storage does not support images",
+			zap.String("storage_name", targetStorage.StorageName),
+			zap.String("content", targetStorage.Content))
+		return nil, nil, nil, v1.WithDetailf(v1.ErrStorageContentUnsupported, "storage=%s, content=%s",
+			targetStorage.StorageName, targetStorage.Content)
+	}
+
+	// 4. 防止使用 local 存储作为目标存储
+	if targetStorage.Type == "dir" && targetStorage.StorageName == "local" {
+		s.logger.WithContext(ctx).Error("cannot use local storage as target",
+			zap.String("storage_name", targetStorage.StorageName))
+		return nil, nil, nil, v1.WithDetail(v1.ErrStorageContentUnsupported, "storage=local")
+	}
+
+	// 5. 验证导入节点是否存在
+	importNode, err := s.nodeRepo.GetByID(ctx, nodeID)
+	if err != nil || importNode == nil {
+		s.logger.WithContext(ctx).Error("failed to get import node", zap.Error(err))
+		return nil, nil, nil, v1.ErrNodeNotFound
+	}
+
+	return backupStorage, targetStorage, importNode, nil
+}
+
+// createImportedInstances 模板导入完成后根据存储类型创建实例，本地存储时按需创建同步任务
+func (s *templateManagementService) createImportedInstances(
+	ctx context.Context,
+	template *model.PveTemplate,
+	upload *model.TemplateUpload,
+	importNode *model.PveNode,
+	targetStorage *model.PveStorage,
+	vmid uint32,
+	syncNodeIDs []int64,
+) ([]v1.TemplateSyncTaskInfo, error) {
 	var syncTasks []v1.TemplateSyncTaskInfo
 
-	if isShared {
+	if targetStorage.Shared == 1 {
 		// 共享存储：为所有可见节点创建逻辑实例
-		visibleNodes, err := s.getStorageVisibleNodes(ctx, req.ClusterID, targetStorage.StorageName)
+		visibleNodes, err := s.getStorageVisibleNodes(ctx, template.ClusterID, targetStorage.StorageName)
 		if err != nil {
 			s.logger.WithContext(ctx).Error("failed to get visible nodes", zap.Error(err))
 			return nil, v1.ErrInternalServerError
@@ -234,7 +636,7 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 			instance := &model.TemplateInstance{
 				TemplateID:  template.Id,
 				UploadID:    upload.Id,
-				ClusterID:   req.ClusterID,
+				ClusterID:   template.ClusterID,
 				NodeID:      node.Id,
 				NodeName:    node.NodeName,
 				StorageID:   targetStorage.Id,
@@ -257,7 +659,7 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 		instance := &model.TemplateInstance{
 			TemplateID:  template.Id,
 			UploadID:    upload.Id,
-			ClusterID:   req.ClusterID,
+			ClusterID:   template.ClusterID,
 			NodeID:      importNode.Id,
 			NodeName:    importNode.NodeName,
 			StorageID:   targetStorage.Id,
@@ -275,8 +677,9 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 		}
 
 		// 如果指定了同步节点，创建同步任务
-		if len(req.SyncNodeIDs) > 0 {
-			syncTasks, err = s.createSyncTasks(ctx, template, upload, importNode, req.SyncNodeIDs)
+		if len(syncNodeIDs) > 0 {
+			var err error
+			syncTasks, err = s.createSyncTasks(ctx, template, upload, importNode, syncNodeIDs)
 			if err != nil {
 				s.logger.WithContext(ctx).Error("failed to create sync tasks", zap.Error(err))
 				// 不返回错误，允许后续手动同步
@@ -284,48 +687,7 @@ func (s *templateManagementService) ImportTemplateFromBackup(
 		}
 	}
 
-	// 10. 返回响应
-	return &v1.ImportTemplateResponseData{
-		TemplateID:  template.Id,
-		ImportID:    upload.Id,
-		StorageType: targetStorage.Type,
-		IsShared:    isShared,
-		ImportNode: v1.TemplateImportNode{
-			NodeID:   importNode.Id,
-			NodeName: importNode.NodeName,
-		},
-		SyncTasks: syncTasks,
-	}, nil
-}
-
-// getBackupFileFormat 获取备份文件格式
-func (s *templateManagementService) getBackupFileFormat(fileName string) string {
-	// 支持的备份格式：
-	// - vzdump-qemu-100-2024_01_01-00_00_00.vma
-	// - vzdump-qemu-100-2024_01_01-00_00_00.vma.zst
-	// - vzdump-qemu-100-2024_01_01-00_00_00.vma.lzo
-	// - vzdump-qemu-100-2024_01_01-00_00_00.vma.gz
-	if len(fileName) > 4 {
-		// 检查双重扩展名
-		if len(fileName) > 8 {
-			ext := fileName[len(fileName)-8:]
-			if ext == ".vma.zst" || ext == ".vma.lzo" {
-				return ext[1:] // 去掉前导点
-			}
-		}
-		if len(fileName) > 7 {
-			ext := fileName[len(fileName)-7:]
-			if ext == ".vma.gz" {
-				return ext[1:]
-			}
-		}
-		// 检查单扩展名
-		ext := fileName[len(fileName)-4:]
-		if ext == ".vma" {
-			return ext[1:]
-		}
-	}
-	return "vma"
+	return syncTasks, nil
 }
 
 // buildBackupFilePath 构建备份文件完整路径
@@ -333,8 +695,8 @@ func (s *templateManagementService) buildBackupFilePath(storageName, fileName st
 	// PVE 备份文件路径通常为：
 	// 本地存储：/var/lib/vz/dump/文件名
 	// 共享存储：/mnt/pve/{storage_name}/dump/文件名
-	// 这里返回相对路径，实际路径由 PVE API 处理
-	return fmt.Sprintf("%s:backup/%s", storageName, fileName)
+	// 这里返回相对路径，实际路径由 PVE API 处理；磁盘镜像和 OVA 位于 import 目录
+	return fmt.Sprintf("%s:%s/%s", storageName, templateImportContent(templateImportFormat(fileName)), fileName)
 }
 
 // getBackupFileSize 获取备份文件大小
@@ -350,8 +712,9 @@ func (s *templateManagementService) getBackupFileSize(
 		return 0, fmt.Errorf("failed to get proxmox client: %w", err)
 	}
 
-	// 2. 查询存储内容（备份文件）
-	contentList, err := client.GetStorageContent(ctx, node.NodeName, storageName, "backup")
+	// 2. 查询存储内容（备份文件或导入镜像）
+	content := templateImportContent(templateImportFormat(fileName))
+	contentList, err := client.GetStorageContent(ctx, node.NodeName, storageName, content)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get storage content: %w", err)
 	}
@@ -359,7 +722,7 @@ func (s *templateManagementService) getBackupFileSize(
 	// 3. 查找匹配的备份文件
 	// volid 格式通常是：storage:backup/filename
 	// 例如：local:backup/vzdump-qemu-100-2024_01_01-00_00_00.vma.zst
-	expectedVolid := fmt.Sprintf("%s:backup/%s", storageName, fileName)
+	expectedVolid := fmt.Sprintf("%s:%s/%s", storageName, content, fileName)
 
 	for _, item := range contentList {
 		volid, ok := item["volid"].(string)
@@ -425,6 +788,7 @@ func (s *templateManagementService) getStorageVisibleNodes(ctx context.Context,
 // importTemplateFromBackup 从备份文件导入模板到 PVE
 // backupStorage: 备份文件所在的存储
 // targetStorage: VM 磁盘要创建的目标存储
+// 磁盘镜像（qcow2/raw/vmdk）和 OVA 不走恢复流程，而是创建虚拟机并通过 import-from 导入磁盘
 func (s *templateManagementService) importTemplateFromBackup(
 	ctx context.Context,
 	node *model.PveNode,
@@ -433,7 +797,13 @@ func (s *templateManagementService) importTemplateFromBackup(
 	filePath string,
 	fileName string,
 	templateName string,
+	opts *v1.TemplateImageOptions,
+	progressCallback func(progress int),
 ) (uint32, error) {
+	if format := templateImportFormat(fileName); format != "" && templateImportContent(format) == "import" {
+		return s.importTemplateFromImage(ctx, node, backupStorage, targetStorage, filePath, format, templateName, opts, progressCallback)
+	}
+
 	// TODO: 实现从备份导入模板的逻辑
 	//
 	// 根据 Proxmox VE API 文档：https://pve.proxmox.com/pve-docs/api-viewer
@@ -469,6 +839,10 @@ func (s *templateManagementService) importTemplateFromBackup(
 		archivePath = fmt.Sprintf("%s:backup/%s", backupStorage.StorageName, fileName)
 	}
 
+	if err := (storageForecaster{}).checkRestore(ctx, s.logger, client, node.NodeName, archivePath, targetStorage.StorageName); err != nil {
+		return 0, err
+	}
+
 	params := url.Values{}
 	params.Set("vmid", fmt.Sprintf("%d", vmid))
 	params.Set("archive", archivePath) // 从备份存储读取备份文件
@@ -495,7 +869,7 @@ func (s *templateManagementService) importTemplateFromBackup(
 	s.logger.WithContext(ctx).Info("restore task started", zap.String("upid", upid), zap.Uint32("vmid", vmid))
 
 	// 4. 等待恢复任务完成
-	err = s.waitForTask(ctx, client, node.NodeName, upid, 30*time.Minute, nil)
+	err = s.waitForTask(ctx, client, node.NodeName, upid, 30*time.Minute, progressCallback)
 	if err != nil {
 		s.logger.WithContext(ctx).Error("restore task failed", zap.Error(err), zap.String("upid", upid))
 		return 0, fmt.Errorf("restore task failed: %w", err)
@@ -518,6 +892,116 @@ func (s *templateManagementService) importTemplateFromBackup(
 	return vmid, nil
 }
 
+// importTemplateFromImage 以磁盘镜像或 OVA 创建虚拟机并转换为模板
+// 磁盘镜像按 opts 生成硬件配置；OVA 以包内的虚拟机配置为准，opts 中设置的 CPU / 内存会覆盖
+func (s *templateManagementService) importTemplateFromImage(
+	ctx context.Context,
+	node *model.PveNode,
+	sourceStorage *model.PveStorage,
+	targetStorage *model.PveStorage,
+	volume string,
+	format string,
+	templateName string,
+	opts *v1.TemplateImageOptions,
+	progressCallback func(progress int),
+) (uint32, error) {
+	if opts == nil {
+		opts = &v1.TemplateImageOptions{}
+	}
+
+	client, _, err := s.getProxmoxClientForNode(ctx, node.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get proxmox client", zap.Error(err))
+		return 0, fmt.Errorf("failed to get proxmox client: %w", err)
+	}
+
+	vmid, err := client.GetNextFreeVMID(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get next free vmid", zap.Error(err))
+		return 0, fmt.Errorf("failed to get next free vmid: %w", err)
+	}
+
+	params := url.Values{}
+	if format == "ova" {
+		metadata, err := client.GetImportMetadata(ctx, node.NodeName, sourceStorage.StorageName, volume)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read ova metadata: %w", err)
+		}
+		if metadata.Type != "vm" || len(metadata.Disks) == 0 {
+			return 0, fmt.Errorf("ova %s has no importable disks", volume)
+		}
+		for key, value := range buildVMImportParams(metadata, &v1.CreateVMImportRequest{
+			TargetStorage: targetStorage.StorageName,
+			DefaultBridge: opts.Bridge,
+		}, vmid, templateName) {
+			params.Set(key, value)
+		}
+		if opts.OSType != "" {
+			params.Set("ostype", opts.OSType)
+		}
+	} else {
+		bridge := opts.Bridge
+		if bridge == "" {
+			bridge = "vmbr0"
+		}
+		params.Set("vmid", strconv.FormatUint(uint64(vmid), 10))
+		params.Set("name", templateName)
+		params.Set("cores", "1")
+		params.Set("memory", "1024")
+		params.Set("scsihw", "virtio-scsi-single")
+		params.Set("scsi0", fmt.Sprintf("%s:0,import-from=%s", targetStorage.StorageName, volume))
+		params.Set("boot", "order=scsi0")
+		params.Set("net0", "virtio,bridge="+bridge)
+		params.Set("agent", "1")
+		params.Set("ostype", "l26")
+		if opts.OSType != "" {
+			params.Set("ostype", opts.OSType)
+		}
+	}
+	if opts.Cores > 0 {
+		params.Set("cores", strconv.Itoa(opts.Cores))
+	}
+	if opts.Memory > 0 {
+		params.Set("memory", strconv.Itoa(opts.Memory))
+	}
+	if opts.CloudInit {
+		// 云镜像通常只输出串口控制台
+		params.Set("ide2", targetStorage.StorageName+":cloudinit")
+		params.Set("serial0", "socket")
+		params.Set("vga", "serial0")
+	}
+
+	s.logger.WithContext(ctx).Info("creating vm from disk image",
+		zap.String("node", node.NodeName),
+		zap.String("volume", volume),
+		zap.String("format", format),
+		zap.String("target_storage", targetStorage.StorageName),
+		zap.Uint32("vmid", vmid))
+
+	upid, err := client.CreateQemuVM(ctx, node.NodeName, params)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vm from disk image", zap.Error(err))
+		return 0, fmt.Errorf("failed to create vm from disk image: %w", err)
+	}
+	if err := s.waitForTask(ctx, client, node.NodeName, upid, 30*time.Minute, progressCallback); err != nil {
+		s.logger.WithContext(ctx).Error("disk import task failed", zap.Error(err), zap.String("upid", upid))
+		return 0, fmt.Errorf("disk import task failed: %w", err)
+	}
+
+	if err := client.ConvertToTemplate(ctx, node.NodeName, vmid, ""); err != nil {
+		s.logger.WithContext(ctx).Error("failed to convert to template", zap.Error(err), zap.Uint32("vmid", vmid))
+		return 0, fmt.Errorf("failed to convert to template: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("template imported from disk image successfully",
+		zap.String("node", node.NodeName),
+		zap.Uint32("vmid", vmid),
+		zap.String("format", format),
+		zap.String("template_name", templateName))
+
+	return vmid, nil
+}
+
 // createSyncTasks 创建同步任务
 func (s *templateManagementService) createSyncTasks(
 	ctx context.Context,
@@ -628,6 +1112,7 @@ func (s *templateManagementService) createSyncTasks(
 		})
 
 		// 将任务加入队列（串行执行，避免并发克隆冲突）
+		s.acquireSyncTaskLease(ctx, syncTask.Id)
 		select {
 		case s.syncTaskQueue <- syncTask.Id:
 			s.logger.WithContext(ctx).Info("sync task queued",
@@ -902,6 +1387,7 @@ func (s *templateManagementService) RetrySyncTask(ctx context.Context, taskID in
 	}
 
 	// 将任务加入队列（串行执行）
+	s.acquireSyncTaskLease(ctx, taskID)
 	select {
 	case s.syncTaskQueue <- taskID:
 		s.logger.WithContext(ctx).Info("retry task queued",
@@ -918,6 +1404,126 @@ func (s *templateManagementService) RetrySyncTask(ctx context.Context, taskID in
 	return nil
 }
 
+// CancelSyncTask 取消同步任务：终止正在等待的 Proxmox 任务，删除临时克隆的虚拟机，
+// 并将任务和目标实例标记为失败。进程重启后遗留在同步中的任务同样可以取消清理。
+func (s *templateManagementService) CancelSyncTask(ctx context.Context, taskID int64, req *v1.CancelSyncTaskRequest) (*v1.CancelSyncTaskResponseData, error) {
+	task, err := s.syncTaskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get sync task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if task == nil {
+		return nil, v1.ErrNotFound
+	}
+
+	value, running := s.runningSyncs.Load(taskID)
+	if !running && task.Status != model.TemplateSyncTaskStatusPending &&
+		task.Status != model.TemplateSyncTaskStatusSyncing && task.Status != model.TemplateSyncTaskStatusImporting {
+		return nil, v1.WithDetailf(v1.ErrInvalidOperation, "task status is %s", task.Status)
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "cancelled by user"
+	} else {
+		reason = "cancelled: " + reason
+	}
+	data := &v1.CancelSyncTaskResponseData{TaskID: taskID, Status: model.TemplateSyncTaskStatusFailed}
+
+	if running {
+		run := value.(*syncTaskRun)
+		// 先终止 Proxmox 任务，再中断等待，避免执行协程在任务结束前继续下一步
+		if client, node, upid := run.current(); client != nil && upid != "" {
+			if err := client.StopTask(ctx, node, upid); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to stop proxmox task", zap.String("upid", upid), zap.Error(err))
+			}
+			data.StoppedUPID = upid
+		}
+		run.cancel()
+		select {
+		case <-run.done:
+		case <-time.After(syncTaskCancelWait):
+			s.logger.WithContext(ctx).Warn("sync task did not exit in time after cancel", zap.Int64("task_id", taskID))
+		}
+
+		// 执行协程可能已在取消前完成
+		latest, err := s.syncTaskRepo.GetByID(ctx, taskID)
+		if err == nil && latest != nil {
+			if latest.Status == model.TemplateSyncTaskStatusCompleted {
+				return nil, v1.WithDetail(v1.ErrInvalidOperation, "task already completed")
+			}
+			task = latest
+		}
+	}
+
+	// 未开始执行的任务没有临时虚拟机
+	if running || task.Status != model.TemplateSyncTaskStatusPending {
+		node, vmid, err := s.cleanupSyncVM(ctx, task)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to cleanup sync vm", zap.Int64("task_id", taskID), zap.Error(err))
+			reason = fmt.Sprintf("%s (temporary vm cleanup failed: %v)", reason, err)
+		}
+		data.CleanedNode, data.CleanedVMID = node, vmid
+	}
+
+	endTime := time.Now()
+	task.Status = model.TemplateSyncTaskStatusFailed
+	task.ErrorMessage = reason
+	task.SyncEndTime = &endTime
+	if err := s.syncTaskRepo.Update(ctx, task); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update sync task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	instance, err := s.instanceRepo.GetByTemplateAndNode(ctx, task.TemplateID, task.TargetNodeID)
+	if err == nil && instance != nil && instance.Status != model.TemplateInstanceStatusAvailable {
+		instance.Status = model.TemplateInstanceStatusFailed
+		if err := s.instanceRepo.Update(ctx, instance); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update instance", zap.Error(err), zap.Int64("instance_id", instance.Id))
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("sync task cancelled",
+		zap.Int64("task_id", taskID),
+		zap.String("reason", reason),
+		zap.String("stopped_upid", data.StoppedUPID),
+		zap.Uint32("cleaned_vmid", data.CleanedVMID),
+		zap.String("cleaned_node", data.CleanedNode))
+	return data, nil
+}
+
+// cleanupSyncVM 按名称 sync-{template_name}-{task_id} 在集群中查找同步任务的临时虚拟机，
+// 无论它停留在源节点还是已迁移到目标节点都将其删除。找不到时返回空值。
+func (s *templateManagementService) cleanupSyncVM(ctx context.Context, task *model.TemplateSyncTask) (string, uint32, error) {
+	template, err := s.templateRepo.GetByID(ctx, task.TemplateID)
+	if err != nil || template == nil {
+		return "", 0, fmt.Errorf("template %d not found", task.TemplateID)
+	}
+	client, _, err := s.getProxmoxClientForNode(ctx, task.SourceNodeID)
+	if err != nil {
+		return "", 0, err
+	}
+	resources, err := client.GetClusterResourcesByType(ctx, "vm")
+	if err != nil {
+		return "", 0, err
+	}
+
+	syncVMName := fmt.Sprintf("sync-%s-%d", template.TemplateName, task.Id)
+	for _, resource := range resources {
+		name, _ := resource["name"].(string)
+		if name != syncVMName {
+			continue
+		}
+		node, _ := resource["node"].(string)
+		vmidFloat, _ := resource["vmid"].(float64)
+		vmid := uint32(vmidFloat)
+		if err := client.DeleteVM(ctx, node, vmid, true); err != nil {
+			return node, vmid, err
+		}
+		return node, vmid, nil
+	}
+	return "", 0, nil
+}
+
 // ListTemplateInstances 列出模板实例
 func (s *templateManagementService) ListTemplateInstances(
 	ctx context.Context,
@@ -1031,12 +1637,12 @@ func (s *templateManagementService) getProxmoxClientForNode(
 		return nil, nil, v1.ErrInternalServerError
 	}
 	if node == nil {
-		return nil, nil, fmt.Errorf("节点不存在")
+		return nil, nil, v1.ErrNodeNotFound
 	}
 
 	// 2. 获取集群信息
 	if node.ClusterID <= 0 {
-		return nil, nil, fmt.Errorf("节点的集群 ID 无效")
+		return nil, nil, v1.ErrInvalidClusterID
 	}
 	cluster, err := s.clusterRepo.GetByID(ctx, node.ClusterID)
 	if err != nil {
@@ -1044,11 +1650,11 @@ func (s *templateManagementService) getProxmoxClientForNode(
 		return nil, nil, v1.ErrInternalServerError
 	}
 	if cluster == nil {
-		return nil, nil, fmt.Errorf("集群 ID %d 不存在", node.ClusterID)
+		return nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", node.ClusterID)
 	}
 
 	// 3. 创建 Proxmox 客户端
-	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken)
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
 	if err != nil {
 		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
 		return nil, nil, v1.ErrInternalServerError
@@ -1078,6 +1684,17 @@ func (s *templateManagementService) executeSyncTask(ctx context.Context, taskID
 	templateLock.Lock()
 	defer templateLock.Unlock()
 
+	// 登记执行中的任务，取消时通过 ctx 中断等待
+	var cancel context.CancelFunc
+	ctx, cancel = context.WithCancel(ctx)
+	run := &syncTaskRun{cancel: cancel, done: make(chan struct{})}
+	s.runningSyncs.Store(taskID, run)
+	defer func() {
+		cancel()
+		s.runningSyncs.Delete(taskID)
+		close(run.done)
+	}()
+
 	// 再次检查任务状态（可能在等待锁期间被取消或完成）
 	task2, err2 := s.syncTaskRepo.GetByID(ctx, taskID)
 	if err2 != nil {
@@ -1212,6 +1829,8 @@ func (s *templateManagementService) executeSyncTask(ctx context.Context, taskID
 		return
 	}
 
+	run.track(sourceClient, sourceNode.NodeName, cloneUPID)
+
 	// 等待克隆任务完成
 	task.Progress = 20
 	_ = s.syncTaskRepo.Update(ctx, task)
@@ -1260,6 +1879,8 @@ func (s *templateManagementService) executeSyncTask(ctx context.Context, taskID
 		return
 	}
 
+	run.track(sourceClient, sourceNode.NodeName, migrateUPID)
+
 	// 等待迁移任务完成
 	err = s.waitForTask(ctx, sourceClient, sourceNode.NodeName, migrateUPID, 60*time.Minute, func(progress int) {
 		// 迁移进度：50-90%
@@ -0,0 +1,666 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 模板晋级默认参数，可通过 template_promotion.* 调整
+const (
+	defaultPromotionPollInterval = 10 * time.Second
+	defaultPromotionTimeout      = 12 * time.Hour
+)
+
+// defaultPromotionStages 默认的环境顺序，对应 pve_cluster.env
+var defaultPromotionStages = []string{"dev", "staging", "prod"}
+
+// TemplatePromotionService 模板按环境逐级晋级：申请 -> 管理员审批 -> 经对象存储跨集群复制并在目标集群登记为新模板
+type TemplatePromotionService interface {
+	// Request 发起晋级申请，目标集群的环境必须是模板所在集群环境的下一环境
+	Request(ctx context.Context, userID string, req *v1.CreateTemplatePromotionRequest) (*v1.TemplatePromotionItem, error)
+	// Approve 审批通过并开始复制，仅管理员可操作，默认不能审批自己的申请
+	Approve(ctx context.Context, userID string, id int64, req *v1.ReviewTemplatePromotionRequest) (*v1.TemplatePromotionItem, error)
+	// Reject 驳回申请，仅管理员可操作
+	Reject(ctx context.Context, userID string, id int64, req *v1.ReviewTemplatePromotionRequest) (*v1.TemplatePromotionItem, error)
+	Get(ctx context.Context, id int64) (*v1.TemplatePromotionItem, error)
+	List(ctx context.Context, req *v1.ListTemplatePromotionsRequest) (*v1.ListTemplatePromotionsResponseData, error)
+	// Chain 返回从最初的晋级到该记录的完整晋级链
+	Chain(ctx context.Context, id int64) ([]v1.TemplatePromotionItem, error)
+}
+
+func NewTemplatePromotionService(
+	service *Service,
+	conf *viper.Viper,
+	promotionRepo repository.TemplatePromotionRepository,
+	templateRepo repository.PveTemplateRepository,
+	uploadRepo repository.TemplateUploadRepository,
+	instanceRepo repository.TemplateInstanceRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	storageRepo repository.PveStorageRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	transferService ImageTransferService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) TemplatePromotionService {
+	return &templatePromotionService{
+		Service:             service,
+		conf:                conf,
+		promotionRepo:       promotionRepo,
+		templateRepo:        templateRepo,
+		uploadRepo:          uploadRepo,
+		instanceRepo:        instanceRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		storageRepo:         storageRepo,
+		vmRepo:              vmRepo,
+		userRepo:            userRepo,
+		transferService:     transferService,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+type templatePromotionService struct {
+	*Service
+	conf                *viper.Viper
+	promotionRepo       repository.TemplatePromotionRepository
+	templateRepo        repository.PveTemplateRepository
+	uploadRepo          repository.TemplateUploadRepository
+	instanceRepo        repository.TemplateInstanceRepository
+	clusterRepo         repository.PveClusterRepository
+	nodeRepo            repository.PveNodeRepository
+	storageRepo         repository.PveStorageRepository
+	vmRepo              repository.PveVMRepository
+	userRepo            repository.UserRepository
+	transferService     ImageTransferService
+	notificationService NotificationService
+	logger              *log.Logger
+}
+
+func (s *templatePromotionService) stages() []string {
+	stages := s.conf.GetStringSlice("template_promotion.stages")
+	if len(stages) < 2 {
+		return defaultPromotionStages
+	}
+	return stages
+}
+
+// stageIndex 返回集群环境在晋级顺序中的位置，不在顺序中返回 -1
+func (s *templatePromotionService) stageIndex(env string) int {
+	for i, stage := range s.stages() {
+		if strings.EqualFold(stage, env) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *templatePromotionService) getCluster(ctx context.Context, id int64) (*model.PveCluster, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", id)
+	}
+	return cluster, nil
+}
+
+func (s *templatePromotionService) Request(ctx context.Context, userID string, req *v1.CreateTemplatePromotionRequest) (*v1.TemplatePromotionItem, error) {
+	if userID == "" {
+		return nil, v1.ErrUnauthorized
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, v1.ErrUnauthorized
+	}
+
+	template, err := s.templateRepo.GetByID(ctx, req.TemplateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if template == nil {
+		return nil, v1.ErrTemplateNotFound
+	}
+	source, err := s.getCluster(ctx, template.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.getCluster(ctx, req.TargetClusterID)
+	if err != nil {
+		return nil, err
+	}
+	from, to := s.stageIndex(source.Env), s.stageIndex(target.Env)
+	if from < 0 || to != from+1 {
+		return nil, v1.WithDetailf(v1.ErrTemplatePromotionStage, "%s (env=%q) -> %s (env=%q), stages: %s",
+			source.ClusterName, source.Env, target.ClusterName, target.Env, strings.Join(s.stages(), " -> "))
+	}
+
+	// 第一环境之后的模板必须由晋级产生，保证来源可追溯
+	var parent *model.TemplatePromotion
+	if from > 0 {
+		parent, err = s.promotionRepo.GetByPromotedTemplate(ctx, template.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get parent promotion", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if parent == nil {
+			return nil, v1.WithDetailf(v1.ErrTemplatePromotionProvenance, "template %d in %s", template.Id, source.ClusterName)
+		}
+	}
+
+	instance, err := s.instanceRepo.GetPrimaryInstance(ctx, template.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template instance", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if instance == nil || instance.Status != model.TemplateInstanceStatusAvailable {
+		return nil, v1.ErrTemplateNoInstance
+	}
+
+	node, err := s.nodeRepo.GetByID(ctx, req.TargetNodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil || node.ClusterID != target.Id {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d in cluster %s", req.TargetNodeID, target.ClusterName)
+	}
+
+	active, err := s.promotionRepo.GetActive(ctx, template.Id, target.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to check active promotion", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if active != nil {
+		return nil, v1.WithDetailf(v1.ErrTemplatePromotionInProgress, "promotion %d is %s", active.Id, active.Status)
+	}
+
+	promotion := &model.TemplatePromotion{
+		OriginTemplateID:     template.Id,
+		TemplateID:           template.Id,
+		TemplateName:         template.TemplateName,
+		SourceClusterID:      source.Id,
+		SourceStage:          source.Env,
+		TargetClusterID:      target.Id,
+		TargetStage:          target.Env,
+		TargetNodeID:         node.Id,
+		TargetNodeName:       node.NodeName,
+		TargetStorage:        req.TargetStorage,
+		StoreID:              req.StoreID,
+		StagingStorage:       req.StagingStorage,
+		TargetStagingStorage: req.TargetStagingStorage,
+		Status:               model.TemplatePromotionStatusPending,
+		Reason:               req.Reason,
+		Requester:            user.Username,
+	}
+	if promotion.TargetStagingStorage == "" {
+		promotion.TargetStagingStorage = req.StagingStorage
+	}
+	if parent != nil {
+		promotion.ParentID = parent.Id
+		promotion.OriginTemplateID = parent.OriginTemplateID
+	}
+	if err := s.promotionRepo.Create(ctx, promotion); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create template promotion", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.notificationService.NotifyAdmins(ctx, &model.Notification{
+		Category:   model.NotificationCategoryApproval,
+		Level:      model.NotificationLevelInfo,
+		Event:      "template_promotion_pending",
+		Title:      fmt.Sprintf("Template %s is waiting for promotion to %s", template.TemplateName, target.Env),
+		Content:    fmt.Sprintf("%s requested promotion from %s to %s: %s", user.Username, source.ClusterName, target.ClusterName, req.Reason),
+		TargetType: "template_promotion",
+		TargetID:   strconv.FormatInt(promotion.Id, 10),
+	})
+	s.logger.WithContext(ctx).Info("template promotion requested",
+		zap.Int64("promotion_id", promotion.Id), zap.Int64("template_id", template.Id),
+		zap.String("from", source.Env), zap.String("to", target.Env), zap.String("requester", user.Username))
+	item := toTemplatePromotionItem(promotion)
+	return &item, nil
+}
+
+// review 取出待审批的记录并记录审批人
+func (s *templatePromotionService) review(ctx context.Context, userID string, id int64, req *v1.ReviewTemplatePromotionRequest) (*model.TemplatePromotion, error) {
+	reviewer, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	promotion, err := s.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template promotion", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if promotion == nil {
+		return nil, v1.ErrTemplatePromotionNotFound
+	}
+	if promotion.Status != model.TemplatePromotionStatusPending {
+		return nil, v1.WithDetailf(v1.ErrTemplatePromotionNotPending, "status=%s", promotion.Status)
+	}
+	now := time.Now()
+	promotion.Reviewer = reviewer
+	promotion.ReviewComment = req.Comment
+	promotion.ReviewTime = &now
+	return promotion, nil
+}
+
+func (s *templatePromotionService) Approve(ctx context.Context, userID string, id int64, req *v1.ReviewTemplatePromotionRequest) (*v1.TemplatePromotionItem, error) {
+	promotion, err := s.review(ctx, userID, id, req)
+	if err != nil {
+		return nil, err
+	}
+	if promotion.Reviewer == promotion.Requester && !s.conf.GetBool("template_promotion.allow_self_approval") {
+		return nil, v1.ErrTemplatePromotionSelfApprove
+	}
+	promotion.Status = model.TemplatePromotionStatusReplicating
+	promotion.StartTime = promotion.ReviewTime
+	if err := s.promotionRepo.Update(ctx, promotion); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update template promotion", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	go s.execute(promotion.Id)
+
+	s.logger.WithContext(ctx).Info("template promotion approved",
+		zap.Int64("promotion_id", promotion.Id), zap.String("reviewer", promotion.Reviewer))
+	item := toTemplatePromotionItem(promotion)
+	return &item, nil
+}
+
+func (s *templatePromotionService) Reject(ctx context.Context, userID string, id int64, req *v1.ReviewTemplatePromotionRequest) (*v1.TemplatePromotionItem, error) {
+	promotion, err := s.review(ctx, userID, id, req)
+	if err != nil {
+		return nil, err
+	}
+	promotion.Status = model.TemplatePromotionStatusRejected
+	promotion.EndTime = promotion.ReviewTime
+	if err := s.promotionRepo.Update(ctx, promotion); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update template promotion", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.notificationService.Notify(ctx, &model.Notification{
+		Category:   model.NotificationCategoryApproval,
+		Level:      model.NotificationLevelWarning,
+		Event:      "template_promotion_rejected",
+		Title:      fmt.Sprintf("Promotion of template %s to %s was rejected", promotion.TemplateName, promotion.TargetStage),
+		Content:    req.Comment,
+		TargetType: "template_promotion",
+		TargetID:   strconv.FormatInt(promotion.Id, 10),
+	}, promotion.Requester)
+	s.logger.WithContext(ctx).Info("template promotion rejected",
+		zap.Int64("promotion_id", promotion.Id), zap.String("reviewer", promotion.Reviewer))
+	item := toTemplatePromotionItem(promotion)
+	return &item, nil
+}
+
+// execute 执行跨集群复制：导出 -> 导入 -> 转换并登记模板
+func (s *templatePromotionService) execute(id int64) {
+	timeout := s.conf.GetDuration("template_promotion.timeout")
+	if timeout <= 0 {
+		timeout = defaultPromotionTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	promotion, err := s.promotionRepo.GetByID(ctx, id)
+	if err != nil || promotion == nil {
+		s.logger.Error("failed to load template promotion", zap.Int64("promotion_id", id), zap.Error(err))
+		return
+	}
+
+	err = s.replicate(ctx, promotion)
+
+	end := time.Now()
+	promotion.EndTime = &end
+	if err != nil {
+		s.logger.Error("template promotion failed", zap.Int64("promotion_id", id), zap.String("phase", promotion.Phase), zap.Error(err))
+		promotion.Status = model.TemplatePromotionStatusFailed
+		promotion.ErrorMessage = err.Error()
+	} else {
+		promotion.Status = model.TemplatePromotionStatusCompleted
+		s.logger.Info("template promotion completed", zap.Int64("promotion_id", id),
+			zap.Int64("template_id", promotion.PromotedTemplateID), zap.String("checksum", promotion.Checksum))
+	}
+	s.save(context.Background(), promotion)
+	s.notificationService.Notify(context.Background(), taskFinishedNotification("template_promotion", promotion.Id,
+		fmt.Sprintf("Promotion of template %s to %s", promotion.TemplateName, promotion.TargetStage), err),
+		promotion.Requester, promotion.Reviewer)
+}
+
+func (s *templatePromotionService) save(ctx context.Context, promotion *model.TemplatePromotion) {
+	if err := s.promotionRepo.Update(ctx, promotion); err != nil {
+		s.logger.Error("failed to update template promotion", zap.Int64("promotion_id", promotion.Id), zap.Error(err))
+	}
+}
+
+func (s *templatePromotionService) replicate(ctx context.Context, promotion *model.TemplatePromotion) error {
+	// 1. 导出源模板（审批期间主实例可能变化，执行时重新查询）
+	promotion.Phase = model.TemplatePromotionPhaseExport
+	s.save(ctx, promotion)
+	instance, err := s.instanceRepo.GetPrimaryInstance(ctx, promotion.TemplateID)
+	if err != nil {
+		return err
+	}
+	if instance == nil {
+		return fmt.Errorf("template %d has no primary instance", promotion.TemplateID)
+	}
+	vm, err := s.vmRepo.GetByVMID(ctx, instance.VMID, instance.NodeID)
+	if err != nil {
+		return err
+	}
+	if vm == nil {
+		return fmt.Errorf("template vm %d on node %s not found", instance.VMID, instance.NodeName)
+	}
+	export, err := s.transferService.CreateExport(ctx, &v1.CreateImageExportRequest{
+		VmId:           vm.Id,
+		StoreID:        promotion.StoreID,
+		StagingStorage: promotion.StagingStorage,
+		Mode:           "snapshot",
+	}, promotion.Requester)
+	if err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	promotion.ExportTaskID = export.Id
+	s.save(ctx, promotion)
+	if export, err = s.waitTransfer(ctx, export.Id); err != nil {
+		return fmt.Errorf("export: %w", err)
+	}
+	promotion.ObjectKey = export.ObjectKey
+	promotion.Checksum = export.Checksum
+
+	// 2. 导入到目标集群
+	promotion.Phase = model.TemplatePromotionPhaseImport
+	s.save(ctx, promotion)
+	imported, err := s.transferService.CreateImport(ctx, &v1.CreateImageImportRequest{
+		StoreID:        promotion.StoreID,
+		ObjectKey:      promotion.ObjectKey,
+		NodeID:         promotion.TargetNodeID,
+		StagingStorage: promotion.TargetStagingStorage,
+		TargetStorage:  promotion.TargetStorage,
+	}, promotion.Requester)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+	promotion.ImportTaskID = imported.Id
+	promotion.PromotedVMID = imported.VMID
+	s.save(ctx, promotion)
+	if imported, err = s.waitTransfer(ctx, imported.Id); err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	// 3. 模板的备份恢复后通常仍是模板，否则转换；然后登记到模板管理
+	promotion.Phase = model.TemplatePromotionPhaseRegister
+	s.save(ctx, promotion)
+	cluster, err := s.clusterRepo.GetByID(ctx, promotion.TargetClusterID)
+	if err != nil {
+		return err
+	}
+	if cluster == nil {
+		return fmt.Errorf("cluster %d not found", promotion.TargetClusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return err
+	}
+	config, err := client.GetVMConfig(ctx, promotion.TargetNodeName, imported.VMID)
+	if err != nil {
+		return fmt.Errorf("get imported vm config: %w", err)
+	}
+	if configInt(config, "template") != 1 {
+		if err := client.ConvertToTemplate(ctx, promotion.TargetNodeName, imported.VMID, ""); err != nil {
+			return fmt.Errorf("convert to template: %w", err)
+		}
+	}
+	return s.register(ctx, promotion, imported)
+}
+
+// waitTransfer 等待镜像传输任务结束
+func (s *templatePromotionService) waitTransfer(ctx context.Context, id int64) (*v1.ImageTransferItem, error) {
+	interval := s.conf.GetDuration("template_promotion.poll_interval")
+	if interval <= 0 {
+		interval = defaultPromotionPollInterval
+	}
+	for {
+		task, err := s.transferService.GetTransfer(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		switch task.Status {
+		case model.ImageTransferStatusCompleted:
+			return task, nil
+		case model.ImageTransferStatusFailed:
+			return nil, fmt.Errorf("transfer task %d failed: %s", id, task.ErrorMessage)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("transfer task %d: %w", id, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+// register 在目标集群登记模板、导入记录和实例（共享存储为所有可见节点创建实例），并把导入的虚拟机记录标记为模板
+func (s *templatePromotionService) register(ctx context.Context, promotion *model.TemplatePromotion, imported *v1.ImageTransferItem) error {
+	storage, err := s.storageRepo.GetByStorageName(ctx, promotion.TargetStorage, promotion.TargetNodeName, promotion.TargetClusterID)
+	if err != nil {
+		return err
+	}
+	if storage == nil {
+		return fmt.Errorf("storage %s on node %s not found", promotion.TargetStorage, promotion.TargetNodeName)
+	}
+	nodes := []*model.PveNode{{Id: promotion.TargetNodeID, NodeName: promotion.TargetNodeName}}
+	if storage.Shared == 1 {
+		storages, err := s.storageRepo.ListByStorageName(ctx, promotion.TargetClusterID, storage.StorageName)
+		if err != nil {
+			return err
+		}
+		for _, other := range storages {
+			if other.NodeName == promotion.TargetNodeName {
+				continue
+			}
+			node, err := s.nodeRepo.GetByNodeName(ctx, other.NodeName, promotion.TargetClusterID)
+			if err != nil || node == nil {
+				continue
+			}
+			nodes = append(nodes, node)
+		}
+	}
+
+	description := fmt.Sprintf("Promoted from %s (template %d) by promotion %d, approved by %s, sha256 %s",
+		promotion.SourceStage, promotion.TemplateID, promotion.Id, promotion.Reviewer, promotion.Checksum)
+	if source, err := s.templateRepo.GetByID(ctx, promotion.TemplateID); err == nil && source != nil && source.Description != "" {
+		description = source.Description + "\n\n" + description
+	}
+	fileName := path.Base(promotion.ObjectKey)
+
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		template := &model.PveTemplate{
+			TemplateName: promotion.TemplateName,
+			ClusterID:    promotion.TargetClusterID,
+			Description:  description,
+			CreateTime:   time.Now(),
+			UpdateTime:   time.Now(),
+			Creator:      promotion.Requester,
+		}
+		if err := s.templateRepo.Create(ctx, template); err != nil {
+			return fmt.Errorf("create template: %w", err)
+		}
+
+		upload := &model.TemplateUpload{
+			TemplateID:     template.Id,
+			ClusterID:      promotion.TargetClusterID,
+			StorageID:      storage.Id,
+			StorageName:    storage.StorageName,
+			StorageType:    storage.Type,
+			IsShared:       int8(storage.Shared),
+			UploadNodeID:   promotion.TargetNodeID,
+			UploadNodeName: promotion.TargetNodeName,
+			FileName:       fileName,
+			FilePath:       promotion.ObjectKey,
+			FileSize:       imported.TotalBytes,
+			FileFormat:     templateImportFormat(fileName),
+			Status:         model.TemplateUploadStatusImported,
+			ImportProgress: 100,
+			Creator:        promotion.Requester,
+		}
+		if err := s.uploadRepo.Create(ctx, upload); err != nil {
+			return fmt.Errorf("create template upload: %w", err)
+		}
+
+		for i, node := range nodes {
+			instance := &model.TemplateInstance{
+				TemplateID:  template.Id,
+				UploadID:    upload.Id,
+				ClusterID:   promotion.TargetClusterID,
+				NodeID:      node.Id,
+				NodeName:    node.NodeName,
+				StorageID:   storage.Id,
+				StorageName: storage.StorageName,
+				IsShared:    int8(storage.Shared),
+				VMID:        imported.VMID,
+				Status:      model.TemplateInstanceStatusAvailable,
+				CreateTime:  time.Now(),
+				UpdateTime:  time.Now(),
+			}
+			if i == 0 {
+				instance.IsPrimary = 1
+			}
+			if err := s.instanceRepo.Create(ctx, instance); err != nil {
+				return fmt.Errorf("create template instance: %w", err)
+			}
+		}
+
+		if imported.VmId > 0 {
+			vm, err := s.vmRepo.GetByID(ctx, imported.VmId)
+			if err != nil {
+				return err
+			}
+			if vm != nil {
+				vm.IsTemplate = 1
+				vm.TemplateID = template.Id
+				if err := s.vmRepo.Update(ctx, vm); err != nil {
+					return fmt.Errorf("update vm record: %w", err)
+				}
+			}
+		}
+		promotion.PromotedTemplateID = template.Id
+		return nil
+	})
+}
+
+func (s *templatePromotionService) getPromotion(ctx context.Context, id int64) (*model.TemplatePromotion, error) {
+	promotion, err := s.promotionRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template promotion", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if promotion == nil {
+		return nil, v1.ErrTemplatePromotionNotFound
+	}
+	return promotion, nil
+}
+
+func (s *templatePromotionService) Get(ctx context.Context, id int64) (*v1.TemplatePromotionItem, error) {
+	promotion, err := s.getPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item := toTemplatePromotionItem(promotion)
+	return &item, nil
+}
+
+func (s *templatePromotionService) List(ctx context.Context, req *v1.ListTemplatePromotionsRequest) (*v1.ListTemplatePromotionsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	promotions, total, err := s.promotionRepo.List(ctx, page, pageSize, req.TemplateID, req.ClusterID, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list template promotions", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.TemplatePromotionItem, 0, len(promotions))
+	for _, promotion := range promotions {
+		list = append(list, toTemplatePromotionItem(promotion))
+	}
+	return &v1.ListTemplatePromotionsResponseData{Total: total, List: list}, nil
+}
+
+func (s *templatePromotionService) Chain(ctx context.Context, id int64) ([]v1.TemplatePromotionItem, error) {
+	promotion, err := s.getPromotion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	chain := []v1.TemplatePromotionItem{toTemplatePromotionItem(promotion)}
+	// 晋级链长度不超过环境数，额外的上限防止数据异常时死循环
+	for parentID := promotion.ParentID; parentID > 0 && len(chain) < len(s.stages())+1; {
+		parent, err := s.getPromotion(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]v1.TemplatePromotionItem{toTemplatePromotionItem(parent)}, chain...)
+		parentID = parent.ParentID
+	}
+	return chain, nil
+}
+
+func toTemplatePromotionItem(p *model.TemplatePromotion) v1.TemplatePromotionItem {
+	return v1.TemplatePromotionItem{
+		Id:                   p.Id,
+		ParentID:             p.ParentID,
+		OriginTemplateID:     p.OriginTemplateID,
+		TemplateID:           p.TemplateID,
+		TemplateName:         p.TemplateName,
+		SourceClusterID:      p.SourceClusterID,
+		SourceStage:          p.SourceStage,
+		TargetClusterID:      p.TargetClusterID,
+		TargetStage:          p.TargetStage,
+		TargetNodeID:         p.TargetNodeID,
+		TargetNodeName:       p.TargetNodeName,
+		TargetStorage:        p.TargetStorage,
+		StoreID:              p.StoreID,
+		StagingStorage:       p.StagingStorage,
+		TargetStagingStorage: p.TargetStagingStorage,
+		Status:               p.Status,
+		Phase:                p.Phase,
+		ExportTaskID:         p.ExportTaskID,
+		ImportTaskID:         p.ImportTaskID,
+		ObjectKey:            p.ObjectKey,
+		Checksum:             p.Checksum,
+		PromotedTemplateID:   p.PromotedTemplateID,
+		PromotedVMID:         p.PromotedVMID,
+		Reason:               p.Reason,
+		Requester:            p.Requester,
+		Reviewer:             p.Reviewer,
+		ReviewComment:        p.ReviewComment,
+		ReviewTime:           p.ReviewTime,
+		ErrorMessage:         p.ErrorMessage,
+		StartTime:            p.StartTime,
+		EndTime:              p.EndTime,
+		CreateTime:           p.CreateTime,
+	}
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultTemplateUnusedMonths = 6
+	templateUsageRecentLimit    = 50
+)
+
+// TemplateUsageService 记录模板克隆并统计使用情况：克隆次数、最近使用时间、老化（长期未用 / 操作系统停止维护）标记
+type TemplateUsageService interface {
+	// RecordClone 记录一次从模板克隆虚拟机并登记首次启动钩子，失败只记录日志；creator 为空时取当前请求用户
+	RecordClone(ctx context.Context, vm *model.PveVM, instance *model.TemplateInstance, source, creator string)
+	Stats(ctx context.Context, templateIDs []int64) (map[int64]*v1.TemplateUsageStats, error)
+	AgingReport(ctx context.Context, req *v1.TemplateAgingReportRequest) (*v1.TemplateAgingReportData, error)
+	GetUsage(ctx context.Context, templateID int64) (*v1.TemplateUsageDetail, error)
+}
+
+func NewTemplateUsageService(
+	service *Service,
+	conf *viper.Viper,
+	usageRepo repository.TemplateUsageRepository,
+	templateRepo repository.VmTemplateRepository,
+	uploadRepo repository.TemplateUploadRepository,
+	vmRepo repository.PveVMRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationService,
+	firstBoot FirstBootHookService,
+	logger *log.Logger,
+) TemplateUsageService {
+	return &templateUsageService{
+		Service:      service,
+		conf:         conf,
+		usageRepo:    usageRepo,
+		templateRepo: templateRepo,
+		uploadRepo:   uploadRepo,
+		vmRepo:       vmRepo,
+		clusterRepo:  clusterRepo,
+		userRepo:     userRepo,
+		rules:        loadOSEOLRules(conf, logger),
+		notification: notificationService,
+		firstBoot:    firstBoot,
+		logger:       logger,
+	}
+}
+
+type templateUsageService struct {
+	*Service
+	conf         *viper.Viper
+	usageRepo    repository.TemplateUsageRepository
+	templateRepo repository.VmTemplateRepository
+	uploadRepo   repository.TemplateUploadRepository
+	vmRepo       repository.PveVMRepository
+	clusterRepo  repository.PveClusterRepository
+	userRepo     repository.UserRepository
+	rules        []osEOLRule
+	notification NotificationService
+	firstBoot    FirstBootHookService
+	logger       *log.Logger
+}
+
+func (s *templateUsageService) unusedMonths(override int) int {
+	if override > 0 {
+		return override
+	}
+	if months := s.conf.GetInt("template_usage.unused_months"); months > 0 {
+		return months
+	}
+	return defaultTemplateUnusedMonths
+}
+
+func (s *templateUsageService) RecordClone(ctx context.Context, vm *model.PveVM, instance *model.TemplateInstance, source, creator string) {
+	if vm == nil || vm.TemplateID == 0 {
+		return
+	}
+	if creator == "" {
+		if userID := userIDFromCtx(ctx); userID != "" {
+			if user, err := s.userRepo.GetByID(ctx, userID); err == nil && user != nil {
+				creator = user.Username
+			}
+		}
+	}
+	entry := &model.TemplateUsageLog{
+		TemplateID: vm.TemplateID,
+		ClusterID:  vm.ClusterID,
+		NodeName:   vm.NodeName,
+		VmId:       vm.Id,
+		VMID:       vm.VMID,
+		VmName:     vm.VmName,
+		Source:     source,
+		Creator:    creator,
+	}
+	if instance != nil {
+		entry.UploadID = instance.UploadID
+		entry.InstanceID = instance.Id
+		if entry.NodeName == "" {
+			entry.NodeName = instance.NodeName
+		}
+	}
+	if err := s.usageRepo.Create(ctx, entry); err != nil {
+		s.logger.WithContext(ctx).Error("failed to record template usage", zap.Error(err),
+			zap.Int64("template_id", vm.TemplateID), zap.Int64("vm_id", vm.Id))
+	}
+	s.alertEOLTemplate(ctx, vm, creator)
+	s.firstBoot.Enqueue(ctx, vm, creator)
+}
+
+// alertEOLTemplate 从已停止维护（或即将停止维护）的操作系统模板创建虚拟机时通知管理员和创建人
+func (s *templateUsageService) alertEOLTemplate(ctx context.Context, vm *model.PveVM, creator string) {
+	t, err := s.templateRepo.GetByID(ctx, vm.TemplateID)
+	if err != nil || t == nil {
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get template", zap.Error(err), zap.Int64("template_id", vm.TemplateID))
+		}
+		return
+	}
+	rule := matchOSEOL(s.rules, t.TemplateName, t.Description)
+	if rule == nil {
+		return
+	}
+	notification := &model.Notification{
+		Category:   model.NotificationCategoryAlert,
+		Event:      "vm_created_from_eol_template",
+		TargetType: "vm",
+		TargetID:   strconv.FormatInt(vm.Id, 10),
+	}
+	switch rule.status(time.Now()) {
+	case v1.OSEOLStatusEOL:
+		notification.Level = model.NotificationLevelWarning
+		notification.Title = fmt.Sprintf("VM %s was created from end-of-life template %s", vm.VmName, t.TemplateName)
+		notification.Content = fmt.Sprintf("%s reached end of life on %s", rule.Name, rule.EOL)
+	case v1.OSEOLStatusEOLSoon:
+		notification.Level = model.NotificationLevelInfo
+		notification.Title = fmt.Sprintf("VM %s was created from template %s nearing end of life", vm.VmName, t.TemplateName)
+		notification.Content = fmt.Sprintf("%s reaches end of life on %s", rule.Name, rule.EOL)
+	default:
+		return
+	}
+	s.logger.WithContext(ctx).Warn("vm created from eol template",
+		zap.Int64("vm_id", vm.Id), zap.Int64("template_id", t.Id), zap.String("os", rule.Name))
+	var also []string
+	if creator != "" {
+		also = append(also, creator)
+	}
+	s.notification.NotifyAdmins(ctx, notification, also...)
+}
+
+func (s *templateUsageService) Stats(ctx context.Context, templateIDs []int64) (map[int64]*v1.TemplateUsageStats, error) {
+	templates, err := s.templateRepo.GetByIDs(ctx, templateIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get templates", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]*model.VmTemplate, 0, len(templates))
+	for _, t := range templates {
+		list = append(list, t)
+	}
+	items, err := s.buildItems(ctx, list, s.unusedMonths(0), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[int64]*v1.TemplateUsageStats, len(items))
+	for _, item := range items {
+		stat := item.TemplateUsageStats
+		stats[item.Id] = &stat
+	}
+	return stats, nil
+}
+
+func (s *templateUsageService) AgingReport(ctx context.Context, req *v1.TemplateAgingReportRequest) (*v1.TemplateAgingReportData, error) {
+	templates, err := s.templateRepo.List(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list templates", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	now := time.Now()
+	months := s.unusedMonths(req.UnusedMonths)
+	items, err := s.buildItems(ctx, templates, months, now)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.TemplateAgingReportData{
+		UnusedMonths: months,
+		GeneratedAt:  now,
+		Flagged:      make(map[string]int),
+		Items:        make([]*v1.TemplateAgingItem, 0, len(items)),
+	}
+	for _, item := range items {
+		for _, flag := range item.Flags {
+			data.Flagged[flag]++
+		}
+		if req.FlaggedOnly && len(item.Flags) == 0 {
+			continue
+		}
+		data.Items = append(data.Items, item)
+	}
+	sort.SliceStable(data.Items, func(i, j int) bool {
+		a, b := data.Items[i], data.Items[j]
+		if (len(a.Flags) > 0) != (len(b.Flags) > 0) {
+			return len(a.Flags) > 0
+		}
+		return lastUsedBefore(a.LastUsedAt, b.LastUsedAt)
+	})
+	data.Total = len(data.Items)
+	return data, nil
+}
+
+func (s *templateUsageService) GetUsage(ctx context.Context, templateID int64) (*v1.TemplateUsageDetail, error) {
+	template, err := s.templateRepo.GetByID(ctx, templateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template", zap.Error(err), zap.Int64("template_id", templateID))
+		return nil, v1.ErrInternalServerError
+	}
+	if template == nil {
+		return nil, v1.WithDetailf(v1.ErrTemplateNotFound, "template_id=%d", templateID)
+	}
+	items, err := s.buildItems(ctx, []*model.VmTemplate{template}, s.unusedMonths(0), time.Now())
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.usageRepo.ListRecent(ctx, templateID, templateUsageRecentLimit)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list template usage", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	detail := &v1.TemplateUsageDetail{
+		TemplateAgingItem: *items[0],
+		Recent:            make([]v1.TemplateUsageRecord, 0, len(logs)),
+	}
+	for _, l := range logs {
+		detail.Recent = append(detail.Recent, v1.TemplateUsageRecord{
+			Id:         l.Id,
+			UploadID:   l.UploadID,
+			ClusterID:  l.ClusterID,
+			NodeName:   l.NodeName,
+			VmId:       l.VmId,
+			VMID:       l.VMID,
+			VmName:     l.VmName,
+			Source:     l.Source,
+			Creator:    l.Creator,
+			CreateTime: l.CreateTime,
+		})
+	}
+	return detail, nil
+}
+
+// buildItems 汇总模板及各版本的克隆统计并计算老化标记，返回顺序与 templates 一致
+func (s *templateUsageService) buildItems(ctx context.Context, templates []*model.VmTemplate, months int, now time.Time) ([]*v1.TemplateAgingItem, error) {
+	items := make([]*v1.TemplateAgingItem, 0, len(templates))
+	if len(templates) == 0 {
+		return items, nil
+	}
+	ids := make([]int64, 0, len(templates))
+	for _, t := range templates {
+		ids = append(ids, t.Id)
+	}
+	since := now.AddDate(0, -months, 0)
+
+	summaries, err := s.usageRepo.Summarize(ctx, ids, since)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to summarize template usage", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	lastIDs := make([]int64, 0, len(summaries))
+	for _, sum := range summaries {
+		lastIDs = append(lastIDs, sum.LastID)
+	}
+	lastLogs, err := s.usageRepo.GetByIDs(ctx, lastIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get template usage", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	active, err := s.vmRepo.CountByTemplateIDs(ctx, ids)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to count template vms", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	clusters, err := s.clusterRepo.GetByIDs(ctx, clusterIDsOf(templates))
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get clusters", zap.Error(err))
+		clusters = nil
+	}
+
+	byTemplate := make(map[int64][]*repository.TemplateUsageSummary)
+	for _, sum := range summaries {
+		byTemplate[sum.TemplateID] = append(byTemplate[sum.TemplateID], sum)
+	}
+	uploads := make(map[int64]*model.TemplateUpload)
+
+	for _, t := range templates {
+		item := &v1.TemplateAgingItem{
+			Id:           t.Id,
+			TemplateName: t.TemplateName,
+			ClusterID:    t.ClusterID,
+			CreateTime:   t.CreatedAt,
+			TemplateUsageStats: v1.TemplateUsageStats{
+				ActiveVMs: active[t.Id],
+				Flags:     []string{},
+			},
+			Versions: []v1.TemplateVersionUsage{},
+		}
+		if c, ok := clusters[t.ClusterID]; ok {
+			item.ClusterName = c.ClusterName
+		}
+
+		var lastLog *model.TemplateUsageLog
+		for _, sum := range byTemplate[t.Id] {
+			item.CloneCount += sum.Count
+			item.RecentClones += sum.RecentCount
+			version := v1.TemplateVersionUsage{
+				UploadID:     sum.UploadID,
+				CloneCount:   sum.Count,
+				RecentClones: sum.RecentCount,
+			}
+			if l, ok := lastLogs[sum.LastID]; ok {
+				lastUsed := l.CreateTime
+				version.LastUsedAt = &lastUsed
+				if lastLog == nil || l.Id > lastLog.Id {
+					lastLog = l
+				}
+			}
+			if upload := s.upload(ctx, uploads, sum.UploadID); upload != nil {
+				version.FileName = upload.FileName
+				importedAt := upload.CreateTime
+				version.ImportedAt = &importedAt
+			}
+			item.Versions = append(item.Versions, version)
+		}
+		sort.Slice(item.Versions, func(i, j int) bool { return item.Versions[i].UploadID > item.Versions[j].UploadID })
+
+		if lastLog != nil {
+			lastUsed := lastLog.CreateTime
+			item.LastUsedAt = &lastUsed
+			item.LastUsedBy = lastLog.Creator
+		} else if item.ActiveVMs > 0 {
+			// 启用统计之前创建的虚拟机没有克隆记录，以其中最新的创建时间作为最近使用时间
+			item.LastUsedAt = s.latestVMCreateTime(ctx, t.Id)
+		}
+
+		// 模板本身创建不足 unused_months 个月时不标记为未使用
+		if (item.LastUsedAt == nil || item.LastUsedAt.Before(since)) && t.CreatedAt.Before(since) {
+			item.Flags = append(item.Flags, v1.TemplateFlagUnused)
+		}
+		if rule := matchOSEOL(s.rules, t.TemplateName, t.Description); rule != nil {
+			item.OSName = rule.Name
+			item.EOLDate = rule.EOL
+			switch rule.status(now) {
+			case v1.OSEOLStatusEOL:
+				item.Flags = append(item.Flags, v1.TemplateFlagEOL)
+			case v1.OSEOLStatusEOLSoon:
+				item.Flags = append(item.Flags, v1.TemplateFlagEOLSoon)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *templateUsageService) upload(ctx context.Context, cache map[int64]*model.TemplateUpload, id int64) *model.TemplateUpload {
+	if id == 0 {
+		return nil
+	}
+	if upload, ok := cache[id]; ok {
+		return upload
+	}
+	upload, err := s.uploadRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get template upload", zap.Error(err), zap.Int64("upload_id", id))
+	}
+	cache[id] = upload
+	return upload
+}
+
+func (s *templateUsageService) latestVMCreateTime(ctx context.Context, templateID int64) *time.Time {
+	vms, err := s.vmRepo.ListByTemplateID(ctx, templateID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to list template vms", zap.Error(err), zap.Int64("template_id", templateID))
+		return nil
+	}
+	var latest *time.Time
+	for _, vm := range vms {
+		if vm.IsTemplate == 1 || vm.CreateTime.IsZero() {
+			continue
+		}
+		if latest == nil || vm.CreateTime.After(*latest) {
+			created := vm.CreateTime
+			latest = &created
+		}
+	}
+	return latest
+}
+
+func clusterIDsOf(templates []*model.VmTemplate) []int64 {
+	seen := make(map[int64]bool)
+	ids := make([]int64, 0)
+	for _, t := range templates {
+		if t.ClusterID > 0 && !seen[t.ClusterID] {
+			seen[t.ClusterID] = true
+			ids = append(ids, t.ClusterID)
+		}
+	}
+	return ids
+}
+
+// lastUsedBefore 从未使用的排在最前
+func lastUsedBefore(a, b *time.Time) bool {
+	switch {
+	case a == nil:
+		return b != nil
+	case b == nil:
+		return false
+	default:
+		return a.Before(*b)
+	}
+}
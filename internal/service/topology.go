@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// topologyBridgeConcurrency 查询网桥时同时请求的节点数
+const topologyBridgeConcurrency = 8
+
+// TopologyService 集群 -> 节点 -> 虚拟机拓扑图
+type TopologyService interface {
+	GetTopology(ctx context.Context, req *v1.GetTopologyRequest) (*v1.TopologyData, error)
+}
+
+func NewTopologyService(
+	service *Service,
+	topologyRepo repository.TopologyRepository,
+	logger *log.Logger,
+) TopologyService {
+	return &topologyService{
+		Service:      service,
+		topologyRepo: topologyRepo,
+		logger:       logger,
+	}
+}
+
+type topologyService struct {
+	*Service
+	topologyRepo repository.TopologyRepository
+	logger       *log.Logger
+}
+
+// topologyGraph 构建中的拓扑图
+type topologyGraph struct {
+	data *v1.TopologyData
+}
+
+func (g *topologyGraph) addVertex(vertex v1.TopologyVertex) {
+	g.data.Vertices = append(g.data.Vertices, vertex)
+	g.data.Counts[vertex.Kind]++
+}
+
+func (g *topologyGraph) addEdge(source, target, kind string) {
+	g.data.Edges = append(g.data.Edges, v1.TopologyEdge{Source: source, Target: target, Kind: kind})
+}
+
+func (s *topologyService) GetTopology(ctx context.Context, req *v1.GetTopologyRequest) (*v1.TopologyData, error) {
+	snapshot, err := s.topologyRepo.Load(ctx, req.ClusterID, req.IncludeTemplates)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load topology", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if req.ClusterID > 0 && len(snapshot.Clusters) == 0 {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+	}
+
+	g := &topologyGraph{data: &v1.TopologyData{
+		Vertices:    []v1.TopologyVertex{},
+		Edges:       []v1.TopologyEdge{},
+		Counts:      make(map[string]int),
+		GeneratedAt: time.Now(),
+	}}
+
+	clusterIDs := make(map[int64]string, len(snapshot.Clusters))
+	for _, cluster := range snapshot.Clusters {
+		id := fmt.Sprintf("cluster:%d", cluster.Id)
+		clusterIDs[cluster.Id] = id
+		g.addVertex(v1.TopologyVertex{ID: id, Kind: v1.TopologyKindCluster, RefID: cluster.Id, Name: cluster.ClusterName,
+			ClusterID: cluster.Id, Env: cluster.Env})
+	}
+
+	nodeIDs := make(map[int64]string, len(snapshot.Nodes))
+	nodeByID := make(map[int64]*model.PveNode, len(snapshot.Nodes))
+	nodeByName := make(map[string]*model.PveNode, len(snapshot.Nodes))
+	for _, node := range snapshot.Nodes {
+		clusterVertex, ok := clusterIDs[node.ClusterID]
+		if !ok {
+			continue
+		}
+		id := fmt.Sprintf("node:%d", node.Id)
+		nodeIDs[node.Id] = id
+		nodeByID[node.Id] = node
+		nodeByName[topologyNodeKey(node.ClusterID, node.NodeName)] = node
+		g.addVertex(v1.TopologyVertex{ID: id, Kind: v1.TopologyKindNode, RefID: node.Id, Name: node.NodeName,
+			Status: node.Status, ClusterID: node.ClusterID, NodeName: node.NodeName})
+		g.addEdge(clusterVertex, id, v1.TopologyEdgeContains)
+	}
+
+	// 共享存储在每个节点上各有一条记录，合并为集群内的一个顶点
+	storageIDs := make(map[string]string, len(snapshot.Storages)) // cluster/node/storage -> 顶点 ID
+	sharedIDs := make(map[string]string)                          // cluster/storage -> 顶点 ID
+	for _, storage := range snapshot.Storages {
+		node := nodeByName[topologyNodeKey(storage.ClusterID, storage.NodeName)]
+		if node == nil {
+			continue
+		}
+		id := fmt.Sprintf("storage:%d", storage.Id)
+		if storage.Shared == 1 {
+			key := fmt.Sprintf("%d/%s", storage.ClusterID, storage.StorageName)
+			if existing, ok := sharedIDs[key]; ok {
+				id = existing
+			} else {
+				sharedIDs[key] = id
+				g.addVertex(topologyStorageVertex(id, storage, ""))
+			}
+		} else {
+			g.addVertex(topologyStorageVertex(id, storage, storage.NodeName))
+		}
+		storageIDs[topologyNodeKey(storage.ClusterID, storage.NodeName)+"/"+storage.StorageName] = id
+		g.addEdge(nodeIDs[node.Id], id, v1.TopologyEdgeAttached)
+	}
+
+	for _, vm := range snapshot.VMs {
+		nodeVertex, ok := nodeIDs[vm.NodeID]
+		if !ok {
+			continue
+		}
+		node := nodeByID[vm.NodeID]
+		id := fmt.Sprintf("vm:%d", vm.Id)
+		g.addVertex(v1.TopologyVertex{ID: id, Kind: v1.TopologyKindVM, RefID: vm.Id, Name: vm.VmName, Status: vm.Status,
+			ClusterID: vm.ClusterID, NodeName: node.NodeName, VMID: vm.VMID, CPUNum: vm.CPUNum, MemorySize: vm.MemorySize,
+			Template: vm.IsTemplate == 1, Owner: vm.Owner})
+		g.addEdge(nodeVertex, id, v1.TopologyEdgeHosts)
+		if vm.Storage == "" {
+			continue
+		}
+		if storageVertex, ok := storageIDs[topologyNodeKey(vm.ClusterID, node.NodeName)+"/"+vm.Storage]; ok {
+			g.addEdge(id, storageVertex, v1.TopologyEdgeUses)
+		}
+	}
+
+	if req.IncludeBridges {
+		s.addBridges(ctx, g, snapshot, nodeIDs)
+	}
+	return g.data, nil
+}
+
+// addBridges 并发查询各节点的网络配置，把网桥作为节点的附属资源加入拓扑图；查询失败的节点记录在 warnings 中
+func (s *topologyService) addBridges(ctx context.Context, g *topologyGraph, snapshot *repository.TopologySnapshot, nodeIDs map[int64]string) {
+	clients := make(map[int64]*proxmox.ProxmoxClient, len(snapshot.Clusters))
+	for _, cluster := range snapshot.Clusters {
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+		if err != nil {
+			g.data.Warnings = append(g.data.Warnings, fmt.Sprintf("cluster %s: %v", cluster.ClusterName, err))
+			continue
+		}
+		clients[cluster.Id] = client
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, topologyBridgeConcurrency)
+	)
+	for _, node := range snapshot.Nodes {
+		client, ok := clients[node.ClusterID]
+		if !ok || nodeIDs[node.Id] == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(node *model.PveNode) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			networks, err := client.GetNodeNetworks(ctx, node.NodeName)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				s.logger.WithContext(ctx).Warn("failed to get node networks", zap.Error(err), zap.String("node", node.NodeName))
+				g.data.Warnings = append(g.data.Warnings, fmt.Sprintf("node %s: %v", node.NodeName, err))
+				return
+			}
+			for _, network := range networks {
+				if kind, _ := network["type"].(string); kind != "bridge" && kind != "OVSBridge" {
+					continue
+				}
+				iface, _ := network["iface"].(string)
+				vertex := v1.TopologyVertex{
+					ID:        fmt.Sprintf("bridge:%d:%s", node.Id, iface),
+					Kind:      v1.TopologyKindBridge,
+					Name:      iface,
+					Status:    "inactive",
+					ClusterID: node.ClusterID,
+					NodeName:  node.NodeName,
+				}
+				if proxmoxBool(network["active"]) {
+					vertex.Status = "active"
+				}
+				vertex.Ports, _ = network["bridge_ports"].(string)
+				vertex.CIDR, _ = network["cidr"].(string)
+				g.addVertex(vertex)
+				g.addEdge(nodeIDs[node.Id], vertex.ID, v1.TopologyEdgeAttached)
+			}
+		}(node)
+	}
+	wg.Wait()
+}
+
+func topologyNodeKey(clusterID int64, nodeName string) string {
+	return fmt.Sprintf("%d/%s", clusterID, nodeName)
+}
+
+func topologyStorageVertex(id string, storage *model.PveStorage, nodeName string) v1.TopologyVertex {
+	vertex := v1.TopologyVertex{
+		ID:           id,
+		Kind:         v1.TopologyKindStorage,
+		RefID:        storage.Id,
+		Name:         storage.StorageName,
+		Status:       "inactive",
+		ClusterID:    storage.ClusterID,
+		NodeName:     nodeName,
+		StorageType:  storage.Type,
+		Shared:       storage.Shared == 1,
+		UsedFraction: storage.UsedFraction,
+	}
+	if storage.Active == 1 && storage.Enabled == 1 {
+		vertex.Status = "active"
+	}
+	return vertex
+}
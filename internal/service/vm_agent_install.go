@@ -0,0 +1,488 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// guest agent 安装默认参数，可通过 agent_install.* 调整
+const (
+	defaultAgentInstallTimeout = 72 * time.Hour
+	agentInstallBatchSize      = 100
+)
+
+// agentInstallCDROMSlots 挂载安装 ISO 时依次尝试的光驱插槽
+var agentInstallCDROMSlots = []string{"ide0", "ide1", "ide2", "ide3", "sata0", "sata1", "sata2", "sata3", "sata4", "sata5"}
+
+// VMAgentInstallService 为未运行 guest agent 的虚拟机挂载安装 ISO 或 cloud-init snippet，并检测安装结果
+type VMAgentInstallService interface {
+	// Start 为虚拟机开始安装 guest agent，虚拟机已有进行中的安装或 agent 已响应时返回错误
+	Start(ctx context.Context, userID string, vmID int64, req *v1.StartVMAgentInstallRequest) (*v1.VMAgentInstallItem, error)
+	// GetByVM 虚拟机最近一次安装记录，等待中的记录会立即检测一次
+	GetByVM(ctx context.Context, vmID int64) (*v1.VMAgentInstallItem, error)
+	List(ctx context.Context, req *v1.ListVMAgentInstallsRequest) (*v1.ListVMAgentInstallsResponseData, error)
+	// Cancel 取消等待中的安装并恢复原配置，创建人或管理员可操作
+	Cancel(ctx context.Context, userID string, id int64) error
+	// CheckPending 检测等待中的安装并清理已结束安装的介质，返回处理数量
+	CheckPending(ctx context.Context) (int, error)
+}
+
+func NewVMAgentInstallService(
+	service *Service,
+	conf *viper.Viper,
+	installRepo repository.VMAgentInstallRepository,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	changeControl ChangeControlService,
+	vmLock VMLockService,
+	logger *log.Logger,
+) VMAgentInstallService {
+	return &vmAgentInstallService{
+		Service:       service,
+		conf:          conf,
+		installRepo:   installRepo,
+		vmRepo:        vmRepo,
+		nodeRepo:      nodeRepo,
+		clusterRepo:   clusterRepo,
+		userRepo:      userRepo,
+		changeControl: changeControl,
+		vmLock:        vmLock,
+		logger:        logger,
+	}
+}
+
+type vmAgentInstallService struct {
+	*Service
+	conf          *viper.Viper
+	installRepo   repository.VMAgentInstallRepository
+	vmRepo        repository.PveVMRepository
+	nodeRepo      repository.PveNodeRepository
+	clusterRepo   repository.PveClusterRepository
+	userRepo      repository.UserRepository
+	changeControl ChangeControlService
+	vmLock        VMLockService
+	logger        *log.Logger
+
+	// 后台检测与手动查询、取消串行执行，避免同一记录被重复清理
+	mu sync.Mutex
+}
+
+func (s *vmAgentInstallService) timeout() time.Duration {
+	if d := s.conf.GetDuration("agent_install.timeout"); d > 0 {
+		return d
+	}
+	return defaultAgentInstallTimeout
+}
+
+func (s *vmAgentInstallService) operator(ctx context.Context, userID string) (string, bool, error) {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err == nil {
+		return username, true, nil
+	}
+	if !errors.Is(err, v1.ErrAdminRequired) {
+		return "", false, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return "", false, v1.ErrUnauthorized
+	}
+	return user.Username, false, nil
+}
+
+func (s *vmAgentInstallService) Start(ctx context.Context, userID string, vmID int64, req *v1.StartVMAgentInstallRequest) (*v1.VMAgentInstallItem, error) {
+	username, _, err := s.operator(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.ErrVMNotFound
+	}
+	if vm.IsTemplate == 1 {
+		return nil, v1.WithDetail(v1.ErrBadRequest, "vm is a template")
+	}
+
+	volume := strings.TrimSpace(req.Volume)
+	if volume == "" {
+		switch req.Method {
+		case v1.VMAgentInstallMethodCloudInit:
+			volume = s.conf.GetString("agent_install.snippet")
+		case v1.VMAgentInstallMethodISO:
+			volume = s.conf.GetString("agent_install.iso")
+		}
+	}
+	if volume == "" {
+		return nil, v1.WithDetailf(v1.ErrAgentInstallSourceMissing, "method=%s", req.Method)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest, err := s.installRepo.GetLatestByVM(ctx, vm.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get agent install", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if latest != nil && latest.Status == model.VMAgentInstallStatusWaiting {
+		return nil, v1.WithDetailf(v1.ErrAgentInstallInProgress, "id=%d", latest.Id)
+	}
+
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "vm.agent_install",
+		Target:    vm.VmName,
+		ClusterID: vm.ClusterID,
+		AppId:     vm.AppId,
+		VMId:      vm.Id,
+	}); err != nil {
+		return nil, err
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.config"); err != nil {
+		return nil, err
+	}
+
+	config, err := client.GetVMConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	if agentEnabled(config) && client.AgentPing(ctx, node.NodeName, vm.VMID) == nil {
+		return nil, v1.ErrGuestAgentRunning
+	}
+
+	install := &model.VMAgentInstall{
+		ClusterID: vm.ClusterID,
+		VmId:      vm.Id,
+		VMID:      vm.VMID,
+		VmName:    vm.VmName,
+		Method:    req.Method,
+		Volume:    volume,
+		Status:    model.VMAgentInstallStatusWaiting,
+		Creator:   username,
+	}
+	install.PrevAgent, _ = config["agent"].(string)
+	if v, ok := config["agent"].(float64); ok {
+		install.PrevAgent = strconv.Itoa(int(v))
+	}
+	install.PrevCicustom, _ = config["cicustom"].(string)
+
+	changes := make(map[string]interface{})
+	var steps []string
+	if !agentEnabled(config) {
+		changes["agent"] = agentOptionEnabled(install.PrevAgent)
+		install.AgentEnabled = true
+	}
+	switch req.Method {
+	case v1.VMAgentInstallMethodCloudInit:
+		if !hasCloudInitDrive(config) {
+			return nil, v1.ErrCloudInitDriveMissing
+		}
+		cicustom := proxmox.ParseDeviceConfig(install.PrevCicustom)
+		cicustom.Set("vendor", volume)
+		changes["cicustom"] = cicustom.String()
+		steps = append(steps, "reboot the vm so cloud-init installs qemu-guest-agent from the vendor data")
+	case v1.VMAgentInstallMethodISO:
+		slot := freeCDROMSlot(config)
+		if slot == "" {
+			return nil, v1.ErrNoFreeCDROMSlot
+		}
+		install.Slot = slot
+		changes[slot] = volume + ",media=cdrom"
+		steps = append(steps, fmt.Sprintf("install qemu-guest-agent from the iso mounted on %s inside the guest", slot))
+	}
+	if install.AgentEnabled {
+		// agent 选项需要虚拟机重新上电才会添加 virtio-serial 设备，guest 内重启不够
+		steps = append(steps, "shut down and start the vm so the agent device is added")
+	}
+	install.Message = strings.Join(steps, "; ")
+
+	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, changes); err != nil {
+		s.logger.WithContext(ctx).Error("failed to attach agent installer", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	if err := s.installRepo.Create(ctx, install); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create agent install", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("guest agent installation started", zap.Int64("vm_id", vm.Id),
+		zap.String("method", install.Method), zap.String("volume", volume), zap.String("operator", username))
+	item := toVMAgentInstallItem(install)
+	return &item, nil
+}
+
+func (s *vmAgentInstallService) GetByVM(ctx context.Context, vmID int64) (*v1.VMAgentInstallItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	install, err := s.installRepo.GetLatestByVM(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get agent install", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if install == nil {
+		return nil, v1.ErrAgentInstallNotFound
+	}
+	if install.Status == model.VMAgentInstallStatusWaiting {
+		s.check(ctx, install)
+	}
+	item := toVMAgentInstallItem(install)
+	return &item, nil
+}
+
+func (s *vmAgentInstallService) List(ctx context.Context, req *v1.ListVMAgentInstallsRequest) (*v1.ListVMAgentInstallsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	installs, total, err := s.installRepo.List(ctx, page, pageSize, req.ClusterID, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list agent installs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	data := &v1.ListVMAgentInstallsResponseData{Total: total, List: make([]v1.VMAgentInstallItem, 0, len(installs))}
+	for _, install := range installs {
+		data.List = append(data.List, toVMAgentInstallItem(install))
+	}
+	return data, nil
+}
+
+func (s *vmAgentInstallService) Cancel(ctx context.Context, userID string, id int64) error {
+	username, admin, err := s.operator(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	install, err := s.installRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get agent install", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if install == nil {
+		return v1.ErrAgentInstallNotFound
+	}
+	if !admin && install.Creator != username {
+		return v1.ErrAdminRequired
+	}
+	if install.Status != model.VMAgentInstallStatusWaiting {
+		return v1.WithDetailf(v1.ErrAgentInstallFinished, "status=%s", install.Status)
+	}
+
+	s.finish(ctx, install, model.VMAgentInstallStatusCancelled, "cancelled by "+username)
+	s.logger.WithContext(ctx).Info("guest agent installation cancelled", zap.Int64("id", id), zap.String("operator", username))
+	return nil
+}
+
+func (s *vmAgentInstallService) CheckPending(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	installs, err := s.installRepo.ListUnfinished(ctx, agentInstallBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, install := range installs {
+		if install.Status == model.VMAgentInstallStatusWaiting {
+			s.check(ctx, install)
+			continue
+		}
+		s.cleanup(ctx, install)
+		s.save(ctx, install)
+	}
+	return len(installs), nil
+}
+
+// check ping 一次 agent：响应则安装成功，超过 agent_install.timeout 仍未响应则失败
+func (s *vmAgentInstallService) check(ctx context.Context, install *model.VMAgentInstall) {
+	vm, err := s.vmRepo.GetByID(ctx, install.VmId)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vm", zap.Error(err), zap.Int64("vm_id", install.VmId))
+		return
+	}
+	if vm == nil {
+		install.Cleaned = true
+		s.finish(ctx, install, model.VMAgentInstallStatusCancelled, "vm deleted")
+		return
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get proxmox client", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		return
+	}
+
+	now := time.Now()
+	install.Checks++
+	install.LastCheckTime = &now
+	if err := client.AgentPing(ctx, node.NodeName, vm.VMID); err == nil {
+		s.finish(ctx, install, model.VMAgentInstallStatusSucceeded, "guest agent is responding")
+		s.logger.WithContext(ctx).Info("guest agent installed", zap.Int64("vm_id", vm.Id), zap.Int("checks", install.Checks))
+		return
+	}
+	if timeout := s.timeout(); now.Sub(install.CreateTime) > timeout {
+		s.finish(ctx, install, model.VMAgentInstallStatusFailed, fmt.Sprintf("guest agent did not respond within %s", timeout))
+		return
+	}
+	s.save(ctx, install)
+}
+
+// finish 结束安装并清理安装介质，清理失败时保留 cleaned=false 由后台检测重试
+func (s *vmAgentInstallService) finish(ctx context.Context, install *model.VMAgentInstall, status, message string) {
+	now := time.Now()
+	install.Status = status
+	install.Message = message
+	install.FinishTime = &now
+	s.cleanup(ctx, install)
+	s.save(ctx, install)
+}
+
+// cleanup 卸载安装 ISO、恢复原 cicustom；取消时同时恢复原 agent 选项。
+// 只还原仍是本次安装写入的配置，用户在此期间手动修改过的配置保持不变
+func (s *vmAgentInstallService) cleanup(ctx context.Context, install *model.VMAgentInstall) {
+	if install.Cleaned {
+		return
+	}
+	vm, err := s.vmRepo.GetByID(ctx, install.VmId)
+	if err != nil {
+		return
+	}
+	if vm == nil {
+		install.Cleaned = true
+		return
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return
+	}
+	config, err := client.GetVMConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vm config", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		return
+	}
+
+	changes := make(map[string]interface{})
+	var deletes []string
+	switch install.Method {
+	case v1.VMAgentInstallMethodISO:
+		if value, _ := config[install.Slot].(string); strings.HasPrefix(value, install.Volume+",") {
+			deletes = append(deletes, install.Slot)
+		}
+	case v1.VMAgentInstallMethodCloudInit:
+		current, _ := config["cicustom"].(string)
+		if vendor, _ := proxmox.ParseDeviceConfig(current).Get("vendor"); vendor == install.Volume {
+			if install.PrevCicustom == "" {
+				deletes = append(deletes, "cicustom")
+			} else {
+				changes["cicustom"] = install.PrevCicustom
+			}
+		}
+	}
+	if install.Status == model.VMAgentInstallStatusCancelled && install.AgentEnabled {
+		if install.PrevAgent == "" {
+			deletes = append(deletes, "agent")
+		} else {
+			changes["agent"] = install.PrevAgent
+		}
+	}
+	if len(deletes) > 0 {
+		changes["delete"] = strings.Join(deletes, ",")
+	}
+	if len(changes) > 0 {
+		if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, changes); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to remove agent installer", zap.Error(err), zap.Int64("vm_id", vm.Id))
+			return
+		}
+	}
+	install.Cleaned = true
+}
+
+func (s *vmAgentInstallService) save(ctx context.Context, install *model.VMAgentInstall) {
+	if err := s.installRepo.Update(ctx, install); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update agent install", zap.Error(err), zap.Int64("id", install.Id))
+	}
+}
+
+// agentOptionEnabled 在原 agent 选项上开启 enabled，保留 fstrim_cloned_disks 等其他选项
+func agentOptionEnabled(prev string) string {
+	dev := proxmox.ParseDeviceConfig(prev)
+	if dev.Head != "" {
+		dev.Head = "1"
+		return dev.String()
+	}
+	if len(dev.Options) == 0 {
+		return "1"
+	}
+	dev.Set("enabled", "1")
+	return dev.String()
+}
+
+func hasCloudInitDrive(config map[string]interface{}) bool {
+	for key, raw := range config {
+		if value, _ := raw.(string); proxmox.IsDiskKey(key) && strings.Contains(value, "cloudinit") {
+			return true
+		}
+	}
+	return false
+}
+
+// freeCDROMSlot 返回第一个未使用的 IDE / SATA 插槽
+func freeCDROMSlot(config map[string]interface{}) string {
+	for _, slot := range agentInstallCDROMSlots {
+		if _, ok := config[slot]; !ok {
+			return slot
+		}
+	}
+	return ""
+}
+
+func toVMAgentInstallItem(install *model.VMAgentInstall) v1.VMAgentInstallItem {
+	return v1.VMAgentInstallItem{
+		Id:            install.Id,
+		ClusterID:     install.ClusterID,
+		VmId:          install.VmId,
+		VMID:          install.VMID,
+		VmName:        install.VmName,
+		Method:        install.Method,
+		Volume:        install.Volume,
+		Slot:          install.Slot,
+		AgentEnabled:  install.AgentEnabled,
+		Status:        install.Status,
+		Message:       install.Message,
+		Checks:        install.Checks,
+		LastCheckTime: install.LastCheckTime,
+		FinishTime:    install.FinishTime,
+		Creator:       install.Creator,
+		CreateTime:    install.CreateTime,
+		UpdateTime:    install.UpdateTime,
+	}
+}
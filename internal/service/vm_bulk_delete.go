@@ -0,0 +1,633 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// vmBulkDeleteTokenTTL 批量删除确认令牌有效期
+	vmBulkDeleteTokenTTL = 5 * time.Minute
+	// maxVMBulkDeleteTargets 单次批量删除最多选中的虚拟机数
+	maxVMBulkDeleteTargets = 500
+	// defaultVMBulkDeleteConcurrency 同一任务中同时停止、删除的虚拟机数，可通过 vm_bulk_delete.concurrency 调整
+	defaultVMBulkDeleteConcurrency = 4
+	// defaultVMBulkDeleteStopTimeout 等待单台虚拟机停止的最长时间，可通过 vm_bulk_delete.stop_timeout 调整
+	defaultVMBulkDeleteStopTimeout = 10 * time.Minute
+)
+
+// VMBulkDeleteService 批量删除虚拟机：预检列出将删除和跳过的虚拟机并签发确认令牌，
+// 提交令牌和确认文本后在后台逐台停止并删除，任务记录即删除报告
+type VMBulkDeleteService interface {
+	// Prepare 按 ID、标签或项目选出虚拟机并签发确认令牌，不执行删除
+	Prepare(ctx context.Context, userID string, req *v1.PrepareVMBulkDeleteRequest) (*v1.PrepareVMBulkDeleteResponseData, error)
+	// Confirm 校验令牌和确认文本后创建后台删除任务，令牌单次使用
+	Confirm(ctx context.Context, userID string, req *v1.ConfirmVMBulkDeleteRequest) (*v1.VMBulkDeleteJobItem, error)
+	Get(ctx context.Context, id int64) (*v1.VMBulkDeleteJobItem, error)
+	List(ctx context.Context, req *v1.ListVMBulkDeleteJobsRequest) (*v1.ListVMBulkDeleteJobsResponseData, error)
+}
+
+func NewVMBulkDeleteService(
+	service *Service,
+	conf *viper.Viper,
+	jobRepo repository.VMBulkDeleteJobRepository,
+	vmService PveVMService,
+	vmRepo repository.PveVMRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationService,
+	sharedTokens SharedTokenStore,
+	jobLeases JobLeaseService,
+) VMBulkDeleteService {
+	s := &vmBulkDeleteService{
+		Service:             service,
+		conf:                conf,
+		jobRepo:             jobRepo,
+		vmService:           vmService,
+		vmRepo:              vmRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		sharedTokens:        sharedTokens,
+		jobLeases:           jobLeases,
+	}
+	jobLeases.RegisterRecoverer(model.JobTypeVMBulkDelete, s.recover)
+	return s
+}
+
+type vmBulkDeleteService struct {
+	*Service
+	conf                *viper.Viper
+	jobRepo             repository.VMBulkDeleteJobRepository
+	vmService           PveVMService
+	vmRepo              repository.PveVMRepository
+	clusterRepo         repository.PveClusterRepository
+	nodeRepo            repository.PveNodeRepository
+	userRepo            repository.UserRepository
+	notificationService NotificationService
+	sharedTokens        SharedTokenStore
+	jobLeases           JobLeaseService
+}
+
+// vmBulkDeleteToken 预检通过的批量删除请求，确认时按预检结果删除
+type vmBulkDeleteToken struct {
+	UserID      string
+	Selector    string
+	StopMode    string
+	ConfirmText string
+	Targets     []model.VMBulkDeleteItem
+}
+
+func (s *vmBulkDeleteService) Prepare(ctx context.Context, userID string, req *v1.PrepareVMBulkDeleteRequest) (*v1.PrepareVMBulkDeleteResponseData, error) {
+	tag := strings.TrimSpace(req.Tag)
+	businessService := strings.TrimSpace(req.BusinessService)
+	if len(req.VmIDs) == 0 && tag == "" && businessService == "" {
+		return nil, v1.WithDetail(v1.ErrBadRequest, "one of vm_ids, tag or business_service is required")
+	}
+	if tag != "" && req.ClusterID <= 0 {
+		return nil, v1.WithDetail(v1.ErrBadRequest, "cluster_id is required when selecting by tag")
+	}
+
+	vms, err := s.selectVMs(ctx, req, tag, businessService)
+	if err != nil {
+		return nil, err
+	}
+	if len(vms) > maxVMBulkDeleteTargets {
+		return nil, v1.WithDetailf(v1.ErrBadRequest, "selection matches %d vms, at most %d can be deleted at once", len(vms), maxVMBulkDeleteTargets)
+	}
+
+	result := &v1.PrepareVMBulkDeleteResponseData{Targets: []v1.VMBulkDeleteItem{}, Skipped: []v1.VMBulkDeleteItem{}}
+	var targets []model.VMBulkDeleteItem
+	clients := make(map[int64]*proxmox.ProxmoxClient)
+	for _, vm := range vms {
+		item := model.VMBulkDeleteItem{VmId: vm.Id, VMID: vm.VMID, VmName: vm.VmName, ClusterID: vm.ClusterID, NodeName: vm.NodeName}
+		if reason := s.skipReason(ctx, clients, vm); reason != "" {
+			item.Status, item.Reason = model.VMBulkDeleteItemSkipped, reason
+			result.Skipped = append(result.Skipped, toVMBulkDeleteItem(item))
+			continue
+		}
+		item.Status = model.VMBulkDeleteItemPending
+		targets = append(targets, item)
+		result.Targets = append(result.Targets, toVMBulkDeleteItem(item))
+	}
+	if len(targets) == 0 {
+		return nil, v1.ErrVMBulkDeleteNoTargets
+	}
+
+	token, err := newVMBulkDeleteToken()
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to generate bulk delete token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	stopMode := req.StopMode
+	if stopMode == "" {
+		stopMode = vmStopModeStop
+	}
+	// 确认文本包含删除数量，避免误删比预期更多的虚拟机
+	confirmText := fmt.Sprintf("delete %d vms", len(targets))
+	expiresAt := time.Now().Add(vmBulkDeleteTokenTTL)
+	if err := s.sharedTokens.Put(ctx, model.SharedTokenVMBulkDelete, token, &vmBulkDeleteToken{
+		UserID:      userID,
+		Selector:    vmBulkDeleteSelector(req, tag, businessService),
+		StopMode:    stopMode,
+		ConfirmText: confirmText,
+		Targets:     targets,
+	}, expiresAt); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save bulk delete token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	result.ConfirmToken = token
+	result.ConfirmText = confirmText
+	result.ExpiresAt = expiresAt.Unix()
+	return result, nil
+}
+
+// selectVMs 按请求选出虚拟机（不含重复），多个条件同时指定时取交集，按集群和 VMID 排序
+func (s *vmBulkDeleteService) selectVMs(ctx context.Context, req *v1.PrepareVMBulkDeleteRequest, tag, businessService string) ([]*model.PveVM, error) {
+	var candidates []*model.PveVM
+	switch {
+	case len(req.VmIDs) > 0:
+		seen := make(map[int64]bool, len(req.VmIDs))
+		for _, id := range req.VmIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			vm, err := s.vmRepo.GetByID(ctx, id)
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to get vm", zap.Int64("vm_id", id), zap.Error(err))
+				return nil, v1.ErrInternalServerError
+			}
+			if vm == nil {
+				return nil, v1.WithDetailf(v1.ErrVMNotFound, "vm_id=%d", id)
+			}
+			candidates = append(candidates, vm)
+		}
+	case req.ClusterID > 0:
+		vms, err := s.vmRepo.GetByClusterID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list cluster vms", zap.Int64("cluster_id", req.ClusterID), zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		candidates = vms
+	default:
+		vms, _, err := s.vmRepo.ListWithPagination(ctx, 1, maxVMBulkDeleteTargets+1, 0, "", 0, "", 0, "", "",
+			&repository.PveVMMetaFilter{BusinessService: businessService})
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list vms", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		candidates = vms
+	}
+
+	var tagged map[string]bool // node/vmid
+	if tag != "" {
+		var err error
+		if tagged, err = s.taggedVMs(ctx, req.ClusterID, tag); err != nil {
+			return nil, err
+		}
+	}
+	nodeNames := make(map[int64]string)
+	vms := make([]*model.PveVM, 0, len(candidates))
+	for _, vm := range candidates {
+		if req.ClusterID > 0 && vm.ClusterID != req.ClusterID {
+			continue
+		}
+		if businessService != "" && vm.BusinessService != businessService {
+			continue
+		}
+		if vm.NodeName == "" {
+			if _, ok := nodeNames[vm.NodeID]; !ok {
+				if node, err := s.nodeRepo.GetByID(ctx, vm.NodeID); err == nil && node != nil {
+					nodeNames[vm.NodeID] = node.NodeName
+				}
+			}
+			vm.NodeName = nodeNames[vm.NodeID]
+		}
+		if tagged != nil && !tagged[fmt.Sprintf("%s/%d", vm.NodeName, vm.VMID)] {
+			continue
+		}
+		vms = append(vms, vm)
+	}
+	sort.Slice(vms, func(i, j int) bool {
+		if vms[i].ClusterID != vms[j].ClusterID {
+			return vms[i].ClusterID < vms[j].ClusterID
+		}
+		return vms[i].VMID < vms[j].VMID
+	})
+	return vms, nil
+}
+
+// taggedVMs 集群中带有 tag 标签的虚拟机（node/vmid）
+func (s *vmBulkDeleteService) taggedVMs(ctx context.Context, clusterID int64, tag string) (map[string]bool, error) {
+	client, err := s.clusterClient(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	resources, err := client.GetClusterResourcesByType(ctx, "vm")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cluster resources", zap.Int64("cluster_id", clusterID), zap.Error(err))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "list cluster resources: %v", err)
+	}
+	tagged := make(map[string]bool)
+	for _, resource := range resources {
+		if resourceType, _ := resource["type"].(string); resourceType != "qemu" {
+			continue
+		}
+		tags, _ := resource["tags"].(string)
+		for _, t := range strings.FieldsFunc(tags, func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+			if strings.EqualFold(t, tag) {
+				node, _ := resource["node"].(string)
+				vmid, _ := resource["vmid"].(float64)
+				tagged[fmt.Sprintf("%s/%d", node, uint32(vmid))] = true
+				break
+			}
+		}
+	}
+	return tagged, nil
+}
+
+// skipReason 不能删除的原因：模板、已开启删除保护（PveSphere 或 Proxmox）、被 Proxmox 任务锁定；无法读取配置时不跳过，由删除流程报告错误
+func (s *vmBulkDeleteService) skipReason(ctx context.Context, clients map[int64]*proxmox.ProxmoxClient, vm *model.PveVM) string {
+	if vm.IsTemplate == 1 {
+		return "vm is a template"
+	}
+	if vm.Protected == 1 {
+		return fmt.Sprintf("protected by %s", vm.ProtectedBy)
+	}
+	client, ok := clients[vm.ClusterID]
+	if !ok {
+		client, _ = s.clusterClient(ctx, vm.ClusterID)
+		clients[vm.ClusterID] = client
+	}
+	if client == nil || vm.NodeName == "" {
+		return ""
+	}
+	config, err := client.GetVMConfig(ctx, vm.NodeName, vm.VMID)
+	if err != nil {
+		return ""
+	}
+	if protection := fmt.Sprint(config["protection"]); protection == "1" {
+		return "protection is enabled"
+	}
+	if lock, _ := config["lock"].(string); lock != "" {
+		return fmt.Sprintf("locked by proxmox (%s)", lock)
+	}
+	if template := fmt.Sprint(config["template"]); template == "1" {
+		return "vm is a template"
+	}
+	return ""
+}
+
+func (s *vmBulkDeleteService) Confirm(ctx context.Context, userID string, req *v1.ConfirmVMBulkDeleteRequest) (*v1.VMBulkDeleteJobItem, error) {
+	var token vmBulkDeleteToken
+	ok, err := s.sharedTokens.Get(ctx, model.SharedTokenVMBulkDelete, req.ConfirmToken, &token)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load bulk delete token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if !ok || token.UserID != userID {
+		return nil, v1.ErrVMBulkDeleteTokenInvalid
+	}
+	// 确认文本不匹配时令牌保留，可以重新输入
+	if strings.TrimSpace(req.ConfirmText) != token.ConfirmText {
+		return nil, v1.WithDetailf(v1.ErrVMBulkDeleteConfirmText, "type %q to confirm", token.ConfirmText)
+	}
+	// 令牌单次使用：并发确认时只有一个请求能取到
+	if ok, err := s.sharedTokens.Consume(ctx, model.SharedTokenVMBulkDelete, req.ConfirmToken, &token); err != nil || !ok {
+		return nil, v1.ErrVMBulkDeleteTokenInvalid
+	}
+
+	creator := ""
+	if user, err := s.userRepo.GetByID(ctx, userID); err == nil && user != nil {
+		creator = user.Username
+	}
+	items, err := json.Marshal(token.Targets)
+	if err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+	job := &model.VMBulkDeleteJob{
+		Selector: token.Selector,
+		StopMode: token.StopMode,
+		Items:    string(items),
+		Status:   model.VMBulkDeleteJobStatusPending,
+		Total:    len(token.Targets),
+		Creator:  creator,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vm bulk delete job", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	// 任务归属当前实例，实例退出后由其他实例接管继续执行
+	if _, err := s.jobLeases.Acquire(ctx, model.JobTypeVMBulkDelete, job.Id); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to acquire vm bulk delete job lease", zap.Int64("job_id", job.Id), zap.Error(err))
+	}
+
+	go s.execute(detachedRequestContext(ctx), job.Id)
+
+	s.logger.WithContext(ctx).Info("vm bulk delete job submitted", zap.Int64("job_id", job.Id),
+		zap.String("selector", job.Selector), zap.Int("total", job.Total), zap.String("creator", creator))
+	item := toVMBulkDeleteJobItem(job)
+	return &item, nil
+}
+
+// recover 接管其他实例未完成的删除任务：跳过已有结果的虚拟机，继续处理其余虚拟机
+func (s *vmBulkDeleteService) recover(ctx context.Context, jobID int64, previousOwner string) {
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		s.logger.Error("failed to load vm bulk delete job", zap.Int64("job_id", jobID), zap.Error(err))
+		return
+	}
+	if job == nil || job.Status == model.VMBulkDeleteJobStatusCompleted {
+		s.jobLeases.Release(ctx, model.JobTypeVMBulkDelete, jobID)
+		return
+	}
+	s.logger.Info("resuming vm bulk delete job", zap.Int64("job_id", jobID), zap.String("previous_owner", previousOwner))
+	go s.execute(context.Background(), jobID)
+}
+
+// execute 在后台按并发上限逐台停止并删除虚拟机，全部结束后通知提交人。
+// 已有结果（已删除、跳过、失败）的虚拟机不再处理，接管的任务可以继续执行
+func (s *vmBulkDeleteService) execute(ctx context.Context, jobID int64) {
+	defer s.jobLeases.Release(context.Background(), model.JobTypeVMBulkDelete, jobID)
+
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil || job == nil {
+		s.logger.Error("failed to load vm bulk delete job", zap.Int64("job_id", jobID), zap.Error(err))
+		return
+	}
+	var items []model.VMBulkDeleteItem
+	if err := json.Unmarshal([]byte(job.Items), &items); err != nil {
+		s.logger.Error("invalid vm bulk delete items", zap.Int64("job_id", jobID), zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	job.Status = model.VMBulkDeleteJobStatusRunning
+	if job.StartTime == nil {
+		job.StartTime = &now
+	}
+	s.saveJob(job, items)
+
+	// 各虚拟机进度写回同一任务记录，需串行化
+	var mu sync.Mutex
+	update := func(i int, status, reason string) {
+		mu.Lock()
+		defer mu.Unlock()
+		items[i].Status, items[i].Reason = status, reason
+		switch status {
+		case model.VMBulkDeleteItemDeleted:
+			job.Deleted++
+		case model.VMBulkDeleteItemSkipped:
+			job.Skipped++
+		case model.VMBulkDeleteItemFailed:
+			job.Failed++
+		}
+		s.saveJob(job, items)
+	}
+
+	concurrency := s.conf.GetInt("vm_bulk_delete.concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultVMBulkDeleteConcurrency
+	}
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range items {
+		switch items[i].Status {
+		case model.VMBulkDeleteItemDeleted, model.VMBulkDeleteItemSkipped, model.VMBulkDeleteItemFailed:
+			continue
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, item model.VMBulkDeleteItem) {
+			defer func() { <-slots; wg.Done() }()
+			status, reason := s.deleteOne(ctx, job.StopMode, item, func(status string) { update(i, status, "") })
+			update(i, status, reason)
+		}(i, items[i])
+	}
+	wg.Wait()
+
+	end := time.Now()
+	job.Status = model.VMBulkDeleteJobStatusCompleted
+	job.EndTime = &end
+	s.saveJob(job, items)
+	s.logger.Info("vm bulk delete job completed", zap.Int64("job_id", job.Id), zap.Int("deleted", job.Deleted),
+		zap.Int("skipped", job.Skipped), zap.Int("failed", job.Failed))
+
+	if job.Creator != "" {
+		var jobErr error
+		if job.Failed > 0 {
+			jobErr = fmt.Errorf("%d of %d vms failed to delete, %d skipped", job.Failed, job.Total, job.Skipped)
+		}
+		s.notificationService.Notify(context.Background(), taskFinishedNotification("vm_bulk_delete", job.Id,
+			fmt.Sprintf("Bulk deletion of %d VMs", job.Total), jobErr), job.Creator)
+	}
+}
+
+// deleteOne 停止并删除单台虚拟机，返回最终状态和原因；执行前再次检查保护和锁定
+func (s *vmBulkDeleteService) deleteOne(ctx context.Context, stopMode string, item model.VMBulkDeleteItem, progress func(status string)) (string, string) {
+	vm, err := s.vmRepo.GetByID(ctx, item.VmId)
+	if err != nil {
+		return model.VMBulkDeleteItemFailed, fmt.Sprintf("get vm: %v", err)
+	}
+	if vm == nil {
+		return model.VMBulkDeleteItemSkipped, "vm no longer exists"
+	}
+	vm.NodeName = item.NodeName
+	if reason := s.skipReason(ctx, make(map[int64]*proxmox.ProxmoxClient), vm); reason != "" {
+		return model.VMBulkDeleteItemSkipped, reason
+	}
+
+	if vm.Status != "stopped" {
+		progress(model.VMBulkDeleteItemStopping)
+		err := s.vmService.StopVM(ctx, vm.Id, &v1.StopVMRequest{Mode: stopMode})
+		if err != nil && !errors.Is(err, v1.ErrVMAlreadyStopped) {
+			return bulkDeleteFailure("stop", err)
+		}
+		if err := s.waitStopped(ctx, vm.Id); err != nil {
+			return model.VMBulkDeleteItemFailed, err.Error()
+		}
+	}
+
+	progress(model.VMBulkDeleteItemDeleting)
+	if err := s.vmService.DeleteVM(ctx, vm.Id); err != nil {
+		return bulkDeleteFailure("delete", err)
+	}
+	return model.VMBulkDeleteItemDeleted, ""
+}
+
+// bulkDeleteFailure 删除保护、维护锁、Proxmox 锁和变更管控拒绝视为跳过，其余为失败
+func bulkDeleteFailure(step string, err error) (string, string) {
+	if errors.Is(err, v1.ErrVMProtected) || errors.Is(err, v1.ErrVMLocked) || errors.Is(err, v1.ErrVMProxmoxLocked) ||
+		errors.Is(err, v1.ErrChangeFrozen) || errors.Is(err, v1.ErrOutsideMaintenanceWindow) {
+		return model.VMBulkDeleteItemSkipped, fmt.Sprintf("%s: %v", step, err)
+	}
+	return model.VMBulkDeleteItemFailed, fmt.Sprintf("%s: %v", step, err)
+}
+
+// waitStopped 等待停止任务结束后虚拟机记录变为 stopped
+func (s *vmBulkDeleteService) waitStopped(ctx context.Context, vmID int64) error {
+	timeout := s.conf.GetDuration("vm_bulk_delete.stop_timeout")
+	if timeout <= 0 {
+		timeout = defaultVMBulkDeleteStopTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	for {
+		vm, err := s.vmRepo.GetByID(ctx, vmID)
+		if err == nil && vm != nil && vm.Status == "stopped" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("stop: vm did not stop within %s", timeout)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *vmBulkDeleteService) saveJob(job *model.VMBulkDeleteJob, items []model.VMBulkDeleteItem) {
+	if b, err := json.Marshal(items); err == nil {
+		job.Items = string(b)
+	}
+	if err := s.jobRepo.Update(context.Background(), job); err != nil {
+		s.logger.Error("failed to update vm bulk delete job", zap.Int64("job_id", job.Id), zap.Error(err))
+	}
+}
+
+func (s *vmBulkDeleteService) Get(ctx context.Context, id int64) (*v1.VMBulkDeleteJobItem, error) {
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm bulk delete job", zap.Int64("job_id", id), zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if job == nil {
+		return nil, v1.ErrVMBulkDeleteJobNotFound
+	}
+	item := toVMBulkDeleteJobItem(job)
+	return &item, nil
+}
+
+func (s *vmBulkDeleteService) List(ctx context.Context, req *v1.ListVMBulkDeleteJobsRequest) (*v1.ListVMBulkDeleteJobsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	jobs, total, err := s.jobRepo.List(ctx, page, pageSize, req.Status, req.Creator)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm bulk delete jobs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.VMBulkDeleteJobItem, 0, len(jobs))
+	for _, job := range jobs {
+		list = append(list, toVMBulkDeleteJobItem(job))
+	}
+	return &v1.ListVMBulkDeleteJobsResponseData{Total: total, List: list}, nil
+}
+
+func (s *vmBulkDeleteService) clusterClient(ctx context.Context, clusterID int64) (*proxmox.ProxmoxClient, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return client, nil
+}
+
+func newVMBulkDeleteToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// vmBulkDeleteSelector 记录在任务中的选择条件
+func vmBulkDeleteSelector(req *v1.PrepareVMBulkDeleteRequest, tag, businessService string) string {
+	var parts []string
+	if len(req.VmIDs) > 0 {
+		ids := make([]string, 0, len(req.VmIDs))
+		for _, id := range req.VmIDs {
+			ids = append(ids, fmt.Sprint(id))
+		}
+		parts = append(parts, "vm_ids="+strings.Join(ids, ","))
+	}
+	if req.ClusterID > 0 {
+		parts = append(parts, fmt.Sprintf("cluster_id=%d", req.ClusterID))
+	}
+	if tag != "" {
+		parts = append(parts, "tag="+tag)
+	}
+	if businessService != "" {
+		parts = append(parts, "business_service="+businessService)
+	}
+	return strings.Join(parts, " ")
+}
+
+func toVMBulkDeleteItem(item model.VMBulkDeleteItem) v1.VMBulkDeleteItem {
+	return v1.VMBulkDeleteItem{
+		VmId:      item.VmId,
+		VMID:      item.VMID,
+		VmName:    item.VmName,
+		ClusterID: item.ClusterID,
+		NodeName:  item.NodeName,
+		Status:    item.Status,
+		Reason:    item.Reason,
+	}
+}
+
+func toVMBulkDeleteJobItem(job *model.VMBulkDeleteJob) v1.VMBulkDeleteJobItem {
+	item := v1.VMBulkDeleteJobItem{
+		Id:         job.Id,
+		Selector:   job.Selector,
+		StopMode:   job.StopMode,
+		Status:     job.Status,
+		Total:      job.Total,
+		Deleted:    job.Deleted,
+		Skipped:    job.Skipped,
+		Failed:     job.Failed,
+		Items:      []v1.VMBulkDeleteItem{},
+		StartTime:  job.StartTime,
+		EndTime:    job.EndTime,
+		Creator:    job.Creator,
+		CreateTime: job.CreateTime,
+	}
+	var items []model.VMBulkDeleteItem
+	if err := json.Unmarshal([]byte(job.Items), &items); err == nil {
+		for _, i := range items {
+			item.Items = append(item.Items, toVMBulkDeleteItem(i))
+		}
+	}
+	return item
+}
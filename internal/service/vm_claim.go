@@ -0,0 +1,481 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 vm_claim.reminder_interval 时的默认提醒间隔
+const defaultVMClaimReminderInterval = 24 * time.Hour
+
+type VMClaimService interface {
+	ListUnclaimed(ctx context.Context, req *v1.ListUnclaimedVMsRequest) (*v1.ListUnclaimedVMsResponseData, error)
+	// Adopt 认领虚拟机：untracked 时创建虚拟机记录，unowned 时补充归属信息
+	Adopt(ctx context.Context, userID string, id int64, req *v1.AdoptUnclaimedVMRequest) (*v1.AdoptUnclaimedVMData, error)
+	// SetIgnored 忽略或取消忽略待认领虚拟机，仅管理员可操作
+	SetIgnored(ctx context.Context, userID string, id int64, req *v1.IgnoreUnclaimedVMRequest) error
+	// Refresh 立即扫描，仅管理员可操作
+	Refresh(ctx context.Context, userID string) (*v1.RefreshUnclaimedVMsData, error)
+	// Scan 比对各集群 Proxmox 中的虚拟机与数据库记录，更新待认领列表
+	Scan(ctx context.Context) (*v1.RefreshUnclaimedVMsData, error)
+	// SendReminders 推送待认领提醒，同一虚拟机按 vm_claim.reminder_interval 间隔提醒
+	SendReminders(ctx context.Context) error
+}
+
+func NewVMClaimService(
+	service *Service,
+	conf *viper.Viper,
+	claimRepo repository.VMClaimRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) VMClaimService {
+	return &vmClaimService{
+		conf:        conf,
+		claimRepo:   claimRepo,
+		clusterRepo: clusterRepo,
+		nodeRepo:    nodeRepo,
+		vmRepo:      vmRepo,
+		userRepo:    userRepo,
+		Service:     service,
+		logger:      logger,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type vmClaimService struct {
+	conf        *viper.Viper
+	claimRepo   repository.VMClaimRepository
+	clusterRepo repository.PveClusterRepository
+	nodeRepo    repository.PveNodeRepository
+	vmRepo      repository.PveVMRepository
+	userRepo    repository.UserRepository
+	*Service
+	logger     *log.Logger
+	httpClient *http.Client // 认领提醒 webhook
+}
+
+func (s *vmClaimService) reminderInterval() time.Duration {
+	interval := s.conf.GetDuration("vm_claim.reminder_interval")
+	if interval <= 0 {
+		return defaultVMClaimReminderInterval
+	}
+	return interval
+}
+
+func (s *vmClaimService) ListUnclaimed(ctx context.Context, req *v1.ListUnclaimedVMsRequest) (*v1.ListUnclaimedVMsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	items, total, err := s.claimRepo.ListWithPagination(ctx, page, pageSize, req.ClusterID, req.Reason, req.IncludeIgnored)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list unclaimed vms", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	clusterIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		clusterIDs = append(clusterIDs, item.ClusterID)
+	}
+	clusterMap, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.UnclaimedVMItem, 0, len(items))
+	for _, item := range items {
+		entry := v1.UnclaimedVMItem{
+			Id:           item.Id,
+			ClusterID:    item.ClusterID,
+			NodeName:     item.NodeName,
+			VMID:         item.VMID,
+			VmName:       item.VmName,
+			Status:       item.Status,
+			CPUNum:       item.CPUNum,
+			MemorySize:   item.MemorySize,
+			DiskSize:     item.DiskSize,
+			Reason:       item.Reason,
+			VmID:         item.VmID,
+			Creator:      item.Creator,
+			Ignored:      item.Ignored,
+			IgnoredBy:    item.IgnoredBy,
+			FirstSeen:    item.FirstSeen,
+			LastSeen:     item.LastSeen,
+			LastRemindAt: item.LastRemindAt,
+		}
+		if cluster, ok := clusterMap[item.ClusterID]; ok {
+			entry.ClusterName = cluster.ClusterName
+		}
+		list = append(list, entry)
+	}
+	return &v1.ListUnclaimedVMsResponseData{Total: total, List: list}, nil
+}
+
+func (s *vmClaimService) getUnclaimed(ctx context.Context, id int64) (*model.UnclaimedVM, error) {
+	item, err := s.claimRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get unclaimed vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if item == nil {
+		return nil, v1.ErrUnclaimedVMNotFound
+	}
+	return item, nil
+}
+
+func (s *vmClaimService) Adopt(ctx context.Context, userID string, id int64, req *v1.AdoptUnclaimedVMRequest) (*v1.AdoptUnclaimedVMData, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.getUnclaimed(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := strings.TrimSpace(req.Owner)
+	if owner == "" {
+		owner = username
+	}
+
+	var vm *model.PveVM
+	if item.VmID > 0 {
+		vm, err = s.vmRepo.GetByID(ctx, item.VmID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+	}
+	if vm == nil {
+		// untracked：按扫描时 Proxmox 中的信息创建虚拟机记录，后续由控制器同步更新
+		node, err := s.nodeRepo.GetByNodeName(ctx, item.NodeName, item.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if node == nil {
+			return nil, v1.WithDetail(v1.ErrUnclaimedVMNodeSync, item.NodeName)
+		}
+		// 扫描之后控制器可能已同步了该虚拟机
+		vm, err = s.vmRepo.GetByVMID(ctx, item.VMID, node.Id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if vm == nil {
+			if err := s.ensureVMExists(ctx, item); err != nil {
+				return nil, err
+			}
+			now := time.Now()
+			vm = &model.PveVM{
+				VmName:     item.VmName,
+				NodeID:     node.Id,
+				VMID:       item.VMID,
+				CPUNum:     item.CPUNum,
+				MemorySize: item.MemorySize,
+				DiskSize:   item.DiskSize,
+				ClusterID:  item.ClusterID,
+				Status:     item.Status,
+				NodeIP:     node.IPAddress,
+				Creator:    username,
+				CreateTime: now,
+				UpdateTime: now,
+			}
+		}
+	}
+
+	// 未填写的字段保留虚拟机记录上已有的值
+	set := func(field *string, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			*field = value
+		}
+	}
+	vm.Owner = owner
+	set(&vm.Team, req.Team)
+	set(&vm.Contact, req.Contact)
+	set(&vm.AppId, req.AppId)
+	set(&vm.BusinessService, req.BusinessService)
+	set(&vm.Environment, req.Environment)
+	set(&vm.CostCenter, req.CostCenter)
+	if req.ReviewDate != nil {
+		vm.ReviewDate = req.ReviewDate
+	}
+	vm.Modifier = username
+
+	if vm.Id > 0 {
+		vm.UpdateTime = time.Now()
+		err = s.vmRepo.Update(ctx, vm)
+	} else {
+		err = s.vmRepo.Create(ctx, vm)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to save adopted vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if err := s.claimRepo.Delete(ctx, item.Id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete unclaimed vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("vm adopted",
+		zap.Int64("cluster_id", item.ClusterID), zap.Uint32("vmid", item.VMID), zap.String("reason", item.Reason),
+		zap.Int64("vm_id", vm.Id), zap.String("owner", owner), zap.String("operator", username))
+	return &v1.AdoptUnclaimedVMData{VmID: vm.Id, Owner: owner}, nil
+}
+
+// ensureVMExists 确认虚拟机仍在 Proxmox 中，避免为已删除的虚拟机创建记录
+func (s *vmClaimService) ensureVMExists(ctx context.Context, item *model.UnclaimedVM) error {
+	cluster, err := s.clusterRepo.GetByID(ctx, item.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return v1.ErrUnclaimedVMGone
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if _, err := client.GetVMConfig(ctx, item.NodeName, item.VMID); err != nil {
+		s.logger.WithContext(ctx).Warn("unclaimed vm not found in proxmox", zap.Uint32("vmid", item.VMID), zap.Error(err))
+		return v1.WithDetailf(v1.ErrUnclaimedVMGone, "%s/%d", item.NodeName, item.VMID)
+	}
+	return nil
+}
+
+func (s *vmClaimService) SetIgnored(ctx context.Context, userID string, id int64, req *v1.IgnoreUnclaimedVMRequest) error {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return err
+	}
+	item, err := s.getUnclaimed(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	item.Ignored = req.Ignored
+	item.IgnoredBy = ""
+	if req.Ignored {
+		item.IgnoredBy = username
+	}
+	if err := s.claimRepo.Save(ctx, item); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update unclaimed vm", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("unclaimed vm ignore changed",
+		zap.Int64("cluster_id", item.ClusterID), zap.Uint32("vmid", item.VMID), zap.Bool("ignored", req.Ignored), zap.String("operator", username))
+	return nil
+}
+
+func (s *vmClaimService) Refresh(ctx context.Context, userID string) (*v1.RefreshUnclaimedVMsData, error) {
+	if _, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID); err != nil {
+		return nil, err
+	}
+	data, err := s.Scan(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to scan unclaimed vms", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return data, nil
+}
+
+// skipClaimLocks 创建、克隆过程中的虚拟机还没有写入记录，不计入待认领
+var skipClaimLocks = map[string]bool{
+	"create": true,
+	"clone":  true,
+}
+
+func (s *vmClaimService) Scan(ctx context.Context) (*v1.RefreshUnclaimedVMsData, error) {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.RefreshUnclaimedVMsData{}
+	for _, cluster := range clusters {
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to create proxmox client", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			continue
+		}
+		// 获取失败时保留该集群上次的扫描结果
+		resources, err := client.GetClusterResourcesByType(ctx, "vm")
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get cluster vms", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			continue
+		}
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return nil, err
+		}
+		vmByVMID := make(map[uint32]*model.PveVM, len(vms))
+		for _, vm := range vms {
+			vmByVMID[vm.VMID] = vm
+		}
+		existing, err := s.claimRepo.ListByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return nil, err
+		}
+		itemByVMID := make(map[uint32]*model.UnclaimedVM, len(existing))
+		for _, item := range existing {
+			itemByVMID[item.VMID] = item
+		}
+
+		now := time.Now()
+		seen := make(map[uint32]bool)
+		for _, resource := range resources {
+			resourceType, _ := resource["type"].(string)
+			template, _ := resource["template"].(float64)
+			lock, _ := resource["lock"].(string)
+			name, _ := resource["name"].(string)
+			// 模板和模板同步过程中的临时虚拟机（sync- 前缀）不需要认领
+			if resourceType != "qemu" || template == 1 || skipClaimLocks[lock] || strings.HasPrefix(name, "sync-") {
+				continue
+			}
+			vmidFloat, _ := resource["vmid"].(float64)
+			vmid := uint32(vmidFloat)
+
+			vm := vmByVMID[vmid]
+			reason := model.UnclaimedReasonUntracked
+			if vm != nil {
+				if vm.IsTemplate == 1 || vm.Owner != "" {
+					continue
+				}
+				reason = model.UnclaimedReasonUnowned
+			}
+
+			item, ok := itemByVMID[vmid]
+			if !ok {
+				item = &model.UnclaimedVM{ClusterID: cluster.Id, VMID: vmid, FirstSeen: now}
+			}
+			nodeName, _ := resource["node"].(string)
+			status, _ := resource["status"].(string)
+			maxCPU, _ := resource["maxcpu"].(float64)
+			maxMem, _ := resource["maxmem"].(float64)
+			maxDisk, _ := resource["maxdisk"].(float64)
+			item.NodeName = nodeName
+			item.VmName = name
+			item.Status = status
+			item.CPUNum = int(maxCPU)
+			item.MemorySize = int(maxMem / 1024 / 1024)
+			item.DiskSize = int(maxDisk / 1024 / 1024)
+			item.Reason = reason
+			item.VmID, item.Creator = 0, ""
+			if vm != nil {
+				item.VmID, item.Creator = vm.Id, vm.Creator
+			}
+			item.LastSeen = now
+			if err := s.claimRepo.Save(ctx, item); err != nil {
+				return nil, err
+			}
+			seen[vmid] = true
+			if reason == model.UnclaimedReasonUntracked {
+				data.Untracked++
+			} else {
+				data.Unowned++
+			}
+		}
+
+		// 已认领或已从 Proxmox 删除的虚拟机移出列表
+		for _, item := range existing {
+			if seen[item.VMID] {
+				continue
+			}
+			if err := s.claimRepo.Delete(ctx, item.Id); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("unclaimed vms scanned", zap.Int("untracked", data.Untracked), zap.Int("unowned", data.Unowned))
+	return data, nil
+}
+
+func (s *vmClaimService) SendReminders(ctx context.Context) error {
+	webhook := s.conf.GetString("vm_claim.reminder_webhook")
+	if webhook == "" {
+		return nil
+	}
+
+	// 新发现的虚拟机留出一个提醒间隔的认领时间，之后每个间隔提醒一次
+	now := time.Now()
+	items, err := s.claimRepo.ListRemindable(ctx, now.Add(-s.reminderInterval()))
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	clusterIDs := make([]int64, 0, len(items))
+	for _, item := range items {
+		clusterIDs = append(clusterIDs, item.ClusterID)
+	}
+	clusterMap, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		return err
+	}
+	vms := make([]map[string]interface{}, 0, len(items))
+	ids := make([]int64, 0, len(items))
+	for _, item := range items {
+		var clusterName string
+		if cluster, ok := clusterMap[item.ClusterID]; ok {
+			clusterName = cluster.ClusterName
+		}
+		vms = append(vms, map[string]interface{}{
+			"id":           item.Id,
+			"cluster_name": clusterName,
+			"node_name":    item.NodeName,
+			"vmid":         item.VMID,
+			"vm_name":      item.VmName,
+			"reason":       item.Reason,
+			"creator":      item.Creator,
+			"first_seen":   item.FirstSeen,
+		})
+		ids = append(ids, item.Id)
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"event": "vm_claim_reminder",
+		"count": len(vms),
+		"vms":   vms,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		s.logger.WithContext(ctx).Error("vm claim reminder webhook returned error status", zap.Int("status", resp.StatusCode))
+		return nil
+	}
+	s.logger.WithContext(ctx).Info("vm claim reminder sent", zap.Int("count", len(vms)))
+	return s.claimRepo.MarkReminded(ctx, ids, now)
+}
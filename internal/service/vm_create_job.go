@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultVMCreateJobConcurrency 同时执行的异步创建任务数，可通过 vm_create_job.concurrency 调整
+	defaultVMCreateJobConcurrency = 4
+	// defaultVMCreateJobTimeout 单个异步创建任务的最长执行时间，可通过 vm_create_job.timeout 调整
+	defaultVMCreateJobTimeout = 30 * time.Minute
+)
+
+// VMCreateJobService 异步创建虚拟机：提交时只校验集群和节点并登记任务，
+// 创建流程（与同步创建相同）在后台执行，结束后更新任务状态并通知提交人
+type VMCreateJobService interface {
+	Submit(ctx context.Context, req *v1.CreateVMRequest) (*v1.VMCreateJobItem, error)
+	Get(ctx context.Context, id int64) (*v1.VMCreateJobItem, error)
+	List(ctx context.Context, req *v1.ListVMCreateJobsRequest) (*v1.ListVMCreateJobsResponseData, error)
+}
+
+func NewVMCreateJobService(
+	service *Service,
+	conf *viper.Viper,
+	jobRepo repository.VMCreateJobRepository,
+	vmService PveVMService,
+	vmRepo repository.PveVMRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationService,
+	nodePools NodePoolService,
+	vmidRanges VMIDRangeService,
+	jobLeases JobLeaseService,
+) VMCreateJobService {
+	concurrency := conf.GetInt("vm_create_job.concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultVMCreateJobConcurrency
+	}
+	s := &vmCreateJobService{
+		Service:             service,
+		conf:                conf,
+		jobRepo:             jobRepo,
+		vmService:           vmService,
+		vmRepo:              vmRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		nodePools:           nodePools,
+		vmidRanges:          vmidRanges,
+		jobLeases:           jobLeases,
+		slots:               make(chan struct{}, concurrency),
+	}
+	jobLeases.RegisterRecoverer(model.JobTypeVMCreate, s.recover)
+	return s
+}
+
+type vmCreateJobService struct {
+	*Service
+	conf                *viper.Viper
+	jobRepo             repository.VMCreateJobRepository
+	vmService           PveVMService
+	vmRepo              repository.PveVMRepository
+	clusterRepo         repository.PveClusterRepository
+	nodeRepo            repository.PveNodeRepository
+	userRepo            repository.UserRepository
+	notificationService NotificationService
+	nodePools           NodePoolService
+	vmidRanges          VMIDRangeService
+	jobLeases           JobLeaseService
+
+	// slots 限制同时执行的创建任务数
+	slots chan struct{}
+}
+
+func (s *vmCreateJobService) Submit(ctx context.Context, req *v1.CreateVMRequest) (*v1.VMCreateJobItem, error) {
+	createMode := strings.ToLower(strings.TrimSpace(req.CreateMode))
+	if createMode == "" {
+		createMode = "template"
+	}
+
+	cluster, node, err := s.resolveTarget(ctx, req, createMode)
+	if err != nil {
+		return nil, err
+	}
+	// 后台创建直接使用已解析的 ID，避免提交后集群或节点改名导致按名称查找失败
+	req.ClusterID = cluster.Id
+	req.NodeID = node.Id
+
+	// 提交时即确定 VMID（从 VMID 段分配或自动生成），调用方可据此在任务完成前识别虚拟机；
+	// 任务执行失败时由创建流程撤销分配
+	if req.VMID, err = s.vmidRanges.Allocate(ctx, cluster, req.Team, req.VmName, req.VMID); err != nil {
+		return nil, err
+	}
+
+	creator := ""
+	if userID := userIDFromCtx(ctx); userID != "" {
+		if user, err := s.userRepo.GetByID(ctx, userID); err == nil && user != nil {
+			creator = user.Username
+		}
+	}
+
+	job := &model.VMCreateJob{
+		ClusterID:   cluster.Id,
+		ClusterName: cluster.ClusterName,
+		NodeID:      node.Id,
+		NodeName:    node.NodeName,
+		VMID:        req.VMID,
+		VmName:      req.VmName,
+		CreateMode:  createMode,
+		TemplateID:  req.TemplateID,
+		Status:      model.VMCreateJobStatusPending,
+		Creator:     creator,
+	}
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vm create job", zap.Error(err))
+		s.vmidRanges.Cancel(ctx, cluster.Id, req.VMID)
+		return nil, v1.ErrInternalServerError
+	}
+	// 任务归属当前实例，实例退出后由其他实例接管（请求参数不落库，无法继续执行，只能标记失败）
+	if _, err := s.jobLeases.Acquire(ctx, model.JobTypeVMCreate, job.Id); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to acquire vm create job lease", zap.Int64("job_id", job.Id), zap.Error(err))
+	}
+
+	go s.execute(detachedRequestContext(ctx), job.Id, req)
+
+	s.logger.WithContext(ctx).Info("vm create job submitted",
+		zap.Int64("job_id", job.Id), zap.String("vm_name", req.VmName), zap.String("node", node.NodeName), zap.Uint32("vmid", req.VMID))
+	item := toVMCreateJobItem(job)
+	return &item, nil
+}
+
+// resolveTarget 提交时校验目标集群和节点（优先使用 ID，没有时使用名称，都未指定时由节点池调度）
+func (s *vmCreateJobService) resolveTarget(ctx context.Context, req *v1.CreateVMRequest, createMode string) (*model.PveCluster, *model.PveNode, error) {
+	var cluster *model.PveCluster
+	var err error
+	switch {
+	case req.ClusterID > 0:
+		cluster, err = s.clusterRepo.GetByID(ctx, req.ClusterID)
+	case req.ClusterName != "":
+		cluster, err = s.clusterRepo.GetByClusterName(ctx, req.ClusterName)
+	default:
+		return nil, nil, v1.ErrClusterRequired
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		if req.ClusterID > 0 {
+			return nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+		}
+		return nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_name=%s", req.ClusterName)
+	}
+
+	var node *model.PveNode
+	switch {
+	case req.NodeID > 0:
+		node, err = s.nodeRepo.GetByID(ctx, req.NodeID)
+	case req.NodeName != "":
+		node, err = s.nodeRepo.GetByNodeName(ctx, req.NodeName, cluster.Id)
+	case req.NodePoolID > 0:
+		// 节点池在提交时调度，任务记录中即为最终节点
+		reservation := createVMReservation(req, createMode, cluster.Id, 0, "")
+		if node, err = s.nodePools.Schedule(ctx, cluster, req.NodePoolID, reservation.CPUNum, reservation.MemoryMB); err != nil {
+			return nil, nil, err
+		}
+		return cluster, node, nil
+	default:
+		return nil, nil, v1.ErrNodeRequired
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if node == nil || node.ClusterID != cluster.Id {
+		if req.NodeID > 0 {
+			return nil, nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", req.NodeID)
+		}
+		return nil, nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_name=%s", req.NodeName)
+	}
+	return cluster, node, nil
+}
+
+// recover 接管其他实例未完成的创建任务：请求参数（含密码）不保存，任务无法继续，标记为失败并通知提交人。
+// 尚未开始的任务同时撤销已分配的 VMID，执行中的任务可能已在 Proxmox 中创建了部分虚拟机，VMID 保留待人工确认
+func (s *vmCreateJobService) recover(ctx context.Context, jobID int64, previousOwner string) {
+	defer s.jobLeases.Release(ctx, model.JobTypeVMCreate, jobID)
+
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil {
+		s.logger.Error("failed to load vm create job", zap.Int64("job_id", jobID), zap.Error(err))
+		return
+	}
+	if job == nil || (job.Status != model.VMCreateJobStatusPending && job.Status != model.VMCreateJobStatusRunning) {
+		return
+	}
+
+	if job.Status == model.VMCreateJobStatusPending {
+		s.vmidRanges.Cancel(ctx, job.ClusterID, job.VMID)
+		job.ErrorMessage = fmt.Sprintf("interrupted: instance %s stopped before the job started", previousOwner)
+	} else {
+		job.ErrorMessage = fmt.Sprintf("interrupted: instance %s stopped while the job was running, check whether vm %d was partially created", previousOwner, job.VMID)
+	}
+	end := time.Now()
+	job.Status = model.VMCreateJobStatusFailed
+	job.EndTime = &end
+	s.saveJob(ctx, job)
+	s.logger.Warn("vm create job interrupted", zap.Int64("job_id", jobID), zap.String("previous_owner", previousOwner))
+
+	if job.Creator != "" {
+		s.notificationService.Notify(context.Background(), taskFinishedNotification("vm_create", job.Id,
+			fmt.Sprintf("Creation of VM %s", job.VmName), errors.New(job.ErrorMessage)), job.Creator)
+	}
+}
+
+// execute 在后台执行创建流程
+func (s *vmCreateJobService) execute(parent context.Context, jobID int64, req *v1.CreateVMRequest) {
+	defer s.jobLeases.Release(context.Background(), model.JobTypeVMCreate, jobID)
+
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	timeout := s.conf.GetDuration("vm_create_job.timeout")
+	if timeout <= 0 {
+		timeout = defaultVMCreateJobTimeout
+	}
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	job, err := s.jobRepo.GetByID(ctx, jobID)
+	if err != nil || job == nil {
+		s.logger.Error("failed to load vm create job", zap.Int64("job_id", jobID), zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	job.Status = model.VMCreateJobStatusRunning
+	job.StartTime = &now
+	s.saveJob(ctx, job)
+
+	err = s.vmService.CreateVMInProxmox(ctx, req)
+
+	// 任务上下文可能已超时，最终状态使用新的上下文保存
+	end := time.Now()
+	job.EndTime = &end
+	if err != nil {
+		s.logger.Error("vm create job failed", zap.Int64("job_id", jobID), zap.String("vm_name", job.VmName), zap.Error(err))
+		job.Status = model.VMCreateJobStatusFailed
+		job.ErrorMessage = err.Error()
+	} else {
+		job.Status = model.VMCreateJobStatusCompleted
+		if vm, lookupErr := s.vmRepo.GetByVMID(context.Background(), job.VMID, job.NodeID); lookupErr == nil && vm != nil {
+			job.VmId = vm.Id
+		}
+		s.logger.Info("vm create job completed", zap.Int64("job_id", jobID), zap.String("vm_name", job.VmName),
+			zap.Uint32("vmid", job.VMID), zap.Int64("vm_id", job.VmId))
+	}
+	s.saveJob(context.Background(), job)
+	if job.Creator != "" {
+		s.notificationService.Notify(context.Background(), taskFinishedNotification("vm_create", job.Id,
+			fmt.Sprintf("Creation of VM %s", job.VmName), err), job.Creator)
+	}
+}
+
+func (s *vmCreateJobService) saveJob(ctx context.Context, job *model.VMCreateJob) {
+	if err := s.jobRepo.Update(ctx, job); err != nil {
+		s.logger.Error("failed to update vm create job", zap.Int64("job_id", job.Id), zap.Error(err))
+	}
+}
+
+func (s *vmCreateJobService) Get(ctx context.Context, id int64) (*v1.VMCreateJobItem, error) {
+	job, err := s.jobRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm create job", zap.Int64("job_id", id), zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if job == nil {
+		return nil, v1.ErrVMCreateJobNotFound
+	}
+	item := toVMCreateJobItem(job)
+	return &item, nil
+}
+
+func (s *vmCreateJobService) List(ctx context.Context, req *v1.ListVMCreateJobsRequest) (*v1.ListVMCreateJobsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	jobs, total, err := s.jobRepo.List(ctx, page, pageSize, req.ClusterID, req.Status, req.Creator)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm create jobs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.VMCreateJobItem, 0, len(jobs))
+	for _, job := range jobs {
+		list = append(list, toVMCreateJobItem(job))
+	}
+	return &v1.ListVMCreateJobsResponseData{Total: total, List: list}, nil
+}
+
+// detachedRequestContext 后台任务使用的上下文：请求结束后 gin.Context 会被回收复用，
+// 只保留创建流程依赖的操作人信息和变更管控紧急放行原因
+func detachedRequestContext(ctx context.Context) context.Context {
+	detached := context.Background()
+	if claims := ctx.Value("claims"); claims != nil {
+		detached = context.WithValue(detached, "claims", claims)
+	}
+	if reason := ctx.Value(v1.ChangeOverrideCtxKey); reason != nil {
+		detached = context.WithValue(detached, v1.ChangeOverrideCtxKey, reason)
+	}
+	return detached
+}
+
+func toVMCreateJobItem(job *model.VMCreateJob) v1.VMCreateJobItem {
+	return v1.VMCreateJobItem{
+		Id:           job.Id,
+		ClusterID:    job.ClusterID,
+		ClusterName:  job.ClusterName,
+		NodeID:       job.NodeID,
+		NodeName:     job.NodeName,
+		VMID:         job.VMID,
+		VmName:       job.VmName,
+		CreateMode:   job.CreateMode,
+		TemplateID:   job.TemplateID,
+		VmId:         job.VmId,
+		Status:       job.Status,
+		ErrorMessage: job.ErrorMessage,
+		StartTime:    job.StartTime,
+		EndTime:      job.EndTime,
+		Creator:      job.Creator,
+		CreateTime:   job.CreateTime,
+		UpdateTime:   job.UpdateTime,
+	}
+}
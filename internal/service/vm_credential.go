@@ -0,0 +1,510 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/secret"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// 未配置 secret.lease_ttl 时凭据租约的默认有效期
+	defaultCredentialLeaseTTL = time.Minute
+	// 单次迁移处理的明文密码数量上限，超过时需再次调用
+	credentialMigrateBatch = 1000
+)
+
+// VMCredentialService 虚拟机登录凭据：密码保存在可插拔的密钥后端（本地加密 / Vault / Kubernetes Secret），
+// pve_vm 中只保存引用；读取需先签发短期单次租约，写入、租约、读取、删除和迁移都记录审计
+//
+// 配置示例：
+//
+//	secret:
+//	  backend: vault
+//	  fallback_local: true
+//	  lease_ttl: 60s
+//	  local:
+//	    key: "base64-encoded-32-byte-key"
+//	  vault:
+//	    address: https://vault.example.com:8200
+//	    mount: secret
+type VMCredentialService interface {
+	// SetPassword 保存虚拟机密码并只更新密码相关字段，password 为空时清除已保存的密码；vm 需已落库
+	SetPassword(ctx context.Context, vm *model.PveVM, password string) error
+	// Remove 删除虚拟机时清理密钥后端中的密码
+	Remove(ctx context.Context, vm *model.PveVM)
+	// Lease 为虚拟机负责人、创建人或管理员签发读取凭据的单次租约
+	Lease(ctx context.Context, userID string, vmID int64, clientIP string) (*v1.VMCredentialLeaseData, error)
+	// Retrieve 凭租约读取密码，租约只能由签发对象使用一次
+	Retrieve(ctx context.Context, userID, token, clientIP string) (*v1.VMCredentialData, error)
+	// Reveal 读取虚拟机密码用于导出元数据，不经过租约，调用方需已校验管理员权限；未保存密码时返回空字符串
+	Reveal(ctx context.Context, operator string, vm *model.PveVM) (string, error)
+	// MigratePlaintext 将明文保存的密码迁移到当前密钥后端，仅管理员
+	MigratePlaintext(ctx context.Context, userID, clientIP string) (*v1.MigrateVMCredentialsData, error)
+	// ListAudit 查询凭据审计记录，仅管理员
+	ListAudit(ctx context.Context, userID string, req *v1.ListSecretAuditRequest) (*v1.ListSecretAuditResponseData, error)
+}
+
+func NewVMCredentialService(
+	service *Service,
+	conf *viper.Viper,
+	auditRepo repository.SecretAuditRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	sharedTokens SharedTokenStore,
+	logger *log.Logger,
+) VMCredentialService {
+	s := &vmCredentialService{
+		Service:      service,
+		conf:         conf,
+		auditRepo:    auditRepo,
+		vmRepo:       vmRepo,
+		userRepo:     userRepo,
+		sharedTokens: sharedTokens,
+		logger:       logger,
+		stores:       make(map[string]secret.Store),
+	}
+	s.initStores()
+	return s
+}
+
+type vmCredentialService struct {
+	*Service
+	conf      *viper.Viper
+	auditRepo repository.SecretAuditRepository
+	vmRepo    repository.PveVMRepository
+	userRepo  repository.UserRepository
+	// sharedTokens 保存凭据读取租约，任一实例签发的租约可在其他实例上使用
+	sharedTokens SharedTokenStore
+	logger       *log.Logger
+
+	backend  string                  // secret.backend，为空表示明文存储
+	primary  secret.Store            // 写入使用的后端，初始化失败时为空
+	fallback secret.Store            // primary 写入失败时回退的本地加密存储
+	stores   map[string]secret.Store // 按引用前缀读取
+}
+
+type credentialLease struct {
+	UserID string
+	VMId   int64
+}
+
+// initStores 按配置创建密钥后端；远程后端初始化失败时不回退明文，写入将返回 ErrSecretBackendUnavailable
+func (s *vmCredentialService) initStores() {
+	s.backend = s.conf.GetString("secret.backend")
+	timeout := s.conf.GetDuration("secret.timeout")
+
+	var local secret.Store
+	if key := s.conf.GetString("secret.local.key"); key != "" {
+		store, err := secret.NewLocalStore(key)
+		if err != nil {
+			s.logger.Error("failed to init local secret store", zap.Error(err))
+		} else {
+			local = store
+			s.stores[secret.BackendLocal] = store
+		}
+	}
+
+	switch s.backend {
+	case "":
+		s.logger.Warn("secret.backend is not configured, vm passwords are stored in plaintext")
+		return
+	case secret.BackendLocal:
+		s.primary = local
+	default:
+		store, err := secret.NewStore(secret.Config{
+			Backend: s.backend,
+			Vault: secret.VaultConfig{
+				Address:            s.conf.GetString("secret.vault.address"),
+				Token:              s.conf.GetString("secret.vault.token"),
+				Namespace:          s.conf.GetString("secret.vault.namespace"),
+				Mount:              s.conf.GetString("secret.vault.mount"),
+				Prefix:             s.conf.GetString("secret.vault.prefix"),
+				InsecureSkipVerify: s.conf.GetBool("secret.vault.insecure_skip_verify"),
+			},
+			Kubernetes: secret.KubernetesConfig{
+				Host:               s.conf.GetString("secret.kubernetes.host"),
+				Token:              s.conf.GetString("secret.kubernetes.token"),
+				CAFile:             s.conf.GetString("secret.kubernetes.ca_file"),
+				Namespace:          s.conf.GetString("secret.kubernetes.namespace"),
+				Prefix:             s.conf.GetString("secret.kubernetes.prefix"),
+				InsecureSkipVerify: s.conf.GetBool("secret.kubernetes.insecure_skip_verify"),
+			},
+			Timeout: timeout,
+		})
+		if err != nil {
+			s.logger.Error("failed to init secret backend", zap.String("backend", s.backend), zap.Error(err))
+		} else {
+			s.primary = store
+			s.stores[store.Backend()] = store
+		}
+		if s.conf.GetBool("secret.fallback_local") {
+			s.fallback = local
+		}
+	}
+	if s.primary == nil && s.fallback == nil {
+		s.logger.Error("no usable secret backend, saving vm passwords will fail", zap.String("backend", s.backend))
+	}
+}
+
+// secretName 密钥在后端中的名称，按集群和 VMID 区分，重建同一虚拟机时覆盖
+func secretName(vm *model.PveVM) string {
+	return fmt.Sprintf("vm-%d-%d", vm.ClusterID, vm.VMID)
+}
+
+// put 写入当前后端，失败时按配置回退到本地加密存储
+func (s *vmCredentialService) put(ctx context.Context, name, password string) (string, string, error) {
+	var primaryErr error
+	if s.primary != nil {
+		ref, err := s.primary.Put(ctx, name, password)
+		if err == nil {
+			return ref, s.primary.Backend(), nil
+		}
+		primaryErr = err
+	} else {
+		primaryErr = fmt.Errorf("secret backend %q is not available", s.backend)
+	}
+	if s.fallback == nil {
+		return "", s.backend, primaryErr
+	}
+	s.logger.WithContext(ctx).Warn("secret backend write failed, falling back to local store",
+		zap.String("backend", s.backend), zap.Error(primaryErr))
+	ref, err := s.fallback.Put(ctx, name, password)
+	if err != nil {
+		return "", secret.BackendLocal, err
+	}
+	return ref, secret.BackendLocal, nil
+}
+
+// storeFor 返回引用所属的后端
+func (s *vmCredentialService) storeFor(ref string) (secret.Store, error) {
+	backend, _, ok := secret.SplitRef(ref)
+	if !ok {
+		return nil, v1.WithDetail(v1.ErrSecretBackendUnavailable, "malformed secret reference")
+	}
+	store, ok := s.stores[backend]
+	if !ok {
+		return nil, v1.WithDetailf(v1.ErrSecretBackendUnavailable, "secret backend %s is not configured", backend)
+	}
+	return store, nil
+}
+
+// backendOf 返回凭据当前的存储方式
+func backendOf(vm *model.PveVM) string {
+	if vm.VmPasswordRef != "" {
+		if backend, _, ok := secret.SplitRef(vm.VmPasswordRef); ok {
+			return backend
+		}
+	}
+	return model.SecretBackendPlaintext
+}
+
+// deleteRef 删除后端中的旧密钥，失败只记录日志
+func (s *vmCredentialService) deleteRef(ctx context.Context, ref string) error {
+	if ref == "" {
+		return nil
+	}
+	store, err := s.storeFor(ref)
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, ref)
+}
+
+func (s *vmCredentialService) SetPassword(ctx context.Context, vm *model.PveVM, password string) error {
+	oldRef := vm.VmPasswordRef
+	hadPassword := oldRef != "" || vm.VmPassword != ""
+	operator := s.operator(ctx, userIDFromCtx(ctx))
+
+	if password == "" {
+		if !hadPassword {
+			return nil
+		}
+		if err := s.vmRepo.UpdatePassword(ctx, vm.Id, "", ""); err != nil {
+			s.logger.WithContext(ctx).Error("failed to clear vm password", zap.Error(err), zap.Int64("vm_id", vm.Id))
+			return v1.ErrInternalServerError
+		}
+		backend := backendOf(vm)
+		vm.VmPassword, vm.VmPasswordRef = "", ""
+		err := s.deleteRef(ctx, oldRef)
+		s.audit(ctx, vm, model.SecretActionDelete, backend, operator, "", err)
+		return nil
+	}
+
+	ref, backend := "", model.SecretBackendPlaintext
+	plain := password
+	if s.backend != "" {
+		var err error
+		ref, backend, err = s.put(ctx, secretName(vm), password)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to store vm password", zap.Error(err), zap.Int64("vm_id", vm.Id))
+			s.audit(ctx, vm, model.SecretActionWrite, backend, operator, "", err)
+			return v1.WithDetail(v1.ErrSecretBackendUnavailable, err.Error())
+		}
+		plain = ""
+	}
+
+	if err := s.vmRepo.UpdatePassword(ctx, vm.Id, plain, ref); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update vm password", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		return v1.ErrInternalServerError
+	}
+	vm.VmPassword, vm.VmPasswordRef = plain, ref
+	s.audit(ctx, vm, model.SecretActionWrite, backend, operator, "", nil)
+
+	// 同名密钥已被覆盖；后端变化（如回退到本地后恢复）时删除旧后端中的密钥
+	if oldRef != "" && oldRef != ref {
+		if err := s.deleteRef(ctx, oldRef); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to delete previous vm secret", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		}
+	}
+	return nil
+}
+
+func (s *vmCredentialService) Remove(ctx context.Context, vm *model.PveVM) {
+	if vm.VmPasswordRef == "" {
+		return
+	}
+	err := s.deleteRef(ctx, vm.VmPasswordRef)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to delete vm secret", zap.Error(err), zap.Int64("vm_id", vm.Id))
+	}
+	s.audit(ctx, vm, model.SecretActionDelete, backendOf(vm), s.operator(ctx, userIDFromCtx(ctx)), "", err)
+}
+
+func (s *vmCredentialService) Lease(ctx context.Context, userID string, vmID int64, clientIP string) (*v1.VMCredentialLeaseData, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err), zap.Int64("vm_id", vmID))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.WithDetailf(v1.ErrVMNotFound, "vm_id=%d", vmID)
+	}
+	if !canReadVMCredential(s.conf, username, vm) {
+		s.audit(ctx, vm, model.SecretActionLease, backendOf(vm), username, clientIP, v1.ErrVMCredentialForbidden)
+		return nil, v1.ErrVMCredentialForbidden
+	}
+	if vm.VmPasswordRef == "" && vm.VmPassword == "" {
+		return nil, v1.ErrVMCredentialNotFound
+	}
+
+	token, err := newConsoleToken()
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to generate credential lease token", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	ttl := s.conf.GetDuration("secret.lease_ttl")
+	if ttl <= 0 {
+		ttl = defaultCredentialLeaseTTL
+	}
+	expiresAt := time.Now().Add(ttl)
+	if err := s.sharedTokens.Put(ctx, model.SharedTokenVMCredential, token, &credentialLease{UserID: userID, VMId: vm.Id}, expiresAt); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save credential lease", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.audit(ctx, vm, model.SecretActionLease, backendOf(vm), username, clientIP, nil)
+
+	return &v1.VMCredentialLeaseData{Token: token, VMId: vm.Id, ExpiresAt: expiresAt}, nil
+}
+
+func (s *vmCredentialService) Retrieve(ctx context.Context, userID, token, clientIP string) (*v1.VMCredentialData, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	// 租约单次使用，不论是否属于当前用户都会被消费
+	var lease credentialLease
+	ok, err := s.sharedTokens.Consume(ctx, model.SharedTokenVMCredential, token, &lease)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load credential lease", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if !ok || lease.UserID != userID {
+		return nil, v1.ErrVMCredentialLeaseInvalid
+	}
+
+	vm, err := s.vmRepo.GetByID(ctx, lease.VMId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err), zap.Int64("vm_id", lease.VMId))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.WithDetailf(v1.ErrVMNotFound, "vm_id=%d", lease.VMId)
+	}
+
+	backend := backendOf(vm)
+	password := vm.VmPassword
+	if vm.VmPasswordRef != "" {
+		store, err := s.storeFor(vm.VmPasswordRef)
+		if err == nil {
+			password, err = store.Get(ctx, vm.VmPasswordRef)
+		}
+		if err != nil {
+			s.audit(ctx, vm, model.SecretActionRead, backend, username, clientIP, err)
+			if errors.Is(err, secret.ErrNotFound) {
+				return nil, v1.ErrVMCredentialNotFound
+			}
+			s.logger.WithContext(ctx).Error("failed to read vm secret", zap.Error(err), zap.Int64("vm_id", vm.Id))
+			if errors.Is(err, v1.ErrSecretBackendUnavailable) {
+				return nil, err
+			}
+			return nil, v1.WithDetail(v1.ErrSecretBackendUnavailable, err.Error())
+		}
+	}
+	if password == "" {
+		s.audit(ctx, vm, model.SecretActionRead, backend, username, clientIP, v1.ErrVMCredentialNotFound)
+		return nil, v1.ErrVMCredentialNotFound
+	}
+	s.audit(ctx, vm, model.SecretActionRead, backend, username, clientIP, nil)
+
+	return &v1.VMCredentialData{
+		VMId:     vm.Id,
+		VmName:   vm.VmName,
+		VmUser:   vm.VmUser,
+		Password: password,
+		Backend:  backend,
+	}, nil
+}
+
+func (s *vmCredentialService) Reveal(ctx context.Context, operator string, vm *model.PveVM) (string, error) {
+	if vm.VmPasswordRef == "" {
+		return vm.VmPassword, nil
+	}
+	store, err := s.storeFor(vm.VmPasswordRef)
+	if err != nil {
+		return "", err
+	}
+	password, err := store.Get(ctx, vm.VmPasswordRef)
+	s.audit(ctx, vm, model.SecretActionRead, backendOf(vm), operator, "", err)
+	if err != nil && !errors.Is(err, secret.ErrNotFound) {
+		return "", v1.WithDetail(v1.ErrSecretBackendUnavailable, err.Error())
+	}
+	return password, nil
+}
+
+func (s *vmCredentialService) MigratePlaintext(ctx context.Context, userID, clientIP string) (*v1.MigrateVMCredentialsData, error) {
+	username, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	if s.backend == "" {
+		return nil, v1.WithDetail(v1.ErrBadRequest, "secret.backend is not configured")
+	}
+
+	vms, err := s.vmRepo.ListWithPlaintextPassword(ctx, credentialMigrateBatch)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list plaintext vm passwords", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	data := &v1.MigrateVMCredentialsData{Backend: s.backend}
+	for _, vm := range vms {
+		ref, backend, err := s.put(ctx, secretName(vm), vm.VmPassword)
+		if err == nil {
+			err = s.vmRepo.UpdatePassword(ctx, vm.Id, "", ref)
+		}
+		s.audit(ctx, vm, model.SecretActionMigrate, backend, username, clientIP, err)
+		if err != nil {
+			data.Failed++
+			data.Errors = append(data.Errors, fmt.Sprintf("vm %d (%s): %v", vm.Id, vm.VmName, err))
+			continue
+		}
+		data.Migrated++
+	}
+	s.logger.WithContext(ctx).Info("vm passwords migrated to secret backend",
+		zap.String("backend", s.backend), zap.Int("migrated", data.Migrated), zap.Int("failed", data.Failed))
+	return data, nil
+}
+
+func (s *vmCredentialService) ListAudit(ctx context.Context, userID string, req *v1.ListSecretAuditRequest) (*v1.ListSecretAuditResponseData, error) {
+	if _, err := requireAdminUser(ctx, s.conf, s.userRepo, s.logger, userID); err != nil {
+		return nil, err
+	}
+
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	logs, total, err := s.auditRepo.ListWithPagination(ctx, page, pageSize, req.VMId, req.Action, req.Operator)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list secret audit logs", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]*v1.SecretAuditItem, 0, len(logs))
+	for _, l := range logs {
+		list = append(list, &v1.SecretAuditItem{
+			Id:         l.Id,
+			VMId:       l.VmId,
+			VmName:     l.VmName,
+			Action:     l.Action,
+			Backend:    l.Backend,
+			Operator:   l.Operator,
+			ClientIP:   l.ClientIP,
+			Success:    l.Success,
+			Message:    l.Message,
+			CreateTime: l.CreateTime,
+		})
+	}
+	return &v1.ListSecretAuditResponseData{Total: total, List: list}, nil
+}
+
+// canReadVMCredential 管理员、虚拟机负责人和创建人可以读取凭据
+func canReadVMCredential(conf *viper.Viper, username string, vm *model.PveVM) bool {
+	if username == vm.Owner || (vm.Creator != "" && username == vm.Creator) {
+		return true
+	}
+	return isAdminUsername(conf, username)
+}
+
+// operator 审计记录中的操作人，后台任务或用户不存在时为 system
+func (s *vmCredentialService) operator(ctx context.Context, userID string) string {
+	if userID == "" {
+		return "system"
+	}
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return "system"
+	}
+	return username
+}
+
+// audit 写入凭据审计记录，失败只记录日志，不影响业务
+func (s *vmCredentialService) audit(ctx context.Context, vm *model.PveVM, action, backend, operator, clientIP string, cause error) {
+	entry := &model.SecretAuditLog{
+		VmId:     vm.Id,
+		VmName:   vm.VmName,
+		Action:   action,
+		Backend:  backend,
+		Operator: operator,
+		ClientIP: clientIP,
+		Success:  cause == nil,
+	}
+	if cause != nil {
+		entry.Message = cause.Error()
+		if len(entry.Message) > 500 {
+			entry.Message = entry.Message[:500]
+		}
+	}
+	if err := s.auditRepo.Create(ctx, entry); err != nil {
+		s.logger.WithContext(ctx).Error("failed to write secret audit log", zap.Error(err),
+			zap.Int64("vm_id", vm.Id), zap.String("action", action))
+	}
+}
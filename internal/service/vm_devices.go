@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox"
+)
+
+// vmDiskBusSlots 各总线可用的磁盘槽位数（scsi0-30、virtio0-15、sata0-5、ide0-3）
+var vmDiskBusSlots = map[string]int{"scsi": 31, "virtio": 16, "sata": 6, "ide": 4}
+
+// vmDeviceKeyPattern 虚拟机配置中占用总线槽位的设备（磁盘、光驱、cloud-init 盘等）
+var vmDeviceKeyPattern = regexp.MustCompile(`^(scsi|virtio|sata|ide)\d+$`)
+
+func diskSpecBus(disk v1.VMDiskSpec) string {
+	if bus := strings.TrimSpace(disk.Bus); bus != "" {
+		return bus
+	}
+	return "scsi"
+}
+
+// diskSpecStorage 磁盘所在存储：磁盘单独指定 > 默认存储
+func diskSpecStorage(disk v1.VMDiskSpec, fallback string) string {
+	if storage := strings.TrimSpace(disk.Storage); storage != "" {
+		return storage
+	}
+	return strings.TrimSpace(fallback)
+}
+
+// diskSpecValue 新建磁盘的配置值：<storage>:<sizeGB>[,format=..][,ssd=1][,iothread=1]
+func diskSpecValue(disk v1.VMDiskSpec, storage string) string {
+	value := fmt.Sprintf("%s:%d", storage, disk.SizeGB)
+	if format := strings.TrimSpace(disk.Format); format != "" {
+		value += ",format=" + format
+	}
+	if disk.SSD != nil && *disk.SSD {
+		value += ",ssd=1"
+	}
+	if disk.IOThread != nil && *disk.IOThread {
+		value += ",iothread=1"
+	}
+	return value
+}
+
+// checkDiskSpecs 校验磁盘选项与总线的组合
+func checkDiskSpecs(disks []v1.VMDiskSpec) []string {
+	var issues []string
+	for i, disk := range disks {
+		bus := diskSpecBus(disk)
+		if disk.SSD != nil && *disk.SSD && bus == "virtio" {
+			issues = append(issues, fmt.Sprintf("disks[%d]: ssd is not supported on the virtio bus", i))
+		}
+		if disk.IOThread != nil && *disk.IOThread && bus != "scsi" && bus != "virtio" {
+			issues = append(issues, fmt.Sprintf("disks[%d]: iothread is only supported on the scsi and virtio buses", i))
+		}
+	}
+	return issues
+}
+
+// assignDiskSlots 按顺序为磁盘分配所在总线上第一个未被占用的槽位
+func assignDiskSlots(used map[string]bool, disks []v1.VMDiskSpec) ([]string, error) {
+	taken := make(map[string]bool, len(used)+len(disks))
+	for key, ok := range used {
+		taken[key] = ok
+	}
+	slots := make([]string, 0, len(disks))
+	for i, disk := range disks {
+		bus := diskSpecBus(disk)
+		slot := ""
+		for n := 0; n < vmDiskBusSlots[bus]; n++ {
+			if key := fmt.Sprintf("%s%d", bus, n); !taken[key] {
+				slot = key
+				break
+			}
+		}
+		if slot == "" {
+			return nil, v1.WithDetailf(v1.ErrCreateVMValidationFailed, "disks[%d]: no free %s slot", i, bus)
+		}
+		taken[slot] = true
+		slots = append(slots, slot)
+	}
+	return slots, nil
+}
+
+// usedDeviceSlots 虚拟机配置中已占用的磁盘总线槽位
+func usedDeviceSlots(config map[string]interface{}) map[string]bool {
+	used := make(map[string]bool)
+	for key := range config {
+		if vmDeviceKeyPattern.MatchString(key) {
+			used[key] = true
+		}
+	}
+	return used
+}
+
+func nicSpecModel(nic v1.VMNICSpec) string {
+	if model := strings.TrimSpace(nic.Model); model != "" {
+		return model
+	}
+	return "virtio"
+}
+
+// nicSpecValue 网卡配置值：<model>=<mac>,bridge=<bridge>[,tag=<vlan>][,firewall=1]
+func nicSpecValue(nic v1.VMNICSpec, mac string) string {
+	value := nicSpecModel(nic)
+	if mac != "" {
+		value += "=" + mac
+	}
+	value += ",bridge=" + strings.TrimSpace(nic.Bridge)
+	if nic.VLANTag != nil && *nic.VLANTag > 0 {
+		value += fmt.Sprintf(",tag=%d", *nic.VLANTag)
+	}
+	if nic.Firewall != nil && *nic.Firewall {
+		value += ",firewall=1"
+	}
+	return value
+}
+
+// setNICOptions 修改网卡的 VLAN 标签和防火墙：vlanTag 为 0 时移除标签，firewall 为 false 时关闭防火墙，nil 保持不变
+func setNICOptions(dev *proxmox.DeviceConfig, vlanTag *int, firewall *bool) {
+	if vlanTag != nil {
+		if *vlanTag > 0 {
+			dev.Set("tag", strconv.Itoa(*vlanTag))
+		} else {
+			dev.Del("tag")
+		}
+	}
+	if firewall != nil {
+		if *firewall {
+			dev.Set("firewall", "1")
+		} else {
+			dev.Del("firewall")
+		}
+	}
+}
+
+// allocateNICMACs 为每个网卡分配登记过的 MAC，任一失败时释放已分配的地址
+func (s *pveVMService) allocateNICMACs(ctx context.Context, clusterID int64, vmID uint32, hostname string, nics []v1.VMNICSpec) ([]string, error) {
+	macs := make([]string, 0, len(nics))
+	for i, nic := range nics {
+		mac, err := s.macRegistry.Allocate(ctx, &MACAllocation{
+			MAC:       strings.TrimSpace(nic.MACAddress),
+			ClusterID: clusterID,
+			VMID:      vmID,
+			NicName:   fmt.Sprintf("net%d", i),
+			Hostname:  hostname,
+		})
+		if err != nil {
+			s.releaseMACs(ctx, macs)
+			return nil, err
+		}
+		macs = append(macs, mac)
+	}
+	return macs, nil
+}
+
+func (s *pveVMService) releaseMACs(ctx context.Context, macs []string) {
+	for _, mac := range macs {
+		s.macRegistry.Release(ctx, mac)
+	}
+}
+
+func (s *pveVMService) bindMACs(ctx context.Context, macs []string, vmID int64) {
+	for _, mac := range macs {
+		s.macRegistry.BindVM(ctx, mac, vmID)
+	}
+}
+
+// vmMaxSerialPorts Proxmox 支持的串口数量（serial0-3）
+const vmMaxSerialPorts = 4
+
+// checkConsoleOptions 校验串口数量与显示设备的组合：display=serialN 时需要 serialN 存在
+func checkConsoleOptions(serialNum *int, display string) string {
+	index, ok := serialDisplayIndex(display)
+	if ok && serialNum != nil && *serialNum <= index {
+		return fmt.Sprintf("display %s requires serial_num to be at least %d", display, index+1)
+	}
+	return ""
+}
+
+func serialDisplayIndex(display string) (int, bool) {
+	if !strings.HasPrefix(display, "serial") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(display, "serial"))
+	return index, err == nil
+}
+
+// vmConsoleConfig 串口与显示设备配置：serialNum 为虚拟机应有的串口数量（serial0..N-1，nil 表示不修改），
+// existing 中多余的串口会被删除；display 为 serialN 且未指定串口数量时只添加该串口
+func vmConsoleConfig(serialNum *int, display string, existing map[string]interface{}) map[string]interface{} {
+	config := make(map[string]interface{})
+	if serialNum != nil {
+		var deletes []string
+		for i := 0; i < vmMaxSerialPorts; i++ {
+			key := fmt.Sprintf("serial%d", i)
+			if i < *serialNum {
+				config[key] = "socket"
+			} else if existing[key] != nil {
+				deletes = append(deletes, key)
+			}
+		}
+		if len(deletes) > 0 {
+			config["delete"] = strings.Join(deletes, ",")
+		}
+	} else if index, ok := serialDisplayIndex(display); ok && existing[fmt.Sprintf("serial%d", index)] == nil {
+		config[fmt.Sprintf("serial%d", index)] = "socket"
+	}
+	if display != "" {
+		config["vga"] = display
+	}
+	return config
+}
+
+// cloudImageConsoleDefaults 云镜像模板（带 cloud-init 盘）通常只向串口输出控制台，
+// 模板没有串口时默认添加 serial0，未设置显示设备时显示设为 serial0
+func cloudImageConsoleDefaults(templateConfig map[string]interface{}) map[string]interface{} {
+	if templateConfig["serial0"] != nil {
+		return nil
+	}
+	hasCloudInit := false
+	for key, raw := range templateConfig {
+		if value, _ := raw.(string); vmDeviceKeyPattern.MatchString(key) && strings.Contains(value, "cloudinit") {
+			hasCloudInit = true
+			break
+		}
+	}
+	if !hasCloudInit {
+		return nil
+	}
+	config := map[string]interface{}{"serial0": "socket"}
+	if templateConfig["vga"] == nil {
+		config["vga"] = "serial0"
+	}
+	return config
+}
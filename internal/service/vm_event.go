@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// vmEventSourceLimit 每个来源最多读取的事件数，时间线在合并后分页
+const vmEventSourceLimit = 500
+
+// VMEventService 虚拟机事件时间线：合并 Proxmox 任务、PveSphere 操作记录和检测到的状态变化
+type VMEventService interface {
+	ListEvents(ctx context.Context, vmID int64, req *v1.ListVMEventsRequest) (*v1.ListVMEventsResponseData, error)
+}
+
+func NewVMEventService(
+	service *Service,
+	conf *viper.Viper,
+	vmRepo repository.PveVMRepository,
+	clusterRepo repository.PveClusterRepository,
+	auditRepo repository.OperationAuditRepository,
+	eventRepo repository.VMStatusEventRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) VMEventService {
+	return &vmEventService{
+		Service:     service,
+		conf:        conf,
+		vmRepo:      vmRepo,
+		clusterRepo: clusterRepo,
+		auditRepo:   auditRepo,
+		eventRepo:   eventRepo,
+		userRepo:    userRepo,
+		logger:      logger,
+	}
+}
+
+type vmEventService struct {
+	*Service
+	conf        *viper.Viper
+	vmRepo      repository.PveVMRepository
+	clusterRepo repository.PveClusterRepository
+	auditRepo   repository.OperationAuditRepository
+	eventRepo   repository.VMStatusEventRepository
+	userRepo    repository.UserRepository
+	logger      *log.Logger
+}
+
+func (s *vmEventService) ListEvents(ctx context.Context, vmID int64, req *v1.ListVMEventsRequest) (*v1.ListVMEventsResponseData, error) {
+	username, own, err := operationViewer(ctx, s.conf, s.userRepo, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.ErrVMNotFound
+	}
+
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	wants := func(source string) bool { return req.Source == "" || req.Source == source }
+
+	data := &v1.ListVMEventsResponseData{}
+	var events []v1.VMEventItem
+
+	// 操作记录按任务 UPID 关联，因此即使只查看 audit 也需要先取任务列表
+	var tasks []v1.VMEventItem
+	if wants(v1.VMEventSourceTask) || wants(v1.VMEventSourceAudit) {
+		tasks, err = s.listTasks(ctx, vm)
+		if err != nil {
+			data.Warnings = append(data.Warnings, fmt.Sprintf("proxmox tasks: %v", err))
+		}
+	}
+
+	if wants(v1.VMEventSourceAudit) {
+		upids := make([]string, 0, len(tasks))
+		for _, task := range tasks {
+			upids = append(upids, task.UPID)
+		}
+		owner := ""
+		if own {
+			owner = username
+		}
+		audits, err := s.auditRepo.ListForVM(ctx, fmt.Sprintf("/api/v1/vms/%d", vm.Id), upids, owner, vmEventSourceLimit)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list operation audits", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		operationByUPID := make(map[string]string)
+		for _, audit := range audits {
+			item := auditEvent(audit)
+			for _, upid := range auditTasks(audit) {
+				operationByUPID[upid] = audit.OperationID
+			}
+			events = append(events, item)
+		}
+		for i := range tasks {
+			tasks[i].OperationID = operationByUPID[tasks[i].UPID]
+		}
+	}
+	if wants(v1.VMEventSourceTask) {
+		events = append(events, tasks...)
+	}
+
+	if wants(v1.VMEventSourceStatus) {
+		changes, err := s.eventRepo.ListByVM(ctx, vm.ClusterID, vm.VMID, vmEventSourceLimit)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list vm status events", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		for _, change := range changes {
+			events = append(events, v1.VMEventItem{
+				Time:    change.CreateTime,
+				Source:  v1.VMEventSourceStatus,
+				Type:    "status_change",
+				Summary: statusEventSummary(change),
+				Status:  change.NewStatus,
+				Node:    change.NodeName,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.After(events[j].Time) })
+	data.Total = int64(len(events))
+	data.List = []v1.VMEventItem{}
+	if start := (page - 1) * pageSize; start < len(events) {
+		end := start + pageSize
+		if end > len(events) {
+			end = len(events)
+		}
+		data.List = events[start:end]
+	}
+	return data, nil
+}
+
+// statusEventSummary 状态变化摘要；虚拟机出现在节点上时没有旧状态
+func statusEventSummary(change *model.VMStatusEvent) string {
+	if change.NewStatus == model.VMStatusEventAdded {
+		return fmt.Sprintf("added on %s", change.NodeName)
+	}
+	return fmt.Sprintf("%s -> %s", change.OldStatus, change.NewStatus)
+}
+
+// listTasks 从集群任务列表中筛选该虚拟机的任务（按 VMID 匹配，包括在 Proxmox 中直接执行的操作）
+func (s *vmEventService) listTasks(ctx context.Context, vm *model.PveVM) ([]v1.VMEventItem, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", vm.ClusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.GetClusterTasks(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get cluster tasks", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+		return nil, err
+	}
+
+	vmid := strconv.FormatUint(uint64(vm.VMID), 10)
+	tasks := make([]v1.VMEventItem, 0)
+	for _, task := range list {
+		if id, _ := task["id"].(string); id != vmid {
+			continue
+		}
+		item := v1.VMEventItem{Source: v1.VMEventSourceTask}
+		item.Type, _ = task["type"].(string)
+		item.User, _ = task["user"].(string)
+		item.Node, _ = task["node"].(string)
+		item.UPID, _ = task["upid"].(string)
+		item.Status, _ = task["status"].(string)
+		if start, ok := task["starttime"].(float64); ok {
+			item.Time = time.Unix(int64(start), 0)
+		}
+		if end, ok := task["endtime"].(float64); ok {
+			endTime := time.Unix(int64(end), 0)
+			item.EndTime = &endTime
+		}
+		item.Summary = fmt.Sprintf("%s by %s", item.Type, item.User)
+		tasks = append(tasks, item)
+	}
+	return tasks, nil
+}
+
+func auditEvent(audit *model.OperationAudit) v1.VMEventItem {
+	route := audit.Route
+	if route == "" {
+		route = audit.Path
+	}
+	return v1.VMEventItem{
+		Time:        audit.CreateTime,
+		Source:      v1.VMEventSourceAudit,
+		Type:        route,
+		Summary:     fmt.Sprintf("%s %s", audit.Method, audit.Path),
+		Status:      strconv.Itoa(audit.StatusCode),
+		User:        audit.Username,
+		OperationID: audit.OperationID,
+	}
+}
+
+func auditTasks(audit *model.OperationAudit) []string {
+	var tasks []string
+	if audit.Tasks != "" {
+		_ = json.Unmarshal([]byte(audit.Tasks), &tasks)
+	}
+	return tasks
+}
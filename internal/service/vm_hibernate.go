@@ -0,0 +1,308 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// defaultVMHibernateTimeout 等待休眠任务结束的默认时长：内存全部写入 vmstate 卷，内存较大时耗时较长
+const defaultVMHibernateTimeout = 10 * time.Minute
+
+// proxmoxLockHibernated 休眠完成后虚拟机配置中的 lock
+const proxmoxLockHibernated = "suspended"
+
+// VMHibernateService 虚拟机休眠（挂起到磁盘）与恢复，以及查询休眠中的虚拟机
+type VMHibernateService interface {
+	Hibernate(ctx context.Context, vmID int64, req *v1.HibernateVMRequest) (*v1.HibernateVMData, error)
+	Resume(ctx context.Context, vmID int64) (*v1.ResumeVMData, error)
+	ListHibernated(ctx context.Context, req *v1.ListHibernatedVMsRequest) (*v1.ListHibernatedVMsData, error)
+}
+
+func NewVMHibernateService(
+	service *Service,
+	conf *viper.Viper,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	changeControl ChangeControlService,
+	vmLock VMLockService,
+	taskTracker VMTaskTracker,
+	logger *log.Logger,
+) VMHibernateService {
+	return &vmHibernateService{
+		Service:       service,
+		conf:          conf,
+		vmRepo:        vmRepo,
+		nodeRepo:      nodeRepo,
+		clusterRepo:   clusterRepo,
+		changeControl: changeControl,
+		vmLock:        vmLock,
+		taskTracker:   taskTracker,
+		logger:        logger,
+	}
+}
+
+type vmHibernateService struct {
+	*Service
+	conf          *viper.Viper
+	vmRepo        repository.PveVMRepository
+	nodeRepo      repository.PveNodeRepository
+	clusterRepo   repository.PveClusterRepository
+	changeControl ChangeControlService
+	vmLock        VMLockService
+	taskTracker   VMTaskTracker
+	logger        *log.Logger
+}
+
+func (s *vmHibernateService) Hibernate(ctx context.Context, vmID int64, req *v1.HibernateVMRequest) (*v1.HibernateVMData, error) {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	if vm.Status == "stopped" {
+		return nil, v1.ErrVMAlreadyStopped
+	}
+	if err := s.authorize(ctx, "vm.hibernate", vm); err != nil {
+		return nil, err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.hibernate"); err != nil {
+		return nil, err
+	}
+
+	stateStorage := ""
+	if req != nil {
+		stateStorage = strings.TrimSpace(req.StateStorage)
+	}
+	if stateStorage != "" {
+		if err := s.checkStateStorage(ctx, client, node.NodeName, stateStorage); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("hibernating vm", zap.Uint32("vmid", vm.VMID),
+		zap.String("node", node.NodeName), zap.String("state_storage", stateStorage))
+	upid, err := client.SuspendVM(ctx, node.NodeName, vm.VMID, true, stateStorage)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to hibernate vm", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "hibernate vm: %v", err)
+	}
+
+	// 休眠完成后虚拟机停止，任务结束后更新数据库中的状态
+	s.taskTracker.Track(ctx, &VMPowerTask{
+		VM:       vm,
+		NodeName: node.NodeName,
+		Client:   client,
+		UPID:     upid,
+		Action:   "hibernate",
+		Expected: "stopped",
+		Timeout:  defaultVMHibernateTimeout,
+	})
+	return &v1.HibernateVMData{UPID: upid, StateStorage: stateStorage}, nil
+}
+
+func (s *vmHibernateService) Resume(ctx context.Context, vmID int64) (*v1.ResumeVMData, error) {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	status, err := client.GetVMStatus(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm status", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm status: %v", err)
+	}
+	if lock, _ := status["lock"].(string); lock != proxmoxLockHibernated {
+		current, _ := status["status"].(string)
+		return nil, v1.WithDetailf(v1.ErrVMNotHibernated, "status=%s, lock=%s", current, lock)
+	}
+	if err := s.authorize(ctx, "vm.resume", vm); err != nil {
+		return nil, err
+	}
+
+	// 休眠的虚拟机启动即恢复，Proxmox 加载 vmstate 后删除该卷并释放 lock
+	s.logger.WithContext(ctx).Info("resuming hibernated vm", zap.Uint32("vmid", vm.VMID), zap.String("node", node.NodeName))
+	upid, err := client.StartVM(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to resume vm", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "resume vm: %v", err)
+	}
+
+	s.taskTracker.Track(ctx, &VMPowerTask{
+		VM:       vm,
+		NodeName: node.NodeName,
+		Client:   client,
+		UPID:     upid,
+		Action:   "resume",
+		Expected: "running",
+		Timeout:  defaultVMHibernateTimeout,
+	})
+	return &v1.ResumeVMData{UPID: upid}, nil
+}
+
+func (s *vmHibernateService) ListHibernated(ctx context.Context, req *v1.ListHibernatedVMsRequest) (*v1.ListHibernatedVMsData, error) {
+	var clusters []*model.PveCluster
+	if req.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+		}
+		clusters = []*model.PveCluster{cluster}
+	} else {
+		var err error
+		if clusters, err = s.clusterRepo.List(ctx); err != nil {
+			s.logger.WithContext(ctx).Error("failed to list clusters", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+	}
+
+	data := &v1.ListHibernatedVMsData{Items: make([]v1.HibernatedVMItem, 0)}
+	for _, cluster := range clusters {
+		items, err := s.clusterHibernated(ctx, cluster)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to list hibernated vms", zap.Error(err),
+				zap.String("cluster", cluster.ClusterName))
+			data.FailedClusters = append(data.FailedClusters, cluster.ClusterName)
+			continue
+		}
+		data.Items = append(data.Items, items...)
+	}
+	sort.SliceStable(data.Items, func(i, j int) bool {
+		if data.Items[i].ClusterID != data.Items[j].ClusterID {
+			return data.Items[i].ClusterID < data.Items[j].ClusterID
+		}
+		return data.Items[i].VMID < data.Items[j].VMID
+	})
+	return data, nil
+}
+
+// clusterHibernated 从集群资源中找出 lock 为 suspended 的虚拟机，读取配置中的 vmstate 卷，
+// 并关联 PveSphere 中的虚拟机记录
+func (s *vmHibernateService) clusterHibernated(ctx context.Context, cluster *model.PveCluster) ([]v1.HibernatedVMItem, error) {
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return nil, err
+	}
+	resources, err := client.GetClusterResourcesByType(ctx, "vm")
+	if err != nil {
+		return nil, err
+	}
+
+	var vmsByVMID map[uint32]*model.PveVM
+	nodeIDs := make(map[string]int64)
+	items := make([]v1.HibernatedVMItem, 0)
+	for _, res := range resources {
+		if lock, _ := res["lock"].(string); lock != proxmoxLockHibernated {
+			continue
+		}
+		if resType, _ := res["type"].(string); resType != "qemu" {
+			continue
+		}
+		vmidFloat, _ := res["vmid"].(float64)
+		vmid := uint32(vmidFloat)
+		nodeName, _ := res["node"].(string)
+		name, _ := res["name"].(string)
+		maxMem, _ := res["maxmem"].(float64)
+		item := v1.HibernatedVMItem{
+			VMID:        vmid,
+			VmName:      name,
+			ClusterID:   cluster.Id,
+			ClusterName: cluster.ClusterName,
+			NodeName:    nodeName,
+			MemoryMB:    int64(maxMem) >> 20,
+		}
+
+		config, err := client.GetVMConfig(ctx, nodeName, vmid)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to get vm config", zap.Error(err),
+				zap.String("node", nodeName), zap.Uint32("vmid", vmid))
+		} else if volume, _ := config["vmstate"].(string); volume != "" {
+			item.StateVolume = volume
+			item.StateStorage, _, _ = strings.Cut(volume, ":")
+		}
+
+		if vmsByVMID == nil {
+			vmsByVMID = make(map[uint32]*model.PveVM)
+			vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to list vms", zap.Error(err))
+				return nil, err
+			}
+			for _, vm := range vms {
+				vmsByVMID[vm.VMID] = vm
+			}
+		}
+		if vm := vmsByVMID[vmid]; vm != nil {
+			item.VmId, item.NodeID, item.Owner = vm.Id, vm.NodeID, vm.Owner
+		} else if nodeID, ok := nodeIDs[nodeName]; ok {
+			item.NodeID = nodeID
+		} else if node, err := s.nodeRepo.GetByNodeName(ctx, nodeName, cluster.Id); err == nil && node != nil {
+			item.NodeID = node.Id
+			nodeIDs[nodeName] = node.Id
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// checkStateStorage 校验状态存储存在于节点、已启用且支持 images（vmstate 卷与磁盘镜像同类）
+func (s *vmHibernateService) checkStateStorage(ctx context.Context, client *proxmox.ProxmoxClient, nodeName, name string) error {
+	storages, err := client.GetNodeStorages(ctx, nodeName, "")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list node storages", zap.Error(err), zap.String("node", nodeName))
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "list node storages: %v", err)
+	}
+	var storage map[string]interface{}
+	for _, item := range storages {
+		if storageName, _ := item["storage"].(string); storageName == name {
+			storage = item
+			break
+		}
+	}
+	if issues := checkCreateVMStorage(storage, name, nodeName, "images"); len(issues) > 0 {
+		return v1.WithDetailf(v1.ErrVMStateStorageInvalid, "%s", strings.Join(issues, "; "))
+	}
+	return nil
+}
+
+func (s *vmHibernateService) authorize(ctx context.Context, action string, vm *model.PveVM) error {
+	return s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    action,
+		Target:    vm.VmName,
+		ClusterID: vm.ClusterID,
+		AppId:     vm.AppId,
+		VMId:      vm.Id,
+	})
+}
+
+func (s *vmHibernateService) vmClient(ctx context.Context, vmID int64) (*model.PveVM, *model.PveNode, *proxmox.ProxmoxClient, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, nil, nil, v1.ErrVMNotFound
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return vm, node, client, nil
+}
@@ -0,0 +1,805 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 导入任务默认超时与并发数，可通过 vm_import.timeout / vm_import.concurrency 调整
+const (
+	defaultVMImportTimeout     = 24 * time.Hour
+	defaultVMImportConcurrency = 2
+)
+
+// vmImportStorageIDPattern Proxmox 存储 ID 规则
+var vmImportStorageIDPattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9\-_.]*$`)
+
+// vmImportProgressPattern 匹配导入任务日志中的磁盘传输进度，如 "transferred 1.0 GiB of 32.0 GiB (3.12%)"
+var vmImportProgressPattern = regexp.MustCompile(`\(([0-9.]+)%\)`)
+
+type VMImportService interface {
+	// ESXi 导入源（仅管理员）
+	CreateSource(ctx context.Context, userID string, req *v1.CreateVMImportSourceRequest) (*v1.VMImportSourceItem, error)
+	ListSources(ctx context.Context, req *v1.ListVMImportSourcesRequest) ([]v1.VMImportSourceItem, error)
+	DeleteSource(ctx context.Context, userID string, id int64) error
+	ListSourceVMs(ctx context.Context, id int64, req *v1.ListImportableVMsRequest) ([]v1.ImportableVM, error)
+
+	// 导入元数据与导入任务
+	GetMetadata(ctx context.Context, req *v1.GetVMImportMetadataRequest) (*v1.VMImportMetadata, error)
+	CreateImport(ctx context.Context, req *v1.CreateVMImportRequest, creator string) (*v1.VMImportTaskDetail, error)
+	GetImport(ctx context.Context, id int64) (*v1.VMImportTaskDetail, error)
+	ListImports(ctx context.Context, req *v1.ListVMImportTasksRequest) (*v1.ListVMImportTasksResponseData, error)
+}
+
+func NewVMImportService(
+	service *Service,
+	conf *viper.Viper,
+	importRepo repository.VmImportRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	changeControl ChangeControlService,
+	notificationService NotificationService,
+	logger *log.Logger,
+) VMImportService {
+	concurrency := conf.GetInt("vm_import.concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultVMImportConcurrency
+	}
+	return &vmImportService{
+		conf:                conf,
+		importRepo:          importRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		vmRepo:              vmRepo,
+		userRepo:            userRepo,
+		changeControl:       changeControl,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+		slots:               make(chan struct{}, concurrency),
+	}
+}
+
+type vmImportService struct {
+	conf          *viper.Viper
+	importRepo    repository.VmImportRepository
+	clusterRepo   repository.PveClusterRepository
+	nodeRepo      repository.PveNodeRepository
+	vmRepo        repository.PveVMRepository
+	userRepo      repository.UserRepository
+	changeControl ChangeControlService
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+
+	// 导入会占用大量网络和存储带宽，限制同时执行的任务数，其余任务保持 pending 排队
+	slots chan struct{}
+}
+
+// getClusterClient 获取集群及其 Proxmox 客户端
+func (s *vmImportService) getClusterClient(ctx context.Context, clusterID int64) (*model.PveCluster, *proxmox.ProxmoxClient, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	return cluster, client, nil
+}
+
+// getNodeClient 获取节点、所属集群及 Proxmox 客户端
+func (s *vmImportService) getNodeClient(ctx context.Context, nodeID int64) (*model.PveNode, *model.PveCluster, *proxmox.ProxmoxClient, error) {
+	node, err := s.nodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, nil, nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", nodeID)
+	}
+	cluster, client, err := s.getClusterClient(ctx, node.ClusterID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return node, cluster, client, nil
+}
+
+func (s *vmImportService) CreateSource(ctx context.Context, userID string, req *v1.CreateVMImportSourceRequest) (*v1.VMImportSourceItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_import.manage")
+	if err != nil {
+		return nil, err
+	}
+	if !vmImportStorageIDPattern.MatchString(req.Name) {
+		return nil, v1.WithDetailf(v1.ErrVMImportInvalidSpec, "name %q must start with a letter and contain only letters, digits, '-', '_' and '.'", req.Name)
+	}
+
+	existing, err := s.importRepo.GetSourceByName(ctx, req.ClusterID, req.Name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm import source", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return nil, v1.WithDetail(v1.ErrVMImportSourceExists, req.Name)
+	}
+
+	_, client, err := s.getClusterClient(ctx, req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+
+	skipCertVerify := int8(1)
+	if req.SkipCertVerify != nil {
+		skipCertVerify = *req.SkipCertVerify
+	}
+	params := url.Values{}
+	params.Set("storage", req.Name)
+	params.Set("type", "esxi")
+	params.Set("server", req.Server)
+	params.Set("username", req.Username)
+	params.Set("password", req.Password)
+	params.Set("skip-cert-verification", strconv.Itoa(int(skipCertVerify)))
+	if err := client.CreateStorage(ctx, params); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create esxi storage", zap.Error(err), zap.String("name", req.Name))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "create esxi storage: %v", err)
+	}
+
+	source := &model.VmImportSource{
+		ClusterID:      req.ClusterID,
+		Name:           req.Name,
+		Server:         req.Server,
+		Username:       req.Username,
+		Password:       req.Password,
+		SkipCertVerify: skipCertVerify,
+		Creator:        username,
+	}
+	if err := s.importRepo.CreateSource(ctx, source); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vm import source", zap.Error(err))
+		// 回滚 Proxmox 侧的存储配置，避免残留
+		if derr := client.DeleteStorage(ctx, req.Name); derr != nil {
+			s.logger.WithContext(ctx).Warn("failed to rollback esxi storage", zap.Error(derr), zap.String("name", req.Name))
+		}
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("vm import source created",
+		zap.Int64("cluster_id", req.ClusterID), zap.String("name", req.Name), zap.String("server", req.Server), zap.String("operator", username))
+	item := toVMImportSourceItem(source)
+	return &item, nil
+}
+
+func (s *vmImportService) ListSources(ctx context.Context, req *v1.ListVMImportSourcesRequest) ([]v1.VMImportSourceItem, error) {
+	sources, err := s.importRepo.ListSources(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm import sources", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.VMImportSourceItem, 0, len(sources))
+	for _, source := range sources {
+		list = append(list, toVMImportSourceItem(source))
+	}
+	return list, nil
+}
+
+// DeleteSource 删除导入源（同时删除 Proxmox 存储配置，已导入的虚拟机不受影响）
+func (s *vmImportService) DeleteSource(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_import.manage")
+	if err != nil {
+		return err
+	}
+	source, err := s.getSource(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	_, client, err := s.getClusterClient(ctx, source.ClusterID)
+	if err != nil {
+		return err
+	}
+	if err := client.DeleteStorage(ctx, source.Name); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete esxi storage", zap.Error(err), zap.String("name", source.Name))
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "delete esxi storage: %v", err)
+	}
+	if err := s.importRepo.DeleteSource(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete vm import source", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("vm import source deleted", zap.Int64("id", id), zap.String("name", source.Name), zap.String("operator", username))
+	return nil
+}
+
+func (s *vmImportService) getSource(ctx context.Context, id int64) (*model.VmImportSource, error) {
+	source, err := s.importRepo.GetSourceByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm import source", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if source == nil {
+		return nil, v1.ErrVMImportSourceNotFound
+	}
+	return source, nil
+}
+
+// ListSourceVMs 列出 ESXi 上可导入的虚拟机
+func (s *vmImportService) ListSourceVMs(ctx context.Context, id int64, req *v1.ListImportableVMsRequest) ([]v1.ImportableVM, error) {
+	source, err := s.getSource(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	node, _, client, err := s.getNodeClient(ctx, req.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	if node.ClusterID != source.ClusterID {
+		return nil, v1.WithDetailf(v1.ErrTargetNodeNotInCluster, "node=%s", node.NodeName)
+	}
+
+	contents, err := client.GetStorageContent(ctx, node.NodeName, source.Name, "import")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list esxi guests", zap.Error(err), zap.String("source", source.Name))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "list esxi guests: %v", err)
+	}
+
+	list := make([]v1.ImportableVM, 0, len(contents))
+	for _, item := range contents {
+		volid, _ := item["volid"].(string)
+		if volid == "" {
+			continue
+		}
+		format, _ := item["format"].(string)
+		base := path.Base(volid[strings.Index(volid, ":")+1:])
+		list = append(list, v1.ImportableVM{
+			Volume: volid,
+			Name:   strings.TrimSuffix(base, path.Ext(base)),
+			Format: format,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Volume < list[j].Volume })
+	return list, nil
+}
+
+// readMetadata 读取导入卷的元数据，卷格式为 <storage>:<path>
+func (s *vmImportService) readMetadata(ctx context.Context, client *proxmox.ProxmoxClient, node *model.PveNode, volume string) (*proxmox.ImportMetadata, error) {
+	storage, _, ok := strings.Cut(volume, ":")
+	if !ok || storage == "" {
+		return nil, v1.WithDetail(v1.ErrVMImportInvalidVolume, volume)
+	}
+	metadata, err := client.GetImportMetadata(ctx, node.NodeName, storage, volume)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get import metadata", zap.Error(err), zap.String("volume", volume))
+		return nil, v1.WithDetailf(v1.ErrVMImportInvalidVolume, "%s: %v", volume, err)
+	}
+	if metadata.Type != "vm" || len(metadata.Disks) == 0 {
+		return nil, v1.WithDetailf(v1.ErrVMImportInvalidVolume, "%s: no importable disks", volume)
+	}
+	return metadata, nil
+}
+
+func (s *vmImportService) GetMetadata(ctx context.Context, req *v1.GetVMImportMetadataRequest) (*v1.VMImportMetadata, error) {
+	node, _, client, err := s.getNodeClient(ctx, req.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := s.readMetadata(ctx, client, node, req.Volume)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.VMImportMetadata{
+		Volume:     req.Volume,
+		SourceType: vmImportSourceType(metadata, req.Volume),
+		CreateArgs: metadata.CreateArgs,
+		Disks:      make([]v1.VMImportDisk, 0, len(metadata.Disks)),
+		Nets:       make([]v1.VMImportNet, 0, len(metadata.Net)),
+		Warnings:   make([]string, 0, len(metadata.Warnings)),
+	}
+	for _, key := range sortedKeys(metadata.Disks) {
+		disk := metadata.Disks[key]
+		data.Disks = append(data.Disks, v1.VMImportDisk{Key: key, Volume: disk.Volid, Size: disk.Size})
+	}
+	for _, key := range sortedKeys(metadata.Net) {
+		nic := metadata.Net[key]
+		data.Nets = append(data.Nets, v1.VMImportNet{Key: key, Model: nic.Model, MACAddr: nic.MACAddr})
+	}
+	for _, w := range metadata.Warnings {
+		data.Warnings = append(data.Warnings, formatImportWarning(w))
+	}
+	return data, nil
+}
+
+// CreateImport 校验映射并生成创建参数，随后异步执行导入，立即返回任务
+func (s *vmImportService) CreateImport(ctx context.Context, req *v1.CreateVMImportRequest, creator string) (*v1.VMImportTaskDetail, error) {
+	node, cluster, client, err := s.getNodeClient(ctx, req.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster.IsSchedulable != 1 {
+		return nil, v1.WithDetailf(v1.ErrClusterNotSchedulable, "cluster=%s", cluster.ClusterName)
+	}
+
+	metadata, err := s.readMetadata(ctx, client, node, req.Volume)
+	if err != nil {
+		return nil, err
+	}
+	sourceType := vmImportSourceType(metadata, req.Volume)
+	if req.LiveImport && sourceType != model.VmImportSourceESXi {
+		return nil, v1.WithDetail(v1.ErrVMImportInvalidSpec, "live_import is only supported for esxi sources")
+	}
+	for key := range req.DiskStorageMap {
+		if _, ok := metadata.Disks[key]; !ok {
+			return nil, v1.WithDetailf(v1.ErrVMImportInvalidSpec, "disk_storage_map: source has no disk %q", key)
+		}
+	}
+	for key := range req.NetworkMap {
+		if _, ok := metadata.Net[key]; !ok {
+			return nil, v1.WithDetailf(v1.ErrVMImportInvalidSpec, "network_map: source has no network %q", key)
+		}
+	}
+
+	vmName := req.VmName
+	if vmName == "" {
+		vmName = fmt.Sprint(metadata.CreateArgs["name"])
+	}
+
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "vm.import",
+		Target:    vmName,
+		ClusterID: cluster.Id,
+		AppId:     req.AppId,
+	}); err != nil {
+		return nil, err
+	}
+
+	vmID := generateProxmoxVMID(req.VMID)
+	existing, err := s.vmRepo.GetByVMID(ctx, vmID, node.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to check vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return nil, v1.WithDetailf(v1.ErrVMAlreadyExists, "vmid=%d, node=%s", vmID, node.NodeName)
+	}
+
+	params := buildVMImportParams(metadata, req, vmID, vmName)
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+
+	task := &model.VmImportTask{
+		ClusterID:     cluster.Id,
+		NodeID:        node.Id,
+		NodeName:      node.NodeName,
+		SourceType:    sourceType,
+		Volume:        req.Volume,
+		VMID:          vmID,
+		VmName:        vmName,
+		TargetStorage: req.TargetStorage,
+		CreateParams:  string(paramsJSON),
+		AppId:         req.AppId,
+		Description:   req.Description,
+		Status:        model.VmImportTaskStatusPending,
+		Creator:       creator,
+	}
+	if req.LiveImport {
+		task.LiveImport = 1
+	}
+	if err := s.importRepo.CreateTask(ctx, task); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vm import task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	go s.executeImport(task.Id)
+
+	s.logger.WithContext(ctx).Info("vm import task created",
+		zap.Int64("task_id", task.Id), zap.String("volume", req.Volume), zap.String("node", node.NodeName), zap.Uint32("vmid", vmID))
+	return toVMImportTaskDetail(task), nil
+}
+
+// buildVMImportParams 以源虚拟机转换后的参数为基础，按映射为每块磁盘指定 import-from 目标存储、为每块网卡指定网桥
+func buildVMImportParams(metadata *proxmox.ImportMetadata, req *v1.CreateVMImportRequest, vmID uint32, vmName string) map[string]string {
+	params := make(map[string]string, len(metadata.CreateArgs)+len(metadata.Disks)+len(metadata.Net)+4)
+	for key, value := range metadata.CreateArgs {
+		switch v := value.(type) {
+		case bool:
+			if v {
+				params[key] = "1"
+			} else {
+				params[key] = "0"
+			}
+		case float64:
+			params[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			params[key] = fmt.Sprint(v)
+		}
+	}
+	params["vmid"] = strconv.FormatUint(uint64(vmID), 10)
+	params["name"] = vmName
+	if req.Description != "" {
+		params["description"] = req.Description
+	}
+
+	for key, disk := range metadata.Disks {
+		storage := req.TargetStorage
+		if mapped := req.DiskStorageMap[key]; mapped != "" {
+			storage = mapped
+		}
+		params[key] = fmt.Sprintf("%s:0,import-from=%s", storage, disk.Volid)
+	}
+
+	defaultBridge := req.DefaultBridge
+	if defaultBridge == "" {
+		defaultBridge = "vmbr0"
+	}
+	for key, nic := range metadata.Net {
+		netModel := nic.Model
+		if netModel == "" {
+			netModel = "virtio"
+		}
+		bridge := defaultBridge
+		if mapped := req.NetworkMap[key]; mapped != "" {
+			bridge = mapped
+		}
+		value := fmt.Sprintf("%s,bridge=%s", netModel, bridge)
+		if nic.MACAddr != "" {
+			value += ",macaddr=" + nic.MACAddr
+		}
+		params[key] = value
+	}
+
+	if req.LiveImport {
+		// 创建后立即启动虚拟机，磁盘数据在后台从 ESXi 迁移
+		params["live-restore"] = "1"
+	}
+	return params
+}
+
+// executeImport 创建虚拟机并等待导入完成，成功后写入虚拟机记录
+func (s *vmImportService) executeImport(taskID int64) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	ctx := context.Background()
+	task, err := s.importRepo.GetTaskByID(ctx, taskID)
+	if err != nil || task == nil {
+		s.logger.Error("failed to load vm import task", zap.Int64("task_id", taskID), zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	task.Status = model.VmImportTaskStatusImporting
+	task.StartTime = &now
+	s.saveTask(ctx, task)
+
+	if err := s.runImport(ctx, task); err != nil {
+		s.logger.Error("vm import failed", zap.Int64("task_id", taskID), zap.String("volume", task.Volume), zap.Error(err))
+		end := time.Now()
+		task.Status = model.VmImportTaskStatusFailed
+		task.ErrorMessage = err.Error()
+		task.EndTime = &end
+		s.saveTask(ctx, task)
+		s.notificationService.Notify(ctx, taskFinishedNotification("vm_import", task.Id,
+			fmt.Sprintf("Import of VM %s", task.VmName), err), task.Creator)
+		return
+	}
+
+	end := time.Now()
+	task.Status = model.VmImportTaskStatusCompleted
+	task.Progress = 100
+	task.EndTime = &end
+	s.saveTask(ctx, task)
+	s.logger.Info("vm imported", zap.Int64("task_id", taskID), zap.String("vm_name", task.VmName), zap.Uint32("vmid", task.VMID))
+	s.notificationService.Notify(ctx, taskFinishedNotification("vm_import", task.Id,
+		fmt.Sprintf("Import of VM %s", task.VmName), nil), task.Creator)
+}
+
+func (s *vmImportService) saveTask(ctx context.Context, task *model.VmImportTask) {
+	if err := s.importRepo.UpdateTask(ctx, task); err != nil {
+		s.logger.Error("failed to update vm import task", zap.Int64("task_id", task.Id), zap.Error(err))
+	}
+}
+
+func (s *vmImportService) runImport(ctx context.Context, task *model.VmImportTask) error {
+	cluster, client, err := s.getClusterClient(ctx, task.ClusterID)
+	if err != nil {
+		return err
+	}
+
+	var params map[string]string
+	if err := json.Unmarshal([]byte(task.CreateParams), &params); err != nil {
+		return fmt.Errorf("decode create params: %w", err)
+	}
+	form := url.Values{}
+	disks := 0
+	for key, value := range params {
+		form.Set(key, value)
+		if strings.Contains(value, "import-from=") {
+			disks++
+		}
+	}
+
+	upid, err := client.CreateQemuVM(ctx, task.NodeName, form)
+	if err != nil {
+		return fmt.Errorf("create vm: %w", err)
+	}
+	task.UPID = upid
+	s.saveTask(ctx, task)
+
+	timeout := s.conf.GetDuration("vm_import.timeout")
+	if timeout <= 0 {
+		timeout = defaultVMImportTimeout
+	}
+	if err := s.waitForImport(ctx, client, task, disks, timeout); err != nil {
+		return err
+	}
+
+	// 虚拟机记录（controller 同步时会以 Proxmox 实际配置更新）
+	vm := &model.PveVM{
+		VmName:      task.VmName,
+		ClusterID:   cluster.Id,
+		NodeID:      task.NodeID,
+		VMID:        task.VMID,
+		Storage:     task.TargetStorage,
+		Status:      "stopped",
+		AppId:       task.AppId,
+		Description: task.Description,
+		CreateTime:  time.Now(),
+		UpdateTime:  time.Now(),
+	}
+	if task.LiveImport == 1 {
+		vm.Status = "running"
+	}
+	if config, err := client.GetVMConfig(ctx, task.NodeName, task.VMID); err == nil {
+		vm.CPUNum = configInt(config, "cores") * max(configInt(config, "sockets"), 1)
+		vm.MemorySize = configInt(config, "memory")
+	} else {
+		s.logger.Warn("failed to get imported vm config", zap.Uint32("vmid", task.VMID), zap.Error(err))
+	}
+	if storageCfg, err := json.Marshal(map[string]interface{}{
+		"create_mode": "import",
+		"source_type": task.SourceType,
+		"volume":      task.Volume,
+	}); err == nil {
+		vm.StorageCfg = string(storageCfg)
+	}
+	if node, err := s.nodeRepo.GetByID(ctx, task.NodeID); err == nil && node != nil {
+		vm.NodeIP = node.IPAddress
+	}
+	if err := s.vmRepo.Create(ctx, vm); err != nil {
+		return fmt.Errorf("create vm record: %w", err)
+	}
+	task.VmId = vm.Id
+	return nil
+}
+
+// waitForImport 轮询导入任务，并根据任务日志中的磁盘传输进度估算整体进度
+func (s *vmImportService) waitForImport(ctx context.Context, client *proxmox.ProxmoxClient, task *model.VmImportTask, disks int, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	progress := &vmImportProgress{disks: disks}
+	logStart := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for import task %s: %w", task.UPID, ctx.Err())
+		case <-ticker.C:
+			if lines, err := client.GetTaskLog(ctx, task.NodeName, task.UPID, logStart, 500); err == nil {
+				for _, line := range lines {
+					if n, ok := line["n"].(float64); ok && int(n) > logStart {
+						logStart = int(n)
+					}
+					if text, ok := line["t"].(string); ok {
+						progress.feed(text)
+					}
+				}
+				if p := progress.percent(); p != task.Progress {
+					task.Progress = p
+					s.saveTask(ctx, task)
+				}
+			}
+
+			status, err := client.GetTaskStatus(ctx, task.NodeName, task.UPID)
+			if err != nil {
+				// 节点繁忙时查询可能偶发失败，继续轮询直到超时
+				continue
+			}
+			if st, _ := status["status"].(string); st != "stopped" {
+				continue
+			}
+			if exitStatus, _ := status["exitstatus"].(string); exitStatus != "OK" {
+				return fmt.Errorf("import task failed: %s", exitStatus)
+			}
+			return nil
+		}
+	}
+}
+
+// vmImportProgress 导入进度估算：每块磁盘的传输进度按磁盘数折算为整体进度
+type vmImportProgress struct {
+	disks   int
+	done    int
+	current float64
+}
+
+func (p *vmImportProgress) feed(line string) {
+	m := vmImportProgressPattern.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	pct, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return
+	}
+	if pct >= 100 {
+		p.done++
+		p.current = 0
+		return
+	}
+	p.current = pct
+}
+
+// percent 返回 0-99，任务结束后由调用方置为 100
+func (p *vmImportProgress) percent() int {
+	if p.disks <= 0 {
+		return 0
+	}
+	done := min(p.done, p.disks)
+	v := int((float64(done)*100 + p.current) / float64(p.disks))
+	return min(max(v, 0), 99)
+}
+
+func (s *vmImportService) GetImport(ctx context.Context, id int64) (*v1.VMImportTaskDetail, error) {
+	task, err := s.importRepo.GetTaskByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm import task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if task == nil {
+		return nil, v1.ErrVMImportTaskNotFound
+	}
+	return toVMImportTaskDetail(task), nil
+}
+
+func (s *vmImportService) ListImports(ctx context.Context, req *v1.ListVMImportTasksRequest) (*v1.ListVMImportTasksResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	tasks, total, err := s.importRepo.ListTasks(ctx, page, pageSize, req.ClusterID, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm import tasks", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.VMImportTaskItem, 0, len(tasks))
+	for _, task := range tasks {
+		list = append(list, toVMImportTaskItem(task))
+	}
+	return &v1.ListVMImportTasksResponseData{Total: total, List: list}, nil
+}
+
+// vmImportSourceType 根据元数据和卷后缀判断导入源类型
+func vmImportSourceType(metadata *proxmox.ImportMetadata, volume string) string {
+	if metadata.Source == model.VmImportSourceESXi {
+		return model.VmImportSourceESXi
+	}
+	switch strings.ToLower(path.Ext(volume)) {
+	case ".ova":
+		return model.VmImportSourceOVA
+	case ".ovf":
+		return model.VmImportSourceOVF
+	default:
+		return metadata.Source
+	}
+}
+
+// formatImportWarning 将 Proxmox 返回的告警（type / key / value）转换为可读文本
+func formatImportWarning(w map[string]interface{}) string {
+	parts := make([]string, 0, 3)
+	for _, field := range []string{"type", "key", "value"} {
+		if v, ok := w[field]; ok && v != nil && fmt.Sprint(v) != "" {
+			parts = append(parts, fmt.Sprint(v))
+		}
+	}
+	return strings.Join(parts, ": ")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configInt 读取虚拟机配置中的整数字段（Proxmox 可能返回数字或字符串）
+func configInt(config map[string]interface{}, key string) int {
+	switch v := config[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(v)
+		return n
+	default:
+		return 0
+	}
+}
+
+func toVMImportSourceItem(source *model.VmImportSource) v1.VMImportSourceItem {
+	return v1.VMImportSourceItem{
+		Id:             source.Id,
+		ClusterID:      source.ClusterID,
+		Name:           source.Name,
+		Server:         source.Server,
+		Username:       source.Username,
+		SkipCertVerify: source.SkipCertVerify,
+		Creator:        source.Creator,
+		CreateTime:     source.CreateTime,
+	}
+}
+
+func toVMImportTaskItem(task *model.VmImportTask) v1.VMImportTaskItem {
+	return v1.VMImportTaskItem{
+		Id:            task.Id,
+		ClusterID:     task.ClusterID,
+		NodeID:        task.NodeID,
+		NodeName:      task.NodeName,
+		SourceType:    task.SourceType,
+		Volume:        task.Volume,
+		VMID:          task.VMID,
+		VmName:        task.VmName,
+		TargetStorage: task.TargetStorage,
+		LiveImport:    task.LiveImport == 1,
+		Status:        task.Status,
+		Progress:      task.Progress,
+		UPID:          task.UPID,
+		VmId:          task.VmId,
+		ErrorMessage:  task.ErrorMessage,
+		StartTime:     task.StartTime,
+		EndTime:       task.EndTime,
+		Creator:       task.Creator,
+		CreateTime:    task.CreateTime,
+	}
+}
+
+func toVMImportTaskDetail(task *model.VmImportTask) *v1.VMImportTaskDetail {
+	detail := &v1.VMImportTaskDetail{VMImportTaskItem: toVMImportTaskItem(task)}
+	if task.CreateParams != "" {
+		_ = json.Unmarshal([]byte(task.CreateParams), &detail.CreateParams)
+	}
+	return detail
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"go.uber.org/zap"
+)
+
+// 虚拟机列表可选的显示列（与 v1.ListVMResponseData 中的字段对应）
+var vmListViewColumns = map[string]bool{
+	"id":               true,
+	"vm_name":          true,
+	"vmid":             true,
+	"cluster_name":     true,
+	"node_name":        true,
+	"node_ip":          true,
+	"status":           true,
+	"cpu_num":          true,
+	"memory_size":      true,
+	"storage":          true,
+	"app_id":           true,
+	"template_name":    true,
+	"vm_user":          true,
+	"creator":          true,
+	"descriptions":     true,
+	"owner":            true,
+	"team":             true,
+	"contact":          true,
+	"business_service": true,
+	"environment":      true,
+	"cost_center":      true,
+	"review_date":      true,
+	"create_time":      true,
+	"update_time":      true,
+	"last_sync_time":   true,
+}
+
+// 虚拟机列表可排序的字段
+var vmListViewSortFields = map[string]bool{
+	"id":             true,
+	"vm_name":        true,
+	"vmid":           true,
+	"status":         true,
+	"cpu_num":        true,
+	"memory_size":    true,
+	"create_time":    true,
+	"update_time":    true,
+	"last_sync_time": true,
+	"review_date":    true,
+}
+
+type VMListViewService interface {
+	CreateView(ctx context.Context, userId string, req *v1.CreateVMListViewRequest) (*v1.VMListViewItem, error)
+	UpdateView(ctx context.Context, userId string, id int64, req *v1.UpdateVMListViewRequest) error
+	DeleteView(ctx context.Context, userId string, id int64) error
+	GetView(ctx context.Context, userId string, id int64) (*v1.VMListViewItem, error)
+	ListViews(ctx context.Context, userId string) (*v1.ListVMListViewsResponseData, error)
+}
+
+func NewVMListViewService(
+	service *Service,
+	viewRepo repository.VmListViewRepository,
+	logger *log.Logger,
+) VMListViewService {
+	return &vmListViewService{
+		viewRepo: viewRepo,
+		Service:  service,
+		logger:   logger,
+	}
+}
+
+type vmListViewService struct {
+	viewRepo repository.VmListViewRepository
+	*Service
+	logger *log.Logger
+}
+
+func (s *vmListViewService) CreateView(ctx context.Context, userId string, req *v1.CreateVMListViewRequest) (*v1.VMListViewItem, error) {
+	if userId == "" {
+		return nil, v1.ErrUnauthorized
+	}
+	if err := validateVMListView(req.SortBy, req.Columns); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.viewRepo.GetByUserAndName(ctx, userId, req.Name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm list view by name", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return nil, v1.ErrVMListViewNameExists
+	}
+
+	filters, columns, err := marshalVMListView(req.Filters, req.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &model.VmListView{
+		UserId:    userId,
+		Name:      req.Name,
+		Filters:   filters,
+		SortBy:    req.SortBy,
+		SortOrder: req.SortOrder,
+		Columns:   columns,
+		IsDefault: boolToInt8(req.IsDefault),
+		Shared:    boolToInt8(req.Shared),
+	}
+
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		// 每个用户只能有一个默认视图
+		if view.IsDefault == 1 {
+			if err := s.viewRepo.ClearDefault(ctx, userId); err != nil {
+				return err
+			}
+		}
+		return s.viewRepo.Create(ctx, view)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vm list view", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	item := toVMListViewItem(view, userId)
+	return &item, nil
+}
+
+func (s *vmListViewService) UpdateView(ctx context.Context, userId string, id int64, req *v1.UpdateVMListViewRequest) error {
+	view, err := s.getOwnedView(ctx, userId, id)
+	if err != nil {
+		return err
+	}
+
+	if req.Name != nil && *req.Name != view.Name {
+		if *req.Name == "" {
+			return v1.WithDetail(v1.ErrMissingParameter, "name")
+		}
+		existing, err := s.viewRepo.GetByUserAndName(ctx, userId, *req.Name)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm list view by name", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if existing != nil {
+			return v1.ErrVMListViewNameExists
+		}
+		view.Name = *req.Name
+	}
+
+	sortBy := view.SortBy
+	if req.SortBy != nil {
+		sortBy = *req.SortBy
+	}
+	if err := validateVMListView(sortBy, req.Columns); err != nil {
+		return err
+	}
+	view.SortBy = sortBy
+	if req.SortOrder != nil {
+		view.SortOrder = *req.SortOrder
+	}
+
+	if req.Filters != nil {
+		data, err := json.Marshal(req.Filters)
+		if err != nil {
+			return v1.ErrBadRequest
+		}
+		view.Filters = string(data)
+	}
+	if req.Columns != nil {
+		data, err := json.Marshal(req.Columns)
+		if err != nil {
+			return v1.ErrBadRequest
+		}
+		view.Columns = string(data)
+	}
+	if req.Shared != nil {
+		view.Shared = boolToInt8(*req.Shared)
+	}
+
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if req.IsDefault != nil {
+			if *req.IsDefault && view.IsDefault != 1 {
+				if err := s.viewRepo.ClearDefault(ctx, userId); err != nil {
+					return err
+				}
+			}
+			view.IsDefault = boolToInt8(*req.IsDefault)
+		}
+		return s.viewRepo.Update(ctx, view)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to update vm list view", zap.Error(err), zap.Int64("id", id))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *vmListViewService) DeleteView(ctx context.Context, userId string, id int64) error {
+	if _, err := s.getOwnedView(ctx, userId, id); err != nil {
+		return err
+	}
+
+	if err := s.viewRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete vm list view", zap.Error(err), zap.Int64("id", id))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+// GetView 获取视图详情（自己的视图或他人共享的视图）
+func (s *vmListViewService) GetView(ctx context.Context, userId string, id int64) (*v1.VMListViewItem, error) {
+	view, err := s.viewRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm list view", zap.Error(err), zap.Int64("id", id))
+		return nil, v1.ErrInternalServerError
+	}
+	if view == nil || (view.UserId != userId && view.Shared != 1) {
+		return nil, v1.ErrNotFound
+	}
+
+	item := toVMListViewItem(view, userId)
+	return &item, nil
+}
+
+// ListViews 获取当前用户可见的视图：自己的视图在前，他人共享的视图在后
+func (s *vmListViewService) ListViews(ctx context.Context, userId string) (*v1.ListVMListViewsResponseData, error) {
+	views, err := s.viewRepo.ListVisible(ctx, userId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm list views", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	owned := make([]v1.VMListViewItem, 0, len(views))
+	shared := make([]v1.VMListViewItem, 0)
+	for _, view := range views {
+		item := toVMListViewItem(view, userId)
+		if item.IsOwner {
+			owned = append(owned, item)
+		} else {
+			shared = append(shared, item)
+		}
+	}
+
+	return &v1.ListVMListViewsResponseData{List: append(owned, shared...)}, nil
+}
+
+// getOwnedView 获取视图并校验当前用户为所有者
+func (s *vmListViewService) getOwnedView(ctx context.Context, userId string, id int64) (*model.VmListView, error) {
+	view, err := s.viewRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm list view", zap.Error(err), zap.Int64("id", id))
+		return nil, v1.ErrInternalServerError
+	}
+	if view == nil || (view.UserId != userId && view.Shared != 1) {
+		return nil, v1.ErrNotFound
+	}
+	if view.UserId != userId {
+		return nil, v1.ErrVMListViewForbidden
+	}
+	return view, nil
+}
+
+func validateVMListView(sortBy string, columns []string) error {
+	if sortBy != "" && !vmListViewSortFields[sortBy] {
+		return v1.WithDetailf(v1.ErrInvalidParameter, "sort_by=%s", sortBy)
+	}
+	for _, column := range columns {
+		if !vmListViewColumns[column] {
+			return v1.WithDetailf(v1.ErrInvalidParameter, "columns=%s", column)
+		}
+	}
+	return nil
+}
+
+func marshalVMListView(filters v1.VMListViewFilters, columns []string) (string, string, error) {
+	filtersData, err := json.Marshal(filters)
+	if err != nil {
+		return "", "", v1.ErrBadRequest
+	}
+	if columns == nil {
+		columns = []string{}
+	}
+	columnsData, err := json.Marshal(columns)
+	if err != nil {
+		return "", "", v1.ErrBadRequest
+	}
+	return string(filtersData), string(columnsData), nil
+}
+
+func toVMListViewItem(view *model.VmListView, userId string) v1.VMListViewItem {
+	item := v1.VMListViewItem{
+		Id:         view.Id,
+		UserId:     view.UserId,
+		Name:       view.Name,
+		SortBy:     view.SortBy,
+		SortOrder:  view.SortOrder,
+		Columns:    []string{},
+		IsDefault:  view.IsDefault == 1,
+		Shared:     view.Shared == 1,
+		IsOwner:    view.UserId == userId,
+		CreateTime: view.CreateTime,
+		UpdateTime: view.UpdateTime,
+	}
+	// 默认视图只对所有者有意义
+	if !item.IsOwner {
+		item.IsDefault = false
+	}
+	if view.Filters != "" {
+		_ = json.Unmarshal([]byte(view.Filters), &item.Filters)
+	}
+	if view.Columns != "" {
+		_ = json.Unmarshal([]byte(view.Columns), &item.Columns)
+	}
+	return item
+}
+
+func boolToInt8(b bool) int8 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,321 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 未配置 vm_lock.default_duration 时维护锁的默认有效期（分钟）
+const defaultVMLockDuration = 240
+
+// VMLockService 虚拟机维护锁：加锁期间除持有人外任何人（包括后台任务）都不能对虚拟机执行开关机、修改配置、迁移和删除
+type VMLockService interface {
+	Lock(ctx context.Context, userID string, vmID int64, req *v1.LockVMRequest) (*v1.VMLockItem, error)
+	// Unlock 解锁，持有人或管理员可操作
+	Unlock(ctx context.Context, userID string, vmID int64) error
+	Get(ctx context.Context, userID string, vmID int64) (*v1.VMLockStatusData, error)
+	List(ctx context.Context, userID string, req *v1.ListVMLocksRequest) (*v1.ListVMLocksResponseData, error)
+	// Check 虚拟机被他人锁定时返回 ErrVMLocked；当前用户从请求上下文读取，没有用户的后台任务视为非持有人
+	Check(ctx context.Context, vmID int64) error
+	// Release 删除虚拟机时移除其维护锁
+	Release(ctx context.Context, vmID int64)
+	// CheckProxmox 虚拟机持有 Proxmox lock（备份、克隆、迁移、快照等任务进行中）时返回 ErrVMProxmoxLocked，
+	// 说明 lock 原因和正在运行的任务；配置了 vm_lock.proxmox_wait_timeout 时先等待 lock 释放
+	CheckProxmox(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, action string) error
+}
+
+func NewVMLockService(
+	service *Service,
+	conf *viper.Viper,
+	lockRepo repository.VMLockRepository,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) VMLockService {
+	return &vmLockService{
+		Service:     service,
+		conf:        conf,
+		lockRepo:    lockRepo,
+		vmRepo:      vmRepo,
+		nodeRepo:    nodeRepo,
+		clusterRepo: clusterRepo,
+		userRepo:    userRepo,
+		logger:      logger,
+	}
+}
+
+type vmLockService struct {
+	*Service
+	conf        *viper.Viper
+	lockRepo    repository.VMLockRepository
+	vmRepo      repository.PveVMRepository
+	nodeRepo    repository.PveNodeRepository
+	clusterRepo repository.PveClusterRepository
+	userRepo    repository.UserRepository
+	logger      *log.Logger
+}
+
+func (s *vmLockService) getVM(ctx context.Context, vmID int64) (*model.PveVM, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err), zap.Int64("vm_id", vmID))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.WithDetailf(v1.ErrVMNotFound, "vm_id=%d", vmID)
+	}
+	return vm, nil
+}
+
+// activeLock 返回虚拟机生效中的锁，已过期的锁顺带删除
+func (s *vmLockService) activeLock(ctx context.Context, vmID int64) (*model.VMLock, error) {
+	lock, err := s.lockRepo.GetByVMId(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm lock", zap.Error(err), zap.Int64("vm_id", vmID))
+		return nil, v1.ErrInternalServerError
+	}
+	if lock == nil {
+		return nil, nil
+	}
+	if !lock.Active(time.Now()) {
+		if err := s.lockRepo.DeleteByVMId(ctx, vmID); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to delete expired vm lock", zap.Error(err), zap.Int64("vm_id", vmID))
+		}
+		return nil, nil
+	}
+	return lock, nil
+}
+
+func (s *vmLockService) Lock(ctx context.Context, userID string, vmID int64, req *v1.LockVMRequest) (*v1.VMLockItem, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	owner := req.Owner
+	if owner == "" {
+		owner = username
+	}
+	if owner != username {
+		if !isAdminUsername(s.conf, username) {
+			return nil, v1.ErrAdminRequired
+		}
+		user, err := s.userRepo.GetByUsername(ctx, owner)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get user", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if user == nil {
+			return nil, v1.WithDetailf(v1.ErrInvalidParameter, "owner %s does not exist", owner)
+		}
+	}
+
+	vm, err := s.getVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := s.activeLock(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	if lock != nil && lock.Owner != username {
+		// 他人持有的锁需要先由持有人或管理员解锁
+		return nil, v1.WithDetailf(v1.ErrVMLockNotHeld, "locked by %s", lock.Owner)
+	}
+	if lock == nil {
+		lock = &model.VMLock{VmId: vm.Id, Creator: username}
+	}
+
+	lock.ClusterID = vm.ClusterID
+	lock.VMID = vm.VMID
+	lock.VmName = vm.VmName
+	lock.Owner = owner
+	lock.Reason = req.Reason
+	lock.ExpiresAt = nil
+	if !req.NoExpiry {
+		duration := req.Duration
+		if duration <= 0 {
+			duration = s.conf.GetInt("vm_lock.default_duration")
+		}
+		if duration <= 0 {
+			duration = defaultVMLockDuration
+		}
+		expiresAt := time.Now().Add(time.Duration(duration) * time.Minute)
+		lock.ExpiresAt = &expiresAt
+	}
+	if err := s.lockRepo.Save(ctx, lock); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save vm lock", zap.Error(err), zap.Int64("vm_id", vmID))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("vm locked",
+		zap.Int64("vm_id", vmID), zap.String("vm_name", vm.VmName), zap.String("owner", owner),
+		zap.String("operator", username), zap.String("reason", req.Reason))
+	item := toVMLockItem(lock)
+	return &item, nil
+}
+
+func (s *vmLockService) Unlock(ctx context.Context, userID string, vmID int64) error {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return err
+	}
+	lock, err := s.activeLock(ctx, vmID)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return nil
+	}
+	if lock.Owner != username {
+		if !isAdminUsername(s.conf, username) {
+			return v1.WithDetailf(v1.ErrVMLockNotHeld, "locked by %s", lock.Owner)
+		}
+		s.logger.WithContext(ctx).Warn("vm lock force released by admin",
+			zap.Int64("vm_id", vmID), zap.String("owner", lock.Owner), zap.String("operator", username))
+	}
+	if err := s.lockRepo.DeleteByVMId(ctx, vmID); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete vm lock", zap.Error(err), zap.Int64("vm_id", vmID))
+		return v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("vm unlocked",
+		zap.Int64("vm_id", vmID), zap.String("vm_name", lock.VmName), zap.String("operator", username))
+	return nil
+}
+
+func (s *vmLockService) Get(ctx context.Context, userID string, vmID int64) (*v1.VMLockStatusData, error) {
+	username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+	if err != nil {
+		return nil, err
+	}
+	vm, err := s.getVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := s.activeLock(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.VMLockStatusData{VmId: vmID, PendingChanges: []string{}}
+	if lock != nil {
+		item := toVMLockItem(lock)
+		data.Locked = true
+		data.HeldByMe = lock.Owner == username
+		data.Lock = &item
+	}
+	s.fillProxmoxState(ctx, vm, data)
+	return data, nil
+}
+
+// fillProxmoxState 补充 Proxmox 的 lock 字段、持有 lock 的任务和待生效的配置，获取失败时留空
+func (s *vmLockService) fillProxmoxState(ctx context.Context, vm *model.PveVM, data *v1.VMLockStatusData) {
+	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
+	if err != nil || node == nil {
+		return
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID)
+	if err != nil || cluster == nil {
+		return
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		return
+	}
+
+	data.ProxmoxLock = s.readProxmoxLock(ctx, client, node.NodeName, vm.VMID)
+	if data.ProxmoxLock != "" {
+		data.ProxmoxLockReason = proxmoxLockReason(data.ProxmoxLock)
+		data.ProxmoxTask = runningVMTask(ctx, client, vm.VMID)
+	}
+	pending, err := client.GetVMPendingConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vm pending config", zap.Error(err), zap.Uint32("vmid", vm.VMID))
+		return
+	}
+	data.PendingChanges = pendingConfigKeys(pending)
+}
+
+func (s *vmLockService) List(ctx context.Context, userID string, req *v1.ListVMLocksRequest) (*v1.ListVMLocksResponseData, error) {
+	owner := req.Owner
+	if req.Mine {
+		username, err := lookupUsername(ctx, s.userRepo, s.logger, userID)
+		if err != nil {
+			return nil, err
+		}
+		owner = username
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+
+	locks, total, err := s.lockRepo.ListActive(ctx, page, pageSize, req.ClusterID, owner, time.Now())
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm locks", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	list := make([]v1.VMLockItem, 0, len(locks))
+	for _, lock := range locks {
+		list = append(list, toVMLockItem(lock))
+	}
+	return &v1.ListVMLocksResponseData{Total: total, List: list}, nil
+}
+
+func (s *vmLockService) Check(ctx context.Context, vmID int64) error {
+	lock, err := s.activeLock(ctx, vmID)
+	if err != nil {
+		return err
+	}
+	if lock == nil {
+		return nil
+	}
+	if userID := userIDFromCtx(ctx); userID != "" {
+		if username, err := lookupUsername(ctx, s.userRepo, s.logger, userID); err == nil && username == lock.Owner {
+			return nil
+		}
+	}
+	if lock.ExpiresAt != nil {
+		return v1.WithDetailf(v1.ErrVMLocked, "locked by %s until %s: %s",
+			lock.Owner, lock.ExpiresAt.Format(time.RFC3339), lock.Reason)
+	}
+	return v1.WithDetailf(v1.ErrVMLocked, "locked by %s: %s", lock.Owner, lock.Reason)
+}
+
+func (s *vmLockService) Release(ctx context.Context, vmID int64) {
+	if err := s.lockRepo.DeleteByVMId(ctx, vmID); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to delete vm lock", zap.Error(err), zap.Int64("vm_id", vmID))
+	}
+}
+
+func toVMLockItem(lock *model.VMLock) v1.VMLockItem {
+	return v1.VMLockItem{
+		Id:         lock.Id,
+		VmId:       lock.VmId,
+		ClusterID:  lock.ClusterID,
+		VMID:       lock.VMID,
+		VmName:     lock.VmName,
+		Owner:      lock.Owner,
+		Reason:     lock.Reason,
+		ExpiresAt:  lock.ExpiresAt,
+		Creator:    lock.Creator,
+		CreateTime: lock.CreateTime,
+		UpdateTime: lock.UpdateTime,
+	}
+}
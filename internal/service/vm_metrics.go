@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// vmMetricsExecWait 通过 guest agent 读取 /proc/loadavg 的最长等待时间，避免 agent 卡住拖慢详情页
+const vmMetricsExecWait = 5 * time.Second
+
+func (s *pveVMService) GetVMMetrics(ctx context.Context, vmID int64, req *v1.GetVMMetricsRequest) (*v1.VMMetricsData, error) {
+	client, node, err := s.getProxmoxClientForVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+
+	data := &v1.VMMetricsData{
+		VMID:        vm.Id,
+		Vmid:        vm.VMID,
+		Name:        vm.VmName,
+		NodeName:    node.NodeName,
+		Timeframe:   req.Timeframe,
+		Cf:          req.Cf,
+		Series:      []v1.VMMetricsPoint{},
+		CollectedAt: time.Now(),
+	}
+	if data.Timeframe == "" {
+		data.Timeframe = "hour"
+	}
+	if data.Cf == "" {
+		data.Cf = "AVERAGE"
+	}
+
+	// 当前状态是其他部分的前提（是否运行决定是否查询 agent），获取失败时整体失败
+	status, err := client.GetVMStatus(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm status", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm status: %v", err)
+	}
+	data.Current = vmCurrentMetrics(status)
+	data.Balloon = vmBalloonMetrics(status)
+
+	rrd, err := client.GetVMRRDData(ctx, node.NodeName, vm.VMID, data.Timeframe, data.Cf)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vm rrd data", zap.Error(err), zap.Uint32("vmid", vm.VMID))
+		data.Warnings = append(data.Warnings, fmt.Sprintf("rrd: %v", err))
+	} else {
+		data.Series = vmMetricsSeries(rrd)
+	}
+
+	s.fillAgentMetrics(ctx, client, node.NodeName, vm.VMID, data)
+	return data, nil
+}
+
+// fillAgentMetrics 虚拟机运行且启用 agent 时读取系统信息和平均负载
+func (s *pveVMService) fillAgentMetrics(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, data *v1.VMMetricsData) {
+	if data.Current.Status != "running" {
+		return
+	}
+	config, err := client.GetVMConfig(ctx, nodeName, vmid)
+	if err != nil {
+		data.Warnings = append(data.Warnings, fmt.Sprintf("config: %v", err))
+		return
+	}
+	data.Agent.Enabled = agentEnabled(config)
+	if !data.Agent.Enabled {
+		return
+	}
+
+	osInfo, err := client.GetVMAgentOSInfo(ctx, nodeName, vmid)
+	if err != nil {
+		data.Warnings = append(data.Warnings, fmt.Sprintf("guest agent: %v", err))
+		return
+	}
+	data.Agent.Available = true
+	data.Agent.OS, _ = osInfo["pretty-name"].(string)
+	data.Agent.OSID, _ = osInfo["id"].(string)
+	data.Agent.Kernel, _ = osInfo["kernel-release"].(string)
+
+	ostype, _ := config["ostype"].(string)
+	if data.Agent.OSID == "mswindows" || (data.Agent.OSID == "" && strings.HasPrefix(ostype, "w")) {
+		return
+	}
+	out, err := guestExec(ctx, s.logger, client, nodeName, vmid, []string{"cat", "/proc/loadavg"}, vmMetricsExecWait)
+	if err != nil {
+		data.Warnings = append(data.Warnings, fmt.Sprintf("load average: %v", err))
+		return
+	}
+	load, ok := parseLoadAverage(out)
+	if !ok {
+		data.Warnings = append(data.Warnings, fmt.Sprintf("load average: unexpected output %q", out))
+		return
+	}
+	data.Agent.LoadAverage = load
+}
+
+// vmCurrentMetrics 从 status/current 中提取当前指标，Proxmox 以浮点数返回数值
+func vmCurrentMetrics(status map[string]interface{}) v1.VMCurrentMetrics {
+	num := func(key string) float64 {
+		v, _ := status[key].(float64)
+		return v
+	}
+	metrics := v1.VMCurrentMetrics{
+		Uptime:         int64(num("uptime")),
+		CPUUsage:       num("cpu"),
+		CPUs:           int(num("cpus")),
+		MemUsedBytes:   int64(num("mem")),
+		MemTotalBytes:  int64(num("maxmem")),
+		DiskReadBytes:  int64(num("diskread")),
+		DiskWriteBytes: int64(num("diskwrite")),
+		NetInBytes:     int64(num("netin")),
+		NetOutBytes:    int64(num("netout")),
+	}
+	metrics.Status, _ = status["status"].(string)
+	// 暂停的虚拟机 status 仍为 running，qmpstatus 为 paused
+	if qmp, _ := status["qmpstatus"].(string); qmp == "paused" {
+		metrics.Status = qmp
+	}
+	if metrics.MemTotalBytes > 0 {
+		metrics.MemUsage = float64(metrics.MemUsedBytes) / float64(metrics.MemTotalBytes)
+	}
+	return metrics
+}
+
+// vmMetricsSeries 转换 RRD 数据点，跳过没有时间戳的点
+func vmMetricsSeries(rrd []map[string]interface{}) []v1.VMMetricsPoint {
+	value := func(point map[string]interface{}, key string) *float64 {
+		if v, ok := point[key].(float64); ok {
+			return &v
+		}
+		return nil
+	}
+	series := make([]v1.VMMetricsPoint, 0, len(rrd))
+	for _, point := range rrd {
+		t, ok := point["time"].(float64)
+		if !ok {
+			continue
+		}
+		series = append(series, v1.VMMetricsPoint{
+			Time:           int64(t),
+			CPUUsage:       value(point, "cpu"),
+			MemUsedBytes:   value(point, "mem"),
+			MemTotalBytes:  value(point, "maxmem"),
+			DiskReadBytes:  value(point, "diskread"),
+			DiskWriteBytes: value(point, "diskwrite"),
+			NetInBytes:     value(point, "netin"),
+			NetOutBytes:    value(point, "netout"),
+		})
+	}
+	return series
+}
+
+// parseLoadAverage 解析 /proc/loadavg，如 "0.52 0.58 0.59 1/389 12345"
+func parseLoadAverage(out string) (*v1.VMLoadAverage, bool) {
+	fields := strings.Fields(out)
+	if len(fields) < 3 {
+		return nil, false
+	}
+	var loads [3]float64
+	for i := range loads {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, false
+		}
+		loads[i] = v
+	}
+	return &v1.VMLoadAverage{Load1: loads[0], Load5: loads[1], Load15: loads[2]}, true
+}
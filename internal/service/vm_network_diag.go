@@ -0,0 +1,547 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultNetworkDiagTimeout = 3 // 单项探测默认超时（秒）
+	networkDiagPingCount      = 3
+	guestExecPollInterval     = 500 * time.Millisecond
+)
+
+// diagTargetPattern 诊断目标只允许主机名 / IPv4 / IPv6 字符，且不能以 "-" 开头，
+// 避免被当作 ping 参数或在 guest 内拼接命令时注入
+var diagTargetPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.:\-]*$`)
+
+// pingAvgPattern 解析 Linux / busybox ping 汇总行中的平均往返时间（rtt min/avg/max/mdev = ...）
+var pingAvgPattern = regexp.MustCompile(`= [\d.]+/([\d.]+)/`)
+
+// windowsPingAvgPattern 解析 Windows ping 汇总行中的平均往返时间（Average = 1ms）
+var windowsPingAvgPattern = regexp.MustCompile(`Average = (\d+)ms`)
+
+// VMNetworkDiagService 虚拟机网络诊断：从服务器及虚拟机内部探测目标，并给出网卡、网桥、VLAN 配置
+type VMNetworkDiagService interface {
+	Diagnose(ctx context.Context, userID string, vmID int64, req *v1.VMNetworkDiagnoseRequest) (*v1.VMNetworkDiagnoseData, error)
+}
+
+func NewVMNetworkDiagService(
+	service *Service,
+	conf *viper.Viper,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) VMNetworkDiagService {
+	return &vmNetworkDiagService{
+		Service:     service,
+		conf:        conf,
+		vmRepo:      vmRepo,
+		nodeRepo:    nodeRepo,
+		clusterRepo: clusterRepo,
+		userRepo:    userRepo,
+		logger:      logger,
+	}
+}
+
+type vmNetworkDiagService struct {
+	*Service
+	conf        *viper.Viper
+	vmRepo      repository.PveVMRepository
+	nodeRepo    repository.PveNodeRepository
+	clusterRepo repository.PveClusterRepository
+	userRepo    repository.UserRepository
+	logger      *log.Logger
+}
+
+// Diagnose 执行网络诊断。服务器侧探测所有登录用户都可以执行；
+// 从虚拟机内部探测需要通过 guest agent 执行命令，仅管理员可用
+func (s *vmNetworkDiagService) Diagnose(ctx context.Context, userID string, vmID int64, req *v1.VMNetworkDiagnoseRequest) (*v1.VMNetworkDiagnoseData, error) {
+	target := strings.TrimSpace(req.Target)
+	if !diagTargetPattern.MatchString(target) {
+		return nil, v1.WithDetailf(v1.ErrInvalidDiagTarget, "target=%q", req.Target)
+	}
+	if req.FromGuest {
+		if _, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm.guest_diag"); err != nil {
+			return nil, err
+		}
+	}
+	timeout := req.Timeout
+	if timeout <= 0 {
+		timeout = defaultNetworkDiagTimeout
+	}
+	ping := req.Ping == nil || *req.Ping
+
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := client.GetVMCurrentConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+
+	data := &v1.VMNetworkDiagnoseData{
+		VMID:         vmID,
+		VMName:       vm.VmName,
+		Node:         node.NodeName,
+		Target:       target,
+		Interfaces:   []v1.VMNetworkInterface{},
+		ServerChecks: []v1.VMNetworkCheck{},
+		GuestChecks:  []v1.VMNetworkCheck{},
+		Hints:        []string{},
+	}
+
+	if status, err := client.GetVMStatus(ctx, node.NodeName, vm.VMID); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vm status", zap.Error(err), zap.Uint32("vmid", vm.VMID))
+	} else {
+		data.Status, _ = status["status"].(string)
+	}
+
+	data.Interfaces = parseVMNetworkInterfaces(config)
+	s.fillBridgeInfo(ctx, client, node.NodeName, data.Interfaces)
+
+	// 服务器侧探测与 guest agent 信息互不依赖，并发执行
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		data.ServerChecks = runServerChecks(ctx, target, req.Ports, ping, timeout)
+	}()
+
+	windows := false
+	data.GuestAgent.Enabled = agentEnabled(config)
+	if data.GuestAgent.Enabled && data.Status == "running" {
+		windows = s.fillGuestAgent(ctx, client, node.NodeName, vm.VMID, config, data)
+	}
+	wg.Wait()
+
+	if req.FromGuest && data.GuestAgent.Available {
+		data.GuestChecks = s.runGuestChecks(ctx, client, node.NodeName, vm.VMID, windows, target, req.Ports, ping, timeout)
+	}
+
+	data.Hints = networkDiagHints(data, req.FromGuest)
+	s.logger.WithContext(ctx).Info("vm network diagnosed", zap.Int64("vm_id", vmID),
+		zap.String("target", target), zap.Bool("from_guest", req.FromGuest), zap.Int("hints", len(data.Hints)))
+	return data, nil
+}
+
+func (s *vmNetworkDiagService) vmClient(ctx context.Context, vmID int64) (*model.PveVM, *model.PveNode, *proxmox.ProxmoxClient, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, nil, nil, v1.ErrVMNotFound
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return vm, node, client, nil
+}
+
+// parseVMNetworkInterfaces 解析配置中的 netN，例如 "virtio=BC:24:11:00:00:01,bridge=vmbr0,tag=10,firewall=1"
+func parseVMNetworkInterfaces(config map[string]interface{}) []v1.VMNetworkInterface {
+	keys := make([]string, 0)
+	for key := range config {
+		if proxmox.IsNetKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(strings.TrimPrefix(keys[i], "net"))
+		b, _ := strconv.Atoi(strings.TrimPrefix(keys[j], "net"))
+		return a < b
+	})
+
+	interfaces := make([]v1.VMNetworkInterface, 0, len(keys))
+	for _, key := range keys {
+		value, _ := config[key].(string)
+		dev := proxmox.ParseDeviceConfig(value)
+		nic := v1.VMNetworkInterface{Name: key, GuestIPs: []string{}}
+		for i, opt := range dev.Options {
+			switch opt.Key {
+			case "bridge":
+				nic.Bridge = opt.Value
+			case "tag":
+				if tag, err := strconv.Atoi(opt.Value); err == nil {
+					nic.VLANTag = &tag
+				}
+			case "trunks":
+				nic.Trunks = opt.Value
+			case "firewall":
+				nic.Firewall = opt.Value == "1"
+			case "link_down":
+				nic.LinkDown = opt.Value == "1"
+			case "rate":
+				nic.Rate = opt.Value
+			case "macaddr":
+				nic.MAC = opt.Value
+			default:
+				// 首段为 model=MAC
+				if i == 0 {
+					nic.Model = opt.Key
+					nic.MAC = opt.Value
+				}
+			}
+		}
+		interfaces = append(interfaces, nic)
+	}
+	return interfaces
+}
+
+// fillBridgeInfo 根据节点网络配置补充网卡所连接网桥的状态和 VLAN 设置
+func (s *vmNetworkDiagService) fillBridgeInfo(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, interfaces []v1.VMNetworkInterface) {
+	if len(interfaces) == 0 {
+		return
+	}
+	networks, err := client.GetNodeNetworks(ctx, nodeName)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get node networks", zap.Error(err), zap.String("node", nodeName))
+		return
+	}
+	byName := make(map[string]map[string]interface{}, len(networks))
+	for _, network := range networks {
+		iface, _ := network["iface"].(string)
+		byName[iface] = network
+	}
+
+	for i := range interfaces {
+		if interfaces[i].Bridge == "" {
+			continue
+		}
+		bridge := &v1.VMNetworkBridge{Name: interfaces[i].Bridge}
+		if network, ok := byName[bridge.Name]; ok {
+			bridge.Exists = true
+			bridge.Active = proxmoxBool(network["active"])
+			bridge.VLANAware = proxmoxBool(network["bridge_vlan_aware"])
+			bridge.Ports, _ = network["bridge_ports"].(string)
+			bridge.CIDR, _ = network["cidr"].(string)
+			bridge.Gateway, _ = network["gateway"].(string)
+			bridge.Comments, _ = network["comments"].(string)
+		}
+		interfaces[i].BridgeInfo = bridge
+	}
+}
+
+// fillGuestAgent 读取 guest agent 上报的系统和网卡信息，返回虚拟机是否为 Windows
+func (s *vmNetworkDiagService) fillGuestAgent(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, config map[string]interface{}, data *v1.VMNetworkDiagnoseData) bool {
+	ostype, _ := config["ostype"].(string)
+	windows := strings.HasPrefix(ostype, "w")
+
+	guestIfaces, err := client.GetVMAgentNetworkInterfaces(ctx, nodeName, vmid)
+	if err != nil {
+		data.GuestAgent.Error = err.Error()
+		return windows
+	}
+	data.GuestAgent.Available = true
+
+	if osInfo, err := client.GetVMAgentOSInfo(ctx, nodeName, vmid); err == nil {
+		data.GuestAgent.OS, _ = osInfo["pretty-name"].(string)
+		if id, _ := osInfo["id"].(string); id != "" {
+			windows = id == "mswindows"
+		}
+	}
+
+	byMAC := make(map[string]map[string]interface{}, len(guestIfaces))
+	for _, iface := range guestIfaces {
+		mac, _ := iface["hardware-address"].(string)
+		byMAC[strings.ToLower(mac)] = iface
+	}
+	for i := range data.Interfaces {
+		iface, ok := byMAC[strings.ToLower(data.Interfaces[i].MAC)]
+		if !ok {
+			continue
+		}
+		data.Interfaces[i].GuestName, _ = iface["name"].(string)
+		addresses, _ := iface["ip-addresses"].([]interface{})
+		for _, raw := range addresses {
+			addr, _ := raw.(map[string]interface{})
+			ip, _ := addr["ip-address"].(string)
+			if ip == "" {
+				continue
+			}
+			if prefix, ok := addr["prefix"].(float64); ok {
+				ip = fmt.Sprintf("%s/%d", ip, int(prefix))
+			}
+			data.Interfaces[i].GuestIPs = append(data.Interfaces[i].GuestIPs, ip)
+		}
+	}
+	return windows
+}
+
+// runServerChecks 从 PveSphere 服务器执行 ping 和 TCP 端口探测
+func runServerChecks(ctx context.Context, target string, ports []int, ping bool, timeout int) []v1.VMNetworkCheck {
+	checks := make([]v1.VMNetworkCheck, 0, len(ports)+1)
+	if ping {
+		checks = append(checks, v1.VMNetworkCheck{Source: "server", Type: "ping"})
+	}
+	for _, port := range ports {
+		checks = append(checks, v1.VMNetworkCheck{Source: "server", Type: "tcp", Port: port})
+	}
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(check *v1.VMNetworkCheck) {
+			defer wg.Done()
+			if check.Type == "ping" {
+				serverPing(ctx, target, timeout, check)
+			} else {
+				serverDial(ctx, target, check.Port, timeout, check)
+			}
+		}(&checks[i])
+	}
+	wg.Wait()
+	return checks
+}
+
+func serverPing(ctx context.Context, target string, timeout int, check *v1.VMNetworkCheck) {
+	if _, err := exec.LookPath("ping"); err != nil {
+		check.Error = "ping command is not available on server"
+		return
+	}
+	pingCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout*networkDiagPingCount+2)*time.Second)
+	defer cancel()
+
+	out, err := exec.CommandContext(pingCtx, "ping", "-c", strconv.Itoa(networkDiagPingCount),
+		"-W", strconv.Itoa(timeout), target).CombinedOutput()
+	check.Output = strings.TrimSpace(string(out))
+	if err != nil {
+		check.Error = err.Error()
+		return
+	}
+	check.Success = true
+	check.LatencyMs = parsePingAvg(check.Output)
+}
+
+func serverDial(ctx context.Context, target string, port int, timeout int, check *v1.VMNetworkCheck) {
+	dialer := net.Dialer{Timeout: time.Duration(timeout) * time.Second}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(target, strconv.Itoa(port)))
+	if err != nil {
+		check.Error = err.Error()
+		return
+	}
+	_ = conn.Close()
+	latency := float64(time.Since(start).Microseconds()) / 1000
+	check.Success = true
+	check.LatencyMs = &latency
+}
+
+func parsePingAvg(output string) *float64 {
+	for _, pattern := range []*regexp.Regexp{pingAvgPattern, windowsPingAvgPattern} {
+		if m := pattern.FindStringSubmatch(output); len(m) == 2 {
+			if avg, err := strconv.ParseFloat(m[1], 64); err == nil {
+				return &avg
+			}
+		}
+	}
+	return nil
+}
+
+// runGuestChecks 通过 guest agent 在虚拟机内执行 ping 和 TCP 端口探测（逐项执行）
+func (s *vmNetworkDiagService) runGuestChecks(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, windows bool, target string, ports []int, ping bool, timeout int) []v1.VMNetworkCheck {
+	checks := make([]v1.VMNetworkCheck, 0, len(ports)+1)
+	if ping {
+		check := v1.VMNetworkCheck{Source: "guest", Type: "ping"}
+		command := []string{"ping", "-c", strconv.Itoa(networkDiagPingCount), "-W", strconv.Itoa(timeout), target}
+		if windows {
+			command = []string{"ping", "-n", strconv.Itoa(networkDiagPingCount), "-w", strconv.Itoa(timeout * 1000), target}
+		}
+		out, err := guestExec(ctx, s.logger, client, nodeName, vmid, command, time.Duration(timeout*networkDiagPingCount+2)*time.Second)
+		check.Output = out
+		if err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Success = true
+			check.LatencyMs = parsePingAvg(out)
+		}
+		checks = append(checks, check)
+	}
+
+	for _, port := range ports {
+		check := v1.VMNetworkCheck{Source: "guest", Type: "tcp", Port: port}
+		// target 已通过 diagTargetPattern 校验，可以安全地拼接到命令中
+		command := []string{"timeout", strconv.Itoa(timeout), "bash", "-c",
+			fmt.Sprintf("exec 3<>/dev/tcp/%s/%d", target, port)}
+		if windows {
+			command = []string{"powershell", "-NoProfile", "-Command",
+				fmt.Sprintf("$c = New-Object Net.Sockets.TcpClient; if ($c.ConnectAsync('%s', %d).Wait(%d)) { exit 0 } else { exit 1 }", target, port, timeout*1000)}
+		}
+		out, err := guestExec(ctx, s.logger, client, nodeName, vmid, command, time.Duration(timeout+2)*time.Second)
+		check.Output = out
+		if err != nil {
+			check.Error = err.Error()
+		} else {
+			check.Success = true
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// guestExec 通过 guest agent 执行命令并等待结束，退出码非 0 时返回错误
+func guestExec(ctx context.Context, logger *log.Logger, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, command []string, wait time.Duration) (string, error) {
+	pid, err := client.AgentExec(ctx, nodeName, vmid, command)
+	if err != nil {
+		logger.WithContext(ctx).Warn("guest agent exec failed", zap.Error(err),
+			zap.Uint32("vmid", vmid), zap.String("command", command[0]))
+		return "", fmt.Errorf("guest agent exec failed: %w", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		status, err := client.GetAgentExecStatus(ctx, nodeName, vmid, pid)
+		if err != nil {
+			return "", fmt.Errorf("guest agent exec-status failed: %w", err)
+		}
+		if proxmoxBool(status["exited"]) {
+			out, _ := status["out-data"].(string)
+			errOut, _ := status["err-data"].(string)
+			output := strings.TrimSpace(out + errOut)
+			exitCode, _ := status["exitcode"].(float64)
+			if exitCode != 0 {
+				return output, fmt.Errorf("exit code %d", int(exitCode))
+			}
+			return output, nil
+		}
+		if time.Now().After(deadline) {
+			return "", errors.New("timed out waiting for guest command")
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(guestExecPollInterval):
+		}
+	}
+}
+
+// agentEnabled 配置 agent 形如 "1" 或 "enabled=1,fstrim_cloned_disks=1"
+func agentEnabled(config map[string]interface{}) bool {
+	var value string
+	switch v := config["agent"].(type) {
+	case string:
+		value = v
+	case float64:
+		value = strconv.Itoa(int(v))
+	}
+	dev := proxmox.ParseDeviceConfig(value)
+	if dev.Head != "" {
+		return dev.Head == "1"
+	}
+	enabled, _ := dev.Get("enabled")
+	return enabled == "1"
+}
+
+// proxmoxBool Proxmox 接口中布尔值可能以 0/1 数字或 true/false 返回
+func proxmoxBool(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case float64:
+		return b != 0
+	case string:
+		return b == "1" || b == "true"
+	}
+	return false
+}
+
+// networkDiagHints 根据配置和探测结果给出排查提示
+func networkDiagHints(data *v1.VMNetworkDiagnoseData, fromGuest bool) []string {
+	hints := make([]string, 0)
+	if data.Status != "" && data.Status != "running" {
+		hints = append(hints, fmt.Sprintf("vm is not running (status=%s)", data.Status))
+	}
+	if len(data.Interfaces) == 0 {
+		hints = append(hints, "vm has no network interface configured")
+	}
+
+	for _, nic := range data.Interfaces {
+		if nic.LinkDown {
+			hints = append(hints, fmt.Sprintf("%s: link is disconnected (link_down=1)", nic.Name))
+		}
+		if bridge := nic.BridgeInfo; bridge != nil {
+			switch {
+			case !bridge.Exists:
+				hints = append(hints, fmt.Sprintf("%s: bridge %s does not exist in node %s network config", nic.Name, bridge.Name, data.Node))
+			case !bridge.Active:
+				hints = append(hints, fmt.Sprintf("%s: bridge %s is not active on node %s", nic.Name, bridge.Name, data.Node))
+			case bridge.Ports == "":
+				hints = append(hints, fmt.Sprintf("%s: bridge %s has no physical ports, only guests on node %s are reachable without routing", nic.Name, bridge.Name, data.Node))
+			}
+			if bridge.Exists && nic.VLANTag != nil && !bridge.VLANAware {
+				hints = append(hints, fmt.Sprintf("%s: vlan tag %d is set on non vlan-aware bridge %s, make sure the uplink %s carries vlan %d",
+					nic.Name, *nic.VLANTag, bridge.Name, bridge.Ports, *nic.VLANTag))
+			}
+		}
+		if nic.Firewall {
+			hints = append(hints, fmt.Sprintf("%s: proxmox firewall is enabled, check vm and cluster firewall rules", nic.Name))
+		}
+		if data.GuestAgent.Available && !hasGuestIPv4(nic.GuestIPs) {
+			hints = append(hints, fmt.Sprintf("%s: no ipv4 address in guest, check dhcp or static network configuration", nic.Name))
+		}
+	}
+
+	switch {
+	case !data.GuestAgent.Enabled:
+		hints = append(hints, "guest agent is not enabled, guest ip addresses and guest checks are unavailable")
+	case data.Status == "running" && !data.GuestAgent.Available:
+		hints = append(hints, "guest agent is not responding, make sure qemu-guest-agent is installed and running in the vm")
+	}
+
+	if fromGuest && len(data.GuestChecks) > 0 {
+		serverOK, guestOK := anyCheckSucceeded(data.ServerChecks), anyCheckSucceeded(data.GuestChecks)
+		switch {
+		case serverOK && !guestOK:
+			hints = append(hints, "target is reachable from pvesphere server but not from the guest, check guest network config, bridge/vlan and firewall")
+		case !serverOK && !guestOK:
+			hints = append(hints, "target is unreachable from both pvesphere server and the guest, check the target and the route to it")
+		}
+	}
+	return hints
+}
+
+func hasGuestIPv4(ips []string) bool {
+	for _, ip := range ips {
+		addr := net.ParseIP(strings.SplitN(ip, "/", 2)[0])
+		if addr != nil && addr.To4() != nil && !addr.IsLinkLocalUnicast() && !addr.IsLoopback() {
+			return true
+		}
+	}
+	return false
+}
+
+func anyCheckSucceeded(checks []v1.VMNetworkCheck) bool {
+	for _, check := range checks {
+		if check.Success {
+			return true
+		}
+	}
+	return false
+}
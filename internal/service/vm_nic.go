@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// vmMaxNICs Proxmox 支持的网卡数量（net0-31）
+const vmMaxNICs = 32
+
+// VMNICService 虚拟机网卡管理：查看、添加、修改（网桥 / VLAN / 模型 / 防火墙）和移除网卡
+type VMNICService interface {
+	List(ctx context.Context, vmID int64) ([]v1.VMNetworkInterface, error)
+	Add(ctx context.Context, vmID int64, req *v1.VMNICSpec) (*v1.VMNetworkInterface, error)
+	Update(ctx context.Context, vmID int64, name string, req *v1.UpdateVMNICRequest) (*v1.VMNetworkInterface, error)
+	Remove(ctx context.Context, vmID int64, name string) error
+}
+
+func NewVMNICService(
+	service *Service,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	macRegistry MACRegistryService,
+	vmLock VMLockService,
+	logger *log.Logger,
+) VMNICService {
+	return &vmNICService{
+		Service:     service,
+		vmRepo:      vmRepo,
+		nodeRepo:    nodeRepo,
+		clusterRepo: clusterRepo,
+		macRegistry: macRegistry,
+		vmLock:      vmLock,
+		logger:      logger,
+	}
+}
+
+type vmNICService struct {
+	*Service
+	vmRepo      repository.PveVMRepository
+	nodeRepo    repository.PveNodeRepository
+	clusterRepo repository.PveClusterRepository
+	macRegistry MACRegistryService
+	vmLock      VMLockService
+	logger      *log.Logger
+}
+
+func (s *vmNICService) List(ctx context.Context, vmID int64) ([]v1.VMNetworkInterface, error) {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	config, err := s.vmConfig(ctx, client, node.NodeName, vm.VMID)
+	if err != nil {
+		return nil, err
+	}
+	return parseVMNetworkInterfaces(config), nil
+}
+
+// Add 在第一个空闲的 netN 上添加网卡，MAC 从登记表分配；运行中的虚拟机由 Proxmox 热插拔
+func (s *vmNICService) Add(ctx context.Context, vmID int64, req *v1.VMNICSpec) (*v1.VMNetworkInterface, error) {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLocks(ctx, client, node.NodeName, vm); err != nil {
+		return nil, err
+	}
+	config, err := s.vmConfig(ctx, client, node.NodeName, vm.VMID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := ""
+	for n := 0; n < vmMaxNICs; n++ {
+		if key := fmt.Sprintf("net%d", n); config[key] == nil {
+			name = key
+			break
+		}
+	}
+	if name == "" {
+		return nil, v1.WithDetailf(v1.ErrInvalidParameter, "vm already has %d network interfaces", vmMaxNICs)
+	}
+	if err := s.checkBridge(ctx, client, node.NodeName, strings.TrimSpace(req.Bridge)); err != nil {
+		return nil, err
+	}
+
+	mac, err := s.macRegistry.Allocate(ctx, &MACAllocation{
+		MAC:       strings.TrimSpace(req.MACAddress),
+		ClusterID: vm.ClusterID,
+		VMID:      vm.VMID,
+		NicName:   name,
+		Hostname:  vm.VmName,
+	})
+	if err != nil {
+		return nil, err
+	}
+	value := nicSpecValue(*req, mac)
+	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, map[string]interface{}{name: value}); err != nil {
+		s.macRegistry.Release(ctx, mac)
+		s.logger.WithContext(ctx).Error("failed to add vm nic", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID), zap.String("nic", name))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "add %s: %v", name, err)
+	}
+	s.macRegistry.BindVM(ctx, mac, vm.Id)
+
+	s.logger.WithContext(ctx).Info("vm nic added", zap.Uint32("vmid", vm.VMID),
+		zap.String("node", node.NodeName), zap.String("nic", name), zap.String("value", value))
+	nic := parseVMNetworkInterfaces(map[string]interface{}{name: value})[0]
+	return &nic, nil
+}
+
+// Update 修改网卡的网桥、VLAN 标签、模型和防火墙，保留 MAC 及限速等其他选项
+func (s *vmNICService) Update(ctx context.Context, vmID int64, name string, req *v1.UpdateVMNICRequest) (*v1.VMNetworkInterface, error) {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLocks(ctx, client, node.NodeName, vm); err != nil {
+		return nil, err
+	}
+	config, err := s.vmConfig(ctx, client, node.NodeName, vm.VMID)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := config[name].(string)
+	if !proxmox.IsNetKey(name) || value == "" {
+		return nil, v1.WithDetailf(v1.ErrInvalidParameter, "vm has no device %s", name)
+	}
+
+	dev := proxmox.ParseDeviceConfig(value)
+	if bridge := strings.TrimSpace(req.Bridge); bridge != "" {
+		if err := s.checkBridge(ctx, client, node.NodeName, bridge); err != nil {
+			return nil, err
+		}
+		dev.Set("bridge", bridge)
+	}
+	if model := strings.TrimSpace(req.Model); model != "" && len(dev.Options) > 0 {
+		if dev.Options[0].Key == "model" {
+			// 另一种写法 "model=virtio,macaddr=..."
+			dev.Options[0].Value = model
+		} else {
+			dev.Options[0].Key = model
+		}
+	}
+	setNICOptions(dev, req.VLANTag, req.Firewall)
+
+	newValue := dev.String()
+	if newValue != value {
+		if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, map[string]interface{}{name: newValue}); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update vm nic", zap.Error(err),
+				zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID), zap.String("nic", name))
+			return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update %s: %v", name, err)
+		}
+		s.logger.WithContext(ctx).Info("vm nic updated", zap.Uint32("vmid", vm.VMID),
+			zap.String("node", node.NodeName), zap.String("nic", name), zap.String("value", newValue))
+	}
+	nic := parseVMNetworkInterfaces(map[string]interface{}{name: newValue})[0]
+	return &nic, nil
+}
+
+// Remove 移除网卡并释放登记的 MAC
+func (s *vmNICService) Remove(ctx context.Context, vmID int64, name string) error {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkLocks(ctx, client, node.NodeName, vm); err != nil {
+		return err
+	}
+	config, err := s.vmConfig(ctx, client, node.NodeName, vm.VMID)
+	if err != nil {
+		return err
+	}
+	value, _ := config[name].(string)
+	if !proxmox.IsNetKey(name) || value == "" {
+		return v1.WithDetailf(v1.ErrInvalidParameter, "vm has no device %s", name)
+	}
+
+	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, map[string]interface{}{"delete": name}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to remove vm nic", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID), zap.String("nic", name))
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "remove %s: %v", name, err)
+	}
+	if nics := parseVMNetworkInterfaces(map[string]interface{}{name: value}); nics[0].MAC != "" {
+		s.macRegistry.Release(ctx, strings.ToUpper(nics[0].MAC))
+	}
+
+	s.logger.WithContext(ctx).Info("vm nic removed", zap.Uint32("vmid", vm.VMID),
+		zap.String("node", node.NodeName), zap.String("nic", name))
+	return nil
+}
+
+func (s *vmNICService) vmClient(ctx context.Context, vmID int64) (*model.PveVM, *model.PveNode, *proxmox.ProxmoxClient, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, nil, nil, v1.ErrVMNotFound
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return vm, node, client, nil
+}
+
+func (s *vmNICService) vmConfig(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32) (map[string]interface{}, error) {
+	config, err := client.GetVMCurrentConfig(ctx, nodeName, vmid)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", nodeName), zap.Uint32("vmid", vmid))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm config: %v", err)
+	}
+	return config, nil
+}
+
+func (s *vmNICService) checkLocks(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vm *model.PveVM) error {
+	if err := s.vmLock.Check(ctx, vm.Id); err != nil {
+		return err
+	}
+	return s.vmLock.CheckProxmox(ctx, client, nodeName, vm.VMID, "vm.nic")
+}
+
+// checkBridge 校验网桥存在于虚拟机所在节点；获取节点网络失败时跳过校验，由 Proxmox 报错
+func (s *vmNICService) checkBridge(ctx context.Context, client *proxmox.ProxmoxClient, nodeName, bridge string) error {
+	networks, err := client.GetNodeNetworks(ctx, nodeName)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to list node networks, skip bridge validation", zap.String("node", nodeName), zap.Error(err))
+		return nil
+	}
+	for _, item := range networks {
+		iface, _ := item["iface"].(string)
+		ifaceType, _ := item["type"].(string)
+		if iface == bridge && (ifaceType == "bridge" || ifaceType == "OVSBridge") {
+			return nil
+		}
+	}
+	return v1.WithDetailf(v1.ErrInvalidParameter, "bridge %s does not exist on node %s", bridge, nodeName)
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// Proxmox description 的长度上限（字节），可通过 vm_notes.max_length 调低
+const defaultVMNotesMaxLength = 8 << 10
+
+var (
+	// vmNotesAnnotationPattern 单独成行的 key=value 注解，key 以字母开头，可包含字母、数字、_ . -
+	vmNotesAnnotationPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_.-]{0,63})\s*=\s*(.*?)\s*$`)
+	vmNotesAnnotationKey     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,63}$`)
+	// vmNotesForbiddenHTML 备注会在 Proxmox 和 PveSphere 界面中渲染，不允许嵌入脚本等活动内容
+	vmNotesForbiddenHTML = regexp.MustCompile(`(?i)<\s*(script|iframe|object|embed|style)\b`)
+)
+
+// VMNotesService 虚拟机备注（Proxmox description）的读取、修改和同步，Proxmox 为准
+type VMNotesService interface {
+	// GetNotes 从 Proxmox 读取备注，与数据库记录不一致时一并更新
+	GetNotes(ctx context.Context, vmID int64) (*v1.VMNotesData, error)
+	// UpdateNotes 修改备注并写入 Proxmox，传入的摘要与 Proxmox 当前配置不一致时返回 ErrVMNotesConflict
+	UpdateNotes(ctx context.Context, vmID int64, req *v1.UpdateVMNotesRequest) (*v1.VMNotesData, error)
+	// SyncVM 同步单台虚拟机的备注，返回是否有更新
+	SyncVM(ctx context.Context, vm *model.PveVM) (bool, error)
+	// Sync 同步所有集群中虚拟机的备注，返回更新的数量
+	Sync(ctx context.Context) (int, error)
+}
+
+func NewVMNotesService(
+	service *Service,
+	conf *viper.Viper,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	vmLock VMLockService,
+	logger *log.Logger,
+) VMNotesService {
+	return &vmNotesService{
+		Service:     service,
+		conf:        conf,
+		vmRepo:      vmRepo,
+		nodeRepo:    nodeRepo,
+		clusterRepo: clusterRepo,
+		vmLock:      vmLock,
+		logger:      logger,
+	}
+}
+
+type vmNotesService struct {
+	*Service
+	conf        *viper.Viper
+	vmRepo      repository.PveVMRepository
+	nodeRepo    repository.PveNodeRepository
+	clusterRepo repository.PveClusterRepository
+	vmLock      VMLockService
+	logger      *log.Logger
+}
+
+func (s *vmNotesService) GetNotes(ctx context.Context, vmID int64) (*v1.VMNotesData, error) {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	description, digest, err := s.readDescription(ctx, client, node.NodeName, vm.VMID)
+	if err != nil {
+		return nil, err
+	}
+	s.saveDescription(ctx, vm, description)
+	return vmNotesData(vm.Id, description, digest), nil
+}
+
+func (s *vmNotesService) UpdateNotes(ctx context.Context, vmID int64, req *v1.UpdateVMNotesRequest) (*v1.VMNotesData, error) {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vmLock.Check(ctx, vm.Id); err != nil {
+		return nil, err
+	}
+	if err := validateVMAnnotations(req.Annotations); err != nil {
+		return nil, err
+	}
+
+	current, digest, err := s.readDescription(ctx, client, node.NodeName, vm.VMID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Digest != "" && req.Digest != digest {
+		s.saveDescription(ctx, vm, current)
+		return nil, v1.ErrVMNotesConflict
+	}
+
+	notes, annotations := parseVMNotes(current)
+	if req.Notes != nil {
+		notes = *req.Notes
+	}
+	if req.Annotations != nil {
+		annotations = req.Annotations
+	}
+	description := renderVMNotes(notes, annotations)
+	if err := validateVMNotes(description, vmNotesMaxLength(s.conf)); err != nil {
+		return nil, err
+	}
+
+	if description != current {
+		if err := setVMDescription(ctx, client, node.NodeName, vm.VMID, description, digest); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to update vm notes", zap.Error(err),
+				zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+			return nil, err
+		}
+		s.logger.WithContext(ctx).Info("vm notes updated", zap.Uint32("vmid", vm.VMID), zap.String("node", node.NodeName))
+		// 重新读取新的配置摘要
+		if _, newDigest, err := s.readDescription(ctx, client, node.NodeName, vm.VMID); err == nil {
+			digest = newDigest
+		}
+	}
+	s.saveDescription(ctx, vm, description)
+	return vmNotesData(vm.Id, description, digest), nil
+}
+
+func (s *vmNotesService) SyncVM(ctx context.Context, vm *model.PveVM) (bool, error) {
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return false, err
+	}
+	description, _, err := s.readDescription(ctx, client, node.NodeName, vm.VMID)
+	if err != nil {
+		return false, err
+	}
+	return s.saveDescription(ctx, vm, description), nil
+}
+
+func (s *vmNotesService) Sync(ctx context.Context) (int, error) {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	updated := 0
+	for _, cluster := range clusters {
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to create proxmox client", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			continue
+		}
+		nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return updated, err
+		}
+		for _, node := range nodes {
+			vms, err := s.vmRepo.ListByNodeID(ctx, node.Id)
+			if err != nil {
+				return updated, err
+			}
+			for _, vm := range vms {
+				if ctx.Err() != nil {
+					return updated, ctx.Err()
+				}
+				description, _, err := s.readDescription(ctx, client, node.NodeName, vm.VMID)
+				if err != nil {
+					// 节点离线或虚拟机已迁移、删除，等待下次同步
+					s.logger.WithContext(ctx).Debug("failed to sync vm notes", zap.String("node", node.NodeName),
+						zap.Uint32("vmid", vm.VMID), zap.Error(err))
+					continue
+				}
+				if s.saveDescription(ctx, vm, description) {
+					updated++
+				}
+			}
+		}
+	}
+	return updated, nil
+}
+
+func (s *vmNotesService) readDescription(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32) (string, string, error) {
+	config, err := client.GetVMConfig(ctx, nodeName, vmid)
+	if err != nil {
+		return "", "", v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm config: %v", err)
+	}
+	description, _ := config["description"].(string)
+	digest, _ := config["digest"].(string)
+	return description, digest, nil
+}
+
+// saveDescription 数据库中的描述与 Proxmox 不一致时更新，返回是否有更新
+func (s *vmNotesService) saveDescription(ctx context.Context, vm *model.PveVM, description string) bool {
+	if vm.Description == description {
+		return false
+	}
+	if err := s.vmRepo.UpdateDescription(ctx, vm.Id, description); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save vm description", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		return false
+	}
+	vm.Description = description
+	return true
+}
+
+func (s *vmNotesService) vmClient(ctx context.Context, vmID int64) (*model.PveVM, *model.PveNode, *proxmox.ProxmoxClient, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, nil, nil, v1.ErrVMNotFound
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return vm, node, client, nil
+}
+
+func vmNotesMaxLength(conf *viper.Viper) int {
+	if n := conf.GetInt("vm_notes.max_length"); n > 0 && n < defaultVMNotesMaxLength {
+		return n
+	}
+	return defaultVMNotesMaxLength
+}
+
+// setVMDescription 写入 Proxmox description，为空时删除；digest 非空时由 Proxmox 检查配置是否已被修改
+func setVMDescription(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, description, digest string) error {
+	params := map[string]interface{}{"description": description}
+	if description == "" {
+		params = map[string]interface{}{"delete": "description"}
+	}
+	if digest != "" {
+		params["digest"] = digest
+	}
+	if err := client.UpdateVMConfig(ctx, nodeName, vmid, params); err != nil {
+		// Proxmox: "detected modified configuration - file changed by other user? Try again."
+		if strings.Contains(err.Error(), "file changed") {
+			return v1.ErrVMNotesConflict
+		}
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update vm description: %v", err)
+	}
+	return nil
+}
+
+func vmNotesData(vmID int64, description, digest string) *v1.VMNotesData {
+	notes, annotations := parseVMNotes(description)
+	return &v1.VMNotesData{
+		VmId:        vmID,
+		Description: description,
+		Notes:       notes,
+		Annotations: annotations,
+		Digest:      digest,
+		SyncTime:    time.Now(),
+	}
+}
+
+// parseVMNotes 拆分 Markdown 正文和 key=value 注解，代码块中的行不作为注解
+func parseVMNotes(description string) (string, map[string]string) {
+	annotations := make(map[string]string)
+	lines := strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n")
+	body := make([]string, 0, len(lines))
+	inFence := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		} else if !inFence {
+			if m := vmNotesAnnotationPattern.FindStringSubmatch(line); m != nil {
+				annotations[m[1]] = m[2]
+				continue
+			}
+		}
+		body = append(body, line)
+	}
+	notes := strings.TrimRight(strings.TrimLeft(strings.Join(body, "\n"), "\n"), " \t\n")
+	return notes, annotations
+}
+
+// renderVMNotes 正文在前，注解按 key 排序放在最后，与 parseVMNotes 互逆
+func renderVMNotes(notes string, annotations map[string]string) string {
+	notes = strings.TrimRight(strings.ReplaceAll(notes, "\r\n", "\n"), " \t\n")
+	if len(annotations) == 0 {
+		return notes
+	}
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(notes)
+	if notes != "" {
+		b.WriteString("\n\n")
+	}
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(key + "=" + strings.TrimSpace(annotations[key]))
+	}
+	return b.String()
+}
+
+func validateVMAnnotations(annotations map[string]string) error {
+	for key, value := range annotations {
+		if !vmNotesAnnotationKey.MatchString(key) {
+			return v1.WithDetailf(v1.ErrVMNotesInvalid, "invalid annotation key %q", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return v1.WithDetailf(v1.ErrVMNotesInvalid, "annotation %q must be a single line", key)
+		}
+	}
+	return nil
+}
+
+// validateVMNotes 检查备注能否安全写入 Proxmox 并渲染：长度、UTF-8、控制字符、未闭合的代码块和活动 HTML 内容
+func validateVMNotes(description string, maxLength int) error {
+	if len(description) > maxLength {
+		return v1.WithDetailf(v1.ErrVMNotesInvalid, "notes exceed %d bytes", maxLength)
+	}
+	if !utf8.ValidString(description) {
+		return v1.WithDetail(v1.ErrVMNotesInvalid, "notes must be valid utf-8")
+	}
+	for _, r := range description {
+		if (r < 0x20 && r != '\n' && r != '\r' && r != '\t') || r == 0x7f {
+			return v1.WithDetailf(v1.ErrVMNotesInvalid, "control character %U is not allowed", r)
+		}
+	}
+	inFence := false
+	for _, line := range strings.Split(description, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+	}
+	if inFence {
+		return v1.WithDetail(v1.ErrVMNotesInvalid, "unclosed code block")
+	}
+	if m := vmNotesForbiddenHTML.FindStringSubmatch(description); m != nil {
+		return v1.WithDetailf(v1.ErrVMNotesInvalid, "html <%s> is not allowed", strings.ToLower(m[1]))
+	}
+	return nil
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type VMProfileService interface {
+	CreateProfile(ctx context.Context, userID string, req *v1.CreateVMProfileRequest) error
+	UpdateProfile(ctx context.Context, userID string, id int64, req *v1.UpdateVMProfileRequest) error
+	DeleteProfile(ctx context.Context, userID string, id int64) error
+	GetProfile(ctx context.Context, id int64) (*v1.VMProfileItem, error)
+	ListProfiles(ctx context.Context, req *v1.ListVMProfilesRequest) (*v1.ListVMProfilesResponseData, error)
+	// Resolve 返回创建虚拟机时使用的规格：指定 profileID 时必须属于该集群或为全局规格；
+	// 未指定时依次使用集群默认规格、全局默认规格，都没有时返回 nil
+	Resolve(ctx context.Context, clusterID int64, profileID *int64) (*model.VMProfile, error)
+}
+
+func NewVMProfileService(
+	service *Service,
+	conf *viper.Viper,
+	profileRepo repository.VMProfileRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) VMProfileService {
+	return &vmProfileService{
+		conf:        conf,
+		profileRepo: profileRepo,
+		clusterRepo: clusterRepo,
+		userRepo:    userRepo,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type vmProfileService struct {
+	conf        *viper.Viper
+	profileRepo repository.VMProfileRepository
+	clusterRepo repository.PveClusterRepository
+	userRepo    repository.UserRepository
+	*Service
+	logger *log.Logger
+}
+
+func (s *vmProfileService) CreateProfile(ctx context.Context, userID string, req *v1.CreateVMProfileRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_profile.manage")
+	if err != nil {
+		return err
+	}
+	if req.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+		}
+	}
+
+	name := strings.TrimSpace(req.Name)
+	existing, err := s.profileRepo.GetByName(ctx, req.ClusterID, name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return v1.WithDetail(v1.ErrVMProfileExists, name)
+	}
+
+	profile := &model.VMProfile{
+		ClusterID:  req.ClusterID,
+		Name:       name,
+		CPUNum:     req.CPUNum,
+		MemorySize: req.MemorySize,
+		CPUType:    strings.TrimSpace(req.CPUType),
+		Storage:    strings.TrimSpace(req.Storage),
+		DiskSizeGB: req.DiskSizeGB,
+		DiskFormat: req.DiskFormat,
+		Bridge:     strings.TrimSpace(req.Bridge),
+		NetModel:   req.NetModel,
+		SCSIHw:     req.SCSIHw,
+		OSType:     strings.TrimSpace(req.OSType),
+		Agent:      strings.TrimSpace(req.Agent),
+		Tags:       normalizeVMTags(req.Tags),
+		SerialNum:  req.SerialNum,
+		Display:    req.Display,
+		IsDefault:  req.IsDefault,
+		Describes:  req.Describes,
+		Creator:    username,
+		Modifier:   username,
+	}
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.profileRepo.Create(ctx, profile); err != nil {
+			return err
+		}
+		if profile.IsDefault {
+			return s.profileRepo.ClearDefault(ctx, profile.ClusterID, profile.Id)
+		}
+		return nil
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vm profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("vm profile created",
+		zap.Int64("cluster_id", profile.ClusterID), zap.String("name", profile.Name), zap.Bool("is_default", profile.IsDefault), zap.String("operator", username))
+	return nil
+}
+
+func (s *vmProfileService) getProfile(ctx context.Context, id int64) (*model.VMProfile, error) {
+	profile, err := s.profileRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm profile", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if profile == nil {
+		return nil, v1.WithDetailf(v1.ErrVMProfileNotFound, "profile_id=%d", id)
+	}
+	return profile, nil
+}
+
+func (s *vmProfileService) UpdateProfile(ctx context.Context, userID string, id int64, req *v1.UpdateVMProfileRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_profile.manage")
+	if err != nil {
+		return err
+	}
+	profile, err := s.getProfile(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name != profile.Name {
+			existing, err := s.profileRepo.GetByName(ctx, profile.ClusterID, name)
+			if err != nil {
+				s.logger.WithContext(ctx).Error("failed to get vm profile", zap.Error(err))
+				return v1.ErrInternalServerError
+			}
+			if existing != nil {
+				return v1.WithDetail(v1.ErrVMProfileExists, name)
+			}
+			profile.Name = name
+		}
+	}
+	if req.IsDefault != nil {
+		profile.IsDefault = *req.IsDefault
+	}
+	if req.Describes != nil {
+		profile.Describes = *req.Describes
+	}
+	if req.CPUNum != nil {
+		profile.CPUNum = *req.CPUNum
+	}
+	if req.MemorySize != nil {
+		profile.MemorySize = *req.MemorySize
+	}
+	if req.CPUType != nil {
+		profile.CPUType = strings.TrimSpace(*req.CPUType)
+	}
+	if req.Storage != nil {
+		profile.Storage = strings.TrimSpace(*req.Storage)
+	}
+	if req.DiskSizeGB != nil {
+		profile.DiskSizeGB = *req.DiskSizeGB
+	}
+	if req.DiskFormat != nil {
+		profile.DiskFormat = *req.DiskFormat
+	}
+	if req.Bridge != nil {
+		profile.Bridge = strings.TrimSpace(*req.Bridge)
+	}
+	if req.NetModel != nil {
+		profile.NetModel = *req.NetModel
+	}
+	if req.SCSIHw != nil {
+		profile.SCSIHw = *req.SCSIHw
+	}
+	if req.OSType != nil {
+		profile.OSType = strings.TrimSpace(*req.OSType)
+	}
+	if req.Agent != nil {
+		profile.Agent = strings.TrimSpace(*req.Agent)
+	}
+	if req.Tags != nil {
+		profile.Tags = normalizeVMTags(*req.Tags)
+	}
+	if req.SerialNum != nil {
+		profile.SerialNum = *req.SerialNum
+	}
+	if req.Display != nil {
+		profile.Display = *req.Display
+	}
+	profile.Modifier = username
+
+	if err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.profileRepo.Update(ctx, profile); err != nil {
+			return err
+		}
+		if profile.IsDefault {
+			return s.profileRepo.ClearDefault(ctx, profile.ClusterID, profile.Id)
+		}
+		return nil
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update vm profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *vmProfileService) DeleteProfile(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_profile.manage")
+	if err != nil {
+		return err
+	}
+	profile, err := s.getProfile(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.profileRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete vm profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("vm profile deleted",
+		zap.Int64("cluster_id", profile.ClusterID), zap.String("name", profile.Name), zap.String("operator", username))
+	return nil
+}
+
+func (s *vmProfileService) GetProfile(ctx context.Context, id int64) (*v1.VMProfileItem, error) {
+	profile, err := s.getProfile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	item := toVMProfileItem(profile)
+	if profile.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, profile.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		if cluster != nil {
+			item.ClusterName = cluster.ClusterName
+		}
+	}
+	return &item, nil
+}
+
+func (s *vmProfileService) ListProfiles(ctx context.Context, req *v1.ListVMProfilesRequest) (*v1.ListVMProfilesResponseData, error) {
+	profiles, err := s.profileRepo.List(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm profiles", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	clusterIDs := make([]int64, 0)
+	for _, profile := range profiles {
+		if profile.ClusterID > 0 {
+			clusterIDs = append(clusterIDs, profile.ClusterID)
+		}
+	}
+	clusters, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	items := make([]v1.VMProfileItem, 0, len(profiles))
+	for _, profile := range profiles {
+		item := toVMProfileItem(profile)
+		if cluster, ok := clusters[profile.ClusterID]; ok {
+			item.ClusterName = cluster.ClusterName
+		}
+		items = append(items, item)
+	}
+	return &v1.ListVMProfilesResponseData{List: items}, nil
+}
+
+func (s *vmProfileService) Resolve(ctx context.Context, clusterID int64, profileID *int64) (*model.VMProfile, error) {
+	if profileID != nil && *profileID > 0 {
+		profile, err := s.getProfile(ctx, *profileID)
+		if err != nil {
+			return nil, err
+		}
+		if profile.ClusterID != 0 && profile.ClusterID != clusterID {
+			return nil, v1.WithDetailf(v1.ErrVMProfileClusterMismatch, "profile_id=%d, cluster_id=%d", profile.Id, clusterID)
+		}
+		return profile, nil
+	}
+
+	for _, id := range []int64{clusterID, 0} {
+		profile, err := s.profileRepo.GetDefault(ctx, id)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get default vm profile", zap.Error(err), zap.Int64("cluster_id", id))
+			return nil, v1.ErrInternalServerError
+		}
+		if profile != nil {
+			return profile, nil
+		}
+	}
+	return nil, nil
+}
+
+// applyVMProfile 用规格补全创建请求中未填写的字段，请求中显式传入的值优先
+func applyVMProfile(req *v1.CreateVMRequest, profile *model.VMProfile) {
+	if profile == nil {
+		return
+	}
+	if req.CPUNum == nil && profile.CPUNum > 0 {
+		cpu := profile.CPUNum
+		req.CPUNum = &cpu
+	}
+	if req.MemorySize == nil && profile.MemorySize > 0 {
+		mem := profile.MemorySize
+		req.MemorySize = &mem
+	}
+	if strings.TrimSpace(req.CPUType) == "" {
+		req.CPUType = profile.CPUType
+	}
+	// 链接克隆必须与模板位于同一存储，不使用规格中的存储
+	if req.Storage == "" && !isLinkedClone(req) {
+		req.Storage = profile.Storage
+	}
+	if req.DiskSizeGB == nil && profile.DiskSizeGB > 0 {
+		size := profile.DiskSizeGB
+		req.DiskSizeGB = &size
+	}
+	if strings.TrimSpace(req.DiskFormat) == "" {
+		req.DiskFormat = profile.DiskFormat
+	}
+	if strings.TrimSpace(req.Bridge) == "" {
+		req.Bridge = profile.Bridge
+	}
+	if strings.TrimSpace(req.NetModel) == "" {
+		req.NetModel = profile.NetModel
+	}
+	if strings.TrimSpace(req.SCSIHw) == "" {
+		req.SCSIHw = profile.SCSIHw
+	}
+	if strings.TrimSpace(req.OSType) == "" {
+		req.OSType = profile.OSType
+	}
+	if strings.TrimSpace(req.Agent) == "" {
+		req.Agent = profile.Agent
+	}
+	if strings.TrimSpace(req.Tags) == "" {
+		req.Tags = profile.Tags
+	}
+	if req.SerialNum == nil && profile.SerialNum > 0 {
+		serials := profile.SerialNum
+		req.SerialNum = &serials
+	}
+	if req.Display == "" {
+		req.Display = profile.Display
+	}
+}
+
+// normalizeVMTags 将逗号、分号或空白分隔的标签转为 Proxmox 使用的分号分隔格式，去重并转为小写
+func normalizeVMTags(tags string) string {
+	fields := strings.FieldsFunc(strings.ToLower(tags), func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t'
+	})
+	seen := make(map[string]bool, len(fields))
+	result := make([]string, 0, len(fields))
+	for _, tag := range fields {
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return strings.Join(result, ";")
+}
+
+func toVMProfileItem(profile *model.VMProfile) v1.VMProfileItem {
+	return v1.VMProfileItem{
+		Id:        profile.Id,
+		ClusterID: profile.ClusterID,
+		Name:      profile.Name,
+		IsDefault: profile.IsDefault,
+		Describes: profile.Describes,
+		VMProfileSpec: v1.VMProfileSpec{
+			CPUNum:     profile.CPUNum,
+			MemorySize: profile.MemorySize,
+			CPUType:    profile.CPUType,
+			Storage:    profile.Storage,
+			DiskSizeGB: profile.DiskSizeGB,
+			DiskFormat: profile.DiskFormat,
+			Bridge:     profile.Bridge,
+			NetModel:   profile.NetModel,
+			SCSIHw:     profile.SCSIHw,
+			OSType:     profile.OSType,
+			Agent:      profile.Agent,
+			Tags:       profile.Tags,
+			SerialNum:  profile.SerialNum,
+			Display:    profile.Display,
+		},
+		Creator:    profile.Creator,
+		Modifier:   profile.Modifier,
+		CreateTime: profile.CreateTime,
+		UpdateTime: profile.UpdateTime,
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// protectedVMActions 虚拟机开启删除保护后拒绝的操作
+var protectedVMActions = []string{"vm.delete", "vm.stop", "vm.migrate", "vm.remote_migrate"}
+
+// checkVMProtection 虚拟机开启了 PveSphere 删除保护且 action 属于被拒绝的操作时返回 ErrVMProtected
+func checkVMProtection(vm *model.PveVM, action string) error {
+	if vm.Protected != 1 {
+		return nil
+	}
+	for _, a := range protectedVMActions {
+		if a == action {
+			return v1.WithDetailf(v1.ErrVMProtected, "protected by %s: %s", vm.ProtectedBy, vm.ProtectedReason)
+		}
+	}
+	return nil
+}
+
+// VMProtectionService 虚拟机删除保护：任何登录用户都可以开启，只有管理员可以解除
+type VMProtectionService interface {
+	Get(ctx context.Context, vmID int64) (*v1.VMProtectionData, error)
+	Protect(ctx context.Context, userID string, vmID int64, req *v1.ProtectVMRequest) (*v1.VMProtectionData, error)
+	Unprotect(ctx context.Context, userID string, vmID int64, req *v1.UnprotectVMRequest) (*v1.VMProtectionData, error)
+}
+
+func NewVMProtectionService(
+	service *Service,
+	conf *viper.Viper,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) VMProtectionService {
+	return &vmProtectionService{
+		Service:     service,
+		conf:        conf,
+		vmRepo:      vmRepo,
+		nodeRepo:    nodeRepo,
+		clusterRepo: clusterRepo,
+		userRepo:    userRepo,
+		logger:      logger,
+	}
+}
+
+type vmProtectionService struct {
+	*Service
+	conf        *viper.Viper
+	vmRepo      repository.PveVMRepository
+	nodeRepo    repository.PveNodeRepository
+	clusterRepo repository.PveClusterRepository
+	userRepo    repository.UserRepository
+	logger      *log.Logger
+}
+
+func (s *vmProtectionService) getVM(ctx context.Context, vmID int64) (*model.PveVM, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err), zap.Int64("vm_id", vmID))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.WithDetailf(v1.ErrVMNotFound, "vm_id=%d", vmID)
+	}
+	return vm, nil
+}
+
+func (s *vmProtectionService) Get(ctx context.Context, vmID int64) (*v1.VMProtectionData, error) {
+	vm, err := s.getVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	return s.protectionData(ctx, vm), nil
+}
+
+func (s *vmProtectionService) Protect(ctx context.Context, userID string, vmID int64, req *v1.ProtectVMRequest) (*v1.VMProtectionData, error) {
+	if userID == "" {
+		return nil, v1.ErrUnauthorized
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, v1.ErrUnauthorized
+	}
+	vm, err := s.getVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先设置 Proxmox 标志，失败时不改变平台状态
+	if req.Proxmox {
+		if err := s.setProxmoxProtection(ctx, vm, true); err != nil {
+			return nil, err
+		}
+	}
+
+	vm.Protected = 1
+	vm.ProtectedBy = user.Username
+	vm.ProtectedReason = strings.TrimSpace(req.Reason)
+	vm.UpdateTime = time.Now()
+	if err := s.vmRepo.Update(ctx, vm); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update vm protection", zap.Error(err), zap.Int64("vm_id", vmID))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.logger.WithContext(ctx).Info("vm protected", zap.Int64("vm_id", vmID), zap.String("vm_name", vm.VmName),
+		zap.String("operator", user.Username), zap.String("reason", vm.ProtectedReason), zap.Bool("proxmox", req.Proxmox))
+	return s.protectionData(ctx, vm), nil
+}
+
+func (s *vmProtectionService) Unprotect(ctx context.Context, userID string, vmID int64, req *v1.UnprotectVMRequest) (*v1.VMProtectionData, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm.unprotect")
+	if err != nil {
+		return nil, err
+	}
+	vm, err := s.getVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Proxmox {
+		if err := s.setProxmoxProtection(ctx, vm, false); err != nil {
+			return nil, err
+		}
+	}
+
+	if vm.Protected == 1 {
+		s.logger.WithContext(ctx).Warn("vm protection removed", zap.Int64("vm_id", vmID), zap.String("vm_name", vm.VmName),
+			zap.String("protected_by", vm.ProtectedBy), zap.String("operator", username))
+		vm.Protected = 0
+		vm.ProtectedBy = ""
+		vm.ProtectedReason = ""
+		vm.UpdateTime = time.Now()
+		if err := s.vmRepo.Update(ctx, vm); err != nil {
+			s.logger.WithContext(ctx).Error("failed to update vm protection", zap.Error(err), zap.Int64("vm_id", vmID))
+			return nil, v1.ErrInternalServerError
+		}
+	}
+	return s.protectionData(ctx, vm), nil
+}
+
+// setProxmoxProtection 设置或清除 Proxmox 配置中的 protection 标志
+func (s *vmProtectionService) setProxmoxProtection(ctx context.Context, vm *model.PveVM, enabled bool) error {
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return err
+	}
+	config := map[string]interface{}{"protection": 1}
+	if !enabled {
+		config = map[string]interface{}{"delete": "protection"}
+	}
+	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, config); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update proxmox protection flag", zap.Error(err),
+			zap.Uint32("vmid", vm.VMID), zap.Bool("enabled", enabled))
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update protection: %v", err)
+	}
+	return nil
+}
+
+// protectionData 组装保护状态，Proxmox 标志读取失败时视为未设置
+func (s *vmProtectionService) protectionData(ctx context.Context, vm *model.PveVM) *v1.VMProtectionData {
+	data := &v1.VMProtectionData{
+		VmId:           vm.Id,
+		Protected:      vm.Protected == 1,
+		ProtectedBy:    vm.ProtectedBy,
+		Reason:         vm.ProtectedReason,
+		BlockedActions: []string{},
+	}
+	if data.Protected {
+		data.BlockedActions = append(data.BlockedActions, protectedVMActions...)
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return data
+	}
+	config, err := client.GetVMConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vm config", zap.Error(err), zap.Uint32("vmid", vm.VMID))
+		return data
+	}
+	data.ProxmoxProtection = fmt.Sprint(config["protection"]) == "1"
+	if data.ProxmoxProtection && !data.Protected {
+		// Proxmox 的 protection 只拒绝删除
+		data.BlockedActions = append(data.BlockedActions, "vm.delete")
+	}
+	return data
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// proxmoxLockReasons Proxmox 虚拟机配置中 lock 字段的含义
+var proxmoxLockReasons = map[string]string{
+	"backup":          "backup in progress",
+	"clone":           "clone in progress",
+	"create":          "creation in progress",
+	"migrate":         "migration in progress",
+	"rollback":        "snapshot rollback in progress",
+	"snapshot":        "snapshot in progress",
+	"snapshot-delete": "snapshot deletion in progress",
+	"suspending":      "hibernation in progress",
+	"suspended":       "hibernated, start the vm to resume",
+	"disk":            "disk operation in progress",
+}
+
+// proxmoxLockAllowedActions 持有 lock 时 Proxmox 仍然接受的操作（休眠的虚拟机启动即恢复）
+var proxmoxLockAllowedActions = map[string][]string{
+	"suspended": {"vm.start"},
+}
+
+func proxmoxLockReason(lock string) string {
+	if reason, ok := proxmoxLockReasons[lock]; ok {
+		return reason
+	}
+	return "locked (" + lock + ")"
+}
+
+func proxmoxLockAllows(lock, action string) bool {
+	for _, allowed := range proxmoxLockAllowedActions[lock] {
+		if allowed == action {
+			return true
+		}
+	}
+	return false
+}
+
+// readProxmoxLock 读取虚拟机当前的 lock，查询失败时返回空，交给 Proxmox 自己报错
+func (s *vmLockService) readProxmoxLock(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32) string {
+	status, err := client.GetVMStatus(ctx, nodeName, vmid)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vm status", zap.Error(err),
+			zap.String("node", nodeName), zap.Uint32("vmid", vmid))
+		return ""
+	}
+	lock, _ := status["lock"].(string)
+	return lock
+}
+
+// waitProxmoxUnlock 等待 lock 释放（或变为允许该操作的 lock），超时后返回仍持有的 lock
+func (s *vmLockService) waitProxmoxUnlock(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, action, lock string, timeout time.Duration) string {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return lock
+		case <-ticker.C:
+			current := s.readProxmoxLock(ctx, client, nodeName, vmid)
+			if ctx.Err() != nil {
+				return lock
+			}
+			if current == "" || proxmoxLockAllows(current, action) {
+				return ""
+			}
+			lock = current
+		}
+	}
+}
+
+// runningVMTask 从集群任务列表中查找虚拟机上正在运行的任务
+func runningVMTask(ctx context.Context, client *proxmox.ProxmoxClient, vmid uint32) *v1.VMProxmoxTask {
+	tasks, err := client.GetClusterTasks(ctx)
+	if err != nil {
+		return nil
+	}
+	id := strconv.FormatUint(uint64(vmid), 10)
+	for _, task := range tasks {
+		if taskID, _ := task["id"].(string); taskID != id {
+			continue
+		}
+		// 运行中的任务没有 endtime
+		if _, ok := task["endtime"]; ok {
+			continue
+		}
+		item := &v1.VMProxmoxTask{}
+		item.UPID, _ = task["upid"].(string)
+		item.Type, _ = task["type"].(string)
+		item.User, _ = task["user"].(string)
+		if start, ok := task["starttime"].(float64); ok {
+			item.StartTime = int64(start)
+		}
+		return item
+	}
+	return nil
+}
+
+func (s *vmLockService) CheckProxmox(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmid uint32, action string) error {
+	lock := s.readProxmoxLock(ctx, client, nodeName, vmid)
+	if lock == "" || proxmoxLockAllows(lock, action) {
+		return nil
+	}
+
+	if wait := s.conf.GetInt("vm_lock.proxmox_wait_timeout"); wait > 0 {
+		s.logger.WithContext(ctx).Info("waiting for proxmox vm lock",
+			zap.Uint32("vmid", vmid), zap.String("lock", lock), zap.String("action", action), zap.Int("timeout", wait))
+		lock = s.waitProxmoxUnlock(ctx, client, nodeName, vmid, action, lock, time.Duration(wait)*time.Second)
+		if lock == "" {
+			return nil
+		}
+	}
+
+	detail := fmt.Sprintf("vmid %d is locked by proxmox: %s", vmid, proxmoxLockReason(lock))
+	if task := runningVMTask(ctx, client, vmid); task != nil {
+		detail += fmt.Sprintf("; running task %s (%s) started by %s", task.UPID, task.Type, task.User)
+	}
+	if lock != "suspended" {
+		detail += "; retry after it finishes"
+	}
+	s.logger.WithContext(ctx).Info("vm operation blocked by proxmox lock",
+		zap.Uint32("vmid", vmid), zap.String("lock", lock), zap.String("action", action))
+	return v1.WithDetail(v1.ErrVMProxmoxLocked, detail)
+}
+
+// pendingConfigKeys 返回尚未生效的配置项（修改或删除后等待重启）
+func pendingConfigKeys(items []map[string]interface{}) []string {
+	keys := []string{}
+	for _, item := range items {
+		key, _ := item["key"].(string)
+		if key == "" {
+			continue
+		}
+		_, pending := item["pending"]
+		if pending || proxmoxBool(item["delete"]) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// Proxmox 限速相关上限（超出范围的值 Proxmox 会拒绝，这里提前校验给出明确错误）
+const (
+	qosMaxMbps = 1000000.0 // 磁盘带宽上限（MB/s）
+	qosMaxIops = 10000000  // 磁盘 IOPS 上限
+	qosMaxRate = 100000.0  // 网卡速率上限（MB/s）
+)
+
+type VMQosService interface {
+	CreateProfile(ctx context.Context, req *v1.CreateQosProfileRequest) error
+	UpdateProfile(ctx context.Context, id int64, req *v1.UpdateQosProfileRequest) error
+	DeleteProfile(ctx context.Context, id int64) error
+	GetProfile(ctx context.Context, id int64) (*v1.QosProfileItem, error)
+	ListProfiles(ctx context.Context, req *v1.ListQosProfileRequest) (*v1.ListQosProfileResponseData, error)
+	ApplyProfile(ctx context.Context, id int64, req *v1.ApplyQosProfileRequest) (*v1.ApplyQosProfileResponseData, error)
+	GetVMQos(ctx context.Context, vmID int64) (*v1.GetVMQosResponseData, error)
+	UpdateVMQos(ctx context.Context, req *v1.UpdateVMQosRequest) error
+}
+
+func NewVMQosService(
+	service *Service,
+	profileRepo repository.VmQosProfileRepository,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	vmLock VMLockService,
+	logger *log.Logger,
+) VMQosService {
+	return &vmQosService{
+		profileRepo: profileRepo,
+		vmRepo:      vmRepo,
+		nodeRepo:    nodeRepo,
+		clusterRepo: clusterRepo,
+		vmLock:      vmLock,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type vmQosService struct {
+	profileRepo repository.VmQosProfileRepository
+	vmRepo      repository.PveVMRepository
+	nodeRepo    repository.PveNodeRepository
+	clusterRepo repository.PveClusterRepository
+	vmLock      VMLockService
+	*Service
+	logger *log.Logger
+}
+
+// validateQosLimits 校验限速参数（不能为负数，且不能超过 Proxmox 允许的范围）
+func validateQosLimits(limits *v1.QosLimits) error {
+	if limits.MbpsRd != nil && (*limits.MbpsRd < 0 || *limits.MbpsRd > qosMaxMbps) {
+		return v1.ErrInvalidQosLimit
+	}
+	if limits.MbpsWr != nil && (*limits.MbpsWr < 0 || *limits.MbpsWr > qosMaxMbps) {
+		return v1.ErrInvalidQosLimit
+	}
+	if limits.IopsRd != nil && (*limits.IopsRd < 0 || *limits.IopsRd > qosMaxIops) {
+		return v1.ErrInvalidQosLimit
+	}
+	if limits.IopsWr != nil && (*limits.IopsWr < 0 || *limits.IopsWr > qosMaxIops) {
+		return v1.ErrInvalidQosLimit
+	}
+	if limits.NetRate != nil && (*limits.NetRate < 0 || *limits.NetRate > qosMaxRate) {
+		return v1.ErrInvalidQosLimit
+	}
+	return nil
+}
+
+func (s *vmQosService) CreateProfile(ctx context.Context, req *v1.CreateQosProfileRequest) error {
+	if err := validateQosLimits(&req.QosLimits); err != nil {
+		return err
+	}
+
+	existing, err := s.profileRepo.GetByName(ctx, req.Name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get qos profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return v1.ErrQosProfileNameExists
+	}
+
+	profile := &model.VmQosProfile{
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	applyQosLimitsToProfile(profile, &req.QosLimits)
+
+	if err := s.profileRepo.Create(ctx, profile); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create qos profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *vmQosService) UpdateProfile(ctx context.Context, id int64, req *v1.UpdateQosProfileRequest) error {
+	if err := validateQosLimits(&req.QosLimits); err != nil {
+		return err
+	}
+
+	profile, err := s.profileRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get qos profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if profile == nil {
+		return v1.ErrNotFound
+	}
+
+	if req.Name != nil && *req.Name != profile.Name {
+		existing, err := s.profileRepo.GetByName(ctx, *req.Name)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get qos profile", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if existing != nil {
+			return v1.ErrQosProfileNameExists
+		}
+		profile.Name = *req.Name
+	}
+	if req.Description != nil {
+		profile.Description = *req.Description
+	}
+	applyQosLimitsToProfile(profile, &req.QosLimits)
+
+	if err := s.profileRepo.Update(ctx, profile); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update qos profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *vmQosService) DeleteProfile(ctx context.Context, id int64) error {
+	profile, err := s.profileRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get qos profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if profile == nil {
+		return v1.ErrNotFound
+	}
+
+	if err := s.profileRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete qos profile", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *vmQosService) GetProfile(ctx context.Context, id int64) (*v1.QosProfileItem, error) {
+	profile, err := s.profileRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get qos profile", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if profile == nil {
+		return nil, v1.ErrNotFound
+	}
+	item := toQosProfileItem(profile)
+	return &item, nil
+}
+
+func (s *vmQosService) ListProfiles(ctx context.Context, req *v1.ListQosProfileRequest) (*v1.ListQosProfileResponseData, error) {
+	profiles, total, err := s.profileRepo.ListWithPagination(ctx, req.Page, req.PageSize, req.Name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list qos profiles", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	items := make([]v1.QosProfileItem, 0, len(profiles))
+	for _, profile := range profiles {
+		items = append(items, toQosProfileItem(profile))
+	}
+
+	return &v1.ListQosProfileResponseData{
+		Total: total,
+		List:  items,
+	}, nil
+}
+
+// ApplyProfile 将 QoS 模板应用到一组虚拟机的所有磁盘和网卡
+// 单台虚拟机失败不影响其他虚拟机，结果逐台返回
+func (s *vmQosService) ApplyProfile(ctx context.Context, id int64, req *v1.ApplyQosProfileRequest) (*v1.ApplyQosProfileResponseData, error) {
+	profile, err := s.profileRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get qos profile", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if profile == nil {
+		return nil, v1.ErrNotFound
+	}
+
+	limits := v1.QosLimits{
+		MbpsRd:  &profile.MbpsRd,
+		MbpsWr:  &profile.MbpsWr,
+		IopsRd:  &profile.IopsRd,
+		IopsWr:  &profile.IopsWr,
+		NetRate: &profile.NetRate,
+	}
+
+	result := &v1.ApplyQosProfileResponseData{
+		Results: make([]v1.VMQosApplyItem, 0, len(req.VMIDs)),
+	}
+	for _, vmID := range req.VMIDs {
+		item := v1.VMQosApplyItem{VMID: vmID, Success: true}
+		if err := s.applyVMQos(ctx, vmID, nil, &limits); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to apply qos profile to vm", zap.Error(err),
+				zap.Int64("profile_id", id), zap.Int64("vm_id", vmID))
+			item.Success = false
+			item.Message = err.Error()
+			result.Failed++
+		} else {
+			result.Success++
+		}
+		result.Results = append(result.Results, item)
+	}
+
+	s.logger.WithContext(ctx).Info("qos profile applied", zap.Int64("profile_id", id),
+		zap.Int("success", result.Success), zap.Int("failed", result.Failed))
+	return result, nil
+}
+
+// GetVMQos 从 Proxmox 读取虚拟机当前的磁盘/网卡限速配置
+func (s *vmQosService) GetVMQos(ctx context.Context, vmID int64) (*v1.GetVMQosResponseData, error) {
+	client, node, vm, err := s.getProxmoxClientForVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := client.GetVMCurrentConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.ErrInternalServerError
+	}
+
+	data := &v1.GetVMQosResponseData{
+		VMID:  vmID,
+		Disks: []v1.VMDeviceQos{},
+		Nics:  []v1.VMDeviceQos{},
+	}
+	for key, raw := range config {
+		value, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		dev := proxmox.ParseDeviceConfig(value)
+		switch {
+		case proxmox.IsDiskKey(key):
+			if dev.IsCDROM() {
+				continue
+			}
+			data.Disks = append(data.Disks, v1.VMDeviceQos{
+				Device: key,
+				MbpsRd: parseQosFloat(dev, "mbps_rd"),
+				MbpsWr: parseQosFloat(dev, "mbps_wr"),
+				IopsRd: int(parseQosFloat(dev, "iops_rd")),
+				IopsWr: int(parseQosFloat(dev, "iops_wr")),
+			})
+		case proxmox.IsNetKey(key):
+			data.Nics = append(data.Nics, v1.VMDeviceQos{
+				Device:  key,
+				NetRate: parseQosFloat(dev, "rate"),
+			})
+		}
+	}
+	return data, nil
+}
+
+// UpdateVMQos 更新单台虚拟机的限速配置
+func (s *vmQosService) UpdateVMQos(ctx context.Context, req *v1.UpdateVMQosRequest) error {
+	if err := validateQosLimits(&req.QosLimits); err != nil {
+		return err
+	}
+	return s.applyVMQos(ctx, req.VMID, req.Devices, &req.QosLimits)
+}
+
+// applyVMQos 将限速参数写入虚拟机的磁盘/网卡配置
+// devices 为空时对所有磁盘（不含光驱）和网卡生效；limits 中为 nil 的字段保持不变，为 0 的字段会被移除
+func (s *vmQosService) applyVMQos(ctx context.Context, vmID int64, devices []string, limits *v1.QosLimits) error {
+	client, node, vm, err := s.getProxmoxClientForVM(ctx, vmID)
+	if err != nil {
+		return err
+	}
+	if err := s.vmLock.Check(ctx, vm.Id); err != nil {
+		return err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.qos"); err != nil {
+		return err
+	}
+
+	config, err := client.GetVMCurrentConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm config: %v", err)
+	}
+
+	target := make(map[string]bool, len(devices))
+	for _, d := range devices {
+		target[d] = true
+	}
+
+	updates := make(map[string]interface{})
+	for key, raw := range config {
+		value, ok := raw.(string)
+		if !ok {
+			continue
+		}
+		if len(target) > 0 && !target[key] {
+			continue
+		}
+		dev := proxmox.ParseDeviceConfig(value)
+		switch {
+		case proxmox.IsDiskKey(key):
+			if dev.IsCDROM() {
+				continue
+			}
+			setQosFloat(dev, "mbps_rd", limits.MbpsRd)
+			setQosFloat(dev, "mbps_wr", limits.MbpsWr)
+			setQosInt(dev, "iops_rd", limits.IopsRd)
+			setQosInt(dev, "iops_wr", limits.IopsWr)
+		case proxmox.IsNetKey(key):
+			setQosFloat(dev, "rate", limits.NetRate)
+		default:
+			continue
+		}
+		if newValue := dev.String(); newValue != value {
+			updates[key] = newValue
+		}
+	}
+
+	for d := range target {
+		if _, ok := config[d]; !ok {
+			return v1.WithDetailf(v1.ErrInvalidParameter, "vm has no device %s", d)
+		}
+	}
+
+	if len(updates) == 0 {
+		return nil
+	}
+
+	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, updates); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update vm qos", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update vm qos: %v", err)
+	}
+
+	s.logger.WithContext(ctx).Info("vm qos updated", zap.Uint32("vmid", vm.VMID),
+		zap.String("node", node.NodeName), zap.Any("updates", updates))
+	return nil
+}
+
+// getProxmoxClientForVM 根据虚拟机ID获取ProxmoxClient、节点和虚拟机信息
+func (s *vmQosService) getProxmoxClientForVM(ctx context.Context, vmID int64) (*proxmox.ProxmoxClient, *model.PveNode, *model.PveVM, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, nil, nil, v1.ErrNotFound
+	}
+
+	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, nil, nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
+	}
+
+	cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, nil, nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", vm.ClusterID)
+	}
+
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+
+	return client, node, vm, nil
+}
+
+func applyQosLimitsToProfile(profile *model.VmQosProfile, limits *v1.QosLimits) {
+	if limits.MbpsRd != nil {
+		profile.MbpsRd = *limits.MbpsRd
+	}
+	if limits.MbpsWr != nil {
+		profile.MbpsWr = *limits.MbpsWr
+	}
+	if limits.IopsRd != nil {
+		profile.IopsRd = *limits.IopsRd
+	}
+	if limits.IopsWr != nil {
+		profile.IopsWr = *limits.IopsWr
+	}
+	if limits.NetRate != nil {
+		profile.NetRate = *limits.NetRate
+	}
+}
+
+func toQosProfileItem(profile *model.VmQosProfile) v1.QosProfileItem {
+	return v1.QosProfileItem{
+		Id:          profile.Id,
+		Name:        profile.Name,
+		Description: profile.Description,
+		MbpsRd:      profile.MbpsRd,
+		MbpsWr:      profile.MbpsWr,
+		IopsRd:      profile.IopsRd,
+		IopsWr:      profile.IopsWr,
+		NetRate:     profile.NetRate,
+		CreateTime:  profile.CreateTime,
+		UpdateTime:  profile.UpdateTime,
+		Creator:     profile.Creator,
+		Modifier:    profile.Modifier,
+	}
+}
+
+func parseQosFloat(dev *proxmox.DeviceConfig, key string) float64 {
+	value, ok := dev.Get(key)
+	if !ok {
+		return 0
+	}
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+func setQosFloat(dev *proxmox.DeviceConfig, key string, value *float64) {
+	if value == nil {
+		return
+	}
+	if *value <= 0 {
+		dev.Del(key)
+		return
+	}
+	dev.Set(key, strconv.FormatFloat(*value, 'f', -1, 64))
+}
+
+func setQosInt(dev *proxmox.DeviceConfig, key string, value *int) {
+	if value == nil {
+		return
+	}
+	if *value <= 0 {
+		dev.Del(key)
+		return
+	}
+	dev.Set(key, strconv.Itoa(*value))
+}
@@ -0,0 +1,351 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// migrationWindow 跨集群迁移窗口策略（remote_migration.windows）：窗口内发起的迁移未指定带宽限制时使用窗口的 bwlimit，
+// 计划迁移可以指定在某个窗口开始时发起。时间规则与每周重复的变更窗口相同
+//
+//	remote_migration:
+//	  windows:
+//	    - name: night
+//	      weekdays: [1, 2, 3, 4, 5] # 0=周日 ... 6=周六，为空表示每天
+//	      start: "22:00"
+//	      end: "06:00"              # 早于 start 表示跨零点
+//	      timezone: Asia/Shanghai   # 为空表示服务器本地时区
+//	      bwlimit: 0                # KiB/s，0 表示不限制
+//	    - name: business-hours
+//	      weekdays: [1, 2, 3, 4, 5]
+//	      start: "09:00"
+//	      end: "18:00"
+//	      bwlimit: 51200
+type migrationWindow struct {
+	Name     string `mapstructure:"name"`
+	Weekdays []int  `mapstructure:"weekdays"`
+	Start    string `mapstructure:"start"`
+	End      string `mapstructure:"end"`
+	Timezone string `mapstructure:"timezone"`
+	Bwlimit  int    `mapstructure:"bwlimit"`
+}
+
+// changeWindow 转换为每周重复的变更窗口，复用其时间判断
+func (w *migrationWindow) changeWindow() *model.ChangeWindow {
+	days := make([]string, 0, 7)
+	if len(w.Weekdays) == 0 {
+		days = append(days, "0", "1", "2", "3", "4", "5", "6")
+	}
+	for _, d := range w.Weekdays {
+		days = append(days, strconv.Itoa(d))
+	}
+	return &model.ChangeWindow{
+		Name:       w.Name,
+		Recurrence: model.ChangeWindowRecurrenceWeekly,
+		Weekdays:   strings.Join(days, ","),
+		DailyStart: w.Start,
+		DailyEnd:   w.End,
+		Timezone:   w.Timezone,
+	}
+}
+
+// loadMigrationWindows 读取迁移窗口配置，忽略时间格式错误的窗口
+func loadMigrationWindows(conf *viper.Viper, logger *log.Logger) []migrationWindow {
+	var configured []migrationWindow
+	if err := conf.UnmarshalKey("remote_migration.windows", &configured); err != nil {
+		logger.Warn("invalid remote migration windows, ignored", zap.Error(err))
+		return nil
+	}
+	windows := make([]migrationWindow, 0, len(configured))
+	for _, w := range configured {
+		_, startErr := parseClockMinutes(w.Start)
+		_, endErr := parseClockMinutes(w.End)
+		if w.Name == "" || startErr != nil || endErr != nil {
+			logger.Warn("invalid remote migration window, ignored", zap.String("name", w.Name),
+				zap.String("start", w.Start), zap.String("end", w.End))
+			continue
+		}
+		windows = append(windows, w)
+	}
+	return windows
+}
+
+func findMigrationWindow(windows []migrationWindow, name string) *migrationWindow {
+	for i := range windows {
+		if windows[i].Name == name {
+			return &windows[i]
+		}
+	}
+	return nil
+}
+
+// activeMigrationWindow 返回 now 所在的第一个迁移窗口
+func activeMigrationWindow(windows []migrationWindow, now time.Time) *migrationWindow {
+	for i := range windows {
+		if changeWindowActive(windows[i].changeWindow(), now) {
+			return &windows[i]
+		}
+	}
+	return nil
+}
+
+// nextMigrationWindowStart 返回不早于 after 的窗口时刻：after 已在窗口内时返回 after，否则返回之后最近一次窗口开始时间
+func nextMigrationWindowStart(w *migrationWindow, after time.Time) (time.Time, bool) {
+	cw := w.changeWindow()
+	if changeWindowActive(cw, after) {
+		return after, true
+	}
+	loc := time.Local
+	if w.Timezone != "" {
+		if l, err := time.LoadLocation(w.Timezone); err == nil {
+			loc = l
+		}
+	}
+	start, err := parseClockMinutes(w.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	t := after.In(loc)
+	for day := 0; day <= 7; day++ {
+		candidate := time.Date(t.Year(), t.Month(), t.Day()+day, start/60, start%60, 0, 0, loc)
+		if candidate.After(after) && changeWindowActive(cw, candidate) {
+			return candidate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// remoteMigrateBwlimit 依次使用请求指定、当前迁移窗口和跨站点的带宽限制，返回限制值、来源和当前所在的窗口
+func (s *pveVMService) remoteMigrateBwlimit(ctx context.Context, req *v1.RemoteMigrateVMRequest, sourceClusterID int64,
+	targetCluster *model.PveCluster, now time.Time) (int, string, string) {
+	window := ""
+	active := activeMigrationWindow(loadMigrationWindows(s.conf, s.logger), now)
+	if active != nil {
+		window = active.Name
+	}
+	if req.Bwlimit != nil {
+		return *req.Bwlimit, "request", window
+	}
+	if active != nil && active.Bwlimit > 0 {
+		return active.Bwlimit, "window", window
+	}
+	// 跨站点迁移走专线/公网，未指定带宽限制时使用站点配置，避免占满站点间链路
+	if bwlimit := s.crossSiteBwlimit(ctx, sourceClusterID, targetCluster); bwlimit > 0 {
+		return bwlimit, "cross_site", window
+	}
+	return 0, "", window
+}
+
+// remoteMigration 跨集群迁移涉及的源和目标
+type remoteMigration struct {
+	vm            *model.PveVM
+	client        *proxmox.ProxmoxClient
+	sourceNode    *model.PveNode
+	targetCluster *model.PveCluster
+	targetNode    *model.PveNode
+	targetClient  *proxmox.ProxmoxClient
+}
+
+// loadRemoteMigration 校验并加载迁移的虚拟机、源节点、目标集群和目标节点
+func (s *pveVMService) loadRemoteMigration(ctx context.Context, req *v1.RemoteMigrateVMRequest) (*remoteMigration, error) {
+	// 1. 获取源虚拟机信息
+	vm, err := s.vmRepo.GetByID(ctx, req.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.ErrNotFound
+	}
+
+	// 2. 获取源集群和节点信息
+	client, sourceNode, err := s.getProxmoxClientForVM(ctx, req.VMID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. 获取目标集群信息
+	targetCluster, err := s.clusterRepo.GetByID(ctx, req.TargetClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get target cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if targetCluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "target_cluster_id=%d", req.TargetClusterID)
+	}
+
+	// 4. 获取目标节点信息
+	targetNode, err := s.nodeRepo.GetByID(ctx, req.TargetNodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get target node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if targetNode == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "target_node_id=%d", req.TargetNodeID)
+	}
+
+	// 验证目标节点是否在目标集群内
+	if targetNode.ClusterID != req.TargetClusterID {
+		return nil, v1.ErrTargetNodeNotInCluster
+	}
+
+	targetClient, err := proxmox.NewProxmoxClient(targetCluster.ApiUrl, targetCluster.UserId, targetCluster.UserToken, proxmox.WithRequestLog(targetCluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create target cluster client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	return &remoteMigration{
+		vm:            vm,
+		client:        client,
+		sourceNode:    sourceNode,
+		targetCluster: targetCluster,
+		targetNode:    targetNode,
+		targetClient:  targetClient,
+	}, nil
+}
+
+func (s *pveVMService) RemoteMigratePreflight(ctx context.Context, req *v1.RemoteMigrateVMRequest) (*v1.RemoteMigratePreflightData, error) {
+	m, err := s.loadRemoteMigration(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.remoteMigratePreflight(ctx, m, req), nil
+}
+
+// remoteMigratePreflight 发起跨集群迁移前检查目标存储、网桥、证书指纹、两端 Token 权限和目标 VMID，
+// 无法从 Proxmox 获取数据的检查项记为警告
+func (s *pveVMService) remoteMigratePreflight(ctx context.Context, m *remoteMigration, req *v1.RemoteMigrateVMRequest) *v1.RemoteMigratePreflightData {
+	targetVMID := m.vm.VMID
+	if req.TargetVMID != nil {
+		targetVMID = uint32(*req.TargetVMID)
+	}
+	data := &v1.RemoteMigratePreflightData{
+		VmID:            m.vm.Id,
+		VMID:            m.vm.VMID,
+		TargetClusterID: m.targetCluster.Id,
+		TargetNode:      m.targetNode.NodeName,
+		TargetVMID:      targetVMID,
+		Blockers:        []v1.MigrationCheckIssue{},
+		Warnings:        []v1.MigrationCheckIssue{},
+	}
+	block := func(code, format string, args ...interface{}) {
+		data.Blockers = append(data.Blockers, v1.MigrationCheckIssue{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(code, format string, args ...interface{}) {
+		data.Warnings = append(data.Warnings, v1.MigrationCheckIssue{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+	defer func() {
+		data.Passed = len(data.Blockers) == 0
+	}()
+	data.Bwlimit, data.BwlimitSource, data.Window = s.remoteMigrateBwlimit(ctx, req, m.vm.ClusterID, m.targetCluster, time.Now())
+
+	// 需要复制的磁盘大小
+	if config, err := m.client.GetVMConfig(ctx, m.sourceNode.NodeName, m.vm.VMID); err != nil {
+		warn("source_config_unavailable", "failed to read vm config, disk size unknown: %v", err)
+	} else {
+		for key, value := range config {
+			spec, _ := value.(string)
+			if !movableDiskPattern.MatchString(key) || strings.Contains(","+spec+",", ",media=cdrom,") {
+				continue
+			}
+			data.RequiredBytes += parseDiskSize(spec)
+		}
+	}
+
+	// 目标存储：存在、启用、支持 images 且空间足够
+	storages, err := nodeStoragesByName(ctx, m.targetClient, m.targetNode.NodeName)
+	if err != nil {
+		block("target_unreachable", "failed to query target node %s: %v", m.targetNode.NodeName, err)
+		return data
+	}
+	storage := storages[req.TargetStorage]
+	for _, issue := range checkCreateVMStorage(storage, req.TargetStorage, m.targetNode.NodeName, "images") {
+		block("target_storage", "%s", issue)
+	}
+	if storage != nil {
+		if avail, ok := storage["avail"].(float64); ok && data.RequiredBytes > int64(avail) {
+			block("storage_insufficient", "storage %s needs about %.1f GiB but only %.1f GiB is available",
+				req.TargetStorage, float64(data.RequiredBytes)/(1<<30), avail/(1<<30))
+		}
+	}
+
+	// 目标网桥
+	if networks, err := m.targetClient.GetNodeNetworks(ctx, m.targetNode.NodeName); err != nil {
+		warn("bridge_unknown", "failed to list networks on %s: %v", m.targetNode.NodeName, err)
+	} else {
+		found := false
+		for _, item := range networks {
+			if iface, _ := item["iface"].(string); iface == req.TargetBridge {
+				found = true
+				break
+			}
+		}
+		if !found {
+			block("bridge_missing", "bridge %s does not exist on node %s", req.TargetBridge, m.targetNode.NodeName)
+		}
+	}
+
+	// 证书指纹
+	if _, _, err := resolveNodeFingerprint(ctx, s.certRepo, m.targetClient, m.targetCluster, m.targetNode.NodeName, s.logger); err != nil {
+		if errors.Is(err, v1.ErrNodeCertificateChanged) {
+			block("fingerprint_changed", "%s", err.Error())
+		} else {
+			block("fingerprint_unavailable", "%s", err.Error())
+		}
+	}
+
+	// Token 权限：目标集群需要创建虚拟机，源集群需要迁移虚拟机
+	for _, side := range []struct {
+		client  *proxmox.ProxmoxClient
+		cluster *model.PveCluster
+		feature string
+		code    string
+	}{
+		{m.targetClient, m.targetCluster, "vm.create", "target_privileges"},
+		{m.client, nil, "vm.migrate", "source_privileges"},
+	} {
+		userID := ""
+		name := "source cluster"
+		if side.cluster != nil {
+			userID, name = side.cluster.UserId, "target cluster "+side.cluster.ClusterName
+		} else if source, err := s.clusterRepo.GetByID(ctx, m.vm.ClusterID); err == nil && source != nil {
+			userID, name = source.UserId, "source cluster "+source.ClusterName
+		}
+		capabilities := probeClusterCapabilities(ctx, side.client, userID)
+		if capabilities.Message != "" {
+			warn("privileges_unknown", "failed to check token privileges on %s: %s", name, capabilities.Message)
+			continue
+		}
+		for _, feature := range capabilities.Features {
+			if feature.Feature == side.feature && !feature.Supported {
+				block(side.code, "token on %s is missing %s", name, strings.Join(feature.Missing, ", "))
+			}
+		}
+	}
+
+	// 目标 VMID 未被占用
+	if resources, err := m.targetClient.GetClusterResourcesByType(ctx, "vm"); err != nil {
+		warn("vmid_unknown", "failed to list vms on target cluster: %v", err)
+	} else {
+		for _, item := range resources {
+			if vmid, ok := item["vmid"].(float64); ok && uint32(vmid) == targetVMID {
+				block("vmid_in_use", "vmid %d is already used on target cluster %s", targetVMID, m.targetCluster.ClusterName)
+				break
+			}
+		}
+	}
+	return data
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 安全检查默认参数，可通过 security_scan.* 调整
+const (
+	defaultSecurityMinMachineVersion = "6.0"
+	defaultSecurityCPUType           = "x86-64-v2-AES"
+)
+
+// securityMachinePattern 固定版本的机器类型，如 pc-i440fx-5.1、pc-q35-6.2+pve0
+var securityMachinePattern = regexp.MustCompile(`^pc-(i440fx|q35)-(\d+)\.(\d+)`)
+
+// securityCheck 检查项的风险等级和修复建议
+type securityCheck struct {
+	severity    string
+	remediation string
+	fixable     bool
+	restart     bool // 自动修复后需要关机再启动才能生效
+}
+
+var securityChecks = map[string]securityCheck{
+	v1.SecurityFindingAgentDisabled: {
+		severity:    v1.SecuritySeverityLow,
+		remediation: "enable the qemu guest agent option and install qemu-guest-agent in the guest",
+		fixable:     true,
+		restart:     true,
+	},
+	v1.SecurityFindingFirewallDisabled: {
+		severity:    v1.SecuritySeverityMedium,
+		remediation: "enable the proxmox firewall on the nic (firewall=1) and define vm firewall rules",
+		fixable:     true,
+	},
+	v1.SecurityFindingVNCNoPassword: {
+		severity:    v1.SecuritySeverityHigh,
+		remediation: "remove the -vnc argument from args or add password=on and set a vnc password",
+	},
+	v1.SecurityFindingOutdatedMachine: {
+		severity:    v1.SecuritySeverityLow,
+		remediation: "switch to the latest machine version; windows guests may detect new hardware",
+		fixable:     true,
+		restart:     true,
+	},
+	v1.SecurityFindingISOMounted: {
+		severity:    v1.SecuritySeverityLow,
+		remediation: "eject the iso from the cdrom drive",
+		fixable:     true,
+	},
+	v1.SecurityFindingCPUKVM64Prod: {
+		severity:    v1.SecuritySeverityMedium,
+		remediation: "use a modern cpu type such as x86-64-v2-AES or host so the guest gets current cpu flags and mitigations",
+		fixable:     true,
+		restart:     true,
+	},
+}
+
+var securitySeverityRank = map[string]int{
+	v1.SecuritySeverityHigh:   3,
+	v1.SecuritySeverityMedium: 2,
+	v1.SecuritySeverityLow:    1,
+}
+
+// VMSecurityService 扫描虚拟机配置中的安全风险项，并提供自动修复
+type VMSecurityService interface {
+	// Scan 实时扫描单台虚拟机并保存结果
+	Scan(ctx context.Context, vmID int64) (*v1.VMSecurityScanData, error)
+	// ScanAll 扫描全部集群的虚拟机，返回扫描成功的数量
+	ScanAll(ctx context.Context) (int, error)
+	List(ctx context.Context, req *v1.ListVMSecurityFindingsRequest) (*v1.ListVMSecurityFindingsResponseData, error)
+	// Remediate 自动修复指定的风险项，修复后重新扫描
+	Remediate(ctx context.Context, userID string, vmID int64, req *v1.RemediateVMSecurityRequest) (*v1.RemediateVMSecurityData, error)
+}
+
+func NewVMSecurityService(
+	service *Service,
+	conf *viper.Viper,
+	securityRepo repository.VMSecurityRepository,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	changeControl ChangeControlService,
+	vmLock VMLockService,
+	logger *log.Logger,
+) VMSecurityService {
+	ignored := make(map[string]bool)
+	for _, code := range conf.GetStringSlice("security_scan.ignore") {
+		ignored[code] = true
+	}
+	minMachine := conf.GetString("security_scan.min_machine_version")
+	if minMachine == "" {
+		minMachine = defaultSecurityMinMachineVersion
+	}
+	cpuType := conf.GetString("security_scan.cpu_type")
+	if cpuType == "" {
+		cpuType = defaultSecurityCPUType
+	}
+	return &vmSecurityService{
+		Service:       service,
+		securityRepo:  securityRepo,
+		vmRepo:        vmRepo,
+		nodeRepo:      nodeRepo,
+		clusterRepo:   clusterRepo,
+		userRepo:      userRepo,
+		changeControl: changeControl,
+		vmLock:        vmLock,
+		ignored:       ignored,
+		minMachine:    parseMachineVersion(minMachine),
+		cpuType:       cpuType,
+		logger:        logger,
+	}
+}
+
+type vmSecurityService struct {
+	*Service
+	securityRepo  repository.VMSecurityRepository
+	vmRepo        repository.PveVMRepository
+	nodeRepo      repository.PveNodeRepository
+	clusterRepo   repository.PveClusterRepository
+	userRepo      repository.UserRepository
+	changeControl ChangeControlService
+	vmLock        VMLockService
+	ignored       map[string]bool // security_scan.ignore 中关闭的检查项
+	minMachine    [2]int
+	cpuType       string
+	logger        *log.Logger
+}
+
+func (s *vmSecurityService) Scan(ctx context.Context, vmID int64) (*v1.VMSecurityScanData, error) {
+	vm, cluster, err := s.getVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	config, err := client.GetVMConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+	findings, err := s.save(ctx, vm, cluster, config)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.VMSecurityScanData{VmId: vm.Id, ScanTime: time.Now(), Findings: toVMSecurityFindingItems(findings)}, nil
+}
+
+func (s *vmSecurityService) ScanAll(ctx context.Context) (int, error) {
+	clusters, err := s.clusterRepo.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var scanned int
+	for _, cluster := range clusters {
+		client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to create proxmox client", zap.String("cluster", cluster.ClusterName), zap.Error(err))
+			continue
+		}
+		nodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return scanned, err
+		}
+		nodeNames := make(map[int64]string, len(nodes))
+		for _, node := range nodes {
+			nodeNames[node.Id] = node.NodeName
+		}
+		vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+		if err != nil {
+			return scanned, err
+		}
+		for _, vm := range vms {
+			if vm.IsTemplate == 1 || nodeNames[vm.NodeID] == "" {
+				continue
+			}
+			config, err := client.GetVMConfig(ctx, nodeNames[vm.NodeID], vm.VMID)
+			if err != nil {
+				s.logger.WithContext(ctx).Warn("failed to get vm config", zap.String("vm", vm.VmName), zap.Error(err))
+				continue
+			}
+			if _, err := s.save(ctx, vm, cluster, config); err != nil {
+				return scanned, err
+			}
+			scanned++
+		}
+	}
+	if _, err := s.securityRepo.DeleteOrphaned(ctx); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to delete orphaned security findings", zap.Error(err))
+	}
+	s.logger.WithContext(ctx).Info("vm security scan finished", zap.Int("scanned", scanned))
+	return scanned, nil
+}
+
+func (s *vmSecurityService) List(ctx context.Context, req *v1.ListVMSecurityFindingsRequest) (*v1.ListVMSecurityFindingsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	findings, total, err := s.securityRepo.List(ctx, page, pageSize, req.ClusterID, req.Severity, req.Code)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list security findings", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	counts, err := s.securityRepo.CountBySeverity(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to count security findings", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	return &v1.ListVMSecurityFindingsResponseData{
+		Total:      total,
+		BySeverity: counts,
+		List:       toVMSecurityFindingItems(findings),
+	}, nil
+}
+
+func (s *vmSecurityService) Remediate(ctx context.Context, userID string, vmID int64, req *v1.RemediateVMSecurityRequest) (*v1.RemediateVMSecurityData, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return nil, v1.ErrUnauthorized
+	}
+	vm, cluster, err := s.getVM(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "vm.security_remediate",
+		Target:    vm.VmName,
+		ClusterID: vm.ClusterID,
+		AppId:     vm.AppId,
+		VMId:      vm.Id,
+	}); err != nil {
+		return nil, err
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.config"); err != nil {
+		return nil, err
+	}
+	config, err := client.GetVMConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+	}
+
+	byCode := make(map[string][]*model.VMSecurityFinding)
+	for _, finding := range s.scanConfig(vm, cluster, config) {
+		byCode[finding.Code] = append(byCode[finding.Code], finding)
+	}
+	data := &v1.RemediateVMSecurityData{Applied: make([]string, 0), Skipped: make([]v1.VMSecuritySkipped, 0)}
+	changes := make(map[string]interface{})
+	seen := make(map[string]bool)
+	for _, code := range req.Codes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		findings := byCode[code]
+		if len(findings) == 0 {
+			data.Skipped = append(data.Skipped, v1.VMSecuritySkipped{Code: code, Reason: "not found in current config"})
+			continue
+		}
+		s.remediation(code, config, findings, changes)
+		data.Applied = append(data.Applied, code)
+		data.RestartRequired = data.RestartRequired || securityChecks[code].restart
+	}
+
+	if len(changes) > 0 {
+		if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, changes); err != nil {
+			s.logger.WithContext(ctx).Error("failed to remediate vm config", zap.Error(err),
+				zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+			return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+		}
+		s.logger.WithContext(ctx).Info("vm security findings remediated", zap.Int64("vm_id", vm.Id),
+			zap.Strings("codes", data.Applied), zap.String("operator", user.Username))
+		if config, err = client.GetVMConfig(ctx, node.NodeName, vm.VMID); err != nil {
+			s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+				zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+			return nil, v1.WithDetail(v1.ErrProxmoxRequestFailed, err.Error())
+		}
+	}
+	findings, err := s.save(ctx, vm, cluster, config)
+	if err != nil {
+		return nil, err
+	}
+	data.Findings = toVMSecurityFindingItems(findings)
+	return data, nil
+}
+
+// remediation 把修复风险项需要的配置修改写入 changes
+func (s *vmSecurityService) remediation(code string, config map[string]interface{}, findings []*model.VMSecurityFinding, changes map[string]interface{}) {
+	switch code {
+	case v1.SecurityFindingAgentDisabled:
+		prev, _ := config["agent"].(string)
+		if v, ok := config["agent"].(float64); ok {
+			prev = strconv.Itoa(int(v))
+		}
+		changes["agent"] = agentOptionEnabled(prev)
+	case v1.SecurityFindingFirewallDisabled:
+		enabled := true
+		for _, finding := range findings {
+			value, _ := config[finding.Target].(string)
+			dev := proxmox.ParseDeviceConfig(value)
+			setNICOptions(dev, nil, &enabled)
+			changes[finding.Target] = dev.String()
+		}
+	case v1.SecurityFindingOutdatedMachine:
+		value, _ := config["machine"].(string)
+		dev := proxmox.ParseDeviceConfig(value)
+		dev.Head = "pc"
+		if strings.HasPrefix(value, "pc-q35") {
+			dev.Head = "q35"
+		}
+		changes["machine"] = dev.String()
+	case v1.SecurityFindingISOMounted:
+		for _, finding := range findings {
+			changes[finding.Target] = "none,media=cdrom"
+		}
+	case v1.SecurityFindingCPUKVM64Prod:
+		value, _ := config["cpu"].(string)
+		dev := proxmox.ParseDeviceConfig(value)
+		if _, ok := dev.Get("cputype"); ok {
+			dev.Set("cputype", s.cpuType)
+		} else {
+			dev.Head = s.cpuType
+		}
+		changes["cpu"] = dev.String()
+	}
+}
+
+func (s *vmSecurityService) getVM(ctx context.Context, vmID int64) (*model.PveVM, *model.PveCluster, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, nil, v1.ErrVMNotFound
+	}
+	if vm.IsTemplate == 1 {
+		return nil, nil, v1.WithDetail(v1.ErrBadRequest, "vm is a template")
+	}
+	cluster, err := s.clusterRepo.GetByID(ctx, vm.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	return vm, cluster, nil
+}
+
+func (s *vmSecurityService) save(ctx context.Context, vm *model.PveVM, cluster *model.PveCluster, config map[string]interface{}) ([]*model.VMSecurityFinding, error) {
+	findings := s.scanConfig(vm, cluster, config)
+	if err := s.securityRepo.ReplaceByVM(ctx, vm.Id, findings); err != nil {
+		s.logger.WithContext(ctx).Error("failed to save security findings", zap.Error(err), zap.Int64("vm_id", vm.Id))
+		return nil, v1.ErrInternalServerError
+	}
+	return findings, nil
+}
+
+// scanConfig 按检查项逐一检查虚拟机配置，返回按风险等级从高到低排序的风险项
+func (s *vmSecurityService) scanConfig(vm *model.PveVM, cluster *model.PveCluster, config map[string]interface{}) []*model.VMSecurityFinding {
+	now := time.Now()
+	findings := make([]*model.VMSecurityFinding, 0)
+	add := func(code, target, message string) {
+		if s.ignored[code] {
+			return
+		}
+		findings = append(findings, &model.VMSecurityFinding{
+			ClusterID: vm.ClusterID,
+			VmId:      vm.Id,
+			VMID:      vm.VMID,
+			VmName:    vm.VmName,
+			Code:      code,
+			Severity:  securityChecks[code].severity,
+			Target:    target,
+			Message:   message,
+			ScanTime:  now,
+		})
+	}
+	str := func(key string) string {
+		value, _ := config[key].(string)
+		return value
+	}
+
+	if !agentEnabled(config) {
+		add(v1.SecurityFindingAgentDisabled, "agent", "qemu guest agent is disabled")
+	}
+
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch {
+		case proxmox.IsNetKey(key):
+			if firewall, _ := proxmox.ParseDeviceConfig(str(key)).Get("firewall"); firewall != "1" {
+				add(v1.SecurityFindingFirewallDisabled, key, fmt.Sprintf("firewall is disabled on %s", key))
+			}
+		case proxmox.IsDiskKey(key):
+			dev := proxmox.ParseDeviceConfig(str(key))
+			if dev.IsCDROM() && dev.Head != "" && dev.Head != "none" && !strings.Contains(dev.Head, "cloudinit") {
+				add(v1.SecurityFindingISOMounted, key, fmt.Sprintf("%s is still mounted on %s", dev.Head, key))
+			}
+		}
+	}
+
+	if args := str("args"); strings.Contains(args, "-vnc") && !strings.Contains(args, "password") {
+		add(v1.SecurityFindingVNCNoPassword, "args", "vnc is exposed through args without a password")
+	}
+
+	if machine := str("machine"); machine != "" {
+		if m := securityMachinePattern.FindStringSubmatch(machine); m != nil {
+			major, _ := strconv.Atoi(m[2])
+			minor, _ := strconv.Atoi(m[3])
+			if major < s.minMachine[0] || (major == s.minMachine[0] && minor < s.minMachine[1]) {
+				add(v1.SecurityFindingOutdatedMachine, "machine", fmt.Sprintf("machine type %s is older than %d.%d", machine, s.minMachine[0], s.minMachine[1]))
+			}
+		}
+	}
+
+	if vm.Environment == "prod" || (cluster != nil && cluster.Env == "prod") {
+		dev := proxmox.ParseDeviceConfig(str("cpu"))
+		cpuType := dev.Head
+		if v, ok := dev.Get("cputype"); ok {
+			cpuType = v
+		}
+		// 未设置 cpu 时 Proxmox 默认使用 kvm64
+		if cpuType == "" || cpuType == "kvm64" {
+			add(v1.SecurityFindingCPUKVM64Prod, "cpu", "production vm uses the kvm64 cpu type")
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return securitySeverityRank[findings[i].Severity] > securitySeverityRank[findings[j].Severity]
+	})
+	return findings
+}
+
+// parseMachineVersion 解析 security_scan.min_machine_version，如 "6.0"
+func parseMachineVersion(version string) [2]int {
+	var v [2]int
+	parts := strings.SplitN(version, ".", 2)
+	v[0], _ = strconv.Atoi(parts[0])
+	if len(parts) > 1 {
+		v[1], _ = strconv.Atoi(parts[1])
+	}
+	return v
+}
+
+func toVMSecurityFindingItems(findings []*model.VMSecurityFinding) []v1.VMSecurityFindingItem {
+	items := make([]v1.VMSecurityFindingItem, 0, len(findings))
+	for _, f := range findings {
+		check := securityChecks[f.Code]
+		items = append(items, v1.VMSecurityFindingItem{
+			ClusterID:   f.ClusterID,
+			VmId:        f.VmId,
+			VMID:        f.VMID,
+			VmName:      f.VmName,
+			Code:        f.Code,
+			Severity:    f.Severity,
+			Target:      f.Target,
+			Message:     f.Message,
+			Remediation: check.remediation,
+			Fixable:     check.fixable,
+			ScanTime:    f.ScanTime,
+		})
+	}
+	return items
+}
@@ -0,0 +1,735 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"go.uber.org/zap"
+)
+
+// stack 规模限制
+const maxVMStackMembers = 100
+
+// stack 创建过程中各步骤的超时
+const (
+	vmStackCloneTimeout  = 30 * time.Minute
+	vmStackStartTimeout  = 5 * time.Minute
+	vmStackIPWaitTimeout = 5 * time.Minute
+)
+
+// vmStackNamePattern stack / 角色名称同时用于虚拟机名称（即 cloud-init hostname），需满足 DNS label 规则
+var vmStackNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+type VMStackService interface {
+	CreateStack(ctx context.Context, req *v1.CreateVMStackRequest, creator string) (*v1.VMStackDetail, error)
+	GetStack(ctx context.Context, id int64) (*v1.VMStackDetail, error)
+	ListStacks(ctx context.Context, req *v1.ListVMStacksRequest) (*v1.ListVMStacksResponseData, error)
+	GetStackIPs(ctx context.Context, id int64) (*v1.GetVMStackIPsResponseData, error)
+	DeleteStack(ctx context.Context, id int64) error
+}
+
+func NewVMStackService(
+	service *Service,
+	stackRepo repository.VmStackRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	templateRepo repository.PveTemplateRepository,
+	templateInstanceRepo repository.TemplateInstanceRepository,
+	changeControl ChangeControlService,
+	templateUsage TemplateUsageService,
+	logger *log.Logger,
+) VMStackService {
+	return &vmStackService{
+		stackRepo:            stackRepo,
+		clusterRepo:          clusterRepo,
+		nodeRepo:             nodeRepo,
+		vmRepo:               vmRepo,
+		templateRepo:         templateRepo,
+		templateInstanceRepo: templateInstanceRepo,
+		changeControl:        changeControl,
+		templateUsage:        templateUsage,
+		Service:              service,
+		logger:               logger,
+	}
+}
+
+type vmStackService struct {
+	stackRepo            repository.VmStackRepository
+	clusterRepo          repository.PveClusterRepository
+	nodeRepo             repository.PveNodeRepository
+	vmRepo               repository.PveVMRepository
+	templateRepo         repository.PveTemplateRepository
+	templateInstanceRepo repository.TemplateInstanceRepository
+	changeControl        ChangeControlService
+	templateUsage        TemplateUsageService
+	*Service
+	logger *log.Logger
+
+	// 当前进程内正在创建的 stack，创建中的 stack 不允许删除
+	runningStacks sync.Map // map[int64]struct{}
+}
+
+// CreateStack 校验并规划全部虚拟机（名称、节点、IP），随后异步按顺序创建，立即返回规划结果
+func (s *vmStackService) CreateStack(ctx context.Context, req *v1.CreateVMStackRequest, creator string) (*v1.VMStackDetail, error) {
+	if err := validateVMStackRequest(req); err != nil {
+		return nil, err
+	}
+
+	cluster, err := s.clusterRepo.GetByID(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", req.ClusterID)
+	}
+	if cluster.IsSchedulable != 1 {
+		return nil, v1.WithDetailf(v1.ErrClusterNotSchedulable, "cluster=%s", cluster.ClusterName)
+	}
+
+	// 变更管控校验（在请求内完成，异步创建阶段不再重复校验）
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "stack.create",
+		Target:    req.Name,
+		ClusterID: cluster.Id,
+	}); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.stackRepo.GetByName(ctx, req.Name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm stack", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if existing != nil {
+		return nil, v1.WithDetail(v1.ErrVMStackNameExists, req.Name)
+	}
+
+	// 集群内可调度节点（角色未指定 node_ids 时使用）
+	clusterNodes, err := s.nodeRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster nodes", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	schedulable := make([]*model.PveNode, 0, len(clusterNodes))
+	nodeByID := make(map[int64]*model.PveNode, len(clusterNodes))
+	for _, n := range clusterNodes {
+		nodeByID[n.Id] = n
+		if n.IsSchedulable == 1 && n.Status == "online" {
+			schedulable = append(schedulable, n)
+		}
+	}
+
+	// 角色按 order 排序（相同 order 保持定义顺序）
+	roles := make([]v1.VMStackRole, len(req.Roles))
+	copy(roles, req.Roles)
+	sort.SliceStable(roles, func(i, j int) bool { return roles[i].Order < roles[j].Order })
+
+	members := make([]*model.VmStackMember, 0)
+	for _, role := range roles {
+		templateID := role.TemplateID
+		if templateID <= 0 {
+			templateID = req.TemplateID
+		}
+		template, err := s.templateRepo.GetByID(ctx, templateID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get template", zap.Error(err), zap.Int64("template_id", templateID))
+			return nil, v1.ErrInternalServerError
+		}
+		if template == nil {
+			return nil, v1.WithDetailf(v1.ErrTemplateNotFound, "role=%s, template_id=%d", role.Name, templateID)
+		}
+
+		nodes := schedulable
+		if len(role.NodeIDs) > 0 {
+			nodes = make([]*model.PveNode, 0, len(role.NodeIDs))
+			for _, id := range role.NodeIDs {
+				n, ok := nodeByID[id]
+				if !ok {
+					return nil, v1.WithDetailf(v1.ErrTargetNodeNotInCluster, "role=%s, node_id=%d", role.Name, id)
+				}
+				nodes = append(nodes, n)
+			}
+		}
+		if len(nodes) == 0 {
+			return nil, v1.WithDetailf(v1.ErrVMStackNoSchedulableNode, "cluster=%s", cluster.ClusterName)
+		}
+
+		cloudInit, err := json.Marshal(mergeVMStackCloudInit(req.CloudInit, role.CloudInit))
+		if err != nil {
+			return nil, v1.ErrInternalServerError
+		}
+		gateway := mergeVMStackCloudInit(req.CloudInit, role.CloudInit).Gateway
+
+		for i := 0; i < role.Count; i++ {
+			node := nodes[i%len(nodes)]
+			member := &model.VmStackMember{
+				Role:       role.Name,
+				RoleOrder:  role.Order,
+				Seq:        i + 1,
+				VmName:     fmt.Sprintf("%s-%s-%d", req.Name, role.Name, i+1),
+				NodeID:     node.Id,
+				NodeName:   node.NodeName,
+				TemplateID: template.Id,
+				Storage:    role.Storage,
+				IPConfig:   "ip=dhcp",
+				CloudInit:  string(cloudInit),
+				Status:     model.VmStackMemberStatusPending,
+			}
+			if role.CPUNum != nil {
+				member.CPUNum = *role.CPUNum
+			}
+			if role.MemorySize != nil {
+				member.MemorySize = *role.MemorySize
+			}
+			if len(role.IPAddresses) > 0 {
+				cidr := role.IPAddresses[i]
+				member.IPConfig = "ip=" + cidr
+				if gateway != "" {
+					member.IPConfig += ",gw=" + gateway
+				}
+				member.IPAddress = strings.SplitN(cidr, "/", 2)[0]
+			}
+			members = append(members, member)
+		}
+	}
+
+	spec, err := json.Marshal(req)
+	if err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+	stack := &model.VmStack{
+		Name:        req.Name,
+		ClusterID:   cluster.Id,
+		Description: req.Description,
+		Spec:        string(spec),
+		Status:      model.VmStackStatusPending,
+		MemberCount: len(members),
+		Creator:     creator,
+	}
+
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.stackRepo.Create(ctx, stack); err != nil {
+			return err
+		}
+		for _, m := range members {
+			m.StackID = stack.Id
+		}
+		return s.stackRepo.CreateMembers(ctx, members)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vm stack", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	s.runningStacks.Store(stack.Id, struct{}{})
+	go s.executeStack(stack.Id)
+
+	return toVMStackDetail(stack, members), nil
+}
+
+func validateVMStackRequest(req *v1.CreateVMStackRequest) error {
+	if !vmStackNamePattern.MatchString(req.Name) {
+		return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "name %q must consist of lowercase letters, digits and '-'", req.Name)
+	}
+
+	total := 0
+	roleNames := make(map[string]struct{}, len(req.Roles))
+	for _, role := range req.Roles {
+		if !vmStackNamePattern.MatchString(role.Name) {
+			return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "role name %q must consist of lowercase letters, digits and '-'", role.Name)
+		}
+		if _, ok := roleNames[role.Name]; ok {
+			return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "duplicate role %q", role.Name)
+		}
+		roleNames[role.Name] = struct{}{}
+
+		if role.Count <= 0 {
+			return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "role %q: count must be positive", role.Name)
+		}
+		total += role.Count
+		if role.TemplateID <= 0 && req.TemplateID <= 0 {
+			return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "role %q: template_id is required", role.Name)
+		}
+		if role.CPUNum != nil && *role.CPUNum <= 0 {
+			return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "role %q: cpu_num must be positive", role.Name)
+		}
+		if role.MemorySize != nil && *role.MemorySize <= 0 {
+			return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "role %q: memory_size must be positive", role.Name)
+		}
+		if len(role.IPAddresses) > 0 {
+			if len(role.IPAddresses) != role.Count {
+				return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "role %q: %d ip_addresses for %d vms", role.Name, len(role.IPAddresses), role.Count)
+			}
+			for _, cidr := range role.IPAddresses {
+				if _, _, err := net.ParseCIDR(cidr); err != nil {
+					return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "role %q: invalid ip address %q, expected CIDR like 10.0.0.11/24", role.Name, cidr)
+				}
+			}
+		}
+	}
+	if total > maxVMStackMembers {
+		return v1.WithDetailf(v1.ErrVMStackInvalidSpec, "stack has %d vms, at most %d allowed", total, maxVMStackMembers)
+	}
+	return nil
+}
+
+// mergeVMStackCloudInit 角色级配置中非空字段覆盖公共配置
+func mergeVMStackCloudInit(base, override *v1.VMStackCloudInit) v1.VMStackCloudInit {
+	var merged v1.VMStackCloudInit
+	if base != nil {
+		merged = *base
+	}
+	if override == nil {
+		return merged
+	}
+	if override.User != "" {
+		merged.User = override.User
+	}
+	if override.Password != "" {
+		merged.Password = override.Password
+	}
+	if override.SSHKeys != "" {
+		merged.SSHKeys = override.SSHKeys
+	}
+	if override.Gateway != "" {
+		merged.Gateway = override.Gateway
+	}
+	if override.Nameserver != "" {
+		merged.Nameserver = override.Nameserver
+	}
+	if override.Searchdomain != "" {
+		merged.Searchdomain = override.Searchdomain
+	}
+	return merged
+}
+
+// executeStack 按顺序逐台创建虚拟机；任一台失败则停止，后续虚拟机标记为 skipped
+func (s *vmStackService) executeStack(stackID int64) {
+	defer s.runningStacks.Delete(stackID)
+	ctx := context.Background()
+
+	stack, err := s.stackRepo.GetByID(ctx, stackID)
+	if err != nil || stack == nil {
+		s.logger.Error("failed to load vm stack", zap.Int64("stack_id", stackID), zap.Error(err))
+		return
+	}
+	members, err := s.stackRepo.ListMembers(ctx, stackID)
+	if err != nil {
+		s.logger.Error("failed to load vm stack members", zap.Int64("stack_id", stackID), zap.Error(err))
+		return
+	}
+
+	now := time.Now()
+	stack.Status = model.VmStackStatusRunning
+	stack.StartTime = &now
+	if err := s.stackRepo.Update(ctx, stack); err != nil {
+		s.logger.Error("failed to update vm stack", zap.Int64("stack_id", stackID), zap.Error(err))
+	}
+
+	finish := func(status, errMsg string) {
+		end := time.Now()
+		stack.Status = status
+		stack.ErrorMessage = errMsg
+		stack.EndTime = &end
+		if err := s.stackRepo.Update(ctx, stack); err != nil {
+			s.logger.Error("failed to update vm stack", zap.Int64("stack_id", stackID), zap.Error(err))
+		}
+	}
+
+	cluster, err := s.clusterRepo.GetByID(ctx, stack.ClusterID)
+	if err != nil || cluster == nil {
+		finish(model.VmStackStatusFailed, "cluster not found")
+		return
+	}
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		finish(model.VmStackStatusFailed, fmt.Sprintf("create proxmox client: %v", err))
+		return
+	}
+
+	var failed *model.VmStackMember
+	for _, m := range members {
+		if failed != nil {
+			m.Status = model.VmStackMemberStatusSkipped
+			s.saveMember(ctx, m)
+			continue
+		}
+		if err := s.provisionMember(ctx, client, cluster, stack.Creator, m); err != nil {
+			s.logger.Error("failed to provision vm stack member",
+				zap.Int64("stack_id", stackID), zap.String("vm_name", m.VmName), zap.Error(err))
+			m.Status = model.VmStackMemberStatusFailed
+			m.ErrorMessage = err.Error()
+			s.saveMember(ctx, m)
+			failed = m
+		}
+	}
+
+	if failed != nil {
+		finish(model.VmStackStatusFailed, fmt.Sprintf("%s: %s", failed.VmName, failed.ErrorMessage))
+		return
+	}
+	finish(model.VmStackStatusCompleted, "")
+	s.logger.Info("vm stack provisioned", zap.Int64("stack_id", stackID), zap.String("name", stack.Name), zap.Int("vms", len(members)))
+}
+
+func (s *vmStackService) saveMember(ctx context.Context, m *model.VmStackMember) {
+	if err := s.stackRepo.UpdateMember(ctx, m); err != nil {
+		s.logger.Error("failed to update vm stack member", zap.Int64("member_id", m.Id), zap.Error(err))
+	}
+}
+
+// provisionMember 克隆 -> 配置规格与 cloud-init -> 启动 -> 写入虚拟机记录 -> 获取 IP
+func (s *vmStackService) provisionMember(ctx context.Context, client *proxmox.ProxmoxClient, cluster *model.PveCluster, creator string, m *model.VmStackMember) error {
+	// 1. 克隆
+	instance, err := s.findTemplateInstance(ctx, m.TemplateID, m.NodeID)
+	if err != nil {
+		return err
+	}
+	sourceNode, err := s.nodeRepo.GetByID(ctx, instance.NodeID)
+	if err != nil || sourceNode == nil {
+		return fmt.Errorf("template instance node %d not found", instance.NodeID)
+	}
+
+	m.VMID = generateProxmoxVMID(0)
+	m.Status = model.VmStackMemberStatusCloning
+	s.saveMember(ctx, m)
+
+	cloneReq := &proxmox.CloneVMRequest{
+		NewID:   m.VMID,
+		Name:    m.VmName,
+		Full:    1,
+		Storage: m.Storage,
+	}
+	if sourceNode.NodeName != m.NodeName {
+		cloneReq.Target = m.NodeName
+	}
+	upid, err := client.CloneVM(ctx, sourceNode.NodeName, instance.VMID, cloneReq)
+	if err != nil {
+		return fmt.Errorf("clone vm: %w", err)
+	}
+	if err := client.WaitForTask(ctx, sourceNode.NodeName, upid, vmStackCloneTimeout); err != nil {
+		return fmt.Errorf("clone vm: %w", err)
+	}
+
+	// 2. 规格与 cloud-init（cloud-init 字段通过虚拟机配置接口设置）
+	m.Status = model.VmStackMemberStatusConfiguring
+	s.saveMember(ctx, m)
+
+	var ci v1.VMStackCloudInit
+	if m.CloudInit != "" {
+		if err := json.Unmarshal([]byte(m.CloudInit), &ci); err != nil {
+			return fmt.Errorf("decode cloud-init: %w", err)
+		}
+	}
+	config := map[string]interface{}{"ipconfig0": m.IPConfig}
+	if m.CPUNum > 0 {
+		config["cores"] = m.CPUNum
+	}
+	if m.MemorySize > 0 {
+		config["memory"] = m.MemorySize
+	}
+	if ci.User != "" {
+		config["ciuser"] = ci.User
+	}
+	if ci.Password != "" {
+		config["cipassword"] = ci.Password
+	}
+	if ci.SSHKeys != "" {
+		// Proxmox 要求 sshkeys 为 URL 编码（空格编码为 %20）
+		config["sshkeys"] = strings.ReplaceAll(url.QueryEscape(strings.TrimSpace(ci.SSHKeys)), "+", "%20")
+	}
+	if ci.Nameserver != "" {
+		config["nameserver"] = ci.Nameserver
+	}
+	if ci.Searchdomain != "" {
+		config["searchdomain"] = ci.Searchdomain
+	}
+	if err := client.UpdateVMConfig(ctx, m.NodeName, m.VMID, config); err != nil {
+		return fmt.Errorf("update vm config: %w", err)
+	}
+
+	// 3. 启动
+	m.Status = model.VmStackMemberStatusStarting
+	s.saveMember(ctx, m)
+
+	upid, err = client.StartVM(ctx, m.NodeName, m.VMID)
+	if err != nil {
+		return fmt.Errorf("start vm: %w", err)
+	}
+	if err := client.WaitForTask(ctx, m.NodeName, upid, vmStackStartTimeout); err != nil {
+		return fmt.Errorf("start vm: %w", err)
+	}
+
+	// 4. 虚拟机记录（controller 同步时会以 Proxmox 实际配置更新）
+	node, err := s.nodeRepo.GetByID(ctx, m.NodeID)
+	if err != nil || node == nil {
+		return fmt.Errorf("node %d not found", m.NodeID)
+	}
+	storage := m.Storage
+	if storage == "" {
+		storage = instance.StorageName
+	}
+	vm := &model.PveVM{
+		VmName:      m.VmName,
+		ClusterID:   cluster.Id,
+		NodeID:      node.Id,
+		TemplateID:  m.TemplateID,
+		VMID:        m.VMID,
+		CPUNum:      m.CPUNum,
+		MemorySize:  m.MemorySize,
+		Storage:     storage,
+		StorageCfg:  "{}",
+		Status:      "running",
+		VmUser:      ci.User,
+		NodeIP:      node.IPAddress,
+		Description: fmt.Sprintf("stack member: %s", m.Role),
+		CreateTime:  time.Now(),
+		UpdateTime:  time.Now(),
+	}
+	if err := s.vmRepo.Create(ctx, vm); err != nil {
+		return fmt.Errorf("create vm record: %w", err)
+	}
+	m.VmId = vm.Id
+	vm.NodeName = node.NodeName
+	s.templateUsage.RecordClone(ctx, vm, instance, model.TemplateUsageSourceStack, creator)
+
+	// 5. DHCP 时通过 guest agent 获取 IP；获取不到不视为失败
+	if m.IPAddress == "" {
+		ip, err := s.waitForGuestIP(ctx, client, m.NodeName, m.VMID)
+		if err != nil {
+			s.logger.Warn("failed to get vm ip from guest agent",
+				zap.String("vm_name", m.VmName), zap.Uint32("vmid", m.VMID), zap.Error(err))
+			m.ErrorMessage = "ip address not reported by qemu-guest-agent"
+		}
+		m.IPAddress = ip
+	}
+
+	m.Status = model.VmStackMemberStatusCompleted
+	s.saveMember(ctx, m)
+	return nil
+}
+
+// findTemplateInstance 优先使用目标节点上的模板实例，其次主实例，最后任一可用实例
+func (s *vmStackService) findTemplateInstance(ctx context.Context, templateID, nodeID int64) (*model.TemplateInstance, error) {
+	usable := func(inst *model.TemplateInstance) bool {
+		return inst != nil && inst.Status == model.TemplateInstanceStatusAvailable && inst.VMID > 0
+	}
+
+	instance, err := s.templateInstanceRepo.GetByTemplateAndNode(ctx, templateID, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("get template instance: %w", err)
+	}
+	if usable(instance) {
+		return instance, nil
+	}
+	primary, err := s.templateInstanceRepo.GetPrimaryInstance(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("get primary template instance: %w", err)
+	}
+	if usable(primary) {
+		return primary, nil
+	}
+	instances, err := s.templateInstanceRepo.ListByTemplateID(ctx, templateID)
+	if err != nil {
+		return nil, fmt.Errorf("list template instances: %w", err)
+	}
+	for _, inst := range instances {
+		if usable(inst) {
+			return inst, nil
+		}
+	}
+	return nil, fmt.Errorf("template %d has no available instance", templateID)
+}
+
+// waitForGuestIP 轮询 guest agent，返回第一个非回环 IPv4 地址
+func (s *vmStackService) waitForGuestIP(ctx context.Context, client *proxmox.ProxmoxClient, nodeName string, vmID uint32) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, vmStackIPWaitTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return "", lastErr
+			}
+			return "", ctx.Err()
+		case <-ticker.C:
+			// guest agent 在系统启动完成前不可用，出错时继续等待
+			ifaces, err := client.GetVMAgentNetworkInterfaces(ctx, nodeName, vmID)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			if ip := firstGuestIPv4(ifaces); ip != "" {
+				return ip, nil
+			}
+		}
+	}
+}
+
+func firstGuestIPv4(ifaces []map[string]interface{}) string {
+	for _, iface := range ifaces {
+		if name, _ := iface["name"].(string); name == "lo" {
+			continue
+		}
+		addrs, _ := iface["ip-addresses"].([]interface{})
+		for _, a := range addrs {
+			addr, _ := a.(map[string]interface{})
+			if t, _ := addr["ip-address-type"].(string); t != "ipv4" {
+				continue
+			}
+			ip, _ := addr["ip-address"].(string)
+			if parsed := net.ParseIP(ip); parsed != nil && !parsed.IsLoopback() && !parsed.IsLinkLocalUnicast() {
+				return ip
+			}
+		}
+	}
+	return ""
+}
+
+func (s *vmStackService) GetStack(ctx context.Context, id int64) (*v1.VMStackDetail, error) {
+	stack, members, err := s.loadStack(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toVMStackDetail(stack, members), nil
+}
+
+func (s *vmStackService) ListStacks(ctx context.Context, req *v1.ListVMStacksRequest) (*v1.ListVMStacksResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	stacks, total, err := s.stackRepo.ListWithPagination(ctx, page, pageSize, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm stacks", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.VMStackItem, 0, len(stacks))
+	for _, stack := range stacks {
+		list = append(list, toVMStackItem(stack))
+	}
+	return &v1.ListVMStacksResponseData{Total: total, List: list}, nil
+}
+
+func (s *vmStackService) GetStackIPs(ctx context.Context, id int64) (*v1.GetVMStackIPsResponseData, error) {
+	stack, members, err := s.loadStack(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &v1.GetVMStackIPsResponseData{
+		Name:   stack.Name,
+		Status: stack.Status,
+		List:   make([]v1.VMStackIP, 0, len(members)),
+		ByRole: make(map[string][]string),
+	}
+	for _, m := range members {
+		data.List = append(data.List, v1.VMStackIP{Role: m.Role, VmName: m.VmName, IPAddress: m.IPAddress})
+		if m.IPAddress != "" {
+			data.ByRole[m.Role] = append(data.ByRole[m.Role], m.IPAddress)
+		}
+	}
+	return data, nil
+}
+
+// DeleteStack 删除 stack 记录，已创建的虚拟机保留（可通过虚拟机接口单独删除）
+func (s *vmStackService) DeleteStack(ctx context.Context, id int64) error {
+	stack, err := s.stackRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm stack", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if stack == nil {
+		return v1.ErrNotFound
+	}
+	if _, running := s.runningStacks.Load(id); running {
+		return v1.ErrVMStackProvisioning
+	}
+	if err := s.stackRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete vm stack", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *vmStackService) loadStack(ctx context.Context, id int64) (*model.VmStack, []*model.VmStackMember, error) {
+	stack, err := s.stackRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm stack", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	if stack == nil {
+		return nil, nil, v1.ErrNotFound
+	}
+	members, err := s.stackRepo.ListMembers(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vm stack members", zap.Error(err))
+		return nil, nil, v1.ErrInternalServerError
+	}
+	return stack, members, nil
+}
+
+func toVMStackItem(stack *model.VmStack) v1.VMStackItem {
+	return v1.VMStackItem{
+		Id:           stack.Id,
+		Name:         stack.Name,
+		ClusterID:    stack.ClusterID,
+		Description:  stack.Description,
+		Status:       stack.Status,
+		MemberCount:  stack.MemberCount,
+		ErrorMessage: stack.ErrorMessage,
+		StartTime:    stack.StartTime,
+		EndTime:      stack.EndTime,
+		Creator:      stack.Creator,
+		CreateTime:   stack.CreateTime,
+	}
+}
+
+func toVMStackDetail(stack *model.VmStack, members []*model.VmStackMember) *v1.VMStackDetail {
+	detail := &v1.VMStackDetail{
+		VMStackItem: toVMStackItem(stack),
+		Members:     make([]v1.VMStackMember, 0, len(members)),
+	}
+	for _, m := range members {
+		detail.Members = append(detail.Members, v1.VMStackMember{
+			Id:           m.Id,
+			Role:         m.Role,
+			Seq:          m.Seq,
+			VmName:       m.VmName,
+			NodeID:       m.NodeID,
+			NodeName:     m.NodeName,
+			TemplateID:   m.TemplateID,
+			VMID:         m.VMID,
+			VmId:         m.VmId,
+			IPAddress:    m.IPAddress,
+			Status:       m.Status,
+			ErrorMessage: m.ErrorMessage,
+		})
+	}
+	return detail
+}
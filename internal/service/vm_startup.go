@@ -0,0 +1,486 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// VMStartupService 虚拟机开机自启（onboot）与启动顺序（startup）管理，以及按标签分组的集群启动顺序策略
+type VMStartupService interface {
+	GetVMStartup(ctx context.Context, vmID int64) (*v1.VMStartupConfig, error)
+	UpdateVMStartup(ctx context.Context, vmID int64, req *v1.VMStartupConfig) error
+
+	CreatePolicy(ctx context.Context, userID string, req *v1.BootOrderPolicyRequest) (*v1.BootOrderPolicyItem, error)
+	UpdatePolicy(ctx context.Context, userID string, id int64, req *v1.BootOrderPolicyRequest) (*v1.BootOrderPolicyItem, error)
+	DeletePolicy(ctx context.Context, userID string, id int64) error
+	ListPolicies(ctx context.Context, req *v1.ListBootOrderPoliciesRequest) (*v1.ListBootOrderPoliciesResponseData, error)
+	// ApplyPolicies 将集群的策略写入带有对应标签的虚拟机，多个标签命中时使用 order 最小的策略
+	ApplyPolicies(ctx context.Context, userID string, req *v1.ApplyBootOrderPoliciesRequest) (*v1.ApplyBootOrderPoliciesData, error)
+}
+
+func NewVMStartupService(
+	service *Service,
+	conf *viper.Viper,
+	policyRepo repository.BootOrderPolicyRepository,
+	vmRepo repository.PveVMRepository,
+	nodeRepo repository.PveNodeRepository,
+	clusterRepo repository.PveClusterRepository,
+	userRepo repository.UserRepository,
+	vmLock VMLockService,
+	logger *log.Logger,
+) VMStartupService {
+	return &vmStartupService{
+		Service:     service,
+		conf:        conf,
+		policyRepo:  policyRepo,
+		vmRepo:      vmRepo,
+		nodeRepo:    nodeRepo,
+		clusterRepo: clusterRepo,
+		userRepo:    userRepo,
+		vmLock:      vmLock,
+		logger:      logger,
+	}
+}
+
+type vmStartupService struct {
+	*Service
+	conf        *viper.Viper
+	policyRepo  repository.BootOrderPolicyRepository
+	vmRepo      repository.PveVMRepository
+	nodeRepo    repository.PveNodeRepository
+	clusterRepo repository.PveClusterRepository
+	userRepo    repository.UserRepository
+	vmLock      VMLockService
+	logger      *log.Logger
+}
+
+func (s *vmStartupService) GetVMStartup(ctx context.Context, vmID int64) (*v1.VMStartupConfig, error) {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return nil, err
+	}
+	config, err := client.GetVMCurrentConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm config", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm config: %v", err)
+	}
+	startup := parseVMStartup(config)
+	return &startup, nil
+}
+
+func (s *vmStartupService) UpdateVMStartup(ctx context.Context, vmID int64, req *v1.VMStartupConfig) error {
+	vm, node, client, err := s.vmClient(ctx, vmID)
+	if err != nil {
+		return err
+	}
+	if err := s.vmLock.Check(ctx, vm.Id); err != nil {
+		return err
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.startup"); err != nil {
+		return err
+	}
+
+	updates := vmStartupUpdates(req)
+	if err := client.UpdateVMConfig(ctx, node.NodeName, vm.VMID, updates); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update vm startup", zap.Error(err),
+			zap.String("node", node.NodeName), zap.Uint32("vmid", vm.VMID))
+		return v1.WithDetailf(v1.ErrProxmoxRequestFailed, "update vm startup: %v", err)
+	}
+	s.logger.WithContext(ctx).Info("vm startup updated", zap.Uint32("vmid", vm.VMID),
+		zap.String("node", node.NodeName), zap.Any("updates", updates))
+	return nil
+}
+
+func (s *vmStartupService) CreatePolicy(ctx context.Context, userID string, req *v1.BootOrderPolicyRequest) (*v1.BootOrderPolicyItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_startup.manage")
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := s.getCluster(ctx, req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	tag := strings.ToLower(strings.TrimSpace(req.Tag))
+	if err := s.checkTagUnused(ctx, req.ClusterID, tag, 0); err != nil {
+		return nil, err
+	}
+
+	policy := &model.BootOrderPolicy{
+		ClusterID:   req.ClusterID,
+		Tag:         tag,
+		OnBoot:      req.OnBoot,
+		StartOrder:  req.Order,
+		UpDelay:     req.Up,
+		DownTimeout: req.Down,
+		Description: req.Description,
+		Creator:     username,
+		Modifier:    username,
+	}
+	if err := s.policyRepo.Create(ctx, policy); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create boot order policy", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("boot order policy created", zap.Int64("cluster_id", policy.ClusterID),
+		zap.String("tag", policy.Tag), zap.String("operator", username))
+	item := toBootOrderPolicyItem(policy)
+	item.ClusterName = cluster.ClusterName
+	return &item, nil
+}
+
+func (s *vmStartupService) UpdatePolicy(ctx context.Context, userID string, id int64, req *v1.BootOrderPolicyRequest) (*v1.BootOrderPolicyItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_startup.manage")
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.getPolicy(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := s.getCluster(ctx, req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	tag := strings.ToLower(strings.TrimSpace(req.Tag))
+	if err := s.checkTagUnused(ctx, req.ClusterID, tag, policy.Id); err != nil {
+		return nil, err
+	}
+
+	policy.ClusterID = req.ClusterID
+	policy.Tag = tag
+	policy.OnBoot = req.OnBoot
+	policy.StartOrder = req.Order
+	policy.UpDelay = req.Up
+	policy.DownTimeout = req.Down
+	policy.Description = req.Description
+	policy.Modifier = username
+	if err := s.policyRepo.Update(ctx, policy); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update boot order policy", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("boot order policy updated", zap.Int64("id", policy.Id),
+		zap.String("tag", policy.Tag), zap.String("operator", username))
+	item := toBootOrderPolicyItem(policy)
+	item.ClusterName = cluster.ClusterName
+	return &item, nil
+}
+
+func (s *vmStartupService) DeletePolicy(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_startup.manage")
+	if err != nil {
+		return err
+	}
+	policy, err := s.getPolicy(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.policyRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete boot order policy", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("boot order policy deleted", zap.Int64("cluster_id", policy.ClusterID),
+		zap.String("tag", policy.Tag), zap.String("operator", username))
+	return nil
+}
+
+func (s *vmStartupService) ListPolicies(ctx context.Context, req *v1.ListBootOrderPoliciesRequest) (*v1.ListBootOrderPoliciesResponseData, error) {
+	policies, err := s.policyRepo.List(ctx, req.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list boot order policies", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	clusterIDs := make([]int64, 0, len(policies))
+	for _, policy := range policies {
+		clusterIDs = append(clusterIDs, policy.ClusterID)
+	}
+	clusters, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	items := make([]v1.BootOrderPolicyItem, 0, len(policies))
+	for _, policy := range policies {
+		item := toBootOrderPolicyItem(policy)
+		if cluster, ok := clusters[policy.ClusterID]; ok {
+			item.ClusterName = cluster.ClusterName
+		}
+		items = append(items, item)
+	}
+	return &v1.ListBootOrderPoliciesResponseData{List: items}, nil
+}
+
+func (s *vmStartupService) ApplyPolicies(ctx context.Context, userID string, req *v1.ApplyBootOrderPoliciesRequest) (*v1.ApplyBootOrderPoliciesData, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vm_startup.manage")
+	if err != nil {
+		return nil, err
+	}
+	cluster, err := s.getCluster(ctx, req.ClusterID)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := s.policyRepo.List(ctx, cluster.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list boot order policies", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	data := &v1.ApplyBootOrderPoliciesData{Items: []v1.BootOrderApplyItem{}}
+	if len(policies) == 0 {
+		return data, nil
+	}
+	byTag := make(map[string]*model.BootOrderPolicy, len(policies))
+	for _, policy := range policies {
+		byTag[policy.Tag] = policy
+	}
+
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	resources, err := client.GetClusterResourcesByType(ctx, "vm")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list cluster resources", zap.Error(err), zap.Int64("cluster_id", cluster.Id))
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "list cluster resources: %v", err)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		a, _ := resources[i]["vmid"].(float64)
+		b, _ := resources[j]["vmid"].(float64)
+		return a < b
+	})
+
+	for _, resource := range resources {
+		if resourceType, _ := resource["type"].(string); resourceType != "qemu" {
+			continue
+		}
+		if template, _ := resource["template"].(float64); template == 1 {
+			continue
+		}
+		tags, _ := resource["tags"].(string)
+		policy := matchBootOrderPolicy(byTag, tags)
+		if policy == nil {
+			continue
+		}
+		vmidFloat, _ := resource["vmid"].(float64)
+		item := v1.BootOrderApplyItem{VMID: uint32(vmidFloat), Tag: policy.Tag}
+		item.VmName, _ = resource["name"].(string)
+		item.Node, _ = resource["node"].(string)
+		data.Matched++
+
+		desired := policyStartupConfig(policy)
+		item.OnBoot, item.Startup = desired.OnBoot, vmStartupValue(&desired)
+		s.applyPolicyToVM(ctx, client, &item, &desired, req.DryRun)
+		if item.Error != "" {
+			data.Failed++
+		} else if item.Changed {
+			data.Changed++
+		}
+		data.Items = append(data.Items, item)
+	}
+
+	s.logger.WithContext(ctx).Info("boot order policies applied", zap.Int64("cluster_id", cluster.Id), zap.Bool("dry_run", req.DryRun),
+		zap.Int("matched", data.Matched), zap.Int("changed", data.Changed), zap.Int("failed", data.Failed), zap.String("operator", username))
+	return data, nil
+}
+
+// applyPolicyToVM 对比虚拟机当前的 onboot / startup，与策略不一致时写入（dry_run 时只标记）
+func (s *vmStartupService) applyPolicyToVM(ctx context.Context, client *proxmox.ProxmoxClient, item *v1.BootOrderApplyItem, desired *v1.VMStartupConfig, dryRun bool) {
+	if vm, err := s.vmRepo.GetByVMIDAndNodeName(ctx, item.VMID, item.Node); err == nil && vm != nil {
+		item.VMId = vm.Id
+	}
+	config, err := client.GetVMCurrentConfig(ctx, item.Node, item.VMID)
+	if err != nil {
+		item.Error = fmt.Sprintf("get vm config: %v", err)
+		return
+	}
+	current := parseVMStartup(config)
+	item.Current = fmt.Sprintf("onboot=%d startup=%s", boolToInt(current.OnBoot), vmStartupValue(&current))
+	if current.OnBoot == desired.OnBoot && vmStartupValue(&current) == item.Startup {
+		return
+	}
+	item.Changed = true
+	if dryRun {
+		return
+	}
+
+	if item.VMId > 0 {
+		if err := s.vmLock.Check(ctx, item.VMId); err != nil {
+			item.Error = err.Error()
+			return
+		}
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, item.Node, item.VMID, "vm.startup"); err != nil {
+		item.Error = err.Error()
+		return
+	}
+	if err := client.UpdateVMConfig(ctx, item.Node, item.VMID, vmStartupUpdates(desired)); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to apply boot order policy", zap.Error(err),
+			zap.String("node", item.Node), zap.Uint32("vmid", item.VMID), zap.String("tag", item.Tag))
+		item.Error = fmt.Sprintf("update vm config: %v", err)
+	}
+}
+
+func (s *vmStartupService) vmClient(ctx context.Context, vmID int64) (*model.PveVM, *model.PveNode, *proxmox.ProxmoxClient, error) {
+	vm, err := s.vmRepo.GetByID(ctx, vmID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, nil, nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, nil, nil, v1.ErrVMNotFound
+	}
+	client, node, err := nodeProxmoxClient(ctx, s.nodeRepo, s.clusterRepo, s.logger, vm.NodeID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return vm, node, client, nil
+}
+
+func (s *vmStartupService) getCluster(ctx context.Context, clusterID int64) (*model.PveCluster, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if cluster == nil {
+		return nil, v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", clusterID)
+	}
+	return cluster, nil
+}
+
+func (s *vmStartupService) getPolicy(ctx context.Context, id int64) (*model.BootOrderPolicy, error) {
+	policy, err := s.policyRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get boot order policy", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if policy == nil {
+		return nil, v1.ErrBootOrderPolicyNotFound
+	}
+	return policy, nil
+}
+
+func (s *vmStartupService) checkTagUnused(ctx context.Context, clusterID int64, tag string, exceptID int64) error {
+	existing, err := s.policyRepo.GetByTag(ctx, clusterID, tag)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get boot order policy", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if existing != nil && existing.Id != exceptID {
+		return v1.WithDetail(v1.ErrBootOrderPolicyExists, tag)
+	}
+	return nil
+}
+
+// matchBootOrderPolicy 按虚拟机标签（分号分隔）查找策略，多个命中时取 order 最小的（未设置 order 的排在最后）
+func matchBootOrderPolicy(byTag map[string]*model.BootOrderPolicy, tags string) *model.BootOrderPolicy {
+	var matched *model.BootOrderPolicy
+	for _, tag := range strings.FieldsFunc(strings.ToLower(tags), func(r rune) bool { return r == ';' || r == ',' || r == ' ' }) {
+		policy, ok := byTag[tag]
+		if !ok {
+			continue
+		}
+		if matched == nil || bootOrderRank(policy) < bootOrderRank(matched) ||
+			(bootOrderRank(policy) == bootOrderRank(matched) && policy.Tag < matched.Tag) {
+			matched = policy
+		}
+	}
+	return matched
+}
+
+func bootOrderRank(policy *model.BootOrderPolicy) int {
+	if policy.StartOrder == nil {
+		return int(^uint(0) >> 1)
+	}
+	return *policy.StartOrder
+}
+
+func policyStartupConfig(policy *model.BootOrderPolicy) v1.VMStartupConfig {
+	return v1.VMStartupConfig{OnBoot: policy.OnBoot, Order: policy.StartOrder, Up: policy.UpDelay, Down: policy.DownTimeout}
+}
+
+// parseVMStartup 解析虚拟机配置中的 onboot 和 startup（如 "order=1,up=30,down=60"）
+func parseVMStartup(config map[string]interface{}) v1.VMStartupConfig {
+	startup := v1.VMStartupConfig{OnBoot: proxmoxBool(config["onboot"])}
+	value, _ := config["startup"].(string)
+	dev := proxmox.ParseDeviceConfig(value)
+	if dev.Head != "" {
+		// 简写形式 "startup: 1" 等同于 order=1
+		dev.Options = append([]proxmox.DeviceOption{{Key: "order", Value: dev.Head}}, dev.Options...)
+	}
+	for _, opt := range dev.Options {
+		n, err := strconv.Atoi(opt.Value)
+		if err != nil {
+			continue
+		}
+		switch opt.Key {
+		case "order":
+			startup.Order = &n
+		case "up":
+			startup.Up = &n
+		case "down":
+			startup.Down = &n
+		}
+	}
+	return startup
+}
+
+// vmStartupValue 生成 Proxmox startup 配置值，order / up / down 都未设置时为空
+func vmStartupValue(startup *v1.VMStartupConfig) string {
+	var parts []string
+	if startup.Order != nil {
+		parts = append(parts, fmt.Sprintf("order=%d", *startup.Order))
+	}
+	if startup.Up != nil {
+		parts = append(parts, fmt.Sprintf("up=%d", *startup.Up))
+	}
+	if startup.Down != nil {
+		parts = append(parts, fmt.Sprintf("down=%d", *startup.Down))
+	}
+	return strings.Join(parts, ",")
+}
+
+// vmStartupUpdates 生成 UpdateVMConfig 参数，startup 为空时删除该配置
+func vmStartupUpdates(startup *v1.VMStartupConfig) map[string]interface{} {
+	updates := map[string]interface{}{"onboot": boolToInt(startup.OnBoot)}
+	if value := vmStartupValue(startup); value != "" {
+		updates["startup"] = value
+	} else {
+		updates["delete"] = "startup"
+	}
+	return updates
+}
+
+func toBootOrderPolicyItem(policy *model.BootOrderPolicy) v1.BootOrderPolicyItem {
+	return v1.BootOrderPolicyItem{
+		Id:              policy.Id,
+		ClusterID:       policy.ClusterID,
+		Tag:             policy.Tag,
+		Description:     policy.Description,
+		VMStartupConfig: policyStartupConfig(policy),
+		Creator:         policy.Creator,
+		Modifier:        policy.Modifier,
+		CreateTime:      policy.CreateTime,
+		UpdateTime:      policy.UpdateTime,
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// 存储迁移默认参数，可通过 vm_storage_move.* 调整
+const (
+	defaultStorageMoveConcurrency = 2
+	defaultStorageMoveDiskTimeout = 12 * time.Hour
+)
+
+// movableDiskPattern 可通过 move_disk 移动的磁盘配置项
+var movableDiskPattern = regexp.MustCompile(`^(scsi|virtio|sata|ide)\d+$|^efidisk0$|^tpmstate0$`)
+
+type VMStorageMoveService interface {
+	CreateMove(ctx context.Context, req *v1.CreateVMStorageMoveRequest, creator string) (*v1.VMStorageMoveItem, error)
+	GetMove(ctx context.Context, id int64) (*v1.VMStorageMoveItem, error)
+	ListMoves(ctx context.Context, req *v1.ListVMStorageMovesRequest) (*v1.ListVMStorageMovesResponseData, error)
+}
+
+func NewVMStorageMoveService(
+	service *Service,
+	conf *viper.Viper,
+	moveRepo repository.VMStorageMoveRepository,
+	clusterRepo repository.PveClusterRepository,
+	nodeRepo repository.PveNodeRepository,
+	vmRepo repository.PveVMRepository,
+	changeControl ChangeControlService,
+	notificationService NotificationService,
+	vmLock VMLockService,
+	logger *log.Logger,
+) VMStorageMoveService {
+	concurrency := conf.GetInt("vm_storage_move.concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultStorageMoveConcurrency
+	}
+	return &vmStorageMoveService{
+		conf:                conf,
+		moveRepo:            moveRepo,
+		clusterRepo:         clusterRepo,
+		nodeRepo:            nodeRepo,
+		vmRepo:              vmRepo,
+		changeControl:       changeControl,
+		vmLock:              vmLock,
+		Service:             service,
+		notificationService: notificationService,
+		logger:              logger,
+		slots:               make(chan struct{}, concurrency),
+	}
+}
+
+type vmStorageMoveService struct {
+	conf          *viper.Viper
+	moveRepo      repository.VMStorageMoveRepository
+	clusterRepo   repository.PveClusterRepository
+	nodeRepo      repository.PveNodeRepository
+	vmRepo        repository.PveVMRepository
+	changeControl ChangeControlService
+	vmLock        VMLockService
+	*Service
+	notificationService NotificationService
+	logger              *log.Logger
+
+	// 限制同时执行的存储迁移任务数，其余任务保持 pending 排队
+	slots chan struct{}
+}
+
+// CreateMove 校验目标存储并列出需要移动的磁盘，创建任务后在后台执行
+func (s *vmStorageMoveService) CreateMove(ctx context.Context, req *v1.CreateVMStorageMoveRequest, creator string) (*v1.VMStorageMoveItem, error) {
+	vm, err := s.vmRepo.GetByID(ctx, req.VmId)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vm", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if vm == nil {
+		return nil, v1.ErrVMNotFound
+	}
+	node, err := s.nodeRepo.GetByID(ctx, vm.NodeID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get node", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if node == nil {
+		return nil, v1.WithDetailf(v1.ErrNodeNotFound, "node_id=%d", vm.NodeID)
+	}
+
+	active, err := s.moveRepo.CountActiveByVM(ctx, vm.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to count storage move tasks", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if active > 0 {
+		return nil, v1.WithDetailf(v1.ErrStorageMoveInProgress, "vm=%s", vm.VmName)
+	}
+
+	// 删除源卷不可恢复，需符合变更窗口
+	if err := s.changeControl.Authorize(ctx, &ChangeOperation{
+		Action:    "vm.storage_move",
+		Target:    vm.VmName,
+		ClusterID: vm.ClusterID,
+		AppId:     vm.AppId,
+		VMId:      vm.Id,
+	}); err != nil {
+		return nil, err
+	}
+
+	client, err := s.getClient(ctx, vm.ClusterID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to create proxmox client", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if err := s.vmLock.CheckProxmox(ctx, client, node.NodeName, vm.VMID, "vm.storage_move"); err != nil {
+		return nil, err
+	}
+
+	storages, err := client.GetNodeStorages(ctx, node.NodeName, "")
+	if err != nil {
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "list node storages: %v", err)
+	}
+	var target map[string]interface{}
+	for _, item := range storages {
+		if name, _ := item["storage"].(string); name == req.TargetStorage {
+			target = item
+			break
+		}
+	}
+	if issues := checkCreateVMStorage(target, req.TargetStorage, node.NodeName, "images"); len(issues) > 0 {
+		return nil, v1.WithDetail(v1.ErrStorageContentUnsupported, strings.Join(issues, "; "))
+	}
+
+	config, err := client.GetVMConfig(ctx, node.NodeName, vm.VMID)
+	if err != nil {
+		return nil, v1.WithDetailf(v1.ErrProxmoxRequestFailed, "get vm config: %v", err)
+	}
+	disks := movableDisks(config, req.TargetStorage)
+	if len(disks) == 0 {
+		return nil, v1.WithDetailf(v1.ErrStorageMoveNoDisks, "target_storage=%s", req.TargetStorage)
+	}
+	diskJSON, err := json.Marshal(disks)
+	if err != nil {
+		return nil, v1.ErrInternalServerError
+	}
+
+	task := &model.VMStorageMoveTask{
+		ClusterID:     vm.ClusterID,
+		NodeID:        node.Id,
+		NodeName:      node.NodeName,
+		VmId:          vm.Id,
+		VMID:          vm.VMID,
+		VmName:        vm.VmName,
+		TargetStorage: req.TargetStorage,
+		TargetFormat:  req.Format,
+		Bwlimit:       req.Bwlimit,
+		Disks:         string(diskJSON),
+		Status:        model.VMStorageMoveStatusPending,
+		TotalDisks:    len(disks),
+		Creator:       creator,
+	}
+	if req.DeleteSource {
+		task.DeleteSource = 1
+	}
+	if req.Parallel {
+		task.Parallel = 1
+	}
+	if err := s.moveRepo.Create(ctx, task); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create storage move task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	go s.execute(task.Id)
+
+	s.logger.WithContext(ctx).Info("storage move task created", zap.Int64("task_id", task.Id),
+		zap.String("vm_name", vm.VmName), zap.String("target_storage", req.TargetStorage), zap.Int("disks", len(disks)))
+	item := toVMStorageMoveItem(task)
+	return &item, nil
+}
+
+func (s *vmStorageMoveService) getClient(ctx context.Context, clusterID int64) (*proxmox.ProxmoxClient, error) {
+	cluster, err := s.clusterRepo.GetByID(ctx, clusterID)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == nil {
+		return nil, fmt.Errorf("cluster %d not found", clusterID)
+	}
+	return proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+}
+
+// execute 执行存储迁移任务：逐个或并行移动各磁盘，全部成功后更新虚拟机记录
+func (s *vmStorageMoveService) execute(taskID int64) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	ctx := context.Background()
+	task, err := s.moveRepo.GetByID(ctx, taskID)
+	if err != nil || task == nil {
+		s.logger.Error("failed to load storage move task", zap.Int64("task_id", taskID), zap.Error(err))
+		return
+	}
+
+	var disks []model.VMStorageMoveDisk
+	if err := json.Unmarshal([]byte(task.Disks), &disks); err != nil {
+		s.finish(ctx, task, nil, fmt.Errorf("invalid disk list: %w", err))
+		return
+	}
+
+	now := time.Now()
+	task.Status = model.VMStorageMoveStatusRunning
+	task.StartTime = &now
+	s.saveTask(ctx, task)
+
+	client, err := s.getClient(ctx, task.ClusterID)
+	if err != nil {
+		s.finish(ctx, task, disks, err)
+		return
+	}
+
+	// 各磁盘进度写回同一任务记录，需串行化
+	var mu sync.Mutex
+	update := func(i int, fn func(disk *model.VMStorageMoveDisk)) {
+		mu.Lock()
+		defer mu.Unlock()
+		fn(&disks[i])
+		if b, err := json.Marshal(disks); err == nil {
+			task.Disks = string(b)
+		}
+		moved := 0
+		for _, disk := range disks {
+			if disk.Status == model.VMStorageMoveStatusCompleted {
+				moved++
+			}
+		}
+		task.MovedDisks = moved
+		s.saveTask(ctx, task)
+	}
+
+	if task.Parallel == 1 {
+		var wg sync.WaitGroup
+		for i := range disks {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				s.moveDisk(ctx, client, task, i, update)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range disks {
+			// 逐个移动时遇到失败即停止，剩余磁盘保持 pending
+			if !s.moveDisk(ctx, client, task, i, update) {
+				break
+			}
+		}
+	}
+
+	var failed []string
+	for _, disk := range disks {
+		if disk.Status != model.VMStorageMoveStatusCompleted {
+			failed = append(failed, disk.Disk)
+		}
+	}
+	if len(failed) > 0 {
+		s.finish(ctx, task, disks, fmt.Errorf("disks not moved: %s", strings.Join(failed, ", ")))
+		return
+	}
+	s.updateVMStorage(ctx, task)
+	s.finish(ctx, task, disks, nil)
+}
+
+// moveDisk 移动单个磁盘并等待 Proxmox 任务完成，返回是否成功
+func (s *vmStorageMoveService) moveDisk(ctx context.Context, client *proxmox.ProxmoxClient, task *model.VMStorageMoveTask, i int,
+	update func(i int, fn func(disk *model.VMStorageMoveDisk))) bool {
+	var diskName string
+	update(i, func(disk *model.VMStorageMoveDisk) {
+		disk.Status = model.VMStorageMoveStatusRunning
+		diskName = disk.Disk
+	})
+
+	params := url.Values{}
+	params.Set("disk", diskName)
+	params.Set("storage", task.TargetStorage)
+	if task.DeleteSource == 1 {
+		params.Set("delete", "1")
+	}
+	if task.TargetFormat != "" {
+		params.Set("format", task.TargetFormat)
+	}
+	if task.Bwlimit > 0 {
+		params.Set("bwlimit", strconv.Itoa(task.Bwlimit))
+	}
+
+	fail := func(err error) bool {
+		s.logger.Error("failed to move vm disk", zap.Int64("task_id", task.Id), zap.Uint32("vmid", task.VMID),
+			zap.String("disk", diskName), zap.Error(err))
+		update(i, func(disk *model.VMStorageMoveDisk) {
+			disk.Status = model.VMStorageMoveStatusFailed
+			disk.ErrorMessage = err.Error()
+		})
+		return false
+	}
+
+	upid, err := client.MoveVMDisk(ctx, task.NodeName, task.VMID, params)
+	if err != nil {
+		return fail(err)
+	}
+	update(i, func(disk *model.VMStorageMoveDisk) { disk.UPID = upid })
+
+	timeout := s.conf.GetDuration("vm_storage_move.disk_timeout")
+	if timeout <= 0 {
+		timeout = defaultStorageMoveDiskTimeout
+	}
+	if err := client.WaitForTask(ctx, task.NodeName, upid, timeout); err != nil {
+		return fail(err)
+	}
+	update(i, func(disk *model.VMStorageMoveDisk) { disk.Status = model.VMStorageMoveStatusCompleted })
+	s.logger.Info("vm disk moved", zap.Int64("task_id", task.Id), zap.Uint32("vmid", task.VMID),
+		zap.String("disk", diskName), zap.String("storage", task.TargetStorage))
+	return true
+}
+
+// updateVMStorage 更新虚拟机记录的存储及 storage_cfg 中的 storage 字段（失败只记录日志）
+func (s *vmStorageMoveService) updateVMStorage(ctx context.Context, task *model.VMStorageMoveTask) {
+	vm, err := s.vmRepo.GetByID(ctx, task.VmId)
+	if err != nil || vm == nil {
+		s.logger.Warn("failed to load vm after storage move", zap.Int64("vm_id", task.VmId), zap.Error(err))
+		return
+	}
+	vm.Storage = task.TargetStorage
+	var cfg map[string]interface{}
+	if err := json.Unmarshal([]byte(vm.StorageCfg), &cfg); err == nil && cfg != nil {
+		if _, ok := cfg["storage"]; ok {
+			cfg["storage"] = task.TargetStorage
+			if b, err := json.Marshal(cfg); err == nil {
+				vm.StorageCfg = string(b)
+			}
+		}
+	}
+	vm.UpdateTime = time.Now()
+	if err := s.vmRepo.Update(ctx, vm); err != nil {
+		s.logger.Warn("failed to update vm storage", zap.Int64("vm_id", vm.Id), zap.Error(err))
+	}
+}
+
+func (s *vmStorageMoveService) finish(ctx context.Context, task *model.VMStorageMoveTask, disks []model.VMStorageMoveDisk, err error) {
+	end := time.Now()
+	task.EndTime = &end
+	if disks != nil {
+		if b, err := json.Marshal(disks); err == nil {
+			task.Disks = string(b)
+		}
+	}
+	if err != nil {
+		s.logger.Error("storage move failed", zap.Int64("task_id", task.Id), zap.Uint32("vmid", task.VMID), zap.Error(err))
+		task.Status = model.VMStorageMoveStatusFailed
+		task.ErrorMessage = err.Error()
+	} else {
+		task.Status = model.VMStorageMoveStatusCompleted
+		s.logger.Info("storage move completed", zap.Int64("task_id", task.Id), zap.Uint32("vmid", task.VMID),
+			zap.String("target_storage", task.TargetStorage), zap.Int("disks", task.TotalDisks))
+	}
+	s.saveTask(ctx, task)
+	s.notificationService.Notify(ctx, taskFinishedNotification("vm_storage_move", task.Id,
+		fmt.Sprintf("Storage move of VM %d to %s", task.VMID, task.TargetStorage), err), task.Creator)
+}
+
+func (s *vmStorageMoveService) saveTask(ctx context.Context, task *model.VMStorageMoveTask) {
+	if err := s.moveRepo.Update(ctx, task); err != nil {
+		s.logger.Error("failed to update storage move task", zap.Int64("task_id", task.Id), zap.Error(err))
+	}
+}
+
+// movableDisks 列出虚拟机配置中可移动且不在目标存储上的磁盘，跳过 CD-ROM、cloud-init 盘和直通设备，按磁盘名排序
+func movableDisks(config map[string]interface{}, targetStorage string) []model.VMStorageMoveDisk {
+	disks := make([]model.VMStorageMoveDisk, 0)
+	for key, value := range config {
+		if !movableDiskPattern.MatchString(key) {
+			continue
+		}
+		spec, _ := value.(string)
+		volume, opts, _ := strings.Cut(spec, ",")
+		if volume == "" || volume == "none" || strings.Contains(volume, "cloudinit") ||
+			strings.Contains(","+opts+",", ",media=cdrom,") {
+			continue
+		}
+		storage, _, ok := strings.Cut(volume, ":")
+		if !ok || strings.HasPrefix(volume, "/") || storage == targetStorage {
+			continue
+		}
+		disks = append(disks, model.VMStorageMoveDisk{
+			Disk:         key,
+			SourceVolume: volume,
+			Status:       model.VMStorageMoveStatusPending,
+		})
+	}
+	sort.Slice(disks, func(i, j int) bool { return disks[i].Disk < disks[j].Disk })
+	return disks
+}
+
+func (s *vmStorageMoveService) GetMove(ctx context.Context, id int64) (*v1.VMStorageMoveItem, error) {
+	task, err := s.moveRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get storage move task", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if task == nil {
+		return nil, v1.ErrStorageMoveNotFound
+	}
+	item := toVMStorageMoveItem(task)
+	return &item, nil
+}
+
+func (s *vmStorageMoveService) ListMoves(ctx context.Context, req *v1.ListVMStorageMovesRequest) (*v1.ListVMStorageMovesResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	tasks, total, err := s.moveRepo.List(ctx, page, pageSize, req.ClusterID, req.VmId, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list storage move tasks", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	list := make([]v1.VMStorageMoveItem, 0, len(tasks))
+	for _, task := range tasks {
+		list = append(list, toVMStorageMoveItem(task))
+	}
+	return &v1.ListVMStorageMovesResponseData{Total: total, List: list}, nil
+}
+
+func toVMStorageMoveItem(task *model.VMStorageMoveTask) v1.VMStorageMoveItem {
+	var disks []model.VMStorageMoveDisk
+	_ = json.Unmarshal([]byte(task.Disks), &disks)
+	items := make([]v1.VMStorageMoveDiskItem, 0, len(disks))
+	for _, disk := range disks {
+		items = append(items, v1.VMStorageMoveDiskItem{
+			Disk:         disk.Disk,
+			SourceVolume: disk.SourceVolume,
+			Status:       disk.Status,
+			UPID:         disk.UPID,
+			ErrorMessage: disk.ErrorMessage,
+		})
+	}
+	progress := 0
+	if task.Status == model.VMStorageMoveStatusCompleted {
+		progress = 100
+	} else if task.TotalDisks > 0 {
+		progress = task.MovedDisks * 100 / task.TotalDisks
+	}
+	return v1.VMStorageMoveItem{
+		Id:            task.Id,
+		ClusterID:     task.ClusterID,
+		NodeID:        task.NodeID,
+		NodeName:      task.NodeName,
+		VmId:          task.VmId,
+		VMID:          task.VMID,
+		VmName:        task.VmName,
+		TargetStorage: task.TargetStorage,
+		TargetFormat:  task.TargetFormat,
+		DeleteSource:  task.DeleteSource == 1,
+		Parallel:      task.Parallel == 1,
+		Bwlimit:       task.Bwlimit,
+		Status:        task.Status,
+		TotalDisks:    task.TotalDisks,
+		MovedDisks:    task.MovedDisks,
+		Progress:      progress,
+		Disks:         items,
+		ErrorMessage:  task.ErrorMessage,
+		StartTime:     task.StartTime,
+		EndTime:       task.EndTime,
+		Creator:       task.Creator,
+		CreateTime:    task.CreateTime,
+	}
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+// defaultVMTaskWaitTimeout 等待开关机任务结束的默认时长，可通过 vm_task.wait_timeout 调整
+const defaultVMTaskWaitTimeout = 2 * time.Minute
+
+// VMPowerTask 已提交到 Proxmox 的开关机任务
+type VMPowerTask struct {
+	VM       *model.PveVM
+	NodeName string
+	Client   *proxmox.ProxmoxClient
+	UPID     string
+	Action   string        // start / shutdown / stop
+	Expected string        // 任务成功后期望的状态：running / stopped
+	Timeout  time.Duration // 等待任务结束的时长，小于 vm_task.wait_timeout 时使用配置值
+}
+
+// VMTaskTracker 跟踪虚拟机开关机任务：任务结束后以 Proxmox 实际状态更新数据库，并通知操作人；
+// 等待超时时直接查询虚拟机当前状态，不再等待任务
+type VMTaskTracker interface {
+	Track(ctx context.Context, task *VMPowerTask)
+}
+
+func NewVMTaskTracker(
+	conf *viper.Viper,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	notificationService NotificationService,
+	logger *log.Logger,
+) VMTaskTracker {
+	return &vmTaskTracker{
+		conf:                conf,
+		vmRepo:              vmRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		logger:              logger,
+		pending:             make(map[int64]string),
+	}
+}
+
+type vmTaskTracker struct {
+	conf                *viper.Viper
+	vmRepo              repository.PveVMRepository
+	userRepo            repository.UserRepository
+	notificationService NotificationService
+	logger              *log.Logger
+
+	mu sync.Mutex
+	// pending 每台虚拟机最近一次提交的任务 UPID；同一虚拟机的新任务覆盖旧任务，旧任务结束时不再更新状态
+	pending map[int64]string
+}
+
+func (t *vmTaskTracker) Track(ctx context.Context, task *VMPowerTask) {
+	operator := ""
+	if userID := userIDFromCtx(ctx); userID != "" {
+		if user, err := t.userRepo.GetByID(ctx, userID); err == nil && user != nil {
+			operator = user.Username
+		}
+	}
+
+	t.mu.Lock()
+	t.pending[task.VM.Id] = task.UPID
+	t.mu.Unlock()
+
+	go t.wait(task, operator)
+}
+
+func (t *vmTaskTracker) wait(task *VMPowerTask, operator string) {
+	ctx := context.Background()
+	vm := task.VM
+	timeout := t.conf.GetDuration("vm_task.wait_timeout")
+	if timeout <= 0 {
+		timeout = defaultVMTaskWaitTimeout
+	}
+	if task.Timeout > timeout {
+		timeout = task.Timeout
+	}
+
+	taskErr := task.Client.WaitForTask(ctx, task.NodeName, task.UPID, timeout)
+	timedOut := errors.Is(taskErr, context.DeadlineExceeded)
+
+	status := ""
+	current, err := task.Client.GetVMStatus(ctx, task.NodeName, vm.VMID)
+	if err != nil {
+		t.logger.Warn("failed to get vm status after task", zap.Uint32("vmid", vm.VMID),
+			zap.String("node", task.NodeName), zap.String("upid", task.UPID), zap.Error(err))
+		if taskErr == nil {
+			status = task.Expected
+		}
+	} else {
+		status, _ = current["status"].(string)
+	}
+	if timedOut {
+		// 任务仍在执行时以当前状态为准：已达到期望状态视为成功
+		if status == task.Expected {
+			taskErr = nil
+		} else {
+			taskErr = fmt.Errorf("task %s did not finish within %s", task.UPID, timeout)
+		}
+	}
+
+	t.mu.Lock()
+	latest := t.pending[vm.Id] == task.UPID
+	if latest {
+		delete(t.pending, vm.Id)
+	}
+	t.mu.Unlock()
+	if !latest {
+		t.logger.Info("vm task superseded, skip status update", zap.Uint32("vmid", vm.VMID), zap.String("upid", task.UPID))
+		return
+	}
+
+	if isStableVMStatus(status) && status != vm.Status {
+		if err := t.vmRepo.UpdateStatus(ctx, vm.Id, status); err != nil {
+			t.logger.Error("failed to update vm status", zap.Int64("vm_id", vm.Id), zap.String("status", status), zap.Error(err))
+		}
+	}
+	if taskErr != nil {
+		t.logger.Warn("vm task failed", zap.Uint32("vmid", vm.VMID), zap.String("node", task.NodeName),
+			zap.String("action", task.Action), zap.String("upid", task.UPID), zap.String("status", status), zap.Error(taskErr))
+	} else {
+		t.logger.Info("vm task completed", zap.Uint32("vmid", vm.VMID), zap.String("node", task.NodeName),
+			zap.String("action", task.Action), zap.String("upid", task.UPID), zap.String("status", status))
+	}
+
+	if operator != "" {
+		t.notificationService.Notify(ctx, taskFinishedNotification("vm_"+task.Action, vm.Id,
+			fmt.Sprintf("VM %s %s", vm.VmName, task.Action), taskErr), operator)
+	}
+}
+
+// isStableVMStatus 只把稳定状态写入数据库，过滤 Proxmox 返回的空值或临时状态
+func isStableVMStatus(status string) bool {
+	return status == "running" || status == "stopped" || status == "paused"
+}
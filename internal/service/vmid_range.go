@@ -0,0 +1,571 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultVMIDQuarantine 释放的 VMID 默认隔离 7 天后才再次分配
+	defaultVMIDQuarantine = 7 * 24 * time.Hour
+	// vmidRandomAttempts 随机生成 VMID 时避开已配置 VMID 段的最大尝试次数
+	vmidRandomAttempts = 20
+)
+
+type VMIDRangeService interface {
+	CreateRange(ctx context.Context, userID string, req *v1.CreateVMIDRangeRequest) (*v1.VMIDRangeItem, error)
+	UpdateRange(ctx context.Context, userID string, id int64, req *v1.UpdateVMIDRangeRequest) error
+	DeleteRange(ctx context.Context, userID string, id int64) error
+	GetRange(ctx context.Context, id int64) (*v1.VMIDRangeItem, error)
+	ListRanges(ctx context.Context, req *v1.ListVMIDRangesRequest) (*v1.ListVMIDRangesResponseData, error)
+	ListAllocations(ctx context.Context, req *v1.ListVMIDAllocationsRequest) (*v1.ListVMIDAllocationsResponseData, error)
+	// Allocate 为新虚拟机确定 VMID：
+	// - requested 不为 0 时校验其不属于其他团队的段、不在隔离期内，落在段内时登记分配
+	// - requested 为 0 时从 team 的段（没有时使用集群默认段）中分配最小的可用 VMID，跳过平台记录和 Proxmox 中已占用的 VMID；
+	//   没有适用的段时随机生成 8 位 VMID（避开已配置的段）
+	Allocate(ctx context.Context, cluster *model.PveCluster, team, vmName string, requested uint32) (uint32, error)
+	// Cancel 创建失败时撤销分配，VMID 不进入隔离期
+	Cancel(ctx context.Context, clusterID int64, vmid uint32)
+	// Release 虚拟机删除后释放 VMID，隔离期满后才会再次分配
+	Release(ctx context.Context, clusterID int64, vmid uint32)
+}
+
+func NewVMIDRangeService(
+	service *Service,
+	conf *viper.Viper,
+	rangeRepo repository.VMIDRangeRepository,
+	clusterRepo repository.PveClusterRepository,
+	vmRepo repository.PveVMRepository,
+	userRepo repository.UserRepository,
+	logger *log.Logger,
+) VMIDRangeService {
+	return &vmidRangeService{
+		conf:        conf,
+		rangeRepo:   rangeRepo,
+		clusterRepo: clusterRepo,
+		vmRepo:      vmRepo,
+		userRepo:    userRepo,
+		Service:     service,
+		logger:      logger,
+	}
+}
+
+type vmidRangeService struct {
+	conf        *viper.Viper
+	rangeRepo   repository.VMIDRangeRepository
+	clusterRepo repository.PveClusterRepository
+	vmRepo      repository.PveVMRepository
+	userRepo    repository.UserRepository
+	*Service
+	logger *log.Logger
+}
+
+// quarantine 释放的 VMID 的隔离期，配置为 0 时释放后立即可再次分配
+func (s *vmidRangeService) quarantine() time.Duration {
+	if s.conf.IsSet("vmid_range.quarantine") {
+		if d := s.conf.GetDuration("vmid_range.quarantine"); d >= 0 {
+			return d
+		}
+	}
+	return defaultVMIDQuarantine
+}
+
+// vmidRangesOverlap 两个段的适用集群有交集（任一适用全部集群或为同一集群）且区间相交
+func vmidRangesOverlap(a, b *model.VMIDRange) bool {
+	if a.ClusterID != 0 && b.ClusterID != 0 && a.ClusterID != b.ClusterID {
+		return false
+	}
+	return a.Start <= b.End && b.Start <= a.End
+}
+
+// checkRange 校验段的区间有效、集群存在且不与其他段重叠
+func (s *vmidRangeService) checkRange(ctx context.Context, rng *model.VMIDRange) error {
+	if rng.Start > rng.End {
+		return v1.WithDetailf(v1.ErrVMIDRangeInvalid, "start %d is greater than end %d", rng.Start, rng.End)
+	}
+	if rng.ClusterID > 0 {
+		cluster, err := s.clusterRepo.GetByID(ctx, rng.ClusterID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to get cluster", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+		if cluster == nil {
+			return v1.WithDetailf(v1.ErrClusterNotFound, "cluster_id=%d", rng.ClusterID)
+		}
+	}
+	ranges, err := s.rangeRepo.List(ctx, 0, "")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vmid ranges", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	for _, other := range ranges {
+		if other.Id != rng.Id && vmidRangesOverlap(rng, other) {
+			return v1.WithDetailf(v1.ErrVMIDRangeOverlap, "%d-%d (id=%d, team=%s)", other.Start, other.End, other.Id, other.Team)
+		}
+	}
+	return nil
+}
+
+func (s *vmidRangeService) getRange(ctx context.Context, id int64) (*model.VMIDRange, error) {
+	rng, err := s.rangeRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vmid range", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	if rng == nil {
+		return nil, v1.WithDetailf(v1.ErrVMIDRangeNotFound, "range_id=%d", id)
+	}
+	return rng, nil
+}
+
+func (s *vmidRangeService) CreateRange(ctx context.Context, userID string, req *v1.CreateVMIDRangeRequest) (*v1.VMIDRangeItem, error) {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vmid_range.manage")
+	if err != nil {
+		return nil, err
+	}
+	rng := &model.VMIDRange{
+		ClusterID:   req.ClusterID,
+		Team:        strings.TrimSpace(req.Team),
+		Start:       req.Start,
+		End:         req.End,
+		Description: req.Description,
+		Creator:     username,
+		Modifier:    username,
+	}
+	if err := s.checkRange(ctx, rng); err != nil {
+		return nil, err
+	}
+	if err := s.rangeRepo.Create(ctx, rng); err != nil {
+		s.logger.WithContext(ctx).Error("failed to create vmid range", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("vmid range created", zap.Int64("cluster_id", rng.ClusterID), zap.String("team", rng.Team),
+		zap.Uint32("start", rng.Start), zap.Uint32("end", rng.End), zap.String("operator", username))
+	return s.GetRange(ctx, rng.Id)
+}
+
+func (s *vmidRangeService) UpdateRange(ctx context.Context, userID string, id int64, req *v1.UpdateVMIDRangeRequest) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vmid_range.manage")
+	if err != nil {
+		return err
+	}
+	rng, err := s.getRange(ctx, id)
+	if err != nil {
+		return err
+	}
+	if req.Team != nil {
+		rng.Team = strings.TrimSpace(*req.Team)
+	}
+	if req.Start != nil {
+		rng.Start = *req.Start
+	}
+	if req.End != nil {
+		rng.End = *req.End
+	}
+	if req.Description != nil {
+		rng.Description = *req.Description
+	}
+	if err := s.checkRange(ctx, rng); err != nil {
+		return err
+	}
+	rng.Modifier = username
+	if err := s.rangeRepo.Update(ctx, rng); err != nil {
+		s.logger.WithContext(ctx).Error("failed to update vmid range", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+func (s *vmidRangeService) DeleteRange(ctx context.Context, userID string, id int64) error {
+	username, err := authorizeOperation(ctx, s.conf, s.userRepo, s.logger, userID, "vmid_range.manage")
+	if err != nil {
+		return err
+	}
+	rng, err := s.getRange(ctx, id)
+	if err != nil {
+		return err
+	}
+	// 分配记录按 (集群, VMID) 保留，重新配置覆盖这些 VMID 的段时隔离期仍然生效
+	if err := s.rangeRepo.Delete(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("failed to delete vmid range", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	s.logger.WithContext(ctx).Info("vmid range deleted", zap.Int64("cluster_id", rng.ClusterID), zap.String("team", rng.Team),
+		zap.Uint32("start", rng.Start), zap.Uint32("end", rng.End), zap.String("operator", username))
+	return nil
+}
+
+func (s *vmidRangeService) GetRange(ctx context.Context, id int64) (*v1.VMIDRangeItem, error) {
+	rng, err := s.getRange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.toItems(ctx, []*model.VMIDRange{rng})
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func (s *vmidRangeService) ListRanges(ctx context.Context, req *v1.ListVMIDRangesRequest) (*v1.ListVMIDRangesResponseData, error) {
+	ranges, err := s.rangeRepo.List(ctx, req.ClusterID, strings.TrimSpace(req.Team))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vmid ranges", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	items, err := s.toItems(ctx, ranges)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ListVMIDRangesResponseData{List: items}, nil
+}
+
+// toItems 补全集群名称和段内的分配统计
+func (s *vmidRangeService) toItems(ctx context.Context, ranges []*model.VMIDRange) ([]v1.VMIDRangeItem, error) {
+	clusterIDs := make([]int64, 0, len(ranges))
+	for _, rng := range ranges {
+		if rng.ClusterID > 0 {
+			clusterIDs = append(clusterIDs, rng.ClusterID)
+		}
+	}
+	clusters, err := s.clusterRepo.GetByIDs(ctx, clusterIDs)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get clusters", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+
+	releasedAfter := time.Now().Add(-s.quarantine())
+	items := make([]v1.VMIDRangeItem, 0, len(ranges))
+	for _, rng := range ranges {
+		allocated, quarantined, err := s.rangeRepo.CountAllocations(ctx, rng.ClusterID, rng.Start, rng.End, releasedAfter)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to count vmid allocations", zap.Error(err))
+			return nil, v1.ErrInternalServerError
+		}
+		item := v1.VMIDRangeItem{
+			Id:          rng.Id,
+			ClusterID:   rng.ClusterID,
+			Team:        rng.Team,
+			Start:       rng.Start,
+			End:         rng.End,
+			Size:        int64(rng.End) - int64(rng.Start) + 1,
+			Allocated:   allocated,
+			Quarantined: quarantined,
+			Description: rng.Description,
+			Creator:     rng.Creator,
+			Modifier:    rng.Modifier,
+			CreateTime:  rng.CreateTime,
+			UpdateTime:  rng.UpdateTime,
+		}
+		if cluster, ok := clusters[rng.ClusterID]; ok {
+			item.ClusterName = cluster.ClusterName
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *vmidRangeService) ListAllocations(ctx context.Context, req *v1.ListVMIDAllocationsRequest) (*v1.ListVMIDAllocationsResponseData, error) {
+	page, pageSize := req.Page, req.PageSize
+	if page <= 0 {
+		page = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	var rng *model.VMIDRange
+	if req.RangeID > 0 {
+		var err error
+		if rng, err = s.getRange(ctx, req.RangeID); err != nil {
+			return nil, err
+		}
+	}
+	allocs, total, err := s.rangeRepo.ListAllocations(ctx, page, pageSize, req.ClusterID, rng, req.Status)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vmid allocations", zap.Error(err))
+		return nil, v1.ErrInternalServerError
+	}
+	quarantine := s.quarantine()
+	list := make([]v1.VMIDAllocationItem, 0, len(allocs))
+	for _, alloc := range allocs {
+		item := v1.VMIDAllocationItem{
+			Id:         alloc.Id,
+			ClusterID:  alloc.ClusterID,
+			VMID:       alloc.VMID,
+			RangeID:    alloc.RangeID,
+			Team:       alloc.Team,
+			Status:     alloc.Status,
+			VmName:     alloc.VmName,
+			ReleasedAt: alloc.ReleasedAt,
+			CreateTime: alloc.CreateTime,
+			UpdateTime: alloc.UpdateTime,
+		}
+		if alloc.Status == model.VMIDAllocationStatusReleased && alloc.ReleasedAt != nil {
+			until := alloc.ReleasedAt.Add(quarantine)
+			item.QuarantineUntil = &until
+		}
+		list = append(list, item)
+	}
+	return &v1.ListVMIDAllocationsResponseData{Total: total, List: list}, nil
+}
+
+// rangeContaining 返回包含 vmid 的段，集群专属段优先于全部集群适用的段
+func rangeContaining(ranges []*model.VMIDRange, vmid uint32) *model.VMIDRange {
+	var found *model.VMIDRange
+	for _, rng := range ranges {
+		if vmid < rng.Start || vmid > rng.End {
+			continue
+		}
+		if found == nil || (found.ClusterID == 0 && rng.ClusterID != 0) {
+			found = rng
+		}
+	}
+	return found
+}
+
+// teamRanges 返回团队可分配的段：团队专属段，没有时使用默认段（team 为空）；集群专属段在前
+func teamRanges(ranges []*model.VMIDRange, team string) []*model.VMIDRange {
+	pick := func(team string) []*model.VMIDRange {
+		var result []*model.VMIDRange
+		for _, rng := range ranges {
+			if rng.Team == team {
+				result = append(result, rng)
+			}
+		}
+		return result
+	}
+	var result []*model.VMIDRange
+	if team != "" {
+		result = pick(team)
+	}
+	if len(result) == 0 {
+		result = pick("")
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if (result[i].ClusterID == 0) != (result[j].ClusterID == 0) {
+			return result[i].ClusterID != 0
+		}
+		return result[i].Start < result[j].Start
+	})
+	return result
+}
+
+// inQuarantine 分配记录已释放且仍在隔离期内
+func (s *vmidRangeService) inQuarantine(alloc *model.VMIDAllocation, now time.Time) bool {
+	return alloc.Status == model.VMIDAllocationStatusReleased && alloc.ReleasedAt != nil &&
+		now.Before(alloc.ReleasedAt.Add(s.quarantine()))
+}
+
+func (s *vmidRangeService) Allocate(ctx context.Context, cluster *model.PveCluster, team, vmName string, requested uint32) (uint32, error) {
+	team = strings.TrimSpace(team)
+	ranges, err := s.rangeRepo.List(ctx, cluster.Id, "")
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to list vmid ranges", zap.Error(err))
+		return 0, v1.ErrInternalServerError
+	}
+	if requested != 0 {
+		return requested, s.claimRequested(ctx, cluster, ranges, team, vmName, requested)
+	}
+
+	candidates := teamRanges(ranges, team)
+	if len(candidates) == 0 {
+		// 未配置适用的段：随机 8 位 VMID，避免落入其他团队的段
+		for attempt := 0; attempt < vmidRandomAttempts; attempt++ {
+			if vmid := generateProxmoxVMID(0); rangeContaining(ranges, vmid) == nil {
+				return vmid, nil
+			}
+		}
+		return 0, v1.WithDetail(v1.ErrVMIDRangeExhausted, "no vmid outside the configured ranges")
+	}
+
+	used := s.usedVMIDs(ctx, cluster)
+	now := time.Now()
+	for _, rng := range candidates {
+		allocs, err := s.rangeRepo.ListAllocationsInRange(ctx, cluster.Id, rng.Start, rng.End)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to list vmid allocations", zap.Error(err))
+			return 0, v1.ErrInternalServerError
+		}
+		byVMID := make(map[uint32]*model.VMIDAllocation, len(allocs))
+		for _, alloc := range allocs {
+			byVMID[alloc.VMID] = alloc
+		}
+		for vmid := rng.Start; vmid <= rng.End; vmid++ {
+			if used[vmid] {
+				continue
+			}
+			if alloc, ok := byVMID[vmid]; ok {
+				if alloc.Status == model.VMIDAllocationStatusAllocated || s.inQuarantine(alloc, now) {
+					continue
+				}
+				// 隔离期已满，删除旧记录后重新分配
+				if err := s.rangeRepo.DeleteAllocation(ctx, alloc.Id); err != nil {
+					s.logger.WithContext(ctx).Error("failed to delete vmid allocation", zap.Error(err))
+					return 0, v1.ErrInternalServerError
+				}
+			}
+			// 唯一索引兜底：并发分配到同一 VMID 时继续尝试下一个
+			if err := s.rangeRepo.CreateAllocation(ctx, &model.VMIDAllocation{
+				ClusterID: cluster.Id,
+				VMID:      vmid,
+				RangeID:   rng.Id,
+				Team:      team,
+				Status:    model.VMIDAllocationStatusAllocated,
+				VmName:    vmName,
+			}); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to register vmid allocation, trying next", zap.Uint32("vmid", vmid), zap.Error(err))
+				continue
+			}
+			s.logger.WithContext(ctx).Info("vmid allocated from range", zap.Int64("cluster_id", cluster.Id), zap.String("team", team),
+				zap.Int64("range_id", rng.Id), zap.Uint32("vmid", vmid), zap.String("vm_name", vmName))
+			return vmid, nil
+		}
+	}
+	details := make([]string, 0, len(candidates))
+	for _, rng := range candidates {
+		details = append(details, rangeLabel(rng))
+	}
+	return 0, v1.WithDetail(v1.ErrVMIDRangeExhausted, strings.Join(details, ", "))
+}
+
+// rangeLabel 段的简短描述，用于错误详情
+func rangeLabel(rng *model.VMIDRange) string {
+	team := rng.Team
+	if team == "" {
+		team = "default"
+	}
+	return fmt.Sprintf("%s %d-%d", team, rng.Start, rng.End)
+}
+
+// claimRequested 校验并登记调用方指定的 VMID；不在任何段内的 VMID 不做登记
+func (s *vmidRangeService) claimRequested(ctx context.Context, cluster *model.PveCluster, ranges []*model.VMIDRange, team, vmName string, vmid uint32) error {
+	rng := rangeContaining(ranges, vmid)
+	if rng == nil {
+		return nil
+	}
+	if rng.Team != "" && rng.Team != team {
+		return v1.WithDetailf(v1.ErrVMIDReservedByTeam, "vmid %d is in the range %s", vmid, rangeLabel(rng))
+	}
+	alloc, err := s.rangeRepo.GetAllocation(ctx, cluster.Id, vmid)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to get vmid allocation", zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	if alloc != nil {
+		// 已分配：异步创建任务提交时登记，执行时再次校验
+		if alloc.Status == model.VMIDAllocationStatusAllocated {
+			return nil
+		}
+		if s.inQuarantine(alloc, time.Now()) {
+			return v1.WithDetailf(v1.ErrVMIDQuarantined, "vmid %d is quarantined until %s", vmid,
+				alloc.ReleasedAt.Add(s.quarantine()).Format(time.RFC3339))
+		}
+		if err := s.rangeRepo.DeleteAllocation(ctx, alloc.Id); err != nil {
+			s.logger.WithContext(ctx).Error("failed to delete vmid allocation", zap.Error(err))
+			return v1.ErrInternalServerError
+		}
+	}
+	if err := s.rangeRepo.CreateAllocation(ctx, &model.VMIDAllocation{
+		ClusterID: cluster.Id,
+		VMID:      vmid,
+		RangeID:   rng.Id,
+		Team:      team,
+		Status:    model.VMIDAllocationStatusAllocated,
+		VmName:    vmName,
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to register vmid allocation", zap.Uint32("vmid", vmid), zap.Error(err))
+		return v1.ErrInternalServerError
+	}
+	return nil
+}
+
+// usedVMIDs 集群内已被占用的 VMID：平台记录的虚拟机和模板，以及 Proxmox 中现有的虚拟机和容器（含手动创建的）；
+// 无法从 Proxmox 获取时只使用平台记录，由创建前校验兜底
+func (s *vmidRangeService) usedVMIDs(ctx context.Context, cluster *model.PveCluster) map[uint32]bool {
+	used := make(map[uint32]bool)
+	vms, err := s.vmRepo.GetByClusterID(ctx, cluster.Id)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to list cluster vms for vmid allocation", zap.Error(err))
+	}
+	for _, vm := range vms {
+		used[vm.VMID] = true
+	}
+
+	client, err := proxmox.NewProxmoxClient(cluster.ApiUrl, cluster.UserId, cluster.UserToken, proxmox.WithRequestLog(cluster.ApiLogEnabled == 1))
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to create proxmox client for vmid allocation", zap.Error(err))
+		return used
+	}
+	resources, err := client.GetClusterResourcesByType(ctx, "vm")
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to list cluster resources for vmid allocation", zap.Error(err), zap.String("cluster", cluster.ClusterName))
+		return used
+	}
+	for _, resource := range resources {
+		if vmid, ok := resource["vmid"].(float64); ok {
+			used[uint32(vmid)] = true
+		}
+	}
+	return used
+}
+
+func (s *vmidRangeService) Cancel(ctx context.Context, clusterID int64, vmid uint32) {
+	alloc, err := s.rangeRepo.GetAllocation(ctx, clusterID, vmid)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vmid allocation", zap.Uint32("vmid", vmid), zap.Error(err))
+		return
+	}
+	if alloc == nil || alloc.Status != model.VMIDAllocationStatusAllocated {
+		return
+	}
+	if err := s.rangeRepo.DeleteAllocation(ctx, alloc.Id); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to cancel vmid allocation", zap.Uint32("vmid", vmid), zap.Error(err))
+	}
+}
+
+func (s *vmidRangeService) Release(ctx context.Context, clusterID int64, vmid uint32) {
+	alloc, err := s.rangeRepo.GetAllocation(ctx, clusterID, vmid)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to get vmid allocation", zap.Uint32("vmid", vmid), zap.Error(err))
+		return
+	}
+	now := time.Now()
+	if alloc == nil {
+		// 配置段之前创建或手动创建的虚拟机，落在段内时同样进入隔离期
+		ranges, err := s.rangeRepo.List(ctx, clusterID, "")
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("failed to list vmid ranges", zap.Error(err))
+			return
+		}
+		rng := rangeContaining(ranges, vmid)
+		if rng == nil {
+			return
+		}
+		alloc = &model.VMIDAllocation{ClusterID: clusterID, VMID: vmid, RangeID: rng.Id, Team: rng.Team}
+	}
+	alloc.Status = model.VMIDAllocationStatusReleased
+	alloc.ReleasedAt = &now
+	if alloc.Id == 0 {
+		err = s.rangeRepo.CreateAllocation(ctx, alloc)
+	} else {
+		err = s.rangeRepo.UpdateAllocation(ctx, alloc)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to release vmid", zap.Uint32("vmid", vmid), zap.Error(err))
+		return
+	}
+	s.logger.WithContext(ctx).Info("vmid released", zap.Int64("cluster_id", clusterID), zap.Uint32("vmid", vmid),
+		zap.Time("quarantine_until", now.Add(s.quarantine())))
+}
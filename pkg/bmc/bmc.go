@@ -0,0 +1,89 @@
+package bmc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 支持的 BMC 协议
+const (
+	ProtocolRedfish = "redfish"
+	ProtocolIPMI    = "ipmi"
+)
+
+// DefaultTimeout BMC 请求默认超时时间
+const DefaultTimeout = 10 * time.Second
+
+// 电源状态
+const (
+	PowerStateOn      = "on"
+	PowerStateOff     = "off"
+	PowerStateUnknown = "unknown"
+)
+
+// 电源操作
+const (
+	PowerActionOn    = "on"    // 开机
+	PowerActionOff   = "off"   // 强制断电
+	PowerActionCycle = "cycle" // 强制重启（用于挂死节点）
+)
+
+// 传感器类型
+const (
+	SensorTypeTemperature = "temperature"
+	SensorTypeFan         = "fan"
+	SensorTypeVoltage     = "voltage"
+	SensorTypeOther       = "other"
+)
+
+// Sensor 传感器读数
+type Sensor struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Reading *float64 `json:"reading"` // 无读数时为空
+	Unit    string   `json:"unit"`
+	Status  string   `json:"status"` // BMC 返回的健康状态，如 OK / Warning / Critical（IPMI 为 ok / nc / cr 等）
+}
+
+// ErrPowerReadingUnavailable BMC 未提供功率读数（不支持 DCMI / Redfish Power 资源）
+var ErrPowerReadingUnavailable = errors.New("bmc: power reading unavailable")
+
+// Config BMC 连接配置
+type Config struct {
+	Protocol           string
+	Address            string // Redfish 为 https://host[:port]（可省略 scheme），IPMI 为 host[:port]
+	Username           string
+	Password           string
+	InsecureSkipVerify bool          // Redfish 是否跳过证书校验（BMC 普遍使用自签名证书）
+	Timeout            time.Duration // <= 0 时使用 DefaultTimeout
+	IPMIToolPath       string        // IPMI 使用的 ipmitool 路径，默认从 PATH 查找
+}
+
+// Client 带外管理客户端
+type Client interface {
+	// PowerReading 读取整机当前功率（瓦）
+	PowerReading(ctx context.Context) (float64, error)
+	// PowerState 读取电源状态（PowerStateOn / PowerStateOff / PowerStateUnknown）
+	PowerState(ctx context.Context) (string, error)
+	// Power 执行电源操作（PowerActionOn / PowerActionOff / PowerActionCycle）
+	Power(ctx context.Context, action string) error
+	// Sensors 读取温度、风扇、电压等传感器
+	Sensors(ctx context.Context) ([]Sensor, error)
+}
+
+// NewClient 根据协议创建 BMC 客户端
+func NewClient(cfg Config) (Client, error) {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	switch cfg.Protocol {
+	case ProtocolRedfish:
+		return newRedfishClient(cfg)
+	case ProtocolIPMI:
+		return newIPMIClient(cfg)
+	default:
+		return nil, fmt.Errorf("bmc: unsupported protocol %q", cfg.Protocol)
+	}
+}
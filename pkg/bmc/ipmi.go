@@ -0,0 +1,148 @@
+package bmc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ipmiClient 通过 ipmitool（lanplus 接口）访问 BMC
+// 密码通过 IPMI_PASSWORD 环境变量传递（-E），避免出现在进程参数中
+type ipmiClient struct {
+	toolPath string
+	host     string
+	port     string
+	cfg      Config
+}
+
+// powerReadingPattern 匹配 `ipmitool dcmi power reading` 输出中的瞬时功率
+var powerReadingPattern = regexp.MustCompile(`Instantaneous power reading:\s+([0-9.]+)\s+Watts`)
+
+func newIPMIClient(cfg Config) (*ipmiClient, error) {
+	host, port := cfg.Address, ""
+	if h, p, err := net.SplitHostPort(cfg.Address); err == nil {
+		host, port = h, p
+	}
+	if host == "" {
+		return nil, fmt.Errorf("bmc: invalid ipmi address %q", cfg.Address)
+	}
+	toolPath := cfg.IPMIToolPath
+	if toolPath == "" {
+		toolPath = "ipmitool"
+	}
+	return &ipmiClient{
+		toolPath: toolPath,
+		host:     host,
+		port:     port,
+		cfg:      cfg,
+	}, nil
+}
+
+func (c *ipmiClient) run(ctx context.Context, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	base := []string{"-I", "lanplus", "-H", c.host, "-U", c.cfg.Username, "-E"}
+	if c.port != "" {
+		base = append(base, "-p", c.port)
+	}
+	cmd := exec.CommandContext(ctx, c.toolPath, append(base, args...)...)
+	cmd.Env = append(os.Environ(), "IPMI_PASSWORD="+c.cfg.Password)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("ipmitool %s: %w: %s", strings.Join(args, " "), err, msg)
+		}
+		return "", fmt.Errorf("ipmitool %s: %w", strings.Join(args, " "), err)
+	}
+	return stdout.String(), nil
+}
+
+// PowerReading 通过 DCMI 读取瞬时功率
+func (c *ipmiClient) PowerReading(ctx context.Context) (float64, error) {
+	out, err := c.run(ctx, "dcmi", "power", "reading")
+	if err != nil {
+		return 0, err
+	}
+	m := powerReadingPattern.FindStringSubmatch(out)
+	if m == nil {
+		return 0, ErrPowerReadingUnavailable
+	}
+	return strconv.ParseFloat(m[1], 64)
+}
+
+// ipmiPowerCommands 电源操作对应的 `ipmitool chassis power` 子命令
+var ipmiPowerCommands = map[string]string{
+	PowerActionOn:    "on",
+	PowerActionOff:   "off",
+	PowerActionCycle: "cycle",
+}
+
+// PowerState 解析 `ipmitool chassis power status` 输出，如 "Chassis Power is on"
+func (c *ipmiClient) PowerState(ctx context.Context) (string, error) {
+	out, err := c.run(ctx, "chassis", "power", "status")
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasSuffix(strings.TrimSpace(out), " on"):
+		return PowerStateOn, nil
+	case strings.HasSuffix(strings.TrimSpace(out), " off"):
+		return PowerStateOff, nil
+	default:
+		return PowerStateUnknown, nil
+	}
+}
+
+func (c *ipmiClient) Power(ctx context.Context, action string) error {
+	command, ok := ipmiPowerCommands[action]
+	if !ok {
+		return fmt.Errorf("bmc: unsupported power action %q", action)
+	}
+	_, err := c.run(ctx, "chassis", "power", command)
+	return err
+}
+
+// Sensors 解析 `ipmitool sensor` 输出，每行格式为 "名称 | 读数 | 单位 | 状态 | 阈值..."
+func (c *ipmiClient) Sensors(ctx context.Context) ([]Sensor, error) {
+	out, err := c.run(ctx, "sensor")
+	if err != nil {
+		return nil, err
+	}
+
+	sensors := make([]Sensor, 0)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "|")
+		if len(fields) < 4 {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		sensor := Sensor{Name: fields[0], Unit: fields[2], Status: fields[3]}
+		switch fields[2] {
+		case "degrees C":
+			sensor.Type, sensor.Unit = SensorTypeTemperature, "C"
+		case "RPM":
+			sensor.Type = SensorTypeFan
+		case "Volts":
+			sensor.Type, sensor.Unit = SensorTypeVoltage, "V"
+		default:
+			sensor.Type = SensorTypeOther
+		}
+		if v, err := strconv.ParseFloat(fields[1], 64); err == nil {
+			sensor.Reading = &v
+		}
+		sensors = append(sensors, sensor)
+	}
+	return sensors, nil
+}
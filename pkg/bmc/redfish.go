@@ -0,0 +1,254 @@
+package bmc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type redfishClient struct {
+	baseURL    *url.URL
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func newRedfishClient(cfg Config) (*redfishClient, error) {
+	address := cfg.Address
+	if !strings.Contains(address, "://") {
+		address = "https://" + address
+	}
+	baseURL, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("bmc: invalid redfish address: %w", err)
+	}
+	return &redfishClient{
+		baseURL:  baseURL,
+		username: cfg.Username,
+		password: cfg.Password,
+		httpClient: &http.Client{
+			Timeout: cfg.Timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
+			},
+		},
+	}, nil
+}
+
+// redfishLink Redfish 资源引用
+type redfishLink struct {
+	ODataID string `json:"@odata.id"`
+}
+
+type redfishCollection struct {
+	Members []redfishLink `json:"Members"`
+}
+
+type redfishStatus struct {
+	Health string `json:"Health"`
+	State  string `json:"State"`
+}
+
+type redfishPower struct {
+	PowerControl []struct {
+		PowerConsumedWatts *float64 `json:"PowerConsumedWatts"`
+	} `json:"PowerControl"`
+	Voltages []struct {
+		Name         string        `json:"Name"`
+		ReadingVolts *float64      `json:"ReadingVolts"`
+		Status       redfishStatus `json:"Status"`
+	} `json:"Voltages"`
+}
+
+type redfishThermal struct {
+	Temperatures []struct {
+		Name           string        `json:"Name"`
+		ReadingCelsius *float64      `json:"ReadingCelsius"`
+		Status         redfishStatus `json:"Status"`
+	} `json:"Temperatures"`
+	Fans []struct {
+		Name         string        `json:"Name"`
+		FanName      string        `json:"FanName"` // Redfish 早期版本使用 FanName
+		Reading      *float64      `json:"Reading"`
+		ReadingUnits string        `json:"ReadingUnits"`
+		Status       redfishStatus `json:"Status"`
+	} `json:"Fans"`
+}
+
+type redfishSystem struct {
+	PowerState string `json:"PowerState"`
+}
+
+// redfishResetTypes 电源操作对应的 ComputerSystem.Reset ResetType
+var redfishResetTypes = map[string]string{
+	PowerActionOn:    "On",
+	PowerActionOff:   "ForceOff",
+	PowerActionCycle: "ForceRestart",
+}
+
+func (c *redfishClient) get(ctx context.Context, path string, result interface{}) error {
+	return c.do(ctx, http.MethodGet, path, nil, result)
+}
+
+func (c *redfishClient) do(ctx context.Context, method, path string, payload, result interface{}) error {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	var reqBody io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL.ResolveReference(ref).String(), reqBody)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("redfish %s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if result == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, result)
+}
+
+// chassis 返回所有机箱资源路径
+func (c *redfishClient) chassis(ctx context.Context) ([]string, error) {
+	var collection redfishCollection
+	if err := c.get(ctx, "/redfish/v1/Chassis", &collection); err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(collection.Members))
+	for _, m := range collection.Members {
+		if m.ODataID != "" {
+			paths = append(paths, m.ODataID)
+		}
+	}
+	return paths, nil
+}
+
+// PowerReading 读取第一个提供 PowerConsumedWatts 的机箱功率
+func (c *redfishClient) PowerReading(ctx context.Context) (float64, error) {
+	paths, err := c.chassis(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, path := range paths {
+		var power redfishPower
+		if err := c.get(ctx, strings.TrimSuffix(path, "/")+"/Power", &power); err != nil {
+			// 部分机箱（如背板、扩展柜）没有 Power 资源，继续尝试下一个
+			continue
+		}
+		for _, pc := range power.PowerControl {
+			if pc.PowerConsumedWatts != nil {
+				return *pc.PowerConsumedWatts, nil
+			}
+		}
+	}
+	return 0, ErrPowerReadingUnavailable
+}
+
+// system 返回第一个计算机系统资源路径
+func (c *redfishClient) system(ctx context.Context) (string, error) {
+	var collection redfishCollection
+	if err := c.get(ctx, "/redfish/v1/Systems", &collection); err != nil {
+		return "", err
+	}
+	for _, m := range collection.Members {
+		if m.ODataID != "" {
+			return strings.TrimSuffix(m.ODataID, "/"), nil
+		}
+	}
+	return "", fmt.Errorf("redfish: no computer system found")
+}
+
+func (c *redfishClient) PowerState(ctx context.Context) (string, error) {
+	path, err := c.system(ctx)
+	if err != nil {
+		return "", err
+	}
+	var system redfishSystem
+	if err := c.get(ctx, path, &system); err != nil {
+		return "", err
+	}
+	switch system.PowerState {
+	case "On", "PoweringOn":
+		return PowerStateOn, nil
+	case "Off", "PoweringOff":
+		return PowerStateOff, nil
+	default:
+		return PowerStateUnknown, nil
+	}
+}
+
+func (c *redfishClient) Power(ctx context.Context, action string) error {
+	resetType, ok := redfishResetTypes[action]
+	if !ok {
+		return fmt.Errorf("bmc: unsupported power action %q", action)
+	}
+	path, err := c.system(ctx)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPost, path+"/Actions/ComputerSystem.Reset", map[string]string{"ResetType": resetType}, nil)
+}
+
+func (c *redfishClient) Sensors(ctx context.Context) ([]Sensor, error) {
+	paths, err := c.chassis(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sensors := make([]Sensor, 0)
+	for _, path := range paths {
+		path = strings.TrimSuffix(path, "/")
+
+		var thermal redfishThermal
+		if err := c.get(ctx, path+"/Thermal", &thermal); err == nil {
+			for _, t := range thermal.Temperatures {
+				sensors = append(sensors, Sensor{Name: t.Name, Type: SensorTypeTemperature, Reading: t.ReadingCelsius, Unit: "C", Status: t.Status.Health})
+			}
+			for _, f := range thermal.Fans {
+				name := f.Name
+				if name == "" {
+					name = f.FanName
+				}
+				sensors = append(sensors, Sensor{Name: name, Type: SensorTypeFan, Reading: f.Reading, Unit: f.ReadingUnits, Status: f.Status.Health})
+			}
+		}
+
+		var power redfishPower
+		if err := c.get(ctx, path+"/Power", &power); err == nil {
+			for _, v := range power.Voltages {
+				sensors = append(sensors, Sensor{Name: v.Name, Type: SensorTypeVoltage, Reading: v.ReadingVolts, Unit: "V", Status: v.Status.Health})
+			}
+		}
+	}
+	return sensors, nil
+}
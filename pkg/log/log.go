@@ -2,6 +2,7 @@ package log
 
 import (
 	"context"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -13,6 +14,46 @@ import (
 
 const ctxLoggerKey = "zapLogger"
 
+// level 进程内所有 logger 共享的日志级别
+var level = zap.NewAtomicLevel()
+
+// parseLevel debug<info<warn<error<fatal<panic
+func parseLevel(lv string) (zapcore.Level, bool) {
+	switch lv {
+	case "debug":
+		return zap.DebugLevel, true
+	case "info":
+		return zap.InfoLevel, true
+	case "warn":
+		return zap.WarnLevel, true
+	case "error":
+		return zap.ErrorLevel, true
+	default:
+		return zap.InfoLevel, false
+	}
+}
+
+// ValidLevel 判断日志级别是否合法（debug / info / warn / error）
+func ValidLevel(lv string) bool {
+	_, ok := parseLevel(lv)
+	return ok
+}
+
+// SetLevel 运行时修改日志级别，立即对所有 logger 生效
+func SetLevel(lv string) error {
+	parsed, ok := parseLevel(lv)
+	if !ok {
+		return fmt.Errorf("invalid log level: %s", lv)
+	}
+	level.SetLevel(parsed)
+	return nil
+}
+
+// GetLevel 返回当前日志级别
+func GetLevel() string {
+	return level.Level().String()
+}
+
 type Logger struct {
 	*zap.Logger
 }
@@ -21,20 +62,12 @@ func NewLog(conf *viper.Viper) *Logger {
 	// log address "out.log" User-defined
 	lp := conf.GetString("log.log_file_name")
 	lv := conf.GetString("log.log_level")
-	var level zapcore.Level
-	//debug<info<warn<error<fatal<panic
-	switch lv {
-	case "debug":
-		level = zap.DebugLevel
-	case "info":
-		level = zap.InfoLevel
-	case "warn":
-		level = zap.WarnLevel
-	case "error":
-		level = zap.ErrorLevel
-	default:
-		level = zap.InfoLevel
+	// 使用 AtomicLevel，支持运行时通过 SetLevel 热更新日志级别
+	parsed, ok := parseLevel(lv)
+	if !ok {
+		parsed = zap.InfoLevel
 	}
+	level.SetLevel(parsed)
 	hook := lumberjack.Logger{
 		Filename:   lp,                             // Log file path
 		MaxSize:    conf.GetInt("log.max_size"),    // Maximum size unit for each log file: M
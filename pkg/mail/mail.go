@@ -0,0 +1,205 @@
+// Package mail 通过 SMTP 发送带附件的邮件
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Config SMTP 服务器配置，Username 为空时不认证
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// TLS 为 true 时直接使用 TLS 连接（通常为 465 端口）；否则服务器支持时使用 STARTTLS
+	TLS                bool
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+}
+
+// Attachment 邮件附件
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Message 邮件，Body 为纯文本
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string
+	Attachments []Attachment
+}
+
+// Sender SMTP 发件客户端
+type Sender struct {
+	conf Config
+}
+
+func NewSender(conf Config) (*Sender, error) {
+	if conf.Host == "" {
+		return nil, fmt.Errorf("mail: smtp host is required")
+	}
+	if _, err := mail.ParseAddress(conf.From); err != nil {
+		return nil, fmt.Errorf("mail: invalid from address %q: %w", conf.From, err)
+	}
+	if conf.Port == 0 {
+		conf.Port = 25
+	}
+	if conf.Timeout <= 0 {
+		conf.Timeout = 30 * time.Second
+	}
+	return &Sender{conf: conf}, nil
+}
+
+// Send 发送邮件，所有收件人在同一封邮件中
+func (s *Sender) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("mail: no recipients")
+	}
+	for _, to := range msg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return fmt.Errorf("mail: invalid recipient %q: %w", to, err)
+		}
+	}
+	data, err := s.build(msg)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.conf.Host, fmt.Sprint(s.conf.Port))
+	dialer := &net.Dialer{Timeout: s.conf.Timeout}
+	tlsConfig := &tls.Config{ServerName: s.conf.Host, InsecureSkipVerify: s.conf.InsecureSkipVerify}
+	var conn net.Conn
+	if s.conf.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("mail: connect %s: %w", addr, err)
+	}
+	deadline := time.Now().Add(s.conf.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.conf.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mail: %w", err)
+	}
+	defer client.Close()
+
+	if !s.conf.TLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("mail: starttls: %w", err)
+			}
+		}
+	}
+	if s.conf.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.conf.Username, s.conf.Password, s.conf.Host)); err != nil {
+			return fmt.Errorf("mail: auth: %w", err)
+		}
+	}
+	from, _ := mail.ParseAddress(s.conf.From)
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("mail: MAIL FROM: %w", err)
+	}
+	for _, to := range msg.To {
+		addr, _ := mail.ParseAddress(to)
+		if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("mail: RCPT TO %s: %w", addr.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mail: DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mail: write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+	return client.Quit()
+}
+
+// build 生成 multipart/mixed 邮件内容：正文为 UTF-8 纯文本，附件使用 base64 编码
+func (s *Sender) build(msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	header := make([]string, 0, 8)
+	header = append(header,
+		"From: "+s.conf.From,
+		"To: "+strings.Join(msg.To, ", "),
+		"Subject: "+mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: "+time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", writer.Boundary()),
+	)
+	var out bytes.Buffer
+	out.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	body, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := body.Write(wrapBase64(msg.Body)); err != nil {
+		return nil, err
+	}
+	for _, a := range msg.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(wrapBase64(string(a.Data))); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	out.Write(buf.Bytes())
+	return out.Bytes(), nil
+}
+
+// wrapBase64 base64 编码并按 76 字符换行
+func wrapBase64(s string) []byte {
+	encoded := base64.StdEncoding.EncodeToString([]byte(s))
+	var b bytes.Buffer
+	for len(encoded) > 76 {
+		b.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	b.WriteString(encoded + "\r\n")
+	return b.Bytes()
+}
@@ -0,0 +1,155 @@
+package proxmox
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Proxmox 用户、API Token、角色和 ACL 管理（/access/*）
+// 参考: https://pve.proxmox.com/pve-docs/api-viewer/#/access
+
+// ListAccessUsers 获取用户列表（full=1 时包含每个用户的 API Token）
+// GET /api2/json/access/users
+func (c *ProxmoxClient) ListAccessUsers(ctx context.Context) ([]map[string]interface{}, error) {
+	endpoint := c.baseUrl.JoinPath("/api2/json", "/access/users").String() + "?full=1"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	var users []map[string]interface{}
+	if err := c.Request(ctx, req, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetAccessUser 获取用户配置
+// GET /api2/json/access/users/{userid}
+func (c *ProxmoxClient) GetAccessUser(ctx context.Context, userID string) (map[string]interface{}, error) {
+	var user map[string]interface{}
+	if err := c.Get(ctx, "/access/users/"+userID, &user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CreateAccessUser 创建用户（userid、password、email、comment、enable、expire、groups 等）
+// POST /api2/json/access/users
+func (c *ProxmoxClient) CreateAccessUser(ctx context.Context, params url.Values) error {
+	return c.PostForm(ctx, "/access/users", params, nil)
+}
+
+// UpdateAccessUser 更新用户配置
+// PUT /api2/json/access/users/{userid}
+func (c *ProxmoxClient) UpdateAccessUser(ctx context.Context, userID string, params url.Values) error {
+	return c.PutForm(ctx, "/access/users/"+userID, params, nil)
+}
+
+// DeleteAccessUser 删除用户（同时删除其 API Token 和 ACL）
+// DELETE /api2/json/access/users/{userid}
+func (c *ProxmoxClient) DeleteAccessUser(ctx context.Context, userID string) error {
+	return c.Delete(ctx, "/access/users/"+userID)
+}
+
+// ListUserTokens 获取用户的 API Token 列表
+// GET /api2/json/access/users/{userid}/token
+func (c *ProxmoxClient) ListUserTokens(ctx context.Context, userID string) ([]map[string]interface{}, error) {
+	path := fmt.Sprintf("/access/users/%s/token", userID)
+	var tokens []map[string]interface{}
+	if err := c.Get(ctx, path, &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CreateUserToken 创建 API Token，返回 full-tokenid、value（secret，仅创建时返回一次）和 info
+// POST /api2/json/access/users/{userid}/token/{tokenid}
+func (c *ProxmoxClient) CreateUserToken(ctx context.Context, userID, tokenID string, params url.Values) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/access/users/%s/token/%s", userID, tokenID)
+	var result map[string]interface{}
+	if err := c.PostForm(ctx, path, params, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteUserToken 删除 API Token
+// DELETE /api2/json/access/users/{userid}/token/{tokenid}
+func (c *ProxmoxClient) DeleteUserToken(ctx context.Context, userID, tokenID string) error {
+	return c.Delete(ctx, fmt.Sprintf("/access/users/%s/token/%s", userID, tokenID))
+}
+
+// GetACL 获取 ACL 列表（path、type、ugid、roleid、propagate）
+// GET /api2/json/access/acl
+func (c *ProxmoxClient) GetACL(ctx context.Context) ([]map[string]interface{}, error) {
+	var acl []map[string]interface{}
+	if err := c.Get(ctx, "/access/acl", &acl); err != nil {
+		return nil, err
+	}
+	return acl, nil
+}
+
+// UpdateACL 添加或删除（delete=1）ACL，参数 path、roles、users / groups / tokens、propagate
+// PUT /api2/json/access/acl
+func (c *ProxmoxClient) UpdateACL(ctx context.Context, params url.Values) error {
+	return c.PutForm(ctx, "/access/acl", params, nil)
+}
+
+// ListRoles 获取角色列表（roleid、privs、special）
+// GET /api2/json/access/roles
+func (c *ProxmoxClient) ListRoles(ctx context.Context) ([]map[string]interface{}, error) {
+	var roles []map[string]interface{}
+	if err := c.Get(ctx, "/access/roles", &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// GetRole 获取角色包含的权限，返回 权限名 -> 1
+// GET /api2/json/access/roles/{roleid}
+func (c *ProxmoxClient) GetRole(ctx context.Context, roleID string) (map[string]interface{}, error) {
+	var privs map[string]interface{}
+	if err := c.Get(ctx, "/access/roles/"+roleID, &privs); err != nil {
+		return nil, err
+	}
+	return privs, nil
+}
+
+// CreateRole 创建角色，privs 为逗号分隔的权限列表
+// POST /api2/json/access/roles
+func (c *ProxmoxClient) CreateRole(ctx context.Context, roleID, privs string) error {
+	params := url.Values{}
+	params.Set("roleid", roleID)
+	params.Set("privs", privs)
+	return c.PostForm(ctx, "/access/roles", params, nil)
+}
+
+// UpdateRole 覆盖角色的权限列表
+// PUT /api2/json/access/roles/{roleid}
+func (c *ProxmoxClient) UpdateRole(ctx context.Context, roleID, privs string) error {
+	params := url.Values{}
+	params.Set("privs", privs)
+	return c.PutForm(ctx, "/access/roles/"+roleID, params, nil)
+}
+
+// GetPermissions 获取当前认证身份（用户或 API Token）在指定路径上的有效权限，返回 路径 -> 权限名 -> 1
+// GET /api2/json/access/permissions
+func (c *ProxmoxClient) GetPermissions(ctx context.Context, path string) (map[string]map[string]interface{}, error) {
+	endpoint := c.baseUrl.JoinPath("/api2/json", "/access/permissions").String()
+	if path != "" {
+		params := url.Values{}
+		params.Set("path", path)
+		endpoint += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	var perms map[string]map[string]interface{}
+	if err := c.Request(ctx, req, &perms); err != nil {
+		return nil, err
+	}
+	return perms, nil
+}
@@ -0,0 +1,153 @@
+package proxmox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Proxmox API 调用记录：请求 context 中放入 CallRecorder 后，该请求内所有客户端的调用都会被记录，
+// 供调用方对照 Proxmox 任务日志（UPID）。未放入 CallRecorder 的调用（如后台同步）不受影响。
+
+// maxRecordedCalls 单个 CallRecorder 最多记录的调用数，超出部分只计数
+const maxRecordedCalls = 200
+
+// CallRecord 一次（或连续多次相同的）Proxmox API 调用
+type CallRecord struct {
+	Method     string    `json:"method"`
+	Host       string    `json:"host"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`          // HTTP 状态码，请求未发出时为 0
+	Error      string    `json:"error,omitempty"` // 传输层错误（已脱敏）
+	DurationMs int64     `json:"duration_ms"`
+	UPID       string    `json:"upid,omitempty"` // 调用启动的任务
+	Count      int       `json:"count"`          // 连续相同调用（如等待任务时轮询状态）合并后的次数
+	StartTime  time.Time `json:"start_time"`
+}
+
+// CallRecorder 请求内的 Proxmox API 调用记录，可并发使用
+type CallRecorder struct {
+	mu      sync.Mutex
+	calls   []CallRecord
+	dropped int
+}
+
+func NewCallRecorder() *CallRecorder {
+	return &CallRecorder{}
+}
+
+type callRecorderKey struct{}
+
+// WithCallRecorder 返回带有 CallRecorder 的 context
+func WithCallRecorder(ctx context.Context, recorder *CallRecorder) context.Context {
+	return context.WithValue(ctx, callRecorderKey{}, recorder)
+}
+
+// CallRecorderFromContext 获取 context 中的 CallRecorder，没有时返回 nil
+func CallRecorderFromContext(ctx context.Context) *CallRecorder {
+	recorder, _ := ctx.Value(callRecorderKey{}).(*CallRecorder)
+	return recorder
+}
+
+// Calls 已记录的调用，dropped 为超出上限未记录的调用数
+func (r *CallRecorder) Calls() (calls []CallRecord, dropped int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]CallRecord(nil), r.calls...), r.dropped
+}
+
+// Tasks 调用启动的任务 UPID（按调用顺序）
+func (r *CallRecorder) Tasks() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var upids []string
+	for _, call := range r.calls {
+		if call.UPID != "" {
+			upids = append(upids, call.UPID)
+		}
+	}
+	return upids
+}
+
+func (r *CallRecorder) add(call CallRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n := len(r.calls); n > 0 && call.UPID == "" {
+		last := &r.calls[n-1]
+		if last.Method == call.Method && last.Host == call.Host && last.Path == call.Path &&
+			last.Status == call.Status && last.Error == call.Error && last.UPID == "" {
+			last.Count++
+			last.DurationMs += call.DurationMs
+			return
+		}
+	}
+	if len(r.calls) >= maxRecordedCalls {
+		r.dropped++
+		return
+	}
+	call.Count = 1
+	r.calls = append(r.calls, call)
+}
+
+// callAuditTransport 将调用写入请求 context 中的 CallRecorder
+type callAuditTransport struct {
+	base http.RoundTripper
+}
+
+func (t *callAuditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := CallRecorderFromContext(req.Context())
+	if recorder == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	call := CallRecord{
+		Method:    req.Method,
+		Host:      req.URL.Host,
+		Path:      req.URL.Path,
+		StartTime: time.Now(),
+	}
+	resp, err := t.base.RoundTrip(req)
+	call.DurationMs = time.Since(call.StartTime).Milliseconds()
+	if err != nil {
+		call.Error = RedactText(err.Error())
+		recorder.add(call)
+		return resp, err
+	}
+
+	call.Status = resp.StatusCode
+	if req.Method != http.MethodGet && resp.StatusCode < 300 {
+		call.UPID = peekResponseUPID(resp)
+	}
+	recorder.add(call)
+	return resp, nil
+}
+
+// peekResponseUPID 读取 {"data":"UPID:..."} 形式的响应（启动任务的接口），读取后重新放回供调用方解析
+func peekResponseUPID(resp *http.Response) string {
+	if resp.Body == nil || resp.ContentLength > maxLoggedBodySize {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLoggedBodySize+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	if err != nil || len(data) > maxLoggedBodySize {
+		return ""
+	}
+	var result struct {
+		Data interface{} `json:"data"`
+	}
+	if json.Unmarshal(data, &result) != nil {
+		return ""
+	}
+	if upid, ok := result.Data.(string); ok && strings.HasPrefix(upid, "UPID:") {
+		return upid
+	}
+	return ""
+}
@@ -12,6 +12,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -24,43 +26,81 @@ type ProxmoxClient struct {
 	// 高权限认证（可选）：如果设置了 Ticket 和 CSRFToken，将优先使用 Cookie + CSRF 方式
 	Ticket    string // Proxmox 高权限票据（用于 Cookie: PVEAuthCookie=<ticket>）
 	CSRFToken string // CSRF 防护令牌（用于 Header: CSRFPreventionToken: <token>）
+
+	requestLog bool // 是否记录该客户端的 API 调用日志（见 request_log.go）
 }
 
-func NewProxmoxClient(apiURL string, userId, userToken string) (*ProxmoxClient, error) {
+func NewProxmoxClient(apiURL string, userId, userToken string, opts ...ClientOption) (*ProxmoxClient, error) {
 	baseUrl, err := url.Parse(apiURL)
 	if err != nil {
 		return nil, err
 	}
-	return &ProxmoxClient{
+	c := &ProxmoxClient{
 		baseUrl: baseUrl,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			},
-		},
-		Token: fmt.Sprintf("PVEAPIToken=%s=%s", userId, userToken),
-	}, nil
+		Token:   fmt.Sprintf("PVEAPIToken=%s=%s", userId, userToken),
+	}
+	c.httpClient = newHTTPClient(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
 }
 
 // NewProxmoxClientWithTicket 使用高权限 ticket 和 CSRF token 创建 ProxmoxClient
 // 这种方式使用 Cookie + CSRF 认证，通常具有更高的权限（如 root 账号）
-func NewProxmoxClientWithTicket(apiURL string, ticket, csrfToken string) (*ProxmoxClient, error) {
+func NewProxmoxClientWithTicket(apiURL string, ticket, csrfToken string, opts ...ClientOption) (*ProxmoxClient, error) {
 	baseUrl, err := url.Parse(apiURL)
 	if err != nil {
 		return nil, err
 	}
-	return &ProxmoxClient{
-		baseUrl: baseUrl,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-			},
-		},
+	c := &ProxmoxClient{
+		baseUrl:   baseUrl,
 		Ticket:    ticket,
 		CSRFToken: csrfToken,
-	}, nil
+	}
+	c.httpClient = newHTTPClient(c)
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// DefaultRequestTimeout Proxmox API 请求默认超时时间（文件上传除外）
+const DefaultRequestTimeout = 30 * time.Second
+
+var requestTimeout atomic.Int64
+
+func init() {
+	requestTimeout.Store(int64(DefaultRequestTimeout))
+}
+
+// SetRequestTimeout 设置 Proxmox API 请求超时时间，对之后创建的客户端生效（d <= 0 时恢复默认值）
+func SetRequestTimeout(d time.Duration) {
+	if d <= 0 {
+		d = DefaultRequestTimeout
+	}
+	requestTimeout.Store(int64(d))
+}
+
+// RequestTimeout 返回当前 Proxmox API 请求超时时间
+func RequestTimeout() time.Duration {
+	return time.Duration(requestTimeout.Load())
+}
+
+func newHTTPClient(c *ProxmoxClient) *http.Client {
+	return &http.Client{
+		Timeout: RequestTimeout(),
+		Transport: &tracingTransport{
+			base: &callAuditTransport{
+				base: &loggingTransport{
+					base: &http.Transport{
+						TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+					},
+					client: c,
+				},
+			},
+		},
+	}
 }
 
 func (c *ProxmoxClient) Request(ctx context.Context, req *http.Request, result interface{}) error {
@@ -106,15 +146,15 @@ func (c *ProxmoxClient) Request(ctx context.Context, req *http.Request, result i
 	}
 
 	if result != nil {
+		// 直接保留 data 的原始字节再解析到 result，避免先解析成 interface{} 再序列化一遍
 		var apiResp struct {
-			Data interface{} `json:"data"`
+			Data json.RawMessage `json:"data"`
 		}
 		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
 			return err
 		}
-		if apiResp.Data != nil {
-			data, _ := json.Marshal(apiResp.Data)
-			return json.Unmarshal(data, result)
+		if len(apiResp.Data) > 0 && string(apiResp.Data) != "null" {
+			return json.Unmarshal(apiResp.Data, result)
 		}
 	}
 	return nil
@@ -256,15 +296,20 @@ func (c *ProxmoxClient) QemuVNCProxy(ctx context.Context, nodeName string, vmID
 	return result, nil
 }
 
+// webSocketWriteBufferPool websocket 连接共用的写缓冲区，连接只在写消息期间持有
+var webSocketWriteBufferPool = &sync.Pool{}
+
 func (c *ProxmoxClient) WebSocket(path, params string) (*websocket.Conn, *http.Response, error) {
 	endpoint := fmt.Sprintf("wss://%s/api2/json%s?%s", c.baseUrl.Host, path, params)
-	dialer := websocket.DefaultDialer
+	// 复制默认 Dialer，避免并发建立控制台连接时修改共享的 websocket.DefaultDialer
+	dialer := *websocket.DefaultDialer
 	dialer.HandshakeTimeout = 30 * time.Second
 	dialer.TLSClientConfig = &tls.Config{
 		InsecureSkipVerify: true,
 	}
 	dialer.ReadBufferSize = 8192
 	dialer.WriteBufferSize = 8192
+	dialer.WriteBufferPool = webSocketWriteBufferPool
 
 	requestHeader := http.Header{}
 	// 如果提供了 Ticket 和 CSRFToken，使用 Cookie + CSRF 认证方式
@@ -361,6 +406,61 @@ func (c *ProxmoxClient) GetVMConfig(ctx context.Context, nodeName string, vmID u
 	return config, nil
 }
 
+// GetLXCConfig 获取容器配置
+// GET /api2/json/nodes/{node}/lxc/{vmid}/config
+func (c *ProxmoxClient) GetLXCConfig(ctx context.Context, nodeName string, vmID uint32) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/lxc/%d/config", nodeName, vmID)
+	var config map[string]interface{}
+	if err := c.Get(ctx, path, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// GetVMSnapshots 获取虚拟机快照列表（包含表示当前状态的 "current" 项）
+// GET /api2/json/nodes/{node}/qemu/{vmid}/snapshot
+func (c *ProxmoxClient) GetVMSnapshots(ctx context.Context, nodeName string, vmID uint32) ([]map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/snapshot", nodeName, vmID)
+	var snapshots []map[string]interface{}
+	if err := c.Get(ctx, path, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// GetVMSnapshotConfig 获取虚拟机快照保存的配置
+// GET /api2/json/nodes/{node}/qemu/{vmid}/snapshot/{snapname}/config
+func (c *ProxmoxClient) GetVMSnapshotConfig(ctx context.Context, nodeName string, vmID uint32, snapName string) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/snapshot/%s/config", nodeName, vmID, snapName)
+	var config map[string]interface{}
+	if err := c.Get(ctx, path, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// GetLXCSnapshots 获取容器快照列表（包含表示当前状态的 "current" 项）
+// GET /api2/json/nodes/{node}/lxc/{vmid}/snapshot
+func (c *ProxmoxClient) GetLXCSnapshots(ctx context.Context, nodeName string, vmID uint32) ([]map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/lxc/%d/snapshot", nodeName, vmID)
+	var snapshots []map[string]interface{}
+	if err := c.Get(ctx, path, &snapshots); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// GetLXCSnapshotConfig 获取容器快照保存的配置
+// GET /api2/json/nodes/{node}/lxc/{vmid}/snapshot/{snapname}/config
+func (c *ProxmoxClient) GetLXCSnapshotConfig(ctx context.Context, nodeName string, vmID uint32, snapName string) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/lxc/%d/snapshot/%s/config", nodeName, vmID, snapName)
+	var config map[string]interface{}
+	if err := c.Get(ctx, path, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
 // GetVMStatus 获取虚拟机状态
 func (c *ProxmoxClient) GetVMStatus(ctx context.Context, nodeName string, vmID uint32) (map[string]interface{}, error) {
 	path := fmt.Sprintf("/nodes/%s/qemu/%d/status/current", nodeName, vmID)
@@ -371,6 +471,77 @@ func (c *ProxmoxClient) GetVMStatus(ctx context.Context, nodeName string, vmID u
 	return status, nil
 }
 
+// GetVMAgentNetworkInterfaces 通过 qemu-guest-agent 获取虚拟机网卡及 IP 信息（需虚拟机内运行 guest agent）
+// GET /api2/json/nodes/{node}/qemu/{vmid}/agent/network-get-interfaces
+func (c *ProxmoxClient) GetVMAgentNetworkInterfaces(ctx context.Context, nodeName string, vmID uint32) ([]map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/agent/network-get-interfaces", nodeName, vmID)
+	var result struct {
+		Result []map[string]interface{} `json:"result"`
+	}
+	if err := c.Get(ctx, path, &result); err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+// GetVMAgentOSInfo 通过 qemu-guest-agent 获取虚拟机操作系统信息（id、name、pretty-name、version-id、kernel-release 等）
+// GET /api2/json/nodes/{node}/qemu/{vmid}/agent/get-osinfo
+func (c *ProxmoxClient) GetVMAgentOSInfo(ctx context.Context, nodeName string, vmID uint32) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/agent/get-osinfo", nodeName, vmID)
+	var result struct {
+		Result map[string]interface{} `json:"result"`
+	}
+	if err := c.Get(ctx, path, &result); err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+// AgentPing 检测虚拟机内的 qemu-guest-agent 是否响应，未启用或未运行时返回错误
+// POST /api2/json/nodes/{node}/qemu/{vmid}/agent/ping
+func (c *ProxmoxClient) AgentPing(ctx context.Context, nodeName string, vmID uint32) error {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/agent/ping", nodeName, vmID)
+	return c.PostForm(ctx, path, url.Values{}, nil)
+}
+
+// AgentExec 通过 qemu-guest-agent 在虚拟机内执行命令（不经过 shell），返回进程 PID，结果通过 GetAgentExecStatus 查询
+// POST /api2/json/nodes/{node}/qemu/{vmid}/agent/exec
+func (c *ProxmoxClient) AgentExec(ctx context.Context, nodeName string, vmID uint32, command []string) (int, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/agent/exec", nodeName, vmID)
+	params := url.Values{}
+	for _, arg := range command {
+		params.Add("command", arg)
+	}
+	var result struct {
+		Pid int `json:"pid"`
+	}
+	if err := c.PostForm(ctx, path, params, &result); err != nil {
+		return 0, err
+	}
+	return result.Pid, nil
+}
+
+// GetAgentExecStatus 查询 AgentExec 启动的进程状态（exited、exitcode、out-data、err-data 等）
+// GET /api2/json/nodes/{node}/qemu/{vmid}/agent/exec-status?pid={pid}
+func (c *ProxmoxClient) GetAgentExecStatus(ctx context.Context, nodeName string, vmID uint32, pid int) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/agent/exec-status", nodeName, vmID)
+
+	params := url.Values{}
+	params.Set("pid", strconv.Itoa(pid))
+	endpoint := c.baseUrl.JoinPath("/api2/json", path).String() + "?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var status map[string]interface{}
+	if err := c.Request(ctx, req, &status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
 // StartVM 启动虚拟机
 func (c *ProxmoxClient) StartVM(ctx context.Context, nodeName string, vmID uint32) (string, error) {
 	path := fmt.Sprintf("/nodes/%s/qemu/%d/status/start", nodeName, vmID)
@@ -381,14 +552,51 @@ func (c *ProxmoxClient) StartVM(ctx context.Context, nodeName string, vmID uint3
 	return upid, nil
 }
 
-// StopVM 停止虚拟机
-func (c *ProxmoxClient) StopVM(ctx context.Context, nodeName string, vmID uint32) (string, error) {
+// StopVM 停止虚拟机（直接断电），timeout 为等待停止的最长秒数，<= 0 时使用 Proxmox 默认值
+func (c *ProxmoxClient) StopVM(ctx context.Context, nodeName string, vmID uint32, timeout int) (string, error) {
 	path := fmt.Sprintf("/nodes/%s/qemu/%d/status/stop", nodeName, vmID)
 	params := url.Values{}
-	params.Set("timeout", "30") // 等待最多30秒
+	if timeout > 0 {
+		params.Set("timeout", strconv.Itoa(timeout))
+	}
+	return c.postVMStatus(ctx, path, params)
+}
+
+// ShutdownVM 正常关闭虚拟机（ACPI 或 guest agent），timeout 为等待关机的最长秒数，
+// forceStop 为 true 时超时后强制断电，否则任务失败、虚拟机保持运行
+// POST /api2/json/nodes/{node}/qemu/{vmid}/status/shutdown
+func (c *ProxmoxClient) ShutdownVM(ctx context.Context, nodeName string, vmID uint32, timeout int, forceStop bool) (string, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/status/shutdown", nodeName, vmID)
+	params := url.Values{}
+	if timeout > 0 {
+		params.Set("timeout", strconv.Itoa(timeout))
+	}
+	if forceStop {
+		params.Set("forceStop", "1")
+	}
+	return c.postVMStatus(ctx, path, params)
+}
 
+// SuspendVM 挂起虚拟机。toDisk 为 true 时休眠（内存写入 vmstate 卷后停止虚拟机，启动即恢复），
+// stateStorage 为保存 vmstate 的存储，为空时由 Proxmox 选择；否则只暂停在内存中，通过 ResumeVM 恢复
+// POST /api2/json/nodes/{node}/qemu/{vmid}/status/suspend
+func (c *ProxmoxClient) SuspendVM(ctx context.Context, nodeName string, vmID uint32, toDisk bool, stateStorage string) (string, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/status/suspend", nodeName, vmID)
+	params := url.Values{}
+	if toDisk {
+		params.Set("todisk", "1")
+		if stateStorage != "" {
+			params.Set("statestorage", stateStorage)
+		}
+	}
+	return c.postVMStatus(ctx, path, params)
+}
+
+func (c *ProxmoxClient) postVMStatus(ctx context.Context, path string, params url.Values) (string, error) {
 	endpoint := c.baseUrl.JoinPath("/api2/json", path).String()
-	endpoint += "?" + params.Encode()
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
 	if err != nil {
@@ -427,11 +635,34 @@ func (c *ProxmoxClient) DeleteVM(ctx context.Context, nodeName string, vmID uint
 	return c.Request(ctx, req, nil)
 }
 
-// WaitForTask 等待任务完成（保留占位，当前未使用）
+// WaitForTask 轮询任务状态直到任务结束，exitstatus 非 OK 时返回错误
+// GET /api2/json/nodes/{node}/tasks/{upid}/status
 func (c *ProxmoxClient) WaitForTask(ctx context.Context, nodeName, upid string, timeout time.Duration) error {
-	// 这里可以实现任务状态查询逻辑
-	// 实际使用时可以调用 /api2/json/nodes/{node}/tasks/{upid}/status 来查询
-	return nil
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("wait for task %s: %w", upid, ctx.Err())
+		case <-ticker.C:
+			status, err := c.GetTaskStatus(ctx, nodeName, upid)
+			if err != nil {
+				// 节点繁忙时查询可能偶发失败，继续轮询直到超时
+				continue
+			}
+			if s, _ := status["status"].(string); s != "stopped" {
+				continue
+			}
+			if exitStatus, _ := status["exitstatus"].(string); exitStatus != "OK" {
+				return fmt.Errorf("task %s failed: %s", upid, exitStatus)
+			}
+			return nil
+		}
+	}
 }
 
 // GetClusterTasks 获取集群任务列表
@@ -456,6 +687,36 @@ func (c *ProxmoxClient) GetNodeTasks(ctx context.Context, nodeName string) ([]ma
 	return tasks, nil
 }
 
+// GetNodeJournal 获取节点 systemd journal，since 为 Unix 时间（0 表示不限），lastEntries 为最多返回的行数（0 表示不限）
+// GET /api2/json/nodes/{node}/journal
+func (c *ProxmoxClient) GetNodeJournal(ctx context.Context, nodeName string, since int64, lastEntries int) ([]string, error) {
+	path := fmt.Sprintf("/nodes/%s/journal", nodeName)
+
+	params := url.Values{}
+	if since > 0 {
+		params.Set("since", fmt.Sprintf("%d", since))
+	}
+	if lastEntries > 0 {
+		params.Set("lastentries", fmt.Sprintf("%d", lastEntries))
+	}
+
+	endpoint := c.baseUrl.JoinPath("/api2/json", path).String()
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if err := c.Request(ctx, req, &lines); err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
 // GetTaskLog 获取任务日志
 // GET /api2/json/nodes/{node}/tasks/{upid}/log
 func (c *ProxmoxClient) GetTaskLog(ctx context.Context, nodeName, upid string, start, limit int) ([]map[string]interface{}, error) {
@@ -592,6 +853,17 @@ func (c *ProxmoxClient) GetNodeStatus(ctx context.Context, nodeName string) (map
 	return status, nil
 }
 
+// GetNodePackageVersions 获取节点上 Proxmox 相关软件包版本（pveversion -v）
+// GET /api2/json/nodes/{node}/apt/versions
+func (c *ProxmoxClient) GetNodePackageVersions(ctx context.Context, nodeName string) ([]map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/apt/versions", nodeName)
+	var packages []map[string]interface{}
+	if err := c.Get(ctx, path, &packages); err != nil {
+		return nil, err
+	}
+	return packages, nil
+}
+
 // GetNodeServices 获取节点服务列表
 // GET /api2/json/nodes/{node}/services
 func (c *ProxmoxClient) GetNodeServices(ctx context.Context, nodeName string) ([]map[string]interface{}, error) {
@@ -686,6 +958,30 @@ func (c *ProxmoxClient) SetNodeStatus(ctx context.Context, nodeName, command str
 	return upid, nil
 }
 
+// SetNodeWakeOnLan 设置节点配置中的 Wake-on-LAN MAC 地址（mac 为空时删除）
+// PUT /api2/json/nodes/{node}/config
+func (c *ProxmoxClient) SetNodeWakeOnLan(ctx context.Context, nodeName, mac string) error {
+	path := fmt.Sprintf("/nodes/%s/config", nodeName)
+	params := url.Values{}
+	if mac != "" {
+		params.Set("wakeonlan", mac)
+	} else {
+		params.Set("delete", "wakeonlan")
+	}
+	return c.PutForm(ctx, path, params, nil)
+}
+
+// WakeOnLan 由接收请求的节点向目标节点发送 Wake-on-LAN 魔术包（使用目标节点配置中的 MAC）
+// POST /api2/json/nodes/{node}/wakeonlan，返回发送的 MAC 地址
+func (c *ProxmoxClient) WakeOnLan(ctx context.Context, nodeName string) (string, error) {
+	path := fmt.Sprintf("/nodes/%s/wakeonlan", nodeName)
+	var mac string
+	if err := c.PostForm(ctx, path, url.Values{}, &mac); err != nil {
+		return "", err
+	}
+	return mac, nil
+}
+
 // GetNodeRRDData 获取节点RRD监控数据
 // GET /api2/json/nodes/{node}/rrddata
 // 参数: timeframe (hour|day|week|month|year), cf (AVERAGE|MAX)
@@ -798,6 +1094,19 @@ func (c *ProxmoxClient) UpdateVMCloudInitConfig(ctx context.Context, nodeName st
 	return c.PutForm(ctx, path, params, nil)
 }
 
+// MoveVMDisk 将虚拟机磁盘移动到其他存储（qm move-disk）
+// POST /api2/json/nodes/{node}/qemu/{vmid}/move_disk
+// 参数: disk (磁盘名，如 scsi0), storage (目标存储), delete (删除源卷), format, bwlimit (KiB/s)
+// 返回: UPID (任务ID)
+func (c *ProxmoxClient) MoveVMDisk(ctx context.Context, nodeName string, vmID uint32, params url.Values) (string, error) {
+	path := fmt.Sprintf("/nodes/%s/qemu/%d/move_disk", nodeName, vmID)
+	var upid string
+	if err := c.PostForm(ctx, path, params, &upid); err != nil {
+		return "", err
+	}
+	return upid, nil
+}
+
 // MigrateVM 同集群迁移虚拟机
 // POST /api2/json/nodes/{node}/qemu/{vmid}/migrate
 // 参数: target (目标节点), online (在线迁移), bwlimit (带宽限制), 等
@@ -917,6 +1226,16 @@ func (c *ProxmoxClient) GetClusterStatus(ctx context.Context) ([]map[string]inte
 	return status, nil
 }
 
+// GetClusterOptions 获取数据中心选项（datacenter.cfg），如 keyboard（默认键盘布局）、console 等
+// GET /api2/json/cluster/options
+func (c *ProxmoxClient) GetClusterOptions(ctx context.Context) (map[string]interface{}, error) {
+	var options map[string]interface{}
+	if err := c.Get(ctx, "/cluster/options", &options); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
 // GetClusterResources 获取集群资源
 // GET /api2/json/cluster/resources
 func (c *ProxmoxClient) GetClusterResources(ctx context.Context) ([]map[string]interface{}, error) {
@@ -927,6 +1246,41 @@ func (c *ProxmoxClient) GetClusterResources(ctx context.Context) ([]map[string]i
 	return resources, nil
 }
 
+// GetClusterResourcesByType 按类型获取集群资源（由 Proxmox 端过滤，减少返回数据量）
+// GET /api2/json/cluster/resources?type={vm|storage|node|sdn}
+func (c *ProxmoxClient) GetClusterResourcesByType(ctx context.Context, resourceType string) ([]map[string]interface{}, error) {
+	params := url.Values{}
+	if resourceType != "" {
+		params.Set("type", resourceType)
+	}
+
+	endpoint := c.baseUrl.JoinPath("/api2/json", "/cluster/resources").String()
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []map[string]interface{}
+	if err := c.Request(ctx, req, &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// GetClusterResourcesRaw 获取集群资源的原始 JSON（每个元素未解析），用于增量同步
+// GET /api2/json/cluster/resources
+func (c *ProxmoxClient) GetClusterResourcesRaw(ctx context.Context) ([]json.RawMessage, error) {
+	var resources []json.RawMessage
+	if err := c.Get(ctx, "/cluster/resources", &resources); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
 // GetNodeDisksList 获取节点磁盘列表
 // GET /api2/json/nodes/{node}/disks/list
 func (c *ProxmoxClient) GetNodeDisksList(ctx context.Context, nodeName string, includePartitions bool) ([]map[string]interface{}, error) {
@@ -955,9 +1309,31 @@ func (c *ProxmoxClient) GetNodeDisksList(ctx context.Context, nodeName string, i
 	return disks, nil
 }
 
+// GetNodeDiskSMART 获取磁盘 SMART 信息
+// GET /api2/json/nodes/{node}/disks/smart?disk=/dev/sda
+// 返回 health、type（ata / text）以及 attributes（ata）或 text（NVMe / SAS 的 smartctl 输出）
+func (c *ProxmoxClient) GetNodeDiskSMART(ctx context.Context, nodeName string, disk string) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/disks/smart", nodeName)
+
+	params := url.Values{}
+	params.Set("disk", disk)
+	endpoint := c.baseUrl.JoinPath("/api2/json", path).String() + "?" + params.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var smart map[string]interface{}
+	if err := c.Request(ctx, req, &smart); err != nil {
+		return nil, err
+	}
+	return smart, nil
+}
+
 // GetNodeDisksDirectory 获取节点 Directory 存储
 // GET /api2/json/nodes/{node}/disks/directory
-// Proxmox API 返回的是对象格式，需要转换为数组
+// 不同版本返回对象（名称 -> 属性）或数组，统一转换为带 name 字段的数组（数组元素取挂载路径最后一段作为名称）
 func (c *ProxmoxClient) GetNodeDisksDirectory(ctx context.Context, nodeName string) ([]map[string]interface{}, error) {
 	path := fmt.Sprintf("/nodes/%s/disks/directory", nodeName)
 
@@ -967,58 +1343,7 @@ func (c *ProxmoxClient) GetNodeDisksDirectory(ctx context.Context, nodeName stri
 		return nil, err
 	}
 
-	// 如果返回的是 nil，返回空数组
-	if rawData == nil {
-		return []map[string]interface{}{}, nil
-	}
-
-	// 尝试解析为对象
-	directoriesObj, ok := rawData.(map[string]interface{})
-	if !ok {
-		// 如果不是对象，可能是数组，直接返回
-		if arr, ok := rawData.([]interface{}); ok {
-			result := make([]map[string]interface{}, 0, len(arr))
-			for _, item := range arr {
-				if itemMap, ok := item.(map[string]interface{}); ok {
-					result = append(result, itemMap)
-				}
-			}
-			return result, nil
-		}
-		// 其他类型，返回空数组
-		return []map[string]interface{}{}, nil
-	}
-
-	// 如果返回的是空对象，直接返回空数组
-	if len(directoriesObj) == 0 {
-		return []map[string]interface{}{}, nil
-	}
-
-	// 将对象转换为数组格式
-	directories := make([]map[string]interface{}, 0, len(directoriesObj))
-	for name, data := range directoriesObj {
-		// 处理不同的数据类型
-		var dataMap map[string]interface{}
-		switch v := data.(type) {
-		case map[string]interface{}:
-			dataMap = make(map[string]interface{})
-			// 复制所有字段
-			for k, val := range v {
-				dataMap[k] = val
-			}
-		case []interface{}:
-			// 如果是数组，跳过（不应该出现）
-			continue
-		default:
-			// 如果是其他类型，创建一个新的 map
-			dataMap = make(map[string]interface{})
-			dataMap["value"] = v
-		}
-		// 添加名称字段
-		dataMap["name"] = name
-		directories = append(directories, dataMap)
-	}
-	return directories, nil
+	return namedList(rawData, "path"), nil
 }
 
 // GetNodeDisksLVM 获取节点 LVM 存储
@@ -1051,17 +1376,18 @@ func (c *ProxmoxClient) GetNodeDisksLVM(ctx context.Context, nodeName string) ([
 
 		// 获取节点信息
 		name, _ := node["name"].(string)
-		leaf, _ := node["leaf"].(float64)
+		// 旧版本（6.x）的数值字段可能以字符串返回
+		leaf, _ := jsonNumber(node["leaf"])
 
 		// 如果是叶子节点（逻辑卷），添加到结果中
 		if leaf == 1 {
 			item := make(map[string]interface{})
 			item["name"] = name
 			item["vg_name"] = parentVG
-			if size, ok := node["size"].(float64); ok {
+			if size, ok := jsonNumber(node["size"]); ok {
 				item["size"] = int64(size)
 			}
-			if free, ok := node["free"].(float64); ok {
+			if free, ok := jsonNumber(node["free"]); ok {
 				item["free"] = int64(free)
 			}
 			item["leaf"] = int(leaf)
@@ -1071,13 +1397,13 @@ func (c *ProxmoxClient) GetNodeDisksLVM(ctx context.Context, nodeName string) ([
 			item := make(map[string]interface{})
 			item["name"] = name
 			item["vg_name"] = name
-			if size, ok := node["size"].(float64); ok {
+			if size, ok := jsonNumber(node["size"]); ok {
 				item["size"] = int64(size)
 			}
-			if free, ok := node["free"].(float64); ok {
+			if free, ok := jsonNumber(node["free"]); ok {
 				item["free"] = int64(free)
 			}
-			if lvcount, ok := node["lvcount"].(float64); ok {
+			if lvcount, ok := jsonNumber(node["lvcount"]); ok {
 				item["lvcount"] = int(lvcount)
 			}
 			item["leaf"] = int(leaf)
@@ -1118,7 +1444,7 @@ func (c *ProxmoxClient) GetNodeDisksLVM(ctx context.Context, nodeName string) ([
 
 // GetNodeDisksLVMThin 获取节点 LVM-Thin 存储
 // GET /api2/json/nodes/{node}/disks/lvmthin
-// Proxmox API 返回的是对象格式，需要转换为数组
+// 不同版本返回对象（名称 -> 属性）或数组，统一转换为带 name 字段的数组
 func (c *ProxmoxClient) GetNodeDisksLVMThin(ctx context.Context, nodeName string) ([]map[string]interface{}, error) {
 	path := fmt.Sprintf("/nodes/%s/disks/lvmthin", nodeName)
 
@@ -1128,63 +1454,12 @@ func (c *ProxmoxClient) GetNodeDisksLVMThin(ctx context.Context, nodeName string
 		return nil, err
 	}
 
-	// 如果返回的是 nil，返回空数组
-	if rawData == nil {
-		return []map[string]interface{}{}, nil
-	}
-
-	// 尝试解析为对象
-	lvmthinsObj, ok := rawData.(map[string]interface{})
-	if !ok {
-		// 如果不是对象，可能是数组，直接返回
-		if arr, ok := rawData.([]interface{}); ok {
-			result := make([]map[string]interface{}, 0, len(arr))
-			for _, item := range arr {
-				if itemMap, ok := item.(map[string]interface{}); ok {
-					result = append(result, itemMap)
-				}
-			}
-			return result, nil
-		}
-		// 其他类型，返回空数组
-		return []map[string]interface{}{}, nil
-	}
-
-	// 如果返回的是空对象，直接返回空数组
-	if len(lvmthinsObj) == 0 {
-		return []map[string]interface{}{}, nil
-	}
-
-	// 将对象转换为数组格式
-	lvmthins := make([]map[string]interface{}, 0, len(lvmthinsObj))
-	for name, data := range lvmthinsObj {
-		// 处理不同的数据类型
-		var dataMap map[string]interface{}
-		switch v := data.(type) {
-		case map[string]interface{}:
-			dataMap = make(map[string]interface{})
-			// 复制所有字段
-			for k, val := range v {
-				dataMap[k] = val
-			}
-		case []interface{}:
-			// 如果是数组，跳过（不应该出现）
-			continue
-		default:
-			// 如果是其他类型，创建一个新的 map
-			dataMap = make(map[string]interface{})
-			dataMap["value"] = v
-		}
-		// 添加名称字段
-		dataMap["name"] = name
-		lvmthins = append(lvmthins, dataMap)
-	}
-	return lvmthins, nil
+	return namedList(rawData, "lv"), nil
 }
 
 // GetNodeDisksZFS 获取节点 ZFS 存储
 // GET /api2/json/nodes/{node}/disks/zfs
-// Proxmox API 返回的是对象格式，需要转换为数组
+// 不同版本返回对象（名称 -> 属性）或数组，统一转换为带 name 字段的数组
 func (c *ProxmoxClient) GetNodeDisksZFS(ctx context.Context, nodeName string) ([]map[string]interface{}, error) {
 	path := fmt.Sprintf("/nodes/%s/disks/zfs", nodeName)
 
@@ -1194,58 +1469,71 @@ func (c *ProxmoxClient) GetNodeDisksZFS(ctx context.Context, nodeName string) ([
 		return nil, err
 	}
 
-	// 如果返回的是 nil，返回空数组
-	if rawData == nil {
-		return []map[string]interface{}{}, nil
+	return namedList(rawData), nil
+}
+
+// CreateNodeZFSPool 在节点上创建 ZFS 存储池
+// POST /api2/json/nodes/{node}/disks/zfs
+// 参数: name, raidlevel, devices（逗号分隔的磁盘设备）, ashift, compression, add_storage，返回任务 UPID
+func (c *ProxmoxClient) CreateNodeZFSPool(ctx context.Context, nodeName string, params url.Values) (string, error) {
+	path := fmt.Sprintf("/nodes/%s/disks/zfs", nodeName)
+
+	var upid string
+	if err := c.PostForm(ctx, path, params, &upid); err != nil {
+		return "", err
 	}
+	return upid, nil
+}
 
-	// 尝试解析为对象
-	zfssObj, ok := rawData.(map[string]interface{})
-	if !ok {
-		// 如果不是对象，可能是数组，直接返回
-		if arr, ok := rawData.([]interface{}); ok {
-			result := make([]map[string]interface{}, 0, len(arr))
-			for _, item := range arr {
-				if itemMap, ok := item.(map[string]interface{}); ok {
-					result = append(result, itemMap)
-				}
-			}
-			return result, nil
-		}
-		// 其他类型，返回空数组
-		return []map[string]interface{}{}, nil
+// GetNodeZFSPoolDetail 获取 ZFS 存储池详情（zpool status）
+// GET /api2/json/nodes/{node}/disks/zfs/{name}
+// 返回 state、status、action、scan、errors 以及 children 设备树（含 read/write/cksum 错误计数）
+func (c *ProxmoxClient) GetNodeZFSPoolDetail(ctx context.Context, nodeName, poolName string) (map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/disks/zfs/%s", nodeName, url.PathEscape(poolName))
+
+	var detail map[string]interface{}
+	if err := c.Get(ctx, path, &detail); err != nil {
+		return nil, err
 	}
+	return detail, nil
+}
 
-	// 如果返回的是空对象，直接返回空数组
-	if len(zfssObj) == 0 {
-		return []map[string]interface{}{}, nil
+// ScanNodeZFS 扫描节点上可用作存储的 ZFS 存储池和数据集
+// GET /api2/json/nodes/{node}/scan/zfs
+func (c *ProxmoxClient) ScanNodeZFS(ctx context.Context, nodeName string) ([]map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/scan/zfs", nodeName)
+
+	var datasets []map[string]interface{}
+	if err := c.Get(ctx, path, &datasets); err != nil {
+		return nil, err
+	}
+	return datasets, nil
+}
+
+// CreateNodeLVM 在未使用的磁盘上创建 LVM 卷组
+// POST /api2/json/nodes/{node}/disks/lvm
+// 参数: name（卷组名）, device, add_storage，返回任务 UPID
+func (c *ProxmoxClient) CreateNodeLVM(ctx context.Context, nodeName string, params url.Values) (string, error) {
+	path := fmt.Sprintf("/nodes/%s/disks/lvm", nodeName)
+
+	var upid string
+	if err := c.PostForm(ctx, path, params, &upid); err != nil {
+		return "", err
 	}
+	return upid, nil
+}
 
-	// 将对象转换为数组格式
-	zfss := make([]map[string]interface{}, 0, len(zfssObj))
-	for name, data := range zfssObj {
-		// 处理不同的数据类型
-		var dataMap map[string]interface{}
-		switch v := data.(type) {
-		case map[string]interface{}:
-			dataMap = make(map[string]interface{})
-			// 复制所有字段
-			for k, val := range v {
-				dataMap[k] = val
-			}
-		case []interface{}:
-			// 如果是数组，跳过（不应该出现）
-			continue
-		default:
-			// 如果是其他类型，创建一个新的 map
-			dataMap = make(map[string]interface{})
-			dataMap["value"] = v
-		}
-		// 添加名称字段
-		dataMap["name"] = name
-		zfss = append(zfss, dataMap)
+// CreateNodeLVMThin 在未使用的磁盘上创建 LVM 卷组及同名 thin pool
+// POST /api2/json/nodes/{node}/disks/lvmthin
+// 参数: name（thin pool 名）, device, add_storage，返回任务 UPID
+func (c *ProxmoxClient) CreateNodeLVMThin(ctx context.Context, nodeName string, params url.Values) (string, error) {
+	path := fmt.Sprintf("/nodes/%s/disks/lvmthin", nodeName)
+
+	var upid string
+	if err := c.PostForm(ctx, path, params, &upid); err != nil {
+		return "", err
 	}
-	return zfss, nil
+	return upid, nil
 }
 
 // InitGPTDisk 初始化 GPT 磁盘
@@ -1316,6 +1604,34 @@ func (c *ProxmoxClient) GetStorageStatus(ctx context.Context, nodeName, storage
 	return status, nil
 }
 
+// GetNodeStorages 获取节点可用的存储列表（含共享存储）
+// GET /api2/json/nodes/{node}/storage
+// 可通过 content 过滤支持的内容类型: images,iso 等
+func (c *ProxmoxClient) GetNodeStorages(ctx context.Context, nodeName, content string) ([]map[string]interface{}, error) {
+	path := fmt.Sprintf("/nodes/%s/storage", nodeName)
+
+	params := url.Values{}
+	if content != "" {
+		params.Set("content", content)
+	}
+
+	endpoint := c.baseUrl.JoinPath("/api2/json", path).String()
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []map[string]interface{}
+	if err := c.Request(ctx, req, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // GetStorageRRDData 获取存储 RRD 监控数据
 // GET /api2/json/nodes/{node}/storage/{storage}/rrddata
 // 参数: timeframe (hour|day|week|month|year), cf (AVERAGE|MAX)
@@ -1395,12 +1711,34 @@ func (c *ProxmoxClient) UploadStorageContent(
 	ctx context.Context,
 	nodeName, storage, content, filename string,
 	file multipart.File,
+) (interface{}, error) {
+	// multipart.File 可 Seek，先取得文件大小以便设置 Content-Length
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return c.UploadStorageContentStream(ctx, nodeName, storage, content, filename, file, size, nil)
+}
+
+// UploadStorageContentStream 以流式方式上传文件到存储，不在内存中缓存整个文件
+// size > 0 时设置准确的 Content-Length，否则使用 chunked 传输；
+// progress 不为空时在每次写出数据后回调已上传的字节数
+func (c *ProxmoxClient) UploadStorageContentStream(
+	ctx context.Context,
+	nodeName, storage, content, filename string,
+	file io.Reader,
+	size int64,
+	progress func(uploaded int64),
 ) (interface{}, error) {
 	path := fmt.Sprintf("/nodes/%s/storage/%s/upload", nodeName, storage)
 	endpoint := c.baseUrl.JoinPath("/api2/json", path).String()
 
-	var buf bytes.Buffer
-	writer := multipart.NewWriter(&buf)
+	// 先把文件之前的表单部分写入缓冲区，文件内容直接从 file 流式读取
+	var head bytes.Buffer
+	writer := multipart.NewWriter(&head)
 
 	// 可选的 content 类型
 	if content != "" {
@@ -1410,33 +1748,43 @@ func (c *ProxmoxClient) UploadStorageContent(
 	}
 
 	// Proxmox 要求文件字段名为 "filename"
-	fw, err := writer.CreateFormFile("filename", filename)
-	if err != nil {
-		return nil, err
-	}
-	if _, err := io.Copy(fw, file); err != nil {
+	if _, err := writer.CreateFormFile("filename", filename); err != nil {
 		return nil, err
 	}
+	// 与 multipart.Writer.Close 写出的结束边界一致
+	tail := fmt.Sprintf("\r\n--%s--\r\n", writer.Boundary())
 
-	if err := writer.Close(); err != nil {
-		return nil, err
+	var body io.Reader = file
+	if progress != nil {
+		body = &uploadProgressReader{r: file, progress: progress}
 	}
+	body = io.MultiReader(&head, body, strings.NewReader(tail))
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &buf)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
 	if err != nil {
 		return nil, err
 	}
+	if size > 0 {
+		req.ContentLength = int64(head.Len()) + size + int64(len(tail))
+	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 	req.Header.Set("Authorization", c.Token)
 
 	uploadClient := &http.Client{
 		Timeout: 60 * time.Minute, // 60分钟超时
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		Transport: &tracingTransport{
+			base: &callAuditTransport{
+				base: &loggingTransport{
+					base: &http.Transport{
+						TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+					},
+					client: c,
+				},
+			},
 		},
 	}
 
-	resp, err := uploadClient.Do(req.WithContext(ctx))
+	resp, err := uploadClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -1467,6 +1815,22 @@ func (c *ProxmoxClient) UploadStorageContent(
 	return apiResp.Data, nil
 }
 
+// uploadProgressReader 统计已读取（即已上传）的字节数
+type uploadProgressReader struct {
+	r        io.Reader
+	uploaded int64
+	progress func(uploaded int64)
+}
+
+func (p *uploadProgressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.uploaded += int64(n)
+		p.progress(p.uploaded)
+	}
+	return n, err
+}
+
 // DeleteStorageContent 删除存储内容（镜像 / ISO / OVA / VM 镜像等）
 // DELETE /api2/json/nodes/{node}/storage/{storage}/content/{volume}
 // 参数：volume 需要 URL 编码（例如：/local-dir:iso/ubuntu-22.04-server-amd64.iso）
@@ -1494,6 +1858,97 @@ func (c *ProxmoxClient) DeleteStorageContent(ctx context.Context, nodeName, stor
 	return c.Request(ctx, req, nil)
 }
 
+// CreateStorage 创建集群存储配置（如 type=esxi 的导入源）
+// POST /api2/json/storage
+func (c *ProxmoxClient) CreateStorage(ctx context.Context, params url.Values) error {
+	return c.PostForm(ctx, "/storage", params, nil)
+}
+
+// DeleteStorage 删除集群存储配置（不会删除存储上的数据）
+// DELETE /api2/json/storage/{storage}
+func (c *ProxmoxClient) DeleteStorage(ctx context.Context, storage string) error {
+	return c.Delete(ctx, fmt.Sprintf("/storage/%s", storage))
+}
+
+// ImportMetadata 可导入客户机（ESXi 虚拟机 / OVA / OVF）的元数据
+type ImportMetadata struct {
+	Type       string                   `json:"type"`
+	Source     string                   `json:"source"`
+	CreateArgs map[string]interface{}   `json:"create-args"` // 可直接用于创建虚拟机的参数（name、memory、cores、ostype 等）
+	Disks      map[string]ImportDisk    `json:"disks"`       // key 为磁盘槽位，如 scsi0 / sata0
+	Net        map[string]ImportNet     `json:"net"`         // key 为网卡槽位，如 net0
+	Warnings   []map[string]interface{} `json:"warnings"`
+}
+
+// ImportDisk 待导入的磁盘
+type ImportDisk struct {
+	Volid  string `json:"volid"`
+	Size   int64  `json:"size"`
+	Format string `json:"format,omitempty"`
+}
+
+// ImportNet 源虚拟机网卡
+type ImportNet struct {
+	Model   string `json:"model"`
+	MACAddr string `json:"macaddr"`
+}
+
+// GetImportMetadata 读取可导入客户机的元数据
+// GET /api2/json/nodes/{node}/storage/{storage}/import-metadata?volume=...
+// 需要 Proxmox VE 8.2 及以上版本
+func (c *ProxmoxClient) GetImportMetadata(ctx context.Context, nodeName, storage, volume string) (*ImportMetadata, error) {
+	path := fmt.Sprintf("/nodes/%s/storage/%s/import-metadata", nodeName, storage)
+	endpoint := c.baseUrl.JoinPath("/api2/json", path).String() + "?" + url.Values{"volume": {volume}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata ImportMetadata
+	if err := c.Request(ctx, req, &metadata); err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
+
+// DownloadURLRequest 由节点直接从 URL 下载文件到存储的参数
+type DownloadURLRequest struct {
+	URL               string // 下载地址（http / https）
+	Content           string // 内容类型：iso / vztmpl / import
+	Filename          string // 保存的文件名
+	Checksum          string // 可选，下载完成后由节点校验
+	ChecksumAlgorithm string // md5 / sha1 / sha224 / sha256 / sha384 / sha512
+	VerifyCertificate bool
+}
+
+// DownloadURL 由节点从 URL 下载文件到存储，返回任务 UPID
+// POST /api2/json/nodes/{node}/storage/{storage}/download-url
+// content=import 需要 Proxmox VE 8.4 及以上版本
+func (c *ProxmoxClient) DownloadURL(ctx context.Context, nodeName, storage string, req *DownloadURLRequest) (string, error) {
+	path := fmt.Sprintf("/nodes/%s/storage/%s/download-url", nodeName, storage)
+
+	params := url.Values{}
+	params.Set("url", req.URL)
+	params.Set("content", req.Content)
+	params.Set("filename", req.Filename)
+	if req.Checksum != "" {
+		params.Set("checksum", req.Checksum)
+		params.Set("checksum-algorithm", req.ChecksumAlgorithm)
+	}
+	if req.VerifyCertificate {
+		params.Set("verify-certificates", "1")
+	} else {
+		params.Set("verify-certificates", "0")
+	}
+
+	var upid string
+	if err := c.PostForm(ctx, path, params, &upid); err != nil {
+		return "", err
+	}
+	return upid, nil
+}
+
 // AccessTicketResult 封装 /access/ticket 返回的数据
 type AccessTicketResult struct {
 	Username            string `json:"username"`
@@ -1589,8 +2044,9 @@ func (c *ProxmoxClient) NodeTermProxy(ctx context.Context, nodeName string) (map
 
 // NodeVncShell 获取节点 VNC Shell 信息（用于图形界面控制台）
 // POST /api2/json/nodes/{node}/vncshell
+// width、height 为终端像素尺寸，0 表示使用 Proxmox 默认值
 // 返回字段通常包含：port、ticket、user、cert 等
-func (c *ProxmoxClient) NodeVncShell(ctx context.Context, nodeName string, websocket, generatePassword bool) (map[string]interface{}, error) {
+func (c *ProxmoxClient) NodeVncShell(ctx context.Context, nodeName string, websocket, generatePassword bool, width, height int) (map[string]interface{}, error) {
 	path := fmt.Sprintf("/nodes/%s/vncshell", nodeName)
 
 	params := url.Values{}
@@ -1600,6 +2056,12 @@ func (c *ProxmoxClient) NodeVncShell(ctx context.Context, nodeName string, webso
 	if generatePassword {
 		params.Set("generate-password", "1")
 	}
+	if width > 0 {
+		params.Set("width", strconv.Itoa(width))
+	}
+	if height > 0 {
+		params.Set("height", strconv.Itoa(height))
+	}
 
 	var result map[string]interface{}
 	if err := c.PostForm(ctx, path, params, &result); err != nil {
@@ -0,0 +1,113 @@
+package proxmox
+
+import (
+	"regexp"
+	"strings"
+)
+
+// diskKeyPattern 匹配 Proxmox 虚拟机配置中的磁盘设备键（scsi0、virtio1、sata2、ide0 等）
+var diskKeyPattern = regexp.MustCompile(`^(scsi|virtio|sata|ide)\d+$`)
+
+// netKeyPattern 匹配 Proxmox 虚拟机配置中的网卡设备键（net0、net1 等）
+var netKeyPattern = regexp.MustCompile(`^net\d+$`)
+
+// IsDiskKey 判断配置键是否为磁盘设备
+func IsDiskKey(key string) bool {
+	return diskKeyPattern.MatchString(key)
+}
+
+// IsNetKey 判断配置键是否为网卡设备
+func IsNetKey(key string) bool {
+	return netKeyPattern.MatchString(key)
+}
+
+// DeviceOption 设备配置项（保持原始顺序，便于回写时不打乱 Proxmox 的配置）
+type DeviceOption struct {
+	Key   string
+	Value string
+}
+
+// DeviceConfig 解析后的设备配置字符串
+// 例如 "local-lvm:vm-100-disk-0,size=32G,iothread=1" 解析为：
+// Head = "local-lvm:vm-100-disk-0"，Options = [{size 32G} {iothread 1}]
+// 网卡 "virtio=BC:24:11:00:00:01,bridge=vmbr0" 的首段同样包含 "="，也会被当作 Options 处理
+type DeviceConfig struct {
+	Head    string
+	Options []DeviceOption
+}
+
+// ParseDeviceConfig 解析设备配置字符串
+func ParseDeviceConfig(value string) *DeviceConfig {
+	cfg := &DeviceConfig{}
+	for i, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if i == 0 && len(kv) == 1 {
+			cfg.Head = part
+			continue
+		}
+		if len(kv) == 1 {
+			cfg.Options = append(cfg.Options, DeviceOption{Key: kv[0]})
+			continue
+		}
+		cfg.Options = append(cfg.Options, DeviceOption{Key: kv[0], Value: kv[1]})
+	}
+	return cfg
+}
+
+// Get 获取配置项的值
+func (c *DeviceConfig) Get(key string) (string, bool) {
+	for _, opt := range c.Options {
+		if opt.Key == key {
+			return opt.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set 设置配置项，已存在则原位替换，否则追加到末尾
+func (c *DeviceConfig) Set(key, value string) {
+	for i, opt := range c.Options {
+		if opt.Key == key {
+			c.Options[i].Value = value
+			return
+		}
+	}
+	c.Options = append(c.Options, DeviceOption{Key: key, Value: value})
+}
+
+// Del 删除配置项
+func (c *DeviceConfig) Del(key string) {
+	opts := c.Options[:0]
+	for _, opt := range c.Options {
+		if opt.Key != key {
+			opts = append(opts, opt)
+		}
+	}
+	c.Options = opts
+}
+
+// IsCDROM 判断磁盘是否为光驱（media=cdrom），光驱不参与限速、迁移等磁盘操作
+func (c *DeviceConfig) IsCDROM() bool {
+	media, _ := c.Get("media")
+	return media == "cdrom"
+}
+
+// String 将设备配置还原为 Proxmox 配置字符串
+func (c *DeviceConfig) String() string {
+	parts := make([]string, 0, len(c.Options)+1)
+	if c.Head != "" {
+		parts = append(parts, c.Head)
+	}
+	for _, opt := range c.Options {
+		if opt.Value == "" {
+			parts = append(parts, opt.Key)
+			continue
+		}
+		parts = append(parts, opt.Key+"="+opt.Value)
+	}
+	return strings.Join(parts, ",")
+}
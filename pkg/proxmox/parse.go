@@ -0,0 +1,80 @@
+package proxmox
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// 不同 PVE 版本返回格式不一致的接口的解析辅助函数（见 test/server/proxmox 下各版本的录制数据）：
+//   - disks/directory、disks/lvmthin、disks/zfs 旧版本返回以名称为键的对象，新版本返回数组，
+//     数组元素的名称字段因接口而异（lvmthin 为 lv，directory 只有挂载路径）
+//   - 旧版本的部分数值字段（如 disks/lvm 的 size、free、leaf）以字符串返回
+
+// namedList 将对象（名称 -> 属性）或数组统一转换为带 name 字段的列表；
+// 数组元素没有 name 时依次使用 nameKeys 中的字段，"path" 取挂载路径的最后一段
+func namedList(raw interface{}, nameKeys ...string) []map[string]interface{} {
+	switch data := raw.(type) {
+	case []interface{}:
+		result := make([]map[string]interface{}, 0, len(data))
+		for _, item := range data {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if name, _ := itemMap["name"].(string); name == "" {
+				for _, key := range nameKeys {
+					value, _ := itemMap[key].(string)
+					if key == "path" && value != "" {
+						value = path.Base(value)
+					}
+					if value != "" {
+						itemMap["name"] = value
+						break
+					}
+				}
+			}
+			result = append(result, itemMap)
+		}
+		return result
+	case map[string]interface{}:
+		result := make([]map[string]interface{}, 0, len(data))
+		for name, value := range data {
+			var item map[string]interface{}
+			switch v := value.(type) {
+			case map[string]interface{}:
+				item = make(map[string]interface{}, len(v)+1)
+				for k, val := range v {
+					item[k] = val
+				}
+			case []interface{}:
+				// 不应出现，跳过
+				continue
+			default:
+				item = map[string]interface{}{"value": v}
+			}
+			item["name"] = name
+			result = append(result, item)
+		}
+		return result
+	}
+	// null 或其他类型
+	return []map[string]interface{}{}
+}
+
+// jsonNumber 读取数值字段，兼容以字符串返回的数字
+func jsonNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
@@ -0,0 +1,123 @@
+package proxmoxtest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+)
+
+// 节点控制台：POST nodes/{node}/termproxy、vncshell 返回 port / ticket，
+// 之后通过 GET nodes/{node}/vncwebsocket?port=...&vncticket=... 连接（每个 ticket 只能连接一次）。
+// termproxy 控制台按 Proxmox 协议先校验 "用户:票据\n" 并回复 "OK"，之后：
+//   - 输入消息 "0:字节数:数据" 校验字节数后原样回显数据
+//   - 调整大小 "1:列数:行数:" 与心跳 "2" 只记录
+// vncshell 控制台不做认证，原样回显收到的消息
+
+type console struct {
+	node   string
+	kind   string // termproxy / vncshell
+	user   string
+	ticket string
+}
+
+// TermMessages 返回 termproxy 控制台认证之后收到的消息（输入、调整大小、心跳）
+func (s *Server) TermMessages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.termMessages...)
+}
+
+// openConsole 调用时持有锁
+func (s *Server) openConsole(node *Node, kind string) map[string]interface{} {
+	s.consoleSeq++
+	port := 5900 + s.consoleSeq
+	c := &console{
+		node:   node.Name,
+		kind:   kind,
+		user:   "root@pam",
+		ticket: fmt.Sprintf("PVEVNC:%s:%d", kind, s.consoleSeq),
+	}
+	s.consoles[port] = c
+	return map[string]interface{}{
+		"port":   port,
+		"ticket": c.ticket,
+		"user":   c.user,
+		"upid":   fmt.Sprintf("UPID:%s:%08X:%s::%s:", node.Name, s.consoleSeq, kind, c.user),
+	}
+}
+
+func (s *Server) serveConsole(w http.ResponseWriter, r *http.Request, path string, params url.Values) {
+	seg := strings.Split(strings.Trim(path, "/"), "/")
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path, Params: params})
+	if s.token != "" && r.Header.Get("Authorization") != s.token {
+		s.mu.Unlock()
+		writeError(w, http.StatusUnauthorized, "authentication failure")
+		return
+	}
+	port, _ := strconv.Atoi(params.Get("port"))
+	c := s.consoles[port]
+	if len(seg) != 3 || seg[0] != "nodes" || seg[2] != "vncwebsocket" || c == nil || c.node != seg[1] || c.ticket != params.Get("vncticket") {
+		s.mu.Unlock()
+		writeError(w, http.StatusForbidden, "permission denied - invalid vnc ticket")
+		return
+	}
+	delete(s.consoles, port)
+	s.mu.Unlock()
+
+	upgrader := websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	if c.kind != "termproxy" {
+		for {
+			mt, msg, err := conn.ReadMessage()
+			if err != nil || conn.WriteMessage(mt, msg) != nil {
+				return
+			}
+		}
+	}
+
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		return
+	}
+	if string(msg) != c.user+":"+c.ticket+"\n" {
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "authentication failure"))
+		return
+	}
+	if err := conn.WriteMessage(websocket.BinaryMessage, []byte("OK")); err != nil {
+		return
+	}
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.termMessages = append(s.termMessages, string(msg))
+		s.mu.Unlock()
+		if !bytes.HasPrefix(msg, []byte("0:")) {
+			continue
+		}
+		parts := bytes.SplitN(msg[2:], []byte(":"), 2)
+		if len(parts) != 2 {
+			return
+		}
+		if n, err := strconv.Atoi(string(parts[0])); err != nil || n != len(parts[1]) {
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseProtocolError, "invalid input length"))
+			return
+		}
+		if err := conn.WriteMessage(websocket.BinaryMessage, parts[1]); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package proxmoxtest
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 模拟在 PveSphere 之外（Proxmox 网页、qm 命令行）进行的操作：已结束的任务和节点 journal
+
+type journalEntry struct {
+	time time.Time
+	line string
+}
+
+// AddTask 登记一个已结束的任务（如其他用户在 Proxmox 中创建、删除虚拟机），返回 UPID；
+// 任务只出现在任务列表中，虚拟机的变化由调用方通过 AddVM / RemoveVM 模拟
+func (s *Server) AddTask(nodeName, taskType string, vmid uint32, user, exitStatus string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taskSeq++
+	now := time.Now()
+	id := ""
+	if vmid > 0 {
+		id = strconv.FormatUint(uint64(vmid), 10)
+	}
+	upid := fmt.Sprintf("UPID:%s:%08X:%08X:%08X:%s:%s:%s:",
+		nodeName, 1000+s.taskSeq, s.taskSeq, now.Unix(), taskType, id, user)
+	s.tasks[upid] = &task{
+		upid:       upid,
+		node:       nodeName,
+		taskType:   taskType,
+		id:         id,
+		user:       user,
+		start:      now,
+		end:        now,
+		exitStatus: exitStatus,
+		done:       true,
+	}
+	s.taskOrder = append(s.taskOrder, upid)
+	return upid
+}
+
+// RemoveVM 删除虚拟机（不产生任务）
+func (s *Server) RemoveVM(vmid uint32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.vms, vmid)
+}
+
+// SetVMConfig 直接修改虚拟机配置（如在 Proxmox 界面中编辑备注），value 为空时删除该项
+func (s *Server) SetVMConfig(vmid uint32, key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vm := s.vms[vmid]
+	if vm == nil {
+		return
+	}
+	if value == "" {
+		delete(vm.Config, key)
+		return
+	}
+	vm.Config[key] = value
+}
+
+// AddJournal 向节点 journal 追加一条日志，message 为进程名之后的内容，
+// 如 "pvedaemon[1234]: <root@pam> update VM 100: -memory 4096"
+func (s *Server) AddJournal(nodeName, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.journal[nodeName] = append(s.journal[nodeName], journalEntry{
+		time: now,
+		line: fmt.Sprintf("%s %s %s", now.Format(time.Stamp), nodeName, message),
+	})
+}
+
+// journalLines GET /nodes/{node}/journal，支持 since（Unix 时间）和 lastentries
+func (s *Server) journalLines(nodeName string, params url.Values) []string {
+	since, _ := strconv.ParseInt(params.Get("since"), 10, 64)
+	lines := make([]string, 0, len(s.journal[nodeName]))
+	for _, entry := range s.journal[nodeName] {
+		if since > 0 && entry.time.Unix() < since {
+			continue
+		}
+		lines = append(lines, entry.line)
+	}
+	if last, _ := strconv.Atoi(params.Get("lastentries")); last > 0 && len(lines) > last {
+		lines = lines[len(lines)-last:]
+	}
+	return lines
+}
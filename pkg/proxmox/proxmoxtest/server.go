@@ -0,0 +1,1221 @@
+// Package proxmoxtest 提供模拟 Proxmox VE API 的测试服务器，实现 proxmox 客户端在创建、删除、
+// 迁移虚拟机和同步模板时用到的接口子集，并支持注入故障（认证失败、5xx、慢任务、任务失败），
+// 供服务层集成测试使用。
+//
+// 虚拟机、存储、网桥等状态保存在内存中；启动任务的接口立即返回 UPID，任务到期后（首次查询时）
+// 才生效（如克隆出新虚拟机、迁移到目标节点），运行期间虚拟机持有对应的 lock。
+package proxmoxtest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Storage 节点上的存储
+type Storage struct {
+	Name    string
+	Type    string // 默认 dir
+	Content string // 逗号分隔，如 images,iso
+	Total   int64  // 字节，默认 1 TiB
+	Used    int64
+	Shared  bool
+	Volumes []string // 卷 ID，如 local:iso/debian.iso
+}
+
+// Node 模拟节点
+type Node struct {
+	Name     string
+	MaxMem   int64 // 字节，默认 64 GiB
+	Mem      int64
+	MaxCPU   int     // 默认 16
+	CPU      float64 // CPU 使用率（0-1），默认 0.05
+	Storages []Storage
+	Bridges  []string
+	// Fingerprint pve-ssl.pem 证书的 SHA256 指纹，为空时根据节点名生成
+	Fingerprint string
+}
+
+// VM 模拟虚拟机，Config 为 Proxmox 配置项（name、memory、cores 等）
+type VM struct {
+	VMID     uint32
+	Node     string
+	Name     string
+	Status   string // running / stopped
+	Template bool
+	Lock     string
+	Config   map[string]string
+	Stats    map[string]float64 // 运行中时附加到 status/current 和 rrddata 数据点的指标（cpu、mem、netin 等）
+	Agent    *GuestAgent        // 不为空且虚拟机运行中时响应 guest agent 接口
+	// Snapshots 快照名到快照保存的配置的映射
+	Snapshots map[string]map[string]string
+}
+
+// GuestAgent 模拟的 qemu-guest-agent
+type GuestAgent struct {
+	OSInfo map[string]interface{} // get-osinfo 的结果（id、pretty-name、kernel-release 等）
+	Exec   map[string]string      // 命令（参数以空格拼接）到输出的映射，未登记的命令退出码为 127
+	// Interfaces network-get-interfaces 的结果（name、hardware-address、ip-addresses）
+	Interfaces []map[string]interface{}
+}
+
+// Request 服务器收到的请求
+type Request struct {
+	Method string
+	Path   string // 去掉 /api2/json 或 /api2/extjs 前缀后的路径
+	Params url.Values
+}
+
+type fault struct {
+	method    string
+	prefix    string
+	status    int
+	remaining int // <0 表示不限次数
+}
+
+type task struct {
+	upid       string
+	node       string
+	taskType   string
+	id         string
+	user       string
+	start      time.Time
+	end        time.Time
+	exitStatus string
+	done       bool
+	lockVMID   uint32
+	apply      func() // 任务成功结束时生效的变更，调用时持有锁
+	onFail     func() // 任务失败时的清理（如删除未创建完成的虚拟机）
+}
+
+// Server 模拟的 Proxmox VE API 服务器
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	token         string
+	nodes         map[string]*Node
+	vms           map[uint32]*VM
+	tasks         map[string]*task
+	taskOrder     []string
+	nextVMID      uint32
+	taskSeq       int
+	taskDuration  time.Duration
+	typeDurations map[string]time.Duration
+	taskFailures  map[string]string
+	faults        []*fault
+	requests      []Request
+	execSeq       int
+	certSeq       int
+	execResults   map[int]map[string]interface{}
+	options       map[string]interface{}
+	consoleSeq    int
+	consoles      map[int]*console
+	termMessages  []string
+	taskUser      string // 通过 API 启动的任务记录的用户，RequireToken 后为 token 的用户
+	journal       map[string][]journalEntry
+}
+
+// NewServer 启动模拟服务器（HTTPS，客户端默认跳过证书校验），使用完毕后调用 Close
+func NewServer() *Server {
+	s := &Server{
+		nodes:         make(map[string]*Node),
+		vms:           make(map[uint32]*VM),
+		tasks:         make(map[string]*task),
+		nextVMID:      100,
+		typeDurations: make(map[string]time.Duration),
+		taskFailures:  make(map[string]string),
+		execResults:   make(map[int]map[string]interface{}),
+		options:       make(map[string]interface{}),
+		consoles:      make(map[int]*console),
+		taskUser:      "root@pam!proxmoxtest",
+		journal:       make(map[string][]journalEntry),
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddNode 添加节点，未设置的容量使用默认值
+func (s *Server) AddNode(node Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if node.MaxMem == 0 {
+		node.MaxMem = 64 << 30
+	}
+	if node.MaxCPU == 0 {
+		node.MaxCPU = 16
+	}
+	if node.CPU == 0 {
+		node.CPU = 0.05
+	}
+	if node.Fingerprint == "" {
+		node.Fingerprint = nodeFingerprint(node.Name, 1)
+	}
+	for i := range node.Storages {
+		if node.Storages[i].Type == "" {
+			node.Storages[i].Type = "dir"
+		}
+		if node.Storages[i].Total == 0 {
+			node.Storages[i].Total = 1 << 40
+		}
+	}
+	s.nodes[node.Name] = &node
+}
+
+// AddVM 添加虚拟机（或模板），Status 为空时为 stopped
+func (s *Server) AddVM(vm VM) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if vm.Status == "" {
+		vm.Status = "stopped"
+	}
+	if vm.Config == nil {
+		vm.Config = make(map[string]string)
+	}
+	if vm.Name != "" {
+		vm.Config["name"] = vm.Name
+	}
+	s.vms[vm.VMID] = &vm
+	if vm.VMID >= s.nextVMID {
+		s.nextVMID = vm.VMID + 1
+	}
+}
+
+// VM 返回虚拟机当前状态的副本，会先结算已到期的任务
+func (s *Server) VM(vmid uint32) (VM, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advanceLocked()
+	vm, ok := s.vms[vmid]
+	if !ok {
+		return VM{}, false
+	}
+	copied := *vm
+	copied.Config = make(map[string]string, len(vm.Config))
+	for k, v := range vm.Config {
+		copied.Config[k] = v
+	}
+	return copied, true
+}
+
+// AddSnapshot 为虚拟机添加快照，config 为快照保存的配置
+func (s *Server) AddSnapshot(vmid uint32, name string, config map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if vm, ok := s.vms[vmid]; ok {
+		if vm.Snapshots == nil {
+			vm.Snapshots = make(map[string]map[string]string)
+		}
+		vm.Snapshots[name] = config
+	}
+}
+
+// SetGuestAgent 设置虚拟机的 guest agent，为 nil 时模拟 agent 停止响应
+func (s *Server) SetGuestAgent(vmid uint32, agent *GuestAgent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if vm, ok := s.vms[vmid]; ok {
+		vm.Agent = agent
+	}
+}
+
+// RenewCertificate 模拟节点更换证书，返回新的 pve-ssl.pem 指纹
+func (s *Server) RenewCertificate(nodeName string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	node := s.nodes[nodeName]
+	if node == nil {
+		return ""
+	}
+	s.certSeq++
+	node.Fingerprint = nodeFingerprint(nodeName, s.certSeq+1)
+	return node.Fingerprint
+}
+
+// RequireToken 要求请求携带指定的 API Token，否则返回 401（为空时不校验）
+func (s *Server) RequireToken(userID, token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if userID == "" {
+		s.token = ""
+		return
+	}
+	s.token = fmt.Sprintf("PVEAPIToken=%s=%s", userID, token)
+	s.taskUser = userID
+}
+
+// SetClusterOption 设置数据中心选项（GET /cluster/options），如 keyboard
+func (s *Server) SetClusterOption(key string, value interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.options[key] = value
+}
+
+// FailRequests 让接下来 count 个匹配的请求返回 status（count < 0 时一直失败）；
+// method 为空时匹配所有方法，pathPrefix 为去掉 /api2/json 后的路径前缀
+func (s *Server) FailRequests(method, pathPrefix string, status, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault{method: method, prefix: pathPrefix, status: status, remaining: count})
+}
+
+// ClearFaults 清除 FailRequests 注入的故障
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// SetTaskDuration 设置任务默认的运行时长（默认 0，首次查询即结束）
+func (s *Server) SetTaskDuration(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taskDuration = d
+}
+
+// SetTaskDurationFor 设置某类任务（qmcreate、qmclone、qmigrate 等）的运行时长
+func (s *Server) SetTaskDurationFor(taskType string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.typeDurations[taskType] = d
+}
+
+// FailTasks 让之后启动的某类任务以 exitStatus 结束（不产生变更），exitStatus 为空时取消
+func (s *Server) FailTasks(taskType, exitStatus string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if exitStatus == "" {
+		delete(s.taskFailures, taskType)
+		return
+	}
+	s.taskFailures[taskType] = exitStatus
+}
+
+// Requests 返回已收到的请求
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// CountRequests 统计匹配方法和路径前缀的请求数
+func (s *Server) CountRequests(method, pathPrefix string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.requests {
+		if (method == "" || r.Method == method) && strings.HasPrefix(r.Path, pathPrefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	for _, prefix := range []string{"/api2/json", "/api2/extjs"} {
+		if strings.HasPrefix(path, prefix+"/") {
+			path = strings.TrimPrefix(path, prefix)
+			break
+		}
+	}
+	params, err := requestParams(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// 控制台 websocket 连接持续时间较长，单独处理，不在整个连接期间持有锁
+	if websocket.IsWebSocketUpgrade(r) {
+		s.serveConsole(w, r, path, params)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path, Params: params})
+
+	if s.token != "" && r.Header.Get("Authorization") != s.token {
+		writeError(w, http.StatusUnauthorized, "authentication failure")
+		return
+	}
+	for _, f := range s.faults {
+		if f.remaining == 0 || (f.method != "" && f.method != r.Method) || !strings.HasPrefix(path, f.prefix) {
+			continue
+		}
+		if f.remaining > 0 {
+			f.remaining--
+		}
+		writeError(w, f.status, fmt.Sprintf("injected failure for %s %s", r.Method, path))
+		return
+	}
+
+	s.advanceLocked()
+	status, data := s.route(r.Method, strings.Split(strings.Trim(path, "/"), "/"), params)
+	if status >= 400 {
+		msg, _ := data.(string)
+		writeError(w, status, msg)
+		return
+	}
+	writeJSON(w, status, map[string]interface{}{"data": data})
+}
+
+// route 分发请求，返回状态码和 data（出错时 data 为错误信息）
+func (s *Server) route(method string, seg []string, params url.Values) (int, interface{}) {
+	switch {
+	case method == http.MethodGet && match(seg, "version"):
+		return http.StatusOK, map[string]interface{}{"version": "8.2.4", "release": "8.2", "repoid": "proxmoxtest"}
+	case method == http.MethodGet && match(seg, "cluster", "resources"):
+		return http.StatusOK, s.clusterResources(params.Get("type"))
+	case method == http.MethodGet && match(seg, "cluster", "nextid"):
+		for s.vms[s.nextVMID] != nil {
+			s.nextVMID++
+		}
+		return http.StatusOK, strconv.FormatUint(uint64(s.nextVMID), 10)
+	case method == http.MethodGet && match(seg, "cluster", "tasks"):
+		return http.StatusOK, s.taskList("")
+	case method == http.MethodGet && match(seg, "cluster", "options"):
+		options := make(map[string]interface{}, len(s.options))
+		for key, value := range s.options {
+			options[key] = value
+		}
+		return http.StatusOK, options
+	case method == http.MethodGet && match(seg, "cluster", "status"):
+		list := []map[string]interface{}{{"type": "cluster", "id": "cluster", "name": "proxmoxtest", "nodes": len(s.nodes), "quorate": 1}}
+		for i, name := range s.nodeNames() {
+			list = append(list, map[string]interface{}{"type": "node", "id": "node/" + name, "name": name, "nodeid": i + 1, "online": 1})
+		}
+		return http.StatusOK, list
+	case len(seg) >= 2 && seg[0] == "nodes":
+		node := s.nodes[seg[1]]
+		if node == nil {
+			return http.StatusInternalServerError, fmt.Sprintf("hostname lookup '%s' failed - no such node", seg[1])
+		}
+		return s.routeNode(method, node, seg[2:], params)
+	}
+	return http.StatusNotImplemented, fmt.Sprintf("proxmoxtest: %s /%s not implemented", method, strings.Join(seg, "/"))
+}
+
+func (s *Server) routeNode(method string, node *Node, seg []string, params url.Values) (int, interface{}) {
+	switch {
+	case method == http.MethodGet && match(seg, "status"):
+		return http.StatusOK, map[string]interface{}{
+			"uptime": 86400,
+			"cpu":    node.CPU,
+			"memory": map[string]interface{}{"total": node.MaxMem, "used": node.Mem, "free": node.MaxMem - node.Mem},
+			"cpuinfo": map[string]interface{}{
+				"cpus": node.MaxCPU, "sockets": 1, "model": "QEMU Virtual CPU", "flags": "sse sse2 ssse3 sse4_1 sse4_2 avx avx2",
+			},
+			"pveversion": "pve-manager/8.2.4/proxmoxtest",
+			"kversion":   "Linux 6.8.8-2-pve",
+		}
+	case method == http.MethodGet && match(seg, "certificates", "info"):
+		return http.StatusOK, []map[string]interface{}{
+			{"filename": "pve-root-ca.pem", "fingerprint": nodeFingerprint("root-ca", 1), "subject": "/CN=Proxmox Virtual Environment"},
+			{"filename": "pve-ssl.pem", "fingerprint": node.Fingerprint, "subject": "/CN=" + node.Name,
+				"notafter": time.Now().AddDate(2, 0, 0).Unix()},
+		}
+	case method == http.MethodGet && match(seg, "network"):
+		list := make([]map[string]interface{}, 0, len(node.Bridges))
+		for _, bridge := range node.Bridges {
+			list = append(list, map[string]interface{}{"iface": bridge, "type": "bridge", "active": 1, "autostart": 1})
+		}
+		return http.StatusOK, list
+	case method == http.MethodGet && match(seg, "storage"):
+		content := params.Get("content")
+		list := make([]map[string]interface{}, 0, len(node.Storages))
+		for _, st := range node.Storages {
+			if content != "" && !hasContent(st.Content, content) {
+				continue
+			}
+			list = append(list, storageItem(st))
+		}
+		return http.StatusOK, list
+	case method == http.MethodGet && match(seg, "storage", "*", "status"):
+		if st := findStorage(node, seg[1]); st != nil {
+			return http.StatusOK, storageItem(*st)
+		}
+	case method == http.MethodGet && match(seg, "storage", "*", "content"):
+		st := findStorage(node, seg[1])
+		if st == nil {
+			return http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", seg[1])
+		}
+		content := params.Get("content")
+		list := make([]map[string]interface{}, 0, len(st.Volumes))
+		for _, volid := range st.Volumes {
+			volContent := "images"
+			if _, rest, ok := strings.Cut(volid, ":"); ok {
+				if kind, _, ok := strings.Cut(rest, "/"); ok && kind != "" && !strings.HasPrefix(kind, "vm-") {
+					volContent = kind
+				}
+			}
+			if content != "" && content != volContent {
+				continue
+			}
+			item := map[string]interface{}{"volid": volid, "content": volContent, "format": "raw", "size": 1 << 30}
+			if vmid := volumeOwner(volid); vmid != 0 {
+				item["vmid"] = vmid
+			}
+			list = append(list, item)
+		}
+		return http.StatusOK, list
+	case method == http.MethodDelete && len(seg) >= 4 && seg[0] == "storage" && seg[2] == "content":
+		st := findStorage(node, seg[1])
+		if st == nil {
+			return http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", seg[1])
+		}
+		// 卷 ID 中的 / 经过转义，解码后的路径会被拆成多段
+		volid := strings.Join(seg[3:], "/")
+		i := slices.Index(st.Volumes, volid)
+		if i < 0 {
+			return http.StatusInternalServerError, fmt.Sprintf("volume '%s' does not exist", volid)
+		}
+		st.Volumes = slices.Delete(st.Volumes, i, i+1)
+		return http.StatusOK, nil
+	case method == http.MethodPost && match(seg, "storage", "*", "upload"):
+		return s.uploadContent(node, seg[1], params)
+	case method == http.MethodGet && match(seg, "tasks", "*", "status"):
+		t := s.tasks[seg[1]]
+		if t == nil || t.node != node.Name {
+			return http.StatusInternalServerError, fmt.Sprintf("no such task '%s'", seg[1])
+		}
+		return http.StatusOK, taskStatus(t)
+	case method == http.MethodGet && match(seg, "tasks"):
+		return http.StatusOK, s.taskList(node.Name)
+	case method == http.MethodGet && match(seg, "journal"):
+		return http.StatusOK, s.journalLines(node.Name, params)
+	case method == http.MethodPost && match(seg, "qemu"):
+		return s.createVM(node, params)
+	case method == http.MethodPost && match(seg, "vzdump"):
+		return s.backupVM(node, params)
+	case method == http.MethodPost && (match(seg, "termproxy") || match(seg, "vncshell")):
+		return http.StatusOK, s.openConsole(node, seg[0])
+	case len(seg) >= 2 && seg[0] == "qemu":
+		vmid, err := strconv.ParseUint(seg[1], 10, 32)
+		if err != nil {
+			return http.StatusBadRequest, fmt.Sprintf("invalid vmid '%s'", seg[1])
+		}
+		vm := s.vms[uint32(vmid)]
+		if vm == nil || vm.Node != node.Name {
+			return http.StatusInternalServerError, fmt.Sprintf("Configuration file 'nodes/%s/qemu-server/%d.conf' does not exist", node.Name, vmid)
+		}
+		return s.routeVM(method, node, vm, seg[2:], params)
+	}
+	return http.StatusNotImplemented, fmt.Sprintf("proxmoxtest: %s /nodes/%s/%s not implemented", method, node.Name, strings.Join(seg, "/"))
+}
+
+func (s *Server) routeVM(method string, node *Node, vm *VM, seg []string, params url.Values) (int, interface{}) {
+	switch {
+	case method == http.MethodGet && match(seg, "config"):
+		return http.StatusOK, vmConfig(vm)
+	case method == http.MethodGet && match(seg, "snapshot"):
+		names := make([]string, 0, len(vm.Snapshots))
+		for name := range vm.Snapshots {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		list := make([]map[string]interface{}, 0, len(names)+1)
+		for _, name := range names {
+			list = append(list, map[string]interface{}{"name": name})
+		}
+		list = append(list, map[string]interface{}{"name": "current", "description": "You are here!"})
+		return http.StatusOK, list
+	case method == http.MethodGet && match(seg, "snapshot", "*", "config"):
+		config, ok := vm.Snapshots[seg[1]]
+		if !ok {
+			return http.StatusInternalServerError, fmt.Sprintf("snapshot '%s' does not exist", seg[1])
+		}
+		return http.StatusOK, vmConfig(&VM{Config: config})
+	case (method == http.MethodPut || method == http.MethodPost) && match(seg, "config"):
+		if vm.Lock != "" {
+			return http.StatusInternalServerError, fmt.Sprintf("VM is locked (%s)", vm.Lock)
+		}
+		if digest := params.Get("digest"); digest != "" && digest != configDigest(vm) {
+			return http.StatusInternalServerError, "detected modified configuration - file changed by other user? Try again."
+		}
+		for key := range params {
+			if key == "digest" {
+				continue
+			}
+			if key == "delete" {
+				for _, k := range strings.Split(params.Get(key), ",") {
+					delete(vm.Config, strings.TrimSpace(k))
+				}
+				continue
+			}
+			vm.Config[key] = params.Get(key)
+		}
+		if name := params.Get("name"); name != "" {
+			vm.Name = name
+		}
+		return http.StatusOK, nil
+	case method == http.MethodGet && match(seg, "status", "current"):
+		status := map[string]interface{}{
+			"vmid":      vm.VMID,
+			"name":      vm.Name,
+			"status":    vm.Status,
+			"qmpstatus": vm.Status,
+			"maxmem":    configInt(vm, "memory", 2048) << 20,
+			"cpus":      configInt(vm, "cores", 1),
+		}
+		if vm.Lock != "" {
+			status["lock"] = vm.Lock
+		}
+		if vm.Template {
+			status["template"] = 1
+		}
+		if vm.Status == "running" {
+			for key, value := range vm.Stats {
+				status[key] = value
+			}
+		}
+		return http.StatusOK, status
+	case method == http.MethodGet && match(seg, "rrddata"):
+		return http.StatusOK, rrdData(vm)
+	case len(seg) >= 2 && seg[0] == "agent":
+		return s.routeAgent(method, vm, seg[1], params)
+	case method == http.MethodPost && match(seg, "status", "suspend"):
+		return s.suspendVM(node, vm, params)
+	case method == http.MethodPost && match(seg, "status", "*"):
+		return s.changeStatus(node, vm, seg[1])
+	case method == http.MethodDelete && len(seg) == 0:
+		if vm.Lock != "" {
+			return http.StatusInternalServerError, fmt.Sprintf("VM is locked (%s)", vm.Lock)
+		}
+		if vm.Status == "running" {
+			return http.StatusInternalServerError, fmt.Sprintf("VM %d is running - destroy failed", vm.VMID)
+		}
+		vmid := vm.VMID
+		return http.StatusOK, s.startTask(node.Name, "qmdestroy", vm, func() { delete(s.vms, vmid) })
+	case method == http.MethodPost && match(seg, "clone"):
+		return s.cloneVM(node, vm, params)
+	case method == http.MethodPost && match(seg, "migrate"):
+		target := params.Get("target")
+		if s.nodes[target] == nil {
+			return http.StatusBadRequest, fmt.Sprintf("no such node '%s'", target)
+		}
+		if target == node.Name {
+			return http.StatusBadRequest, fmt.Sprintf("target is local node '%s'", target)
+		}
+		if vm.Lock != "" {
+			return http.StatusInternalServerError, fmt.Sprintf("VM is locked (%s)", vm.Lock)
+		}
+		if vm.Status == "running" && params.Get("online") != "1" {
+			return http.StatusInternalServerError, "can't migrate running VM without --online"
+		}
+		vm.Lock = "migrate"
+		return http.StatusOK, s.startTask(node.Name, "qmigrate", vm, func() { vm.Node = target })
+	case method == http.MethodPost && match(seg, "remote_migrate"):
+		if params.Get("target-endpoint") == "" {
+			return http.StatusBadRequest, "missing target-endpoint"
+		}
+		if vm.Lock != "" {
+			return http.StatusInternalServerError, fmt.Sprintf("VM is locked (%s)", vm.Lock)
+		}
+		return http.StatusOK, s.startTask(node.Name, "qmigrate", vm, func() {})
+	case method == http.MethodPost && match(seg, "template"):
+		if vm.Status == "running" {
+			return http.StatusInternalServerError, "you can't convert a running VM to a template"
+		}
+		vm.Template = true
+		vm.Config["template"] = "1"
+		return http.StatusOK, s.startTask(node.Name, "qmtemplate", vm, nil)
+	case method == http.MethodPost && match(seg, "move_disk"):
+		disk, storage := params.Get("disk"), params.Get("storage")
+		if _, ok := vm.Config[disk]; !ok {
+			return http.StatusBadRequest, fmt.Sprintf("disk '%s' does not exist", disk)
+		}
+		return http.StatusOK, s.startTask(node.Name, "qmmove", vm, func() {
+			if _, rest, ok := strings.Cut(vm.Config[disk], ":"); ok {
+				vm.Config[disk] = storage + ":" + rest
+			}
+		})
+	}
+	return http.StatusNotImplemented, fmt.Sprintf("proxmoxtest: %s /nodes/%s/qemu/%d/%s not implemented", method, node.Name, vm.VMID, strings.Join(seg, "/"))
+}
+
+func (s *Server) createVM(node *Node, params url.Values) (int, interface{}) {
+	vmid, err := strconv.ParseUint(params.Get("vmid"), 10, 32)
+	if err != nil || vmid == 0 {
+		return http.StatusBadRequest, "vmid: property is missing"
+	}
+	if s.vms[uint32(vmid)] != nil {
+		return http.StatusInternalServerError, fmt.Sprintf("VM %d already exists", vmid)
+	}
+	config := make(map[string]string)
+	for key := range params {
+		if key != "vmid" && key != "start" {
+			config[key] = params.Get(key)
+		}
+	}
+	// 磁盘参数形如 storage:size，创建后变为卷 ID
+	for key, value := range config {
+		if !isDiskKey(key) || strings.Contains(value, "media=cdrom") {
+			continue
+		}
+		if storage, rest, ok := strings.Cut(value, ":"); ok {
+			size, opts, _ := strings.Cut(rest, ",")
+			if findStorage(node, storage) == nil {
+				return http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", storage)
+			}
+			config[key] = fmt.Sprintf("%s:vm-%d-%s,size=%sG", storage, vmid, key, size)
+			if opts != "" {
+				config[key] += "," + opts
+			}
+		}
+	}
+	vm := &VM{VMID: uint32(vmid), Node: node.Name, Name: config["name"], Status: "stopped", Config: config, Lock: "create"}
+	s.vms[vm.VMID] = vm
+	upid := s.startTask(node.Name, "qmcreate", vm, nil)
+	// 创建失败时 Proxmox 会删除未创建完成的虚拟机
+	s.tasks[upid].onFail = func() { delete(s.vms, vm.VMID) }
+	return http.StatusOK, upid
+}
+
+func (s *Server) cloneVM(node *Node, source *VM, params url.Values) (int, interface{}) {
+	newID, err := strconv.ParseUint(params.Get("newid"), 10, 32)
+	if err != nil || newID == 0 {
+		return http.StatusBadRequest, "newid: property is missing"
+	}
+	if s.vms[uint32(newID)] != nil {
+		return http.StatusInternalServerError, fmt.Sprintf("VM %d already exists", newID)
+	}
+	if source.Lock != "" {
+		return http.StatusInternalServerError, fmt.Sprintf("VM is locked (%s)", source.Lock)
+	}
+	targetNode := node.Name
+	if target := params.Get("target"); target != "" {
+		if s.nodes[target] == nil {
+			return http.StatusBadRequest, fmt.Sprintf("no such node '%s'", target)
+		}
+		targetNode = target
+	}
+	config := make(map[string]string, len(source.Config))
+	for key, value := range source.Config {
+		if key == "template" {
+			continue
+		}
+		if isDiskKey(key) && !strings.Contains(value, "media=cdrom") {
+			if storage, rest, ok := strings.Cut(value, ":"); ok {
+				if st := params.Get("storage"); st != "" {
+					storage = st
+				}
+				_, opts, _ := strings.Cut(rest, ",")
+				value = fmt.Sprintf("%s:vm-%d-%s", storage, newID, key)
+				if opts != "" {
+					value += "," + opts
+				}
+			}
+		}
+		config[key] = value
+	}
+	if name := params.Get("name"); name != "" {
+		config["name"] = name
+	}
+	if desc := params.Get("description"); desc != "" {
+		config["description"] = desc
+	}
+	clone := &VM{VMID: uint32(newID), Node: targetNode, Name: config["name"], Status: "stopped", Config: config, Lock: "clone"}
+	s.vms[clone.VMID] = clone
+	upid := s.startTask(node.Name, "qmclone", source, nil)
+	t := s.tasks[upid]
+	t.lockVMID = clone.VMID
+	id := clone.VMID
+	t.onFail = func() { delete(s.vms, id) }
+	return http.StatusOK, upid
+}
+
+// backupVM 模拟 vzdump：任务成功后在目标存储（默认 local）上生成备份卷
+func (s *Server) backupVM(node *Node, params url.Values) (int, interface{}) {
+	vmid, _ := strconv.ParseUint(params.Get("vmid"), 10, 32)
+	vm := s.vms[uint32(vmid)]
+	if vm == nil || vm.Node != node.Name {
+		return http.StatusInternalServerError, fmt.Sprintf("unable to find VM %d", vmid)
+	}
+	if vm.Lock != "" {
+		return http.StatusInternalServerError, fmt.Sprintf("VM is locked (%s)", vm.Lock)
+	}
+	storage := params.Get("storage")
+	if storage == "" {
+		storage = "local"
+	}
+	st := findStorage(node, storage)
+	if st == nil {
+		return http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", storage)
+	}
+	ext := map[string]string{"zstd": ".zst", "lzo": ".lzo", "1": ".lzo", "gzip": ".gz"}[params.Get("compress")]
+	volid := fmt.Sprintf("%s:backup/vzdump-qemu-%d-%s.vma%s", storage, vm.VMID, time.Now().Format("2006_01_02-15_04_05"), ext)
+	vm.Lock = "backup"
+	return http.StatusOK, s.startTask(node.Name, "vzdump", vm, func() { st.Volumes = append(st.Volumes, volid) })
+}
+
+func (s *Server) changeStatus(node *Node, vm *VM, action string) (int, interface{}) {
+	var next string
+	switch action {
+	case "start", "resume":
+		if vm.Template {
+			return http.StatusInternalServerError, "you can't start a vm if it's a template"
+		}
+		next = "running"
+	case "stop", "shutdown":
+		next = "stopped"
+	case "reboot", "reset":
+		next = "running"
+	default:
+		return http.StatusNotImplemented, fmt.Sprintf("proxmoxtest: status/%s not implemented", action)
+	}
+	if vm.Lock == "suspended" && action == "start" {
+		// 从休眠恢复：启动时加载 vmstate，完成后删除
+		return http.StatusOK, s.startTask(node.Name, "qmstart", vm, func() {
+			vm.Status, vm.Lock = next, ""
+			delete(vm.Config, "vmstate")
+		})
+	}
+	if vm.Lock != "" {
+		return http.StatusInternalServerError, fmt.Sprintf("VM is locked (%s)", vm.Lock)
+	}
+	return http.StatusOK, s.startTask(node.Name, "qm"+action, vm, func() { vm.Status = next })
+}
+
+// suspendVM 挂起虚拟机：todisk=1 时休眠，任务期间 lock 为 suspending，完成后虚拟机停止、lock 为 suspended，
+// 并在配置中记录 vmstate 卷；否则只暂停（status 为 paused）
+func (s *Server) suspendVM(node *Node, vm *VM, params url.Values) (int, interface{}) {
+	if vm.Status != "running" {
+		return http.StatusInternalServerError, fmt.Sprintf("VM %d not running", vm.VMID)
+	}
+	if vm.Lock != "" {
+		return http.StatusInternalServerError, fmt.Sprintf("VM is locked (%s)", vm.Lock)
+	}
+	if params.Get("todisk") != "1" {
+		return http.StatusOK, s.startTask(node.Name, "qmpause", vm, func() { vm.Status = "paused" })
+	}
+	storage := params.Get("statestorage")
+	if storage == "" {
+		storage = "local-lvm"
+	}
+	st := findStorage(node, storage)
+	if st == nil {
+		return http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", storage)
+	}
+	volid := fmt.Sprintf("%s:vm-%d-state-suspend-%s", storage, vm.VMID, time.Now().Format("2006-01-02"))
+	vm.Lock = "suspending"
+	return http.StatusOK, s.startTask(node.Name, "qmsuspend", vm, func() {
+		vm.Status, vm.Lock = "stopped", "suspended"
+		if vm.Config == nil {
+			vm.Config = map[string]string{}
+		}
+		vm.Config["vmstate"] = volid
+	})
+}
+
+// startTask 登记任务并返回 UPID，vm 在任务运行期间保持 lock（创建、迁移等由调用方设置）
+func (s *Server) startTask(nodeName, taskType string, vm *VM, apply func()) string {
+	s.taskSeq++
+	now := time.Now()
+	duration := s.taskDuration
+	if d, ok := s.typeDurations[taskType]; ok {
+		duration = d
+	}
+	id := ""
+	if vm != nil {
+		id = strconv.FormatUint(uint64(vm.VMID), 10)
+	}
+	upid := fmt.Sprintf("UPID:%s:%08X:%08X:%08X:%s:%s:%s:",
+		nodeName, 1000+s.taskSeq, s.taskSeq, now.Unix(), taskType, id, s.taskUser)
+	exitStatus := "OK"
+	if failure, ok := s.taskFailures[taskType]; ok {
+		exitStatus = failure
+	}
+	t := &task{
+		upid:       upid,
+		node:       nodeName,
+		taskType:   taskType,
+		id:         id,
+		user:       s.taskUser,
+		start:      now,
+		end:        now.Add(duration),
+		exitStatus: exitStatus,
+		apply:      apply,
+	}
+	if vm != nil && vm.Lock != "" {
+		t.lockVMID = vm.VMID
+	}
+	s.tasks[upid] = t
+	s.taskOrder = append(s.taskOrder, upid)
+	return upid
+}
+
+// advanceLocked 结束已到期的任务：成功时应用变更，并释放虚拟机 lock
+func (s *Server) advanceLocked() {
+	now := time.Now()
+	for _, upid := range s.taskOrder {
+		t := s.tasks[upid]
+		if t.done || now.Before(t.end) {
+			continue
+		}
+		t.done = true
+		if vm := s.vms[t.lockVMID]; vm != nil && t.lockVMID != 0 {
+			vm.Lock = ""
+		}
+		if t.exitStatus == "OK" {
+			if t.apply != nil {
+				t.apply()
+			}
+		} else if t.onFail != nil {
+			t.onFail()
+		}
+	}
+}
+
+func (s *Server) clusterResources(resourceType string) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0)
+	if resourceType == "" || resourceType == "node" {
+		for _, name := range s.nodeNames() {
+			node := s.nodes[name]
+			list = append(list, map[string]interface{}{
+				"id": "node/" + name, "type": "node", "node": name, "status": "online",
+				"maxmem": node.MaxMem, "mem": node.Mem, "maxcpu": node.MaxCPU, "cpu": node.CPU,
+			})
+		}
+	}
+	if resourceType == "" || resourceType == "vm" {
+		vmids := make([]int, 0, len(s.vms))
+		for vmid := range s.vms {
+			vmids = append(vmids, int(vmid))
+		}
+		sort.Ints(vmids)
+		for _, vmid := range vmids {
+			vm := s.vms[uint32(vmid)]
+			template := 0
+			if vm.Template {
+				template = 1
+			}
+			item := map[string]interface{}{
+				"id": fmt.Sprintf("qemu/%d", vmid), "type": "qemu", "vmid": vmid, "node": vm.Node, "name": vm.Name,
+				"status": vm.Status, "template": template, "maxmem": configInt(vm, "memory", 2048) << 20, "maxcpu": configInt(vm, "cores", 1),
+			}
+			if tags := vm.Config["tags"]; tags != "" {
+				item["tags"] = tags
+			}
+			if vm.Lock != "" {
+				item["lock"] = vm.Lock
+			}
+			list = append(list, item)
+		}
+	}
+	if resourceType == "" || resourceType == "storage" {
+		for _, name := range s.nodeNames() {
+			for _, st := range s.nodes[name].Storages {
+				item := storageItem(st)
+				item["id"] = fmt.Sprintf("storage/%s/%s", name, st.Name)
+				item["type"] = "storage"
+				item["node"] = name
+				item["plugintype"] = st.Type
+				item["disk"], item["maxdisk"] = st.Used, st.Total
+				list = append(list, item)
+			}
+		}
+	}
+	return list
+}
+
+func (s *Server) taskList(nodeName string) []map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(s.taskOrder))
+	for i := len(s.taskOrder) - 1; i >= 0; i-- {
+		t := s.tasks[s.taskOrder[i]]
+		if nodeName != "" && t.node != nodeName {
+			continue
+		}
+		item := map[string]interface{}{
+			"upid": t.upid, "node": t.node, "type": t.taskType, "id": t.id, "user": t.user, "starttime": t.start.Unix(),
+		}
+		if t.done {
+			item["endtime"] = t.end.Unix()
+			item["status"] = t.exitStatus
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+func (s *Server) nodeNames() []string {
+	names := make([]string, 0, len(s.nodes))
+	for name := range s.nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nodeFingerprint 生成稳定的 SHA256 指纹（冒号分隔的 32 个十六进制字节）
+func nodeFingerprint(name string, generation int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", name, generation)))
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+func taskStatus(t *task) map[string]interface{} {
+	status := map[string]interface{}{
+		"upid": t.upid, "node": t.node, "type": t.taskType, "id": t.id, "user": t.user,
+		"starttime": t.start.Unix(), "status": "running",
+	}
+	if t.done {
+		status["status"] = "stopped"
+		status["exitstatus"] = t.exitStatus
+		return status
+	}
+	if total := t.end.Sub(t.start); total > 0 {
+		status["progress"] = float64(time.Since(t.start)) / float64(total)
+	}
+	return status
+}
+
+// routeAgent 模拟 guest agent 接口，exec 同步执行，结果通过 exec-status 查询
+func (s *Server) routeAgent(method string, vm *VM, command string, params url.Values) (int, interface{}) {
+	if vm.Agent == nil || vm.Status != "running" {
+		return http.StatusInternalServerError, "QEMU guest agent is not running"
+	}
+	switch {
+	case method == http.MethodPost && command == "ping":
+		return http.StatusOK, nil
+	case method == http.MethodGet && command == "get-osinfo":
+		return http.StatusOK, map[string]interface{}{"result": vm.Agent.OSInfo}
+	case method == http.MethodGet && command == "network-get-interfaces":
+		return http.StatusOK, map[string]interface{}{"result": vm.Agent.Interfaces}
+	case method == http.MethodPost && command == "exec":
+		result := map[string]interface{}{"exited": 1, "exitcode": 0}
+		if out, ok := vm.Agent.Exec[strings.Join(params["command"], " ")]; ok {
+			result["out-data"] = out
+		} else {
+			result["exitcode"] = 127
+			result["err-data"] = "command not found"
+		}
+		s.execSeq++
+		s.execResults[s.execSeq] = result
+		return http.StatusOK, map[string]interface{}{"pid": s.execSeq}
+	case method == http.MethodGet && command == "exec-status":
+		pid, _ := strconv.Atoi(params.Get("pid"))
+		result, ok := s.execResults[pid]
+		if !ok {
+			return http.StatusInternalServerError, fmt.Sprintf("PID %s does not exist", params.Get("pid"))
+		}
+		return http.StatusOK, result
+	}
+	return http.StatusNotImplemented, fmt.Sprintf("proxmoxtest: %s agent/%s not implemented", method, command)
+}
+
+// rrdData 返回最近 3 分钟每分钟一个数据点，运行中的虚拟机附带 Stats
+func rrdData(vm *VM) []map[string]interface{} {
+	now := time.Now().Unix() / 60 * 60
+	points := make([]map[string]interface{}, 0, 3)
+	for i := int64(2); i >= 0; i-- {
+		point := map[string]interface{}{
+			"time":   now - i*60,
+			"maxmem": configInt(vm, "memory", 2048) << 20,
+			"maxcpu": configInt(vm, "cores", 1),
+		}
+		if vm.Status == "running" {
+			for key, value := range vm.Stats {
+				point[key] = value
+			}
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+func vmConfig(vm *VM) map[string]interface{} {
+	config := make(map[string]interface{}, len(vm.Config)+2)
+	for key, value := range vm.Config {
+		if n, err := strconv.Atoi(value); err == nil && !isDiskKey(key) && !strings.HasPrefix(key, "net") && key != "description" {
+			config[key] = n
+			continue
+		}
+		config[key] = value
+	}
+	config["digest"] = configDigest(vm)
+	if vm.Lock != "" {
+		config["lock"] = vm.Lock
+	}
+	if vm.Template {
+		config["template"] = 1
+	}
+	return config
+}
+
+// volumeOwner 从 vm-<vmid>-disk-N / base-<vmid>-disk-N 形式的卷名解析所属 VMID，无法解析时返回 0
+func volumeOwner(volid string) uint32 {
+	_, name, _ := strings.Cut(volid, ":")
+	name = name[strings.LastIndex(name, "/")+1:]
+	for _, prefix := range []string{"vm-", "base-"} {
+		if rest, ok := strings.CutPrefix(name, prefix); ok {
+			id, _, _ := strings.Cut(rest, "-")
+			if vmid, err := strconv.ParseUint(id, 10, 32); err == nil {
+				return uint32(vmid)
+			}
+		}
+	}
+	return 0
+}
+
+// configDigest 配置摘要，配置内容变化时改变
+func configDigest(vm *VM) string {
+	keys := make([]string, 0, len(vm.Config))
+	for key := range vm.Config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s: %s\n", key, vm.Config[key])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+func configInt(vm *VM, key string, def int64) int64 {
+	if n, err := strconv.ParseInt(vm.Config[key], 10, 64); err == nil {
+		return n
+	}
+	return def
+}
+
+// uploadContent 模拟上传 ISO / 容器模板到存储，文件在 imgcopy 任务结束后出现在存储内容中
+func (s *Server) uploadContent(node *Node, storage string, params url.Values) (int, interface{}) {
+	st := findStorage(node, storage)
+	if st == nil {
+		return http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not exist", storage)
+	}
+	content, filename := params.Get("content"), params.Get("filename")
+	if !hasContent(st.Content, content) {
+		return http.StatusInternalServerError, fmt.Sprintf("storage '%s' does not support content-type '%s'", storage, content)
+	}
+	volid := fmt.Sprintf("%s:%s/%s", storage, content, filename)
+	if slices.Contains(st.Volumes, volid) {
+		return http.StatusInternalServerError, fmt.Sprintf("refusing to override existing file '%s'", filename)
+	}
+	size, _ := strconv.ParseInt(params.Get("size"), 10, 64)
+	return http.StatusOK, s.startTask(node.Name, "imgcopy", nil, func() {
+		if st := findStorage(node, storage); st != nil {
+			st.Volumes = append(st.Volumes, volid)
+			st.Used += size
+		}
+	})
+}
+
+func storageItem(st Storage) map[string]interface{} {
+	shared := 0
+	if st.Shared {
+		shared = 1
+	}
+	return map[string]interface{}{
+		"storage": st.Name, "type": st.Type, "content": st.Content, "enabled": 1, "active": 1, "shared": shared,
+		"total": st.Total, "used": st.Used, "avail": st.Total - st.Used,
+	}
+}
+
+func findStorage(node *Node, name string) *Storage {
+	for i := range node.Storages {
+		if node.Storages[i].Name == name {
+			return &node.Storages[i]
+		}
+	}
+	return nil
+}
+
+func hasContent(contents, content string) bool {
+	for _, item := range strings.Split(contents, ",") {
+		if strings.TrimSpace(item) == content {
+			return true
+		}
+	}
+	return false
+}
+
+func isDiskKey(key string) bool {
+	for _, prefix := range []string{"scsi", "virtio", "sata", "ide", "efidisk", "tpmstate"} {
+		if strings.HasPrefix(key, prefix) {
+			if _, err := strconv.Atoi(strings.TrimPrefix(key, prefix)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// match 判断路径段是否与模式一致，"*" 匹配任意单个段
+func match(seg []string, pattern ...string) bool {
+	if len(seg) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != seg[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// requestParams 合并查询参数、表单和 JSON 请求体中的参数
+func requestParams(r *http.Request) (url.Values, error) {
+	params := url.Values{}
+	for key, values := range r.URL.Query() {
+		params[key] = values
+	}
+	if r.Body == nil || r.Method == http.MethodGet {
+		return params, nil
+	}
+	// 上传文件（storage/{storage}/upload）：记录表单字段，文件只记录名称和大小
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(32 << 20); err != nil {
+			return nil, err
+		}
+		for key, values := range r.MultipartForm.Value {
+			params[key] = values
+		}
+		if files := r.MultipartForm.File["filename"]; len(files) > 0 {
+			params.Set("filename", files[0].Filename)
+			params.Set("size", strconv.FormatInt(files[0].Size, 10))
+		}
+		return params, nil
+	}
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("invalid json body: %w", err)
+		}
+		for key, value := range body {
+			params.Set(key, fmt.Sprint(value))
+		}
+		return params, nil
+	}
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	for key, values := range r.PostForm {
+		params[key] = values
+	}
+	return params, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"data": nil, "message": message + "\n"})
+}
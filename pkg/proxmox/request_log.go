@@ -0,0 +1,233 @@
+package proxmox
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Proxmox API 调用日志（debug 级别）
+// 全局开关通过 ConfigureRequestLog 设置，单个集群可通过 WithRequestLog 选项开启；
+// 日志中的 token、ticket、password 等敏感信息会被自动脱敏。
+
+const (
+	redactedValue = "REDACTED"
+	// 记录请求体/错误响应体的最大长度
+	maxLoggedBodySize = 4096
+)
+
+var requestLog struct {
+	sync.RWMutex
+	logger *zap.Logger
+	logAll bool
+}
+
+// ConfigureRequestLog 设置 API 调用日志的 logger，logAll 为 true 时记录所有集群的调用
+func ConfigureRequestLog(logger *zap.Logger, logAll bool) {
+	requestLog.Lock()
+	defer requestLog.Unlock()
+	requestLog.logger = logger
+	requestLog.logAll = logAll
+}
+
+// SetRequestLogAll 运行时开关所有集群的 API 调用日志
+func SetRequestLogAll(logAll bool) {
+	requestLog.Lock()
+	defer requestLog.Unlock()
+	requestLog.logAll = logAll
+}
+
+// RequestLogAll 返回是否记录所有集群的 API 调用
+func RequestLogAll() bool {
+	requestLog.RLock()
+	defer requestLog.RUnlock()
+	return requestLog.logAll
+}
+
+func requestLogger(clientEnabled bool) *zap.Logger {
+	requestLog.RLock()
+	defer requestLog.RUnlock()
+	if requestLog.logger == nil || (!requestLog.logAll && !clientEnabled) {
+		return nil
+	}
+	return requestLog.logger
+}
+
+// ClientOption ProxmoxClient 可选配置
+type ClientOption func(*ProxmoxClient)
+
+// WithRequestLog 为单个客户端（集群）开启 API 调用日志
+func WithRequestLog(enabled bool) ClientOption {
+	return func(c *ProxmoxClient) {
+		c.requestLog = enabled
+	}
+}
+
+// loggingTransport 记录每次请求的方法、路径、状态码和耗时
+type loggingTransport struct {
+	base   http.RoundTripper
+	client *ProxmoxClient
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	logger := requestLogger(t.client.requestLog)
+	if logger == nil || !logger.Core().Enabled(zap.DebugLevel) {
+		return t.base.RoundTrip(req)
+	}
+
+	fields := []zap.Field{
+		zap.String("method", req.Method),
+		zap.String("host", req.URL.Host),
+		zap.String("path", req.URL.Path),
+	}
+	if req.URL.RawQuery != "" {
+		fields = append(fields, zap.String("query", redactQuery(req.URL.RawQuery)))
+	}
+	if body := snapshotRequestBody(req); body != "" {
+		fields = append(fields, zap.String("request_body", body))
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	fields = append(fields, zap.Duration("duration", time.Since(start)))
+	if err != nil {
+		logger.Debug("proxmox api call failed", append(fields, zap.String("error", RedactText(err.Error())))...)
+		return resp, err
+	}
+
+	fields = append(fields, zap.Int("status", resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		// 错误响应记录响应体，读取后重新放回供调用方解析
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if readErr == nil {
+			fields = append(fields, zap.String("response_body", RedactText(truncateLogBody(string(data)))))
+		}
+	}
+	logger.Debug("proxmox api call", fields...)
+	return resp, nil
+}
+
+// snapshotRequestBody 获取请求体副本（不消费原始 body），文件上传等大请求体不记录
+func snapshotRequestBody(req *http.Request) string {
+	if req.GetBody == nil || req.ContentLength <= 0 || req.ContentLength > maxLoggedBodySize {
+		return ""
+	}
+	contentType := req.Header.Get("Content-Type")
+	if strings.HasPrefix(contentType, "multipart/") {
+		return ""
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		return redactQuery(string(data))
+	case strings.HasPrefix(contentType, "application/json"):
+		return RedactJSON(data)
+	default:
+		return RedactText(string(data))
+	}
+}
+
+// 敏感字段名（包含即脱敏，不区分大小写）
+var sensitiveKeys = []string{"password", "passwd", "token", "ticket", "secret", "csrf", "authorization", "cookie"}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactQuery 脱敏 URL 查询串 / 表单中的敏感字段
+func redactQuery(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return RedactText(raw)
+	}
+	for key := range values {
+		if isSensitiveKey(key) {
+			values[key] = []string{redactedValue}
+		}
+	}
+	return values.Encode()
+}
+
+// RedactJSON 脱敏 JSON 中的敏感字段（递归处理嵌套对象和数组）
+func RedactJSON(data []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return RedactText(truncateLogBody(string(data)))
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, item := range val {
+			if isSensitiveKey(k) {
+				val[k] = redactedValue
+			} else {
+				val[k] = redactValue(item)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = redactValue(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+var (
+	// PVEAPIToken=user@realm!tokenid=uuid
+	apiTokenPattern = regexp.MustCompile(`PVEAPIToken=[^\s"',;]+`)
+	// PVE:user@realm:HEX::signature
+	ticketPattern = regexp.MustCompile(`PVE:[^\s"',;]+::[^\s"',;]+`)
+	// password=xxx / "password":"xxx"
+	keyValuePattern = regexp.MustCompile(`(?i)("?[a-z_]*(?:password|passwd|token|ticket|secret)[a-z_]*"?\s*[=:]\s*"?)[^\s"&,;]+`)
+)
+
+// RedactText 脱敏任意文本中的 API Token、Ticket 以及 key=value 形式的敏感字段
+func RedactText(s string) string {
+	s = apiTokenPattern.ReplaceAllString(s, "PVEAPIToken="+redactedValue)
+	s = ticketPattern.ReplaceAllString(s, redactedValue)
+	s = keyValuePattern.ReplaceAllString(s, "${1}"+redactedValue)
+	return s
+}
+
+func truncateLogBody(s string) string {
+	if len(s) > maxLoggedBodySize {
+		return s[:maxLoggedBodySize] + "...(truncated)"
+	}
+	return s
+}
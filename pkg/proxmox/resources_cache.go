@@ -0,0 +1,122 @@
+package proxmox
+
+import (
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// ClusterResourceCache 按集群缓存 /cluster/resources 的结果
+// 增量同步时按每个资源条目原始 JSON 的哈希比对，只解析发生变化的条目，未变化的条目复用上次解析结果，
+// 大集群（数千条资源）下可显著减少重复解析带来的 CPU 和内存分配。
+// 返回的资源 map 会在多次调用间共享，调用方只能读取，不能修改。
+type ClusterResourceCache struct {
+	ttl    time.Duration
+	mu     sync.Mutex
+	states map[int64]*clusterResourcesState
+}
+
+type clusterResourcesState struct {
+	mu        sync.Mutex
+	byHash    map[uint64]map[string]interface{}
+	snapshot  []map[string]interface{}
+	fetchedAt time.Time
+}
+
+// NewClusterResourceCache 创建缓存，ttl 内的重复读取直接返回上次结果（ttl <= 0 表示每次都增量同步）
+func NewClusterResourceCache(ttl time.Duration) *ClusterResourceCache {
+	return &ClusterResourceCache{
+		ttl:    ttl,
+		states: make(map[int64]*clusterResourcesState),
+	}
+}
+
+func (c *ClusterResourceCache) state(clusterID int64) *clusterResourcesState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	st, ok := c.states[clusterID]
+	if !ok {
+		st = &clusterResourcesState{byHash: make(map[uint64]map[string]interface{})}
+		c.states[clusterID] = st
+	}
+	return st
+}
+
+// Get 获取集群资源，缓存未过期时直接返回，否则执行一次增量同步
+// 同一集群的并发调用会合并为一次 Proxmox 请求
+func (c *ClusterResourceCache) Get(ctx context.Context, clusterID int64, client *ProxmoxClient) ([]map[string]interface{}, error) {
+	st := c.state(clusterID)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.snapshot != nil && c.ttl > 0 && time.Since(st.fetchedAt) < c.ttl {
+		return st.snapshot, nil
+	}
+	if err := st.sync(ctx, client); err != nil {
+		return nil, err
+	}
+	return st.snapshot, nil
+}
+
+// GetByType 获取指定类型的集群资源（qemu、lxc、node、storage 等），基于 Get 的缓存结果过滤
+func (c *ClusterResourceCache) GetByType(ctx context.Context, clusterID int64, client *ProxmoxClient, types ...string) ([]map[string]interface{}, error) {
+	resources, err := c.Get(ctx, clusterID, client)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]interface{}, 0, len(resources))
+	for _, resource := range resources {
+		resourceType, _ := resource["type"].(string)
+		for _, t := range types {
+			if resourceType == t {
+				result = append(result, resource)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// Invalidate 清除集群的缓存（集群连接信息变更或删除时调用）
+func (c *ClusterResourceCache) Invalidate(clusterID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.states, clusterID)
+}
+
+// sync 重新拉取集群资源，内容未变化的条目复用上次解析结果；调用方需持有 st.mu
+func (st *clusterResourcesState) sync(ctx context.Context, client *ProxmoxClient) error {
+	raws, err := client.GetClusterResourcesRaw(ctx)
+	if err != nil {
+		return err
+	}
+
+	byHash := make(map[uint64]map[string]interface{}, len(raws))
+	snapshot := make([]map[string]interface{}, 0, len(raws))
+
+	for _, raw := range raws {
+		h := fnv.New64a()
+		_, _ = h.Write(raw)
+		sum := h.Sum64()
+
+		// 内容完全一致的条目直接复用，无需重新解析
+		data, ok := st.byHash[sum]
+		if !ok {
+			if err := json.Unmarshal(raw, &data); err != nil {
+				return err
+			}
+		}
+		byHash[sum] = data
+		snapshot = append(snapshot, data)
+	}
+
+	// 已消失或已变化的条目不再保留旧的解析结果
+	st.byHash = byHash
+	st.snapshot = snapshot
+	st.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,56 @@
+package proxmox
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// termproxy（Proxmox xterm.js 控制台）的 websocket 协议：
+//   - 连接后客户端先发送 "用户:票据\n" 认证，成功时服务端回复 "OK"
+//   - 输入为 "0:字节数:数据"，字节数按 UTF-8 编码计算（按字符数计算时非 ASCII 输入会被截断）
+//   - 调整终端大小为 "1:列数:行数:"
+//   - 心跳为 "2"
+// 之后服务端发来的消息都是终端输出的原始字节
+
+// TermProxyAuthMessage termproxy 认证消息
+func TermProxyAuthMessage(user, ticket string) []byte {
+	return []byte(user + ":" + ticket + "\n")
+}
+
+// TermProxyInputMessage 将终端输入封装为 termproxy 输入消息
+func TermProxyInputMessage(data []byte) []byte {
+	msg := make([]byte, 0, len(data)+16)
+	msg = append(msg, "0:"...)
+	msg = strconv.AppendInt(msg, int64(len(data)), 10)
+	msg = append(msg, ':')
+	return append(msg, data...)
+}
+
+// TermProxyResizeMessage termproxy 调整终端大小消息
+func TermProxyResizeMessage(cols, rows int) []byte {
+	return []byte(fmt.Sprintf("1:%d:%d:", cols, rows))
+}
+
+// TermResizeControl 客户端通过控制台代理发送的调整终端大小消息：{"type":"resize","cols":120,"rows":40}
+type TermResizeControl struct {
+	Type string `json:"type"`
+	Cols int    `json:"cols"`
+	Rows int    `json:"rows"`
+}
+
+// TermProxyClientMessage 将简化协议下的客户端消息转换为 termproxy 消息：
+// resize 控制消息转换为调整大小消息（列数、行数超出 1-1000 时忽略，返回 nil），其他消息都作为终端输入
+func TermProxyClientMessage(msg []byte) []byte {
+	if len(msg) > 0 && msg[0] == '{' && bytes.Contains(msg, []byte(`"resize"`)) {
+		var control TermResizeControl
+		if err := json.Unmarshal(msg, &control); err == nil && control.Type == "resize" {
+			if control.Cols < 1 || control.Cols > 1000 || control.Rows < 1 || control.Rows > 1000 {
+				return nil
+			}
+			return TermProxyResizeMessage(control.Cols, control.Rows)
+		}
+	}
+	return TermProxyInputMessage(msg)
+}
@@ -0,0 +1,223 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Pod 内 ServiceAccount 挂载路径
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubernetesSecretKey Secret 中保存密钥的数据项
+const kubernetesSecretKey = "value"
+
+// kubernetesStore 每个密钥对应一个 Opaque 类型的 Secret，引用形如 kubernetes:<namespace>/<name>
+type kubernetesStore struct {
+	host       string
+	token      string
+	namespace  string
+	prefix     string
+	httpClient *http.Client
+}
+
+func newKubernetesStore(cfg KubernetesConfig, timeout time.Duration) (*kubernetesStore, error) {
+	host := cfg.Host
+	if host == "" {
+		h, p := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if h == "" || p == "" {
+			return nil, errors.New("secret: kubernetes host is required when not running in a pod")
+		}
+		host = "https://" + net.JoinHostPort(h, p)
+	}
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+
+	token := cfg.Token
+	if token == "" {
+		data, err := os.ReadFile(serviceAccountDir + "/token")
+		if err != nil {
+			return nil, fmt.Errorf("secret: read service account token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	namespace := cfg.Namespace
+	if namespace == "" {
+		data, err := os.ReadFile(serviceAccountDir + "/namespace")
+		if err != nil {
+			return nil, fmt.Errorf("secret: kubernetes namespace is required: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	caFile := cfg.CAFile
+	if caFile == "" && cfg.Host == "" {
+		caFile = serviceAccountDir + "/ca.crt"
+	}
+	if caFile != "" && !cfg.InsecureSkipVerify {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("secret: read kubernetes ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("secret: invalid kubernetes ca certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	prefix := strings.Trim(strings.ToLower(cfg.Prefix), "-")
+	if prefix == "" {
+		prefix = "pvesphere"
+	}
+	return &kubernetesStore{
+		host:      strings.TrimRight(host, "/"),
+		token:     token,
+		namespace: namespace,
+		prefix:    prefix,
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+	}, nil
+}
+
+func (s *kubernetesStore) Backend() string {
+	return BackendKubernetes
+}
+
+// kubernetesSecret Secret 资源中用到的字段，data 为 base64（encoding/json 自动处理 []byte）
+type kubernetesSecret struct {
+	APIVersion string            `json:"apiVersion"`
+	Kind       string            `json:"kind"`
+	Metadata   kubernetesMeta    `json:"metadata"`
+	Type       string            `json:"type,omitempty"`
+	Data       map[string][]byte `json:"data"`
+}
+
+type kubernetesMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+func (s *kubernetesStore) Put(ctx context.Context, name, value string) (string, error) {
+	secretName := s.prefix + "-" + strings.ToLower(name)
+	body := kubernetesSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: kubernetesMeta{
+			Name:      secretName,
+			Namespace: s.namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "pvesphere"},
+		},
+		Type: "Opaque",
+		Data: map[string][]byte{kubernetesSecretKey: []byte(value)},
+	}
+
+	collection := "/api/v1/namespaces/" + s.namespace + "/secrets"
+	status, err := s.do(ctx, http.MethodPost, collection, body, nil)
+	if status == http.StatusConflict {
+		// 已存在时整体替换
+		_, err = s.do(ctx, http.MethodPut, collection+"/"+secretName, body, nil)
+	}
+	if err != nil {
+		return "", err
+	}
+	return makeRef(BackendKubernetes, s.namespace+"/"+secretName), nil
+}
+
+func (s *kubernetesStore) Get(ctx context.Context, ref string) (string, error) {
+	namespace, name, err := s.split(ref)
+	if err != nil {
+		return "", err
+	}
+	var result kubernetesSecret
+	status, err := s.do(ctx, http.MethodGet, "/api/v1/namespaces/"+namespace+"/secrets/"+name, nil, &result)
+	if status == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	value, ok := result.Data[kubernetesSecretKey]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return string(value), nil
+}
+
+func (s *kubernetesStore) Delete(ctx context.Context, ref string) error {
+	namespace, name, err := s.split(ref)
+	if err != nil {
+		return err
+	}
+	status, err := s.do(ctx, http.MethodDelete, "/api/v1/namespaces/"+namespace+"/secrets/"+name, nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+func (s *kubernetesStore) split(ref string) (string, string, error) {
+	locator, err := locatorOf(BackendKubernetes, ref)
+	if err != nil {
+		return "", "", err
+	}
+	namespace, name, ok := strings.Cut(locator, "/")
+	if !ok || namespace == "" || name == "" {
+		return "", "", errors.New("secret: malformed kubernetes reference")
+	}
+	return namespace, name, nil
+}
+
+func (s *kubernetesStore) do(ctx context.Context, method, path string, payload, result interface{}) (int, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.host+path, reqBody)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.token)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 400 {
+		return resp.StatusCode, fmt.Errorf("kubernetes %s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if result == nil || len(body) == 0 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.Unmarshal(body, result)
+}
@@ -0,0 +1,83 @@
+package secret
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+)
+
+// localVersion 本地加密格式版本，更换算法时递增
+const localVersion = "v1"
+
+// localStore 本地加密存储：密文直接作为引用保存在数据库中，密钥只存在于配置文件
+type localStore struct {
+	aead cipher.AEAD
+}
+
+// NewLocalStore 创建本地加密存储。key 为 base64 编码的 32 字节密钥；
+// 不是合法的 32 字节 base64 时按口令处理，使用其 SHA-256 作为密钥
+func NewLocalStore(key string) (Store, error) {
+	if key == "" {
+		return nil, errors.New("secret: local key is required")
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(raw) != 32 {
+		sum := sha256.Sum256([]byte(key))
+		raw = sum[:]
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &localStore{aead: aead}, nil
+}
+
+func (s *localStore) Backend() string {
+	return BackendLocal
+}
+
+// Put 加密 value，name 作为附加认证数据，防止密文被挪用到其他虚拟机
+func (s *localStore) Put(ctx context.Context, name, value string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(value), []byte(name))
+	return makeRef(BackendLocal, localVersion+":"+name+":"+base64.RawURLEncoding.EncodeToString(sealed)), nil
+}
+
+func (s *localStore) Get(ctx context.Context, ref string) (string, error) {
+	locator, err := locatorOf(BackendLocal, ref)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.SplitN(locator, ":", 3)
+	if len(parts) != 3 || parts[0] != localVersion {
+		return "", errors.New("secret: malformed local reference")
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(sealed) < s.aead.NonceSize() {
+		return "", errors.New("secret: malformed local reference")
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plain, err := s.aead.Open(nil, nonce, ciphertext, []byte(parts[1]))
+	if err != nil {
+		return "", errors.New("secret: failed to decrypt local secret, check secret.local.key")
+	}
+	return string(plain), nil
+}
+
+// Delete 本地密文保存在数据库记录中，随记录一起清除
+func (s *localStore) Delete(ctx context.Context, ref string) error {
+	return nil
+}
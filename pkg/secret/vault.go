@@ -0,0 +1,181 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// vaultStore 使用 Vault KV v2 引擎保存密钥，引用形如 vault:<mount>/<prefix>/<name>
+type vaultStore struct {
+	baseURL    *url.URL
+	token      string
+	namespace  string
+	mount      string
+	prefix     string
+	httpClient *http.Client
+}
+
+func newVaultStore(cfg VaultConfig, timeout time.Duration) (*vaultStore, error) {
+	if cfg.Address == "" {
+		return nil, errors.New("secret: vault address is required")
+	}
+	address := cfg.Address
+	if !strings.Contains(address, "://") {
+		address = "https://" + address
+	}
+	baseURL, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("secret: invalid vault address: %w", err)
+	}
+	token := cfg.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if token == "" {
+		return nil, errors.New("secret: vault token is required")
+	}
+	mount := strings.Trim(cfg.Mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix == "" {
+		prefix = "pvesphere"
+	}
+	return &vaultStore{
+		baseURL:   baseURL,
+		token:     token,
+		namespace: cfg.Namespace,
+		mount:     mount,
+		prefix:    prefix,
+		httpClient: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify},
+			},
+		},
+	}, nil
+}
+
+func (s *vaultStore) Backend() string {
+	return BackendVault
+}
+
+// vaultSecret KV v2 读取响应
+type vaultSecret struct {
+	Data struct {
+		Data map[string]string `json:"data"`
+	} `json:"data"`
+}
+
+func (s *vaultStore) Put(ctx context.Context, name, value string) (string, error) {
+	path := s.prefix + "/" + name
+	payload := map[string]interface{}{
+		"data": map[string]string{"value": value},
+	}
+	if _, err := s.do(ctx, http.MethodPost, "/v1/"+s.mount+"/data/"+path, payload, nil); err != nil {
+		return "", err
+	}
+	return makeRef(BackendVault, s.mount+"/"+path), nil
+}
+
+func (s *vaultStore) Get(ctx context.Context, ref string) (string, error) {
+	mount, path, err := s.split(ref)
+	if err != nil {
+		return "", err
+	}
+	var result vaultSecret
+	status, err := s.do(ctx, http.MethodGet, "/v1/"+mount+"/data/"+path, nil, &result)
+	if status == http.StatusNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	value, ok := result.Data.Data["value"]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Delete 删除元数据，连同所有历史版本一起清除
+func (s *vaultStore) Delete(ctx context.Context, ref string) error {
+	mount, path, err := s.split(ref)
+	if err != nil {
+		return err
+	}
+	status, err := s.do(ctx, http.MethodDelete, "/v1/"+mount+"/metadata/"+path, nil, nil)
+	if status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// split 从引用中取出挂载路径和密钥路径
+func (s *vaultStore) split(ref string) (string, string, error) {
+	locator, err := locatorOf(BackendVault, ref)
+	if err != nil {
+		return "", "", err
+	}
+	mount, path, ok := strings.Cut(locator, "/")
+	if !ok || path == "" {
+		return "", "", errors.New("secret: malformed vault reference")
+	}
+	return mount, path, nil
+}
+
+func (s *vaultStore) do(ctx context.Context, method, path string, payload, result interface{}) (int, error) {
+	ref, err := url.Parse(path)
+	if err != nil {
+		return 0, err
+	}
+	var reqBody io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.baseURL.ResolveReference(ref).String(), reqBody)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+	if s.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.namespace)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, err
+	}
+	if resp.StatusCode >= 400 {
+		// 错误响应只包含 errors 字段，不会回显密钥内容
+		return resp.StatusCode, fmt.Errorf("vault %s %s: status %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if result == nil || len(body) == 0 {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.Unmarshal(body, result)
+}
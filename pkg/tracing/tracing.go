@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"pvesphere/pkg/log"
+
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// TracerName 本项目所有 span 使用的 tracer 名称
+const TracerName = "pvesphere"
+
+// Tracer 返回全局 tracer（未启用时为 noop，开销可以忽略）
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// Init 根据配置初始化 OpenTelemetry，返回用于退出时刷新并关闭 exporter 的函数
+//
+// 配置示例：
+//
+//	trace:
+//	  enabled: true
+//	  service_name: pvesphere
+//	  otlp_endpoint: 127.0.0.1:4318
+//	  insecure: true
+//	  sample_ratio: 1.0
+func Init(conf *viper.Viper, logger *log.Logger, defaultServiceName string) func() {
+	// 无论是否启用导出，都使用 W3C traceparent 传播，便于与上游网关串联
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if !conf.GetBool("trace.enabled") {
+		return func() {}
+	}
+
+	opts := []otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(conf.GetString("trace.otlp_endpoint")),
+	}
+	if conf.GetBool("trace.insecure") {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		logger.Error("failed to create otlp trace exporter, tracing disabled", zap.Error(err))
+		return func() {}
+	}
+
+	serviceName := conf.GetString("trace.service_name")
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.DeploymentEnvironment(conf.GetString("env")),
+	))
+	if err != nil {
+		res = resource.Default()
+	}
+
+	ratio := 1.0
+	if conf.IsSet("trace.sample_ratio") {
+		ratio = conf.GetFloat64("trace.sample_ratio")
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	logger.Info("opentelemetry tracing enabled",
+		zap.String("endpoint", conf.GetString("trace.otlp_endpoint")),
+		zap.String("service_name", serviceName),
+		zap.Float64("sample_ratio", ratio))
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := provider.Shutdown(ctx); err != nil {
+			logger.Error("failed to shutdown tracer provider", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package wol
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// DefaultBroadcast 默认广播地址（UDP 9 端口，discard 服务）
+const DefaultBroadcast = "255.255.255.255:9"
+
+// ParseMAC 解析并规范化 MAC 地址（小写、冒号分隔），仅支持 48 位以太网地址
+func ParseMAC(s string) (string, error) {
+	hw, err := net.ParseMAC(strings.TrimSpace(s))
+	if err != nil {
+		return "", err
+	}
+	if len(hw) != 6 {
+		return "", fmt.Errorf("wol: unsupported mac address %q", s)
+	}
+	return hw.String(), nil
+}
+
+// MagicPacket 构造魔术包：6 字节 0xFF 后接 16 次目标 MAC
+func MagicPacket(mac string) ([]byte, error) {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return nil, err
+	}
+	if len(hw) != 6 {
+		return nil, fmt.Errorf("wol: unsupported mac address %q", mac)
+	}
+	packet := bytes.Repeat([]byte{0xFF}, 6)
+	packet = append(packet, bytes.Repeat(hw, 16)...)
+	return packet, nil
+}
+
+// Send 向广播地址发送魔术包，broadcast 为空时使用 DefaultBroadcast
+// 广播只在本机所在二层网络内有效，跨网段需要通过同网段节点中继
+func Send(ctx context.Context, mac, broadcast string) error {
+	packet, err := MagicPacket(mac)
+	if err != nil {
+		return err
+	}
+	if broadcast == "" {
+		broadcast = DefaultBroadcast
+	}
+	addr, err := net.ResolveUDPAddr("udp4", broadcast)
+	if err != nil {
+		return fmt.Errorf("wol: invalid broadcast address %q: %w", broadcast, err)
+	}
+
+	conn, err := net.DialUDP("udp4", nil, addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	}
+	_, err = conn.Write(packet)
+	return err
+}
@@ -0,0 +1,128 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (e *testEnv) capabilityService() service.CapabilityService {
+	return service.NewCapabilityService(e.svc, e.conf, e.userRepo, e.clusterRepo, e.vmRepo, repository.NewVMLockRepository(e.repo),
+		service.NewClusterCapabilityService(e.svc, e.clusterRepo, repository.NewClusterCapabilityRepository(e.repo), e.logger), e.logger)
+}
+
+func findCapability(t *testing.T, items []v1.CapabilityItem, operation string) v1.CapabilityItem {
+	t.Helper()
+	for _, item := range items {
+		if item.Operation == operation {
+			return item
+		}
+	}
+	t.Fatalf("operation %s not found", operation)
+	return v1.CapabilityItem{}
+}
+
+func TestCapabilities(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	aliceID := env.addUser(t, "alice")
+	ctx := context.Background()
+	svc := env.capabilityService()
+
+	// 集群 API Token 缺少迁移权限
+	features, err := json.Marshal([]v1.ClusterFeatureCapability{
+		{Feature: "vm.create", Supported: true, Missing: []string{}},
+		{Feature: "vm.config", Supported: true, Missing: []string{}},
+		{Feature: "vm.power", Supported: true, Missing: []string{}},
+		{Feature: "vm.delete", Supported: true, Missing: []string{}},
+		{Feature: "vm.migrate", Supported: false, Optional: true, Missing: []string{"VM.Migrate"}},
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, repository.NewClusterCapabilityRepository(env.repo).Save(ctx, &model.ClusterCapability{
+		ClusterID: env.cluster.Id, Connected: true, Authenticated: true, Features: string(features), ProbeTime: &now,
+	}))
+
+	owned := env.addVM(t, "pve1", 900, "alice-web", "running")
+	owned.Owner = "alice"
+	require.NoError(t, env.vmRepo.Update(ctx, owned))
+	protected := env.addVM(t, "pve1", 901, "prod-db", "running")
+	protected.Protected, protected.ProtectedBy, protected.ProtectedReason = 1, "bob", "production database"
+	require.NoError(t, env.vmRepo.Update(ctx, protected))
+	locked := env.addVM(t, "pve2", 902, "batch", "running")
+	require.NoError(t, repository.NewVMLockRepository(env.repo).Save(ctx, &model.VMLock{
+		VmId: locked.Id, ClusterID: locked.ClusterID, VMID: locked.VMID, Owner: "bob", Reason: "kernel upgrade",
+	}))
+
+	req := &v1.GetMyCapabilitiesRequest{VMIds: []int64{owned.Id, protected.Id, locked.Id}}
+	data, err := svc.GetMine(ctx, aliceID, req)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", data.Username)
+	assert.False(t, data.IsAdmin)
+
+	// 管理员专属操作
+	item := findCapability(t, data.Global, "system.config")
+	assert.False(t, item.Allowed)
+	assert.Equal(t, v1.CapabilityReasonAdminRequired, item.Reason)
+
+	require.Len(t, data.Clusters, 1)
+	cluster := data.Clusters[0]
+	assert.True(t, cluster.Probed)
+	assert.True(t, findCapability(t, cluster.Operations, "vm.create").Allowed)
+	assert.Equal(t, v1.CapabilityReasonAdminRequired, findCapability(t, cluster.Operations, "node.power").Reason)
+	assert.Equal(t, v1.CapabilityReasonAdminRequired, findCapability(t, cluster.Operations, "storage.manage").Reason)
+
+	require.Len(t, data.VMs, 3)
+	ownedOps, protectedOps, lockedOps := data.VMs[0].Operations, data.VMs[1].Operations, data.VMs[2].Operations
+
+	// 负责人可以查看凭据，其他人不行
+	assert.True(t, findCapability(t, ownedOps, "vm.credential").Allowed)
+	assert.Equal(t, v1.CapabilityReasonNotVMOwner, findCapability(t, protectedOps, "vm.credential").Reason)
+
+	// 集群能力缺失
+	item = findCapability(t, ownedOps, "vm.migrate")
+	assert.False(t, item.Allowed)
+	assert.Equal(t, v1.CapabilityReasonClusterPermission, item.Reason)
+	assert.Equal(t, []string{"VM.Migrate"}, item.Missing)
+	assert.True(t, findCapability(t, ownedOps, "vm.delete").Allowed)
+
+	// 删除保护只拒绝删除、停止、迁移
+	item = findCapability(t, protectedOps, "vm.delete")
+	assert.False(t, item.Allowed)
+	assert.Equal(t, v1.CapabilityReasonVMProtected, item.Reason)
+	assert.Contains(t, item.Detail, "production database")
+	assert.Equal(t, v1.CapabilityReasonVMProtected, findCapability(t, protectedOps, "vm.stop").Reason)
+	assert.True(t, findCapability(t, protectedOps, "vm.start").Allowed)
+
+	// 被他人锁定维护时拒绝变更操作，控制台不受影响
+	item = findCapability(t, lockedOps, "vm.config")
+	assert.False(t, item.Allowed)
+	assert.Equal(t, v1.CapabilityReasonVMLocked, item.Reason)
+	assert.Contains(t, item.Detail, "bob")
+	assert.True(t, findCapability(t, lockedOps, "vm.console").Allowed)
+
+	// 管理员：管理员专属操作可用，删除保护和集群能力仍然生效
+	data, err = svc.GetMine(ctx, adminID, req)
+	require.NoError(t, err)
+	assert.True(t, data.IsAdmin)
+	assert.True(t, findCapability(t, data.Global, "system.config").Allowed)
+	assert.True(t, findCapability(t, data.Clusters[0].Operations, "node.power").Allowed)
+	assert.True(t, findCapability(t, data.VMs[1].Operations, "vm.credential").Allowed)
+	assert.True(t, findCapability(t, data.VMs[1].Operations, "vm.unprotect").Allowed)
+	assert.Equal(t, v1.CapabilityReasonVMProtected, findCapability(t, data.VMs[1].Operations, "vm.delete").Reason)
+	assert.Equal(t, v1.CapabilityReasonClusterPermission, findCapability(t, data.VMs[0].Operations, "vm.migrate").Reason)
+
+	_, err = svc.GetMine(ctx, aliceID, &v1.GetMyCapabilitiesRequest{VMIds: []int64{99999}})
+	assert.ErrorIs(t, err, v1.ErrVMNotFound)
+	_, err = svc.GetMine(ctx, "", req)
+	assert.ErrorIs(t, err, v1.ErrUnauthorized)
+}
@@ -0,0 +1,170 @@
+package integration
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEndpointServer 模拟同一集群的另一个接入地址（如负载均衡）或另一个集群
+func newEndpointServer(t *testing.T, nodes ...string) *proxmoxtest.Server {
+	t.Helper()
+	pve := proxmoxtest.NewServer()
+	t.Cleanup(pve.Close)
+	pve.RequireToken(testUserID, testToken)
+	for _, name := range nodes {
+		pve.AddNode(testNode(name, 0))
+	}
+	return pve
+}
+
+func endpointCheck(data *v1.ClusterEndpointData, name string) v1.ClusterEndpointCheck {
+	for _, check := range data.Checks {
+		if check.Check == name {
+			return check
+		}
+	}
+	return v1.ClusterEndpointCheck{}
+}
+
+func TestClusterEndpointRotation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	// 不修改接入信息时仅验证并记录证书指纹
+	data, err := env.endpointService.Verify(ctx, env.cluster.Id, &v1.UpdateClusterEndpointRequest{})
+	require.NoError(t, err)
+	assert.True(t, data.Passed)
+	assert.Equal(t, v1.EndpointCheckOK, endpointCheck(data, "connect").Status)
+	assert.Equal(t, v1.EndpointCheckOK, endpointCheck(data, "identity").Status)
+	assert.Equal(t, v1.EndpointCheckOK, endpointCheck(data, "remote_migration").Status)
+	require.Len(t, data.Certificates, 2)
+	assert.Equal(t, v1.NodeCertNew, data.Certificates[0].Status)
+	certs, err := env.endpointService.ListCertificates(ctx, env.cluster.Id)
+	require.NoError(t, err)
+	assert.Empty(t, certs, "verify must not save fingerprints")
+
+	data, err = env.endpointService.Apply(ctx, env.cluster.Id, &v1.UpdateClusterEndpointRequest{})
+	require.NoError(t, err)
+	assert.True(t, data.Applied)
+	certs, err = env.endpointService.ListCertificates(ctx, env.cluster.Id)
+	require.NoError(t, err)
+	require.Len(t, certs, 2)
+	original := certs[0].Fingerprint
+
+	// 指向另一个集群的地址：不保存
+	other := newEndpointServer(t, "other1")
+	otherURL := other.URL
+	data, err = env.endpointService.Apply(ctx, env.cluster.Id, &v1.UpdateClusterEndpointRequest{ApiUrl: &otherURL})
+	require.ErrorIs(t, err, v1.ErrClusterEndpointCheckFailed)
+	require.NotNil(t, data)
+	assert.False(t, data.Passed)
+	assert.False(t, data.Applied)
+	assert.Equal(t, v1.EndpointCheckFailed, endpointCheck(data, "identity").Status)
+	cluster, err := env.clusterRepo.GetByID(ctx, env.cluster.Id)
+	require.NoError(t, err)
+	assert.Equal(t, env.pve.URL, cluster.ApiUrl)
+
+	// 错误的 Token：连接检查失败
+	lb := newEndpointServer(t, "pve1", "pve2")
+	lbURL := lb.URL
+	badToken := "wrong"
+	data, err = env.endpointService.Verify(ctx, env.cluster.Id, &v1.UpdateClusterEndpointRequest{ApiUrl: &lbURL, UserToken: &badToken})
+	require.NoError(t, err)
+	assert.False(t, data.Passed)
+	assert.Equal(t, v1.EndpointCheckFailed, endpointCheck(data, "connect").Status)
+
+	// 迁移到负载均衡后 pve1 的证书也已更换
+	renewed := lb.RenewCertificate("pve1")
+	data, err = env.endpointService.Verify(ctx, env.cluster.Id, &v1.UpdateClusterEndpointRequest{ApiUrl: &lbURL})
+	require.NoError(t, err)
+	assert.True(t, data.Passed)
+	require.Len(t, data.Certificates, 2)
+	assert.Equal(t, v1.NodeCertChanged, data.Certificates[0].Status)
+	assert.Equal(t, original, data.Certificates[0].PreviousFingerprint)
+	assert.Equal(t, renewed, data.Certificates[0].Fingerprint)
+	assert.Equal(t, v1.NodeCertUnchanged, data.Certificates[1].Status)
+
+	data, err = env.endpointService.Apply(ctx, env.cluster.Id, &v1.UpdateClusterEndpointRequest{ApiUrl: &lbURL})
+	require.NoError(t, err)
+	assert.True(t, data.Applied)
+	cluster, err = env.clusterRepo.GetByID(ctx, env.cluster.Id)
+	require.NoError(t, err)
+	assert.Equal(t, lb.URL, cluster.ApiUrl)
+	cert, err := env.certRepo.GetByNode(ctx, env.cluster.Id, "pve1")
+	require.NoError(t, err)
+	assert.Equal(t, renewed, cert.Fingerprint)
+
+	_, err = env.endpointService.Verify(ctx, 999, &v1.UpdateClusterEndpointRequest{})
+	assert.ErrorIs(t, err, v1.ErrClusterNotFound)
+}
+
+func TestRemoteMigrateCertificateFingerprint(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	target := newEndpointServer(t, "pve3")
+	targetCluster := &model.PveCluster{
+		ClusterName: "target", ApiUrl: target.URL, UserId: testUserID, UserToken: testToken,
+		IsEnabled: 1, CreateTime: time.Now(), UpdateTime: time.Now(),
+	}
+	require.NoError(t, env.clusterRepo.Create(ctx, targetCluster))
+	targetNode := &model.PveNode{NodeName: "pve3", ClusterID: targetCluster.Id, Status: "online", CreateTime: time.Now(), UpdateTime: time.Now()}
+	require.NoError(t, env.nodeRepo.Create(ctx, targetNode))
+
+	vm := env.addVM(t, "pve1", 400, "mover", "stopped")
+	req := &v1.RemoteMigrateVMRequest{
+		VMID: vm.Id, TargetClusterID: targetCluster.Id, TargetNodeID: targetNode.Id,
+		TargetBridge: "vmbr0", TargetStorage: "local-lvm",
+	}
+	lastEndpoint := func() string {
+		requests := env.pve.Requests()
+		for i := len(requests) - 1; i >= 0; i-- {
+			if strings.HasSuffix(requests[i].Path, "/remote_migrate") {
+				return requests[i].Params.Get("target-endpoint")
+			}
+		}
+		return ""
+	}
+
+	// 首次迁移记录目标节点指纹
+	_, err := env.vmService.RemoteMigrateVM(ctx, req)
+	require.NoError(t, err)
+	cert, err := env.certRepo.GetByNode(ctx, targetCluster.Id, "pve3")
+	require.NoError(t, err)
+	require.NotNil(t, cert)
+	assert.Contains(t, lastEndpoint(), "fingerprint="+cert.Fingerprint)
+
+	// 读取证书失败时使用保存的指纹
+	target.FailRequests("GET", "/nodes/pve3/certificates", 500, 1)
+	_, err = env.vmService.RemoteMigrateVM(ctx, req)
+	require.NoError(t, err)
+	assert.Contains(t, lastEndpoint(), "fingerprint="+cert.Fingerprint)
+
+	// 证书更换后拒绝迁移，直到重新验证接入地址
+	renewed := target.RenewCertificate("pve3")
+	before := env.pve.CountRequests("POST", "/nodes/pve1/qemu/400/remote_migrate")
+	_, err = env.vmService.RemoteMigrateVM(ctx, req)
+	require.ErrorIs(t, err, v1.ErrNodeCertificateChanged)
+	assert.Equal(t, before, env.pve.CountRequests("POST", "/nodes/pve1/qemu/400/remote_migrate"))
+
+	_, err = env.endpointService.Apply(ctx, targetCluster.Id, &v1.UpdateClusterEndpointRequest{})
+	require.NoError(t, err)
+	_, err = env.vmService.RemoteMigrateVM(ctx, req)
+	require.NoError(t, err)
+	assert.Contains(t, lastEndpoint(), "fingerprint="+renewed)
+
+	// 没有保存的指纹且无法读取证书时返回明确的错误
+	require.NoError(t, env.certRepo.DeleteByClusterID(ctx, targetCluster.Id))
+	target.FailRequests("GET", "/nodes/pve3/certificates", 500, 1)
+	_, err = env.vmService.RemoteMigrateVM(ctx, req)
+	assert.ErrorIs(t, err, v1.ErrNodeCertificateUnavailable)
+}
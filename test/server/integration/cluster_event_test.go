@@ -0,0 +1,129 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/service"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clusterEventService 组装集群外部变更服务，reconcile 使用真实的待认领扫描
+func (e *testEnv) clusterEventService() (service.ClusterEventService, service.VMClaimService) {
+	claimService := service.NewVMClaimService(e.svc, e.conf, repository.NewVMClaimRepository(e.repo), e.clusterRepo, e.nodeRepo,
+		e.vmRepo, e.userRepo, e.logger)
+	eventService := service.NewClusterEventService(e.svc, e.conf, repository.NewClusterEventRepository(e.repo), e.clusterRepo,
+		e.nodeRepo, e.vmRepo, claimService, e.notesService, e.notificationService, e.logger)
+	return eventService, claimService
+}
+
+func TestClusterEvent_PollOutOfBandChanges(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	aliceID := env.addUser(t, "alice")
+	ctx := context.Background()
+	eventService, claimService := env.clusterEventService()
+
+	owned := env.addVM(t, "pve1", 100, "web-1", "running")
+	owned.Owner = "alice"
+	require.NoError(t, env.vmRepo.Update(ctx, owned))
+	env.addVM(t, "pve1", 101, "web-2", "running")
+
+	// Proxmox 网页中由其他用户操作：新建 200、删除 101、修改 100 的配置
+	env.pve.AddVM(proxmoxtest.VM{VMID: 200, Node: "pve2", Name: "manual", Status: "stopped"})
+	env.pve.AddTask("pve2", "qmcreate", 200, "bob@pve", "OK")
+	env.pve.RemoveVM(101)
+	env.pve.AddTask("pve1", "qmdestroy", 101, "bob@pve", "OK")
+	env.pve.AddJournal("pve1", "pvedaemon[1234]: <bob@pve> update VM 100: -memory 4096 -cores 4")
+	// PveSphere 自己（集群 API 用户）的操作和无关任务不记录
+	env.pve.AddTask("pve1", "qmstart", 100, testUserID, "OK")
+	env.pve.AddJournal("pve1", "pvedaemon[1234]: <"+testUserID+"> update VM 100: -memory 2048")
+	env.pve.AddTask("pve1", "vzdump", 100, "bob@pve", "OK")
+
+	created, err := eventService.Poll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, created)
+
+	// 同一来源记录不重复记录
+	created, err = eventService.Poll(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	data, err := eventService.List(ctx, &v1.ListClusterEventsRequest{})
+	require.NoError(t, err)
+	require.EqualValues(t, 3, data.Total)
+	byType := make(map[string]v1.ClusterEventItem)
+	for _, item := range data.List {
+		assert.Equal(t, "bob@pve", item.User)
+		assert.Equal(t, "integration", item.ClusterName)
+		byType[item.Type] = item
+	}
+	assert.Equal(t, uint32(200), byType[model.ClusterEventVMCreated].VMID)
+	assert.Equal(t, "pve2", byType[model.ClusterEventVMCreated].NodeName)
+	assert.Equal(t, "web-2", byType[model.ClusterEventVMDeleted].VmName)
+	config := byType[model.ClusterEventVMConfigChanged]
+	assert.Equal(t, model.ClusterEventSourceJournal, config.Source)
+	assert.Equal(t, "-memory 4096 -cores 4", config.Detail)
+
+	filtered, err := eventService.List(ctx, &v1.ListClusterEventsRequest{VMID: 100})
+	require.NoError(t, err)
+	require.Len(t, filtered.List, 1)
+	assert.Equal(t, model.ClusterEventVMConfigChanged, filtered.List[0].Type)
+
+	// 管理员收到全部通知，负责人收到自己虚拟机的通知
+	notifications, err := env.notificationService.List(ctx, adminID, &v1.ListNotificationsRequest{Category: "alert"})
+	require.NoError(t, err)
+	assert.Len(t, notifications.List, 3)
+	notifications, err = env.notificationService.List(ctx, aliceID, &v1.ListNotificationsRequest{Category: "alert"})
+	require.NoError(t, err)
+	require.Len(t, notifications.List, 1)
+	assert.Equal(t, "external_vm_config_changed", notifications.List[0].Event)
+	assert.Contains(t, notifications.List[0].Title, "web-1")
+
+	// 外部创建的虚拟机立即出现在待认领列表中
+	unclaimed, err := claimService.ListUnclaimed(ctx, &v1.ListUnclaimedVMsRequest{Reason: model.UnclaimedReasonUntracked})
+	require.NoError(t, err)
+	vmids := make([]uint32, 0, len(unclaimed.List))
+	for _, item := range unclaimed.List {
+		vmids = append(vmids, item.VMID)
+	}
+	assert.Contains(t, vmids, uint32(200))
+}
+
+func TestClusterEvent_FailedTaskAndCleanup(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	ctx := context.Background()
+	env.conf.Set("cluster_event.reconcile", false)
+	eventService, _ := env.clusterEventService()
+
+	env.pve.AddTask("pve1", "qmigrate", 300, "bob@pve", "migration aborted")
+
+	created, err := eventService.Poll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+
+	// 失败的任务只记录，不通知
+	data, err := eventService.List(ctx, &v1.ListClusterEventsRequest{Type: model.ClusterEventVMMigrated})
+	require.NoError(t, err)
+	require.Len(t, data.List, 1)
+	assert.Equal(t, "migration aborted", data.List[0].Status)
+	notifications, err := env.notificationService.List(ctx, adminID, &v1.ListNotificationsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, notifications.List)
+
+	removed, err := eventService.Cleanup(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	require.NoError(t, env.repo.DB(ctx).Model(&model.ClusterEvent{}).Where("1 = 1").
+		Update("event_time", data.List[0].EventTime.AddDate(0, 0, -100)).Error)
+	removed, err = eventService.Cleanup(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, removed)
+}
@@ -0,0 +1,78 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"pvesphere/pkg/proxmox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClusterResourceCache_IncrementalSync(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.addVM(t, "pve1", 100, "app-01", "stopped")
+	env.addVM(t, "pve1", 101, "app-02", "running")
+
+	client, err := proxmox.NewProxmoxClient(env.pve.URL, testUserID, testToken)
+	require.NoError(t, err)
+	byID := func(resources []map[string]interface{}) map[string]map[string]interface{} {
+		m := make(map[string]map[string]interface{}, len(resources))
+		for _, resource := range resources {
+			id, _ := resource["id"].(string)
+			m[id] = resource
+		}
+		return m
+	}
+
+	// ttl 为 0 时每次读取都重新拉取
+	cache := proxmox.NewClusterResourceCache(0)
+	first, err := cache.Get(ctx, env.cluster.Id, client)
+	require.NoError(t, err)
+	before := byID(first)
+	require.Contains(t, before, "qemu/100")
+	require.Contains(t, before, "qemu/101")
+
+	// 101 停机、100 删除、新增 102 后再次同步
+	vm, ok := env.pve.VM(101)
+	require.True(t, ok)
+	vm.Status = "stopped"
+	env.pve.AddVM(vm)
+	require.NoError(t, client.DeleteVM(ctx, "pve1", 100, false))
+	_, ok = env.pve.VM(100)
+	require.False(t, ok)
+	env.addVM(t, "pve2", 102, "app-03", "running")
+
+	second, err := cache.Get(ctx, env.cluster.Id, client)
+	require.NoError(t, err)
+	after := byID(second)
+	assert.Len(t, second, len(first))
+	assert.NotContains(t, after, "qemu/100")
+	assert.Equal(t, "stopped", after["qemu/101"]["status"])
+	assert.Equal(t, "app-03", after["qemu/102"]["name"])
+
+	// 未变化的条目复用上次的解析结果，变化的条目重新解析
+	assert.Equal(t, reflect.ValueOf(before["node/pve1"]).Pointer(), reflect.ValueOf(after["node/pve1"]).Pointer())
+	assert.NotEqual(t, reflect.ValueOf(before["qemu/101"]).Pointer(), reflect.ValueOf(after["qemu/101"]).Pointer())
+	assert.Equal(t, "running", before["qemu/101"]["status"])
+
+	// ttl 内的重复读取直接返回缓存
+	cached := proxmox.NewClusterResourceCache(time.Minute)
+	_, err = cached.Get(ctx, env.cluster.Id, client)
+	require.NoError(t, err)
+	requests := env.pve.CountRequests(http.MethodGet, "/cluster/resources")
+	vms, err := cached.GetByType(ctx, env.cluster.Id, client, "qemu")
+	require.NoError(t, err)
+	assert.Len(t, vms, 2)
+	assert.Equal(t, requests, env.pve.CountRequests(http.MethodGet, "/cluster/resources"))
+
+	cached.Invalidate(env.cluster.Id)
+	_, err = cached.Get(ctx, env.cluster.Id, client)
+	require.NoError(t, err)
+	assert.Equal(t, requests+1, env.pve.CountRequests(http.MethodGet, "/cluster/resources"))
+}
@@ -0,0 +1,127 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/handler"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleProxy_ConnectionLimits(t *testing.T) {
+	env := newTestEnv(t)
+	env.conf.Set("console.proxy.max_sessions", 3)
+	env.conf.Set("console.proxy.max_per_ip", 2)
+	admin := env.addUser(t, "admin")
+	bob := env.addUser(t, "bob")
+
+	releaseA1, err := env.consoleProxy.Acquire("10.0.0.1")
+	require.NoError(t, err)
+	releaseA2, err := env.consoleProxy.Acquire("10.0.0.1")
+	require.NoError(t, err)
+	// 单 IP 上限
+	_, err = env.consoleProxy.Acquire("10.0.0.1")
+	require.ErrorIs(t, err, v1.ErrConsoleConnectionLimit)
+	_, err = env.consoleProxy.Acquire("10.0.0.2")
+	require.NoError(t, err)
+	// 总数上限
+	_, err = env.consoleProxy.Acquire("10.0.0.3")
+	require.ErrorIs(t, err, v1.ErrConsoleConnectionLimit)
+
+	// release 可重复调用，只释放一次
+	releaseA1()
+	releaseA1()
+	_, err = env.consoleProxy.Acquire("10.0.0.3")
+	require.NoError(t, err)
+	releaseA2()
+
+	_, err = env.consoleProxy.GetMetrics(userCtx(bob), bob)
+	require.ErrorIs(t, err, v1.ErrAdminRequired)
+	metrics, err := env.consoleProxy.GetMetrics(userCtx(admin), admin)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, metrics.ActiveSessions)
+	require.EqualValues(t, 3, metrics.PeakSessions)
+	require.Equal(t, 2, metrics.ActiveClientIPs)
+	require.EqualValues(t, 4, metrics.TotalSessions)
+	require.EqualValues(t, 2, metrics.RejectedSessions)
+	require.Equal(t, 3, metrics.MaxSessions)
+	require.Equal(t, 2, metrics.MaxSessionsPerIP)
+	require.EqualValues(t, 30*60, metrics.IdleTimeoutSeconds)
+}
+
+// startNodeConsoleProxy 启动只包含节点控制台 websocket 代理的 HTTP 服务
+func (e *testEnv) startNodeConsoleProxy(t *testing.T) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	nodeHandler := handler.NewPveNodeHandler(handler.NewHandler(e.logger), e.nodeService, e.consoleAudit, e.consoleProxy)
+	engine := gin.New()
+	engine.GET("/api/v1/nodes/console/ws", nodeHandler.NodeConsoleWS)
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (e *testEnv) nodeConsoleToken(t *testing.T) string {
+	t.Helper()
+	result, err := e.nodeService.GetNodeConsole(context.Background(), "u1", &v1.GetNodeConsoleRequest{
+		NodeID: e.nodes["pve1"].Id, ConsoleType: "xtermjs",
+	})
+	require.NoError(t, err)
+	return result["ws_token"].(string)
+}
+
+func TestConsoleProxy_IdleTimeoutAndPerIPLimit(t *testing.T) {
+	env := newTestEnv(t)
+	env.conf.Set("console.proxy.idle_timeout", "600ms")
+	env.conf.Set("console.proxy.max_per_ip", 1)
+	admin := env.addUser(t, "admin")
+	srv := env.startNodeConsoleProxy(t)
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/v1/nodes/console/ws?token="
+
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL+env.nodeConsoleToken(t), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("uptime\r")))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "uptime\r", string(msg))
+
+	// 同一 IP 的第二个连接在升级前被拒绝，ws_token 不被消耗
+	token := env.nodeConsoleToken(t)
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL+token, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// 没有输入时按空闲超时断开
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	require.Equal(t, "idle timeout", closeErr.Text)
+
+	// 名额释放后可以使用之前未消耗的 ws_token
+	eventually(t, 5*time.Second, func() bool {
+		metrics, err := env.consoleProxy.GetMetrics(userCtx(admin), admin)
+		return err == nil && metrics.ActiveSessions == 0
+	}, "idle session released")
+	conn2, _, err := websocket.DefaultDialer.Dial(wsURL+token, nil)
+	require.NoError(t, err)
+	conn2.Close()
+
+	metrics, err := env.consoleProxy.GetMetrics(userCtx(admin), admin)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, metrics.IdleTimeouts)
+	require.EqualValues(t, 1, metrics.RejectedSessions)
+	require.EqualValues(t, len("uptime\r"), metrics.BytesIn)
+	require.EqualValues(t, 1, metrics.MessagesIn)
+	require.EqualValues(t, 1, metrics.MessagesOut)
+}
@@ -0,0 +1,166 @@
+package integration
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/service"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentReplication(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	adminID := env.addUser(t, "admin")
+	userID := env.addUser(t, "alice")
+
+	// pve1 的 local 上有三个 ISO，pve2 的 local 只有 debian-12.iso；nfs 为共享存储，还没有任何 ISO
+	for _, name := range []string{"pve1", "pve2"} {
+		node := testNode(name, 0)
+		node.Storages = append(node.Storages, proxmoxtest.Storage{Name: "nfs", Type: "nfs", Content: "iso,backup", Shared: true})
+		if name == "pve1" {
+			node.Storages[0].Volumes = append(node.Storages[0].Volumes, "local:iso/alpine-3.20.iso", "local:iso/windows.iso")
+		}
+		env.pve.AddNode(node)
+		require.NoError(t, env.storageRepo.Create(ctx, &model.PveStorage{NodeName: name, ClusterID: env.cluster.Id, StorageName: "local",
+			Type: "dir", Content: "iso,vztmpl,backup", Active: 1, Enabled: 1, CreateTime: time.Now(), UpdateTime: time.Now()}))
+		require.NoError(t, env.storageRepo.Create(ctx, &model.PveStorage{NodeName: name, ClusterID: env.cluster.Id, StorageName: "local-lvm",
+			Type: "lvmthin", Content: "images,rootdir", Active: 1, Enabled: 1, CreateTime: time.Now(), UpdateTime: time.Now()}))
+		require.NoError(t, env.storageRepo.Create(ctx, &model.PveStorage{NodeName: name, ClusterID: env.cluster.Id, StorageName: "nfs",
+			Type: "nfs", Content: "iso,backup", Active: 1, Enabled: 1, Shared: 1, CreateTime: time.Now(), UpdateTime: time.Now()}))
+	}
+
+	// 源存储挂载在本机的目录
+	sourceDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(sourceDir, "template", "iso"), 0o755))
+	for _, name := range []string{"debian-12.iso", "alpine-3.20.iso"} {
+		require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "template", "iso", name), []byte("iso image "+name), 0o644))
+	}
+	env.conf.Set("content_replication.source_dirs", map[string]string{"local": sourceDir})
+
+	svc := service.NewContentReplicationService(env.svc, env.conf, repository.NewContentReplicationRepository(env.repo),
+		env.clusterRepo, env.storageRepo, env.userRepo, env.logger)
+
+	req := &v1.CreateContentReplicationPolicyRequest{
+		Name:           "linux-isos",
+		ClusterID:      env.cluster.Id,
+		Content:        v1.ContentReplicationContentISO,
+		SourceStorage:  "local",
+		SourceNode:     "pve1",
+		Pattern:        "[ad]*.iso",
+		TargetStorages: []string{"local", "nfs"},
+	}
+	_, err := svc.CreatePolicy(ctx, userID, req)
+	assert.ErrorIs(t, err, v1.ErrAdminRequired)
+
+	// 校验：存储不存在、内容类型不允许、通配符非法、共享存储同时作为源和目标
+	invalid := *req
+	invalid.TargetStorages = []string{"ceph"}
+	_, err = svc.CreatePolicy(ctx, adminID, &invalid)
+	assert.ErrorIs(t, err, v1.ErrContentReplicationPolicyInvalid)
+	invalid.TargetStorages = []string{"local-lvm"}
+	_, err = svc.CreatePolicy(ctx, adminID, &invalid)
+	assert.ErrorIs(t, err, v1.ErrContentReplicationPolicyInvalid)
+	invalid.TargetStorages, invalid.Pattern = []string{"local"}, "[a"
+	_, err = svc.CreatePolicy(ctx, adminID, &invalid)
+	assert.ErrorIs(t, err, v1.ErrContentReplicationPolicyInvalid)
+	invalid.SourceStorage, invalid.SourceNode, invalid.Pattern, invalid.TargetStorages = "nfs", "", "", []string{"nfs"}
+	_, err = svc.CreatePolicy(ctx, adminID, &invalid)
+	assert.ErrorIs(t, err, v1.ErrContentReplicationPolicyInvalid)
+
+	autoCopy := false
+	req.AutoCopy = &autoCopy
+	policy, err := svc.CreatePolicy(ctx, adminID, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"local", "nfs"}, policy.TargetStorages)
+	assert.Equal(t, model.ContentReplicationStatusUnknown, policy.Status)
+	_, err = svc.CreatePolicy(ctx, adminID, req)
+	assert.ErrorIs(t, err, v1.ErrContentReplicationPolicyExists)
+
+	// 对账只报告：windows.iso 不匹配通配符；pve2 的 local 缺 alpine，nfs 缺两个
+	report, err := svc.Check(ctx, policy.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentReplicationStatusDiverged, report.Status)
+	assert.Equal(t, "pve1", report.SourceNode)
+	require.Len(t, report.SourceFiles, 2)
+	assert.Equal(t, "alpine-3.20.iso", report.SourceFiles[0].FileName)
+	require.Len(t, report.Targets, 2)
+	assert.Equal(t, "pve2", report.Targets[0].NodeName)
+	require.Len(t, report.Targets[0].Missing, 1)
+	assert.Equal(t, "alpine-3.20.iso", report.Targets[0].Missing[0].FileName)
+	assert.True(t, report.Targets[1].Shared)
+	assert.Equal(t, "pve1", report.Targets[1].NodeName, "shared targets are read through the source node")
+	assert.Len(t, report.Targets[1].Missing, 2)
+	assert.Equal(t, 3, report.MissingCount)
+	assert.Zero(t, report.QueuedCopies)
+
+	// auto_copy 关闭时后台对账不复制
+	require.NoError(t, svc.ReconcileAll(ctx))
+	copies, err := svc.ListCopies(ctx, &v1.ListContentReplicationCopiesRequest{PolicyID: policy.Id})
+	require.NoError(t, err)
+	assert.Zero(t, copies.Total)
+	got, err := svc.GetPolicy(ctx, policy.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentReplicationStatusDiverged, got.Status)
+	assert.Equal(t, 3, got.MissingCount)
+	require.NotNil(t, got.LastCheckTime)
+
+	// 立即对账并复制，再次对账时进行中的文件不重复排队
+	env.pve.SetTaskDurationFor("imgcopy", 3*time.Second)
+	_, err = svc.Reconcile(ctx, userID, policy.Id)
+	assert.ErrorIs(t, err, v1.ErrAdminRequired)
+	report, err = svc.Reconcile(ctx, adminID, policy.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.QueuedCopies)
+	report, err = svc.Check(ctx, policy.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.MissingCount)
+	assert.Len(t, report.Targets[0].Copying, 1)
+	assert.Empty(t, report.Targets[0].Missing)
+
+	eventually(t, 15*time.Second, func() bool {
+		data, err := svc.ListCopies(ctx, &v1.ListContentReplicationCopiesRequest{PolicyID: policy.Id, Status: model.ContentReplicationCopyCompleted})
+		return err == nil && data.Total == 3
+	}, "content replication copies did not complete")
+	assert.Equal(t, 1, env.pve.CountRequests("POST", "/nodes/pve2/storage/local/upload"))
+	assert.Equal(t, 2, env.pve.CountRequests("POST", "/nodes/pve1/storage/nfs/upload"))
+	copies, err = svc.ListCopies(ctx, &v1.ListContentReplicationCopiesRequest{PolicyID: policy.Id})
+	require.NoError(t, err)
+	for _, c := range copies.List {
+		assert.Equal(t, 100, c.Progress)
+		assert.Equal(t, "admin", c.Creator)
+		assert.NotNil(t, c.EndTime)
+	}
+
+	report, err = svc.Check(ctx, policy.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentReplicationStatusInSync, report.Status)
+	assert.Zero(t, report.MissingCount)
+
+	// 源存储未挂载在本机时只能对账，不能复制
+	env.conf.Set("content_replication.source_dirs", map[string]string{})
+	pattern := "*.iso"
+	_, err = svc.UpdatePolicy(ctx, adminID, policy.Id, &v1.UpdateContentReplicationPolicyRequest{Pattern: &pattern})
+	require.NoError(t, err)
+	got, err = svc.GetPolicy(ctx, policy.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.ContentReplicationStatusUnknown, got.Status, "changing the pattern resets the last result")
+	report, err = svc.Reconcile(ctx, adminID, policy.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.MissingCount)
+	assert.Zero(t, report.QueuedCopies)
+	assert.Contains(t, report.Error, "not mounted")
+
+	require.NoError(t, svc.DeletePolicy(ctx, adminID, policy.Id))
+	_, err = svc.GetPolicy(ctx, policy.Id)
+	assert.ErrorIs(t, err, v1.ErrContentReplicationPolicyNotFound)
+}
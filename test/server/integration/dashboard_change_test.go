@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/controller"
+	"pvesphere/internal/model"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboardChanges(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	h := controller.NewVMEventHandler(env.vmRepo, env.nodeRepo, env.statusRepo, env.logger, env.cluster.Id, env.cluster.ClusterName)
+
+	// 两天前的容量快照：只有一台虚拟机
+	require.NoError(t, env.snapshotRepo.Create(ctx, &model.ClusterCapacitySnapshot{ClusterID: env.cluster.Id, VMCount: 1, VCPUs: 2,
+		MemoryMB: 2048, CreateTime: time.Now().Add(-48 * time.Hour)}))
+
+	env.addVM(t, "pve1", 801, "old-db", "stopped")
+	env.addVM(t, "pve1", 802, "app", "running")
+
+	// 新建 800；删除 801；802 从 pve1 迁移到 pve2（新节点先上报）
+	require.NoError(t, h.OnAdd(&model.PveVM{VMID: 800, VmName: "new-web", NodeName: "pve1", Status: "running", CPUNum: 4, MemorySize: 4096}))
+	require.NoError(t, h.OnDelete(&model.PveVM{VMID: 801, VmName: "old-db", NodeName: "pve1"}))
+	require.NoError(t, h.OnAdd(&model.PveVM{VMID: 802, VmName: "app", NodeName: "pve2", Status: "running", CPUNum: 2, MemorySize: 2048}))
+	require.NoError(t, h.OnDelete(&model.PveVM{VMID: 802, VmName: "app", NodeName: "pve1"}))
+
+	// 配置修改：admin 和 bob 各修改一次 800，失败的调用和读取不计
+	u, err := url.Parse(env.pve.URL)
+	require.NoError(t, err)
+	addConfigAudit := func(id, username string, calls []proxmox.CallRecord) {
+		data, err := json.Marshal(calls)
+		require.NoError(t, err)
+		require.NoError(t, env.auditRepo.Create(ctx, &model.OperationAudit{OperationID: id, Method: "PUT", Route: "/api/v1/vms/:id",
+			Path: "/api/v1/vms/1", StatusCode: 200, Username: username, Tasks: "[]", Calls: string(data)}))
+	}
+	addConfigAudit("op-1", "admin", []proxmox.CallRecord{
+		{Method: "GET", Host: u.Host, Path: "/api2/json/nodes/pve1/qemu/800/config", Status: 200},
+		{Method: "PUT", Host: u.Host, Path: "/api2/json/nodes/pve1/qemu/800/config", Status: 200},
+		{Method: "POST", Host: u.Host, Path: "/api2/json/nodes/pve1/qemu/800/config", Status: 200},
+	})
+	addConfigAudit("op-2", "bob", []proxmox.CallRecord{
+		{Method: "PUT", Host: u.Host, Path: "/api2/json/nodes/pve1/qemu/800/config", Status: 200},
+		{Method: "PUT", Host: u.Host, Path: "/api2/json/nodes/pve2/qemu/802/config", Status: 500},
+	})
+
+	data, err := env.changeService.GetChanges(ctx, &v1.DashboardChangesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, v1.DashboardChangeSummary{Created: 1, Deleted: 1, Migrated: 1, ConfigChanged: 1}, data.Summary)
+	require.Len(t, data.Clusters, 1)
+	cluster := data.Clusters[0]
+	assert.Equal(t, uint32(800), cluster.Created[0].VMID)
+	assert.Equal(t, "old-db", cluster.Deleted[0].VmName)
+	assert.Equal(t, v1.DashboardVMMigration{VMID: 802, VmName: "app", FromNode: "pve1", ToNode: "pve2", Time: cluster.Migrations[0].Time}, cluster.Migrations[0])
+	require.Len(t, cluster.ConfigChanges, 1)
+	assert.Equal(t, "new-web", cluster.ConfigChanges[0].VmName)
+	assert.Equal(t, 2, cluster.ConfigChanges[0].Count)
+	assert.Equal(t, []string{"admin", "bob"}, cluster.ConfigChanges[0].Users)
+
+	// 与两天前的快照相比多了一台虚拟机（800 新建，801 删除，802 仍在）
+	require.NotNil(t, cluster.Capacity.BaselineTime)
+	assert.Equal(t, v1.DashboardCapacityValue{Before: 1, Current: 2, Delta: 1}, cluster.Capacity.VMs)
+	assert.Equal(t, v1.DashboardCapacityValue{Before: 2, Current: 6, Delta: 4}, cluster.Capacity.VCPUs)
+
+	// 7 天前没有快照，只返回当前值
+	data, err = env.changeService.GetChanges(ctx, &v1.DashboardChangesRequest{Period: v1.DashboardChangePeriod7d})
+	require.NoError(t, err)
+	assert.Nil(t, data.Clusters[0].Capacity.BaselineTime)
+	assert.Equal(t, v1.DashboardCapacityValue{Current: 2}, data.Clusters[0].Capacity.VMs)
+
+	// 起始时间之后没有变化
+	since := time.Now()
+	time.Sleep(20 * time.Millisecond)
+	data, err = env.changeService.GetChanges(ctx, &v1.DashboardChangesRequest{Since: &since, ClusterID: &env.cluster.Id})
+	require.NoError(t, err)
+	assert.Equal(t, v1.DashboardChangeSummary{}, data.Summary)
+
+	future := time.Now().Add(time.Hour)
+	_, err = env.changeService.GetChanges(ctx, &v1.DashboardChangesRequest{Since: &future})
+	assert.True(t, errors.Is(err, v1.ErrInvalidParameter))
+	missing := env.cluster.Id + 100
+	_, err = env.changeService.GetChanges(ctx, &v1.DashboardChangesRequest{ClusterID: &missing})
+	assert.True(t, errors.Is(err, v1.ErrClusterNotFound))
+
+	// 记录的快照作为之后的基线
+	require.NoError(t, env.changeService.RecordCapacitySnapshots(ctx))
+	latest, err := env.snapshotRepo.GetLatestBefore(ctx, env.cluster.Id, time.Now())
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, 2, latest.VMCount)
+	assert.Equal(t, 2, latest.RunningVMs)
+}
@@ -0,0 +1,239 @@
+package integration
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func firstBootAgent(ip string) *proxmoxtest.GuestAgent {
+	return &proxmoxtest.GuestAgent{
+		OSInfo: map[string]interface{}{"pretty-name": "Debian GNU/Linux 12 (bookworm)"},
+		Interfaces: []map[string]interface{}{
+			{"name": "lo", "hardware-address": "00:00:00:00:00:00", "ip-addresses": []map[string]interface{}{
+				{"ip-address": "127.0.0.1", "ip-address-type": "ipv4", "prefix": 8},
+			}},
+			{"name": "eth0", "hardware-address": "bc:24:11:00:00:01", "ip-addresses": []map[string]interface{}{
+				{"ip-address": ip, "ip-address-type": "ipv4", "prefix": 24},
+				{"ip-address": "fe80::be24:11ff:fe00:1", "ip-address-type": "ipv6", "prefix": 64},
+			}},
+		},
+	}
+}
+
+func TestFirstBootHook(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	adminID := env.addUser(t, "admin")
+	aliceID := env.addUser(t, "alice")
+	svc := env.firstBootService
+	env.conf.Set("first_boot.max_attempts", 2)
+	env.conf.Set("first_boot.retry_interval", time.Millisecond)
+
+	var mu sync.Mutex
+	var received [][]byte
+	var signatures []string
+	failing := true
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/flaky" && failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, body)
+		signatures = append(signatures, r.Header.Get("X-PveSphere-Signature"))
+	}))
+	defer webhook.Close()
+
+	tpl := &model.VmTemplate{TemplateName: "debian-12-base", ClusterID: env.cluster.Id, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	flaky := &model.VmTemplate{TemplateName: "debian-12-flaky", ClusterID: env.cluster.Id, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	require.NoError(t, env.vmTemplateRepo.Create(ctx, tpl))
+	require.NoError(t, env.vmTemplateRepo.Create(ctx, flaky))
+
+	// 仅管理员可以配置，地址和脚本需要有效
+	req := &v1.SaveFirstBootHookRequest{Type: v1.FirstBootHookTypeWebhook, URL: webhook.URL + "/cmdb", Secret: "s3cret"}
+	_, err := svc.SaveHook(ctx, aliceID, tpl.Id, req)
+	assert.ErrorIs(t, err, v1.ErrAdminRequired)
+	_, err = svc.SaveHook(ctx, adminID, tpl.Id, &v1.SaveFirstBootHookRequest{Type: v1.FirstBootHookTypeWebhook, URL: "ftp://cmdb"})
+	assert.ErrorIs(t, err, v1.ErrFirstBootHookInvalid)
+	_, err = svc.SaveHook(ctx, adminID, tpl.Id, &v1.SaveFirstBootHookRequest{Type: v1.FirstBootHookTypeScript, Script: "unknown"})
+	assert.ErrorIs(t, err, v1.ErrFirstBootHookInvalid)
+	_, err = svc.SaveHook(ctx, adminID, 99999, req)
+	assert.ErrorIs(t, err, v1.ErrTemplateNotFound)
+
+	hook, err := svc.SaveHook(ctx, adminID, tpl.Id, req)
+	require.NoError(t, err)
+	assert.True(t, hook.Enabled)
+	assert.True(t, hook.HasSecret)
+	// 不传 secret 时保留原密钥
+	hook, err = svc.SaveHook(ctx, adminID, tpl.Id, &v1.SaveFirstBootHookRequest{Type: v1.FirstBootHookTypeWebhook, URL: webhook.URL + "/cmdb", Description: "register in cmdb"})
+	require.NoError(t, err)
+	assert.True(t, hook.HasSecret)
+	_, err = svc.SaveHook(ctx, adminID, flaky.Id, &v1.SaveFirstBootHookRequest{Type: v1.FirstBootHookTypeWebhook, URL: webhook.URL + "/flaky"})
+	require.NoError(t, err)
+
+	// 从带钩子的模板创建虚拟机后登记执行记录，没有钩子的模板不登记
+	vm := env.addVM(t, "pve1", 920, "app-01", "running")
+	vm.TemplateID, vm.Owner, vm.Environment = tpl.Id, "alice", model.VMEnvironmentProd
+	require.NoError(t, env.vmRepo.Update(ctx, vm))
+	env.templateUsageService.RecordClone(ctx, vm, nil, model.TemplateUsageSourceCreate, "alice")
+	plain := env.addVM(t, "pve1", 921, "plain", "running")
+	plain.TemplateID = 99999
+	env.templateUsageService.RecordClone(ctx, plain, nil, model.TemplateUsageSourceCreate, "alice")
+	_, err = svc.GetRunByVM(ctx, plain.Id)
+	assert.ErrorIs(t, err, v1.ErrFirstBootRunNotFound)
+
+	// agent 未上线时继续等待
+	_, err = svc.CheckPending(ctx)
+	require.NoError(t, err)
+	run, err := svc.GetRunByVM(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.FirstBootRunStatusWaiting, run.Status)
+	assert.Equal(t, 1, run.Checks)
+	assert.Empty(t, received)
+
+	// agent 上线后调用 webhook
+	env.pve.SetGuestAgent(920, firstBootAgent("10.0.0.20"))
+	_, err = svc.CheckPending(ctx)
+	require.NoError(t, err)
+	run, err = svc.GetRunByVM(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.FirstBootRunStatusSucceeded, run.Status, run.Message)
+	assert.Equal(t, []string{"10.0.0.20"}, run.IPAddresses)
+	assert.Equal(t, 1, run.Attempts)
+	require.NotNil(t, run.FinishTime)
+
+	mu.Lock()
+	require.Len(t, received, 1)
+	body, signature := received[0], signatures[0]
+	mu.Unlock()
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+	var payload v1.FirstBootPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, v1.FirstBootEvent, payload.Event)
+	assert.Equal(t, "app-01", payload.VM.Name)
+	assert.Equal(t, env.cluster.ClusterName, payload.VM.ClusterName)
+	assert.Equal(t, "pve1", payload.VM.NodeName)
+	assert.Equal(t, "alice", payload.VM.Owner)
+	assert.Equal(t, "debian-12-base", payload.Template.Name)
+	assert.Equal(t, []string{"10.0.0.20"}, payload.IPAddresses)
+	assert.Equal(t, "Debian GNU/Linux 12 (bookworm)", payload.OS)
+
+	// 已结束的记录不再调用
+	_, err = svc.CheckPending(ctx)
+	require.NoError(t, err)
+	mu.Lock()
+	assert.Len(t, received, 1)
+	mu.Unlock()
+
+	// 调用失败时重试，超过次数后失败；创建人可以重新执行
+	other := env.addVM(t, "pve2", 922, "app-02", "running")
+	other.TemplateID = flaky.Id
+	env.templateUsageService.RecordClone(ctx, other, nil, model.TemplateUsageSourceCreate, "alice")
+	env.pve.SetGuestAgent(922, firstBootAgent("10.0.0.21"))
+	_, err = svc.CheckPending(ctx)
+	require.NoError(t, err)
+	run, err = svc.GetRunByVM(ctx, other.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.FirstBootRunStatusRetrying, run.Status)
+	assert.Contains(t, run.Message, "503")
+	_, err = svc.Retry(ctx, aliceID, run.Id)
+	assert.ErrorIs(t, err, v1.ErrFirstBootRunActive)
+
+	time.Sleep(5 * time.Millisecond)
+	_, err = svc.CheckPending(ctx)
+	require.NoError(t, err)
+	run, err = svc.GetRunByVM(ctx, other.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.FirstBootRunStatusFailed, run.Status)
+	assert.Equal(t, 2, run.Attempts)
+
+	list, err := svc.ListRuns(ctx, &v1.ListFirstBootRunsRequest{Status: model.FirstBootRunStatusFailed})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), list.Total)
+	assert.Equal(t, other.Id, list.List[0].VmId)
+
+	bobID := env.addUser(t, "bob")
+	_, err = svc.Retry(ctx, bobID, run.Id)
+	assert.ErrorIs(t, err, v1.ErrAdminRequired)
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	retried, err := svc.Retry(ctx, aliceID, run.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.FirstBootRunStatusSucceeded, retried.Status, retried.Message)
+	assert.Equal(t, 1, retried.Attempts)
+
+	// 删除钩子后尚未执行的记录不再调用
+	third := env.addVM(t, "pve1", 923, "app-03", "running")
+	third.TemplateID = tpl.Id
+	env.templateUsageService.RecordClone(ctx, third, nil, model.TemplateUsageSourceCreate, "alice")
+	require.NoError(t, svc.DeleteHook(ctx, adminID, tpl.Id))
+	_, err = svc.GetHook(ctx, tpl.Id)
+	assert.ErrorIs(t, err, v1.ErrFirstBootHookNotFound)
+	_, err = svc.CheckPending(ctx)
+	require.NoError(t, err)
+	run, err = svc.GetRunByVM(ctx, third.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.FirstBootRunStatusFailed, run.Status)
+	assert.Contains(t, run.Message, "removed or disabled")
+}
+
+func TestFirstBootHookScript(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	adminID := env.addUser(t, "admin")
+	svc := env.firstBootService
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "payload.json")
+	script := filepath.Join(dir, "register.sh")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\ncat > "+output+"\necho registered $PVESPHERE_VM_NAME $PVESPHERE_IP_ADDRESSES\n"), 0o755))
+	env.conf.Set("first_boot.scripts", map[string]string{"register-cmdb": script})
+
+	tpl := &model.VmTemplate{TemplateName: "rocky-9-base", ClusterID: env.cluster.Id, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	require.NoError(t, env.vmTemplateRepo.Create(ctx, tpl))
+	_, err := svc.SaveHook(ctx, adminID, tpl.Id, &v1.SaveFirstBootHookRequest{Type: v1.FirstBootHookTypeScript, Script: "register-cmdb"})
+	require.NoError(t, err)
+
+	vm := env.addVM(t, "pve1", 930, "db-01", "running")
+	vm.TemplateID = tpl.Id
+	env.templateUsageService.RecordClone(ctx, vm, nil, model.TemplateUsageSourceCreate, "alice")
+	env.pve.SetGuestAgent(930, firstBootAgent("10.0.0.30"))
+	_, err = svc.CheckPending(ctx)
+	require.NoError(t, err)
+
+	run, err := svc.GetRunByVM(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.FirstBootRunStatusSucceeded, run.Status, run.Message)
+	assert.Equal(t, "register-cmdb", run.Target)
+	assert.Contains(t, run.Message, "registered db-01 10.0.0.30")
+
+	body, err := os.ReadFile(output)
+	require.NoError(t, err)
+	var payload v1.FirstBootPayload
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, uint32(930), payload.VM.VMID)
+	assert.Equal(t, "rocky-9-base", payload.Template.Name)
+}
@@ -0,0 +1,272 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/service"
+
+	"github.com/gorilla/websocket"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// instanceConf 复制环境配置并设置实例标识，模拟共享同一数据库的另一个实例
+func (e *testEnv) instanceConf(instanceID string) *viper.Viper {
+	conf := viper.New()
+	for _, key := range e.conf.AllKeys() {
+		conf.Set(key, e.conf.Get(key))
+	}
+	conf.Set("ha.instance_id", instanceID)
+	return conf
+}
+
+// expireLease 登记一个属于已崩溃实例、已经过期的租约
+func (e *testEnv) expireLease(t *testing.T, jobType string, jobID int64) {
+	t.Helper()
+	ok, err := e.jobLeaseRepo.Acquire(context.Background(), jobType, jobID, "crashed", time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestHA_ConsoleTokenAcrossInstances(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	// 另一个实例：独立的服务对象，共享数据库和 secret.local.key
+	conf := env.instanceConf("replica-b")
+	otherTokens, err := service.NewSharedTokenStore(conf, repository.NewSharedTokenRepository(env.repo), env.logger)
+	require.NoError(t, err)
+	other := service.NewPveNodeService(env.svc, env.nodeRepo, env.clusterRepo, repository.NewNodeDiskHealthRepository(env.repo),
+		otherTokens, env.logger)
+
+	result, err := env.nodeService.GetNodeConsole(ctx, "u1", &v1.GetNodeConsoleRequest{
+		NodeID: env.nodes["pve1"].Id, ConsoleType: "vncshell", Websocket: true,
+	})
+	require.NoError(t, err)
+	token := result["ws_token"].(string)
+
+	// 令牌内容（含 VNC ticket）加密保存
+	var records []model.SharedToken
+	require.NoError(t, env.repo.DB(ctx).Where("kind = ?", model.SharedTokenNodeConsole).Find(&records).Error)
+	require.Len(t, records, 1)
+	assert.NotContains(t, records[0].Payload, result["ticket"].(string))
+
+	// 负载均衡到另一个实例时仍可建立连接
+	conn, target, err := other.DialNodeConsoleWebsocket(ctx, token)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, "pve1", target.NodeName)
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("RFB 003.008\n")))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.Equal(t, "RFB 003.008\n", string(msg))
+
+	// 令牌单次使用，在任一实例上都不能再次使用
+	_, _, err = env.nodeService.DialNodeConsoleWebsocket(ctx, token)
+	assert.True(t, errors.Is(err, v1.ErrNotFound))
+	_, _, err = other.DialNodeConsoleWebsocket(ctx, token)
+	assert.True(t, errors.Is(err, v1.ErrNotFound))
+}
+
+func TestHA_ConfirmTokensAcrossInstances(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	ctx := context.Background()
+
+	node := testNode("pve1", 0)
+	node.Storages[1].Volumes = []string{"local-lvm:vm-999-disk-0"}
+	env.pve.AddNode(node)
+	vm := env.addVM(t, "pve1", 100, "app-01", "running")
+	vm.VmPassword = "s3cret"
+	require.NoError(t, env.vmRepo.Update(ctx, vm))
+
+	// 两个实例：独立的服务对象，共享数据库和 secret.local.key
+	browser := func(conf *viper.Viper, tokens service.SharedTokenStore) service.StorageBrowserService {
+		vmLocks := service.NewVMLockService(env.svc, conf, repository.NewVMLockRepository(env.repo), env.vmRepo, env.nodeRepo,
+			env.clusterRepo, env.userRepo, env.logger)
+		changeControl := service.NewChangeControlService(env.svc, conf, repository.NewChangeWindowRepository(env.repo), env.clusterRepo,
+			env.userRepo, vmLocks, env.logger)
+		return service.NewStorageBrowserService(env.svc, conf, env.userRepo, env.nodeRepo, env.clusterRepo, env.vmRepo, changeControl,
+			tokens, env.logger)
+	}
+	conf := env.instanceConf("replica-b")
+	otherTokens, err := service.NewSharedTokenStore(conf, repository.NewSharedTokenRepository(env.repo), env.logger)
+	require.NoError(t, err)
+	browserA, browserB := browser(env.conf, env.sharedTokens), browser(conf, otherTokens)
+	otherCredentials := service.NewVMCredentialService(env.svc, conf, repository.NewSecretAuditRepository(env.repo), env.vmRepo,
+		env.userRepo, otherTokens, env.logger)
+
+	// 存储批量删除：实例 A 预检，实例 B 确认
+	prepared, err := browserA.PrepareDelete(ctx, adminID, &v1.PrepareStorageDeleteRequest{
+		NodeID: env.nodes["pve1"].Id, Storage: "local-lvm", Volumes: []string{"local-lvm:vm-999-disk-0"},
+	})
+	require.NoError(t, err)
+	confirmed, err := browserB.ConfirmDelete(ctx, adminID, &v1.ConfirmStorageDeleteRequest{ConfirmToken: prepared.ConfirmToken})
+	require.NoError(t, err)
+	assert.Equal(t, 1, confirmed.Deleted)
+	_, err = browserA.ConfirmDelete(ctx, adminID, &v1.ConfirmStorageDeleteRequest{ConfirmToken: prepared.ConfirmToken})
+	assert.ErrorIs(t, err, v1.ErrStorageDeleteTokenInvalid)
+
+	// 凭据读取租约：实例 A 签发，实例 B 读取
+	lease, err := env.credentialService.Lease(ctx, adminID, vm.Id, "127.0.0.1")
+	require.NoError(t, err)
+	credential, err := otherCredentials.Retrieve(ctx, adminID, lease.Token, "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", credential.Password)
+	_, err = env.credentialService.Retrieve(ctx, adminID, lease.Token, "127.0.0.1")
+	assert.ErrorIs(t, err, v1.ErrVMCredentialLeaseInvalid)
+
+	// 令牌内容加密保存
+	var records []model.SharedToken
+	require.NoError(t, env.repo.DB(ctx).Find(&records).Error)
+	for _, record := range records {
+		assert.NotContains(t, record.Payload, "local-lvm")
+	}
+}
+
+func TestHA_SharedTokenStoreRequiresKey(t *testing.T) {
+	env := newTestEnv(t)
+
+	// 令牌可能包含 Proxmox 票据，未配置密钥时拒绝启动而不是明文保存
+	conf := env.instanceConf("replica-b")
+	conf.Set("secret.local.key", "")
+	_, err := service.NewSharedTokenStore(conf, repository.NewSharedTokenRepository(env.repo), env.logger)
+	assert.ErrorContains(t, err, "secret.local.key")
+}
+
+func TestHA_JobLeaseTakeover(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	confA, confB := env.instanceConf("replica-a"), env.instanceConf("replica-b")
+	confA.Set("ha.lease.ttl", "200ms")
+	leasesA := service.NewJobLeaseService(confA, env.jobLeaseRepo, env.logger)
+	leasesB := service.NewJobLeaseService(confB, env.jobLeaseRepo, env.logger)
+
+	var recovered []string
+	leasesB.RegisterRecoverer("test", func(ctx context.Context, jobID int64, previousOwner string) {
+		recovered = append(recovered, previousOwner)
+		leasesB.Release(ctx, "test", jobID)
+	})
+
+	ok, err := leasesA.Acquire(ctx, "test", 1)
+	require.NoError(t, err)
+	require.True(t, ok)
+	// 租约有效期内其他实例不能获取
+	ok, err = leasesB.Acquire(ctx, "test", 1)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	n, err := leasesB.RecoverExpired(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// 实例 A 停止续约后租约过期，由实例 B 接管
+	time.Sleep(300 * time.Millisecond)
+	n, err = leasesB.RecoverExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, []string{"replica-a"}, recovered)
+
+	// 实例 A 恢复后不再持有租约
+	renewed, err := leasesA.RenewLeases(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, renewed)
+	ok, err = leasesA.Acquire(ctx, "test", 1)
+	require.NoError(t, err)
+	assert.True(t, ok, "released lease can be acquired again")
+}
+
+func TestHA_ResumeBulkDeleteJob(t *testing.T) {
+	env := newTestEnv(t)
+	env.addUser(t, "admin")
+	ctx := context.Background()
+
+	done := env.addProjectVM(t, 700, "web-1", "stopped", "legacy", nil)
+	pending := env.addProjectVM(t, 701, "web-2", "running", "legacy", nil)
+
+	// 实例崩溃时 web-1 已删除（模拟的 Proxmox 中保留，用于确认不会重复删除），web-2 尚未处理
+	items, err := json.Marshal([]model.VMBulkDeleteItem{
+		{VmId: done.Id, VMID: done.VMID, VmName: done.VmName, ClusterID: env.cluster.Id, NodeName: "pve1", Status: model.VMBulkDeleteItemDeleted},
+		{VmId: pending.Id, VMID: pending.VMID, VmName: pending.VmName, ClusterID: env.cluster.Id, NodeName: "pve1", Status: model.VMBulkDeleteItemPending},
+	})
+	require.NoError(t, err)
+	start := time.Now()
+	job := &model.VMBulkDeleteJob{
+		Selector:  "business_service=legacy",
+		StopMode:  "stop",
+		Items:     string(items),
+		Status:    model.VMBulkDeleteJobStatusRunning,
+		Total:     2,
+		Deleted:   1,
+		StartTime: &start,
+		Creator:   "admin",
+	}
+	require.NoError(t, repository.NewVMBulkDeleteJobRepository(env.repo).Create(ctx, job))
+	env.expireLease(t, model.JobTypeVMBulkDelete, job.Id)
+
+	n, err := env.jobLeases.RecoverExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	eventually(t, 30*time.Second, func() bool {
+		report, err := env.bulkDeleteService.Get(ctx, job.Id)
+		return err == nil && report.Status == model.VMBulkDeleteJobStatusCompleted
+	}, "resumed bulk delete job did not complete")
+
+	report, err := env.bulkDeleteService.Get(ctx, job.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Deleted)
+	_, ok := env.pve.VM(701)
+	assert.False(t, ok)
+	_, ok = env.pve.VM(700)
+	assert.True(t, ok, "items finished before the crash are not processed again")
+	assert.Zero(t, env.pve.CountRequests("DELETE", "/nodes/pve1/qemu/700"))
+
+	// 任务结束后释放租约
+	eventually(t, 5*time.Second, func() bool {
+		ok, err := env.jobLeaseRepo.Acquire(ctx, model.JobTypeVMBulkDelete, job.Id, "other", time.Now().Add(time.Minute))
+		return err == nil && ok
+	}, "bulk delete job lease was not released")
+}
+
+func TestHA_InterruptedTemplateSyncTask(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	task := &model.TemplateSyncTask{
+		TemplateID:     1,
+		UploadID:       1,
+		ClusterID:      env.cluster.Id,
+		SourceNodeID:   env.nodes["pve1"].Id,
+		SourceNodeName: "pve1",
+		TargetNodeID:   env.nodes["pve2"].Id,
+		TargetNodeName: "pve2",
+		StorageName:    "local",
+		FilePath:       "template/iso/debian.qcow2",
+		Status:         model.TemplateSyncTaskStatusSyncing,
+		Progress:       40,
+	}
+	require.NoError(t, env.syncTaskRepo.Create(ctx, task))
+	env.expireLease(t, model.JobTypeTemplateSync, task.Id)
+
+	n, err := env.jobLeases.RecoverExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// 执行中断的任务状态未知，标记失败后可重试
+	got, err := env.syncTaskRepo.GetByID(ctx, task.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.TemplateSyncTaskStatusFailed, got.Status)
+	assert.Contains(t, got.ErrorMessage, "interrupted: instance crashed")
+	ok, err := env.jobLeaseRepo.Acquire(ctx, model.JobTypeTemplateSync, task.Id, "other", time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
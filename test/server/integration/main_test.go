@@ -0,0 +1,310 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/server"
+	"pvesphere/internal/service"
+	"pvesphere/pkg/jwt"
+	"pvesphere/pkg/log"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// 服务层集成测试：真实的 service / repository（SQLite 临时库）对接 proxmoxtest 模拟的 Proxmox API，
+// 覆盖创建、删除、迁移虚拟机和模板同步流程，以及认证失败、5xx、慢任务等故障场景
+
+const (
+	testUserID = "root@pam!pvesphere"
+	testToken  = "integration-token"
+)
+
+// testEnv 单个测试使用的环境，每个测试独立的数据库和模拟集群（节点 pve1、pve2）
+type testEnv struct {
+	conf    *viper.Viper
+	pve     *proxmoxtest.Server
+	cluster *model.PveCluster
+	nodes   map[string]*model.PveNode
+	svc     *service.Service
+	repo    *repository.Repository
+
+	nodeRepo     repository.PveNodeRepository
+	vmRepo       repository.PveVMRepository
+	templateRepo repository.PveTemplateRepository
+	uploadRepo   repository.TemplateUploadRepository
+	instanceRepo repository.TemplateInstanceRepository
+	syncTaskRepo repository.TemplateSyncTaskRepository
+
+	poolRepo       repository.NodePoolRepository
+	clusterRepo    repository.PveClusterRepository
+	userRepo       repository.UserRepository
+	promotionRepo  repository.TemplatePromotionRepository
+	auditRepo      repository.OperationAuditRepository
+	statusRepo     repository.VMStatusEventRepository
+	storageRepo    repository.PveStorageRepository
+	vmTemplateRepo repository.VmTemplateRepository
+	logger         *log.Logger
+
+	vmService            service.PveVMService
+	templateService      service.TemplateManagementService
+	promotionService     service.TemplatePromotionService
+	credentialService    service.VMCredentialService
+	metadataService      service.MetadataBackupService
+	vmidRangeService     service.VMIDRangeService
+	vmEventService       service.VMEventService
+	topologyService      service.TopologyService
+	agentInstallService  service.VMAgentInstallService
+	notificationService  service.NotificationService
+	templateUsageService service.TemplateUsageService
+	firstBootService     service.FirstBootHookService
+	licenseService       service.LicenseService
+	osEOLService         service.OSEOLService
+	securityService      service.VMSecurityService
+	endpointService      service.ClusterEndpointService
+	remoteMigration      service.RemoteMigrationJobService
+	cutoverService       service.RemoteMigrationCutoverService
+	bulkDeleteService    service.VMBulkDeleteService
+	protectionService    service.VMProtectionService
+	notesService         service.VMNotesService
+	changeService        service.DashboardChangeService
+	nodeService          service.PveNodeService
+	consoleAudit         service.ConsoleAuditService
+	consoleProxy         service.ConsoleProxyService
+	sharedTokens         service.SharedTokenStore
+	jobLeases            service.JobLeaseService
+	sharedTokenRepo      repository.SharedTokenRepository
+	jobLeaseRepo         repository.JobLeaseRepository
+	snapshotRepo         repository.ClusterCapacitySnapshotRepository
+	ipRepo               repository.VMIPAddressRepository
+	certRepo             repository.PveNodeCertificateRepository
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+	dir := t.TempDir()
+
+	conf := viper.New()
+	conf.Set("data.db.user.driver", "sqlite")
+	conf.Set("data.db.user.dsn", filepath.Join(dir, "pvesphere.db"))
+	conf.Set("log.log_level", "error")
+	conf.Set("log.log_file_name", filepath.Join(dir, "server.log"))
+	conf.Set("security.jwt.key", "integration")
+	conf.Set("security.admin_users", []string{"admin"})
+	// 每个环境使用不同的本地密钥，模拟不同实例
+	conf.Set("secret.backend", "local")
+	conf.Set("secret.local.key", dir)
+
+	logger := log.NewLog(conf)
+	db := repository.NewDB(conf, logger)
+	require.NoError(t, server.AutoMigrate(db))
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	repo := repository.NewRepository(logger, db)
+	// sid 仅用于注册用户，沙箱环境中 sonyflake 可能取不到内网 IP，这里不创建
+	svc := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(conf))
+
+	clusterRepo := repository.NewPveClusterRepository(repo)
+	siteRepo := repository.NewPveSiteRepository(repo)
+	nodeRepo := repository.NewPveNodeRepository(repo)
+	userRepo := repository.NewUserRepository(repo)
+	vmRepo := repository.NewPveVMRepository(repo)
+	vmTemplateRepo := repository.NewVmTemplateRepository(repo)
+	instanceRepo := repository.NewTemplateInstanceRepository(repo)
+	storageRepo := repository.NewPveStorageRepository(repo)
+	ipRepo := repository.NewVMIPAddressRepository(repo)
+	uploadRepo := repository.NewTemplateUploadRepository(repo)
+	syncTaskRepo := repository.NewTemplateSyncTaskRepository(repo)
+	templateRepo := repository.NewPveTemplateRepository(repo)
+	poolRepo := repository.NewNodePoolRepository(repo)
+	promotionRepo := repository.NewTemplatePromotionRepository(repo)
+	auditRepo := repository.NewOperationAuditRepository(repo)
+	statusRepo := repository.NewVMStatusEventRepository(repo)
+	licenseRepo := repository.NewLicenseRepository(repo)
+	certRepo := repository.NewPveNodeCertificateRepository(repo)
+	sharedTokenRepo := repository.NewSharedTokenRepository(repo)
+	jobLeaseRepo := repository.NewJobLeaseRepository(repo)
+	sharedTokens, err := service.NewSharedTokenStore(conf, sharedTokenRepo, logger)
+	require.NoError(t, err)
+	jobLeases := service.NewJobLeaseService(conf, jobLeaseRepo, logger)
+
+	notificationService := service.NewNotificationService(svc, conf, repository.NewNotificationRepository(repo), userRepo, logger)
+	vmLockService := service.NewVMLockService(svc, conf, repository.NewVMLockRepository(repo), vmRepo, nodeRepo, clusterRepo, userRepo, logger)
+	changeControlService := service.NewChangeControlService(svc, conf, repository.NewChangeWindowRepository(repo), clusterRepo, userRepo, vmLockService, logger)
+	nodeVersionService := service.NewNodeVersionService(svc, conf, repository.NewNodeVersionRepository(repo), clusterRepo, nodeRepo, vmRepo, logger)
+	vmProfileService := service.NewVMProfileService(svc, conf, repository.NewVMProfileRepository(repo), clusterRepo, userRepo, logger)
+	macRegistryService := service.NewMACRegistryService(svc, conf, repository.NewMACAddressRepository(repo), ipRepo, vmRepo, clusterRepo, userRepo, logger)
+	pendingOperationService := service.NewPendingOperationService(svc, conf, repository.NewPendingOperationRepository(repo), vmRepo, nodeRepo, clusterRepo, userRepo, changeControlService, vmLockService, notificationService, logger)
+	vmCredentialService := service.NewVMCredentialService(svc, conf, repository.NewSecretAuditRepository(repo), vmRepo, userRepo, sharedTokens, logger)
+	firstBootService := service.NewFirstBootHookService(svc, conf, repository.NewFirstBootHookRepository(repo), vmTemplateRepo, vmRepo, nodeRepo, clusterRepo, userRepo, logger)
+	templateUsageService := service.NewTemplateUsageService(svc, conf, repository.NewTemplateUsageRepository(repo), vmTemplateRepo, uploadRepo, vmRepo, clusterRepo, userRepo, notificationService, firstBootService, logger)
+	vmTaskTracker := service.NewVMTaskTracker(conf, vmRepo, userRepo, notificationService, logger)
+	reservations := service.NewProvisionReservationService(conf)
+	nodePoolService := service.NewNodePoolService(svc, conf, poolRepo, clusterRepo, nodeRepo, vmRepo, userRepo, reservations, logger)
+	vmidRangeService := service.NewVMIDRangeService(svc, conf, repository.NewVMIDRangeRepository(repo), clusterRepo, vmRepo, userRepo, logger)
+	cutoverService := service.NewRemoteMigrationCutoverService(svc, conf, repository.NewRemoteMigrationCutoverRepository(repo), vmRepo, ipRepo,
+		repository.NewMACAddressRepository(repo), clusterRepo, nodeRepo, userRepo, notificationService, logger)
+	imageTransferService := service.NewImageTransferService(svc, conf, repository.NewImageTransferRepository(repo), clusterRepo, nodeRepo, vmRepo, userRepo, changeControlService, notificationService, logger)
+
+	env := &testEnv{
+		conf:           conf,
+		pve:            proxmoxtest.NewServer(),
+		nodes:          make(map[string]*model.PveNode),
+		svc:            svc,
+		repo:           repo,
+		nodeRepo:       nodeRepo,
+		vmRepo:         vmRepo,
+		templateRepo:   templateRepo,
+		uploadRepo:     uploadRepo,
+		instanceRepo:   instanceRepo,
+		syncTaskRepo:   syncTaskRepo,
+		poolRepo:       poolRepo,
+		clusterRepo:    clusterRepo,
+		userRepo:       userRepo,
+		promotionRepo:  promotionRepo,
+		auditRepo:      auditRepo,
+		statusRepo:     statusRepo,
+		storageRepo:    storageRepo,
+		vmTemplateRepo: vmTemplateRepo,
+		logger:         logger,
+		vmService: service.NewPveVMService(svc, conf, vmRepo, vmTemplateRepo, instanceRepo, storageRepo, ipRepo, clusterRepo, nodeRepo, siteRepo, licenseRepo,
+			certRepo, changeControlService, nodeVersionService, vmProfileService, macRegistryService, vmLockService, pendingOperationService,
+			vmCredentialService, templateUsageService, vmTaskTracker, reservations, nodePoolService, vmidRangeService, cutoverService, sharedTokens, logger),
+		templateService: service.NewTemplateManagementService(svc, templateRepo, uploadRepo, instanceRepo,
+			syncTaskRepo, vmRepo, storageRepo, nodeRepo, clusterRepo, jobLeases, logger),
+		credentialService: vmCredentialService,
+		vmidRangeService:  vmidRangeService,
+		vmEventService:    service.NewVMEventService(svc, conf, vmRepo, clusterRepo, auditRepo, statusRepo, userRepo, logger),
+		topologyService:   service.NewTopologyService(svc, repository.NewTopologyRepository(repo), logger),
+		agentInstallService: service.NewVMAgentInstallService(svc, conf, repository.NewVMAgentInstallRepository(repo), vmRepo, nodeRepo,
+			clusterRepo, userRepo, changeControlService, vmLockService, logger),
+		metadataService: service.NewMetadataBackupService(svc, conf, repository.NewMetadataBackupRepository(repo), userRepo,
+			vmCredentialService, logger),
+		promotionService: service.NewTemplatePromotionService(svc, conf, promotionRepo, templateRepo, uploadRepo,
+			instanceRepo, clusterRepo, nodeRepo, storageRepo, vmRepo, userRepo, imageTransferService, notificationService, logger),
+		notificationService:  notificationService,
+		templateUsageService: templateUsageService,
+		firstBootService:     firstBootService,
+		licenseService: service.NewLicenseService(svc, conf, licenseRepo, clusterRepo, nodeRepo, vmRepo, vmTemplateRepo, userRepo,
+			notificationService, logger),
+		osEOLService: service.NewOSEOLService(svc, licenseRepo, vmRepo, clusterRepo, logger),
+		securityService: service.NewVMSecurityService(svc, conf, repository.NewVMSecurityRepository(repo), vmRepo, nodeRepo,
+			clusterRepo, userRepo, changeControlService, vmLockService, logger),
+		endpointService: service.NewClusterEndpointService(svc, clusterRepo, nodeRepo, certRepo,
+			service.NewClusterCapabilityService(svc, clusterRepo, repository.NewClusterCapabilityRepository(repo), logger), logger),
+		cutoverService:  cutoverService,
+		certRepo:        certRepo,
+		ipRepo:          ipRepo,
+		sharedTokens:    sharedTokens,
+		jobLeases:       jobLeases,
+		sharedTokenRepo: sharedTokenRepo,
+		jobLeaseRepo:    jobLeaseRepo,
+	}
+	env.remoteMigration = service.NewRemoteMigrationJobService(svc, conf, repository.NewRemoteMigrationJobRepository(repo), vmRepo,
+		userRepo, env.vmService, notificationService, logger)
+	env.bulkDeleteService = service.NewVMBulkDeleteService(svc, conf, repository.NewVMBulkDeleteJobRepository(repo), env.vmService,
+		vmRepo, clusterRepo, nodeRepo, userRepo, notificationService, sharedTokens, jobLeases)
+	env.protectionService = service.NewVMProtectionService(svc, conf, vmRepo, nodeRepo, clusterRepo, userRepo, logger)
+	env.notesService = service.NewVMNotesService(svc, conf, vmRepo, nodeRepo, clusterRepo, vmLockService, logger)
+	env.snapshotRepo = repository.NewClusterCapacitySnapshotRepository(repo)
+	env.changeService = service.NewDashboardChangeService(svc, clusterRepo, nodeRepo, vmRepo, storageRepo, statusRepo, auditRepo,
+		env.snapshotRepo, logger)
+	env.nodeService = service.NewPveNodeService(svc, nodeRepo, clusterRepo, repository.NewNodeDiskHealthRepository(repo), sharedTokens, logger)
+	env.consoleAudit = service.NewConsoleAuditService(svc, conf, repository.NewConsoleSessionRepository(repo), clusterRepo,
+		repository.NewImageTransferRepository(repo), userRepo, logger)
+	env.consoleProxy = service.NewConsoleProxyService(conf, userRepo, logger)
+	t.Cleanup(env.pve.Close)
+
+	env.pve.RequireToken(testUserID, testToken)
+	for _, name := range []string{"pve1", "pve2"} {
+		env.pve.AddNode(testNode(name, 0))
+	}
+
+	ctx := context.Background()
+	env.cluster = &model.PveCluster{
+		ClusterName:   "integration",
+		ApiUrl:        env.pve.URL,
+		UserId:        testUserID,
+		UserToken:     testToken,
+		IsSchedulable: 1,
+		IsEnabled:     1,
+		CreateTime:    time.Now(),
+		UpdateTime:    time.Now(),
+	}
+	require.NoError(t, clusterRepo.Create(ctx, env.cluster))
+	for _, name := range []string{"pve1", "pve2"} {
+		node := &model.PveNode{
+			NodeName:      name,
+			ClusterID:     env.cluster.Id,
+			IsSchedulable: 1,
+			Status:        "online",
+			CreateTime:    time.Now(),
+			UpdateTime:    time.Now(),
+		}
+		require.NoError(t, nodeRepo.Create(ctx, node))
+		env.nodes[name] = node
+	}
+	return env
+}
+
+// testNode 模拟集群中的节点：local（含 ISO）和 local-lvm 两个存储、网桥 vmbr0，mem 为已用内存（字节）
+func testNode(name string, mem int64) proxmoxtest.Node {
+	return proxmoxtest.Node{
+		Name: name,
+		Mem:  mem,
+		Storages: []proxmoxtest.Storage{
+			{Name: "local", Content: "iso,vztmpl,backup", Volumes: []string{"local:iso/debian-12.iso"}},
+			{Name: "local-lvm", Type: "lvmthin", Content: "images,rootdir"},
+		},
+		Bridges: []string{"vmbr0"},
+	}
+}
+
+// addVM 在模拟集群和数据库中同时登记一台虚拟机（模拟上报同步后的状态）
+func (e *testEnv) addVM(t *testing.T, nodeName string, vmid uint32, name, status string) *model.PveVM {
+	t.Helper()
+	e.pve.AddVM(proxmoxtest.VM{
+		VMID:   vmid,
+		Node:   nodeName,
+		Name:   name,
+		Status: status,
+		Config: map[string]string{"memory": "2048", "cores": "2", "scsi0": fmt.Sprintf("local-lvm:vm-%d-disk-0,size=32G", vmid)},
+	})
+	vm := &model.PveVM{
+		VmName:     name,
+		ClusterID:  e.cluster.Id,
+		NodeID:     e.nodes[nodeName].Id,
+		VMID:       vmid,
+		CPUNum:     2,
+		MemorySize: 2048,
+		Status:     status,
+		CreateTime: time.Now(),
+		UpdateTime: time.Now(),
+	}
+	require.NoError(t, e.vmRepo.Create(context.Background(), vm))
+	return vm
+}
+
+// eventually 轮询 cond 直到返回 true 或超时
+func eventually(t *testing.T, timeout time.Duration, cond func() bool, msg string) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	t.Fatalf("timed out after %s: %s", timeout, msg)
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPassphrase = "correct-horse-battery"
+
+// emptyEnv 返回一个清空了集群和节点的环境，模拟新部署的实例（仅保留管理员账号）
+func emptyEnv(t *testing.T) (*testEnv, string) {
+	t.Helper()
+	ctx := context.Background()
+	env := newTestEnv(t)
+	for _, node := range env.nodes {
+		require.NoError(t, env.nodeRepo.Delete(ctx, node.Id))
+	}
+	require.NoError(t, env.clusterRepo.Delete(ctx, env.cluster.Id))
+	return env, env.addUser(t, "admin")
+}
+
+func TestMetadataExportImport(t *testing.T) {
+	for _, format := range []string{"json", "yaml"} {
+		t.Run(format, func(t *testing.T) {
+			ctx := context.Background()
+			src := newTestEnv(t)
+			srcAdmin := src.addUser(t, "admin")
+			vm := src.addVM(t, "pve1", 100, "web", "running")
+			require.NoError(t, src.credentialService.SetPassword(ctx, vm, "vm-secret-password"))
+			templateID := src.addLocalTemplate(t)
+
+			data, contentType, err := src.metadataService.Export(ctx, srcAdmin, &v1.ExportMetadataRequest{Passphrase: testPassphrase, Format: format})
+			require.NoError(t, err)
+			assert.Contains(t, contentType, format)
+			assert.NotContains(t, string(data), testToken)
+			assert.NotContains(t, string(data), "vm-secret-password")
+
+			dst, dstAdmin := emptyEnv(t)
+			_, err = dst.metadataService.Import(ctx, dstAdmin, &v1.ImportMetadataRequest{Passphrase: "wrong-passphrase"}, data)
+			assert.ErrorIs(t, err, v1.ErrMetadataPassphrase)
+
+			preview, err := dst.metadataService.Import(ctx, dstAdmin, &v1.ImportMetadataRequest{Passphrase: testPassphrase, DryRun: true}, data)
+			require.NoError(t, err)
+			assert.Equal(t, 1, preview.Counts["clusters"])
+			assert.Equal(t, 2, preview.Counts["nodes"])
+			assert.Equal(t, 1, preview.Counts["vms"])
+			assert.Equal(t, 1, preview.Counts["templates"])
+			assert.Equal(t, 2, preview.Secrets)
+			cluster, err := dst.clusterRepo.GetByID(ctx, src.cluster.Id)
+			require.NoError(t, err)
+			assert.Nil(t, cluster, "dry run must not write")
+
+			result, err := dst.metadataService.Import(ctx, dstAdmin, &v1.ImportMetadataRequest{Passphrase: testPassphrase}, data)
+			require.NoError(t, err)
+			assert.Empty(t, result.Warnings)
+
+			cluster, err = dst.clusterRepo.GetByID(ctx, src.cluster.Id)
+			require.NoError(t, err)
+			require.NotNil(t, cluster)
+			assert.Equal(t, testToken, cluster.UserToken)
+			template, err := dst.templateRepo.GetByID(ctx, templateID)
+			require.NoError(t, err)
+			require.NotNil(t, template)
+
+			// 虚拟机密码使用新实例的密钥重新加密，旧实例的密文在新实例上无法解密
+			restored, err := dst.vmRepo.GetByID(ctx, vm.Id)
+			require.NoError(t, err)
+			require.NotNil(t, restored)
+			assert.NotEmpty(t, restored.VmPasswordRef)
+			assert.NotEqual(t, vm.VmPasswordRef, restored.VmPasswordRef)
+			password, err := dst.credentialService.Reveal(ctx, "admin", restored)
+			require.NoError(t, err)
+			assert.Equal(t, "vm-secret-password", password)
+			_, err = dst.credentialService.Reveal(ctx, "admin", vm)
+			assert.Error(t, err)
+
+			_, err = dst.metadataService.Import(ctx, dstAdmin, &v1.ImportMetadataRequest{Passphrase: testPassphrase}, data)
+			assert.ErrorIs(t, err, v1.ErrMetadataTargetNotEmpty)
+		})
+	}
+}
+
+func TestMetadataImport_InvalidBundle(t *testing.T) {
+	ctx := context.Background()
+	env, admin := emptyEnv(t)
+
+	_, err := env.metadataService.Import(ctx, admin, &v1.ImportMetadataRequest{Passphrase: testPassphrase}, []byte("not: [a bundle"))
+	assert.ErrorIs(t, err, v1.ErrMetadataBundleInvalid)
+
+	_, err = env.metadataService.Import(ctx, admin, &v1.ImportMetadataRequest{Passphrase: testPassphrase}, []byte(`{"format_version": 99}`))
+	assert.ErrorIs(t, err, v1.ErrMetadataBundleVersion)
+}
@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// lastRequest 返回最后一个匹配方法和路径的请求参数
+func (e *testEnv) lastRequest(t *testing.T, method, path string) map[string][]string {
+	t.Helper()
+	requests := e.pve.Requests()
+	for i := len(requests) - 1; i >= 0; i-- {
+		if requests[i].Method == method && requests[i].Path == path {
+			return requests[i].Params
+		}
+	}
+	t.Fatalf("no %s %s request", method, path)
+	return nil
+}
+
+func TestNodeConsole_VncShellSizeAndKeyboard(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.pve.SetClusterOption("keyboard", "de")
+
+	result, err := env.nodeService.GetNodeConsole(ctx, "u1", &v1.GetNodeConsoleRequest{
+		NodeID: env.nodes["pve1"].Id, ConsoleType: "vncshell", Websocket: true, Width: 1280, Height: 800,
+	})
+	require.NoError(t, err)
+	// 未指定键盘布局时使用数据中心的 keyboard
+	require.Equal(t, "de", result["keyboard"])
+	params := env.lastRequest(t, "POST", "/nodes/pve1/vncshell")
+	require.Equal(t, []string{"1280"}, params["width"])
+	require.Equal(t, []string{"800"}, params["height"])
+
+	result, err = env.nodeService.GetNodeConsole(ctx, "u1", &v1.GetNodeConsoleRequest{
+		NodeID: env.nodes["pve1"].Id, ConsoleType: "vncshell", Websocket: true, Keyboard: "fr-ch",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "fr-ch", result["keyboard"])
+	params = env.lastRequest(t, "POST", "/nodes/pve1/vncshell")
+	require.Empty(t, params["width"])
+
+	// vncshell 透明转发，客户端自行处理协议
+	conn, target, err := env.nodeService.DialNodeConsoleWebsocket(ctx, result["ws_token"].(string))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, "vncshell", target.ConsoleType)
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, []byte("RFB 003.008\n")))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "RFB 003.008\n", string(msg))
+}
+
+func TestNodeConsole_XtermjsHandshakeAndResize(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	result, err := env.nodeService.GetNodeConsole(ctx, "u1", &v1.GetNodeConsoleRequest{
+		NodeID: env.nodes["pve2"].Id, ConsoleType: "xtermjs", Cols: 132, Rows: 43,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "xtermjs", result["console_type"])
+	require.Equal(t, 132, result["cols"])
+	require.Equal(t, 43, result["rows"])
+	// 数据中心没有设置 keyboard 时默认 en-us
+	require.Equal(t, "en-us", result["keyboard"])
+	require.Equal(t, 1, env.pve.CountRequests("POST", "/nodes/pve2/termproxy"))
+
+	// 代理已完成认证并设置初始大小
+	conn, target, err := env.nodeService.DialNodeConsoleWebsocket(ctx, result["ws_token"].(string))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, "xtermjs", target.ConsoleType)
+	eventually(t, 5*time.Second, func() bool {
+		messages := env.pve.TermMessages()
+		return len(messages) == 1 && messages[0] == "1:132:43:"
+	}, "initial resize message sent")
+
+	// 非 ASCII 输入按 UTF-8 字节数封装，Proxmox 端不会截断
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, proxmox.TermProxyClientMessage([]byte("echo ä€\r"))))
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	require.NoError(t, err)
+	require.Equal(t, "echo ä€\r", string(msg))
+
+	// 会话中调整大小，超出范围的消息被丢弃
+	require.Nil(t, proxmox.TermProxyClientMessage([]byte(`{"type":"resize","cols":0,"rows":40}`)))
+	require.NoError(t, conn.WriteMessage(websocket.BinaryMessage, proxmox.TermProxyClientMessage([]byte(`{"type":"resize","cols":200,"rows":50}`))))
+	eventually(t, 5*time.Second, func() bool {
+		messages := env.pve.TermMessages()
+		return len(messages) == 3 && messages[2] == "1:200:50:"
+	}, "resize message forwarded")
+	require.Equal(t, "0:11:echo ä€\r", env.pve.TermMessages()[1])
+
+	// ws_token 只能使用一次
+	_, _, err = env.nodeService.DialNodeConsoleWebsocket(ctx, result["ws_token"].(string))
+	require.ErrorIs(t, err, v1.ErrNotFound)
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nodeNames(items []v1.NodeItem) []string {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.NodeName)
+	}
+	return names
+}
+
+func TestListNodesWithMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	// pve1：内存 48/64 GiB、CPU 60%、local-lvm 剩余 100 GiB；pve2：内存 8/64 GiB、CPU 10%、local-lvm 剩余 1 TiB
+	busy := testNode("pve1", 48<<30)
+	busy.CPU = 0.6
+	busy.Storages[1].Used = 1<<40 - 100<<30
+	env.pve.AddNode(busy)
+	idle := testNode("pve2", 8<<30)
+	idle.CPU = 0.1
+	env.pve.AddNode(idle)
+	env.addVM(t, "pve1", 100, "web-1", "running")
+	env.addVM(t, "pve1", 101, "web-2", "running")
+	env.addVM(t, "pve2", 102, "batch", "stopped")
+
+	// 不需要实时指标时不访问 Proxmox
+	data, err := env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), data.Total)
+	assert.Nil(t, data.List[0].Metrics)
+	assert.Zero(t, env.pve.CountRequests("GET", "/cluster/resources"))
+
+	data, err = env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 1, PageSize: 10, Storage: "local-lvm", SortBy: "memory"})
+	require.NoError(t, err)
+	require.Len(t, data.List, 2)
+	assert.Equal(t, []string{"pve2", "pve1"}, nodeNames(data.List))
+	m := data.List[1].Metrics
+	require.NotNil(t, m)
+	assert.True(t, m.Online)
+	assert.Equal(t, 60.0, m.CPUPercent)
+	assert.Equal(t, 75.0, m.MemoryPercent)
+	assert.Equal(t, int64(16<<30), m.MemoryFree)
+	assert.Equal(t, 2, m.RunningVMs)
+	require.NotNil(t, m.StorageFree)
+	assert.Equal(t, int64(100<<30), *m.StorageFree)
+	assert.Equal(t, 0, data.List[0].Metrics.RunningVMs)
+
+	data, err = env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 1, PageSize: 10, SortBy: "running_vms", Order: "desc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pve1", "pve2"}, nodeNames(data.List))
+	assert.Nil(t, data.List[0].Metrics.StorageFree)
+
+	// 过滤：CPU 不超过 50% 或 local-lvm 剩余不少于 200 GiB 的都只有 pve2
+	data, err = env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 1, PageSize: 10, MaxCPUPercent: 50})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), data.Total)
+	assert.Equal(t, []string{"pve2"}, nodeNames(data.List))
+	data, err = env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 1, PageSize: 10, Storage: "local-lvm", MinStorageFreeGB: 200})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"pve2"}, nodeNames(data.List))
+
+	// 排序后再分页
+	data, err = env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 2, PageSize: 1, Storage: "local-lvm", SortBy: "storage_free", Order: "desc"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), data.Total)
+	assert.Equal(t, []string{"pve1"}, nodeNames(data.List))
+
+	_, err = env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 1, PageSize: 10, SortBy: "storage_free"})
+	assert.ErrorIs(t, err, v1.ErrBadRequest)
+
+	// 集群无法连接时仍然返回节点，只是没有实时指标，也不满足实时指标过滤条件
+	env.pve.FailRequests("GET", "/cluster/resources", 500, 2)
+	data, err = env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 1, PageSize: 10, WithMetrics: true})
+	require.NoError(t, err)
+	require.Len(t, data.List, 2)
+	assert.Nil(t, data.List[0].Metrics)
+	data, err = env.nodeService.ListNodes(ctx, &v1.ListNodeRequest{Page: 1, PageSize: 10, MaxMemoryPercent: 90})
+	require.NoError(t, err)
+	assert.Zero(t, data.Total)
+}
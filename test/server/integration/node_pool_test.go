@@ -0,0 +1,116 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addNodePool 登记包含 pve1、pve2 的节点池
+func (e *testEnv) addNodePool(t *testing.T, pool *model.NodePool) *model.NodePool {
+	t.Helper()
+	ctx := context.Background()
+	pool.ClusterID = e.cluster.Id
+	require.NoError(t, e.poolRepo.Create(ctx, pool))
+	require.NoError(t, e.poolRepo.ReplaceMembers(ctx, pool.Id, []int64{e.nodes["pve1"].Id, e.nodes["pve2"].Id}))
+	return pool
+}
+
+func poolCreateRequest(env *testEnv, poolID int64, vmid uint32, name string) *v1.CreateVMRequest {
+	req := isoCreateRequest(env, vmid, name)
+	req.NodeID = 0
+	req.NodePoolID = poolID
+	return req
+}
+
+func TestCreateVM_NodePoolPicksLeastLoadedNode(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	pool := env.addNodePool(t, &model.NodePool{Name: "ssd-nodes"})
+
+	// pve1 已用 48 GiB，pve2 空闲
+	env.pve.AddNode(testNode("pve1", 48<<30))
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, poolCreateRequest(env, pool.Id, 501, "pool-01")))
+
+	vm, ok := env.pve.VM(501)
+	require.True(t, ok)
+	assert.Equal(t, "pve2", vm.Node)
+	record, err := env.vmRepo.GetByVMID(ctx, 501, env.nodes["pve2"].Id)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+}
+
+func TestCreateVM_NodePoolSkipsUnschedulableNode(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	pool := env.addNodePool(t, &model.NodePool{Name: "dmz"})
+
+	// pve2 空闲但不可调度，pve1 内存不足
+	pve2 := env.nodes["pve2"]
+	pve2.IsSchedulable = 0
+	require.NoError(t, env.nodeRepo.Update(ctx, pve2))
+	env.pve.AddNode(testNode("pve1", 63<<30))
+
+	err := env.vmService.CreateVMInProxmox(ctx, poolCreateRequest(env, pool.Id, 502, "pool-02"))
+	require.ErrorIs(t, err, v1.ErrNodePoolNoCapacity)
+	assert.Contains(t, err.Error(), "pve2: not schedulable")
+	assert.Contains(t, err.Error(), "pve1: only")
+	_, ok := env.pve.VM(502)
+	assert.False(t, ok)
+}
+
+func TestCreateVM_NodePoolQuota(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	pool := env.addNodePool(t, &model.NodePool{Name: "gpu-nodes", MaxVMs: 2, MaxMemoryMB: 8192})
+	env.addVM(t, "pve1", 601, "gpu-01", "running") // 2048 MB
+
+	// 内存配额：已用 2048 + 8192 > 8192
+	req := poolCreateRequest(env, pool.Id, 602, "gpu-02")
+	memory := 8192
+	req.MemorySize = &memory
+	err := env.vmService.CreateVMInProxmox(ctx, req)
+	require.ErrorIs(t, err, v1.ErrNodePoolQuotaExceeded)
+	assert.Contains(t, err.Error(), "MiB of memory")
+
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, poolCreateRequest(env, pool.Id, 603, "gpu-03")))
+
+	// 虚拟机数配额已满，直接指定池内节点同样受限
+	err = env.vmService.CreateVMInProxmox(ctx, isoCreateRequest(env, 604, "gpu-04"))
+	require.ErrorIs(t, err, v1.ErrNodePoolQuotaExceeded)
+	assert.Contains(t, err.Error(), "already has 2 of 2 vms")
+	_, ok := env.pve.VM(604)
+	assert.False(t, ok)
+}
+
+func TestCreateVM_SystemReservation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	pool := env.addNodePool(t, &model.NodePool{Name: "reserved"})
+
+	// 两个节点都已用 47 GiB：不预留时 2 GiB 的虚拟机放得下，预留 25%（16 GiB）后只剩 1 GiB
+	env.pve.AddNode(testNode("pve1", 47<<30))
+	env.pve.AddNode(testNode("pve2", 47<<30))
+	env.cluster.ReservedMemoryPercent = 25
+	require.NoError(t, env.clusterRepo.Update(ctx, env.cluster))
+
+	err := env.vmService.CreateVMInProxmox(ctx, poolCreateRequest(env, pool.Id, 503, "pool-03"))
+	require.ErrorIs(t, err, v1.ErrNodePoolNoCapacity)
+	assert.Contains(t, err.Error(), "pve1: only 1024 MiB of memory available")
+
+	err = env.vmService.CreateVMInProxmox(ctx, isoCreateRequest(env, 504, "direct-04"))
+	require.ErrorIs(t, err, v1.ErrCreateVMValidationFailed)
+	assert.Contains(t, err.Error(), "25% of node memory is reserved for system overhead")
+	_, ok := env.pve.VM(504)
+	assert.False(t, ok)
+
+	// 取消预留后可以创建
+	env.cluster.ReservedMemoryPercent = 0
+	require.NoError(t, env.clusterRepo.Update(ctx, env.cluster))
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, poolCreateRequest(env, pool.Id, 503, "pool-03")))
+}
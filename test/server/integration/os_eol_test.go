@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOSEOLDetection(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	bionic := env.addRunningVM(t, 300, "legacy-01", &proxmoxtest.GuestAgent{
+		OSInfo: map[string]interface{}{"id": "ubuntu", "name": "Ubuntu", "pretty-name": "Ubuntu 18.04.6 LTS", "version-id": "18.04"},
+	})
+	stream := env.addRunningVM(t, 301, "build-01", &proxmoxtest.GuestAgent{
+		OSInfo: map[string]interface{}{"id": "centos", "name": "CentOS Stream", "pretty-name": "CentOS Stream 8", "version-id": "8"},
+	})
+	env.addRunningVM(t, 302, "web-01", &proxmoxtest.GuestAgent{
+		OSInfo: map[string]interface{}{"id": "ubuntu", "name": "Ubuntu", "pretty-name": "Ubuntu 24.04.1 LTS", "version-id": "24.04"},
+	})
+	env.addRunningVM(t, 303, "no-agent", nil)
+
+	require.NoError(t, env.licenseService.CollectOSInfo(ctx))
+
+	// 列表只返回已停止维护的虚拟机，并带上匹配结果
+	list, err := env.vmService.ListVMs(ctx, &v1.ListVMRequest{Page: 1, PageSize: 10, OSEOL: v1.OSEOLStatusEOL})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, list.Total)
+	byID := make(map[int64]v1.VMItem)
+	for _, item := range list.List {
+		byID[item.Id] = item
+	}
+	require.Contains(t, byID, bionic)
+	assert.Equal(t, "Ubuntu 18.04.6 LTS", byID[bionic].OSName)
+	assert.Equal(t, v1.OSEOLStatusEOL, byID[bionic].OSEOLStatus)
+	require.NotNil(t, byID[bionic].OSEOLDate)
+	assert.Equal(t, "2023-05-31", byID[bionic].OSEOLDate.Format("2006-01-02"))
+	// CentOS Stream 8 不应被识别为 CentOS 8
+	require.Contains(t, byID, stream)
+	assert.Equal(t, "2024-05-31", byID[stream].OSEOLDate.Format("2006-01-02"))
+
+	all, err := env.vmService.ListVMs(ctx, &v1.ListVMRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.EqualValues(t, 4, all.Total)
+	for _, item := range all.List {
+		switch item.VmName {
+		case "web-01":
+			assert.Equal(t, "Ubuntu 24.04.1 LTS", item.OSName)
+			assert.Empty(t, item.OSEOLStatus)
+		case "no-agent":
+			assert.Empty(t, item.OSName)
+			assert.Nil(t, item.OSEOLDate)
+		}
+	}
+
+	report, err := env.osEOLService.Report(ctx, &v1.OSEOLReportRequest{ClusterID: env.cluster.Id})
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.TotalVMs)
+	assert.Equal(t, 3, report.Identified)
+	assert.Equal(t, 1, report.Unknown)
+	assert.Equal(t, 2, report.EOL)
+	require.Len(t, report.Groups, 2)
+	assert.Equal(t, "Ubuntu 18.04", report.Groups[0].OSName)
+	assert.Equal(t, "CentOS Stream 8", report.Groups[1].OSName)
+	require.Len(t, report.VMs, 2)
+	assert.Equal(t, "legacy-01", report.VMs[0].VmName)
+	assert.Equal(t, "integration", report.VMs[0].ClusterName)
+
+	filtered, err := env.osEOLService.Report(ctx, &v1.OSEOLReportRequest{Status: v1.OSEOLStatusEOLSoon})
+	require.NoError(t, err)
+	assert.Equal(t, 2, filtered.EOL)
+	assert.Empty(t, filtered.VMs)
+
+	_, err = env.osEOLService.Report(ctx, &v1.OSEOLReportRequest{ClusterID: 999})
+	assert.ErrorIs(t, err, v1.ErrClusterNotFound)
+}
+
+func TestOSEOLTemplateCloneAlert(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	adminID := env.addUser(t, "admin")
+	aliceID := env.addUser(t, "alice")
+
+	eol := &model.VmTemplate{TemplateName: "ubuntu-18.04-base", ClusterID: env.cluster.Id, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	current := &model.VmTemplate{TemplateName: "ubuntu-24.04-base", ClusterID: env.cluster.Id, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	require.NoError(t, env.vmTemplateRepo.Create(ctx, eol))
+	require.NoError(t, env.vmTemplateRepo.Create(ctx, current))
+
+	vm := env.addVM(t, "pve1", 310, "from-eol", "running")
+	vm.TemplateID = eol.Id
+	env.templateUsageService.RecordClone(ctx, vm, nil, "create", "alice")
+	other := env.addVM(t, "pve1", 311, "from-current", "running")
+	other.TemplateID = current.Id
+	env.templateUsageService.RecordClone(ctx, other, nil, "create", "alice")
+
+	for _, userID := range []string{adminID, aliceID} {
+		data, err := env.notificationService.List(ctx, userID, &v1.ListNotificationsRequest{Category: "alert"})
+		require.NoError(t, err)
+		require.Len(t, data.List, 1)
+		n := data.List[0]
+		assert.Equal(t, "vm_created_from_eol_template", n.Event)
+		assert.Equal(t, "warning", n.Level)
+		assert.Contains(t, n.Title, "ubuntu-18.04-base")
+		assert.Contains(t, n.Content, "Ubuntu 18.04")
+	}
+}
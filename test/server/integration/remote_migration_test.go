@@ -0,0 +1,297 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addRemoteTarget 登记跨集群迁移的目标集群（节点 pve3，local-lvm 容量为 capacity 字节）
+func (e *testEnv) addRemoteTarget(t *testing.T, capacity int64) (*proxmoxtest.Server, *model.PveCluster, *model.PveNode) {
+	t.Helper()
+	ctx := context.Background()
+	target := newEndpointServer(t)
+	node := testNode("pve3", 0)
+	node.Storages[1].Total = capacity
+	target.AddNode(node)
+
+	cluster := &model.PveCluster{
+		ClusterName: "target", ApiUrl: target.URL, UserId: testUserID, UserToken: testToken,
+		IsEnabled: 1, CreateTime: time.Now(), UpdateTime: time.Now(),
+	}
+	require.NoError(t, e.clusterRepo.Create(ctx, cluster))
+	pveNode := &model.PveNode{NodeName: "pve3", ClusterID: cluster.Id, Status: "online", CreateTime: time.Now(), UpdateTime: time.Now()}
+	require.NoError(t, e.nodeRepo.Create(ctx, pveNode))
+	return target, cluster, pveNode
+}
+
+func preflightCodes(issues []v1.MigrationCheckIssue) []string {
+	codes := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		codes = append(codes, issue.Code)
+	}
+	return codes
+}
+
+func TestRemoteMigratePreflight(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	_, small, smallNode := env.addRemoteTarget(t, 16<<30)
+	vm := env.addVM(t, "pve1", 400, "mover", "stopped")
+	req := &v1.RemoteMigrateVMRequest{
+		VMID: vm.Id, TargetClusterID: small.Id, TargetNodeID: smallNode.Id,
+		TargetBridge: "vmbr9", TargetStorage: "local-lvm",
+	}
+
+	// 32G 磁盘放不进 16G 的存储，目标节点也没有 vmbr9
+	data, err := env.vmService.RemoteMigratePreflight(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, data.Passed)
+	assert.Equal(t, int64(32<<30), data.RequiredBytes)
+	assert.ElementsMatch(t, []string{"storage_insufficient", "bridge_missing"}, preflightCodes(data.Blockers))
+
+	_, err = env.vmService.RemoteMigrateVM(ctx, req)
+	require.ErrorIs(t, err, v1.ErrRemoteMigratePreflightFailed)
+	assert.Zero(t, env.pve.CountRequests("POST", "/nodes/pve1/qemu/400/remote_migrate"))
+
+	// 目标存储不存在
+	req.TargetBridge, req.TargetStorage = "vmbr0", "ceph"
+	data, err = env.vmService.RemoteMigratePreflight(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"target_storage"}, preflightCodes(data.Blockers))
+
+	// force 时忽略阻断问题
+	force := true
+	req.Force = &force
+	_, err = env.vmService.RemoteMigrateVM(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 1, env.pve.CountRequests("POST", "/nodes/pve1/qemu/400/remote_migrate"))
+
+	// 目标空间足够时通过，位于迁移窗口内时使用窗口的带宽限制
+	_, large, largeNode := env.addRemoteTarget(t, 1<<40)
+	env.conf.Set("remote_migration.windows", []map[string]interface{}{
+		{"name": "always", "start": "00:00", "end": "00:00", "bwlimit": 2048},
+	})
+	req = &v1.RemoteMigrateVMRequest{
+		VMID: vm.Id, TargetClusterID: large.Id, TargetNodeID: largeNode.Id,
+		TargetBridge: "vmbr0", TargetStorage: "local-lvm",
+	}
+	data, err = env.vmService.RemoteMigratePreflight(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, data.Passed, "blockers: %v", data.Blockers)
+	assert.Equal(t, 2048, data.Bwlimit)
+	assert.Equal(t, "window", data.BwlimitSource)
+	assert.Equal(t, "always", data.Window)
+
+	_, err = env.vmService.RemoteMigrateVM(ctx, req)
+	require.NoError(t, err)
+	requests := env.pve.Requests()
+	last := requests[len(requests)-1]
+	for i := len(requests) - 1; i >= 0; i-- {
+		if requests[i].Path == "/nodes/pve1/qemu/400/remote_migrate" {
+			last = requests[i]
+			break
+		}
+	}
+	assert.Equal(t, "2048", last.Params.Get("bwlimit"))
+
+	// 请求指定的带宽限制优先
+	bwlimit := 512
+	req.Bwlimit = &bwlimit
+	data, err = env.vmService.RemoteMigratePreflight(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 512, data.Bwlimit)
+	assert.Equal(t, "request", data.BwlimitSource)
+}
+
+func TestScheduleRemoteMigration(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	aliceID := env.addUser(t, "alice")
+	bobID := env.addUser(t, "bob")
+
+	_, cluster, node := env.addRemoteTarget(t, 1<<40)
+	vm := env.addVM(t, "pve1", 400, "mover", "stopped")
+	// later 窗口只在后天开放
+	later := int(time.Now().AddDate(0, 0, 2).Weekday())
+	env.conf.Set("remote_migration.windows", []map[string]interface{}{
+		{"name": "always", "start": "00:00", "end": "00:00", "bwlimit": 4096},
+		{"name": "later", "weekdays": []int{later}, "start": "01:00", "end": "05:00", "bwlimit": 1024},
+	})
+	migrate := v1.RemoteMigrateVMRequest{
+		VMID: vm.Id, TargetClusterID: cluster.Id, TargetNodeID: node.Id,
+		TargetBridge: "vmbr0", TargetStorage: "local-lvm",
+	}
+
+	_, err := env.remoteMigration.Schedule(ctx, aliceID, &v1.ScheduleRemoteMigrationRequest{RemoteMigrateVMRequest: migrate})
+	require.ErrorIs(t, err, v1.ErrBadRequest)
+	_, err = env.remoteMigration.Schedule(ctx, aliceID, &v1.ScheduleRemoteMigrationRequest{RemoteMigrateVMRequest: migrate, Window: "weekend"})
+	require.ErrorIs(t, err, v1.ErrMigrationWindowNotFound)
+
+	// 预检未通过时不登记
+	bad := migrate
+	bad.TargetBridge = "vmbr9"
+	_, err = env.remoteMigration.Schedule(ctx, aliceID, &v1.ScheduleRemoteMigrationRequest{RemoteMigrateVMRequest: bad, Window: "always"})
+	require.ErrorIs(t, err, v1.ErrRemoteMigratePreflightFailed)
+
+	// 下一个 later 窗口开始时发起
+	pending, err := env.remoteMigration.Schedule(ctx, aliceID, &v1.ScheduleRemoteMigrationRequest{RemoteMigrateVMRequest: migrate, Window: "later"})
+	require.NoError(t, err)
+	assert.Equal(t, model.RemoteMigrationJobScheduled, pending.Status)
+	assert.Equal(t, time.Weekday(later), pending.ScheduledAt.Weekday())
+	assert.Equal(t, 1, pending.ScheduledAt.Hour())
+	require.NotNil(t, pending.Preflight)
+	assert.True(t, pending.Preflight.Passed)
+
+	// 当前窗口内：立即到期，发起时使用窗口的带宽限制
+	due, err := env.remoteMigration.Schedule(ctx, aliceID, &v1.ScheduleRemoteMigrationRequest{RemoteMigrateVMRequest: migrate, Window: "always"})
+	require.NoError(t, err)
+	processed, err := env.remoteMigration.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.Equal(t, 1, env.pve.CountRequests("POST", "/nodes/pve1/qemu/400/remote_migrate"))
+
+	list, err := env.remoteMigration.List(ctx, &v1.ListRemoteMigrationJobsRequest{VmId: vm.Id})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, list.Total)
+	assert.Equal(t, due.Id, list.List[0].Id)
+	assert.Equal(t, model.RemoteMigrationJobStarted, list.List[0].Status)
+	assert.NotEmpty(t, list.List[0].UPID)
+	assert.Equal(t, 4096, list.List[0].Bwlimit)
+	assert.Equal(t, model.RemoteMigrationJobScheduled, list.List[1].Status)
+
+	// 仅创建人或管理员可取消，取消后不再发起
+	require.ErrorIs(t, env.remoteMigration.Cancel(ctx, bobID, pending.Id), v1.ErrAdminRequired)
+	require.NoError(t, env.remoteMigration.Cancel(ctx, aliceID, pending.Id))
+	require.ErrorIs(t, env.remoteMigration.Cancel(ctx, aliceID, pending.Id), v1.ErrRemoteMigrationJobFinished)
+	require.ErrorIs(t, env.remoteMigration.Cancel(ctx, aliceID, 999), v1.ErrRemoteMigrationJobNotFound)
+
+	processed, err = env.remoteMigration.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, processed)
+}
+
+func TestRemoteMigrationCutover(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	adminID := env.addUser(t, "admin")
+
+	var dnsPayloads []map[string]interface{}
+	dnsStatus := http.StatusInternalServerError
+	dns := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		dnsPayloads = append(dnsPayloads, payload)
+		w.WriteHeader(dnsStatus)
+	}))
+	defer dns.Close()
+	env.conf.Set("remote_migration.dns_webhook", dns.URL)
+
+	target, cluster, node := env.addRemoteTarget(t, 1<<40)
+	vm := env.addVM(t, "pve1", 400, "mover", "running")
+	for _, ip := range []*model.VMIPAddress{
+		{IPAddress: "10.1.0.5", NicName: "net0", VMId: vm.Id, ClusterID: env.cluster.Id, MacAddress: "BC:24:11:00:00:05"},
+		{IPAddress: "10.1.0.6", NicName: "net1", VMId: vm.Id, ClusterID: env.cluster.Id},
+		{IPAddress: "10.2.0.1", ClusterID: cluster.Id}, // 与网关相同，不分配
+		{IPAddress: "10.2.0.10", ClusterID: cluster.Id},
+	} {
+		require.NoError(t, env.ipRepo.Create(ctx, ip))
+	}
+
+	// 迁移任务失败：不切换，也不能重试
+	failed := env.addVM(t, "pve2", 401, "stuck", "stopped")
+	env.pve.FailTasks("qmigrate", "migration aborted")
+	_, err := env.vmService.RemoteMigrateVM(ctx, &v1.RemoteMigrateVMRequest{
+		VMID: failed.Id, TargetClusterID: cluster.Id, TargetNodeID: node.Id, TargetBridge: "vmbr0", TargetStorage: "local-lvm",
+	})
+	require.NoError(t, err)
+	env.pve.FailTasks("qmigrate", "")
+
+	targetVMID := int64(500)
+	_, err = env.vmService.RemoteMigrateVM(ctx, &v1.RemoteMigrateVMRequest{
+		VMID: vm.Id, TargetClusterID: cluster.Id, TargetNodeID: node.Id, TargetBridge: "vmbr0", TargetStorage: "local-lvm",
+		TargetVMID: &targetVMID,
+		ReIP:       []v1.RemoteMigrateReIP{{NicName: "net0", Subnet: "10.2.0.0/24", Gateway: "10.2.0.1"}},
+	})
+	require.NoError(t, err)
+
+	list, err := env.cutoverService.List(ctx, &v1.ListRemoteMigrationCutoversRequest{Status: model.RemoteMigrationCutoverWaiting})
+	require.NoError(t, err)
+	require.EqualValues(t, 2, list.Total)
+
+	// 模拟 Proxmox 完成迁移：目标集群出现虚拟机
+	target.AddVM(proxmoxtest.VM{VMID: 500, Node: "pve3", Name: "mover", Status: "running"})
+	processed, err := env.cutoverService.RunPending(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, processed)
+
+	list, err = env.cutoverService.List(ctx, &v1.ListRemoteMigrationCutoversRequest{VmId: failed.Id})
+	require.NoError(t, err)
+	require.Len(t, list.List, 1)
+	assert.Equal(t, model.RemoteMigrationCutoverFailed, list.List[0].Status)
+	assert.Contains(t, list.List[0].ErrorMessage, "migration aborted")
+	_, err = env.cutoverService.Retry(ctx, adminID, list.List[0].Id)
+	require.ErrorIs(t, err, v1.ErrRemoteMigrationCutoverNotRetryable)
+	unchanged, err := env.vmRepo.GetByID(ctx, failed.Id)
+	require.NoError(t, err)
+	assert.Equal(t, env.cluster.Id, unchanged.ClusterID)
+
+	// DNS webhook 失败：其余步骤已完成，切换记为失败
+	list, err = env.cutoverService.List(ctx, &v1.ListRemoteMigrationCutoversRequest{VmId: vm.Id})
+	require.NoError(t, err)
+	require.Len(t, list.List, 1)
+	cutover := list.List[0]
+	assert.Equal(t, model.RemoteMigrationCutoverFailed, cutover.Status)
+	steps := make(map[string]string)
+	for _, step := range cutover.Steps {
+		steps[step.Step] = step.Status
+	}
+	assert.Equal(t, map[string]string{"migration": "ok", "inventory": "ok", "ip": "ok", "cloudinit": "ok", "mac": "ok", "dns": "failed"}, steps)
+	require.Len(t, cutover.Addresses, 1)
+	assert.Equal(t, "10.1.0.5", cutover.Addresses[0].OldAddress)
+	assert.Equal(t, "10.2.0.10", cutover.Addresses[0].NewAddress)
+
+	moved, err := env.vmRepo.GetByID(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, cluster.Id, moved.ClusterID)
+	assert.Equal(t, node.Id, moved.NodeID)
+	assert.Equal(t, uint32(500), moved.VMID)
+
+	ips, err := env.ipRepo.GetByVMID(ctx, vm.Id)
+	require.NoError(t, err)
+	addresses := make(map[string]string)
+	for _, ip := range ips {
+		assert.Equal(t, cluster.Id, ip.ClusterID)
+		addresses[ip.NicName] = ip.IPAddress
+	}
+	assert.Equal(t, map[string]string{"net0": "10.2.0.10", "net1": "10.1.0.6"}, addresses)
+
+	migrated, ok := target.VM(500)
+	require.True(t, ok)
+	assert.Equal(t, "ip=10.2.0.10/24,gw=10.2.0.1", migrated.Config["ipconfig0"])
+
+	// 重试只重新执行切换，沿用已分配的地址
+	dnsStatus = http.StatusOK
+	item, err := env.cutoverService.Retry(ctx, adminID, cutover.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.RemoteMigrationCutoverCompleted, item.Status)
+	assert.Equal(t, cutover.Addresses, item.Addresses)
+	require.Len(t, dnsPayloads, 2)
+	assert.Equal(t, "vm_remote_migrated", dnsPayloads[1]["event"])
+	assert.Equal(t, "mover", dnsPayloads[1]["vm_name"])
+	assert.Equal(t, "target", dnsPayloads[1]["target_cluster"])
+
+	_, err = env.cutoverService.Retry(ctx, adminID, cutover.Id)
+	require.ErrorIs(t, err, v1.ErrRemoteMigrationCutoverNotRetryable)
+}
@@ -0,0 +1,301 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTP 记录收到的邮件的 SMTP 服务器（不支持 STARTTLS 和认证）
+type fakeSMTP struct {
+	port     int
+	mu       sync.Mutex
+	messages []smtpMessage
+}
+
+type smtpMessage struct {
+	to   []string
+	data []byte
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTP{port: ln.Addr().(*net.TCPAddr).Port}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ESMTP")
+	var msg smtpMessage
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			msg.to = append(msg.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			_ = tp.PrintfLine("250 OK")
+		case cmd == "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = smtpMessage{}
+			_ = tp.PrintfLine("250 OK")
+		case cmd == "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("250 localhost")
+		}
+	}
+}
+
+func (s *fakeSMTP) received() []smtpMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMessage(nil), s.messages...)
+}
+
+// xlsxAttachment 解析邮件中的 XLSX 附件，返回工作簿中各文件的内容
+func xlsxAttachment(t *testing.T, data []byte) (string, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		require.NoError(t, err, "attachment not found")
+		if part.FileName() == "" {
+			continue
+		}
+		raw, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, part))
+		require.NoError(t, err)
+		zr, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+		require.NoError(t, err)
+		files := make(map[string]string)
+		for _, f := range zr.File {
+			rc, err := f.Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(rc)
+			rc.Close()
+			require.NoError(t, err)
+			files[f.Name] = string(content)
+		}
+		return part.FileName(), files
+	}
+}
+
+func (e *testEnv) reportService() service.ReportService {
+	return service.NewReportService(e.svc, e.conf, repository.NewReportRepository(e.repo), e.clusterRepo, e.vmRepo, e.userRepo,
+		repository.NewVMSecurityRepository(e.repo), e.changeService, e.osEOLService, e.notificationService, e.logger)
+}
+
+func TestReport_ScheduledDigest(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	aliceID := env.addUser(t, "alice")
+	ctx := context.Background()
+	smtp := startFakeSMTP(t)
+	env.conf.Set("report.smtp.host", "127.0.0.1")
+	env.conf.Set("report.smtp.port", smtp.port)
+	env.conf.Set("report.smtp.from", "PveSphere <pvesphere@example.com>")
+	reports := env.reportService()
+
+	// 合规：db-1 全部通过；web-1 无负责人和复核日期；web-2 复核过期且有高危风险
+	future, past := time.Now().AddDate(0, 1, 0), time.Now().AddDate(0, 0, -1)
+	compliant := env.addVM(t, "pve1", 100, "db-1", "running")
+	compliant.Owner, compliant.ReviewDate = "alice", &future
+	require.NoError(t, env.vmRepo.Update(ctx, compliant))
+	missing := env.addVM(t, "pve1", 101, "web-1", "running")
+	risky := env.addVM(t, "pve2", 102, "web-2", "stopped")
+	risky.Owner, risky.ReviewDate = "alice", &past
+	require.NoError(t, env.vmRepo.Update(ctx, risky))
+	require.NoError(t, repository.NewVMSecurityRepository(env.repo).ReplaceByVM(ctx, risky.Id, []*model.VMSecurityFinding{
+		{ClusterID: env.cluster.Id, VmId: risky.Id, VMID: 102, Code: "firewall_disabled", Severity: "high", ScanTime: time.Now()},
+	}))
+	// 备份：两个成功、一个失败；其他类型的任务不统计
+	env.pve.AddTask("pve1", "vzdump", 100, "root@pam", "OK")
+	env.pve.AddTask("pve2", "vzdump", 102, "root@pam", "OK")
+	env.pve.AddTask("pve1", "vzdump", 101, "root@pam", "ERROR: job failed with err -5")
+	env.pve.AddTask("pve1", "qmstart", 100, "root@pam", "OK")
+
+	schedule, err := reports.CreateSchedule(ctx, adminID, &v1.CreateReportScheduleRequest{
+		Name:       "weekly digest",
+		Sections:   []string{"compliance", "capacity", "backup"},
+		Frequency:  "weekly",
+		SendTime:   "08:00",
+		Timezone:   "Asia/Shanghai",
+		Recipients: []string{"alice"},
+		Emails:     []string{"ops@example.com"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"capacity", "backup", "compliance"}, schedule.Sections)
+	require.NotNil(t, schedule.NextRunTime)
+	loc, _ := time.LoadLocation("Asia/Shanghai")
+	next := schedule.NextRunTime.In(loc)
+	assert.Equal(t, time.Monday, next.Weekday())
+	assert.Equal(t, "08:00", next.Format("15:04"))
+	assert.True(t, next.After(time.Now()))
+
+	// 未到期时不发送；到期后只发送一次
+	sent, err := reports.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	// 报告统计截至计划发送时间，上面的备份任务在此之前
+	due := time.Now()
+	require.NoError(t, env.repo.DB(ctx).Model(&model.ReportSchedule{}).Where("id = ?", schedule.Id).
+		Update("next_run_time", due).Error)
+	sent, err = reports.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	sent, err = reports.RunDue(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	got, err := reports.GetSchedule(ctx, schedule.Id)
+	require.NoError(t, err)
+	assert.True(t, got.NextRunTime.After(time.Now()))
+
+	runs, err := reports.ListRuns(ctx, schedule.Id, &v1.ListReportRunsRequest{})
+	require.NoError(t, err)
+	require.Len(t, runs.List, 1)
+	run := runs.List[0]
+	assert.Equal(t, model.ReportRunStatusSent, run.Status, run.ErrorMessage)
+	require.NotNil(t, run.ComplianceScore)
+	assert.Equal(t, 66.7, *run.ComplianceScore)
+	require.NotNil(t, run.BackupSuccessRate, run.Summary)
+	assert.Equal(t, 66.7, *run.BackupSuccessRate)
+	assert.Contains(t, run.Summary, "Backups: 66.7% succeeded (2 of 3 jobs, 1 failed)")
+	assert.Contains(t, run.Summary, "Capacity: 3 VMs")
+	assert.Equal(t, 1, run.Notified)
+	assert.Equal(t, 2, run.Emailed)
+
+	// 接收人收到站内通知，邮件发送到用户邮箱和额外地址并附带 XLSX
+	notifications, err := env.notificationService.List(ctx, aliceID, &v1.ListNotificationsRequest{Category: model.NotificationCategoryReport})
+	require.NoError(t, err)
+	require.Len(t, notifications.List, 1)
+	assert.Contains(t, notifications.List[0].Title, "weekly digest")
+	assert.Contains(t, notifications.List[0].Content, "Compliance: score 66.7 (3 VMs)")
+	messages := smtp.received()
+	require.Len(t, messages, 1)
+	assert.ElementsMatch(t, []string{"alice@example.com", "ops@example.com"}, messages[0].to)
+	filename, files := xlsxAttachment(t, messages[0].data)
+	assert.True(t, strings.HasSuffix(filename, ".xlsx"))
+	workbook := files["xl/workbook.xml"]
+	for _, sheet := range []string{"Summary", "Capacity", "Backups", "Compliance", "Compliance Trend"} {
+		assert.Contains(t, workbook, `name="`+sheet+`"`)
+	}
+	assert.Contains(t, files["xl/worksheets/sheet4.xml"], "db-1")
+
+	// 修复后再次发送，趋势包含上一次的得分
+	missing.Owner, missing.ReviewDate = "alice", &future
+	require.NoError(t, env.vmRepo.Update(ctx, missing))
+	again, err := reports.SendNow(ctx, adminID, schedule.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 83.3, *again.ComplianceScore)
+	assert.Contains(t, again.Summary, "+16.6 since")
+	assert.Equal(t, "admin", again.Operator)
+
+	// 管理员和接收人可以下载附件，其他用户不能
+	_, data, err := reports.GetRunAttachment(ctx, aliceID, again.Id)
+	require.NoError(t, err)
+	assert.NotEmpty(t, data)
+	bobID := env.addUser(t, "bob")
+	_, _, err = reports.GetRunAttachment(ctx, bobID, again.Id)
+	assert.True(t, errors.Is(err, v1.ErrAdminRequired), "got %v", err)
+	_, _, err = reports.GetRunAttachment(ctx, adminID, again.Id)
+	require.NoError(t, err)
+}
+
+func TestReport_ScheduleValidation(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	aliceID := env.addUser(t, "alice")
+	ctx := context.Background()
+	reports := env.reportService()
+
+	req := &v1.CreateReportScheduleRequest{
+		Name:       "monthly",
+		Sections:   []string{"capacity"},
+		Frequency:  "monthly",
+		MonthDay:   func(d int) *int { return &d }(31),
+		SendTime:   "23:30",
+		Recipients: []string{"alice"},
+	}
+	_, err := reports.CreateSchedule(ctx, aliceID, req)
+	assert.True(t, errors.Is(err, v1.ErrAdminRequired), "got %v", err)
+
+	schedule, err := reports.CreateSchedule(ctx, adminID, req)
+	require.NoError(t, err)
+	// 31 号超过当月天数时在月末发送
+	next := *schedule.NextRunTime
+	assert.Equal(t, time.Date(next.Year(), next.Month()+1, 0, 23, 30, 0, 0, time.Local), next)
+
+	_, err = reports.CreateSchedule(ctx, adminID, req)
+	assert.True(t, errors.Is(err, v1.ErrReportScheduleExists), "got %v", err)
+
+	for name, invalid := range map[string]*v1.CreateReportScheduleRequest{
+		"unknown user": {Name: "a", Sections: []string{"backup"}, Frequency: "daily", SendTime: "08:00", Recipients: []string{"nobody"}},
+		"no recipient": {Name: "b", Sections: []string{"backup"}, Frequency: "daily", SendTime: "08:00"},
+		"send time":    {Name: "c", Sections: []string{"backup"}, Frequency: "daily", SendTime: "8am", Recipients: []string{"alice"}},
+		"timezone":     {Name: "d", Sections: []string{"backup"}, Frequency: "daily", SendTime: "08:00", Timezone: "Mars/Base", Recipients: []string{"alice"}},
+	} {
+		_, err := reports.CreateSchedule(ctx, adminID, invalid)
+		assert.True(t, errors.Is(err, v1.ErrReportScheduleInvalid), "%s: got %v", name, err)
+	}
+
+	// 禁用的计划不定时发送
+	disabled := int8(0)
+	req.IsEnabled = &disabled
+	updated, err := reports.UpdateSchedule(ctx, adminID, schedule.Id, req)
+	require.NoError(t, err)
+	assert.Nil(t, updated.NextRunTime)
+
+	require.NoError(t, reports.DeleteSchedule(ctx, adminID, schedule.Id))
+	_, err = reports.GetSchedule(ctx, schedule.Id)
+	assert.True(t, errors.Is(err, v1.ErrReportScheduleNotFound))
+}
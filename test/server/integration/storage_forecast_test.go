@@ -0,0 +1,79 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageForecast(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	// pve1 的 local 只有 20G；已有 vm 500 的两次 zstd 备份（模拟存储上每个卷 1G），
+	// small 存储 2.1G 用于恢复告警
+	node := testNode("pve1", 0)
+	node.Storages[0].Total = 20 << 30
+	node.Storages[0].Volumes = append(node.Storages[0].Volumes,
+		"local:backup/vzdump-qemu-500-2026_01_01-00_00_00.vma.zst",
+		"local:backup/vzdump-qemu-500-2026_01_02-00_00_00.vma.zst",
+		"local:backup/vzdump-qemu-999-2026_01_02-00_00_00.vma.zst")
+	node.Storages = append(node.Storages, proxmoxtest.Storage{Name: "small", Content: "images", Total: 21 << 30 / 10})
+	env.pve.AddNode(node)
+	vm := env.addVM(t, "pve1", 500, "db", "running")
+
+	// 历史压缩比：1G / 32G
+	data, err := env.vmService.ForecastStorage(ctx, &v1.StorageForecastRequest{Operation: "backup", VmID: vm.Id, Storage: "local", Compress: "zst"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(32<<30), data.SourceBytes)
+	assert.Equal(t, v1.CompressionRatioHistory, data.RatioSource)
+	assert.Equal(t, 2, data.HistorySamples)
+	assert.Equal(t, int64(1<<30), data.RequiredBytes)
+	assert.Equal(t, v1.StorageForecastOK, data.Verdict)
+
+	// lzo 没有历史备份，按默认 0.65 估算约 20.8G，超过可用空间
+	data, err = env.vmService.ForecastStorage(ctx, &v1.StorageForecastRequest{Operation: "backup", VmID: vm.Id, Storage: "local", Compress: "lzo"})
+	require.NoError(t, err)
+	assert.Equal(t, v1.CompressionRatioDefault, data.RatioSource)
+	assert.Equal(t, v1.StorageForecastInsufficient, data.Verdict)
+
+	// 完整克隆需要磁盘总大小
+	data, err = env.vmService.ForecastStorage(ctx, &v1.StorageForecastRequest{Operation: "clone", VmID: vm.Id, NodeID: env.nodes["pve2"].Id, Storage: "local-lvm"})
+	require.NoError(t, err)
+	assert.Equal(t, "pve2", data.NodeName)
+	assert.Equal(t, int64(32<<30), data.RequiredBytes)
+	assert.Equal(t, v1.StorageForecastOK, data.Verdict)
+
+	// 恢复：虚拟机仍存在时使用其磁盘大小；不存在时按默认压缩比推算（1G / 0.5），接近 small 的容量
+	data, err = env.vmService.ForecastStorage(ctx, &v1.StorageForecastRequest{Operation: "restore", NodeID: env.nodes["pve1"].Id,
+		Archive: "local:backup/vzdump-qemu-500-2026_01_02-00_00_00.vma.zst", Storage: "local-lvm"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(32<<30), data.RequiredBytes)
+	data, err = env.vmService.ForecastStorage(ctx, &v1.StorageForecastRequest{Operation: "restore", NodeID: env.nodes["pve1"].Id,
+		Archive: "local:backup/vzdump-qemu-999-2026_01_02-00_00_00.vma.zst", Storage: "small"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2<<30), data.RequiredBytes)
+	assert.Equal(t, v1.StorageForecastWarning, data.Verdict)
+
+	_, err = env.vmService.ForecastStorage(ctx, &v1.StorageForecastRequest{Operation: "backup", VmID: vm.Id, Storage: "nfs"})
+	assert.True(t, errors.Is(err, v1.ErrStorageNotFound))
+
+	// 备份：预估不足时拒绝，force 时仍然发起并返回预估结果
+	_, err = env.vmService.CreateBackup(ctx, &v1.CreateBackupRequest{VMID: 500, Storage: "local", Compress: "lzo"})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, v1.ErrStorageSpaceInsufficient))
+	assert.Zero(t, env.pve.CountRequests("POST", "/nodes/pve1/vzdump"))
+
+	backup, err := env.vmService.CreateBackup(ctx, &v1.CreateBackupRequest{VMID: 500, Storage: "local", Compress: "lzo", Force: true})
+	require.NoError(t, err)
+	require.NotNil(t, backup.Forecast)
+	assert.Equal(t, v1.StorageForecastInsufficient, backup.Forecast.Verdict)
+	assert.NotEmpty(t, backup.UPID)
+	assert.Equal(t, 1, env.pve.CountRequests("POST", "/nodes/pve1/vzdump"))
+}
@@ -0,0 +1,124 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageGC_SnapshotReferencesAndRecheck(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	// pve1 的 local-lvm：disk-0 挂载在 100 上，disk-1 只被快照引用，disk-2、disk-3 和 999 的磁盘没有被引用
+	node := testNode("pve1", 0)
+	node.Storages[1].Volumes = []string{
+		"local-lvm:vm-100-disk-0", "local-lvm:vm-100-disk-1", "local-lvm:vm-100-disk-2",
+		"local-lvm:vm-100-disk-3", "local-lvm:vm-999-disk-0",
+	}
+	env.pve.AddNode(node)
+	require.NoError(t, env.storageRepo.Create(ctx, &model.PveStorage{NodeName: "pve1", ClusterID: env.cluster.Id, StorageName: "local-lvm",
+		Type: "lvmthin", Content: "images,rootdir", Active: 1, Enabled: 1, CreateTime: time.Now(), UpdateTime: time.Now()}))
+	env.addVM(t, "pve1", 100, "app-01", "running")
+	env.pve.AddSnapshot(100, "pre-upgrade", map[string]string{
+		"scsi0": "local-lvm:vm-100-disk-0,size=32G",
+		"scsi1": "local-lvm:vm-100-disk-1,size=8G",
+	})
+
+	adminID := env.addUser(t, "admin")
+	aliceID := env.addUser(t, "alice")
+	gc := service.NewStorageGCService(env.svc, env.conf, env.userRepo, repository.NewStorageGCRepository(env.repo), env.clusterRepo,
+		env.storageRepo, env.notificationService, env.logger)
+	isoMaxAge := 0
+	scan, err := gc.CreateScan(ctx, &v1.CreateStorageGCScanRequest{ClusterID: env.cluster.Id, IsoMaxAgeDays: &isoMaxAge})
+	require.NoError(t, err)
+	eventually(t, 5*time.Second, func() bool {
+		got, err := gc.GetScan(ctx, scan.Id)
+		return err == nil && got.Status == model.StorageGCScanStatusCompleted
+	}, "storage gc scan not completed")
+
+	// 快照引用的 disk-1 不是孤儿磁盘
+	list, err := gc.ListItems(ctx, &v1.ListStorageGCItemsRequest{ScanID: scan.Id, PageSize: 100})
+	require.NoError(t, err)
+	ids := make(map[string]int64)
+	for _, item := range list.List {
+		assert.Equal(t, model.StorageGCReasonOrphanDisk, item.Reason)
+		ids[item.VolID] = item.Id
+	}
+	require.Len(t, ids, 3)
+	assert.NotContains(t, ids, "local-lvm:vm-100-disk-1")
+
+	itemIDs := []int64{ids["local-lvm:vm-100-disk-2"], ids["local-lvm:vm-100-disk-3"], ids["local-lvm:vm-999-disk-0"]}
+
+	// 审核和删除仅管理员可用
+	err = gc.ReviewItems(ctx, aliceID, &v1.ReviewStorageGCItemsRequest{ItemIDs: itemIDs, Action: "approve"})
+	assert.ErrorIs(t, err, v1.ErrAdminRequired)
+	require.NoError(t, gc.ReviewItems(ctx, adminID, &v1.ReviewStorageGCItemsRequest{ItemIDs: itemIDs, Action: "approve"}))
+	_, err = gc.DeleteItems(ctx, aliceID, &v1.DeleteStorageGCItemsRequest{ItemIDs: itemIDs})
+	assert.ErrorIs(t, err, v1.ErrAdminRequired)
+	assert.Zero(t, env.pve.CountRequests(http.MethodDelete, "/nodes/pve1/storage/local-lvm/content"))
+
+	// 审核后 disk-2 重新挂载到 100，disk-3 被新快照引用
+	vm, ok := env.pve.VM(100)
+	require.True(t, ok)
+	vm.Config["scsi1"] = "local-lvm:vm-100-disk-2,size=8G"
+	env.pve.AddVM(vm)
+	env.pve.AddSnapshot(100, "after-upgrade", map[string]string{
+		"scsi0": "local-lvm:vm-100-disk-0,size=32G",
+		"scsi2": "local-lvm:vm-100-disk-3,size=8G",
+	})
+
+	// 复核失败时不删除
+	env.pve.FailRequests(http.MethodGet, "/cluster/resources", http.StatusInternalServerError, 1)
+	result, err := gc.DeleteItems(ctx, adminID, &v1.DeleteStorageGCItemsRequest{ItemIDs: itemIDs[2:]})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Failed)
+	assert.Zero(t, result.Deleted)
+	require.Len(t, result.Items, 1)
+	assert.Contains(t, result.Items[0].ErrorMessage, "get cluster resources")
+	assert.Zero(t, env.pve.CountRequests(http.MethodDelete, "/nodes/pve1/storage/local-lvm/content"))
+
+	result, err = gc.DeleteItems(ctx, adminID, &v1.DeleteStorageGCItemsRequest{ItemIDs: itemIDs})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Deleted)
+	assert.Equal(t, 2, result.Skipped)
+	assert.Zero(t, result.Failed)
+	statuses := make(map[string]v1.StorageGCItem)
+	for _, item := range result.Items {
+		statuses[item.VolID] = item
+	}
+	assert.Equal(t, model.StorageGCItemStatusIgnored, statuses["local-lvm:vm-100-disk-2"].Status)
+	assert.Contains(t, statuses["local-lvm:vm-100-disk-2"].ErrorMessage, "scsi1")
+	assert.Equal(t, model.StorageGCItemStatusIgnored, statuses["local-lvm:vm-100-disk-3"].Status)
+	assert.Contains(t, statuses["local-lvm:vm-100-disk-3"].ErrorMessage, "scsi2@after-upgrade")
+	assert.Equal(t, model.StorageGCItemStatusDeleted, statuses["local-lvm:vm-999-disk-0"].Status)
+
+	// 只删除了未被引用的卷
+	deletes := 0
+	for _, req := range env.pve.Requests() {
+		if req.Method == http.MethodDelete {
+			assert.Equal(t, "/nodes/pve1/storage/local-lvm/content/local-lvm:vm-999-disk-0", req.Path)
+			deletes++
+		}
+	}
+	assert.Equal(t, 1, deletes)
+
+	// 卷已不在存储上时跳过
+	require.NoError(t, gc.ReviewItems(ctx, adminID, &v1.ReviewStorageGCItemsRequest{ItemIDs: itemIDs[:1], Action: "approve"}))
+	node.Storages[1].Volumes = []string{"local-lvm:vm-100-disk-0", "local-lvm:vm-100-disk-1"}
+	env.pve.AddNode(node)
+	result, err = gc.DeleteItems(ctx, adminID, &v1.DeleteStorageGCItemsRequest{ItemIDs: itemIDs[:1]})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Skipped)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "volume no longer exists on storage", result.Items[0].ErrorMessage)
+}
@@ -0,0 +1,43 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemConfig_InvalidValueDetail(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	ctx := context.Background()
+	svc := service.NewSystemConfigService(env.svc, env.conf, repository.NewConfigAuditRepository(env.repo), env.userRepo, env.logger)
+
+	str := func(s string) *string { return &s }
+	cases := []struct {
+		req    *v1.UpdateRuntimeConfigRequest
+		detail string
+	}{
+		{&v1.UpdateRuntimeConfigRequest{LogLevel: str("verbose")}, `log.log_level: must be debug/info/warn/error, got "verbose"`},
+		{&v1.UpdateRuntimeConfigRequest{ProxmoxTimeout: str("1ms")}, "proxmox.timeout: must be between"},
+		{&v1.UpdateRuntimeConfigRequest{ProxmoxTimeout: str("soon")}, `proxmox.timeout: invalid duration "soon"`},
+	}
+	for _, c := range cases {
+		_, err := svc.UpdateRuntimeConfig(ctx, adminID, c.req)
+		require.ErrorIs(t, err, v1.ErrInvalidConfigValue)
+		var de *v1.DetailError
+		require.True(t, errors.As(err, &de))
+		assert.Contains(t, de.Detail, c.detail)
+	}
+
+	// 非法配置不生效，也不写审计
+	audits, err := svc.ListAudits(ctx, adminID, &v1.ListConfigAuditRequest{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Zero(t, audits.Total)
+}
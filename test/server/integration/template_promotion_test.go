@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addUser 登记一个用户，返回 user_id（admin 为管理员）
+func (e *testEnv) addUser(t *testing.T, username string) string {
+	t.Helper()
+	user := &model.User{UserId: "uid-" + username, Username: username, Nickname: username, Password: "-", Email: username + "@example.com"}
+	require.NoError(t, e.userRepo.Create(context.Background(), user))
+	return user.UserId
+}
+
+// addStageCluster 登记一个指定环境的集群（仅数据库，含一个节点），返回集群和节点
+func (e *testEnv) addStageCluster(t *testing.T, name, env string) (*model.PveCluster, *model.PveNode) {
+	t.Helper()
+	ctx := context.Background()
+	cluster := &model.PveCluster{ClusterName: name, Env: env, ApiUrl: e.pve.URL, UserId: testUserID, UserToken: testToken,
+		IsSchedulable: 1, IsEnabled: 1, CreateTime: time.Now(), UpdateTime: time.Now()}
+	require.NoError(t, e.clusterRepo.Create(ctx, cluster))
+	node := &model.PveNode{NodeName: name + "-1", ClusterID: cluster.Id, IsSchedulable: 1, Status: "online",
+		CreateTime: time.Now(), UpdateTime: time.Now()}
+	require.NoError(t, e.nodeRepo.Create(ctx, node))
+	return cluster, node
+}
+
+// setupPromotionStages 把默认集群设为 dev 并登记 staging、prod 集群
+func (e *testEnv) setupPromotionStages(t *testing.T) (staging, prod *model.PveNode) {
+	t.Helper()
+	e.cluster.Env = "dev"
+	require.NoError(t, e.clusterRepo.Update(context.Background(), e.cluster))
+	_, staging = e.addStageCluster(t, "staging", "staging")
+	_, prod = e.addStageCluster(t, "prod", "prod")
+	return staging, prod
+}
+
+func promotionRequest(templateID int64, target *model.PveNode) *v1.CreateTemplatePromotionRequest {
+	return &v1.CreateTemplatePromotionRequest{
+		TemplateID:      templateID,
+		TargetClusterID: target.ClusterID,
+		TargetNodeID:    target.Id,
+		TargetStorage:   "local-lvm",
+		StoreID:         1,
+		StagingStorage:  "backup-nfs",
+		Reason:          "passed regression tests",
+	}
+}
+
+func TestTemplatePromotion_StageOrder(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	staging, prod := env.setupPromotionStages(t)
+	alice := env.addUser(t, "alice")
+	templateID := env.addLocalTemplate(t)
+
+	// dev 不能跳过 staging 直接晋级到 prod
+	_, err := env.promotionService.Request(ctx, alice, promotionRequest(templateID, prod))
+	assert.ErrorIs(t, err, v1.ErrTemplatePromotionStage)
+
+	promotion, err := env.promotionService.Request(ctx, alice, promotionRequest(templateID, staging))
+	require.NoError(t, err)
+	assert.Equal(t, model.TemplatePromotionStatusPending, promotion.Status)
+	assert.Equal(t, "dev", promotion.SourceStage)
+	assert.Equal(t, "staging", promotion.TargetStage)
+	assert.Equal(t, "alice", promotion.Requester)
+	assert.Equal(t, templateID, promotion.OriginTemplateID)
+	assert.Equal(t, "backup-nfs", promotion.TargetStagingStorage)
+
+	_, err = env.promotionService.Request(ctx, alice, promotionRequest(templateID, staging))
+	assert.ErrorIs(t, err, v1.ErrTemplatePromotionInProgress)
+}
+
+func TestTemplatePromotion_Approval(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	staging, _ := env.setupPromotionStages(t)
+	alice := env.addUser(t, "alice")
+	admin := env.addUser(t, "admin")
+	templateID := env.addLocalTemplate(t)
+
+	own, err := env.promotionService.Request(ctx, admin, promotionRequest(templateID, staging))
+	require.NoError(t, err)
+	_, err = env.promotionService.Approve(ctx, admin, own.Id, &v1.ReviewTemplatePromotionRequest{})
+	assert.ErrorIs(t, err, v1.ErrTemplatePromotionSelfApprove)
+	rejected, err := env.promotionService.Reject(ctx, admin, own.Id, &v1.ReviewTemplatePromotionRequest{Comment: "duplicate"})
+	require.NoError(t, err)
+	assert.Equal(t, model.TemplatePromotionStatusRejected, rejected.Status)
+	assert.Equal(t, "admin", rejected.Reviewer)
+
+	promotion, err := env.promotionService.Request(ctx, alice, promotionRequest(templateID, staging))
+	require.NoError(t, err)
+	_, err = env.promotionService.Approve(ctx, alice, promotion.Id, &v1.ReviewTemplatePromotionRequest{})
+	assert.ErrorIs(t, err, v1.ErrAdminRequired)
+
+	approved, err := env.promotionService.Approve(ctx, admin, promotion.Id, &v1.ReviewTemplatePromotionRequest{Comment: "ok"})
+	require.NoError(t, err)
+	assert.Equal(t, model.TemplatePromotionStatusReplicating, approved.Status)
+	assert.NotNil(t, approved.ReviewTime)
+	_, err = env.promotionService.Reject(ctx, admin, promotion.Id, &v1.ReviewTemplatePromotionRequest{})
+	assert.ErrorIs(t, err, v1.ErrTemplatePromotionNotPending)
+
+	// 测试环境没有对象存储，复制在导出阶段失败并保留错误信息
+	eventually(t, 10*time.Second, func() bool {
+		item, err := env.promotionService.Get(ctx, promotion.Id)
+		return err == nil && item.Status == model.TemplatePromotionStatusFailed
+	}, "promotion should fail without an object store")
+	item, err := env.promotionService.Get(ctx, promotion.Id)
+	require.NoError(t, err)
+	assert.Equal(t, model.TemplatePromotionPhaseExport, item.Phase)
+	assert.NotEmpty(t, item.ErrorMessage)
+	assert.NotNil(t, item.EndTime)
+}
+
+func TestTemplatePromotion_Provenance(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	staging, prod := env.setupPromotionStages(t)
+	alice := env.addUser(t, "alice")
+	devTemplateID := env.addLocalTemplate(t)
+
+	// staging 集群中手工登记的模板不能晋级到 prod
+	stagingTemplate := &model.PveTemplate{TemplateName: "debian-12", ClusterID: staging.ClusterID, CreateTime: time.Now(), UpdateTime: time.Now()}
+	require.NoError(t, env.templateRepo.Create(ctx, stagingTemplate))
+	require.NoError(t, env.instanceRepo.Create(ctx, &model.TemplateInstance{
+		TemplateID: stagingTemplate.Id,
+		ClusterID:  staging.ClusterID,
+		NodeID:     staging.Id,
+		NodeName:   staging.NodeName,
+		VMID:       9100,
+		Status:     model.TemplateInstanceStatusAvailable,
+		IsPrimary:  1,
+	}))
+	_, err := env.promotionService.Request(ctx, alice, promotionRequest(stagingTemplate.Id, prod))
+	assert.ErrorIs(t, err, v1.ErrTemplatePromotionProvenance)
+
+	// 由 dev 晋级产生后可以继续晋级，并记录完整晋级链
+	first, err := env.promotionService.Request(ctx, alice, promotionRequest(devTemplateID, staging))
+	require.NoError(t, err)
+	record, err := env.promotionRepo.GetByID(ctx, first.Id)
+	require.NoError(t, err)
+	record.Status = model.TemplatePromotionStatusCompleted
+	record.PromotedTemplateID = stagingTemplate.Id
+	require.NoError(t, env.promotionRepo.Update(ctx, record))
+
+	second, err := env.promotionService.Request(ctx, alice, promotionRequest(stagingTemplate.Id, prod))
+	require.NoError(t, err)
+	assert.Equal(t, first.Id, second.ParentID)
+	assert.Equal(t, devTemplateID, second.OriginTemplateID)
+
+	chain, err := env.promotionService.Chain(ctx, second.Id)
+	require.NoError(t, err)
+	require.Len(t, chain, 2)
+	assert.Equal(t, "dev", chain[0].SourceStage)
+	assert.Equal(t, "prod", chain[1].TargetStage)
+}
@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"pvesphere/internal/model"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addLocalTemplate 在 pve1 的本地存储上登记一个已导入的模板（主实例 VMID 9000）
+func (e *testEnv) addLocalTemplate(t *testing.T) int64 {
+	t.Helper()
+	ctx := context.Background()
+	e.pve.AddVM(proxmoxtest.VM{
+		VMID:     9000,
+		Node:     "pve1",
+		Name:     "debian-12",
+		Template: true,
+		Config:   map[string]string{"memory": "2048", "cores": "2", "scsi0": "local-lvm:base-9000-disk-0,size=8G", "template": "1"},
+	})
+
+	template := &model.PveTemplate{TemplateName: "debian-12", ClusterID: e.cluster.Id, CreateTime: time.Now(), UpdateTime: time.Now()}
+	require.NoError(t, e.templateRepo.Create(ctx, template))
+	upload := &model.TemplateUpload{
+		TemplateID:     template.Id,
+		ClusterID:      e.cluster.Id,
+		StorageName:    "local-lvm",
+		StorageType:    "lvmthin",
+		UploadNodeID:   e.nodes["pve1"].Id,
+		UploadNodeName: "pve1",
+		FileName:       "vzdump-qemu-9000.vma.zst",
+		FilePath:       "/var/lib/vz/dump/vzdump-qemu-9000.vma.zst",
+		FileFormat:     "vma.zst",
+		Status:         model.TemplateUploadStatusImported,
+	}
+	require.NoError(t, e.uploadRepo.Create(ctx, upload))
+	require.NoError(t, e.instanceRepo.Create(ctx, &model.TemplateInstance{
+		TemplateID:  template.Id,
+		UploadID:    upload.Id,
+		ClusterID:   e.cluster.Id,
+		NodeID:      e.nodes["pve1"].Id,
+		NodeName:    "pve1",
+		StorageName: "local-lvm",
+		VMID:        9000,
+		Status:      model.TemplateInstanceStatusAvailable,
+		IsPrimary:   1,
+	}))
+	return template.Id
+}
+
+func TestSyncTemplateToNodes(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	templateID := env.addLocalTemplate(t)
+
+	resp, err := env.templateService.SyncTemplateToNodes(ctx, templateID, []int64{env.nodes["pve2"].Id})
+	require.NoError(t, err)
+	require.Len(t, resp.SyncTasks, 1)
+	taskID := resp.SyncTasks[0].TaskID
+
+	var task *model.TemplateSyncTask
+	eventually(t, 30*time.Second, func() bool {
+		task, err = env.syncTaskRepo.GetByID(ctx, taskID)
+		return err == nil && task != nil &&
+			(task.Status == model.TemplateSyncTaskStatusCompleted || task.Status == model.TemplateSyncTaskStatusFailed)
+	}, "sync task did not finish")
+	require.Equal(t, model.TemplateSyncTaskStatusCompleted, task.Status, task.ErrorMessage)
+	assert.Equal(t, 100, task.Progress)
+
+	instance, err := env.instanceRepo.GetByTemplateAndNode(ctx, templateID, env.nodes["pve2"].Id)
+	require.NoError(t, err)
+	require.NotNil(t, instance)
+	assert.Equal(t, model.TemplateInstanceStatusAvailable, instance.Status)
+
+	synced, ok := env.pve.VM(instance.VMID)
+	require.True(t, ok, "synced template %d should exist", instance.VMID)
+	assert.Equal(t, "pve2", synced.Node)
+	assert.True(t, synced.Template)
+	assert.Empty(t, synced.Lock)
+	// 源模板保持不变
+	source, ok := env.pve.VM(9000)
+	require.True(t, ok)
+	assert.Equal(t, "pve1", source.Node)
+
+	record, err := env.vmRepo.GetByVMID(ctx, instance.VMID, env.nodes["pve2"].Id)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, int8(1), record.IsTemplate)
+}
+
+func TestSyncTemplateToNodes_CloneTaskFails(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	templateID := env.addLocalTemplate(t)
+
+	env.pve.FailTasks("qmclone", "storage 'local-lvm' is full")
+	resp, err := env.templateService.SyncTemplateToNodes(ctx, templateID, []int64{env.nodes["pve2"].Id})
+	require.NoError(t, err)
+	require.Len(t, resp.SyncTasks, 1)
+
+	var task *model.TemplateSyncTask
+	eventually(t, 30*time.Second, func() bool {
+		task, err = env.syncTaskRepo.GetByID(ctx, resp.SyncTasks[0].TaskID)
+		return err == nil && task != nil &&
+			(task.Status == model.TemplateSyncTaskStatusCompleted || task.Status == model.TemplateSyncTaskStatusFailed)
+	}, "sync task did not finish")
+	assert.Equal(t, model.TemplateSyncTaskStatusFailed, task.Status)
+	assert.Contains(t, task.ErrorMessage, "storage 'local-lvm' is full")
+
+	// 失败的克隆不应留下虚拟机
+	for _, req := range env.pve.Requests() {
+		if req.Method == "POST" && req.Path == "/nodes/pve1/qemu/9000/clone" {
+			newID, err := strconv.ParseUint(req.Params.Get("newid"), 10, 32)
+			require.NoError(t, err)
+			_, ok := env.pve.VM(uint32(newID))
+			assert.False(t, ok, "failed clone %s should be removed", req.Params.Get("newid"))
+		}
+	}
+}
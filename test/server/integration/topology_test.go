@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hasEdge(data *v1.TopologyData, source, target, kind string) bool {
+	for _, e := range data.Edges {
+		if e.Source == source && e.Target == target && e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func TestGetTopology(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	for _, node := range []string{"pve1", "pve2"} {
+		require.NoError(t, env.storageRepo.Create(ctx, &model.PveStorage{NodeName: node, ClusterID: env.cluster.Id, StorageName: "local-lvm",
+			Type: "lvmthin", Active: 1, Enabled: 1, CreateTime: time.Now(), UpdateTime: time.Now()}))
+		require.NoError(t, env.storageRepo.Create(ctx, &model.PveStorage{NodeName: node, ClusterID: env.cluster.Id, StorageName: "ceph",
+			Type: "rbd", Active: 1, Enabled: 1, Shared: 1, CreateTime: time.Now(), UpdateTime: time.Now()}))
+	}
+	web := env.addVM(t, "pve1", 300, "web-01", "running")
+	web.Storage = "ceph"
+	require.NoError(t, env.vmRepo.Update(ctx, web))
+	env.addVM(t, "pve2", 301, "db-01", "stopped")
+	tpl := env.addVM(t, "pve2", 9000, "debian-12-tpl", "stopped")
+	tpl.IsTemplate = 1
+	require.NoError(t, env.vmRepo.Update(ctx, tpl))
+
+	data, err := env.topologyService.GetTopology(ctx, &v1.GetTopologyRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, data.Counts[v1.TopologyKindCluster])
+	assert.Equal(t, 2, data.Counts[v1.TopologyKindNode])
+	assert.Equal(t, 2, data.Counts[v1.TopologyKindVM], "templates are excluded by default")
+	// 两个节点各自的 local-lvm，加上合并后的共享存储 ceph
+	assert.Equal(t, 3, data.Counts[v1.TopologyKindStorage])
+	assert.Zero(t, data.Counts[v1.TopologyKindBridge])
+
+	var ceph string
+	for _, vertex := range data.Vertices {
+		if vertex.Kind == v1.TopologyKindStorage && vertex.Shared {
+			ceph = vertex.ID
+		}
+	}
+	require.NotEmpty(t, ceph)
+	pve1, pve2 := fmt.Sprintf("node:%d", env.nodes["pve1"].Id), fmt.Sprintf("node:%d", env.nodes["pve2"].Id)
+	vm := fmt.Sprintf("vm:%d", web.Id)
+	assert.True(t, hasEdge(data, fmt.Sprintf("cluster:%d", env.cluster.Id), pve1, v1.TopologyEdgeContains))
+	assert.True(t, hasEdge(data, pve1, vm, v1.TopologyEdgeHosts))
+	assert.True(t, hasEdge(data, pve1, ceph, v1.TopologyEdgeAttached))
+	assert.True(t, hasEdge(data, pve2, ceph, v1.TopologyEdgeAttached))
+	assert.True(t, hasEdge(data, vm, ceph, v1.TopologyEdgeUses))
+
+	data, err = env.topologyService.GetTopology(ctx, &v1.GetTopologyRequest{ClusterID: env.cluster.Id, IncludeTemplates: true, IncludeBridges: true})
+	require.NoError(t, err)
+	assert.Equal(t, 3, data.Counts[v1.TopologyKindVM])
+	assert.Equal(t, 2, data.Counts[v1.TopologyKindBridge])
+	assert.Empty(t, data.Warnings)
+
+	_, err = env.topologyService.GetTopology(ctx, &v1.GetTopologyRequest{ClusterID: env.cluster.Id + 100})
+	assert.ErrorIs(t, err, v1.ErrClusterNotFound)
+}
+
+func TestGetTopology_BridgeUnavailable(t *testing.T) {
+	env := newTestEnv(t)
+	env.addVM(t, "pve1", 300, "web-01", "running")
+
+	env.pve.FailRequests(http.MethodGet, "/nodes/pve1/network", http.StatusServiceUnavailable, -1)
+	data, err := env.topologyService.GetTopology(context.Background(), &v1.GetTopologyRequest{IncludeBridges: true})
+	require.NoError(t, err)
+	assert.Len(t, data.Warnings, 1)
+	assert.Equal(t, 1, data.Counts[v1.TopologyKindBridge])
+	assert.Equal(t, 1, data.Counts[v1.TopologyKindVM])
+}
@@ -0,0 +1,104 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startGuestAgent 模拟用户在虚拟机内装好 agent 并重新上电
+func (e *testEnv) startGuestAgent(t *testing.T, vmid uint32) {
+	t.Helper()
+	vm, ok := e.pve.VM(vmid)
+	require.True(t, ok)
+	vm.Status = "running"
+	vm.Agent = &proxmoxtest.GuestAgent{}
+	e.pve.AddVM(vm)
+}
+
+func TestVMAgentInstall_CloudInit(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.addUser(t, "admin")
+	ctx := userCtx(admin)
+	vm := env.addVM(t, "pve1", 300, "web-01", "running")
+	env.pve.AddVM(proxmoxtest.VM{VMID: 300, Node: "pve1", Name: "web-01", Status: "running", Config: map[string]string{
+		"memory": "2048", "scsi0": "local-lvm:vm-300-disk-0,size=32G", "ide2": "local-lvm:vm-300-cloudinit,media=cdrom",
+		"cicustom": "user=local:snippets/users.yaml",
+	}})
+
+	req := &v1.StartVMAgentInstallRequest{Method: v1.VMAgentInstallMethodCloudInit, Volume: "local:snippets/qemu-guest-agent.yaml"}
+	item, err := env.agentInstallService.Start(ctx, admin, vm.Id, req)
+	require.NoError(t, err)
+	assert.Equal(t, "waiting", item.Status)
+	assert.True(t, item.AgentEnabled)
+	config, _ := env.pve.VM(300)
+	assert.Equal(t, "1", config.Config["agent"])
+	assert.Equal(t, "user=local:snippets/users.yaml,vendor=local:snippets/qemu-guest-agent.yaml", config.Config["cicustom"])
+
+	_, err = env.agentInstallService.Start(ctx, admin, vm.Id, req)
+	assert.ErrorIs(t, err, v1.ErrAgentInstallInProgress)
+
+	// agent 尚未响应
+	item, err = env.agentInstallService.GetByVM(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "waiting", item.Status)
+	assert.Equal(t, 1, item.Checks)
+
+	env.startGuestAgent(t, 300)
+	processed, err := env.agentInstallService.CheckPending(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	item, err = env.agentInstallService.GetByVM(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "succeeded", item.Status)
+	assert.NotNil(t, item.FinishTime)
+
+	// 成功后恢复原 cicustom，保留 agent 选项
+	config, _ = env.pve.VM(300)
+	assert.Equal(t, "user=local:snippets/users.yaml", config.Config["cicustom"])
+	assert.Equal(t, "1", config.Config["agent"])
+
+	_, err = env.agentInstallService.Start(ctx, admin, vm.Id, req)
+	assert.ErrorIs(t, err, v1.ErrGuestAgentRunning)
+
+	list, err := env.agentInstallService.List(ctx, &v1.ListVMAgentInstallsRequest{Status: "succeeded"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, list.Total)
+}
+
+func TestVMAgentInstall_ISO(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.addUser(t, "admin")
+	bob := env.addUser(t, "bob")
+	alice := env.addUser(t, "alice")
+	vm := env.addVM(t, "pve1", 300, "win-01", "stopped")
+
+	_, err := env.agentInstallService.Start(userCtx(bob), bob, vm.Id, &v1.StartVMAgentInstallRequest{Method: v1.VMAgentInstallMethodCloudInit, Volume: "local:snippets/qga.yaml"})
+	assert.ErrorIs(t, err, v1.ErrCloudInitDriveMissing)
+	_, err = env.agentInstallService.Start(userCtx(bob), bob, vm.Id, &v1.StartVMAgentInstallRequest{Method: v1.VMAgentInstallMethodISO})
+	assert.ErrorIs(t, err, v1.ErrAgentInstallSourceMissing)
+
+	item, err := env.agentInstallService.Start(userCtx(bob), bob, vm.Id, &v1.StartVMAgentInstallRequest{Method: v1.VMAgentInstallMethodISO, Volume: "local:iso/virtio-win.iso"})
+	require.NoError(t, err)
+	assert.Equal(t, "ide0", item.Slot)
+	config, _ := env.pve.VM(300)
+	assert.Equal(t, "local:iso/virtio-win.iso,media=cdrom", config.Config["ide0"])
+
+	// 只有创建人或管理员可以取消
+	assert.ErrorIs(t, env.agentInstallService.Cancel(userCtx(alice), alice, item.Id), v1.ErrAdminRequired)
+	require.NoError(t, env.agentInstallService.Cancel(userCtx(bob), bob, item.Id))
+	assert.ErrorIs(t, env.agentInstallService.Cancel(userCtx(admin), admin, item.Id), v1.ErrAgentInstallFinished)
+
+	// 取消后卸载 ISO 并恢复 agent 选项
+	config, _ = env.pve.VM(300)
+	assert.NotContains(t, config.Config, "ide0")
+	assert.NotContains(t, config.Config, "agent")
+	item, err = env.agentInstallService.GetByVM(userCtx(bob), vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "cancelled", item.Status)
+}
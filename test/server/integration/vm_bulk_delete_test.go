@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addProjectVM 添加属于项目 project 的虚拟机，config 追加到模拟的 Proxmox 配置中（如 tags、protection）
+func (e *testEnv) addProjectVM(t *testing.T, vmid uint32, name, status, project string, config map[string]string) *model.PveVM {
+	t.Helper()
+	vm := e.addVM(t, "pve1", vmid, name, status)
+	vm.BusinessService = project
+	require.NoError(t, e.vmRepo.Update(context.Background(), vm))
+	pveVM, ok := e.pve.VM(vmid)
+	require.True(t, ok)
+	for key, value := range config {
+		pveVM.Config[key] = value
+	}
+	e.pve.AddVM(pveVM)
+	return vm
+}
+
+func bulkDeleteVMIDs(items []v1.VMBulkDeleteItem) []uint32 {
+	vmids := make([]uint32, 0, len(items))
+	for _, item := range items {
+		vmids = append(vmids, item.VMID)
+	}
+	return vmids
+}
+
+func TestVMBulkDelete(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	ctx := userCtx(adminID)
+
+	web1 := env.addProjectVM(t, 700, "web-1", "running", "legacy", map[string]string{"tags": "decommission;web"})
+	web2 := env.addProjectVM(t, 701, "web-2", "stopped", "legacy", map[string]string{"tags": "decommission"})
+	db := env.addProjectVM(t, 702, "db", "running", "legacy", map[string]string{"protection": "1"})
+	env.addProjectVM(t, 703, "batch", "stopped", "legacy", nil)
+	keep := env.addProjectVM(t, 704, "billing", "stopped", "billing", map[string]string{"tags": "web"})
+	pveBatch, _ := env.pve.VM(703)
+	pveBatch.Lock = "backup"
+	env.pve.AddVM(pveBatch)
+
+	// 按标签选择需要集群
+	_, err := env.bulkDeleteService.Prepare(ctx, adminID, &v1.PrepareVMBulkDeleteRequest{Tag: "decommission"})
+	assert.True(t, errors.Is(err, v1.ErrBadRequest))
+	data, err := env.bulkDeleteService.Prepare(ctx, adminID, &v1.PrepareVMBulkDeleteRequest{ClusterID: env.cluster.Id, Tag: "decommission"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{700, 701}, bulkDeleteVMIDs(data.Targets))
+
+	// 只选中受保护的虚拟机时没有可删除的目标；不存在的虚拟机直接报错
+	_, err = env.bulkDeleteService.Prepare(ctx, adminID, &v1.PrepareVMBulkDeleteRequest{VmIDs: []int64{db.Id}})
+	assert.True(t, errors.Is(err, v1.ErrVMBulkDeleteNoTargets))
+	_, err = env.bulkDeleteService.Prepare(ctx, adminID, &v1.PrepareVMBulkDeleteRequest{VmIDs: []int64{keep.Id + 100}})
+	assert.True(t, errors.Is(err, v1.ErrVMNotFound))
+
+	// 按项目选择：跳过开启删除保护和被备份锁定的虚拟机
+	data, err = env.bulkDeleteService.Prepare(ctx, adminID, &v1.PrepareVMBulkDeleteRequest{BusinessService: "legacy"})
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{700, 701}, bulkDeleteVMIDs(data.Targets))
+	require.Equal(t, []uint32{702, 703}, bulkDeleteVMIDs(data.Skipped))
+	assert.Equal(t, "protection is enabled", data.Skipped[0].Reason)
+	assert.Contains(t, data.Skipped[1].Reason, "backup")
+	assert.Equal(t, "delete 2 vms", data.ConfirmText)
+
+	// 确认文本错误时令牌保留；其他用户不能使用该令牌
+	_, err = env.bulkDeleteService.Confirm(ctx, adminID, &v1.ConfirmVMBulkDeleteRequest{ConfirmToken: data.ConfirmToken, ConfirmText: "delete 5 vms"})
+	assert.True(t, errors.Is(err, v1.ErrVMBulkDeleteConfirmText))
+	_, err = env.bulkDeleteService.Confirm(ctx, "someone-else", &v1.ConfirmVMBulkDeleteRequest{ConfirmToken: data.ConfirmToken, ConfirmText: data.ConfirmText})
+	assert.True(t, errors.Is(err, v1.ErrVMBulkDeleteTokenInvalid))
+
+	job, err := env.bulkDeleteService.Confirm(ctx, adminID, &v1.ConfirmVMBulkDeleteRequest{ConfirmToken: data.ConfirmToken, ConfirmText: data.ConfirmText})
+	require.NoError(t, err)
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, "admin", job.Creator)
+
+	// 令牌单次使用
+	_, err = env.bulkDeleteService.Confirm(ctx, adminID, &v1.ConfirmVMBulkDeleteRequest{ConfirmToken: data.ConfirmToken, ConfirmText: data.ConfirmText})
+	assert.True(t, errors.Is(err, v1.ErrVMBulkDeleteTokenInvalid))
+
+	eventually(t, 30*time.Second, func() bool {
+		report, err := env.bulkDeleteService.Get(context.Background(), job.Id)
+		return err == nil && report.Status == model.VMBulkDeleteJobStatusCompleted
+	}, "bulk delete job did not complete")
+
+	report, err := env.bulkDeleteService.Get(context.Background(), job.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Deleted)
+	assert.Zero(t, report.Failed)
+	for _, item := range report.Items {
+		assert.Equal(t, model.VMBulkDeleteItemDeleted, item.Status, item.Reason)
+	}
+	// 运行中的 web-1 先停止再删除
+	assert.Equal(t, 1, env.pve.CountRequests("POST", "/nodes/pve1/qemu/700/status/stop"))
+	for _, vm := range []*model.PveVM{web1, web2} {
+		_, ok := env.pve.VM(vm.VMID)
+		assert.False(t, ok)
+	}
+	for _, vmid := range []uint32{702, 703, 704} {
+		_, ok := env.pve.VM(vmid)
+		assert.True(t, ok)
+	}
+
+	list, err := env.bulkDeleteService.List(context.Background(), &v1.ListVMBulkDeleteJobsRequest{Creator: "admin"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), list.Total)
+
+	notifications, err := env.notificationService.List(context.Background(), adminID, &v1.ListNotificationsRequest{})
+	require.NoError(t, err)
+	require.NotEmpty(t, notifications.List)
+	assert.Equal(t, "vm_bulk_delete_completed", notifications.List[0].Event)
+}
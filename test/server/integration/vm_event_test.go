@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/controller"
+	"pvesphere/internal/model"
+	"pvesphere/pkg/jwt"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// userCtx 模拟 StrictAuth 中间件写入的登录信息
+func userCtx(userID string) context.Context {
+	return context.WithValue(context.Background(), "claims", &jwt.MyCustomClaims{UserId: userID})
+}
+
+func countSource(events []v1.VMEventItem, source string) int {
+	n := 0
+	for _, e := range events {
+		if e.Source == source {
+			n++
+		}
+	}
+	return n
+}
+
+func TestListVMEvents(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admin := env.addUser(t, "admin")
+	bob := env.addUser(t, "bob")
+	vm := env.addVM(t, "pve1", 300, "web-01", "stopped")
+	env.addVM(t, "pve1", 301, "web-02", "stopped")
+
+	require.NoError(t, env.vmService.StartVM(ctx, vm.Id))
+	started, err := env.vmEventService.ListEvents(userCtx(admin), vm.Id, &v1.ListVMEventsRequest{Source: v1.VMEventSourceTask})
+	require.NoError(t, err)
+	require.Len(t, started.List, 1)
+	upid := started.List[0].UPID
+
+	// 启动请求（关联任务）、bob 的配置修改和其他虚拟机的操作
+	require.NoError(t, env.auditRepo.Create(ctx, &model.OperationAudit{OperationID: "op-start", Method: "POST", Route: "/api/v1/vms/:id/start",
+		Path: fmt.Sprintf("/api/v1/vms/%d/start", vm.Id), StatusCode: 200, Username: "admin", TaskCount: 1, Tasks: fmt.Sprintf("[%q]", upid)}))
+	require.NoError(t, env.auditRepo.Create(ctx, &model.OperationAudit{OperationID: "op-update", Method: "PUT", Route: "/api/v1/vms/:id",
+		Path: fmt.Sprintf("/api/v1/vms/%d", vm.Id), StatusCode: 200, Username: "bob", Tasks: "[]"}))
+	require.NoError(t, env.auditRepo.Create(ctx, &model.OperationAudit{OperationID: "op-other", Method: "POST", Route: "/api/v1/vms/:id/start",
+		Path: fmt.Sprintf("/api/v1/vms/%d0/start", vm.Id), StatusCode: 200, Username: "admin", Tasks: "[]"}))
+
+	// 控制器同步时发现虚拟机已停止（如在 guest 内关机）
+	h := controller.NewVMEventHandler(env.vmRepo, env.nodeRepo, env.statusRepo, env.logger, env.cluster.Id, env.cluster.ClusterName)
+	require.NoError(t, h.OnUpdate(nil, &model.PveVM{VMID: 300, VmName: "web-01", NodeName: "pve1", Status: "running"}))
+	require.NoError(t, h.OnUpdate(nil, &model.PveVM{VMID: 300, VmName: "web-01", NodeName: "pve1", Status: "stopped"}))
+
+	data, err := env.vmEventService.ListEvents(userCtx(admin), vm.Id, &v1.ListVMEventsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, data.Warnings)
+	assert.EqualValues(t, 5, data.Total)
+	assert.Equal(t, 1, countSource(data.List, v1.VMEventSourceTask))
+	assert.Equal(t, 2, countSource(data.List, v1.VMEventSourceAudit))
+	assert.Equal(t, 2, countSource(data.List, v1.VMEventSourceStatus))
+	for i := 1; i < len(data.List); i++ {
+		assert.False(t, data.List[i].Time.After(data.List[i-1].Time), "events must be newest first")
+	}
+	for _, e := range data.List {
+		if e.Source == v1.VMEventSourceTask {
+			assert.Equal(t, "qmstart", e.Type)
+			assert.Equal(t, "op-start", e.OperationID)
+		}
+	}
+	// 最新的事件是检测到的 running -> stopped
+	assert.Equal(t, "running -> stopped", data.List[0].Summary)
+
+	// 非管理员只能看到自己的操作记录
+	data, err = env.vmEventService.ListEvents(userCtx(bob), vm.Id, &v1.ListVMEventsRequest{Source: v1.VMEventSourceAudit})
+	require.NoError(t, err)
+	require.Len(t, data.List, 1)
+	assert.Equal(t, "op-update", data.List[0].OperationID)
+
+	page, err := env.vmEventService.ListEvents(userCtx(admin), vm.Id, &v1.ListVMEventsRequest{Page: 2, PageSize: 2})
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, page.Total)
+	assert.Len(t, page.List, 2)
+}
+
+func TestListVMEvents_ProxmoxUnavailable(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.addUser(t, "admin")
+	vm := env.addVM(t, "pve1", 300, "web-01", "running")
+	h := controller.NewVMEventHandler(env.vmRepo, env.nodeRepo, env.statusRepo, env.logger, env.cluster.Id, env.cluster.ClusterName)
+	require.NoError(t, h.OnUpdate(nil, &model.PveVM{VMID: 300, VmName: "web-01", NodeName: "pve1", Status: "stopped"}))
+
+	env.pve.FailRequests(http.MethodGet, "/cluster/tasks", http.StatusServiceUnavailable, -1)
+	data, err := env.vmEventService.ListEvents(userCtx(admin), vm.Id, &v1.ListVMEventsRequest{})
+	require.NoError(t, err)
+	assert.Len(t, data.Warnings, 1)
+	require.Len(t, data.List, 1)
+	assert.Equal(t, v1.VMEventSourceStatus, data.List[0].Source)
+
+	_, err = env.vmEventService.ListEvents(userCtx(admin), vm.Id+100, &v1.ListVMEventsRequest{})
+	assert.ErrorIs(t, err, v1.ErrVMNotFound)
+}
@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/repository"
+	"pvesphere/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (e *testEnv) vmHibernateService() service.VMHibernateService {
+	notifications := service.NewNotificationService(e.svc, e.conf, repository.NewNotificationRepository(e.repo), e.userRepo, e.logger)
+	vmLock := service.NewVMLockService(e.svc, e.conf, repository.NewVMLockRepository(e.repo), e.vmRepo, e.nodeRepo, e.clusterRepo, e.userRepo, e.logger)
+	changeControl := service.NewChangeControlService(e.svc, e.conf, repository.NewChangeWindowRepository(e.repo), e.clusterRepo, e.userRepo, vmLock, e.logger)
+	return service.NewVMHibernateService(e.svc, e.conf, e.vmRepo, e.nodeRepo, e.clusterRepo, changeControl, vmLock,
+		service.NewVMTaskTracker(e.conf, e.vmRepo, e.userRepo, notifications, e.logger), e.logger)
+}
+
+func TestVMHibernate(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	svc := env.vmHibernateService()
+
+	vm := env.addVM(t, "pve1", 910, "stateful-app", "running")
+	vm.Owner = "alice"
+	require.NoError(t, env.vmRepo.Update(ctx, vm))
+	env.addVM(t, "pve2", 911, "web", "running")
+
+	// 状态存储必须支持 images
+	_, err := svc.Hibernate(ctx, vm.Id, &v1.HibernateVMRequest{StateStorage: "local"})
+	assert.ErrorIs(t, err, v1.ErrVMStateStorageInvalid)
+	_, err = svc.Hibernate(ctx, vm.Id, &v1.HibernateVMRequest{StateStorage: "nfs-missing"})
+	assert.ErrorIs(t, err, v1.ErrVMStateStorageInvalid)
+
+	// 未休眠的虚拟机不能恢复
+	_, err = svc.Resume(ctx, vm.Id)
+	assert.ErrorIs(t, err, v1.ErrVMNotHibernated)
+
+	data, err := svc.Hibernate(ctx, vm.Id, &v1.HibernateVMRequest{StateStorage: "local-lvm"})
+	require.NoError(t, err)
+	assert.NotEmpty(t, data.UPID)
+	assert.Equal(t, "local-lvm", data.StateStorage)
+
+	eventually(t, 5*time.Second, func() bool {
+		pveVM, _ := env.pve.VM(910)
+		return pveVM.Lock == "suspended" && pveVM.Status == "stopped"
+	}, "vm hibernated")
+	eventually(t, 5*time.Second, func() bool {
+		current, err := env.vmRepo.GetByID(ctx, vm.Id)
+		return err == nil && current.Status == "stopped"
+	}, "vm status synced")
+
+	// 已停止的虚拟机不能再次休眠
+	_, err = svc.Hibernate(ctx, vm.Id, nil)
+	assert.ErrorIs(t, err, v1.ErrVMAlreadyStopped)
+
+	list, err := svc.ListHibernated(ctx, &v1.ListHibernatedVMsRequest{})
+	require.NoError(t, err)
+	require.Len(t, list.Items, 1)
+	item := list.Items[0]
+	assert.Equal(t, vm.Id, item.VmId)
+	assert.Equal(t, uint32(910), item.VMID)
+	assert.Equal(t, "pve1", item.NodeName)
+	assert.Equal(t, env.nodes["pve1"].Id, item.NodeID)
+	assert.Equal(t, "local-lvm", item.StateStorage)
+	assert.Contains(t, item.StateVolume, "vm-910-state-suspend")
+	assert.Equal(t, int64(2048), item.MemoryMB)
+	assert.Equal(t, "alice", item.Owner)
+	assert.Empty(t, list.FailedClusters)
+
+	_, err = svc.ListHibernated(ctx, &v1.ListHibernatedVMsRequest{ClusterID: 99999})
+	assert.ErrorIs(t, err, v1.ErrClusterNotFound)
+
+	// 恢复：启动后删除 vmstate
+	resumed, err := svc.Resume(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.NotEmpty(t, resumed.UPID)
+	eventually(t, 5*time.Second, func() bool {
+		pveVM, _ := env.pve.VM(910)
+		return pveVM.Status == "running" && pveVM.Lock == "" && pveVM.Config["vmstate"] == ""
+	}, "vm resumed")
+	eventually(t, 5*time.Second, func() bool {
+		current, err := env.vmRepo.GetByID(ctx, vm.Id)
+		return err == nil && current.Status == "running"
+	}, "vm status synced")
+
+	list, err = svc.ListHibernated(ctx, &v1.ListHibernatedVMsRequest{ClusterID: env.cluster.Id})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+
+	_, err = svc.Hibernate(ctx, 99999, nil)
+	assert.ErrorIs(t, err, v1.ErrVMNotFound)
+}
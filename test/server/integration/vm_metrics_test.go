@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addRunningVM 登记一台启用 agent 的运行中虚拟机，agent 为空时模拟 agent 未运行
+func (e *testEnv) addRunningVM(t *testing.T, vmid uint32, name string, agent *proxmoxtest.GuestAgent) int64 {
+	t.Helper()
+	vm := e.addVM(t, "pve1", vmid, name, "running")
+	e.pve.AddVM(proxmoxtest.VM{
+		VMID:   vmid,
+		Node:   "pve1",
+		Name:   name,
+		Status: "running",
+		Config: map[string]string{"memory": "2048", "cores": "2", "agent": "1", "ostype": "l26"},
+		Stats: map[string]float64{
+			"cpu": 0.25, "mem": 1 << 30, "uptime": 3600,
+			"diskread": 4096, "diskwrite": 8192, "netin": 1000, "netout": 2000,
+		},
+		Agent: agent,
+	})
+	return vm.Id
+}
+
+func TestGetVMMetrics(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	id := env.addRunningVM(t, 300, "web-01", &proxmoxtest.GuestAgent{
+		OSInfo: map[string]interface{}{"id": "ubuntu", "pretty-name": "Ubuntu 22.04.4 LTS", "kernel-release": "5.15.0-100-generic"},
+		Exec:   map[string]string{"cat /proc/loadavg": "0.52 0.58 0.59 1/389 12345\n"},
+	})
+
+	data, err := env.vmService.GetVMMetrics(ctx, id, &v1.GetVMMetricsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, data.Warnings)
+	assert.Equal(t, "hour", data.Timeframe)
+	assert.Equal(t, "running", data.Current.Status)
+	assert.Equal(t, 2, data.Current.CPUs)
+	assert.InDelta(t, 0.25, data.Current.CPUUsage, 1e-9)
+	assert.EqualValues(t, 2048<<20, data.Current.MemTotalBytes)
+	assert.InDelta(t, 0.5, data.Current.MemUsage, 1e-9)
+	assert.EqualValues(t, 2000, data.Current.NetOutBytes)
+
+	require.Len(t, data.Series, 3)
+	require.NotNil(t, data.Series[0].CPUUsage)
+	assert.InDelta(t, 0.25, *data.Series[0].CPUUsage, 1e-9)
+
+	assert.True(t, data.Agent.Enabled)
+	assert.True(t, data.Agent.Available)
+	assert.Equal(t, "Ubuntu 22.04.4 LTS", data.Agent.OS)
+	require.NotNil(t, data.Agent.LoadAverage)
+	assert.InDelta(t, 0.58, data.Agent.LoadAverage.Load5, 1e-9)
+}
+
+func TestGetVMMetrics_PartialFailure(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	id := env.addRunningVM(t, 300, "web-01", nil)
+
+	// RRD 和 agent 失败时仍返回当前指标
+	env.pve.FailRequests(http.MethodGet, "/nodes/pve1/qemu/300/rrddata", http.StatusInternalServerError, 1)
+	data, err := env.vmService.GetVMMetrics(ctx, id, &v1.GetVMMetricsRequest{Timeframe: "day", Cf: "MAX"})
+	require.NoError(t, err)
+	assert.Equal(t, "running", data.Current.Status)
+	assert.Empty(t, data.Series)
+	assert.True(t, data.Agent.Enabled)
+	assert.False(t, data.Agent.Available)
+	assert.Len(t, data.Warnings, 2)
+
+	// 当前状态获取失败时整体失败
+	env.pve.FailRequests(http.MethodGet, "/nodes/pve1/qemu/300/status", http.StatusInternalServerError, 1)
+	_, err = env.vmService.GetVMMetrics(ctx, id, &v1.GetVMMetricsRequest{})
+	assert.ErrorIs(t, err, v1.ErrProxmoxRequestFailed)
+}
+
+func TestGetVMMetrics_StoppedSkipsAgent(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 300, "web-01", "stopped")
+
+	data, err := env.vmService.GetVMMetrics(ctx, vm.Id, &v1.GetVMMetricsRequest{})
+	require.NoError(t, err)
+	assert.Empty(t, data.Warnings)
+	assert.Equal(t, "stopped", data.Current.Status)
+	assert.False(t, data.Agent.Enabled)
+	assert.Zero(t, env.pve.CountRequests("", "/nodes/pve1/qemu/300/agent"))
+}
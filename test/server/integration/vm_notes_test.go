@@ -0,0 +1,123 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+const testVMNotes = "## 订单数据库\n\n```\nport=5432\n```\nowner=alice\nbackup.policy = daily"
+
+func TestVMNotes_AnnotationsAndUpdate(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 100, "db-1", "running")
+	env.pve.SetVMConfig(100, "description", testVMNotes)
+
+	// 读取时拆分正文和注解，代码块中的行不是注解，并同步到数据库
+	data, err := env.notesService.GetNotes(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"owner": "alice", "backup.policy": "daily"}, data.Annotations)
+	assert.Equal(t, "## 订单数据库\n\n```\nport=5432\n```", data.Notes)
+	assert.NotEmpty(t, data.Digest)
+	got, err := env.vmRepo.GetByID(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, testVMNotes, got.Description)
+
+	// 只替换注解，保留正文
+	updated, err := env.notesService.UpdateNotes(ctx, vm.Id, &v1.UpdateVMNotesRequest{
+		Annotations: map[string]string{"owner": "bob", "tier": "gold"},
+		Digest:      data.Digest,
+	})
+	require.NoError(t, err)
+	want := "## 订单数据库\n\n```\nport=5432\n```\n\nowner=bob\ntier=gold"
+	assert.Equal(t, want, updated.Description)
+	assert.NotEqual(t, data.Digest, updated.Digest)
+	pveVM, _ := env.pve.VM(100)
+	assert.Equal(t, want, pveVM.Config["description"])
+	got, err = env.vmRepo.GetByID(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, want, got.Description)
+
+	// 读取后在 Proxmox 中又被修改，使用旧摘要提交时拒绝
+	env.pve.SetVMConfig(100, "description", "edited in proxmox")
+	notes := "mine"
+	_, err = env.notesService.UpdateNotes(ctx, vm.Id, &v1.UpdateVMNotesRequest{Notes: &notes, Digest: updated.Digest})
+	assert.True(t, errors.Is(err, v1.ErrVMNotesConflict), "got %v", err)
+	pveVM, _ = env.pve.VM(100)
+	assert.Equal(t, "edited in proxmox", pveVM.Config["description"])
+
+	// 清空正文和注解时删除 description
+	empty := ""
+	_, err = env.notesService.UpdateNotes(ctx, vm.Id, &v1.UpdateVMNotesRequest{Notes: &empty, Annotations: map[string]string{}})
+	require.NoError(t, err)
+	pveVM, _ = env.pve.VM(100)
+	_, ok := pveVM.Config["description"]
+	assert.False(t, ok)
+}
+
+func TestVMNotes_Validation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 100, "web-1", "running")
+
+	for name, req := range map[string]*v1.UpdateVMNotesRequest{
+		"script":         {Notes: strPtr("hello <script>alert(1)</script>")},
+		"unclosed fence": {Notes: strPtr("```\ncode")},
+		"control char":   {Notes: strPtr("bell\x07")},
+		"too long":       {Notes: strPtr(string(make([]byte, 9000)))},
+		"annotation key": {Annotations: map[string]string{"1bad": "x"}},
+		"multiline":      {Annotations: map[string]string{"owner": "a\nb"}},
+	} {
+		_, err := env.notesService.UpdateNotes(ctx, vm.Id, req)
+		assert.True(t, errors.Is(err, v1.ErrVMNotesInvalid), "%s: got %v", name, err)
+	}
+	assert.Zero(t, env.pve.CountRequests("PUT", "/nodes/pve1/qemu/100/config"))
+
+	// 通过虚拟机修改接口设置的描述同样写入 Proxmox
+	description := "managed by **ops**"
+	require.NoError(t, env.vmService.UpdateVM(ctx, vm.Id, &v1.UpdateVMRequest{Description: &description}))
+	pveVM, _ := env.pve.VM(100)
+	assert.Equal(t, description, pveVM.Config["description"])
+	invalid := "<iframe src=x>"
+	err := env.vmService.UpdateVM(ctx, vm.Id, &v1.UpdateVMRequest{Description: &invalid})
+	assert.True(t, errors.Is(err, v1.ErrVMNotesInvalid), "got %v", err)
+}
+
+func TestVMNotes_SyncFromProxmox(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 100, "web-1", "running")
+	other := env.addVM(t, "pve2", 101, "web-2", "running")
+
+	// 定期同步：只更新有变化的虚拟机
+	env.pve.SetVMConfig(100, "description", "notes from proxmox")
+	n, err := env.notesService.Sync(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	got, err := env.vmRepo.GetByID(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "notes from proxmox", got.Description)
+	n, err = env.notesService.Sync(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	// 外部变更轮询发现备注修改时立即同步
+	env.pve.SetVMConfig(101, "description", "owner=carol")
+	env.pve.AddJournal("pve2", "pvedaemon[1234]: <carol@pve> update VM 101: -description owner=carol")
+	eventService, _ := env.clusterEventService()
+	_, err = eventService.Poll(ctx)
+	require.NoError(t, err)
+	got, err = env.vmRepo.GetByID(ctx, other.Id)
+	require.NoError(t, err)
+	assert.Equal(t, "owner=carol", got.Description)
+}
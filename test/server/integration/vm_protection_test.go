@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"errors"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVMProtection(t *testing.T) {
+	env := newTestEnv(t)
+	adminID := env.addUser(t, "admin")
+	aliceID := env.addUser(t, "alice")
+	ctx := userCtx(aliceID)
+
+	db := env.addVM(t, "pve1", 800, "prod-db", "running")
+
+	data, err := env.protectionService.Protect(ctx, aliceID, db.Id, &v1.ProtectVMRequest{Reason: "production database", Proxmox: true})
+	require.NoError(t, err)
+	assert.True(t, data.Protected)
+	assert.Equal(t, "alice", data.ProtectedBy)
+	assert.True(t, data.ProxmoxProtection)
+	assert.Contains(t, data.BlockedActions, "vm.stop")
+	pveVM, _ := env.pve.VM(800)
+	assert.Equal(t, "1", pveVM.Config["protection"])
+
+	// 删除、停止、迁移都被拒绝，且没有请求发到 Proxmox
+	err = env.vmService.StopVM(ctx, db.Id, &v1.StopVMRequest{})
+	assert.True(t, errors.Is(err, v1.ErrVMProtected))
+	_, err = env.vmService.MigrateVM(ctx, &v1.MigrateVMRequest{VMID: db.Id, TargetNodeID: env.nodes["pve2"].Id})
+	assert.True(t, errors.Is(err, v1.ErrVMProtected))
+	assert.Zero(t, env.pve.CountRequests("POST", "/nodes/pve1/qemu/800/status/stop"))
+	assert.Zero(t, env.pve.CountRequests("POST", "/nodes/pve1/qemu/800/migrate"))
+	_, err = env.bulkDeleteService.Prepare(ctx, aliceID, &v1.PrepareVMBulkDeleteRequest{VmIDs: []int64{db.Id}})
+	assert.True(t, errors.Is(err, v1.ErrVMBulkDeleteNoTargets))
+
+	detail, err := env.vmService.GetVM(ctx, db.Id)
+	require.NoError(t, err)
+	assert.True(t, detail.Protected)
+	assert.Equal(t, "production database", detail.ProtectedReason)
+
+	// 只有管理员可以解除保护
+	_, err = env.protectionService.Unprotect(ctx, aliceID, db.Id, &v1.UnprotectVMRequest{})
+	assert.True(t, errors.Is(err, v1.ErrAdminRequired))
+	data, err = env.protectionService.Unprotect(userCtx(adminID), adminID, db.Id, &v1.UnprotectVMRequest{})
+	require.NoError(t, err)
+	assert.False(t, data.Protected)
+	assert.True(t, data.ProxmoxProtection)
+	assert.Equal(t, []string{"vm.delete"}, data.BlockedActions)
+
+	// 平台保护解除后可以停止；Proxmox 的 protection 标志仍然拒绝删除
+	require.NoError(t, env.vmService.StopVM(ctx, db.Id, &v1.StopVMRequest{}))
+	stopped := env.addVM(t, "pve1", 801, "archive", "stopped")
+	pveVM, _ = env.pve.VM(801)
+	pveVM.Config["protection"] = "1"
+	env.pve.AddVM(pveVM)
+	err = env.vmService.DeleteVM(ctx, stopped.Id)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, v1.ErrVMProtected))
+	assert.Zero(t, env.pve.CountRequests("DELETE", "/nodes/pve1/qemu/801"))
+
+	data, err = env.protectionService.Unprotect(userCtx(adminID), adminID, db.Id, &v1.UnprotectVMRequest{Proxmox: true})
+	require.NoError(t, err)
+	assert.False(t, data.ProxmoxProtection)
+}
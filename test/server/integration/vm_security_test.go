@@ -0,0 +1,112 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findingCodes(items []v1.VMSecurityFindingItem) map[string]int {
+	codes := make(map[string]int)
+	for _, item := range items {
+		codes[item.Code]++
+	}
+	return codes
+}
+
+func TestVMSecurityScanAndRemediate(t *testing.T) {
+	env := newTestEnv(t)
+	admin := env.addUser(t, "admin")
+	ctx := userCtx(admin)
+	vm := env.addVM(t, "pve1", 300, "db-01", "running")
+	vm.Environment = "prod"
+	require.NoError(t, env.vmRepo.Update(context.Background(), vm))
+	env.pve.AddVM(proxmoxtest.VM{VMID: 300, Node: "pve1", Name: "db-01", Status: "running", Config: map[string]string{
+		"memory":  "4096",
+		"machine": "pc-q35-5.1",
+		"net0":    "virtio=BC:24:11:00:00:01,bridge=vmbr0",
+		"net1":    "virtio=BC:24:11:00:00:02,bridge=vmbr1,firewall=1",
+		"ide2":    "local:iso/ubuntu-22.04-live-server-amd64.iso,media=cdrom",
+		"ide3":    "local-lvm:vm-300-cloudinit,media=cdrom",
+		"args":    "-vnc 0.0.0.0:77",
+		"scsi0":   "local-lvm:vm-300-disk-0,size=32G",
+	}})
+
+	scan, err := env.securityService.Scan(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		v1.SecurityFindingAgentDisabled:    1,
+		v1.SecurityFindingFirewallDisabled: 1,
+		v1.SecurityFindingVNCNoPassword:    1,
+		v1.SecurityFindingOutdatedMachine:  1,
+		v1.SecurityFindingISOMounted:       1,
+		v1.SecurityFindingCPUKVM64Prod:     1,
+	}, findingCodes(scan.Findings))
+	assert.Equal(t, v1.SecuritySeverityHigh, scan.Findings[0].Severity)
+	assert.False(t, scan.Findings[0].Fixable)
+
+	list, err := env.securityService.List(context.Background(), &v1.ListVMSecurityFindingsRequest{Severity: v1.SecuritySeverityMedium})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, list.Total)
+	assert.EqualValues(t, 1, list.BySeverity[v1.SecuritySeverityHigh])
+	assert.EqualValues(t, 3, list.BySeverity[v1.SecuritySeverityLow])
+
+	result, err := env.securityService.Remediate(ctx, admin, vm.Id, &v1.RemediateVMSecurityRequest{Codes: []string{
+		v1.SecurityFindingFirewallDisabled, v1.SecurityFindingISOMounted, v1.SecurityFindingOutdatedMachine,
+		v1.SecurityFindingCPUKVM64Prod, v1.SecurityFindingAgentDisabled,
+	}})
+	require.NoError(t, err)
+	assert.Len(t, result.Applied, 5)
+	assert.Empty(t, result.Skipped)
+	assert.True(t, result.RestartRequired)
+	assert.Equal(t, map[string]int{v1.SecurityFindingVNCNoPassword: 1}, findingCodes(result.Findings))
+
+	config, _ := env.pve.VM(300)
+	assert.Equal(t, "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1", config.Config["net0"])
+	assert.Equal(t, "none,media=cdrom", config.Config["ide2"])
+	assert.Equal(t, "local-lvm:vm-300-cloudinit,media=cdrom", config.Config["ide3"])
+	assert.Equal(t, "q35", config.Config["machine"])
+	assert.Equal(t, "x86-64-v2-AES", config.Config["cpu"])
+	assert.Equal(t, "1", config.Config["agent"])
+
+	// 已修复的风险项再次修复时跳过
+	result, err = env.securityService.Remediate(ctx, admin, vm.Id, &v1.RemediateVMSecurityRequest{Codes: []string{v1.SecurityFindingISOMounted}})
+	require.NoError(t, err)
+	assert.Empty(t, result.Applied)
+	require.Len(t, result.Skipped, 1)
+	assert.False(t, result.RestartRequired)
+}
+
+func TestVMSecurityScanAll(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	clean := env.addVM(t, "pve1", 300, "web-01", "running")
+	env.pve.AddVM(proxmoxtest.VM{VMID: 300, Node: "pve1", Name: "web-01", Status: "running", Config: map[string]string{
+		"agent": "1", "machine": "q35", "net0": "virtio=BC:24:11:00:00:01,bridge=vmbr0,firewall=1", "ide2": "none,media=cdrom",
+	}})
+	risky := env.addVM(t, "pve2", 301, "web-02", "stopped")
+	env.pve.AddVM(proxmoxtest.VM{VMID: 301, Node: "pve2", Name: "web-02", Status: "stopped", Config: map[string]string{
+		"agent": "enabled=0", "net0": "virtio=BC:24:11:00:00:03,bridge=vmbr0",
+	}})
+	// 读取配置失败的虚拟机跳过
+	env.addVM(t, "pve1", 302, "broken", "stopped")
+	env.pve.FailRequests("GET", "/nodes/pve1/qemu/302/config", 500, 1)
+
+	scanned, err := env.securityService.ScanAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, scanned)
+
+	list, err := env.securityService.List(ctx, &v1.ListVMSecurityFindingsRequest{ClusterID: env.cluster.Id})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, list.Total)
+	for _, item := range list.List {
+		assert.Equal(t, risky.Id, item.VmId)
+		assert.NotEqual(t, clean.Id, item.VmId)
+	}
+	assert.Equal(t, map[string]int{v1.SecurityFindingAgentDisabled: 1, v1.SecurityFindingFirewallDisabled: 1}, findingCodes(list.List))
+}
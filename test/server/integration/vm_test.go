@@ -0,0 +1,201 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "pvesphere/api/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isoCreateRequest(env *testEnv, vmid uint32, name string) *v1.CreateVMRequest {
+	return &v1.CreateVMRequest{
+		CreateMode: "iso",
+		VmName:     name,
+		ClusterID:  env.cluster.Id,
+		NodeID:     env.nodes["pve1"].Id,
+		VMID:       vmid,
+		Storage:    "local-lvm",
+		ISOVolume:  "local:iso/debian-12.iso",
+	}
+}
+
+func TestCreateVM_ISO(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, isoCreateRequest(env, 201, "web-01")))
+
+	vm, ok := env.pve.VM(201)
+	require.True(t, ok, "vm should exist in proxmox")
+	assert.Equal(t, "pve1", vm.Node)
+	assert.Equal(t, "web-01", vm.Name)
+	assert.Equal(t, "local:iso/debian-12.iso,media=cdrom", vm.Config["ide2"])
+	assert.True(t, strings.HasPrefix(vm.Config["scsi0"], "local-lvm:vm-201-"), "scsi0=%s", vm.Config["scsi0"])
+
+	record, err := env.vmRepo.GetByVMID(ctx, 201, env.nodes["pve1"].Id)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "web-01", record.VmName)
+	assert.Equal(t, "stopped", record.Status)
+	assert.Equal(t, 1, env.pve.CountRequests(http.MethodPost, "/nodes/pve1/qemu"))
+}
+
+func TestCreateVM_ValidationFailed(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	req := isoCreateRequest(env, 202, "web-02")
+	req.Bridge = "vmbr9"
+	req.ISOVolume = "local:iso/missing.iso"
+	err := env.vmService.CreateVMInProxmox(ctx, req)
+	require.ErrorIs(t, err, v1.ErrCreateVMValidationFailed)
+	assert.Contains(t, err.Error(), "bridge vmbr9 does not exist")
+	assert.Contains(t, err.Error(), "iso volume local:iso/missing.iso does not exist")
+
+	// 校验失败时不应调用创建接口
+	assert.Equal(t, 0, env.pve.CountRequests(http.MethodPost, "/nodes/pve1/qemu"))
+}
+
+func TestCreateVM_Unauthorized(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	// 集群令牌失效：校验接口返回 401 时跳过校验，创建调用失败
+	env.pve.RequireToken(testUserID, "rotated-token")
+	err := env.vmService.CreateVMInProxmox(ctx, isoCreateRequest(env, 203, "web-03"))
+	require.ErrorIs(t, err, v1.ErrProxmoxRequestFailed)
+	assert.Contains(t, err.Error(), "status 401")
+
+	record, err := env.vmRepo.GetByVMID(ctx, 203, env.nodes["pve1"].Id)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestCreateVM_ServerError(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	env.pve.FailRequests(http.MethodPost, "/nodes/pve1/qemu", http.StatusInternalServerError, 1)
+	err := env.vmService.CreateVMInProxmox(ctx, isoCreateRequest(env, 204, "web-04"))
+	require.ErrorIs(t, err, v1.ErrProxmoxRequestFailed)
+
+	record, err := env.vmRepo.GetByVMID(ctx, 204, env.nodes["pve1"].Id)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	_, ok := env.pve.VM(204)
+	assert.False(t, ok)
+
+	// 故障只注入一次，重试成功
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, isoCreateRequest(env, 204, "web-04")))
+	_, ok = env.pve.VM(204)
+	assert.True(t, ok)
+}
+
+func TestDeleteVM(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 301, "db-01", "stopped")
+
+	require.NoError(t, env.vmService.DeleteVM(ctx, vm.Id))
+
+	_, ok := env.pve.VM(301)
+	assert.False(t, ok, "vm should be destroyed in proxmox")
+	record, err := env.vmRepo.GetByID(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	requests := env.pve.Requests()
+	last := requests[len(requests)-1]
+	assert.Equal(t, http.MethodDelete, last.Method)
+	assert.Equal(t, "/nodes/pve1/qemu/301", last.Path)
+	assert.Equal(t, "1", last.Params.Get("purge"))
+}
+
+func TestDeleteVM_ProxmoxUnavailable(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 302, "db-02", "stopped")
+
+	// 503 不能当作虚拟机已不存在处理，数据库记录必须保留
+	env.pve.FailRequests(http.MethodGet, "/nodes/pve1/qemu/302/config", http.StatusServiceUnavailable, -1)
+	err := env.vmService.DeleteVM(ctx, vm.Id)
+	require.ErrorIs(t, err, v1.ErrProxmoxRequestFailed)
+
+	record, err := env.vmRepo.GetByID(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.NotNil(t, record)
+	_, ok := env.pve.VM(302)
+	assert.True(t, ok)
+	assert.Equal(t, 0, env.pve.CountRequests(http.MethodDelete, "/nodes/pve1/qemu/302"))
+}
+
+func TestDeleteVM_AlreadyGoneInProxmox(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 303, "db-03", "stopped")
+
+	// Proxmox 中已不存在（配置文件不存在返回 500）时只删除数据库记录
+	env.pve.FailRequests(http.MethodGet, "/nodes/pve1/qemu/303/config", http.StatusInternalServerError, -1)
+	require.NoError(t, env.vmService.DeleteVM(ctx, vm.Id))
+
+	record, err := env.vmRepo.GetByID(ctx, vm.Id)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+	assert.Equal(t, 0, env.pve.CountRequests(http.MethodDelete, "/nodes/pve1/qemu/303"))
+}
+
+func TestMigrateVM(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 401, "app-01", "stopped")
+
+	upid, err := env.vmService.MigrateVM(ctx, &v1.MigrateVMRequest{VMID: vm.Id, TargetNodeID: env.nodes["pve2"].Id})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(upid, "UPID:pve1:"), "upid=%s", upid)
+	assert.Contains(t, upid, ":qmigrate:401:")
+
+	migrated, ok := env.pve.VM(401)
+	require.True(t, ok)
+	assert.Equal(t, "pve2", migrated.Node)
+	assert.Empty(t, migrated.Lock)
+}
+
+func TestMigrateVM_SlowTaskBlocksDelete(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 402, "app-02", "stopped")
+
+	env.pve.SetTaskDurationFor("qmigrate", time.Minute)
+	upid, err := env.vmService.MigrateVM(ctx, &v1.MigrateVMRequest{VMID: vm.Id, TargetNodeID: env.nodes["pve2"].Id})
+	require.NoError(t, err)
+
+	// 迁移进行中虚拟机持有 migrate lock，删除被拒绝并给出正在运行的任务
+	err = env.vmService.DeleteVM(ctx, vm.Id)
+	require.ErrorIs(t, err, v1.ErrVMProxmoxLocked)
+	assert.Contains(t, err.Error(), upid)
+
+	running, ok := env.pve.VM(402)
+	require.True(t, ok)
+	assert.Equal(t, "pve1", running.Node)
+	assert.Equal(t, "migrate", running.Lock)
+}
+
+func TestMigrateVM_Unauthorized(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	vm := env.addVM(t, "pve1", 403, "app-03", "stopped")
+
+	env.pve.RequireToken(testUserID, "rotated-token")
+	_, err := env.vmService.MigrateVM(ctx, &v1.MigrateVMRequest{VMID: vm.Id, TargetNodeID: env.nodes["pve2"].Id})
+	require.Error(t, err)
+
+	still, ok := env.pve.VM(403)
+	require.True(t, ok)
+	assert.Equal(t, "pve1", still.Node)
+}
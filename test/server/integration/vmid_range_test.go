@@ -0,0 +1,119 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	v1 "pvesphere/api/v1"
+	"pvesphere/internal/model"
+	"pvesphere/pkg/proxmox/proxmoxtest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupVMIDRanges 登记 team-a 专属段 1000-1009 和集群默认段 2000-2009
+func (e *testEnv) setupVMIDRanges(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+	admin := e.addUser(t, "admin")
+	_, err := e.vmidRangeService.CreateRange(ctx, admin, &v1.CreateVMIDRangeRequest{ClusterID: e.cluster.Id, Team: "team-a", Start: 1000, End: 1009})
+	require.NoError(t, err)
+	_, err = e.vmidRangeService.CreateRange(ctx, admin, &v1.CreateVMIDRangeRequest{ClusterID: e.cluster.Id, Start: 2000, End: 2009})
+	require.NoError(t, err)
+	return admin
+}
+
+func teamCreateRequest(env *testEnv, team, name string) *v1.CreateVMRequest {
+	req := isoCreateRequest(env, 0, name)
+	req.Team = team
+	return req
+}
+
+func TestVMIDRange_AllocatesFromTeamRange(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.setupVMIDRanges(t)
+
+	// 1000 被手动创建的虚拟机占用（平台无记录），分配时跳过
+	env.pve.AddVM(proxmoxtest.VM{VMID: 1000, Node: "pve1", Name: "manual", Status: "stopped"})
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, teamCreateRequest(env, "team-a", "a-01")))
+	vm, err := env.vmRepo.GetByVMID(ctx, 1001, env.nodes["pve1"].Id)
+	require.NoError(t, err)
+	require.NotNil(t, vm)
+	assert.Equal(t, "team-a", vm.Team)
+
+	// 没有专属段的团队使用集群默认段
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, teamCreateRequest(env, "team-b", "b-01")))
+	_, ok := env.pve.VM(2000)
+	assert.True(t, ok)
+
+	// 不能指定其他团队段内的 VMID
+	req := isoCreateRequest(env, 1005, "b-02")
+	req.Team = "team-b"
+	err = env.vmService.CreateVMInProxmox(ctx, req)
+	require.ErrorIs(t, err, v1.ErrVMIDReservedByTeam)
+	_, ok = env.pve.VM(1005)
+	assert.False(t, ok)
+
+	data, err := env.vmidRangeService.ListRanges(ctx, &v1.ListVMIDRangesRequest{ClusterID: env.cluster.Id, Team: "team-a"})
+	require.NoError(t, err)
+	require.Len(t, data.List, 1)
+	assert.EqualValues(t, 10, data.List[0].Size)
+	assert.EqualValues(t, 1, data.List[0].Allocated)
+}
+
+func TestVMIDRange_ReleasedVMIDIsQuarantined(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.setupVMIDRanges(t)
+
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, teamCreateRequest(env, "team-a", "a-01")))
+	vm, err := env.vmRepo.GetByVMID(ctx, 1000, env.nodes["pve1"].Id)
+	require.NoError(t, err)
+	require.NotNil(t, vm)
+	require.NoError(t, env.vmService.DeleteVM(ctx, vm.Id))
+
+	// 1000 处于隔离期，新虚拟机使用下一个 VMID，显式指定也会被拒绝
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, teamCreateRequest(env, "team-a", "a-02")))
+	_, ok := env.pve.VM(1001)
+	assert.True(t, ok)
+	req := isoCreateRequest(env, 1000, "a-03")
+	req.Team = "team-a"
+	require.ErrorIs(t, env.vmService.CreateVMInProxmox(ctx, req), v1.ErrVMIDQuarantined)
+
+	released, err := env.vmidRangeService.ListAllocations(ctx, &v1.ListVMIDAllocationsRequest{ClusterID: env.cluster.Id, Status: model.VMIDAllocationStatusReleased})
+	require.NoError(t, err)
+	require.EqualValues(t, 1, released.Total)
+	assert.EqualValues(t, 1000, released.List[0].VMID)
+	require.NotNil(t, released.List[0].QuarantineUntil)
+}
+
+func TestVMIDRange_FailedCreateCancelsAllocation(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	env.setupVMIDRanges(t)
+
+	// 存储不存在，创建前校验失败，分配被撤销，下一次仍从 1000 开始
+	req := teamCreateRequest(env, "team-a", "a-01")
+	req.Storage = "missing"
+	require.Error(t, env.vmService.CreateVMInProxmox(ctx, req))
+	require.NoError(t, env.vmService.CreateVMInProxmox(ctx, teamCreateRequest(env, "team-a", "a-02")))
+	vm, ok := env.pve.VM(1000)
+	require.True(t, ok)
+	assert.Equal(t, "a-02", vm.Name)
+}
+
+func TestVMIDRange_RejectsOverlap(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admin := env.setupVMIDRanges(t)
+
+	// 全部集群适用的段与集群专属段重叠
+	_, err := env.vmidRangeService.CreateRange(ctx, admin, &v1.CreateVMIDRangeRequest{Team: "team-c", Start: 1005, End: 1100})
+	assert.ErrorIs(t, err, v1.ErrVMIDRangeOverlap)
+	_, err = env.vmidRangeService.CreateRange(ctx, admin, &v1.CreateVMIDRangeRequest{Team: "team-c", Start: 3000, End: 2999})
+	assert.ErrorIs(t, err, v1.ErrVMIDRangeInvalid)
+	_, err = env.vmidRangeService.CreateRange(ctx, admin, &v1.CreateVMIDRangeRequest{Team: "team-c", Start: 3000, End: 3999})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,248 @@
+package proxmox
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"pvesphere/pkg/proxmox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 客户端解析的契约测试：testdata/pve-<版本> 下是从各版本 PVE 录制的接口响应，
+// 覆盖 version、节点状态、集群资源和磁盘树（LVM / LVM-Thin / ZFS / Directory），
+// 这些接口在不同版本间存在对象与数组、数值与字符串等差异
+
+// fixtureRoutes API 路径到录制文件的映射
+var fixtureRoutes = map[string]string{
+	"/api2/json/version":                    "version.json",
+	"/api2/json/nodes/pve1/status":          "node_status.json",
+	"/api2/json/cluster/resources":          "cluster_resources.json",
+	"/api2/json/nodes/pve1/disks/lvm":       "disks_lvm.json",
+	"/api2/json/nodes/pve1/disks/lvmthin":   "disks_lvmthin.json",
+	"/api2/json/nodes/pve1/disks/zfs":       "disks_zfs.json",
+	"/api2/json/nodes/pve1/disks/directory": "disks_directory.json",
+}
+
+type diskExpect struct {
+	name  string
+	vg    string
+	size  int64
+	free  int64
+	leaf  int
+	count int // lvcount，-1 表示该版本不返回
+}
+
+type contractCase struct {
+	version string
+	release string
+	manager string // pveversion 中的 pve-manager 版本
+	kernel  string // current-kernel.release，旧版本为空
+	vmName  string
+
+	lvm       []diskExpect
+	lvmthin   map[string]string // 名称 -> vg
+	zfs       map[string]string // 名称 -> health
+	directory map[string]string // 名称 -> path
+}
+
+var contractCases = []contractCase{
+	{
+		version: "6.4-13",
+		release: "6.4",
+		manager: "6.4-15",
+		vmName:  "legacy-app",
+		lvm: []diskExpect{
+			{name: "pve", vg: "pve", size: 1000203091968, free: 16106127360, leaf: 0, count: -1},
+			{name: "/dev/sda3", vg: "pve", size: 1000203091968, free: 16106127360, leaf: 1, count: -1},
+		},
+		lvmthin:   map[string]string{"data": "pve"},
+		zfs:       map[string]string{"tank": "ONLINE"},
+		directory: map[string]string{"backup": "/mnt/pve/backup"},
+	},
+	{
+		version: "7.4-17",
+		release: "7.4",
+		manager: "7.4-17",
+		vmName:  "web-01",
+		lvm: []diskExpect{
+			{name: "pve", vg: "pve", size: 1999844147200, free: 17179869184, leaf: 0, count: 3},
+			{name: "/dev/nvme0n1p3", vg: "pve", size: 1999844147200, free: 17179869184, leaf: 1, count: -1},
+		},
+		lvmthin:   map[string]string{"data": "pve"},
+		zfs:       map[string]string{"rpool": "ONLINE", "tank": "DEGRADED"},
+		directory: map[string]string{"backup": "/mnt/pve/backup"},
+	},
+	{
+		version: "8.2.4",
+		release: "8.2",
+		manager: "8.2.4",
+		kernel:  "6.8.8-2-pve",
+		vmName:  "db-01",
+		lvm: []diskExpect{
+			{name: "pve", vg: "pve", size: 3839999279104, free: 34359738368, leaf: 0, count: 4},
+			{name: "/dev/nvme0n1p3", vg: "pve", size: 3839999279104, free: 34359738368, leaf: 1, count: -1},
+		},
+		lvmthin:   map[string]string{"data": "pve", "fast": "nvme"},
+		zfs:       map[string]string{},
+		directory: map[string]string{},
+	},
+}
+
+// newFixtureServer 按请求路径返回指定版本目录下的录制响应
+func newFixtureServer(t *testing.T, dir string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, ok := fixtureRoutes[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"data":null,"message":"no fixture"}`, http.StatusNotImplemented)
+			return
+		}
+		body, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClientContract(t *testing.T) {
+	for _, tc := range contractCases {
+		tc := tc
+		t.Run("pve-"+tc.release, func(t *testing.T) {
+			srv := newFixtureServer(t, filepath.Join("testdata", "pve-"+tc.release))
+			client, err := proxmox.NewProxmoxClient(srv.URL, "root@pam!contract", "token")
+			require.NoError(t, err)
+			ctx := context.Background()
+
+			t.Run("version", func(t *testing.T) {
+				version, err := client.GetVersion(ctx)
+				require.NoError(t, err)
+				assert.Equal(t, tc.version, version["version"])
+				assert.Equal(t, tc.release, version["release"])
+			})
+
+			t.Run("node status", func(t *testing.T) {
+				status, err := client.GetNodeStatus(ctx, "pve1")
+				require.NoError(t, err)
+				assert.Equal(t, "pve-manager/"+tc.manager, filepath.Dir(status["pveversion"].(string)))
+				kernel, ok := status["current-kernel"].(map[string]interface{})
+				if tc.kernel == "" {
+					assert.False(t, ok, "current-kernel should be absent")
+					assert.NotEmpty(t, status["kversion"])
+				} else {
+					require.True(t, ok, "current-kernel should be present")
+					assert.Equal(t, tc.kernel, kernel["release"])
+				}
+				cpuinfo, ok := status["cpuinfo"].(map[string]interface{})
+				require.True(t, ok)
+				assert.NotEmpty(t, cpuinfo["flags"])
+			})
+
+			t.Run("cluster resources", func(t *testing.T) {
+				resources, err := client.GetClusterResources(ctx)
+				require.NoError(t, err)
+				require.Len(t, resources, 3)
+
+				byType := make(map[string]map[string]interface{})
+				for _, res := range resources {
+					byType[res["type"].(string)] = res
+				}
+				require.Contains(t, byType, "qemu")
+				assert.Equal(t, tc.vmName, byType["qemu"]["name"])
+				assert.Equal(t, "pve1", byType["node"]["node"])
+				assert.Equal(t, "local-lvm", byType["storage"]["storage"])
+			})
+
+			t.Run("disks lvm", func(t *testing.T) {
+				lvm, err := client.GetNodeDisksLVM(ctx, "pve1")
+				require.NoError(t, err)
+				require.Len(t, lvm, len(tc.lvm))
+				for i, want := range tc.lvm {
+					got := lvm[i]
+					assert.Equal(t, want.name, got["name"])
+					assert.Equal(t, want.vg, got["vg_name"])
+					assert.Equal(t, want.size, got["size"], "size of %s", want.name)
+					assert.Equal(t, want.free, got["free"], "free of %s", want.name)
+					assert.Equal(t, want.leaf, got["leaf"])
+					if want.count < 0 {
+						assert.NotContains(t, got, "lvcount")
+					} else {
+						assert.Equal(t, want.count, got["lvcount"])
+					}
+				}
+			})
+
+			t.Run("disks lvmthin", func(t *testing.T) {
+				pools, err := client.GetNodeDisksLVMThin(ctx, "pve1")
+				require.NoError(t, err)
+				got := make(map[string]string, len(pools))
+				for _, pool := range pools {
+					name, _ := pool["name"].(string)
+					vg, _ := pool["vg"].(string)
+					got[name] = vg
+				}
+				assert.Equal(t, tc.lvmthin, got)
+			})
+
+			t.Run("disks zfs", func(t *testing.T) {
+				pools, err := client.GetNodeDisksZFS(ctx, "pve1")
+				require.NoError(t, err)
+				require.NotNil(t, pools)
+				got := make(map[string]string, len(pools))
+				for _, pool := range pools {
+					name, _ := pool["name"].(string)
+					health, _ := pool["health"].(string)
+					got[name] = health
+				}
+				assert.Equal(t, tc.zfs, got)
+			})
+
+			t.Run("disks directory", func(t *testing.T) {
+				dirs, err := client.GetNodeDisksDirectory(ctx, "pve1")
+				require.NoError(t, err)
+				require.NotNil(t, dirs)
+				got := make(map[string]string, len(dirs))
+				for _, dir := range dirs {
+					name, _ := dir["name"].(string)
+					path, _ := dir["path"].(string)
+					got[name] = path
+				}
+				assert.Equal(t, tc.directory, got)
+			})
+		})
+	}
+}
+
+// TestFixturesCoverRoutes 每个版本目录必须包含全部录制文件，新增路由时同步补齐各版本数据
+func TestFixturesCoverRoutes(t *testing.T) {
+	dirs, err := filepath.Glob(filepath.Join("testdata", "pve-*"))
+	require.NoError(t, err)
+	require.Len(t, dirs, len(contractCases))
+
+	want := make([]string, 0, len(fixtureRoutes))
+	for _, file := range fixtureRoutes {
+		want = append(want, file)
+	}
+	sort.Strings(want)
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		got := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			got = append(got, entry.Name())
+		}
+		sort.Strings(got)
+		assert.Equal(t, want, got, dir)
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"pvesphere/internal/model"
+	"pvesphere/internal/repository"
+	"pvesphere/pkg/config"
+	"pvesphere/pkg/log"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 数据库兼容性测试：连接真实数据库（MySQL / PostgreSQL / SQLite）执行基础读写
+// 通过 TEST_DB_CONF 指定配置文件，例如：TEST_DB_CONF=../../../config/ci-postgres.yml
+func setupDatabase(t *testing.T) *repository.Repository {
+	confPath := os.Getenv("TEST_DB_CONF")
+	if confPath == "" {
+		t.Skip("TEST_DB_CONF not set, skip database compatibility test")
+	}
+	conf := config.NewConfig(confPath)
+	l := log.NewLog(conf)
+	db := repository.NewDB(conf, l)
+	require.NoError(t, db.AutoMigrate(&model.PveNode{}, &model.VmQosProfile{}))
+	return repository.NewRepository(l, db)
+}
+
+func TestDatabase_CaseInsensitiveLike(t *testing.T) {
+	repo := setupDatabase(t)
+	qosRepo := repository.NewVmQosProfileRepository(repo)
+	ctx := context.Background()
+
+	name := fmt.Sprintf("CI-Profile-%d", time.Now().UnixNano())
+	profile := &model.VmQosProfile{Name: name, MbpsRd: 100}
+	require.NoError(t, qosRepo.Create(ctx, profile))
+	defer qosRepo.Delete(ctx, profile.Id)
+
+	profiles, total, err := qosRepo.ListWithPagination(ctx, 1, 10, "ci-profile-")
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, total, int64(1))
+
+	found := false
+	for _, p := range profiles {
+		if p.Id == profile.Id {
+			found = true
+			// 时间字段写入后读出应保持一致
+			assert.True(t, p.CreateTime.Equal(profile.CreateTime), "create_time %v != %v", p.CreateTime, profile.CreateTime)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestDatabase_Upsert(t *testing.T) {
+	repo := setupDatabase(t)
+	nodeRepo := repository.NewPveNodeRepository(repo)
+	ctx := context.Background()
+
+	clusterID := time.Now().UnixNano()
+	node := &model.PveNode{NodeName: "pve-ci", ClusterID: clusterID, Status: "online", ResourceHash: "h1"}
+	require.NoError(t, nodeRepo.Upsert(ctx, node))
+	defer nodeRepo.DeleteByNodeName(ctx, "pve-ci", clusterID)
+	firstID := node.Id
+	require.NotZero(t, firstID)
+
+	// hash 不变：只更新同步时间
+	require.NoError(t, nodeRepo.Upsert(ctx, &model.PveNode{NodeName: "pve-ci", ClusterID: clusterID, Status: "online", ResourceHash: "h1"}))
+
+	// hash 变化：完整更新，记录 ID 不变
+	updated := &model.PveNode{NodeName: "pve-ci", ClusterID: clusterID, Status: "offline", ResourceHash: "h2"}
+	require.NoError(t, nodeRepo.Upsert(ctx, updated))
+	assert.Equal(t, firstID, updated.Id)
+
+	got, err := nodeRepo.GetByNodeName(ctx, "pve-ci", clusterID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "offline", got.Status)
+	assert.Equal(t, "h2", got.ResourceHash)
+}
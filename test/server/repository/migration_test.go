@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"pvesphere/internal/migration"
+	"pvesphere/internal/model"
+	"pvesphere/internal/server"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestSchemaMigration_Up(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migration.db")), &gorm.Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// 空库：执行全部迁移
+	done, err := migration.Up(ctx, db)
+	require.NoError(t, err)
+	assert.Len(t, done, len(migration.Migrations()))
+	assert.True(t, db.Migrator().HasTable(&model.User{}))
+	assert.True(t, db.Migrator().HasTable(&model.PveVM{}))
+
+	current, statuses, err := migration.GetStatus(ctx, db)
+	require.NoError(t, err)
+	assert.Equal(t, migration.Latest(), current)
+	require.Len(t, statuses, len(migration.Migrations()))
+	for _, status := range statuses {
+		assert.True(t, status.Applied, "migration %d not applied", status.Version)
+		assert.False(t, status.AppliedAt.IsZero())
+	}
+
+	// 再次执行不会重复
+	done, err = migration.Up(ctx, db)
+	require.NoError(t, err)
+	assert.Empty(t, done)
+
+	// 删除执行记录后该版本显示为 pending，重新执行时迁移可重复执行
+	latest := migration.Latest()
+	require.NoError(t, db.Exec("DELETE FROM goose_db_version WHERE version_id = ?", latest).Error)
+	current, statuses, err = migration.GetStatus(ctx, db)
+	require.NoError(t, err)
+	assert.Less(t, current, latest)
+	assert.False(t, statuses[len(statuses)-1].Applied)
+
+	done, err = migration.Up(ctx, db)
+	require.NoError(t, err)
+	require.Len(t, done, 1)
+	assert.Equal(t, latest, done[0].Version)
+}
+
+// 数据拷贝依赖 MigrateModels，新增的表必须同时有对应的迁移
+func TestSchemaMigration_CoversModels(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migration.db")), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, server.AutoMigrate(db))
+
+	for _, m := range server.MigrateModels() {
+		assert.True(t, db.Migrator().HasTable(m), "table for %T not created by migrations", m)
+	}
+}
+
+// 已有部署升级：表上缺少新增的列和索引时，由对应版本的迁移补齐
+func TestSchemaMigration_AddColumns(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migration.db")), &gorm.Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = migration.Up(ctx, db)
+	require.NoError(t, err)
+
+	// 模拟升级前的库：pve_cluster 没有 site_id 列及其索引，站点及之后的迁移尚未执行
+	cluster := &model.PveCluster{}
+	require.NoError(t, db.Migrator().DropIndex(cluster, "SiteID"))
+	require.NoError(t, db.Migrator().DropColumn(cluster, "SiteID"))
+	require.NoError(t, db.Exec("DELETE FROM goose_db_version WHERE version_id >= ?", 4).Error)
+	require.False(t, db.Migrator().HasColumn(cluster, "SiteID"))
+
+	done, err := migration.Up(ctx, db)
+	require.NoError(t, err)
+	require.NotEmpty(t, done)
+	assert.Equal(t, int64(4), done[0].Version)
+	assert.True(t, db.Migrator().HasColumn(cluster, "SiteID"))
+	assert.True(t, db.Migrator().HasIndex(cluster, "SiteID"))
+}
+
+// 成本用量的唯一键增加 cost_center 后，旧库上的唯一索引按新定义重建
+func TestSchemaMigration_CostUsageKey(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migration.db")), &gorm.Config{})
+	require.NoError(t, err)
+	ctx := context.Background()
+	_, err = migration.Up(ctx, db)
+	require.NoError(t, err)
+
+	// 模拟升级前的 cost_usage_daily：唯一键不含 cost_center
+	usage := &model.CostUsageDaily{}
+	m := db.Migrator()
+	require.NoError(t, m.DropIndex(usage, "idx_cost_usage_key"))
+	require.NoError(t, m.DropColumn(usage, "CostCenter"))
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX idx_cost_usage_key ON cost_usage_daily (usage_date, cluster_id, appid)").Error)
+	require.NoError(t, db.Exec("DELETE FROM goose_db_version WHERE version_id >= ?", 14).Error)
+
+	_, err = migration.Up(ctx, db)
+	require.NoError(t, err)
+	require.True(t, m.HasColumn(usage, "CostCenter"))
+
+	// 同一天、集群、应用下不同成本中心的用量可以分别记录
+	require.NoError(t, db.Create(&model.CostUsageDaily{UsageDate: "2026-01-01", ClusterID: 1, AppId: "app", CostCenter: "cc-a"}).Error)
+	require.NoError(t, db.Create(&model.CostUsageDaily{UsageDate: "2026-01-01", ClusterID: 1, AppId: "app", CostCenter: "cc-b"}).Error)
+	assert.Error(t, db.Create(&model.CostUsageDaily{UsageDate: "2026-01-01", ClusterID: 1, AppId: "app", CostCenter: "cc-a"}).Error)
+}